@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/node"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// ConvertNodeSubnetsCommand rewrites the node subnets annotations of all the
+// nodes between the legacy and the per-network formats
+var ConvertNodeSubnetsCommand = cli.Command{
+	Name: "convert-node-subnets",
+	Usage: "convert the k8s.ovn.org/node-subnets annotation of all the nodes to the per-network format " +
+		"when upgrading, or back to the legacy format before a rollback",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "kubeconfig",
+			Usage: "absolute path to the kubeconfig file, the in-cluster configuration is used if empty",
+		},
+		&cli.StringFlag{
+			Name:     "cluster-subnets",
+			Usage:    "the cluster subnets the host subnets of the nodes are validated against, as configured in the cluster manager",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "the format to convert the annotations to, either per-network or legacy",
+			Value: string(util.NodeHostSubnetAnnotationFormatPerNetwork),
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "validate and list the nodes to convert without converting them",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		format := util.NodeHostSubnetAnnotationFormat(ctx.String("to"))
+		if format != util.NodeHostSubnetAnnotationFormatPerNetwork && format != util.NodeHostSubnetAnnotationFormatLegacy {
+			return fmt.Errorf("unknown format %q, expected %s or %s", format,
+				util.NodeHostSubnetAnnotationFormatPerNetwork, util.NodeHostSubnetAnnotationFormatLegacy)
+		}
+
+		clusterSubnets, err := config.ParseClusterSubnetEntries(ctx.String("cluster-subnets"))
+		if err != nil {
+			return fmt.Errorf("invalid cluster subnets: %v", err)
+		}
+		config.Default.ClusterSubnets = clusterSubnets
+		for _, clusterSubnet := range clusterSubnets {
+			if utilnet.IsIPv6CIDR(clusterSubnet.CIDR) {
+				config.IPv6Mode = true
+			} else {
+				config.IPv4Mode = true
+			}
+		}
+
+		config.Kubernetes.Kubeconfig = ctx.String("kubeconfig")
+		clientset, err := util.NewKubernetesClientset(&config.Kubernetes)
+		if err != nil {
+			return err
+		}
+		nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %v", err)
+		}
+		nodes := make([]*kapi.Node, 0, len(nodeList.Items))
+		for i := range nodeList.Items {
+			nodes = append(nodes, &nodeList.Items[i])
+		}
+
+		dryRun := ctx.Bool("dry-run")
+		converted, err := node.ConvertNodeSubnetAnnotations(&kube.Kube{KClient: clientset}, nodes, format, dryRun)
+		for _, nodeName := range converted {
+			if dryRun {
+				fmt.Printf("node %s would be converted to the %s format\n", nodeName, format)
+			} else {
+				fmt.Printf("node %s converted to the %s format\n", nodeName, format)
+			}
+		}
+		return err
+	},
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/routeaudit"
+)
+
+// StaticRouteDiffCommand prints the expected-vs-actual diff of the static
+// routes of the routers of a zone
+var StaticRouteDiffCommand = cli.Command{
+	Name: "static-route-diff",
+	Usage: "print, for each router of the zone of an ovnkube-controller, the static routes it created for the " +
+		"nodes, the pods and the external gateways that are missing or stale according to the cluster",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "controller-url",
+			Usage:    "the URL of the metrics server of the ovnkube-controller of the zone, e.g. http://10.0.0.1:9410",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "network",
+			Usage: "only print the routers of the network",
+		},
+		&cli.StringFlag{
+			Name:  "router",
+			Usage: "only print the router",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the diff as JSON",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		query := url.Values{}
+		if network := ctx.String("network"); network != "" {
+			query.Set("network", network)
+		}
+		if router := ctx.String("router"); router != "" {
+			query.Set("router", router)
+		}
+		diffs, err := getStaticRouteDiff(ctx.String("controller-url"), query)
+		if err != nil {
+			return err
+		}
+
+		if ctx.Bool("json") {
+			out, err := json.MarshalIndent(diffs, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		if len(diffs) == 0 {
+			fmt.Println("no audited static route found")
+			return nil
+		}
+		for _, diff := range diffs {
+			fmt.Printf("%s (network %s): %d in sync, %d missing, %d stale\n", diff.Router, diff.Network, diff.InSync,
+				len(diff.Missing), len(diff.Stale))
+			for _, route := range diff.Missing {
+				fmt.Printf("+ %s\n", route)
+			}
+			for _, route := range diff.Stale {
+				fmt.Printf("- %s\n", route)
+			}
+		}
+		return nil
+	},
+}
+
+// getStaticRouteDiff queries the static route endpoint of the ovnkube-controller
+func getStaticRouteDiff(controllerURL string, query url.Values) ([]routeaudit.RouterDiff, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(controllerURL, "/") + routeaudit.Path + "?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ovnkube-controller: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ovnkube-controller answered %s", resp.Status)
+	}
+	var diffs []routeaudit.RouterDiff
+	if err := json.NewDecoder(resp.Body).Decode(&diffs); err != nil {
+		return nil, fmt.Errorf("failed to decode the answer of ovnkube-controller: %v", err)
+	}
+	return diffs, nil
+}
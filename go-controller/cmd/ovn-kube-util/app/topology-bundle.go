@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	nettypes "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	"github.com/urfave/cli/v2"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kexec "k8s.io/utils/exec"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// topologyInventory is the node inventory a topology bundle is built from: the
+// nodes of the cluster and the network attachment definitions of the
+// secondary networks, as listed with `kubectl get -o json`
+type topologyInventory struct {
+	Nodes                        []kapi.Node                            `json:"nodes"`
+	NetworkAttachmentDefinitions []nettypes.NetworkAttachmentDefinition `json:"networkAttachmentDefinitions,omitempty"`
+}
+
+// BuildTopologyBundleCommand computes offline the topology bundle of a cluster
+// from its configuration and node inventory
+var BuildTopologyBundleCommand = cli.Command{
+	Name: "build-topology-bundle",
+	Usage: "pre-compute the node subnets, network IDs, tunnel keys and initial NB database content of a cluster " +
+		"from the ovnkube configuration and a node inventory, for installations that must come up without " +
+		"a live cluster manager",
+	Flags: append(config.GetFlags(nil),
+		&cli.StringFlag{
+			Name: "inventory",
+			Usage: "the JSON node inventory, with the nodes under \"nodes\" and the network attachment " +
+				"definitions of the secondary networks under \"networkAttachmentDefinitions\"",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "the file the bundle is written to, the standard output if empty",
+		},
+	),
+	Action: func(ctx *cli.Context) error {
+		if _, err := config.InitConfig(ctx, kexec.New(), nil); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(ctx.String("inventory"))
+		if err != nil {
+			return fmt.Errorf("failed to read the inventory: %v", err)
+		}
+		var inventory topologyInventory
+		if err := json.Unmarshal(data, &inventory); err != nil {
+			return fmt.Errorf("failed to parse the inventory: %v", err)
+		}
+		nodes := make([]*kapi.Node, 0, len(inventory.Nodes))
+		for i := range inventory.Nodes {
+			nodes = append(nodes, &inventory.Nodes[i])
+		}
+		// several network attachment definitions may belong to the same network
+		var networks []util.NetInfo
+		networkNames := map[string]bool{}
+		for i := range inventory.NetworkAttachmentDefinitions {
+			netInfo, err := util.ParseNADInfo(&inventory.NetworkAttachmentDefinitions[i])
+			if err != nil {
+				return err
+			}
+			if networkNames[netInfo.GetNetworkName()] {
+				continue
+			}
+			networkNames[netInfo.GetNetworkName()] = true
+			networks = append(networks, netInfo)
+		}
+
+		bundle, err := clustermanager.BuildTopologyBundle(nodes, networks)
+		if err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			return err
+		}
+		if ctx.String("output") == "" {
+			fmt.Println(string(out))
+			return nil
+		}
+		return os.WriteFile(ctx.String("output"), out, 0o644)
+	},
+}
+
+// ImportTopologyBundleCommand imports a topology bundle on the first boot of
+// a cluster
+var ImportTopologyBundleCommand = cli.Command{
+	Name: "import-topology-bundle",
+	Usage: "import a topology bundle on the first boot of a cluster: annotate the nodes with the allocations " +
+		"of the bundle they do not have yet, and create the initial topology in the empty NB database",
+	Flags: append(config.GetFlags(nil),
+		&cli.StringFlag{
+			Name:     "bundle",
+			Usage:    "the topology bundle, as written by build-topology-bundle",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "skip-nb",
+			Usage: "only annotate the nodes, the NB database being populated by ovnkube-controller",
+		},
+	),
+	Action: func(ctx *cli.Context) error {
+		exec := kexec.New()
+		if _, err := config.InitConfig(ctx, exec, nil); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(ctx.String("bundle"))
+		if err != nil {
+			return fmt.Errorf("failed to read the bundle: %v", err)
+		}
+		var bundle clustermanager.TopologyBundle
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return fmt.Errorf("failed to parse the bundle: %v", err)
+		}
+
+		clientset, err := util.NewKubernetesClientset(&config.Kubernetes)
+		if err != nil {
+			return err
+		}
+		k := &kube.Kube{KClient: clientset}
+		for nodeName, annotations := range bundle.NodeAnnotations {
+			node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get node %s: %v", nodeName, err)
+			}
+			// the allocations done since the bundle was built win
+			missing := map[string]interface{}{}
+			for key, value := range annotations {
+				if _, ok := node.Annotations[key]; !ok {
+					missing[key] = value
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			if err := k.SetAnnotationsOnNode(nodeName, missing); err != nil {
+				return fmt.Errorf("failed to annotate node %s: %v", nodeName, err)
+			}
+			fmt.Printf("node %s annotated\n", nodeName)
+		}
+
+		if ctx.Bool("skip-nb") || len(bundle.NBTransaction) == 0 {
+			return nil
+		}
+		if err := util.SetExec(exec); err != nil {
+			return fmt.Errorf("failed to initialize exec helper: %v", err)
+		}
+		// the transaction fails instead of duplicating the topology if the
+		// cluster router already exists. The wait operation is not built as an
+		// ovsdb.Operation, whose empty rows would be omitted.
+		ops := []interface{}{"OVN_Northbound", map[string]interface{}{
+			"op":      ovsdb.OperationWait,
+			"table":   "Logical_Router",
+			"timeout": 0,
+			"where":   []ovsdb.Condition{ovsdb.NewCondition("name", ovsdb.ConditionEqual, types.OVNClusterRouter)},
+			"columns": []string{"name"},
+			"until":   "==",
+			"rows":    []ovsdb.Row{},
+		}}
+		for _, op := range bundle.NBTransaction {
+			ops = append(ops, op)
+		}
+		transaction, err := json.Marshal(ops)
+		if err != nil {
+			return err
+		}
+		stdout, stderr, err := util.RunOVSDBClientOVNNB("transact", string(transaction))
+		if err != nil {
+			return fmt.Errorf("failed to import the NB topology, stderr: %q: %v", stderr, err)
+		}
+		fmt.Printf("NB topology imported: %s\n", stdout)
+		return nil
+	},
+}
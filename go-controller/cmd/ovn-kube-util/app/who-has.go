@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// WhoHasCommand looks up the networks, nodes and pods owning an IP or subnet
+var WhoHasCommand = cli.Command{
+	Name: "who-has",
+	Usage: "list the networks, nodes and pods owning an IP or the addresses and subnets overlapping a subnet, " +
+		"according to the node and pod annotations and, if its metrics address is given, the allocators of the " +
+		"cluster manager, and report the ones claimed by several nodes or pods",
+	ArgsUsage: "<IP or subnet>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "kubeconfig",
+			Usage: "absolute path to the kubeconfig file, the in-cluster configuration is used if empty",
+		},
+		&cli.StringFlag{
+			Name:  "cluster-manager-url",
+			Usage: "the URL of the metrics server of the ovnkube-cluster-manager leader, e.g. http://10.0.0.1:9411",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the owners as JSON",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() != 1 {
+			return fmt.Errorf("expected a single IP or subnet, got %d arguments", ctx.NArg())
+		}
+		network, err := clustermanager.ParseWhoHasQuery(ctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		config.Kubernetes.Kubeconfig = ctx.String("kubeconfig")
+		ovnClientset, err := util.NewOVNClientset(&config.Kubernetes)
+		if err != nil {
+			return err
+		}
+		clientset := ovnClientset.KubeClient
+		nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %v", err)
+		}
+		nodes := make([]*kapi.Node, 0, len(nodeList.Items))
+		for i := range nodeList.Items {
+			nodes = append(nodes, &nodeList.Items[i])
+		}
+		// the NodeNetworkState CRD is only installed with the "crd" node
+		// network state backend
+		stateList, err := ovnClientset.NodeNetworkStateClient.K8sV1().NodeNetworkStates().List(context.TODO(), metav1.ListOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to list node network states: %v", err)
+		}
+		var states []*nodenetworkstatev1.NodeNetworkState
+		if stateList != nil {
+			for i := range stateList.Items {
+				states = append(states, &stateList.Items[i])
+			}
+		}
+		podList, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list pods: %v", err)
+		}
+		pods := make([]*kapi.Pod, 0, len(podList.Items))
+		for i := range podList.Items {
+			pods = append(pods, &podList.Items[i])
+		}
+		owners := clustermanager.WhoHasAnnotated(nodes, states, pods, network)
+
+		if clusterManagerURL := ctx.String("cluster-manager-url"); clusterManagerURL != "" {
+			allocated, err := getAllocatorOwners(clusterManagerURL, network.String())
+			if err != nil {
+				return err
+			}
+			owners = append(allocated, owners...)
+		}
+
+		if ctx.Bool("json") {
+			out, err := json.MarshalIndent(owners, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		if len(owners) == 0 {
+			fmt.Printf("no owner of %s found\n", network)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAME\tNETWORK\tCIDR\tSOURCE")
+		for _, owner := range owners {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", owner.Kind, owner.Name, owner.Network, owner.CIDR, owner.Source)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		for _, conflict := range clustermanager.WhoHasConflicts(owners) {
+			fmt.Printf("conflict: %s\n", conflict)
+		}
+		return nil
+	},
+}
+
+// getAllocatorOwners queries the who-has endpoint of the cluster manager for
+// the owners of the subnet known to its allocators
+func getAllocatorOwners(clusterManagerURL, subnet string) ([]clustermanager.WhoHasOwner, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(clusterManagerURL, "/") + clustermanager.WhoHasPath +
+		"?subnet=" + url.QueryEscape(subnet))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the cluster manager: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the cluster manager answered %s", resp.Status)
+	}
+	var owners []clustermanager.WhoHasOwner
+	if err := json.NewDecoder(resp.Body).Decode(&owners); err != nil {
+		return nil, fmt.Errorf("failed to decode the answer of the cluster manager: %v", err)
+	}
+	return owners, nil
+}
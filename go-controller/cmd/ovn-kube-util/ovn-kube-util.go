@@ -29,6 +29,11 @@ func main() {
 		&app.BridgesToNicCommand,
 		&app.ReadinessProbeCommand,
 		&app.OvsExporterCommand,
+		&app.ConvertNodeSubnetsCommand,
+		&app.BuildTopologyBundleCommand,
+		&app.ImportTopologyBundleCommand,
+		&app.WhoHasCommand,
+		&app.StaticRouteDiffCommand,
 	}
 
 	c.Before = func(ctx *cli.Context) error {
@@ -21,14 +21,17 @@ import (
 
 	libovsdbclient "github.com/ovn-org/libovsdb/client"
 	"github.com/urfave/cli/v2"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/fullsync"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	controllerManager "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/network-controller-manager"
 	ovnnode "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/standalonehost"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
@@ -181,6 +184,7 @@ type ovnkubeRunMode struct {
 	ovnkubeController bool // ovnkube controller (--init-ovnkube-controller or --init-master) is enabled
 	clusterManager    bool // cluster manager (--init-cluster-manager or --init-master) is enabled
 	node              bool // node (--init-node) is enabled
+	singleNode        bool // all of the above for a single node cluster (--init-single-node) are enabled
 	cleanupNode       bool // cleanup (--cleanup-node) is enabled
 
 	// Along with the run mode, an identity is provided that uniquely identifies
@@ -196,6 +200,7 @@ type ovnkubeRunMode struct {
 //   - master (ovnkube controller + cluster manager) + node
 //   - ovnkube controller + cluster manager
 //   - ovnkube controller + node
+//   - single node (ovnkube controller + cluster manager + node)
 func determineOvnkubeRunMode(ctx *cli.Context) (*ovnkubeRunMode, error) {
 	mode := &ovnkubeRunMode{}
 
@@ -203,6 +208,7 @@ func determineOvnkubeRunMode(ctx *cli.Context) (*ovnkubeRunMode, error) {
 	cm := ctx.String("init-cluster-manager")
 	ovnkController := ctx.String("init-ovnkube-controller")
 	node := ctx.String("init-node")
+	single := ctx.String("init-single-node")
 	cleanup := ctx.String("cleanup-node")
 
 	if master != "" {
@@ -224,6 +230,14 @@ func determineOvnkubeRunMode(ctx *cli.Context) (*ovnkubeRunMode, error) {
 		mode.node = true
 	}
 
+	if single != "" {
+		// a single node cluster runs everything in the same process
+		mode.ovnkubeController = true
+		mode.clusterManager = true
+		mode.node = true
+		mode.singleNode = true
+	}
+
 	if cleanup != "" {
 		mode.cleanupNode = true
 	}
@@ -240,7 +254,15 @@ func determineOvnkubeRunMode(ctx *cli.Context) (*ovnkubeRunMode, error) {
 		return nil, fmt.Errorf("cannot run in both cluster manager and node mode")
 	}
 
-	identities := sets.NewString(master, cm, ovnkController, node, cleanup)
+	if mode.node && (mode.clusterManager || mode.ovnkubeController) && config.OvnKubeNode.Mode == types.NodeModeStandaloneHost {
+		return nil, fmt.Errorf("cannot run in %s node mode along with any other mode", types.NodeModeStandaloneHost)
+	}
+
+	if mode.singleNode && config.OvnKubeNode.Mode != types.NodeModeFull {
+		return nil, fmt.Errorf("cannot run in single node mode along with %s node mode", config.OvnKubeNode.Mode)
+	}
+
+	identities := sets.NewString(master, cm, ovnkController, node, single, cleanup)
 	identities.Delete("")
 	if identities.Len() != 1 {
 		return nil, fmt.Errorf("provided no identity or different identities for different modes")
@@ -289,23 +311,55 @@ func startOvnKube(ctx *cli.Context, cancel context.CancelFunc) error {
 		ovnKubeStartWg.Wait()
 	}()
 
+	metricsAuth, err := newMetricsAuth(ovnClientset)
+	if err != nil {
+		return err
+	}
+
 	// Start metric server for master and node. Expose the metrics HTTP endpoint if configured.
 	// Non LE master instances also are required to expose the metrics server.
 	if config.Metrics.BindAddress != "" {
 		metrics.StartMetricsServer(config.Metrics.BindAddress, config.Metrics.EnablePprof,
-			config.Metrics.NodeServerCert, config.Metrics.NodeServerPrivKey, ctx.Done(), ovnKubeStartWg)
+			config.Metrics.NodeServerCert, config.Metrics.NodeServerPrivKey, metricsAuth, ctx.Done(), ovnKubeStartWg)
+		metrics.RegisterIPv6OnlyCompatibilityHandler()
+	}
+
+	// the node annotation webhook is served by every cluster manager instance,
+	// whether leader or not
+	if runMode.clusterManager && config.ClusterManager.WebhookBindAddress != "" {
+		if err := clustermanager.StartNodeAnnotationWebhook(ovnClientset, ctx.Done(), ovnKubeStartWg); err != nil {
+			return err
+		}
+	}
+
+	// the shards of the secondary networks are led by any cluster manager
+	// instance, whether leader or not
+	if runMode.clusterManager && config.OVNKubernetesFeature.EnableMultiNetwork && config.ClusterManager.NetworkShards > 0 {
+		shardWatchFactory, err := factory.NewClusterManagerWatchFactory(ovnClientset.GetClusterManagerClientset())
+		if err != nil {
+			return err
+		}
+		shardManager := clustermanager.NewNetworkShardManager(ovnClientset.GetClusterManagerClientset(),
+			shardWatchFactory, runMode.identity, eventRecorder)
+		if err := shardManager.Run(ctx.Context, ovnKubeStartWg); err != nil {
+			return err
+		}
 	}
 
 	// no need for leader election in node mode
 	// only node mode
 	if !runMode.clusterManager && !runMode.ovnkubeController {
-		return runOvnKube(ctx.Context, runMode, ovnClientset, eventRecorder)
+		return runOvnKube(ctx.Context, runMode, ovnClientset, eventRecorder, metricsAuth)
 	}
 
-	// ovnkube-controller with node
+	// ovnkube-controller with node, there is no other instance to elect a
+	// leader among, like in single node mode
 	if runMode.node && runMode.ovnkubeController {
+		if runMode.clusterManager {
+			metrics.RegisterClusterManagerBase()
+		}
 		metrics.RegisterOVNKubeControllerBase()
-		return runOvnKube(ctx.Context, runMode, ovnClientset, eventRecorder)
+		return runOvnKube(ctx.Context, runMode, ovnClientset, eventRecorder, metricsAuth)
 	}
 
 	// Register prometheus metrics that do not depend on becoming ovnkube-controller
@@ -371,7 +425,7 @@ func startOvnKube(ctx *cli.Context, cancel context.CancelFunc) error {
 				defer ovnKubeStartWg.Done()
 				ovnKubeStopLock.Unlock()
 				klog.Infof("Won leader election; in active mode")
-				if err := runOvnKube(ctx, runMode, ovnClientset, eventRecorder); err != nil {
+				if err := runOvnKube(ctx, runMode, ovnClientset, eventRecorder, metricsAuth); err != nil {
 					klog.Error(err)
 					cancel()
 				}
@@ -409,7 +463,8 @@ func startOvnKube(ctx *cli.Context, cancel context.CancelFunc) error {
 	return nil
 }
 
-func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util.OVNClientset, eventRecorder record.EventRecorder) error {
+func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util.OVNClientset, eventRecorder record.EventRecorder,
+	metricsAuth *metrics.EndpointAuth) error {
 	startTime := time.Now()
 
 	if runMode.cleanupNode {
@@ -426,7 +481,18 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 	var masterWatchFactory *factory.WatchFactory
 	var err error
 
-	if runMode.ovnkubeController {
+	// ovnkube-controller and ovnkube-node apply the cluster subnets added to
+	// the default network at runtime on startup, ovnkube-cluster-manager only
+	// handing out host subnets from them once applied for every node
+	if (runMode.ovnkubeController || runMode.node) && !runMode.clusterManager {
+		if err := util.LoadAdditionalClusterSubnets(ovnClientset.KubeClient); err != nil {
+			return err
+		}
+	}
+
+	// with the cluster manager, the ovnkube controller shares the master watch
+	// factory created below
+	if runMode.ovnkubeController && !runMode.clusterManager {
 		// create factory and start the controllers asked for
 		masterWatchFactory, err = factory.NewOVNKubeControllerWatchFactory(ovnClientset.GetOVNKubeControllerClientset())
 		if err != nil {
@@ -443,6 +509,7 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 			if err != nil {
 				return err
 			}
+			defer masterWatchFactory.Shutdown()
 			clusterManagerWatchFactory = masterWatchFactory
 		} else {
 			clusterManagerWatchFactory, err = factory.NewClusterManagerWatchFactory(ovnClientset.GetClusterManagerClientset())
@@ -468,6 +535,17 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 		metrics.MetricClusterManagerReadyDuration.Set(time.Since(startTime).Seconds())
 	}
 
+	// the ovnkube-controller running along with the ovnkube-node, as with the
+	// single node zones, runs its full sync under the token of the node
+	releaseFullSyncToken := func() {}
+	if runMode.ovnkubeController && runMode.node {
+		var releaseOnce sync.Once
+		release := newFullSyncTokenPool(ovnClientset, "ovnkube-node", runMode.identity,
+			config.Kubernetes.FullSyncNodeTokens).Acquire(ctx)
+		releaseFullSyncToken = func() { releaseOnce.Do(release) }
+		defer releaseFullSyncToken()
+	}
+
 	var ovnkubeControllerStartErr error
 	ovnkubeControllerWG := sync.WaitGroup{}
 	if runMode.ovnkubeController {
@@ -477,7 +555,12 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 			return fmt.Errorf("error when trying to initialize libovsdb NB client: %v", err)
 		}
 
-		if libovsdbOvnSBClient, err = libovsdb.NewSBClient(stopChan); err != nil {
+		if runMode.singleNode {
+			libovsdbOvnSBClient, err = libovsdb.NewSingleNodeSBClient(stopChan)
+		} else {
+			libovsdbOvnSBClient, err = libovsdb.NewSBClient(stopChan)
+		}
+		if err != nil {
 			return fmt.Errorf("error when trying to initialize libovsdb SB client: %v", err)
 		}
 
@@ -493,7 +576,14 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 		ovnkubeControllerWG.Add(1)
 		go func() {
 			defer ovnkubeControllerWG.Done()
+			// stagger the full syncs of the ovnkube-controllers after an outage
+			release := func() {}
+			if !runMode.node {
+				release = newFullSyncTokenPool(ovnClientset, "ovnkube-controller", runMode.identity,
+					config.Kubernetes.FullSyncControllerTokens).Acquire(ctx)
+			}
 			err = cm.Start(ctx)
+			release()
 			if err != nil {
 				ovnkubeControllerStartErr = fmt.Errorf("failed to start ovnkube controller: %w", err)
 				return
@@ -508,11 +598,19 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 		}()
 	}
 
+	if runMode.node && config.OvnKubeNode.Mode == types.NodeModeStandaloneHost {
+		// a standalone host is not a Kubernetes node, it only attaches itself
+		// to its network as described by the Host of the same name
+		agent := standalonehost.NewAgent(runMode.identity, ovnClientset.GetNodeClientset().HostClient)
+		return agent.Run(ctx)
+	}
+
 	if runMode.node {
 		var nodeWatchFactory factory.NodeWatchFactory
 
 		if runMode.ovnkubeController {
-			// masterWatchFactory would be initialized as NewOVNKubeControllerWatchFactory already, let's use that
+			// masterWatchFactory would be initialized as NewOVNKubeControllerWatchFactory, or NewMasterWatchFactory with
+			// the cluster manager, already, let's use that
 			nodeWatchFactory = masterWatchFactory
 		} else {
 			var err error
@@ -532,7 +630,14 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 		if err != nil {
 			return fmt.Errorf("failed to create ovnkube node ovnkube controller: %w", err)
 		}
+		// stagger the full syncs of the ovnkube-nodes after an outage
+		release := func() {}
+		if !runMode.ovnkubeController {
+			release = newFullSyncTokenPool(ovnClientset, "ovnkube-node", runMode.identity,
+				config.Kubernetes.FullSyncNodeTokens).Acquire(ctx)
+		}
 		err = ncm.Start(ctx)
+		release()
 		if err != nil {
 			return fmt.Errorf("failed to start node network manager: %w", err)
 		}
@@ -544,6 +649,7 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 
 	if runMode.ovnkubeController {
 		ovnkubeControllerWG.Wait()
+		releaseFullSyncToken()
 		if ovnkubeControllerStartErr != nil {
 			return ovnkubeControllerStartErr
 		}
@@ -557,7 +663,7 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 		}
 		metrics.RegisterOvnMetrics(ovnClientset.KubeClient, runMode.identity, stopChan)
 		metrics.StartOVNMetricsServer(config.Metrics.OVNMetricsBindAddress,
-			config.Metrics.NodeServerCert, config.Metrics.NodeServerPrivKey, stopChan, wg)
+			config.Metrics.NodeServerCert, config.Metrics.NodeServerPrivKey, metricsAuth, stopChan, wg)
 	}
 
 	// run until cancelled
@@ -565,6 +671,33 @@ func runOvnKube(ctx context.Context, runMode *ovnkubeRunMode, ovnClientset *util
 	return nil
 }
 
+// newMetricsAuth returns the auth protecting the endpoints of the metrics
+// servers, nil if disabled
+func newMetricsAuth(ovnClientset *util.OVNClientset) (*metrics.EndpointAuth, error) {
+	if !config.Metrics.EnableAuth {
+		return nil, nil
+	}
+	var auditLog io.Writer
+	if config.Logging.MetricsAuditLogFile != "" {
+		auditLog = &lumberjack.Logger{
+			Filename:   config.Logging.MetricsAuditLogFile,
+			MaxSize:    config.Logging.LogFileMaxSize, // megabytes
+			MaxBackups: config.Logging.LogFileMaxBackups,
+			MaxAge:     config.Logging.LogFileMaxAge, // days
+			Compress:   true,
+		}
+	}
+	return metrics.NewEndpointAuth(ovnClientset.KubeClient, config.Metrics.ClientCA, auditLog)
+}
+
+// newFullSyncTokenPool returns the pool of the tokens the components of the
+// given kind hold while running their full sync
+func newFullSyncTokenPool(ovnClientset *util.OVNClientset, name, identity string, tokens int) *fullsync.TokenPool {
+	return fullsync.NewTokenPool(ovnClientset.KubeClient.CoordinationV1(), config.Kubernetes.OVNConfigNamespace, name,
+		identity, tokens, time.Duration(config.Kubernetes.FullSyncTokenLeaseDuration)*time.Second,
+		time.Duration(config.Kubernetes.FullSyncTokenMaxWait)*time.Second)
+}
+
 type leaderMetrics struct {
 	runMode *ovnkubeRunMode
 }
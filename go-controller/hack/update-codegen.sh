@@ -81,6 +81,14 @@ echo "Copying egressIP CRD"
 cp _output/crds/k8s.ovn.org_egressips.yaml ../dist/templates/k8s.ovn.org_egressips.yaml.j2
 echo "Copying egressQoS CRD"
 cp _output/crds/k8s.ovn.org_egressqoses.yaml ../dist/templates/k8s.ovn.org_egressqoses.yaml.j2
+echo "Copying egressIPPool CRD"
+cp _output/crds/k8s.ovn.org_egressippools.yaml ../dist/templates/k8s.ovn.org_egressippools.yaml.j2
+echo "Copying nodeNetworkState CRD"
+cp _output/crds/k8s.ovn.org_nodenetworkstates.yaml ../dist/templates/k8s.ovn.org_nodenetworkstates.yaml.j2
+echo "Copying ipFamilyConversion CRD"
+cp _output/crds/k8s.ovn.org_ipfamilyconversions.yaml ../dist/templates/k8s.ovn.org_ipfamilyconversions.yaml.j2
+echo "Copying host CRD"
+cp _output/crds/k8s.ovn.org_hosts.yaml ../dist/templates/k8s.ovn.org_hosts.yaml.j2
 # NOTE: When you update vendoring versions for the ANP & BANP APIs, we must update the version of the CRD we pull from in the below URL
 echo "Copying Admin Network Policy CRD"
 curl -sSL https://raw.githubusercontent.com/kubernetes-sigs/network-policy-api/v0.1.0/config/crd/policy.networking.k8s.io_adminnetworkpolicies.yaml -o ../dist/templates/policy.networking.k8s.io_adminnetworkpolicies.yaml
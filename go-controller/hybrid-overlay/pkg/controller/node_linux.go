@@ -562,7 +562,7 @@ func (n *NodeController) EnsureHybridOverlayBridge(node *kapi.Node) error {
 		rampExt string = "ext"
 	)
 	// Create the connection between OVN's br-int and our hybrid overlay bridge br-ext
-	_, stderr, err = util.RunOVSVsctl("--may-exist", "add-port", "br-int", rampInt,
+	_, stderr, err = util.RunOVSVsctl("--may-exist", "add-port", config.OvnKubeNode.IntegrationBridge, rampInt,
 		"--", "--may-exist", "add-port", extBridgeName, rampExt,
 		"--", "set", "Interface", rampInt, "type=patch", "options:peer="+rampExt, "external-ids:iface-id="+portName,
 		"--", "set", "Interface", rampExt, "type=patch", "options:peer="+rampInt)
@@ -17,6 +17,8 @@ type Allocator interface {
 	ReserveID(name string, id int) error
 	ReleaseID(name string)
 	ForName(name string) NamedAllocator
+	// Usage returns the number of used and free ids
+	Usage() (int, int)
 }
 
 // NamedAllocator of IDs for a specific resource
@@ -30,6 +32,7 @@ type NamedAllocator interface {
 type idAllocator struct {
 	nameIdMap sync.Map
 	idBitmap  *bitmapallocator.AllocationBitmap
+	maxIds    int
 }
 
 // NewIDAllocator returns an IDAllocator
@@ -39,6 +42,7 @@ func NewIDAllocator(name string, maxIds int) (Allocator, error) {
 	return &idAllocator{
 		nameIdMap: sync.Map{},
 		idBitmap:  idBitmap,
+		maxIds:    maxIds,
 	}, nil
 }
 
@@ -93,6 +97,12 @@ func (idAllocator *idAllocator) ReleaseID(name string) {
 	}
 }
 
+// Usage returns the number of used and free ids
+func (idAllocator *idAllocator) Usage() (int, int) {
+	free := idAllocator.idBitmap.Free()
+	return idAllocator.maxIds - free, free
+}
+
 func (idAllocator *idAllocator) ForName(name string) NamedAllocator {
 	return &namedAllocator{
 		name:      name,
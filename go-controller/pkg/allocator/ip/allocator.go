@@ -36,6 +36,8 @@ type Interface interface {
 	CIDR() net.IPNet
 	Has(ip net.IP) bool
 	Reserved(ip net.IP) bool
+	Used() int
+	Free() int
 }
 
 var (
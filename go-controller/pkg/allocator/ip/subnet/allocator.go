@@ -12,6 +12,7 @@ import (
 	ipallocator "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/ip"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
 )
 
 // Allocator manages the allocation of IP within specific set of subnets
@@ -27,6 +28,12 @@ type Allocator interface {
 	ConditionalIPRelease(name string, ips []*net.IPNet, predicate func() (bool, error)) (bool, error)
 	ForSubnet(name string) NamedAllocator
 	GetSubnetName(subnets []*net.IPNet) (string, bool)
+	// GetUsage returns the number of allocated and free IPs of a given subnet
+	// set
+	GetUsage(name string) (uint64, uint64, error)
+	// GetExhaustedSubnets returns the subnets of a given subnet set without
+	// free IPs
+	GetExhaustedSubnets(name string) ([]*net.IPNet, error)
 }
 
 // NamedAllocator manages the allocation of IPs within a specific subnet
@@ -56,6 +63,10 @@ type allocator struct {
 	// A RW mutex which holds subnet information
 	sync.RWMutex
 	ipamFunc ipamFactoryFunc
+	// byIPFamily allocates a single IP of each IP family instead of an IP of
+	// each subnet, the subnets of an IP family after the first one only
+	// being used once the previous ones are full
+	byIPFamily bool
 }
 
 // newIPAMAllocator provides an ipam interface which can be used for IPAM
@@ -77,15 +88,36 @@ func NewAllocator() *allocator {
 	}
 }
 
+// NewIPFamilyAllocator initializes a new subnet IP allocator allocating a
+// single IP of each IP family, like for the node subnets that may have
+// additional subnets of an IP family
+func NewIPFamilyAllocator() *allocator {
+	allocator := NewAllocator()
+	allocator.byIPFamily = true
+	return allocator
+}
+
 // AddOrUpdateSubnet set to the allocator for IPAM management, or update it.
+// The IPs allocated in the subnets kept on update stay allocated, like when
+// additional subnets are added to the set.
 func (allocator *allocator) AddOrUpdateSubnet(name string, subnets []*net.IPNet, excludeSubnets ...*net.IPNet) error {
 	allocator.Lock()
 	defer allocator.Unlock()
-	if subnetInfo, ok := allocator.cache[name]; ok && !reflect.DeepEqual(subnetInfo.subnets, subnets) {
-		klog.Warningf("Replacing subnets %v with %v for %s", util.StringSlice(subnetInfo.subnets), util.StringSlice(subnets), name)
+	existingIPAMs := map[string]ipallocator.Interface{}
+	if subnetInfo, ok := allocator.cache[name]; ok {
+		if !reflect.DeepEqual(subnetInfo.subnets, subnets) {
+			klog.Warningf("Replacing subnets %v with %v for %s", util.StringSlice(subnetInfo.subnets), util.StringSlice(subnets), name)
+		}
+		for i, subnet := range subnetInfo.subnets {
+			existingIPAMs[subnet.String()] = subnetInfo.ipams[i]
+		}
 	}
 	var ipams []ipallocator.Interface
 	for _, subnet := range subnets {
+		if ipam, ok := existingIPAMs[subnet.String()]; ok {
+			ipams = append(ipams, ipam)
+			continue
+		}
 		ipam, err := allocator.ipamFunc(subnet)
 		if err != nil {
 			return fmt.Errorf("failed to initialize IPAM of subnet %s for %s: %w", subnet, name, err)
@@ -213,7 +245,8 @@ func (allocator *allocator) AllocateIPs(name string, ips []*net.IPNet) error {
 func reserveSubnets(subnet *net.IPNet, ipam ipallocator.Interface) error {
 	// FIXME: allocate IP ranges when https://github.com/ovn-org/ovn-kubernetes/issues/3369 is fixed
 	for ip := subnet.IP; subnet.Contains(ip); ip = iputils.NextIP(ip) {
-		if ipam.Reserved(ip) {
+		// the IPs of a kept IPAM may already be reserved
+		if ipam.Reserved(ip) || ipam.Has(ip) {
 			continue
 		}
 		err := ipam.Allocate(ip)
@@ -224,11 +257,14 @@ func reserveSubnets(subnet *net.IPNet, ipam ipallocator.Interface) error {
 	return nil
 }
 
-// AllocateNextIPs allocates IP addresses from the given subnet set
+// AllocateNextIPs allocates IP addresses from the given subnet set, one from
+// each subnet, or, when allocating by IP family, one of each IP family from
+// the first subnet of that IP family with a free IP
 func (allocator *allocator) AllocateNextIPs(name string) ([]*net.IPNet, error) {
 	allocator.RLock()
 	defer allocator.RUnlock()
 	var ipnets []*net.IPNet
+	var ipnetIPAMs []ipallocator.Interface
 	var ip net.IP
 	var err error
 	subnetInfo, ok := allocator.cache[name]
@@ -248,10 +284,10 @@ func (allocator *allocator) AllocateNextIPs(name string) ([]*net.IPNet, error) {
 
 	defer func() {
 		if err != nil {
-			// iterate over range of already allocated indices and release
-			// ips allocated before the error occurred.
+			// iterate over range of already allocated IPs and release
+			// the ones allocated before the error occurred.
 			for relIdx, relIPNet := range ipnets {
-				subnetInfo.ipams[relIdx].Release(relIPNet.IP)
+				ipnetIPAMs[relIdx].Release(relIPNet.IP)
 				if relIPNet.IP != nil {
 					klog.Warningf("Reserved IP %s was released for %s", relIPNet.IP, name)
 				}
@@ -259,20 +295,52 @@ func (allocator *allocator) AllocateNextIPs(name string) ([]*net.IPNet, error) {
 		}
 	}()
 
-	for idx, ipam := range subnetInfo.ipams {
-		ip, err = ipam.AllocateNext()
-		if err != nil {
-			return nil, err
-		}
-		ipnet := &net.IPNet{
-			IP:   ip,
-			Mask: subnetInfo.subnets[idx].Mask,
+	for _, group := range allocator.allocationGroups(subnetInfo.subnets) {
+		for i, idx := range group {
+			ip, err = subnetInfo.ipams[idx].AllocateNext()
+			if errors.Is(err, ipallocator.ErrFull) && i < len(group)-1 {
+				// try the next subnet of the group
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			ipnets = append(ipnets, &net.IPNet{
+				IP:   ip,
+				Mask: subnetInfo.subnets[idx].Mask,
+			})
+			ipnetIPAMs = append(ipnetIPAMs, subnetInfo.ipams[idx])
+			break
 		}
-		ipnets = append(ipnets, ipnet)
 	}
 	return ipnets, nil
 }
 
+// allocationGroups returns the indexes of the subnets an IP is allocated from
+// by AllocateNextIPs, grouped by the IPs: each subnet by default, or the
+// subnets of each IP family, in order, when allocating by IP family
+func (allocator *allocator) allocationGroups(subnets []*net.IPNet) [][]int {
+	var groups [][]int
+	if !allocator.byIPFamily {
+		for idx := range subnets {
+			groups = append(groups, []int{idx})
+		}
+		return groups
+	}
+	groupOfFamily := map[bool]int{}
+	for idx, subnet := range subnets {
+		isIPv6 := utilnet.IsIPv6CIDR(subnet)
+		group, ok := groupOfFamily[isIPv6]
+		if !ok {
+			group = len(groups)
+			groupOfFamily[isIPv6] = group
+			groups = append(groups, nil)
+		}
+		groups[group] = append(groups[group], idx)
+	}
+	return groups
+}
+
 // ReleaseIPs marks the IPs in ipnets slice as available for allocation by
 // releasing them from the IPAM pool of allocated IPs of the given subnet set.
 // If there aren't IPs to release the method does not return an error.
@@ -358,6 +426,41 @@ func (allocator *allocator) GetSubnetName(subnets []*net.IPNet) (string, bool) {
 	return "", false
 }
 
+// GetUsage returns the number of allocated and free IPs across all the
+// subnets of the given subnet set
+func (allocator *allocator) GetUsage(name string) (uint64, uint64, error) {
+	allocator.RLock()
+	defer allocator.RUnlock()
+	subnetInfo, ok := allocator.cache[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("failed to get usage for %s: %w", name, ErrSubnetNotFound)
+	}
+	var used, free uint64
+	for _, ipam := range subnetInfo.ipams {
+		used += uint64(ipam.Used())
+		free += uint64(ipam.Free())
+	}
+	return used, free, nil
+}
+
+// GetExhaustedSubnets returns the subnets of the given subnet set without
+// free IPs
+func (allocator *allocator) GetExhaustedSubnets(name string) ([]*net.IPNet, error) {
+	allocator.RLock()
+	defer allocator.RUnlock()
+	subnetInfo, ok := allocator.cache[name]
+	if !ok {
+		return nil, fmt.Errorf("failed to get exhausted subnets for %s: %w", name, ErrSubnetNotFound)
+	}
+	var exhausted []*net.IPNet
+	for i, ipam := range subnetInfo.ipams {
+		if ipam.Free() == 0 {
+			exhausted = append(exhausted, subnetInfo.subnets[i])
+		}
+	}
+	return exhausted, nil
+}
+
 type IPAllocator struct {
 	allocator *allocator
 	name      string
@@ -209,6 +209,94 @@ var _ = ginkgo.Describe("Subnet IP allocator operations", func() {
 
 	})
 
+	ginkgo.Context("when allocating by IP family", func() {
+		ginkgo.BeforeEach(func() {
+			allocator = NewIPFamilyAllocator()
+		})
+
+		ginkgo.It("allocates a single IP of each IP family, from the next subnet once full", func() {
+			subnetName := "subnet1"
+			subnets := []string{
+				"10.1.1.0/30",
+				"2000::/64",
+				"10.1.2.0/30",
+			}
+
+			expectedIPAllocations := [][]string{
+				{"10.1.1.1", "2000::1"},
+				{"10.1.1.2", "2000::2"},
+				{"10.1.2.1", "2000::3"},
+				{"10.1.2.2", "2000::4"},
+			}
+
+			err := allocator.AddOrUpdateSubnet(subnetName, ovntest.MustParseIPNets(subnets...))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			for _, expectedIPs := range expectedIPAllocations {
+				ips, err := allocator.AllocateNextIPs(subnetName)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(ips).To(gomega.HaveLen(len(expectedIPs)))
+				for i, ip := range ips {
+					gomega.Expect(ip.IP.String()).To(gomega.Equal(expectedIPs[i]))
+				}
+			}
+
+			exhausted, err := allocator.GetExhaustedSubnets(subnetName)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(exhausted).To(gomega.Equal(ovntest.MustParseIPNets("10.1.1.0/30", "10.1.2.0/30")))
+
+			// the IPv6 IP is released when the IPv4 subnets are full
+			_, err = allocator.AllocateNextIPs(subnetName)
+			gomega.Expect(err).To(gomega.MatchError(ipam.ErrFull))
+			used, _, err := allocator.GetUsage(subnetName)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(used).To(gomega.BeEquivalentTo(8))
+		})
+
+		ginkgo.It("keeps the allocated IPs of the kept subnets on update", func() {
+			subnetName := "subnet1"
+
+			err := allocator.AddOrUpdateSubnet(subnetName, ovntest.MustParseIPNets("10.1.1.0/30"))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			err = allocator.AllocateUntilFull(subnetName)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			err = allocator.AddOrUpdateSubnet(subnetName, ovntest.MustParseIPNets("10.1.1.0/30", "10.1.2.0/24"),
+				ovntest.MustParseIPNets("10.1.1.1/32", "10.1.2.1/32")...)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			ips, err := allocator.AllocateNextIPs(subnetName)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(ips).To(gomega.HaveLen(1))
+			gomega.Expect(ips[0].String()).To(gomega.Equal("10.1.2.2/24"))
+		})
+	})
+
+	ginkgo.Context("when getting usage", func() {
+		ginkgo.It("reports allocated and free IPs across the subnets", func() {
+			subnetName := "subnet1"
+			subnets := []string{
+				"10.1.1.0/29",
+				"10.1.2.0/29",
+			}
+
+			err := allocator.AddOrUpdateSubnet(subnetName, ovntest.MustParseIPNets(subnets...))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			for i := 0; i < 2; i++ {
+				_, err := allocator.AllocateNextIPs(subnetName)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			}
+
+			used, free, err := allocator.GetUsage(subnetName)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(used).To(gomega.BeEquivalentTo(4))
+			gomega.Expect(free).To(gomega.BeEquivalentTo(8))
+		})
+
+		ginkgo.It("fails for an unknown subnet", func() {
+			_, _, err := allocator.GetUsage("subnet1")
+			gomega.Expect(err).To(gomega.MatchError(ErrSubnetNotFound))
+		})
+	})
+
 })
 
 func TestSubnetIPAllocator(t *testing.T) {
@@ -240,6 +240,11 @@ func allocatePodAnnotationWithRollback(
 		}
 	}()
 
+	// don't override a malformed, likely hand-edited, annotation
+	if err = util.ValidatePodAnnotation(pod.Annotations); err != nil {
+		err = fmt.Errorf("rejected the pod annotation of %s: %w", podDesc, err)
+		return
+	}
 	podAnnotation, _ = util.UnmarshalPodAnnotation(pod.Annotations, nadName)
 	if podAnnotation == nil {
 		podAnnotation = &util.PodAnnotation{}
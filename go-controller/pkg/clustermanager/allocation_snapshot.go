@@ -0,0 +1,218 @@
+package clustermanager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/snapshot"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// allocationSnapshotInterval is the interval the allocation snapshot is
+	// refreshed at
+	allocationSnapshotInterval = 30 * time.Second
+
+	// allocationSnapshotConflictReason is the reason of the event posted for
+	// allocations conflicting with the snapshot
+	allocationSnapshotConflictReason = "AllocationSnapshotConflict"
+)
+
+// allocationSnapshotController persists a snapshot of the pod IP, tunnel ID,
+// egress IP and network ID allocations of the cluster manager leader, and on
+// startup reconciles the allocations found in the cluster against the last
+// snapshot. After a control plane rebuild, e.g. an etcd restore with older
+// data, conflicts are reported instead of going unnoticed.
+type allocationSnapshotController struct {
+	wf       *factory.WatchFactory
+	recorder record.EventRecorder
+	path     string
+
+	// last persisted snapshot
+	last *snapshot.Snapshot
+
+	stopChan chan struct{}
+	wg       *sync.WaitGroup
+}
+
+func newAllocationSnapshotController(wf *factory.WatchFactory, recorder record.EventRecorder) *allocationSnapshotController {
+	return &allocationSnapshotController{
+		wf:       wf,
+		recorder: recorder,
+		path:     config.ClusterManager.AllocationSnapshotPath,
+		stopChan: make(chan struct{}),
+		wg:       &sync.WaitGroup{},
+	}
+}
+
+// Reconcile compares the allocations of the cluster with the persisted
+// snapshot, if any. It needs to run after the watch factory is synced and
+// before any controller allocates anything. Conflicts, values allocated to
+// different owners overlapping with their owners of the snapshot, are logged,
+// recorded in a metric and posted as an event, and fail the reconciliation in
+// strict mode. The values of the owners deleted since the snapshot and
+// allocated to newer owners are not conflicts.
+func (c *allocationSnapshotController) Reconcile() error {
+	previous, err := snapshot.Load(c.path)
+	if err != nil {
+		return err
+	}
+	current, err := c.build()
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		klog.Infof("No allocation snapshot found at %s, %d allocations will be persisted", c.path, current.Len())
+		metrics.RecordAllocationSnapshotConflicts(0)
+		return nil
+	}
+
+	conflicts, lost := snapshot.Reconcile(previous, current)
+	metrics.RecordAllocationSnapshotConflicts(len(conflicts))
+	klog.Infof("Reconciled %d allocations against the allocation snapshot taken at %v: %d conflicts, %d no longer allocated",
+		current.Len(), previous.Timestamp, len(conflicts), lost)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		klog.Errorf("Allocation conflicts with the snapshot: %s", conflict)
+		descriptions = append(descriptions, conflict.String())
+		if strings.HasPrefix(conflict.Pool, snapshot.PoolPodIPs+"/") || strings.HasPrefix(conflict.Pool, snapshot.PoolTunnelIDs+"/") {
+			// let the owners of the pods know about it
+			namespace, name, _ := strings.Cut(conflict.CurrentOwner, "/")
+			podRef := corev1.ObjectReference{Kind: "Pod", Namespace: namespace, Name: name}
+			c.recorder.Eventf(&podRef, corev1.EventTypeWarning, allocationSnapshotConflictReason,
+				"%s %s was allocated to %s before the control plane was rebuilt", conflict.Pool, conflict.Value, conflict.SnapshotOwner)
+		}
+	}
+
+	if config.ClusterManager.AllocationSnapshotStrict {
+		return fmt.Errorf("%d allocations conflict with the snapshot %s, remove the snapshot once resolved: %s",
+			len(conflicts), c.path, strings.Join(descriptions, "; "))
+	}
+	return nil
+}
+
+// Start refreshes the snapshot periodically until stopped
+func (c *allocationSnapshotController) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(allocationSnapshotInterval)
+		defer ticker.Stop()
+		c.persist()
+		for {
+			select {
+			case <-ticker.C:
+				c.persist()
+			case <-c.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic refresh and persists a last snapshot
+func (c *allocationSnapshotController) Stop() {
+	close(c.stopChan)
+	c.wg.Wait()
+	c.persist()
+}
+
+// persist writes a new snapshot if the allocations changed since the last
+// one was written
+func (c *allocationSnapshotController) persist() {
+	current, err := c.build()
+	if err != nil {
+		klog.Errorf("Failed to build allocation snapshot: %v", err)
+		return
+	}
+	if c.last != nil && c.last.Equal(current) {
+		return
+	}
+	current.Timestamp = time.Now()
+	if err = snapshot.Save(c.path, current); err != nil {
+		klog.Errorf("Failed to persist allocation snapshot: %v", err)
+		return
+	}
+	c.last = current
+	metrics.RecordAllocationSnapshotTimestamp(current.Timestamp)
+	klog.V(5).Infof("Persisted %d allocations to allocation snapshot %s", current.Len(), c.path)
+}
+
+// build takes a snapshot of the allocations of the cluster as found in the
+// informer caches
+func (c *allocationSnapshotController) build() (*snapshot.Snapshot, error) {
+	s := snapshot.New()
+
+	// the cluster manager only allocates pod IPs and tunnel IDs with
+	// interconnect and multiple networks enabled
+	if config.OVNKubernetesFeature.EnableInterconnect && config.OVNKubernetesFeature.EnableMultiNetwork {
+		pods, err := c.wf.GetAllPods()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		for _, pod := range pods {
+			if util.PodCompleted(pod) {
+				// the allocations of completed pods are released
+				continue
+			}
+			podNetworks, err := util.UnmarshalPodAnnotationAllNetworks(pod.Annotations)
+			if err != nil {
+				klog.Warningf("Skipping pod %s/%s in allocation snapshot: %v", pod.Namespace, pod.Name, err)
+				continue
+			}
+			owner := pod.Namespace + "/" + pod.Name
+			s.SetCreated(owner, pod.CreationTimestamp.Time)
+			for nadName, podNetwork := range podNetworks {
+				for _, ip := range podNetwork.IPs {
+					s.Add(snapshot.PodIPsPool(nadName), ip, owner)
+				}
+				if podNetwork.TunnelID != 0 {
+					s.Add(snapshot.TunnelIDsPool(nadName), strconv.Itoa(podNetwork.TunnelID), owner)
+				}
+			}
+		}
+	}
+
+	nodes, err := c.wf.GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes {
+		networkIDs, err := util.GetNodeNetworkIDsAnnotationNetworkIDs(node)
+		if err != nil {
+			continue
+		}
+		for networkName, networkID := range networkIDs {
+			s.Add(snapshot.PoolNetworkIDs, strconv.Itoa(networkID), networkName)
+		}
+	}
+
+	if config.OVNKubernetesFeature.EnableEgressIP {
+		egressIPs, err := c.wf.GetEgressIPs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list egress IPs: %w", err)
+		}
+		for _, egressIP := range egressIPs {
+			s.SetCreated(egressIP.Name, egressIP.CreationTimestamp.Time)
+			for _, status := range egressIP.Status.Items {
+				s.Add(snapshot.PoolEgressIPs, status.EgressIP, egressIP.Name)
+			}
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,175 @@
+package clustermanager
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/urfave/cli/v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/snapshot"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var _ = ginkgo.Describe("Allocation snapshot", func() {
+	var (
+		app      *cli.App
+		f        *factory.WatchFactory
+		tmpDir   string
+		path     string
+		recorder *record.FakeRecorder
+	)
+
+	newPod := func(namespace, name, podNetworks string) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Annotations: map[string]string{util.OvnPodAnnotationName: podNetworks},
+			},
+			Spec: v1.PodSpec{NodeName: "node1"},
+		}
+	}
+
+	startController := func(pods ...v1.Pod) *allocationSnapshotController {
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Annotations: map[string]string{"k8s.ovn.org/network-ids": `{"default":"0","blue":"2"}`},
+			},
+		}
+		fakeClient := &util.OVNClusterManagerClientset{
+			KubeClient: fake.NewSimpleClientset(&v1.NodeList{Items: []v1.Node{node}}, &v1.PodList{Items: pods}),
+		}
+		var err error
+		f, err = factory.NewClusterManagerWatchFactory(fakeClient)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(f.Start()).To(gomega.Succeed())
+		return newAllocationSnapshotController(f, recorder)
+	}
+
+	ginkgo.BeforeEach(func() {
+		// Restore global default values before each testcase
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+		var err error
+		tmpDir, err = os.MkdirTemp("", "allocation-snapshot")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		path = filepath.Join(tmpDir, "allocations.json.gz")
+		recorder = record.NewFakeRecorder(10)
+		f = nil
+	})
+
+	ginkgo.AfterEach(func() {
+		if f != nil {
+			f.Shutdown()
+		}
+		gomega.Expect(os.RemoveAll(tmpDir)).To(gomega.Succeed())
+	})
+
+	ginkgo.It("persists the allocations and detects conflicts after a rebuild", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := config.InitConfig(ctx, nil, nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			config.Kubernetes.HostNetworkNamespace = ""
+			config.OVNKubernetesFeature.EnableInterconnect = true
+			config.OVNKubernetesFeature.EnableMultiNetwork = true
+			config.ClusterManager.AllocationSnapshotPath = path
+
+			c := startController(
+				newPod("ns1", "pod1", `{"default":{"ip_addresses":["10.128.0.5/24"],"mac_address":"0a:58:0a:80:00:05"}}`),
+				newPod("ns1", "pod2", `{"default":{"ip_addresses":["10.128.0.6/24"],"mac_address":"0a:58:0a:80:00:06"},`+
+					`"ns1/l2":{"ip_addresses":["10.1.130.2/24"],"mac_address":"0a:58:0a:01:82:02","tunnel_id":3}}`),
+			)
+
+			// nothing to reconcile against on first start
+			gomega.Expect(c.Reconcile()).To(gomega.Succeed())
+			c.Start()
+			c.Stop()
+
+			persisted, err := snapshot.Load(path)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(persisted.Pools).To(gomega.Equal(map[string]map[string]string{
+				snapshot.PodIPsPool("default"):   {"10.128.0.5/24": "ns1/pod1", "10.128.0.6/24": "ns1/pod2"},
+				snapshot.PodIPsPool("ns1/l2"):    {"10.1.130.2/24": "ns1/pod2"},
+				snapshot.TunnelIDsPool("ns1/l2"): {"3": "ns1/pod2"},
+				snapshot.PoolNetworkIDs:          {"0": "default", "2": "blue"},
+			}))
+			f.Shutdown()
+
+			// the control plane is rebuilt from older data, pod2 is gone and
+			// its IP was allocated to pod3
+			c = startController(
+				newPod("ns1", "pod1", `{"default":{"ip_addresses":["10.128.0.5/24"],"mac_address":"0a:58:0a:80:00:05"}}`),
+				newPod("ns2", "pod3", `{"default":{"ip_addresses":["10.128.0.6/24"],"mac_address":"0a:58:0a:80:00:06"}}`),
+			)
+			gomega.Expect(c.Reconcile()).To(gomega.Succeed())
+			gomega.Expect(recorder.Events).To(gomega.HaveLen(1))
+			gomega.Expect(<-recorder.Events).To(gomega.ContainSubstring(allocationSnapshotConflictReason))
+
+			// in strict mode, the cluster manager refuses to start
+			config.ClusterManager.AllocationSnapshotStrict = true
+			err = c.Reconcile()
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("10.128.0.6/24 allocated to ns1/pod2 in the snapshot and to ns2/pod3 in the cluster"))
+
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("does not report the pods deleted and re-created while restarting as conflicts", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := config.InitConfig(ctx, nil, nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			config.Kubernetes.HostNetworkNamespace = ""
+			config.OVNKubernetesFeature.EnableInterconnect = true
+			config.OVNKubernetesFeature.EnableMultiNetwork = true
+			config.ClusterManager.AllocationSnapshotPath = path
+			config.ClusterManager.AllocationSnapshotStrict = true
+
+			c := startController(
+				newPod("ns1", "pod1", `{"default":{"ip_addresses":["10.128.0.5/24"],"mac_address":"0a:58:0a:80:00:05"}}`),
+				newPod("ns1", "pod2", `{"default":{"ip_addresses":["10.128.0.6/24"],"mac_address":"0a:58:0a:80:00:06"}}`),
+			)
+			gomega.Expect(c.Reconcile()).To(gomega.Succeed())
+			c.Start()
+			c.Stop()
+			f.Shutdown()
+
+			// while restarting, pod2 was deleted, pod1 was deleted and
+			// re-created getting the IP of pod2 and the IP of pod1 was
+			// allocated to the new pod3
+			recreated := metav1.NewTime(time.Now().Add(time.Minute))
+			pod1 := newPod("ns1", "pod1", `{"default":{"ip_addresses":["10.128.0.6/24"],"mac_address":"0a:58:0a:80:00:06"}}`)
+			pod1.CreationTimestamp = recreated
+			pod3 := newPod("ns2", "pod3", `{"default":{"ip_addresses":["10.128.0.5/24"],"mac_address":"0a:58:0a:80:00:05"}}`)
+			pod3.CreationTimestamp = recreated
+			c = startController(pod1, pod3)
+			gomega.Expect(c.Reconcile()).To(gomega.Succeed())
+			gomega.Expect(recorder.Events).To(gomega.BeEmpty())
+
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+})
@@ -0,0 +1,462 @@
+package clustermanager
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+const (
+	// capacityReportPath is the path of the metrics server where the capacity
+	// report is served
+	capacityReportPath = "/capacity"
+
+	// capacityDryRunPath is the path of the metrics server answering whether
+	// host subnets can be allocated to a number of new nodes
+	capacityDryRunPath = "/capacity/dry-run"
+	// nodeCapacityPath is the path of the metrics server answering how many
+	// more nodes can join each network, per pool of cluster subnets
+	nodeCapacityPath = "/capacity/nodes"
+	// maxDryRunNodes bounds the number of nodes of a dry run, the allocations
+	// being simulated one by one
+	maxDryRunNodes = 100000
+
+	capacityFormatJSON    = "json"
+	capacityFormatCSV     = "csv"
+	capacityFormatMetrics = "metrics"
+)
+
+// usageCount holds the number of allocated and free items of a resource
+type usageCount struct {
+	Allocated uint64 `json:"allocated"`
+	Free      uint64 `json:"free"`
+}
+
+// clusterSubnetCapacity holds the host subnets allocated and free in a
+// cluster subnet. The host subnets of other lengths, like the ones requested
+// by node annotations, are counted as allocated.
+type clusterSubnetCapacity struct {
+	CIDR             string `json:"cidr"`
+	IPFamily         string `json:"ipFamily"`
+	HostSubnetLength int    `json:"hostSubnetLength"`
+	usageCount
+}
+
+// networkCapacity holds the allocation totals of a single network
+type networkCapacity struct {
+	Name           string                  `json:"name"`
+	Topology       string                  `json:"topology"`
+	ID             int                     `json:"id"`
+	V4HostSubnets  *usageCount             `json:"v4HostSubnets,omitempty"`
+	V6HostSubnets  *usageCount             `json:"v6HostSubnets,omitempty"`
+	ClusterSubnets []clusterSubnetCapacity `json:"clusterSubnets,omitempty"`
+	IPs            *usageCount             `json:"ips,omitempty"`
+	TunnelIDs      *usageCount             `json:"tunnelIDs,omitempty"`
+}
+
+// egressIPCapacity holds the number of assigned and unassigned egress IPs
+type egressIPCapacity struct {
+	Assigned   uint64 `json:"assigned"`
+	Unassigned uint64 `json:"unassigned"`
+}
+
+// capacityReport is a snapshot of the allocations made by the cluster manager
+type capacityReport struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Networks   []networkCapacity `json:"networks"`
+	NetworkIDs *usageCount       `json:"networkIDs,omitempty"`
+	EgressIPs  *egressIPCapacity `json:"egressIPs,omitempty"`
+}
+
+// getCapacity returns the allocation totals of the network. Only the resources
+// the network allocates are reported.
+func (ncc *networkClusterController) getCapacity() networkCapacity {
+	nc := networkCapacity{
+		Name:     ncc.GetNetworkName(),
+		Topology: ncc.TopologyType(),
+		ID:       ncc.networkID,
+	}
+
+	if ncc.nodeAllocator != nil {
+		v4used, v4count, v6used, v6count := ncc.nodeAllocator.GetSubnetUsage()
+		if v4count > 0 {
+			nc.V4HostSubnets = &usageCount{Allocated: v4used, Free: v4count - v4used}
+		}
+		if v6count > 0 {
+			nc.V6HostSubnets = &usageCount{Allocated: v6used, Free: v6count - v6used}
+		}
+		for _, usage := range ncc.nodeAllocator.GetClusterSubnetUsage() {
+			cs := clusterSubnetCapacity{
+				CIDR:             usage.Network.String(),
+				IPFamily:         ipFamilyName(utilnet.IsIPv6CIDR(usage.Network)),
+				HostSubnetLength: usage.HostSubnetLength,
+				usageCount:       usageCount{Allocated: usage.Used},
+			}
+			if usage.Count > usage.Used {
+				cs.Free = usage.Count - usage.Used
+			}
+			nc.ClusterSubnets = append(nc.ClusterSubnets, cs)
+		}
+	}
+
+	if ncc.podAllocator != nil {
+		used, free, err := ncc.podAllocator.GetIPUsage()
+		if err != nil {
+			klog.Warningf("Failed to get IP usage of network %s: %v", nc.Name, err)
+		} else if used+free > 0 {
+			nc.IPs = &usageCount{Allocated: used, Free: free}
+		}
+		used32, free32 := ncc.podAllocator.GetTunnelIDUsage()
+		if used32+free32 > 0 {
+			nc.TunnelIDs = &usageCount{Allocated: uint64(used32), Free: uint64(free32)}
+		}
+	}
+
+	return nc
+}
+
+func ipFamilyName(ipv6 bool) string {
+	if ipv6 {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+// getNetworkClusterController returns the controller of the named network,
+// nil if the cluster manager doesn't manage it
+func (cm *ClusterManager) getNetworkClusterController(name string) *networkClusterController {
+	if name == cm.defaultNetClusterController.GetNetworkName() {
+		return cm.defaultNetClusterController
+	}
+	if cm.secondaryNetClusterManager == nil {
+		return nil
+	}
+	for _, nc := range cm.secondaryNetClusterManager.nadController.GetAllNetworkControllers() {
+		if ncc, ok := nc.(*networkClusterController); ok && ncc.GetNetworkName() == name {
+			return ncc
+		}
+	}
+	return nil
+}
+
+// getCapacityReport builds a snapshot of the allocations made by the cluster
+// manager across all the networks it manages.
+func (cm *ClusterManager) getCapacityReport() *capacityReport {
+	report := &capacityReport{
+		Timestamp: time.Now().UTC(),
+		Networks:  []networkCapacity{cm.defaultNetClusterController.getCapacity()},
+	}
+
+	if cm.secondaryNetClusterManager != nil {
+		for _, nc := range cm.secondaryNetClusterManager.nadController.GetAllNetworkControllers() {
+			ncc, ok := nc.(*networkClusterController)
+			if !ok {
+				continue
+			}
+			report.Networks = append(report.Networks, ncc.getCapacity())
+		}
+		used, free := cm.secondaryNetClusterManager.networkIDAllocator.Usage()
+		report.NetworkIDs = &usageCount{Allocated: uint64(used), Free: uint64(free)}
+	}
+
+	if cm.eIPC != nil {
+		assigned, unassigned, err := cm.eIPC.getEgressIPUsage()
+		if err != nil {
+			klog.Warningf("Failed to get egress IP usage: %v", err)
+		} else {
+			report.EgressIPs = &egressIPCapacity{Assigned: uint64(assigned), Unassigned: uint64(unassigned)}
+		}
+	}
+
+	return report
+}
+
+// capacityRecord is a single row of the flattened capacity report
+type capacityRecord struct {
+	network   string
+	topology  string
+	resource  string
+	ipFamily  string
+	allocated uint64
+	free      uint64
+}
+
+// records flattens the report in a list of rows, one per network, resource and
+// IP family.
+func (r *capacityReport) records() []capacityRecord {
+	records := []capacityRecord{}
+	add := func(network, topology, resource, ipFamily string, u *usageCount) {
+		if u == nil {
+			return
+		}
+		records = append(records, capacityRecord{network, topology, resource, ipFamily, u.Allocated, u.Free})
+	}
+	for _, nc := range r.Networks {
+		add(nc.Name, nc.Topology, "host_subnets", "ipv4", nc.V4HostSubnets)
+		add(nc.Name, nc.Topology, "host_subnets", "ipv6", nc.V6HostSubnets)
+		add(nc.Name, nc.Topology, "ips", "", nc.IPs)
+		add(nc.Name, nc.Topology, "tunnel_ids", "", nc.TunnelIDs)
+	}
+	add("", "", "network_ids", "", r.NetworkIDs)
+	if r.EgressIPs != nil {
+		add("", "", "egress_ips", "", &usageCount{Allocated: r.EgressIPs.Assigned, Free: r.EgressIPs.Unassigned})
+	}
+	return records
+}
+
+// writeCSV writes the report as CSV, one row per network, resource and IP family
+func (r *capacityReport) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"network", "topology", "resource", "ip_family", "allocated", "free"}); err != nil {
+		return err
+	}
+	for _, rec := range r.records() {
+		row := []string{
+			rec.network,
+			rec.topology,
+			rec.resource,
+			rec.ipFamily,
+			strconv.FormatUint(rec.allocated, 10),
+			strconv.FormatUint(rec.free, 10),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// capacityCollector exposes a capacity report as prometheus gauges
+type capacityCollector struct {
+	report *capacityReport
+	desc   *prometheus.Desc
+}
+
+func newCapacityCollector(report *capacityReport) *capacityCollector {
+	return &capacityCollector{
+		report: report,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.MetricOvnkubeNamespace, metrics.MetricOvnkubeSubsystemClusterManager, "capacity"),
+			"The number of allocated and free resources per network, resource and IP family",
+			[]string{"network", "topology", "resource", "ip_family", "state"},
+			nil,
+		),
+	}
+}
+
+func (c *capacityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *capacityCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, rec := range c.report.records() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(rec.allocated),
+			rec.network, rec.topology, rec.resource, rec.ipFamily, "allocated")
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(rec.free),
+			rec.network, rec.topology, rec.resource, rec.ipFamily, "free")
+	}
+}
+
+// serveCapacityReport serves a snapshot of the cluster manager allocations.
+// The format is selected with the "format" query parameter and can be one of
+// "json" (default), "csv" or "metrics" (Prometheus/OpenMetrics exposition).
+func (cm *ClusterManager) serveCapacityReport(w http.ResponseWriter, req *http.Request) {
+	report := cm.getCapacityReport()
+
+	format := req.URL.Query().Get("format")
+	switch format {
+	case "", capacityFormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			klog.Errorf("Failed to write capacity report: %v", err)
+		}
+	case capacityFormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=\"capacity-%s.csv\"", report.Timestamp.Format("20060102T150405Z")))
+		if err := report.writeCSV(w); err != nil {
+			klog.Errorf("Failed to write capacity report: %v", err)
+		}
+	case capacityFormatMetrics:
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(newCapacityCollector(report))
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(w, req)
+	default:
+		http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+	}
+}
+
+// ipFamilyDryRun tells how many of the new nodes of a dry run could be
+// allocated a host subnet of an IP family
+type ipFamilyDryRun struct {
+	IPFamily    string `json:"ipFamily"`
+	Allocatable uint64 `json:"allocatable"`
+	Error       string `json:"error,omitempty"`
+}
+
+// capacityDryRun is the answer to whether host subnets can be allocated to a
+// number of new nodes
+type capacityDryRun struct {
+	Network      string           `json:"network"`
+	Nodes        uint64           `json:"nodes"`
+	PrefixLength int              `json:"prefixLength,omitempty"`
+	IPFamilies   []ipFamilyDryRun `json:"ipFamilies"`
+	// Fits is set if all the new nodes can be allocated a host subnet of
+	// each IP family
+	Fits bool `json:"fits"`
+}
+
+// dryRunNodeAllocations simulates the allocation of host subnets of the IP
+// families of the network, or of the given one, to new nodes
+func (ncc *networkClusterController) dryRunNodeAllocations(nodes uint64, ipFamily string, prefixLen int) *capacityDryRun {
+	dryRun := &capacityDryRun{
+		Network:      ncc.GetNetworkName(),
+		Nodes:        nodes,
+		PrefixLength: prefixLen,
+		Fits:         true,
+	}
+	v4mode, v6mode := ncc.IPMode()
+	for _, ipv6 := range []bool{false, true} {
+		family := ipFamilyName(ipv6)
+		if ipFamily != "" {
+			if ipFamily != family {
+				continue
+			}
+		} else if ipv6 && !v6mode || !ipv6 && !v4mode {
+			continue
+		}
+		result := ipFamilyDryRun{IPFamily: family}
+		allocatable, err := ncc.nodeAllocator.SimulateNodeAllocations(ipv6, prefixLen, nodes)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Allocatable = allocatable
+		dryRun.Fits = dryRun.Fits && err == nil && allocatable == nodes
+		dryRun.IPFamilies = append(dryRun.IPFamilies, result)
+	}
+	return dryRun
+}
+
+// serveCapacityDryRun answers whether host subnets can be allocated to a
+// number of new nodes, without allocating them. The query parameters are
+// "nodes", the number of new nodes, "network", the name of the network, the
+// default network if not set, and, to simulate host subnets requested with a
+// different prefix length, "prefixLength" together with "ipFamily", "ipv4"
+// or "ipv6".
+func (cm *ClusterManager) serveCapacityDryRun(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	nodes, err := strconv.ParseUint(query.Get("nodes"), 10, 64)
+	if err != nil || nodes == 0 || nodes > maxDryRunNodes {
+		http.Error(w, fmt.Sprintf("invalid number of nodes %q, must be between 1 and %d", query.Get("nodes"), maxDryRunNodes),
+			http.StatusBadRequest)
+		return
+	}
+	ipFamily := query.Get("ipFamily")
+	if ipFamily != "" && ipFamily != ipFamilyName(false) && ipFamily != ipFamilyName(true) {
+		http.Error(w, fmt.Sprintf("unsupported IP family %q", ipFamily), http.StatusBadRequest)
+		return
+	}
+	var prefixLen int
+	if value := query.Get("prefixLength"); value != "" {
+		prefixLen, err = strconv.Atoi(value)
+		if err != nil || prefixLen <= 0 {
+			http.Error(w, fmt.Sprintf("invalid prefix length %q", value), http.StatusBadRequest)
+			return
+		}
+		if ipFamily == "" {
+			http.Error(w, "the IP family is required with a prefix length", http.StatusBadRequest)
+			return
+		}
+	}
+	network := query.Get("network")
+	if network == "" {
+		network = types.DefaultNetworkName
+	}
+	ncc := cm.getNetworkClusterController(network)
+	if ncc == nil || ncc.nodeAllocator == nil {
+		http.Error(w, fmt.Sprintf("network %q does not allocate host subnets", network), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ncc.dryRunNodeAllocations(nodes, ipFamily, prefixLen)); err != nil {
+		klog.Errorf("Failed to write capacity dry run: %v", err)
+	}
+}
+
+// nodePoolCapacity is how many more nodes can join a network from a pool of
+// cluster subnets, the topology zone they are mapped to
+type nodePoolCapacity struct {
+	Network string `json:"network"`
+	Pool    string `json:"pool"`
+	Nodes   uint64 `json:"nodes"`
+}
+
+// getNodeCapacity returns how many more nodes can join each network
+// allocating host subnets, or only the named one, per pool
+func (cm *ClusterManager) getNodeCapacity(network string) []nodePoolCapacity {
+	nccs := []*networkClusterController{cm.defaultNetClusterController}
+	if network != "" {
+		nccs = []*networkClusterController{cm.getNetworkClusterController(network)}
+	} else if cm.secondaryNetClusterManager != nil {
+		for _, nc := range cm.secondaryNetClusterManager.nadController.GetAllNetworkControllers() {
+			if ncc, ok := nc.(*networkClusterController); ok {
+				nccs = append(nccs, ncc)
+			}
+		}
+	}
+	capacity := []nodePoolCapacity{}
+	for _, ncc := range nccs {
+		if ncc == nil || ncc.nodeAllocator == nil {
+			continue
+		}
+		for _, pc := range ncc.nodeAllocator.GetNodeCapacity() {
+			capacity = append(capacity, nodePoolCapacity{Network: ncc.GetNetworkName(), Pool: pc.Pool, Nodes: pc.Nodes})
+		}
+	}
+	return capacity
+}
+
+// serveNodeCapacity answers how many more nodes can be allocated host subnets
+// of each network, per pool of cluster subnets, so that the tools scaling the
+// cluster up don't create nodes that can't join it. The "network" query
+// parameter restricts the answer to a network.
+func (cm *ClusterManager) serveNodeCapacity(w http.ResponseWriter, req *http.Request) {
+	network := req.URL.Query().Get("network")
+	if network != "" {
+		if ncc := cm.getNetworkClusterController(network); ncc == nil || ncc.nodeAllocator == nil {
+			http.Error(w, fmt.Sprintf("network %q does not allocate host subnets", network), http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cm.getNodeCapacity(network)); err != nil {
+		klog.Errorf("Failed to write node capacity: %v", err)
+	}
+}
+
+// registerCapacityReportHandler exposes the capacity report, dry runs and node
+// capacity on the metrics server if metrics are enabled
+func (cm *ClusterManager) registerCapacityReportHandler() {
+	if config.Metrics.BindAddress == "" {
+		return
+	}
+	metrics.RegisterHTTPHandler(capacityReportPath, http.HandlerFunc(cm.serveCapacityReport))
+	metrics.RegisterHTTPHandler(capacityDryRunPath, http.HandlerFunc(cm.serveCapacityDryRun))
+	metrics.RegisterHTTPHandler(nodeCapacityPath, http.HandlerFunc(cm.serveNodeCapacity))
+}
@@ -0,0 +1,98 @@
+package clustermanager
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ = ginkgo.Describe("Cluster manager capacity report", func() {
+	var report *capacityReport
+
+	ginkgo.BeforeEach(func() {
+		report = &capacityReport{
+			Timestamp: time.Now(),
+			Networks: []networkCapacity{
+				{
+					Name:          "default",
+					Topology:      "layer3",
+					V4HostSubnets: &usageCount{Allocated: 3, Free: 253},
+				},
+				{
+					Name:      "blue",
+					Topology:  "layer2",
+					ID:        1,
+					IPs:       &usageCount{Allocated: 10, Free: 243},
+					TunnelIDs: &usageCount{Allocated: 10, Free: 65525},
+				},
+			},
+			NetworkIDs: &usageCount{Allocated: 2, Free: 4094},
+			EgressIPs:  &egressIPCapacity{Assigned: 1, Unassigned: 2},
+		}
+	})
+
+	ginkgo.It("renders a CSV snapshot", func() {
+		var buf bytes.Buffer
+		gomega.Expect(report.writeCSV(&buf)).To(gomega.Succeed())
+		gomega.Expect(strings.Split(strings.TrimSpace(buf.String()), "\n")).To(gomega.Equal([]string{
+			"network,topology,resource,ip_family,allocated,free",
+			"default,layer3,host_subnets,ipv4,3,253",
+			"blue,layer2,ips,,10,243",
+			"blue,layer2,tunnel_ids,,10,65525",
+			",,network_ids,,2,4094",
+			",,egress_ips,,1,2",
+		}))
+	})
+
+	ginkgo.It("exposes the snapshot as metrics", func() {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(newCapacityCollector(report))
+		families, err := registry.Gather()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(families).To(gomega.HaveLen(1))
+		gomega.Expect(families[0].GetName()).To(gomega.Equal("ovnkube_clustermanager_capacity"))
+		values := map[string]float64{}
+		for _, m := range families[0].GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			key := strings.Join([]string{labels["network"], labels["resource"], labels["ip_family"], labels["state"]}, "/")
+			values[key] = m.GetGauge().GetValue()
+		}
+		gomega.Expect(values).To(gomega.Equal(map[string]float64{
+			"default/host_subnets/ipv4/allocated": 3,
+			"default/host_subnets/ipv4/free":      253,
+			"blue/ips//allocated":                 10,
+			"blue/ips//free":                      243,
+			"blue/tunnel_ids//allocated":          10,
+			"blue/tunnel_ids//free":               65525,
+			"/network_ids//allocated":             2,
+			"/network_ids//free":                  4094,
+			"/egress_ips//allocated":              1,
+			"/egress_ips//free":                   2,
+		}))
+	})
+
+	ginkgo.It("rejects invalid dry runs", func() {
+		cm := &ClusterManager{}
+		for _, query := range []string{
+			"",
+			"nodes=0",
+			"nodes=1000000",
+			"nodes=10&ipFamily=ipv5",
+			"nodes=10&prefixLength=24",
+			"nodes=10&prefixLength=-1&ipFamily=ipv4",
+		} {
+			rec := httptest.NewRecorder()
+			cm.serveCapacityDryRun(rec, httptest.NewRequest(http.MethodGet, capacityDryRunPath+"?"+query, nil))
+			gomega.Expect(rec.Code).To(gomega.Equal(http.StatusBadRequest), query)
+		}
+	})
+})
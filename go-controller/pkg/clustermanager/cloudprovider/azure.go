@@ -0,0 +1,274 @@
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+const (
+	azureManagementEndpoint = "https://management.azure.com"
+	azureMetadataEndpoint   = "http://169.254.169.254/metadata"
+	azureComputeAPIVersion  = "2023-03-01"
+	azureNetworkAPIVersion  = "2023-05-01"
+	// azureIPConfigurationPrefix prefixes the names of the IP configurations
+	// of the egress IPs
+	azureIPConfigurationPrefix = "egressip-"
+)
+
+// azureVirtualMachine is a virtual machine, only the fields read are decoded
+type azureVirtualMachine struct {
+	Properties struct {
+		NetworkProfile struct {
+			NetworkInterfaces []struct {
+				ID         string `json:"id"`
+				Properties struct {
+					Primary bool `json:"primary"`
+				} `json:"properties"`
+			} `json:"networkInterfaces"`
+		} `json:"networkProfile"`
+	} `json:"properties"`
+}
+
+// azureSubResource is a reference to another resource
+type azureSubResource struct {
+	ID string `json:"id"`
+}
+
+// azureIPConfiguration is an IP configuration of a network interface, only
+// the fields read or set are decoded
+type azureIPConfiguration struct {
+	Name       string `json:"name"`
+	Properties struct {
+		PrivateIPAddress          string            `json:"privateIPAddress,omitempty"`
+		PrivateIPAllocationMethod string            `json:"privateIPAllocationMethod,omitempty"`
+		PrivateIPAddressVersion   string            `json:"privateIPAddressVersion,omitempty"`
+		Primary                   bool              `json:"primary,omitempty"`
+		Subnet                    *azureSubResource `json:"subnet,omitempty"`
+	} `json:"properties"`
+}
+
+// azureAsyncOperation is the status of an asynchronous operation
+type azureAsyncOperation struct {
+	Status string `json:"status"`
+	Error  *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// azureProvider attaches the egress IPs to the primary network interface of
+// the nodes as static secondary IP configurations, in the subnet of their
+// primary IP configuration. The virtual machines of scale sets aren't
+// supported, their network interfaces are managed by the scale set. The
+// updates of a network interface are guarded by its etag.
+type azureProvider struct {
+	client             *http.Client
+	managementEndpoint string
+	metadataEndpoint   string
+	pollInterval       time.Duration
+	token              *accessToken
+}
+
+func newAzureProvider(client *http.Client, managementEndpoint, metadataEndpoint string) *azureProvider {
+	p := &azureProvider{
+		client:             client,
+		managementEndpoint: managementEndpoint,
+		metadataEndpoint:   metadataEndpoint,
+		pollInterval:       operationPollInterval,
+	}
+	p.token = &accessToken{fetch: p.fetchToken}
+	return p
+}
+
+func (p *azureProvider) Name() string {
+	return config.EgressIPCloudProviderAzure
+}
+
+// fetchToken gets the access token of the managed identity of the virtual
+// machine from the instance metadata service
+func (p *azureProvider) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	var resp tokenResponse
+	tokenURL := fmt.Sprintf("%s/identity/oauth2/token?api-version=2018-02-01&resource=%s", p.metadataEndpoint,
+		url.QueryEscape(azureManagementEndpoint+"/"))
+	if _, err := doJSON(ctx, p.client, http.MethodGet, tokenURL, map[string]string{"Metadata": "true"}, nil, &resp); err != nil {
+		return "", 0, err
+	}
+	lifetime, err := resp.lifetime()
+	return resp.AccessToken, lifetime, err
+}
+
+// azureVirtualMachineID returns the resource ID of the virtual machine of the
+// node, from its provider ID: azure:///subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Compute/virtualMachines/<name>
+func azureVirtualMachineID(node *v1.Node) (string, error) {
+	id := strings.TrimPrefix(node.Spec.ProviderID, "azure://")
+	if !strings.HasPrefix(node.Spec.ProviderID, "azure://") || !strings.HasPrefix(id, "/subscriptions/") {
+		return "", fmt.Errorf("invalid Azure provider ID %q of node %s", node.Spec.ProviderID, node.Name)
+	}
+	if strings.Contains(strings.ToLower(id), "/virtualmachinescalesets/") {
+		return "", fmt.Errorf("node %s is a virtual machine of a scale set, which isn't supported", node.Name)
+	}
+	return id, nil
+}
+
+// azureIPConfigurationName returns the name of the IP configuration of the
+// egress IP
+func azureIPConfigurationName(ip net.IP) string {
+	return azureIPConfigurationPrefix + strings.NewReplacer(".", "-", ":", "-").Replace(ip.String())
+}
+
+func (p *azureProvider) AttachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error {
+	return p.updateIPConfigurations(ctx, node, ip, true)
+}
+
+func (p *azureProvider) DetachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error {
+	err := p.updateIPConfigurations(ctx, node, ip, false)
+	if isNotFound(err) {
+		klog.Infof("The virtual machine of node %s is gone, egress IP %s is detached from it", node.Name, ip)
+		return nil
+	}
+	return err
+}
+
+// updateIPConfigurations adds the IP configuration of the egress IP to the
+// primary network interface of the node, or removes it, if needed
+func (p *azureProvider) updateIPConfigurations(ctx context.Context, node *v1.Node, ip net.IP, attach bool) error {
+	vmID, err := azureVirtualMachineID(node)
+	if err != nil {
+		return err
+	}
+	token, err := p.token.get(ctx)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	var vm azureVirtualMachine
+	if _, err := doJSON(ctx, p.client, http.MethodGet, p.resourceURL(vmID, azureComputeAPIVersion), headers, nil, &vm); err != nil {
+		return fmt.Errorf("failed to get the virtual machine of node %s: %w", node.Name, err)
+	}
+	nicID := ""
+	for _, nic := range vm.Properties.NetworkProfile.NetworkInterfaces {
+		if nic.Properties.Primary || len(vm.Properties.NetworkProfile.NetworkInterfaces) == 1 {
+			nicID = nic.ID
+			break
+		}
+	}
+	if nicID == "" {
+		return fmt.Errorf("the virtual machine of node %s has no primary network interface", node.Name)
+	}
+
+	// the network interface is updated as a whole, its fields not decoded
+	// here are sent back as is
+	var nic map[string]json.RawMessage
+	nicHeaders, err := doJSON(ctx, p.client, http.MethodGet, p.resourceURL(nicID, azureNetworkAPIVersion), headers, nil, &nic)
+	if err != nil {
+		return fmt.Errorf("failed to get the network interface of node %s: %w", node.Name, err)
+	}
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(nic["properties"], &properties); err != nil {
+		return fmt.Errorf("failed to decode the network interface %s: %v", nicID, err)
+	}
+	var rawIPConfigurations []json.RawMessage
+	if err := json.Unmarshal(properties["ipConfigurations"], &rawIPConfigurations); err != nil {
+		return fmt.Errorf("failed to decode the IP configurations of the network interface %s: %v", nicID, err)
+	}
+
+	ipConfigurations := make([]json.RawMessage, 0, len(rawIPConfigurations)+1)
+	var primary *azureIPConfiguration
+	found := false
+	for _, raw := range rawIPConfigurations {
+		var ipConfiguration azureIPConfiguration
+		if err := json.Unmarshal(raw, &ipConfiguration); err != nil {
+			return fmt.Errorf("failed to decode an IP configuration of the network interface %s: %v", nicID, err)
+		}
+		if ipConfiguration.Properties.Primary {
+			primary = &ipConfiguration
+		}
+		if ip.Equal(net.ParseIP(ipConfiguration.Properties.PrivateIPAddress)) {
+			if ipConfiguration.Properties.Primary {
+				return fmt.Errorf("egress IP %s is the primary IP of node %s", ip, node.Name)
+			}
+			found = true
+			if !attach {
+				continue
+			}
+		}
+		ipConfigurations = append(ipConfigurations, raw)
+	}
+	if found == attach {
+		return nil
+	}
+	if attach {
+		if primary == nil || primary.Properties.Subnet == nil {
+			return fmt.Errorf("the network interface of node %s has no primary IP configuration", node.Name)
+		}
+		ipConfiguration := azureIPConfiguration{Name: azureIPConfigurationName(ip)}
+		ipConfiguration.Properties.PrivateIPAddress = ip.String()
+		ipConfiguration.Properties.PrivateIPAllocationMethod = "Static"
+		ipConfiguration.Properties.PrivateIPAddressVersion = "IPv4"
+		if utilnet.IsIPv6(ip) {
+			ipConfiguration.Properties.PrivateIPAddressVersion = "IPv6"
+		}
+		ipConfiguration.Properties.Subnet = primary.Properties.Subnet
+		raw, err := json.Marshal(ipConfiguration)
+		if err != nil {
+			return err
+		}
+		ipConfigurations = append(ipConfigurations, raw)
+	}
+	if properties["ipConfigurations"], err = json.Marshal(ipConfigurations); err != nil {
+		return err
+	}
+	if nic["properties"], err = json.Marshal(properties); err != nil {
+		return err
+	}
+
+	updateHeaders := map[string]string{"Authorization": "Bearer " + token}
+	if etag := nicHeaders.Get("ETag"); etag != "" {
+		updateHeaders["If-Match"] = etag
+	}
+	respHeaders, err := doJSON(ctx, p.client, http.MethodPut, p.resourceURL(nicID, azureNetworkAPIVersion), updateHeaders, nic, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update the IP configurations of node %s: %w", node.Name, err)
+	}
+	asyncURL := respHeaders.Get("Azure-AsyncOperation")
+	if asyncURL == "" {
+		return nil
+	}
+	klog.V(5).Infof("Waiting for the update of the IP configurations of node %s for egress IP %s", node.Name, ip)
+	var op azureAsyncOperation
+	err = waitFor(ctx, p.pollInterval, func() (bool, error) {
+		if _, err := doJSON(ctx, p.client, http.MethodGet, asyncURL, headers, nil, &op); err != nil {
+			return false, err
+		}
+		return op.Status != "InProgress", nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for the update of the IP configurations of node %s: %w", node.Name, err)
+	}
+	if op.Status != "Succeeded" {
+		message := ""
+		if op.Error != nil {
+			message = op.Error.Code + ": " + op.Error.Message
+		}
+		return fmt.Errorf("the update of the IP configurations of node %s %s: %s", node.Name, strings.ToLower(op.Status), message)
+	}
+	return nil
+}
+
+// resourceURL returns the URL of the resource in the API version
+func (p *azureProvider) resourceURL(id, apiVersion string) string {
+	return fmt.Sprintf("%s%s?api-version=%s", p.managementEndpoint, id, apiVersion)
+}
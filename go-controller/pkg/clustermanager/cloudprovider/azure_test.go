@@ -0,0 +1,146 @@
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	azureTestVMID  = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/node1"
+	azureTestNICID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/node1-nic"
+)
+
+// fakeAzure serves the management and metadata APIs used by the Azure
+// provider for a single virtual machine
+type fakeAzure struct {
+	sync.Mutex
+	serverURL string
+	nic       []byte
+	etag      int
+	tokens    int
+	updates   int
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if req.URL.Path == "/metadata/identity/oauth2/token" {
+		if req.Header.Get("Metadata") != "true" || req.URL.Query().Get("resource") != "https://management.azure.com/" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.tokens++
+		fmt.Fprint(w, `{"access_token":"token","expires_in":"86399","token_type":"Bearer"}`)
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == azureTestVMID:
+		fmt.Fprintf(w, `{"properties":{"networkProfile":{"networkInterfaces":[{"id":"%s","properties":{"primary":true}}]}}}`,
+			azureTestNICID)
+	case req.Method == http.MethodGet && req.URL.Path == azureTestNICID:
+		w.Header().Set("ETag", fmt.Sprintf(`W/"%d"`, f.etag))
+		w.Write(f.nic)
+	case req.Method == http.MethodPut && req.URL.Path == azureTestNICID:
+		if req.Header.Get("If-Match") != fmt.Sprintf(`W/"%d"`, f.etag) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.nic, _ = io.ReadAll(req.Body)
+		f.etag++
+		f.updates++
+		w.Header().Set("Azure-AsyncOperation", f.serverURL+"/operations/1")
+		w.WriteHeader(http.StatusOK)
+		w.Write(f.nic)
+	case req.Method == http.MethodGet && req.URL.Path == "/operations/1":
+		fmt.Fprint(w, `{"status":"Succeeded"}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ipConfigurations returns the private IPs of the IP configurations of the
+// network interface, by name, checking that the other fields were kept
+func (f *fakeAzure) ipConfigurations(g *gomega.WithT) map[string]string {
+	f.Lock()
+	defer f.Unlock()
+	var nic struct {
+		Location   string `json:"location"`
+		Properties struct {
+			EnableAcceleratedNetworking bool                   `json:"enableAcceleratedNetworking"`
+			IPConfigurations            []azureIPConfiguration `json:"ipConfigurations"`
+		} `json:"properties"`
+	}
+	g.Expect(json.Unmarshal(f.nic, &nic)).To(gomega.Succeed())
+	g.Expect(nic.Location).To(gomega.Equal("eastus"))
+	g.Expect(nic.Properties.EnableAcceleratedNetworking).To(gomega.BeTrue())
+	ips := map[string]string{}
+	for _, ipConfiguration := range nic.Properties.IPConfigurations {
+		g.Expect(ipConfiguration.Properties.Subnet).NotTo(gomega.BeNil())
+		g.Expect(ipConfiguration.Properties.Subnet.ID).To(gomega.Equal("/subscriptions/sub/subnets/worker"))
+		ips[ipConfiguration.Name] = ipConfiguration.Properties.PrivateIPAddress
+	}
+	return ips
+}
+
+func TestAzureProvider(t *testing.T) {
+	g := gomega.NewWithT(t)
+	fake := &fakeAzure{nic: []byte(`{"location":"eastus","properties":{"enableAcceleratedNetworking":true,` +
+		`"ipConfigurations":[{"name":"ipconfig1","properties":{"privateIPAddress":"10.0.0.4","primary":true,` +
+		`"privateIPAllocationMethod":"Dynamic","subnet":{"id":"/subscriptions/sub/subnets/worker"}}}]}}`)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	fake.serverURL = server.URL
+	p := newAzureProvider(server.Client(), server.URL, server.URL+"/metadata")
+	p.pollInterval = 0
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Spec:       v1.NodeSpec{ProviderID: "azure://" + azureTestVMID},
+	}
+	ctx := context.Background()
+	egressIP := net.ParseIP("10.0.0.100")
+
+	g.Expect(p.AttachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.ipConfigurations(g)).To(gomega.Equal(map[string]string{
+		"ipconfig1":           "10.0.0.4",
+		"egressip-10-0-0-100": "10.0.0.100",
+	}))
+
+	// attaching is idempotent
+	g.Expect(p.AttachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.updates).To(gomega.Equal(1))
+
+	g.Expect(p.DetachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.ipConfigurations(g)).To(gomega.Equal(map[string]string{"ipconfig1": "10.0.0.4"}))
+
+	// detaching is idempotent, also from a deleted virtual machine
+	g.Expect(p.DetachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.updates).To(gomega.Equal(2))
+	gone := node.DeepCopy()
+	gone.Spec.ProviderID = "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/node2"
+	g.Expect(p.DetachPrivateIP(ctx, gone, egressIP)).To(gomega.Succeed())
+
+	// the access token is cached
+	g.Expect(fake.tokens).To(gomega.Equal(1))
+
+	// the primary IP and the virtual machines of scale sets are rejected
+	g.Expect(p.DetachPrivateIP(ctx, node, net.ParseIP("10.0.0.4"))).NotTo(gomega.Succeed())
+	scaleSet := node.DeepCopy()
+	scaleSet.Spec.ProviderID = "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/workers/virtualMachines/0"
+	g.Expect(p.AttachPrivateIP(ctx, scaleSet, egressIP)).NotTo(gomega.Succeed())
+	g.Expect(fake.updates).To(gomega.Equal(2))
+}
@@ -0,0 +1,177 @@
+// Package cloudprovider attaches the egress IPs to the network interfaces of
+// the nodes through the APIs of the cloud providers, so that the cloud network
+// delivers the traffic of the egress IPs to their egress node.
+package cloudprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+const (
+	// requestTimeout is the timeout of every request to the cloud APIs
+	requestTimeout = 30 * time.Second
+	// operationPollInterval is the interval at which the asynchronous
+	// operations of the cloud APIs are polled
+	operationPollInterval = 2 * time.Second
+	// tokenExpiryMargin is how long before their expiry the access tokens
+	// are renewed
+	tokenExpiryMargin = time.Minute
+)
+
+// Provider attaches the egress IPs to the primary network interface of the
+// nodes on a cloud. Both operations are idempotent and block until the cloud
+// completed them. The concurrent operations on the same node may fail on
+// conflicting updates of its network interface, the callers serialize them.
+type Provider interface {
+	// Name returns the name of the provider
+	Name() string
+	// AttachPrivateIP attaches the IP to the node, a no-op if it is already
+	// attached
+	AttachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error
+	// DetachPrivateIP detaches the IP from the node, a no-op if it isn't
+	// attached
+	DetachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error
+}
+
+// New returns the provider with the name, config.EgressIPCloudProviderGCP or
+// config.EgressIPCloudProviderAzure, authenticating with the identity of
+// the instance it runs on
+func New(name string) (Provider, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	switch name {
+	case config.EgressIPCloudProviderGCP:
+		return newGCPProvider(client, gcpComputeEndpoint, gcpMetadataEndpoint), nil
+	case config.EgressIPCloudProviderAzure:
+		return newAzureProvider(client, azureManagementEndpoint, azureMetadataEndpoint), nil
+	}
+	return nil, fmt.Errorf("unknown egress IP cloud provider %q, must be %q or %q", name,
+		config.EgressIPCloudProviderGCP, config.EgressIPCloudProviderAzure)
+}
+
+// apiError is an error returned by a cloud API
+type apiError struct {
+	method string
+	url    string
+	status int
+	body   string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d: %s", e.method, e.url, e.status, e.body)
+}
+
+// isNotFound returns whether the error is a cloud API error for a missing
+// resource
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound
+}
+
+// doJSON sends the request with the body encoded in JSON, if any, and decodes
+// the JSON response into out, if set. It returns the response headers.
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, in, out interface{}) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the %s %s request: %v", method, url, err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s %s response: %v", method, url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &apiError{method: method, url: url, status: resp.StatusCode, body: string(respBody)}
+	}
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return nil, fmt.Errorf("failed to decode the %s %s response: %v", method, url, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// accessToken caches the access token of the instance identity, fetched from
+// the metadata server of the cloud
+type accessToken struct {
+	sync.Mutex
+	fetch  func(ctx context.Context) (string, time.Duration, error)
+	token  string
+	expiry time.Time
+}
+
+// get returns the cached access token, renewing it when it is about to expire
+func (t *accessToken) get(ctx context.Context) (string, error) {
+	t.Lock()
+	defer t.Unlock()
+	if t.token != "" && time.Now().Add(tokenExpiryMargin).Before(t.expiry) {
+		return t.token, nil
+	}
+	token, expiresIn, err := t.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get an access token from the metadata server: %v", err)
+	}
+	t.token, t.expiry = token, time.Now().Add(expiresIn)
+	return t.token, nil
+}
+
+// tokenResponse is the access token returned by the metadata servers
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	// ExpiresIn is the lifetime of the token in seconds, a string on Azure
+	ExpiresIn json.Number `json:"expires_in"`
+}
+
+func (r *tokenResponse) lifetime() (time.Duration, error) {
+	seconds, err := r.ExpiresIn.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("invalid token lifetime %q: %v", r.ExpiresIn, err)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// waitFor polls done until it returns true or an error, or the context
+// expires
+func waitFor(ctx context.Context, interval time.Duration, done func() (bool, error)) error {
+	for {
+		finished, err := done()
+		if err != nil || finished {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+const (
+	gcpComputeEndpoint  = "https://compute.googleapis.com/compute/v1"
+	gcpMetadataEndpoint = "http://metadata.google.internal/computeMetadata/v1"
+)
+
+// gcpAliasIPRange is an alias IP range of a network interface
+type gcpAliasIPRange struct {
+	IPCidrRange         string `json:"ipCidrRange"`
+	SubnetworkRangeName string `json:"subnetworkRangeName,omitempty"`
+}
+
+// gcpNetworkInterface is the network interface of an instance
+type gcpNetworkInterface struct {
+	Name          string            `json:"name"`
+	Fingerprint   string            `json:"fingerprint"`
+	AliasIPRanges []gcpAliasIPRange `json:"aliasIpRanges"`
+}
+
+// gcpInstance is a compute instance
+type gcpInstance struct {
+	NetworkInterfaces []gcpNetworkInterface `json:"networkInterfaces"`
+}
+
+// gcpOperation is a zonal operation
+type gcpOperation struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error,omitempty"`
+}
+
+// gcpProvider attaches the egress IPs to the first network interface of the
+// nodes as /32 alias IP ranges. The egress IPs must belong to the primary IP
+// range of the subnet of the nodes. IPv6 isn't supported by the alias IP
+// ranges. The updates of a network interface are guarded by its fingerprint.
+type gcpProvider struct {
+	client           *http.Client
+	computeEndpoint  string
+	metadataEndpoint string
+	pollInterval     time.Duration
+	token            *accessToken
+}
+
+func newGCPProvider(client *http.Client, computeEndpoint, metadataEndpoint string) *gcpProvider {
+	p := &gcpProvider{
+		client:           client,
+		computeEndpoint:  computeEndpoint,
+		metadataEndpoint: metadataEndpoint,
+		pollInterval:     operationPollInterval,
+	}
+	p.token = &accessToken{fetch: p.fetchToken}
+	return p
+}
+
+func (p *gcpProvider) Name() string {
+	return config.EgressIPCloudProviderGCP
+}
+
+// fetchToken gets the access token of the service account of the instance
+// from the metadata server
+func (p *gcpProvider) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	var resp tokenResponse
+	if _, err := doJSON(ctx, p.client, http.MethodGet, p.metadataEndpoint+"/instance/service-accounts/default/token",
+		map[string]string{"Metadata-Flavor": "Google"}, nil, &resp); err != nil {
+		return "", 0, err
+	}
+	lifetime, err := resp.lifetime()
+	return resp.AccessToken, lifetime, err
+}
+
+// gcpInstancePath returns the API path of the instance of the node, from its
+// provider ID: gce://<project>/<zone>/<instance>
+func gcpInstancePath(node *v1.Node) (string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(node.Spec.ProviderID, "gce://"), "/")
+	if !strings.HasPrefix(node.Spec.ProviderID, "gce://") || len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("invalid GCP provider ID %q of node %s", node.Spec.ProviderID, node.Name)
+	}
+	zonePath := fmt.Sprintf("/projects/%s/zones/%s", url.PathEscape(parts[0]), url.PathEscape(parts[1]))
+	return zonePath, zonePath + "/instances/" + url.PathEscape(parts[2]), nil
+}
+
+func (p *gcpProvider) AttachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error {
+	return p.updateAliasIPRanges(ctx, node, ip, func(ranges []gcpAliasIPRange, cidr string) ([]gcpAliasIPRange, bool) {
+		for _, r := range ranges {
+			if r.IPCidrRange == cidr {
+				return ranges, false
+			}
+		}
+		return append(ranges, gcpAliasIPRange{IPCidrRange: cidr}), true
+	})
+}
+
+func (p *gcpProvider) DetachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error {
+	err := p.updateAliasIPRanges(ctx, node, ip, func(ranges []gcpAliasIPRange, cidr string) ([]gcpAliasIPRange, bool) {
+		kept := make([]gcpAliasIPRange, 0, len(ranges))
+		for _, r := range ranges {
+			if r.IPCidrRange != cidr {
+				kept = append(kept, r)
+			}
+		}
+		return kept, len(kept) < len(ranges)
+	})
+	if isNotFound(err) {
+		klog.Infof("The instance of node %s is gone, egress IP %s is detached from it", node.Name, ip)
+		return nil
+	}
+	return err
+}
+
+// updateAliasIPRanges updates the alias IP ranges of the first network
+// interface of the node with the ranges returned by update, if they changed
+func (p *gcpProvider) updateAliasIPRanges(ctx context.Context, node *v1.Node, ip net.IP,
+	update func(ranges []gcpAliasIPRange, cidr string) ([]gcpAliasIPRange, bool)) error {
+	if !utilnet.IsIPv4(ip) {
+		return fmt.Errorf("egress IP %s is not supported by the GCP alias IP ranges, only IPv4 is", ip)
+	}
+	zonePath, instancePath, err := gcpInstancePath(node)
+	if err != nil {
+		return err
+	}
+	token, err := p.token.get(ctx)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	var instance gcpInstance
+	if _, err := doJSON(ctx, p.client, http.MethodGet, p.computeEndpoint+instancePath, headers, nil, &instance); err != nil {
+		return fmt.Errorf("failed to get the instance of node %s: %w", node.Name, err)
+	}
+	if len(instance.NetworkInterfaces) == 0 {
+		return fmt.Errorf("the instance of node %s has no network interface", node.Name)
+	}
+	nic := instance.NetworkInterfaces[0]
+	ranges, changed := update(nic.AliasIPRanges, ip.String()+"/32")
+	if !changed {
+		return nil
+	}
+
+	var op gcpOperation
+	updateURL := fmt.Sprintf("%s%s/updateNetworkInterface?networkInterface=%s", p.computeEndpoint, instancePath,
+		url.QueryEscape(nic.Name))
+	if _, err := doJSON(ctx, p.client, http.MethodPatch, updateURL, headers,
+		gcpNetworkInterface{Name: nic.Name, Fingerprint: nic.Fingerprint, AliasIPRanges: ranges}, &op); err != nil {
+		return fmt.Errorf("failed to update the alias IP ranges of node %s: %w", node.Name, err)
+	}
+	klog.V(5).Infof("Waiting for operation %s updating the alias IP ranges of node %s to %v", op.Name, node.Name, ranges)
+	waitURL := fmt.Sprintf("%s%s/operations/%s/wait", p.computeEndpoint, zonePath, url.PathEscape(op.Name))
+	err = waitFor(ctx, p.pollInterval, func() (bool, error) {
+		if op.Status == "DONE" {
+			return true, nil
+		}
+		_, err := doJSON(ctx, p.client, http.MethodPost, waitURL, headers, nil, &op)
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for operation %s updating the alias IP ranges of node %s: %w", op.Name, node.Name, err)
+	}
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return fmt.Errorf("operation %s updating the alias IP ranges of node %s failed: %s: %s", op.Name, node.Name,
+			op.Error.Errors[0].Code, op.Error.Errors[0].Message)
+	}
+	return nil
+}
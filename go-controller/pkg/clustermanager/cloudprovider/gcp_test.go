@@ -0,0 +1,145 @@
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeGCP serves the compute and metadata APIs used by the GCP provider for
+// a single instance
+type fakeGCP struct {
+	sync.Mutex
+	nic         gcpNetworkInterface
+	fingerprint int
+	tokens      int
+	updates     int
+	// running keeps the operations running
+	running bool
+}
+
+func (f *fakeGCP) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	instancePath := "/compute/v1/projects/project/zones/us-central1-a/instances/node1"
+	if req.URL.Path == "/computeMetadata/v1/instance/service-accounts/default/token" {
+		if req.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.tokens++
+		fmt.Fprint(w, `{"access_token":"token","expires_in":3599,"token_type":"Bearer"}`)
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == instancePath:
+		f.nic.Fingerprint = fmt.Sprint(f.fingerprint)
+		json.NewEncoder(w).Encode(gcpInstance{NetworkInterfaces: []gcpNetworkInterface{f.nic}})
+	case req.Method == http.MethodPatch && req.URL.Path == instancePath+"/updateNetworkInterface":
+		var nic gcpNetworkInterface
+		if err := json.NewDecoder(req.Body).Decode(&nic); err != nil || req.URL.Query().Get("networkInterface") != "nic0" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if nic.Fingerprint != fmt.Sprint(f.fingerprint) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.nic.AliasIPRanges = nic.AliasIPRanges
+		f.fingerprint++
+		f.updates++
+		fmt.Fprint(w, `{"name":"operation-1","status":"RUNNING"}`)
+	case req.Method == http.MethodPost && req.URL.Path == "/compute/v1/projects/project/zones/us-central1-a/operations/operation-1/wait":
+		if f.running {
+			fmt.Fprint(w, `{"name":"operation-1","status":"RUNNING"}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"operation-1","status":"DONE"}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGCPProvider(t *testing.T) {
+	g := gomega.NewWithT(t)
+	fake := &fakeGCP{nic: gcpNetworkInterface{
+		Name:          "nic0",
+		AliasIPRanges: []gcpAliasIPRange{{IPCidrRange: "10.4.0.0/24", SubnetworkRangeName: "pods"}},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	p := newGCPProvider(server.Client(), server.URL+"/compute/v1", server.URL+"/computeMetadata/v1")
+	p.pollInterval = 0
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Spec:       v1.NodeSpec{ProviderID: "gce://project/us-central1-a/node1"},
+	}
+	ctx := context.Background()
+	egressIP := net.ParseIP("10.0.0.100")
+
+	g.Expect(p.AttachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.nic.AliasIPRanges).To(gomega.Equal([]gcpAliasIPRange{
+		{IPCidrRange: "10.4.0.0/24", SubnetworkRangeName: "pods"},
+		{IPCidrRange: "10.0.0.100/32"},
+	}))
+
+	// attaching is idempotent
+	g.Expect(p.AttachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.updates).To(gomega.Equal(1))
+
+	g.Expect(p.DetachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.nic.AliasIPRanges).To(gomega.Equal([]gcpAliasIPRange{{IPCidrRange: "10.4.0.0/24", SubnetworkRangeName: "pods"}}))
+
+	// detaching is idempotent, also from a deleted instance
+	g.Expect(p.DetachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.updates).To(gomega.Equal(2))
+	gone := node.DeepCopy()
+	gone.Spec.ProviderID = "gce://project/us-central1-a/node2"
+	g.Expect(p.DetachPrivateIP(ctx, gone, egressIP)).To(gomega.Succeed())
+	g.Expect(p.AttachPrivateIP(ctx, gone, egressIP)).NotTo(gomega.Succeed())
+
+	// the operations of other nodes don't wait for a running one
+	fake.Lock()
+	fake.running = true
+	fake.Unlock()
+	attached := make(chan error)
+	go func() {
+		attached <- p.AttachPrivateIP(ctx, node, egressIP)
+	}()
+	g.Eventually(func() int {
+		fake.Lock()
+		defer fake.Unlock()
+		return fake.updates
+	}).Should(gomega.Equal(3))
+	g.Expect(p.DetachPrivateIP(ctx, gone, egressIP)).To(gomega.Succeed())
+	g.Consistently(attached).ShouldNot(gomega.Receive())
+	fake.Lock()
+	fake.running = false
+	fake.Unlock()
+	g.Eventually(attached).Should(gomega.Receive(gomega.BeNil()))
+	g.Expect(p.DetachPrivateIP(ctx, node, egressIP)).To(gomega.Succeed())
+	g.Expect(fake.updates).To(gomega.Equal(4))
+
+	// the access token is cached
+	g.Expect(fake.tokens).To(gomega.Equal(1))
+
+	// IPv6 and nodes without a GCP provider ID are rejected
+	g.Expect(p.AttachPrivateIP(ctx, node, net.ParseIP("fd00::100"))).NotTo(gomega.Succeed())
+	invalid := node.DeepCopy()
+	invalid.Spec.ProviderID = "aws:///us-east-1a/i-0123456789"
+	g.Expect(p.AttachPrivateIP(ctx, invalid, egressIP)).NotTo(gomega.Succeed())
+	g.Expect(fake.updates).To(gomega.Equal(4))
+}
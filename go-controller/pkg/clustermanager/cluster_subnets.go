@@ -0,0 +1,245 @@
+package clustermanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// loadAdditionalClusterSubnets adds the cluster subnets of the additional
+// cluster subnets ConfigMap to the node allocator, those every node applied or
+// some node holds a host subnet from. Must be called before the existing nodes
+// are synced so that their host subnets from these cluster subnets are kept.
+func (ncc *networkClusterController) loadAdditionalClusterSubnets() error {
+	cm, err := ncc.kubeClient.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace).Get(context.TODO(),
+		util.AdditionalClusterSubnetsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get the additional cluster subnets: %w", err)
+	}
+	ncc.syncAdditionalClusterSubnets(cm, false)
+	return nil
+}
+
+// watchAdditionalClusterSubnets watches the additional cluster subnets
+// ConfigMap and adds the new cluster subnets to the node allocator, and
+// removes those no longer listed, until the controller is stopped
+func (ncc *networkClusterController) watchAdditionalClusterSubnets() error {
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(ncc.kubeClient, 0,
+		informers.WithNamespace(config.Kubernetes.OVNConfigNamespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", util.AdditionalClusterSubnetsConfigMapName).String()
+		}))
+	informer := informerFactory.Core().V1().ConfigMaps().Informer()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ncc.syncAdditionalClusterSubnets(obj, true)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			ncc.syncAdditionalClusterSubnets(newObj, true)
+		},
+		DeleteFunc: func(_ interface{}) {
+			ncc.setPendingClusterSubnets(nil)
+			ncc.removeAdditionalClusterSubnets(ncc.nodeAllocator.AdditionalClusterSubnets())
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add event handler for the additional cluster subnets: %w", err)
+	}
+	informerFactory.Start(ncc.stopChan)
+	if !cache.WaitForCacheSync(ncc.stopChan, informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for the informer of the additional cluster subnets to sync")
+	}
+	return nil
+}
+
+// syncAdditionalClusterSubnets adds the new cluster subnets of the additional
+// cluster subnets ConfigMap to the node allocator once applied by every node
+// and, if requested, retries the nodes without host subnets so that they get
+// some from them. The cluster subnets no longer listed are removed. An invalid
+// ConfigMap is ignored.
+func (ncc *networkClusterController) syncAdditionalClusterSubnets(obj interface{}, retryNodes bool) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		klog.Errorf("Could not cast %T object to *corev1.ConfigMap", obj)
+		return
+	}
+	clusterSubnets, err := util.ParseAdditionalClusterSubnets(cm)
+	if err != nil {
+		klog.Errorf("Ignoring ConfigMap %s: %v", util.AdditionalClusterSubnetsConfigMapName, err)
+		return
+	}
+	ncc.removeAdditionalClusterSubnets(unlistedClusterSubnets(ncc.nodeAllocator.AdditionalClusterSubnets(), clusterSubnets))
+	ncc.setPendingClusterSubnets(unlistedClusterSubnets(clusterSubnets, ncc.nodeAllocator.AdditionalClusterSubnets()))
+	ncc.addAppliedClusterSubnets(retryNodes)
+}
+
+// setPendingClusterSubnets sets the cluster subnets of the additional cluster
+// subnets ConfigMap not yet added to the node allocator
+func (ncc *networkClusterController) setPendingClusterSubnets(clusterSubnets []config.CIDRNetworkEntry) {
+	ncc.pendingClusterSubnetsLock.Lock()
+	defer ncc.pendingClusterSubnetsLock.Unlock()
+	ncc.pendingClusterSubnets = clusterSubnets
+}
+
+// addAppliedClusterSubnets adds the pending cluster subnets that every node,
+// both its ovnkube-node and the ovnkube-controller of its zone, applied, or
+// that some node holds a host subnet from, to the node allocator. Until then,
+// no host subnet is handed out from them since the routes, ACLs and address
+// sets of the cluster subnets of some nodes don't cover them yet. If
+// requested, the nodes without host subnets are retried so that they get some
+// from the added cluster subnets.
+func (ncc *networkClusterController) addAppliedClusterSubnets(retryNodes bool) {
+	ncc.pendingClusterSubnetsLock.Lock()
+	defer ncc.pendingClusterSubnetsLock.Unlock()
+	if len(ncc.pendingClusterSubnets) == 0 {
+		return
+	}
+	nodes, err := ncc.watchFactory.GetNodes()
+	if err != nil {
+		klog.Errorf("Unable to list nodes to add the additional cluster subnets they applied: %v", err)
+		return
+	}
+	var applied, pending []config.CIDRNetworkEntry
+	for _, clusterSubnet := range ncc.pendingClusterSubnets {
+		if ncc.isClusterSubnetApplied(clusterSubnet.CIDR, nodes) {
+			applied = append(applied, clusterSubnet)
+		} else {
+			pending = append(pending, clusterSubnet)
+		}
+	}
+	ncc.pendingClusterSubnets = pending
+	if len(pending) > 0 {
+		cidrs := make([]string, 0, len(pending))
+		for _, clusterSubnet := range pending {
+			cidrs = append(cidrs, clusterSubnet.CIDR.String())
+		}
+		klog.Infof("Cluster subnets %v of ConfigMap %s are not handed out until every ovnkube-controller "+
+			"and ovnkube-node applied them", cidrs, util.AdditionalClusterSubnetsConfigMapName)
+	}
+	if len(applied) == 0 {
+		return
+	}
+	added, err := ncc.nodeAllocator.AddClusterSubnets(applied)
+	if err != nil {
+		klog.Errorf("Failed to add the additional cluster subnets: %v", err)
+	}
+	if len(added) == 0 || !retryNodes {
+		return
+	}
+
+	retried := 0
+	for _, node := range nodes {
+		if util.NoHostSubnet(node) {
+			continue
+		}
+		if _, err := util.ParseNodeHostSubnets(node, ncc.GetNetworkName(), ncc.stateStore); err == nil {
+			continue
+		}
+		if err := ncc.retryNodes.AddRetryObjWithAddNoBackoff(node); err != nil {
+			klog.Errorf("Failed to retry node %s: %v", node.Name, err)
+			continue
+		}
+		retried++
+	}
+	if retried > 0 {
+		klog.Infof("Retrying %d nodes without host subnets after adding cluster subnets", retried)
+		ncc.retryNodes.RequestRetryObjs()
+	}
+}
+
+// handleAppliedClusterSubnets adds the pending cluster subnets applied by
+// every node once a node applied more of them or is deleted. Runs
+// asynchronously since it retries the nodes, the node being handled included.
+func (ncc *networkClusterController) handleAppliedClusterSubnets() {
+	if ncc.IsSecondary() || ncc.kubeClient == nil {
+		return
+	}
+	ncc.pendingClusterSubnetsLock.Lock()
+	pending := len(ncc.pendingClusterSubnets) > 0
+	ncc.pendingClusterSubnetsLock.Unlock()
+	if pending {
+		go ncc.addAppliedClusterSubnets(true)
+	}
+}
+
+// isClusterSubnetApplied returns true if every node managed by OVN applied the
+// cluster subnet, or if some node holds a host subnet from it
+func (ncc *networkClusterController) isClusterSubnetApplied(clusterSubnet *net.IPNet, nodes []*corev1.Node) bool {
+	applied := true
+	for _, node := range nodes {
+		if util.NoHostSubnet(node) {
+			continue
+		}
+		hostSubnets, _ := util.ParseNodeHostSubnets(node, ncc.GetNetworkName(), ncc.stateStore)
+		for _, hostSubnet := range hostSubnets {
+			if clusterSubnet.Contains(hostSubnet.IP) {
+				return true
+			}
+		}
+		if !util.HasAppliedClusterSubnet(node, clusterSubnet) {
+			applied = false
+		}
+	}
+	return applied
+}
+
+// unlistedClusterSubnets returns the cluster subnets that are not listed
+func unlistedClusterSubnets(clusterSubnets, listed []config.CIDRNetworkEntry) []config.CIDRNetworkEntry {
+	var unlisted []config.CIDRNetworkEntry
+	for _, clusterSubnet := range clusterSubnets {
+		found := false
+		for _, entry := range listed {
+			if entry.CIDR.String() == clusterSubnet.CIDR.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unlisted = append(unlisted, clusterSubnet)
+		}
+	}
+	return unlisted
+}
+
+// removeAdditionalClusterSubnets removes the given cluster subnets, added at
+// runtime, from the node allocator if the migration of removed cluster
+// subnets is enabled, the nodes holding host subnets from them being migrated
+// to the remaining cluster subnets in batches. Otherwise they stay in use
+// until restarted.
+func (ncc *networkClusterController) removeAdditionalClusterSubnets(clusterSubnets []config.CIDRNetworkEntry) {
+	if len(clusterSubnets) == 0 {
+		return
+	}
+	if !config.ClusterManager.MigrateRemovedClusterSubnets {
+		cidrs := make([]string, 0, len(clusterSubnets))
+		for _, clusterSubnet := range clusterSubnets {
+			cidrs = append(cidrs, clusterSubnet.CIDR.String())
+		}
+		klog.Warningf("Cluster subnets %v were removed from ConfigMap %s but stay in use until "+
+			"ovnkube-cluster-manager is restarted, enable the migration of removed cluster subnets to "+
+			"migrate the nodes off them at runtime", cidrs, util.AdditionalClusterSubnetsConfigMapName)
+		return
+	}
+	nodes, err := ncc.watchFactory.GetNodes()
+	if err != nil {
+		klog.Errorf("Unable to list nodes to migrate them off the removed cluster subnets: %v", err)
+		return
+	}
+	if _, err := ncc.nodeAllocator.RemoveClusterSubnets(clusterSubnets, nodes); err != nil {
+		klog.Errorf("Failed to remove the additional cluster subnets: %v", err)
+	}
+}
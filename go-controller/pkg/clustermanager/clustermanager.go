@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/egressservice"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/loadbalancer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/unidling"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/healthcheck"
@@ -14,6 +16,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/node"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
@@ -22,6 +25,10 @@ import (
 const (
 	// ID of the default network.
 	defaultNetworkID = 0
+
+	// nodeAnnotationFieldManager is the field manager of the node
+	// annotations applied by the cluster manager
+	nodeAnnotationFieldManager = "ovnkube-cluster-manager"
 )
 
 // ClusterManager structure is the object which manages the cluster nodes.
@@ -38,6 +45,10 @@ type ClusterManager struct {
 	// The OVN DB setup is handled by egressIPZoneController that runs in ovnkube-controller
 	eIPC                    *egressIPClusterController
 	egressServiceController *egressservice.Controller
+	// Built-in load balancer provider, if load balancer IP pools are configured
+	loadBalancerController *loadbalancer.Controller
+	// Controller persisting a snapshot of the allocations, if enabled
+	allocationSnapshot *allocationSnapshotController
 	// event recorder used to post events to k8s
 	recorder record.EventRecorder
 
@@ -51,6 +62,7 @@ func NewClusterManager(ovnClient *util.OVNClusterManagerClientset, wf *factory.W
 	identity string, wg *sync.WaitGroup, recorder record.EventRecorder) (*ClusterManager, error) {
 
 	defaultNetClusterController := newDefaultNetworkClusterController(&util.DefaultNetInfo{}, ovnClient, wf)
+	defaultNetClusterController.recorder = recorder
 
 	zoneClusterController, err := newZoneClusterController(ovnClient, wf)
 	if err != nil {
@@ -67,15 +79,32 @@ func NewClusterManager(ovnClient *util.OVNClusterManagerClientset, wf *factory.W
 		identity:                    identity,
 	}
 
-	if config.OVNKubernetesFeature.EnableMultiNetwork {
+	// with network shards, the secondary networks are managed by the
+	// NetworkShardManager of the instance leading their shard instead
+	if config.OVNKubernetesFeature.EnableMultiNetwork && config.ClusterManager.NetworkShards == 0 {
 		cm.secondaryNetClusterManager, err = newSecondaryNetworkClusterManager(ovnClient, wf, recorder)
 		if err != nil {
 			return nil, err
 		}
+		cm.secondaryNetClusterManager.joinSubnetAllocator = zoneClusterController.joinSubnetAllocator
+	}
+
+	if config.ClusterManager.NodeAnnotationBatchInterval > 0 {
+		batcher := kube.NewNodeAnnotationBatcher(&kube.Kube{KClient: ovnClient.KubeClient}, nodeAnnotationFieldManager,
+			time.Duration(config.ClusterManager.NodeAnnotationBatchInterval)*time.Millisecond)
+		defaultNetClusterController.nodeAnnotationBatcher = batcher
+		zoneClusterController.nodeAnnotationBatcher = batcher
+		if cm.secondaryNetClusterManager != nil {
+			cm.secondaryNetClusterManager.nodeAnnotationBatcher = batcher
+		}
 	}
 
 	if config.OVNKubernetesFeature.EnableEgressIP {
-		cm.eIPC = newEgressIPController(ovnClient, wf, recorder)
+		cm.eIPC = newEgressIPController(ovnClient, wf, defaultNetClusterController.stateStore, recorder)
+	}
+
+	if config.ClusterManager.AllocationSnapshotPath != "" {
+		cm.allocationSnapshot = newAllocationSnapshotController(wf, recorder)
 	}
 
 	if config.OVNKubernetesFeature.EnableEgressService {
@@ -98,7 +127,13 @@ func NewClusterManager(ovnClient *util.OVNClusterManagerClientset, wf *factory.W
 			return isReachableViaGRPC(mgmtIPs, healthClient, hcPort, timeout)
 		}
 
-		cm.egressServiceController, err = egressservice.NewController(ovnClient, wf, isReachable)
+		cm.egressServiceController, err = egressservice.NewController(ovnClient, wf, defaultNetClusterController.stateStore, isReachable)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(config.OVNKubernetesFeature.LoadBalancerIPPools) > 0 {
+		cm.loadBalancerController, err = loadbalancer.NewController(ovnClient.KubeClient, wf)
 		if err != nil {
 			return nil, err
 		}
@@ -120,6 +155,24 @@ func (cm *ClusterManager) Start(ctx context.Context) error {
 		return err
 	}
 
+	if cm.defaultNetClusterController.stateStore != nil {
+		if err := cm.migrateNodeNetworkState(); err != nil {
+			return err
+		}
+	}
+
+	// the legacy format is still understood, so a failed conversion is not fatal
+	if err := cm.convertNodeSubnetAnnotations(); err != nil {
+		klog.Errorf("Failed to convert the legacy node subnets annotations: %v", err)
+	}
+
+	if cm.allocationSnapshot != nil {
+		// reconcile against the snapshot before anything gets allocated
+		if err := cm.allocationSnapshot.Reconcile(); err != nil {
+			return fmt.Errorf("failed to reconcile allocations with the allocation snapshot: %w", err)
+		}
+	}
+
 	if err := cm.defaultNetClusterController.Start(ctx); err != nil {
 		return err
 	}
@@ -128,7 +181,7 @@ func (cm *ClusterManager) Start(ctx context.Context) error {
 		return fmt.Errorf("could not start zone controller, err: %w", err)
 	}
 
-	if config.OVNKubernetesFeature.EnableMultiNetwork {
+	if cm.secondaryNetClusterManager != nil {
 		if err := cm.secondaryNetClusterManager.Start(); err != nil {
 			return err
 		}
@@ -146,15 +199,65 @@ func (cm *ClusterManager) Start(ctx context.Context) error {
 		}
 	}
 
+	if cm.loadBalancerController != nil {
+		if err := cm.loadBalancerController.Start(1); err != nil {
+			return err
+		}
+	}
+
+	cm.registerCapacityReportHandler()
+	cm.registerWhoHasHandler()
+
+	if cm.allocationSnapshot != nil {
+		cm.allocationSnapshot.Start()
+	}
+
+	return nil
+}
+
+// migrateNodeNetworkState copies the network state of all the nodes from
+// their annotations to the node network state store. Annotations are still
+// kept up to date so this is safe to run on every start.
+func (cm *ClusterManager) migrateNodeNetworkState() error {
+	nodes, err := cm.wf.GetNodes()
+	if err != nil {
+		return fmt.Errorf("unable to get nodes for network state migration: %w", err)
+	}
+	klog.Infof("Migrating network state of %d nodes to NodeNetworkState", len(nodes))
+	for _, node := range nodes {
+		if err := cm.defaultNetClusterController.stateStore.MigrateNodeNetworkState(node); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// convertNodeSubnetAnnotations rewrites the node subnets annotations written in
+// the legacy format by old releases in the per-network format, so that very old
+// clusters are upgraded without manual node edits. Nothing is written when the
+// host subnets of the nodes fail the validation of the node allocator.
+func (cm *ClusterManager) convertNodeSubnetAnnotations() error {
+	nodes, err := cm.wf.GetNodes()
+	if err != nil {
+		return fmt.Errorf("unable to get nodes for node subnets annotation conversion: %w", err)
+	}
+	converted, err := node.ConvertNodeSubnetAnnotations(&kube.Kube{KClient: cm.client}, nodes,
+		util.NodeHostSubnetAnnotationFormatPerNetwork, false)
+	if len(converted) > 0 {
+		klog.Infof("Converted the subnets annotation of %d nodes to the per-network format", len(converted))
+	}
+	return err
+}
+
 // Stop the cluster manager.
 func (cm *ClusterManager) Stop() {
 	klog.Info("Stopping the cluster manager")
+	if cm.allocationSnapshot != nil {
+		cm.allocationSnapshot.Stop()
+	}
 	cm.defaultNetClusterController.Stop()
 	cm.zoneClusterController.Stop()
-	if config.OVNKubernetesFeature.EnableMultiNetwork {
+	if cm.secondaryNetClusterManager != nil {
 		cm.secondaryNetClusterManager.Stop()
 	}
 	if config.OVNKubernetesFeature.EnableEgressIP {
@@ -163,4 +266,7 @@ func (cm *ClusterManager) Stop() {
 	if config.OVNKubernetesFeature.EnableEgressService {
 		cm.egressServiceController.Stop()
 	}
+	if cm.loadBalancerController != nil {
+		cm.loadBalancerController.Stop()
+	}
 }
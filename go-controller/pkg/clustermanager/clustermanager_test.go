@@ -18,6 +18,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"github.com/urfave/cli/v2"
@@ -424,6 +425,193 @@ var _ = ginkgo.Describe("Cluster Manager", func() {
 			})
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
+
+		ginkgo.It("Hybrid nodes - enable and disable hybrid overlay at runtime", func() {
+
+			app.Action = func(ctx *cli.Context) error {
+				nodes := []v1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "node1",
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:   "winnode1",
+							Labels: map[string]string{v1.LabelOSStable: "windows"},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:   "winnode2",
+							Labels: map[string]string{v1.LabelOSStable: "windows"},
+						},
+					},
+				}
+				kubeFakeClient := fake.NewSimpleClientset(&v1.NodeList{
+					Items: nodes,
+				})
+				fakeClient := &util.OVNClusterManagerClientset{
+					KubeClient: kubeFakeClient,
+				}
+
+				_, err := config.InitConfig(ctx, nil, nil)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				config.Kubernetes.HostNetworkNamespace = ""
+
+				f, err = factory.NewClusterManagerWatchFactory(fakeClient)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = f.Start()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				c, cancel := context.WithCancel(ctx.Context)
+				defer cancel()
+				clusterManager, err := NewClusterManager(fakeClient, f, "identity", wg, nil)
+				gomega.Expect(clusterManager).NotTo(gomega.BeNil())
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = clusterManager.Start(c)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				defer clusterManager.Stop()
+
+				hybridOverlaySubnets := func() []string {
+					var subnets []string
+					for _, n := range nodes {
+						updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), n.Name, metav1.GetOptions{})
+						gomega.Expect(err).NotTo(gomega.HaveOccurred())
+						if subnet, ok := updatedNode.Annotations[hotypes.HybridOverlayNodeSubnet]; ok {
+							subnets = append(subnets, subnet)
+						}
+					}
+					return subnets
+				}
+				// the linux node gets its host subnet, but the hybrid overlay
+				// is disabled
+				gomega.Eventually(func() ([]*net.IPNet, error) {
+					updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+					if err != nil {
+						return nil, err
+					}
+					return util.ParseNodeHostSubnetAnnotation(updatedNode, ovntypes.DefaultNetworkName)
+				}, 2).Should(gomega.HaveLen(1))
+				gomega.Consistently(hybridOverlaySubnets, 1).Should(gomega.BeEmpty())
+
+				// enabling the hybrid overlay allocates the hybrid overlay
+				// subnets of the windows nodes
+				cm := &v1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      HybridOverlayConfigMapName,
+						Namespace: config.Kubernetes.OVNConfigNamespace,
+					},
+					Data: map[string]string{
+						HybridOverlayEnabledKey:        "true",
+						HybridOverlayClusterSubnetsKey: hybridOverlayClusterCIDR,
+					},
+				}
+				cm, err = fakeClient.KubeClient.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace).Create(
+					context.TODO(), cm, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(hybridOverlaySubnets, 2).Should(gomega.ConsistOf("11.1.0.0/24", "11.1.1.0/24"))
+
+				// disabling it removes the hybrid overlay subnet annotations
+				cm.Data[HybridOverlayEnabledKey] = "false"
+				_, err = fakeClient.KubeClient.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace).Update(
+					context.TODO(), cm, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(hybridOverlaySubnets, 2).Should(gomega.BeEmpty())
+
+				// and enabling it again allocates them from released subnets
+				cm.Data[HybridOverlayEnabledKey] = "true"
+				_, err = fakeClient.KubeClient.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace).Update(
+					context.TODO(), cm, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(hybridOverlaySubnets, 2).Should(gomega.ConsistOf("11.1.0.0/24", "11.1.1.0/24"))
+				return nil
+			}
+
+			err := app.Run([]string{
+				app.Name,
+				"--no-hostsubnet-nodes=kubernetes.io/os=windows",
+				"-cluster-subnets=" + clusterCIDR,
+				"-gateway-mode=shared",
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("Linux nodes - removed cluster subnet", func() {
+			app.Action = func(ctx *cli.Context) error {
+				nodes := []v1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "node1",
+							Annotations: map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.1.0.0/24"]}`},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "node2",
+							Annotations: map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.2.0.0/24"]}`},
+						},
+					},
+				}
+				kubeFakeClient := fake.NewSimpleClientset(&v1.NodeList{
+					Items: nodes,
+				})
+				fakeClient := &util.OVNClusterManagerClientset{
+					KubeClient: kubeFakeClient,
+				}
+
+				_, err := config.InitConfig(ctx, nil, nil)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				config.Kubernetes.HostNetworkNamespace = ""
+
+				f, err = factory.NewClusterManagerWatchFactory(fakeClient)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = f.Start()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				// 10.2.0.0/16 was removed from the cluster subnets, the
+				// cluster manager refuses to start
+				c, cancel := context.WithCancel(ctx.Context)
+				defer cancel()
+				clusterManager, err := NewClusterManager(fakeClient, f, "identity", wg, nil)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = clusterManager.Start(c)
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(err.Error()).To(gomega.ContainSubstring("host subnets of 1 nodes for network default are not part " +
+					"of the configured cluster subnets: node2 (10.2.0.0/24)"))
+				clusterManager.Stop()
+
+				// node2 keeps its host subnet
+				updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), "node2", metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(util.ParseNodeHostSubnetAnnotation(updatedNode, ovntypes.DefaultNetworkName)).To(
+					gomega.Equal(ovntest.MustParseIPNets("10.2.0.0/24")))
+
+				// with migration enabled, node2 gets a new host subnet
+				config.ClusterManager.MigrateRemovedClusterSubnets = true
+				clusterManager, err = NewClusterManager(fakeClient, f, "identity", wg, nil)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = clusterManager.Start(c)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				defer clusterManager.Stop()
+
+				gomega.Eventually(func() ([]*net.IPNet, error) {
+					updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), "node2", metav1.GetOptions{})
+					if err != nil {
+						return nil, err
+					}
+					return util.ParseNodeHostSubnetAnnotation(updatedNode, ovntypes.DefaultNetworkName)
+				}, 2).Should(gomega.Equal(ovntest.MustParseIPNets("10.1.1.0/24")))
+
+				return nil
+			}
+
+			err := app.Run([]string{
+				app.Name,
+				"-cluster-subnets=" + clusterCIDR,
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
 	})
 
 	ginkgo.Context("Node Id allocations", func() {
@@ -1040,7 +1228,7 @@ var _ = ginkgo.Describe("Cluster Manager", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 
-		ginkgo.It("Stop cluster manager, change id of a node and verify the gateway router port addr node annotation", func() {
+		ginkgo.It("Stop cluster manager, change id of a node, duplicate the gateway router port addr of another and verify the node annotations", func() {
 			app.Action = func(ctx *cli.Context) error {
 				nodes := []v1.Node{
 					{
@@ -1082,7 +1270,7 @@ var _ = ginkgo.Describe("Cluster Manager", func() {
 				err = clusterManager.Start(ctx.Context)
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
-				node3GWRPAnnotation := ""
+				gwRPAnnotations := map[string]string{}
 				// Check that cluster manager has set the node-gateway-router-lrp-ifaddr annotation for each node.
 				for _, n := range nodes {
 					gomega.Eventually(func() error {
@@ -1098,10 +1286,8 @@ var _ = ginkgo.Describe("Cluster Manager", func() {
 						gomega.Expect(gwLRPAddrs).NotTo(gomega.BeNil())
 						gomega.Expect(len(gwLRPAddrs)).To(gomega.Equal(2))
 
-						// Store the node 3's gw router port addresses
-						if updatedNode.Name == "node3" {
-							node3GWRPAnnotation = updatedNode.Annotations[ovnNodeGRLRPAddrAnnotaton]
-						}
+						// Store the gw router port addresses of the node
+						gwRPAnnotations[updatedNode.Name] = updatedNode.Annotations[ovnNodeGRLRPAddrAnnotaton]
 						return nil
 					}).ShouldNot(gomega.HaveOccurred())
 				}
@@ -1114,9 +1300,14 @@ var _ = ginkgo.Describe("Cluster Manager", func() {
 
 				for _, n := range nodes {
 					updatedNode, _ := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), n.Name, metav1.GetOptions{})
-					if updatedNode.Name == "node3" {
-						// Change the id of node3 duplicate.
+					switch updatedNode.Name {
+					case "node2":
+						// Change the id of node2, its gw router port addresses
+						// don't depend on it.
 						updatedNode.Annotations[ovnNodeIDAnnotaton] = "50"
+					case "node3":
+						// Duplicate the gw router port addresses of node1.
+						updatedNode.Annotations[ovnNodeGRLRPAddrAnnotaton] = gwRPAnnotations["node1"]
 					}
 					updatedNodes = append(updatedNodes, *updatedNode)
 				}
@@ -1149,7 +1340,7 @@ var _ = ginkgo.Describe("Cluster Manager", func() {
 					}
 
 					node3UpdatedGWRPAnnotation := updatedNode.Annotations[ovnNodeGRLRPAddrAnnotaton]
-					gomega.Expect(node3UpdatedGWRPAnnotation).NotTo(gomega.Equal(node3GWRPAnnotation))
+					gomega.Expect(node3UpdatedGWRPAnnotation).NotTo(gomega.Equal(gwRPAnnotations["node1"]))
 
 					gwLRPAddrs, err := util.ParseNodeGatewayRouterLRPAddrs(updatedNode)
 					if err != nil {
@@ -1159,6 +1350,13 @@ var _ = ginkgo.Describe("Cluster Manager", func() {
 					gomega.Expect(len(gwLRPAddrs)).To(gomega.Equal(2))
 					return nil
 				}).ShouldNot(gomega.HaveOccurred())
+
+				// node1 and node2 keep their gw router port addresses
+				for _, nodeName := range []string{"node1", "node2"} {
+					updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(updatedNode.Annotations[ovnNodeGRLRPAddrAnnotaton]).To(gomega.Equal(gwRPAnnotations[nodeName]))
+				}
 				return nil
 			}
 
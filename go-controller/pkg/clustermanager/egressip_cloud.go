@@ -0,0 +1,503 @@
+package clustermanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	ocpcloudnetworkapi "github.com/openshift/api/cloudnetwork/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// The cloud provider APIs (AWS, Azure, GCP) attaching the egress IPs to the
+// NICs of the nodes are driven by the cloud-network-config-controller through
+// CloudPrivateIPConfig objects, which also retries the failed assignments.
+// This file surfaces the failures it reports and reconciles the
+// CloudPrivateIPConfigs which drifted from the egress IP assignments.
+// Without the cloud-network-config-controller, an egress IP cloud provider
+// calls the cloud provider APIs from the cluster manager itself, in the
+// background from a queue of nodes, see attachCloudEgressIPs and
+// detachCloudEgressIPs.
+
+const (
+	cloudAssignmentFailureQuota = "quota"
+	cloudAssignmentFailureError = "error"
+)
+
+const (
+	// cloudProviderTimeout is the timeout of the attachment or detachment of
+	// an egress IP by the egress IP cloud provider
+	cloudProviderTimeout = 2 * time.Minute
+	// cloudEgressIPWorkers is the number of nodes whose egress IPs are
+	// attached or detached by the egress IP cloud provider in parallel
+	cloudEgressIPWorkers = 5
+	// cloudAttachMaxFailures is the number of attempts to attach an egress IP
+	// to a node before it is assigned again
+	cloudAttachMaxFailures = 5
+)
+
+// cloudQuotaErrors are substrings of the errors reported by the cloud
+// providers when a node has no room left for another private IP
+var cloudQuotaErrors = []string{
+	"quota",
+	"limit exceeded",
+	"PrivateIpAddressLimitExceeded",
+	"QuotaExceeded",
+	"QUOTA_EXCEEDED",
+}
+
+// cloudAssignmentFailureReason classifies the message of a failed cloud
+// assignment condition
+func cloudAssignmentFailureReason(message string) string {
+	lower := strings.ToLower(message)
+	for _, quotaError := range cloudQuotaErrors {
+		if strings.Contains(lower, strings.ToLower(quotaError)) {
+			return cloudAssignmentFailureQuota
+		}
+	}
+	return cloudAssignmentFailureError
+}
+
+// cloudAssignmentFailed returns the failed assignment condition of the
+// CloudPrivateIPConfig, if any
+func cloudAssignmentFailed(cloudPrivateIPConfig *ocpcloudnetworkapi.CloudPrivateIPConfig) (bool, string) {
+	if len(cloudPrivateIPConfig.Status.Conditions) == 0 {
+		return false, ""
+	}
+	condition := cloudPrivateIPConfig.Status.Conditions[0]
+	if ocpcloudnetworkapi.CloudPrivateIPConfigConditionType(condition.Type) != ocpcloudnetworkapi.Assigned ||
+		v1.ConditionStatus(condition.Status) != v1.ConditionFalse {
+		return false, ""
+	}
+	return true, condition.Message
+}
+
+// recordCloudAssignmentFailure emits an event on the EgressIP owning the
+// CloudPrivateIPConfig when the cloud-network-config-controller reports a new
+// assignment failure
+func (eIPC *egressIPClusterController) recordCloudAssignmentFailure(old, new *ocpcloudnetworkapi.CloudPrivateIPConfig) {
+	failed, message := cloudAssignmentFailed(new)
+	if !failed {
+		return
+	}
+	if old != nil {
+		if oldFailed, oldMessage := cloudAssignmentFailed(old); oldFailed && oldMessage == message {
+			return
+		}
+	}
+	reason := cloudAssignmentFailureReason(message)
+	metrics.RecordEgressIPCloudAssignmentFailure(reason)
+	egressIPName, exists := new.Annotations[util.OVNEgressIPOwnerRefLabel]
+	if !exists {
+		return
+	}
+	eventReason := "CloudAssignmentFailed"
+	if reason == cloudAssignmentFailureQuota {
+		eventReason = "CloudQuotaExceeded"
+	}
+	eIPRef := v1.ObjectReference{
+		Kind: "EgressIP",
+		Name: egressIPName,
+	}
+	eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, eventReason,
+		"egress IP: %s for object EgressIP: %s could not be assigned to node: %s by the cloud provider, err: %s",
+		cloudPrivateIPConfigNameToIPString(new.Name), egressIPName, new.Spec.Node, message)
+}
+
+// cloudEgressIPOp is a pending cloud provider operation on an egress IP of a
+// node
+type cloudEgressIPOp struct {
+	egressIPName string
+	assignment   egressipv1.EgressIPStatusItem
+	attach       bool
+	// failures is the number of failed attempts of an attachment
+	failures int
+}
+
+// withCloudPendingEgressIPs returns the status with the assignments of the
+// EgressIP being attached to their node by the egress IP cloud provider, or
+// attached but not seen in the status yet, if any
+func (eIPC *egressIPClusterController) withCloudPendingEgressIPs(name string, status []egressipv1.EgressIPStatusItem) []egressipv1.EgressIPStatusItem {
+	if eIPC.cloudProvider == nil {
+		return status
+	}
+	inStatus := sets.New[egressipv1.EgressIPStatusItem](status...)
+	var pending []egressipv1.EgressIPStatusItem
+	eIPC.cloudOpsMutex.Lock()
+	defer eIPC.cloudOpsMutex.Unlock()
+	for _, assignment := range eIPC.cloudAttached[name] {
+		if !inStatus.Has(assignment) {
+			pending = append(pending, assignment)
+		}
+	}
+	for _, ops := range eIPC.cloudOps {
+		for _, op := range ops {
+			if op.attach && op.egressIPName == name && !inStatus.Has(op.assignment) {
+				pending = append(pending, op.assignment)
+			}
+		}
+	}
+	if len(pending) == 0 {
+		return status
+	}
+	// the status may be the one of the informer cache, don't append to it
+	return append(append(make([]egressipv1.EgressIPStatusItem, 0, len(status)+len(pending)), status...), pending...)
+}
+
+// attachCloudEgressIPs returns the assignments to keep that are in the
+// status of the EgressIP or attached to their node by the egress IP cloud
+// provider, if any, and the attached ones among them which are not in the
+// status yet. The attachment of the other ones is queued, the EgressIP being
+// reconciled again once it completes.
+func (eIPC *egressIPClusterController) attachCloudEgressIPs(name string, status, statusToKeep,
+	statusToAdd []egressipv1.EgressIPStatusItem) ([]egressipv1.EgressIPStatusItem, []egressipv1.EgressIPStatusItem) {
+	if eIPC.cloudProvider == nil {
+		return statusToKeep, statusToAdd
+	}
+	inStatus := sets.New[egressipv1.EgressIPStatusItem](status...)
+	attached := make([]egressipv1.EgressIPStatusItem, 0, len(statusToKeep))
+	added := []egressipv1.EgressIPStatusItem{}
+	eIPC.cloudOpsMutex.Lock()
+	defer eIPC.cloudOpsMutex.Unlock()
+	for _, assignment := range statusToKeep {
+		cloudAttached, isAttached := eIPC.cloudAttached[name][assignment.EgressIP]
+		isAttached = isAttached && cloudAttached == assignment
+		switch {
+		case inStatus.Has(assignment):
+			if isAttached {
+				delete(eIPC.cloudAttached[name], assignment.EgressIP)
+				if len(eIPC.cloudAttached[name]) == 0 {
+					delete(eIPC.cloudAttached, name)
+				}
+			}
+			attached = append(attached, assignment)
+		case isAttached:
+			attached = append(attached, assignment)
+			added = append(added, assignment)
+		default:
+			if op := eIPC.cloudOps[assignment.Node][assignment.EgressIP]; op == nil || !op.attach || op.egressIPName != name {
+				eIPC.queueCloudEgressIPOp(&cloudEgressIPOp{egressIPName: name, assignment: assignment, attach: true})
+			}
+		}
+	}
+	return attached, added
+}
+
+// detachCloudEgressIPs queues the detachment of the egress IPs of the
+// assignments from their node by the egress IP cloud provider, if any,
+// replacing their pending attachment
+func (eIPC *egressIPClusterController) detachCloudEgressIPs(name string, assignments []egressipv1.EgressIPStatusItem) {
+	if eIPC.cloudProvider == nil {
+		return
+	}
+	eIPC.cloudOpsMutex.Lock()
+	defer eIPC.cloudOpsMutex.Unlock()
+	for _, assignment := range assignments {
+		if cloudAttached, ok := eIPC.cloudAttached[name][assignment.EgressIP]; ok && cloudAttached.Node == assignment.Node {
+			delete(eIPC.cloudAttached[name], assignment.EgressIP)
+			if len(eIPC.cloudAttached[name]) == 0 {
+				delete(eIPC.cloudAttached, name)
+			}
+		}
+		eIPC.queueCloudEgressIPOp(&cloudEgressIPOp{egressIPName: name, assignment: assignment})
+	}
+}
+
+// queueCloudEgressIPOp queues the operation, replacing the pending one on
+// the same egress IP of the node. Needs to be called with the cloudOpsMutex
+// held.
+func (eIPC *egressIPClusterController) queueCloudEgressIPOp(op *cloudEgressIPOp) {
+	nodeName := op.assignment.Node
+	if eIPC.cloudOps[nodeName] == nil {
+		eIPC.cloudOps[nodeName] = map[string]*cloudEgressIPOp{}
+	}
+	eIPC.cloudOps[nodeName][op.assignment.EgressIP] = op
+	eIPC.cloudQueue.Add(nodeName)
+}
+
+// deleteCloudEgressIPOp deletes the operation if it is still pending. Needs
+// to be called with the cloudOpsMutex held.
+func (eIPC *egressIPClusterController) deleteCloudEgressIPOp(op *cloudEgressIPOp) bool {
+	nodeName := op.assignment.Node
+	if eIPC.cloudOps[nodeName][op.assignment.EgressIP] != op {
+		return false
+	}
+	delete(eIPC.cloudOps[nodeName], op.assignment.EgressIP)
+	if len(eIPC.cloudOps[nodeName]) == 0 {
+		delete(eIPC.cloudOps, nodeName)
+	}
+	return true
+}
+
+// runCloudEgressIPWorkers runs the workers calling the egress IP cloud
+// provider for the queued nodes until the controller is stopped. The
+// operations of a node are run by a single worker at a time.
+func (eIPC *egressIPClusterController) runCloudEgressIPWorkers() {
+	for i := 0; i < cloudEgressIPWorkers; i++ {
+		eIPC.wg.Add(1)
+		go func() {
+			defer eIPC.wg.Done()
+			wait.Until(func() {
+				for eIPC.processNextCloudEgressIPNode() {
+				}
+			}, time.Second, eIPC.stopChan)
+		}()
+	}
+	go func() {
+		<-eIPC.stopChan
+		eIPC.cloudQueue.ShutDown()
+	}()
+}
+
+func (eIPC *egressIPClusterController) processNextCloudEgressIPNode() bool {
+	key, quit := eIPC.cloudQueue.Get()
+	if quit {
+		return false
+	}
+	defer eIPC.cloudQueue.Done(key)
+
+	err := eIPC.syncCloudEgressIPs(key.(string))
+	if err == nil {
+		eIPC.cloudQueue.Forget(key)
+		return true
+	}
+	utilruntime.HandleError(fmt.Errorf("failed to update the egress IPs of node %v with cloud provider %s: %w",
+		key, eIPC.cloudProvider.Name(), err))
+	eIPC.cloudQueue.AddRateLimited(key)
+	return true
+}
+
+// syncCloudEgressIPs runs the pending cloud provider operations of the node.
+// The EgressIPs whose egress IP got attached are reconciled again to add it
+// to their status. The failed operations are retried, up to
+// cloudAttachMaxFailures times for an attachment: the assignment is then
+// released so that the egress IP is assigned again.
+func (eIPC *egressIPClusterController) syncCloudEgressIPs(nodeName string) error {
+	eIPC.cloudOpsMutex.Lock()
+	ops := make([]*cloudEgressIPOp, 0, len(eIPC.cloudOps[nodeName]))
+	for _, op := range eIPC.cloudOps[nodeName] {
+		ops = append(ops, op)
+	}
+	eIPC.cloudOpsMutex.Unlock()
+
+	var errs []error
+	var released []egressipv1.EgressIPStatusItem
+	requeue := sets.New[string]()
+	for _, op := range ops {
+		err := eIPC.callCloudProvider(op.assignment, op.attach)
+		if err != nil {
+			eIPC.recordCloudOperationFailure(op, err)
+			errs = append(errs, err)
+		}
+		eIPC.cloudOpsMutex.Lock()
+		switch {
+		case err == nil:
+			if eIPC.deleteCloudEgressIPOp(op) && op.attach {
+				if eIPC.cloudAttached[op.egressIPName] == nil {
+					eIPC.cloudAttached[op.egressIPName] = map[string]egressipv1.EgressIPStatusItem{}
+				}
+				eIPC.cloudAttached[op.egressIPName][op.assignment.EgressIP] = op.assignment
+				requeue.Insert(op.egressIPName)
+			}
+		case op.attach:
+			op.failures++
+			if op.failures >= cloudAttachMaxFailures && eIPC.deleteCloudEgressIPOp(op) {
+				released = append(released, op.assignment)
+				requeue.Insert(op.egressIPName)
+			}
+		}
+		eIPC.cloudOpsMutex.Unlock()
+	}
+	eIPC.deleteAllocatorEgressIPAssignments(released)
+	if requeue.Len() > 0 {
+		eIPC.requeueEgressIPs(func(eIP *egressipv1.EgressIP) bool {
+			return requeue.Has(eIP.Name)
+		})
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// recordCloudOperationFailure reports the failed cloud provider operation
+// with an event on its EgressIP, and the failure metric for an attachment
+func (eIPC *egressIPClusterController) recordCloudOperationFailure(op *cloudEgressIPOp, err error) {
+	eIPRef := v1.ObjectReference{
+		Kind: "EgressIP",
+		Name: op.egressIPName,
+	}
+	if !op.attach {
+		eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, "CloudDeletionFailed",
+			"egress IP: %s for object EgressIP: %s could not be detached from node: %s by cloud provider %s, err: %v",
+			op.assignment.EgressIP, op.egressIPName, op.assignment.Node, eIPC.cloudProvider.Name(), err)
+		return
+	}
+	reason := cloudAssignmentFailureReason(err.Error())
+	metrics.RecordEgressIPCloudAssignmentFailure(reason)
+	eventReason := "CloudAssignmentFailed"
+	if reason == cloudAssignmentFailureQuota {
+		eventReason = "CloudQuotaExceeded"
+	}
+	eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, eventReason,
+		"egress IP: %s for object EgressIP: %s could not be attached to node: %s by cloud provider %s, err: %v",
+		op.assignment.EgressIP, op.egressIPName, op.assignment.Node, eIPC.cloudProvider.Name(), err)
+}
+
+// callCloudProvider attaches or detaches the egress IP of the assignment with
+// the egress IP cloud provider. The egress IPs of the nodes which no longer
+// exist are detached already.
+func (eIPC *egressIPClusterController) callCloudProvider(assignment egressipv1.EgressIPStatusItem, attach bool) error {
+	node, err := eIPC.watchFactory.GetNode(assignment.Node)
+	if err != nil {
+		if apierrors.IsNotFound(err) && !attach {
+			return nil
+		}
+		return err
+	}
+	ip := net.ParseIP(assignment.EgressIP)
+	if ip == nil {
+		return fmt.Errorf("invalid egress IP %q", assignment.EgressIP)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cloudProviderTimeout)
+	defer cancel()
+	start := time.Now()
+	action := "attached"
+	if attach {
+		err = eIPC.cloudProvider.AttachPrivateIP(ctx, node, ip)
+	} else {
+		action = "detached"
+		err = eIPC.cloudProvider.DetachPrivateIP(ctx, node, ip)
+	}
+	if err == nil {
+		klog.Infof("Cloud provider %s %s egress IP %s on node %s in %v", eIPC.cloudProvider.Name(), action, ip,
+			node.Name, time.Since(start))
+	}
+	return err
+}
+
+// checkCloudPrivateIPConfigDrift periodically reconciles the
+// CloudPrivateIPConfigs which drifted from the egress IP assignments, for
+// instance because they were modified or deleted out of band.
+func (eIPC *egressIPClusterController) checkCloudPrivateIPConfigDrift() {
+	timer := time.NewTicker(time.Duration(config.OVNKubernetesFeature.EgressIPCloudReconcileInterval) * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if err := eIPC.reconcileCloudPrivateIPConfigDrift(); err != nil {
+				klog.Errorf("Failed to reconcile the CloudPrivateIPConfigs of the egress IPs: %v", err)
+			}
+		case <-eIPC.stopChan:
+			klog.V(5).Infof("Stop channel got triggered: will stop checkCloudPrivateIPConfigDrift")
+			return
+		}
+	}
+}
+
+// reconcileCloudPrivateIPConfigDrift compares the CloudPrivateIPConfigs with
+// the egress IP assignments, skipping the egress IPs with pending cloud
+// operations, and:
+//   - moves back the CloudPrivateIPConfigs assigned to another node than the
+//     one of their egress IP
+//   - deletes the CloudPrivateIPConfigs of egress IPs that no longer exist
+//   - removes the assignments without a CloudPrivateIPConfig from the EgressIP
+//     status, so that they are assigned again
+func (eIPC *egressIPClusterController) reconcileCloudPrivateIPConfigDrift() error {
+	eIPC.egressIPAssignmentMutex.Lock()
+	defer eIPC.egressIPAssignmentMutex.Unlock()
+
+	pending := sets.New[string]()
+	eIPC.pendingCloudPrivateIPConfigsMutex.Lock()
+	for _, ops := range eIPC.pendingCloudPrivateIPConfigsOps {
+		for egressIP := range ops {
+			pending.Insert(egressIP)
+		}
+	}
+	eIPC.pendingCloudPrivateIPConfigsMutex.Unlock()
+
+	egressIPs, err := eIPC.watchFactory.GetEgressIPs()
+	if err != nil {
+		return err
+	}
+	cloudPrivateIPConfigs, err := eIPC.watchFactory.GetCloudPrivateIPConfigs()
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*egressipv1.EgressIP, len(egressIPs))
+	for _, egressIP := range egressIPs {
+		byName[egressIP.Name] = egressIP
+	}
+
+	drifted := 0
+	existing := sets.New[string]()
+	var errs []error
+	for _, cloudPrivateIPConfig := range cloudPrivateIPConfigs {
+		egressIPString := cloudPrivateIPConfigNameToIPString(cloudPrivateIPConfig.Name)
+		existing.Insert(egressIPString)
+		egressIPName, exists := cloudPrivateIPConfig.Annotations[util.OVNEgressIPOwnerRefLabel]
+		if !exists || pending.Has(egressIPString) || !cloudPrivateIPConfig.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		egressIP := byName[egressIPName]
+		if egressIP == nil || !sets.New[string](egressIP.Spec.EgressIPs...).Has(egressIPString) {
+			klog.Warningf("CloudPrivateIPConfig: %s is not used by EgressIP: %s anymore, deleting it",
+				cloudPrivateIPConfig.Name, egressIPName)
+			drifted++
+			toRemove := []egressipv1.EgressIPStatusItem{{EgressIP: egressIPString, Node: cloudPrivateIPConfig.Spec.Node}}
+			if err := eIPC.executeCloudPrivateIPConfigChange(egressIPName, nil, toRemove); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		// only consider settled assignments, the others are still being
+		// processed by the cloud-network-config-controller
+		if cloudPrivateIPConfig.Status.Node != cloudPrivateIPConfig.Spec.Node {
+			continue
+		}
+		for _, status := range egressIP.Status.Items {
+			if status.EgressIP != egressIPString || status.Node == cloudPrivateIPConfig.Spec.Node {
+				continue
+			}
+			klog.Warningf("CloudPrivateIPConfig: %s is assigned to node: %s instead of node: %s, moving it back",
+				cloudPrivateIPConfig.Name, cloudPrivateIPConfig.Spec.Node, status.Node)
+			drifted++
+			toAssign := []egressipv1.EgressIPStatusItem{status}
+			toRemove := []egressipv1.EgressIPStatusItem{{EgressIP: egressIPString, Node: cloudPrivateIPConfig.Spec.Node}}
+			if err := eIPC.executeCloudPrivateIPConfigChange(egressIPName, toAssign, toRemove); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, egressIP := range egressIPs {
+		updatedStatus := []egressipv1.EgressIPStatusItem{}
+		for _, status := range egressIP.Status.Items {
+			if existing.Has(status.EgressIP) || pending.Has(status.EgressIP) {
+				updatedStatus = append(updatedStatus, status)
+			}
+		}
+		if len(updatedStatus) == len(egressIP.Status.Items) {
+			continue
+		}
+		klog.Warningf("EgressIP: %s has %d assignment(s) without a CloudPrivateIPConfig, removing them",
+			egressIP.Name, len(egressIP.Status.Items)-len(updatedStatus))
+		drifted += len(egressIP.Status.Items) - len(updatedStatus)
+		if err := eIPC.patchReplaceEgressIPStatus(egressIP.Name, updatedStatus); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	metrics.RecordEgressIPCloudDrift(drifted)
+	return utilerrors.NewAggregate(errs)
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	ocpcloudnetworkapi "github.com/openshift/api/cloudnetwork/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/cloudprovider"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
@@ -26,11 +27,14 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
 )
@@ -116,6 +120,27 @@ func (e *egressNode) getAllocationCountForEgressIP(name string) (count int) {
 	return
 }
 
+// exhaustedCapacity returns the capacity, IP, IPv4 or IPv6, an additional
+// allocation of the egress IP on the node would exceed, if any
+func (e *egressNode) exhaustedCapacity(eIP net.IP) string {
+	if e.egressIPConfig.Capacity.IP < util.UnlimitedNodeCapacity {
+		if e.egressIPConfig.Capacity.IP-len(e.allocations) <= 0 {
+			return "IP"
+		}
+	}
+	if e.egressIPConfig.Capacity.IPv4 < util.UnlimitedNodeCapacity && utilnet.IsIPv4(eIP) {
+		if e.egressIPConfig.Capacity.IPv4-getIPFamilyAllocationCount(e.allocations, false) <= 0 {
+			return "IPv4"
+		}
+	}
+	if e.egressIPConfig.Capacity.IPv6 < util.UnlimitedNodeCapacity && utilnet.IsIPv6(eIP) {
+		if e.egressIPConfig.Capacity.IPv6-getIPFamilyAllocationCount(e.allocations, true) <= 0 {
+			return "IPv6"
+		}
+	}
+	return ""
+}
+
 type EgressIPPatchStatus struct {
 	Op    string                    `json:"op"`
 	Path  string                    `json:"path"`
@@ -130,15 +155,31 @@ type EgressIPPatchStatus struct {
 // object update which risks resetting the EgressIP object's fields to the state
 // they had when we started processing the change.
 func (eIPC *egressIPClusterController) patchReplaceEgressIPStatus(name string, statusItems []egressipv1.EgressIPStatusItem) error {
+	// keep the conditions of the status, only reconcileEgressIP updates them
+	var conditions []metav1.Condition
+	if eIP, err := eIPC.watchFactory.GetEgressIP(name); err == nil {
+		conditions = eIP.Status.Conditions
+	}
+	return eIPC.patchEgressIPStatus(name, statusItems, conditions)
+}
+
+// patchEgressIPStatus replaces the status of the egress IP with the provided
+// items and conditions, recording the moves of the egress IPs in its history
+func (eIPC *egressIPClusterController) patchEgressIPStatus(name string, statusItems []egressipv1.EgressIPStatusItem,
+	conditions []metav1.Condition) error {
 	klog.Infof("Patching status on EgressIP %s: %v", name, statusItems)
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// the moves are recorded against the latest status, the informer
+		// might not have caught up with the previous patch yet
+		current, err := eIPC.kube.GetEgressIP(name)
+		if err != nil {
+			return err
+		}
 		t := []EgressIPPatchStatus{
 			{
-				Op:   "replace",
-				Path: "/status",
-				Value: egressipv1.EgressIPStatus{
-					Items: statusItems,
-				},
+				Op:    "replace",
+				Path:  "/status",
+				Value: eIPC.buildEgressIPStatus(current, statusItems, conditions),
 			},
 		}
 		op, err := json.Marshal(&t)
@@ -159,6 +200,30 @@ func (eIPC *egressIPClusterController) getAllocationTotalCount() float64 {
 	return float64(count)
 }
 
+// getEgressIPUsage returns the number of egress IPs requested by EgressIP
+// specs that are assigned to a node and the number that are not.
+func (eIPC *egressIPClusterController) getEgressIPUsage() (int, int, error) {
+	egressIPs, err := eIPC.watchFactory.GetEgressIPs()
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to get Egress IPs: %w", err)
+	}
+	var assigned, unassigned int
+	for _, egressIP := range egressIPs {
+		statusIPs := sets.New[string]()
+		for _, status := range egressIP.Status.Items {
+			statusIPs.Insert(status.EgressIP)
+		}
+		for _, ip := range egressIP.Spec.EgressIPs {
+			if statusIPs.Has(ip) {
+				assigned++
+			} else {
+				unassigned++
+			}
+		}
+	}
+	return assigned, unassigned, nil
+}
+
 type allocator struct {
 	*sync.Mutex
 	// A cache used for egress IP assignments containing data for all cluster nodes
@@ -364,6 +429,9 @@ type egressIPClusterController struct {
 	allocator allocator
 	// watchFactory watching k8s objects
 	watchFactory *factory.WatchFactory
+	// nodeNetworkStates gets the node network state holding the host subnets
+	// of the nodes with the "crd" backend
+	nodeNetworkStates util.NodeNetworkStateGetter
 	// EgressIP Node reachability total timeout configuration
 	egressIPTotalTimeout int
 	// reachability check interval
@@ -382,9 +450,30 @@ type egressIPClusterController struct {
 	egressIPHandler *factory.Handler
 	// cloudPrivateIPConfig events factory handler
 	cloudPrivateIPConfigHandler *factory.Handler
+	// namespace events factory handler, for the egress IP quotas
+	namespaceHandler *factory.Handler
+	// egressIPPools allocates the egress IPs of the EgressIPs requesting
+	// egress IPs from a pool, nil if the EgressIPPools can't be watched
+	egressIPPools *egressIPPools
+	// cloudProvider attaches the egress IPs to the network interface of their
+	// node, nil unless an egress IP cloud provider is configured
+	cloudProvider cloudprovider.Provider
+	// cloudQueue holds the nodes with pending cloud provider operations
+	cloudQueue workqueue.RateLimitingInterface
+	// cloudOpsMutex guards cloudOps and cloudAttached, accessed by the egress
+	// IP and cloud provider go-routines
+	cloudOpsMutex sync.Mutex
+	// cloudOps are the pending cloud provider operations of each node, by
+	// egress IP
+	cloudOps map[string]map[string]*cloudEgressIPOp
+	// cloudAttached are the assignments attached to their node by the cloud
+	// provider but not seen in the status of their EgressIP yet, by EgressIP
+	// and egress IP
+	cloudAttached map[string]map[string]egressipv1.EgressIPStatusItem
 }
 
-func newEgressIPController(ovnClient *util.OVNClusterManagerClientset, wf *factory.WatchFactory, recorder record.EventRecorder) *egressIPClusterController {
+func newEgressIPController(ovnClient *util.OVNClusterManagerClientset, wf *factory.WatchFactory,
+	nodeNetworkStates util.NodeNetworkStateGetter, recorder record.EventRecorder) *egressIPClusterController {
 	kube := &kube.KubeOVN{
 		Kube:               kube.Kube{KClient: ovnClient.KubeClient},
 		EIPClient:          ovnClient.EgressIPClient,
@@ -399,11 +488,21 @@ func newEgressIPController(ovnClient *util.OVNClusterManagerClientset, wf *facto
 		pendingCloudPrivateIPConfigsOps:   make(map[string]map[string]*cloudPrivateIPConfigOp),
 		allocator:                         allocator{&sync.Mutex{}, make(map[string]*egressNode)},
 		watchFactory:                      wf,
+		nodeNetworkStates:                 nodeNetworkStates,
 		recorder:                          recorder,
 		egressIPTotalTimeout:              config.OVNKubernetesFeature.EgressIPReachabiltyTotalTimeout,
 		reachabilityCheckInterval:         egressIPReachabilityCheckInterval,
 		egressIPNodeHealthCheckPort:       config.OVNKubernetesFeature.EgressIPNodeHealthCheckPort,
 		stopChan:                          make(chan struct{}),
+		cloudQueue: workqueue.NewNamedRateLimitingQueue(
+			workqueue.NewItemFastSlowRateLimiter(1*time.Second, 5*time.Second, 5),
+			"egressipcloud",
+		),
+		cloudOps:      make(map[string]map[string]*cloudEgressIPOp),
+		cloudAttached: make(map[string]map[string]egressipv1.EgressIPStatusItem),
+	}
+	if ovnClient.EgressIPPoolClient != nil {
+		eIPC.egressIPPools = newEgressIPPools(ovnClient.EgressIPPoolClient)
 	}
 	eIPC.initRetryFramework()
 	return eIPC
@@ -434,18 +533,43 @@ func (eIPC *egressIPClusterController) newRetryFramework(objectType reflect.Type
 
 func (eIPC *egressIPClusterController) Start() error {
 	var err error
+	if config.OVNKubernetesFeature.EgressIPCloudProvider != "" {
+		if util.PlatformTypeIsEgressIPCloudProvider() {
+			return fmt.Errorf("egress IP cloud provider %s is not supported on platform %s, whose egress IPs are "+
+				"attached by the cloud-network-config-controller", config.OVNKubernetesFeature.EgressIPCloudProvider,
+				config.Kubernetes.PlatformType)
+		}
+		if eIPC.cloudProvider, err = cloudprovider.New(config.OVNKubernetesFeature.EgressIPCloudProvider); err != nil {
+			return fmt.Errorf("unable to attach the egress IPs to the nodes: %w", err)
+		}
+		klog.Infof("EgressIP cloud provider %s attaches the egress IPs to the nodes", eIPC.cloudProvider.Name())
+		eIPC.runCloudEgressIPWorkers()
+	}
 	// In cluster manager, we only need to watch for egressNodes, egressIPs
 	// and cloudPrivateIPConfig
 	if eIPC.egressNodeHandler, err = eIPC.WatchEgressNodes(); err != nil {
 		return fmt.Errorf("unable to watch egress nodes %w", err)
 	}
+	if err = eIPC.WatchEgressIPPools(); err != nil {
+		return fmt.Errorf("unable to watch the egress IP pools %w", err)
+	}
 	if eIPC.egressIPHandler, err = eIPC.WatchEgressIP(); err != nil {
 		return err
 	}
+	eIPC.updateEgressIPPoolStatuses()
+	if eIPC.namespaceHandler, err = eIPC.WatchEgressIPQuotas(); err != nil {
+		return fmt.Errorf("unable to watch the egress IP quotas %w", err)
+	}
+	if config.OVNKubernetesFeature.EgressIPRebalanceInterval > 0 && config.OVNKubernetesFeature.EgressIPRebalanceMaxMoves > 0 {
+		go eIPC.checkEgressIPBalance()
+	}
 	if util.PlatformTypeIsEgressIPCloudProvider() {
 		if eIPC.cloudPrivateIPConfigHandler, err = eIPC.WatchCloudPrivateIPConfig(); err != nil {
 			return err
 		}
+		if config.OVNKubernetesFeature.EgressIPCloudReconcileInterval > 0 {
+			go eIPC.checkCloudPrivateIPConfigDrift()
+		}
 	}
 	if config.OVNKubernetesFeature.EgressIPReachabiltyTotalTimeout == 0 {
 		klog.V(2).Infof("EgressIP node reachability check disabled")
@@ -487,6 +611,9 @@ func (eIPC *egressIPClusterController) Stop() {
 	if eIPC.cloudPrivateIPConfigHandler != nil {
 		eIPC.watchFactory.RemoveCloudPrivateIPConfigHandler(eIPC.cloudPrivateIPConfigHandler)
 	}
+	if eIPC.namespaceHandler != nil {
+		eIPC.watchFactory.RemoveNamespaceHandler(eIPC.namespaceHandler)
+	}
 }
 
 type egressIPNodeStatus struct {
@@ -637,6 +764,11 @@ func checkEgressNodesReachabilityIterate(eIPC *egressIPClusterController) {
 }
 
 func (eIPC *egressIPClusterController) isReachable(nodeName string, mgmtIPs []net.IP, healthClient healthcheck.EgressIPHealthClient) bool {
+	// A node whose gateway next hops are unreachable can't egress traffic
+	if eIPC.hasUnreachableNextHops(nodeName) {
+		return false
+	}
+
 	// Check if we need to do node reachability check
 	if eIPC.egressIPTotalTimeout == 0 {
 		return true
@@ -648,6 +780,25 @@ func (eIPC *egressIPClusterController) isReachable(nodeName string, mgmtIPs []ne
 	return isReachableViaGRPC(mgmtIPs, healthClient, eIPC.egressIPNodeHealthCheckPort, eIPC.egressIPTotalTimeout)
 }
 
+// hasUnreachableNextHops returns true if the probes of ovnkube-node found the
+// gateway next hops of the node unreachable
+func (eIPC *egressIPClusterController) hasUnreachableNextHops(nodeName string) bool {
+	node, err := eIPC.watchFactory.GetNode(nodeName)
+	if err != nil {
+		return false
+	}
+	nextHops, err := util.ParseNodeEgressNextHopsUnreachable(node)
+	if err != nil {
+		klog.Warningf("Ignoring the unreachable egress next hops of node %s: %v", nodeName, err)
+		return false
+	}
+	if len(nextHops) > 0 {
+		klog.V(5).Infof("Node %s reports its egress next hops %v unreachable", nodeName, nextHops)
+		return true
+	}
+	return false
+}
+
 func (eIPC *egressIPClusterController) isEgressNodeReachable(egressNode *v1.Node) bool {
 	eIPC.allocator.Lock()
 	defer eIPC.allocator.Unlock()
@@ -694,6 +845,20 @@ func (eIPC *egressIPClusterController) reconcileNonOVNNetworkEIPs(node *v1.Node)
 		for _, status := range egressIP.Status.Items {
 			if status.Node == node.Name && status.Network != "" {
 				eIP := net.ParseIP(status.EgressIP)
+				if egressIP.Spec.Network != "" {
+					// the egress IPs of a selected network are re-assigned
+					// once the node has no address in the network anymore
+					network, err := getEgressIPNetwork(node, eIP, egressIP.Spec.Network)
+					if err == nil && network == status.Network {
+						continue
+					}
+					if err := eIPC.reconcileEgressIP(nil, &egressIP); err != nil {
+						errorAggregate = append(errorAggregate, fmt.Errorf("re-assignment for EgressIP %s IP %s hosted by "+
+							"network %s failed, unable to update object, err: %v", egressIP.Name, eIP.String(),
+							egressIP.Spec.Network, err))
+					}
+					continue
+				}
 				isOVNManagedNetwork, err := util.IsOVNManagedNetwork(node, eIP)
 				if err != nil {
 					errorAggregate = append(errorAggregate, fmt.Errorf("failed to determine if egress IP %s IP %s "+
@@ -725,6 +890,41 @@ func (eIPC *egressIPClusterController) reconcileNonOVNNetworkEIPs(node *v1.Node)
 	return nil
 }
 
+// reconcilePlacedEgressIPs reconciles the EgressIPs with placement constraints
+// which are either assigned to the node or not fully assigned, after a change of
+// the node labels or zone: the node might not satisfy the placement constraints
+// of the egress IPs assigned to it anymore, or satisfy those of other EgressIPs.
+func (eIPC *egressIPClusterController) reconcilePlacedEgressIPs(nodeName string) error {
+	var errorAggregate []error
+	egressIPs, err := eIPC.kube.GetEgressIPs()
+	if err != nil {
+		return fmt.Errorf("unable to list EgressIPs, err: %v", err)
+	}
+	for _, egressIP := range egressIPs.Items {
+		if egressIP.Spec.Placement == nil {
+			continue
+		}
+		reconcile := len(egressIP.Spec.EgressIPs) != len(egressIP.Status.Items)
+		for _, status := range egressIP.Status.Items {
+			if status.Node == nodeName {
+				reconcile = true
+				break
+			}
+		}
+		if !reconcile {
+			continue
+		}
+		if err := eIPC.reconcileEgressIP(nil, &egressIP); err != nil {
+			errorAggregate = append(errorAggregate, fmt.Errorf("re-assignment for EgressIP: %s failed after node %s "+
+				"placement change, err: %v", egressIP.Name, nodeName, err))
+		}
+	}
+	if len(errorAggregate) > 0 {
+		return utilerrors.NewAggregate(errorAggregate)
+	}
+	return nil
+}
+
 func (eIPC *egressIPClusterController) addEgressNode(nodeName string) error {
 	var errors []error
 	klog.V(5).Infof("Egress node: %s about to be initialized", nodeName)
@@ -748,6 +948,11 @@ func (eIPC *egressIPClusterController) addEgressNode(nodeName string) error {
 			if err := eIPC.reconcileEgressIP(nil, &egressIP); err != nil {
 				errors = append(errors, fmt.Errorf("synthetic update for EgressIP: %s failed, err: %v", egressIP.Name, err))
 			}
+		} else if isEgressIPStandbyEnabled() && len(egressIP.Status.Standbys) < len(egressIP.Status.Items) {
+			// The node may stand by for the egress IPs without a standby node
+			if err := eIPC.reconcileEgressIPStandbys(&egressIP); err != nil {
+				errors = append(errors, err)
+			}
 		}
 	}
 
@@ -779,8 +984,10 @@ func (eIPC *egressIPClusterController) deleteEgressNode(nodeName string) error {
 		return fmt.Errorf("unable to list EgressIPs, err: %v", err)
 	}
 	for _, egressIP := range egressIPs.Items {
+		assigned := false
 		for _, status := range egressIP.Status.Items {
 			if status.Node == nodeName {
+				assigned = true
 				// Send a "synthetic update" on all egress IPs which have an
 				// assignment to this node. The reconciliation loop for
 				// WatchEgressIP will see that the current assignment status to
@@ -794,6 +1001,18 @@ func (eIPC *egressIPClusterController) deleteEgressNode(nodeName string) error {
 				break
 			}
 		}
+		if assigned || !isEgressIPStandbyEnabled() {
+			continue
+		}
+		// The egress IPs the node stands by for need another standby node
+		for _, standby := range egressIP.Status.Standbys {
+			if standby.Node == nodeName {
+				if err := eIPC.reconcileEgressIPStandbys(&egressIP); err != nil {
+					errorAggregate = append(errorAggregate, err)
+				}
+				break
+			}
+		}
 	}
 	if len(errorAggregate) > 0 {
 		return utilerrors.NewAggregate(errorAggregate)
@@ -814,10 +1033,11 @@ func (eIPC *egressIPClusterController) initEgressIPAllocator(node *v1.Node) (err
 			return fmt.Errorf("unable to use node for egress assignment, err: %v", err)
 		}
 	}
-	nodeSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+	nodeSubnets, err := util.ParseNodeHostSubnets(node, types.DefaultNetworkName, eIPC.nodeNetworkStates)
 	if err != nil {
 		return fmt.Errorf("failed to parse node %s subnets annotation %v", node.Name, err)
 	}
+	nodeSubnets = util.PrimaryHostSubnets(nodeSubnets)
 	mgmtIPs := make([]net.IP, len(nodeSubnets))
 	for i, subnet := range nodeSubnets {
 		mgmtIPs[i] = util.GetNodeManagementIfAddr(subnet).IP
@@ -877,13 +1097,23 @@ func (eIPC *egressIPClusterController) addAllocatorEgressIPAssignments(name stri
 	}
 }
 
-func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.EgressIP) (err error) {
+func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.EgressIP) error {
 	// Lock the assignment, this is needed because this function can end up
 	// being called from WatchEgressNodes and WatchEgressIP, i.e: two different
 	// go-routines and we need to make sure the assignment is safe.
 	eIPC.egressIPAssignmentMutex.Lock()
 	defer eIPC.egressIPAssignmentMutex.Unlock()
+	new, err := eIPC.reconcileEgressIPPool(old, new)
+	if err != nil {
+		return err
+	}
+	return eIPC.reconcileEgressIPAssignment(old, new, nil)
+}
 
+// reconcileEgressIPAssignment reconciles the assignment of the egress IPs,
+// moving the egress IPs of moves, if any, away from the node they map to.
+// Needs to be called with the egressIPAssignmentMutex held.
+func (eIPC *egressIPClusterController) reconcileEgressIPAssignment(old, new *egressipv1.EgressIP, moves map[string]string) (err error) {
 	name := ""
 
 	// Initialize a status which will be used to compare against
@@ -916,6 +1146,10 @@ func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.Eg
 		}
 	}
 
+	// With a cloud provider, the egress IPs being attached to their node are
+	// assigned already
+	status = eIPC.withCloudPendingEgressIPs(name, status)
+
 	// Validate the spec and use only the valid egress IPs when performing any
 	// successive operations, theoretically: the user could specify invalid IP
 	// addresses, which would break us.
@@ -923,19 +1157,32 @@ func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.Eg
 	if err != nil {
 		return fmt.Errorf("invalid EgressIP spec, err: %v", err)
 	}
+	if err := eIPC.validateEgressIPNetwork(name, newEIP.Spec.Network); err != nil {
+		return fmt.Errorf("invalid EgressIP spec, err: %v", err)
+	}
 
 	// Validate the status, on restart it could be the case that what might have
 	// been assigned when ovnkube-master last ran is not a valid assignment
 	// anymore (specifically if ovnkube-master has been crashing for a while).
 	// Any invalid status at this point in time needs to be removed and assigned
 	// to a valid node.
-	validStatus, invalidStatus := eIPC.validateEgressIPStatus(name, status)
+	validStatus, invalidStatus := eIPC.validateEgressIPStatus(name, status, newEIP.Spec.Placement, newEIP.Spec.Network,
+		getEgressIPAssignmentPolicy(newEIP))
+	movedFrom := []string{}
 	for status := range validStatus {
 		// If the spec has changed and an egress IP has been removed by the
 		// user: we need to un-assign that egress IP
 		if !validSpecIPs.Has(status.EgressIP) {
 			invalidStatus[status] = ""
 			delete(validStatus, status)
+			continue
+		}
+		// The egress IPs moved by the rebalancing are re-assigned to another
+		// node
+		if node, ok := moves[status.EgressIP]; ok && node == status.Node {
+			invalidStatus[status] = ""
+			delete(validStatus, status)
+			movedFrom = append(movedFrom, node)
 		}
 	}
 
@@ -946,6 +1193,7 @@ func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.Eg
 
 	// Add only the diff between what is requested and valid and that which
 	// isn't already assigned.
+	requested := validSpecIPs.Len()
 	ipsToAssign := validSpecIPs
 	ipsToRemove := sets.New[string]()
 	statusToAdd := make([]egressipv1.EgressIPStatusItem, 0, len(ipsToAssign))
@@ -990,25 +1238,63 @@ func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.Eg
 		ipsToAssign = ipsToAssign.Intersection(ipsToRemove)
 	}
 
+	// Leave unassigned the egress IPs exceeding the egress IP quota of the
+	// namespaces the EgressIP selects, and report it in its conditions.
+	conditions, conditionsChanged := newEIP.Status.Conditions, false
+	if new != nil {
+		quota, err := eIPC.getEgressIPQuota(newEIP)
+		if err != nil {
+			return err
+		}
+		var overQuota []egressipv1.EgressIPStatusItem
+		statusToKeep, ipsToAssign, overQuota = applyEgressIPQuota(quota, statusToKeep, ipsToAssign)
+		for _, status := range overQuota {
+			statusToRemove = append(statusToRemove, status)
+			ipsToRemove.Insert(status.EgressIP)
+		}
+		conditions, conditionsChanged = egressIPQuotaConditions(newEIP, quota, requested)
+		if exceeded := meta.FindStatusCondition(conditions, egressipv1.EgressIPConditionQuotaExceeded); exceeded != nil && conditionsChanged {
+			eIPRef := v1.ObjectReference{
+				Kind: "EgressIP",
+				Name: name,
+			}
+			eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, egressIPQuotaExceededReason, "EgressIP: %s %s", name, exceeded.Message)
+		}
+	}
+	defer func() {
+		// the egress IPs unassigned may be assigned to the EgressIPs exceeding
+		// their quota
+		if err == nil && (len(statusToRemove) > 0 || new == nil) {
+			eIPC.requeueQuotaExceededEgressIPs(name)
+		}
+	}()
+
 	if !util.PlatformTypeIsEgressIPCloudProvider() {
 		if len(statusToRemove) > 0 {
+			// With a cloud provider, the egress IPs are detached from their
+			// node in the background, without holding back their failover.
+			eIPC.detachCloudEgressIPs(name, statusToRemove)
 			// Delete the statusToRemove from the allocator cache. If we don't
 			// do this we will occupy assignment positions for the ipsToAssign,
 			// even though statusToRemove will be removed afterwards
 			eIPC.deleteAllocatorEgressIPAssignments(statusToRemove)
 		}
 		if len(ipsToAssign) > 0 {
-			statusToAdd = eIPC.assignEgressIPs(name, ipsToAssign.UnsortedList())
+			statusToAdd = eIPC.assignEgressIPs(name, ipsToAssign.UnsortedList(), newEIP.Spec.Placement, newEIP.Spec.Network,
+				movedFrom...)
 			statusToKeep = append(statusToKeep, statusToAdd...)
 		}
 		// Add all assignments which are to be kept to the allocator cache,
 		// allowing us to track all assignments which have been performed and
 		// avoid incorrect future assignments due to a de-synchronized cache.
 		eIPC.addAllocatorEgressIPAssignments(name, statusToKeep)
+		// With a cloud provider, only the egress IPs attached to their node
+		// are added to the status, the others are attached in the background
+		statusToKeep, statusToAdd = eIPC.attachCloudEgressIPs(name, newEIP.Status.Items, statusToKeep, statusToAdd)
 		// Update the object only on an ADD/UPDATE. If we are processing a
 		// DELETE, new will be nil and we should not update the object.
-		if len(statusToAdd) > 0 || (len(statusToRemove) > 0 && new != nil) {
-			if err := eIPC.patchReplaceEgressIPStatus(name, statusToKeep); err != nil {
+		if len(statusToAdd) > 0 || ((len(statusToRemove) > 0 || conditionsChanged) && new != nil) {
+			if err := eIPC.patchEgressIPStatus(name, statusToKeep, conditions); err != nil {
 				return err
 			}
 		}
@@ -1036,10 +1322,14 @@ func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.Eg
 			// Update the object only on an ADD/UPDATE. If we are processing a
 			// DELETE, new will be nil and we should not update the object.
 			if new != nil {
-				if err := eIPC.patchReplaceEgressIPStatus(name, statusToKeep); err != nil {
+				if err := eIPC.patchEgressIPStatus(name, statusToKeep, conditions); err != nil {
 					return err
 				}
 			}
+		} else if new != nil && conditionsChanged {
+			if err := eIPC.patchEgressIPStatus(name, statusToKeep, conditions); err != nil {
+				return err
+			}
 		}
 		// When egress IP is not fully assigned to a node, then statusToRemove may not
 		// have those entries, hence retrieve it from staleEgressIPs for removing
@@ -1062,7 +1352,8 @@ func (eIPC *egressIPClusterController) reconcileEgressIP(old, new *egressipv1.Eg
 		// processing the answer from the requests we make here, and update OVN
 		// accordingly when we know what the outcome is.
 		if len(ipsToAssign) > 0 {
-			statusToAdd = eIPC.assignEgressIPs(name, ipsToAssign.UnsortedList())
+			statusToAdd = eIPC.assignEgressIPs(name, ipsToAssign.UnsortedList(), newEIP.Spec.Placement, newEIP.Spec.Network,
+				movedFrom...)
 			statusToKeep = append(statusToKeep, statusToAdd...)
 		}
 		// Same as above: Add all assignments which are to be kept to the
@@ -1159,7 +1450,10 @@ func (eIPC *egressIPClusterController) getCloudPrivateIPConfigMap(objs []interfa
 // time, this does not guarantee complete balance, but mostly complete.
 // For Egress IPs that are hosted by non-OVN managed networks, there must be at least
 // one node that hosts the network and exposed via the nodes host-addresses annotation.
-func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []string) []egressipv1.EgressIPStatusItem {
+// The egress IPs are not assigned to the excludedNodes, like the nodes they are
+// moved away from.
+func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []string, placement *egressipv1.EgressIPPlacement,
+	network string, excludedNodes ...string) []egressipv1.EgressIPStatusItem {
 	eIPC.allocator.Lock()
 	defer eIPC.allocator.Unlock()
 	assignments := []egressipv1.EgressIPStatusItem{}
@@ -1173,7 +1467,20 @@ func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []
 		klog.Errorf("No assignable nodes found for EgressIP: %s and requested IPs: %v", name, egressIPs)
 		return assignments
 	}
+	if placement != nil {
+		assignableNodes = eIPC.filterEgressNodesByPlacement(name, placement, assignableNodes)
+		if len(assignableNodes) == 0 {
+			eIPRef := v1.ObjectReference{
+				Kind: "EgressIP",
+				Name: name,
+			}
+			eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, "NoMatchingNodeFound", "no assignable nodes satisfy the placement constraints of EgressIP: %s", name)
+			klog.Errorf("No assignable nodes satisfy the placement constraints of EgressIP: %s and requested IPs: %v", name, egressIPs)
+			return assignments
+		}
+	}
 	klog.V(5).Infof("Current assignments are: %+v", existingAllocations)
+	excluded := sets.New[string](excludedNodes...)
 	for _, egressIP := range egressIPs {
 		klog.V(5).Infof("Will attempt assignment for egress IP: %s", egressIP)
 		eIP := net.ParseIP(egressIP)
@@ -1211,7 +1518,7 @@ func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []
 						egressIP, status.Node, err)
 					continue
 				}
-				eipNetwork, err := util.GetEgressIPNetwork(node, eIP)
+				eipNetwork, err := getEgressIPNetwork(node, eIP, network)
 				if err != nil {
 					klog.Errorf("Failed to determine egress IP %s network for node %s: %v", name, node.Name, err)
 					continue
@@ -1243,7 +1550,7 @@ func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []
 			}
 		}
 		// Egress IP for non-OVN managed networks is only available on baremetal environments
-		if !util.PlatformTypeIsEgressIPCloudProvider() {
+		if network == "" && !util.PlatformTypeIsEgressIPCloudProvider() {
 			assignableNodesWithSecondaryNet := make([]*egressNode, 0)
 			for _, eNode := range assignableNodes {
 				node, err := eIPC.watchFactory.GetNode(eNode.name)
@@ -1273,20 +1580,35 @@ func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []
 			}
 		}
 
+		candidateNodes := assignableNodes
+		if network != "" {
+			candidateNodes = eIPC.filterEgressNodesByNetwork(eIP, network, candidateNodes)
+		}
+		if placement != nil && placement.TopologyKey != "" {
+			candidateNodes = eIPC.spreadEgressNodes(name, placement.TopologyKey, candidateNodes)
+		}
+		// the egress IP fails over to its standby node, if any, which has its
+		// SNAT pre-programmed
+		candidateNodes = eIPC.preferEgressIPStandby(name, egressIP, candidateNodes)
+
 		var assignmentSuccessful bool
-		for i := 0; i < len(assignableNodes) && !assignmentSuccessful; i++ {
-			eNode := assignableNodes[i]
+		for i := 0; i < len(candidateNodes) && !assignmentSuccessful; i++ {
+			eNode := candidateNodes[i]
 			klog.V(5).Infof("Attempting assignment on egress node: %+v", eNode)
 			if eNode.getAllocationCountForEgressIP(name) > 0 {
 				klog.V(5).Infof("Node: %s is already in use by another egress IP for this EgressIP: %s, trying another node", eNode.name, name)
 				continue
 			}
+			if excluded.Has(eNode.name) {
+				klog.V(5).Infof("Node: %s is excluded for EgressIP: %s, trying another node", eNode.name, name)
+				continue
+			}
 			node, err := eIPC.watchFactory.GetNode(eNode.name)
 			if err != nil {
 				klog.Errorf("Failed to consider node %s because lookup of kubernetes object failed: %v", eNode.name, err)
 				continue
 			}
-			egressIPNetwork, err := util.GetEgressIPNetwork(node, eIP)
+			egressIPNetwork, err := getEgressIPNetwork(node, eIP, network)
 			if err != nil {
 				klog.Errorf("Failed to consider node %s for EgressIP %s IP %s because unable to find a network to host it: %v",
 					node.Name, name, eIP.String(), err)
@@ -1295,23 +1617,9 @@ func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []
 			if egressIPNetwork == "" {
 				continue
 			}
-			if eNode.egressIPConfig.Capacity.IP < util.UnlimitedNodeCapacity {
-				if eNode.egressIPConfig.Capacity.IP-len(eNode.allocations) <= 0 {
-					klog.V(5).Infof("Additional allocation on Node: %s exhausts it's IP capacity, trying another node", eNode.name)
-					continue
-				}
-			}
-			if eNode.egressIPConfig.Capacity.IPv4 < util.UnlimitedNodeCapacity && utilnet.IsIPv4(eIP) {
-				if eNode.egressIPConfig.Capacity.IPv4-getIPFamilyAllocationCount(eNode.allocations, false) <= 0 {
-					klog.V(5).Infof("Additional allocation on Node: %s exhausts it's IPv4 capacity, trying another node", eNode.name)
-					continue
-				}
-			}
-			if eNode.egressIPConfig.Capacity.IPv6 < util.UnlimitedNodeCapacity && utilnet.IsIPv6(eIP) {
-				if eNode.egressIPConfig.Capacity.IPv6-getIPFamilyAllocationCount(eNode.allocations, true) <= 0 {
-					klog.V(5).Infof("Additional allocation on Node: %s exhausts it's IPv6 capacity, trying another node", eNode.name)
-					continue
-				}
+			if capacity := eNode.exhaustedCapacity(eIP); capacity != "" {
+				klog.V(5).Infof("Additional allocation on Node: %s exhausts it's %s capacity, trying another node", eNode.name, capacity)
+				continue
 			}
 			assignments = append(assignments, egressipv1.EgressIPStatusItem{
 				Node:     eNode.name,
@@ -1343,6 +1651,89 @@ func (eIPC *egressIPClusterController) assignEgressIPs(name string, egressIPs []
 	return assignments
 }
 
+// egressIPPlacementMatchesNode returns whether a node satisfies the placement
+// constraints of an EgressIP
+func egressIPPlacementMatchesNode(placement *egressipv1.EgressIPPlacement, node *v1.Node) (bool, error) {
+	if placement == nil {
+		return true, nil
+	}
+	if len(placement.Zones) > 0 && !sets.New(placement.Zones...).Has(util.GetNodeZone(node)) {
+		return false, nil
+	}
+	if placement.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(placement.NodeSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid node selector: %w", err)
+		}
+		if !selector.Matches(labels.Set(node.Labels)) {
+			return false, nil
+		}
+	}
+	if placement.TopologyKey != "" {
+		if _, ok := node.Labels[placement.TopologyKey]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// filterEgressNodesByPlacement returns the egress nodes satisfying the
+// placement constraints of an EgressIP
+func (eIPC *egressIPClusterController) filterEgressNodesByPlacement(name string, placement *egressipv1.EgressIPPlacement, eNodes []*egressNode) []*egressNode {
+	filtered := make([]*egressNode, 0, len(eNodes))
+	for _, eNode := range eNodes {
+		node, err := eIPC.watchFactory.GetNode(eNode.name)
+		if err != nil {
+			klog.Errorf("Failed to consider node %s for EgressIP %s placement because lookup of kubernetes object failed: %v",
+				eNode.name, name, err)
+			continue
+		}
+		matches, err := egressIPPlacementMatchesNode(placement, node)
+		if err != nil {
+			klog.Errorf("Failed to evaluate the placement constraints of EgressIP %s: %v", name, err)
+			return nil
+		}
+		if matches {
+			filtered = append(filtered, eNode)
+		}
+	}
+	return filtered
+}
+
+// spreadEgressNodes orders the egress nodes so that the nodes of the topology
+// domains hosting the fewest egress IPs of an EgressIP come first, preserving
+// their relative order otherwise. The topology domain of a node is the value of
+// its topologyKey label. Needs to be called with the allocator lock held.
+func (eIPC *egressIPClusterController) spreadEgressNodes(name, topologyKey string, eNodes []*egressNode) []*egressNode {
+	domainOf := func(nodeName string) (string, bool) {
+		node, err := eIPC.watchFactory.GetNode(nodeName)
+		if err != nil {
+			return "", false
+		}
+		domain, ok := node.Labels[topologyKey]
+		return domain, ok
+	}
+	domainAllocations := map[string]int{}
+	for _, eNode := range eIPC.allocator.cache {
+		if count := eNode.getAllocationCountForEgressIP(name); count > 0 {
+			if domain, ok := domainOf(eNode.name); ok {
+				domainAllocations[domain] += count
+			}
+		}
+	}
+	nodeDomainAllocations := make(map[string]int, len(eNodes))
+	for _, eNode := range eNodes {
+		domain, _ := domainOf(eNode.name)
+		nodeDomainAllocations[eNode.name] = domainAllocations[domain]
+	}
+	spread := make([]*egressNode, len(eNodes))
+	copy(spread, eNodes)
+	sort.SliceStable(spread, func(i, j int) bool {
+		return nodeDomainAllocations[spread[i].name] < nodeDomainAllocations[spread[j].name]
+	})
+	return spread
+}
+
 func getIPFamilyAllocationCount(allocations map[string]string, isIPv6 bool) (count int) {
 	for allocation := range allocations {
 		if utilnet.IsIPv4String(allocation) && !isIPv6 {
@@ -1372,6 +1763,64 @@ func (eIPC *egressIPClusterController) validateEgressIPSpec(name string, egressI
 	return validatedEgressIPs, nil
 }
 
+// validateEgressIPNetwork validates the non-OVN managed network selected by
+// the EgressIP, if any
+func (eIPC *egressIPClusterController) validateEgressIPNetwork(name, network string) error {
+	if network == "" {
+		return nil
+	}
+	eIPRef := v1.ObjectReference{
+		Kind: "EgressIP",
+		Name: name,
+	}
+	if _, _, err := net.ParseCIDR(network); err != nil {
+		eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, "InvalidEgressIP", "network: %s for object EgressIP: %s is not a valid CIDR", network, name)
+		return fmt.Errorf("unable to parse provided EgressIP network: %s, invalid", network)
+	}
+	if util.PlatformTypeIsEgressIPCloudProvider() {
+		eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, "UnsupportedRequest", "network: %s for object EgressIP: %s is not supported on cloud platforms", network, name)
+		return fmt.Errorf("EgressIP network %s is not supported on cloud platforms", network)
+	}
+	return nil
+}
+
+// filterEgressNodesByNetwork returns the egress nodes with an address in the
+// non-OVN managed network selected to host the egress IP
+func (eIPC *egressIPClusterController) filterEgressNodesByNetwork(eIP net.IP, network string, eNodes []*egressNode) []*egressNode {
+	filtered := make([]*egressNode, 0, len(eNodes))
+	for _, eNode := range eNodes {
+		node, err := eIPC.watchFactory.GetNode(eNode.name)
+		if err != nil {
+			continue
+		}
+		hostingNetwork, err := getEgressIPNetwork(node, eIP, network)
+		if err != nil {
+			klog.Warningf("Failed to determine if egress IP %s can be hosted by network %s of node %s: %v",
+				eIP.String(), network, node.Name, err)
+			continue
+		}
+		if hostingNetwork != "" {
+			filtered = append(filtered, eNode)
+		}
+	}
+	return filtered
+}
+
+// getEgressIPNetwork returns the network of the node to host the egress IP:
+// the non-OVN managed network selected by the EgressIP if any, provided the
+// IP isn't part of the OVN managed network, else the network containing the
+// IP. An empty network is returned if the node has no such network.
+func getEgressIPNetwork(node *v1.Node, eIP net.IP, network string) (string, error) {
+	if network == "" {
+		return util.GetEgressIPNetwork(node, eIP)
+	}
+	isOVNManagedNetwork, err := util.IsOVNManagedNetwork(node, eIP)
+	if err != nil || isOVNManagedNetwork {
+		return "", err
+	}
+	return util.GetNonOVNNetwork(node, network, eIP)
+}
+
 // isEgressIPAddrConflict iterates through all the nodes in the cluster and ensures that the IP specified by func parameter
 // egressIP is not equal to any existing IP address
 func (eIPC *egressIPClusterController) isEgressIPAddrConflict(egressIP net.IP) (bool, string, error) {
@@ -1397,7 +1846,8 @@ func (eIPC *egressIPClusterController) isEgressIPAddrConflict(egressIP net.IP) (
 // cache knows about all egress nodes. WatchEgressNodes is initialized before
 // any other egress IP handler, so the cache should be warm and correct once we
 // start going this.
-func (eIPC *egressIPClusterController) validateEgressIPStatus(name string, items []egressipv1.EgressIPStatusItem) (map[egressipv1.EgressIPStatusItem]string, map[egressipv1.EgressIPStatusItem]string) {
+func (eIPC *egressIPClusterController) validateEgressIPStatus(name string, items []egressipv1.EgressIPStatusItem, placement *egressipv1.EgressIPPlacement,
+	network string, policy egressipv1.EgressIPAssignmentPolicy) (map[egressipv1.EgressIPStatusItem]string, map[egressipv1.EgressIPStatusItem]string) {
 	eIPC.allocator.Lock()
 	defer eIPC.allocator.Unlock()
 	valid, invalid := make(map[egressipv1.EgressIPStatusItem]string), make(map[egressipv1.EgressIPStatusItem]string)
@@ -1416,11 +1866,13 @@ func (eIPC *egressIPClusterController) validateEgressIPStatus(name string, items
 				klog.Errorf("Allocator error: EgressIP: %s assigned to node: %s which does not have egress label, will attempt rebalancing", name, eIPStatus.Node)
 				validAssignment = false
 			}
-			if !eNode.isReachable {
+			// the egress IPs with the manual assignment policy are pinned to
+			// their node while it is unreachable or not ready
+			if !eNode.isReachable && policy != egressipv1.EgressIPAssignmentManual {
 				klog.Errorf("Allocator error: EgressIP: %s assigned to node: %s which is not reachable, will attempt rebalancing", name, eIPStatus.Node)
 				validAssignment = false
 			}
-			if !eNode.isReady {
+			if !eNode.isReady && policy != egressipv1.EgressIPAssignmentManual {
 				klog.Errorf("Allocator error: EgressIP: %s assigned to node: %s which is not ready, will attempt rebalancing", name, eIPStatus.Node)
 				validAssignment = false
 			}
@@ -1433,19 +1885,36 @@ func (eIPC *egressIPClusterController) validateEgressIPStatus(name string, items
 				klog.Errorf("Allocator error: failed to validate and will not consider node %s for egress IP %s: %v",
 					eNode.name, name, err)
 			}
-			isOVNManaged, err := util.IsOVNManagedNetwork(node, ip)
-			if err != nil {
-				klog.Errorf("Allocator error: failed to determine if IP %q is part of an OVN managed network for "+
-					"egress IP %s: %v", eIPStatus.EgressIP, name, err)
-			}
-			isSecondaryNetwork, err := util.IsNonOVNManagedNetworkContainingIP(node, ip)
-			if err != nil {
-				klog.Errorf("Allocator error: failed to determine if Egress IP %q is to be hosted by a non-OVN managed "+
-					"network for egress IP %s: %v", eIPStatus.EgressIP, name, err)
+			if network != "" {
+				if node == nil {
+					validAssignment = false
+				} else if hostingNetwork, err := getEgressIPNetwork(node, ip, network); err != nil || hostingNetwork == "" {
+					klog.Errorf("Allocator error: EgressIP: %s assigned to node: %s which can't host IP %q on network %s "+
+						"(err: %v), will attempt rebalancing", name, eIPStatus.Node, eIPStatus.EgressIP, network, err)
+					validAssignment = false
+				}
+			} else {
+				isOVNManaged, err := util.IsOVNManagedNetwork(node, ip)
+				if err != nil {
+					klog.Errorf("Allocator error: failed to determine if IP %q is part of an OVN managed network for "+
+						"egress IP %s: %v", eIPStatus.EgressIP, name, err)
+				}
+				isSecondaryNetwork, err := util.IsNonOVNManagedNetworkContainingIP(node, ip)
+				if err != nil {
+					klog.Errorf("Allocator error: failed to determine if Egress IP %q is to be hosted by a non-OVN managed "+
+						"network for egress IP %s: %v", eIPStatus.EgressIP, name, err)
+				}
+				if !isOVNManaged && !isSecondaryNetwork {
+					klog.Errorf("Allocator error: failed to assign Egress IP %s IP %q", name, eIPStatus.EgressIP)
+					validAssignment = false
+				}
 			}
-			if !isOVNManaged && !isSecondaryNetwork {
-				klog.Errorf("Allocator error: failed to assign Egress IP %s IP %q", name, eIPStatus.EgressIP)
-				validAssignment = false
+			if node != nil {
+				if matches, err := egressIPPlacementMatchesNode(placement, node); err != nil || !matches {
+					klog.Errorf("Allocator error: EgressIP: %s assigned to node: %s which does not satisfy its placement "+
+						"constraints (err: %v), will attempt rebalancing", name, eIPStatus.Node, err)
+					validAssignment = false
+				}
 			}
 		}
 		if validAssignment {
@@ -1503,6 +1972,10 @@ func (eIPC *egressIPClusterController) reconcileCloudPrivateIPConfig(old, new *o
 		return nil
 	}
 
+	if new != nil {
+		eIPC.recordCloudAssignmentFailure(old, new)
+	}
+
 	if shouldDelete {
 		// Get the EgressIP owner reference
 		egressIPName, exists := oldCloudPrivateIPConfig.Annotations[util.OVNEgressIPOwnerRefLabel]
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/onsi/ginkgo/extensions/table"
@@ -14,10 +15,13 @@ import (
 	ocpconfigapi "github.com/openshift/api/config/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	egressippoolv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/healthcheck"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"github.com/urfave/cli/v2"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -1571,6 +1575,78 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 			err := app.Run([]string{app.Name})
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
+
+		ginkgo.It("should unassign the egress IPs of a node whose gateway next hops are unreachable", func() {
+			app.Action = func(ctx *cli.Context) error {
+				egressIP := "192.168.126.101"
+				nodeIPv4 := "192.168.126.51/24"
+				node := v1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: node1Name,
+						Annotations: map[string]string{
+							"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\"}", nodeIPv4),
+							"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":[\"%s\"]}", v4NodeSubnet),
+							"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", nodeIPv4),
+						},
+						Labels: map[string]string{
+							"k8s.ovn.org/egress-assignable": "",
+						},
+					},
+					Status: v1.NodeStatus{
+						Conditions: []v1.NodeCondition{
+							{
+								Type:   v1.NodeReady,
+								Status: v1.ConditionTrue,
+							},
+						},
+					},
+				}
+				eIP1 := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP},
+					},
+				}
+				fakeClusterManagerOVN.start(
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP1},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{node},
+					},
+				)
+
+				// Virtually disable background reachability check by using a huge interval
+				fakeClusterManagerOVN.eIPC.reachabilityCheckInterval = time.Hour
+
+				_, err := fakeClusterManagerOVN.eIPC.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getEgressIPStatusLen(eIP1.Name)).Should(gomega.Equal(1))
+
+				// the node stays reachable while its next hops aren't
+				node.Annotations["k8s.ovn.org/egress-next-hops-unreachable"] = "[\"192.168.126.1\"]"
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &node, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(func() bool {
+					return fakeClusterManagerOVN.eIPC.hasUnreachableNextHops(node.Name)
+				}).Should(gomega.BeTrue())
+				checkEgressNodesReachabilityIterate(fakeClusterManagerOVN.eIPC)
+				gomega.Eventually(getEgressIPStatusLen(eIP1.Name)).Should(gomega.Equal(0))
+
+				delete(node.Annotations, "k8s.ovn.org/egress-next-hops-unreachable")
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &node, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(func() bool {
+					return fakeClusterManagerOVN.eIPC.hasUnreachableNextHops(node.Name)
+				}).Should(gomega.BeFalse())
+				checkEgressNodesReachabilityIterate(fakeClusterManagerOVN.eIPC)
+				gomega.Eventually(getEgressIPStatusLen(eIP1.Name)).Should(gomega.Equal(1))
+
+				return nil
+			}
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
 	})
 
 	ginkgo.Context("IPv6 assignment", func() {
@@ -1643,7 +1719,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 						EgressIPs: []string{egressIP},
 					},
 				}
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(egressNode2.name))
 				gomega.Expect(assignedStatuses[0].EgressIP).To(gomega.Equal(net.ParseIP(egressIP).String()))
@@ -1728,7 +1804,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
 
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(2))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(egressNode2.name))
 				gomega.Expect(assignedStatuses[0].EgressIP).To(gomega.Equal(net.ParseIP(egressIP1).String()))
@@ -1815,7 +1891,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node1)).To(gomega.Succeed())
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node2)).To(gomega.Succeed())
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(2))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(egressNode2.name))
 				gomega.Expect(assignedStatuses[0].EgressIP).To(gomega.Equal(net.ParseIP(egressIP1).String()))
@@ -1909,7 +1985,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node1)).To(gomega.Succeed())
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node2)).To(gomega.Succeed())
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(2))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(egressNode2.name))
 				gomega.Expect(assignedStatuses[0].EgressIP).To(gomega.Equal(net.ParseIP(egressIP1NonOVNManaged).String()))
@@ -1995,10 +2071,10 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node1)).To(gomega.Succeed())
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node2)).To(gomega.Succeed())
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(node2Name))
-				assignedStatuses = fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses = fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(node2Name))
 				return nil
@@ -2080,7 +2156,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
 
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(0))
 
 				return nil
@@ -2163,7 +2239,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node1)).To(gomega.Succeed())
 				gomega.Expect(fakeClusterManagerOVN.eIPC.initEgressIPAllocator(&node2)).To(gomega.Succeed())
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(0))
 
 				return nil
@@ -2242,7 +2318,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
 
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(0))
 				return nil
 			}
@@ -2321,7 +2397,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
 
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(0))
 				return nil
 			}
@@ -2400,7 +2476,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
 
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(egressNode2.name))
 				gomega.Expect(assignedStatuses[0].EgressIP).To(gomega.Equal(net.ParseIP(egressIP).String()))
@@ -2481,7 +2557,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
 
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(0))
 				return nil
 			}
@@ -2637,7 +2713,7 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
 				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
 
-				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs)
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(eIP.Name, eIP.Spec.EgressIPs, eIP.Spec.Placement, eIP.Spec.Network)
 				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
 				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(egressNode2.name))
 				gomega.Expect(assignedStatuses[0].EgressIP).To(gomega.Equal(net.ParseIP(egressIP).String()))
@@ -2733,6 +2809,213 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 
 	})
 
+	ginkgo.Context("Placement", func() {
+
+		newPlacementNode := func(name, nodeIPv4, zone string, labels map[string]string) v1.Node {
+			nodeLabels := map[string]string{
+				"k8s.ovn.org/egress-assignable": "",
+			}
+			for key, value := range labels {
+				nodeLabels[key] = value
+			}
+			return v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+					Annotations: map[string]string{
+						"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", nodeIPv4, ""),
+						"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":[\"%s\"]}", v4NodeSubnet),
+						"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", nodeIPv4),
+						"k8s.ovn.org/zone-name":           zone,
+					},
+					Labels: nodeLabels,
+				},
+				Status: v1.NodeStatus{
+					Conditions: []v1.NodeCondition{
+						{
+							Type:   v1.NodeReady,
+							Status: v1.ConditionTrue,
+						},
+					},
+				},
+			}
+		}
+
+		ginkgo.It("should only assign egress IPs to nodes of the placement zones and node selector", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				egressIP1 := "192.168.126.101"
+				egressIP2 := "192.168.126.102"
+				node1IPv4 := "192.168.126.12/24"
+				node2IPv4 := "192.168.126.51/24"
+
+				node1 := newPlacementNode(node1Name, node1IPv4, "az1", map[string]string{"region": "east"})
+				node2 := newPlacementNode(node2Name, node2IPv4, "az2", map[string]string{"region": "west"})
+
+				fakeClusterManagerOVN.start(&v1.NodeList{
+					Items: []v1.Node{node1, node2},
+				})
+
+				egressNode1 := setupNode(node1Name, []string{node1IPv4}, map[string]string{})
+				egressNode2 := setupNode(node2Name, []string{node2IPv4}, map[string]string{})
+
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
+
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(egressIPName, []string{egressIP1},
+					&egressipv1.EgressIPPlacement{Zones: []string{"az2"}}, "")
+				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
+				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(node2Name))
+
+				assignedStatuses = fakeClusterManagerOVN.eIPC.assignEgressIPs(egressIPName2, []string{egressIP2},
+					&egressipv1.EgressIPPlacement{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}}}, "")
+				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
+				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(node1Name))
+
+				assignedStatuses = fakeClusterManagerOVN.eIPC.assignEgressIPs("egressip-3", []string{"192.168.126.103"},
+					&egressipv1.EgressIPPlacement{Zones: []string{"az1"}, NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "west"}}}, "")
+				gomega.Expect(assignedStatuses).To(gomega.BeEmpty())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("should spread egress IPs across the topology domains", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				egressIPs := []string{"192.168.126.101", "192.168.126.102"}
+				node1IPv4 := "192.168.126.12/24"
+				node2IPv4 := "192.168.126.51/24"
+				node3Name := "node3"
+				node3IPv4 := "192.168.126.60/24"
+
+				node1 := newPlacementNode(node1Name, node1IPv4, "global", map[string]string{"topology.kubernetes.io/zone": "a"})
+				node2 := newPlacementNode(node2Name, node2IPv4, "global", map[string]string{"topology.kubernetes.io/zone": "a"})
+				node3 := newPlacementNode(node3Name, node3IPv4, "global", map[string]string{"topology.kubernetes.io/zone": "b"})
+
+				fakeClusterManagerOVN.start(&v1.NodeList{
+					Items: []v1.Node{node1, node2, node3},
+				})
+
+				// node3 hosts the most egress IPs and would be the last one
+				// considered without spreading
+				egressNode1 := setupNode(node1Name, []string{node1IPv4}, map[string]string{})
+				egressNode2 := setupNode(node2Name, []string{node2IPv4}, map[string]string{})
+				egressNode3 := setupNode(node3Name, []string{node3IPv4}, map[string]string{"192.168.126.111": "bogus1", "192.168.126.112": "bogus2"})
+
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode3.name] = &egressNode3
+
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(egressIPName, egressIPs,
+					&egressipv1.EgressIPPlacement{TopologyKey: "topology.kubernetes.io/zone"}, "")
+				gomega.Expect(assignedStatuses).To(gomega.HaveLen(2))
+				assignedNodes := []string{assignedStatuses[0].Node, assignedStatuses[1].Node}
+				gomega.Expect(assignedNodes).To(gomega.ContainElement(node3Name))
+				gomega.Expect(assignedNodes).To(gomega.ContainElement(gomega.BeElementOf(node1Name, node2Name)))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("should invalidate assignments to nodes not satisfying the placement anymore", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				egressIP := "192.168.126.101"
+				node1IPv4 := "192.168.126.12/24"
+				node2IPv4 := "192.168.126.51/24"
+
+				node1 := newPlacementNode(node1Name, node1IPv4, "az1", nil)
+				node2 := newPlacementNode(node2Name, node2IPv4, "az2", nil)
+
+				fakeClusterManagerOVN.start(&v1.NodeList{
+					Items: []v1.Node{node1, node2},
+				})
+
+				egressNode1 := setupNode(node1Name, []string{node1IPv4}, map[string]string{})
+				egressNode2 := setupNode(node2Name, []string{node2IPv4}, map[string]string{})
+
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
+
+				status := []egressipv1.EgressIPStatusItem{
+					{
+						Node:     node1Name,
+						EgressIP: egressIP,
+					},
+				}
+				valid, invalid := fakeClusterManagerOVN.eIPC.validateEgressIPStatus(egressIPName, status,
+					&egressipv1.EgressIPPlacement{Zones: []string{"az1"}}, "", egressipv1.EgressIPAssignmentSticky)
+				gomega.Expect(valid).To(gomega.HaveLen(1))
+				gomega.Expect(invalid).To(gomega.BeEmpty())
+
+				valid, invalid = fakeClusterManagerOVN.eIPC.validateEgressIPStatus(egressIPName, status,
+					&egressipv1.EgressIPPlacement{Zones: []string{"az2"}}, "", egressipv1.EgressIPAssignmentSticky)
+				gomega.Expect(valid).To(gomega.BeEmpty())
+				gomega.Expect(invalid).To(gomega.HaveLen(1))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("should only assign egress IPs to nodes with an address in the selected network", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				egressIP := "192.0.2.10"
+				network := "172.19.0.0/16"
+				node1IPv4 := "192.168.126.12/24"
+				node2IPv4 := "192.168.126.51/24"
+
+				node1 := newPlacementNode(node1Name, node1IPv4, "global", nil)
+				node2 := newPlacementNode(node2Name, node2IPv4, "global", nil)
+				node2.Annotations["k8s.ovn.org/host-addresses"] = fmt.Sprintf("[\"%s\", \"%s\"]", node2IPv4, "172.19.0.5/16")
+
+				fakeClusterManagerOVN.start(&v1.NodeList{
+					Items: []v1.Node{node1, node2},
+				})
+
+				egressNode1 := setupNode(node1Name, []string{node1IPv4}, map[string]string{})
+				egressNode2 := setupNode(node2Name, []string{node2IPv4}, map[string]string{})
+
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
+
+				assignedStatuses := fakeClusterManagerOVN.eIPC.assignEgressIPs(egressIPName, []string{egressIP}, nil, network)
+				gomega.Expect(assignedStatuses).To(gomega.HaveLen(1))
+				gomega.Expect(assignedStatuses[0].Node).To(gomega.Equal(node2Name))
+				gomega.Expect(assignedStatuses[0].Network).To(gomega.Equal("172.19.0.5/16"))
+
+				// egress IPs of the other IP family or part of the OVN managed
+				// network are not hosted by the selected network
+				assignedStatuses = fakeClusterManagerOVN.eIPC.assignEgressIPs(egressIPName2, []string{"2001:db8::10"}, nil, network)
+				gomega.Expect(assignedStatuses).To(gomega.BeEmpty())
+				assignedStatuses = fakeClusterManagerOVN.eIPC.assignEgressIPs(egressIPName2, []string{"192.168.126.101"}, nil, network)
+				gomega.Expect(assignedStatuses).To(gomega.BeEmpty())
+
+				status := []egressipv1.EgressIPStatusItem{
+					{
+						Node:     node1Name,
+						EgressIP: egressIP,
+					},
+				}
+				valid, invalid := fakeClusterManagerOVN.eIPC.validateEgressIPStatus(egressIPName, status, nil, network,
+					egressipv1.EgressIPAssignmentSticky)
+				gomega.Expect(valid).To(gomega.BeEmpty())
+				gomega.Expect(invalid).To(gomega.HaveLen(1))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+	})
+
 	ginkgo.Context("WatchEgressIP", func() {
 
 		ginkgo.It("should update status correctly for single-stack IPv4", func() {
@@ -3484,6 +3767,108 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 
+		ginkgo.It("should reconcile the cloud private ip configs which drifted from the egress IP assignments", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Kubernetes.PlatformType = string(ocpconfigapi.AWSPlatformType)
+				egressIP1 := "192.168.126.101"
+				egressIP2 := "192.168.126.102"
+
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP1},
+					},
+					Status: egressipv1.EgressIPStatus{
+						Items: []egressipv1.EgressIPStatusItem{
+							{
+								EgressIP: egressIP1,
+								Node:     node1Name,
+							},
+						},
+					},
+				}
+				assigned := ocpcloudnetworkapi.CloudPrivateIPConfigStatus{
+					Node:       node2Name,
+					Conditions: []metav1.Condition{{Status: metav1.ConditionTrue, Type: string(ocpcloudnetworkapi.Assigned)}},
+				}
+				// the cloud private ip config of egressIP1 was moved to node2
+				// out of band
+				moved := ocpcloudnetworkapi.CloudPrivateIPConfig{
+					ObjectMeta: newCloudPrivateIPConfigMeta(egressIP1),
+					Spec:       ocpcloudnetworkapi.CloudPrivateIPConfigSpec{Node: node2Name},
+					Status:     assigned,
+				}
+				moved.Annotations = map[string]string{util.OVNEgressIPOwnerRefLabel: egressIPName}
+				// egressIP2 was removed from the EgressIP but its cloud private
+				// ip config was left behind
+				orphan := ocpcloudnetworkapi.CloudPrivateIPConfig{
+					ObjectMeta: newCloudPrivateIPConfigMeta(egressIP2),
+					Spec:       ocpcloudnetworkapi.CloudPrivateIPConfigSpec{Node: node2Name},
+					Status:     assigned,
+				}
+				orphan.Annotations = map[string]string{util.OVNEgressIPOwnerRefLabel: egressIPName}
+				fakeClusterManagerOVN.start(
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP},
+					},
+					&ocpcloudnetworkapi.CloudPrivateIPConfigList{
+						Items: []ocpcloudnetworkapi.CloudPrivateIPConfig{moved, orphan},
+					},
+				)
+
+				err := fakeClusterManagerOVN.eIPC.reconcileCloudPrivateIPConfigDrift()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				cloudPrivateIPConfigs := fakeClusterManagerOVN.fakeClient.CloudNetworkClient.CloudV1().CloudPrivateIPConfigs()
+				cloudPrivateIPConfig, err := cloudPrivateIPConfigs.Get(context.TODO(), moved.Name, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(cloudPrivateIPConfig.Spec.Node).To(gomega.Equal(node1Name))
+				_, err = cloudPrivateIPConfigs.Get(context.TODO(), orphan.Name, metav1.GetOptions{})
+				gomega.Expect(apierrors.IsNotFound(err)).To(gomega.BeTrue())
+				gomega.Expect(fakeClusterManagerOVN.eIPC.pendingCloudPrivateIPConfigsOps[egressIPName]).To(gomega.HaveLen(2))
+				return nil
+			}
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("should surface the cloud assignment failures on the egress IP", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Kubernetes.PlatformType = string(ocpconfigapi.AWSPlatformType)
+				egressIP1 := "192.168.126.101"
+
+				pending := ocpcloudnetworkapi.CloudPrivateIPConfig{
+					ObjectMeta: newCloudPrivateIPConfigMeta(egressIP1),
+					Spec:       ocpcloudnetworkapi.CloudPrivateIPConfigSpec{Node: node1Name},
+					Status: ocpcloudnetworkapi.CloudPrivateIPConfigStatus{
+						Conditions: []metav1.Condition{{Status: metav1.ConditionUnknown, Type: string(ocpcloudnetworkapi.Assigned)}},
+					},
+				}
+				pending.Annotations = map[string]string{util.OVNEgressIPOwnerRefLabel: egressIPName}
+				failed := pending.DeepCopy()
+				failed.Status.Conditions = []metav1.Condition{{
+					Status:  metav1.ConditionFalse,
+					Type:    string(ocpcloudnetworkapi.Assigned),
+					Message: "PrivateIpAddressLimitExceeded: Number of private addresses will exceed limit",
+				}}
+				fakeClusterManagerOVN.start()
+
+				err := fakeClusterManagerOVN.eIPC.reconcileCloudPrivateIPConfig(&pending, failed)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				recordedEvent := <-fakeClusterManagerOVN.fakeRecorder.Events
+				gomega.Expect(recordedEvent).To(gomega.ContainSubstring("CloudQuotaExceeded"))
+				gomega.Expect(recordedEvent).To(gomega.ContainSubstring("PrivateIpAddressLimitExceeded"))
+
+				// the same failure is not reported twice
+				err = fakeClusterManagerOVN.eIPC.reconcileCloudPrivateIPConfig(failed, failed.DeepCopy())
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Consistently(fakeClusterManagerOVN.fakeRecorder.Events).ShouldNot(gomega.Receive())
+				return nil
+			}
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
 		ginkgo.It("ensure failover egressIP status is updated properly while cloud private ip config update in progress", func() {
 			app.Action = func(ctx *cli.Context) error {
 				config.OVNKubernetesFeature.EnableInterconnect = true
@@ -3982,4 +4367,741 @@ var _ = ginkgo.Describe("OVN cluster-manager EgressIP Operations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 	})
-})
+
+	ginkgo.Context("EgressIP quota", func() {
+
+		ginkgo.It("should leave the egress IPs exceeding the namespace quota unassigned", func() {
+			app.Action = func(ctx *cli.Context) error {
+				egressIP1 := "192.168.126.101"
+				egressIP2 := "192.168.126.102"
+				node1IPv4 := "192.168.126.12/24"
+				node2IPv4 := "192.168.126.51/24"
+
+				egressNamespace := newNamespace(namespace)
+				egressNamespace.Annotations[util.EgressIPQuotaAnnotation] = "1"
+				node1 := v1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: node1Name,
+						Annotations: map[string]string{
+							"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", node1IPv4, ""),
+							"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4NodeSubnet),
+							"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", node1IPv4),
+						},
+						Labels: map[string]string{
+							"k8s.ovn.org/egress-assignable": "",
+						},
+					},
+					Status: v1.NodeStatus{
+						Conditions: []v1.NodeCondition{
+							{
+								Type:   v1.NodeReady,
+								Status: v1.ConditionTrue,
+							},
+						},
+					},
+				}
+				node2 := v1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: node2Name,
+						Annotations: map[string]string{
+							"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", node2IPv4, ""),
+							"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4NodeSubnet),
+							"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", node2IPv4),
+						},
+						Labels: map[string]string{
+							"k8s.ovn.org/egress-assignable": "",
+						},
+					},
+					Status: v1.NodeStatus{
+						Conditions: []v1.NodeCondition{
+							{
+								Type:   v1.NodeReady,
+								Status: v1.ConditionTrue,
+							},
+						},
+					},
+				}
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP1, egressIP2},
+						NamespaceSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"name": egressNamespace.Name,
+							},
+						},
+					},
+				}
+				fakeClusterManagerOVN.start(
+					&egressipv1.EgressIPList{Items: []egressipv1.EgressIP{eIP}},
+					&v1.NodeList{Items: []v1.Node{node1, node2}},
+					&v1.NamespaceList{Items: []v1.Namespace{*egressNamespace}},
+				)
+
+				_, err := fakeClusterManagerOVN.eIPC.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				_, err = fakeClusterManagerOVN.eIPC.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				_, err = fakeClusterManagerOVN.eIPC.WatchEgressIPQuotas()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				quotaExceeded := func() bool {
+					eIP, err := fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					return meta.IsStatusConditionTrue(eIP.Status.Conditions, egressipv1.EgressIPConditionQuotaExceeded)
+				}
+				gomega.Eventually(quotaExceeded).Should(gomega.BeTrue())
+				gomega.Eventually(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(1))
+				gomega.Consistently(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(1))
+
+				// raising the quota assigns the other egress IP
+				egressNamespace.Annotations[util.EgressIPQuotaAnnotation] = "2"
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), egressNamespace, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Eventually(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(2))
+				gomega.Eventually(quotaExceeded).Should(gomega.BeFalse())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIP assignment policy", func() {
+
+		newEgressNode := func(name, nodeIPv4 string) v1.Node {
+			return v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+					Annotations: map[string]string{
+						"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", nodeIPv4, ""),
+						"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4NodeSubnet),
+						"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", nodeIPv4),
+					},
+					Labels: map[string]string{
+						"k8s.ovn.org/egress-assignable": "",
+					},
+				},
+				Status: v1.NodeStatus{
+					Conditions: []v1.NodeCondition{
+						{
+							Type:   v1.NodeReady,
+							Status: v1.ConditionTrue,
+						},
+					},
+				},
+			}
+		}
+
+		ginkgo.It("should only move the egress IPs with the balanced assignment policy, at the rate limit", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				egressIP1 := "192.168.126.101"
+				egressIP2 := "192.168.126.102"
+				egressIP3 := "192.168.126.103"
+				node1IPv4 := "192.168.126.12/24"
+				node2IPv4 := "192.168.126.51/24"
+				node1 := newEgressNode(node1Name, node1IPv4)
+				node2 := newEgressNode(node2Name, node2IPv4)
+
+				newAssignedEgressIP := func(name, egressIP string, policy egressipv1.EgressIPAssignmentPolicy) egressipv1.EgressIP {
+					return egressipv1.EgressIP{
+						ObjectMeta: newEgressIPMeta(name),
+						Spec: egressipv1.EgressIPSpec{
+							EgressIPs:        []string{egressIP},
+							AssignmentPolicy: policy,
+						},
+						Status: egressipv1.EgressIPStatus{
+							Items: []egressipv1.EgressIPStatusItem{
+								{
+									Node:     node1Name,
+									EgressIP: egressIP,
+									Network:  "192.168.126.0/24",
+								},
+							},
+						},
+					}
+				}
+				// the sticky egress IP is the first one considered
+				eIP1 := newAssignedEgressIP(egressIPName, egressIP1, egressipv1.EgressIPAssignmentSticky)
+				eIP2 := newAssignedEgressIP(egressIPName2, egressIP2, egressipv1.EgressIPAssignmentBalanced)
+				eIP3 := newAssignedEgressIP("egressip-3", egressIP3, egressipv1.EgressIPAssignmentBalanced)
+
+				fakeClusterManagerOVN.start(
+					&egressipv1.EgressIPList{Items: []egressipv1.EgressIP{eIP1, eIP2, eIP3}},
+					&v1.NodeList{Items: []v1.Node{node1, node2}},
+				)
+
+				egressNode1 := setupNode(node1Name, []string{node1IPv4}, map[string]string{
+					egressIP1: eIP1.Name,
+					egressIP2: eIP2.Name,
+					egressIP3: eIP3.Name,
+				})
+				egressNode2 := setupNode(node2Name, []string{node2IPv4}, map[string]string{})
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode2.name] = &egressNode2
+
+				getEgressIPNodes := func(name string) []string {
+					eIP, err := fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), name, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					nodes := []string{}
+					for _, status := range eIP.Status.Items {
+						nodes = append(nodes, status.Node)
+					}
+					return nodes
+				}
+
+				moved, err := fakeClusterManagerOVN.eIPC.rebalanceEgressIPs(1)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(moved).To(gomega.Equal(1))
+				gomega.Expect(getEgressIPNodes(eIP1.Name)).To(gomega.Equal([]string{node1Name}))
+				gomega.Expect(getEgressIPNodes(eIP2.Name)).To(gomega.Equal([]string{node2Name}))
+				gomega.Expect(getEgressIPNodes(eIP3.Name)).To(gomega.Equal([]string{node1Name}))
+
+				// the nodes host 2 and 1 egress IPs, which is balanced
+				gomega.Eventually(func() int {
+					moved, err := fakeClusterManagerOVN.eIPC.rebalanceEgressIPs(1)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					return moved
+				}).Should(gomega.Equal(0))
+				gomega.Expect(getEgressIPNodes(eIP1.Name)).To(gomega.Equal([]string{node1Name}))
+				gomega.Expect(getEgressIPNodes(eIP3.Name)).To(gomega.Equal([]string{node1Name}))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("should keep the egress IPs with the manual assignment policy on unreachable nodes", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				egressIP := "192.168.126.101"
+				node1IPv4 := "192.168.126.12/24"
+				node1 := newEgressNode(node1Name, node1IPv4)
+
+				fakeClusterManagerOVN.start(&v1.NodeList{Items: []v1.Node{node1}})
+
+				egressNode1 := setupNode(node1Name, []string{node1IPv4}, map[string]string{egressIP: egressIPName})
+				egressNode1.isReachable = false
+				fakeClusterManagerOVN.eIPC.allocator.cache[egressNode1.name] = &egressNode1
+
+				status := []egressipv1.EgressIPStatusItem{
+					{
+						Node:     node1Name,
+						EgressIP: egressIP,
+					},
+				}
+				valid, invalid := fakeClusterManagerOVN.eIPC.validateEgressIPStatus(egressIPName, status, nil, "",
+					egressipv1.EgressIPAssignmentManual)
+				gomega.Expect(valid).To(gomega.HaveLen(1))
+				gomega.Expect(invalid).To(gomega.BeEmpty())
+
+				valid, invalid = fakeClusterManagerOVN.eIPC.validateEgressIPStatus(egressIPName, status, nil, "",
+					egressipv1.EgressIPAssignmentSticky)
+				gomega.Expect(valid).To(gomega.BeEmpty())
+				gomega.Expect(invalid).To(gomega.HaveLen(1))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIPPool", func() {
+
+		ginkgo.It("should allocate the egress IPs of the EgressIPs from the pool", func() {
+			app.Action = func(ctx *cli.Context) error {
+				node1IPv4 := "192.168.126.12/24"
+
+				node1 := v1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: node1Name,
+						Annotations: map[string]string{
+							"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", node1IPv4, ""),
+							"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4NodeSubnet),
+							"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", node1IPv4),
+						},
+						Labels: map[string]string{
+							"k8s.ovn.org/egress-assignable": "",
+						},
+					},
+					Status: v1.NodeStatus{
+						Conditions: []v1.NodeCondition{
+							{
+								Type:   v1.NodeReady,
+								Status: v1.ConditionTrue,
+							},
+						},
+					},
+				}
+				pool := egressippoolv1.EgressIPPool{
+					ObjectMeta: metav1.ObjectMeta{Name: "pool"},
+					Spec: egressippoolv1.EgressIPPoolSpec{
+						// 192.168.126.101 and 192.168.126.102 can be allocated
+						CIDRs:       []string{"192.168.126.96/29"},
+						ExcludedIPs: []string{"192.168.126.97", "192.168.126.98", "192.168.126.99", "192.168.126.100"},
+					},
+				}
+				eIP1 := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						FromPool: &egressipv1.EgressIPFromPool{Name: pool.Name},
+					},
+				}
+				eIP2 := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName2),
+					Spec: egressipv1.EgressIPSpec{
+						FromPool: &egressipv1.EgressIPFromPool{Name: pool.Name, Count: 2},
+					},
+				}
+				fakeClusterManagerOVN.start(
+					&egressipv1.EgressIPList{Items: []egressipv1.EgressIP{eIP1}},
+					&egressippoolv1.EgressIPPoolList{Items: []egressippoolv1.EgressIPPool{pool}},
+					&v1.NodeList{Items: []v1.Node{node1}},
+				)
+
+				err := fakeClusterManagerOVN.eIPC.WatchEgressIPPools()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				_, err = fakeClusterManagerOVN.eIPC.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				_, err = fakeClusterManagerOVN.eIPC.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				getSpecEgressIPs := func(name string) func() []string {
+					return func() []string {
+						eIP, err := fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), name, metav1.GetOptions{})
+						gomega.Expect(err).NotTo(gomega.HaveOccurred())
+						return eIP.Spec.EgressIPs
+					}
+				}
+				getPoolStatus := func() egressippoolv1.EgressIPPoolStatus {
+					pool, err := fakeClusterManagerOVN.fakeClient.EgressIPPoolClient.K8sV1().EgressIPPools().Get(context.TODO(), pool.Name, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					return pool.Status
+				}
+				exhausted := func() bool {
+					return meta.IsStatusConditionTrue(getPoolStatus().Conditions, egressippoolv1.EgressIPPoolConditionExhausted)
+				}
+
+				gomega.Eventually(getSpecEgressIPs(egressIPName)).Should(gomega.Equal([]string{"192.168.126.101"}))
+				gomega.Eventually(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(1))
+				egressIPs, nodes, _ := getEgressIPStatus(egressIPName)
+				gomega.Expect(egressIPs).To(gomega.Equal([]string{"192.168.126.101"}))
+				gomega.Expect(nodes).To(gomega.Equal([]string{node1.Name}))
+				gomega.Eventually(func() int { return getPoolStatus().Allocated }).Should(gomega.Equal(1))
+				gomega.Expect(getPoolStatus().Size).To(gomega.Equal(int64(2)))
+
+				// the pool has a single free egress IP left for the two requested
+				_, err = fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Create(context.TODO(), &eIP2, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getSpecEgressIPs(egressIPName2)).Should(gomega.Equal([]string{"192.168.126.102"}))
+				gomega.Eventually(exhausted).Should(gomega.BeTrue())
+				gomega.Expect(getPoolStatus().Allocations).To(gomega.Equal([]egressippoolv1.EgressIPPoolAllocation{
+					{EgressIP: egressIPName, IPs: []string{"192.168.126.101"}},
+					{EgressIP: egressIPName2, IPs: []string{"192.168.126.102"}},
+				}))
+
+				// the egress IP released by the deleted EgressIP is allocated
+				// to the EgressIP left short
+				err = fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Delete(context.TODO(), egressIPName, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getSpecEgressIPs(egressIPName2)).Should(gomega.Equal([]string{"192.168.126.101", "192.168.126.102"}))
+				gomega.Eventually(exhausted).Should(gomega.BeFalse())
+				gomega.Expect(getPoolStatus().Allocations).To(gomega.Equal([]egressippoolv1.EgressIPPoolAllocation{
+					{EgressIP: egressIPName2, IPs: []string{"192.168.126.101", "192.168.126.102"}},
+				}))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIP status", func() {
+
+		ginkgo.It("should report the interfaces and the transitions of the egress IPs", func() {
+			app.Action = func(ctx *cli.Context) error {
+				egressIP := "192.168.126.101"
+				node1IPv4 := "192.168.126.12/24"
+				node2IPv4 := "192.168.126.51/24"
+
+				newNode := func(name, nodeIPv4 string) v1.Node {
+					return v1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: name,
+							Annotations: map[string]string{
+								"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", nodeIPv4, ""),
+								"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4NodeSubnet),
+								"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", nodeIPv4),
+							},
+							Labels: map[string]string{
+								"k8s.ovn.org/egress-assignable": "",
+							},
+						},
+						Status: v1.NodeStatus{
+							Conditions: []v1.NodeCondition{
+								{
+									Type:   v1.NodeReady,
+									Status: v1.ConditionTrue,
+								},
+							},
+						},
+					}
+				}
+				node1 := newNode(node1Name, node1IPv4)
+				node2 := newNode(node2Name, node2IPv4)
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP},
+					},
+				}
+				fakeClusterManagerOVN.start(
+					&egressipv1.EgressIPList{Items: []egressipv1.EgressIP{eIP}},
+					&v1.NodeList{Items: []v1.Node{node1}},
+				)
+
+				_, err := fakeClusterManagerOVN.eIPC.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				_, err = fakeClusterManagerOVN.eIPC.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				getStatus := func() egressipv1.EgressIPStatus {
+					eIP, err := fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					return eIP.Status
+				}
+				degraded := func() bool {
+					return meta.IsStatusConditionTrue(getStatus().Conditions, egressipv1.EgressIPConditionDegraded)
+				}
+				getTransitions := func() [][]string {
+					var transitions [][]string
+					for _, transition := range getStatus().Transitions {
+						transitions = append(transitions, []string{transition.EgressIP, transition.FromNode, transition.ToNode})
+					}
+					return transitions
+				}
+
+				// the egress IP is degraded until node1 reports it programmed
+				gomega.Eventually(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(1))
+				gomega.Eventually(degraded).Should(gomega.BeTrue())
+				gomega.Expect(getStatus().Interfaces).To(gomega.BeEmpty())
+				gomega.Expect(getTransitions()).To(gomega.Equal([][]string{{egressIP, "", node1.Name}}))
+				gomega.Expect(getStatus().LastFailoverTime).To(gomega.BeNil())
+
+				node1.Annotations["k8s.ovn.org/egress-ip-interfaces"] = fmt.Sprintf("{\"%s\":\"breth0\"}", egressIP)
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &node1, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(degraded).Should(gomega.BeFalse())
+				gomega.Expect(getStatus().Interfaces).To(gomega.Equal([]egressipv1.EgressIPInterfaceStatus{
+					{EgressIP: egressIP, Node: node1.Name, Interface: "breth0"},
+				}))
+
+				// the egress IP fails over to node2 once node1 is not ready
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Create(context.TODO(), &node2, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(isEgressAssignableNode(node2.Name)).Should(gomega.BeTrue())
+				node1.Status.Conditions[0].Status = v1.ConditionFalse
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &node1, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getTransitions).Should(gomega.Equal([][]string{
+					{egressIP, "", node1.Name},
+					{egressIP, node1.Name, node2.Name},
+				}))
+				gomega.Expect(getStatus().LastFailoverTime).NotTo(gomega.BeNil())
+				gomega.Expect(degraded()).To(gomega.BeTrue())
+				gomega.Expect(getStatus().Interfaces).To(gomega.BeEmpty())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIP standby", func() {
+
+		ginkgo.It("should give the egress IPs a standby node and fail them over to it", func() {
+			config.OVNKubernetesFeature.EnableEgressIPStandby = true
+			app.Action = func(ctx *cli.Context) error {
+				egressIP := "192.168.126.101"
+				node3Name := "node3"
+
+				newNode := func(name, nodeIPv4 string) v1.Node {
+					return v1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: name,
+							Annotations: map[string]string{
+								"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", nodeIPv4, ""),
+								"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4NodeSubnet),
+								"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", nodeIPv4),
+							},
+							Labels: map[string]string{
+								"k8s.ovn.org/egress-assignable": "",
+							},
+						},
+						Status: v1.NodeStatus{
+							Conditions: []v1.NodeCondition{
+								{
+									Type:   v1.NodeReady,
+									Status: v1.ConditionTrue,
+								},
+							},
+						},
+					}
+				}
+				nodes := map[string]v1.Node{
+					node1Name: newNode(node1Name, "192.168.126.12/24"),
+					node2Name: newNode(node2Name, "192.168.126.51/24"),
+					node3Name: newNode(node3Name, "192.168.126.52/24"),
+				}
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP},
+					},
+				}
+				fakeClusterManagerOVN.start(
+					&egressipv1.EgressIPList{Items: []egressipv1.EgressIP{eIP}},
+					&v1.NodeList{Items: []v1.Node{nodes[node1Name], nodes[node2Name]}},
+				)
+
+				_, err := fakeClusterManagerOVN.eIPC.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				_, err = fakeClusterManagerOVN.eIPC.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				// getNodes returns the egress node and the standby node of the
+				// egress IP
+				getNodes := func() []string {
+					eIP, err := fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					if len(eIP.Status.Items) != 1 || len(eIP.Status.Standbys) != 1 {
+						return nil
+					}
+					gomega.Expect(eIP.Status.Standbys[0].EgressIP).To(gomega.Equal(egressIP))
+					return []string{eIP.Status.Items[0].Node, eIP.Status.Standbys[0].Node}
+				}
+
+				// the other egress node stands by for the egress IP
+				gomega.Eventually(getNodes).Should(gomega.HaveLen(2))
+				active, standby := getNodes()[0], getNodes()[1]
+				gomega.Expect([]string{active, standby}).To(gomega.ConsistOf(node1Name, node2Name))
+
+				// the standby node is kept when another egress node shows up
+				node3 := nodes[node3Name]
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Create(context.TODO(), &node3, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(isEgressAssignableNode(node3Name)).Should(gomega.BeTrue())
+				gomega.Consistently(getNodes).Should(gomega.Equal([]string{active, standby}))
+
+				// the egress IP fails over to its standby node, which the
+				// remaining egress node then stands by for
+				activeNode := nodes[active]
+				activeNode.Status.Conditions[0].Status = v1.ConditionFalse
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &activeNode, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getNodes).Should(gomega.Equal([]string{standby, node3Name}))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIP cloud provider", func() {
+
+		ginkgo.It("should attach the egress IPs to their node before adding them to the status", func() {
+			app.Action = func(ctx *cli.Context) error {
+				egressIP := "192.168.126.101"
+
+				newNode := func(name, nodeIPv4 string) v1.Node {
+					return v1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: name,
+							Annotations: map[string]string{
+								"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", nodeIPv4, ""),
+								"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4NodeSubnet),
+								"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", nodeIPv4),
+							},
+							Labels: map[string]string{
+								"k8s.ovn.org/egress-assignable": "",
+							},
+						},
+						Spec: v1.NodeSpec{
+							ProviderID: "gce://project/us-central1-a/" + name,
+						},
+						Status: v1.NodeStatus{
+							Conditions: []v1.NodeCondition{
+								{
+									Type:   v1.NodeReady,
+									Status: v1.ConditionTrue,
+								},
+							},
+						},
+					}
+				}
+				nodes := map[string]v1.Node{
+					node1Name: newNode(node1Name, "192.168.126.12/24"),
+					node2Name: newNode(node2Name, "192.168.126.51/24"),
+				}
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP},
+					},
+				}
+				fakeClusterManagerOVN.start(
+					&v1.NodeList{Items: []v1.Node{nodes[node1Name], nodes[node2Name]}},
+				)
+				provider := &fakeCloudProvider{attached: map[string]string{}, failing: true}
+				fakeClusterManagerOVN.eIPC.cloudProvider = provider
+				fakeClusterManagerOVN.eIPC.runCloudEgressIPWorkers()
+
+				_, err := fakeClusterManagerOVN.eIPC.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				_, err = fakeClusterManagerOVN.eIPC.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(isEgressAssignableNode(node1Name)).Should(gomega.BeTrue())
+				gomega.Eventually(isEgressAssignableNode(node2Name)).Should(gomega.BeTrue())
+
+				// the egress IP the cloud provider fails to attach is not
+				// added to the status
+				_, err = fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Create(context.TODO(), &eIP, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				recordedEvent := <-fakeClusterManagerOVN.fakeRecorder.Events
+				gomega.Expect(recordedEvent).To(gomega.ContainSubstring("CloudAssignmentFailed"))
+				gomega.Consistently(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(0))
+
+				// it is once the attachment is retried successfully
+				provider.setFailing(false)
+				gomega.Eventually(getEgressIPStatusLen(egressIPName), 5*time.Second).Should(gomega.Equal(1))
+				egressIPs, nodeNames, _ := getEgressIPStatus(egressIPName)
+				gomega.Expect(egressIPs).To(gomega.Equal([]string{egressIP}))
+				gomega.Expect(provider.getAttached()).To(gomega.Equal(map[string]string{egressIP: nodeNames[0]}))
+
+				// the egress IP is detached from its node when it moves away
+				// from it, and added back to the status once attached to the
+				// other node
+				active := nodes[nodeNames[0]]
+				standby := node1Name
+				if active.Name == node1Name {
+					standby = node2Name
+				}
+				active.Status.Conditions[0].Status = v1.ConditionFalse
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &active, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(func() []string {
+					_, nodeNames, _ := getEgressIPStatus(egressIPName)
+					return nodeNames
+				}, 5*time.Second).Should(gomega.Equal([]string{standby}))
+				gomega.Expect(provider.getAttached()).To(gomega.Equal(map[string]string{egressIP: standby}))
+
+				// a detachment failing doesn't hold back the failover
+				provider.setDetachFailing(true)
+				standbyNode := nodes[standby]
+				standbyNode.Status.Conditions[0].Status = v1.ConditionFalse
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &standbyNode, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(0))
+				gomega.Eventually(fakeClusterManagerOVN.fakeRecorder.Events).Should(gomega.Receive(gomega.ContainSubstring("CloudDeletionFailed")))
+				provider.setDetachFailing(false)
+				active.Status.Conditions[0].Status = v1.ConditionTrue
+				_, err = fakeClusterManagerOVN.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), &active, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(func() []string {
+					_, nodeNames, _ := getEgressIPStatus(egressIPName)
+					return nodeNames
+				}, 10*time.Second).Should(gomega.Equal([]string{active.Name}))
+				gomega.Expect(provider.getAttached()).To(gomega.Equal(map[string]string{egressIP: active.Name}))
+
+				// and when the EgressIP is deleted
+				err = fakeClusterManagerOVN.fakeClient.EgressIPClient.K8sV1().EgressIPs().Delete(context.TODO(), egressIPName, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(provider.getAttached).Should(gomega.BeEmpty())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("should fail to start with an unknown cloud provider", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.OVNKubernetesFeature.EgressIPCloudProvider = "unknown"
+				fakeClusterManagerOVN.start()
+				err := fakeClusterManagerOVN.eIPC.Start()
+				gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(`unknown egress IP cloud provider "unknown"`)))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+})
+
+// fakeCloudProvider tracks the nodes the egress IPs are attached to
+type fakeCloudProvider struct {
+	sync.Mutex
+	attached      map[string]string
+	failing       bool
+	detachFailing bool
+}
+
+func (p *fakeCloudProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeCloudProvider) AttachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.failing {
+		return fmt.Errorf("cloud API unavailable")
+	}
+	if attachedTo, ok := p.attached[ip.String()]; ok && attachedTo != node.Name {
+		return fmt.Errorf("%s is attached to %s", ip, attachedTo)
+	}
+	p.attached[ip.String()] = node.Name
+	return nil
+}
+
+func (p *fakeCloudProvider) DetachPrivateIP(ctx context.Context, node *v1.Node, ip net.IP) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.detachFailing {
+		return fmt.Errorf("cloud API unavailable")
+	}
+	if p.attached[ip.String()] == node.Name {
+		delete(p.attached, ip.String())
+	}
+	return nil
+}
+
+func (p *fakeCloudProvider) setFailing(failing bool) {
+	p.Lock()
+	defer p.Unlock()
+	p.failing = failing
+}
+
+func (p *fakeCloudProvider) setDetachFailing(failing bool) {
+	p.Lock()
+	defer p.Unlock()
+	p.detachFailing = failing
+}
+
+func (p *fakeCloudProvider) getAttached() map[string]string {
+	p.Lock()
+	defer p.Unlock()
+	attached := make(map[string]string, len(p.attached))
+	for ip, node := range p.attached {
+		attached[ip] = node
+	}
+	return attached
+}
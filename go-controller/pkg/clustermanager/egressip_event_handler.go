@@ -76,7 +76,15 @@ func (h *egressIPClusterControllerEventHandler) UpdateResource(oldObj, newObj in
 	case factory.EgressIPType:
 		oldEIP := oldObj.(*egressipv1.EgressIP)
 		newEIP := newObj.(*egressipv1.EgressIP)
-		return h.eIPC.reconcileEgressIP(oldEIP, newEIP)
+		if err := h.eIPC.reconcileEgressIP(oldEIP, newEIP); err != nil {
+			return err
+		}
+		// the egress nodes might have reported the interfaces of the egress
+		// IPs before the status was seen
+		if !reflect.DeepEqual(oldEIP.Status.Items, newEIP.Status.Items) {
+			return h.eIPC.reconcileEgressIPInterfaces(newEIP.Name)
+		}
+		return nil
 	case factory.EgressNodeType:
 		oldNode := oldObj.(*v1.Node)
 		newNode := newObj.(*v1.Node)
@@ -88,6 +96,11 @@ func (h *egressIPClusterControllerEventHandler) UpdateResource(oldObj, newObj in
 		if err := h.eIPC.initEgressIPAllocator(newNode); err != nil {
 			klog.Warningf("Egress node initialization error: %v", err)
 		}
+		if util.NodeEgressIPInterfacesAnnotationChanged(oldNode, newNode) {
+			if err := h.eIPC.updateEgressIPInterfaces(newNode.Name); err != nil {
+				return err
+			}
+		}
 		nodeEgressLabel := util.GetNodeEgressLabel()
 		oldLabels := oldNode.GetLabels()
 		newLabels := newNode.GetLabels()
@@ -122,6 +135,12 @@ func (h *egressIPClusterControllerEventHandler) UpdateResource(oldObj, newObj in
 			}
 			return nil
 		}
+		isPlacementAltered := !reflect.DeepEqual(oldLabels, newLabels) || util.NodeZoneAnnotationChanged(oldNode, newNode)
+		if isNewReady && isNewReachable && isPlacementAltered {
+			if err := h.eIPC.reconcilePlacedEgressIPs(newNode.Name); err != nil {
+				return fmt.Errorf("failed to reconsider egress IPs with placement constraints: %v", err)
+			}
+		}
 		if isOldReady == isNewReady && !isHostAddrAltered {
 			return nil
 		}
@@ -189,7 +208,7 @@ func (h *egressIPClusterControllerEventHandler) SyncFunc(objs []interface{}) err
 	} else {
 		switch h.objType {
 		case factory.EgressIPType:
-			syncFunc = nil
+			syncFunc = h.eIPC.syncEgressIPPools
 		case factory.EgressNodeType:
 			syncFunc = h.eIPC.initEgressNodeReachability
 		case factory.CloudPrivateIPConfigType:
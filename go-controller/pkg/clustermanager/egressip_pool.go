@@ -0,0 +1,556 @@
+package clustermanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	egressippoolv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1"
+	egressippoolclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1/apis/clientset/versioned"
+	egressippoolinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1/apis/informers/externalversions"
+	egressippoollisters "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1/apis/listers/egressippool/v1"
+)
+
+// An EgressIP with fromPool gets its egress IPs allocated from the ranges of
+// an EgressIPPool, the lowest free ones first, and written to its spec, from
+// where they are assigned to the egress nodes like any other egress IP. The
+// egress IPs an EgressIP holds are kept as long as it requests them and they
+// are part of the pool. The allocations are rebuilt from the spec of the
+// EgressIPs on restart, and reported in the status of the pools.
+
+const (
+	egressIPPoolExhaustedReason = "PoolExhausted"
+	egressIPPoolNotFoundReason  = "EgressIPPoolNotFound"
+	egressIPPoolInvalidReason   = "InvalidEgressIPPool"
+)
+
+// egressIPPools allocates the egress IPs of the EgressIPs requesting them from
+// an EgressIPPool. Needs to be used with the egressIPAssignmentMutex held.
+type egressIPPools struct {
+	client egressippoolclientset.Interface
+	lister egressippoollisters.EgressIPPoolLister
+	// allocations are the egress IPs allocated from each pool, mapped to the
+	// name of their EgressIP
+	allocations map[string]map[string]string
+	// short are the EgressIPs of each pool which didn't get all the egress
+	// IPs they requested
+	short map[string]sets.Set[string]
+}
+
+func newEgressIPPools(client egressippoolclientset.Interface) *egressIPPools {
+	return &egressIPPools{
+		client:      client,
+		allocations: map[string]map[string]string{},
+		short:       map[string]sets.Set[string]{},
+	}
+}
+
+// egressIPPoolRanges are the parsed ranges of an EgressIPPool
+type egressIPPoolRanges struct {
+	cidrs    []*net.IPNet
+	excluded sets.Set[string]
+}
+
+func parseEgressIPPool(pool *egressippoolv1.EgressIPPool) (*egressIPPoolRanges, error) {
+	ranges := &egressIPPoolRanges{excluded: sets.New[string]()}
+	for _, cidr := range pool.Spec.CIDRs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q of EgressIPPool %s: %v", cidr, pool.Name, err)
+		}
+		ranges.cidrs = append(ranges.cidrs, ipNet)
+	}
+	for _, excluded := range pool.Spec.ExcludedIPs {
+		ip := net.ParseIP(strings.TrimSpace(excluded))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid excluded IP %q of EgressIPPool %s", excluded, pool.Name)
+		}
+		ranges.excluded.Insert(ip.String())
+	}
+	return ranges, nil
+}
+
+// contains returns whether the IP can be allocated from the ranges
+func (r *egressIPPoolRanges) contains(ip net.IP) bool {
+	if r.excluded.Has(ip.String()) {
+		return false
+	}
+	for _, cidr := range r.cidrs {
+		if !cidr.Contains(ip) {
+			continue
+		}
+		ones, bits := cidr.Mask.Size()
+		if bits == 32 && bits-ones >= 2 {
+			// the network and broadcast addresses
+			if ip.Equal(cidr.IP) || ip.Equal(utilnet.AddIPOffset(utilnet.BigForIP(cidr.IP), int(utilnet.RangeSize(cidr)-1))) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// size returns the number of IPs that can be allocated from the ranges
+func (r *egressIPPoolRanges) size() int64 {
+	var size int64
+	for _, cidr := range r.cidrs {
+		ones, bits := cidr.Mask.Size()
+		if bits-ones >= 63 {
+			return math.MaxInt64
+		}
+		cidrSize := int64(1) << uint(bits-ones)
+		if bits == 32 && bits-ones >= 2 {
+			cidrSize -= 2
+		}
+		if size > math.MaxInt64-cidrSize {
+			return math.MaxInt64
+		}
+		size += cidrSize
+	}
+	for excluded := range r.excluded {
+		if r.containsIgnoringExcluded(net.ParseIP(excluded)) {
+			size--
+		}
+	}
+	return size
+}
+
+func (r *egressIPPoolRanges) containsIgnoringExcluded(ip net.IP) bool {
+	ranges := egressIPPoolRanges{cidrs: r.cidrs, excluded: sets.New[string]()}
+	return ranges.contains(ip)
+}
+
+// ipFamilies returns whether the ranges hold IPv4 and IPv6 IPs
+func (r *egressIPPoolRanges) ipFamilies() (bool, bool) {
+	var v4, v6 bool
+	for _, cidr := range r.cidrs {
+		if utilnet.IsIPv6CIDR(cidr) {
+			v6 = true
+		} else {
+			v4 = true
+		}
+	}
+	return v4, v6
+}
+
+// next returns the lowest IP of the family that can be allocated from the
+// ranges and isn't used, nil if there is none
+func (r *egressIPPoolRanges) next(isIPv6 bool, used map[string]string) net.IP {
+	for _, cidr := range r.cidrs {
+		if utilnet.IsIPv6CIDR(cidr) != isIPv6 {
+			continue
+		}
+		base := utilnet.BigForIP(cidr.IP)
+		for offset := 0; offset < math.MaxInt32; offset++ {
+			ip := utilnet.AddIPOffset(base, offset)
+			if !cidr.Contains(ip) {
+				break
+			}
+			if !isIPv6 {
+				ip = ip.To4()
+			}
+			if _, ok := used[ip.String()]; !ok && r.contains(ip) {
+				return ip
+			}
+		}
+	}
+	return nil
+}
+
+// heldBy returns the egress IPs allocated to the EgressIP from the pool
+func (p *egressIPPools) heldBy(pool, name string) []string {
+	ips := []string{}
+	for ip, holder := range p.allocations[pool] {
+		if holder == name {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// allocate allocates count egress IPs of each IP family of the ranges of the
+// pool to the EgressIP, keeping the ones it already holds, and releases the
+// ones it doesn't need anymore. It returns the egress IPs it holds and whether
+// it got all the egress IPs it requested.
+func (p *egressIPPools) allocate(pool, name string, ranges *egressIPPoolRanges, count int) ([]string, bool) {
+	if p.allocations[pool] == nil {
+		p.allocations[pool] = map[string]string{}
+	}
+	allocations := p.allocations[pool]
+	v4, v6 := ranges.ipFamilies()
+	complete := true
+	for _, family := range []struct {
+		isIPv6  bool
+		enabled bool
+	}{{false, v4}, {true, v6}} {
+		held := 0
+		for _, ip := range p.heldBy(pool, name) {
+			parsed := net.ParseIP(ip)
+			if utilnet.IsIPv6(parsed) != family.isIPv6 {
+				continue
+			}
+			if family.enabled && held < count && ranges.contains(parsed) {
+				held++
+				continue
+			}
+			delete(allocations, ip)
+		}
+		if !family.enabled {
+			continue
+		}
+		for ; held < count; held++ {
+			ip := ranges.next(family.isIPv6, allocations)
+			if ip == nil {
+				complete = false
+				break
+			}
+			allocations[ip.String()] = name
+		}
+	}
+	p.setShort(pool, name, !complete)
+	return p.heldBy(pool, name), complete
+}
+
+// release releases the egress IPs allocated to the EgressIP from the pools
+// other than keep, and returns the pools it released egress IPs from
+func (p *egressIPPools) release(name, keep string) []string {
+	released := []string{}
+	for pool, allocations := range p.allocations {
+		if pool == keep {
+			continue
+		}
+		found := false
+		for ip, holder := range allocations {
+			if holder == name {
+				delete(allocations, ip)
+				found = true
+			}
+		}
+		if p.short[pool].Has(name) {
+			p.setShort(pool, name, false)
+			found = true
+		}
+		if found {
+			released = append(released, pool)
+		}
+	}
+	sort.Strings(released)
+	return released
+}
+
+func (p *egressIPPools) setShort(pool, name string, short bool) {
+	if short {
+		if p.short[pool] == nil {
+			p.short[pool] = sets.New[string]()
+		}
+		p.short[pool].Insert(name)
+		return
+	}
+	p.short[pool].Delete(name)
+	if p.short[pool].Len() == 0 {
+		delete(p.short, pool)
+	}
+}
+
+// getStatus returns the status of the pool from its allocations
+func (p *egressIPPools) getStatus(pool *egressippoolv1.EgressIPPool) egressippoolv1.EgressIPPoolStatus {
+	status := egressippoolv1.EgressIPPoolStatus{
+		Allocated: len(p.allocations[pool.Name]),
+	}
+	if ranges, err := parseEgressIPPool(pool); err == nil {
+		status.Size = ranges.size()
+	}
+	byEgressIP := map[string][]string{}
+	for ip, name := range p.allocations[pool.Name] {
+		byEgressIP[name] = append(byEgressIP[name], ip)
+	}
+	for name, ips := range byEgressIP {
+		sort.Strings(ips)
+		status.Allocations = append(status.Allocations, egressippoolv1.EgressIPPoolAllocation{EgressIP: name, IPs: ips})
+	}
+	sort.Slice(status.Allocations, func(i, j int) bool { return status.Allocations[i].EgressIP < status.Allocations[j].EgressIP })
+
+	status.Conditions = make([]metav1.Condition, len(pool.Status.Conditions))
+	copy(status.Conditions, pool.Status.Conditions)
+	if short := p.short[pool.Name]; short.Len() > 0 {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    egressippoolv1.EgressIPPoolConditionExhausted,
+			Status:  metav1.ConditionTrue,
+			Reason:  egressIPPoolExhaustedReason,
+			Message: fmt.Sprintf("EgressIPs %s didn't get all the egress IPs they requested", strings.Join(sets.List(short), ", ")),
+		})
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, egressippoolv1.EgressIPPoolConditionExhausted)
+	}
+	if len(status.Conditions) == 0 {
+		status.Conditions = nil
+	}
+	return status
+}
+
+// updateStatus reports the allocations of the pool in its status
+func (p *egressIPPools) updateStatus(name string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pool, err := p.client.K8sV1().EgressIPPools().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		status := p.getStatus(pool)
+		if reflect.DeepEqual(status, pool.Status) {
+			return nil
+		}
+		pool = pool.DeepCopy()
+		pool.Status = status
+		_, err = p.client.K8sV1().EgressIPPools().UpdateStatus(context.TODO(), pool, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// syncEgressIPPools rebuilds the allocations of the pools from the egress IPs
+// of the EgressIPs requesting egress IPs from a pool
+func (eIPC *egressIPClusterController) syncEgressIPPools(objs []interface{}) error {
+	if eIPC.egressIPPools == nil {
+		return nil
+	}
+	eIPC.egressIPAssignmentMutex.Lock()
+	defer eIPC.egressIPAssignmentMutex.Unlock()
+	for _, obj := range objs {
+		eIP, ok := obj.(*egressipv1.EgressIP)
+		if !ok {
+			return fmt.Errorf("spurious object in syncEgressIPPools: %v", obj)
+		}
+		if eIP.Spec.FromPool == nil {
+			continue
+		}
+		pool := eIP.Spec.FromPool.Name
+		if eIPC.egressIPPools.allocations[pool] == nil {
+			eIPC.egressIPPools.allocations[pool] = map[string]string{}
+		}
+		for _, egressIP := range eIP.Spec.EgressIPs {
+			ip := net.ParseIP(egressIP)
+			if ip == nil {
+				continue
+			}
+			if holder, ok := eIPC.egressIPPools.allocations[pool][ip.String()]; ok {
+				klog.Warningf("Egress IP %s of EgressIP %s is already allocated to EgressIP %s from EgressIPPool %s",
+					egressIP, eIP.Name, holder, pool)
+				continue
+			}
+			eIPC.egressIPPools.allocations[pool][ip.String()] = eIP.Name
+		}
+	}
+	return nil
+}
+
+type egressIPSpecPatch struct {
+	Op    string   `json:"op"`
+	Path  string   `json:"path"`
+	Value []string `json:"value"`
+}
+
+// reconcileEgressIPPool allocates the egress IPs the EgressIP requests from
+// its pool, writes them to its spec, and releases the ones it doesn't request
+// anymore. It returns the EgressIP with the egress IPs from its pool. Needs to
+// be called with the egressIPAssignmentMutex held.
+func (eIPC *egressIPClusterController) reconcileEgressIPPool(old, new *egressipv1.EgressIP) (*egressipv1.EgressIP, error) {
+	pools := eIPC.egressIPPools
+	if pools == nil {
+		return new, nil
+	}
+	name := ""
+	if old != nil {
+		name = old.Name
+	}
+	if new != nil {
+		name = new.Name
+	}
+	keep := ""
+	if new != nil && new.Spec.FromPool != nil {
+		keep = new.Spec.FromPool.Name
+	}
+	changed := pools.release(name, keep)
+	defer func() {
+		for _, pool := range changed {
+			if err := pools.updateStatus(pool); err != nil {
+				klog.Errorf("Failed to update the status of EgressIPPool %s: %v", pool, err)
+			}
+		}
+		if len(changed) > 0 {
+			eIPC.requeueShortEgressIPs(changed, name)
+		}
+	}()
+	if keep == "" {
+		return new, nil
+	}
+
+	eIPRef := v1.ObjectReference{
+		Kind: "EgressIP",
+		Name: name,
+	}
+	pool, err := pools.lister.Get(keep)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// reconciled again once the pool is created
+			eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, egressIPPoolNotFoundReason,
+				"EgressIP: %s requests egress IPs from EgressIPPool: %s which doesn't exist", name, keep)
+			return new, nil
+		}
+		return nil, fmt.Errorf("failed to get EgressIPPool %s: %w", keep, err)
+	}
+	ranges, err := parseEgressIPPool(pool)
+	if err != nil {
+		// reconciled again once the pool is fixed
+		eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, egressIPPoolInvalidReason, "EgressIP: %s %v", name, err)
+		return new, nil
+	}
+	count := 1
+	if new.Spec.FromPool.Count > 0 {
+		count = int(new.Spec.FromPool.Count)
+	}
+	before := pools.heldBy(keep, name)
+	wasShort := pools.short[keep].Has(name)
+	ips, complete := pools.allocate(keep, name, ranges, count)
+	if !reflect.DeepEqual(before, ips) || wasShort == complete {
+		changed = append(changed, keep)
+	}
+	if !complete && !wasShort {
+		eIPC.recorder.Eventf(&eIPRef, v1.EventTypeWarning, egressIPPoolExhaustedReason,
+			"EgressIP: %s got %d egress IPs from EgressIPPool: %s which has no free IP left", name, len(ips), keep)
+	}
+
+	if sets.New(ips...).Equal(sets.New(new.Spec.EgressIPs...)) {
+		return new, nil
+	}
+	klog.Infof("Setting the egress IPs of EgressIP %s allocated from EgressIPPool %s: %v", name, keep, ips)
+	patch, err := json.Marshal([]egressIPSpecPatch{{Op: "add", Path: "/spec/egressIPs", Value: ips}})
+	if err != nil {
+		return nil, fmt.Errorf("error serializing spec patch operation: %+v, err: %v", ips, err)
+	}
+	if err := eIPC.kube.PatchEgressIP(name, patch); err != nil {
+		return nil, fmt.Errorf("failed to set the egress IPs of EgressIP %s allocated from EgressIPPool %s: %w", name, keep, err)
+	}
+	updated := new.DeepCopy()
+	updated.Spec.EgressIPs = ips
+	return updated, nil
+}
+
+// requeueShortEgressIPs reconciles again the EgressIPs which didn't get all
+// the egress IPs they requested from the pools, once egress IPs were released
+func (eIPC *egressIPClusterController) requeueShortEgressIPs(pools []string, except string) {
+	short := sets.New[string]()
+	for _, pool := range pools {
+		short = short.Union(eIPC.egressIPPools.short[pool])
+	}
+	short.Delete(except)
+	if short.Len() == 0 {
+		return
+	}
+	eIPC.requeueEgressIPs(func(eIP *egressipv1.EgressIP) bool {
+		return short.Has(eIP.Name)
+	})
+}
+
+// WatchEgressIPPools reconciles the EgressIPs requesting egress IPs from a
+// pool once it is created, updated or deleted. It needs to be started before
+// the EgressIPs are watched.
+func (eIPC *egressIPClusterController) WatchEgressIPPools() error {
+	if eIPC.egressIPPools == nil {
+		return nil
+	}
+	informerFactory := egressippoolinformerfactory.NewSharedInformerFactory(eIPC.egressIPPools.client, 0)
+	informer := informerFactory.K8s().V1().EgressIPPools()
+	eIPC.egressIPPools.lister = informer.Lister()
+	fromPool := func(name string) func(*egressipv1.EgressIP) bool {
+		return func(eIP *egressipv1.EgressIP) bool {
+			return eIP.Spec.FromPool != nil && eIP.Spec.FromPool.Name == name
+		}
+	}
+	refresh := func(name string) {
+		eIPC.requeueEgressIPs(fromPool(name))
+		eIPC.egressIPAssignmentMutex.Lock()
+		defer eIPC.egressIPAssignmentMutex.Unlock()
+		if err := eIPC.egressIPPools.updateStatus(name); err != nil {
+			klog.Errorf("Failed to update the status of EgressIPPool %s: %v", name, err)
+		}
+	}
+	_, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			// the EgressIPs are reconciled when they are watched
+			if isInInitialList {
+				return
+			}
+			refresh(obj.(*egressippoolv1.EgressIPPool).Name)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPool := oldObj.(*egressippoolv1.EgressIPPool)
+			newPool := newObj.(*egressippoolv1.EgressIPPool)
+			if reflect.DeepEqual(oldPool.Spec, newPool.Spec) {
+				return
+			}
+			refresh(newPool.Name)
+		},
+		DeleteFunc: func(obj interface{}) {
+			pool, ok := obj.(*egressippoolv1.EgressIPPool)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				if pool, ok = tombstone.Obj.(*egressippoolv1.EgressIPPool); !ok {
+					return
+				}
+			}
+			eIPC.requeueEgressIPs(fromPool(pool.Name))
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add event handler for the EgressIPPools: %w", err)
+	}
+	informerFactory.Start(eIPC.stopChan)
+	if !cache.WaitForCacheSync(eIPC.stopChan, informer.Informer().HasSynced) {
+		return fmt.Errorf("timed out waiting for the informer of the EgressIPPools to sync")
+	}
+	return nil
+}
+
+// updateEgressIPPoolStatuses reports the allocations of all the pools, once
+// the allocations of the existing EgressIPs are rebuilt
+func (eIPC *egressIPClusterController) updateEgressIPPoolStatuses() {
+	if eIPC.egressIPPools == nil {
+		return
+	}
+	pools, err := eIPC.egressIPPools.lister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Unable to list the EgressIPPools: %v", err)
+		return
+	}
+	eIPC.egressIPAssignmentMutex.Lock()
+	defer eIPC.egressIPAssignmentMutex.Unlock()
+	for _, pool := range pools {
+		if err := eIPC.egressIPPools.updateStatus(pool.Name); err != nil {
+			klog.Errorf("Failed to update the status of EgressIPPool %s: %v", pool.Name, err)
+		}
+	}
+}
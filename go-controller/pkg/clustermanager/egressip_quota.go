@@ -0,0 +1,221 @@
+package clustermanager
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// A namespace annotated with k8s.ovn.org/egressip-quota limits the number of
+// egress IPs assigned to the EgressIPs selecting it, so that the EgressIPs of
+// a tenant don't exhaust the egress IPs the egress nodes can host. The egress
+// IPs exceeding the quota are left unassigned, and the QuotaExceeded condition
+// of their EgressIP explains why.
+
+const egressIPQuotaExceededReason = "NamespaceQuotaExceeded"
+
+// egressIPQuota is the number of egress IPs an EgressIP can be assigned
+type egressIPQuota struct {
+	// limit is the number of egress IPs, -1 if no namespace the EgressIP
+	// selects has a quota
+	limit int
+	// message names the namespace with the least quota left
+	message string
+}
+
+// getEgressIPQuota returns the number of egress IPs the EgressIP can be
+// assigned without exceeding the quota of a namespace it selects. The egress
+// IPs assigned to the other EgressIPs selecting a namespace count against its
+// quota.
+func (eIPC *egressIPClusterController) getEgressIPQuota(eIP *egressipv1.EgressIP) (egressIPQuota, error) {
+	quota := egressIPQuota{limit: -1}
+	namespaces, err := eIPC.watchFactory.GetNamespacesBySelector(eIP.Spec.NamespaceSelector)
+	if err != nil {
+		return quota, fmt.Errorf("failed to get the namespaces selected by EgressIP %s: %w", eIP.Name, err)
+	}
+	var egressIPs []*egressipv1.EgressIP
+	for _, namespace := range namespaces {
+		limit, ok, err := util.ParseEgressIPQuotaAnnotation(namespace)
+		if err != nil {
+			klog.Warningf("Ignoring the egress IP quota of namespace %s: %v", namespace.Name, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if egressIPs == nil {
+			if egressIPs, err = eIPC.watchFactory.GetEgressIPs(); err != nil {
+				return quota, fmt.Errorf("unable to get Egress IPs: %w", err)
+			}
+		}
+		used := 0
+		for _, other := range egressIPs {
+			if other.Name != eIP.Name && egressIPSelectsNamespace(other, namespace) {
+				used += eIPC.getAllocationCount(other.Name)
+			}
+		}
+		left := limit - used
+		if left < 0 {
+			left = 0
+		}
+		if quota.limit == -1 || left < quota.limit {
+			quota.limit = left
+			quota.message = fmt.Sprintf("namespace %s has an egress IP quota of %d, %d used by other EgressIPs",
+				namespace.Name, limit, used)
+		}
+	}
+	return quota, nil
+}
+
+// getAllocationCount returns the number of egress IPs of the EgressIP
+// assigned to egress nodes
+func (eIPC *egressIPClusterController) getAllocationCount(name string) int {
+	eIPC.allocator.Lock()
+	defer eIPC.allocator.Unlock()
+	count := 0
+	for _, eNode := range eIPC.allocator.cache {
+		count += eNode.getAllocationCountForEgressIP(name)
+	}
+	return count
+}
+
+func egressIPSelectsNamespace(eIP *egressipv1.EgressIP, namespace *v1.Namespace) bool {
+	selector, err := metav1.LabelSelectorAsSelector(&eIP.Spec.NamespaceSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(namespace.Labels))
+}
+
+// applyEgressIPQuota drops the egress IPs to keep and to assign exceeding the
+// quota, the egress IPs to keep being dropped last. It returns the egress IPs
+// to keep and to assign within the quota, and the egress IPs to keep that
+// are to be unassigned.
+func applyEgressIPQuota(quota egressIPQuota, statusToKeep []egressipv1.EgressIPStatusItem,
+	ipsToAssign sets.Set[string]) ([]egressipv1.EgressIPStatusItem, sets.Set[string], []egressipv1.EgressIPStatusItem) {
+	if quota.limit < 0 || len(statusToKeep)+ipsToAssign.Len() <= quota.limit {
+		return statusToKeep, ipsToAssign, nil
+	}
+	if len(statusToKeep) >= quota.limit {
+		sort.Slice(statusToKeep, func(i, j int) bool { return statusToKeep[i].EgressIP < statusToKeep[j].EgressIP })
+		return statusToKeep[:quota.limit], sets.New[string](), statusToKeep[quota.limit:]
+	}
+	return statusToKeep, sets.New(sets.List(ipsToAssign)[:quota.limit-len(statusToKeep)]...), nil
+}
+
+// egressIPQuotaConditions returns the conditions of the EgressIP updated
+// with whether its egress IPs exceed the quota, and if they changed
+func egressIPQuotaConditions(eIP *egressipv1.EgressIP, quota egressIPQuota, requested int) ([]metav1.Condition, bool) {
+	conditions := make([]metav1.Condition, len(eIP.Status.Conditions))
+	copy(conditions, eIP.Status.Conditions)
+	if quota.limit >= 0 && requested > quota.limit {
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    egressipv1.EgressIPConditionQuotaExceeded,
+			Status:  metav1.ConditionTrue,
+			Reason:  egressIPQuotaExceededReason,
+			Message: fmt.Sprintf("%d of the %d egress IPs assigned: %s", quota.limit, requested, quota.message),
+		})
+	} else {
+		meta.RemoveStatusCondition(&conditions, egressipv1.EgressIPConditionQuotaExceeded)
+	}
+	if len(conditions) == 0 {
+		conditions = nil
+	}
+	return conditions, !reflect.DeepEqual(conditions, eIP.Status.Conditions)
+}
+
+// requeueQuotaExceededEgressIPs reconciles again the EgressIPs with egress
+// IPs left unassigned because of a quota, once egress IPs were unassigned
+func (eIPC *egressIPClusterController) requeueQuotaExceededEgressIPs(except string) {
+	eIPC.requeueEgressIPs(func(eIP *egressipv1.EgressIP) bool {
+		return eIP.Name != except && meta.IsStatusConditionTrue(eIP.Status.Conditions, egressipv1.EgressIPConditionQuotaExceeded)
+	})
+}
+
+func (eIPC *egressIPClusterController) requeueEgressIPs(filter func(*egressipv1.EgressIP) bool) {
+	egressIPs, err := eIPC.watchFactory.GetEgressIPs()
+	if err != nil {
+		klog.Errorf("Unable to get Egress IPs: %v", err)
+		return
+	}
+	requeued := false
+	for _, eIP := range egressIPs {
+		if !filter(eIP) {
+			continue
+		}
+		if err := eIPC.retryEgressIPs.AddRetryObjWithAddNoBackoff(eIP); err != nil {
+			klog.Errorf("Failed to requeue EgressIP %s: %v", eIP.Name, err)
+			continue
+		}
+		requeued = true
+	}
+	if requeued {
+		eIPC.retryEgressIPs.RequestRetryObjs()
+	}
+}
+
+// WatchEgressIPQuotas reconciles the EgressIPs selecting a namespace whose
+// egress IP quota or labels changed
+func (eIPC *egressIPClusterController) WatchEgressIPQuotas() (*factory.Handler, error) {
+	selectsNamespaces := func(namespaces ...*v1.Namespace) func(*egressipv1.EgressIP) bool {
+		return func(eIP *egressipv1.EgressIP) bool {
+			for _, namespace := range namespaces {
+				if egressIPSelectsNamespace(eIP, namespace) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	hasQuota := func(namespace *v1.Namespace) bool {
+		_, ok := namespace.Annotations[util.EgressIPQuotaAnnotation]
+		return ok
+	}
+	return eIPC.watchFactory.AddNamespaceHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			namespace := obj.(*v1.Namespace)
+			if hasQuota(namespace) {
+				eIPC.requeueEgressIPs(selectsNamespaces(namespace))
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNamespace := oldObj.(*v1.Namespace)
+			newNamespace := newObj.(*v1.Namespace)
+			if !hasQuota(oldNamespace) && !hasQuota(newNamespace) {
+				return
+			}
+			if oldNamespace.Annotations[util.EgressIPQuotaAnnotation] == newNamespace.Annotations[util.EgressIPQuotaAnnotation] &&
+				reflect.DeepEqual(oldNamespace.Labels, newNamespace.Labels) {
+				return
+			}
+			eIPC.requeueEgressIPs(selectsNamespaces(oldNamespace, newNamespace))
+		},
+		DeleteFunc: func(obj interface{}) {
+			namespace, ok := obj.(*v1.Namespace)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				if namespace, ok = tombstone.Obj.(*v1.Namespace); !ok {
+					return
+				}
+			}
+			if hasQuota(namespace) {
+				eIPC.requeueQuotaExceededEgressIPs("")
+			}
+		},
+	}, nil)
+}
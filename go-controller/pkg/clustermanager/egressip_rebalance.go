@@ -0,0 +1,183 @@
+package clustermanager
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+)
+
+// The egress IPs are assigned to the egress nodes hosting the fewest egress
+// IPs, and then stay on their node as long as it can host them. The egress IPs
+// of the EgressIPs with the balanced assignment policy are also periodically
+// moved from the egress nodes hosting the most egress IPs to the ones hosting
+// the fewest, for instance to the nodes added since they were assigned. Moving
+// an egress IP breaks the connections SNATed to it, so the number of egress IPs
+// moved per interval is limited.
+
+// getEgressIPAssignmentPolicy returns the assignment policy of the EgressIP,
+// the default assignment policy of the cluster if it has none
+func getEgressIPAssignmentPolicy(eIP *egressipv1.EgressIP) egressipv1.EgressIPAssignmentPolicy {
+	if eIP.Spec.AssignmentPolicy != "" {
+		return eIP.Spec.AssignmentPolicy
+	}
+	switch config.OVNKubernetesFeature.EgressIPAssignmentPolicy {
+	case config.EgressIPAssignmentPolicyBalanced:
+		return egressipv1.EgressIPAssignmentBalanced
+	case config.EgressIPAssignmentPolicyManual:
+		return egressipv1.EgressIPAssignmentManual
+	default:
+		return egressipv1.EgressIPAssignmentSticky
+	}
+}
+
+// checkEgressIPBalance periodically rebalances the egress IPs of the
+// EgressIPs with the balanced assignment policy
+func (eIPC *egressIPClusterController) checkEgressIPBalance() {
+	timer := time.NewTicker(time.Duration(config.OVNKubernetesFeature.EgressIPRebalanceInterval) * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if _, err := eIPC.rebalanceEgressIPs(config.OVNKubernetesFeature.EgressIPRebalanceMaxMoves); err != nil {
+				klog.Errorf("Failed to rebalance the egress IPs: %v", err)
+			}
+		case <-eIPC.stopChan:
+			klog.V(5).Infof("Stop channel got triggered: will stop checkEgressIPBalance")
+			return
+		}
+	}
+}
+
+// egressIPMove is the move of an egress IP of an EgressIP away from a node
+type egressIPMove struct {
+	name     string
+	egressIP string
+	node     string
+}
+
+// rebalanceEgressIPs moves up to maxMoves egress IPs of the EgressIPs with the
+// balanced assignment policy, at most one per EgressIP, and returns the number
+// of egress IPs moved
+func (eIPC *egressIPClusterController) rebalanceEgressIPs(maxMoves int) (int, error) {
+	eIPC.egressIPAssignmentMutex.Lock()
+	defer eIPC.egressIPAssignmentMutex.Unlock()
+
+	egressIPs, err := eIPC.watchFactory.GetEgressIPs()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get Egress IPs: %w", err)
+	}
+	eIPC.pendingCloudPrivateIPConfigsMutex.Lock()
+	balanced := make(map[string]*egressipv1.EgressIP)
+	for _, eIP := range egressIPs {
+		if getEgressIPAssignmentPolicy(eIP) != egressipv1.EgressIPAssignmentBalanced {
+			continue
+		}
+		// the egress IPs being assigned by the cloud are moved once settled
+		if _, pending := eIPC.pendingCloudPrivateIPConfigsOps[eIP.Name]; pending {
+			continue
+		}
+		balanced[eIP.Name] = eIP
+	}
+	eIPC.pendingCloudPrivateIPConfigsMutex.Unlock()
+
+	moved := 0
+	for moved < maxMoves {
+		move := eIPC.nextEgressIPMove(balanced)
+		if move == nil {
+			break
+		}
+		eIP := balanced[move.name]
+		// the status of the EgressIP is stale until the move is observed
+		delete(balanced, move.name)
+		klog.Infof("Moving egress IP: %s of EgressIP: %s away from node: %s to rebalance the egress IPs",
+			move.egressIP, move.name, move.node)
+		if err := eIPC.reconcileEgressIPAssignment(nil, eIP, map[string]string{move.egressIP: move.node}); err != nil {
+			return moved, fmt.Errorf("failed to move egress IP %s of EgressIP %s away from node %s: %w",
+				move.egressIP, move.name, move.node, err)
+		}
+		eIPRef := v1.ObjectReference{
+			Kind: "EgressIP",
+			Name: move.name,
+		}
+		eIPC.recorder.Eventf(&eIPRef, v1.EventTypeNormal, "EgressIPRebalanced",
+			"egress IP: %s for object EgressIP: %s moved away from node: %s", move.egressIP, move.name, move.node)
+		moved++
+	}
+	return moved, nil
+}
+
+// nextEgressIPMove returns the move of an egress IP of the EgressIPs from the
+// egress node hosting the most egress IPs to an egress node which can host it
+// and hosts at least two egress IPs less, if any
+func (eIPC *egressIPClusterController) nextEgressIPMove(egressIPs map[string]*egressipv1.EgressIP) *egressIPMove {
+	eIPC.allocator.Lock()
+	defer eIPC.allocator.Unlock()
+	assignableNodes, _ := eIPC.getSortedEgressData()
+	for i := len(assignableNodes) - 1; i > 0; i-- {
+		from := assignableNodes[i]
+		ips := make([]string, 0, len(from.allocations))
+		for ip := range from.allocations {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+		for _, ip := range ips {
+			eIP, ok := egressIPs[from.allocations[ip]]
+			if !ok || !eIPC.isEgressIPAssignmentSettled(eIP) {
+				continue
+			}
+			for _, to := range assignableNodes[:i] {
+				if len(from.allocations)-len(to.allocations) < 2 {
+					break
+				}
+				if eIPC.canHostEgressIP(to, eIP, net.ParseIP(ip)) {
+					return &egressIPMove{name: eIP.Name, egressIP: ip, node: from.name}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isEgressIPAssignmentSettled returns whether the status of the EgressIP
+// matches its allocations. Needs to be called with the allocator lock held.
+func (eIPC *egressIPClusterController) isEgressIPAssignmentSettled(eIP *egressipv1.EgressIP) bool {
+	count := 0
+	for _, eNode := range eIPC.allocator.cache {
+		count += eNode.getAllocationCountForEgressIP(eIP.Name)
+	}
+	if count != len(eIP.Status.Items) {
+		return false
+	}
+	for _, status := range eIP.Status.Items {
+		eNode, exists := eIPC.allocator.cache[status.Node]
+		if !exists || eNode.allocations[status.EgressIP] != eIP.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// canHostEgressIP returns whether the egress IP of the EgressIP can be
+// assigned to the egress node. Needs to be called with the allocator lock
+// held.
+func (eIPC *egressIPClusterController) canHostEgressIP(eNode *egressNode, eIP *egressipv1.EgressIP, ip net.IP) bool {
+	if eNode.getAllocationCountForEgressIP(eIP.Name) > 0 || eNode.exhaustedCapacity(ip) != "" {
+		return false
+	}
+	node, err := eIPC.watchFactory.GetNode(eNode.name)
+	if err != nil {
+		return false
+	}
+	if matches, err := egressIPPlacementMatchesNode(eIP.Spec.Placement, node); err != nil || !matches {
+		return false
+	}
+	network, err := getEgressIPNetwork(node, ip, eIP.Spec.Network)
+	return err == nil && network != ""
+}
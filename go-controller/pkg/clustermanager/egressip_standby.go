@@ -0,0 +1,129 @@
+package clustermanager
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// With the standby feature enabled, each egress IP assigned on the OVN managed
+// network gets a standby node: an egress node which could host it, recorded in
+// the status of its EgressIP. ovnkube-controller pre-programs the SNAT of the
+// egress IP, disabled, on the gateway router of the standby node, and the
+// egress IP fails over to its standby node first when its egress node becomes
+// unusable, so that the pre-programmed SNAT only has to be flipped.
+
+// isEgressIPStandbyEnabled returns whether the egress IPs get a standby node.
+// The egress IPs of the cloud platforms move with their CloudPrivateIPConfig
+// and don't.
+func isEgressIPStandbyEnabled() bool {
+	return config.OVNKubernetesFeature.EnableEgressIPStandby && !util.PlatformTypeIsEgressIPCloudProvider()
+}
+
+// getEgressIPStandbyNodes returns the standby node of each egress IP of the
+// status of the EgressIP
+func getEgressIPStandbyNodes(eIP *egressipv1.EgressIP) map[string]string {
+	standbys := make(map[string]string, len(eIP.Status.Standbys))
+	for _, standby := range eIP.Status.Standbys {
+		standbys[standby.EgressIP] = standby.Node
+	}
+	return standbys
+}
+
+// getEgressIPStandbys returns the standby node of each egress IP of the items
+// hosted on the OVN managed network, an egress node which could host it and
+// isn't the standby node of another egress IP of the EgressIP. The current
+// standby node of an egress IP is kept if it still qualifies, the egress node
+// hosting the fewest egress IPs is picked otherwise.
+func (eIPC *egressIPClusterController) getEgressIPStandbys(eIP *egressipv1.EgressIP, items []egressipv1.EgressIPStatusItem) []egressipv1.EgressIPStandbyStatus {
+	if !isEgressIPStandbyEnabled() || eIP == nil {
+		return nil
+	}
+	current := getEgressIPStandbyNodes(eIP)
+	eIPC.allocator.Lock()
+	defer eIPC.allocator.Unlock()
+	assignableNodes, _ := eIPC.getSortedEgressData()
+	taken := sets.New[string]()
+	var standbys []egressipv1.EgressIPStandbyStatus
+	for _, item := range items {
+		ip := net.ParseIP(item.EgressIP)
+		if ip == nil {
+			continue
+		}
+		var candidates []string
+		for _, eNode := range assignableNodes {
+			if eNode.name == item.Node || taken.Has(eNode.name) || !eIPC.canHostEgressIP(eNode, eIP, ip) {
+				continue
+			}
+			node, err := eIPC.watchFactory.GetNode(eNode.name)
+			if err != nil {
+				continue
+			}
+			if isOVNManaged, err := util.IsOVNManagedNetwork(node, ip); err != nil || !isOVNManaged {
+				continue
+			}
+			candidates = append(candidates, eNode.name)
+		}
+		if len(candidates) == 0 {
+			klog.V(5).Infof("No standby node found for egress IP %s of EgressIP %s", item.EgressIP, eIP.Name)
+			continue
+		}
+		standby := candidates[0]
+		for _, candidate := range candidates {
+			if candidate == current[item.EgressIP] {
+				standby = candidate
+				break
+			}
+		}
+		taken.Insert(standby)
+		standbys = append(standbys, egressipv1.EgressIPStandbyStatus{EgressIP: item.EgressIP, Node: standby})
+	}
+	sort.Slice(standbys, func(i, j int) bool { return standbys[i].EgressIP < standbys[j].EgressIP })
+	return standbys
+}
+
+// preferEgressIPStandby moves the standby node of the egress IP of the
+// EgressIP, if any, first in the egress nodes considered for its assignment
+func (eIPC *egressIPClusterController) preferEgressIPStandby(name, egressIP string, eNodes []*egressNode) []*egressNode {
+	if !isEgressIPStandbyEnabled() {
+		return eNodes
+	}
+	eIP, err := eIPC.watchFactory.GetEgressIP(name)
+	if err != nil {
+		return eNodes
+	}
+	standby := getEgressIPStandbyNodes(eIP)[egressIP]
+	for i, eNode := range eNodes {
+		if eNode.name != standby {
+			continue
+		}
+		preferred := make([]*egressNode, 0, len(eNodes))
+		preferred = append(preferred, eNode)
+		preferred = append(preferred, eNodes[:i]...)
+		return append(preferred, eNodes[i+1:]...)
+	}
+	return eNodes
+}
+
+// reconcileEgressIPStandbys updates the standby nodes of the EgressIP if they
+// changed, like when a standby node became unusable or an egress node able to
+// stand by for an egress IP without a standby node showed up
+func (eIPC *egressIPClusterController) reconcileEgressIPStandbys(eIP *egressipv1.EgressIP) error {
+	eIPC.egressIPAssignmentMutex.Lock()
+	defer eIPC.egressIPAssignmentMutex.Unlock()
+	if reflect.DeepEqual(eIPC.getEgressIPStandbys(eIP, eIP.Status.Items), eIP.Status.Standbys) {
+		return nil
+	}
+	if err := eIPC.patchEgressIPStatus(eIP.Name, eIP.Status.Items, eIP.Status.Conditions); err != nil {
+		return fmt.Errorf("unable to update the standby nodes of EgressIP %s: %v", eIP.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package clustermanager
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// Besides the egress node of each egress IP, the status of an EgressIP reports
+// the interface the egress IPs are programmed on, as annotated on the egress
+// nodes by ovnkube-node, and the history of the moves of the egress IPs
+// between the egress nodes. The Degraded condition reports the egress IPs
+// assigned but not programmed yet.
+
+const (
+	// egressIPMaxTransitions is the number of transitions kept in the status
+	// of an EgressIP
+	egressIPMaxTransitions = 10
+	// egressIPNotProgrammedReason is the reason of the Degraded condition
+	egressIPNotProgrammedReason = "NotProgrammed"
+)
+
+// buildEgressIPStatus returns the status of the EgressIP with the given items
+// and conditions, recording the transitions from the items of its current
+// status, if any, reporting the interfaces the egress IPs are programmed on
+// and designating their standby nodes
+func (eIPC *egressIPClusterController) buildEgressIPStatus(current *egressipv1.EgressIP, items []egressipv1.EgressIPStatusItem,
+	conditions []metav1.Condition) egressipv1.EgressIPStatus {
+	status := egressipv1.EgressIPStatus{Items: items}
+	if current != nil {
+		status.LastFailoverTime = current.Status.LastFailoverTime
+		status.Transitions = recordEgressIPTransitions(&status, current.Status, metav1.Now())
+	}
+	var unprogrammed []string
+	status.Interfaces, unprogrammed = eIPC.getEgressIPInterfaces(items)
+	status.Conditions = egressIPDegradedConditions(conditions, unprogrammed)
+	status.Standbys = eIPC.getEgressIPStandbys(current, items)
+	return status
+}
+
+// recordEgressIPTransitions returns the transitions of the previous status
+// followed by the moves of the egress IPs from the previous status to the
+// new one, and sets the last failover time of the new status if an egress IP
+// moved from an egress node to another
+func recordEgressIPTransitions(status *egressipv1.EgressIPStatus, previous egressipv1.EgressIPStatus, now metav1.Time) []egressipv1.EgressIPTransition {
+	previousNodes := map[string]string{}
+	for _, item := range previous.Items {
+		previousNodes[item.EgressIP] = item.Node
+	}
+	nodes := map[string]string{}
+	for _, item := range status.Items {
+		nodes[item.EgressIP] = item.Node
+	}
+	var moves []egressipv1.EgressIPTransition
+	for egressIP, node := range nodes {
+		previousNode := previousNodes[egressIP]
+		if previousNode == node {
+			continue
+		}
+		moves = append(moves, egressipv1.EgressIPTransition{EgressIP: egressIP, FromNode: previousNode, ToNode: node, Time: now})
+		if previousNode != "" {
+			status.LastFailoverTime = &now
+		}
+	}
+	for egressIP, previousNode := range previousNodes {
+		if _, ok := nodes[egressIP]; !ok {
+			moves = append(moves, egressipv1.EgressIPTransition{EgressIP: egressIP, FromNode: previousNode, Time: now})
+		}
+	}
+	if len(moves) == 0 {
+		return previous.Transitions
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].EgressIP < moves[j].EgressIP })
+	transitions := append(append([]egressipv1.EgressIPTransition{}, previous.Transitions...), moves...)
+	if len(transitions) > egressIPMaxTransitions {
+		transitions = transitions[len(transitions)-egressIPMaxTransitions:]
+	}
+	return transitions
+}
+
+// getEgressIPInterfaces returns the interfaces the egress IPs of the items are
+// programmed on, as reported by their egress node, and the egress IPs not
+// reported programmed
+func (eIPC *egressIPClusterController) getEgressIPInterfaces(items []egressipv1.EgressIPStatusItem) ([]egressipv1.EgressIPInterfaceStatus, []string) {
+	var interfaces []egressipv1.EgressIPInterfaceStatus
+	var unprogrammed []string
+	for _, item := range items {
+		iface := ""
+		if node, err := eIPC.watchFactory.GetNode(item.Node); err == nil {
+			nodeInterfaces, err := util.ParseNodeEgressIPInterfaces(node)
+			if err != nil {
+				klog.Warningf("Ignoring the egress IP interfaces of node %s: %v", item.Node, err)
+			}
+			iface = nodeInterfaces[item.EgressIP]
+		}
+		if iface == "" {
+			unprogrammed = append(unprogrammed, item.EgressIP)
+			continue
+		}
+		interfaces = append(interfaces, egressipv1.EgressIPInterfaceStatus{
+			EgressIP:  item.EgressIP,
+			Node:      item.Node,
+			Interface: iface,
+		})
+	}
+	return interfaces, unprogrammed
+}
+
+// egressIPDegradedConditions returns the conditions updated with whether some
+// egress IPs are not reported programmed
+func egressIPDegradedConditions(conditions []metav1.Condition, unprogrammed []string) []metav1.Condition {
+	updated := make([]metav1.Condition, len(conditions))
+	copy(updated, conditions)
+	if len(unprogrammed) > 0 {
+		sort.Strings(unprogrammed)
+		meta.SetStatusCondition(&updated, metav1.Condition{
+			Type:    egressipv1.EgressIPConditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  egressIPNotProgrammedReason,
+			Message: fmt.Sprintf("egress IPs %s not reported programmed by their egress node", strings.Join(unprogrammed, ", ")),
+		})
+	} else {
+		meta.RemoveStatusCondition(&updated, egressipv1.EgressIPConditionDegraded)
+	}
+	if len(updated) == 0 {
+		updated = nil
+	}
+	return updated
+}
+
+// updateEgressIPInterfaces updates the status of the EgressIPs assigned to the
+// node once it reported the interfaces their egress IPs are programmed on
+func (eIPC *egressIPClusterController) updateEgressIPInterfaces(nodeName string) error {
+	eIPs, err := eIPC.watchFactory.GetEgressIPs()
+	if err != nil {
+		return fmt.Errorf("unable to list EgressIPs: %v", err)
+	}
+	for _, eIP := range eIPs {
+		for _, item := range eIP.Status.Items {
+			if item.Node != nodeName {
+				continue
+			}
+			if err := eIPC.reconcileEgressIPInterfaces(eIP.Name); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// reconcileEgressIPInterfaces updates the interfaces and the Degraded
+// condition of the EgressIP if they don't match what its egress nodes
+// reported, like when the nodes reported them before the informer caught up
+// with the status of the EgressIP
+func (eIPC *egressIPClusterController) reconcileEgressIPInterfaces(name string) error {
+	eIP, err := eIPC.kube.GetEgressIP(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get EgressIP %s: %v", name, err)
+	}
+	status := eIPC.buildEgressIPStatus(nil, eIP.Status.Items, eIP.Status.Conditions)
+	if reflect.DeepEqual(status.Interfaces, eIP.Status.Interfaces) && reflect.DeepEqual(status.Conditions, eIP.Status.Conditions) {
+		return nil
+	}
+	if err := eIPC.patchEgressIPStatus(name, eIP.Status.Items, eIP.Status.Conditions); err != nil {
+		return fmt.Errorf("unable to update the interfaces of EgressIP %s: %v", name, err)
+	}
+	return nil
+}
@@ -57,8 +57,11 @@ type Controller struct {
 	// be allocated on it - if it does we queue the service again.
 	// We also check this cache when an ep is added, as the service might
 	// got to this cache by having no eps.
-	unallocatedServices  map[string]labels.Selector // svc key -> its node selector
-	watchFactory         *factory.WatchFactory
+	unallocatedServices map[string]labels.Selector // svc key -> its node selector
+	watchFactory        *factory.WatchFactory
+	// nodeNetworkStates gets the node network state holding the host subnets
+	// of the nodes with the "crd" backend
+	nodeNetworkStates    util.NodeNetworkStateGetter
 	egressServiceLister  egressservicelisters.EgressServiceLister
 	egressServiceSynced  cache.InformerSynced
 	egressServiceQueue   workqueue.RateLimitingInterface
@@ -93,6 +96,7 @@ type nodeState struct {
 func NewController(
 	ovnClient *util.OVNClusterManagerClientset,
 	wf *factory.WatchFactory,
+	nodeNetworkStates util.NodeNetworkStateGetter,
 	isReachable func(nodeName string, mgmtIPs []net.IP, healthClient healthcheck.EgressIPHealthClient) bool) (*Controller, error) {
 	klog.Info("Setting up event handlers for Egress Services")
 
@@ -103,6 +107,7 @@ func NewController(
 			EgressServiceClient: ovnClient.EgressServiceClient,
 		},
 		watchFactory:        wf,
+		nodeNetworkStates:   nodeNetworkStates,
 		IsReachable:         isReachable,
 		stopCh:              make(chan struct{}),
 		wg:                  wg,
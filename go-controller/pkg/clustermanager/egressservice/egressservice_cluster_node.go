@@ -302,11 +302,12 @@ func (c *Controller) nodeStateFor(name string) (*nodeState, error) {
 		return nil, err
 	}
 
-	nodeSubnets, err := util.ParseNodeHostSubnetAnnotation(node, ovntypes.DefaultNetworkName)
+	nodeSubnets, err := util.ParseNodeHostSubnets(node, ovntypes.DefaultNetworkName, c.nodeNetworkStates)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse node %s subnets annotation %v", node.Name, err)
 	}
 
+	nodeSubnets = util.PrimaryHostSubnets(nodeSubnets)
 	mgmtIPs := make([]net.IP, len(nodeSubnets))
 	for i, subnet := range nodeSubnets {
 		mgmtIPs[i] = util.GetNodeManagementIfAddr(subnet).IP
@@ -7,9 +7,12 @@ import (
 	ocpcloudnetworkapi "github.com/openshift/api/cloudnetwork/v1"
 	cloudservicefake "github.com/openshift/client-go/cloudnetwork/clientset/versioned/fake"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/egressservice"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/loadbalancer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	egressip "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
 	egressipfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned/fake"
+	egressippool "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1"
+	egressippoolfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1/apis/clientset/versioned/fake"
 	egresssvc "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressservice/v1"
 	egresssvcfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressservice/v1/apis/clientset/versioned/fake"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
@@ -25,6 +28,7 @@ type FakeClusterManager struct {
 	watcher      *factory.WatchFactory
 	eIPC         *egressIPClusterController
 	esvc         *egressservice.Controller
+	lbc          *loadbalancer.Controller
 	fakeRecorder *record.FakeRecorder
 }
 
@@ -40,12 +44,15 @@ func NewFakeClusterManagerOVN() *FakeClusterManager {
 
 func (o *FakeClusterManager) start(objects ...runtime.Object) {
 	egressIPObjects := []runtime.Object{}
+	egressIPPoolObjects := []runtime.Object{}
 	egressSvcObjects := []runtime.Object{}
 	v1Objects := []runtime.Object{}
 	cloudObjects := []runtime.Object{}
 	for _, object := range objects {
 		if _, isEgressIPObject := object.(*egressip.EgressIPList); isEgressIPObject {
 			egressIPObjects = append(egressIPObjects, object)
+		} else if _, isEgressIPPoolObject := object.(*egressippool.EgressIPPoolList); isEgressIPPoolObject {
+			egressIPPoolObjects = append(egressIPPoolObjects, object)
 		} else if _, isEgressSVCObj := object.(*egresssvc.EgressServiceList); isEgressSVCObj {
 			egressSvcObjects = append(egressSvcObjects, object)
 		} else if _, isCloudPrivateIPConfig := object.(*ocpcloudnetworkapi.CloudPrivateIPConfigList); isCloudPrivateIPConfig {
@@ -57,6 +64,7 @@ func (o *FakeClusterManager) start(objects ...runtime.Object) {
 	o.fakeClient = &util.OVNClusterManagerClientset{
 		KubeClient:          fake.NewSimpleClientset(v1Objects...),
 		EgressIPClient:      egressipfake.NewSimpleClientset(egressIPObjects...),
+		EgressIPPoolClient:  egressippoolfake.NewSimpleClientset(egressIPPoolObjects...),
 		EgressServiceClient: egresssvcfake.NewSimpleClientset(egressSvcObjects...),
 		CloudNetworkClient:  cloudservicefake.NewSimpleClientset(cloudObjects...),
 	}
@@ -71,16 +79,23 @@ func (o *FakeClusterManager) init() {
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 	if config.OVNKubernetesFeature.EnableEgressIP {
-		o.eIPC = newEgressIPController(o.fakeClient, o.watcher, o.fakeRecorder)
+		o.eIPC = newEgressIPController(o.fakeClient, o.watcher, nil, o.fakeRecorder)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	}
 	if config.OVNKubernetesFeature.EnableEgressService {
-		o.esvc, err = egressservice.NewController(o.fakeClient, o.watcher, isReachable)
+		o.esvc, err = egressservice.NewController(o.fakeClient, o.watcher, nil, isReachable)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 		err = o.esvc.Start(1)
 		gomega.Expect(err).ToNot(gomega.HaveOccurred())
 	}
+	if len(config.OVNKubernetesFeature.LoadBalancerIPPools) > 0 {
+		o.lbc, err = loadbalancer.NewController(o.fakeClient.KubeClient, o.watcher)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		err = o.lbc.Start(1)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	}
 }
 
 func (o *FakeClusterManager) shutdown() {
@@ -91,4 +106,7 @@ func (o *FakeClusterManager) shutdown() {
 	if config.OVNKubernetesFeature.EnableEgressService {
 		o.esvc.Stop()
 	}
+	if o.lbc != nil {
+		o.lbc.Stop()
+	}
 }
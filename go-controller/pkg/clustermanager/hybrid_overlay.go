@@ -0,0 +1,140 @@
+package clustermanager
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	houtil "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+const (
+	// HybridOverlayConfigMapName is the name of the ConfigMap, in the
+	// OVN-Kubernetes config namespace, enabling or disabling the hybrid
+	// overlay at runtime in place of the hybrid overlay options
+	HybridOverlayConfigMapName = "hybrid-overlay"
+	// HybridOverlayEnabledKey is the key of the ConfigMap holding whether the
+	// hybrid overlay is enabled, "true" or "false"
+	HybridOverlayEnabledKey = "enabled"
+	// HybridOverlayClusterSubnetsKey is the key of the ConfigMap holding the
+	// hybrid overlay cluster subnets, in the format of the
+	// hybrid-overlay-cluster-subnets option
+	HybridOverlayClusterSubnetsKey = "cluster-subnets"
+)
+
+// parseHybridOverlayConfigMap returns whether the hybrid overlay ConfigMap
+// enables the hybrid overlay and its valid hybrid overlay cluster subnets
+func parseHybridOverlayConfigMap(cm *corev1.ConfigMap) (bool, []config.CIDRNetworkEntry, error) {
+	enabled, err := strconv.ParseBool(cm.Data[HybridOverlayEnabledKey])
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid %s %q: %w", HybridOverlayEnabledKey, cm.Data[HybridOverlayEnabledKey], err)
+	}
+	value := cm.Data[HybridOverlayClusterSubnetsKey]
+	if !enabled || value == "" {
+		return enabled, nil, nil
+	}
+	clusterSubnets, err := config.ParseClusterSubnetEntries(value)
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid hybrid overlay cluster subnets %q: %w", value, err)
+	}
+	if err := config.ValidateHybridOverlayClusterSubnets(clusterSubnets); err != nil {
+		return false, nil, fmt.Errorf("invalid hybrid overlay cluster subnets %q: %w", value, err)
+	}
+	return true, clusterSubnets, nil
+}
+
+// watchHybridOverlay watches the hybrid overlay ConfigMap and enables or
+// disables the hybrid overlay accordingly until the controller is stopped. The
+// hybrid overlay options apply again once the ConfigMap is deleted.
+func (ncc *networkClusterController) watchHybridOverlay() error {
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(ncc.kubeClient, 0,
+		informers.WithNamespace(config.Kubernetes.OVNConfigNamespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", HybridOverlayConfigMapName).String()
+		}))
+	informer := informerFactory.Core().V1().ConfigMaps().Informer()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ncc.syncHybridOverlayConfigMap(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			ncc.syncHybridOverlayConfigMap(newObj)
+		},
+		DeleteFunc: func(_ interface{}) {
+			klog.Infof("ConfigMap %s was deleted, applying the hybrid overlay options", HybridOverlayConfigMapName)
+			ncc.syncHybridOverlay(config.HybridOverlay.Enabled, config.HybridOverlay.ClusterSubnets)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add event handler for the hybrid overlay: %w", err)
+	}
+	informerFactory.Start(ncc.stopChan)
+	if !cache.WaitForCacheSync(ncc.stopChan, informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for the informer of the hybrid overlay to sync")
+	}
+	return nil
+}
+
+// syncHybridOverlayConfigMap enables or disables the hybrid overlay as
+// requested by the hybrid overlay ConfigMap. An invalid ConfigMap is ignored.
+func (ncc *networkClusterController) syncHybridOverlayConfigMap(obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		klog.Errorf("Could not cast %T object to *corev1.ConfigMap", obj)
+		return
+	}
+	enabled, clusterSubnets, err := parseHybridOverlayConfigMap(cm)
+	if err != nil {
+		klog.Errorf("Ignoring ConfigMap %s: %v", HybridOverlayConfigMapName, err)
+		return
+	}
+	ncc.syncHybridOverlay(enabled, clusterSubnets)
+}
+
+// syncHybridOverlay enables or disables the hybrid overlay in the node
+// allocator and, if it changed, retries the hybrid overlay nodes so that they
+// get a hybrid overlay subnet or their hybrid overlay subnet annotation is
+// removed
+func (ncc *networkClusterController) syncHybridOverlay(enabled bool, clusterSubnets []config.CIDRNetworkEntry) {
+	nodes, err := ncc.watchFactory.GetNodes()
+	if err != nil {
+		klog.Errorf("Unable to list nodes to sync the hybrid overlay: %v", err)
+		return
+	}
+	var changed bool
+	if enabled {
+		changed, err = ncc.nodeAllocator.EnableHybridOverlay(clusterSubnets, nodes)
+		if err != nil {
+			klog.Errorf("Failed to enable the hybrid overlay: %v", err)
+			return
+		}
+	} else {
+		changed = ncc.nodeAllocator.DisableHybridOverlay()
+	}
+	if !changed {
+		return
+	}
+
+	retried := 0
+	for _, node := range nodes {
+		if !houtil.IsHybridOverlayNode(node) {
+			continue
+		}
+		if err := ncc.retryNodes.AddRetryObjWithAddNoBackoff(node); err != nil {
+			klog.Errorf("Failed to retry node %s: %v", node.Name, err)
+			continue
+		}
+		retried++
+	}
+	if retried > 0 {
+		klog.Infof("Retrying %d hybrid overlay nodes after the hybrid overlay was enabled or disabled", retried)
+		ncc.retryNodes.RequestRetryObjs()
+	}
+}
@@ -40,3 +40,11 @@ func (ipGenerator *ipGenerator) GenerateIP(idx int) (*net.IPNet, error) {
 	}
 	return nil, fmt.Errorf("generated ip %s from the idx %d is out of range in the network %s", ip.String(), idx, ipGenerator.netCidr.String())
 }
+
+// overlapsNodeIDs returns whether the subnet holds addresses derived from the
+// node IDs
+func (ipGenerator *ipGenerator) overlapsNodeIDs(subnet *net.IPNet) bool {
+	first := new(big.Int).Sub(utilnet.BigForIP(subnet.IP), ipGenerator.netBaseIP)
+	last := new(big.Int).Add(first, big.NewInt(utilnet.RangeSize(subnet)-1))
+	return first.Cmp(big.NewInt(maxNodeIDs)) <= 0 && last.Sign() > 0
+}
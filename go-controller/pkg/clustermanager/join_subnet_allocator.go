@@ -0,0 +1,367 @@
+package clustermanager
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/bitmap"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// maxJoinNodeSubnets caps the number of node subnets of a join subnet, since
+// the allocator keeps a bitmap of that size
+const maxJoinNodeSubnets = 65536
+
+// joinSubnetAllocator allocates to each node a subnet of each join subnet, one
+// of each IP family, out of the join subnets, whatever their size. The address
+// of the gateway router port of the node on the join switch is the first one
+// of its subnet. The first address of each join subnet is the one of the
+// ovn_cluster_router port, the node subnets holding it or the network address
+// are never allocated. The subnets of the secondary networks are avoided when
+// allocating, and a secondary network whose subnets hold the address of a
+// node is rejected: the nodes are never renumbered.
+type joinSubnetAllocator struct {
+	sync.Mutex
+	// ranges holds the allocator of each join subnet
+	ranges []*joinSubnetRange
+	// nodes holds the addresses allocated, by node
+	nodes map[string][]*net.IPNet
+	// networkSubnets holds the subnets of the secondary networks, by network
+	networkSubnets map[string][]*net.IPNet
+}
+
+// joinSubnetRange allocates the node subnets of a join subnet
+type joinSubnetRange struct {
+	cidr *net.IPNet
+	// hostBits is the number of address bits of the node subnets
+	hostBits uint
+	// routerIP is the address of the ovn_cluster_router port
+	routerIP net.IP
+	// subnets holds the allocated node subnets, by index
+	subnets *bitmap.AllocationBitmap
+	// holders counts the nodes holding an address of each node subnet, by
+	// index: a node subnet may hold the addresses of several nodes allocated
+	// with a longer node subnet length, which keep them. The node subnets
+	// holding the ovn_cluster_router or reserved addresses are held forever.
+	holders map[int]int
+	// addresses holds the node holding each address
+	addresses map[string]string
+}
+
+// newJoinSubnetAllocator returns the allocator of the join subnets of the IP
+// families of the cluster, the node subnets holding the address of the
+// ovn_cluster_router port reserved
+func newJoinSubnetAllocator() (*joinSubnetAllocator, error) {
+	type joinSubnet struct {
+		cidr      string
+		subnetLen int
+	}
+	joinSubnets := []joinSubnet{}
+	if config.IPv4Mode {
+		joinSubnets = append(joinSubnets, joinSubnet{config.Gateway.V4JoinSubnet, config.Gateway.V4JoinNodeSubnetLength})
+	}
+	if config.IPv6Mode {
+		joinSubnets = append(joinSubnets, joinSubnet{config.Gateway.V6JoinSubnet, config.Gateway.V6JoinNodeSubnetLength})
+	}
+	jsa := &joinSubnetAllocator{
+		nodes:          map[string][]*net.IPNet{},
+		networkSubnets: map[string][]*net.IPNet{},
+	}
+	for _, joinSubnet := range joinSubnets {
+		_, cidr, err := net.ParseCIDR(joinSubnet.cidr)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing join subnet %s: %w", joinSubnet.cidr, err)
+		}
+		r, err := newJoinSubnetRange(cidr, joinSubnet.subnetLen)
+		if err != nil {
+			return nil, err
+		}
+		jsa.ranges = append(jsa.ranges, r)
+	}
+	return jsa, nil
+}
+
+func newJoinSubnetRange(cidr *net.IPNet, subnetLen int) (*joinSubnetRange, error) {
+	prefixLen, addrLen := cidr.Mask.Size()
+	if subnetLen < prefixLen || subnetLen > addrLen {
+		return nil, fmt.Errorf("invalid node subnet length %d of join subnet %s", subnetLen, cidr)
+	}
+	numSubnets := maxJoinNodeSubnets
+	if subnetLen-prefixLen < 16 {
+		numSubnets = 1 << (subnetLen - prefixLen)
+	}
+	r := &joinSubnetRange{
+		cidr:      cidr,
+		hostBits:  uint(addrLen - subnetLen),
+		routerIP:  utilnet.AddIPOffset(utilnet.BigForIP(cidr.IP), 1),
+		subnets:   bitmap.NewContiguousAllocationMap(numSubnets, cidr.String()),
+		holders:   map[int]int{},
+		addresses: map[string]string{},
+	}
+	// the node subnets holding the network address, the ovn_cluster_router
+	// address and, for IPv4, the broadcast address are never allocated
+	reserved := []net.IP{cidr.IP, r.routerIP}
+	if !utilnet.IsIPv6CIDR(cidr) && addrLen-prefixLen > 1 {
+		broadcast := make(net.IP, len(cidr.IP))
+		for i := range cidr.IP {
+			broadcast[i] = cidr.IP[i] | ^cidr.Mask[i]
+		}
+		reserved = append(reserved, broadcast)
+	}
+	for _, ip := range reserved {
+		if index, ok := r.index(ip); ok {
+			r.hold(index)
+		}
+	}
+	if r.subnets.Free() == 0 {
+		return nil, fmt.Errorf("join subnet %s is too small: no /%d subnet left for the nodes", cidr, subnetLen)
+	}
+	return r, nil
+}
+
+// index returns the index of the node subnet holding the address, and whether
+// the join subnet holds it
+func (r *joinSubnetRange) index(ip net.IP) (int, bool) {
+	if !r.cidr.Contains(ip) {
+		return 0, false
+	}
+	offset := new(big.Int).Sub(utilnet.BigForIP(ip), utilnet.BigForIP(r.cidr.IP))
+	offset.Rsh(offset, r.hostBits)
+	if !offset.IsInt64() || offset.Int64() >= int64(maxJoinNodeSubnets) {
+		return 0, false
+	}
+	return int(offset.Int64()), true
+}
+
+// subnet returns the node subnet of the given index
+func (r *joinSubnetRange) subnet(index int) *net.IPNet {
+	offset := new(big.Int).Lsh(big.NewInt(int64(index)), r.hostBits)
+	ip := net.IP(offset.Add(offset, utilnet.BigForIP(r.cidr.IP)).FillBytes(make([]byte, net.IPv6len)))
+	if ip4 := ip.To4(); ip4 != nil && !utilnet.IsIPv6CIDR(r.cidr) {
+		ip = ip4
+	}
+	_, addrLen := r.cidr.Mask.Size()
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(addrLen-int(r.hostBits), addrLen)}
+}
+
+// hold marks one more node as holding an address of the node subnet
+func (r *joinSubnetRange) hold(index int) {
+	if r.holders[index] == 0 {
+		_, _ = r.subnets.Allocate(index)
+	}
+	r.holders[index]++
+}
+
+// release marks one less node as holding an address of the node subnet, the
+// node subnet being released once none does
+func (r *joinSubnetRange) release(index int) {
+	if r.holders[index] == 0 {
+		return
+	}
+	r.holders[index]--
+	if r.holders[index] == 0 {
+		delete(r.holders, index)
+		r.subnets.Release(index)
+	}
+}
+
+// reserveNodeIPs reserves the addresses the node holds, on startup. Returns an
+// error if they are not valid anymore, like when held by another node, in
+// which case the node gets other addresses once handled.
+func (jsa *joinSubnetAllocator) reserveNodeIPs(node *corev1.Node) error {
+	jsa.Lock()
+	defer jsa.Unlock()
+	if _, ok := jsa.nodes[node.Name]; ok {
+		return nil
+	}
+	_, err := jsa.reserveAnnotatedIPsLocked(node)
+	return err
+}
+
+// allocateNodeIPs returns the addresses of the node: the ones already
+// allocated, else the ones it holds if still valid, else the first address of
+// new node subnets
+func (jsa *joinSubnetAllocator) allocateNodeIPs(node *corev1.Node) ([]*net.IPNet, error) {
+	jsa.Lock()
+	defer jsa.Unlock()
+	if ips, ok := jsa.nodes[node.Name]; ok {
+		return ips, nil
+	}
+	ips, err := jsa.reserveAnnotatedIPsLocked(node)
+	if err == nil {
+		return ips, nil
+	}
+	if !util.IsAnnotationNotSetError(err) {
+		klog.Warningf("Node %s gets new join subnet addresses: %v", node.Name, err)
+	}
+
+	ips = make([]*net.IPNet, 0, len(jsa.ranges))
+	for _, r := range jsa.ranges {
+		ip, err := jsa.allocateNextLocked(r, node.Name)
+		if err != nil {
+			jsa.releaseLocked(ips)
+			return nil, fmt.Errorf("failed to allocate a node subnet of join subnet %s: %w", r.cidr, err)
+		}
+		ips = append(ips, ip)
+	}
+	jsa.nodes[node.Name] = ips
+	return ips, nil
+}
+
+// releaseNodeIPs releases the node subnets of the deleted node
+func (jsa *joinSubnetAllocator) releaseNodeIPs(nodeName string) {
+	jsa.Lock()
+	defer jsa.Unlock()
+	jsa.releaseLocked(jsa.nodes[nodeName])
+	delete(jsa.nodes, nodeName)
+}
+
+// addNetworkSubnets avoids the node subnets overlapping the subnets of the
+// secondary network when allocating. Returns an error, the subnets not being
+// avoided, if they hold the address of the ovn_cluster_router port or the one
+// of a node: the nodes are never renumbered.
+func (jsa *joinSubnetAllocator) addNetworkSubnets(networkName string, subnets []*net.IPNet) error {
+	jsa.Lock()
+	defer jsa.Unlock()
+	for _, r := range jsa.ranges {
+		for _, subnet := range subnets {
+			if subnet.Contains(r.routerIP) {
+				return fmt.Errorf("subnet %s of network %s holds the ovn_cluster_router address %s of the join switch",
+					subnet, networkName, r.routerIP)
+			}
+		}
+	}
+	for nodeName, ips := range jsa.nodes {
+		for _, ip := range ips {
+			for _, subnet := range subnets {
+				if subnet.Contains(ip.IP) {
+					return fmt.Errorf("subnet %s of network %s holds the join subnet address %s of node %s",
+						subnet, networkName, ip.IP, nodeName)
+				}
+			}
+		}
+	}
+	jsa.networkSubnets[networkName] = subnets
+	return nil
+}
+
+// deleteNetworkSubnets allows again the node subnets overlapping the subnets
+// of the deleted secondary network
+func (jsa *joinSubnetAllocator) deleteNetworkSubnets(networkName string) {
+	jsa.Lock()
+	defer jsa.Unlock()
+	delete(jsa.networkSubnets, networkName)
+}
+
+// reserveAnnotatedIPsLocked reserves the addresses annotated on the node, and
+// the node subnets holding them, provided they are of the join subnets and
+// held by no other node. The addresses need not be the first of their node
+// subnet, nor out of the subnets of the secondary networks: a node keeps the
+// addresses it holds.
+func (jsa *joinSubnetAllocator) reserveAnnotatedIPsLocked(node *corev1.Node) ([]*net.IPNet, error) {
+	annotatedIPs, err := util.ParseNodeGatewayRouterLRPAddrs(node)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]*net.IPNet, 0, len(jsa.ranges))
+	for _, r := range jsa.ranges {
+		var ip *net.IPNet
+		for _, annotatedIP := range annotatedIPs {
+			if r.cidr.Contains(annotatedIP.IP) {
+				ip = &net.IPNet{IP: annotatedIP.IP, Mask: r.cidr.Mask}
+				break
+			}
+		}
+		if ip == nil {
+			jsa.releaseLocked(ips)
+			return nil, fmt.Errorf("no address of join subnet %s annotated on node %s", r.cidr, node.Name)
+		}
+		index, ok := r.index(ip.IP)
+		if !ok || ip.IP.Equal(r.cidr.IP) || ip.IP.Equal(r.routerIP) {
+			jsa.releaseLocked(ips)
+			return nil, fmt.Errorf("join subnet address %s of node %s is reserved", ip.IP, node.Name)
+		}
+		if owner, ok := r.addresses[ip.IP.String()]; ok && owner != node.Name {
+			jsa.releaseLocked(ips)
+			return nil, fmt.Errorf("join subnet address %s of node %s is held by node %s", ip.IP, node.Name, owner)
+		}
+		r.hold(index)
+		r.addresses[ip.IP.String()] = node.Name
+		ips = append(ips, ip)
+	}
+	jsa.nodes[node.Name] = ips
+	return ips, nil
+}
+
+// allocateNextLocked allocates to the node the next free node subnet of the
+// join subnet not overlapping the subnets of the secondary networks, and
+// returns its first address, with the mask of the join subnet
+func (jsa *joinSubnetAllocator) allocateNextLocked(r *joinSubnetRange, nodeName string) (*net.IPNet, error) {
+	// the skipped node subnets are held until a node subnet is found so that
+	// they are not allocated again
+	skipped := []int{}
+	defer func() {
+		for _, index := range skipped {
+			r.subnets.Release(index)
+		}
+	}()
+	for {
+		index, ok, err := r.subnets.AllocateNext()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if len(skipped) > 0 {
+				return nil, fmt.Errorf("no free /%d subnet: the free ones overlap the subnets of secondary networks",
+					len(r.cidr.IP)*8-int(r.hostBits))
+			}
+			return nil, fmt.Errorf("no free /%d subnet", len(r.cidr.IP)*8-int(r.hostBits))
+		}
+		subnet := r.subnet(index)
+		if jsa.collidingSubnetLocked(subnet) != nil {
+			skipped = append(skipped, index)
+			continue
+		}
+		r.holders[index] = 1
+		r.addresses[subnet.IP.String()] = nodeName
+		return &net.IPNet{IP: subnet.IP, Mask: r.cidr.Mask}, nil
+	}
+}
+
+// collidingSubnetLocked returns the subnet of a secondary network overlapping
+// the node subnet, if any
+func (jsa *joinSubnetAllocator) collidingSubnetLocked(nodeSubnet *net.IPNet) *net.IPNet {
+	for _, subnets := range jsa.networkSubnets {
+		for _, subnet := range subnets {
+			if subnet.Contains(nodeSubnet.IP) || nodeSubnet.Contains(subnet.IP) {
+				return subnet
+			}
+		}
+	}
+	return nil
+}
+
+// releaseLocked releases the addresses, and the node subnets holding them
+// once they hold no other address
+func (jsa *joinSubnetAllocator) releaseLocked(ips []*net.IPNet) {
+	for _, ip := range ips {
+		for _, r := range jsa.ranges {
+			index, ok := r.index(ip.IP)
+			if !ok {
+				continue
+			}
+			if _, ok := r.addresses[ip.IP.String()]; !ok {
+				continue
+			}
+			delete(r.addresses, ip.IP.String())
+			r.release(index)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package clustermanager
+
+import (
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+)
+
+var _ = ginkgo.Describe("Cluster manager join subnet allocator", func() {
+	newNode := func(name, joinIPs string) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{}}}
+		if joinIPs != "" {
+			node.Annotations[ovnNodeGRLRPAddrAnnotaton] = joinIPs
+		}
+		return node
+	}
+	ipStrings := func(ips []*net.IPNet) []string {
+		strs := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			strs = append(strs, ip.String())
+		}
+		return strs
+	}
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		config.IPv4Mode = true
+		config.IPv6Mode = false
+		config.Gateway.V4JoinSubnet = "100.64.0.0/29"
+	})
+
+	ginkgo.It("allocates the addresses in order, after the one of ovn_cluster_router", func() {
+		jsa, err := newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		// a /29 holds the ovn_cluster_router address and 5 node addresses
+		for i, expected := range []string{"100.64.0.2/29", "100.64.0.3/29", "100.64.0.4/29", "100.64.0.5/29", "100.64.0.6/29"} {
+			ips, err := jsa.allocateNodeIPs(newNode(string(rune('a'+i)), ""))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{expected}))
+		}
+		_, err = jsa.allocateNodeIPs(newNode("f", ""))
+		gomega.Expect(err).To(gomega.HaveOccurred())
+
+		// the addresses of a deleted node are allocated again
+		jsa.releaseNodeIPs("b")
+		ips, err := jsa.allocateNodeIPs(newNode("f", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.3/29"}))
+	})
+
+	ginkgo.It("keeps the annotated addresses and replaces the duplicate ones", func() {
+		jsa, err := newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		gomega.Expect(jsa.reserveNodeIPs(newNode("node1", `{"ipv4":"100.64.0.5/29"}`))).To(gomega.Succeed())
+		gomega.Expect(jsa.reserveNodeIPs(newNode("node2", `{"ipv4":"100.64.0.5/29"}`))).NotTo(gomega.Succeed())
+
+		ips, err := jsa.allocateNodeIPs(newNode("node1", `{"ipv4":"100.64.0.5/29"}`))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.5/29"}))
+		ips, err = jsa.allocateNodeIPs(newNode("node2", `{"ipv4":"100.64.0.5/29"}`))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.2/29"}))
+	})
+
+	ginkgo.It("avoids the subnets of the secondary networks", func() {
+		jsa, err := newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		gomega.Expect(jsa.addNetworkSubnets("blue", []*net.IPNet{ovntest.MustParseIPNet("100.64.0.2/31")})).To(gomega.Succeed())
+		ips, err := jsa.allocateNodeIPs(newNode("node1", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.4/29"}))
+
+		// the addresses of the deleted network are allocated again
+		jsa.deleteNetworkSubnets("blue")
+		ips, err = jsa.allocateNodeIPs(newNode("node2", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.2/29"}))
+	})
+
+	ginkgo.It("rejects the subnets of a secondary network holding the address of a node", func() {
+		jsa, err := newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ips, err := jsa.allocateNodeIPs(newNode("node1", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.2/29"}))
+
+		// node1 keeps its address, the network is not added
+		gomega.Expect(jsa.addNetworkSubnets("blue", []*net.IPNet{ovntest.MustParseIPNet("100.64.0.2/31")})).NotTo(gomega.Succeed())
+		ips, err = jsa.allocateNodeIPs(newNode("node1", `{"ipv4":"100.64.0.2/29"}`))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.2/29"}))
+		ips, err = jsa.allocateNodeIPs(newNode("node2", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.3/29"}))
+
+		// on restart too
+		jsa, err = newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(jsa.reserveNodeIPs(newNode("node1", `{"ipv4":"100.64.0.2/29"}`))).To(gomega.Succeed())
+		gomega.Expect(jsa.addNetworkSubnets("blue", []*net.IPNet{ovntest.MustParseIPNet("100.64.0.2/31")})).NotTo(gomega.Succeed())
+
+		// the network is added once the node is deleted
+		jsa.releaseNodeIPs("node1")
+		gomega.Expect(jsa.addNetworkSubnets("blue", []*net.IPNet{ovntest.MustParseIPNet("100.64.0.2/31")})).To(gomega.Succeed())
+		ips, err = jsa.allocateNodeIPs(newNode("node3", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.4/29"}))
+	})
+
+	ginkgo.It("rejects the subnets of a secondary network holding the ovn_cluster_router address", func() {
+		jsa, err := newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		gomega.Expect(jsa.addNetworkSubnets("blue", []*net.IPNet{ovntest.MustParseIPNet("100.64.0.0/30")})).NotTo(gomega.Succeed())
+		ips, err := jsa.allocateNodeIPs(newNode("node1", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.2/29"}))
+	})
+
+	ginkgo.It("allocates a subnet of the configured length to each node", func() {
+		config.Gateway.V4JoinSubnet = "100.64.0.0/28"
+		config.Gateway.V4JoinNodeSubnetLength = 30
+		jsa, err := newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		// the first /30 holds the ovn_cluster_router address, the last one
+		// the broadcast address
+		for i, expected := range []string{"100.64.0.4/28", "100.64.0.8/28"} {
+			ips, err := jsa.allocateNodeIPs(newNode(string(rune('a'+i)), ""))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{expected}))
+		}
+		_, err = jsa.allocateNodeIPs(newNode("c", ""))
+		gomega.Expect(err).To(gomega.HaveOccurred())
+
+		// the nodes keep the addresses they got with a longer node subnet
+		// length, the node subnet holding them being released once none does
+		jsa, err = newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(jsa.reserveNodeIPs(newNode("a", `{"ipv4":"100.64.0.2/28"}`))).To(gomega.Succeed())
+		gomega.Expect(jsa.reserveNodeIPs(newNode("b", `{"ipv4":"100.64.0.4/28"}`))).To(gomega.Succeed())
+		gomega.Expect(jsa.reserveNodeIPs(newNode("c", `{"ipv4":"100.64.0.5/28"}`))).To(gomega.Succeed())
+		ips, err := jsa.allocateNodeIPs(newNode("d", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.8/28"}))
+		jsa.releaseNodeIPs("b")
+		_, err = jsa.allocateNodeIPs(newNode("e", ""))
+		gomega.Expect(err).To(gomega.HaveOccurred())
+		jsa.releaseNodeIPs("c")
+		ips, err = jsa.allocateNodeIPs(newNode("e", ""))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"100.64.0.4/28"}))
+	})
+
+	ginkgo.It("rejects a join subnet without address for the nodes", func() {
+		config.Gateway.V4JoinSubnet = "100.64.0.0/30"
+		_, err := newJoinSubnetAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		config.Gateway.V4JoinSubnet = "100.64.0.0/31"
+		_, err = newJoinSubnetAllocator()
+		gomega.Expect(err).To(gomega.HaveOccurred())
+	})
+})
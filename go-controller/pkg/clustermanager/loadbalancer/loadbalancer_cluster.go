@@ -0,0 +1,526 @@
+package loadbalancer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	bitmapallocator "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/bitmap"
+	ipallocator "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/ip"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+const maxRetries = 10
+
+// Controller is the built-in load balancer provider for bare metal clusters.
+// It allocates the IPs of the LoadBalancer services from the configured pools,
+// sets them as the ingress IPs of the services, which get them programmed in
+// the OVN load balancers like any other ingress IP, and elects the node
+// announcing them.
+type Controller struct {
+	sync.Mutex
+	client       kubernetes.Interface
+	watchFactory *factory.WatchFactory
+	stopCh       chan struct{}
+	wg           *sync.WaitGroup
+
+	pools       []*ipallocator.Range
+	allocations map[string][]net.IP // svc key -> allocated IPs
+	// services which could not get IPs because the pools are exhausted,
+	// queued again when IPs are released
+	unallocatedServices sets.Set[string]
+
+	servicesSynced       cache.InformerSynced
+	endpointSlicesSynced cache.InformerSynced
+	nodesSynced          cache.InformerSynced
+	servicesQueue        workqueue.RateLimitingInterface
+}
+
+func NewController(client kubernetes.Interface, wf *factory.WatchFactory) (*Controller, error) {
+	klog.Info("Setting up event handlers for the built-in load balancer provider")
+
+	c := &Controller{
+		client:              client,
+		watchFactory:        wf,
+		stopCh:              make(chan struct{}),
+		wg:                  &sync.WaitGroup{},
+		allocations:         map[string][]net.IP{},
+		unallocatedServices: sets.New[string](),
+	}
+	for _, cidr := range config.OVNKubernetesFeature.LoadBalancerIPPools {
+		// IPs are allocated in order so that the allocations are predictable
+		pool, err := ipallocator.NewAllocatorCIDRRange(cidr, func(max int, rangeSpec string) (bitmapallocator.Interface, error) {
+			return bitmapallocator.NewContiguousAllocationMap(max, rangeSpec), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the load balancer IP pool %s: %w", cidr, err)
+		}
+		c.pools = append(c.pools, pool)
+	}
+
+	c.servicesQueue = workqueue.NewNamedRateLimitingQueue(
+		workqueue.NewItemFastSlowRateLimiter(1*time.Second, 5*time.Second, 5),
+		"loadbalancerservices",
+	)
+
+	c.servicesSynced = wf.ServiceInformer().HasSynced
+	_, err := wf.ServiceInformer().AddEventHandler(factory.WithUpdateHandlingForObjReplace(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onServiceAdd,
+		UpdateFunc: c.onServiceUpdate,
+		DeleteFunc: c.onServiceDelete,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	c.endpointSlicesSynced = wf.EndpointSliceInformer().HasSynced
+	_, err = wf.EndpointSliceInformer().AddEventHandler(factory.WithUpdateHandlingForObjReplace(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onEndpointSliceAdd,
+		UpdateFunc: c.onEndpointSliceUpdate,
+		DeleteFunc: c.onEndpointSliceDelete,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	c.nodesSynced = wf.NodeInformer().HasSynced
+	_, err = wf.NodeInformer().AddEventHandler(factory.WithUpdateHandlingForObjReplace(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onNodeAdd,
+		UpdateFunc: c.onNodeUpdate,
+		DeleteFunc: c.onNodeDelete,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Controller) Start(threadiness int) error {
+	defer utilruntime.HandleCrash()
+
+	klog.Infof("Starting the built-in load balancer provider")
+	if !util.WaitForNamedCacheSyncWithTimeout("loadbalancer_services", c.stopCh, c.servicesSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+
+	if !util.WaitForNamedCacheSyncWithTimeout("loadbalancer_endpointslices", c.stopCh, c.endpointSlicesSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+
+	if !util.WaitForNamedCacheSyncWithTimeout("loadbalancer_nodes", c.stopCh, c.nodesSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+
+	klog.Infof("Repairing the IP allocations of the LoadBalancer services")
+	if err := c.repair(); err != nil {
+		klog.Errorf("Failed to repair the IP allocations of the LoadBalancer services: %v", err)
+	}
+
+	for i := 0; i < threadiness; i++ {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			wait.Until(func() {
+				c.runServiceWorker(c.wg)
+			}, time.Second, c.stopCh)
+		}()
+	}
+
+	return nil
+}
+
+func (c *Controller) Stop() {
+	klog.Infof("Shutting down the built-in load balancer provider")
+
+	close(c.stopCh)
+	c.servicesQueue.ShutDown()
+	c.wg.Wait()
+}
+
+// isManaged returns whether the service is a LoadBalancer service handled by
+// the built-in load balancer provider, that is without a class or of the
+// ovn-kubernetes class
+func isManaged(svc *corev1.Service) bool {
+	if !util.ServiceTypeHasLoadBalancer(svc) {
+		return false
+	}
+	return svc.Spec.LoadBalancerClass == nil || *svc.Spec.LoadBalancerClass == types.LoadBalancerClass
+}
+
+// repair reserves the ingress IPs the LoadBalancer services already have, so
+// that they keep them across restarts. Duplicate IPs are reallocated when the
+// services are synced.
+func (c *Controller) repair() error {
+	c.Lock()
+	defer c.Unlock()
+
+	services, err := c.watchFactory.GetServices()
+	if err != nil {
+		return err
+	}
+	for _, svc := range services {
+		if !isManaged(svc) {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(svc)
+		if err != nil {
+			klog.Errorf("Failed to read Service key: %v", err)
+			continue
+		}
+		for _, ip := range ingressIPs(svc) {
+			pool := c.poolFor(ip)
+			if pool == nil {
+				continue
+			}
+			if err := pool.Allocate(ip); err != nil {
+				klog.Warningf("Load balancer IP %s of service %s can't be kept: %v", ip, key, err)
+				continue
+			}
+			c.allocations[key] = append(c.allocations[key], ip)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) runServiceWorker(wg *sync.WaitGroup) {
+	for c.processNextServiceWorkItem(wg) {
+	}
+}
+
+func (c *Controller) processNextServiceWorkItem(wg *sync.WaitGroup) bool {
+	wg.Add(1)
+	defer wg.Done()
+
+	key, quit := c.servicesQueue.Get()
+	if quit {
+		return false
+	}
+
+	defer c.servicesQueue.Done(key)
+
+	err := c.syncService(key.(string))
+	if err == nil {
+		c.servicesQueue.Forget(key)
+		return true
+	}
+
+	utilruntime.HandleError(fmt.Errorf("%v failed with : %v", key, err))
+
+	if c.servicesQueue.NumRequeues(key) < maxRetries {
+		c.servicesQueue.AddRateLimited(key)
+		return true
+	}
+
+	c.servicesQueue.Forget(key)
+	return true
+}
+
+func (c *Controller) syncService(key string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	startTime := time.Now()
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("Processing sync for LoadBalancer service %s/%s", namespace, name)
+
+	defer func() {
+		klog.V(4).Infof("Finished syncing LoadBalancer service %s/%s : %v", namespace, name, time.Since(startTime))
+	}()
+
+	svc, err := c.watchFactory.GetService(namespace, name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if svc == nil {
+		// The service was deleted, its IPs can be reused.
+		c.releaseIPs(key)
+		return nil
+	}
+
+	if !isManaged(svc) {
+		// The service is no longer a LoadBalancer service handled by the
+		// provider, we clear what we set on it before releasing its IPs.
+		if _, found := c.allocations[key]; !found {
+			c.unallocatedServices.Delete(key)
+			return nil
+		}
+		if err := c.setServiceNode(svc, ""); err != nil {
+			return err
+		}
+		if err := c.setServiceIngressIPs(svc, nil); err != nil {
+			return err
+		}
+		c.releaseIPs(key)
+		return nil
+	}
+
+	ips, err := c.allocateIPs(key, svc)
+	if err != nil {
+		c.unallocatedServices.Insert(key)
+		return err
+	}
+	c.unallocatedServices.Delete(key)
+
+	if err := c.setServiceIngressIPs(svc, ips); err != nil {
+		return err
+	}
+
+	if config.OVNKubernetesFeature.LoadBalancerAnnounceMode == config.LoadBalancerAnnounceModeNone {
+		return c.setServiceNode(svc, "")
+	}
+	node, err := c.selectNodeFor(key, svc)
+	if err != nil {
+		return err
+	}
+	if node == "" {
+		klog.Warningf("No node can announce the IPs of LoadBalancer service %s", key)
+	}
+	return c.setServiceNode(svc, node)
+}
+
+// allocateIPs returns the IPs of the service, one for each of its IP families
+// with a pool. The IPs already allocated to the service are kept, otherwise
+// the requested spec.loadBalancerIP is allocated if possible, or the next free
+// IP of the pools. Must be called with the controller locked.
+func (c *Controller) allocateIPs(key string, svc *corev1.Service) ([]net.IP, error) {
+	families := svc.Spec.IPFamilies
+	if len(families) == 0 {
+		if config.IPv4Mode {
+			families = append(families, corev1.IPv4Protocol)
+		}
+		if config.IPv6Mode {
+			families = append(families, corev1.IPv6Protocol)
+		}
+	}
+
+	var requested net.IP
+	if svc.Spec.LoadBalancerIP != "" {
+		requested = utilnet.ParseIPSloppy(svc.Spec.LoadBalancerIP)
+	}
+
+	allocated := c.allocations[key]
+	var ips []net.IP
+	var errs []error
+	for _, family := range families {
+		isIPv6 := family == corev1.IPv6Protocol
+		if !c.hasPool(isIPv6) {
+			continue
+		}
+		var ip net.IP
+		for _, allocatedIP := range allocated {
+			if utilnet.IsIPv6(allocatedIP) == isIPv6 && (requested == nil || utilnet.IsIPv6(requested) != isIPv6 || requested.Equal(allocatedIP)) {
+				ip = allocatedIP
+				break
+			}
+		}
+		if ip == nil {
+			var err error
+			ip, err = c.allocateIP(isIPv6, requested)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to allocate an %s load balancer IP: %w", family, err))
+				continue
+			}
+			klog.Infof("Allocated load balancer IP %s to service %s", ip, key)
+		}
+		ips = append(ips, ip)
+	}
+
+	// release the IPs no longer used, like those of a removed IP family or
+	// replaced by the requested IP
+	for _, allocatedIP := range allocated {
+		if !containsIP(ips, allocatedIP) {
+			c.releaseIP(allocatedIP)
+		}
+	}
+	if len(ips) > 0 {
+		c.allocations[key] = ips
+	} else {
+		delete(c.allocations, key)
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no load balancer IP pool for the IP families %v", families)
+	}
+	return ips, nil
+}
+
+// allocateIP allocates the requested IP, if of the IP family and in a pool,
+// or the next free IP of the pools of the IP family
+func (c *Controller) allocateIP(isIPv6 bool, requested net.IP) (net.IP, error) {
+	if requested != nil && utilnet.IsIPv6(requested) == isIPv6 {
+		pool := c.poolFor(requested)
+		if pool == nil {
+			return nil, fmt.Errorf("requested IP %s is not in a load balancer IP pool", requested)
+		}
+		if err := pool.Allocate(requested); err != nil {
+			return nil, fmt.Errorf("requested IP %s can't be allocated: %w", requested, err)
+		}
+		return requested, nil
+	}
+	for _, pool := range c.pools {
+		if cidr := pool.CIDR(); utilnet.IsIPv6CIDR(&cidr) != isIPv6 {
+			continue
+		}
+		ip, err := pool.AllocateNext()
+		if errors.Is(err, ipallocator.ErrFull) {
+			continue
+		}
+		return ip, err
+	}
+	return nil, ipallocator.ErrFull
+}
+
+// releaseIPs releases the IPs of the service. Must be called with the
+// controller locked.
+func (c *Controller) releaseIPs(key string) {
+	c.unallocatedServices.Delete(key)
+	ips, found := c.allocations[key]
+	if !found {
+		return
+	}
+	for _, ip := range ips {
+		c.releaseIP(ip)
+	}
+	delete(c.allocations, key)
+	klog.Infof("Released load balancer IPs %v of service %s", ips, key)
+}
+
+// releaseIP releases the IP and queues the services which are waiting for one
+func (c *Controller) releaseIP(ip net.IP) {
+	pool := c.poolFor(ip)
+	if pool == nil {
+		return
+	}
+	pool.Release(ip)
+	for key := range c.unallocatedServices {
+		c.servicesQueue.Add(key)
+	}
+}
+
+func (c *Controller) poolFor(ip net.IP) *ipallocator.Range {
+	for _, pool := range c.pools {
+		if cidr := pool.CIDR(); cidr.Contains(ip) {
+			return pool
+		}
+	}
+	return nil
+}
+
+func (c *Controller) hasPool(isIPv6 bool) bool {
+	for _, pool := range c.pools {
+		if cidr := pool.CIDR(); utilnet.IsIPv6CIDR(&cidr) == isIPv6 {
+			return true
+		}
+	}
+	return false
+}
+
+// setServiceIngressIPs replaces the ingress IPs of the service, keeping the
+// ingress IPs out of the pools which are not ours to manage
+func (c *Controller) setServiceIngressIPs(svc *corev1.Service, ips []net.IP) error {
+	ingress := []corev1.LoadBalancerIngress{}
+	for _, ip := range ips {
+		ingress = append(ingress, corev1.LoadBalancerIngress{IP: ip.String()})
+	}
+	for _, current := range svc.Status.LoadBalancer.Ingress {
+		ip := utilnet.ParseIPSloppy(current.IP)
+		if ip == nil || c.poolFor(ip) == nil {
+			ingress = append(ingress, current)
+		}
+	}
+	if ingressEqual(svc.Status.LoadBalancer.Ingress, ingress) {
+		return nil
+	}
+	updated := svc.DeepCopy()
+	updated.Status.LoadBalancer.Ingress = ingress
+	_, err := c.client.CoreV1().Services(svc.Namespace).UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to set the ingress IPs %v of service %s/%s: %w", ips, svc.Namespace, svc.Name, err)
+	}
+	return nil
+}
+
+// setServiceNode sets the node announcing the IPs of the service in its
+// annotation, or removes the annotation when the node is empty
+func (c *Controller) setServiceNode(svc *corev1.Service, node string) error {
+	if svc.Annotations[types.LoadBalancerNodeAnnotation] == node {
+		return nil
+	}
+	var value interface{}
+	if node != "" {
+		value = node
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{types.LoadBalancerNodeAnnotation: value},
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.CoreV1().Services(svc.Namespace).Patch(context.TODO(), svc.Name, ktypes.MergePatchType, patchData, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to set the load balancer node %q of service %s/%s: %w", node, svc.Namespace, svc.Name, err)
+	}
+	return nil
+}
+
+// ingressIPs returns the ingress IPs of the service
+func ingressIPs(svc *corev1.Service) []net.IP {
+	var ips []net.IP
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ip := utilnet.ParseIPSloppy(ingress.IP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func ingressEqual(a, b []corev1.LoadBalancerIngress) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].IP != b[i].IP || a[i].Hostname != b[i].Hostname {
+			return false
+		}
+	}
+	return true
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, candidate := range ips {
+		if candidate.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
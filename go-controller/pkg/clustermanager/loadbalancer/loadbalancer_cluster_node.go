@@ -0,0 +1,216 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+func (c *Controller) onServiceAdd(obj interface{}) {
+	service := obj.(*corev1.Service)
+	if !isManaged(service) {
+		return
+	}
+	c.queueService(obj)
+}
+
+func (c *Controller) onServiceUpdate(oldObj, newObj interface{}) {
+	oldService := oldObj.(*corev1.Service)
+	newService := newObj.(*corev1.Service)
+
+	// don't process resync or objects that are marked for deletion
+	if oldService.ResourceVersion == newService.ResourceVersion ||
+		!newService.GetDeletionTimestamp().IsZero() {
+		return
+	}
+
+	// We care about services which are or were managed, the latter to release their IPs
+	if !isManaged(oldService) && !isManaged(newService) {
+		return
+	}
+	c.queueService(newObj)
+}
+
+func (c *Controller) onServiceDelete(obj interface{}) {
+	if service, ok := obj.(*corev1.Service); ok && !isManaged(service) {
+		return
+	}
+	c.queueService(obj)
+}
+
+func (c *Controller) queueService(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %+v: %v", obj, err))
+		return
+	}
+	c.servicesQueue.Add(key)
+}
+
+func (c *Controller) onEndpointSliceAdd(obj interface{}) {
+	c.queueEndpointSliceService(obj)
+}
+
+func (c *Controller) onEndpointSliceUpdate(oldObj, newObj interface{}) {
+	oldEndpointSlice := oldObj.(*discovery.EndpointSlice)
+	newEndpointSlice := newObj.(*discovery.EndpointSlice)
+	if oldEndpointSlice.ResourceVersion == newEndpointSlice.ResourceVersion ||
+		!newEndpointSlice.GetDeletionTimestamp().IsZero() {
+		return
+	}
+	c.queueEndpointSliceService(newObj)
+}
+
+func (c *Controller) onEndpointSliceDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	c.queueEndpointSliceService(obj)
+}
+
+// queueEndpointSliceService queues the service of the endpoint slice if it is
+// a managed service with externalTrafficPolicy=Local, as only the nodes with
+// local endpoints can announce its IPs
+func (c *Controller) queueEndpointSliceService(obj interface{}) {
+	endpointSlice, ok := obj.(*discovery.EndpointSlice)
+	if !ok {
+		return
+	}
+	serviceName := endpointSlice.Labels[discovery.LabelServiceName]
+	if serviceName == "" {
+		return
+	}
+	svc, err := c.watchFactory.GetService(endpointSlice.Namespace, serviceName)
+	if err != nil || !isManaged(svc) || svc.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+		return
+	}
+	c.queueService(svc)
+}
+
+func (c *Controller) onNodeAdd(obj interface{}) {
+	c.queueAllServices()
+}
+
+func (c *Controller) onNodeUpdate(oldObj, newObj interface{}) {
+	oldNode := oldObj.(*corev1.Node)
+	newNode := newObj.(*corev1.Node)
+	if nodeIsEligible(oldNode) == nodeIsEligible(newNode) {
+		return
+	}
+	c.queueAllServices()
+}
+
+func (c *Controller) onNodeDelete(obj interface{}) {
+	c.queueAllServices()
+}
+
+// queueAllServices queues all the managed services so that they elect a new
+// node when the eligible nodes change
+func (c *Controller) queueAllServices() {
+	services, err := c.watchFactory.GetServices()
+	if err != nil {
+		klog.Errorf("Failed to list services: %v", err)
+		return
+	}
+	for _, svc := range services {
+		if isManaged(svc) {
+			c.queueService(svc)
+		}
+	}
+}
+
+// nodeIsEligible returns whether the node can announce the IPs of the
+// LoadBalancer services: it must be ready, managed by ovn-kubernetes and not
+// excluded from the external load balancers
+func nodeIsEligible(node *corev1.Node) bool {
+	if util.NoHostSubnet(node) {
+		return false
+	}
+	if _, excluded := node.Labels[corev1.LabelNodeExcludeBalancers]; excluded {
+		return false
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// selectNodeFor returns the node announcing the IPs of the service. The
+// current node is kept while it is eligible, otherwise the node is picked by
+// rendezvous hashing of the service and the eligible nodes, which spreads the
+// services across the nodes and only moves those of the nodes that went away.
+// Services with externalTrafficPolicy=Local can only be announced by the nodes
+// with local endpoints. An empty node is returned if no node is eligible.
+func (c *Controller) selectNodeFor(key string, svc *corev1.Service) (string, error) {
+	nodes, err := c.watchFactory.GetNodes()
+	if err != nil {
+		return "", err
+	}
+
+	var endpointNodes sets.Set[string]
+	if svc.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeLocal {
+		endpointNodes, err = c.readyEndpointNodesFor(svc)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	current := svc.Annotations[types.LoadBalancerNodeAnnotation]
+	selected := ""
+	var selectedScore uint64
+	for _, node := range nodes {
+		if !nodeIsEligible(node) || (endpointNodes != nil && !endpointNodes.Has(node.Name)) {
+			continue
+		}
+		if node.Name == current {
+			return current, nil
+		}
+		score := rendezvousScore(key, node.Name)
+		if selected == "" || score > selectedScore {
+			selected, selectedScore = node.Name, score
+		}
+	}
+	return selected, nil
+}
+
+// readyEndpointNodesFor returns the nodes with ready endpoints of the service
+func (c *Controller) readyEndpointNodesFor(svc *corev1.Service) (sets.Set[string], error) {
+	endpointSlices, err := c.watchFactory.GetEndpointSlices(svc.Namespace, svc.Name)
+	if err != nil {
+		return nil, err
+	}
+	nodes := sets.New[string]()
+	for _, endpointSlice := range endpointSlices {
+		if endpointSlice.AddressType == discovery.AddressTypeFQDN {
+			continue
+		}
+		for _, endpoint := range endpointSlice.Endpoints {
+			if endpoint.NodeName == nil {
+				continue
+			}
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			nodes.Insert(*endpoint.NodeName)
+		}
+	}
+	return nodes, nil
+}
+
+func rendezvousScore(key, node string) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(node))
+	return hash.Sum64()
+}
@@ -0,0 +1,245 @@
+package clustermanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/urfave/cli/v2"
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = ginkgo.Describe("Cluster manager built-in load balancer provider operations", func() {
+	var (
+		app    *cli.App
+		fakeCM *FakeClusterManager
+	)
+	const (
+		node1Name       string = "node1"
+		node1IPv4       string = "100.100.100.0"
+		node1IPv6       string = "fc00:f853:ccd:e793::1"
+		node1IPv4Subnet string = "10.128.1.0/24"
+		node1IPv6Subnet string = "fe00:10:128:1::/64"
+		node2Name       string = "node2"
+		node2IPv4       string = "200.200.200.0"
+		node2IPv6       string = "fc00:f853:ccd:e793::2"
+		node2IPv4Subnet string = "10.128.2.0/24"
+		node2IPv6Subnet string = "fe00:10:128:2::/64"
+	)
+
+	ginkgo.BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+		config.OVNKubernetesFeature.EnableEgressIP = false
+		_, cidr4, _ := net.ParseCIDR("10.128.0.0/16")
+		config.Default.ClusterSubnets = []config.CIDRNetworkEntry{{cidr4, 24}}
+		config.IPv4Mode = true
+		// a /30 pool has 2 usable IPs
+		_, pool, _ := net.ParseCIDR("192.168.10.0/30")
+		config.OVNKubernetesFeature.LoadBalancerIPPools = []*net.IPNet{pool}
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fakeCM = NewFakeClusterManagerOVN()
+	})
+
+	ginkgo.AfterEach(func() {
+		fakeCM.shutdown()
+	})
+
+	getService := func(name string) (*v1.Service, error) {
+		return fakeCM.fakeClient.KubeClient.CoreV1().Services("testns").Get(context.TODO(), name, metav1.GetOptions{})
+	}
+
+	expectService := func(name, ip, node string) {
+		gomega.Eventually(func() error {
+			svc, err := getService(name)
+			if err != nil {
+				return err
+			}
+			if len(svc.Status.LoadBalancer.Ingress) != 1 || svc.Status.LoadBalancer.Ingress[0].IP != ip {
+				return fmt.Errorf("expected service %s to have the ingress IP %s, got %v", name, ip, svc.Status.LoadBalancer.Ingress)
+			}
+			if svc.Annotations[types.LoadBalancerNodeAnnotation] != node {
+				return fmt.Errorf("expected service %s to be announced by %q, got %q", name, node,
+					svc.Annotations[types.LoadBalancerNodeAnnotation])
+			}
+			return nil
+		}).ShouldNot(gomega.HaveOccurred())
+	}
+
+	newLoadBalancerService := func(name string, ingressIPs ...string) v1.Service {
+		svc := lbSvcFor("testns", name)
+		svc.Status.LoadBalancer.Ingress = nil
+		for _, ip := range ingressIPs {
+			svc.Status.LoadBalancer.Ingress = append(svc.Status.LoadBalancer.Ingress, v1.LoadBalancerIngress{IP: ip})
+		}
+		return svc
+	}
+
+	ginkgo.It("allocates the IPs from the pools and elects an eligible node", func() {
+		app.Action = func(ctx *cli.Context) error {
+			node1 := nodeFor(node1Name, node1IPv4, node1IPv6, node1IPv4Subnet, node1IPv6Subnet)
+			node1.Status.Conditions[0].Status = v1.ConditionFalse
+			node2 := nodeFor(node2Name, node2IPv4, node2IPv6, node2IPv4Subnet, node2IPv6Subnet)
+			svc1 := newLoadBalancerService("svc1")
+			otherClass := "example.com/other"
+			svc2 := newLoadBalancerService("svc2")
+			svc2.Spec.LoadBalancerClass = &otherClass
+
+			fakeCM.start(
+				&v1.NamespaceList{Items: []v1.Namespace{*newNamespace("testns")}},
+				&v1.NodeList{Items: []v1.Node{*node1, *node2}},
+				&v1.ServiceList{Items: []v1.Service{svc1, svc2}},
+			)
+
+			// node1 is not ready
+			expectService("svc1", "192.168.10.1", node2Name)
+
+			// the services of another class are left alone
+			gomega.Consistently(func() int {
+				svc, err := getService("svc2")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				return len(svc.Status.LoadBalancer.Ingress)
+			}).Should(gomega.Equal(0))
+
+			// the IPs move to node1 when node2 is no longer eligible
+			node1.Status.Conditions[0].Status = v1.ConditionTrue
+			node1.ResourceVersion = "2"
+			_, err := fakeCM.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), node1, metav1.UpdateOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			node2.Labels[v1.LabelNodeExcludeBalancers] = ""
+			node2.ResourceVersion = "2"
+			_, err = fakeCM.fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), node2, metav1.UpdateOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			expectService("svc1", "192.168.10.1", node1Name)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("keeps the IPs of the services on startup and reallocates the duplicates", func() {
+		app.Action = func(ctx *cli.Context) error {
+			node1 := nodeFor(node1Name, node1IPv4, node1IPv6, node1IPv4Subnet, node1IPv6Subnet)
+			svc1 := newLoadBalancerService("svc1", "192.168.10.2")
+			svc2 := newLoadBalancerService("svc2", "192.168.10.2")
+			svc2.Annotations = map[string]string{types.LoadBalancerNodeAnnotation: "deleted-node"}
+
+			fakeCM.start(
+				&v1.NamespaceList{Items: []v1.Namespace{*newNamespace("testns")}},
+				&v1.NodeList{Items: []v1.Node{*node1}},
+				&v1.ServiceList{Items: []v1.Service{svc1, svc2}},
+			)
+
+			ips := map[string]bool{}
+			for _, name := range []string{"svc1", "svc2"} {
+				gomega.Eventually(func() error {
+					svc, err := getService(name)
+					if err != nil {
+						return err
+					}
+					if len(svc.Status.LoadBalancer.Ingress) != 1 || svc.Annotations[types.LoadBalancerNodeAnnotation] != node1Name {
+						return fmt.Errorf("service %s is not allocated yet: %v", name, svc.Status.LoadBalancer.Ingress)
+					}
+					ips[svc.Status.LoadBalancer.Ingress[0].IP] = true
+					return nil
+				}).ShouldNot(gomega.HaveOccurred())
+			}
+			gomega.Expect(ips).To(gomega.Equal(map[string]bool{"192.168.10.1": true, "192.168.10.2": true}))
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("gives the IPs of the deleted services to the services waiting for one", func() {
+		app.Action = func(ctx *cli.Context) error {
+			node1 := nodeFor(node1Name, node1IPv4, node1IPv6, node1IPv4Subnet, node1IPv6Subnet)
+			svc1 := newLoadBalancerService("svc1", "192.168.10.1")
+			svc2 := newLoadBalancerService("svc2", "192.168.10.2")
+
+			fakeCM.start(
+				&v1.NamespaceList{Items: []v1.Namespace{*newNamespace("testns")}},
+				&v1.NodeList{Items: []v1.Node{*node1}},
+				&v1.ServiceList{Items: []v1.Service{svc1, svc2}},
+			)
+			expectService("svc1", "192.168.10.1", node1Name)
+			expectService("svc2", "192.168.10.2", node1Name)
+
+			// the pool is exhausted
+			svc3 := newLoadBalancerService("svc3")
+			_, err := fakeCM.fakeClient.KubeClient.CoreV1().Services("testns").Create(context.TODO(), &svc3, metav1.CreateOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Consistently(func() int {
+				svc, err := getService("svc3")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				return len(svc.Status.LoadBalancer.Ingress)
+			}).Should(gomega.Equal(0))
+
+			err = fakeCM.fakeClient.KubeClient.CoreV1().Services("testns").Delete(context.TODO(), "svc1", metav1.DeleteOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			expectService("svc3", "192.168.10.1", node1Name)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("elects a node with local endpoints for the services with externalTrafficPolicy=Local", func() {
+		app.Action = func(ctx *cli.Context) error {
+			node1 := nodeFor(node1Name, node1IPv4, node1IPv6, node1IPv4Subnet, node1IPv6Subnet)
+			node2 := nodeFor(node2Name, node2IPv4, node2IPv6, node2IPv4Subnet, node2IPv6Subnet)
+			svc1 := newLoadBalancerService("svc1")
+			svc1.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+			epSlice := discovery.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "svc1-epslice",
+					Namespace:       "testns",
+					ResourceVersion: "1",
+					Labels: map[string]string{
+						discovery.LabelServiceName: "svc1",
+					},
+				},
+				AddressType: discovery.AddressTypeIPv4,
+				Endpoints: []discovery.Endpoint{
+					{
+						Addresses: []string{"10.128.2.5"},
+						NodeName:  &node2.Name,
+					},
+				},
+			}
+
+			fakeCM.start(
+				&v1.NamespaceList{Items: []v1.Namespace{*newNamespace("testns")}},
+				&v1.NodeList{Items: []v1.Node{*node1, *node2}},
+				&v1.ServiceList{Items: []v1.Service{svc1}},
+				&discovery.EndpointSliceList{Items: []discovery.EndpointSlice{epSlice}},
+			)
+			expectService("svc1", "192.168.10.1", node2Name)
+
+			// the IPs move with the endpoints
+			epSlice.Endpoints[0].Addresses = []string{"10.128.1.5"}
+			epSlice.Endpoints[0].NodeName = &node1.Name
+			epSlice.ResourceVersion = "2"
+			_, err := fakeCM.fakeClient.KubeClient.DiscoveryV1().EndpointSlices("testns").Update(context.TODO(), &epSlice, metav1.UpdateOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			expectService("svc1", "192.168.10.1", node1Name)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+})
@@ -3,13 +3,17 @@ package clustermanager
 import (
 	"context"
 	"fmt"
+	"net"
 	"reflect"
 	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 	cache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
@@ -17,6 +21,8 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/node"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/pod"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	hostv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
+	hostclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/clientset/versioned"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	objretry "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/retry"
@@ -44,9 +50,41 @@ type networkClusterController struct {
 	podHandler *factory.Handler
 	retryPods  *objretry.RetryFramework
 
+	// retry framework for standalone host allocation
+	hostHandler *factory.Handler
+	retryHosts  *objretry.RetryFramework
+
 	podAllocator       *pod.PodAllocator
+	hostAllocator      *pod.HostAllocator
+	hostClient         hostclientset.Interface
 	nodeAllocator      *node.NodeAllocator
 	networkIDAllocator idallocator.NamedAllocator
+	// stateStore persists the node network state when the CRD backend is
+	// enabled, nil otherwise
+	stateStore node.NetworkStateStore
+	// warmSubnetStore persists the host subnets reserved for the next nodes
+	// of the default network when enabled, nil otherwise
+	warmSubnetStore node.WarmSubnetStore
+	// ipFamilyConversionStore reports the progress of the IP family
+	// conversion of the default network when done in batches, nil otherwise
+	ipFamilyConversionStore node.IPFamilyConversionStore
+	// kubeClient reads the cluster subnets added to the default network and
+	// the hybrid overlay enabled at runtime, nil for the secondary networks
+	kubeClient kubernetes.Interface
+	// pendingClusterSubnets holds the cluster subnets added to the default
+	// network at runtime that some node didn't apply yet
+	pendingClusterSubnets     []config.CIDRNetworkEntry
+	pendingClusterSubnetsLock sync.Mutex
+	// networkID is the id allocated to this network, valid once initialized
+	networkID int
+	// nodeAnnotationBatcher, if set, coalesces the updates of the node
+	// annotations with those of the other networks and of the zones
+	nodeAnnotationBatcher *kube.NodeAnnotationBatcher
+	// recorder, if set, posts the host subnet allocation failures as events
+	recorder record.EventRecorder
+	// joinSubnetAllocator, if set, avoids the subnets of the secondary
+	// network for the join subnet addresses of the nodes
+	joinSubnetAllocator *joinSubnetAllocator
 
 	util.NetInfo
 }
@@ -65,6 +103,11 @@ func newNetworkClusterController(networkIDAllocator idallocator.NamedAllocator,
 		stopChan:           make(chan struct{}),
 		wg:                 wg,
 		networkIDAllocator: networkIDAllocator,
+		hostClient:         ovnClient.HostClient,
+	}
+
+	if config.OVNKubernetesFeature.NodeNetworkStateBackend == config.NodeNetworkStateBackendCRD {
+		ncc.stateStore = node.NewCRDNetworkStateStore(ovnClient.NodeNetworkStateClient)
 	}
 
 	return ncc
@@ -84,7 +127,15 @@ func newDefaultNetworkClusterController(netInfo util.NetInfo, ovnClient *util.OV
 	}
 
 	namedIDAllocator := networkIDAllocator.ForName(types.DefaultNetworkName)
-	return newNetworkClusterController(namedIDAllocator, netInfo, ovnClient, wf)
+	ncc := newNetworkClusterController(namedIDAllocator, netInfo, ovnClient, wf)
+	ncc.kubeClient = ovnClient.KubeClient
+	if config.ClusterManager.WarmHostSubnets > 0 {
+		ncc.warmSubnetStore = node.NewConfigMapWarmSubnetStore(ovnClient.KubeClient)
+	}
+	if config.ClusterManager.IPFamilyConversionBatchSize > 0 {
+		ncc.ipFamilyConversionStore = node.NewCRDIPFamilyConversionStore(ovnClient.IPFamilyConversionClient)
+	}
+	return ncc
 }
 
 func (ncc *networkClusterController) hasPodAllocation() bool {
@@ -100,6 +151,15 @@ func (ncc *networkClusterController) hasPodAllocation() bool {
 	return false
 }
 
+// hasHostAllocation returns true if the network allocates addresses to
+// standalone hosts, which is only supported on L2 topologies with IPAM and
+// without node IP chunks, that are not internal
+func (ncc *networkClusterController) hasHostAllocation() bool {
+	return config.OVNKubernetesFeature.EnableStandaloneHosts && ncc.hasPodAllocation() &&
+		ncc.TopologyType() == types.Layer2Topology && util.DoesNetworkRequireIPAM(ncc.NetInfo) &&
+		ncc.NodeIPChunkSize() == 0 && !ncc.IsInternal()
+}
+
 func (ncc *networkClusterController) hasNodeAllocation() bool {
 	// we only do node allocation on L3 or default network, and L2 on
 	// interconnect
@@ -108,8 +168,8 @@ func (ncc *networkClusterController) hasNodeAllocation() bool {
 		// we need to allocate network IDs and subnets
 		return true
 	case types.Layer2Topology:
-		// we need to allocate network IDs
-		return config.OVNKubernetesFeature.EnableInterconnect
+		// we need to allocate network IDs, and IP chunks if enabled
+		return config.OVNKubernetesFeature.EnableInterconnect || !ncc.IsSecondary()
 	default:
 		// we need to allocate network IDs and subnets
 		return !ncc.IsSecondary()
@@ -121,30 +181,101 @@ func (ncc *networkClusterController) init() error {
 	if err != nil {
 		return err
 	}
+	ncc.networkID = networkID
 
 	if ncc.hasNodeAllocation() {
 		ncc.retryNodes = ncc.newRetryFramework(factory.NodeType, true)
 
-		ncc.nodeAllocator = node.NewNodeAllocator(networkID, ncc.NetInfo, ncc.watchFactory.NodeCoreInformer().Lister(), ncc.kube)
+		ncc.nodeAllocator = node.NewNodeAllocator(networkID, ncc.NetInfo, ncc.watchFactory.NodeCoreInformer().Lister(), ncc.kube, ncc.stateStore)
+		if ncc.warmSubnetStore != nil {
+			ncc.nodeAllocator.EnableWarmSubnets(config.ClusterManager.WarmHostSubnets, ncc.warmSubnetStore)
+		}
+		if config.ClusterManager.IPv6HostSubnetSource == config.IPv6HostSubnetSourceDHCPv6PD && !ncc.IsSecondary() {
+			source, err := node.NewDHCPv6SubnetSource(config.ClusterManager.DHCPv6PDInterface)
+			if err != nil {
+				return fmt.Errorf("failed to initialize the DHCPv6 prefix delegation: %w", err)
+			}
+			ncc.nodeAllocator.EnableDelegatedSubnets(source, config.ClusterManager.DHCPv6PDPrefixLength)
+		}
+		ncc.nodeAllocator.EnableDeletedNodeSubnetGracePeriod(
+			time.Duration(config.ClusterManager.DeletedNodeSubnetGracePeriod) * time.Second)
+		if ncc.ipFamilyConversionStore != nil {
+			ncc.nodeAllocator.EnableIPFamilyConversion(config.ClusterManager.IPFamilyConversionBatchSize,
+				time.Duration(config.ClusterManager.IPFamilyConversionBatchInterval)*time.Second, ncc.ipFamilyConversionStore)
+		}
+		ncc.nodeAllocator.EnableAdditionalHostSubnets(config.Default.MaxHostSubnetsPerNode)
+		if ncc.nodeAnnotationBatcher != nil {
+			ncc.nodeAllocator.EnableAnnotationBatching(ncc.nodeAnnotationBatcher)
+		}
+		ncc.nodeAllocator.EnableSubnetCompaction(config.ClusterManager.SubnetCompactionBatchSize,
+			time.Duration(config.ClusterManager.SubnetCompactionBatchInterval)*time.Second)
+		if config.ClusterManager.MigrateRemovedClusterSubnets {
+			ncc.nodeAllocator.EnableSubnetMigration(config.ClusterManager.SubnetMigrationBatchSize,
+				time.Duration(config.ClusterManager.SubnetMigrationBatchInterval)*time.Second)
+		}
+		ncc.nodeAllocator.EnableHealthCheckSubnets(config.Default.HealthCheckSubnets)
+		if ncc.recorder != nil {
+			ncc.nodeAllocator.EnableEvents(ncc.recorder)
+		}
 		err := ncc.nodeAllocator.Init()
 		if err != nil {
 			return fmt.Errorf("failed to initialize host subnet ip allocator: %w", err)
 		}
+
+		if ncc.kubeClient != nil {
+			if err := ncc.loadAdditionalClusterSubnets(); err != nil {
+				return err
+			}
+		}
+
+		if !ncc.IsSecondary() {
+			if err := ncc.validateClusterSubnets(); err != nil {
+				return err
+			}
+		}
 	}
 
 	if ncc.hasPodAllocation() {
 		ncc.retryPods = ncc.newRetryFramework(factory.PodType, true)
 
 		ncc.podAllocator = pod.NewPodAllocator(ncc.NetInfo, ncc.watchFactory.PodCoreInformer().Lister(), ncc.kube)
+		ncc.podAllocator.EnableNodeIPChunks(ncc.watchFactory.NodeCoreInformer().Lister())
 		err := ncc.podAllocator.Init()
 		if err != nil {
 			return fmt.Errorf("failed to initialize pod ip allocator: %w", err)
 		}
 	}
 
+	if ncc.hasHostAllocation() {
+		ncc.retryHosts = ncc.newRetryFramework(factory.HostType, true)
+		ncc.hostAllocator = ncc.podAllocator.NewHostAllocator(ncc.hostClient)
+	}
+
 	return nil
 }
 
+// validateClusterSubnets makes sure that no node has a host subnet from a
+// cluster subnet that was removed from the configuration. Such nodes would
+// otherwise silently get a new host subnet, breaking their running pods. If
+// migration was requested, the nodes are only reported and get new host
+// subnets in the batches of the migration.
+func (ncc *networkClusterController) validateClusterSubnets() error {
+	nodes, err := ncc.watchFactory.GetNodes()
+	if err != nil {
+		return fmt.Errorf("unable to list nodes to validate the cluster subnets: %w", err)
+	}
+	err = ncc.nodeAllocator.ValidateNodeSubnets(nodes)
+	if err == nil {
+		return nil
+	}
+	if config.ClusterManager.MigrateRemovedClusterSubnets {
+		klog.Warningf("Migrating nodes to new host subnets: %v", err)
+		return nil
+	}
+	return fmt.Errorf("%w; restore the removed cluster subnets, delete these nodes or enable "+
+		"the migration of removed cluster subnets", err)
+}
+
 // Start the network cluster controller. Depending on the cluster configuration
 // and type of network, it does the following:
 //   - initializes the node allocator and starts listening to node events
@@ -155,12 +286,57 @@ func (ncc *networkClusterController) Start(ctx context.Context) error {
 		return err
 	}
 
+	if ncc.IsSecondary() && ncc.joinSubnetAllocator != nil {
+		subnets := make([]*net.IPNet, 0, len(ncc.Subnets()))
+		for _, subnet := range ncc.Subnets() {
+			subnets = append(subnets, subnet.CIDR)
+		}
+		if err := ncc.joinSubnetAllocator.addNetworkSubnets(ncc.GetNetworkName(), subnets); err != nil {
+			return err
+		}
+	}
+
 	if ncc.hasNodeAllocation() {
+		// persist the reserved host subnets in the background from the
+		// initial sync of the nodes on
+		ncc.nodeAllocator.RunWarmSubnetReplenishment(ncc.stopChan, ncc.wg)
 		nodeHandler, err := ncc.retryNodes.WatchResource()
 		if err != nil {
 			return fmt.Errorf("unable to watch pods: %w", err)
 		}
 		ncc.nodeHandler = nodeHandler
+		ncc.nodeAllocator.RunDelegatedSubnetRenewal(ncc.stopChan, ncc.wg)
+		ncc.nodeAllocator.RunDeletedNodeSubnetRelease(ncc.stopChan, ncc.wg)
+		ncc.nodeAllocator.RunIPFamilyConversion(ncc.stopChan, ncc.wg, ncc.retryNodesByName)
+		ncc.nodeAllocator.RunSubnetCompaction(ncc.stopChan, ncc.wg, ncc.retryNodesByName)
+		ncc.nodeAllocator.RunSubnetMigration(ncc.stopChan, ncc.wg, ncc.retryNodesByName)
+		ncc.nodeAllocator.RunSubnetFragmentationReport(ncc.stopChan, ncc.wg,
+			time.Duration(config.ClusterManager.SubnetFragmentationReportInterval)*time.Second)
+
+		if ncc.kubeClient != nil {
+			if err := ncc.watchAdditionalClusterSubnets(); err != nil {
+				return err
+			}
+			if err := ncc.watchHybridOverlay(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ncc.hasHostAllocation() {
+		// reserve the addresses of the existing hosts before any pod or new
+		// host gets allocated
+		hosts, err := ncc.watchFactory.GetHosts()
+		if err != nil {
+			return fmt.Errorf("unable to list hosts: %w", err)
+		}
+		objs := make([]interface{}, 0, len(hosts))
+		for _, host := range hosts {
+			objs = append(objs, host)
+		}
+		if err = ncc.hostAllocator.Sync(objs); err != nil {
+			return fmt.Errorf("unable to sync hosts: %w", err)
+		}
 	}
 
 	if ncc.hasPodAllocation() {
@@ -171,6 +347,14 @@ func (ncc *networkClusterController) Start(ctx context.Context) error {
 		ncc.podHandler = podHandler
 	}
 
+	if ncc.hasHostAllocation() {
+		hostHandler, err := ncc.retryHosts.WatchResource()
+		if err != nil {
+			return fmt.Errorf("unable to watch hosts: %w", err)
+		}
+		ncc.hostHandler = hostHandler
+	}
+
 	return nil
 }
 
@@ -185,6 +369,14 @@ func (ncc *networkClusterController) Stop() {
 	if ncc.podHandler != nil {
 		ncc.watchFactory.RemovePodHandler(ncc.podHandler)
 	}
+
+	if ncc.hostHandler != nil {
+		ncc.watchFactory.RemoveHostHandler(ncc.hostHandler)
+	}
+
+	if ncc.IsSecondary() && ncc.joinSubnetAllocator != nil {
+		ncc.joinSubnetAllocator.deleteNetworkSubnets(ncc.GetNetworkName())
+	}
 }
 
 func (ncc *networkClusterController) newRetryFramework(objectType reflect.Type, hasUpdateFunc bool) *objretry.RetryFramework {
@@ -192,6 +384,7 @@ func (ncc *networkClusterController) newRetryFramework(objectType reflect.Type,
 		HasUpdateFunc:          hasUpdateFunc,
 		NeedsUpdateDuringRetry: false,
 		ObjType:                objectType,
+		NetworkName:            ncc.GetNetworkName(),
 		EventHandler: &networkClusterControllerEventHandler{
 			objType:  objectType,
 			ncc:      ncc,
@@ -251,12 +444,23 @@ func (h *networkClusterControllerEventHandler) AddResource(obj interface{}, from
 		if !ok {
 			return fmt.Errorf("could not cast %T object to *corev1.Node", obj)
 		}
+		// the node may have applied the pending cluster subnets it waits on
+		h.ncc.handleAppliedClusterSubnets()
 		if err = h.ncc.nodeAllocator.HandleAddUpdateNodeEvent(node); err != nil {
 			klog.Infof("Node add failed for %s, will try again later: %v",
 				node.Name, err)
 			return err
 		}
 		h.clearInitialNodeNetworkUnavailableCondition(node)
+	case factory.HostType:
+		host, ok := obj.(*hostv1.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T object to *hostv1.Host", obj)
+		}
+		if err = h.ncc.hostAllocator.Reconcile(nil, host); err != nil {
+			klog.Infof("Host add failed for %s, will try again later: %v", host.Name, err)
+			return err
+		}
 	default:
 		return fmt.Errorf("no add function for object type %s", h.objType)
 	}
@@ -289,11 +493,26 @@ func (h *networkClusterControllerEventHandler) UpdateResource(oldObj, newObj int
 		if !ok {
 			return fmt.Errorf("could not cast %T object to *corev1.Node", newObj)
 		}
+		// the node may have applied the pending cluster subnets it waits on
+		h.ncc.handleAppliedClusterSubnets()
 		if err = h.ncc.nodeAllocator.HandleAddUpdateNodeEvent(node); err != nil {
 			klog.Infof("Node update failed for %s, will try again later: %v",
 				node.Name, err)
 			return err
 		}
+	case factory.HostType:
+		old, ok := oldObj.(*hostv1.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T old object to *hostv1.Host", oldObj)
+		}
+		new, ok := newObj.(*hostv1.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T new object to *hostv1.Host", newObj)
+		}
+		if err = h.ncc.hostAllocator.Reconcile(old, new); err != nil {
+			klog.Infof("Host update failed for %s, will try again later: %v", new.Name, err)
+			return err
+		}
 	default:
 		return fmt.Errorf("no update function for object type %s", h.objType)
 	}
@@ -319,7 +538,17 @@ func (h *networkClusterControllerEventHandler) DeleteResource(obj, cachedObj int
 		if !ok {
 			return fmt.Errorf("could not cast obj of type %T to *knet.Node", obj)
 		}
-		return h.ncc.nodeAllocator.HandleDeleteNode(node)
+		if err := h.ncc.nodeAllocator.HandleDeleteNode(node); err != nil {
+			return err
+		}
+		h.ncc.handleAppliedClusterSubnets()
+		return nil
+	case factory.HostType:
+		host, ok := obj.(*hostv1.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T object to *hostv1.Host", obj)
+		}
+		return h.ncc.hostAllocator.Reconcile(host, nil)
 	}
 	return nil
 }
@@ -336,6 +565,8 @@ func (h *networkClusterControllerEventHandler) SyncFunc(objs []interface{}) erro
 			syncFunc = h.ncc.podAllocator.Sync
 		case factory.NodeType:
 			syncFunc = h.ncc.nodeAllocator.Sync
+		case factory.HostType:
+			syncFunc = h.ncc.hostAllocator.Sync
 
 		default:
 			return fmt.Errorf("no sync function for object type %s", h.objType)
@@ -360,8 +591,23 @@ func (h *networkClusterControllerEventHandler) AreResourcesEqual(obj1, obj2 inte
 		}
 
 		// network cluster controller only updates the node/hybrid subnet annotations.
-		// Check if the annotations have changed.
-		return reflect.DeepEqual(node1.Annotations, node2.Annotations), nil
+		// Check if the annotations have changed, or if the node started or
+		// stopped being managed by OVN.
+		return reflect.DeepEqual(node1.Annotations, node2.Annotations) &&
+			util.NoHostSubnet(node1) == util.NoHostSubnet(node2), nil
+	}
+	if h.objType == factory.HostType {
+		host1, ok := obj1.(*hostv1.Host)
+		if !ok {
+			return false, fmt.Errorf("could not cast obj1 of type %T to *hostv1.Host", obj1)
+		}
+		host2, ok := obj2.(*hostv1.Host)
+		if !ok {
+			return false, fmt.Errorf("could not cast obj2 of type %T to *hostv1.Host", obj2)
+		}
+
+		// network cluster controller only updates the host status
+		return reflect.DeepEqual(host1.Status, host2.Status), nil
 	}
 
 	return false, nil
@@ -384,6 +630,8 @@ func (h *networkClusterControllerEventHandler) GetResourceFromInformerCache(key
 		obj, err = h.ncc.watchFactory.GetNode(name)
 	case factory.PodType:
 		obj, err = h.ncc.watchFactory.GetPod(namespace, name)
+	case factory.HostType:
+		obj, err = h.ncc.watchFactory.GetHost(name)
 	default:
 		err = fmt.Errorf("object type %s not supported, cannot retrieve it from informers cache",
 			h.objType)
@@ -438,3 +686,19 @@ func (h *networkClusterControllerEventHandler) clearInitialNodeNetworkUnavailabl
 		klog.Infof("Cleared node NetworkUnavailable/NoRouteCreated condition for %s", origNode.Name)
 	}
 }
+
+// retryNodesByName handles the given nodes again through the node retry
+// framework, like when their batch of the IP family conversion starts
+func (ncc *networkClusterController) retryNodesByName(nodeNames []string) {
+	for _, nodeName := range nodeNames {
+		node, err := ncc.watchFactory.GetNode(nodeName)
+		if err != nil {
+			klog.Errorf("Unable to get node %s to retry: %v", nodeName, err)
+			continue
+		}
+		if err := ncc.retryNodes.AddRetryObjWithAddNoBackoff(node); err != nil {
+			klog.Errorf("Failed to retry node %s: %v", nodeName, err)
+		}
+	}
+	ncc.retryNodes.RequestRetryObjs()
+}
@@ -196,5 +196,100 @@ var _ = ginkgo.Describe("Network Cluster Controller", func() {
 			})
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
+
+		ginkgo.It("allocates subnets from the cluster subnets added at runtime", func() {
+			app.Action = func(ctx *cli.Context) error {
+				nodes := []v1.Node{
+					{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "node3"}},
+				}
+				kubeFakeClient := fake.NewSimpleClientset(&v1.NodeList{
+					Items: nodes,
+				})
+				fakeClient := &util.OVNClusterManagerClientset{
+					KubeClient: kubeFakeClient,
+				}
+
+				_, err := config.InitConfig(ctx, nil, nil)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				config.Kubernetes.HostNetworkNamespace = ""
+
+				f, err = factory.NewClusterManagerWatchFactory(fakeClient)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = f.Start()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				ncc := newDefaultNetworkClusterController(&util.DefaultNetInfo{}, fakeClient, f)
+				err = ncc.Start(ctx.Context)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				defer ncc.Stop()
+
+				hostSubnets := func() []string {
+					var allocated []string
+					for _, node := range nodes {
+						updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+						gomega.Expect(err).NotTo(gomega.HaveOccurred())
+						subnets, err := util.ParseNodeHostSubnetAnnotation(updatedNode, ovntypes.DefaultNetworkName)
+						if err == nil {
+							allocated = append(allocated, util.JoinIPNets(subnets, ","))
+						}
+					}
+					return allocated
+				}
+				// the cluster subnet only has room for 2 nodes
+				gomega.Eventually(hostSubnets, 2).Should(gomega.ConsistOf("10.128.0.0/24", "10.128.1.0/24"))
+
+				_, err = fakeClient.KubeClient.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace).Create(context.TODO(),
+					&v1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      util.AdditionalClusterSubnetsConfigMapName,
+							Namespace: config.Kubernetes.OVNConfigNamespace,
+						},
+						Data: map[string]string{util.AdditionalClusterSubnetsKey: "10.132.0.0/23/24"},
+					}, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				// no subnet is handed out from the added cluster subnet until
+				// every ovnkube-node and ovnkube-controller applied it
+				gomega.Consistently(hostSubnets, 1).Should(gomega.ConsistOf("10.128.0.0/24", "10.128.1.0/24"))
+				for _, node := range nodes {
+					updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					if updatedNode.Annotations == nil {
+						updatedNode.Annotations = map[string]string{}
+					}
+					updatedNode.Annotations["k8s.ovn.org/node-applied-cluster-subnets"] = `["10.128.0.0/23","10.132.0.0/23"]`
+					if node.Name != "node3" {
+						updatedNode.Annotations["k8s.ovn.org/controller-applied-cluster-subnets"] = `["10.128.0.0/23","10.132.0.0/23"]`
+					}
+					_, err = fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), updatedNode, metav1.UpdateOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				}
+				gomega.Consistently(hostSubnets, 1).Should(gomega.ConsistOf("10.128.0.0/24", "10.128.1.0/24"))
+				gomega.Eventually(func() error {
+					updatedNode, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), "node3", metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					updatedNode.Annotations["k8s.ovn.org/controller-applied-cluster-subnets"] = `["10.128.0.0/23","10.132.0.0/23"]`
+					_, err = fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), updatedNode, metav1.UpdateOptions{})
+					return err
+				}).Should(gomega.Succeed())
+
+				// the third node gets a subnet from the added cluster subnet
+				gomega.Eventually(hostSubnets, 2).Should(gomega.ConsistOf("10.128.0.0/24", "10.128.1.0/24", "10.132.0.0/24"))
+				v4used, v4count, _, _ := ncc.nodeAllocator.GetSubnetUsage()
+				gomega.Expect(v4used).To(gomega.BeEquivalentTo(3))
+				gomega.Expect(v4count).To(gomega.BeEquivalentTo(4))
+				return nil
+			}
+
+			err := app.Run([]string{
+				app.Name,
+				"-cluster-subnets=10.128.0.0/23/24",
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
 	})
 })
@@ -0,0 +1,198 @@
+package clustermanager
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/id"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// networkShardLockPrefix prefixes the name of the lease of each shard
+const networkShardLockPrefix = "ovn-kubernetes-cluster-manager-shard-"
+
+// networkShard is one of the shards the secondary networks are spread over by
+// the hash of their name
+type networkShard struct {
+	index int
+	count int
+}
+
+// networkShardOf returns the index of the shard of the network among count
+// shards
+func networkShardOf(networkName string, count int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(networkName))
+	return int(hash.Sum32() % uint32(count))
+}
+
+// ownsNetwork returns whether the network belongs to the shard
+func (s *networkShard) ownsNetwork(networkName string) bool {
+	return networkShardOf(networkName, s.count) == s.index
+}
+
+// reserveOtherNetworkIDs reserves the network IDs of the other shards so that
+// the shards, allocating concurrently, never allocate the same ID: a shard
+// allocates the IDs equal to its index modulo the number of shards. The ID of
+// the default network, 0, is already reserved.
+func (s *networkShard) reserveOtherNetworkIDs(allocator id.Allocator, maxIDs int) {
+	for networkID := 1; networkID < maxIDs; networkID++ {
+		if networkID%s.count == s.index {
+			continue
+		}
+		// the ID may already be reserved by a network annotated on the nodes
+		_ = allocator.ReserveID(fmt.Sprintf("shard-reserved-%d", networkID), networkID)
+	}
+}
+
+// NetworkShardManager spreads the secondary networks over
+// ClusterManager.NetworkShards shards, each with its own leader election, so
+// that the node subnets and network IDs of the secondary networks are
+// allocated by several cluster manager instances instead of the leader alone.
+// Every cluster manager instance runs it, and runs a secondary network cluster
+// manager for each shard it leads.
+type NetworkShardManager struct {
+	ovnClient *util.OVNClusterManagerClientset
+	wf        *factory.WatchFactory
+	identity  string
+	recorder  record.EventRecorder
+	// nodeAnnotationBatcher, if set, batches the node annotation updates of
+	// the networks of all the shards led
+	nodeAnnotationBatcher *kube.NodeAnnotationBatcher
+}
+
+// NewNetworkShardManager creates the manager of the shards of the secondary
+// networks
+func NewNetworkShardManager(ovnClient *util.OVNClusterManagerClientset, wf *factory.WatchFactory,
+	identity string, recorder record.EventRecorder) *NetworkShardManager {
+	m := &NetworkShardManager{
+		ovnClient: ovnClient,
+		wf:        wf,
+		identity:  identity,
+		recorder:  recorder,
+	}
+	if config.ClusterManager.NodeAnnotationBatchInterval > 0 {
+		m.nodeAnnotationBatcher = kube.NewNodeAnnotationBatcher(&kube.Kube{KClient: ovnClient.KubeClient},
+			nodeAnnotationFieldManager, time.Duration(config.ClusterManager.NodeAnnotationBatchInterval)*time.Millisecond)
+	}
+	return m
+}
+
+// Run starts the watch factory and runs the leader election of each shard
+// until ctx is done, shutting the watch factory down once all the shards
+// stopped
+func (m *NetworkShardManager) Run(ctx context.Context, wg *sync.WaitGroup) error {
+	if err := m.wf.Start(); err != nil {
+		return fmt.Errorf("failed to start the watch factory of the network shards: %w", err)
+	}
+	shardsWg := &sync.WaitGroup{}
+	for i := 0; i < config.ClusterManager.NetworkShards; i++ {
+		shard := &networkShard{index: i, count: config.ClusterManager.NetworkShards}
+		shardsWg.Add(1)
+		go func() {
+			defer shardsWg.Done()
+			// keep competing for the shard after losing it
+			wait.UntilWithContext(ctx, func(ctx context.Context) {
+				ctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+				elector, err := m.newShardLeaderElector(shard, cancel)
+				if err != nil {
+					klog.Errorf("Failed to create the leader elector of network shard %d: %v", shard.index, err)
+					return
+				}
+				elector.Run(ctx)
+			}, time.Duration(config.ClusterMgrHA.ElectionRetryPeriod)*time.Second)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		shardsWg.Wait()
+		m.wf.Shutdown()
+	}()
+	return nil
+}
+
+// newShardLeaderElector returns the leader elector of the shard, stepping down
+// with stepDown if the shard fails to start so that another instance gets it
+func (m *NetworkShardManager) newShardLeaderElector(shard *networkShard, stepDown context.CancelFunc) (*leaderelection.LeaderElector, error) {
+	lockName := fmt.Sprintf("%s%d", networkShardLockPrefix, shard.index)
+	rl, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		config.Kubernetes.OVNConfigNamespace,
+		lockName,
+		m.ovnClient.KubeClient.CoreV1(),
+		m.ovnClient.KubeClient.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      m.identity,
+			EventRecorder: m.recorder,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the callbacks run concurrently, sncmLock keeps a shard from being started
+	// once its leadership is lost
+	var sncm *secondaryNetworkClusterManager
+	var sncmLock sync.Mutex
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            rl,
+		LeaseDuration:   time.Duration(config.ClusterMgrHA.ElectionLeaseDuration) * time.Second,
+		RenewDeadline:   time.Duration(config.ClusterMgrHA.ElectionRenewDeadline) * time.Second,
+		RetryPeriod:     time.Duration(config.ClusterMgrHA.ElectionRetryPeriod) * time.Second,
+		ReleaseOnCancel: true,
+		Name:            lockName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				sncmLock.Lock()
+				defer sncmLock.Unlock()
+				if ctx.Err() != nil {
+					return
+				}
+				klog.Infof("Won leader election of network shard %d", shard.index)
+				var err error
+				sncm, err = m.startShard(shard)
+				if err != nil {
+					klog.Errorf("Failed to start network shard %d: %v", shard.index, err)
+					stepDown()
+				}
+			},
+			// called once the context of OnStartedLeading is canceled
+			OnStoppedLeading: func() {
+				sncmLock.Lock()
+				defer sncmLock.Unlock()
+				klog.Infof("No longer leader of network shard %d", shard.index)
+				if sncm != nil {
+					sncm.Stop()
+					sncm = nil
+				}
+			},
+		},
+	})
+}
+
+func (m *NetworkShardManager) startShard(shard *networkShard) (*secondaryNetworkClusterManager, error) {
+	sncm, err := newShardedSecondaryNetworkClusterManager(m.ovnClient, m.wf, shard, m.recorder)
+	if err != nil {
+		return nil, err
+	}
+	sncm.nodeAnnotationBatcher = m.nodeAnnotationBatcher
+	if err := sncm.Start(); err != nil {
+		sncm.Stop()
+		return nil, err
+	}
+	return sncm, nil
+}
@@ -0,0 +1,31 @@
+package clustermanager
+
+import (
+	"fmt"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Network shards", func() {
+	ginkgo.It("spreads the networks over the shards", func() {
+		networksPerShard := make([]int, 4)
+		for i := 0; i < 400; i++ {
+			networkName := fmt.Sprintf("network%d", i)
+			index := networkShardOf(networkName, 4)
+			gomega.Expect(index).To(gomega.Equal(networkShardOf(networkName, 4)))
+			networksPerShard[index]++
+
+			owners := 0
+			for shard := 0; shard < 4; shard++ {
+				if (&networkShard{index: shard, count: 4}).ownsNetwork(networkName) {
+					owners++
+				}
+			}
+			gomega.Expect(owners).To(gomega.Equal(1))
+		}
+		for _, networks := range networksPerShard {
+			gomega.Expect(networks).To(gomega.BeNumerically(">", 50))
+		}
+	})
+})
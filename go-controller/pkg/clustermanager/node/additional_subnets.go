@@ -0,0 +1,101 @@
+package node
+
+import (
+	"errors"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// EnableAdditionalHostSubnets allows the nodes to be given up to maxPerNode
+// host subnets of each IP family: a node whose host subnets of an IP family
+// were all reported exhausted by ovnkube-controller, in the node exhausted
+// subnets annotation, gets an additional host subnet of that IP family. Only
+// for the default network, must be called before Init.
+func (na *NodeAllocator) EnableAdditionalHostSubnets(maxPerNode int) {
+	if maxPerNode <= 1 || na.netInfo.IsSecondary() {
+		return
+	}
+	na.maxHostSubnetsPerNode = maxPerNode
+}
+
+// hostSubnetsPerFamily returns the maximum number of host subnets of each IP
+// family of a node
+func (na *NodeAllocator) hostSubnetsPerFamily() int {
+	if na.maxHostSubnetsPerNode > 1 {
+		return na.maxHostSubnetsPerNode
+	}
+	return 1
+}
+
+// allocateAdditionalHostSubnets allocates an additional host subnet of each IP
+// family whose host subnets are all exhausted, unless the node already has the
+// maximum number of host subnets of that IP family. The additional host
+// subnets are of the same prefix lengths as the first ones. Running out of
+// host subnets is not an error: the node keeps running with the host subnets
+// it has.
+func (na *NodeAllocator) allocateAdditionalHostSubnets(node *corev1.Node, hostSubnets []*net.IPNet,
+	ipv4PrefixLen, ipv6PrefixLen int) []*net.IPNet {
+	if na.hostSubnetsPerFamily() <= 1 {
+		return nil
+	}
+	exhaustedSubnets, err := util.ParseNodeExhaustedSubnetAnnotation(node, na.netInfo.GetNetworkName())
+	if err != nil {
+		if !util.IsAnnotationNotSetError(err) {
+			klog.Warningf("Failed to get node %s exhausted subnets annotation: %v", node.Name, err)
+		}
+		return nil
+	}
+	exhausted := map[string]bool{}
+	for _, subnet := range exhaustedSubnets {
+		exhausted[subnet.String()] = true
+	}
+
+	var allocatedSubnets []*net.IPNet
+	for _, isIPv6 := range []bool{false, true} {
+		count := 0
+		allExhausted := true
+		for _, hostSubnet := range hostSubnets {
+			if utilnet.IsIPv6CIDR(hostSubnet) != isIPv6 {
+				continue
+			}
+			count++
+			allExhausted = allExhausted && exhausted[hostSubnet.String()]
+		}
+		if count == 0 || count >= na.hostSubnetsPerFamily() || !allExhausted {
+			continue
+		}
+
+		var subnet *net.IPNet
+		allocator := na.nodeSubnetAllocator(node)
+		switch {
+		case isIPv6 && ipv6PrefixLen > 0:
+			subnet, err = allocator.AllocateIPv6NetworkOfLength(node.Name, ipv6PrefixLen)
+		case isIPv6:
+			subnet, err = allocator.AllocateIPv6Network(node.Name)
+		case ipv4PrefixLen > 0:
+			subnet, err = allocator.AllocateIPv4NetworkOfLength(node.Name, ipv4PrefixLen)
+		default:
+			subnet, err = allocator.AllocateIPv4Network(node.Name)
+		}
+		if err != nil {
+			if errors.Is(err, ErrSubnetAllocatorFull) {
+				klog.Warningf("No additional host subnet left for node %s whose host subnets are exhausted", node.Name)
+			} else {
+				klog.Errorf("Failed to allocate an additional host subnet for node %s: %v", node.Name, err)
+			}
+			continue
+		}
+		if subnet == nil {
+			continue
+		}
+		klog.Infof("Allocated the additional host subnet %s to node %s, its host subnets %v are exhausted",
+			subnet, node.Name, util.StringSlice(hostSubnets))
+		allocatedSubnets = append(allocatedSubnets, subnet)
+	}
+	return allocatedSubnets
+}
@@ -0,0 +1,117 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_AdditionalHostSubnets(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.IPv6Mode = false
+
+	node := newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/24"]}`})
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(node); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset(node)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableAdditionalHostSubnets(2)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync([]interface{}{node}); err != nil {
+		t.Fatal(err)
+	}
+
+	// reportExhausted sets the exhausted subnets annotation and handles the
+	// updated node
+	reportExhausted := func(exhausted ...string) {
+		t.Helper()
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		node = node.DeepCopy()
+		if len(exhausted) > 0 {
+			subnets, err := util.ParseIPNets(exhausted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			node.Annotations, err = util.UpdateNodeExhaustedSubnetAnnotation(node.Annotations, subnets, types.DefaultNetworkName)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if node, err = client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Update(node); err != nil {
+			t.Fatal(err)
+		}
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectHostSubnets := func(expected ...string) {
+		t.Helper()
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := util.StringSlice(hostSubnets); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected the host subnets %v, got %v", expected, actual)
+		}
+	}
+
+	// the node keeps its host subnet while it has free pod IPs
+	reportExhausted()
+	expectHostSubnets("10.128.0.0/24")
+
+	// and gets an additional one once it's exhausted
+	reportExhausted("10.128.0.0/24")
+	expectHostSubnets("10.128.0.0/24", "10.128.1.0/24")
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 2 {
+		t.Fatalf("expected 2 allocated host subnets, got %d", v4used)
+	}
+
+	// a stale report of the first host subnet doesn't grow it again
+	reportExhausted("10.128.0.0/24")
+	expectHostSubnets("10.128.0.0/24", "10.128.1.0/24")
+
+	// and it doesn't grow past the maximum
+	reportExhausted("10.128.0.0/24", "10.128.1.0/24")
+	expectHostSubnets("10.128.0.0/24", "10.128.1.0/24")
+
+	// all the host subnets are released with the node
+	if err := na.HandleDeleteNode(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 0 {
+		t.Fatalf("expected no allocated host subnets, got %d", v4used)
+	}
+}
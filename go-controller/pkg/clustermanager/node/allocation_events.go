@@ -0,0 +1,90 @@
+package node
+
+import (
+	"errors"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+)
+
+const (
+	// HostSubnetsExhaustedReason is the reason of the events posted when a
+	// node cannot get a host subnet as the cluster subnets are exhausted
+	HostSubnetsExhaustedReason = "HostSubnetsExhausted"
+	// InvalidHostSubnetsReason is the reason of the events posted when the
+	// host subnets annotation of a node cannot be parsed
+	InvalidHostSubnetsReason = "InvalidHostSubnets"
+	// HostSubnetAllocationFailedReason is the reason of the events posted when
+	// a node cannot get a host subnet for any other reason
+	HostSubnetAllocationFailedReason = "HostSubnetAllocationFailed"
+	// DuplicateHostSubnetReason is the reason of the events posted when a
+	// host subnet annotated on a node is already annotated on an older node
+	DuplicateHostSubnetReason = "DuplicateHostSubnet"
+
+	// networkEventKind is the kind of the cluster scoped object the events of
+	// a network are posted on, named after the network
+	networkEventKind = "Network"
+)
+
+// EnableEvents posts the host subnet allocation failures as warning events on
+// the node and on the network, a cluster scoped object named after the network
+// of kind Network, in addition to logging them. The events of the node show in
+// `kubectl describe node`, those of all the nodes with
+// `kubectl get events --field-selector involvedObject.kind=Network`.
+func (na *NodeAllocator) EnableEvents(recorder record.EventRecorder) {
+	na.recorder = recorder
+}
+
+// recordInvalidHostSubnets posts the failure to parse the host subnets
+// annotation of the node
+func (na *NodeAllocator) recordInvalidHostSubnets(node *corev1.Node, err error) {
+	na.recordAllocationEvent(node, InvalidHostSubnetsReason,
+		"Invalid host subnets annotation for network %s: %v", err)
+}
+
+// recordHostSubnetAllocationFailure posts the failure to allocate the host
+// subnets of the node
+func (na *NodeAllocator) recordHostSubnetAllocationFailure(node *corev1.Node, err error) {
+	reason := HostSubnetAllocationFailedReason
+	if errors.Is(err, ErrSubnetAllocatorFull) {
+		reason = HostSubnetsExhaustedReason
+	}
+	na.recordAllocationEvent(node, reason, "Failed to allocate the host subnets for network %s: %v", err)
+}
+
+// recordDuplicateHostSubnet reports the host subnet annotated on the node that
+// is already annotated on an older node, replaced when the node is handled
+func (na *NodeAllocator) recordDuplicateHostSubnet(node *corev1.Node, hostSubnet *net.IPNet, err error) {
+	networkName := na.netInfo.GetNetworkName()
+	klog.Warningf("Duplicate host subnet %s of node %s for network %s, the node gets another host subnet: %v",
+		hostSubnet, node.Name, networkName, err)
+	metrics.RecordHostSubnetDuplicate(networkName)
+	na.recordAllocationEvent(node, DuplicateHostSubnetReason,
+		"Duplicate host subnet for network %s, replaced with another host subnet: %v", err)
+}
+
+// recordAllocationEvent posts a warning event with the given reason and
+// message, formatted with the network name and the error, on the node and on
+// the network
+func (na *NodeAllocator) recordAllocationEvent(node *corev1.Node, reason, messageFmt string, err error) {
+	if na.recorder == nil {
+		return
+	}
+	networkName := na.netInfo.GetNetworkName()
+	nodeRef := &corev1.ObjectReference{
+		Kind: "Node",
+		Name: node.Name,
+		UID:  ktypes.UID(node.Name),
+	}
+	na.recorder.Eventf(nodeRef, corev1.EventTypeWarning, reason, messageFmt, networkName, err)
+	networkRef := &corev1.ObjectReference{
+		Kind: networkEventKind,
+		Name: networkName,
+	}
+	na.recorder.Eventf(networkRef, corev1.EventTypeWarning, reason, "Node %s: "+messageFmt, node.Name, networkName, err)
+}
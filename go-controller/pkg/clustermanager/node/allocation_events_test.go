@@ -0,0 +1,96 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_EnableEvents(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/23"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset()
+	addNode := func(node *corev1.Node) {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder := record.NewFakeRecorder(10)
+	expectEvents := func(expected ...string) {
+		for _, prefix := range expected {
+			select {
+			case event := <-recorder.Events:
+				if !strings.HasPrefix(event, prefix) {
+					t.Fatalf("expected an event starting with %q, got %q", prefix, event)
+				}
+			default:
+				t.Fatalf("expected an event starting with %q, got none", prefix)
+			}
+		}
+		select {
+		case event := <-recorder.Events:
+			t.Fatalf("expected no more events, got %q", event)
+		default:
+		}
+	}
+
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableEvents(recorder)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid annotation is reported
+	node1 := newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": "invalid"})
+	addNode(node1)
+	if err := na.HandleAddUpdateNodeEvent(node1); err == nil {
+		t.Fatal("expected the annotation update of node1 to fail")
+	}
+	expectEvents(
+		"Warning InvalidHostSubnets Invalid host subnets annotation for network default",
+		"Warning InvalidHostSubnets Node node1: Invalid host subnets annotation for network default",
+	)
+
+	for _, name := range []string{"node2", "node3"} {
+		node := newPlanTestNode(name, nil)
+		addNode(node)
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectEvents()
+
+	// the cluster subnets are exhausted
+	node4 := newPlanTestNode("node4", nil)
+	addNode(node4)
+	if err := na.HandleAddUpdateNodeEvent(node4); err == nil {
+		t.Fatal("expected the host subnet allocation of node4 to fail")
+	}
+	expectEvents(
+		"Warning HostSubnetsExhausted Failed to allocate the host subnets for network default",
+		"Warning HostSubnetsExhausted Node node4: Failed to allocate the host subnets for network default",
+	)
+}
@@ -0,0 +1,39 @@
+package node
+
+import (
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+)
+
+// startSubnetAllocation records the time of the first event of the node
+// without host subnets, the start of the allocation of its host subnets
+func (na *NodeAllocator) startSubnetAllocation(nodeName string) {
+	na.pendingNodesLock.Lock()
+	defer na.pendingNodesLock.Unlock()
+	if _, ok := na.pendingNodes[nodeName]; !ok {
+		na.pendingNodes[nodeName] = time.Now()
+	}
+}
+
+// finishSubnetAllocation records the duration of the allocation of the host
+// subnets of the node, once its host subnets annotation is committed, if
+// started
+func (na *NodeAllocator) finishSubnetAllocation(nodeName string) {
+	na.pendingNodesLock.Lock()
+	defer na.pendingNodesLock.Unlock()
+	start, ok := na.pendingNodes[nodeName]
+	if !ok {
+		return
+	}
+	delete(na.pendingNodes, nodeName)
+	metrics.RecordNodeSubnetAllocationLatency(na.netInfo.GetNetworkName(), time.Since(start))
+}
+
+// forgetSubnetAllocation drops the allocation of the host subnets of the
+// deleted node, if started
+func (na *NodeAllocator) forgetSubnetAllocation(nodeName string) {
+	na.pendingNodesLock.Lock()
+	defer na.pendingNodesLock.Unlock()
+	delete(na.pendingNodes, nodeName)
+}
@@ -0,0 +1,88 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_SubnetAllocationLatency(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset()
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	isPending := func(nodeName string) bool {
+		na.pendingNodesLock.Lock()
+		defer na.pendingNodesLock.Unlock()
+		_, ok := na.pendingNodes[nodeName]
+		return ok
+	}
+
+	// the annotation update of a node missing from the API server fails, its
+	// allocation stays pending until the annotation is committed
+	node1 := newPlanTestNode("node1", nil)
+	if err := indexer.Add(node1); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.HandleAddUpdateNodeEvent(node1); err == nil {
+		t.Fatal("expected the annotation update of node1 to fail")
+	}
+	if !isPending("node1") {
+		t.Fatal("expected the allocation of node1 to be pending")
+	}
+	if _, err := client.CoreV1().Nodes().Create(context.TODO(), node1, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.HandleAddUpdateNodeEvent(node1); err != nil {
+		t.Fatal(err)
+	}
+	if isPending("node1") {
+		t.Fatal("expected the allocation of node1 to be finished")
+	}
+
+	// a node already holding its host subnets is not tracked
+	node2 := newPlanTestNode("node2", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.3.0/24"]}`})
+	if err := indexer.Add(node2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Nodes().Create(context.TODO(), node2, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.HandleAddUpdateNodeEvent(node2); err != nil {
+		t.Fatal(err)
+	}
+	if isPending("node2") {
+		t.Fatal("expected no pending allocation for node2")
+	}
+
+	// the pending allocation of a deleted node is dropped
+	node3 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node3"}}
+	na.startSubnetAllocation(node3.Name)
+	if err := na.HandleDeleteNode(node3); err != nil {
+		t.Fatal(err)
+	}
+	if isPending("node3") {
+		t.Fatal("expected the pending allocation of node3 to be dropped")
+	}
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// ConvertNodeSubnetAnnotations rewrites the "k8s.ovn.org/node-subnets"
+// annotation of the nodes in the given format: to the per-network format when
+// upgrading clusters from releases that wrote the legacy format, or back to
+// the legacy format before a rollback. The host subnets of the default network
+// are validated with a node allocator of the configured cluster subnets first
+// and nothing is converted if any node fails the validation. It returns the
+// names of the nodes converted, or that would be converted with dryRun.
+func ConvertNodeSubnetAnnotations(kube kube.Interface, nodes []*corev1.Node, format util.NodeHostSubnetAnnotationFormat, dryRun bool) ([]string, error) {
+	if err := ValidateNodeSubnetsWithAllocator(nodes); err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{}
+	for _, node := range nodes {
+		current, err := util.GetNodeHostSubnetAnnotationFormat(node)
+		if err != nil {
+			if util.IsAnnotationNotSetError(err) {
+				continue
+			}
+			return nil, err
+		}
+		if current == format {
+			continue
+		}
+		annotation, err := util.ConvertNodeHostSubnetAnnotation(node, format)
+		if err != nil {
+			return nil, err
+		}
+		annotations[node.Name] = annotation
+	}
+
+	converted := make([]string, 0, len(annotations))
+	for nodeName := range annotations {
+		converted = append(converted, nodeName)
+	}
+	sort.Strings(converted)
+	if dryRun {
+		return converted, nil
+	}
+
+	for i, nodeName := range converted {
+		klog.Infof("Converting node %s subnets annotation to the %s format", nodeName, format)
+		err := kube.SetAnnotationsOnNode(nodeName, map[string]interface{}{
+			util.OvnNodeSubnetsAnnotation: annotations[nodeName],
+		})
+		if err != nil {
+			return converted[:i], fmt.Errorf("failed to convert node %s subnets annotation: %w", nodeName, err)
+		}
+	}
+	return converted, nil
+}
+
+// ValidateNodeSubnetsWithAllocator checks that the host subnets of the default
+// network of the nodes all belong to the configured cluster subnets and that
+// no two nodes have overlapping host subnets, by marking them as allocated in a
+// node allocator like the cluster manager does on startup.
+func ValidateNodeSubnetsWithAllocator(nodes []*corev1.Node) error {
+	// the default network always has network id 0
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, nil, nil, nil)
+	if err := na.Init(); err != nil {
+		return fmt.Errorf("failed to initialize the node allocator: %w", err)
+	}
+	if err := na.ValidateNodeSubnets(nodes); err != nil {
+		return err
+	}
+
+	sorted := make([]*corev1.Node, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	clusterSubnets := na.netInfo.Subnets()
+	for _, node := range sorted {
+		if util.NoHostSubnet(node) {
+			continue
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			if util.IsAnnotationNotSetError(err) {
+				continue
+			}
+			return fmt.Errorf("failed to parse node %s subnets annotation: %w", node.Name, err)
+		}
+		for _, hostSubnet := range hostSubnets {
+			// extra host subnets out of the cluster subnets are released
+			// by the cluster manager and were already validated above
+			if !isHostSubnetOfClusterSubnets(hostSubnet, clusterSubnets) {
+				continue
+			}
+			if err := na.clusterSubnetAllocator.MarkAllocatedNetworks(node.Name, hostSubnet); err != nil {
+				return fmt.Errorf("node %s host subnet %s is invalid: %w", node.Name, hostSubnet, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestConvertNodeSubnetAnnotations(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodes     map[string]string
+		format    util.NodeHostSubnetAnnotationFormat
+		dryRun    bool
+		want      map[string]string
+		converted []string
+		wantErr   bool
+	}{
+		{
+			name: "legacy to per-network",
+			nodes: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+				"node2": `{"default":["10.128.2.0/23"]}`,
+				"node3": `{"default":"10.128.4.0/23","blue":"192.168.0.0/24"}`,
+				"node4": "",
+			},
+			format: util.NodeHostSubnetAnnotationFormatPerNetwork,
+			want: map[string]string{
+				"node1": `{"default":["10.128.0.0/23"]}`,
+				"node2": `{"default":["10.128.2.0/23"]}`,
+				"node3": `{"blue":["192.168.0.0/24"],"default":["10.128.4.0/23"]}`,
+				"node4": "",
+			},
+			converted: []string{"node1", "node3"},
+		},
+		{
+			name: "per-network to legacy",
+			nodes: map[string]string{
+				"node1": `{"default":["10.128.0.0/23"]}`,
+				"node2": `{"default":"10.128.2.0/23"}`,
+			},
+			format: util.NodeHostSubnetAnnotationFormatLegacy,
+			want: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+				"node2": `{"default":"10.128.2.0/23"}`,
+			},
+			converted: []string{"node1"},
+		},
+		{
+			name: "dry run",
+			nodes: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+			},
+			format: util.NodeHostSubnetAnnotationFormatPerNetwork,
+			dryRun: true,
+			want: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+			},
+			converted: []string{"node1"},
+		},
+		{
+			name: "overlapping host subnets",
+			nodes: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+				"node2": `{"default":"10.128.0.0/23"}`,
+			},
+			format: util.NodeHostSubnetAnnotationFormatPerNetwork,
+			want: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+				"node2": `{"default":"10.128.0.0/23"}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "host subnet out of the cluster subnets",
+			nodes: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+				"node2": `{"default":"10.200.0.0/23"}`,
+			},
+			format: util.NodeHostSubnetAnnotationFormatPerNetwork,
+			want: map[string]string{
+				"node1": `{"default":"10.128.0.0/23"}`,
+				"node2": `{"default":"10.200.0.0/23"}`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.PrepareTestConfig(); err != nil {
+				t.Fatal(err)
+			}
+			ranges, err := rangesFromStrings([]string{"10.128.0.0/14"}, []int{23})
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.Default.ClusterSubnets = ranges
+			config.IPv4Mode = true
+
+			nodes := []*corev1.Node{}
+			for name, subnets := range tt.nodes {
+				annotations := map[string]string{}
+				if subnets != "" {
+					annotations[util.OvnNodeSubnetsAnnotation] = subnets
+				}
+				nodes = append(nodes, newPlanTestNode(name, annotations))
+			}
+			cluster := NewFakeCluster(nodes...)
+
+			converted, err := ConvertNodeSubnetAnnotations(cluster.Kube(), nodes, tt.format, tt.dryRun)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ConvertNodeSubnetAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(converted) > 0 || len(tt.converted) > 0 {
+				if !reflect.DeepEqual(converted, tt.converted) {
+					t.Fatalf("ConvertNodeSubnetAnnotations() converted = %v, want %v", converted, tt.converted)
+				}
+			}
+
+			for name, want := range tt.want {
+				node, err := cluster.GetNode(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := node.Annotations[util.OvnNodeSubnetsAnnotation]; got != want {
+					t.Fatalf("Node %s subnets annotation = %s, want %s", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package node
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// deletedNodeSubnetReleaseInterval is how often the host subnets of the
+// deleted nodes whose grace period is over are released
+const deletedNodeSubnetReleaseInterval = 5 * time.Second
+
+// deletedNodeSubnets are the host subnets kept for a deleted node
+type deletedNodeSubnets struct {
+	subnets   []*net.IPNet
+	releaseAt time.Time
+}
+
+// EnableDeletedNodeSubnetGracePeriod keeps the host subnets of the deleted
+// nodes allocated for the given grace period so that a node deleted and
+// recreated with the same name, like when re-provisioned by the cloud
+// provider, gets its previous host subnets back. Must be called before Init.
+func (na *NodeAllocator) EnableDeletedNodeSubnetGracePeriod(gracePeriod time.Duration) {
+	if gracePeriod <= 0 {
+		return
+	}
+	na.deletedNodeSubnetGracePeriod = gracePeriod
+	na.deletedNodes = map[string]*deletedNodeSubnets{}
+}
+
+// RunDeletedNodeSubnetRelease releases the host subnets of the deleted nodes
+// once their grace period is over, until stopCh is closed. No-op unless the
+// grace period is enabled.
+func (na *NodeAllocator) RunDeletedNodeSubnetRelease(stopCh <-chan struct{}, wg *sync.WaitGroup) {
+	if na.deletedNodeSubnetGracePeriod == 0 {
+		return
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			na.releaseExpiredNodeSubnets(time.Now())
+		}, deletedNodeSubnetReleaseInterval, stopCh)
+	}()
+}
+
+// deferSubnetRelease keeps the host subnets of the deleted node for the
+// grace period, if enabled. It returns whether the release was deferred.
+func (na *NodeAllocator) deferSubnetRelease(node *corev1.Node) bool {
+	if na.deletedNodeSubnetGracePeriod == 0 {
+		return false
+	}
+	hostSubnets, err := na.getNodeHostSubnets(node, na.netInfo.GetNetworkName())
+	if err != nil || len(hostSubnets) == 0 {
+		return false
+	}
+	na.deletedNodesLock.Lock()
+	defer na.deletedNodesLock.Unlock()
+	na.deletedNodes[node.Name] = &deletedNodeSubnets{
+		subnets:   hostSubnets,
+		releaseAt: time.Now().Add(na.deletedNodeSubnetGracePeriod),
+	}
+	klog.Infof("Keeping the host subnets %v of deleted node %s for network %s for %s", hostSubnets, node.Name,
+		na.netInfo.GetNetworkName(), na.deletedNodeSubnetGracePeriod)
+	return true
+}
+
+// reclaimDeletedNodeSubnets returns the host subnets kept for the node if it
+// was deleted within the grace period, nil otherwise. The node owns them
+// again.
+func (na *NodeAllocator) reclaimDeletedNodeSubnets(nodeName string) []*net.IPNet {
+	if na.deletedNodeSubnetGracePeriod == 0 {
+		return nil
+	}
+	na.deletedNodesLock.Lock()
+	defer na.deletedNodesLock.Unlock()
+	deleted, ok := na.deletedNodes[nodeName]
+	if !ok {
+		return nil
+	}
+	delete(na.deletedNodes, nodeName)
+	klog.Infof("Node %s came back within the grace period, reclaiming its host subnets %v for network %s",
+		nodeName, deleted.subnets, na.netInfo.GetNetworkName())
+	return deleted.subnets
+}
+
+// releaseUnusedSubnets releases the subnets kept for the node that it doesn't
+// use anymore
+func (na *NodeAllocator) releaseUnusedSubnets(nodeName string, keptSubnets, usedSubnets []*net.IPNet) {
+	used := sets.New[string]()
+	for _, subnet := range usedSubnets {
+		used.Insert(subnet.String())
+	}
+	var unused []*net.IPNet
+	for _, subnet := range keptSubnets {
+		if !used.Has(subnet.String()) {
+			unused = append(unused, subnet)
+		}
+	}
+	if err := na.clusterSubnetAllocator.ReleaseNetworks(nodeName, unused...); err != nil {
+		klog.Warningf("Error releasing node %s previous subnets %v: %v", nodeName, unused, err)
+	}
+}
+
+// releaseExpiredNodeSubnets releases the host subnets of the deleted nodes
+// whose grace period is over
+func (na *NodeAllocator) releaseExpiredNodeSubnets(now time.Time) {
+	na.deletedNodesLock.Lock()
+	defer na.deletedNodesLock.Unlock()
+	released := false
+	for nodeName, deleted := range na.deletedNodes {
+		if deleted.releaseAt.After(now) {
+			continue
+		}
+		na.clusterSubnetAllocator.ReleaseAllNetworks(nodeName)
+		delete(na.deletedNodes, nodeName)
+		released = true
+		klog.Infof("Released the host subnets %v of deleted node %s for network %s", deleted.subnets, nodeName,
+			na.netInfo.GetNetworkName())
+	}
+	if released {
+		na.recordSubnetUsage()
+	}
+}
+
+// releaseAllDeletedNodeSubnets releases the host subnets of all the deleted
+// nodes, whatever their grace period
+func (na *NodeAllocator) releaseAllDeletedNodeSubnets() {
+	na.deletedNodesLock.Lock()
+	defer na.deletedNodesLock.Unlock()
+	for nodeName := range na.deletedNodes {
+		na.clusterSubnetAllocator.ReleaseAllNetworks(nodeName)
+		delete(na.deletedNodes, nodeName)
+	}
+}
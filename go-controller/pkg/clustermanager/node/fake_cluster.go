@@ -0,0 +1,89 @@
+package node
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+)
+
+// FakeCluster is an in-memory stand-in for the Kubernetes API holding the
+// nodes a NodeAllocator runs against. It lets external operators and tests
+// embed the NodeAllocator logic without an API server: the allocator reads
+// the nodes through NodeLister and writes its annotations through Kube, and
+// both always see the same, up to date, nodes.
+type FakeCluster struct {
+	client *fake.Clientset
+}
+
+// NewFakeCluster builds a FakeCluster holding copies of the given nodes
+func NewFakeCluster(nodes ...*corev1.Node) *FakeCluster {
+	c := &FakeCluster{client: fake.NewSimpleClientset()}
+	for _, node := range nodes {
+		// the nodes can't conflict in an empty cluster, unless duplicated,
+		// in which case the first one wins
+		_ = c.AddNode(node)
+	}
+	return c
+}
+
+// Kube returns the kube interface to hand to the NodeAllocator
+func (c *FakeCluster) Kube() kube.Interface {
+	return &kube.Kube{KClient: c.client}
+}
+
+// NodeLister returns the node lister to hand to the NodeAllocator
+func (c *FakeCluster) NodeLister() listers.NodeLister {
+	return &fakeNodeLister{client: c.client}
+}
+
+// AddNode adds a copy of the given node to the cluster
+func (c *FakeCluster) AddNode(node *corev1.Node) error {
+	_, err := c.client.CoreV1().Nodes().Create(context.TODO(), node.DeepCopy(), metav1.CreateOptions{})
+	return err
+}
+
+// DeleteNode deletes the node with the given name from the cluster
+func (c *FakeCluster) DeleteNode(name string) error {
+	return c.client.CoreV1().Nodes().Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+// GetNode returns the node with the given name
+func (c *FakeCluster) GetNode(name string) (*corev1.Node, error) {
+	return c.client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// ListNodes returns all the nodes of the cluster sorted by name
+func (c *FakeCluster) ListNodes() ([]*corev1.Node, error) {
+	return c.NodeLister().List(labels.Everything())
+}
+
+// fakeNodeLister lists the nodes straight from the fake clientset so that the
+// updates of the NodeAllocator are immediately visible, unlike with an
+// informer backed lister
+type fakeNodeLister struct {
+	client *fake.Clientset
+}
+
+func (l *fakeNodeLister) List(selector labels.Selector) ([]*corev1.Node, error) {
+	nodeList, err := l.client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*corev1.Node, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes = append(nodes, &nodeList.Items[i])
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+func (l *fakeNodeLister) Get(name string) (*corev1.Node, error) {
+	return l.client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+}
@@ -0,0 +1,119 @@
+package node
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// EnableHealthCheckSubnets gives each node a subnet of each IP family of the
+// given health check subnets for its dedicated health check port, keeping the
+// node originated health check and monitoring traffic out of the management
+// port. Only for the default network, must be called before Init.
+func (na *NodeAllocator) EnableHealthCheckSubnets(clusterSubnets []config.CIDRNetworkEntry) {
+	if len(clusterSubnets) == 0 || na.netInfo.IsSecondary() {
+		return
+	}
+	na.healthCheckClusterSubnets = clusterSubnets
+	na.healthCheckSubnetAllocator = NewSubnetAllocator()
+}
+
+// initHealthCheckSubnets adds the health check subnets to their allocator
+func (na *NodeAllocator) initHealthCheckSubnets() error {
+	if na.healthCheckSubnetAllocator == nil {
+		return nil
+	}
+	for _, clusterSubnet := range na.healthCheckClusterSubnets {
+		if err := na.healthCheckSubnetAllocator.AddNetworkRange(clusterSubnet.CIDR, clusterSubnet.HostSubnetLength); err != nil {
+			return err
+		}
+		klog.V(5).Infof("Added network range %s to health check subnet allocator", clusterSubnet.CIDR)
+	}
+	return nil
+}
+
+// markHealthCheckSubnets marks the health check subnets of the node, if any,
+// as allocated
+func (na *NodeAllocator) markHealthCheckSubnets(node *corev1.Node) {
+	if na.healthCheckSubnetAllocator == nil {
+		return
+	}
+	subnets, err := util.ParseNodeHealthCheckSubnets(node)
+	if err != nil {
+		if !util.IsAnnotationNotSetError(err) {
+			klog.Errorf("Failed to parse the health check subnets of node %s: %v", node.Name, err)
+		}
+		return
+	}
+	if err := na.healthCheckSubnetAllocator.MarkAllocatedNetworks(node.Name, subnets...); err != nil {
+		klog.Errorf("Failed to mark the subnets %v as allocated in the health check subnet allocator for node %s: %v",
+			subnets, node.Name, err)
+	}
+}
+
+// syncHealthCheckSubnets ensures the node has a health check subnet of each
+// IP family of the health check subnets if enabled, or has none otherwise
+func (na *NodeAllocator) syncHealthCheckSubnets(node *corev1.Node) error {
+	if na.netInfo.IsSecondary() {
+		return nil
+	}
+	annotator := kube.NewNodeAnnotator(na.kube, node.Name)
+	existingSubnets, err := util.ParseNodeHealthCheckSubnets(node)
+	if err != nil && !util.IsAnnotationNotSetError(err) {
+		// Log the error and try to allocate new subnets
+		klog.Warningf("Failed to get the health check subnets of node %s: %v", node.Name, err)
+	}
+	if na.healthCheckSubnetAllocator == nil {
+		if util.IsAnnotationNotSetError(err) {
+			return nil
+		}
+		util.DeleteNodeHealthCheckSubnets(annotator)
+		if err := annotator.Run(); err != nil {
+			return fmt.Errorf("failed to remove the health check subnets annotation of node %s: %w", node.Name, err)
+		}
+		klog.Infof("Removed the health check subnets annotation of node %s", node.Name)
+		return nil
+	}
+
+	var ipv4Mode, ipv6Mode bool
+	for _, clusterSubnet := range na.healthCheckClusterSubnets {
+		if utilnet.IsIPv6CIDR(clusterSubnet.CIDR) {
+			ipv6Mode = true
+		} else {
+			ipv4Mode = true
+		}
+	}
+	validExistingSubnets, allocatedSubnets, err := na.allocateNodeSubnets(na.healthCheckSubnetAllocator, node.Name,
+		existingSubnets, ipv4Mode, ipv6Mode, 0, 0, 1)
+	if err != nil {
+		return fmt.Errorf("failed to allocate the health check subnets of node %s: %w", node.Name, err)
+	}
+	if len(existingSubnets) == len(validExistingSubnets) && len(allocatedSubnets) == 0 {
+		return nil
+	}
+	if err := util.SetNodeHealthCheckSubnets(annotator, validExistingSubnets); err != nil {
+		return err
+	}
+	if err := annotator.Run(); err != nil {
+		if errR := na.healthCheckSubnetAllocator.ReleaseNetworks(node.Name, allocatedSubnets...); errR != nil {
+			klog.Warningf("Error releasing the health check subnets of node %s: %v", node.Name, errR)
+		}
+		return fmt.Errorf("failed to set the health check subnets annotation of node %s: %w", node.Name, err)
+	}
+	klog.Infof("Allocated the health check subnets %s to node %s", util.JoinIPNets(validExistingSubnets, ","), node.Name)
+	return nil
+}
+
+// releaseHealthCheckSubnets releases the health check subnets of the node
+func (na *NodeAllocator) releaseHealthCheckSubnets(nodeName string) {
+	if na.healthCheckSubnetAllocator == nil {
+		return
+	}
+	na.healthCheckSubnetAllocator.ReleaseAllNetworks(nodeName)
+}
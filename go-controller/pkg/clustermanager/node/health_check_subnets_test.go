@@ -0,0 +1,114 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_HealthCheckSubnets(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	healthCheckRanges, err := rangesFromStrings([]string{"100.66.0.0/27"}, []int{28})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset()
+	addNode := func(node *corev1.Node) {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	getNode := func(name string) *corev1.Node {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return node
+	}
+	expectHealthCheckSubnet := func(name, expected string) {
+		subnets, err := util.ParseNodeHealthCheckSubnets(getNode(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(subnets) != 1 || subnets[0].String() != expected {
+			t.Fatalf("expected %s to have the health check subnet %s, got %v", name, expected, subnets)
+		}
+	}
+
+	node1 := newPlanTestNode("node1", map[string]string{
+		"k8s.ovn.org/node-subnets":              `{"default":["10.128.0.0/24"]}`,
+		"k8s.ovn.org/node-health-check-subnets": `["100.66.0.0/28"]`,
+	})
+	addNode(node1)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableHealthCheckSubnets(healthCheckRanges)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync([]interface{}{node1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the health check subnet of the existing node is kept and the new node
+	// gets the next one
+	if err := na.HandleAddUpdateNodeEvent(node1); err != nil {
+		t.Fatal(err)
+	}
+	expectHealthCheckSubnet("node1", "100.66.0.0/28")
+	node2 := newPlanTestNode("node2", nil)
+	addNode(node2)
+	if err := na.HandleAddUpdateNodeEvent(node2); err != nil {
+		t.Fatal(err)
+	}
+	expectHealthCheckSubnet("node2", "100.66.0.16/28")
+
+	// the health check subnets are exhausted
+	node3 := newPlanTestNode("node3", nil)
+	addNode(node3)
+	if err := na.HandleAddUpdateNodeEvent(node3); err == nil {
+		t.Fatal("expected the health check subnet allocation of node3 to fail")
+	}
+
+	// the health check subnet of a deleted node is handed out again
+	if err := na.HandleDeleteNode(node2); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.HandleAddUpdateNodeEvent(getNode("node3")); err != nil {
+		t.Fatal(err)
+	}
+	expectHealthCheckSubnet("node3", "100.66.0.16/28")
+
+	// the annotation is removed when the health check subnets are disabled
+	na = NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.HandleAddUpdateNodeEvent(getNode("node1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := util.ParseNodeHealthCheckSubnets(getNode("node1")); !util.IsAnnotationNotSetError(err) {
+		t.Fatalf("expected the health check subnets annotation of node1 to be removed, got %v", err)
+	}
+}
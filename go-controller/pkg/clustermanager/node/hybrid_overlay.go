@@ -0,0 +1,132 @@
+package node
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	hotypes "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/types"
+	houtil "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+)
+
+// EnableHybridOverlay enables the hybrid overlay at runtime with the given
+// hybrid overlay cluster subnets: the hybrid overlay subnets of the given
+// existing nodes are marked as allocated, the hybrid overlay nodes then have
+// to be handled again to get one. The hybrid overlay cluster subnets of an
+// enabled hybrid overlay can't be changed. Only for the default network. It
+// returns whether the hybrid overlay was enabled.
+func (na *NodeAllocator) EnableHybridOverlay(clusterSubnets []config.CIDRNetworkEntry, nodes []*corev1.Node) (bool, error) {
+	if na.netInfo.IsSecondary() {
+		return false, fmt.Errorf("hybrid overlay can only be enabled on the default network")
+	}
+	na.hybridOverlayLock.Lock()
+	defer na.hybridOverlayLock.Unlock()
+	if na.hybridOverlayEnabled {
+		if !sameClusterSubnets(na.hybridOverlayClusterSubnets, clusterSubnets) {
+			return false, fmt.Errorf("the hybrid overlay cluster subnets can't be changed from %v to %v, "+
+				"hybrid overlay must be disabled first", na.hybridOverlayClusterSubnets, clusterSubnets)
+		}
+		return false, nil
+	}
+	allocator := NewSubnetAllocator()
+	if err := addHybridOverlayNetworkRanges(allocator, clusterSubnets); err != nil {
+		return false, err
+	}
+	na.hybridOverlaySubnetAllocator = allocator
+	na.hybridOverlayClusterSubnets = clusterSubnets
+	na.hybridOverlayEnabled = true
+	for _, node := range nodes {
+		if houtil.IsHybridOverlayNode(node) {
+			na.markHybridOverlayNodeSubnet(node)
+		}
+	}
+	klog.Infof("Enabled hybrid overlay with the cluster subnets %v", clusterSubnets)
+	return true, nil
+}
+
+// DisableHybridOverlay disables the hybrid overlay at runtime and releases all
+// the hybrid overlay subnets. The hybrid overlay nodes then have to be handled
+// again for their hybrid overlay subnet annotation to be removed. It returns
+// whether the hybrid overlay was disabled.
+func (na *NodeAllocator) DisableHybridOverlay() bool {
+	na.hybridOverlayLock.Lock()
+	defer na.hybridOverlayLock.Unlock()
+	if !na.hybridOverlayEnabled {
+		return false
+	}
+	na.hybridOverlayEnabled = false
+	na.hybridOverlayClusterSubnets = nil
+	na.hybridOverlaySubnetAllocator = NewSubnetAllocator()
+	klog.Infof("Disabled hybrid overlay")
+	return true
+}
+
+// syncHybridOverlayNode ensures the hybrid overlay node has a hybrid overlay
+// subnet if the hybrid overlay is enabled, or has none otherwise
+func (na *NodeAllocator) syncHybridOverlayNode(node *corev1.Node) error {
+	na.hybridOverlayLock.RLock()
+	defer na.hybridOverlayLock.RUnlock()
+	annotator := kube.NewNodeAnnotator(na.kube, node.Name)
+	if !na.hasHybridOverlayAllocation() {
+		if _, ok := node.Annotations[hotypes.HybridOverlayNodeSubnet]; !ok {
+			return nil
+		}
+		annotator.Delete(hotypes.HybridOverlayNodeSubnet)
+		if err := annotator.Run(); err != nil {
+			return fmt.Errorf("failed to remove the hybrid overlay subnet annotation of node %s: %w", node.Name, err)
+		}
+		klog.Infof("Removed the hybrid overlay subnet annotation of node %s", node.Name)
+		return nil
+	}
+	allocatedSubnet, err := na.hybridOverlayNodeEnsureSubnet(node, annotator)
+	if err != nil {
+		return fmt.Errorf("failed to update node %s hybrid overlay subnet annotation: %v", node.Name, err)
+	}
+	if err := annotator.Run(); err != nil {
+		// Release allocated subnet if any errors occurred
+		if allocatedSubnet != nil {
+			na.releaseHybridOverlayNodeSubnet(node.Name)
+		}
+		return fmt.Errorf("failed to set hybrid overlay annotations for node %s: %v", node.Name, err)
+	}
+	return nil
+}
+
+// markHybridOverlayNodeSubnet marks the hybrid overlay subnet of the node, if
+// any, as allocated. Must be called with hybridOverlayLock held.
+func (na *NodeAllocator) markHybridOverlayNodeSubnet(node *corev1.Node) {
+	hostSubnet, err := houtil.ParseHybridOverlayHostSubnet(node)
+	if err != nil {
+		klog.Errorf("Failed to parse hybrid overlay for node %s: %v", node.Name, err)
+	} else if hostSubnet != nil {
+		klog.V(5).Infof("Node %s contains subnets: %v", node.Name, hostSubnet)
+		if err := na.hybridOverlaySubnetAllocator.MarkAllocatedNetworks(node.Name, hostSubnet); err != nil {
+			klog.Errorf("Failed to mark the subnet %v as allocated in the hybrid subnet allocator for node %s: %v", hostSubnet, node.Name, err)
+		}
+	}
+}
+
+func addHybridOverlayNetworkRanges(allocator SubnetAllocator, clusterSubnets []config.CIDRNetworkEntry) error {
+	for _, hoSubnet := range clusterSubnets {
+		if err := allocator.AddNetworkRange(hoSubnet.CIDR, hoSubnet.HostSubnetLength); err != nil {
+			return err
+		}
+		klog.V(5).Infof("Added network range %s to hybrid overlay subnet allocator", hoSubnet.CIDR)
+	}
+	return nil
+}
+
+func sameClusterSubnets(a, b []config.CIDRNetworkEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].String() != b[i].String() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,298 @@
+package node
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	ipfamilyconversionv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipfamilyconversion/v1"
+	ipfamilyconversionclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipfamilyconversion/v1/apis/clientset/versioned"
+)
+
+const (
+	// IPFamilyConversionName is the name of the IPFamilyConversion reporting
+	// the progress of the conversion
+	IPFamilyConversionName = "default"
+
+	// ipFamilyConversionCheckInterval is how often the progress of the
+	// conversion is checked and reported
+	ipFamilyConversionCheckInterval = 5 * time.Second
+)
+
+// IPFamilyConversionStore persists the progress of the IP family conversion
+type IPFamilyConversionStore interface {
+	// Paused returns whether the conversion of new batches is paused
+	Paused() (bool, error)
+	// UpdateStatus replaces the reported progress of the conversion
+	UpdateStatus(status *ipfamilyconversionv1.IPFamilyConversionStatus) error
+}
+
+// crdIPFamilyConversionStore reports the progress of the conversion in the
+// status of the IPFamilyConversion named IPFamilyConversionName, created if
+// needed
+type crdIPFamilyConversionStore struct {
+	client ipfamilyconversionclientset.Interface
+}
+
+// NewCRDIPFamilyConversionStore returns an IPFamilyConversionStore backed by
+// the IPFamilyConversion CRD
+func NewCRDIPFamilyConversionStore(client ipfamilyconversionclientset.Interface) IPFamilyConversionStore {
+	return &crdIPFamilyConversionStore{client: client}
+}
+
+func (s *crdIPFamilyConversionStore) Paused() (bool, error) {
+	conversion, err := s.client.K8sV1().IPFamilyConversions().Get(context.TODO(), IPFamilyConversionName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return conversion.Spec.Paused, nil
+}
+
+func (s *crdIPFamilyConversionStore) UpdateStatus(status *ipfamilyconversionv1.IPFamilyConversionStatus) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		conversions := s.client.K8sV1().IPFamilyConversions()
+		conversion, err := conversions.Get(context.TODO(), IPFamilyConversionName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			conversion, err = conversions.Create(context.TODO(), &ipfamilyconversionv1.IPFamilyConversion{
+				ObjectMeta: metav1.ObjectMeta{Name: IPFamilyConversionName},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+		conversion = conversion.DeepCopy()
+		conversion.Status = *status
+		_, err = conversions.UpdateStatus(context.TODO(), conversion, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// ipFamilyConverter rolls the conversion of the nodes of the default network
+// from dual-stack to single-stack, or back, through the nodes in batches: the
+// nodes whose host subnets don't match the IP families of the cluster subnets
+// keep them until their batch comes, and the next batch only starts once all
+// the nodes of the current one are converted and the batch interval elapsed.
+// The update of the node subnet annotation of a converted node triggers its
+// reconciliation by ovnkube-controller.
+type ipFamilyConverter struct {
+	batchSize  int
+	interval   time.Duration
+	store      IPFamilyConversionStore
+	ipFamilies []string
+
+	lock sync.Mutex
+	// pending are the nodes to convert that are not in a batch yet
+	pending sets.Set[string]
+	// batch are the nodes of the current batch not converted yet
+	batch     sets.Set[string]
+	total     int
+	converted int
+	paused    bool
+	// batchStart is when the current batch started
+	batchStart time.Time
+	startTime  *metav1.Time
+	// completionTime is set once all the nodes are converted
+	completionTime *metav1.Time
+	// changed is set when the progress changed since last reported
+	changed bool
+}
+
+func newIPFamilyConverter(batchSize int, interval time.Duration, store IPFamilyConversionStore, ipv4Mode, ipv6Mode bool) *ipFamilyConverter {
+	c := &ipFamilyConverter{
+		batchSize: batchSize,
+		interval:  interval,
+		store:     store,
+		pending:   sets.New[string](),
+		batch:     sets.New[string](),
+	}
+	if ipv4Mode {
+		c.ipFamilies = append(c.ipFamilies, "IPv4")
+	}
+	if ipv6Mode {
+		c.ipFamilies = append(c.ipFamilies, "IPv6")
+	}
+	return c
+}
+
+// needsIPFamilyConversion returns whether the host subnets of a node don't
+// match the enabled IP families
+func needsIPFamilyConversion(hostSubnets []*net.IPNet, ipv4Mode, ipv6Mode bool) bool {
+	if len(hostSubnets) == 0 {
+		return false
+	}
+	hasIPv4, hasIPv6 := false, false
+	for _, hostSubnet := range hostSubnets {
+		if utilnet.IsIPv6CIDR(hostSubnet) {
+			hasIPv6 = true
+		} else {
+			hasIPv4 = true
+		}
+	}
+	return hasIPv4 != ipv4Mode || hasIPv6 != ipv6Mode
+}
+
+// hostSubnetsOfIPFamilies returns the host subnets of the enabled IP families
+func hostSubnetsOfIPFamilies(hostSubnets []*net.IPNet, ipv4Mode, ipv6Mode bool) []*net.IPNet {
+	var filtered []*net.IPNet
+	for _, hostSubnet := range hostSubnets {
+		if utilnet.IsIPv6CIDR(hostSubnet) && ipv6Mode || !utilnet.IsIPv6CIDR(hostSubnet) && ipv4Mode {
+			filtered = append(filtered, hostSubnet)
+		}
+	}
+	return filtered
+}
+
+// addPending adds a node to convert, found on startup
+func (c *ipFamilyConverter) addPending(nodeName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.startTime == nil {
+		now := metav1.Now()
+		c.startTime = &now
+	}
+	c.pending.Insert(nodeName)
+	c.total++
+	c.changed = true
+}
+
+// isDeferred returns whether the conversion of the node waits for its batch
+func (c *ipFamilyConverter) isDeferred(nodeName string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.pending.Has(nodeName)
+}
+
+// markConverted records that the node of the current batch was converted
+func (c *ipFamilyConverter) markConverted(nodeName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.batch.Has(nodeName) {
+		return
+	}
+	c.batch.Delete(nodeName)
+	c.converted++
+	c.changed = true
+	klog.Infof("Converted the IP families of node %s to %v (%d/%d)", nodeName, c.ipFamilies, c.converted, c.total)
+}
+
+// forget drops a deleted node from the conversion
+func (c *ipFamilyConverter) forget(nodeName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.pending.Has(nodeName) || c.batch.Has(nodeName) {
+		c.pending.Delete(nodeName)
+		c.batch.Delete(nodeName)
+		c.total--
+		c.changed = true
+	}
+}
+
+// nextBatch starts the next batch of nodes if the current one is done, the
+// batch interval elapsed and the conversion is not paused. It returns the
+// nodes of the started batch.
+func (c *ipFamilyConverter) nextBatch(now time.Time, paused bool) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if paused != c.paused {
+		c.paused = paused
+		c.changed = true
+	}
+	if paused || c.batch.Len() > 0 || c.pending.Len() == 0 {
+		return nil
+	}
+	if !c.batchStart.IsZero() && now.Sub(c.batchStart) < c.interval {
+		return nil
+	}
+	nodeNames := sets.List(c.pending)
+	if len(nodeNames) > c.batchSize {
+		nodeNames = nodeNames[:c.batchSize]
+	}
+	c.pending.Delete(nodeNames...)
+	c.batch.Insert(nodeNames...)
+	c.batchStart = now
+	c.changed = true
+	klog.Infof("Converting the IP families of nodes %v to %v, %d nodes left", nodeNames, c.ipFamilies, c.pending.Len())
+	return nodeNames
+}
+
+// status returns the progress of the conversion if it changed since last
+// reported, nil otherwise
+func (c *ipFamilyConverter) status() *ipfamilyconversionv1.IPFamilyConversionStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.changed {
+		return nil
+	}
+	c.changed = false
+	phase := ipfamilyconversionv1.IPFamilyConversionInProgress
+	switch {
+	case c.pending.Len() == 0 && c.batch.Len() == 0:
+		phase = ipfamilyconversionv1.IPFamilyConversionCompleted
+		if c.completionTime == nil {
+			now := metav1.Now()
+			c.completionTime = &now
+			klog.Infof("Converted the IP families of all the %d nodes to %v", c.total, c.ipFamilies)
+		}
+	case c.paused:
+		phase = ipfamilyconversionv1.IPFamilyConversionPaused
+	}
+	return &ipfamilyconversionv1.IPFamilyConversionStatus{
+		IPFamilies:     c.ipFamilies,
+		Phase:          phase,
+		TotalNodes:     c.total,
+		ConvertedNodes: c.converted,
+		Batch:          sets.List(c.batch),
+		StartTime:      c.startTime,
+		CompletionTime: c.completionTime,
+	}
+}
+
+// run starts the batches and reports the progress of the conversion, calling
+// retryNodes for the nodes of each started batch to be converted, until all
+// the nodes are converted or stopCh is closed
+func (c *ipFamilyConverter) run(stopCh <-chan struct{}, wg *sync.WaitGroup, retryNodes func(nodeNames []string)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = wait.PollImmediateUntil(ipFamilyConversionCheckInterval, func() (bool, error) {
+			return c.sync(time.Now(), retryNodes), nil
+		}, stopCh)
+	}()
+}
+
+// sync starts the next batch, if due, and reports the progress. It returns
+// whether the conversion is completed and reported.
+func (c *ipFamilyConverter) sync(now time.Time, retryNodes func(nodeNames []string)) bool {
+	paused, err := c.store.Paused()
+	if err != nil {
+		klog.Warningf("Failed to check whether the IP family conversion is paused: %v", err)
+		paused = c.paused
+	}
+	if nodeNames := c.nextBatch(now, paused); len(nodeNames) > 0 {
+		retryNodes(nodeNames)
+	}
+	status := c.status()
+	if status == nil {
+		return false
+	}
+	if err := c.store.UpdateStatus(status); err != nil {
+		klog.Errorf("Failed to report the progress of the IP family conversion: %v", err)
+		c.lock.Lock()
+		c.changed = true
+		c.lock.Unlock()
+		return false
+	}
+	return status.Phase == ipfamilyconversionv1.IPFamilyConversionCompleted
+}
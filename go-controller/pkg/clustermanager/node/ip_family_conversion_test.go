@@ -0,0 +1,163 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ipfamilyconversionv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipfamilyconversion/v1"
+	ipfamilyconversionfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipfamilyconversion/v1/apis/clientset/versioned/fake"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_IPFamilyConversion(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// converted from dual-stack to IPv4 single-stack
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.IPv6Mode = false
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	objs := []runtime.Object{}
+	syncNodes := []interface{}{}
+	for _, node := range []*corev1.Node{
+		newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/24","fd00:10:128::/64"]}`}),
+		newPlanTestNode("node2", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.1.0/24","fd00:10:128:1::/64"]}`}),
+		newPlanTestNode("node3", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.2.0/24","fd00:10:128:2::/64"]}`}),
+		newPlanTestNode("node4", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.3.0/24"]}`}),
+	} {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, node)
+		syncNodes = append(syncNodes, node)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	conversionClient := ipfamilyconversionfake.NewSimpleClientset()
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableIPFamilyConversion(2, time.Minute, NewCRDIPFamilyConversionStore(conversionClient))
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync(syncNodes); err != nil {
+		t.Fatal(err)
+	}
+
+	handleNodes := func(nodeNames []string) {
+		for _, nodeName := range nodeNames {
+			node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := indexer.Update(node); err != nil {
+				t.Fatal(err)
+			}
+			if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectHostSubnets := func(nodeName string, expected ...string) {
+		t.Helper()
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := []string{}
+		for _, hostSubnet := range hostSubnets {
+			actual = append(actual, hostSubnet.String())
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %s to have the host subnets %v, got %v", nodeName, expected, actual)
+		}
+	}
+	expectStatus := func(phase string, converted int, batch ...string) {
+		t.Helper()
+		conversion, err := conversionClient.K8sV1().IPFamilyConversions().Get(context.TODO(), IPFamilyConversionName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := conversion.Status
+		if status.Phase != phase || status.ConvertedNodes != converted || status.TotalNodes != 3 ||
+			len(status.Batch) != len(batch) || len(batch) > 0 && !reflect.DeepEqual(status.Batch, batch) || !reflect.DeepEqual(status.IPFamilies, []string{"IPv4"}) {
+			t.Fatalf("expected the conversion to be %s with %d/3 nodes converted and batch %v, got %+v",
+				phase, converted, batch, status)
+		}
+	}
+
+	// the nodes keep their host subnets until their batch starts
+	handleNodes([]string{"node1", "node2", "node3", "node4"})
+	expectHostSubnets("node1", "10.128.0.0/24", "fd00:10:128::/64")
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 4 {
+		t.Fatalf("expected the 4 IPv4 host subnets to stay allocated, got %d", v4used)
+	}
+
+	now := time.Now()
+	var retried []string
+	retryNodes := func(nodeNames []string) { retried = append(retried, nodeNames...) }
+	if na.ipFamilyConversion.sync(now, retryNodes) {
+		t.Fatal("expected the conversion not to be completed")
+	}
+	if !reflect.DeepEqual(retried, []string{"node1", "node2"}) {
+		t.Fatalf("expected the first batch to be node1 and node2, got %v", retried)
+	}
+	expectStatus(ipfamilyconversionv1.IPFamilyConversionInProgress, 0, "node1", "node2")
+	handleNodes(retried)
+	expectHostSubnets("node1", "10.128.0.0/24")
+	expectHostSubnets("node2", "10.128.1.0/24")
+	expectHostSubnets("node3", "10.128.2.0/24", "fd00:10:128:2::/64")
+
+	// the next batch waits for the interval and is not started while paused
+	retried = nil
+	na.ipFamilyConversion.sync(now.Add(time.Second), retryNodes)
+	expectStatus(ipfamilyconversionv1.IPFamilyConversionInProgress, 2)
+	conversion, err := conversionClient.K8sV1().IPFamilyConversions().Get(context.TODO(), IPFamilyConversionName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conversion.Spec.Paused = true
+	if _, err := conversionClient.K8sV1().IPFamilyConversions().Update(context.TODO(), conversion, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	na.ipFamilyConversion.sync(now.Add(2*time.Minute), retryNodes)
+	expectStatus(ipfamilyconversionv1.IPFamilyConversionPaused, 2)
+	if len(retried) > 0 {
+		t.Fatalf("expected no batch to start, got %v", retried)
+	}
+
+	conversion.Spec.Paused = false
+	if _, err := conversionClient.K8sV1().IPFamilyConversions().Update(context.TODO(), conversion, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	na.ipFamilyConversion.sync(now.Add(2*time.Minute), retryNodes)
+	if !reflect.DeepEqual(retried, []string{"node3"}) {
+		t.Fatalf("expected the second batch to be node3, got %v", retried)
+	}
+	handleNodes(retried)
+	expectHostSubnets("node3", "10.128.2.0/24")
+	if !na.ipFamilyConversion.sync(now.Add(2*time.Minute), retryNodes) {
+		t.Fatal("expected the conversion to be completed")
+	}
+	expectStatus(ipfamilyconversionv1.IPFamilyConversionCompleted, 3)
+}
@@ -0,0 +1,327 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	nodenetworkstateclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/clientset/versioned"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// NetworkStateStore persists the per-node network state outside of the node
+// annotations.
+type NetworkStateStore interface {
+	// GetNodeNetworkState returns the last NodeNetworkState stored for the
+	// node, read from the API server the first time, or a not found error
+	// if the node has none.
+	util.NodeNetworkStateGetter
+	// UpdateNodeNetworkState stores the host subnets of each network of
+	// hostSubnetsMap and the ID of network networkName for the node. Nil
+	// host subnets or an invalid network ID remove the respective state.
+	UpdateNodeNetworkState(node *corev1.Node, hostSubnetsMap map[string][]*net.IPNet, networkName string, networkID int) error
+	// UpdateNodeGatewayState stores the chassis ID and gateway config of the
+	// node as set in its annotations, if they changed.
+	UpdateNodeGatewayState(node *corev1.Node) error
+	// MigrateNodeNetworkState stores the whole network state of the node as
+	// set in its annotations, but for the host subnets stored in the state
+	// only, see util.UseNodeNetworkState.
+	MigrateNodeNetworkState(node *corev1.Node) error
+	// UpdateNodeNetworkCondition reports whether the host subnets of network
+	// networkName were allocated to the node, allocationErr being the reason
+	// they were not, if they changed.
+	UpdateNodeNetworkCondition(node *corev1.Node, networkName string, allocationErr error) error
+	// ForgetNode drops what is cached for the deleted node, whose state is
+	// garbage collected along with it, so that the state of a node re-added
+	// with the same name is stored again.
+	ForgetNode(nodeName string)
+}
+
+// crdNetworkStateStore stores the per-node network state in a NodeNetworkState
+// named after the node and owned by it, so that it is garbage collected along
+// with the node.
+type crdNetworkStateStore struct {
+	client nodenetworkstateclientset.Interface
+
+	// statesLock protects states
+	statesLock sync.Mutex
+	// states caches the NodeNetworkState of each node as last read or
+	// written, nil if the node has none. The cluster manager being the only
+	// writer of the states, it reads back what it wrote without waiting for
+	// an informer to catch up.
+	states map[string]*nodenetworkstatev1.NodeNetworkState
+
+	// gatewayStateLock protects gatewayState
+	gatewayStateLock sync.Mutex
+	// gatewayState caches the last chassis ID and gateway config stored for
+	// each node to avoid hitting the API server on every node update
+	gatewayState map[string]nodenetworkstatev1.NodeNetworkStateSpec
+
+	// conditionsLock protects conditions
+	conditionsLock sync.Mutex
+	// conditions caches the last message of the host subnet allocation
+	// condition reported for each network of each node, empty if allocated
+	conditions map[string]map[string]string
+}
+
+// NewCRDNetworkStateStore returns a NetworkStateStore backed by the
+// NodeNetworkState CRD
+func NewCRDNetworkStateStore(client nodenetworkstateclientset.Interface) NetworkStateStore {
+	return &crdNetworkStateStore{
+		client:       client,
+		states:       map[string]*nodenetworkstatev1.NodeNetworkState{},
+		gatewayState: map[string]nodenetworkstatev1.NodeNetworkStateSpec{},
+		conditions:   map[string]map[string]string{},
+	}
+}
+
+// update gets or creates the NodeNetworkState of the node and updates it with
+// the given function, retrying on conflicts. Nothing is written if the cached
+// state is left unchanged.
+func (s *crdNetworkStateStore) update(node *corev1.Node, mutate func(*nodenetworkstatev1.NodeNetworkStateSpec)) error {
+	s.statesLock.Lock()
+	cached := s.states[node.Name]
+	s.statesLock.Unlock()
+	if cached != nil {
+		spec := cached.Spec.DeepCopy()
+		mutate(spec)
+		if reflect.DeepEqual(spec, &cached.Spec) {
+			return nil
+		}
+	}
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		state, err := s.client.K8sV1().NodeNetworkStates().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if apierrors.IsNotFound(err) {
+			state = &nodenetworkstatev1.NodeNetworkState{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: node.Name,
+					OwnerReferences: []metav1.OwnerReference{
+						*metav1.NewControllerRef(node, corev1.SchemeGroupVersion.WithKind("Node")),
+					},
+				},
+			}
+			mutate(&state.Spec)
+			created, err := s.client.K8sV1().NodeNetworkStates().Create(context.TODO(), state, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// created concurrently, retry as an update
+				return apierrors.NewConflict(nodenetworkstatev1.Resource("nodenetworkstates"), node.Name, err)
+			}
+			if err == nil {
+				s.cacheState(node.Name, created)
+			}
+			return err
+		}
+		state = state.DeepCopy()
+		mutate(&state.Spec)
+		updated, err := s.client.K8sV1().NodeNetworkStates().Update(context.TODO(), state, metav1.UpdateOptions{})
+		if err == nil {
+			s.cacheState(node.Name, updated)
+		}
+		return err
+	})
+}
+
+func (s *crdNetworkStateStore) cacheState(nodeName string, state *nodenetworkstatev1.NodeNetworkState) {
+	s.statesLock.Lock()
+	defer s.statesLock.Unlock()
+	s.states[nodeName] = state
+}
+
+func (s *crdNetworkStateStore) GetNodeNetworkState(nodeName string) (*nodenetworkstatev1.NodeNetworkState, error) {
+	s.statesLock.Lock()
+	state, ok := s.states[nodeName]
+	s.statesLock.Unlock()
+	if !ok {
+		var err error
+		state, err = s.client.K8sV1().NodeNetworkStates().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if apierrors.IsNotFound(err) {
+			state = nil
+		}
+		s.statesLock.Lock()
+		if _, ok := s.states[nodeName]; !ok {
+			s.states[nodeName] = state
+		}
+		state = s.states[nodeName]
+		s.statesLock.Unlock()
+	}
+	if state == nil {
+		return nil, apierrors.NewNotFound(nodenetworkstatev1.Resource("nodenetworkstates"), nodeName)
+	}
+	return state, nil
+}
+
+func (s *crdNetworkStateStore) UpdateNodeNetworkState(node *corev1.Node, hostSubnetsMap map[string][]*net.IPNet, networkName string, networkID int) error {
+	err := s.update(node, func(spec *nodenetworkstatev1.NodeNetworkStateSpec) {
+		if spec.Networks == nil {
+			spec.Networks = map[string]nodenetworkstatev1.NodeNetwork{}
+		}
+		for netName, hostSubnets := range hostSubnetsMap {
+			nodeNetwork := spec.Networks[netName]
+			nodeNetwork.Subnets = nil
+			for _, subnet := range hostSubnets {
+				nodeNetwork.Subnets = append(nodeNetwork.Subnets, subnet.String())
+			}
+			spec.Networks[netName] = nodeNetwork
+		}
+		nodeNetwork := spec.Networks[networkName]
+		nodeNetwork.NetworkID = nil
+		if networkID != util.InvalidNetworkID {
+			id := networkID
+			nodeNetwork.NetworkID = &id
+		}
+		spec.Networks[networkName] = nodeNetwork
+		for netName, nodeNetwork := range spec.Networks {
+			if len(nodeNetwork.Subnets) == 0 && nodeNetwork.NetworkID == nil {
+				delete(spec.Networks, netName)
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update node network state of node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+func (s *crdNetworkStateStore) UpdateNodeGatewayState(node *corev1.Node) error {
+	spec, err := util.NodeNetworkStateSpecFromAnnotations(node)
+	if err != nil {
+		return err
+	}
+
+	s.gatewayStateLock.Lock()
+	defer s.gatewayStateLock.Unlock()
+	cached, ok := s.gatewayState[node.Name]
+	if ok && cached.ChassisID == spec.ChassisID && cached.L3GatewayConfig == spec.L3GatewayConfig {
+		return nil
+	}
+
+	err = s.update(node, func(state *nodenetworkstatev1.NodeNetworkStateSpec) {
+		state.ChassisID = spec.ChassisID
+		state.L3GatewayConfig = spec.L3GatewayConfig
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update gateway state of node %s: %w", node.Name, err)
+	}
+	s.gatewayState[node.Name] = nodenetworkstatev1.NodeNetworkStateSpec{
+		ChassisID:       spec.ChassisID,
+		L3GatewayConfig: spec.L3GatewayConfig,
+	}
+	return nil
+}
+
+func (s *crdNetworkStateStore) MigrateNodeNetworkState(node *corev1.Node) error {
+	spec, err := util.NodeNetworkStateSpecFromAnnotations(node)
+	if err != nil {
+		return err
+	}
+	err = s.update(node, func(state *nodenetworkstatev1.NodeNetworkStateSpec) {
+		networks := make(map[string]nodenetworkstatev1.NodeNetwork, len(spec.Networks))
+		for netName, nodeNetwork := range spec.Networks {
+			networks[netName] = nodeNetwork
+		}
+		// the host subnets no longer annotated are only found in the state
+		for netName, nodeNetwork := range state.Networks {
+			if util.UseNodeNetworkState(netName) {
+				networks[netName] = nodeNetwork
+			}
+		}
+		*state = *spec
+		state.Networks = networks
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate network state of node %s: %w", node.Name, err)
+	}
+
+	s.gatewayStateLock.Lock()
+	defer s.gatewayStateLock.Unlock()
+	s.gatewayState[node.Name] = nodenetworkstatev1.NodeNetworkStateSpec{
+		ChassisID:       spec.ChassisID,
+		L3GatewayConfig: spec.L3GatewayConfig,
+	}
+	return nil
+}
+
+func (s *crdNetworkStateStore) UpdateNodeNetworkCondition(node *corev1.Node, networkName string, allocationErr error) error {
+	condition := metav1.Condition{
+		Type:    nodenetworkstatev1.NodeNetworkHostSubnetsAllocated,
+		Status:  metav1.ConditionTrue,
+		Reason:  nodenetworkstatev1.NodeNetworkReasonAllocated,
+		Message: "",
+	}
+	if allocationErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = nodenetworkstatev1.NodeNetworkReasonAllocationFailed
+		condition.Message = allocationErr.Error()
+	}
+
+	s.conditionsLock.Lock()
+	defer s.conditionsLock.Unlock()
+	if message, ok := s.conditions[node.Name][networkName]; ok && message == condition.Message {
+		return nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		state, err := s.client.K8sV1().NodeNetworkStates().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			// the status of a node that didn't get any state yet is
+			// reported on its state, created empty
+			if err = s.update(node, func(*nodenetworkstatev1.NodeNetworkStateSpec) {}); err != nil {
+				return err
+			}
+			state, err = s.client.K8sV1().NodeNetworkStates().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		}
+		if err != nil {
+			return err
+		}
+		state = state.DeepCopy()
+		if state.Status.Networks == nil {
+			state.Status.Networks = map[string]nodenetworkstatev1.NodeNetworkStatus{}
+		}
+		status := state.Status.Networks[networkName]
+		condition.ObservedGeneration = state.Generation
+		meta.SetStatusCondition(&status.Conditions, condition)
+		state.Status.Networks[networkName] = status
+		updated, err := s.client.K8sV1().NodeNetworkStates().UpdateStatus(context.TODO(), state, metav1.UpdateOptions{})
+		if err == nil {
+			s.cacheState(node.Name, updated)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the host subnet condition of network %s of node %s: %w", networkName, node.Name, err)
+	}
+	if s.conditions[node.Name] == nil {
+		s.conditions[node.Name] = map[string]string{}
+	}
+	s.conditions[node.Name][networkName] = condition.Message
+	return nil
+}
+
+func (s *crdNetworkStateStore) ForgetNode(nodeName string) {
+	s.statesLock.Lock()
+	delete(s.states, nodeName)
+	s.statesLock.Unlock()
+
+	s.gatewayStateLock.Lock()
+	delete(s.gatewayState, nodeName)
+	s.gatewayStateLock.Unlock()
+
+	s.conditionsLock.Lock()
+	delete(s.conditions, nodeName)
+	s.conditionsLock.Unlock()
+}
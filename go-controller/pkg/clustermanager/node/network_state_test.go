@@ -0,0 +1,282 @@
+package node
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	nodenetworkstatefake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/clientset/versioned/fake"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestCRDNetworkStateStore(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			UID:  "uid1",
+			Annotations: map[string]string{
+				"k8s.ovn.org/node-subnets":      `{"default":["10.128.0.0/24"],"blue":["10.129.0.0/24"]}`,
+				"k8s.ovn.org/network-ids":       `{"default":"0","blue":"1"}`,
+				"k8s.ovn.org/node-chassis-id":   "chassis1",
+				"k8s.ovn.org/l3-gateway-config": `{"default":{"mode":"shared"}}`,
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		update        func(s NetworkStateStore) error
+		expectedState nodenetworkstatev1.NodeNetworkStateSpec
+		// expectedConditions are the reasons of the host subnet allocation
+		// condition of each network
+		expectedConditions map[string]string
+	}{
+		{
+			name: "migrates the node annotations",
+			update: func(s NetworkStateStore) error {
+				return s.MigrateNodeNetworkState(node)
+			},
+			expectedState: nodenetworkstatev1.NodeNetworkStateSpec{
+				Networks: map[string]nodenetworkstatev1.NodeNetwork{
+					"default": {Subnets: []string{"10.128.0.0/24"}, NetworkID: intPtr(0)},
+					"blue":    {Subnets: []string{"10.129.0.0/24"}, NetworkID: intPtr(1)},
+				},
+				ChassisID:       "chassis1",
+				L3GatewayConfig: `{"default":{"mode":"shared"}}`,
+			},
+		},
+		{
+			name: "stores allocated subnets and network ID",
+			update: func(s NetworkStateStore) error {
+				return s.UpdateNodeNetworkState(node, map[string][]*net.IPNet{
+					"red": ovntest.MustParseIPNets("10.130.0.0/24", "fd00::/64"),
+				}, "red", 2)
+			},
+			expectedState: nodenetworkstatev1.NodeNetworkStateSpec{
+				Networks: map[string]nodenetworkstatev1.NodeNetwork{
+					"red": {Subnets: []string{"10.130.0.0/24", "fd00::/64"}, NetworkID: intPtr(2)},
+				},
+			},
+		},
+		{
+			name: "removes the state of a network",
+			update: func(s NetworkStateStore) error {
+				if err := s.MigrateNodeNetworkState(node); err != nil {
+					return err
+				}
+				return s.UpdateNodeNetworkState(node, map[string][]*net.IPNet{"blue": nil}, "blue", util.InvalidNetworkID)
+			},
+			expectedState: nodenetworkstatev1.NodeNetworkStateSpec{
+				Networks: map[string]nodenetworkstatev1.NodeNetwork{
+					"default": {Subnets: []string{"10.128.0.0/24"}, NetworkID: intPtr(0)},
+				},
+				ChassisID:       "chassis1",
+				L3GatewayConfig: `{"default":{"mode":"shared"}}`,
+			},
+		},
+		{
+			name: "stores the gateway state",
+			update: func(s NetworkStateStore) error {
+				return s.UpdateNodeGatewayState(node)
+			},
+			expectedState: nodenetworkstatev1.NodeNetworkStateSpec{
+				ChassisID:       "chassis1",
+				L3GatewayConfig: `{"default":{"mode":"shared"}}`,
+			},
+		},
+		{
+			name: "reports the host subnet allocation of a node without state",
+			update: func(s NetworkStateStore) error {
+				return s.UpdateNodeNetworkCondition(node, "default", nil)
+			},
+			expectedConditions: map[string]string{"default": nodenetworkstatev1.NodeNetworkReasonAllocated},
+		},
+		{
+			name: "reports the failed host subnet allocations",
+			update: func(s NetworkStateStore) error {
+				if err := s.MigrateNodeNetworkState(node); err != nil {
+					return err
+				}
+				if err := s.UpdateNodeNetworkCondition(node, "default", nil); err != nil {
+					return err
+				}
+				if err := s.UpdateNodeNetworkCondition(node, "blue", nil); err != nil {
+					return err
+				}
+				return s.UpdateNodeNetworkCondition(node, "blue", ErrSubnetAllocatorFull)
+			},
+			expectedState: nodenetworkstatev1.NodeNetworkStateSpec{
+				Networks: map[string]nodenetworkstatev1.NodeNetwork{
+					"default": {Subnets: []string{"10.128.0.0/24"}, NetworkID: intPtr(0)},
+					"blue":    {Subnets: []string{"10.129.0.0/24"}, NetworkID: intPtr(1)},
+				},
+				ChassisID:       "chassis1",
+				L3GatewayConfig: `{"default":{"mode":"shared"}}`,
+			},
+			expectedConditions: map[string]string{
+				"default": nodenetworkstatev1.NodeNetworkReasonAllocated,
+				"blue":    nodenetworkstatev1.NodeNetworkReasonAllocationFailed,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := nodenetworkstatefake.NewSimpleClientset()
+			s := NewCRDNetworkStateStore(client)
+			if err := tt.update(s); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			state, err := client.K8sV1().NodeNetworkStates().Get(context.TODO(), node.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get node network state: %v", err)
+			}
+			if len(state.OwnerReferences) != 1 || state.OwnerReferences[0].UID != node.UID {
+				t.Errorf("expected node network state to be owned by node, got %v", state.OwnerReferences)
+			}
+			if !reflect.DeepEqual(state.Spec, tt.expectedState) {
+				t.Errorf("expected node network state %+v, got %+v", tt.expectedState, state.Spec)
+			}
+			conditions := map[string]string{}
+			for networkName, status := range state.Status.Networks {
+				for _, condition := range status.Conditions {
+					if condition.Type == nodenetworkstatev1.NodeNetworkHostSubnetsAllocated {
+						conditions[networkName] = condition.Reason
+					}
+				}
+			}
+			if len(conditions) > 0 || len(tt.expectedConditions) > 0 {
+				if !reflect.DeepEqual(conditions, tt.expectedConditions) {
+					t.Errorf("expected the host subnet conditions %v, got %v", tt.expectedConditions, conditions)
+				}
+			}
+		})
+	}
+}
+
+func TestCRDNetworkStateStoreNodeReAdded(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			UID:  "uid1",
+			Annotations: map[string]string{
+				"k8s.ovn.org/node-chassis-id":   "chassis1",
+				"k8s.ovn.org/l3-gateway-config": `{"default":{"mode":"shared"}}`,
+			},
+		},
+	}
+	client := nodenetworkstatefake.NewSimpleClientset()
+	s := NewCRDNetworkStateStore(client)
+	if err := s.UpdateNodeGatewayState(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.UpdateNodeNetworkCondition(node, "default", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the node is deleted along with its state, then re-added with the same
+	// name and network state
+	s.ForgetNode(node.Name)
+	if err := client.K8sV1().NodeNetworkStates().Delete(context.TODO(), node.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete node network state: %v", err)
+	}
+	node = node.DeepCopy()
+	node.UID = "uid2"
+	if err := s.UpdateNodeGatewayState(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.UpdateNodeNetworkCondition(node, "default", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state, err := client.K8sV1().NodeNetworkStates().Get(context.TODO(), node.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get node network state: %v", err)
+	}
+	if len(state.OwnerReferences) != 1 || state.OwnerReferences[0].UID != node.UID {
+		t.Errorf("expected node network state to be owned by the re-added node, got %v", state.OwnerReferences)
+	}
+	expectedState := nodenetworkstatev1.NodeNetworkStateSpec{
+		ChassisID:       "chassis1",
+		L3GatewayConfig: `{"default":{"mode":"shared"}}`,
+	}
+	if !reflect.DeepEqual(state.Spec, expectedState) {
+		t.Errorf("expected node network state %+v, got %+v", expectedState, state.Spec)
+	}
+	condition := meta.FindStatusCondition(state.Status.Networks["default"].Conditions, nodenetworkstatev1.NodeNetworkHostSubnetsAllocated)
+	if condition == nil || condition.Reason != nodenetworkstatev1.NodeNetworkReasonAllocated {
+		t.Errorf("expected the host subnets of the re-added node to be reported allocated, got %v", condition)
+	}
+}
+
+func TestCRDNetworkStateStoreStateOnlyHostSubnets(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	config.OVNKubernetesFeature.NodeNetworkStateBackend = config.NodeNetworkStateBackendCRD
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			UID:  "uid1",
+			Annotations: map[string]string{
+				"k8s.ovn.org/node-subnets": `{"blue":["10.129.0.0/24"]}`,
+				"k8s.ovn.org/network-ids":  `{"default":"0","blue":"1"}`,
+			},
+		},
+	}
+	client := nodenetworkstatefake.NewSimpleClientset()
+	s := NewCRDNetworkStateStore(client)
+	if _, err := s.GetNodeNetworkState(node.Name); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected no node network state, got %v", err)
+	}
+	if err := s.UpdateNodeNetworkState(node, map[string][]*net.IPNet{
+		"default": ovntest.MustParseIPNets("10.128.0.0/24"),
+	}, "default", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the default network host subnets are not annotated anymore and the
+	// migration keeps them
+	if err := s.MigrateNodeNetworkState(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedState := nodenetworkstatev1.NodeNetworkStateSpec{
+		Networks: map[string]nodenetworkstatev1.NodeNetwork{
+			"default": {Subnets: []string{"10.128.0.0/24"}, NetworkID: intPtr(0)},
+			"blue":    {Subnets: []string{"10.129.0.0/24"}, NetworkID: intPtr(1)},
+		},
+	}
+	state, err := client.K8sV1().NodeNetworkStates().Get(context.TODO(), node.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get node network state: %v", err)
+	}
+	if !reflect.DeepEqual(state.Spec, expectedState) {
+		t.Errorf("expected node network state %+v, got %+v", expectedState, state.Spec)
+	}
+
+	// the host subnets are read back from the store without getting the
+	// state again
+	client.ClearActions()
+	hostSubnets, err := util.ParseNodeHostSubnets(node, "default", s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hostSubnets) != 1 || hostSubnets[0].String() != "10.128.0.0/24" {
+		t.Errorf("expected the host subnets 10.128.0.0/24, got %v", hostSubnets)
+	}
+	if len(client.Actions()) > 0 {
+		t.Errorf("expected the node network state to be cached, got %v", client.Actions())
+	}
+}
@@ -2,11 +2,22 @@ package node
 
 import (
 	"fmt"
+	"hash/fnv"
 	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
@@ -14,12 +25,30 @@ import (
 	hotypes "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/types"
 	houtil "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/util"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cryptorand"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
 
+const (
+	// HostSubnetPrefixLengthKey is the node label, or annotation, overriding the
+	// prefix length of the IPv4 host subnet allocated to the node for the
+	// default network, e.g. "23" for a node running many pods
+	HostSubnetPrefixLengthKey = "k8s.ovn.org/host-subnet-prefix-length"
+	// HostSubnetIPv6PrefixLengthKey is the node label, or annotation, overriding
+	// the prefix length of the IPv6 host subnet allocated to the node for the
+	// default network
+	HostSubnetIPv6PrefixLengthKey = "k8s.ovn.org/host-subnet-ipv6-prefix-length"
+	// HostSubnetIndexKey is the node label, or annotation, pinning the host
+	// subnets allocated to the node for the default network with the
+	// deterministic host subnet allocation to the given index of the host
+	// subnets of the cluster subnets, e.g. "12", instead of the index given by
+	// the hash of the node name
+	HostSubnetIndexKey = "k8s.ovn.org/host-subnet-index"
+)
+
 // NodeAllocator acts on node events handed off by the cluster network
 // controller and does the following:
 //   - allocates subnet from the cluster subnet pool. It also allocates subnets
@@ -27,40 +56,230 @@ import (
 //     It stores these allocated subnets in the node annotation.
 //     Only for the default or layer3 networks.
 //   - stores the network id in each node's annotation.
+//   - mirrors the above in the node network state store, if any.
 type NodeAllocator struct {
 	kube       kube.Interface
 	nodeLister listers.NodeLister
 
-	clusterSubnetAllocator       SubnetAllocator
+	clusterSubnetAllocator SubnetAllocator
+
+	// hybridOverlayLock protects the hybrid overlay state, which can be
+	// changed at runtime
+	hybridOverlayLock            sync.RWMutex
+	hybridOverlayEnabled         bool
+	hybridOverlayClusterSubnets  []config.CIDRNetworkEntry
 	hybridOverlaySubnetAllocator SubnetAllocator
 
+	// healthCheckSubnetAllocator, if set, allocates the subnets of the
+	// dedicated health check ports of the nodes
+	healthCheckClusterSubnets  []config.CIDRNetworkEntry
+	healthCheckSubnetAllocator SubnetAllocator
+
 	// unique id of the network
 	networkID int
 
 	netInfo util.NetInfo
+
+	// stateStore, if set, persists the node network state in addition to the
+	// node annotations
+	stateStore NetworkStateStore
+
+	// warmSubnets, if set, wraps the cluster subnet allocator to keep host
+	// subnets reserved for the next nodes
+	warmSubnets *warmSubnetAllocator
+
+	// delegatedSubnets, if set, wraps the cluster subnet allocator to obtain
+	// the IPv6 host subnets from a subnet source
+	delegatedSubnets *delegatedSubnetAllocator
+
+	// deletedNodeSubnetGracePeriod, if set, is how long the host subnets of
+	// the deleted nodes are kept for them
+	deletedNodeSubnetGracePeriod time.Duration
+	deletedNodesLock             sync.Mutex
+	// deletedNodes holds the host subnets kept for the deleted nodes
+	deletedNodes map[string]*deletedNodeSubnets
+
+	// additionalClusterSubnets are the cluster subnets added to the default
+	// network at runtime
+	additionalClusterSubnetsLock sync.Mutex
+	additionalClusterSubnets     []config.CIDRNetworkEntry
+
+	// ipFamilyConversion, if set, rolls the conversion of the nodes to the
+	// enabled IP families in batches
+	ipFamilyConversion *ipFamilyConverter
+
+	// subnetCompaction, if set, moves the host subnets of the nodes
+	// requesting it to the lowest free host subnets in batches
+	subnetCompaction *subnetCompactor
+
+	// subnetMigration, if set, moves the nodes off the removed cluster
+	// subnets in batches
+	subnetMigration *subnetMigrator
+
+	// maxHostSubnetsPerNode, if greater than 1, is the maximum number of host
+	// subnets of each IP family of a node, the additional ones allocated when
+	// the previous ones are exhausted
+	maxHostSubnetsPerNode int
+
+	// annotationBatcher, if set, coalesces the updates of the node
+	// annotations with those of the other controllers
+	annotationBatcher *kube.NodeAnnotationBatcher
+
+	// recorder, if set, posts the host subnet allocation failures as events
+	recorder record.EventRecorder
+
+	// pendingNodes holds the time of the first event of the nodes waiting for
+	// their host subnets
+	pendingNodesLock sync.Mutex
+	pendingNodes     map[string]time.Time
 }
 
-func NewNodeAllocator(networkID int, netInfo util.NetInfo, nodeLister listers.NodeLister, kube kube.Interface) *NodeAllocator {
+func NewNodeAllocator(networkID int, netInfo util.NetInfo, nodeLister listers.NodeLister, kube kube.Interface, stateStore NetworkStateStore) *NodeAllocator {
 	na := &NodeAllocator{
 		kube:                         kube,
+		stateStore:                   stateStore,
 		nodeLister:                   nodeLister,
 		networkID:                    networkID,
 		netInfo:                      netInfo,
 		clusterSubnetAllocator:       NewSubnetAllocator(),
 		hybridOverlaySubnetAllocator: NewSubnetAllocator(),
+		pendingNodes:                 map[string]time.Time{},
 	}
 
 	if na.hasNodeSubnetAllocation() {
 		na.clusterSubnetAllocator = NewSubnetAllocator()
 	}
 
-	if na.hasHybridOverlayAllocation() {
-		na.hybridOverlaySubnetAllocator = NewSubnetAllocator()
+	if config.HybridOverlay.Enabled && !na.netInfo.IsSecondary() {
+		na.hybridOverlayEnabled = true
+		na.hybridOverlayClusterSubnets = config.HybridOverlay.ClusterSubnets
 	}
 
 	return na
 }
 
+// EnableWarmSubnets keeps size host subnets of each IP family reserved for the
+// next nodes, persisted in the given store, so that the nodes added during a
+// scale out get their host subnets without waiting on their allocation and so
+// that these are known in advance. Only for the default network, must be
+// called before Init.
+func (na *NodeAllocator) EnableWarmSubnets(size int, store WarmSubnetStore) {
+	if size <= 0 || na.netInfo.IsSecondary() {
+		return
+	}
+	na.warmSubnets = newWarmSubnetAllocator(na.clusterSubnetAllocator, size, store)
+	na.clusterSubnetAllocator = na.warmSubnets
+}
+
+// EnableDelegatedSubnets obtains the IPv6 host subnets of the nodes from the
+// given source, like DHCPv6 prefix delegation, instead of carving them from
+// the cluster subnets, which the obtained subnets must belong to. The subnets
+// are of the given prefix length, or of the length chosen by the source if 0,
+// unless overridden for the node. Only for the default network, must be
+// called before Init.
+func (na *NodeAllocator) EnableDelegatedSubnets(source SubnetSource, prefixLen int) {
+	if na.netInfo.IsSecondary() {
+		return
+	}
+	na.delegatedSubnets = newDelegatedSubnetAllocator(na.clusterSubnetAllocator, source, prefixLen)
+	na.clusterSubnetAllocator = na.delegatedSubnets
+}
+
+// EnableIPFamilyConversion converts the nodes whose host subnets don't match
+// the IP families of the cluster subnets, after a conversion from dual-stack
+// to single-stack or back, batchSize nodes at a time with at least the given
+// interval between two batches instead of all at once, reporting the progress
+// in the given store. Only for the default network, must be called before
+// Sync.
+func (na *NodeAllocator) EnableIPFamilyConversion(batchSize int, interval time.Duration, store IPFamilyConversionStore) {
+	if batchSize <= 0 || na.netInfo.IsSecondary() {
+		return
+	}
+	ipv4Mode, ipv6Mode := na.netInfo.IPMode()
+	na.ipFamilyConversion = newIPFamilyConverter(batchSize, interval, store, ipv4Mode, ipv6Mode)
+}
+
+// RunIPFamilyConversion starts the batches of the IP family conversion until
+// all the nodes found to convert on Sync are converted or stopCh is closed,
+// calling retryNodes for the nodes of each batch to be handled again. No-op
+// unless the conversion is enabled and there are nodes to convert.
+func (na *NodeAllocator) RunIPFamilyConversion(stopCh <-chan struct{}, wg *sync.WaitGroup, retryNodes func(nodeNames []string)) {
+	if na.ipFamilyConversion == nil || na.ipFamilyConversion.total == 0 {
+		return
+	}
+	na.ipFamilyConversion.run(stopCh, wg, retryNodes)
+}
+
+// RunWarmSubnetReplenishment replenishes and persists the host subnets
+// reserved for the next nodes in the background until stopCh is closed, so
+// that a new node is handed over a reserved host subnet without waiting on
+// the replenishment of the reserve nor on its persistence. The reserve is
+// only persisted once started, so it should be started before the nodes are
+// synced. No-op unless the warm subnets are enabled.
+func (na *NodeAllocator) RunWarmSubnetReplenishment(stopCh <-chan struct{}, wg *sync.WaitGroup) {
+	if na.warmSubnets == nil {
+		return
+	}
+	na.warmSubnets.run(stopCh, wg)
+}
+
+// RunDelegatedSubnetRenewal renews the leases of the delegated host subnets
+// until stopCh is closed, updating the node subnet annotation of the nodes
+// whose host subnet changed. No-op unless the delegated subnets are enabled.
+func (na *NodeAllocator) RunDelegatedSubnetRenewal(stopCh <-chan struct{}, wg *sync.WaitGroup) {
+	if na.delegatedSubnets == nil {
+		return
+	}
+	na.delegatedSubnets.run(stopCh, wg, func(nodeName string, oldSubnet, newSubnet *net.IPNet) {
+		if err := na.handleDelegatedSubnetChange(nodeName, oldSubnet, newSubnet); err != nil {
+			klog.Errorf("Failed to update the delegated host subnet %s of node %s: %v", oldSubnet, nodeName, err)
+		}
+	})
+}
+
+// handleDelegatedSubnetChange replaces the old host subnet of the node with
+// the new one in its node subnet annotation, or allocates a new host subnet
+// to the node if it lost the old one
+func (na *NodeAllocator) handleDelegatedSubnetChange(nodeName string, oldSubnet, newSubnet *net.IPNet) error {
+	networkName := na.netInfo.GetNetworkName()
+	node, err := na.nodeLister.Get(nodeName)
+	if err != nil {
+		return err
+	}
+	existingSubnets, err := na.getNodeHostSubnets(node, networkName)
+	if err != nil {
+		return err
+	}
+	hostSubnets := make([]*net.IPNet, 0, len(existingSubnets))
+	for _, hostSubnet := range existingSubnets {
+		if hostSubnet.String() == oldSubnet.String() {
+			continue
+		}
+		hostSubnets = append(hostSubnets, hostSubnet)
+	}
+	if newSubnet != nil {
+		klog.Infof("Delegated host subnet of node %s changed from %s to %s, its pods need to be recreated",
+			nodeName, oldSubnet, newSubnet)
+		hostSubnets = append(hostSubnets, newSubnet)
+		return na.updateNodeNetworkAnnotationsWithRetry(nodeName, map[string][]*net.IPNet{networkName: hostSubnets}, na.networkID)
+	}
+	klog.Warningf("Node %s lost its delegated host subnet %s, its pods need to be recreated", nodeName, oldSubnet)
+	// sync the node as if it had never had the lost host subnet for it to get
+	// a new one
+	if na.useNetworkState(networkName) {
+		if err := na.stateStore.UpdateNodeNetworkState(node, map[string][]*net.IPNet{networkName: hostSubnets}, networkName, na.networkID); err != nil {
+			return err
+		}
+		return na.syncNodeNetworkAnnotations(node)
+	}
+	cnode := node.DeepCopy()
+	cnode.Annotations, err = util.UpdateNodeHostSubnetAnnotation(cnode.Annotations, hostSubnets, networkName)
+	if err != nil {
+		return err
+	}
+	return na.syncNodeNetworkAnnotations(cnode)
+}
+
 func (na *NodeAllocator) Init() error {
 	if !na.hasNodeSubnetAllocation() {
 		return nil
@@ -74,13 +293,19 @@ func (na *NodeAllocator) Init() error {
 		}
 		klog.V(5).Infof("Added network range %s to cluster subnet allocator", clusterSubnet.CIDR)
 	}
+	if excluded := na.excludedSubnets(); len(excluded) > 0 {
+		na.clusterSubnetAllocator.ExcludeNetworks(excluded...)
+		klog.Infof("Excluded subnets %v from the cluster subnet allocator", excluded)
+	}
+	if err := na.initHealthCheckSubnets(); err != nil {
+		return err
+	}
 
+	na.hybridOverlayLock.Lock()
+	defer na.hybridOverlayLock.Unlock()
 	if na.hasHybridOverlayAllocation() {
-		for _, hoSubnet := range config.HybridOverlay.ClusterSubnets {
-			if err := na.hybridOverlaySubnetAllocator.AddNetworkRange(hoSubnet.CIDR, hoSubnet.HostSubnetLength); err != nil {
-				return err
-			}
-			klog.V(5).Infof("Added network range %s to hybrid overlay subnet allocator", hoSubnet.CIDR)
+		if err := addHybridOverlayNetworkRanges(na.hybridOverlaySubnetAllocator, na.hybridOverlayClusterSubnets); err != nil {
+			return err
 		}
 	}
 
@@ -90,24 +315,235 @@ func (na *NodeAllocator) Init() error {
 	return nil
 }
 
+// AddClusterSubnets makes the given cluster subnets, added to the default
+// network at runtime, available for allocation so that the nodes get host
+// subnets from them without a restart. The cluster subnets already available
+// are skipped. It returns the cluster subnets that were added.
+func (na *NodeAllocator) AddClusterSubnets(clusterSubnets []config.CIDRNetworkEntry) ([]config.CIDRNetworkEntry, error) {
+	if !na.hasNodeSubnetAllocation() || na.netInfo.IsSecondary() {
+		return nil, fmt.Errorf("cluster subnets can only be added to the default network")
+	}
+	na.additionalClusterSubnetsLock.Lock()
+	defer na.additionalClusterSubnetsLock.Unlock()
+	// update metrics for the added cluster subnets
+	defer na.recordSubnetUsage()
+	defer na.recordSubnetCount()
+
+	existing := sets.New[string]()
+	for _, clusterSubnet := range na.netInfo.Subnets() {
+		existing.Insert(clusterSubnet.CIDR.String())
+	}
+	for _, clusterSubnet := range na.additionalClusterSubnets {
+		existing.Insert(clusterSubnet.CIDR.String())
+	}
+	var added []config.CIDRNetworkEntry
+	for _, clusterSubnet := range clusterSubnets {
+		if existing.Has(clusterSubnet.CIDR.String()) {
+			continue
+		}
+		if err := na.clusterSubnetAllocator.AddNetworkRange(clusterSubnet.CIDR, clusterSubnet.HostSubnetLength); err != nil {
+			return added, err
+		}
+		klog.Infof("Added network range %s to cluster subnet allocator", clusterSubnet.CIDR)
+		existing.Insert(clusterSubnet.CIDR.String())
+		na.additionalClusterSubnets = append(na.additionalClusterSubnets, clusterSubnet)
+		added = append(added, clusterSubnet)
+	}
+	return added, nil
+}
+
+// RemoveClusterSubnets stops allocating host subnets from the given cluster
+// subnets, added to the default network at runtime, and marks the given nodes
+// holding host subnets from them for the migration to the remaining cluster
+// subnets. The host subnets stay allocated to these nodes until their batch of
+// the migration. The cluster subnets not added at runtime are skipped. It
+// returns the cluster subnets that were removed.
+func (na *NodeAllocator) RemoveClusterSubnets(clusterSubnets []config.CIDRNetworkEntry, nodes []*corev1.Node) ([]config.CIDRNetworkEntry, error) {
+	if na.subnetMigration == nil {
+		return nil, fmt.Errorf("cluster subnets can only be removed with the migration of removed cluster subnets")
+	}
+	// update metrics for the removed cluster subnets
+	defer na.recordSubnetUsage()
+	defer na.recordSubnetCount()
+
+	var removed []config.CIDRNetworkEntry
+	var errs []error
+	na.additionalClusterSubnetsLock.Lock()
+	for _, clusterSubnet := range clusterSubnets {
+		for i, additional := range na.additionalClusterSubnets {
+			if additional.CIDR.String() != clusterSubnet.CIDR.String() {
+				continue
+			}
+			if err := na.clusterSubnetAllocator.DrainNetworkRange(additional.CIDR); err != nil {
+				errs = append(errs, err)
+				break
+			}
+			klog.Infof("Removed network range %s from cluster subnet allocator", additional.CIDR)
+			na.additionalClusterSubnets = append(na.additionalClusterSubnets[:i], na.additionalClusterSubnets[i+1:]...)
+			removed = append(removed, additional)
+			break
+		}
+	}
+	na.additionalClusterSubnetsLock.Unlock()
+	if len(removed) == 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	networkName := na.netInfo.GetNetworkName()
+	for _, node := range nodes {
+		if util.NoHostSubnet(node) {
+			continue
+		}
+		hostSubnets, err := na.getNodeHostSubnets(node, networkName)
+		if err != nil {
+			continue
+		}
+		if len(na.removedHostSubnets(hostSubnets)) > 0 {
+			na.subnetMigration.addPending(node.Name)
+		}
+	}
+	return removed, utilerrors.NewAggregate(errs)
+}
+
+// AdditionalClusterSubnets returns the cluster subnets added to the default
+// network at runtime
+func (na *NodeAllocator) AdditionalClusterSubnets() []config.CIDRNetworkEntry {
+	na.additionalClusterSubnetsLock.Lock()
+	defer na.additionalClusterSubnetsLock.Unlock()
+	return append([]config.CIDRNetworkEntry{}, na.additionalClusterSubnets...)
+}
+
+// clusterSubnets returns the configured cluster subnets and those added at
+// runtime
+func (na *NodeAllocator) clusterSubnets() []config.CIDRNetworkEntry {
+	na.additionalClusterSubnetsLock.Lock()
+	defer na.additionalClusterSubnetsLock.Unlock()
+	return append(append([]config.CIDRNetworkEntry{}, na.netInfo.Subnets()...), na.additionalClusterSubnets...)
+}
+
+// excludedSubnets returns the subnets never allocated to the nodes: those of
+// the default network, or, for the IP chunks of a secondary network, its
+// excluded subnets spanning whole IP chunks, the IPs of the others being
+// excluded from the IP chunks by the PodAllocator
+func (na *NodeAllocator) excludedSubnets() []*net.IPNet {
+	if !na.netInfo.IsSecondary() {
+		return config.ClusterManager.ExcludeSubnets
+	}
+	if na.netInfo.NodeIPChunkSize() <= 0 {
+		return nil
+	}
+	var excluded []*net.IPNet
+	for _, excludeSubnet := range na.netInfo.ExcludeSubnets() {
+		prefixLen, _ := excludeSubnet.Mask.Size()
+		for _, clusterSubnet := range na.netInfo.Subnets() {
+			if util.ContainsCIDR(clusterSubnet.CIDR, excludeSubnet) && prefixLen <= clusterSubnet.HostSubnetLength {
+				excluded = append(excluded, excludeSubnet)
+				break
+			}
+		}
+	}
+	return excluded
+}
+
+// withoutExcludedSubnets returns the host subnets of the node that don't
+// overlap any excluded subnet. The node gets new host subnets in place of the
+// others.
+func (na *NodeAllocator) withoutExcludedSubnets(nodeName string, hostSubnets []*net.IPNet) []*net.IPNet {
+	excludedSubnets := na.excludedSubnets()
+	if len(excludedSubnets) == 0 {
+		return hostSubnets
+	}
+	valid := make([]*net.IPNet, 0, len(hostSubnets))
+	for _, hostSubnet := range hostSubnets {
+		if excluded := overlappingSubnet(hostSubnet, excludedSubnets); excluded != nil {
+			klog.Warningf("Evicting node %s from host subnet %s overlapping the excluded subnet %s, "+
+				"its pods need to be recreated", nodeName, hostSubnet, excluded)
+			continue
+		}
+		valid = append(valid, hostSubnet)
+	}
+	return valid
+}
+
+func overlappingSubnet(subnet *net.IPNet, subnets []*net.IPNet) *net.IPNet {
+	for _, candidate := range subnets {
+		if candidate.Contains(subnet.IP) || subnet.Contains(candidate.IP) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// hasHybridOverlayAllocation must be called with hybridOverlayLock held
 func (na *NodeAllocator) hasHybridOverlayAllocation() bool {
-	return config.HybridOverlay.Enabled && !na.netInfo.IsSecondary()
+	return na.hybridOverlayEnabled
 }
 
 func (na *NodeAllocator) recordSubnetCount() {
-	// only for the default network
-	if !na.netInfo.IsSecondary() {
-		v4count, v6count := na.clusterSubnetAllocator.Count()
-		metrics.RecordSubnetCount(float64(v4count), float64(v6count))
+	if !na.hasNodeSubnetAllocation() {
+		return
+	}
+	v4count, v6count := na.clusterSubnetAllocator.Count()
+	if na.netInfo.IsSecondary() {
+		metrics.RecordNetworkSubnetCount(na.netInfo.GetNetworkName(), float64(v4count), float64(v6count))
+		return
 	}
+	metrics.RecordSubnetCount(float64(v4count), float64(v6count))
 }
 
 func (na *NodeAllocator) recordSubnetUsage() {
-	// only for the default network
-	if !na.netInfo.IsSecondary() {
-		v4used, v6used := na.clusterSubnetAllocator.Usage()
-		metrics.RecordSubnetUsage(float64(v4used), float64(v6used))
+	if !na.hasNodeSubnetAllocation() {
+		return
+	}
+	na.recordNodeCapacity()
+	v4used, v6used := na.clusterSubnetAllocator.Usage()
+	if na.netInfo.IsSecondary() {
+		metrics.RecordNetworkSubnetUsage(na.netInfo.GetNetworkName(), float64(v4used), float64(v6used))
+		return
+	}
+	metrics.RecordSubnetUsage(float64(v4used), float64(v6used))
+}
+
+// GetSubnetUsage returns the number of allocated and the total number of host
+// subnets of the network for each IP family, in this order: v4 allocated, v4
+// total, v6 allocated, v6 total.
+func (na *NodeAllocator) GetSubnetUsage() (uint64, uint64, uint64, uint64) {
+	if !na.hasNodeSubnetAllocation() {
+		return 0, 0, 0, 0
+	}
+	v4used, v6used := na.clusterSubnetAllocator.Usage()
+	v4count, v6count := na.clusterSubnetAllocator.Count()
+	return v4used, v4count, v6used, v6count
+}
+
+// GetClusterSubnetUsage returns the usage of each cluster subnet of the
+// network
+func (na *NodeAllocator) GetClusterSubnetUsage() []SubnetRangeUsage {
+	if !na.hasNodeSubnetAllocation() {
+		return nil
 	}
+	return na.clusterSubnetAllocator.RangeUsage()
+}
+
+// AllocatedSubnets returns the host subnets, including the hybrid overlay
+// ones, allocated overlapping the given network, with the node they are
+// allocated to
+func (na *NodeAllocator) AllocatedSubnets(network *net.IPNet) []AllocatedNetwork {
+	allocated := na.clusterSubnetAllocator.AllocatedNetworks(network)
+	if na.hasHybridOverlayAllocation() {
+		allocated = append(allocated, na.hybridOverlaySubnetAllocator.AllocatedNetworks(network)...)
+	}
+	return allocated
+}
+
+// SimulateNodeAllocations returns how many of the given number of new nodes
+// could be allocated a host subnet of the IP family and prefix length, 0 for
+// the host subnet length of the cluster subnets. Nothing is allocated.
+func (na *NodeAllocator) SimulateNodeAllocations(ipv6 bool, prefixLen int, count uint64) (uint64, error) {
+	if !na.hasNodeSubnetAllocation() {
+		return 0, fmt.Errorf("network %s does not allocate host subnets", na.netInfo.GetNetworkName())
+	}
+	return na.clusterSubnetAllocator.SimulateAllocations(ipv6, prefixLen, count)
 }
 
 // hybridOverlayNodeEnsureSubnet allocates a subnet and sets the
@@ -126,7 +562,7 @@ func (na *NodeAllocator) hybridOverlayNodeEnsureSubnet(node *corev1.Node, annota
 
 	// Allocate a new host subnet for this node
 	// FIXME: hybrid overlay is only IPv4 for now due to limitations on the Windows side
-	hostSubnets, allocatedSubnets, err := na.allocateNodeSubnets(na.hybridOverlaySubnetAllocator, node.Name, existingSubnets, true, false)
+	hostSubnets, allocatedSubnets, err := na.allocateNodeSubnets(na.hybridOverlaySubnetAllocator, node.Name, existingSubnets, true, false, 0, 0, 1)
 	if err != nil {
 		return nil, fmt.Errorf("error allocating hybrid overlay HostSubnet for node %s: %v", node.Name, err)
 	}
@@ -148,27 +584,35 @@ func (na *NodeAllocator) releaseHybridOverlayNodeSubnet(nodeName string) {
 
 // HandleAddUpdateNodeEvent handles the add or update node event
 func (na *NodeAllocator) HandleAddUpdateNodeEvent(node *corev1.Node) error {
+	defer na.recordSubnetUsage()
 	defer na.recordSubnetCount()
 
 	if util.NoHostSubnet(node) {
-		if na.hasHybridOverlayAllocation() && houtil.IsHybridOverlayNode(node) {
-			annotator := kube.NewNodeAnnotator(na.kube, node.Name)
-			allocatedSubnet, err := na.hybridOverlayNodeEnsureSubnet(node, annotator)
-			if err != nil {
-				return fmt.Errorf("failed to update node %s hybrid overlay subnet annotation: %v", node.Name, err)
-			}
-			if err := annotator.Run(); err != nil {
-				// Release allocated subnet if any errors occurred
-				if allocatedSubnet != nil {
-					na.releaseHybridOverlayNodeSubnet(node.Name)
-				}
-				return fmt.Errorf("failed to set hybrid overlay annotations for node %s: %v", node.Name, err)
-			}
+		if err := na.releaseNoHostSubnetNode(node); err != nil {
+			return err
+		}
+		if !na.netInfo.IsSecondary() && houtil.IsHybridOverlayNode(node) {
+			return na.syncHybridOverlayNode(node)
 		}
 		return nil
 	}
 
-	return na.syncNodeNetworkAnnotations(node)
+	if na.stateStore != nil && !na.netInfo.IsSecondary() {
+		if err := na.stateStore.UpdateNodeGatewayState(node); err != nil {
+			return err
+		}
+	}
+
+	err := na.syncNodeNetworkAnnotations(node)
+	if err == nil {
+		err = na.syncHealthCheckSubnets(node)
+	}
+	if na.stateStore != nil && na.hasNodeSubnetAllocation() {
+		if condErr := na.stateStore.UpdateNodeNetworkCondition(node, na.netInfo.GetNetworkName(), err); condErr != nil {
+			klog.Warningf("Failed to report the host subnet allocation of node %s: %v", node.Name, condErr)
+		}
+	}
+	return err
 }
 
 // syncNodeNetworkAnnotations does 2 things
@@ -184,30 +628,99 @@ func (na *NodeAllocator) syncNodeNetworkAnnotations(node *corev1.Node) error {
 	}
 
 	updatedSubnetsMap := map[string][]*net.IPNet{}
-	var validExistingSubnets, allocatedSubnets []*net.IPNet
+	var validExistingSubnets, allocatedSubnets, replacedSubnets, migratedSubnets []*net.IPNet
+	compacting, rotating := false, false
+	var rotationErr error
 	if na.hasNodeSubnetAllocation() {
-		existingSubnets, err := util.ParseNodeHostSubnetAnnotation(node, networkName)
+		// the node keeps the host subnets of the previous IP families until
+		// its batch of the conversion starts
+		if na.ipFamilyConversion != nil && na.ipFamilyConversion.isDeferred(node.Name) {
+			klog.V(5).Infof("Deferring the IP family conversion of node %s", node.Name)
+			return nil
+		}
+		// the node keeps the host subnets from the removed cluster subnets
+		// until its batch of the migration starts
+		if na.subnetMigration != nil && na.subnetMigration.isDeferred(node.Name) {
+			klog.V(5).Infof("Deferring the migration of node %s off the removed cluster subnets", node.Name)
+			return nil
+		}
+		existingSubnets, err := na.getNodeHostSubnets(node, networkName)
 		if err != nil && !util.IsAnnotationNotSetError(err) {
 			// Log the error and try to allocate new subnets
 			klog.Warningf("Failed to get node %s host subnets annotations for network %s : %v", node.Name, networkName, err)
+			na.recordInvalidHostSubnets(node, err)
 		}
+		if len(existingSubnets) == 0 {
+			na.startSubnetAllocation(node.Name)
+		}
+		// a node deleted and recreated within the grace period gets its
+		// previous host subnets back
+		reclaimedSubnets := na.reclaimDeletedNodeSubnets(node.Name)
+		if len(existingSubnets) == 0 {
+			existingSubnets = reclaimedSubnets
+		} else if len(reclaimedSubnets) > 0 {
+			na.releaseUnusedSubnets(node.Name, reclaimedSubnets, existingSubnets)
+			reclaimedSubnets = nil
+		}
+		existingSubnets, migratedSubnets = na.migratedHostSubnets(node.Name, existingSubnets)
 
 		// On return validExistingSubnets will contain any valid subnets that
 		// were already assigned to the node. allocatedSubnets will contain
 		// any newly allocated subnets required to ensure that the node has one subnet
 		// from each enabled IP family.
 		ipv4Mode, ipv6Mode := na.netInfo.IPMode()
-		validExistingSubnets, allocatedSubnets, err = na.allocateNodeSubnets(na.clusterSubnetAllocator, node.Name, existingSubnets, ipv4Mode, ipv6Mode)
+		ipv4PrefixLen, ipv6PrefixLen, err := na.getHostSubnetPrefixLengths(node)
+		if err != nil {
+			return err
+		}
+		allocator := na.nodeSubnetAllocator(node)
+		if index, ok, err := na.getHostSubnetIndex(node); err != nil {
+			return err
+		} else if ok {
+			allocator = &indexedSubnetAllocator{SubnetAllocator: allocator, index: index}
+		}
+		validExistingSubnets, allocatedSubnets, err = na.allocateNodeSubnets(allocator, node.Name,
+			na.withoutExcludedSubnets(node.Name, existingSubnets), ipv4Mode, ipv6Mode, ipv4PrefixLen, ipv6PrefixLen,
+			na.hostSubnetsPerFamily())
 		if err != nil {
+			na.recordHostSubnetAllocationFailure(node, err)
 			return err
 		}
+		additionalSubnets := na.allocateAdditionalHostSubnets(node, validExistingSubnets, ipv4PrefixLen, ipv6PrefixLen)
+		validExistingSubnets = append(validExistingSubnets, additionalSubnets...)
+		allocatedSubnets = append(allocatedSubnets, additionalSubnets...)
+
+		// the host subnets just allocated are already the lowest free ones
+		compacting = na.isSubnetCompactionAdmitted(node)
+		if compacting && len(allocatedSubnets) == 0 {
+			validExistingSubnets, allocatedSubnets, replacedSubnets = na.compactHostSubnets(node.Name, validExistingSubnets)
+		}
+
+		rotating = !compacting && na.isHostSubnetRotationRequested(node)
+		if rotating {
+			// a node whose host subnets can't be rotated keeps them and is
+			// retried
+			validExistingSubnets, allocatedSubnets, replacedSubnets, rotationErr = na.rotateHostSubnets(allocator,
+				node.Name, validExistingSubnets, allocatedSubnets)
+			if rotationErr != nil {
+				na.recordHostSubnetAllocationFailure(node, rotationErr)
+			}
+		}
 
 		// If the existing subnets weren't OK, or new ones were allocated, update the node annotation.
 		// This happens in a couple cases:
 		// 1) new node: no existing subnets and one or more new subnets were allocated
 		// 2) dual-stack to single-stack conversion: two existing subnets but only one will be valid, and no allocated subnets
 		// 3) bad subnet annotation: one more existing subnets will be invalid and might have allocated a correct one
-		if len(existingSubnets) != len(validExistingSubnets) || len(allocatedSubnets) > 0 {
+		// 4) excluded subnet: the node is evicted from a host subnet overlapping an excluded subnet and gets a new one
+		// 5) recreated node: the node gets back the host subnets it had before being deleted
+		// 6) exhausted host subnets: the node gets an additional host subnet
+		// 7) compacted node: the node gets lower host subnets
+		// 8) rotated node: the node gets other host subnets
+		// 9) migrated node: the node gets host subnets from the remaining cluster subnets
+		// 10) node network state: the node still has the host subnets annotated
+		if len(existingSubnets) != len(validExistingSubnets) || len(allocatedSubnets) > 0 || len(reclaimedSubnets) > 0 ||
+			len(migratedSubnets) > 0 || na.hasAnnotatedStateHostSubnets(node, networkName) {
 			updatedSubnetsMap[networkName] = validExistingSubnets
 		}
 	}
@@ -221,6 +734,29 @@ func (na *NodeAllocator) syncNodeNetworkAnnotations(node *corev1.Node) error {
 			}
 			return err
 		}
+		if len(updatedSubnetsMap) > 0 {
+			na.finishSubnetAllocation(node.Name)
+		}
+	}
+	na.finishSubnetMigration(node.Name, migratedSubnets)
+
+	if compacting {
+		if err := na.finishSubnetCompaction(node.Name, replacedSubnets); err != nil {
+			return fmt.Errorf("failed to remove the host subnet compaction request of node %s: %w", node.Name, err)
+		}
+	}
+
+	if rotationErr != nil {
+		return rotationErr
+	}
+	if rotating {
+		if err := na.finishHostSubnetRotation(node.Name, replacedSubnets); err != nil {
+			return fmt.Errorf("failed to remove the host subnet rotation request of node %s: %w", node.Name, err)
+		}
+	}
+
+	if na.ipFamilyConversion != nil {
+		na.ipFamilyConversion.markConverted(node.Name)
 	}
 
 	return nil
@@ -228,19 +764,90 @@ func (na *NodeAllocator) syncNodeNetworkAnnotations(node *corev1.Node) error {
 
 // HandleDeleteNode handles the delete node event
 func (na *NodeAllocator) HandleDeleteNode(node *corev1.Node) error {
-	if na.hasHybridOverlayAllocation() {
+	if na.ipFamilyConversion != nil {
+		na.ipFamilyConversion.forget(node.Name)
+	}
+	if na.subnetCompaction != nil {
+		na.subnetCompaction.forget(node.Name)
+	}
+	if na.subnetMigration != nil {
+		na.subnetMigration.forget(node.Name)
+	}
+
+	na.releaseHealthCheckSubnets(node.Name)
+	na.forgetSubnetAllocation(node.Name)
+	// the host subnets of the node are read from its state before it is
+	// forgotten
+	if na.stateStore != nil {
+		defer na.stateStore.ForgetNode(node.Name)
+	}
+
+	na.hybridOverlayLock.RLock()
+	hasHybridOverlayAllocation := na.hasHybridOverlayAllocation()
+	if hasHybridOverlayAllocation {
 		na.releaseHybridOverlayNodeSubnet(node.Name)
+	}
+	na.hybridOverlayLock.RUnlock()
+	if hasHybridOverlayAllocation {
 		return nil
 	}
 
 	if na.hasNodeSubnetAllocation() {
-		na.clusterSubnetAllocator.ReleaseAllNetworks(node.Name)
+		if !na.deferSubnetRelease(node) {
+			na.clusterSubnetAllocator.ReleaseAllNetworks(node.Name)
+		}
 		na.recordSubnetCount()
+		na.recordSubnetUsage()
 	}
 
 	return nil
 }
 
+// releaseNoHostSubnetNode releases the host subnets of a node labeled as not
+// managed by OVN after it was allocated some, and removes them from its
+// annotations, so that the node is handled like a node that never had any
+func (na *NodeAllocator) releaseNoHostSubnetNode(node *corev1.Node) error {
+	if !na.hasNodeSubnetAllocation() {
+		return nil
+	}
+	networkName := na.netInfo.GetNetworkName()
+	if _, err := na.getNodeHostSubnets(node, networkName); util.IsAnnotationNotSetError(err) {
+		return nil
+	}
+	klog.Infof("Node %s is no longer managed by OVN, releasing its host subnets for network %s", node.Name, networkName)
+
+	if na.ipFamilyConversion != nil {
+		na.ipFamilyConversion.forget(node.Name)
+	}
+	if na.subnetCompaction != nil {
+		na.subnetCompaction.forget(node.Name)
+	}
+	if na.subnetMigration != nil {
+		na.subnetMigration.forget(node.Name)
+	}
+	na.forgetSubnetAllocation(node.Name)
+
+	if !na.netInfo.IsSecondary() {
+		if _, err := util.ParseNodeHealthCheckSubnets(node); !util.IsAnnotationNotSetError(err) {
+			annotator := kube.NewNodeAnnotator(na.kube, node.Name)
+			util.DeleteNodeHealthCheckSubnets(annotator)
+			if err := annotator.Run(); err != nil {
+				return fmt.Errorf("failed to remove the health check subnets annotation of node %s: %w", node.Name, err)
+			}
+		}
+	}
+	na.releaseHealthCheckSubnets(node.Name)
+
+	// passing util.InvalidNetworkID deletes the network id annotation for the network.
+	hostSubnetsMap := map[string][]*net.IPNet{networkName: nil}
+	if err := na.updateNodeNetworkAnnotationsWithRetry(node.Name, hostSubnetsMap, util.InvalidNetworkID); err != nil {
+		return fmt.Errorf("failed to clear node %q subnet annotation for network %s: %w", node.Name, networkName, err)
+	}
+	na.clusterSubnetAllocator.ReleaseAllNetworks(node.Name)
+	na.recordSubnetCount()
+	return nil
+}
+
 func (na *NodeAllocator) Sync(nodes []interface{}) error {
 	if !na.hasNodeSubnetAllocation() {
 		return nil
@@ -248,33 +855,63 @@ func (na *NodeAllocator) Sync(nodes []interface{}) error {
 
 	defer na.recordSubnetUsage()
 
+	na.hybridOverlayLock.RLock()
+	defer na.hybridOverlayLock.RUnlock()
+
 	networkName := na.netInfo.GetNetworkName()
+	ipv4Mode, ipv6Mode := na.netInfo.IPMode()
 
+	sortedNodes := make([]*corev1.Node, 0, len(nodes))
 	for _, tmp := range nodes {
 		node, ok := tmp.(*corev1.Node)
 		if !ok {
 			return fmt.Errorf("spurious object in syncNodes: %v", tmp)
 		}
+		sortedNodes = append(sortedNodes, node)
+	}
+	// a host subnet annotated on several nodes stays with the oldest one, the
+	// others get another host subnet when handled
+	sort.SliceStable(sortedNodes, func(i, j int) bool {
+		if !sortedNodes[i].CreationTimestamp.Equal(&sortedNodes[j].CreationTimestamp) {
+			return sortedNodes[i].CreationTimestamp.Before(&sortedNodes[j].CreationTimestamp)
+		}
+		return sortedNodes[i].Name < sortedNodes[j].Name
+	})
 
+	for _, node := range sortedNodes {
 		if util.NoHostSubnet(node) {
 			if na.hasHybridOverlayAllocation() && houtil.IsHybridOverlayNode(node) {
 				// this is a hybrid overlay node so mark as allocated from the hybrid overlay subnet allocator
-				hostSubnet, err := houtil.ParseHybridOverlayHostSubnet(node)
-				if err != nil {
-					klog.Errorf("Failed to parse hybrid overlay for node %s: %w", node.Name, err)
-				} else if hostSubnet != nil {
-					klog.V(5).Infof("Node %s contains subnets: %v", node.Name, hostSubnet)
-					if err := na.hybridOverlaySubnetAllocator.ReleaseNetworks(node.Name, hostSubnet); err != nil {
-						klog.Errorf("Failed to mark the subnet %v as allocated in the hybrid subnet allocator for node %s: %v", hostSubnet, node.Name, err)
-					}
-				}
+				na.markHybridOverlayNodeSubnet(node)
 			}
 		} else {
-			hostSubnets, _ := util.ParseNodeHostSubnetAnnotation(node, networkName)
+			na.markHealthCheckSubnets(node)
+			hostSubnets, _ := na.getNodeHostSubnets(node, networkName)
+			if na.ipFamilyConversion != nil && needsIPFamilyConversion(hostSubnets, ipv4Mode, ipv6Mode) {
+				// the host subnets of the enabled IP families stay with the
+				// node while it waits for its batch
+				na.ipFamilyConversion.addPending(node.Name)
+				hostSubnets = hostSubnetsOfIPFamilies(hostSubnets, ipv4Mode, ipv6Mode)
+			}
+			if na.subnetMigration != nil && len(na.removedHostSubnets(hostSubnets)) > 0 {
+				// the host subnets from the removed cluster subnets stay
+				// with the node while it waits for its batch
+				na.subnetMigration.addPending(node.Name)
+			}
+			// the host subnets overlapping excluded subnets are replaced when
+			// the node is handled
+			hostSubnets = na.withoutExcludedSubnets(node.Name, hostSubnets)
 			if len(hostSubnets) > 0 {
 				klog.V(5).Infof("Node %s contains subnets: %v for network : %s", node.Name, hostSubnets, networkName)
-				if err := na.clusterSubnetAllocator.MarkAllocatedNetworks(node.Name, hostSubnets...); err != nil {
-					klog.Errorf("Failed to mark the subnet %v as allocated in the cluster subnet allocator for node %s: %v", hostSubnets, node.Name, err)
+				// each host subnet is marked on its own so that the other
+				// host subnets of a node with a duplicate one stay with it
+				for _, hostSubnet := range hostSubnets {
+					err := na.clusterSubnetAllocator.MarkAllocatedNetworks(node.Name, hostSubnet)
+					if IsAlreadyAllocatedError(err) {
+						na.recordDuplicateHostSubnet(node, hostSubnet, err)
+					} else if err != nil {
+						klog.Errorf("Failed to mark the subnet %v as allocated in the cluster subnet allocator for node %s: %v", hostSubnet, node.Name, err)
+					}
 				}
 			} else {
 				klog.V(5).Infof("Node %s contains no subnets for network : %s", node.Name, networkName)
@@ -282,11 +919,156 @@ func (na *NodeAllocator) Sync(nodes []interface{}) error {
 		}
 	}
 
+	// reserve the host subnets for the next nodes once those of the existing
+	// nodes are known
+	if na.warmSubnets != nil {
+		if err := na.warmSubnets.restore(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// ValidateNodeSubnets checks that the host subnets of the nodes belong to the
+// cluster subnets of the network, i.e. that no cluster subnet a node subnet was
+// allocated from was removed from the configuration. A node is only reported
+// when none of its host subnets of an enabled IP family is valid as it would
+// otherwise get a new host subnet; extra invalid host subnets, like those of an
+// IP family no longer enabled, are released without disruption. The returned
+// error lists the offending nodes.
+func (na *NodeAllocator) ValidateNodeSubnets(nodes []*corev1.Node) error {
+	if !na.hasNodeSubnetAllocation() {
+		return nil
+	}
+
+	networkName := na.netInfo.GetNetworkName()
+	clusterSubnets := na.clusterSubnets()
+	ipv4Mode, ipv6Mode := na.netInfo.IPMode()
+
+	var offending []string
+	for _, node := range nodes {
+		if util.NoHostSubnet(node) {
+			continue
+		}
+		hostSubnets, err := na.getNodeHostSubnets(node, networkName)
+		if err != nil {
+			continue
+		}
+		var removed []string
+		for _, isIPv6 := range []bool{false, true} {
+			if isIPv6 && !ipv6Mode || !isIPv6 && !ipv4Mode {
+				continue
+			}
+			var invalid []string
+			valid := false
+			for _, hostSubnet := range hostSubnets {
+				if utilnet.IsIPv6CIDR(hostSubnet) != isIPv6 {
+					continue
+				}
+				if isHostSubnetOfClusterSubnets(hostSubnet, clusterSubnets) {
+					valid = true
+					break
+				}
+				invalid = append(invalid, hostSubnet.String())
+			}
+			if !valid {
+				removed = append(removed, invalid...)
+			}
+		}
+		if len(removed) > 0 {
+			offending = append(offending, fmt.Sprintf("%s (%s)", node.Name, strings.Join(removed, ", ")))
+		}
+	}
+	if len(offending) == 0 {
+		return nil
+	}
+	sort.Strings(offending)
+	return fmt.Errorf("host subnets of %d nodes for network %s are not part of the configured cluster subnets: %s",
+		len(offending), networkName, strings.Join(offending, "; "))
+}
+
+func isHostSubnetOfClusterSubnets(hostSubnet *net.IPNet, clusterSubnets []config.CIDRNetworkEntry) bool {
+	hostSubnetLength, _ := hostSubnet.Mask.Size()
+	for _, clusterSubnet := range clusterSubnets {
+		clusterSubnetLength, _ := clusterSubnet.CIDR.Mask.Size()
+		if clusterSubnet.CIDR.Contains(hostSubnet.IP) && hostSubnetLength >= clusterSubnetLength {
+			return true
+		}
+	}
+	return false
+}
+
+// EnableAnnotationBatching updates the node annotations through the batcher,
+// coalescing them with the updates of the other controllers, instead of
+// updating the node on each change
+func (na *NodeAllocator) EnableAnnotationBatching(batcher *kube.NodeAnnotationBatcher) {
+	na.annotationBatcher = batcher
+}
+
+// updateNodeNetworkAnnotations updates the node's subnet annotation and
+// network id annotation, and bumps the generation of the ones that changed
+func updateNodeNetworkAnnotations(annotations map[string]string, nodeName string, hostSubnetsMap map[string][]*net.IPNet,
+	networkName string, networkId int, withNetworkState bool) (map[string]string, error) {
+	oldAnnotations := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		oldAnnotations[k] = v
+	}
+	var err error
+	for netName, hostSubnets := range hostSubnetsMap {
+		if withNetworkState && util.UseNodeNetworkState(netName) {
+			// only stored in the node network state, any host subnets
+			// annotated before are removed
+			hostSubnets = nil
+		}
+		annotations, err = util.UpdateNodeHostSubnetAnnotation(annotations, hostSubnets, netName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update node %q annotation subnet %s",
+				nodeName, util.JoinIPNets(hostSubnets, ","))
+		}
+	}
+	annotations, err = util.UpdateNetworkIDAnnotation(annotations, networkName, networkId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update node %q network id annotation %d for network %s",
+			nodeName, networkId, networkName)
+	}
+	annotations, err = util.UpdateHandshakeGenerations(oldAnnotations, annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update node %q handshake generations: %v", nodeName, err)
+	}
+	return annotations, nil
+}
+
+// useNetworkState returns whether the host subnets of the network are only
+// stored in the node network state
+func (na *NodeAllocator) useNetworkState(networkName string) bool {
+	return na.stateStore != nil && util.UseNodeNetworkState(networkName)
+}
+
+// getNodeHostSubnets returns the host subnets of the network of the node, read
+// from the node network state if they are only stored there
+func (na *NodeAllocator) getNodeHostSubnets(node *corev1.Node, networkName string) ([]*net.IPNet, error) {
+	if !na.useNetworkState(networkName) {
+		return util.ParseNodeHostSubnetAnnotation(node, networkName)
+	}
+	return util.ParseNodeHostSubnets(node, networkName, na.stateStore)
+}
+
+// hasAnnotatedStateHostSubnets returns whether the node still has the host
+// subnets of a network only stored in the node network state annotated
+func (na *NodeAllocator) hasAnnotatedStateHostSubnets(node *corev1.Node, networkName string) bool {
+	if !na.useNetworkState(networkName) {
+		return false
+	}
+	_, err := util.ParseNodeHostSubnetAnnotation(node, networkName)
+	return !util.IsAnnotationNotSetError(err)
+}
+
 // updateNodeNetworkAnnotationsWithRetry will update the node's subnet annotation and network id annotation
 func (na *NodeAllocator) updateNodeNetworkAnnotationsWithRetry(nodeName string, hostSubnetsMap map[string][]*net.IPNet, networkId int) error {
+	if na.annotationBatcher != nil {
+		return na.updateNodeNetworkAnnotationsBatched(nodeName, hostSubnetsMap, networkId)
+	}
 	// Retry if it fails because of potential conflict which is transient. Return error in the
 	// case of other errors (say temporary API server down), and it will be taken care of by the
 	// retry mechanism.
@@ -298,31 +1080,69 @@ func (na *NodeAllocator) updateNodeNetworkAnnotationsWithRetry(nodeName string,
 		}
 
 		cnode := node.DeepCopy()
-		for netName, hostSubnets := range hostSubnetsMap {
-			cnode.Annotations, err = util.UpdateNodeHostSubnetAnnotation(cnode.Annotations, hostSubnets, netName)
-			if err != nil {
-				return fmt.Errorf("failed to update node %q annotation subnet %s",
-					node.Name, util.JoinIPNets(hostSubnets, ","))
-			}
-		}
-
 		networkName := na.netInfo.GetNetworkName()
-
-		cnode.Annotations, err = util.UpdateNetworkIDAnnotation(cnode.Annotations, networkName, networkId)
+		cnode.Annotations, err = updateNodeNetworkAnnotations(cnode.Annotations, node.Name, hostSubnetsMap, networkName, networkId,
+			na.stateStore != nil)
 		if err != nil {
-			return fmt.Errorf("failed to update node %q network id annotation %d for network %s",
-				node.Name, networkId, networkName)
+			return err
+		}
+		// The node network state is the source of truth of the host subnets,
+		// it is updated before the annotations that mirror it so that the
+		// consumers notified of the annotation change find it up to date.
+		if na.stateStore != nil {
+			if err := na.stateStore.UpdateNodeNetworkState(node, hostSubnetsMap, networkName, networkId); err != nil {
+				return err
+			}
+		}
+		if reflect.DeepEqual(cnode.Annotations, node.Annotations) {
+			return nil
 		}
 		// It is possible to update the node annotations using status subresource
 		// because changes to metadata via status subresource are not restricted for nodes.
-		return na.kube.UpdateNodeStatus(cnode)
+		err = na.kube.UpdateNodeStatus(cnode)
+		if apierrors.IsConflict(err) {
+			metrics.RecordNodeAnnotationUpdateConflict(networkName)
+		}
+		return err
 	})
 	if resultErr != nil {
+		metrics.RecordNodeAnnotationUpdateRetry(na.netInfo.GetNetworkName())
 		return fmt.Errorf("failed to update node %s annotation", nodeName)
 	}
 	return nil
 }
 
+// updateNodeNetworkAnnotationsBatched updates the node's subnet annotation and
+// network id annotation through the annotation batcher, along with the
+// annotations of the other networks
+func (na *NodeAllocator) updateNodeNetworkAnnotationsBatched(nodeName string, hostSubnetsMap map[string][]*net.IPNet, networkId int) error {
+	networkName := na.netInfo.GetNetworkName()
+	withNetworkState := na.stateStore != nil
+	node, err := na.nodeLister.Get(nodeName)
+	if err != nil {
+		return err
+	}
+	if withNetworkState {
+		if err := na.stateStore.UpdateNodeNetworkState(node, hostSubnetsMap, networkName, networkId); err != nil {
+			return err
+		}
+	}
+	// nothing to batch if the annotations are already up to date
+	annotations, err := updateNodeNetworkAnnotations(node.DeepCopy().Annotations, nodeName, hostSubnetsMap, networkName,
+		networkId, withNetworkState)
+	if err == nil && reflect.DeepEqual(annotations, node.Annotations) {
+		return nil
+	}
+	err = na.annotationBatcher.UpdateNodeAnnotations(nodeName, func(annotations map[string]string) (map[string]string, error) {
+		return updateNodeNetworkAnnotations(annotations, nodeName, hostSubnetsMap, networkName, networkId, withNetworkState)
+	})
+	if err != nil {
+		metrics.RecordNodeAnnotationUpdateRetry(networkName)
+		return fmt.Errorf("failed to update node %s annotation: %w", nodeName, err)
+	}
+	return nil
+}
+
 // Cleanup the subnet annotations from the node
 func (na *NodeAllocator) Cleanup(netName string) error {
 	networkName := na.netInfo.GetNetworkName()
@@ -351,13 +1171,101 @@ func (na *NodeAllocator) Cleanup(netName string) error {
 
 		na.clusterSubnetAllocator.ReleaseAllNetworks(node.Name)
 	}
+	na.releaseAllDeletedNodeSubnets()
+	metrics.DeleteNetworkSubnetMetrics(networkName)
 
 	return nil
 }
 
+// getHostSubnetPrefixLengths returns the prefix lengths of the IPv4 and IPv6
+// host subnets requested for the node with the HostSubnetPrefixLengthKey and
+// HostSubnetIPv6PrefixLengthKey labels or annotations, the label taking
+// precedence, or else given by the first host subnet length rule matching the
+// labels of the node. 0 is returned for an IP family without override.
+// Overrides only apply to the default network.
+func (na *NodeAllocator) getHostSubnetPrefixLengths(node *corev1.Node) (int, int, error) {
+	if na.netInfo.IsSecondary() {
+		return 0, 0, nil
+	}
+	prefixLens := make([]int, 2)
+	for _, rule := range config.ClusterManager.HostSubnetLengthRules {
+		if rule.NodeSelector.Matches(labels.Set(node.Labels)) {
+			prefixLens[0], prefixLens[1] = rule.HostSubnetLength, rule.IPv6HostSubnetLength
+			break
+		}
+	}
+	for i, key := range []string{HostSubnetPrefixLengthKey, HostSubnetIPv6PrefixLengthKey} {
+		value, ok := node.Labels[key]
+		if !ok {
+			value, ok = node.Annotations[key]
+		}
+		if !ok {
+			continue
+		}
+		prefixLen, err := strconv.Atoi(value)
+		if err != nil || prefixLen <= 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q on node %s", key, value, node.Name)
+		}
+		prefixLens[i] = prefixLen
+	}
+	return prefixLens[0], prefixLens[1], nil
+}
+
+// getHostSubnetIndex returns the index of the host subnets to allocate to the
+// node: with the deterministic host subnet allocation, the one given by the
+// HostSubnetIndexKey label or annotation, the label taking precedence, or the
+// hash of the node name, and with the randomized host subnet allocation, a
+// random one. It returns false if the host subnets are allocated sequentially.
+func (na *NodeAllocator) getHostSubnetIndex(node *corev1.Node) (uint64, bool, error) {
+	switch na.netInfo.HostSubnetAllocation() {
+	case config.HostSubnetAllocationRandomized:
+		return cryptorand.Uint64(), true, nil
+	case config.HostSubnetAllocationDeterministic:
+		// only the default network allocates deterministically
+		if na.netInfo.IsSecondary() {
+			return 0, false, nil
+		}
+	default:
+		return 0, false, nil
+	}
+	value, ok := node.Labels[HostSubnetIndexKey]
+	if !ok {
+		value, ok = node.Annotations[HostSubnetIndexKey]
+	}
+	if ok {
+		index, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s %q on node %s", HostSubnetIndexKey, value, node.Name)
+		}
+		return index, true, nil
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(node.Name))
+	return hash.Sum64(), true, nil
+}
+
+// indexedSubnetAllocator allocates the networks at the given index, or the
+// next free ones, instead of the next free networks of the ranges
+type indexedSubnetAllocator struct {
+	SubnetAllocator
+	index uint64
+}
+
+func (isa *indexedSubnetAllocator) AllocateIPv4Network(owner string) (*net.IPNet, error) {
+	return isa.AllocateIPv4NetworkAt(owner, isa.index)
+}
+
+func (isa *indexedSubnetAllocator) AllocateIPv6Network(owner string) (*net.IPNet, error) {
+	return isa.AllocateIPv6NetworkAt(owner, isa.index)
+}
+
 // allocateNodeSubnets either validates existing node subnets against the allocators
-// ranges, or allocates new subnets if the node doesn't have any yet, or returns an error
-func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName string, existingSubnets []*net.IPNet, ipv4Mode, ipv6Mode bool) ([]*net.IPNet, []*net.IPNet, error) {
+// ranges, or allocates new subnets if the node doesn't have any yet, or returns an error.
+// New subnets are of the given IPv4 and IPv6 prefix lengths, or of the host subnet
+// length of the allocators ranges if 0. Existing subnets are kept whatever their size,
+// up to maxPerFamily subnets of each IP family.
+func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName string, existingSubnets []*net.IPNet,
+	ipv4Mode, ipv6Mode bool, ipv4PrefixLen, ipv6PrefixLen, maxPerFamily int) ([]*net.IPNet, []*net.IPNet, error) {
 	allocatedSubnets := []*net.IPNet{}
 
 	// OVN can work in single-stack or dual-stack only.
@@ -375,19 +1283,19 @@ func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName
 	// single-stack conversion).
 	// filter in place slice
 	// https://github.com/golang/go/wiki/SliceTricks#filter-in-place
-	foundIPv4 := false
-	foundIPv6 := false
+	foundIPv4 := 0
+	foundIPv6 := 0
 	n := 0
 	for _, subnet := range existingSubnets {
-		if (ipv4Mode && utilnet.IsIPv4CIDR(subnet) && !foundIPv4) || (ipv6Mode && utilnet.IsIPv6CIDR(subnet) && !foundIPv6) {
+		if (ipv4Mode && utilnet.IsIPv4CIDR(subnet) && foundIPv4 < maxPerFamily) || (ipv6Mode && utilnet.IsIPv6CIDR(subnet) && foundIPv6 < maxPerFamily) {
 			if err := allocator.MarkAllocatedNetworks(nodeName, subnet); err == nil {
 				klog.Infof("Valid subnet %v allocated on node %s", subnet, nodeName)
 				existingSubnets[n] = subnet
 				n++
 				if utilnet.IsIPv4CIDR(subnet) {
-					foundIPv4 = true
+					foundIPv4++
 				} else if utilnet.IsIPv6CIDR(subnet) {
-					foundIPv6 = true
+					foundIPv6++
 				}
 				continue
 			}
@@ -402,7 +1310,7 @@ func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName
 	existingSubnets = existingSubnets[:n]
 
 	// Node has enough valid subnets already allocated
-	if len(existingSubnets) == expectedHostSubnets {
+	if (!ipv4Mode || foundIPv4 > 0) && (!ipv6Mode || foundIPv6 > 0) {
 		klog.Infof("Allowed existing subnets %v on node %s", existingSubnets, nodeName)
 		return existingSubnets, allocatedSubnets, nil
 	}
@@ -423,7 +1331,7 @@ func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName
 	// allocateOneSubnet is a helper to process the result of a subnet allocation
 	allocateOneSubnet := func(allocatedHostSubnet *net.IPNet, allocErr error) error {
 		if allocErr != nil {
-			return fmt.Errorf("error allocating network for node %s: %v", nodeName, allocErr)
+			return fmt.Errorf("error allocating network for node %s: %w", nodeName, allocErr)
 		}
 		// the allocator returns nil if it can't provide a subnet
 		// we should filter them out or they will be appended to the slice
@@ -435,13 +1343,24 @@ func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName
 	}
 
 	// allocate new subnets if needed
-	if ipv4Mode && !foundIPv4 {
-		if err := allocateOneSubnet(allocator.AllocateIPv4Network(nodeName)); err != nil {
+	var err error
+	if ipv4Mode && foundIPv4 == 0 {
+		if ipv4PrefixLen > 0 {
+			err = allocateOneSubnet(allocator.AllocateIPv4NetworkOfLength(nodeName, ipv4PrefixLen))
+		} else {
+			err = allocateOneSubnet(allocator.AllocateIPv4Network(nodeName))
+		}
+		if err != nil {
 			return nil, nil, err
 		}
 	}
-	if ipv6Mode && !foundIPv6 {
-		if err := allocateOneSubnet(allocator.AllocateIPv6Network(nodeName)); err != nil {
+	if ipv6Mode && foundIPv6 == 0 {
+		if ipv6PrefixLen > 0 {
+			err = allocateOneSubnet(allocator.AllocateIPv6NetworkOfLength(nodeName, ipv6PrefixLen))
+		} else {
+			err = allocateOneSubnet(allocator.AllocateIPv6Network(nodeName))
+		}
+		if err != nil {
 			return nil, nil, err
 		}
 	}
@@ -449,7 +1368,13 @@ func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName
 	// check if we were able to allocate the new subnets require
 	// this can only happen if OVN is not configured correctly
 	// so it will require a reconfiguration and restart.
-	wantedSubnets := expectedHostSubnets - len(existingSubnets)
+	wantedSubnets := 0
+	if ipv4Mode && foundIPv4 == 0 {
+		wantedSubnets++
+	}
+	if ipv6Mode && foundIPv6 == 0 {
+		wantedSubnets++
+	}
 	if wantedSubnets > 0 && len(allocatedSubnets) != wantedSubnets {
 		return nil, nil, fmt.Errorf("error allocating networks for node %s: %d subnets expected only new %d subnets allocated",
 			nodeName, expectedHostSubnets, len(allocatedSubnets))
@@ -464,6 +1389,15 @@ func (na *NodeAllocator) allocateNodeSubnets(allocator SubnetAllocator, nodeName
 }
 
 func (na *NodeAllocator) hasNodeSubnetAllocation() bool {
-	// we only allocate subnets for L3 secondary network or default network
-	return na.netInfo.TopologyType() == types.Layer3Topology || !na.netInfo.IsSecondary()
+	// we only allocate subnets for L3 secondary networks or a L3 default
+	// network, the nodes of a L2 default network share its cluster subnets,
+	// and the IP chunks of the L2 secondary networks allocating their pod IPs
+	// per node
+	switch na.netInfo.TopologyType() {
+	case types.Layer3Topology:
+		return true
+	case types.Layer2Topology:
+		return na.netInfo.NodeIPChunkSize() > 0
+	}
+	return false
 }
@@ -1,15 +1,28 @@
 package node
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	cnitypes "github.com/containernetworking/cni/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 
 	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodenetworkstatefake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/clientset/versioned/fake"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
@@ -44,6 +57,10 @@ func TestController_allocateNodeSubnets(t *testing.T) {
 		configIPv6    bool
 		existingNets  []*net.IPNet
 		alreadyOwned  *existingAllocation
+		ipv4PrefixLen int
+		ipv6PrefixLen int
+		// maximum number of host subnets per IP family, 1 if 0
+		maxPerFamily int
 		// to be converted during the test to []*net.IPNet
 		wantStr   []string
 		allocated int
@@ -204,6 +221,82 @@ func TestController_allocateNodeSubnets(t *testing.T) {
 			wantStr:   []string{"172.16.0.0/24", "2001:db2:1:2::/64"},
 			allocated: 0,
 		},
+		{
+			name:          "new node with a larger IPv4 host subnet, IPv4 only cluster",
+			networkRanges: []string{"172.16.0.0/16"},
+			networkLens:   []int{24},
+			configIPv4:    true,
+			alreadyOwned: &existingAllocation{
+				owner:  "another-node",
+				subnet: "172.16.1.0/24",
+			},
+			ipv4PrefixLen: 23,
+			wantStr:       []string{"172.16.2.0/23"},
+			allocated:     1,
+		},
+		{
+			name:          "new node with a smaller IPv4 host subnet, IPv4 only cluster",
+			networkRanges: []string{"172.16.0.0/16"},
+			networkLens:   []int{24},
+			configIPv4:    true,
+			alreadyOwned: &existingAllocation{
+				owner:  "another-node",
+				subnet: "172.16.0.0/24",
+			},
+			ipv4PrefixLen: 26,
+			wantStr:       []string{"172.16.1.0/26"},
+			allocated:     1,
+		},
+		{
+			name:          "new node with a larger IPv6 host subnet, dual stack cluster",
+			networkRanges: []string{"172.16.0.0/16", "2001:db2:1::/56"},
+			networkLens:   []int{24, 64},
+			configIPv4:    true,
+			configIPv6:    true,
+			ipv6PrefixLen: 60,
+			wantStr:       []string{"172.16.0.0/24", "2001:db2:1::/60"},
+			allocated:     2,
+		},
+		{
+			name:          "existing annotated node keeps its host subnet of the default size",
+			networkRanges: []string{"172.16.0.0/16"},
+			networkLens:   []int{24},
+			configIPv4:    true,
+			existingNets:  ovntest.MustParseIPNets("172.16.8.0/24"),
+			ipv4PrefixLen: 23,
+			wantStr:       []string{"172.16.8.0/24"},
+			allocated:     0,
+		},
+		{
+			name:          "existing node keeps its additional host subnets up to the maximum",
+			networkRanges: []string{"172.16.0.0/16", "2001:db2::/56"},
+			networkLens:   []int{24, 64},
+			configIPv4:    true,
+			configIPv6:    true,
+			existingNets:  ovntest.MustParseIPNets("172.16.8.0/24", "2001:db2:0:5::/64", "172.16.9.0/24", "172.16.10.0/24"),
+			maxPerFamily:  2,
+			wantStr:       []string{"172.16.8.0/24", "2001:db2:0:5::/64", "172.16.9.0/24"},
+			allocated:     0,
+		},
+		{
+			name:          "existing node with additional host subnets of a single IP family gets one of the other",
+			networkRanges: []string{"172.16.0.0/16", "2001:db2::/56"},
+			networkLens:   []int{24, 64},
+			configIPv4:    true,
+			configIPv6:    true,
+			existingNets:  ovntest.MustParseIPNets("172.16.8.0/24", "172.16.9.0/24"),
+			maxPerFamily:  2,
+			wantStr:       []string{"172.16.8.0/24", "172.16.9.0/24", "2001:db2::/64"},
+			allocated:     1,
+		},
+		{
+			name:          "new node with a host subnet larger than the cluster subnet",
+			networkRanges: []string{"172.16.0.0/16"},
+			networkLens:   []int{24},
+			configIPv4:    true,
+			ipv4PrefixLen: 15,
+			wantErr:       true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -239,8 +332,14 @@ func TestController_allocateNodeSubnets(t *testing.T) {
 				}
 			}
 
+			maxPerFamily := tt.maxPerFamily
+			if maxPerFamily == 0 {
+				maxPerFamily = 1
+			}
+
 			// test network allocation works correctly
-			got, allocated, err := na.allocateNodeSubnets(na.clusterSubnetAllocator, "testnode", tt.existingNets, tt.configIPv4, tt.configIPv6)
+			got, allocated, err := na.allocateNodeSubnets(na.clusterSubnetAllocator, "testnode", tt.existingNets, tt.configIPv4, tt.configIPv6,
+				tt.ipv4PrefixLen, tt.ipv6PrefixLen, maxPerFamily)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("Controller.addNode() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -304,7 +403,7 @@ func TestController_allocateNodeSubnets_ReleaseOnError(t *testing.T) {
 
 	// test network allocation works correctly
 	v4usedBefore, v6usedBefore := na.clusterSubnetAllocator.Usage()
-	got, allocated, err := na.allocateNodeSubnets(na.clusterSubnetAllocator, "testNode", nil, true, true)
+	got, allocated, err := na.allocateNodeSubnets(na.clusterSubnetAllocator, "testNode", nil, true, true, 0, 0, 1)
 	if err == nil {
 		t.Fatalf("allocateNodeSubnets() expected error but got success")
 	}
@@ -323,3 +422,786 @@ func TestController_allocateNodeSubnets_ReleaseOnError(t *testing.T) {
 		t.Fatalf("Expected %d v6 allocated subnets, but got %d", v6usedBefore, v6usedAfter)
 	}
 }
+
+func TestNodeAllocator_ValidateNodeSubnets(t *testing.T) {
+	tests := []struct {
+		name          string
+		networkRanges []string
+		networkLens   []int
+		configIPv6    bool
+		nodes         map[string]string
+		wantErr       string
+	}{
+		{
+			name:          "all host subnets in the cluster subnets",
+			networkRanges: []string{"10.128.0.0/14", "10.132.0.0/14"},
+			networkLens:   []int{23, 23},
+			nodes: map[string]string{
+				"node1": `{"default":["10.128.0.0/23"]}`,
+				"node2": `{"default":["10.132.0.0/23"]}`,
+				"node3": "",
+			},
+		},
+		{
+			name:          "cluster subnet removed",
+			networkRanges: []string{"10.128.0.0/14"},
+			networkLens:   []int{23},
+			nodes: map[string]string{
+				"node1": `{"default":["10.128.0.0/23"]}`,
+				"node3": `{"default":["10.132.2.0/23"]}`,
+				"node2": `{"default":["10.132.0.0/23"]}`,
+			},
+			wantErr: "host subnets of 2 nodes for network default are not part of the configured cluster subnets: " +
+				"node2 (10.132.0.0/23); node3 (10.132.2.0/23)",
+		},
+		{
+			name:          "cluster subnet narrowed",
+			networkRanges: []string{"10.128.0.0/15"},
+			networkLens:   []int{23},
+			nodes: map[string]string{
+				"node1": `{"default":["10.128.0.0/23"]}`,
+				"node2": `{"default":["10.130.0.0/23"]}`,
+			},
+			wantErr: "host subnets of 1 nodes for network default are not part of the configured cluster subnets: " +
+				"node2 (10.130.0.0/23)",
+		},
+		{
+			name:          "IPv6 cluster subnet removed with dual-stack",
+			networkRanges: []string{"10.128.0.0/14", "fd00:10:128::/48"},
+			networkLens:   []int{23, 64},
+			configIPv6:    true,
+			nodes: map[string]string{
+				"node1": `{"default":["10.128.0.0/23","fd00:10:129::/64"]}`,
+			},
+			wantErr: "host subnets of 1 nodes for network default are not part of the configured cluster subnets: " +
+				"node1 (fd00:10:129::/64)",
+		},
+		{
+			name:          "extra invalid host subnet",
+			networkRanges: []string{"10.128.0.0/14"},
+			networkLens:   []int{23},
+			nodes: map[string]string{
+				"node1": `{"default":["10.128.0.0/23","1.2.3.0/24"]}`,
+			},
+		},
+		{
+			name:          "dual-stack to single-stack conversion",
+			networkRanges: []string{"10.128.0.0/14"},
+			networkLens:   []int{23},
+			nodes: map[string]string{
+				"node1": `{"default":["10.128.0.0/23","fd00:10:128::/64"]}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.PrepareTestConfig(); err != nil {
+				t.Fatal(err)
+			}
+			ranges, err := rangesFromStrings(tt.networkRanges, tt.networkLens)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.Default.ClusterSubnets = ranges
+			config.IPv4Mode = true
+			config.IPv6Mode = tt.configIPv6
+
+			nodes := []*corev1.Node{}
+			for name, subnets := range tt.nodes {
+				annotations := map[string]string{}
+				if subnets != "" {
+					annotations["k8s.ovn.org/node-subnets"] = subnets
+				}
+				nodes = append(nodes, newPlanTestNode(name, annotations))
+			}
+
+			na := NewNodeAllocator(0, &util.DefaultNetInfo{}, nil, nil, nil)
+			err = na.ValidateNodeSubnets(nodes)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ValidateNodeSubnets() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("ValidateNodeSubnets() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodeAllocator_AddClusterSubnets(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/23"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, nil, nil, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	additional, err := rangesFromStrings([]string{"10.128.0.0/23", "10.132.0.0/23"}, []int{24, 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := na.AddClusterSubnets(additional)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0].CIDR.String() != "10.132.0.0/23" {
+		t.Fatalf("expected only 10.132.0.0/23 to be added, got %v", added)
+	}
+	// adding the same cluster subnets again is a no-op
+	if added, err = na.AddClusterSubnets(additional); err != nil || len(added) != 0 {
+		t.Fatalf("expected no cluster subnet to be added again, got %v, %v", added, err)
+	}
+	if _, v4count, _, _ := na.GetSubnetUsage(); v4count != 4 {
+		t.Fatalf("expected 4 host subnets, got %d", v4count)
+	}
+	// the host subnets from the added cluster subnets are valid
+	node := newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.132.1.0/24"]}`})
+	if err := na.ValidateNodeSubnets([]*corev1.Node{node}); err != nil {
+		t.Fatalf("ValidateNodeSubnets() unexpected error: %v", err)
+	}
+}
+
+func TestNodeAllocator_getHostSubnetPrefixLengths(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		rules       []config.HostSubnetLengthRule
+		secondary   bool
+		wantIPv4    int
+		wantIPv6    int
+		wantErr     bool
+	}{
+		{
+			name: "no override",
+		},
+		{
+			name:   "first matching rule applies",
+			labels: map[string]string{"node-pool": "large", "zone": "a"},
+			rules: []config.HostSubnetLengthRule{
+				{NodeSelector: labels.SelectorFromSet(labels.Set{"node-pool": "edge"}), HostSubnetLength: 26},
+				{NodeSelector: labels.SelectorFromSet(labels.Set{"node-pool": "large"}), HostSubnetLength: 23},
+				{NodeSelector: labels.SelectorFromSet(labels.Set{"zone": "a"}), HostSubnetLength: 25, IPv6HostSubnetLength: 60},
+			},
+			wantIPv4: 23,
+		},
+		{
+			name:        "label or annotation takes precedence over rule",
+			labels:      map[string]string{"node-pool": "large"},
+			annotations: map[string]string{HostSubnetIPv6PrefixLengthKey: "62"},
+			rules: []config.HostSubnetLengthRule{
+				{NodeSelector: labels.SelectorFromSet(labels.Set{"node-pool": "large"}), HostSubnetLength: 23, IPv6HostSubnetLength: 60},
+			},
+			wantIPv4: 23,
+			wantIPv6: 62,
+		},
+		{
+			name:   "no matching rule",
+			labels: map[string]string{"node-pool": "small"},
+			rules: []config.HostSubnetLengthRule{
+				{NodeSelector: labels.SelectorFromSet(labels.Set{"node-pool": "large"}), HostSubnetLength: 23},
+			},
+		},
+		{
+			name:        "label takes precedence over annotation",
+			labels:      map[string]string{HostSubnetPrefixLengthKey: "23"},
+			annotations: map[string]string{HostSubnetPrefixLengthKey: "26", HostSubnetIPv6PrefixLengthKey: "60"},
+			wantIPv4:    23,
+			wantIPv6:    60,
+		},
+		{
+			name:   "overrides do not apply to secondary networks",
+			labels: map[string]string{HostSubnetPrefixLengthKey: "23"},
+			rules: []config.HostSubnetLengthRule{
+				{NodeSelector: labels.Everything(), HostSubnetLength: 25},
+			},
+			secondary: true,
+		},
+		{
+			name:    "invalid override",
+			labels:  map[string]string{HostSubnetIPv6PrefixLengthKey: "big"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.PrepareTestConfig(); err != nil {
+				t.Fatal(err)
+			}
+			config.ClusterManager.HostSubnetLengthRules = tt.rules
+			netConf := &ovncnitypes.NetConf{NetConf: cnitypes.NetConf{Name: types.DefaultNetworkName}}
+			if tt.secondary {
+				netConf = &ovncnitypes.NetConf{
+					NetConf:  cnitypes.NetConf{Name: "l3-network"},
+					Topology: types.Layer3Topology,
+					Subnets:  "192.168.0.0/16/24",
+				}
+			}
+			netInfo, err := util.NewNetInfo(netConf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			na := &NodeAllocator{netInfo: netInfo}
+			node := &corev1.Node{}
+			node.Name = "node1"
+			node.Labels = tt.labels
+			node.Annotations = tt.annotations
+			ipv4PrefixLen, ipv6PrefixLen, err := na.getHostSubnetPrefixLengths(node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getHostSubnetPrefixLengths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ipv4PrefixLen != tt.wantIPv4 || ipv6PrefixLen != tt.wantIPv6 {
+				t.Fatalf("getHostSubnetPrefixLengths() = %d, %d, want %d, %d", ipv4PrefixLen, ipv6PrefixLen, tt.wantIPv4, tt.wantIPv6)
+			}
+		})
+	}
+}
+
+func TestNodeAllocator_ExcludeSubnets(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.ClusterManager.ExcludeSubnets = []*net.IPNet{ovntest.MustParseIPNet("10.128.0.0/24")}
+	config.IPv4Mode = true
+
+	nodes := []*corev1.Node{
+		newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/24"]}`}),
+		newPlanTestNode("node2", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.1.0/24"]}`}),
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	objs := []runtime.Object{}
+	syncNodes := []interface{}{}
+	for _, node := range nodes {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, node)
+		syncNodes = append(syncNodes, node)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync(syncNodes); err != nil {
+		t.Fatal(err)
+	}
+
+	// node1 is evicted from the excluded subnet and gets a free one
+	for _, node := range nodes {
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, expected := range map[string]string{"node1": "10.128.2.0/24", "node2": "10.128.1.0/24"} {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hostSubnets) != 1 || hostSubnets[0].String() != expected {
+			t.Fatalf("expected %s to have the host subnet %s, got %v", name, expected, hostSubnets)
+		}
+	}
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 2 {
+		t.Fatalf("expected 2 allocated host subnets, got %d", v4used)
+	}
+}
+
+func TestNodeAllocator_NodeIPChunks(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:         cnitypes.NetConf{Name: "l2-network"},
+		Topology:        types.Layer2Topology,
+		Subnets:         "10.1.130.0/24",
+		ExcludeSubnets:  "10.1.130.0/26,10.1.130.70/32",
+		NodeIPChunkSize: 64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := []*corev1.Node{newPlanTestNode("node1", nil), newPlanTestNode("node2", nil)}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	objs := []runtime.Object{}
+	for _, node := range nodes {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, node)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	na := NewNodeAllocator(1, netInfo, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the IP chunk entirely excluded is skipped, the one partially excluded
+	// is allocated
+	for _, node := range nodes {
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, expected := range map[string]string{"node1": "10.1.130.64/26", "node2": "10.1.130.128/26"} {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks, err := util.ParseNodeHostSubnetAnnotation(node, netInfo.GetNetworkName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) != 1 || chunks[0].String() != expected {
+			t.Fatalf("expected %s to have the IP chunk %s, got %v", name, expected, chunks)
+		}
+	}
+}
+
+func TestNodeAllocator_getHostSubnetIndex(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, nil, nil, nil)
+	node := newPlanTestNode("node1", nil)
+	if _, ok, err := na.getHostSubnetIndex(node); ok || err != nil {
+		t.Fatalf("expected no host subnet index with the sequential allocation, got %v, %v", ok, err)
+	}
+
+	config.ClusterManager.HostSubnetAllocation = config.HostSubnetAllocationDeterministic
+	index, ok, err := na.getHostSubnetIndex(node)
+	if !ok || err != nil {
+		t.Fatalf("expected a host subnet index, got %v, %v", ok, err)
+	}
+	// the index only depends on the node name
+	if again, _, _ := na.getHostSubnetIndex(newPlanTestNode("node1", nil)); again != index {
+		t.Fatalf("expected the host subnet index %d, got %d", index, again)
+	}
+	if other, _, _ := na.getHostSubnetIndex(newPlanTestNode("node2", nil)); other == index {
+		t.Fatalf("expected node2 to get another host subnet index than %d", index)
+	}
+
+	// the label takes precedence over the annotation
+	node = newPlanTestNode("node1", map[string]string{HostSubnetIndexKey: "7"})
+	if index, _, err = na.getHostSubnetIndex(node); index != 7 || err != nil {
+		t.Fatalf("expected the host subnet index 7, got %d, %v", index, err)
+	}
+	node.Labels = map[string]string{HostSubnetIndexKey: "12"}
+	if index, _, err = na.getHostSubnetIndex(node); index != 12 || err != nil {
+		t.Fatalf("expected the host subnet index 12, got %d, %v", index, err)
+	}
+	node.Labels[HostSubnetIndexKey] = "-1"
+	if _, _, err = na.getHostSubnetIndex(node); err == nil {
+		t.Fatal("expected an error for an invalid host subnet index")
+	}
+
+	// only applies to the default network
+	netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "l3-network"},
+		Topology: types.Layer3Topology,
+		Subnets:  "192.168.0.0/16/24",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondary := &NodeAllocator{netInfo: netInfo}
+	if _, ok, _ := secondary.getHostSubnetIndex(newPlanTestNode("node1", nil)); ok {
+		t.Fatal("expected no host subnet index for a secondary network")
+	}
+}
+
+func TestNodeAllocator_randomizedHostSubnetIndex(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	config.ClusterManager.HostSubnetAllocation = config.HostSubnetAllocationRandomized
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, nil, nil, nil)
+	// the host subnet index label or annotation is ignored
+	node := newPlanTestNode("node1", map[string]string{HostSubnetIndexKey: "7"})
+	index, ok, err := na.getHostSubnetIndex(node)
+	if !ok || err != nil {
+		t.Fatalf("expected a host subnet index, got %v, %v", ok, err)
+	}
+	if again, _, _ := na.getHostSubnetIndex(node); again == index {
+		t.Fatalf("expected another random host subnet index than %d", index)
+	}
+
+	// the allocation of the secondary networks is set in their netconf
+	config.ClusterManager.HostSubnetAllocation = config.HostSubnetAllocationSequential
+	for allocation, expected := range map[string]bool{"": false, config.HostSubnetAllocationRandomized: true} {
+		netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:              cnitypes.NetConf{Name: "l3-network"},
+			Topology:             types.Layer3Topology,
+			Subnets:              "192.168.0.0/16/24",
+			HostSubnetAllocation: allocation,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		secondary := &NodeAllocator{netInfo: netInfo}
+		if _, ok, _ := secondary.getHostSubnetIndex(newPlanTestNode("node1", nil)); ok != expected {
+			t.Fatalf("expected a host subnet index %v for the %q host subnet allocation, got %v", expected, allocation, ok)
+		}
+	}
+}
+
+func TestNodeAllocator_DeletedNodeSubnetGracePeriod(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset()
+	addNode := func(node *corev1.Node) {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleteNode := func(name string) *corev1.Node {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Delete(node); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.CoreV1().Nodes().Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		return node
+	}
+	expectHostSubnet := func(name, expected string) {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hostSubnets) != 1 || hostSubnets[0].String() != expected {
+			t.Fatalf("expected %s to have the host subnet %s, got %v", name, expected, hostSubnets)
+		}
+	}
+
+	node1 := newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/24"]}`})
+	addNode(node1)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableDeletedNodeSubnetGracePeriod(time.Minute)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync([]interface{}{node1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the host subnet of the deleted node is not handed out to other nodes
+	if err := na.HandleDeleteNode(deleteNode("node1")); err != nil {
+		t.Fatal(err)
+	}
+	node2 := newPlanTestNode("node2", nil)
+	addNode(node2)
+	if err := na.HandleAddUpdateNodeEvent(node2); err != nil {
+		t.Fatal(err)
+	}
+	expectHostSubnet("node2", "10.128.1.0/24")
+
+	// the node recreated within the grace period gets its host subnet back
+	node1 = newPlanTestNode("node1", nil)
+	addNode(node1)
+	if err := na.HandleAddUpdateNodeEvent(node1); err != nil {
+		t.Fatal(err)
+	}
+	expectHostSubnet("node1", "10.128.0.0/24")
+
+	// the host subnet is released once the grace period is over
+	if err := na.HandleDeleteNode(deleteNode("node2")); err != nil {
+		t.Fatal(err)
+	}
+	na.releaseExpiredNodeSubnets(time.Now())
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 2 {
+		t.Fatalf("expected 2 allocated host subnets during the grace period, got %d", v4used)
+	}
+	na.releaseExpiredNodeSubnets(time.Now().Add(2 * time.Minute))
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 1 {
+		t.Fatalf("expected 1 allocated host subnet after the grace period, got %d", v4used)
+	}
+}
+
+func TestNodeAllocator_SyncDuplicateHostSubnets(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset()
+	addNode := func(name string, created time.Time) *corev1.Node {
+		node := newPlanTestNode(name, map[string]string{
+			"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/24"]}`,
+			"k8s.ovn.org/network-ids":  `{"default":"0"}`,
+		})
+		node.CreationTimestamp = metav1.NewTime(created)
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		return node
+	}
+	getHostSubnets := func(name string) []*net.IPNet {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hostSubnets
+	}
+
+	now := time.Now()
+	newer := addNode("node1", now)
+	older := addNode("node2", now.Add(-time.Hour))
+	recorder := record.NewFakeRecorder(10)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableEvents(recorder)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the older node keeps the duplicate host subnet whatever the order of
+	// the nodes
+	if err := na.Sync([]interface{}{newer, older}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, "Warning DuplicateHostSubnet Duplicate host subnet for network default") {
+			t.Fatalf("unexpected event %q", event)
+		}
+	default:
+		t.Fatal("expected a duplicate host subnet event")
+	}
+	for _, node := range []*corev1.Node{newer, older} {
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hostSubnets := getHostSubnets("node2"); len(hostSubnets) != 1 || hostSubnets[0].String() != "10.128.0.0/24" {
+		t.Fatalf("expected node2 to keep the host subnet 10.128.0.0/24, got %v", hostSubnets)
+	}
+	if hostSubnets := getHostSubnets("node1"); len(hostSubnets) != 1 || hostSubnets[0].String() != "10.128.1.0/24" {
+		t.Fatalf("expected node1 to get the host subnet 10.128.1.0/24, got %v", hostSubnets)
+	}
+}
+
+func TestNodeAllocator_NoHostSubnetTransition(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.Kubernetes.NoHostSubnetNodes = &metav1.LabelSelector{MatchLabels: map[string]string{"unmanaged": ""}}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset(newPlanTestNode("node1", nil))
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	// updateNode sets whether the node is managed by OVN and handles the
+	// update like the informer would
+	updateNode := func(managed bool) *corev1.Node {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.Labels = nil
+		if !managed {
+			node.Labels = map[string]string{"unmanaged": ""}
+		}
+		if node, err = client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Update(node); err != nil {
+			t.Fatal(err)
+		}
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatal(err)
+		}
+		node, err = client.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return node
+	}
+
+	node := updateNode(true)
+	if hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName); err != nil || len(hostSubnets) != 1 {
+		t.Fatalf("expected the managed node to be allocated a host subnet, got %v: %v", hostSubnets, err)
+	}
+
+	// the host subnet of the node labeled as not managed is released
+	node = updateNode(false)
+	if _, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName); !util.IsAnnotationNotSetError(err) {
+		t.Fatalf("expected the host subnet annotation of the unmanaged node to be removed, got %v", err)
+	}
+	if _, err := util.ParseNetworkIDAnnotation(node, types.DefaultNetworkName); !util.IsAnnotationNotSetError(err) {
+		t.Fatalf("expected the network id annotation of the unmanaged node to be removed, got %v", err)
+	}
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 0 {
+		t.Fatalf("expected no allocated host subnet, got %d", v4used)
+	}
+
+	// and a host subnet allocated again once it is managed again
+	node = updateNode(true)
+	if hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName); err != nil || len(hostSubnets) != 1 {
+		t.Fatalf("expected the managed node to be allocated a host subnet, got %v: %v", hostSubnets, err)
+	}
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 1 {
+		t.Fatalf("expected 1 allocated host subnet, got %d", v4used)
+	}
+}
+
+func TestNodeAllocator_NodeNetworkStateBackend(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.OVNKubernetesFeature.NodeNetworkStateBackend = config.NodeNetworkStateBackendCRD
+
+	// node2 still has the host subnet annotated before the switch to the
+	// "crd" backend, migrated to its state
+	node1 := newPlanTestNode("node1", nil)
+	node2 := newPlanTestNode("node2", map[string]string{
+		"k8s.ovn.org/node-subnets": `{"default":["10.128.2.0/24"]}`,
+		"k8s.ovn.org/network-ids":  `{"default":"0"}`,
+	})
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset(node1, node2)
+	store := NewCRDNetworkStateStore(nodenetworkstatefake.NewSimpleClientset())
+	if err := store.MigrateNodeNetworkState(node2); err != nil {
+		t.Fatal(err)
+	}
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, store)
+	na.EnableDeletedNodeSubnetGracePeriod(time.Minute)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync([]interface{}{node1, node2}); err != nil {
+		t.Fatal(err)
+	}
+	// handleNode handles the node as the informer would and returns it as
+	// updated on the API server
+	handleNode := func(name string) *corev1.Node {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatal(err)
+		}
+		node, err = client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return node
+	}
+	expectHostSubnet := func(node *corev1.Node, expected string) {
+		t.Helper()
+		if _, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName); !util.IsAnnotationNotSetError(err) {
+			t.Fatalf("expected the host subnet of %s not to be annotated, got %v", node.Name, err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnets(node, types.DefaultNetworkName, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hostSubnets) != 1 || hostSubnets[0].String() != expected {
+			t.Fatalf("expected %s to have the host subnet %s, got %v", node.Name, expected, hostSubnets)
+		}
+	}
+	nodeUpdates := func() int {
+		updates := 0
+		for _, action := range client.Actions() {
+			if action.Matches("update", "nodes") {
+				updates++
+			}
+		}
+		return updates
+	}
+
+	// the host subnets are only stored in the node network state, the
+	// annotated ones being removed
+	expectHostSubnet(handleNode("node1"), "10.128.0.0/24")
+	expectHostSubnet(handleNode("node2"), "10.128.2.0/24")
+
+	// and the node is not updated again when nothing changed
+	updates := nodeUpdates()
+	expectHostSubnet(handleNode("node1"), "10.128.0.0/24")
+	if nodeUpdates() != updates {
+		t.Fatalf("expected node1 not to be updated again, got %d updates instead of %d", nodeUpdates(), updates)
+	}
+
+	// the host subnet of the deleted node is read from its state before the
+	// state is forgotten, and given back to the node recreated within the
+	// grace period
+	if err := na.HandleDeleteNode(node2); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CoreV1().Nodes().Delete(context.TODO(), "node2", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Nodes().Create(context.TODO(), newPlanTestNode("node3", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectHostSubnet(handleNode("node3"), "10.128.1.0/24")
+	if _, err := client.CoreV1().Nodes().Create(context.TODO(), newPlanTestNode("node4", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectHostSubnet(handleNode("node4"), "10.128.3.0/24")
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+)
+
+// NodePoolCapacity is the number of new nodes that can still join a network
+// from a pool of cluster subnets
+type NodePoolCapacity struct {
+	// Pool is the topology zone the cluster subnets are mapped to, empty for
+	// the cluster subnets mapped to no zone
+	Pool string
+	// Nodes is the number of new nodes that can be allocated a host subnet
+	// of each IP family of the network
+	Nodes uint64
+}
+
+// GetNodeCapacity returns, for each pool of cluster subnets the new nodes are
+// allocated host subnets from, how many more nodes can join the network. The
+// cluster subnets of the default network mapped to a topology zone are the
+// pool of the nodes of that zone, the other cluster subnets the pool of the
+// other nodes. The host subnets of other lengths are counted as a single host
+// subnet, like in GetClusterSubnetUsage.
+func (na *NodeAllocator) GetNodeCapacity() []NodePoolCapacity {
+	if !na.hasNodeSubnetAllocation() {
+		return nil
+	}
+	pools := []string{""}
+	if !na.netInfo.IsSecondary() {
+		for zone := range config.ClusterManager.ZoneSubnets {
+			if zone != "" {
+				pools = append(pools, zone)
+			}
+		}
+		sort.Strings(pools)
+	}
+	usage := na.clusterSubnetAllocator.RangeUsage()
+	ipv4Mode, ipv6Mode := na.netInfo.IPMode()
+
+	capacity := make([]NodePoolCapacity, 0, len(pools))
+	for _, pool := range pools {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: pool}}}
+		var v4networks, v6networks sets.Set[string]
+		if zsa, ok := na.nodeSubnetAllocator(node).(*zoneSubnetAllocator); ok {
+			v4networks, v6networks = sets.New[string](), sets.New[string]()
+			for _, network := range zsa.v4networks {
+				v4networks.Insert(network.String())
+			}
+			for _, network := range zsa.v6networks {
+				v6networks.Insert(network.String())
+			}
+		}
+		var v4free, v6free uint64
+		for _, u := range usage {
+			if u.Count <= u.Used {
+				continue
+			}
+			if utilnet.IsIPv6CIDR(u.Network) {
+				if v6networks == nil || v6networks.Has(u.Network.String()) {
+					v6free += u.Count - u.Used
+				}
+			} else if v4networks == nil || v4networks.Has(u.Network.String()) {
+				v4free += u.Count - u.Used
+			}
+		}
+		pc := NodePoolCapacity{Pool: pool}
+		switch {
+		case ipv4Mode && ipv6Mode:
+			pc.Nodes = v4free
+			if v6free < v4free {
+				pc.Nodes = v6free
+			}
+		case ipv4Mode:
+			pc.Nodes = v4free
+		case ipv6Mode:
+			pc.Nodes = v6free
+		}
+		capacity = append(capacity, pc)
+	}
+	return capacity
+}
+
+func (na *NodeAllocator) recordNodeCapacity() {
+	for _, pc := range na.GetNodeCapacity() {
+		metrics.RecordNodeCapacity(na.netInfo.GetNetworkName(), pc.Pool, float64(pc.Nodes))
+	}
+}
@@ -0,0 +1,97 @@
+package node
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_GetNodeCapacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		zoneSubnets map[string][]int
+		nodeZones   map[string]string
+		expected    []NodePoolCapacity
+	}{
+		{
+			name:      "a single pool without zone subnets",
+			nodeZones: map[string]string{"n1": "zone-a", "n2": ""},
+			expected:  []NodePoolCapacity{{Pool: "", Nodes: 6}},
+		},
+		{
+			name:        "a pool per zone and a pool of the cluster subnets mapped to no zone",
+			zoneSubnets: map[string][]int{"zone-a": {1}, "zone-b": {2}},
+			nodeZones:   map[string]string{"a1": "zone-a", "c1": "zone-c", "none": ""},
+			expected: []NodePoolCapacity{
+				{Pool: "", Nodes: 0},
+				{Pool: "zone-a", Nodes: 1},
+				{Pool: "zone-b", Nodes: 4},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.PrepareTestConfig(); err != nil {
+				t.Fatal(err)
+			}
+			ranges, err := rangesFromStrings([]string{"10.128.0.0/23", "10.129.0.0/23", "10.130.0.0/22"}, []int{24, 24, 24})
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.Default.ClusterSubnets = ranges
+			config.IPv4Mode = true
+			config.IPv6Mode = false
+			if tt.zoneSubnets != nil {
+				config.ClusterManager.ZoneSubnets = map[string][]*net.IPNet{}
+				for zone, indexes := range tt.zoneSubnets {
+					for _, i := range indexes {
+						config.ClusterManager.ZoneSubnets[zone] = append(config.ClusterManager.ZoneSubnets[zone], ranges[i].CIDR)
+					}
+				}
+			}
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			nodes := []*corev1.Node{}
+			for name, zone := range tt.nodeZones {
+				node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				if zone != "" {
+					node.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+				}
+				if err := indexer.Add(node); err != nil {
+					t.Fatal(err)
+				}
+				nodes = append(nodes, node)
+			}
+			client := fake.NewSimpleClientset()
+			for _, node := range nodes {
+				if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+			if err := na.Init(); err != nil {
+				t.Fatal(err)
+			}
+			for _, node := range nodes {
+				if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+					t.Fatalf("failed to allocate the host subnets of node %s: %v", node.Name, err)
+				}
+			}
+
+			if actual := na.GetNodeCapacity(); !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("expected the node capacity %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
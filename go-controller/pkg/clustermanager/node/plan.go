@@ -0,0 +1,85 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// SubnetPlan is the outcome of running a NodeAllocator for a network against
+// a set of nodes
+type SubnetPlan struct {
+	// HostSubnets are the host subnets of each node of the network, by node
+	// name. Nodes without host subnets, like hybrid overlay nodes, are not
+	// included.
+	HostSubnets map[string][]*net.IPNet
+	// Nodes are the nodes annotated by the NodeAllocator, sorted by name
+	Nodes []*corev1.Node
+}
+
+// PlanNodeSubnets precomputes the host subnets the NodeAllocator would assign
+// to the given nodes for a network, without an API server. The allocator runs
+// against a FakeCluster and is seeded deterministically: the subnets already
+// annotated on the nodes are reserved first, then the nodes are handled in
+// name order. The plan thus only depends on its input, not on the order
+// nodes would be seen by an informer.
+func PlanNodeSubnets(networkID int, netInfo util.NetInfo, nodes []*corev1.Node) (*SubnetPlan, error) {
+	sorted := make([]*corev1.Node, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	cluster := NewFakeCluster(sorted...)
+	na := NewNodeAllocator(networkID, netInfo, cluster.NodeLister(), cluster.Kube(), nil)
+	if err := na.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize node allocator for network %s: %w", netInfo.GetNetworkName(), err)
+	}
+
+	if err := SeedNodeAllocator(na, sorted); err != nil {
+		return nil, err
+	}
+
+	for _, node := range sorted {
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			return nil, fmt.Errorf("failed to allocate subnets to node %s for network %s: %w",
+				node.Name, netInfo.GetNetworkName(), err)
+		}
+	}
+
+	annotated, err := cluster.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	plan := &SubnetPlan{
+		HostSubnets: map[string][]*net.IPNet{},
+		Nodes:       annotated,
+	}
+	for _, node := range annotated {
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, netInfo.GetNetworkName())
+		if err != nil {
+			if util.IsAnnotationNotSetError(err) {
+				continue
+			}
+			return nil, err
+		}
+		plan.HostSubnets[node.Name] = hostSubnets
+	}
+	return plan, nil
+}
+
+// SeedNodeAllocator reserves in the NodeAllocator the subnets already
+// annotated on the given nodes, in name order, so that conflicting
+// annotations are always resolved in favor of the same node
+func SeedNodeAllocator(na *NodeAllocator, nodes []*corev1.Node) error {
+	sorted := make([]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		sorted = append(sorted, node)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].(*corev1.Node).Name < sorted[j].(*corev1.Node).Name
+	})
+	return na.Sync(sorted)
+}
@@ -0,0 +1,215 @@
+package node
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the node annotations")
+
+func newPlanTestNode(name string, annotations map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+	}
+}
+
+// checkGolden compares the annotations of the nodes against the golden file
+// of the test, or updates the golden file when run with -update
+func checkGolden(t *testing.T, nodes []*corev1.Node) {
+	annotations := map[string]map[string]string{}
+	for _, node := range nodes {
+		annotations[node.Name] = node.Annotations
+	}
+	got, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal node annotations: %v", err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", t.Name()+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %s: %v", golden, err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("Node annotations do not match golden file %s, got:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestPlanNodeSubnets(t *testing.T) {
+	tests := []struct {
+		name        string
+		ranges      []string
+		networkLens []int
+		ipv6        bool
+		netConf     *ovncnitypes.NetConf
+		networkID   int
+		nodes       []*corev1.Node
+		want        map[string][]string
+	}{
+		{
+			name:        "default_ipv4",
+			ranges:      []string{"10.128.0.0/14"},
+			networkLens: []int{23},
+			netConf:     &ovncnitypes.NetConf{NetConf: cnitypes.NetConf{Name: types.DefaultNetworkName}},
+			networkID:   0,
+			// out of order on purpose, nodes are handled in name order
+			nodes: []*corev1.Node{
+				newPlanTestNode("node3", nil),
+				newPlanTestNode("node1", nil),
+				newPlanTestNode("node2", nil),
+			},
+			want: map[string][]string{
+				"node1": {"10.128.0.0/23"},
+				"node2": {"10.129.0.0/23"},
+				"node3": {"10.130.0.0/23"},
+			},
+		},
+		{
+			name:        "default_dualstack_seeded",
+			ranges:      []string{"10.128.0.0/14", "fd00:10:128::/48"},
+			networkLens: []int{23, 64},
+			ipv6:        true,
+			netConf:     &ovncnitypes.NetConf{NetConf: cnitypes.NetConf{Name: types.DefaultNetworkName}},
+			networkID:   0,
+			nodes: []*corev1.Node{
+				newPlanTestNode("node1", nil),
+				newPlanTestNode("node2", map[string]string{
+					"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/23","fd00:10:128::/64"]}`,
+					"k8s.ovn.org/network-ids":  `{"default":"0"}`,
+				}),
+			},
+			want: map[string][]string{
+				"node1": {"10.129.0.0/23", "fd00:10:128:1::/64"},
+				"node2": {"10.128.0.0/23", "fd00:10:128::/64"},
+			},
+		},
+		{
+			name: "layer3_secondary",
+			netConf: &ovncnitypes.NetConf{
+				NetConf:  cnitypes.NetConf{Name: "blue"},
+				Topology: types.Layer3Topology,
+				Subnets:  "192.168.0.0/16/24",
+			},
+			networkID: 2,
+			nodes: []*corev1.Node{
+				newPlanTestNode("node1", map[string]string{
+					"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/23"]}`,
+					"k8s.ovn.org/network-ids":  `{"default":"0"}`,
+				}),
+				newPlanTestNode("node2", nil),
+			},
+			want: map[string][]string{
+				"node1": {"192.168.0.0/24"},
+				"node2": {"192.168.1.0/24"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.PrepareTestConfig(); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.ranges) > 0 {
+				ranges, err := rangesFromStrings(tt.ranges, tt.networkLens)
+				if err != nil {
+					t.Fatal(err)
+				}
+				config.Default.ClusterSubnets = ranges
+			}
+			config.IPv4Mode = true
+			config.IPv6Mode = tt.ipv6
+			netInfo, err := util.NewNetInfo(tt.netConf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plan, err := PlanNodeSubnets(tt.networkID, netInfo, tt.nodes)
+			if err != nil {
+				t.Fatalf("PlanNodeSubnets() unexpected error: %v", err)
+			}
+
+			want := map[string][]string{}
+			for node, subnets := range plan.HostSubnets {
+				want[node] = util.StringSlice(subnets)
+			}
+			if !reflect.DeepEqual(want, tt.want) {
+				t.Fatalf("PlanNodeSubnets() host subnets = %v, want %v", want, tt.want)
+			}
+
+			// the plan is the same when computed again
+			again, err := PlanNodeSubnets(tt.networkID, netInfo, tt.nodes)
+			if err != nil {
+				t.Fatalf("PlanNodeSubnets() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(plan.HostSubnets, again.HostSubnets) {
+				t.Fatalf("PlanNodeSubnets() is not deterministic, got %v then %v", plan.HostSubnets, again.HostSubnets)
+			}
+
+			checkGolden(t, plan.Nodes)
+		})
+	}
+}
+
+func TestFakeCluster(t *testing.T) {
+	cluster := NewFakeCluster(newPlanTestNode("node1", nil))
+	node, err := cluster.GetNode("node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// updates through the kube interface are seen by the lister
+	node.Annotations = map[string]string{"foo": "bar"}
+	if err = cluster.Kube().UpdateNodeStatus(node); err != nil {
+		t.Fatal(err)
+	}
+	listed, err := cluster.NodeLister().Get("node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed.Annotations["foo"] != "bar" {
+		t.Fatalf("Expected lister to see the updated annotations, got %v", listed.Annotations)
+	}
+
+	if err = cluster.AddNode(newPlanTestNode("node0", nil)); err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := cluster.ListNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "node0" || nodes[1].Name != "node1" {
+		t.Fatalf("Expected nodes sorted by name, got %v", nodes)
+	}
+
+	if err = cluster.DeleteNode("node0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cluster.GetNode("node0"); err == nil {
+		t.Fatal("Expected deleted node to be gone")
+	}
+}
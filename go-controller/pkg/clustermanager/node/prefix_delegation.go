@@ -0,0 +1,330 @@
+package node
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util/dhcpv6"
+)
+
+// delegatedSubnetRenewalInterval is how often the leases of the delegated
+// subnets are checked for renewal
+const delegatedSubnetRenewalInterval = 30 * time.Second
+
+// SubnetSource provides the IPv6 host subnets of the nodes from outside the
+// cluster, like the prefixes delegated by DHCPv6 servers or the ones assigned
+// by a cloud API, instead of carving them from the cluster subnets
+type SubnetSource interface {
+	// Acquire obtains a subnet for the node, of the given prefix length if
+	// not 0
+	Acquire(nodeName string, prefixLen int) (*SubnetLease, error)
+	// Renew extends the lease of the subnet of the node. The renewed lease
+	// might hold another subnet.
+	Renew(nodeName string, lease *SubnetLease) (*SubnetLease, error)
+	// Release gives the subnet of the node back to the source
+	Release(nodeName string, lease *SubnetLease) error
+}
+
+// SubnetLease is a subnet obtained from a SubnetSource
+type SubnetLease struct {
+	Subnet *net.IPNet
+	// RenewAt is when the lease must be renewed
+	RenewAt time.Time
+	// Expiry is when the subnet stops being valid, zero if never
+	Expiry time.Time
+	// Data is private to the source
+	Data interface{}
+}
+
+// dhcpv6SubnetSource obtains the subnets with DHCPv6 prefix delegation, each
+// node being its own identity association
+type dhcpv6SubnetSource struct {
+	client *dhcpv6.Client
+}
+
+// NewDHCPv6SubnetSource returns a SubnetSource requesting the delegation of
+// prefixes from the DHCPv6 servers reachable on the given interface
+func NewDHCPv6SubnetSource(ifName string) (SubnetSource, error) {
+	client, err := dhcpv6.NewClient(ifName)
+	if err != nil {
+		return nil, err
+	}
+	return &dhcpv6SubnetSource{client: client}, nil
+}
+
+// nodeIAID returns the identity association of the node, stable across
+// restarts so that the node keeps its prefix
+func nodeIAID(nodeName string) uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(nodeName))
+	return hash.Sum32()
+}
+
+func dhcpv6Lease(nodeName string, lease *SubnetLease) *dhcpv6.Lease {
+	if l, ok := lease.Data.(*dhcpv6.Lease); ok {
+		return l
+	}
+	// the server of the leases adopted on restart is unknown
+	return &dhcpv6.Lease{IAID: nodeIAID(nodeName), Prefix: lease.Subnet}
+}
+
+func subnetLeaseFromDHCPv6(lease *dhcpv6.Lease) *SubnetLease {
+	return &SubnetLease{
+		Subnet:  lease.Prefix,
+		RenewAt: lease.RenewAt(),
+		Expiry:  lease.Expiry(),
+		Data:    lease,
+	}
+}
+
+func (s *dhcpv6SubnetSource) Acquire(nodeName string, prefixLen int) (*SubnetLease, error) {
+	lease, err := s.client.Acquire(nodeIAID(nodeName), prefixLen)
+	if err != nil {
+		return nil, err
+	}
+	return subnetLeaseFromDHCPv6(lease), nil
+}
+
+func (s *dhcpv6SubnetSource) Renew(nodeName string, lease *SubnetLease) (*SubnetLease, error) {
+	renewed, err := s.client.Renew(dhcpv6Lease(nodeName, lease))
+	if err != nil {
+		return nil, err
+	}
+	return subnetLeaseFromDHCPv6(renewed), nil
+}
+
+func (s *dhcpv6SubnetSource) Release(nodeName string, lease *SubnetLease) error {
+	return s.client.Release(dhcpv6Lease(nodeName, lease))
+}
+
+// delegatedSubnetChangeFunc is called when the delegated subnet of a node
+// changes on renewal, with a nil subnet if the node lost it
+type delegatedSubnetChangeFunc func(nodeName string, oldSubnet, newSubnet *net.IPNet)
+
+// delegatedSubnetAllocator is a SubnetAllocator obtaining the IPv6 networks
+// from a SubnetSource. The delegated networks must belong to the IPv6 ranges
+// and are tracked by the wrapped allocator like the others, so that they
+// can't conflict with the networks allocated otherwise. IPv4 networks are
+// allocated from the ranges as usual.
+type delegatedSubnetAllocator struct {
+	SubnetAllocator
+
+	source SubnetSource
+	// prefixLen is the prefix length requested for the networks allocated
+	// without one, 0 to leave it to the source
+	prefixLen int
+	now       func() time.Time
+
+	sync.Mutex
+	// leases holds the lease of the delegated network of each owner
+	leases map[string]*SubnetLease
+}
+
+var _ SubnetAllocator = &delegatedSubnetAllocator{}
+
+func newDelegatedSubnetAllocator(allocator SubnetAllocator, source SubnetSource, prefixLen int) *delegatedSubnetAllocator {
+	return &delegatedSubnetAllocator{
+		SubnetAllocator: allocator,
+		source:          source,
+		prefixLen:       prefixLen,
+		now:             time.Now,
+		leases:          map[string]*SubnetLease{},
+	}
+}
+
+// SimulateAllocations can't tell how many IPv6 networks the source would
+// delegate
+func (dsa *delegatedSubnetAllocator) SimulateAllocations(ipv6 bool, prefixLen int, count uint64) (uint64, error) {
+	if ipv6 {
+		return 0, fmt.Errorf("the IPv6 networks are delegated, their capacity is unknown")
+	}
+	return dsa.SubnetAllocator.SimulateAllocations(ipv6, prefixLen, count)
+}
+
+// AllocateCompactedNetwork doesn't move the delegated networks, the source
+// picks them
+func (dsa *delegatedSubnetAllocator) AllocateCompactedNetwork(owner string, network *net.IPNet) (*net.IPNet, error) {
+	if utilnet.IsIPv6CIDR(network) {
+		return nil, nil
+	}
+	return dsa.SubnetAllocator.AllocateCompactedNetwork(owner, network)
+}
+
+func (dsa *delegatedSubnetAllocator) AllocateIPv6Network(owner string) (*net.IPNet, error) {
+	return dsa.allocateDelegated(owner, dsa.prefixLen)
+}
+
+func (dsa *delegatedSubnetAllocator) AllocateIPv6NetworkOfLength(owner string, prefixLen int) (*net.IPNet, error) {
+	return dsa.allocateDelegated(owner, prefixLen)
+}
+
+// AllocateIPv6NetworkAt ignores the index, the source picks the network
+func (dsa *delegatedSubnetAllocator) AllocateIPv6NetworkAt(owner string, _ uint64) (*net.IPNet, error) {
+	return dsa.allocateDelegated(owner, dsa.prefixLen)
+}
+
+// allocateDelegated obtains a network from the source and marks it allocated
+// in the ranges, giving it back to the source if that fails
+func (dsa *delegatedSubnetAllocator) allocateDelegated(owner string, prefixLen int) (*net.IPNet, error) {
+	lease, err := dsa.source.Acquire(owner, prefixLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain a delegated subnet: %w", err)
+	}
+	if err := dsa.SubnetAllocator.MarkAllocatedNetworks(owner, lease.Subnet); err != nil {
+		dsa.release(owner, lease)
+		return nil, fmt.Errorf("invalid delegated subnet %s: %w", lease.Subnet, err)
+	}
+	dsa.Lock()
+	defer dsa.Unlock()
+	if previous, ok := dsa.leases[owner]; ok && previous.Subnet.String() != lease.Subnet.String() {
+		klog.Warningf("Replacing the delegated subnet %s of %s with %s", previous.Subnet, owner, lease.Subnet)
+	}
+	dsa.leases[owner] = lease
+	klog.Infof("Obtained the delegated subnet %s for %s, to renew at %s", lease.Subnet, owner, lease.RenewAt)
+	return lease.Subnet, nil
+}
+
+// MarkAllocatedNetworks marks the networks allocated. The IPv6 networks of
+// the owners without a lease, like after a restart, are adopted and renewed
+// right away to find out whether they are still delegated.
+func (dsa *delegatedSubnetAllocator) MarkAllocatedNetworks(owner string, subnets ...*net.IPNet) error {
+	if err := dsa.SubnetAllocator.MarkAllocatedNetworks(owner, subnets...); err != nil {
+		return err
+	}
+	dsa.Lock()
+	defer dsa.Unlock()
+	for _, subnet := range subnets {
+		if !utilnet.IsIPv6CIDR(subnet) {
+			continue
+		}
+		if _, ok := dsa.leases[owner]; !ok {
+			dsa.leases[owner] = &SubnetLease{Subnet: subnet, RenewAt: dsa.now()}
+		}
+	}
+	return nil
+}
+
+// ReleaseNetworks releases the networks, giving the delegated ones back to
+// the source
+func (dsa *delegatedSubnetAllocator) ReleaseNetworks(owner string, subnets ...*net.IPNet) error {
+	for _, subnet := range subnets {
+		if lease := dsa.removeLease(owner, subnet); lease != nil {
+			dsa.release(owner, lease)
+		}
+	}
+	return dsa.SubnetAllocator.ReleaseNetworks(owner, subnets...)
+}
+
+// ReleaseAllNetworks releases all the networks of the owner, giving the
+// delegated one back to the source
+func (dsa *delegatedSubnetAllocator) ReleaseAllNetworks(owner string) {
+	if lease := dsa.removeLease(owner, nil); lease != nil {
+		dsa.release(owner, lease)
+	}
+	dsa.SubnetAllocator.ReleaseAllNetworks(owner)
+}
+
+// removeLease removes the lease of the owner, only if it is for the given
+// subnet when not nil, and returns it
+func (dsa *delegatedSubnetAllocator) removeLease(owner string, subnet *net.IPNet) *SubnetLease {
+	dsa.Lock()
+	defer dsa.Unlock()
+	lease, ok := dsa.leases[owner]
+	if !ok || subnet != nil && lease.Subnet.String() != subnet.String() {
+		return nil
+	}
+	delete(dsa.leases, owner)
+	return lease
+}
+
+func (dsa *delegatedSubnetAllocator) release(owner string, lease *SubnetLease) {
+	if err := dsa.source.Release(owner, lease); err != nil {
+		klog.Warningf("Failed to release the delegated subnet %s of %s: %v", lease.Subnet, owner, err)
+	}
+}
+
+// run renews the leases due for renewal until stopCh is closed
+func (dsa *delegatedSubnetAllocator) run(stopCh <-chan struct{}, wg *sync.WaitGroup, onChange delegatedSubnetChangeFunc) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			dsa.renew(onChange)
+		}, delegatedSubnetRenewalInterval, stopCh)
+	}()
+}
+
+// renew renews the leases due for renewal and calls onChange for the owners
+// whose delegated network changed or was lost. The leases that failed to be
+// renewed are retried until they expire.
+func (dsa *delegatedSubnetAllocator) renew(onChange delegatedSubnetChangeFunc) {
+	now := dsa.now()
+	dsa.Lock()
+	due := map[string]*SubnetLease{}
+	for owner, lease := range dsa.leases {
+		if !lease.RenewAt.After(now) {
+			due[owner] = lease
+		}
+	}
+	dsa.Unlock()
+
+	// the source is called without holding the lock to not block the
+	// allocations
+	for owner, lease := range due {
+		renewed, err := dsa.source.Renew(owner, lease)
+		if err != nil {
+			if lease.Expiry.IsZero() || lease.Expiry.After(now) {
+				klog.Warningf("Failed to renew the delegated subnet %s of %s, will retry: %v", lease.Subnet, owner, err)
+				continue
+			}
+			klog.Errorf("Delegated subnet %s of %s expired: %v", lease.Subnet, owner, err)
+		}
+		subnet, unused := dsa.replaceLease(owner, lease, renewed)
+		if unused != nil {
+			dsa.release(owner, unused)
+		}
+		if subnet == nil || subnet.String() != lease.Subnet.String() {
+			onChange(owner, lease.Subnet, subnet)
+		}
+	}
+}
+
+// replaceLease replaces the lease of the owner with the renewed one, nil if
+// it expired, unless it was released meanwhile. It returns the delegated
+// network of the owner, nil if it has none anymore, and the renewed lease if
+// it is not used and must be given back to the source.
+func (dsa *delegatedSubnetAllocator) replaceLease(owner string, lease, renewed *SubnetLease) (*net.IPNet, *SubnetLease) {
+	dsa.Lock()
+	defer dsa.Unlock()
+	if dsa.leases[owner] != lease {
+		if renewed != nil && renewed.Subnet.String() != lease.Subnet.String() {
+			return lease.Subnet, renewed
+		}
+		return lease.Subnet, nil
+	}
+	if renewed != nil && renewed.Subnet.String() == lease.Subnet.String() {
+		dsa.leases[owner] = renewed
+		return renewed.Subnet, nil
+	}
+	if err := dsa.SubnetAllocator.ReleaseNetworks(owner, lease.Subnet); err != nil {
+		klog.Warningf("Failed to release the delegated subnet %s of %s: %v", lease.Subnet, owner, err)
+	}
+	delete(dsa.leases, owner)
+	if renewed == nil {
+		return nil, nil
+	}
+	if err := dsa.SubnetAllocator.MarkAllocatedNetworks(owner, renewed.Subnet); err != nil {
+		klog.Errorf("Invalid delegated subnet %s renewed for %s: %v", renewed.Subnet, owner, err)
+		return nil, renewed
+	}
+	klog.Infof("Delegated subnet of %s changed from %s to %s", owner, lease.Subnet, renewed.Subnet)
+	dsa.leases[owner] = renewed
+	return renewed.Subnet, nil
+}
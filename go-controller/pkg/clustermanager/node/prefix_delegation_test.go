@@ -0,0 +1,157 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+)
+
+// fakeSubnetSource delegates the subnets of its pool, in order, and renews
+// the leases with the subnets set in renewals, if any
+type fakeSubnetSource struct {
+	pool     []string
+	renewals map[string]string
+	released []string
+	// renewErr fails the renewals
+	renewErr error
+}
+
+func (s *fakeSubnetSource) Acquire(nodeName string, _ int) (*SubnetLease, error) {
+	if len(s.pool) == 0 {
+		return nil, fmt.Errorf("no prefix available")
+	}
+	subnet := ovntest.MustParseIPNet(s.pool[0])
+	s.pool = s.pool[1:]
+	return &SubnetLease{Subnet: subnet, RenewAt: time.Now().Add(time.Hour)}, nil
+}
+
+func (s *fakeSubnetSource) Renew(nodeName string, lease *SubnetLease) (*SubnetLease, error) {
+	if s.renewErr != nil {
+		return nil, s.renewErr
+	}
+	subnet := lease.Subnet
+	if renewal, ok := s.renewals[nodeName]; ok {
+		subnet = ovntest.MustParseIPNet(renewal)
+	}
+	return &SubnetLease{Subnet: subnet, RenewAt: time.Now().Add(time.Hour)}, nil
+}
+
+func (s *fakeSubnetSource) Release(nodeName string, lease *SubnetLease) error {
+	s.released = append(s.released, lease.Subnet.String())
+	return nil
+}
+
+func newDelegatedSubnetTestAllocator(t *testing.T, source SubnetSource) *delegatedSubnetAllocator {
+	sna, err := newSubnetAllocator("10.1.0.0/16", 24)
+	if err != nil {
+		t.Fatalf("failed to create the subnet allocator: %v", err)
+	}
+	if err := sna.AddNetworkRange(ovntest.MustParseIPNet("fd00:10::/48"), 64); err != nil {
+		t.Fatalf("failed to add the IPv6 range: %v", err)
+	}
+	return newDelegatedSubnetAllocator(sna, source, 64)
+}
+
+func TestDelegatedSubnetAllocator(t *testing.T) {
+	source := &fakeSubnetSource{pool: []string{"fd00:10:0:5::/64", "fd99::/64", "fd00:10:0:6::/64"}}
+	dsa := newDelegatedSubnetTestAllocator(t, source)
+
+	// IPv4 subnets are allocated from the ranges
+	subnet, err := dsa.AllocateIPv4Network("node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet.String() != "10.1.0.0/24" {
+		t.Fatalf("expected the subnet 10.1.0.0/24, got %s", subnet)
+	}
+
+	subnet, err = dsa.AllocateIPv6Network("node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet.String() != "fd00:10:0:5::/64" {
+		t.Fatalf("expected the delegated subnet fd00:10:0:5::/64, got %s", subnet)
+	}
+	if _, v6used := dsa.Usage(); v6used != 1 {
+		t.Fatalf("expected the delegated subnet to be counted as used, got %d used", v6used)
+	}
+	// the delegated subnet can't be allocated otherwise
+	if err := dsa.SubnetAllocator.MarkAllocatedNetworks("node2", subnet); err == nil {
+		t.Fatal("expected the delegated subnet to be allocated")
+	}
+
+	// a delegated subnet outside of the ranges is given back
+	if _, err := dsa.AllocateIPv6Network("node2"); err == nil {
+		t.Fatal("expected an error for a delegated subnet outside of the ranges")
+	}
+	if len(source.released) != 1 || source.released[0] != "fd99::/64" {
+		t.Fatalf("expected the subnet fd99::/64 to be released, got %v", source.released)
+	}
+	if subnet, err = dsa.AllocateIPv6Network("node2"); err != nil || subnet.String() != "fd00:10:0:6::/64" {
+		t.Fatalf("expected the delegated subnet fd00:10:0:6::/64, got %v, %v", subnet, err)
+	}
+
+	// the delegated subnet is released to the source with the node
+	dsa.ReleaseAllNetworks("node2")
+	if len(source.released) != 2 || source.released[1] != "fd00:10:0:6::/64" {
+		t.Fatalf("expected the subnet fd00:10:0:6::/64 to be released, got %v", source.released)
+	}
+	if _, v6used := dsa.Usage(); v6used != 1 {
+		t.Fatalf("expected 1 IPv6 subnet used, got %d", v6used)
+	}
+}
+
+func TestDelegatedSubnetAllocatorRenew(t *testing.T) {
+	source := &fakeSubnetSource{renewals: map[string]string{"node2": "fd00:10:0:7::/64"}}
+	dsa := newDelegatedSubnetTestAllocator(t, source)
+	now := time.Now()
+	dsa.now = func() time.Time { return now }
+
+	// the subnets of the existing nodes are adopted and renewed right away
+	for node, subnet := range map[string]string{"node1": "fd00:10:0:5::/64", "node2": "fd00:10:0:6::/64", "node3": "fd00:10:0:8::/64"} {
+		if err := dsa.MarkAllocatedNetworks(node, ovntest.MustParseIPNet(subnet)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changes := map[string]string{}
+	onChange := func(nodeName string, oldSubnet, newSubnet *net.IPNet) {
+		changes[nodeName] = fmt.Sprintf("%s>%v", oldSubnet, newSubnet)
+	}
+	dsa.renew(onChange)
+	if len(changes) != 1 || changes["node2"] != "fd00:10:0:6::/64>fd00:10:0:7::/64" {
+		t.Fatalf("expected the subnet of node2 to change, got %v", changes)
+	}
+	if err := dsa.SubnetAllocator.MarkAllocatedNetworks("node4", ovntest.MustParseIPNet("fd00:10:0:6::/64")); err != nil {
+		t.Fatalf("expected the previous subnet of node2 to be released: %v", err)
+	}
+
+	// the renewed leases are not due
+	changes = map[string]string{}
+	dsa.renew(onChange)
+	if len(changes) != 0 {
+		t.Fatalf("expected no change, got %v", changes)
+	}
+
+	// failed renewals are retried until the lease expires
+	source.renewErr = fmt.Errorf("no reply")
+	dsa.leases["node1"].RenewAt = now
+	dsa.leases["node1"].Expiry = now.Add(time.Minute)
+	dsa.renew(onChange)
+	if len(changes) != 0 {
+		t.Fatalf("expected no change before the expiry, got %v", changes)
+	}
+	now = now.Add(2 * time.Minute)
+	dsa.renew(onChange)
+	if len(changes) != 1 || changes["node1"] != "fd00:10:0:5::/64><nil>" {
+		t.Fatalf("expected node1 to lose its subnet, got %v", changes)
+	}
+	if _, ok := dsa.leases["node1"]; ok {
+		t.Fatal("expected the lease of node1 to be removed")
+	}
+	if _, v6used := dsa.Usage(); v6used != 3 {
+		t.Fatalf("expected 3 IPv6 subnets used, got %d", v6used)
+	}
+}
@@ -1,8 +1,10 @@
 package node
 
 import (
+	"bytes"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -21,11 +23,114 @@ type SubnetAllocator interface {
 	AllocateNetworks(string) ([]*net.IPNet, error)
 	AllocateIPv4Network(string) (*net.IPNet, error)
 	AllocateIPv6Network(string) (*net.IPNet, error)
+	// AllocateIPv4NetworkOfLength and AllocateIPv6NetworkOfLength allocate a
+	// network of the given prefix length instead of the host subnet length of
+	// the ranges
+	AllocateIPv4NetworkOfLength(string, int) (*net.IPNet, error)
+	AllocateIPv6NetworkOfLength(string, int) (*net.IPNet, error)
+	// AllocateIPv4NetworkAt and AllocateIPv6NetworkAt allocate the network at
+	// the given index, modulo their number, of the networks of the ranges, or
+	// the next free one if it is allocated
+	AllocateIPv4NetworkAt(string, uint64) (*net.IPNet, error)
+	AllocateIPv6NetworkAt(string, uint64) (*net.IPNet, error)
+	// AllocateNetworkInRanges allocates a network of the given prefix length,
+	// 0 for the host subnet length of the ranges, only from the ranges of the
+	// given networks
+	AllocateNetworkInRanges(string, []*net.IPNet, int) (*net.IPNet, error)
 	// ReleaseNetworks releases the given networks if they are owned by the
 	// given owner
 	ReleaseNetworks(string, ...*net.IPNet) error
 	// ReleaseAllNetworks releases all networks owned by the given owner
 	ReleaseAllNetworks(string)
+	// ExcludeNetworks makes the given networks unavailable for allocation in
+	// the current ranges and in the ranges added later. The networks already
+	// allocated within them are kept until released.
+	ExcludeNetworks(...*net.IPNet)
+	// RangeUsage returns the usage of each range, in the order they were
+	// added
+	RangeUsage() []SubnetRangeUsage
+	// SimulateAllocations returns how many of the given number of networks
+	// of the IP family and prefix length, 0 for the host subnet length of the
+	// ranges, could be allocated. Nothing is allocated.
+	SimulateAllocations(ipv6 bool, prefixLen int, count uint64) (uint64, error)
+	// Fragmentation returns how the free networks of each range are split
+	// in blocks, in the order they were added
+	Fragmentation() []SubnetRangeFragmentation
+	// AllocateCompactedNetwork allocates to the owner the free network of
+	// the same range and length as the given one with the lowest address
+	// lower than its address, and returns nil if there is none. The given
+	// network stays allocated until released.
+	AllocateCompactedNetwork(string, *net.IPNet) (*net.IPNet, error)
+	// DrainNetworkRange makes the range of the given network unavailable for
+	// allocation. The networks allocated in it are kept until released, the
+	// range being dropped once they all are. Adding the range again makes it
+	// available again.
+	DrainNetworkRange(*net.IPNet) error
+	// AllocatedNetworks returns the allocated networks overlapping the given
+	// network, with their owner
+	AllocatedNetworks(*net.IPNet) []AllocatedNetwork
+}
+
+// AllocatedNetwork is a network allocated by a SubnetAllocator to its owner
+type AllocatedNetwork struct {
+	Network *net.IPNet
+	Owner   string
+}
+
+// SubnetRangeUsage is the usage of a range of a SubnetAllocator
+type SubnetRangeUsage struct {
+	Network          *net.IPNet
+	HostSubnetLength int
+	// Used is the number of allocated networks, whatever their length
+	Used uint64
+	// Count is the number of networks of the host subnet length
+	Count uint64
+}
+
+// SubnetRangeFragmentation is how the free networks of a range of a
+// SubnetAllocator are split in blocks: the free space is decomposed in the
+// largest aligned free networks, down to the host subnet length. A network of
+// a prefix length can only be allocated from a block of that prefix length or
+// shorter.
+type SubnetRangeFragmentation struct {
+	Network          *net.IPNet
+	HostSubnetLength int
+	// FreeBlocks is the number of free blocks of each prefix length
+	FreeBlocks map[int]uint64
+	// Free is the number of free networks of the host subnet length
+	Free uint64
+	// LargestFreeBlockLength is the prefix length of the largest free
+	// block, 0 if the range is full
+	LargestFreeBlockLength int
+}
+
+// blockSize returns the number of networks of the host subnet length of a
+// block of the given prefix length
+func (f *SubnetRangeFragmentation) blockSize(prefixLen int) uint64 {
+	bits := f.HostSubnetLength - prefixLen
+	if bits >= 64 {
+		bits = 63
+	}
+	return uint64(1) << bits
+}
+
+// LargestFreeBlock returns the number of networks of the host subnet length
+// of the largest free block
+func (f *SubnetRangeFragmentation) LargestFreeBlock() uint64 {
+	if f.LargestFreeBlockLength == 0 {
+		return 0
+	}
+	return f.blockSize(f.LargestFreeBlockLength)
+}
+
+// Ratio returns the share of the free networks that are not part of the
+// largest free block: 0 when all of them are contiguous or the range is full,
+// close to 1 when they are scattered in single networks
+func (f *SubnetRangeFragmentation) Ratio() float64 {
+	if f.Free == 0 {
+		return 0
+	}
+	return 1 - float64(f.LargestFreeBlock())/float64(f.Free)
 }
 
 type BaseSubnetAllocator struct {
@@ -33,6 +138,10 @@ type BaseSubnetAllocator struct {
 
 	v4ranges []*subnetAllocatorRange
 	v6ranges []*subnetAllocatorRange
+	excluded []*net.IPNet
+	// draining are the ranges no longer allocated from that still have
+	// allocated networks
+	draining []*subnetAllocatorRange
 }
 
 var _ SubnetAllocator = &BaseSubnetAllocator{}
@@ -43,6 +152,8 @@ func NewSubnetAllocator() SubnetAllocator {
 
 // Usage returns the number of used/allocated v4 and v6 subnets
 func (sna *BaseSubnetAllocator) Usage() (uint64, uint64) {
+	sna.Lock()
+	defer sna.Unlock()
 	var v4used, v6used uint64
 	for _, snr := range sna.v4ranges {
 		v4used = v4used + snr.usage()
@@ -50,11 +161,20 @@ func (sna *BaseSubnetAllocator) Usage() (uint64, uint64) {
 	for _, snr := range sna.v6ranges {
 		v6used = v6used + snr.usage()
 	}
+	for _, snr := range sna.draining {
+		if utilnet.IsIPv6CIDR(snr.network) {
+			v6used = v6used + snr.usage()
+		} else {
+			v4used = v4used + snr.usage()
+		}
+	}
 	return v4used, v6used
 }
 
 // Count returns the number of available (both used and unused) v4 and v6 subnets
 func (sna *BaseSubnetAllocator) Count() (uint64, uint64) {
+	sna.Lock()
+	defer sna.Unlock()
 	var v4count, v6count uint64
 	for _, snr := range sna.v4ranges {
 		v4count = v4count + snr.count()
@@ -65,23 +185,223 @@ func (sna *BaseSubnetAllocator) Count() (uint64, uint64) {
 	return v4count, v6count
 }
 
+// RangeUsage returns the usage of each range, IPv4 ranges first
+func (sna *BaseSubnetAllocator) RangeUsage() []SubnetRangeUsage {
+	sna.Lock()
+	defer sna.Unlock()
+	usage := make([]SubnetRangeUsage, 0, len(sna.v4ranges)+len(sna.v6ranges))
+	for _, ranges := range [][]*subnetAllocatorRange{sna.v4ranges, sna.v6ranges} {
+		for _, snr := range ranges {
+			_, addrLen := snr.network.Mask.Size()
+			usage = append(usage, SubnetRangeUsage{
+				Network:          snr.network,
+				HostSubnetLength: addrLen - int(snr.hostBits),
+				Used:             snr.usage(),
+				Count:            snr.count(),
+			})
+		}
+	}
+	return usage
+}
+
+// AllocatedNetworks returns the allocated networks overlapping the given
+// network, with their owner, sorted by network
+func (sna *BaseSubnetAllocator) AllocatedNetworks(network *net.IPNet) []AllocatedNetwork {
+	sna.Lock()
+	defer sna.Unlock()
+	allocated := []AllocatedNetwork{}
+	for _, ranges := range [][]*subnetAllocatorRange{sna.v4ranges, sna.v6ranges, sna.draining} {
+		for _, snr := range ranges {
+			if !networksOverlap(snr.network, network) {
+				continue
+			}
+			for allocatedNetwork, owner := range snr.allocMap {
+				_, n, err := net.ParseCIDR(allocatedNetwork)
+				if err != nil || !networksOverlap(n, network) {
+					continue
+				}
+				allocated = append(allocated, AllocatedNetwork{Network: n, Owner: owner})
+			}
+		}
+	}
+	sort.Slice(allocated, func(i, j int) bool {
+		return allocated[i].Network.String() < allocated[j].Network.String()
+	})
+	return allocated
+}
+
+// networksOverlap returns whether one of the networks contains the other
+func networksOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// SimulateAllocations allocates the networks from copies of the ranges,
+// taken under the lock, so that the simulation follows the allocation
+// order, skipping the excluded networks and the networks overlapping
+// networks of other lengths.
+func (sna *BaseSubnetAllocator) SimulateAllocations(ipv6 bool, prefixLen int, count uint64) (uint64, error) {
+	sna.Lock()
+	ranges := sna.v4ranges
+	if ipv6 {
+		ranges = sna.v6ranges
+	}
+	simulated := make([]*subnetAllocatorRange, 0, len(ranges))
+	for _, snr := range ranges {
+		simulated = append(simulated, snr.clone())
+	}
+	sna.Unlock()
+
+	var allocated uint64
+	for allocated < count {
+		var sn *net.IPNet
+		if prefixLen == 0 {
+			for _, snr := range simulated {
+				if sn = snr.allocateNetwork(""); sn != nil {
+					break
+				}
+			}
+		} else {
+			var err error
+			sn, err = allocateNetworkOfLength(simulated, "", prefixLen)
+			if err != nil && err != ErrSubnetAllocatorFull {
+				return 0, err
+			}
+		}
+		if sn == nil {
+			break
+		}
+		allocated++
+	}
+	return allocated, nil
+}
+
+// Fragmentation analyzes copies of the ranges, taken under the lock, IPv4
+// ranges first
+func (sna *BaseSubnetAllocator) Fragmentation() []SubnetRangeFragmentation {
+	sna.Lock()
+	ranges := make([]*subnetAllocatorRange, 0, len(sna.v4ranges)+len(sna.v6ranges))
+	for _, snr := range append(append([]*subnetAllocatorRange{}, sna.v4ranges...), sna.v6ranges...) {
+		ranges = append(ranges, snr.clone())
+	}
+	sna.Unlock()
+
+	fragmentation := make([]SubnetRangeFragmentation, 0, len(ranges))
+	for _, snr := range ranges {
+		fragmentation = append(fragmentation, snr.fragmentation())
+	}
+	return fragmentation
+}
+
+// AllocateCompactedNetwork allocates the free network of the same length as
+// network with the lowest address lower than its address, in the range of
+// network
+func (sna *BaseSubnetAllocator) AllocateCompactedNetwork(owner string, network *net.IPNet) (*net.IPNet, error) {
+	sna.Lock()
+	defer sna.Unlock()
+
+	ranges := sna.v4ranges
+	if utilnet.IsIPv6CIDR(network) {
+		ranges = sna.v6ranges
+	}
+	for _, snr := range ranges {
+		if !snr.network.Contains(network.IP) {
+			continue
+		}
+		if owned := snr.allocMap[network.String()]; owned != owner {
+			return nil, fmt.Errorf("network %s is not owned by %s", network, owner)
+		}
+		return snr.allocateCompactedNetwork(owner, network), nil
+	}
+	return nil, fmt.Errorf("network %s is not part of any range", network)
+}
+
 // AddNetworkRange makes the given range available for allocation and returns
 // nil, or an error on failure.
 func (sna *BaseSubnetAllocator) AddNetworkRange(network *net.IPNet, hostSubnetLen int) error {
 	sna.Lock()
 	defer sna.Unlock()
 
+	for i, snr := range sna.draining {
+		if snr.network.String() != network.String() {
+			continue
+		}
+		_, addrLen := snr.network.Mask.Size()
+		if addrLen-int(snr.hostBits) != hostSubnetLen {
+			return fmt.Errorf("network %s is being drained with a different host subnet length", network)
+		}
+		sna.draining = append(sna.draining[:i], sna.draining[i+1:]...)
+		sna.addRange(snr)
+		return nil
+	}
+
 	snr, err := newSubnetAllocatorRange(network, hostSubnetLen)
 	if err != nil {
 		return err
 	}
+	for _, excluded := range sna.excluded {
+		snr.exclude(excluded)
+	}
+	sna.addRange(snr)
+	return nil
+}
 
+func (sna *BaseSubnetAllocator) addRange(snr *subnetAllocatorRange) {
 	if utilnet.IsIPv6(snr.network.IP) {
 		sna.v6ranges = append(sna.v6ranges, snr)
 	} else {
 		sna.v4ranges = append(sna.v4ranges, snr)
 	}
-	return nil
+}
+
+// DrainNetworkRange moves the range of the given network to the draining
+// ranges, or drops it right away if it has no allocated network
+func (sna *BaseSubnetAllocator) DrainNetworkRange(network *net.IPNet) error {
+	sna.Lock()
+	defer sna.Unlock()
+
+	ranges := &sna.v4ranges
+	if utilnet.IsIPv6CIDR(network) {
+		ranges = &sna.v6ranges
+	}
+	for i, snr := range *ranges {
+		if snr.network.String() != network.String() {
+			continue
+		}
+		*ranges = append((*ranges)[:i], (*ranges)[i+1:]...)
+		if snr.usage() > 0 {
+			sna.draining = append(sna.draining, snr)
+		}
+		return nil
+	}
+	return fmt.Errorf("network %s is not a range", network)
+}
+
+// dropDrainedRanges drops the draining ranges without allocated networks
+func (sna *BaseSubnetAllocator) dropDrainedRanges() {
+	n := 0
+	for _, snr := range sna.draining {
+		if snr.usage() > 0 {
+			sna.draining[n] = snr
+			n++
+		}
+	}
+	sna.draining = sna.draining[:n]
+}
+
+// ExcludeNetworks makes the given networks unavailable for allocation
+func (sna *BaseSubnetAllocator) ExcludeNetworks(networks ...*net.IPNet) {
+	sna.Lock()
+	defer sna.Unlock()
+
+	sna.excluded = append(sna.excluded, networks...)
+	for _, network := range networks {
+		for _, snr := range sna.v4ranges {
+			snr.exclude(network)
+		}
+		for _, snr := range sna.v6ranges {
+			snr.exclude(network)
+		}
+	}
 }
 
 // MarkAllocatedNetworks will mark the given subnets as already allocated by
@@ -184,6 +504,124 @@ func (sna *BaseSubnetAllocator) AllocateIPv6Network(owner string) (*net.IPNet, e
 	return nil, ErrSubnetAllocatorFull
 }
 
+// AllocateIPv4NetworkOfLength tries to allocate an IPv4 network of the given
+// prefix length if there are ranges available
+func (sna *BaseSubnetAllocator) AllocateIPv4NetworkOfLength(owner string, prefixLen int) (*net.IPNet, error) {
+	sna.Lock()
+	defer sna.Unlock()
+	return allocateNetworkOfLength(sna.v4ranges, owner, prefixLen)
+}
+
+// AllocateIPv6NetworkOfLength tries to allocate an IPv6 network of the given
+// prefix length if there are ranges available
+func (sna *BaseSubnetAllocator) AllocateIPv6NetworkOfLength(owner string, prefixLen int) (*net.IPNet, error) {
+	sna.Lock()
+	defer sna.Unlock()
+	return allocateNetworkOfLength(sna.v6ranges, owner, prefixLen)
+}
+
+func allocateNetworkOfLength(ranges []*subnetAllocatorRange, owner string, prefixLen int) (*net.IPNet, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	fits := false
+	for _, snr := range ranges {
+		clusterCIDRLen, addrLen := snr.network.Mask.Size()
+		if prefixLen < clusterCIDRLen || prefixLen >= addrLen {
+			continue
+		}
+		fits = true
+		var sn *net.IPNet
+		if prefixLen == addrLen-int(snr.hostBits) {
+			sn = snr.allocateNetwork(owner)
+		} else {
+			sn = snr.allocateNetworkOfLength(owner, prefixLen)
+		}
+		if sn != nil {
+			return sn, nil
+		}
+	}
+	if !fits {
+		return nil, fmt.Errorf("no range can hold a network with prefix length %d", prefixLen)
+	}
+	return nil, ErrSubnetAllocatorFull
+}
+
+// AllocateIPv4NetworkAt tries to allocate the IPv4 network at the given index
+// if there are ranges available
+func (sna *BaseSubnetAllocator) AllocateIPv4NetworkAt(owner string, index uint64) (*net.IPNet, error) {
+	sna.Lock()
+	defer sna.Unlock()
+	return allocateNetworkAt(sna.v4ranges, owner, index)
+}
+
+// AllocateIPv6NetworkAt tries to allocate the IPv6 network at the given index
+// if there are ranges available
+func (sna *BaseSubnetAllocator) AllocateIPv6NetworkAt(owner string, index uint64) (*net.IPNet, error) {
+	sna.Lock()
+	defer sna.Unlock()
+	return allocateNetworkAt(sna.v6ranges, owner, index)
+}
+
+// AllocateNetworkInRanges tries to allocate a network of the given prefix
+// length, or of the host subnet length if 0, from the ranges whose network is
+// one of the given networks
+func (sna *BaseSubnetAllocator) AllocateNetworkInRanges(owner string, networks []*net.IPNet, prefixLen int) (*net.IPNet, error) {
+	sna.Lock()
+	defer sna.Unlock()
+
+	wanted := make(map[string]bool, len(networks))
+	for _, network := range networks {
+		wanted[network.String()] = true
+	}
+	var ranges []*subnetAllocatorRange
+	for _, snr := range append(append([]*subnetAllocatorRange{}, sna.v4ranges...), sna.v6ranges...) {
+		if wanted[snr.network.String()] {
+			ranges = append(ranges, snr)
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	if prefixLen > 0 {
+		return allocateNetworkOfLength(ranges, owner, prefixLen)
+	}
+	for _, snr := range ranges {
+		if sn := snr.allocateNetwork(owner); sn != nil {
+			return sn, nil
+		}
+	}
+	return nil, ErrSubnetAllocatorFull
+}
+
+// allocateNetworkAt allocates the network at the given index, modulo their
+// number, of the networks of the ranges in order, or the next free one
+func allocateNetworkAt(ranges []*subnetAllocatorRange, owner string, index uint64) (*net.IPNet, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	var total uint64
+	for _, snr := range ranges {
+		total += uint64(snr.numSubnets())
+	}
+	index %= total
+	first := 0
+	for index >= uint64(ranges[first].numSubnets()) {
+		index -= uint64(ranges[first].numSubnets())
+		first++
+	}
+	for i := range ranges {
+		var start uint32
+		if i == 0 {
+			start = uint32(index)
+		}
+		if sn, _ := ranges[(first+i)%len(ranges)].allocateNetworkFrom(owner, start); sn != nil {
+			return sn, nil
+		}
+	}
+	return nil, ErrSubnetAllocatorFull
+}
+
 func (sna *BaseSubnetAllocator) ReleaseNetworks(owner string, subnets ...*net.IPNet) error {
 	sna.Lock()
 	defer sna.Unlock()
@@ -225,10 +663,23 @@ func (sna *BaseSubnetAllocator) releaseNetworks(owner string, subnets ...*net.IP
 				}
 			}
 		}
+		if !released && !errHandled {
+			for _, snr := range sna.draining {
+				ok, err = snr.releaseNetwork(owner, subnet)
+				if ok {
+					released = true
+					break
+				} else if err != nil {
+					errHandled = true
+					errorList = append(errorList, err)
+				}
+			}
+		}
 		if !released && !errHandled {
 			errorList = append(errorList, fmt.Errorf("network %s does not belong to any known range", subnet.String()))
 		}
 	}
+	sna.dropDrainedRanges()
 
 	return utilerrors.NewAggregate(errorList)
 }
@@ -243,6 +694,10 @@ func (sna *BaseSubnetAllocator) releaseAllNetworks(owner string) {
 	for _, snr := range sna.v6ranges {
 		snr.releaseAllNetworks(owner)
 	}
+	for _, snr := range sna.draining {
+		snr.releaseAllNetworks(owner)
+	}
+	sna.dropDrainedRanges()
 }
 
 // subnetAllocatorRange handles allocating subnets out of a single CIDR
@@ -253,6 +708,12 @@ type subnetAllocatorRange struct {
 	next       uint32
 	allocMap   map[string]string
 	used       uint32
+	// nested counts, for each network enclosing an allocated network, the
+	// number of allocated networks it contains, so that networks of different
+	// prefix lengths are not allocated over each other
+	nested map[string]int
+	// excluded are the networks of the range that are never allocated
+	excluded []*net.IPNet
 
 	// IPv4-only address-alignment hackery; see below
 	leftShift  uint32
@@ -277,6 +738,7 @@ func newSubnetAllocatorRange(network *net.IPNet, hostSubnetLen int) (*subnetAllo
 		subnetBits: subnetBits,
 		next:       0,
 		allocMap:   make(map[string]string),
+		nested:     make(map[string]int),
 	}
 
 	// In the simple case, the subnet part of the 32-bit IP address is just the subnet
@@ -310,6 +772,20 @@ func newSubnetAllocatorRange(network *net.IPNet, hostSubnetLen int) (*subnetAllo
 	return snr, nil
 }
 
+// clone returns a copy of the range whose allocations don't affect the range
+func (snr *subnetAllocatorRange) clone() *subnetAllocatorRange {
+	c := *snr
+	c.allocMap = make(map[string]string, len(snr.allocMap))
+	for network, owner := range snr.allocMap {
+		c.allocMap[network] = owner
+	}
+	c.nested = make(map[string]int, len(snr.nested))
+	for network, n := range snr.nested {
+		c.nested[network] = n
+	}
+	return &c
+}
+
 // usage returns the number of used/allocated subnets
 func (snr *subnetAllocatorRange) usage() uint64 {
 	return uint64(snr.used)
@@ -336,6 +812,93 @@ func IsAlreadyOwnedError(err error) bool {
 	return ok
 }
 
+type overlapsAllocatedError struct {
+	network string
+}
+
+func (e overlapsAllocatedError) Error() string {
+	return fmt.Sprintf("network %s overlaps an already allocated network", e.network)
+}
+
+// IsAlreadyAllocatedError returns true if the error indicates the network, or
+// part of it, was already allocated to another owner
+func IsAlreadyAllocatedError(err error) bool {
+	switch err.(type) {
+	case alreadyOwnedError, overlapsAllocatedError:
+		return true
+	}
+	return false
+}
+
+// exclude makes the part of network within the range unavailable for
+// allocation
+func (snr *subnetAllocatorRange) exclude(network *net.IPNet) {
+	if !snr.network.Contains(network.IP) {
+		if !network.Contains(snr.network.IP) {
+			return
+		}
+		network = snr.network
+	}
+	snr.excluded = append(snr.excluded, network)
+}
+
+// excludedOverlapping returns the excluded network overlapping network, if any
+func (snr *subnetAllocatorRange) excludedOverlapping(network *net.IPNet) *net.IPNet {
+	for _, excluded := range snr.excluded {
+		if excluded.Contains(network.IP) || network.Contains(excluded.IP) {
+			return excluded
+		}
+	}
+	return nil
+}
+
+// isFree returns whether neither network, nor a network enclosing it or
+// enclosed by it, is allocated or excluded
+func (snr *subnetAllocatorRange) isFree(network *net.IPNet) bool {
+	if snr.excludedOverlapping(network) != nil {
+		return false
+	}
+	clusterCIDRLen, addrLen := snr.network.Mask.Size()
+	prefixLen, _ := network.Mask.Size()
+	for l := clusterCIDRLen; l <= prefixLen; l++ {
+		mask := net.CIDRMask(l, addrLen)
+		enclosing := &net.IPNet{IP: network.IP.Mask(mask), Mask: mask}
+		if _, ok := snr.allocMap[enclosing.String()]; ok {
+			return false
+		}
+	}
+	return snr.nested[network.String()] == 0
+}
+
+// updateNested adds delta to the nested count of the networks enclosing
+// network
+func (snr *subnetAllocatorRange) updateNested(network *net.IPNet, delta int) {
+	clusterCIDRLen, addrLen := snr.network.Mask.Size()
+	prefixLen, _ := network.Mask.Size()
+	for l := clusterCIDRLen; l < prefixLen; l++ {
+		mask := net.CIDRMask(l, addrLen)
+		enclosing := (&net.IPNet{IP: network.IP.Mask(mask), Mask: mask}).String()
+		snr.nested[enclosing] += delta
+		if snr.nested[enclosing] <= 0 {
+			delete(snr.nested, enclosing)
+		}
+	}
+}
+
+// allocate marks network, which must be free, as owned by owner
+func (snr *subnetAllocatorRange) allocate(owner string, network *net.IPNet) {
+	snr.allocMap[network.String()] = owner
+	snr.updateNested(network, 1)
+	snr.used++
+}
+
+// release marks network, which must be allocated, as not in use
+func (snr *subnetAllocatorRange) release(network *net.IPNet) {
+	delete(snr.allocMap, network.String())
+	snr.updateNested(network, -1)
+	snr.used--
+}
+
 // markAllocatedNetwork marks network as being in use, if it is part of snr's range.
 // It returns whether the network was in snr's range, and returns an error if
 // network was already allocated to a different owner.
@@ -347,8 +910,13 @@ func (snr *subnetAllocatorRange) markAllocatedNetwork(owner string, network *net
 
 	existingOwner, ok := snr.allocMap[str]
 	if !ok {
-		snr.allocMap[str] = owner
-		snr.used++
+		if excluded := snr.excludedOverlapping(network); excluded != nil {
+			return false, fmt.Errorf("network %s overlaps the excluded network %s", str, excluded)
+		}
+		if !snr.isFree(network) {
+			return false, overlapsAllocatedError{str}
+		}
+		snr.allocate(owner, network)
 		return true, nil
 	} else if existingOwner == owner {
 		return true, nil
@@ -357,22 +925,41 @@ func (snr *subnetAllocatorRange) markAllocatedNetwork(owner string, network *net
 	return false, alreadyOwnedError{str, existingOwner}
 }
 
+// numSubnets returns the number of subnets the range allocates from
+func (snr *subnetAllocatorRange) numSubnets() uint32 {
+	if snr.subnetBits > 24 {
+		// We need to make sure that the uint32 math of allocateNetworkFrom
+		// won't overflow. If snr.subnetBits > 32 then the number of subnets
+		// overflows, but also if it is between 1<<24 and 1<<32 then
+		// "base << (snr.hostBits % 8)" could overflow if snr.hostBits%8 is
+		// non-0. So we cap it at 1<<24. "16M subnets ought to be enough for
+		// anybody."
+		return 1 << 24
+	}
+	return uint32(1) << snr.subnetBits
+}
+
 // allocateNetwork returns a new subnet, or nil if the range is full
 func (snr *subnetAllocatorRange) allocateNetwork(owner string) *net.IPNet {
-	netMaskSize, addrLen := snr.network.Mask.Size()
-	numSubnets := uint32(1) << snr.subnetBits
-	if snr.subnetBits > 24 {
-		// We need to make sure that the uint32 math below won't overflow. If
-		// snr.subnetBits > 32 then numSubnets has already overflowed, but also if
-		// numSubnets is between 1<<24 and 1<<32 then "base << (snr.hostBits % 8)"
-		// below could overflow if snr.hostBits%8 is non-0. So we cap numSubnets
-		// at 1<<24. "16M subnets ought to be enough for anybody."
-		numSubnets = 1 << 24
+	sn, n := snr.allocateNetworkFrom(owner, snr.next)
+	if sn == nil {
+		snr.next = 0
+		return nil
 	}
+	snr.next = n + 1
+	return sn
+}
+
+// allocateNetworkFrom returns the first free subnet starting at the given
+// index of the subnets of the range, wrapping around, and its index, or nil if
+// the range is full
+func (snr *subnetAllocatorRange) allocateNetworkFrom(owner string, start uint32) (*net.IPNet, uint32) {
+	netMaskSize, addrLen := snr.network.Mask.Size()
+	numSubnets := snr.numSubnets()
 
 	var i uint32
 	for i = 0; i < numSubnets; i++ {
-		n := (i + snr.next) % numSubnets
+		n := (i + start) % numSubnets
 		base := n
 		if snr.leftShift != 0 {
 			base = ((base << snr.leftShift) & snr.leftMask) | ((base >> snr.rightShift) & snr.rightMask)
@@ -395,18 +982,137 @@ func (snr *subnetAllocatorRange) allocateNetwork(owner string) *net.IPNet {
 		}
 
 		genSubnet := &net.IPNet{IP: genIP, Mask: net.CIDRMask(int(snr.subnetBits)+netMaskSize, addrLen)}
-		if _, ok := snr.allocMap[genSubnet.String()]; !ok {
-			snr.allocMap[genSubnet.String()] = owner
-			snr.next = n + 1
-			snr.used++
+		if snr.isFree(genSubnet) {
+			snr.allocate(owner, genSubnet)
+			return genSubnet, n
+		}
+	}
+
+	return nil, 0
+}
+
+// allocateNetworkOfLength returns a new subnet of the given prefix length,
+// which must be within the range, or nil if no such subnet is free
+func (snr *subnetAllocatorRange) allocateNetworkOfLength(owner string, prefixLen int) *net.IPNet {
+	netMaskSize, _ := snr.network.Mask.Size()
+	subnetBits := uint32(prefixLen - netMaskSize)
+	numSubnets := uint32(1) << subnetBits
+	if subnetBits > 24 {
+		// see numSubnets
+		numSubnets = 1 << 24
+	}
+
+	var n uint32
+	for n = 0; n < numSubnets; n++ {
+		genSubnet := snr.subnetOfLength(prefixLen, n)
+		if genSubnet != nil && snr.isFree(genSubnet) {
+			snr.allocate(owner, genSubnet)
 			return genSubnet
 		}
 	}
+	return nil
+}
+
+// subnetOfLength returns the n-th subnet of the given prefix length of the
+// range, in address order, or nil if it is one of the skipped subnets
+func (snr *subnetAllocatorRange) subnetOfLength(prefixLen int, n uint32) *net.IPNet {
+	netMaskSize, addrLen := snr.network.Mask.Size()
+	hostBits := uint32(addrLen - prefixLen)
+	subnetBits := uint32(prefixLen - netMaskSize)
+	if addrLen == 128 && subnetBits >= 16 && (n&0xFFFF) == 0 {
+		// see allocateNetworkFrom
+		return nil
+	}
+	genIP := append([]byte{}, []byte(snr.network.IP)...)
+	bits := n << (hostBits % 8)
+	b := (uint32(addrLen) - hostBits - 1) / 8
+	for bits != 0 {
+		genIP[b] |= byte(bits)
+		bits >>= 8
+		b--
+	}
+	return &net.IPNet{IP: genIP, Mask: net.CIDRMask(prefixLen, addrLen)}
+}
 
-	snr.next = 0
+// allocateCompactedNetwork returns the free subnet of the length of network,
+// which must be allocated, with the lowest address lower than its address, or
+// nil if there is none. Moving the networks to the lowest addresses
+// gathers the free networks in large blocks at the end of the range.
+func (snr *subnetAllocatorRange) allocateCompactedNetwork(owner string, network *net.IPNet) *net.IPNet {
+	netMaskSize, _ := snr.network.Mask.Size()
+	prefixLen, _ := network.Mask.Size()
+	numSubnets := uint32(1) << 24
+	if subnetBits := uint32(prefixLen - netMaskSize); subnetBits <= 24 {
+		numSubnets = uint32(1) << subnetBits
+	}
+
+	var n uint32
+	for n = 0; n < numSubnets; n++ {
+		genSubnet := snr.subnetOfLength(prefixLen, n)
+		if genSubnet == nil {
+			continue
+		}
+		if bytes.Compare(genSubnet.IP.To16(), network.IP.To16()) >= 0 {
+			return nil
+		}
+		if snr.isFree(genSubnet) {
+			snr.allocate(owner, genSubnet)
+			return genSubnet
+		}
+	}
 	return nil
 }
 
+// fragmentation decomposes the free space of the range in the largest
+// aligned free networks
+func (snr *subnetAllocatorRange) fragmentation() SubnetRangeFragmentation {
+	_, addrLen := snr.network.Mask.Size()
+	f := SubnetRangeFragmentation{
+		Network:          snr.network,
+		HostSubnetLength: addrLen - int(snr.hostBits),
+		FreeBlocks:       map[int]uint64{},
+	}
+	snr.addFreeBlocks(snr.network, &f)
+	for prefixLen, blocks := range f.FreeBlocks {
+		f.Free += blocks * f.blockSize(prefixLen)
+		if f.LargestFreeBlockLength == 0 || prefixLen < f.LargestFreeBlockLength {
+			f.LargestFreeBlockLength = prefixLen
+		}
+	}
+	return f
+}
+
+// addFreeBlocks counts network as a free block if it is free, or else the
+// free blocks of its halves, down to the host subnet length
+func (snr *subnetAllocatorRange) addFreeBlocks(network *net.IPNet, f *SubnetRangeFragmentation) {
+	prefixLen, addrLen := network.Mask.Size()
+	if snr.isFree(network) {
+		f.FreeBlocks[prefixLen]++
+		return
+	}
+	if prefixLen >= f.HostSubnetLength {
+		return
+	}
+	if _, ok := snr.allocMap[network.String()]; ok {
+		return
+	}
+	for _, excluded := range snr.excluded {
+		if excludedLen, _ := excluded.Mask.Size(); excludedLen <= prefixLen && excluded.Contains(network.IP) {
+			return
+		}
+	}
+	ip := network.IP
+	if addrLen == 32 {
+		ip = ip.To4()
+	}
+	mask := net.CIDRMask(prefixLen+1, addrLen)
+	low := &net.IPNet{IP: ip, Mask: mask}
+	highIP := append(net.IP{}, ip...)
+	highIP[prefixLen/8] |= 0x80 >> (prefixLen % 8)
+	snr.addFreeBlocks(low, f)
+	snr.addFreeBlocks(&net.IPNet{IP: highIP, Mask: mask}, f)
+}
+
 // releaseNetwork marks network as being not in use, if it is part of snr's range.
 // It returns whether the network was in snr's range.
 func (snr *subnetAllocatorRange) releaseNetwork(owner string, network *net.IPNet) (bool, error) {
@@ -419,8 +1125,7 @@ func (snr *subnetAllocatorRange) releaseNetwork(owner string, network *net.IPNet
 	if !ok {
 		return false, nil
 	} else if existingOwner == owner {
-		delete(snr.allocMap, str)
-		snr.used--
+		snr.release(network)
 		return true, nil
 	}
 
@@ -431,8 +1136,11 @@ func (snr *subnetAllocatorRange) releaseNetwork(owner string, network *net.IPNet
 func (snr *subnetAllocatorRange) releaseAllNetworks(owner string) {
 	for network, existingOwner := range snr.allocMap {
 		if existingOwner == owner {
-			delete(snr.allocMap, network)
-			snr.used--
+			_, ipNet, err := net.ParseCIDR(network)
+			if err != nil {
+				continue
+			}
+			snr.release(ipNet)
 		}
 	}
 }
@@ -487,6 +487,66 @@ func TestAllocateReleaseSubnet(t *testing.T) {
 	}
 }
 
+func TestAllocateSubnetOfLength(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/16", 18)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+
+	// a larger network takes the room of two networks of the range
+	sn, err := sna.AllocateIPv4NetworkOfLength(testNodeName, 17)
+	if err != nil {
+		t.Fatal("Failed to allocate network: ", err)
+	}
+	if sn.String() != "10.1.0.0/17" {
+		t.Fatalf("Did not get expected subnet (sn=%s)", sn.String())
+	}
+	if err := allocateExpected(sna, 0, "10.1.128.0/18"); err != nil {
+		t.Fatal(err)
+	}
+
+	// smaller networks fill the room left
+	for i := 0; i < 4; i++ {
+		sn, err = sna.AllocateIPv4NetworkOfLength(testNodeName, 20)
+		if err != nil {
+			t.Fatal("Failed to allocate network: ", err)
+		}
+		if sn.String() != fmt.Sprintf("10.1.%d.0/20", 192+i*16) {
+			t.Fatalf("Did not get expected subnet (i=%d, sn=%s)", i, sn.String())
+		}
+	}
+	if sn, err = sna.AllocateIPv4NetworkOfLength(testNodeName, 20); err != ErrSubnetAllocatorFull {
+		t.Fatalf("Expected the allocator to be full, got sn=%v, err=%v", sn, err)
+	}
+	if err := allocateNotExpected(sna, 6, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// networks overlapping allocated networks can't be marked
+	if err := sna.MarkAllocatedNetworks("thief", ovntest.MustParseIPNet("10.1.0.0/18")); err == nil {
+		t.Fatal("Unexpectedly succeeded in marking a network enclosed by an allocated network")
+	}
+	if err := sna.MarkAllocatedNetworks("thief", ovntest.MustParseIPNet("10.1.192.0/18")); err == nil {
+		t.Fatal("Unexpectedly succeeded in marking a network enclosing allocated networks")
+	}
+
+	// released room can be allocated with the length of the range
+	if err := sna.ReleaseNetworks(testNodeName, ovntest.MustParseIPNet("10.1.0.0/17")); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateExpected(sna, -1, "10.1.0.0/18"); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateExpected(sna, -1, "10.1.64.0/18"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the network must fit in a range
+	if _, err := sna.AllocateIPv4NetworkOfLength(testNodeName, 15); err == nil || err == ErrSubnetAllocatorFull {
+		t.Fatalf("Expected an error allocating a network larger than the range, got %v", err)
+	}
+}
+
 func TestMultipleSubnets(t *testing.T) {
 	sna, err := newSubnetAllocator("10.1.0.0/16", 18)
 	if err != nil {
@@ -589,3 +649,320 @@ func TestDualStack(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestExcludeNetworks(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/22", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	if err := sna.MarkAllocatedNetworks("legacy", ovntest.MustParseIPNet("10.1.2.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	// a network smaller than a host subnet excludes the whole host subnet
+	sna.ExcludeNetworks(ovntest.MustParseIPNet("10.1.1.128/25"), ovntest.MustParseIPNet("10.2.0.0/22"))
+
+	if err := allocateExpected(sna, 0, "10.1.0.0/24"); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateExpected(sna, 1, "10.1.3.0/24"); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateNotExpected(sna, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sna.MarkAllocatedNetworks("thief", ovntest.MustParseIPNet("10.1.1.0/24")); err == nil {
+		t.Fatal("Unexpectedly succeeded in marking a network overlapping an excluded network")
+	}
+
+	// the networks already allocated are kept until released
+	if err := sna.ReleaseNetworks("legacy", ovntest.MustParseIPNet("10.1.2.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateExpected(sna, 2, "10.1.2.0/24"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the ranges added later honor the exclusions
+	if err := sna.AddNetworkRange(ovntest.MustParseIPNet("10.2.0.0/16"), 24); err != nil {
+		t.Fatal("Failed to add network range: ", err)
+	}
+	if err := allocateExpected(sna, 3, "10.2.4.0/24"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllocateSubnetAt(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/23", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	err = sna.AddNetworkRange(ovntest.MustParseIPNet("10.2.0.0/23"), 24)
+	if err != nil {
+		t.Fatal("Failed to add network range: ", err)
+	}
+
+	// the index spans the ranges in order, modulo the number of networks
+	sn, err := sna.AllocateIPv4NetworkAt(testNodeName, 6)
+	if err != nil {
+		t.Fatal("Failed to allocate network: ", err)
+	}
+	if sn.String() != "10.2.0.0/24" {
+		t.Fatalf("Did not get expected subnet (sn=%s)", sn.String())
+	}
+
+	// the next free network is allocated when the one at the index is not
+	for _, expected := range []string{"10.2.1.0/24", "10.1.0.0/24", "10.1.1.0/24"} {
+		sn, err = sna.AllocateIPv4NetworkAt(testNodeName, 2)
+		if err != nil {
+			t.Fatal("Failed to allocate network: ", err)
+		}
+		if sn.String() != expected {
+			t.Fatalf("Did not get expected subnet %s (sn=%s)", expected, sn.String())
+		}
+	}
+	if sn, err = sna.AllocateIPv4NetworkAt(testNodeName, 2); err != ErrSubnetAllocatorFull {
+		t.Fatalf("Expected the allocator to be full, got sn=%v, err=%v", sn, err)
+	}
+
+	// no range of the IP family
+	if sn, err = sna.AllocateIPv6NetworkAt(testNodeName, 2); sn != nil || err != nil {
+		t.Fatalf("Expected no IPv6 network, got sn=%v, err=%v", sn, err)
+	}
+}
+
+func TestSimulateAllocations(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/22", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	if err := sna.AddNetworkRange(ovntest.MustParseIPNet("fd00::/62"), 64); err != nil {
+		t.Fatal("Failed to add network range: ", err)
+	}
+	if err := sna.MarkAllocatedNetworks("legacy", ovntest.MustParseIPNet("10.1.2.0/25")); err != nil {
+		t.Fatal(err)
+	}
+	sna.ExcludeNetworks(ovntest.MustParseIPNet("10.1.3.0/24"))
+
+	for _, tc := range []struct {
+		ipv6      bool
+		prefixLen int
+		count     uint64
+		expected  uint64
+	}{
+		// the /24s overlapping the /25 or excluded are skipped
+		{count: 4, expected: 2},
+		{count: 1, expected: 1},
+		{prefixLen: 25, count: 8, expected: 5},
+		{prefixLen: 23, count: 2, expected: 1},
+		{ipv6: true, count: 8, expected: 4},
+	} {
+		allocatable, err := sna.SimulateAllocations(tc.ipv6, tc.prefixLen, tc.count)
+		if err != nil {
+			t.Fatal("Failed to simulate allocations: ", err)
+		}
+		if allocatable != tc.expected {
+			t.Fatalf("Expected %d allocatable networks of prefix length %d, got %d", tc.expected, tc.prefixLen, allocatable)
+		}
+	}
+	if _, err := sna.SimulateAllocations(false, 20, 1); err == nil {
+		t.Fatal("Unexpectedly simulated networks larger than the range")
+	}
+
+	// nothing was allocated
+	v4used, v6used := sna.Usage()
+	if v4used != 1 || v6used != 0 {
+		t.Fatalf("Expected the usage to be unchanged, got %d/%d", v4used, v6used)
+	}
+	usage := sna.RangeUsage()
+	if len(usage) != 2 || usage[0].Network.String() != "10.1.0.0/22" || usage[0].HostSubnetLength != 24 ||
+		usage[0].Used != 1 || usage[0].Count != 4 || usage[1].Network.String() != "fd00::/62" || usage[1].Count != 4 {
+		t.Fatalf("Unexpected range usage %+v", usage)
+	}
+	if err := allocateExpected(sna, 0, "10.1.0.0/24", "fd00::/64"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFragmentation(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/21", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	if err := sna.AddNetworkRange(ovntest.MustParseIPNet("fd00::/62"), 64); err != nil {
+		t.Fatal("Failed to add network range: ", err)
+	}
+	for owner, subnet := range map[string]string{"a": "10.1.1.0/24", "b": "10.1.4.0/24", "c": "10.1.6.0/24"} {
+		if err := sna.MarkAllocatedNetworks(owner, ovntest.MustParseIPNet(subnet)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sna.ExcludeNetworks(ovntest.MustParseIPNet("10.1.7.0/24"))
+
+	fragmentation := sna.Fragmentation()
+	if len(fragmentation) != 2 {
+		t.Fatalf("Expected the fragmentation of 2 ranges, got %+v", fragmentation)
+	}
+	// 10.1.0.0/24, 10.1.2.0/23 and 10.1.5.0/24 are free
+	f := fragmentation[0]
+	if f.Network.String() != "10.1.0.0/21" || f.HostSubnetLength != 24 || f.Free != 4 || f.LargestFreeBlockLength != 23 ||
+		f.LargestFreeBlock() != 2 || len(f.FreeBlocks) != 2 || f.FreeBlocks[24] != 2 || f.FreeBlocks[23] != 1 || f.Ratio() != 0.5 {
+		t.Fatalf("Unexpected IPv4 fragmentation %+v", f)
+	}
+	// the unused range is a single free block
+	f = fragmentation[1]
+	if f.Free != 4 || f.LargestFreeBlockLength != 62 || f.LargestFreeBlock() != 4 || f.Ratio() != 0 {
+		t.Fatalf("Unexpected IPv6 fragmentation %+v", f)
+	}
+
+	// a full range has no free block
+	sna, err = newSubnetAllocator("10.2.0.0/23", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	if err := sna.MarkAllocatedNetworks("a", ovntest.MustParseIPNet("10.2.0.0/24"), ovntest.MustParseIPNet("10.2.1.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	f = sna.Fragmentation()[0]
+	if f.Free != 0 || f.LargestFreeBlockLength != 0 || f.LargestFreeBlock() != 0 || len(f.FreeBlocks) != 0 || f.Ratio() != 0 {
+		t.Fatalf("Unexpected fragmentation of a full range %+v", f)
+	}
+}
+
+func TestAllocateCompactedNetwork(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/21", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	for owner, subnet := range map[string]string{"a": "10.1.1.0/24", "b": "10.1.4.0/24", "c": "10.1.6.0/24"} {
+		if err := sna.MarkAllocatedNetworks(owner, ovntest.MustParseIPNet(subnet)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sna.ExcludeNetworks(ovntest.MustParseIPNet("10.1.0.0/24"))
+
+	// the lowest free network lower than the given one is allocated, the
+	// given one is kept
+	subnet, err := sna.AllocateCompactedNetwork("c", ovntest.MustParseIPNet("10.1.6.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet == nil || subnet.String() != "10.1.2.0/24" {
+		t.Fatalf("Expected 10.1.2.0/24 to be allocated, got %v", subnet)
+	}
+	if v4used, _ := sna.Usage(); v4used != 4 {
+		t.Fatalf("Expected 4 allocated networks, got %d", v4used)
+	}
+	if err := sna.ReleaseNetworks("c", ovntest.MustParseIPNet("10.1.6.0/24")); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is allocated for a network with no free network below it
+	subnet, err = sna.AllocateCompactedNetwork("a", ovntest.MustParseIPNet("10.1.1.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet != nil {
+		t.Fatalf("Unexpectedly allocated %s", subnet)
+	}
+	subnet, err = sna.AllocateCompactedNetwork("b", ovntest.MustParseIPNet("10.1.4.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet == nil || subnet.String() != "10.1.3.0/24" {
+		t.Fatalf("Expected 10.1.3.0/24 to be allocated, got %v", subnet)
+	}
+
+	// only the owner of the network can compact it
+	if _, err := sna.AllocateCompactedNetwork("a", ovntest.MustParseIPNet("10.1.2.0/24")); err == nil {
+		t.Fatal("Unexpectedly compacted the network of another owner")
+	}
+	if _, err := sna.AllocateCompactedNetwork("a", ovntest.MustParseIPNet("10.2.0.0/24")); err == nil {
+		t.Fatal("Unexpectedly compacted a network out of the ranges")
+	}
+}
+
+func TestDrainNetworkRange(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/23", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	if err := sna.AddNetworkRange(ovntest.MustParseIPNet("10.2.0.0/23"), 24); err != nil {
+		t.Fatal("Failed to add network range: ", err)
+	}
+	if err := sna.MarkAllocatedNetworks("legacy", ovntest.MustParseIPNet("10.1.1.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sna.DrainNetworkRange(ovntest.MustParseIPNet("10.1.0.0/23")); err != nil {
+		t.Fatal("Failed to drain network range: ", err)
+	}
+	if err := sna.DrainNetworkRange(ovntest.MustParseIPNet("10.3.0.0/23")); err == nil {
+		t.Fatal("Unexpectedly succeeded in draining an unknown network range")
+	}
+
+	// the drained range is not allocated from, its allocated networks are
+	// still counted in the usage
+	if err := expectNumSubnets(t, sna, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateExpected(sna, 0, "10.2.0.0/24"); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateExpected(sna, 1, "10.2.1.0/24"); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateNotExpected(sna, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sna.MarkAllocatedNetworks("thief", ovntest.MustParseIPNet("10.1.0.0/24")); err == nil {
+		t.Fatal("Unexpectedly succeeded in marking a network of a drained range")
+	}
+
+	// the drained range is dropped once its networks are released
+	if err := sna.ReleaseNetworks("legacy", ovntest.MustParseIPNet("10.1.1.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateNotExpected(sna, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sna.ReleaseNetworks("legacy", ovntest.MustParseIPNet("10.1.1.0/24")); err == nil {
+		t.Fatal("Unexpectedly succeeded in releasing a network of a dropped range")
+	}
+
+	// a drained range can be added again
+	if err := sna.AddNetworkRange(ovntest.MustParseIPNet("10.1.0.0/23"), 24); err != nil {
+		t.Fatal("Failed to add network range: ", err)
+	}
+	if err := allocateExpected(sna, 2, "10.1.0.0/24"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllocatedNetworks(t *testing.T) {
+	sna, err := newSubnetAllocator("10.1.0.0/16", 24)
+	if err != nil {
+		t.Fatal("Failed to initialize subnet allocator: ", err)
+	}
+	if err := sna.MarkAllocatedNetworks("node1", ovntest.MustParseIPNet("10.1.3.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sna.MarkAllocatedNetworks("node2", ovntest.MustParseIPNet("10.1.4.0/24")); err != nil {
+		t.Fatal(err)
+	}
+
+	for query, expected := range map[string][]string{
+		"10.1.3.7/32": {"10.1.3.0/24 node1"},
+		"10.1.4.0/23": {"10.1.4.0/24 node2"},
+		"10.1.0.0/21": {"10.1.3.0/24 node1", "10.1.4.0/24 node2"},
+		"10.1.5.0/24": {},
+		"10.2.0.0/16": {},
+	} {
+		allocated := sna.AllocatedNetworks(ovntest.MustParseIPNet(query))
+		found := make([]string, 0, len(allocated))
+		for _, a := range allocated {
+			found = append(found, a.Network.String()+" "+a.Owner)
+		}
+		if fmt.Sprint(found) != fmt.Sprint(expected) {
+			t.Fatalf("Expected the networks allocated overlapping %s to be %v, got %v", query, expected, found)
+		}
+	}
+}
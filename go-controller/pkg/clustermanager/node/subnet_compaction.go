@@ -0,0 +1,227 @@
+package node
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// HostSubnetCompactionKey is the node annotation requesting, when set to
+	// "true" on a cordoned node, that the host subnets of the node for the
+	// default network are moved to the lowest free host subnets. It is
+	// removed once the host subnets are compacted.
+	HostSubnetCompactionKey = "k8s.ovn.org/host-subnet-compaction"
+
+	// subnetCompactionCheckInterval is how often the next batch of the
+	// compaction is checked for
+	subnetCompactionCheckInterval = 5 * time.Second
+)
+
+// subnetCompactor limits the disruption of the host subnet compaction: the
+// nodes requesting it are compacted at most batchSize at a time, the next
+// batch only starting once all the nodes of the current one are compacted and
+// the batch interval elapsed. The nodes waiting for their batch keep their
+// host subnets.
+type subnetCompactor struct {
+	batchSize int
+	interval  time.Duration
+
+	lock sync.Mutex
+	// pending are the nodes requesting the compaction that are not in a
+	// batch yet
+	pending sets.Set[string]
+	// batch are the nodes of the current batch not compacted yet
+	batch sets.Set[string]
+	// batchStart is when the current batch started
+	batchStart time.Time
+}
+
+func newSubnetCompactor(batchSize int, interval time.Duration) *subnetCompactor {
+	return &subnetCompactor{
+		batchSize: batchSize,
+		interval:  interval,
+		pending:   sets.New[string](),
+		batch:     sets.New[string](),
+	}
+}
+
+// isSubnetCompactionRequested returns whether the compaction of the host
+// subnets of the node is requested, which requires the node to be cordoned
+func isSubnetCompactionRequested(node *corev1.Node) bool {
+	if node.Annotations[HostSubnetCompactionKey] != "true" {
+		return false
+	}
+	if !node.Spec.Unschedulable {
+		klog.V(5).Infof("Ignoring the host subnet compaction of node %s until it is cordoned", node.Name)
+		return false
+	}
+	return true
+}
+
+// request records that the node requests the compaction and returns whether
+// it is part of the current batch
+func (c *subnetCompactor) request(nodeName string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.batch.Has(nodeName) {
+		return true
+	}
+	c.pending.Insert(nodeName)
+	return false
+}
+
+// forget drops a node that no longer requests the compaction, or is
+// compacted, or deleted
+func (c *subnetCompactor) forget(nodeName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pending.Delete(nodeName)
+	c.batch.Delete(nodeName)
+}
+
+// nextBatch starts the next batch of nodes if the current one is done and the
+// batch interval elapsed. It returns the nodes of the started batch.
+func (c *subnetCompactor) nextBatch(now time.Time) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.batch.Len() > 0 || c.pending.Len() == 0 {
+		return nil
+	}
+	if !c.batchStart.IsZero() && now.Sub(c.batchStart) < c.interval {
+		return nil
+	}
+	nodeNames := sets.List(c.pending)
+	if len(nodeNames) > c.batchSize {
+		nodeNames = nodeNames[:c.batchSize]
+	}
+	c.pending.Delete(nodeNames...)
+	c.batch.Insert(nodeNames...)
+	c.batchStart = now
+	klog.Infof("Compacting the host subnets of nodes %v, %d nodes left", nodeNames, c.pending.Len())
+	return nodeNames
+}
+
+// run starts the batches, calling retryNodes for the nodes of each started
+// batch to be compacted, until stopCh is closed
+func (c *subnetCompactor) run(stopCh <-chan struct{}, wg *sync.WaitGroup, retryNodes func(nodeNames []string)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if nodeNames := c.nextBatch(time.Now()); len(nodeNames) > 0 {
+				retryNodes(nodeNames)
+			}
+		}, subnetCompactionCheckInterval, stopCh)
+	}()
+}
+
+// EnableSubnetCompaction moves the host subnets of the cordoned nodes
+// annotated with HostSubnetCompactionKey to the lowest free host subnets,
+// batchSize nodes at a time with at least the given interval between two
+// batches, gathering the free host subnets in large blocks. Only for the
+// default network, must be called before Init.
+func (na *NodeAllocator) EnableSubnetCompaction(batchSize int, interval time.Duration) {
+	if batchSize <= 0 || na.netInfo.IsSecondary() {
+		return
+	}
+	na.subnetCompaction = newSubnetCompactor(batchSize, interval)
+}
+
+// RunSubnetCompaction starts the batches of the host subnet compaction until
+// stopCh is closed, calling retryNodes for the nodes of each batch to be
+// handled again. No-op unless the compaction is enabled.
+func (na *NodeAllocator) RunSubnetCompaction(stopCh <-chan struct{}, wg *sync.WaitGroup, retryNodes func(nodeNames []string)) {
+	if na.subnetCompaction == nil {
+		return
+	}
+	na.subnetCompaction.run(stopCh, wg, retryNodes)
+}
+
+// isSubnetCompactionAdmitted returns whether the host subnets of the node are
+// to be compacted now, recording the request of the nodes waiting for their
+// batch
+func (na *NodeAllocator) isSubnetCompactionAdmitted(node *corev1.Node) bool {
+	if na.subnetCompaction == nil {
+		return false
+	}
+	if !isSubnetCompactionRequested(node) {
+		na.subnetCompaction.forget(node.Name)
+		return false
+	}
+	return na.subnetCompaction.request(node.Name)
+}
+
+// compactHostSubnets allocates to the node the lowest free host subnet of the
+// same length lower than each of its host subnets. It returns the host
+// subnets of the node once compacted, the allocated host subnets and the
+// replaced ones, to release once the node subnet annotation is updated.
+func (na *NodeAllocator) compactHostSubnets(nodeName string, hostSubnets []*net.IPNet) ([]*net.IPNet, []*net.IPNet, []*net.IPNet) {
+	compactedSubnets := make([]*net.IPNet, 0, len(hostSubnets))
+	var allocatedSubnets, replacedSubnets []*net.IPNet
+	for _, hostSubnet := range hostSubnets {
+		subnet, err := na.clusterSubnetAllocator.AllocateCompactedNetwork(nodeName, hostSubnet)
+		if err != nil {
+			klog.Warningf("Failed to compact the host subnet %s of node %s: %v", hostSubnet, nodeName, err)
+		}
+		if subnet == nil {
+			compactedSubnets = append(compactedSubnets, hostSubnet)
+			continue
+		}
+		klog.Infof("Moving the host subnet %s of node %s to %s", hostSubnet, nodeName, subnet)
+		compactedSubnets = append(compactedSubnets, subnet)
+		allocatedSubnets = append(allocatedSubnets, subnet)
+		replacedSubnets = append(replacedSubnets, hostSubnet)
+	}
+	return compactedSubnets, allocatedSubnets, replacedSubnets
+}
+
+// finishSubnetCompaction releases the host subnets replaced by the compaction
+// of the node, once its node subnet annotation is updated, and removes its
+// compaction request
+func (na *NodeAllocator) finishSubnetCompaction(nodeName string, replacedSubnets []*net.IPNet) error {
+	if len(replacedSubnets) > 0 {
+		if err := na.clusterSubnetAllocator.ReleaseNetworks(nodeName, replacedSubnets...); err != nil {
+			klog.Warningf("Error releasing the compacted host subnets %v of node %s: %v",
+				util.StringSlice(replacedSubnets), nodeName, err)
+		}
+		metrics.RecordHostSubnetsCompacted(len(replacedSubnets))
+	}
+	if err := na.kube.SetAnnotationsOnNode(nodeName, map[string]interface{}{HostSubnetCompactionKey: nil}); err != nil {
+		return err
+	}
+	na.subnetCompaction.forget(nodeName)
+	klog.Infof("Compacted the host subnets of node %s, %d moved", nodeName, len(replacedSubnets))
+	return nil
+}
+
+// RunSubnetFragmentationReport analyzes the fragmentation of the cluster
+// subnets and records it in the metrics every interval until stopCh is
+// closed. Only for the default network, no-op if interval is 0.
+func (na *NodeAllocator) RunSubnetFragmentationReport(stopCh <-chan struct{}, wg *sync.WaitGroup, interval time.Duration) {
+	if interval <= 0 || na.netInfo.IsSecondary() || !na.hasNodeSubnetAllocation() {
+		return
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(na.recordSubnetFragmentation, interval, stopCh)
+	}()
+}
+
+// recordSubnetFragmentation records the fragmentation of each cluster subnet
+func (na *NodeAllocator) recordSubnetFragmentation() {
+	for _, f := range na.clusterSubnetAllocator.Fragmentation() {
+		klog.V(5).Infof("Cluster subnet %s has %d free host subnets, %d in its largest free block, free blocks by prefix length %v",
+			f.Network, f.Free, f.LargestFreeBlock(), f.FreeBlocks)
+		metrics.RecordHostSubnetFragmentation(f.Network.String(), f.Ratio(), f.LargestFreeBlock())
+	}
+}
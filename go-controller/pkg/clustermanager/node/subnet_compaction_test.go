@@ -0,0 +1,152 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_SubnetCompaction(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.IPv6Mode = false
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	objs := []runtime.Object{}
+	syncNodes := []interface{}{}
+	for _, node := range []*corev1.Node{
+		newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.1.0/24"]}`}),
+		newPlanTestNode("node2", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.3.0/24"]}`,
+			HostSubnetCompactionKey: "true"}),
+		// not cordoned
+		newPlanTestNode("node3", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.2.0/24"]}`,
+			HostSubnetCompactionKey: "true"}),
+	} {
+		node.Spec.Unschedulable = node.Name == "node2"
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, node)
+		syncNodes = append(syncNodes, node)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableSubnetCompaction(1, time.Minute)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync(syncNodes); err != nil {
+		t.Fatal(err)
+	}
+
+	handleNodes := func(nodeNames ...string) {
+		t.Helper()
+		for _, nodeName := range nodeNames {
+			node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := indexer.Update(node); err != nil {
+				t.Fatal(err)
+			}
+			if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectNode := func(nodeName string, compacting bool, expected ...string) {
+		t.Helper()
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := util.StringSlice(hostSubnets); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %s to have the host subnets %v, got %v", nodeName, expected, actual)
+		}
+		if _, ok := node.Annotations[HostSubnetCompactionKey]; ok != compacting {
+			t.Fatalf("expected the compaction request of %s to be set: %v, got %v", nodeName, compacting, node.Annotations)
+		}
+	}
+
+	// the fragmentation is analyzed on the allocator state
+	f := na.clusterSubnetAllocator.Fragmentation()[0]
+	if f.Free != 1 || f.FreeBlocks[24] != 1 {
+		t.Fatalf("unexpected fragmentation %+v", f)
+	}
+
+	// the nodes keep their host subnets until their batch starts
+	handleNodes("node1", "node2", "node3")
+	expectNode("node2", true, "10.128.3.0/24")
+	expectNode("node3", true, "10.128.2.0/24")
+
+	// only the cordoned node is compacted
+	now := time.Now()
+	batch := na.subnetCompaction.nextBatch(now)
+	if !reflect.DeepEqual(batch, []string{"node2"}) {
+		t.Fatalf("expected the batch to be node2, got %v", batch)
+	}
+	handleNodes(batch...)
+	expectNode("node2", false, "10.128.0.0/24")
+	expectNode("node3", true, "10.128.2.0/24")
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 3 {
+		t.Fatalf("expected the replaced host subnet to be released, got %d allocated host subnets", v4used)
+	}
+	if batch := na.subnetCompaction.nextBatch(now.Add(2 * time.Minute)); len(batch) > 0 {
+		t.Fatalf("expected no batch to start, got %v", batch)
+	}
+
+	// a node already holding the lowest free host subnet keeps it
+	node3, err := client.CoreV1().Nodes().Get(context.TODO(), "node3", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node3 = node3.DeepCopy()
+	node3.Spec.Unschedulable = true
+	if _, err := client.CoreV1().Nodes().Update(context.TODO(), node3, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	handleNodes("node3")
+	batch = na.subnetCompaction.nextBatch(now.Add(2 * time.Minute))
+	if !reflect.DeepEqual(batch, []string{"node3"}) {
+		t.Fatalf("expected the batch to be node3, got %v", batch)
+	}
+	handleNodes(batch...)
+	expectNode("node3", false, "10.128.2.0/24")
+
+	// the free host subnet is the last one
+	f = na.clusterSubnetAllocator.Fragmentation()[0]
+	if f.Free != 1 || f.FreeBlocks[24] != 1 || f.Ratio() != 0 {
+		t.Fatalf("unexpected fragmentation %+v", f)
+	}
+	subnet, err := na.clusterSubnetAllocator.AllocateIPv4NetworkOfLength("node4", 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet.String() != "10.128.3.0/24" {
+		t.Fatalf("expected 10.128.3.0/24 to be free, got %s", subnet)
+	}
+}
@@ -0,0 +1,213 @@
+package node
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// subnetMigrationCheckInterval is how often the next batch of the migration
+// is checked for
+const subnetMigrationCheckInterval = 5 * time.Second
+
+// subnetMigrator rolls the migration of the nodes of the default network off
+// the removed cluster subnets in batches: the nodes holding host subnets from
+// a removed cluster subnet keep them until their batch comes, and the next
+// batch only starts once all the nodes of the current one are migrated and the
+// batch interval elapsed. A batch size of 0 migrates all the nodes at once.
+type subnetMigrator struct {
+	batchSize int
+	interval  time.Duration
+
+	lock sync.Mutex
+	// pending are the nodes to migrate that are not in a batch yet
+	pending sets.Set[string]
+	// batch are the nodes of the current batch not migrated yet
+	batch    sets.Set[string]
+	total    int
+	migrated int
+	// batchStart is when the current batch started
+	batchStart time.Time
+}
+
+func newSubnetMigrator(batchSize int, interval time.Duration) *subnetMigrator {
+	return &subnetMigrator{
+		batchSize: batchSize,
+		interval:  interval,
+		pending:   sets.New[string](),
+		batch:     sets.New[string](),
+	}
+}
+
+// addPending adds a node to migrate, unless already known
+func (m *subnetMigrator) addPending(nodeName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.pending.Has(nodeName) || m.batch.Has(nodeName) {
+		return
+	}
+	m.pending.Insert(nodeName)
+	m.total++
+}
+
+// isDeferred returns whether the migration of the node waits for its batch
+func (m *subnetMigrator) isDeferred(nodeName string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.pending.Has(nodeName)
+}
+
+// isAdmitted returns whether the node is part of the current batch
+func (m *subnetMigrator) isAdmitted(nodeName string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.batch.Has(nodeName)
+}
+
+// markMigrated records that the node of the current batch was migrated
+func (m *subnetMigrator) markMigrated(nodeName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.batch.Has(nodeName) {
+		return
+	}
+	m.batch.Delete(nodeName)
+	m.migrated++
+	klog.Infof("Migrated node %s off the removed cluster subnets (%d/%d)", nodeName, m.migrated, m.total)
+}
+
+// forget drops a deleted node from the migration
+func (m *subnetMigrator) forget(nodeName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.pending.Has(nodeName) || m.batch.Has(nodeName) {
+		m.pending.Delete(nodeName)
+		m.batch.Delete(nodeName)
+		m.total--
+	}
+}
+
+// nextBatch starts the next batch of nodes if the current one is done and the
+// batch interval elapsed. It returns the nodes of the started batch.
+func (m *subnetMigrator) nextBatch(now time.Time) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.batch.Len() > 0 || m.pending.Len() == 0 {
+		return nil
+	}
+	if !m.batchStart.IsZero() && now.Sub(m.batchStart) < m.interval {
+		return nil
+	}
+	nodeNames := sets.List(m.pending)
+	if m.batchSize > 0 && len(nodeNames) > m.batchSize {
+		nodeNames = nodeNames[:m.batchSize]
+	}
+	m.pending.Delete(nodeNames...)
+	m.batch.Insert(nodeNames...)
+	m.batchStart = now
+	klog.Infof("Migrating nodes %v off the removed cluster subnets, %d nodes left", nodeNames, m.pending.Len())
+	return nodeNames
+}
+
+// run starts the batches, calling retryNodes for the nodes of each started
+// batch to be migrated, until stopCh is closed. Cluster subnets can be
+// removed at runtime so it keeps running once all the nodes are migrated.
+func (m *subnetMigrator) run(stopCh <-chan struct{}, wg *sync.WaitGroup, retryNodes func(nodeNames []string)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if nodeNames := m.nextBatch(time.Now()); len(nodeNames) > 0 {
+				retryNodes(nodeNames)
+			}
+		}, subnetMigrationCheckInterval, stopCh)
+	}()
+}
+
+// EnableSubnetMigration migrates the nodes holding host subnets from cluster
+// subnets removed from the configuration, or removed at runtime, to the
+// remaining cluster subnets, batchSize nodes at a time with at least the given
+// interval between two batches, or all at once if batchSize is 0. Only for the
+// default network, must be called before Init.
+func (na *NodeAllocator) EnableSubnetMigration(batchSize int, interval time.Duration) {
+	if na.netInfo.IsSecondary() {
+		return
+	}
+	na.subnetMigration = newSubnetMigrator(batchSize, interval)
+}
+
+// RunSubnetMigration starts the batches of the migration off the removed
+// cluster subnets until stopCh is closed, calling retryNodes for the nodes of
+// each batch to be handled again. No-op unless the migration is enabled.
+func (na *NodeAllocator) RunSubnetMigration(stopCh <-chan struct{}, wg *sync.WaitGroup, retryNodes func(nodeNames []string)) {
+	if na.subnetMigration == nil {
+		return
+	}
+	na.subnetMigration.run(stopCh, wg, retryNodes)
+}
+
+// removedHostSubnets returns the host subnets of the enabled IP families that
+// don't belong to the cluster subnets anymore. The IPv6 host subnets obtained
+// from a subnet source never belong to the cluster subnets and are skipped.
+func (na *NodeAllocator) removedHostSubnets(hostSubnets []*net.IPNet) []*net.IPNet {
+	clusterSubnets := na.clusterSubnets()
+	ipv4Mode, ipv6Mode := na.netInfo.IPMode()
+	var removed []*net.IPNet
+	for _, hostSubnet := range hostSubnets {
+		isIPv6 := utilnet.IsIPv6CIDR(hostSubnet)
+		if isIPv6 && (!ipv6Mode || na.delegatedSubnets != nil) || !isIPv6 && !ipv4Mode {
+			continue
+		}
+		if !isHostSubnetOfClusterSubnets(hostSubnet, clusterSubnets) {
+			removed = append(removed, hostSubnet)
+		}
+	}
+	return removed
+}
+
+// migratedHostSubnets returns, for a node of the current batch of the
+// migration, its host subnets without those from removed cluster subnets and
+// the removed ones, to release once the node subnet annotation is updated
+func (na *NodeAllocator) migratedHostSubnets(nodeName string, hostSubnets []*net.IPNet) ([]*net.IPNet, []*net.IPNet) {
+	if na.subnetMigration == nil || !na.subnetMigration.isAdmitted(nodeName) {
+		return hostSubnets, nil
+	}
+	removed := na.removedHostSubnets(hostSubnets)
+	if len(removed) == 0 {
+		return hostSubnets, nil
+	}
+	removedSet := sets.New(util.StringSlice(removed)...)
+	kept := make([]*net.IPNet, 0, len(hostSubnets))
+	for _, hostSubnet := range hostSubnets {
+		if !removedSet.Has(hostSubnet.String()) {
+			kept = append(kept, hostSubnet)
+		}
+	}
+	klog.Infof("Replacing the host subnets %v of node %s from removed cluster subnets", util.StringSlice(removed), nodeName)
+	return kept, removed
+}
+
+// finishSubnetMigration releases the host subnets replaced by the migration
+// of the node, once its node subnet annotation is updated, and records it as
+// migrated
+func (na *NodeAllocator) finishSubnetMigration(nodeName string, replacedSubnets []*net.IPNet) {
+	if na.subnetMigration == nil {
+		return
+	}
+	// the host subnets from cluster subnets removed from the configuration
+	// were never allocated, only those removed at runtime are
+	if len(replacedSubnets) > 0 {
+		if err := na.clusterSubnetAllocator.ReleaseNetworks(nodeName, replacedSubnets...); err != nil {
+			klog.V(5).Infof("Did not release the migrated host subnets %v of node %s: %v",
+				util.StringSlice(replacedSubnets), nodeName, err)
+		}
+	}
+	na.subnetMigration.markMigrated(nodeName)
+}
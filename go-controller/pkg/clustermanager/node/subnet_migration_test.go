@@ -0,0 +1,159 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_SubnetMigration(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.IPv6Mode = false
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	objs := []runtime.Object{}
+	syncNodes := []interface{}{}
+	for _, node := range []*corev1.Node{
+		newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/24"]}`}),
+		// from a cluster subnet removed from the configuration
+		newPlanTestNode("node2", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.100.0.0/24"]}`}),
+		// from a cluster subnet added at runtime
+		newPlanTestNode("node3", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.132.0.0/24"]}`}),
+		newPlanTestNode("node4", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.132.1.0/24"]}`}),
+	} {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, node)
+		syncNodes = append(syncNodes, node)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	na.EnableSubnetMigration(1, time.Minute)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	additional, err := rangesFromStrings([]string{"10.132.0.0/23"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := na.AddClusterSubnets(additional); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync(syncNodes); err != nil {
+		t.Fatal(err)
+	}
+
+	getNodes := func(nodeNames ...string) []*corev1.Node {
+		t.Helper()
+		nodes := make([]*corev1.Node, 0, len(nodeNames))
+		for _, nodeName := range nodeNames {
+			node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	handleNodes := func(nodeNames ...string) {
+		t.Helper()
+		for _, node := range getNodes(nodeNames...) {
+			if err := indexer.Update(node); err != nil {
+				t.Fatal(err)
+			}
+			if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectNode := func(nodeName string, expected ...string) {
+		t.Helper()
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(getNodes(nodeName)[0], types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := util.StringSlice(hostSubnets); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %s to have the host subnets %v, got %v", nodeName, expected, actual)
+		}
+	}
+
+	// the node from the cluster subnet removed from the configuration keeps
+	// its host subnet until its batch starts
+	handleNodes("node1", "node2", "node3", "node4")
+	expectNode("node2", "10.100.0.0/24")
+	now := time.Now()
+	batch := na.subnetMigration.nextBatch(now)
+	if !reflect.DeepEqual(batch, []string{"node2"}) {
+		t.Fatalf("expected the batch to be node2, got %v", batch)
+	}
+	handleNodes(batch...)
+	expectNode("node2", "10.128.1.0/24")
+
+	// the nodes from the cluster subnet removed at runtime keep their host
+	// subnets, which are not handed out anymore, until their batch starts
+	removed, err := na.RemoveClusterSubnets(additional, getNodes("node1", "node2", "node3", "node4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].CIDR.String() != "10.132.0.0/23" {
+		t.Fatalf("expected 10.132.0.0/23 to be removed, got %v", removed)
+	}
+	if len(na.AdditionalClusterSubnets()) != 0 {
+		t.Fatalf("expected no additional cluster subnet left, got %v", na.AdditionalClusterSubnets())
+	}
+	handleNodes("node3", "node4")
+	expectNode("node3", "10.132.0.0/24")
+	expectNode("node4", "10.132.1.0/24")
+	if batch := na.subnetMigration.nextBatch(now.Add(30 * time.Second)); len(batch) > 0 {
+		t.Fatalf("expected no batch to start before the batch interval, got %v", batch)
+	}
+
+	batch = na.subnetMigration.nextBatch(now.Add(2 * time.Minute))
+	if !reflect.DeepEqual(batch, []string{"node3"}) {
+		t.Fatalf("expected the batch to be node3, got %v", batch)
+	}
+	handleNodes(batch...)
+	expectNode("node3", "10.128.2.0/24")
+	expectNode("node4", "10.132.1.0/24")
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 4 {
+		t.Fatalf("expected the migrated host subnet to be released, got %d allocated host subnets", v4used)
+	}
+
+	batch = na.subnetMigration.nextBatch(now.Add(4 * time.Minute))
+	if !reflect.DeepEqual(batch, []string{"node4"}) {
+		t.Fatalf("expected the batch to be node4, got %v", batch)
+	}
+	handleNodes(batch...)
+	expectNode("node4", "10.128.3.0/24")
+
+	// the removed cluster subnet is dropped once all its host subnets are
+	// released
+	if v4used, v4count, _, _ := na.GetSubnetUsage(); v4used != 4 || v4count != 4 {
+		t.Fatalf("expected 4 of 4 host subnets to be allocated, got %d of %d", v4used, v4count)
+	}
+	if batch := na.subnetMigration.nextBatch(now.Add(6 * time.Minute)); len(batch) > 0 {
+		t.Fatalf("expected no batch to start, got %v", batch)
+	}
+}
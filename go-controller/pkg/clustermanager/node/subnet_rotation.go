@@ -0,0 +1,95 @@
+package node
+
+import (
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// HostSubnetRotationKey is the node annotation requesting, when set to "true"
+// on a cordoned node, that the host subnets of the node for the default
+// network are replaced with other free host subnets of the same lengths, like
+// when a host subnet leaked out of the cluster. It is removed once the host
+// subnets are rotated.
+const HostSubnetRotationKey = "k8s.ovn.org/rotate-host-subnet"
+
+// isHostSubnetRotationRequested returns whether the rotation of the host
+// subnets of the node is requested, which requires the node to be cordoned.
+// Only for the default network.
+func (na *NodeAllocator) isHostSubnetRotationRequested(node *corev1.Node) bool {
+	if na.netInfo.IsSecondary() || node.Annotations[HostSubnetRotationKey] != "true" {
+		return false
+	}
+	if !node.Spec.Unschedulable {
+		klog.V(5).Infof("Ignoring the host subnet rotation of node %s until it is cordoned", node.Name)
+		return false
+	}
+	return true
+}
+
+// rotateHostSubnets allocates to the node another free host subnet of the same
+// length for each of its host subnets but the just allocated ones. The
+// replaced host subnets stay allocated to the node until released, so that
+// the node can't get them back. It returns the host subnets of the node once
+// rotated, the allocated host subnets and the replaced ones, to release once
+// the node subnet annotation is updated. If a host subnet can't be rotated,
+// the node keeps all its host subnets.
+func (na *NodeAllocator) rotateHostSubnets(allocator SubnetAllocator, nodeName string, hostSubnets,
+	allocatedSubnets []*net.IPNet) ([]*net.IPNet, []*net.IPNet, []*net.IPNet, error) {
+	justAllocated := sets.New[string]()
+	for _, subnet := range allocatedSubnets {
+		justAllocated.Insert(subnet.String())
+	}
+	rotatedSubnets := make([]*net.IPNet, 0, len(hostSubnets))
+	var rotationSubnets, replacedSubnets []*net.IPNet
+	for _, hostSubnet := range hostSubnets {
+		if justAllocated.Has(hostSubnet.String()) {
+			rotatedSubnets = append(rotatedSubnets, hostSubnet)
+			continue
+		}
+		var subnet *net.IPNet
+		var err error
+		prefixLen, _ := hostSubnet.Mask.Size()
+		if utilnet.IsIPv6CIDR(hostSubnet) {
+			subnet, err = allocator.AllocateIPv6NetworkOfLength(nodeName, prefixLen)
+		} else {
+			subnet, err = allocator.AllocateIPv4NetworkOfLength(nodeName, prefixLen)
+		}
+		if err != nil {
+			if errR := allocator.ReleaseNetworks(nodeName, rotationSubnets...); errR != nil {
+				klog.Warningf("Error releasing node %s subnets: %v", nodeName, errR)
+			}
+			return hostSubnets, allocatedSubnets, nil,
+				fmt.Errorf("failed to rotate the host subnet %s of node %s: %w", hostSubnet, nodeName, err)
+		}
+		klog.V(5).Infof("Rotating the host subnet %s of node %s to %s", hostSubnet, nodeName, subnet)
+		rotatedSubnets = append(rotatedSubnets, subnet)
+		rotationSubnets = append(rotationSubnets, subnet)
+		replacedSubnets = append(replacedSubnets, hostSubnet)
+	}
+	return rotatedSubnets, append(allocatedSubnets, rotationSubnets...), replacedSubnets, nil
+}
+
+// finishHostSubnetRotation releases the host subnets replaced by the rotation
+// of the node, once its node subnet annotation is updated, and removes its
+// rotation request
+func (na *NodeAllocator) finishHostSubnetRotation(nodeName string, replacedSubnets []*net.IPNet) error {
+	if len(replacedSubnets) > 0 {
+		if err := na.clusterSubnetAllocator.ReleaseNetworks(nodeName, replacedSubnets...); err != nil {
+			klog.Warningf("Error releasing the rotated host subnets %v of node %s: %v",
+				util.StringSlice(replacedSubnets), nodeName, err)
+		}
+	}
+	if err := na.kube.SetAnnotationsOnNode(nodeName, map[string]interface{}{HostSubnetRotationKey: nil}); err != nil {
+		return err
+	}
+	klog.Infof("Rotated the host subnets %v of node %s, its pods need to be recreated",
+		util.StringSlice(replacedSubnets), nodeName)
+	return nil
+}
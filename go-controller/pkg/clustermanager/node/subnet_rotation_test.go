@@ -0,0 +1,118 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_HostSubnetRotation(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/22"}, []int{24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.IPv6Mode = false
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	objs := []runtime.Object{}
+	syncNodes := []interface{}{}
+	for _, node := range []*corev1.Node{
+		newPlanTestNode("node1", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.0.0/24"]}`,
+			HostSubnetRotationKey: "true"}),
+		// not cordoned
+		newPlanTestNode("node2", map[string]string{"k8s.ovn.org/node-subnets": `{"default":["10.128.1.0/24"]}`,
+			HostSubnetRotationKey: "true"}),
+	} {
+		node.Spec.Unschedulable = node.Name == "node1"
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, node)
+		syncNodes = append(syncNodes, node)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.Sync(syncNodes); err != nil {
+		t.Fatal(err)
+	}
+
+	handleNode := func(nodeName string) error {
+		t.Helper()
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Update(node); err != nil {
+			t.Fatal(err)
+		}
+		return na.HandleAddUpdateNodeEvent(node)
+	}
+	expectNode := func(nodeName string, rotating bool, expected ...string) {
+		t.Helper()
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := util.StringSlice(hostSubnets); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %s to have the host subnets %v, got %v", nodeName, expected, actual)
+		}
+		if _, ok := node.Annotations[HostSubnetRotationKey]; ok != rotating {
+			t.Fatalf("expected the rotation request of %s to be set: %v, got %v", nodeName, rotating, node.Annotations)
+		}
+	}
+
+	// only the cordoned node gets a fresh host subnet, its previous one being
+	// released
+	for _, nodeName := range []string{"node1", "node2"} {
+		if err := handleNode(nodeName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectNode("node1", false, "10.128.2.0/24")
+	expectNode("node2", true, "10.128.1.0/24")
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 2 {
+		t.Fatalf("expected the rotated host subnet to be released, got %d allocated host subnets", v4used)
+	}
+
+	// a node whose host subnet can't be rotated keeps it and is retried
+	if _, err := na.clusterSubnetAllocator.AllocateIPv4Network("other"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := na.clusterSubnetAllocator.AllocateIPv4Network("other"); err != nil {
+		t.Fatal(err)
+	}
+	if err := na.kube.SetAnnotationsOnNode("node1", map[string]interface{}{HostSubnetRotationKey: "true"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := handleNode("node1"); err == nil {
+		t.Fatal("expected the rotation of node1 to fail")
+	}
+	expectNode("node1", true, "10.128.2.0/24")
+	if v4used, _, _, _ := na.GetSubnetUsage(); v4used != 4 {
+		t.Fatalf("expected 4 allocated host subnets, got %d", v4used)
+	}
+}
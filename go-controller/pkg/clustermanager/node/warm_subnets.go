@@ -0,0 +1,448 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// WarmHostSubnetsConfigMapName is the name of the ConfigMap, in the
+	// OVN-Kubernetes config namespace, listing the host subnets reserved for
+	// the next nodes
+	WarmHostSubnetsConfigMapName = "warm-host-subnets"
+	// WarmHostSubnetsKey is the key of the ConfigMap holding the comma
+	// separated list of reserved host subnets
+	WarmHostSubnetsKey = "subnets"
+
+	// warmSubnetsOwner owns the reserved host subnets in the allocator. It is
+	// not a valid node name so it can't conflict with one.
+	warmSubnetsOwner = "_warm-host-subnets"
+
+	// warmSubnetsStoreRetryInterval is the interval after which the reserve is
+	// persisted again when the store failed
+	warmSubnetsStoreRetryInterval = 5 * time.Second
+)
+
+// WarmSubnetStore persists the host subnets reserved for the next nodes
+type WarmSubnetStore interface {
+	// Load returns the reserved host subnets, if any
+	Load() ([]*net.IPNet, error)
+	// Store replaces the reserved host subnets
+	Store(subnets []*net.IPNet) error
+}
+
+// configMapWarmSubnetStore stores the reserved host subnets in the
+// WarmHostSubnetsConfigMapName ConfigMap so that they can be consumed by the
+// tools provisioning the network for the next nodes, like routes on the top
+// of rack switches.
+type configMapWarmSubnetStore struct {
+	client kubernetes.Interface
+}
+
+// NewConfigMapWarmSubnetStore returns a WarmSubnetStore backed by a ConfigMap
+func NewConfigMapWarmSubnetStore(client kubernetes.Interface) WarmSubnetStore {
+	return &configMapWarmSubnetStore{client: client}
+}
+
+func (s *configMapWarmSubnetStore) Load() ([]*net.IPNet, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace).Get(context.TODO(),
+		WarmHostSubnetsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value := cm.Data[WarmHostSubnetsKey]
+	if value == "" {
+		return nil, nil
+	}
+	return util.ParseIPNets(strings.Split(value, ","))
+}
+
+func (s *configMapWarmSubnetStore) Store(subnets []*net.IPNet) error {
+	data := map[string]string{WarmHostSubnetsKey: util.JoinIPNets(subnets, ",")}
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		configMaps := s.client.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace)
+		cm, err := configMaps.Get(context.TODO(), WarmHostSubnetsConfigMapName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      WarmHostSubnetsConfigMapName,
+					Namespace: config.Kubernetes.OVNConfigNamespace,
+				},
+				Data: data,
+			}
+			_, err = configMaps.Create(context.TODO(), cm, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		cm = cm.DeepCopy()
+		cm.Data = data
+		_, err = configMaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// warmSubnetAllocator is a SubnetAllocator keeping a number of host subnets
+// of each IP family reserved for the next nodes. A node allocated a host
+// subnet of the host subnet length gets a reserved one, which is replaced
+// right away, so that the subnets the next nodes get are known in advance.
+// The reserve is always persisted in the background, and once running also
+// replenished there, so that the node allocations don't wait on the store.
+// Reserved subnets are not reported in the usage.
+type warmSubnetAllocator struct {
+	SubnetAllocator
+
+	sync.Mutex
+	size  int
+	store WarmSubnetStore
+	v4    []*net.IPNet
+	v6    []*net.IPNet
+	// restored is set once the reserve was restored, the reserve is only
+	// replenished from the ranges added afterwards
+	restored bool
+	// running is set while the reserve is replenished in the background
+	running bool
+	// dirty is set when the reserve changed since it was last persisted by
+	// the background replenishment, which persists it once started
+	dirty bool
+	// replenishCh wakes up the background replenishment
+	replenishCh chan struct{}
+	// storeRetryInterval is the interval after which the background
+	// replenishment persists the reserve again when the store failed
+	storeRetryInterval time.Duration
+}
+
+var _ SubnetAllocator = &warmSubnetAllocator{}
+
+func newWarmSubnetAllocator(allocator SubnetAllocator, size int, store WarmSubnetStore) *warmSubnetAllocator {
+	return &warmSubnetAllocator{
+		SubnetAllocator: allocator,
+		size:            size,
+		store:           store,
+		replenishCh:     make(chan struct{}, 1),
+
+		storeRetryInterval: warmSubnetsStoreRetryInterval,
+	}
+}
+
+// Usage returns the number of used/allocated v4 and v6 subnets, not counting
+// the reserved ones
+func (wsa *warmSubnetAllocator) Usage() (uint64, uint64) {
+	v4used, v6used := wsa.SubnetAllocator.Usage()
+	wsa.Lock()
+	defer wsa.Unlock()
+	return v4used - uint64(len(wsa.v4)), v6used - uint64(len(wsa.v6))
+}
+
+// RangeUsage returns the usage of each range, not counting the reserved
+// subnets
+func (wsa *warmSubnetAllocator) RangeUsage() []SubnetRangeUsage {
+	usage := wsa.SubnetAllocator.RangeUsage()
+	wsa.Lock()
+	defer wsa.Unlock()
+	for i := range usage {
+		for _, subnet := range append(append([]*net.IPNet{}, wsa.v4...), wsa.v6...) {
+			if usage[i].Network.Contains(subnet.IP) {
+				usage[i].Used--
+			}
+		}
+	}
+	return usage
+}
+
+// SimulateAllocations counts the reserved subnets as allocatable to the next
+// networks of the host subnet length, the reserve being replenished from the
+// ranges as they are handed over
+func (wsa *warmSubnetAllocator) SimulateAllocations(ipv6 bool, prefixLen int, count uint64) (uint64, error) {
+	if prefixLen != 0 {
+		return wsa.SubnetAllocator.SimulateAllocations(ipv6, prefixLen, count)
+	}
+	wsa.Lock()
+	reserved := uint64(len(wsa.v4))
+	if ipv6 {
+		reserved = uint64(len(wsa.v6))
+	}
+	wsa.Unlock()
+	if reserved >= count {
+		return count, nil
+	}
+	allocated, err := wsa.SubnetAllocator.SimulateAllocations(ipv6, prefixLen, count-reserved)
+	if err != nil {
+		return 0, err
+	}
+	return reserved + allocated, nil
+}
+
+// AddNetworkRange makes the given range available for allocation and, for
+// ranges added at runtime, replenishes the reserve from it
+func (wsa *warmSubnetAllocator) AddNetworkRange(network *net.IPNet, hostSubnetLen int) error {
+	if err := wsa.SubnetAllocator.AddNetworkRange(network, hostSubnetLen); err != nil {
+		return err
+	}
+	wsa.Lock()
+	defer wsa.Unlock()
+	if wsa.restored {
+		wsa.update(false)
+	}
+	return nil
+}
+
+// DrainNetworkRange drains the given range and releases the subnets reserved
+// from it, replenishing the reserve from the other ranges
+func (wsa *warmSubnetAllocator) DrainNetworkRange(network *net.IPNet) error {
+	if err := wsa.SubnetAllocator.DrainNetworkRange(network); err != nil {
+		return err
+	}
+	wsa.Lock()
+	defer wsa.Unlock()
+	released := false
+	for _, reserved := range []*[]*net.IPNet{&wsa.v4, &wsa.v6} {
+		kept := (*reserved)[:0]
+		for _, subnet := range *reserved {
+			if !network.Contains(subnet.IP) {
+				kept = append(kept, subnet)
+				continue
+			}
+			if err := wsa.SubnetAllocator.ReleaseNetworks(warmSubnetsOwner, subnet); err != nil {
+				klog.Warningf("Failed to release reserved host subnet %s: %v", subnet, err)
+			}
+			released = true
+		}
+		*reserved = kept
+	}
+	if wsa.restored {
+		wsa.update(released)
+	} else if released {
+		wsa.dirty = true
+		wsa.wakeUp()
+	}
+	return nil
+}
+
+func (wsa *warmSubnetAllocator) AllocateNetworks(owner string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	ipv4network, err := wsa.AllocateIPv4Network(owner)
+	if err != nil {
+		return nil, err
+	}
+	if ipv4network != nil {
+		networks = append(networks, ipv4network)
+	}
+	ipv6network, err := wsa.AllocateIPv6Network(owner)
+	if err != nil {
+		if len(networks) > 0 {
+			_ = wsa.ReleaseNetworks(owner, networks...)
+		}
+		return nil, err
+	}
+	if ipv6network != nil {
+		networks = append(networks, ipv6network)
+	}
+	return networks, nil
+}
+
+func (wsa *warmSubnetAllocator) AllocateIPv4Network(owner string) (*net.IPNet, error) {
+	return wsa.allocateNetwork(owner, false)
+}
+
+func (wsa *warmSubnetAllocator) AllocateIPv6Network(owner string) (*net.IPNet, error) {
+	return wsa.allocateNetwork(owner, true)
+}
+
+// allocateNetwork hands over a reserved subnet of the IP family to the owner,
+// or allocates a new one if none is reserved, and replenishes the reserve
+func (wsa *warmSubnetAllocator) allocateNetwork(owner string, ipv6 bool) (*net.IPNet, error) {
+	wsa.Lock()
+	defer wsa.Unlock()
+	reserved := &wsa.v4
+	if ipv6 {
+		reserved = &wsa.v6
+	}
+	var subnet *net.IPNet
+	for subnet == nil && len(*reserved) > 0 {
+		candidate := (*reserved)[0]
+		*reserved = (*reserved)[1:]
+		if err := wsa.SubnetAllocator.ReleaseNetworks(warmSubnetsOwner, candidate); err != nil {
+			klog.Warningf("Failed to release reserved host subnet %s: %v", candidate, err)
+			continue
+		}
+		// the subnet might have been allocated concurrently once released
+		if err := wsa.SubnetAllocator.MarkAllocatedNetworks(owner, candidate); err != nil {
+			klog.Warningf("Failed to allocate reserved host subnet %s to %s: %v", candidate, owner, err)
+			continue
+		}
+		klog.Infof("Allocated reserved host subnet %s to %s", candidate, owner)
+		subnet = candidate
+	}
+	tookReserved := subnet != nil
+	if subnet == nil {
+		var err error
+		if ipv6 {
+			subnet, err = wsa.SubnetAllocator.AllocateIPv6Network(owner)
+		} else {
+			subnet, err = wsa.SubnetAllocator.AllocateIPv4Network(owner)
+		}
+		if err != nil || subnet == nil {
+			return subnet, err
+		}
+	}
+	wsa.update(tookReserved)
+	return subnet, nil
+}
+
+// update replenishes the reserve, in the background once running, and has
+// it persisted in the background if it changed or if changed is set. Must be
+// called with the lock held.
+func (wsa *warmSubnetAllocator) update(changed bool) {
+	if wsa.running {
+		wsa.dirty = wsa.dirty || changed
+		wsa.wakeUp()
+		return
+	}
+	if wsa.replenish() || changed {
+		// persisted once the background replenishment starts
+		wsa.dirty = true
+		wsa.wakeUp()
+	}
+}
+
+// run replenishes the reserve in the background, when woken up by update,
+// until stopCh is closed
+func (wsa *warmSubnetAllocator) run(stopCh <-chan struct{}, wg *sync.WaitGroup) {
+	wsa.Lock()
+	wsa.running = true
+	wsa.Unlock()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			wsa.Lock()
+			wsa.running = false
+			wsa.Unlock()
+		}()
+		for {
+			select {
+			case <-wsa.replenishCh:
+				wsa.replenishInBackground()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// wakeUp wakes up the background replenishment, right away once started
+func (wsa *warmSubnetAllocator) wakeUp() {
+	select {
+	case wsa.replenishCh <- struct{}{}:
+	default:
+		// a replenishment is already pending
+	}
+}
+
+// replenishInBackground replenishes the reserve and persists it if it
+// changed. The store is called without holding the lock to not block the
+// allocations. If the store fails, the reserve is left dirty and persisted
+// again after the retry interval.
+func (wsa *warmSubnetAllocator) replenishInBackground() {
+	wsa.Lock()
+	changed := wsa.replenish() || wsa.dirty
+	wsa.dirty = false
+	subnets := append(append([]*net.IPNet{}, wsa.v4...), wsa.v6...)
+	wsa.Unlock()
+	if !changed {
+		return
+	}
+	if err := wsa.store.Store(subnets); err != nil {
+		klog.Warningf("Failed to persist the reserved host subnets %v, retrying in %v: %v",
+			subnets, wsa.storeRetryInterval, err)
+		wsa.Lock()
+		wsa.dirty = true
+		wsa.Unlock()
+		time.AfterFunc(wsa.storeRetryInterval, wsa.wakeUp)
+	}
+}
+
+// replenish reserves new subnets until the reserve of each IP family is full
+// or no subnet is left, and returns whether any was reserved. Must be called
+// with the lock held.
+func (wsa *warmSubnetAllocator) replenish() bool {
+	changed := false
+	for _, ipv6 := range []bool{false, true} {
+		reserved := &wsa.v4
+		allocate := wsa.SubnetAllocator.AllocateIPv4Network
+		if ipv6 {
+			reserved = &wsa.v6
+			allocate = wsa.SubnetAllocator.AllocateIPv6Network
+		}
+		for len(*reserved) < wsa.size {
+			subnet, err := allocate(warmSubnetsOwner)
+			if err != nil {
+				if !errors.Is(err, ErrSubnetAllocatorFull) {
+					klog.Warningf("Failed to reserve a host subnet: %v", err)
+				}
+				break
+			}
+			if subnet == nil {
+				// no range of this IP family
+				break
+			}
+			*reserved = append(*reserved, subnet)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// restore reserves the persisted subnets not allocated to any node, then
+// replenishes the reserve. Must be called once the subnets of the existing
+// nodes are marked as allocated.
+func (wsa *warmSubnetAllocator) restore() error {
+	subnets, err := wsa.store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load the reserved host subnets: %w", err)
+	}
+
+	wsa.Lock()
+	defer wsa.Unlock()
+	wsa.SubnetAllocator.ReleaseAllNetworks(warmSubnetsOwner)
+	wsa.v4, wsa.v6 = nil, nil
+	wsa.restored = true
+	for _, subnet := range subnets {
+		reserved := &wsa.v4
+		if utilnet.IsIPv6CIDR(subnet) {
+			reserved = &wsa.v6
+		}
+		if len(*reserved) >= wsa.size {
+			continue
+		}
+		if err := wsa.SubnetAllocator.MarkAllocatedNetworks(warmSubnetsOwner, subnet); err != nil {
+			klog.Infof("Dropping reserved host subnet %s: %v", subnet, err)
+			continue
+		}
+		*reserved = append(*reserved, subnet)
+	}
+	// persist the reserve if subnets were reserved or dropped
+	wsa.update(len(wsa.v4)+len(wsa.v6) != len(subnets))
+	return nil
+}
@@ -0,0 +1,244 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func newWarmSubnetTestAllocator(t *testing.T, store WarmSubnetStore, size int) *warmSubnetAllocator {
+	sna, err := newSubnetAllocator("10.1.0.0/16", 24)
+	if err != nil {
+		t.Fatalf("failed to create the subnet allocator: %v", err)
+	}
+	return newWarmSubnetAllocator(sna, size, store)
+}
+
+func loadWarmSubnets(t *testing.T, store WarmSubnetStore) string {
+	subnets, err := store.Load()
+	if err != nil {
+		t.Fatalf("failed to load the reserved subnets: %v", err)
+	}
+	return util.JoinIPNets(subnets, ",")
+}
+
+// runWarmSubnets starts the background replenishment of the allocator and
+// returns the function stopping it
+func runWarmSubnets(wsa *warmSubnetAllocator) func() {
+	stopCh := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	wsa.run(stopCh, wg)
+	return func() {
+		close(stopCh)
+		wg.Wait()
+	}
+}
+
+// expectWarmSubnets waits for the store to hold the expected reserved subnets
+func expectWarmSubnets(t *testing.T, store WarmSubnetStore, expected string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for reserved := loadWarmSubnets(t, store); reserved != expected; reserved = loadWarmSubnets(t, store) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the reserved subnets %q, got %q", expected, reserved)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWarmSubnetAllocator(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	store := NewConfigMapWarmSubnetStore(fake.NewSimpleClientset())
+	wsa := newWarmSubnetTestAllocator(t, store, 2)
+	stop := runWarmSubnets(wsa)
+
+	// the subnet of an existing node is not reserved
+	if err := wsa.MarkAllocatedNetworks("node1", ovntest.MustParseIPNet("10.1.0.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wsa.restore(); err != nil {
+		t.Fatal(err)
+	}
+	expectWarmSubnets(t, store, "10.1.1.0/24,10.1.2.0/24")
+	if v4used, _ := wsa.Usage(); v4used != 1 {
+		t.Fatalf("expected the reserved subnets not to be counted as used, got %d used", v4used)
+	}
+
+	// a new node gets the first reserved subnet, which is replaced
+	subnet, err := wsa.AllocateIPv4Network("node2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet.String() != "10.1.1.0/24" {
+		t.Fatalf("expected node2 to get the reserved subnet 10.1.1.0/24, got %s", subnet)
+	}
+	expectWarmSubnets(t, store, "10.1.2.0/24,10.1.3.0/24")
+	stop()
+
+	// the reserved subnets survive a restart, except those allocated to nodes
+	// in the meantime
+	restarted := newWarmSubnetTestAllocator(t, store, 2)
+	defer runWarmSubnets(restarted)()
+	if err := restarted.MarkAllocatedNetworks("node3", ovntest.MustParseIPNet("10.1.2.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.restore(); err != nil {
+		t.Fatal(err)
+	}
+	expectWarmSubnets(t, store, "10.1.3.0/24,10.1.0.0/24")
+}
+
+func TestWarmSubnetAllocatorFull(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	sna, err := newSubnetAllocator("10.1.0.0/23", 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewConfigMapWarmSubnetStore(fake.NewSimpleClientset())
+	wsa := newWarmSubnetAllocator(sna, 2, store)
+	defer runWarmSubnets(wsa)()
+	if err := wsa.restore(); err != nil {
+		t.Fatal(err)
+	}
+	expectWarmSubnets(t, store, "10.1.0.0/24,10.1.1.0/24")
+
+	// the reserved subnets are allocatable
+	if allocatable, err := wsa.SimulateAllocations(false, 0, 3); err != nil || allocatable != 2 {
+		t.Fatalf("expected 2 allocatable subnets, got %d: %v", allocatable, err)
+	}
+
+	// reserved subnets are handed over until none is left
+	var subnets []*net.IPNet
+	for _, node := range []string{"node1", "node2"} {
+		subnet, err := wsa.AllocateIPv4Network(node)
+		if err != nil {
+			t.Fatalf("failed to allocate a subnet to %s: %v", node, err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	if got := util.JoinIPNets(subnets, ","); got != "10.1.0.0/24,10.1.1.0/24" {
+		t.Fatalf("expected the subnets 10.1.0.0/24,10.1.1.0/24, got %s", got)
+	}
+	if _, err := wsa.AllocateIPv4Network("node3"); err != ErrSubnetAllocatorFull {
+		t.Fatalf("expected the allocator to be full, got %v", err)
+	}
+	expectWarmSubnets(t, store, "")
+}
+
+func TestWarmSubnetAllocatorAddNetworkRange(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	sna, err := newSubnetAllocator("10.1.0.0/24", 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewConfigMapWarmSubnetStore(fake.NewSimpleClientset())
+	wsa := newWarmSubnetAllocator(sna, 2, store)
+	defer runWarmSubnets(wsa)()
+	if err := wsa.MarkAllocatedNetworks("node1", ovntest.MustParseIPNet("10.1.0.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wsa.restore(); err != nil {
+		t.Fatal(err)
+	}
+	expectWarmSubnets(t, store, "")
+
+	// a range added at runtime replenishes the reserve
+	if err := wsa.AddNetworkRange(ovntest.MustParseIPNet("10.2.0.0/16"), 24); err != nil {
+		t.Fatal(err)
+	}
+	expectWarmSubnets(t, store, "10.2.0.0/24,10.2.1.0/24")
+}
+
+// blockingWarmSubnetStore blocks the stores until unblocked
+type blockingWarmSubnetStore struct {
+	WarmSubnetStore
+	unblock chan struct{}
+}
+
+func (s *blockingWarmSubnetStore) Store(subnets []*net.IPNet) error {
+	<-s.unblock
+	return s.WarmSubnetStore.Store(subnets)
+}
+
+func TestWarmSubnetAllocatorBackgroundReplenishment(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	store := NewConfigMapWarmSubnetStore(fake.NewSimpleClientset())
+	blocking := &blockingWarmSubnetStore{WarmSubnetStore: store, unblock: make(chan struct{})}
+	wsa := newWarmSubnetTestAllocator(t, blocking, 2)
+	defer runWarmSubnets(wsa)()
+
+	// neither the restoration nor the allocations wait on the store
+	if err := wsa.restore(); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range []string{"node1", "node2", "node3"} {
+		if _, err := wsa.AllocateIPv4Network(node); err != nil {
+			t.Fatalf("failed to allocate a subnet to %s: %v", node, err)
+		}
+	}
+	if reserved := loadWarmSubnets(t, store); reserved != "" {
+		t.Fatalf("expected the reserve not to be persisted yet, got %s", reserved)
+	}
+
+	// the replenished reserve is persisted once the store is available
+	close(blocking.unblock)
+	expectWarmSubnets(t, store, "10.1.3.0/24,10.1.4.0/24")
+}
+
+// failingWarmSubnetStore fails the given number of stores
+type failingWarmSubnetStore struct {
+	WarmSubnetStore
+	sync.Mutex
+	failures int
+}
+
+func (s *failingWarmSubnetStore) Store(subnets []*net.IPNet) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return fmt.Errorf("store failure")
+	}
+	return s.WarmSubnetStore.Store(subnets)
+}
+
+func TestWarmSubnetAllocatorStoreFailure(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	store := NewConfigMapWarmSubnetStore(fake.NewSimpleClientset())
+	failing := &failingWarmSubnetStore{WarmSubnetStore: store}
+	wsa := newWarmSubnetTestAllocator(t, failing, 2)
+	wsa.storeRetryInterval = 10 * time.Millisecond
+	defer runWarmSubnets(wsa)()
+	if err := wsa.restore(); err != nil {
+		t.Fatal(err)
+	}
+	expectWarmSubnets(t, store, "10.1.0.0/24,10.1.1.0/24")
+	failing.Lock()
+	failing.failures = 2
+	failing.Unlock()
+
+	// the reserve is persisted once the store recovers, without further
+	// allocation
+	if _, err := wsa.AllocateIPv4Network("node1"); err != nil {
+		t.Fatal(err)
+	}
+	expectWarmSubnets(t, store, "10.1.1.0/24,10.1.2.0/24")
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+// nodeSubnetAllocator returns the allocator of the new host subnets of the
+// node. When cluster subnets are mapped to topology zones, it only allocates
+// them, for each IP family, from the cluster subnets of the zone of the node,
+// or from the cluster subnets mapped to no zone for a node without zone or of
+// a zone without cluster subnets of the IP family, so that the host subnets of
+// a zone can be summarized in a single route. Zones only apply to the default
+// network.
+func (na *NodeAllocator) nodeSubnetAllocator(node *corev1.Node) SubnetAllocator {
+	if na.netInfo.IsSecondary() || len(config.ClusterManager.ZoneSubnets) == 0 {
+		return na.clusterSubnetAllocator
+	}
+	zone := node.Labels[corev1.LabelTopologyZone]
+	zoneSubnets := config.ClusterManager.ZoneSubnets[zone]
+	mappedSubnets := sets.New[string]()
+	for _, subnets := range config.ClusterManager.ZoneSubnets {
+		for _, subnet := range subnets {
+			mappedSubnets.Insert(subnet.String())
+		}
+	}
+	zsa := &zoneSubnetAllocator{SubnetAllocator: na.clusterSubnetAllocator, zone: zone}
+	for _, isIPv6 := range []bool{false, true} {
+		var networks []*net.IPNet
+		for _, subnet := range zoneSubnets {
+			if utilnet.IsIPv6CIDR(subnet) == isIPv6 {
+				networks = append(networks, subnet)
+			}
+		}
+		if len(networks) == 0 {
+			for _, clusterSubnet := range na.clusterSubnets() {
+				if utilnet.IsIPv6CIDR(clusterSubnet.CIDR) == isIPv6 && !mappedSubnets.Has(clusterSubnet.CIDR.String()) {
+					networks = append(networks, clusterSubnet.CIDR)
+				}
+			}
+		}
+		if isIPv6 {
+			zsa.v6networks = networks
+		} else {
+			zsa.v4networks = networks
+		}
+	}
+	return zsa
+}
+
+// zoneSubnetAllocator allocates the networks only from the ranges of the given
+// networks of each IP family instead of from all the ranges
+type zoneSubnetAllocator struct {
+	SubnetAllocator
+	zone       string
+	v4networks []*net.IPNet
+	v6networks []*net.IPNet
+}
+
+func (zsa *zoneSubnetAllocator) allocate(owner string, networks []*net.IPNet, prefixLen int) (*net.IPNet, error) {
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no cluster subnet for zone %q", zsa.zone)
+	}
+	subnet, err := zsa.AllocateNetworkInRanges(owner, networks, prefixLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate a network from the cluster subnets %v of zone %q: %w",
+			networks, zsa.zone, err)
+	}
+	return subnet, nil
+}
+
+func (zsa *zoneSubnetAllocator) AllocateIPv4Network(owner string) (*net.IPNet, error) {
+	return zsa.allocate(owner, zsa.v4networks, 0)
+}
+
+func (zsa *zoneSubnetAllocator) AllocateIPv6Network(owner string) (*net.IPNet, error) {
+	return zsa.allocate(owner, zsa.v6networks, 0)
+}
+
+func (zsa *zoneSubnetAllocator) AllocateIPv4NetworkOfLength(owner string, prefixLen int) (*net.IPNet, error) {
+	return zsa.allocate(owner, zsa.v4networks, prefixLen)
+}
+
+func (zsa *zoneSubnetAllocator) AllocateIPv6NetworkOfLength(owner string, prefixLen int) (*net.IPNet, error) {
+	return zsa.allocate(owner, zsa.v6networks, prefixLen)
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestNodeAllocator_ZoneSubnets(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := rangesFromStrings([]string{"10.128.0.0/23", "10.129.0.0/23", "10.130.0.0/23"}, []int{24, 24, 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Default.ClusterSubnets = ranges
+	config.IPv4Mode = true
+	config.IPv6Mode = false
+	config.ClusterManager.ZoneSubnets = map[string][]*net.IPNet{
+		"zone-a": {ranges[1].CIDR},
+		"zone-b": {ranges[2].CIDR},
+	}
+
+	newNode := func(name, zone string) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if zone != "" {
+			node.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+		}
+		return node
+	}
+	nodes := []*corev1.Node{
+		newNode("a1", "zone-a"),
+		newNode("b1", "zone-b"),
+		newNode("c1", "zone-c"),
+		newNode("none", ""),
+		newNode("a2", "zone-a"),
+		newNode("a3", "zone-a"),
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset()
+	for _, node := range nodes {
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	na := NewNodeAllocator(0, &util.DefaultNetInfo{}, listers.NewNodeLister(indexer), &kube.Kube{KClient: client}, nil)
+	if err := na.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	expectHostSubnets := func(nodeName string, expected ...string) {
+		t.Helper()
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := util.StringSlice(hostSubnets); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected the host subnets %v for node %s, got %v", expected, nodeName, actual)
+		}
+	}
+
+	for _, node := range nodes[:5] {
+		if err := na.HandleAddUpdateNodeEvent(node); err != nil {
+			t.Fatalf("failed to allocate the host subnets of node %s: %v", node.Name, err)
+		}
+	}
+	// the nodes of a zone get their host subnets from the cluster subnets of
+	// their zone
+	expectHostSubnets("a1", "10.129.0.0/24")
+	expectHostSubnets("a2", "10.129.1.0/24")
+	expectHostSubnets("b1", "10.130.0.0/24")
+	// the nodes without zone, or of a zone without cluster subnets, from the
+	// cluster subnets mapped to no zone
+	expectHostSubnets("c1", "10.128.0.0/24")
+	expectHostSubnets("none", "10.128.1.0/24")
+
+	// a node doesn't get a host subnet of another zone once the cluster
+	// subnets of its zone are exhausted
+	if err := na.HandleAddUpdateNodeEvent(nodes[5]); err == nil {
+		t.Fatal("expected an error allocating a host subnet to node a3 in the exhausted zone-a")
+	}
+	node, err := client.CoreV1().Nodes().Get(context.TODO(), "a3", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName); !util.IsAnnotationNotSetError(err) {
+		t.Fatalf("expected no host subnets for node a3, got %v", err)
+	}
+}
@@ -0,0 +1,372 @@
+package clustermanager
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	nadinformers "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/informers/externalversions"
+	nadlisters "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/listers/k8s.cni.cncf.io/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// NodeAnnotationWebhookPath is the path the node annotation admission webhook
+// is served at
+const NodeAnnotationWebhookPath = "/validate-node-annotations"
+
+// nodeAnnotationValidator validates the edits of the host subnet and network
+// ID annotations of the nodes by users other than the trusted ones, so that
+// the out of band changes creating duplicates or out of pool host subnets are
+// rejected instead of being fought by the node allocators
+type nodeAnnotationValidator struct {
+	nodeLister      listers.NodeLister
+	configMapLister listers.ConfigMapLister
+	// nadLister is nil without multiple networks
+	nadLister    nadlisters.NetworkAttachmentDefinitionLister
+	trustedUsers sets.Set[string]
+}
+
+func newNodeAnnotationValidator(nodeLister listers.NodeLister, configMapLister listers.ConfigMapLister,
+	nadLister nadlisters.NetworkAttachmentDefinitionLister, trustedUsers []string) *nodeAnnotationValidator {
+	return &nodeAnnotationValidator{
+		nodeLister:      nodeLister,
+		configMapLister: configMapLister,
+		nadLister:       nadLister,
+		trustedUsers:    sets.New[string](trustedUsers...),
+	}
+}
+
+// StartNodeAnnotationWebhook serves the node annotation admission webhook on
+// the configured address until stopChan is closed. It is served by every
+// ovnkube-cluster-manager instance, whether leader or not.
+func StartNodeAnnotationWebhook(ovnClient *util.OVNClientset, stopChan <-chan struct{}, wg *sync.WaitGroup) error {
+	informerFactory := informers.NewSharedInformerFactory(ovnClient.KubeClient, 0)
+	nodeInformer := informerFactory.Core().V1().Nodes()
+	configMapInformerFactory := informers.NewSharedInformerFactoryWithOptions(ovnClient.KubeClient, 0,
+		informers.WithNamespace(config.Kubernetes.OVNConfigNamespace))
+	configMapInformer := configMapInformerFactory.Core().V1().ConfigMaps()
+	synced := []cache.InformerSynced{nodeInformer.Informer().HasSynced, configMapInformer.Informer().HasSynced}
+	var nadLister nadlisters.NetworkAttachmentDefinitionLister
+	var nadFactory nadinformers.SharedInformerFactory
+	if config.OVNKubernetesFeature.EnableMultiNetwork {
+		nadFactory = nadinformers.NewSharedInformerFactory(ovnClient.NetworkAttchDefClient, 0)
+		nadInformer := nadFactory.K8sCniCncfIo().V1().NetworkAttachmentDefinitions()
+		nadLister = nadInformer.Lister()
+		synced = append(synced, nadInformer.Informer().HasSynced)
+	}
+
+	trustedUsers := []string{}
+	for _, user := range strings.Split(config.ClusterManager.WebhookTrustedUsers, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			trustedUsers = append(trustedUsers, user)
+		}
+	}
+	validator := newNodeAnnotationValidator(nodeInformer.Lister(), configMapInformer.Lister(), nadLister, trustedUsers)
+
+	informerFactory.Start(stopChan)
+	configMapInformerFactory.Start(stopChan)
+	if nadFactory != nil {
+		nadFactory.Start(stopChan)
+	}
+	if !cache.WaitForCacheSync(stopChan, synced...) {
+		return fmt.Errorf("timed out waiting for the informers of the node annotation webhook to sync")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(NodeAnnotationWebhookPath, validator)
+	certFile, keyFile := config.ClusterManager.WebhookCert, config.ClusterManager.WebhookPrivKey
+	server := &http.Server{
+		Addr:    config.ClusterManager.WebhookBindAddress,
+		Handler: mux,
+		TLSConfig: &tls.Config{
+			// reload the certificate on each handshake so that it can be
+			// rotated without a restart
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+				if err != nil {
+					return nil, fmt.Errorf("error loading the node annotation webhook certificate: %v", err)
+				}
+				return &cert, nil
+			},
+		},
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		go utilwait.Until(func() {
+			klog.Infof("Starting the node annotation webhook at address %q", server.Addr)
+			if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				klog.Errorf("Node annotation webhook at address %q failed: %v", server.Addr, err)
+			}
+		}, 5*time.Second, stopChan)
+
+		<-stopChan
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("Error stopping the node annotation webhook: %v", err)
+		}
+	}()
+	return nil
+}
+
+// ServeHTTP answers an admission review of a node
+func (v *nodeAnnotationValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("invalid admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if err := v.review(review.Request); err != nil {
+		klog.Infof("Rejected the %s of node %s by %s: %v", strings.ToLower(string(review.Request.Operation)),
+			review.Request.Name, review.Request.UserInfo.Username, err)
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: err.Error(),
+		}
+	}
+	review.Request = nil
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.Errorf("Failed to write the admission review response: %v", err)
+	}
+}
+
+func (v *nodeAnnotationValidator) review(request *admissionv1.AdmissionRequest) error {
+	if request.Kind.Kind != "Node" {
+		return nil
+	}
+	var oldNode *corev1.Node
+	node := &corev1.Node{}
+	switch request.Operation {
+	case admissionv1.Create:
+	case admissionv1.Update:
+		oldNode = &corev1.Node{}
+		if err := json.Unmarshal(request.OldObject.Raw, oldNode); err != nil {
+			return fmt.Errorf("failed to decode the old node: %v", err)
+		}
+	default:
+		return nil
+	}
+	if err := json.Unmarshal(request.Object.Raw, node); err != nil {
+		return fmt.Errorf("failed to decode the node: %v", err)
+	}
+	return v.validate(request.UserInfo.Username, oldNode, node)
+}
+
+// validate returns an error if the given user can't make the changes of the
+// host subnet and network ID annotations from the old node, nil on creation,
+// to the node
+func (v *nodeAnnotationValidator) validate(user string, oldNode, node *corev1.Node) error {
+	if v.trustedUsers.Has(user) {
+		return nil
+	}
+	if oldNode == nil {
+		oldNode = &corev1.Node{}
+	}
+	if util.NodeSubnetAnnotationChanged(oldNode, node) {
+		if err := v.validateHostSubnets(oldNode, node); err != nil {
+			return err
+		}
+	}
+	return v.validateNetworkIDs(oldNode, node)
+}
+
+// validateHostSubnets checks that the host subnets added to the node belong to
+// the subnets of their network, and overlap neither its excluded subnets nor
+// the host subnets of the other nodes
+func (v *nodeAnnotationValidator) validateHostSubnets(oldNode, node *corev1.Node) error {
+	hostSubnets, err := util.ParseNodeHostSubnetAnnotationAllNetworks(node)
+	if err != nil {
+		if util.IsAnnotationNotSetError(err) {
+			return nil
+		}
+		return fmt.Errorf("invalid host subnet annotation: %v", err)
+	}
+	oldHostSubnets, _ := util.ParseNodeHostSubnetAnnotationAllNetworks(oldNode)
+	nodes, err := v.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	for netName, subnets := range hostSubnets {
+		var pool []*net.IPNet
+		for _, subnet := range subnets {
+			if containsIPNet(oldHostSubnets[netName], subnet) {
+				continue
+			}
+			if pool == nil {
+				if pool, err = v.networkSubnets(netName); err != nil {
+					return err
+				}
+			}
+			inPool := false
+			for _, poolSubnet := range pool {
+				if util.ContainsCIDR(poolSubnet, subnet) {
+					inPool = true
+					break
+				}
+			}
+			if !inPool {
+				return fmt.Errorf("host subnet %s of network %s is not part of the subnets of the network", subnet, netName)
+			}
+			if netName == types.DefaultNetworkName {
+				for _, excluded := range config.ClusterManager.ExcludeSubnets {
+					if ipNetsOverlap(excluded, subnet) {
+						return fmt.Errorf("host subnet %s of network %s overlaps the excluded subnet %s", subnet,
+							netName, excluded)
+					}
+				}
+			}
+			for _, other := range nodes {
+				if other.Name == node.Name {
+					continue
+				}
+				otherSubnets, err := util.ParseNodeHostSubnetAnnotation(other, netName)
+				if err != nil {
+					continue
+				}
+				for _, otherSubnet := range otherSubnets {
+					if ipNetsOverlap(otherSubnet, subnet) {
+						return fmt.Errorf("host subnet %s of network %s overlaps the host subnet %s of node %s",
+							subnet, netName, otherSubnet, other.Name)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateNetworkIDs checks that the network IDs set on the node are neither
+// used by another network nor different from the ID of the network on the
+// other nodes
+func (v *nodeAnnotationValidator) validateNetworkIDs(oldNode, node *corev1.Node) error {
+	networkIDs, err := util.GetNodeNetworkIDsAnnotationNetworkIDs(node)
+	if err != nil {
+		if util.IsAnnotationNotSetError(err) {
+			return nil
+		}
+		return fmt.Errorf("invalid network ID annotation: %v", err)
+	}
+	oldNetworkIDs, _ := util.GetNodeNetworkIDsAnnotationNetworkIDs(oldNode)
+	var nodes []*corev1.Node
+	for netName, networkID := range networkIDs {
+		if oldID, ok := oldNetworkIDs[netName]; ok && oldID == networkID {
+			continue
+		}
+		for otherNetName, otherID := range networkIDs {
+			if otherNetName != netName && otherID == networkID {
+				return fmt.Errorf("network ID %d of network %s is the one of network %s", networkID, netName, otherNetName)
+			}
+		}
+		if nodes == nil {
+			if nodes, err = v.nodeLister.List(labels.Everything()); err != nil {
+				return fmt.Errorf("failed to list nodes: %v", err)
+			}
+		}
+		for _, other := range nodes {
+			if other.Name == node.Name {
+				continue
+			}
+			otherNetworkIDs, err := util.GetNodeNetworkIDsAnnotationNetworkIDs(other)
+			if err != nil {
+				continue
+			}
+			for otherNetName, otherID := range otherNetworkIDs {
+				if otherNetName == netName && otherID != networkID {
+					return fmt.Errorf("network ID %d of network %s differs from its network ID %d on node %s",
+						networkID, netName, otherID, other.Name)
+				}
+				if otherNetName != netName && otherID == networkID {
+					return fmt.Errorf("network ID %d of network %s is the one of network %s on node %s",
+						networkID, netName, otherNetName, other.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// networkSubnets returns the subnets the host subnets of the given network are
+// allocated from: the cluster subnets, including the additional ones, for the
+// default network, and the subnets of the network attachment definitions of
+// the layer3 secondary networks
+func (v *nodeAnnotationValidator) networkSubnets(netName string) ([]*net.IPNet, error) {
+	var subnets []*net.IPNet
+	if netName == types.DefaultNetworkName {
+		for _, clusterSubnet := range config.Default.ClusterSubnets {
+			subnets = append(subnets, clusterSubnet.CIDR)
+		}
+		cm, err := v.configMapLister.ConfigMaps(config.Kubernetes.OVNConfigNamespace).Get(util.AdditionalClusterSubnetsConfigMapName)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get the additional cluster subnets: %v", err)
+		}
+		if cm != nil {
+			additional, err := util.ParseAdditionalClusterSubnets(cm)
+			if err != nil {
+				klog.Warningf("Ignoring the additional cluster subnets: %v", err)
+			}
+			for _, clusterSubnet := range additional {
+				subnets = append(subnets, clusterSubnet.CIDR)
+			}
+		}
+		return subnets, nil
+	}
+
+	if v.nadLister != nil {
+		nads, err := v.nadLister.List(labels.Everything())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list network attachment definitions: %v", err)
+		}
+		for _, nad := range nads {
+			netInfo, err := util.ParseNADInfo(nad)
+			if err != nil || netInfo.GetNetworkName() != netName || netInfo.TopologyType() != types.Layer3Topology {
+				continue
+			}
+			for _, subnet := range netInfo.Subnets() {
+				subnets = append(subnets, subnet.CIDR)
+			}
+			return subnets, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown network %s", netName)
+}
+
+func containsIPNet(ipNets []*net.IPNet, ipNet *net.IPNet) bool {
+	for _, n := range ipNets {
+		if n.String() == ipNet.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func ipNetsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
@@ -0,0 +1,128 @@
+package clustermanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+var _ = ginkgo.Describe("Cluster manager node annotation webhook", func() {
+	const trustedUser = "system:serviceaccount:ovn-kubernetes:ovnkube-cluster-manager"
+	var validator *nodeAnnotationValidator
+
+	newWebhookTestNode := func(name, subnets, networkIDs string) *corev1.Node {
+		annotations := map[string]string{}
+		if subnets != "" {
+			annotations["k8s.ovn.org/node-subnets"] = subnets
+		}
+		if networkIDs != "" {
+			annotations["k8s.ovn.org/network-ids"] = networkIDs
+		}
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}}
+	}
+
+	ginkgo.BeforeEach(func() {
+		// Restore global default values before each testcase
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		var err error
+		config.Default.ClusterSubnets, err = config.ParseClusterSubnetEntries("10.128.0.0/14/23")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		gomega.Expect(nodeIndexer.Add(newWebhookTestNode("node1", `{"default":["10.128.0.0/23"]}`,
+			`{"default":"0","blue":"1"}`))).To(gomega.Succeed())
+		configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		validator = newNodeAnnotationValidator(listers.NewNodeLister(nodeIndexer),
+			listers.NewConfigMapLister(configMapIndexer), nil, []string{trustedUser})
+	})
+
+	ginkgo.It("accepts a free host subnet of the cluster subnets", func() {
+		node := newWebhookTestNode("node2", `{"default":["10.128.2.0/23"]}`, "")
+		gomega.Expect(validator.validate("admin", nil, node)).To(gomega.Succeed())
+	})
+
+	ginkgo.It("rejects a host subnet of another node", func() {
+		node := newWebhookTestNode("node2", `{"default":["10.128.0.0/24"]}`, "")
+		gomega.Expect(validator.validate("admin", nil, node)).To(gomega.MatchError(
+			gomega.ContainSubstring("overlaps the host subnet 10.128.0.0/23 of node node1")))
+	})
+
+	ginkgo.It("rejects a host subnet out of the cluster subnets", func() {
+		node := newWebhookTestNode("node2", `{"default":["10.200.0.0/23"]}`, "")
+		gomega.Expect(validator.validate("admin", nil, node)).To(gomega.MatchError(
+			gomega.ContainSubstring("is not part of the subnets of the network")))
+	})
+
+	ginkgo.It("rejects a host subnet of an unknown network", func() {
+		node := newWebhookTestNode("node2", `{"red":["10.200.0.0/24"]}`, "")
+		gomega.Expect(validator.validate("admin", nil, node)).To(gomega.MatchError(
+			gomega.ContainSubstring("unknown network red")))
+	})
+
+	ginkgo.It("only validates the host subnets added to the node", func() {
+		oldNode := newWebhookTestNode("node2", `{"default":["10.200.0.0/23"]}`, "")
+		node := newWebhookTestNode("node2", `{"default":["10.200.0.0/23"]}`, "")
+		node.Labels = map[string]string{"foo": "bar"}
+		gomega.Expect(validator.validate("admin", oldNode, node)).To(gomega.Succeed())
+	})
+
+	ginkgo.It("rejects duplicate and inconsistent network IDs", func() {
+		node := newWebhookTestNode("node2", "", `{"default":"0","blue":"0"}`)
+		gomega.Expect(validator.validate("admin", nil, node)).To(gomega.MatchError(
+			gomega.ContainSubstring("is the one of network")))
+		node = newWebhookTestNode("node2", "", `{"default":"0","blue":"2"}`)
+		gomega.Expect(validator.validate("admin", nil, node)).To(gomega.MatchError(
+			gomega.ContainSubstring("differs from its network ID 1 on node node1")))
+		node = newWebhookTestNode("node2", "", `{"default":"0","blue":"1"}`)
+		gomega.Expect(validator.validate("admin", nil, node)).To(gomega.Succeed())
+	})
+
+	ginkgo.It("does not validate the edits of the trusted users", func() {
+		node := newWebhookTestNode("node2", `{"default":["10.128.0.0/24"]}`, `{"default":"0","blue":"2"}`)
+		gomega.Expect(validator.validate(trustedUser, nil, node)).To(gomega.Succeed())
+	})
+
+	ginkgo.It("answers admission reviews", func() {
+		oldNode := newWebhookTestNode("node1", `{"default":["10.128.0.0/23"]}`, "")
+		node := newWebhookTestNode("node1", `{"default":["10.200.0.0/23"]}`, "")
+		oldRaw, err := json.Marshal(oldNode)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		raw, err := json.Marshal(node)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		body, err := json.Marshal(&admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+			Request: &admissionv1.AdmissionRequest{
+				UID:       "uid1",
+				Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Node"},
+				Name:      "node1",
+				Operation: admissionv1.Update,
+				UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+				Object:    runtime.RawExtension{Raw: raw},
+				OldObject: runtime.RawExtension{Raw: oldRaw},
+			},
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		recorder := httptest.NewRecorder()
+		validator.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, NodeAnnotationWebhookPath, bytes.NewReader(body)))
+		gomega.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+		review := &admissionv1.AdmissionReview{}
+		gomega.Expect(json.Unmarshal(recorder.Body.Bytes(), review)).To(gomega.Succeed())
+		gomega.Expect(review.Response.UID).To(gomega.BeEquivalentTo("uid1"))
+		gomega.Expect(review.Response.Allowed).To(gomega.BeFalse())
+		gomega.Expect(review.Response.Result.Code).To(gomega.BeEquivalentTo(http.StatusForbidden))
+	})
+})
@@ -0,0 +1,144 @@
+package clustermanager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+// nodeFinalizerTimeout is how long after the deletion of a node was requested
+// the node finalizer is removed even if the zone controller of the node didn't
+// clean up its topology, so that a zone controller that is down or keeps
+// failing doesn't block the node deletion forever
+const nodeFinalizerTimeout = 2 * time.Minute
+
+// nodeFinalizerController enforces the timeout of the node finalizer, set by
+// the zone controllers to hold the deletion of their nodes until their
+// topology is cleaned up. Enforcing it from the cluster manager covers the
+// nodes whose zone controller is down, like the zone controller running on the
+// deleted node itself. The zone controller cleans up the topology of the node
+// on its delete event once back.
+type nodeFinalizerController struct {
+	kube         kube.Interface
+	watchFactory *factory.WatchFactory
+	timeout      time.Duration
+	// queue holds the nodes being deleted, until their finalizer times out
+	queue workqueue.RateLimitingInterface
+}
+
+func newNodeFinalizerController(kube kube.Interface, wf *factory.WatchFactory) *nodeFinalizerController {
+	return &nodeFinalizerController{
+		kube:         kube,
+		watchFactory: wf,
+		timeout:      nodeFinalizerTimeout,
+		queue: workqueue.NewNamedRateLimitingQueue(
+			workqueue.NewItemFastSlowRateLimiter(1*time.Second, 5*time.Second, 5),
+			"nodefinalizer",
+		),
+	}
+}
+
+// Run removes the timed out node finalizers until stopCh is closed
+func (c *nodeFinalizerController) Run(stopCh <-chan struct{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			for c.processNextWorkItem() {
+			}
+		}, time.Second, stopCh)
+	}()
+	go func() {
+		<-stopCh
+		c.queue.ShutDown()
+	}()
+}
+
+// enqueue schedules the removal of the finalizer of the node once it timed
+// out, if the node is being deleted
+func (c *nodeFinalizerController) enqueue(node *corev1.Node) {
+	if !isNodeFinalizerPending(node) {
+		return
+	}
+	c.queue.AddAfter(node.Name, c.remaining(node))
+}
+
+// remaining returns how long until the finalizer of the node times out
+func (c *nodeFinalizerController) remaining(node *corev1.Node) time.Duration {
+	return time.Until(node.DeletionTimestamp.Add(c.timeout))
+}
+
+func (c *nodeFinalizerController) processNextWorkItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	err := c.sync(key.(string))
+	if err == nil {
+		c.queue.Forget(key)
+		return true
+	}
+	utilruntime.HandleError(fmt.Errorf("failed to enforce the finalizer timeout of node %v: %w", key, err))
+	c.queue.AddRateLimited(key)
+	return true
+}
+
+// sync removes the finalizer of the node if it timed out, or schedules it
+// again if not yet
+func (c *nodeFinalizerController) sync(nodeName string) error {
+	node, err := c.watchFactory.GetNode(nodeName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isNodeFinalizerPending(node) {
+		return nil
+	}
+	if remaining := c.remaining(node); remaining > 0 {
+		c.queue.AddAfter(nodeName, remaining)
+		return nil
+	}
+
+	klog.Warningf("The topology of node %s was not cleaned up within %v of its deletion, removing its finalizer",
+		nodeName, c.timeout)
+	newNode := node.DeepCopy()
+	newNode.Finalizers = make([]string, 0, len(node.Finalizers))
+	for _, finalizer := range node.Finalizers {
+		if finalizer != ovntypes.OvnK8sNodeFinalizer {
+			newNode.Finalizers = append(newNode.Finalizers, finalizer)
+		}
+	}
+	if err := c.kube.PatchNode(node, newNode); err != nil {
+		return fmt.Errorf("failed to remove finalizer from node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// isNodeFinalizerPending returns true if the node is being deleted and its
+// deletion is waiting on the node finalizer
+func isNodeFinalizerPending(node *corev1.Node) bool {
+	if node.DeletionTimestamp == nil {
+		return false
+	}
+	for _, finalizer := range node.Finalizers {
+		if finalizer == ovntypes.OvnK8sNodeFinalizer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package clustermanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var _ = ginkgo.Describe("Cluster manager node finalizer", func() {
+	var (
+		f   *factory.WatchFactory
+		zcc *zoneClusterController
+	)
+
+	newDeletedNode := func(name string, deleted time.Time) *corev1.Node {
+		deletionTimestamp := metav1.NewTime(deleted)
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				DeletionTimestamp: &deletionTimestamp,
+				Finalizers:        []string{"example.com/other", ovntypes.OvnK8sNodeFinalizer},
+			},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		config.Kubernetes.HostNetworkNamespace = ""
+		f = nil
+		zcc = nil
+	})
+
+	ginkgo.AfterEach(func() {
+		if zcc != nil {
+			zcc.Stop()
+		}
+		if f != nil {
+			f.Shutdown()
+		}
+	})
+
+	ginkgo.It("removes the node finalizers the zone controllers didn't remove in time", func() {
+		// the zone controller of node1 didn't clean it up in time while node2
+		// was just deleted
+		kubeClient := fake.NewSimpleClientset(
+			newDeletedNode("node1", time.Now().Add(-time.Hour)),
+			newDeletedNode("node2", time.Now()),
+		)
+		fakeClient := &util.OVNClusterManagerClientset{KubeClient: kubeClient}
+		var err error
+		f, err = factory.NewClusterManagerWatchFactory(fakeClient)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(f.Start()).To(gomega.Succeed())
+		zcc, err = newZoneClusterController(fakeClient, f)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		zcc.nodeFinalizer.timeout = 5 * time.Second
+		gomega.Expect(zcc.Start(context.TODO())).To(gomega.Succeed())
+
+		getNodeFinalizers := func(name string) func() []string {
+			return func() []string {
+				node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				return node.Finalizers
+			}
+		}
+		gomega.Eventually(getNodeFinalizers("node1")).Should(gomega.Equal([]string{"example.com/other"}))
+		gomega.Consistently(getNodeFinalizers("node2"), time.Second).Should(
+			gomega.ContainElement(ovntypes.OvnK8sNodeFinalizer))
+		gomega.Eventually(getNodeFinalizers("node2"), 6*time.Second).Should(gomega.Equal([]string{"example.com/other"}))
+	})
+})
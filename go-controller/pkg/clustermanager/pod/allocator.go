@@ -39,6 +39,14 @@ type PodAllocator struct {
 	// release more than once
 	releasedPods      map[string]sets.Set[string]
 	releasedPodsMutex sync.Mutex
+
+	// nodeLister, if set, gets the IP chunks of the nodes the pod IPs are
+	// allocated from
+	nodeLister listers.NodeLister
+
+	// nodeIPChunks, if set, tracks the IP chunks of the IP pool of each node
+	nodeIPChunks     map[string][]*net.IPNet
+	nodeIPChunksLock sync.Mutex
 }
 
 // NewPodAllocator builds a new PodAllocator
@@ -77,9 +85,18 @@ func (a *PodAllocator) Init() error {
 		if err != nil {
 			return err
 		}
+		// the port of the cluster router of a primary layer2 user defined
+		// network has its own reserved tunnel key
+		if a.netInfo.IsSecondary() && a.netInfo.IsPrimaryNetwork() && a.netInfo.TopologyType() == types.Layer2Topology {
+			err = a.idAllocator.ReserveID("router", types.Layer2RouterPortTunnelKey)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	if util.DoesNetworkRequireIPAM(a.netInfo) {
+	// the IP pools of the nodes are added as their pods get allocated
+	if util.DoesNetworkRequireIPAM(a.netInfo) && !a.hasNodeIPChunks() {
 		subnets := a.netInfo.Subnets()
 		ipNets := make([]*net.IPNet, 0, len(subnets))
 		for _, subnet := range subnets {
@@ -92,6 +109,27 @@ func (a *PodAllocator) Init() error {
 	return nil
 }
 
+// GetIPUsage returns the number of allocated and free pod IPs of the network.
+// It returns zero values if the network has no IPAM.
+func (a *PodAllocator) GetIPUsage() (uint64, uint64, error) {
+	if a.ipAllocator == nil {
+		return 0, 0, nil
+	}
+	if a.hasNodeIPChunks() {
+		return a.getNodeIPChunksUsage()
+	}
+	return a.ipAllocator.GetUsage(a.netInfo.GetNetworkName())
+}
+
+// GetTunnelIDUsage returns the number of used and free tunnel IDs of the
+// network. It returns zero values if the network does not require tunnel IDs.
+func (a *PodAllocator) GetTunnelIDUsage() (int, int) {
+	if a.idAllocator == nil {
+		return 0, 0
+	}
+	return a.idAllocator.Usage()
+}
+
 // Reconcile allocates or releases IPs for pods updating the pod annotation
 // as necessary with all the additional information derived from those IPs
 func (a *PodAllocator) Reconcile(old, new *corev1.Pod) error {
@@ -210,8 +248,14 @@ func (a *PodAllocator) releasePodOnNAD(pod *corev1.Pod, nad string, podDeleted,
 		klog.V(5).Infof("Released ID %d", podAnnotation.TunnelID)
 	}
 
+	var poolName string
 	if doReleaseIPs {
-		err := a.ipAllocator.ReleaseIPs(a.netInfo.GetNetworkName(), podAnnotation.IPs)
+		// the IP pool of the IPs may be gone along with their node
+		poolName, doReleaseIPs = a.nodeIPPoolName(podAnnotation.IPs)
+	}
+
+	if doReleaseIPs {
+		err := a.ipAllocator.ReleaseIPs(poolName, podAnnotation.IPs)
 		if err != nil {
 			return fmt.Errorf("failed to release ips %v for pod %s/%s and nad %s: %w",
 				util.StringSlice(podAnnotation.IPs),
@@ -237,6 +281,12 @@ func (a *PodAllocator) allocatePodOnNAD(pod *corev1.Pod, nad string, network *ne
 	var ipAllocator subnet.NamedAllocator
 	if util.DoesNetworkRequireIPAM(a.netInfo) {
 		ipAllocator = a.ipAllocator.ForSubnet(a.netInfo.GetNetworkName())
+		if a.hasNodeIPChunks() {
+			if err := a.ensureNodeIPChunks(pod.Spec.NodeName); err != nil {
+				return err
+			}
+			ipAllocator = a.ipAllocator.ForSubnet(pod.Spec.NodeName)
+		}
 	}
 
 	var idAllocator id.NamedAllocator
@@ -14,15 +14,19 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/pod"
 	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
+	cnitypes "github.com/containernetworking/cni/pkg/types"
 	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apitypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 
 	kubemocks "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube/mocks"
 	v1mocks "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/mocks/k8s.io/client-go/listers/core/v1"
@@ -112,6 +116,14 @@ func (a *ipAllocatorStub) GetSubnetName([]*net.IPNet) (string, bool) {
 	panic("not implemented") // TODO: Implement
 }
 
+func (a *ipAllocatorStub) GetUsage(name string) (uint64, uint64, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (a *ipAllocatorStub) GetExhaustedSubnets(name string) ([]*net.IPNet, error) {
+	panic("not implemented") // TODO: Implement
+}
+
 type idAllocatorStub struct {
 	released bool
 }
@@ -132,6 +144,10 @@ func (a *idAllocatorStub) ForName(name string) id.NamedAllocator {
 	panic("not implemented") // TODO: Implement
 }
 
+func (a *idAllocatorStub) Usage() (int, int) {
+	panic("not implemented") // TODO: Implement
+}
+
 func (a *idAllocatorStub) GetSubnetName([]*net.IPNet) (string, bool) {
 	panic("not implemented") // TODO: Implement
 }
@@ -480,3 +496,156 @@ func TestPodAllocator_reconcileForNAD(t *testing.T) {
 		})
 	}
 }
+
+func TestPodAllocator_NodeIPChunks(t *testing.T) {
+	config.OVNKubernetesFeature.EnableInterconnect = false
+	netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:         cnitypes.NetConf{Name: "l2-network"},
+		Topology:        types.Layer2Topology,
+		Subnets:         "10.1.130.0/24",
+		ExcludeSubnets:  "10.1.130.65/32",
+		NodeIPChunkSize: 64,
+	})
+	if err != nil {
+		t.Fatalf("Invalid netConf: %v", err)
+	}
+	netInfo.AddNAD("namespace/nad")
+
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	setNodeIPChunks := func(nodeName, chunk string) {
+		annotations, err := util.UpdateNodeHostSubnetAnnotation(map[string]string{},
+			[]*net.IPNet{ovntest.MustParseIPNet(chunk)}, netInfo.GetNetworkName())
+		if err != nil {
+			t.Fatalf("Failed to annotate node %s: %v", nodeName, err)
+		}
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Annotations: annotations}}
+		if err := nodeIndexer.Add(node); err != nil {
+			t.Fatalf("Failed to add node %s: %v", nodeName, err)
+		}
+	}
+	setNodeIPChunks("node1", "10.1.130.64/26")
+
+	podListerMock := &v1mocks.PodLister{}
+	podNamespaceLister := &v1mocks.PodNamespaceLister{}
+	podListerMock.On("Pods", mock.AnythingOfType("string")).Return(podNamespaceLister)
+	kubeMock := &kubemocks.Interface{}
+	updatedPods := map[string]*corev1.Pod{}
+	kubeMock.On("UpdatePodStatus", mock.AnythingOfType(fmt.Sprintf("%T", &corev1.Pod{}))).Run(
+		func(args mock.Arguments) {
+			pod := args.Get(0).(*corev1.Pod)
+			updatedPods[pod.Name] = pod
+		},
+	).Return(nil)
+
+	a := NewPodAllocator(netInfo, podListerMock, kubeMock)
+	a.EnableNodeIPChunks(listers.NewNodeLister(nodeIndexer))
+	if err := a.Init(); err != nil {
+		t.Fatalf("Failed to init the pod allocator: %v", err)
+	}
+
+	allocate := func(name, nodeName string) *net.IPNet {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				UID:       apitypes.UID(name),
+				Namespace: "namespace",
+				Annotations: map[string]string{
+					nadapi.NetworkAttachmentAnnot: `[{"name": "nad"}]`,
+				},
+			},
+			Spec: corev1.PodSpec{NodeName: nodeName},
+		}
+		podNamespaceLister.On("Get", name).Return(pod, nil)
+		if err := a.reconcile(nil, pod, true); err != nil {
+			t.Fatalf("Failed to allocate pod %s: %v", name, err)
+		}
+		if updatedPods[name] == nil {
+			t.Fatalf("Expected pod %s to be allocated", name)
+		}
+		podAnnotation, err := util.UnmarshalPodAnnotation(updatedPods[name].Annotations, "namespace/nad")
+		if err != nil || len(podAnnotation.IPs) != 1 {
+			t.Fatalf("Failed to get the IPs of pod %s: %v", name, err)
+		}
+		return podAnnotation.IPs[0]
+	}
+
+	// the pods get IPs of the IP chunk of their node, but its excluded IPs
+	for i := 0; i < 3; i++ {
+		ip := allocate(fmt.Sprintf("pod%d", i), "node1")
+		if !ovntest.MustParseIPNet("10.1.130.64/26").Contains(ip.IP) || ip.IP.String() == "10.1.130.65" {
+			t.Errorf("Expected an IP of the IP chunk of node1, got %s", ip)
+		}
+	}
+	// the excluded IP counts as allocated
+	used, _, err := a.GetIPUsage()
+	if err != nil || used != 4 {
+		t.Errorf("Expected 4 allocated IPs, got %d: %v", used, err)
+	}
+
+	// a node without IP chunk fails to allocate until it gets one
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-node2", UID: "pod-node2", Namespace: "namespace",
+			Annotations: map[string]string{nadapi.NetworkAttachmentAnnot: `[{"name": "nad"}]`}},
+		Spec: corev1.PodSpec{NodeName: "node2"},
+	}
+	if err := a.reconcile(nil, pod, true); err == nil {
+		t.Errorf("Expected the allocation of a pod of a node without IP chunk to fail")
+	}
+
+	// the IP chunk of a deleted node allocated to another node moves to the
+	// IP pool of the new node
+	setNodeIPChunks("node2", "10.1.130.64/26")
+	if ip := allocate("pod-node2", "node2"); !ovntest.MustParseIPNet("10.1.130.64/26").Contains(ip.IP) {
+		t.Errorf("Expected an IP of the IP chunk of node2, got %s", ip)
+	}
+	used, _, err = a.GetIPUsage()
+	if err != nil || used != 2 {
+		t.Errorf("Expected 2 allocated IPs, got %d: %v", used, err)
+	}
+}
+
+func TestPodAllocator_InitRouterPortTunnelKey(t *testing.T) {
+	config.OVNKubernetesFeature.EnableInterconnect = true
+	t.Cleanup(func() { config.OVNKubernetesFeature.EnableInterconnect = false })
+
+	tests := []struct {
+		name          string
+		role          string
+		expectFirstID int
+	}{
+		{
+			name:          "primary layer2 network",
+			role:          types.NetworkRolePrimary,
+			expectFirstID: types.Layer2RouterPortTunnelKey + 1,
+		},
+		{
+			name:          "secondary layer2 network",
+			role:          types.NetworkRoleSecondary,
+			expectFirstID: types.Layer2RouterPortTunnelKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+				NetConf:  cnitypes.NetConf{Name: "l2-network"},
+				Topology: types.Layer2Topology,
+				Subnets:  "10.1.130.0/24",
+				Role:     tt.role,
+			})
+			if err != nil {
+				t.Fatalf("Invalid netConf: %v", err)
+			}
+			a := NewPodAllocator(netInfo, &v1mocks.PodLister{}, &kubemocks.Interface{})
+			if err := a.Init(); err != nil {
+				t.Fatalf("Failed to init the pod allocator: %v", err)
+			}
+			id, err := a.idAllocator.AllocateID("pod")
+			if err != nil {
+				t.Fatalf("Failed to allocate a tunnel key: %v", err)
+			}
+			if id != tt.expectFirstID {
+				t.Fatalf("Expected the first tunnel key %d, got %d", tt.expectFirstID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/id"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/ip"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/ip/subnet"
+	hostv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
+	hostclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/clientset/versioned"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// HostAllocator acts on Host events handed off by the cluster network
+// controller and allocates or releases the IPs, MAC and tunnel ID of the
+// standalone hosts attached to a layer2 network. It allocates from the same
+// pools as the PodAllocator of the network so that hosts and pods never get
+// the same addresses.
+type HostAllocator struct {
+	netInfo util.NetInfo
+
+	client hostclientset.Interface
+
+	// ipAllocator of IPs within subnets, shared with the PodAllocator
+	ipAllocator subnet.Allocator
+
+	// idAllocator of IDs within the network, shared with the PodAllocator
+	idAllocator id.Allocator
+
+	// hosts tracks the status allocated to each host of the network
+	hosts     map[string]hostv1.HostStatus
+	hostsLock sync.Mutex
+}
+
+// NewHostAllocator builds a new HostAllocator sharing the allocators of the
+// PodAllocator. The PodAllocator must be initialized and the network must
+// have IPAM.
+func (a *PodAllocator) NewHostAllocator(client hostclientset.Interface) *HostAllocator {
+	return &HostAllocator{
+		netInfo:     a.netInfo,
+		client:      client,
+		ipAllocator: a.ipAllocator,
+		idAllocator: a.idAllocator,
+		hosts:       map[string]hostv1.HostStatus{},
+	}
+}
+
+// Sync reserves the addresses of the hosts that already have a status. It
+// needs to run before any pod or host gets allocated new addresses.
+func (a *HostAllocator) Sync(objs []interface{}) error {
+	a.hostsLock.Lock()
+	defer a.hostsLock.Unlock()
+	for _, obj := range objs {
+		host, ok := obj.(*hostv1.Host)
+		if !ok {
+			klog.Errorf("Could not cast %T object to *hostv1.Host", obj)
+			continue
+		}
+		if !a.isOnNetwork(host) || len(host.Status.IPAddresses) == 0 {
+			continue
+		}
+		if _, ok := a.hosts[host.Name]; ok {
+			continue
+		}
+		if err := a.reserve(host); err != nil {
+			klog.Errorf("Failed to sync host %s: %v", host.Name, err)
+		}
+	}
+	return nil
+}
+
+// Reconcile allocates addresses to a new host or releases the addresses of a
+// deleted host
+func (a *HostAllocator) Reconcile(old, new *hostv1.Host) error {
+	a.hostsLock.Lock()
+	defer a.hostsLock.Unlock()
+	if new == nil {
+		if old != nil && a.isOnNetwork(old) {
+			return a.release(old.Name)
+		}
+		return nil
+	}
+	if !a.isOnNetwork(new) {
+		return nil
+	}
+	status, ok := a.hosts[new.Name]
+	if ok && reflect.DeepEqual(status, new.Status) {
+		return nil
+	}
+	if !ok && len(new.Status.IPAddresses) > 0 {
+		// allocated by a previous instance, reserve what is already there
+		return a.reserve(new)
+	}
+	if ok {
+		// the status was modified or cleared out of band, restore it
+		return a.updateStatus(new, status)
+	}
+	return a.allocate(new)
+}
+
+func (a *HostAllocator) isOnNetwork(host *hostv1.Host) bool {
+	return host.Spec.Network == a.netInfo.GetNetworkName()
+}
+
+func (a *HostAllocator) reserve(host *hostv1.Host) error {
+	ips, err := util.ParseIPNets(host.Status.IPAddresses)
+	if err != nil {
+		return fmt.Errorf("failed to parse IPs of host %s: %w", host.Name, err)
+	}
+	if err = a.ipAllocator.AllocateIPs(a.netInfo.GetNetworkName(), ips); err != nil && !ip.IsErrAllocated(err) {
+		return fmt.Errorf("failed to reserve IPs %v of host %s: %w", util.StringSlice(ips), host.Name, err)
+	}
+	if a.idAllocator != nil && host.Status.TunnelID > 0 {
+		if err = a.idAllocator.ReserveID(hostIdAllocationName(host.Name), host.Status.TunnelID); err != nil {
+			return fmt.Errorf("failed to reserve tunnel ID %d of host %s: %w", host.Status.TunnelID, host.Name, err)
+		}
+	}
+	a.hosts[host.Name] = host.Status
+	return nil
+}
+
+func (a *HostAllocator) allocate(host *hostv1.Host) error {
+	ips, err := a.ipAllocator.AllocateNextIPs(a.netInfo.GetNetworkName())
+	if err != nil {
+		return fmt.Errorf("failed to allocate IPs for host %s: %w", host.Name, err)
+	}
+	status := hostv1.HostStatus{
+		MACAddress: util.IPAddrToHWAddr(ips[0].IP).String(),
+	}
+	for _, ipNet := range ips {
+		status.IPAddresses = append(status.IPAddresses, ipNet.String())
+	}
+	if a.idAllocator != nil {
+		status.TunnelID, err = a.idAllocator.AllocateID(hostIdAllocationName(host.Name))
+		if err != nil {
+			a.releaseIPs(host.Name, ips)
+			return fmt.Errorf("failed to allocate tunnel ID for host %s: %w", host.Name, err)
+		}
+	}
+	if err = a.updateStatus(host, status); err != nil {
+		a.releaseIPs(host.Name, ips)
+		if a.idAllocator != nil {
+			a.idAllocator.ReleaseID(hostIdAllocationName(host.Name))
+		}
+		return err
+	}
+	a.hosts[host.Name] = status
+	klog.V(5).Infof("Allocated IP addresses %v, mac address %s and tunnel id %d for host %s",
+		status.IPAddresses, status.MACAddress, status.TunnelID, host.Name)
+	return nil
+}
+
+func (a *HostAllocator) updateStatus(host *hostv1.Host, status hostv1.HostStatus) error {
+	host = host.DeepCopy()
+	host.Status = status
+	_, err := a.client.K8sV1().Hosts().UpdateStatus(context.TODO(), host, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update status of host %s: %w", host.Name, err)
+	}
+	return nil
+}
+
+func (a *HostAllocator) release(name string) error {
+	status, ok := a.hosts[name]
+	if !ok {
+		return nil
+	}
+	ips, err := util.ParseIPNets(status.IPAddresses)
+	if err != nil {
+		return fmt.Errorf("failed to parse IPs of host %s: %w", name, err)
+	}
+	a.releaseIPs(name, ips)
+	if a.idAllocator != nil {
+		a.idAllocator.ReleaseID(hostIdAllocationName(name))
+	}
+	delete(a.hosts, name)
+	klog.V(5).Infof("Released IPs %v and tunnel id %d of host %s", status.IPAddresses, status.TunnelID, name)
+	return nil
+}
+
+func (a *HostAllocator) releaseIPs(name string, ips []*net.IPNet) {
+	if err := a.ipAllocator.ReleaseIPs(a.netInfo.GetNetworkName(), ips); err != nil {
+		klog.Errorf("Failed to release IPs %v of host %s: %v", util.StringSlice(ips), name, err)
+	}
+}
+
+func hostIdAllocationName(name string) string {
+	return fmt.Sprintf("host/%s", name)
+}
@@ -0,0 +1,94 @@
+package pod
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+	"github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	hostv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
+	hostfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/clientset/versioned/fake"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func newTestHost(name, network string, status hostv1.HostStatus) *hostv1.Host {
+	return &hostv1.Host{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       hostv1.HostSpec{Network: network},
+		Status:     status,
+	}
+}
+
+func TestHostAllocator(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+	config.OVNKubernetesFeature.EnableInterconnect = true
+
+	netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "blue"},
+		Topology: types.Layer2Topology,
+		Subnets:  "10.1.130.0/24",
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	podAllocator := NewPodAllocator(netInfo, nil, nil)
+	g.Expect(podAllocator.Init()).To(gomega.Succeed())
+
+	existing := newTestHost("existing", "blue", hostv1.HostStatus{
+		IPAddresses: []string{"10.1.130.1/24"},
+		MACAddress:  "0a:58:0a:01:82:01",
+		TunnelID:    1,
+	})
+	added := newTestHost("added", "blue", hostv1.HostStatus{})
+	other := newTestHost("other", "red", hostv1.HostStatus{})
+	client := hostfake.NewSimpleClientset(existing, added, other)
+	a := podAllocator.NewHostAllocator(client)
+
+	// the addresses of the existing host are reserved on sync
+	g.Expect(a.Sync([]interface{}{existing, added, other})).To(gomega.Succeed())
+	allocated, _, err := podAllocator.GetIPUsage()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(allocated).To(gomega.BeEquivalentTo(1))
+	used, _ := podAllocator.GetTunnelIDUsage()
+	// the zero ID is always reserved
+	g.Expect(used).To(gomega.Equal(2))
+
+	// a new host gets new addresses written to its status
+	g.Expect(a.Reconcile(nil, added)).To(gomega.Succeed())
+	updated, err := client.K8sV1().Hosts().Get(context.TODO(), added.Name, metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(updated.Status.IPAddresses).To(gomega.HaveLen(1))
+	g.Expect(updated.Status.IPAddresses).NotTo(gomega.ContainElement("10.1.130.1/24"))
+	ip, _, err := net.ParseCIDR(updated.Status.IPAddresses[0])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(updated.Status.MACAddress).To(gomega.Equal(util.IPAddrToHWAddr(ip).String()))
+	g.Expect(updated.Status.TunnelID).To(gomega.BeNumerically(">", 1))
+
+	// the update event of the status is a no-op
+	g.Expect(a.Reconcile(added, updated)).To(gomega.Succeed())
+	allocated, _, err = podAllocator.GetIPUsage()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(allocated).To(gomega.BeEquivalentTo(2))
+
+	// hosts on other networks are ignored
+	g.Expect(a.Reconcile(nil, other)).To(gomega.Succeed())
+	ignored, err := client.K8sV1().Hosts().Get(context.TODO(), other.Name, metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ignored.Status).To(gomega.Equal(hostv1.HostStatus{}))
+
+	// deleted hosts release their addresses
+	g.Expect(a.Reconcile(updated, nil)).To(gomega.Succeed())
+	g.Expect(a.Reconcile(existing, nil)).To(gomega.Succeed())
+	allocated, _, err = podAllocator.GetIPUsage()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(allocated).To(gomega.BeEquivalentTo(0))
+	used, _ = podAllocator.GetTunnelIDUsage()
+	g.Expect(used).To(gomega.Equal(1))
+}
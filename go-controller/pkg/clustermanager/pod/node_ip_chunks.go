@@ -0,0 +1,121 @@
+package pod
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// EnableNodeIPChunks allocates the IPs of the pods of each node from the IP
+// chunks of the subnets allocated to the node by the NodeAllocator, found in
+// the node subnet annotation, instead of from the whole subnets, so that the
+// pods of different nodes don't compete for the same IPs. No-op unless the
+// network has node IP chunks, must be called before Init.
+func (a *PodAllocator) EnableNodeIPChunks(nodeLister listers.NodeLister) {
+	if a.ipAllocator == nil || a.netInfo.NodeIPChunkSize() <= 0 {
+		return
+	}
+	a.nodeLister = nodeLister
+	a.nodeIPChunks = map[string][]*net.IPNet{}
+}
+
+// hasNodeIPChunks returns true if the pod IPs are allocated from the IP chunks
+// of their nodes
+func (a *PodAllocator) hasNodeIPChunks() bool {
+	return a.nodeIPChunks != nil
+}
+
+// ensureNodeIPChunks makes sure that the IP pool of the node, named after it,
+// holds the IP chunks of its node subnet annotation. The pools of other nodes
+// holding the same IP chunks, like those of deleted nodes whose IP chunks were
+// allocated to the node, are removed.
+func (a *PodAllocator) ensureNodeIPChunks(nodeName string) error {
+	node, err := a.nodeLister.Get(nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	chunks, err := util.ParseNodeHostSubnetAnnotation(node, a.netInfo.GetNetworkName())
+	if err != nil {
+		// the node is yet to get its IP chunks, the pod is retried
+		return fmt.Errorf("failed to get the IP chunks of node %s: %w", nodeName, err)
+	}
+
+	a.nodeIPChunksLock.Lock()
+	defer a.nodeIPChunksLock.Unlock()
+	if reflect.DeepEqual(a.nodeIPChunks[nodeName], chunks) {
+		return nil
+	}
+	for otherNode, otherChunks := range a.nodeIPChunks {
+		if otherNode != nodeName && overlaps(otherChunks, chunks) {
+			klog.Infof("Removing the IP pool of node %s, its IP chunks %v overlap those of node %s",
+				otherNode, util.StringSlice(otherChunks), nodeName)
+			a.ipAllocator.DeleteSubnet(otherNode)
+			delete(a.nodeIPChunks, otherNode)
+		}
+	}
+	if err := a.ipAllocator.AddOrUpdateSubnet(nodeName, chunks, a.excludedFromIPChunks(chunks)...); err != nil {
+		return fmt.Errorf("failed to add the IP chunks %v of node %s: %w", util.StringSlice(chunks), nodeName, err)
+	}
+	a.nodeIPChunks[nodeName] = chunks
+	klog.V(5).Infof("Added the IP chunks %v of node %s", util.StringSlice(chunks), nodeName)
+	return nil
+}
+
+// excludedFromIPChunks returns the parts of the IP chunks covered by the
+// excluded subnets of the network
+func (a *PodAllocator) excludedFromIPChunks(chunks []*net.IPNet) []*net.IPNet {
+	var excluded []*net.IPNet
+	for _, excludeSubnet := range a.netInfo.ExcludeSubnets() {
+		for _, chunk := range chunks {
+			switch {
+			case util.ContainsCIDR(chunk, excludeSubnet):
+				excluded = append(excluded, excludeSubnet)
+			case util.ContainsCIDR(excludeSubnet, chunk):
+				excluded = append(excluded, chunk)
+			}
+		}
+	}
+	return excluded
+}
+
+// nodeIPPoolName returns the name of the IP pool the given IPs were allocated
+// from, false if none holds them
+func (a *PodAllocator) nodeIPPoolName(ips []*net.IPNet) (string, bool) {
+	if !a.hasNodeIPChunks() {
+		return a.netInfo.GetNetworkName(), true
+	}
+	return a.ipAllocator.GetSubnetName(ips)
+}
+
+// getNodeIPChunksUsage returns the number of allocated and free IPs of all the
+// IP chunks of the nodes
+func (a *PodAllocator) getNodeIPChunksUsage() (uint64, uint64, error) {
+	a.nodeIPChunksLock.Lock()
+	defer a.nodeIPChunksLock.Unlock()
+	var used, free uint64
+	for nodeName := range a.nodeIPChunks {
+		nodeUsed, nodeFree, err := a.ipAllocator.GetUsage(nodeName)
+		if err != nil {
+			return 0, 0, err
+		}
+		used += nodeUsed
+		free += nodeFree
+	}
+	return used, free, nil
+}
+
+func overlaps(subnets, others []*net.IPNet) bool {
+	for _, subnet := range subnets {
+		for _, other := range others {
+			if subnet.Contains(other.IP) || other.Contains(subnet.IP) {
+				return true
+			}
+		}
+	}
+	return false
+}
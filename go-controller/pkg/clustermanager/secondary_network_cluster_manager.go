@@ -11,6 +11,7 @@ import (
 	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	nad "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/network-attach-def-controller"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
@@ -31,10 +32,29 @@ type secondaryNetworkClusterManager struct {
 	watchFactory  *factory.WatchFactory
 	// networkIDAllocator is used to allocate a unique ID for each secondary layer3 network
 	networkIDAllocator id.Allocator
+	// nodeAnnotationBatcher, if set, coalesces the updates of the node
+	// annotations of the networks
+	nodeAnnotationBatcher *kube.NodeAnnotationBatcher
+	// recorder posts the host subnet allocation failures of the networks as
+	// events
+	recorder record.EventRecorder
+	// joinSubnetAllocator, if set, avoids the subnets of the networks for the
+	// join subnet addresses of the nodes
+	joinSubnetAllocator *joinSubnetAllocator
+	// shard, if set, restricts the networks managed to those of the shard
+	shard *networkShard
 }
 
 func newSecondaryNetworkClusterManager(ovnClient *util.OVNClusterManagerClientset,
 	wf *factory.WatchFactory, recorder record.EventRecorder) (*secondaryNetworkClusterManager, error) {
+	return newShardedSecondaryNetworkClusterManager(ovnClient, wf, nil, recorder)
+}
+
+// newShardedSecondaryNetworkClusterManager creates a secondary network cluster
+// manager managing only the networks of the shard, or all of them if shard is
+// nil
+func newShardedSecondaryNetworkClusterManager(ovnClient *util.OVNClusterManagerClientset,
+	wf *factory.WatchFactory, shard *networkShard, recorder record.EventRecorder) (*secondaryNetworkClusterManager, error) {
 	klog.Infof("Creating secondary network cluster manager")
 	networkIDAllocator, err := id.NewIDAllocator("NetworkIDs", maxSecondaryNetworkIDs)
 	if err != nil {
@@ -49,10 +69,16 @@ func newSecondaryNetworkClusterManager(ovnClient *util.OVNClusterManagerClientse
 		ovnClient:          ovnClient,
 		watchFactory:       wf,
 		networkIDAllocator: networkIDAllocator,
+		recorder:           recorder,
+		shard:              shard,
 	}
 
+	controllerName := "cluster-manager"
+	if shard != nil {
+		controllerName = fmt.Sprintf("cluster-manager-shard-%d", shard.index)
+	}
 	sncm.nadController, err = nad.NewNetAttachDefinitionController(
-		"cluster-manager", sncm, ovnClient.NetworkAttchDefClient, recorder)
+		controllerName, sncm, ovnClient.NetworkAttchDefClient, recorder)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +119,10 @@ func (sncm *secondaryNetworkClusterManager) init() error {
 		}
 	}
 
+	if sncm.shard != nil {
+		sncm.shard.reserveOtherNetworkIDs(sncm.networkIDAllocator, maxSecondaryNetworkIDs)
+	}
+
 	return nil
 }
 
@@ -108,11 +138,18 @@ func (sncm *secondaryNetworkClusterManager) NewNetworkController(nInfo util.NetI
 	if !sncm.isTopologyManaged(nInfo) {
 		return nil, nad.ErrNetworkControllerTopologyNotManaged
 	}
+	if sncm.shard != nil && !sncm.shard.ownsNetwork(nInfo.GetNetworkName()) {
+		// managed by the instance leading the shard of the network
+		return nil, nad.ErrNetworkControllerTopologyNotManaged
+	}
 
 	klog.Infof("Creating new network controller for network %s of topology %s", nInfo.GetNetworkName(), nInfo.TopologyType())
 
 	namedIDAllocator := sncm.networkIDAllocator.ForName(nInfo.GetNetworkName())
 	sncc := newNetworkClusterController(namedIDAllocator, nInfo, sncm.ovnClient, sncm.watchFactory)
+	sncc.nodeAnnotationBatcher = sncm.nodeAnnotationBatcher
+	sncc.recorder = sncm.recorder
+	sncc.joinSubnetAllocator = sncm.joinSubnetAllocator
 	return sncc, nil
 }
 
@@ -159,6 +196,10 @@ func (sncm *secondaryNetworkClusterManager) CleanupDeletedNetworks(allController
 				continue
 			}
 
+			if sncm.shard != nil && !sncm.shard.ownsNetwork(netName) {
+				continue
+			}
+
 			if _, ok := existingNetworksMap[netName]; ok {
 				// network still exists, no cleanup to do
 				continue
@@ -193,6 +234,7 @@ func (sncm *secondaryNetworkClusterManager) newDummyLayer3NetworkController(netN
 	netInfo, _ := util.NewNetInfo(&ovncnitypes.NetConf{NetConf: types.NetConf{Name: netName}, Topology: ovntypes.Layer3Topology})
 	namedIDAllocator := sncm.networkIDAllocator.ForName(netInfo.GetNetworkName())
 	nc := newNetworkClusterController(namedIDAllocator, netInfo, sncm.ovnClient, sncm.watchFactory)
+	nc.nodeAnnotationBatcher = sncm.nodeAnnotationBatcher
 	err := nc.init()
 	return nc, err
 }
@@ -181,6 +181,64 @@ var _ = ginkgo.Describe("Secondary Layer3 Cluster Controller Manager", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 
+		ginkgo.It("Manages only the networks of its shard", func() {
+			app.Action = func(ctx *cli.Context) error {
+				kubeFakeClient := fake.NewSimpleClientset(&v1.NodeList{
+					Items: []v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}},
+				})
+				fakeClient := &util.OVNClusterManagerClientset{
+					KubeClient: kubeFakeClient,
+				}
+
+				_, err := config.InitConfig(ctx, nil, nil)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				config.Kubernetes.HostNetworkNamespace = ""
+
+				f, err = factory.NewClusterManagerWatchFactory(fakeClient)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = f.Start()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				shard := &networkShard{index: networkShardOf("blue", 3), count: 3}
+				otherNetwork := ""
+				for i := 0; otherNetwork == ""; i++ {
+					if name := fmt.Sprintf("red%d", i); !shard.ownsNetwork(name) {
+						otherNetwork = name
+					}
+				}
+
+				sncm, err := newShardedSecondaryNetworkClusterManager(fakeClient, f, shard, record.NewFakeRecorder(0))
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(sncm.init()).To(gomega.Succeed())
+
+				netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{NetConf: types.NetConf{Name: otherNetwork}, Topology: ovntypes.Layer3Topology, Subnets: "192.168.0.0/16/24"})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				nc, err := sncm.NewNetworkController(netInfo)
+				gomega.Expect(err).To(gomega.Equal(nad.ErrNetworkControllerTopologyNotManaged))
+				gomega.Expect(nc).To(gomega.BeNil())
+
+				netInfo, err = util.NewNetInfo(&ovncnitypes.NetConf{NetConf: types.NetConf{Name: "blue"}, Topology: ovntypes.Layer3Topology, Subnets: "192.168.0.0/16/24"})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				nc, err = sncm.NewNetworkController(netInfo)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(nc).NotTo(gomega.BeNil())
+
+				// the shard only allocates its own network IDs
+				for i := 0; i < 10; i++ {
+					networkID, err := sncm.networkIDAllocator.AllocateID(fmt.Sprintf("green%d", i))
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(networkID % 3).To(gomega.Equal(shard.index))
+				}
+
+				return nil
+			}
+
+			err := app.Run([]string{
+				app.Name,
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
 		ginkgo.It("Cleanup", func() {
 			app.Action = func(ctx *cli.Context) error {
 				nodes := []v1.Node{
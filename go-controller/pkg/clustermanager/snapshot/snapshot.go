@@ -0,0 +1,242 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Version is the version of the snapshot format written by Save
+const Version = 1
+
+// Pools of allocations tracked in a snapshot. Pools of per network
+// allocations are suffixed with the name of the network attachment.
+const (
+	PoolPodIPs     = "pod-ips"
+	PoolTunnelIDs  = "tunnel-ids"
+	PoolEgressIPs  = "egress-ips"
+	PoolNetworkIDs = "network-ids"
+)
+
+// PodIPsPool returns the pool of the pod IPs of a network attachment
+func PodIPsPool(nadName string) string {
+	return PoolPodIPs + "/" + nadName
+}
+
+// TunnelIDsPool returns the pool of the pod tunnel IDs of a network attachment
+func TunnelIDsPool(nadName string) string {
+	return PoolTunnelIDs + "/" + nadName
+}
+
+// Snapshot is a compact record of the allocations made by the cluster
+// manager. It maps, for each allocation pool, each allocated value to its
+// owner. Values and owners are opaque strings, e.g. an IP address owned by a
+// "namespace/name" pod.
+type Snapshot struct {
+	Version   int                          `json:"version"`
+	Timestamp time.Time                    `json:"timestamp"`
+	Pools     map[string]map[string]string `json:"pools"`
+
+	// created holds the creation time of the owners, when known, to tell
+	// the owners created after a previous snapshot from the older ones. It
+	// is only relevant to the allocations of the cluster and not persisted.
+	created map[string]time.Time
+}
+
+// New returns an empty snapshot
+func New() *Snapshot {
+	return &Snapshot{
+		Version: Version,
+		Pools:   map[string]map[string]string{},
+		created: map[string]time.Time{},
+	}
+}
+
+// SetCreated records when owner was created
+func (s *Snapshot) SetCreated(owner string, created time.Time) {
+	if s.created == nil {
+		s.created = map[string]time.Time{}
+	}
+	s.created[owner] = created
+}
+
+// createdAfter returns whether owner is known to have been created after t
+func (s *Snapshot) createdAfter(owner string, t time.Time) bool {
+	created, ok := s.created[owner]
+	return ok && !created.IsZero() && created.After(t)
+}
+
+// Add records that value is allocated to owner in pool
+func (s *Snapshot) Add(pool, value, owner string) {
+	values, ok := s.Pools[pool]
+	if !ok {
+		values = map[string]string{}
+		s.Pools[pool] = values
+	}
+	values[value] = owner
+}
+
+// Len returns the number of allocations in the snapshot
+func (s *Snapshot) Len() int {
+	n := 0
+	for _, values := range s.Pools {
+		n += len(values)
+	}
+	return n
+}
+
+// Equal returns whether both snapshots hold the same allocations, regardless
+// of when they were taken
+func (s *Snapshot) Equal(other *Snapshot) bool {
+	if len(s.Pools) != len(other.Pools) {
+		return false
+	}
+	for pool, values := range s.Pools {
+		otherValues, ok := other.Pools[pool]
+		if !ok || len(values) != len(otherValues) {
+			return false
+		}
+		for value, owner := range values {
+			if otherValues[value] != owner {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Conflict is a value allocated to an owner in a snapshot and to a different
+// owner in the cluster
+type Conflict struct {
+	Pool          string
+	Value         string
+	SnapshotOwner string
+	CurrentOwner  string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s allocated to %s in the snapshot and to %s in the cluster",
+		c.Pool, c.Value, c.SnapshotOwner, c.CurrentOwner)
+}
+
+// Reconcile compares the allocations of the cluster with a previous snapshot
+// and returns, sorted by pool and value, the values now allocated to a
+// different owner while overlapping with their owner of the snapshot. It also
+// returns the number of allocations of the snapshot that are gone from the
+// cluster, which is expected for owners deleted while no snapshot was taken
+// but may also point at state lost by a restore.
+//
+// A value of a snapshot owner deleted since, or re-created since, and now
+// allocated to an owner created after the snapshot was legitimately released
+// and allocated again, which is the normal churn of the owners and no
+// conflict. Any other owner change is an overlap: either the snapshot owner
+// still holds the value or the cluster state predates the snapshot, as after
+// a restore from older data.
+func Reconcile(previous, current *Snapshot) ([]Conflict, int) {
+	var conflicts []Conflict
+	lost := 0
+	// the owners of the snapshot still around, not re-created since
+	live := map[string]bool{}
+	for _, values := range current.Pools {
+		for _, owner := range values {
+			if !current.createdAfter(owner, previous.Timestamp) {
+				live[owner] = true
+			}
+		}
+	}
+	for pool, values := range previous.Pools {
+		currentValues := current.Pools[pool]
+		for value, owner := range values {
+			currentOwner, ok := currentValues[value]
+			switch {
+			case !ok:
+				lost++
+			case currentOwner == owner:
+			case !live[owner] && current.createdAfter(currentOwner, previous.Timestamp):
+				// released by its deleted owner and allocated again since
+				lost++
+			default:
+				conflicts = append(conflicts, Conflict{
+					Pool:          pool,
+					Value:         value,
+					SnapshotOwner: owner,
+					CurrentOwner:  currentOwner,
+				})
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Pool != conflicts[j].Pool {
+			return conflicts[i].Pool < conflicts[j].Pool
+		}
+		return conflicts[i].Value < conflicts[j].Value
+	})
+	return conflicts, lost
+}
+
+// Save writes the snapshot as gzipped JSON to path. The snapshot is written to
+// a temporary file first and then renamed so that a crash never leaves a
+// partial snapshot behind.
+func Save(path string, s *Snapshot) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	if err = json.NewEncoder(zw).Encode(s); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err = zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move snapshot to %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load reads the snapshot at path. It returns a nil snapshot and no error if
+// there is no snapshot at path yet.
+func Load(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open snapshot %s: %w", path, err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	s := &Snapshot{}
+	if err = json.NewDecoder(zr).Decode(s); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot %s: %w", path, err)
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("unsupported snapshot %s version %d, expected %d", path, s.Version, Version)
+	}
+	if s.Pools == nil {
+		s.Pools = map[string]map[string]string{}
+	}
+	return s, nil
+}
@@ -0,0 +1,122 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestSaveLoad(t *testing.T) {
+	g := gomega.NewWithT(t)
+	path := filepath.Join(t.TempDir(), "allocations.json.gz")
+
+	// no snapshot yet
+	s, err := Load(path)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(s).To(gomega.BeNil())
+
+	s = New()
+	s.Timestamp = time.Unix(1700000000, 0).UTC()
+	s.Add(PodIPsPool("default"), "10.128.0.5/24", "ns1/pod1")
+	s.Add(TunnelIDsPool("ns1/l2"), "3", "ns1/pod1")
+	s.Add(PoolNetworkIDs, "0", "default")
+	g.Expect(Save(path, s)).To(gomega.Succeed())
+
+	loaded, err := Load(path)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(loaded.Equal(s)).To(gomega.BeTrue())
+	g.Expect(loaded.Timestamp.Equal(s.Timestamp)).To(gomega.BeTrue())
+	g.Expect(loaded.Len()).To(gomega.Equal(3))
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(entries).To(gomega.HaveLen(1))
+
+	// a snapshot of an unknown version is refused
+	s.Version = Version + 1
+	g.Expect(Save(path, s)).To(gomega.Succeed())
+	_, err = Load(path)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// a corrupted snapshot is refused
+	g.Expect(os.WriteFile(path, []byte("garbage"), 0644)).To(gomega.Succeed())
+	_, err = Load(path)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestReconcile(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	previous := New()
+	previous.Add(PodIPsPool("default"), "10.128.0.5/24", "ns1/pod1")
+	previous.Add(PodIPsPool("default"), "10.128.0.6/24", "ns1/pod2")
+	previous.Add(PodIPsPool("default"), "10.128.0.7/24", "ns1/pod3")
+	previous.Add(PoolNetworkIDs, "2", "blue")
+	previous.Add(PoolEgressIPs, "172.18.0.100", "eip1")
+
+	current := New()
+	// unchanged
+	current.Add(PodIPsPool("default"), "10.128.0.5/24", "ns1/pod1")
+	// reallocated to another pod
+	current.Add(PodIPsPool("default"), "10.128.0.6/24", "ns2/pod4")
+	// network ID reallocated to another network
+	current.Add(PoolNetworkIDs, "2", "red")
+	// new allocation
+	current.Add(PodIPsPool("default"), "10.128.0.8/24", "ns1/pod5")
+	// 10.128.0.7/24 and the egress IP are gone
+
+	conflicts, lost := Reconcile(previous, current)
+	g.Expect(conflicts).To(gomega.Equal([]Conflict{
+		{Pool: PoolNetworkIDs, Value: "2", SnapshotOwner: "blue", CurrentOwner: "red"},
+		{Pool: PodIPsPool("default"), Value: "10.128.0.6/24", SnapshotOwner: "ns1/pod2", CurrentOwner: "ns2/pod4"},
+	}))
+	g.Expect(lost).To(gomega.Equal(2))
+
+	// a snapshot has no conflicts with itself
+	conflicts, lost = Reconcile(current, current)
+	g.Expect(conflicts).To(gomega.BeEmpty())
+	g.Expect(lost).To(gomega.BeZero())
+}
+
+func TestReconcileChurn(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	previous := New()
+	previous.Timestamp = time.Unix(1700000000, 0)
+	previous.Add(PodIPsPool("default"), "10.128.0.5/24", "ns1/pod1")
+	previous.Add(PodIPsPool("default"), "10.128.0.6/24", "ns1/pod2")
+	previous.Add(PodIPsPool("default"), "10.128.0.7/24", "ns1/pod3")
+	before := previous.Timestamp.Add(-time.Minute)
+	after := previous.Timestamp.Add(time.Minute)
+
+	current := New()
+	// pod1 was deleted and re-created since, getting the IP of the deleted
+	// pod2
+	current.Add(PodIPsPool("default"), "10.128.0.6/24", "ns1/pod1")
+	current.SetCreated("ns1/pod1", after)
+	// the IP of pod1 was allocated to a new pod
+	current.Add(PodIPsPool("default"), "10.128.0.5/24", "ns2/pod4")
+	current.SetCreated("ns2/pod4", after)
+	// pod3 is still around while its IP is allocated to a new pod
+	current.Add(PodIPsPool("default"), "10.128.0.7/24", "ns2/pod5")
+	current.SetCreated("ns2/pod5", after)
+	current.Add(PodIPsPool("default"), "10.128.0.8/24", "ns1/pod3")
+	current.SetCreated("ns1/pod3", before)
+
+	conflicts, lost := Reconcile(previous, current)
+	g.Expect(conflicts).To(gomega.Equal([]Conflict{
+		{Pool: PodIPsPool("default"), Value: "10.128.0.7/24", SnapshotOwner: "ns1/pod3", CurrentOwner: "ns2/pod5"},
+	}))
+	g.Expect(lost).To(gomega.Equal(2))
+
+	// a pod older than the snapshot holding the IP of a deleted pod overlaps
+	// with it, as after a restore from older data
+	current.SetCreated("ns2/pod4", before)
+	conflicts, _ = Reconcile(previous, current)
+	g.Expect(conflicts).To(gomega.ContainElement(
+		Conflict{Pool: PodIPsPool("default"), Value: "10.128.0.5/24", SnapshotOwner: "ns1/pod1", CurrentOwner: "ns2/pod4"}))
+}
@@ -0,0 +1,325 @@
+package clustermanager
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/ovn-org/libovsdb/model"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	corev1 "k8s.io/api/core/v1"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/clustermanager/node"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	zoneinterconnect "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/zone_interconnect"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// TopologyBundle is the topology of a cluster computed offline from its
+// configuration and node inventory, for the clusters that must come up
+// without a live cluster manager, like air-gapped or edge installations. It is
+// imported on first boot: the node annotations are set on the nodes, where the
+// cluster manager and ovnkube-controller take them over, and the NB
+// transaction is run against the empty NB database.
+type TopologyBundle struct {
+	// NodeAnnotations are the annotations the cluster manager would set on
+	// each node, by node name: the host subnets, the network IDs, the node ID
+	// and the addresses derived from it
+	NodeAnnotations map[string]map[string]string `json:"nodeAnnotations"`
+	// NetworkIDs are the IDs of the networks, by network name
+	NetworkIDs map[string]int `json:"networkIDs"`
+	// NodeTunnelKeys are the tunnel keys of the transit switch ports of the
+	// nodes, by node name, if interconnect is enabled
+	NodeTunnelKeys map[string]int `json:"nodeTunnelKeys,omitempty"`
+	// TransitSwitchTunnelKeys are the tunnel keys of the transit switches of
+	// the networks, by network name, if interconnect is enabled
+	TransitSwitchTunnelKeys map[string]int `json:"transitSwitchTunnelKeys,omitempty"`
+	// NBTransaction holds the operations creating the initial topology of the
+	// default network in a single zone NB database: the cluster router, the
+	// join switch and the node switches. ovnkube-controller adopts and
+	// completes them on startup.
+	NBTransaction []ovsdb.Operation `json:"nbTransaction"`
+}
+
+// BuildTopologyBundle computes the topology bundle of the given nodes for the
+// default network and the given secondary networks, from the loaded
+// configuration. As for PlanNodeSubnets, the result only depends on the
+// input: the networks get their IDs in name order, the nodes are handled in
+// name order and the allocations already annotated on the nodes are kept.
+func BuildTopologyBundle(nodes []*corev1.Node, networks []util.NetInfo) (*TopologyBundle, error) {
+	sortedNetworks := make([]util.NetInfo, len(networks))
+	copy(sortedNetworks, networks)
+	sort.Slice(sortedNetworks, func(i, j int) bool {
+		return sortedNetworks[i].GetNetworkName() < sortedNetworks[j].GetNetworkName()
+	})
+
+	bundle := &TopologyBundle{
+		NodeAnnotations: map[string]map[string]string{},
+		NetworkIDs:      map[string]int{types.DefaultNetworkName: defaultNetworkID},
+	}
+	if len(sortedNetworks) > maxSecondaryNetworkIDs {
+		return nil, fmt.Errorf("too many secondary networks: %d, at most %d are supported",
+			len(sortedNetworks), maxSecondaryNetworkIDs)
+	}
+
+	// the host subnets and the network IDs of each network are planned on top
+	// of the annotations planned for the previous networks
+	plan, err := node.PlanNodeSubnets(defaultNetworkID, &util.DefaultNetInfo{}, nodes)
+	if err != nil {
+		return nil, err
+	}
+	for i, netInfo := range sortedNetworks {
+		networkID := defaultNetworkID + 1 + i
+		bundle.NetworkIDs[netInfo.GetNetworkName()] = networkID
+		plan, err = node.PlanNodeSubnets(networkID, netInfo, plan.Nodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	annotated, err := planNodeZoneAnnotations(plan.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	original := map[string]*corev1.Node{}
+	for _, n := range nodes {
+		original[n.Name] = n
+	}
+	for _, n := range annotated {
+		annotations := map[string]string{}
+		for key, value := range n.Annotations {
+			if original[n.Name].Annotations[key] != value {
+				annotations[key] = value
+			}
+		}
+		bundle.NodeAnnotations[n.Name] = annotations
+	}
+
+	if config.OVNKubernetesFeature.EnableInterconnect {
+		bundle.NodeTunnelKeys = map[string]int{}
+		for _, n := range annotated {
+			bundle.NodeTunnelKeys[n.Name] = util.GetNodeID(n)
+		}
+		bundle.TransitSwitchTunnelKeys = map[string]int{}
+		for networkName, networkID := range bundle.NetworkIDs {
+			bundle.TransitSwitchTunnelKeys[networkName] = zoneinterconnect.BaseTransitSwitchTunnelKey + networkID
+		}
+	}
+
+	bundle.NBTransaction, err = buildInitialNBTransaction(annotated)
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// planNodeZoneAnnotations runs the zone cluster controller against the given
+// nodes to annotate them with their node IDs and the addresses derived from
+// them, and returns the annotated nodes
+func planNodeZoneAnnotations(nodes []*corev1.Node) ([]*corev1.Node, error) {
+	cluster := node.NewFakeCluster(nodes...)
+	zcc, err := newZoneClusterController(&util.OVNClusterManagerClientset{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	zcc.kube = cluster.Kube()
+
+	existing := make([]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		existing = append(existing, n)
+	}
+	if err := zcc.syncNodeIDs(existing); err != nil {
+		return nil, err
+	}
+	for _, n := range nodes {
+		if err := zcc.handleAddUpdateNodeEvent(n); err != nil {
+			return nil, err
+		}
+	}
+	return cluster.ListNodes()
+}
+
+// nbTransactionBuilder builds the insert operations of a NB transaction from
+// NB models, the references between the rows being named UUIDs
+type nbTransactionBuilder struct {
+	dbModel model.DatabaseModel
+	ops     []ovsdb.Operation
+}
+
+func newNBTransactionBuilder() (*nbTransactionBuilder, error) {
+	clientDBModel, err := nbdb.FullDatabaseModel()
+	if err != nil {
+		return nil, err
+	}
+	dbModel, errs := model.NewDatabaseModel(nbdb.Schema(), clientDBModel)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to build the NB database model: %v", errs)
+	}
+	return &nbTransactionBuilder{dbModel: dbModel}, nil
+}
+
+// insert adds the insert operation of the model, referred to by the other
+// rows with the named UUID of the model, which is returned
+func (b *nbTransactionBuilder) insert(m model.Model) (string, error) {
+	info, err := b.dbModel.NewModelInfo(m)
+	if err != nil {
+		return "", err
+	}
+	row, err := b.dbModel.Mapper.NewRow(info)
+	if err != nil {
+		return "", err
+	}
+	uuidName := fmt.Sprintf("row%d", len(b.ops))
+	b.ops = append(b.ops, ovsdb.Operation{
+		Op:       ovsdb.OperationInsert,
+		Table:    b.dbModel.FindTable(reflect.TypeOf(m)),
+		Row:      row,
+		UUIDName: uuidName,
+	})
+	return uuidName, nil
+}
+
+// buildInitialNBTransaction builds the operations creating the cluster router,
+// the join switch and the node switches of the default network, as set up by
+// ovnkube-controller, for the given annotated nodes
+func buildInitialNBTransaction(nodes []*corev1.Node) ([]ovsdb.Operation, error) {
+	b, err := newNBTransactionBuilder()
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterRouterPorts []string
+	joinIfAddrs, err := clusterRouterToJoinSwitchIfAddrs()
+	if err != nil {
+		return nil, err
+	}
+	joinRouterPort, err := b.insertRouterSwitchPorts(types.OVNJoinSwitch,
+		types.GWRouterToJoinSwitchPrefix+types.OVNClusterRouter, types.JoinSwitchToGWRouterPrefix+types.OVNClusterRouter,
+		joinIfAddrs, nil)
+	if err != nil {
+		return nil, err
+	}
+	clusterRouterPorts = append(clusterRouterPorts, joinRouterPort)
+
+	for _, n := range nodes {
+		if util.NoHostSubnet(n) {
+			continue
+		}
+		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(n, types.DefaultNetworkName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the host subnets of node %s: %w", n.Name, err)
+		}
+		var gwIfAddrs []*net.IPNet
+		otherConfig := map[string]string{}
+		for _, hostSubnet := range util.PrimaryHostSubnets(hostSubnets) {
+			if utilnet.IsIPv6CIDR(hostSubnet) {
+				otherConfig["ipv6_prefix"] = hostSubnet.IP.String()
+			} else {
+				excludeIPs := util.GetNodeManagementIfAddr(hostSubnet).IP.String()
+				if config.HybridOverlay.Enabled {
+					excludeIPs += ".." + util.GetNodeHybridOverlayIfAddr(hostSubnet).IP.String()
+				}
+				otherConfig["subnet"] = hostSubnet.String()
+				otherConfig["exclude_ips"] = excludeIPs
+			}
+		}
+		for _, hostSubnet := range hostSubnets {
+			gwIfAddrs = append(gwIfAddrs, util.GetNodeGatewayIfAddr(hostSubnet))
+		}
+		// the router port MAC is based on the IPv4 host subnet if any
+		sort.SliceStable(gwIfAddrs, func(i, j int) bool {
+			return !utilnet.IsIPv6CIDR(gwIfAddrs[i]) && utilnet.IsIPv6CIDR(gwIfAddrs[j])
+		})
+		nodeRouterPort, err := b.insertRouterSwitchPorts(n.Name, types.RouterToSwitchPrefix+n.Name,
+			types.SwitchToRouterPrefix+n.Name, gwIfAddrs, otherConfig)
+		if err != nil {
+			return nil, err
+		}
+		clusterRouterPorts = append(clusterRouterPorts, nodeRouterPort)
+	}
+
+	_, err = b.insert(&nbdb.LogicalRouter{
+		Name: types.OVNClusterRouter,
+		ExternalIDs: map[string]string{
+			"k8s-cluster-router":            "yes",
+			types.TopologyVersionExternalID: strconv.Itoa(types.OvnCurrentTopologyVersion),
+		},
+		Options: map[string]string{
+			"always_learn_from_arp_request": "false",
+		},
+		Ports: clusterRouterPorts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.ops, nil
+}
+
+// clusterRouterToJoinSwitchIfAddrs returns the addresses of the cluster router
+// port to the join switch, the first IPs of the configured join subnets
+func clusterRouterToJoinSwitchIfAddrs() ([]*net.IPNet, error) {
+	joinSubnetsConfig := []string{}
+	if config.IPv4Mode {
+		joinSubnetsConfig = append(joinSubnetsConfig, config.Gateway.V4JoinSubnet)
+	}
+	if config.IPv6Mode {
+		joinSubnetsConfig = append(joinSubnetsConfig, config.Gateway.V6JoinSubnet)
+	}
+	var ifAddrs []*net.IPNet
+	for _, joinSubnetString := range joinSubnetsConfig {
+		_, joinSubnet, err := net.ParseCIDR(joinSubnetString)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing join subnet string %s: %v", joinSubnetString, err)
+		}
+		ifAddrs = append(ifAddrs, &net.IPNet{
+			IP:   utilnet.AddIPOffset(utilnet.BigForIP(joinSubnet.IP), 1),
+			Mask: joinSubnet.Mask,
+		})
+	}
+	return ifAddrs, nil
+}
+
+// insertRouterSwitchPorts adds the switch of the given name with the given
+// other config, connected to the cluster router through a router port with the
+// given addresses, and returns the named UUID of the router port
+func (b *nbTransactionBuilder) insertRouterSwitchPorts(switchName, routerPortName, switchPortName string,
+	ifAddrs []*net.IPNet, otherConfig map[string]string) (string, error) {
+	networks := make([]string, 0, len(ifAddrs))
+	for _, ifAddr := range ifAddrs {
+		networks = append(networks, ifAddr.String())
+	}
+	routerPort, err := b.insert(&nbdb.LogicalRouterPort{
+		Name:     routerPortName,
+		MAC:      util.IPAddrToHWAddr(ifAddrs[0].IP).String(),
+		Networks: networks,
+	})
+	if err != nil {
+		return "", err
+	}
+	switchPort, err := b.insert(&nbdb.LogicalSwitchPort{
+		Name:      switchPortName,
+		Type:      "router",
+		Addresses: []string{"router"},
+		Options: map[string]string{
+			"router-port": routerPortName,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	_, err = b.insert(&nbdb.LogicalSwitch{
+		Name:        switchName,
+		OtherConfig: otherConfig,
+		Ports:       []string{switchPort},
+	})
+	if err != nil {
+		return "", err
+	}
+	return routerPort, nil
+}
@@ -0,0 +1,101 @@
+package clustermanager
+
+import (
+	"encoding/json"
+
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	zoneinterconnect "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/zone_interconnect"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var _ = ginkgo.Describe("Cluster manager topology bundle", func() {
+	newBundleTestNode := func(name string, annotations map[string]string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}}
+	}
+
+	ginkgo.BeforeEach(func() {
+		// Restore global default values before each testcase
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		config.IPv4Mode = true
+		config.OVNKubernetesFeature.EnableInterconnect = true
+		var err error
+		config.Default.ClusterSubnets, err = config.ParseClusterSubnetEntries("10.128.0.0/14/23")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("computes the node annotations, the tunnel keys and the NB transaction", func() {
+		nodes := []*corev1.Node{
+			newBundleTestNode("node2", nil),
+			newBundleTestNode("node1", map[string]string{
+				"k8s.ovn.org/node-subnets": `{"default":["10.128.4.0/23"]}`,
+			}),
+		}
+		bundle, err := BuildTopologyBundle(nodes, nil)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		gomega.Expect(bundle.NetworkIDs).To(gomega.Equal(map[string]int{types.DefaultNetworkName: 0}))
+		// the host subnets already annotated are kept and not part of the bundle
+		gomega.Expect(bundle.NodeAnnotations["node1"]).NotTo(gomega.HaveKey("k8s.ovn.org/node-subnets"))
+		gomega.Expect(bundle.NodeAnnotations["node2"]).To(gomega.HaveKeyWithValue(
+			"k8s.ovn.org/node-subnets", `{"default":["10.128.0.0/23"]}`))
+		// the nodes get their IDs in name order
+		gomega.Expect(bundle.NodeAnnotations["node1"]).To(gomega.HaveKeyWithValue("k8s.ovn.org/node-id", "2"))
+		gomega.Expect(bundle.NodeAnnotations["node2"]).To(gomega.HaveKeyWithValue("k8s.ovn.org/node-id", "3"))
+		gomega.Expect(bundle.NodeAnnotations["node1"]).To(gomega.HaveKeyWithValue(
+			"k8s.ovn.org/node-gateway-router-lrp-ifaddr", `{"ipv4":"100.64.0.2/16"}`))
+		gomega.Expect(bundle.NodeAnnotations["node2"]).To(gomega.HaveKey("k8s.ovn.org/node-transit-switch-port-ifaddr"))
+
+		gomega.Expect(bundle.NodeTunnelKeys).To(gomega.Equal(map[string]int{"node1": 2, "node2": 3}))
+		gomega.Expect(bundle.TransitSwitchTunnelKeys).To(gomega.Equal(map[string]int{
+			types.DefaultNetworkName: zoneinterconnect.BaseTransitSwitchTunnelKey,
+		}))
+
+		// join switch, node1 and node2 switches with their ports, cluster router
+		gomega.Expect(bundle.NBTransaction).To(gomega.HaveLen(10))
+		tables := map[string]int{}
+		for _, op := range bundle.NBTransaction {
+			tables[op.Table]++
+		}
+		gomega.Expect(tables).To(gomega.Equal(map[string]int{
+			"Logical_Router_Port": 3,
+			"Logical_Switch_Port": 3,
+			"Logical_Switch":      3,
+			"Logical_Router":      1,
+		}))
+		node1Switch := bundle.NBTransaction[5].Row
+		gomega.Expect(node1Switch["name"]).To(gomega.Equal("node1"))
+		_, err = json.Marshal(bundle)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("assigns the network IDs of the secondary networks in name order", func() {
+		var networks []util.NetInfo
+		for _, name := range []string{"red", "blue"} {
+			netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+				NetConf:  cnitypes.NetConf{Name: name},
+				Topology: types.Layer3Topology,
+				Subnets:  "10.200.0.0/16/24",
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			networks = append(networks, netInfo)
+		}
+		bundle, err := BuildTopologyBundle([]*corev1.Node{newBundleTestNode("node1", nil)}, networks)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(bundle.NetworkIDs).To(gomega.Equal(map[string]int{
+			types.DefaultNetworkName: 0,
+			"blue":                   1,
+			"red":                    2,
+		}))
+		gomega.Expect(bundle.TransitSwitchTunnelKeys).To(gomega.HaveKeyWithValue("red",
+			zoneinterconnect.BaseTransitSwitchTunnelKey+2))
+		gomega.Expect(bundle.NodeAnnotations["node1"]["k8s.ovn.org/node-subnets"]).To(gomega.ContainSubstring(`"blue":`))
+	})
+})
@@ -0,0 +1,240 @@
+package clustermanager
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/bitmap"
+	ipallocator "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/ip"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// transitSwitchAllocator allocates to each node the addresses of its port on
+// the transit switch, one of each IP family. The nodes of the zones with a
+// range of the transit switch subnet configured get addresses of the range,
+// the others the addresses derived from their node ID. All the addresses keep
+// the mask of the transit switch subnet so that the nodes of all the zones
+// stay on the same link.
+type transitSwitchAllocator struct {
+	sync.Mutex
+	// generators holds the generator of the addresses derived from the node
+	// ID of each transit switch subnet
+	generators []*ipGenerator
+	// zoneRanges holds the allocators of the ranges of each zone
+	zoneRanges map[string][]*ipallocator.Range
+	// nodes holds the zone and the addresses allocated from its ranges, by node
+	nodes map[string]*transitSwitchNodeIPs
+}
+
+type transitSwitchNodeIPs struct {
+	zone string
+	ips  []net.IP
+}
+
+// newTransitSwitchAllocator returns the allocator of the transit switch
+// subnets of the IP families of the cluster. The ranges of the zones must not
+// hold the addresses derived from the node IDs.
+func newTransitSwitchAllocator() (*transitSwitchAllocator, error) {
+	transitSwitchSubnets := []string{}
+	if config.IPv4Mode {
+		transitSwitchSubnets = append(transitSwitchSubnets, config.ClusterManager.V4TransitSwitchSubnet)
+	}
+	if config.IPv6Mode {
+		transitSwitchSubnets = append(transitSwitchSubnets, config.ClusterManager.V6TransitSwitchSubnet)
+	}
+	tsa := &transitSwitchAllocator{
+		zoneRanges: map[string][]*ipallocator.Range{},
+		nodes:      map[string]*transitSwitchNodeIPs{},
+	}
+	for _, transitSwitchSubnet := range transitSwitchSubnets {
+		generator, err := newIPGenerator(transitSwitchSubnet)
+		if err != nil {
+			return nil, fmt.Errorf("error creating IP Generator for transit switch subnet %s: %w", transitSwitchSubnet, err)
+		}
+		tsa.generators = append(tsa.generators, generator)
+	}
+	for zone, subnets := range config.ClusterManager.ZoneTransitSwitchSubnets {
+		for _, subnet := range subnets {
+			generator := tsa.generatorOf(subnet.IP)
+			if generator == nil {
+				// the IP family is not enabled
+				continue
+			}
+			if generator.overlapsNodeIDs(subnet) {
+				return nil, fmt.Errorf("transit switch subnet %s of zone %s holds the addresses derived from the node IDs of %s",
+					subnet, zone, generator.netCidr)
+			}
+			r, err := ipallocator.NewAllocatorCIDRRange(subnet, func(max int, rangeSpec string) (bitmap.Interface, error) {
+				return bitmap.NewContiguousAllocationMap(max, rangeSpec), nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error creating the allocator of transit switch subnet %s of zone %s: %w", subnet, zone, err)
+			}
+			tsa.zoneRanges[zone] = append(tsa.zoneRanges[zone], r)
+		}
+	}
+	return tsa, nil
+}
+
+// reserveNodeIPs reserves the addresses from the ranges of its zone the node
+// holds, on startup. The nodes holding addresses not valid anymore, like out of
+// the ranges or held by another node, get other addresses once handled.
+func (tsa *transitSwitchAllocator) reserveNodeIPs(node *corev1.Node) error {
+	tsa.Lock()
+	defer tsa.Unlock()
+	zone := util.GetNodeZone(node)
+	if len(tsa.zoneRanges[zone]) == 0 {
+		return nil
+	}
+	annotatedIPs, err := util.ParseNodeTransitSwitchPortAddrs(node)
+	if err != nil {
+		return err
+	}
+	nodeIPs := &transitSwitchNodeIPs{zone: zone}
+	for _, r := range tsa.zoneRanges[zone] {
+		ip := annotatedIPOf(r, annotatedIPs)
+		if ip == nil {
+			continue
+		}
+		if err := r.Allocate(ip); err != nil {
+			tsa.releaseLocked(nodeIPs)
+			return fmt.Errorf("failed to reserve transit switch address %s of node %s: %w", ip, node.Name, err)
+		}
+		nodeIPs.ips = append(nodeIPs.ips, ip)
+	}
+	tsa.nodes[node.Name] = nodeIPs
+	return nil
+}
+
+// nodeIPs returns the transit switch addresses of the node, from the ranges
+// of its zone if any, else derived from its node ID. The addresses from the
+// ranges of its previous zone are released when the node changes zone.
+func (tsa *transitSwitchAllocator) nodeIPs(node *corev1.Node, nodeID int) ([]*net.IPNet, error) {
+	tsa.Lock()
+	defer tsa.Unlock()
+	zone := util.GetNodeZone(node)
+	nodeIPs := tsa.nodes[node.Name]
+	if nodeIPs != nil && nodeIPs.zone != zone {
+		klog.Infof("Node %s moved from zone %s to zone %s, releasing its transit switch addresses %v",
+			node.Name, nodeIPs.zone, zone, nodeIPs.ips)
+		tsa.releaseLocked(nodeIPs)
+		nodeIPs = nil
+	}
+	if nodeIPs == nil {
+		nodeIPs = &transitSwitchNodeIPs{zone: zone}
+	}
+	var annotatedIPs []*net.IPNet
+	if len(tsa.zoneRanges[zone]) > 0 {
+		// recorded first so that the addresses allocated are released along
+		// with the node whatever happens next
+		tsa.nodes[node.Name] = nodeIPs
+		// invalid or missing addresses are replaced
+		annotatedIPs, _ = util.ParseNodeTransitSwitchPortAddrs(node)
+	} else {
+		delete(tsa.nodes, node.Name)
+	}
+
+	ips := make([]*net.IPNet, 0, len(tsa.generators))
+	for _, generator := range tsa.generators {
+		r := tsa.zoneRangeLocked(zone, generator)
+		if r == nil {
+			ip, err := generator.GenerateIP(nodeID)
+			if err != nil {
+				return nil, err
+			}
+			ips = append(ips, ip)
+			continue
+		}
+		ip := allocatedIPOf(r, nodeIPs.ips)
+		if ip == nil {
+			if ip = annotatedIPOf(r, annotatedIPs); ip != nil {
+				if err := r.Allocate(ip); err != nil {
+					klog.Warningf("Node %s gets a new transit switch address: failed to reserve %s: %v", node.Name, ip, err)
+					ip = nil
+				}
+			}
+			if ip == nil {
+				var err error
+				if ip, err = r.AllocateNext(); err != nil {
+					cidr := r.CIDR()
+					return nil, fmt.Errorf("failed to allocate an address of transit switch subnet %s of zone %s: %w",
+						cidr.String(), zone, err)
+				}
+			}
+			nodeIPs.ips = append(nodeIPs.ips, ip)
+		}
+		ips = append(ips, &net.IPNet{IP: ip, Mask: generator.netCidr.Mask})
+	}
+	return ips, nil
+}
+
+// releaseNodeIPs releases the addresses of the deleted node
+func (tsa *transitSwitchAllocator) releaseNodeIPs(nodeName string) {
+	tsa.Lock()
+	defer tsa.Unlock()
+	if nodeIPs := tsa.nodes[nodeName]; nodeIPs != nil {
+		tsa.releaseLocked(nodeIPs)
+	}
+	delete(tsa.nodes, nodeName)
+}
+
+// generatorOf returns the generator of the transit switch subnet holding the
+// address, if any
+func (tsa *transitSwitchAllocator) generatorOf(ip net.IP) *ipGenerator {
+	for _, generator := range tsa.generators {
+		if generator.netCidr.Contains(ip) {
+			return generator
+		}
+	}
+	return nil
+}
+
+// zoneRangeLocked returns the range of the zone within the transit switch
+// subnet of the generator, if any
+func (tsa *transitSwitchAllocator) zoneRangeLocked(zone string, generator *ipGenerator) *ipallocator.Range {
+	for _, r := range tsa.zoneRanges[zone] {
+		cidr := r.CIDR()
+		if generator.netCidr.Contains(cidr.IP) {
+			return r
+		}
+	}
+	return nil
+}
+
+// releaseLocked releases the addresses the node holds from the ranges of its
+// zone
+func (tsa *transitSwitchAllocator) releaseLocked(nodeIPs *transitSwitchNodeIPs) {
+	for _, ip := range nodeIPs.ips {
+		for _, r := range tsa.zoneRanges[nodeIPs.zone] {
+			r.Release(ip)
+		}
+	}
+	nodeIPs.ips = nil
+}
+
+// annotatedIPOf returns the annotated address within the range, if any
+func annotatedIPOf(r *ipallocator.Range, annotatedIPs []*net.IPNet) net.IP {
+	cidr := r.CIDR()
+	for _, annotatedIP := range annotatedIPs {
+		if cidr.Contains(annotatedIP.IP) {
+			return annotatedIP.IP
+		}
+	}
+	return nil
+}
+
+// allocatedIPOf returns the allocated address within the range, if any
+func allocatedIPOf(r *ipallocator.Range, ips []net.IP) net.IP {
+	cidr := r.CIDR()
+	for _, ip := range ips {
+		if cidr.Contains(ip) {
+			return ip
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package clustermanager
+
+import (
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+)
+
+var _ = ginkgo.Describe("Cluster manager transit switch allocator", func() {
+	newNode := func(name, zone, transitSwitchIPs string) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{}}}
+		if zone != "" {
+			node.Annotations["k8s.ovn.org/zone-name"] = zone
+		}
+		if transitSwitchIPs != "" {
+			node.Annotations[ovnTransitSwitchPortAddrAnnotation] = transitSwitchIPs
+		}
+		return node
+	}
+	ipStrings := func(ips []*net.IPNet) []string {
+		strs := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			strs = append(strs, ip.String())
+		}
+		return strs
+	}
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		config.IPv4Mode = true
+		config.IPv6Mode = true
+		config.ClusterManager.ZoneTransitSwitchSubnets = map[string][]*net.IPNet{
+			"zone-a": {ovntest.MustParseIPNet("168.254.128.0/30")},
+		}
+	})
+
+	ginkgo.It("allocates the addresses of the zones from their ranges", func() {
+		tsa, err := newTransitSwitchAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		// the range of the zone holds 2 addresses, with the mask of the
+		// transit switch subnet
+		ips, err := tsa.nodeIPs(newNode("a", "zone-a", ""), 2)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.1/16", "fd97::2/64"}))
+		ips, err = tsa.nodeIPs(newNode("b", "zone-a", ""), 3)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.2/16", "fd97::3/64"}))
+		_, err = tsa.nodeIPs(newNode("c", "zone-a", ""), 4)
+		gomega.Expect(err).To(gomega.HaveOccurred())
+
+		// the addresses are kept on update
+		ips, err = tsa.nodeIPs(newNode("a", "zone-a", ""), 2)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.1/16", "fd97::2/64"}))
+
+		// the nodes of the other zones get the addresses derived from their ID
+		ips, err = tsa.nodeIPs(newNode("d", "zone-b", ""), 5)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.0.5/16", "fd97::5/64"}))
+
+		// the addresses of a deleted node are allocated again
+		tsa.releaseNodeIPs("b")
+		ips, err = tsa.nodeIPs(newNode("c", "zone-a", ""), 4)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.2/16", "fd97::4/64"}))
+
+		// and so are those of a node moving to another zone
+		ips, err = tsa.nodeIPs(newNode("a", "zone-b", ""), 2)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.0.2/16", "fd97::2/64"}))
+		ips, err = tsa.nodeIPs(newNode("b", "zone-a", ""), 3)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.1/16", "fd97::3/64"}))
+	})
+
+	ginkgo.It("keeps the annotated addresses within the range of the zone", func() {
+		tsa, err := newTransitSwitchAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		gomega.Expect(tsa.reserveNodeIPs(newNode("a", "zone-a", `{"ipv4":"168.254.128.2/16"}`))).To(gomega.Succeed())
+		// the duplicate address is not reserved
+		gomega.Expect(tsa.reserveNodeIPs(newNode("b", "zone-a", `{"ipv4":"168.254.128.2/16"}`))).NotTo(gomega.Succeed())
+
+		ips, err := tsa.nodeIPs(newNode("a", "zone-a", `{"ipv4":"168.254.128.2/16"}`), 2)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.2/16", "fd97::2/64"}))
+		ips, err = tsa.nodeIPs(newNode("b", "zone-a", `{"ipv4":"168.254.128.2/16"}`), 3)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.1/16", "fd97::3/64"}))
+		// the address derived from the node ID is replaced once the zone
+		// gets a range
+		tsa.releaseNodeIPs("a")
+		ips, err = tsa.nodeIPs(newNode("c", "zone-a", `{"ipv4":"168.254.0.4/16"}`), 4)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(ipStrings(ips)).To(gomega.Equal([]string{"168.254.128.2/16", "fd97::4/64"}))
+	})
+
+	ginkgo.It("rejects the ranges holding the addresses derived from the node IDs", func() {
+		config.ClusterManager.ZoneTransitSwitchSubnets = map[string][]*net.IPNet{
+			"zone-a": {ovntest.MustParseIPNet("168.254.16.0/20")},
+		}
+		_, err := newTransitSwitchAllocator()
+		gomega.Expect(err).To(gomega.HaveOccurred())
+
+		config.ClusterManager.ZoneTransitSwitchSubnets = map[string][]*net.IPNet{
+			"zone-a": {ovntest.MustParseIPNet("168.254.32.0/20")},
+		}
+		_, err = newTransitSwitchAllocator()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+})
@@ -0,0 +1,286 @@
+package clustermanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// WhoHasPath is the path of the metrics server answering which nodes and
+	// networks the allocators of the cluster manager gave an IP or subnet to
+	WhoHasPath = "/who-has"
+
+	// WhoHasKindNetwork is the kind of the networks whose subnets hold the IP
+	// or subnet looked up
+	WhoHasKindNetwork = "network"
+	// WhoHasKindNode is the kind of the nodes whose host subnets or addresses
+	// hold the IP or subnet looked up
+	WhoHasKindNode = "node"
+	// WhoHasKindPod is the kind of the pods whose IPs are within the IP or
+	// subnet looked up
+	WhoHasKindPod = "pod"
+
+	// WhoHasSourceAllocator is the source of the owners known to the
+	// allocators of the cluster manager
+	WhoHasSourceAllocator = "allocator"
+	// WhoHasSourceAnnotation is the source of the owners found in the
+	// annotations and the status of the nodes and pods
+	WhoHasSourceAnnotation = "annotation"
+	// WhoHasSourceNodeNetworkState is the source of the nodes owning host
+	// subnets only found in their NodeNetworkState
+	WhoHasSourceNodeNetworkState = "nodenetworkstate"
+)
+
+// WhoHasOwner is a network, node or pod owning an address or subnet
+// overlapping the IP or subnet looked up
+type WhoHasOwner struct {
+	Kind string `json:"kind"`
+	// Name is the name of the network or node, or the namespace/name of the
+	// pod
+	Name string `json:"name"`
+	// Network is the network the address or subnet belongs to, the NAD of the
+	// pods, empty for the addresses of the nodes
+	Network string `json:"network,omitempty"`
+	CIDR    string `json:"cidr"`
+	Source  string `json:"source"`
+}
+
+// ParseWhoHasQuery returns the network looked up from an IP, as a network of
+// that single IP, or a CIDR
+func ParseWhoHasQuery(query string) (*net.IPNet, error) {
+	if strings.Contains(query, "/") {
+		_, network, err := net.ParseCIDR(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet %q: %v", query, err)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(query)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP %q", query)
+	}
+	return fullMaskNetwork(ip), nil
+}
+
+// WhoHasAnnotated returns the owners of the addresses and subnets overlapping
+// the network according to the host subnet annotations and addresses of the
+// nodes, the host subnets of the networks only found in their node network
+// states, and the pod annotations of the pods
+func WhoHasAnnotated(nodes []*kapi.Node, states []*nodenetworkstatev1.NodeNetworkState, pods []*kapi.Pod, network *net.IPNet) []WhoHasOwner {
+	nodeStates := make(map[string]*nodenetworkstatev1.NodeNetworkState, len(states))
+	for _, state := range states {
+		nodeStates[state.Name] = state
+	}
+	owners := []WhoHasOwner{}
+	for _, node := range nodes {
+		hostSubnetsMap, err := util.ParseNodeHostSubnetAnnotationAllNetworks(node)
+		if err != nil && !util.IsAnnotationNotSetError(err) {
+			klog.Warningf("Failed to parse the host subnets of node %s: %v", node.Name, err)
+		}
+		for networkName, hostSubnets := range hostSubnetsMap {
+			for _, hostSubnet := range hostSubnets {
+				if cidrsOverlap(hostSubnet, network) {
+					owners = append(owners, WhoHasOwner{Kind: WhoHasKindNode, Name: node.Name, Network: networkName,
+						CIDR: hostSubnet.String(), Source: WhoHasSourceAnnotation})
+				}
+			}
+		}
+		if state := nodeStates[node.Name]; state != nil {
+			for networkName := range state.Spec.Networks {
+				if _, annotated := hostSubnetsMap[networkName]; annotated {
+					continue
+				}
+				hostSubnets, err := util.ParseNodeNetworkStateHostSubnets(node.Name, state, networkName)
+				if err != nil {
+					if !util.IsAnnotationNotSetError(err) {
+						klog.Warningf("Failed to parse the host subnets of node %s: %v", node.Name, err)
+					}
+					continue
+				}
+				for _, hostSubnet := range hostSubnets {
+					if cidrsOverlap(hostSubnet, network) {
+						owners = append(owners, WhoHasOwner{Kind: WhoHasKindNode, Name: node.Name, Network: networkName,
+							CIDR: hostSubnet.String(), Source: WhoHasSourceNodeNetworkState})
+					}
+				}
+			}
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type != kapi.NodeInternalIP && address.Type != kapi.NodeExternalIP {
+				continue
+			}
+			ip := net.ParseIP(address.Address)
+			if ip != nil && network.Contains(ip) {
+				owners = append(owners, WhoHasOwner{Kind: WhoHasKindNode, Name: node.Name,
+					CIDR: fullMaskNetwork(ip).String(), Source: WhoHasSourceAnnotation})
+			}
+		}
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork {
+			continue
+		}
+		podNetworks, err := util.UnmarshalPodAnnotationAllNetworks(pod.Annotations)
+		if err != nil {
+			klog.Warningf("Failed to parse the annotation of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		for nadName := range podNetworks {
+			podAnnotation, err := util.UnmarshalPodAnnotation(pod.Annotations, nadName)
+			if err != nil {
+				klog.Warningf("Failed to parse the annotation of pod %s/%s for %s: %v", pod.Namespace, pod.Name, nadName, err)
+				continue
+			}
+			for _, podIP := range podAnnotation.IPs {
+				if network.Contains(podIP.IP) {
+					owners = append(owners, WhoHasOwner{Kind: WhoHasKindPod, Name: pod.Namespace + "/" + pod.Name,
+						Network: nadName, CIDR: fullMaskNetwork(podIP.IP).String(), Source: WhoHasSourceAnnotation})
+				}
+			}
+		}
+	}
+
+	sortWhoHasOwners(owners)
+	return owners
+}
+
+// WhoHasConflicts returns a description of each address or subnet of a
+// network claimed by several nodes or pods, or given by the allocators to a
+// node different from the annotated one
+func WhoHasConflicts(owners []WhoHasOwner) []string {
+	claimants := map[string]map[string]bool{}
+	keys := []string{}
+	for _, owner := range owners {
+		if owner.Kind == WhoHasKindNetwork {
+			continue
+		}
+		key := fmt.Sprintf("%s of network %q", owner.CIDR, owner.Network)
+		if owner.Network == "" {
+			key = fmt.Sprintf("node address %s", owner.CIDR)
+		}
+		if claimants[key] == nil {
+			claimants[key] = map[string]bool{}
+			keys = append(keys, key)
+		}
+		claimants[key][owner.Kind+" "+owner.Name] = true
+	}
+
+	conflicts := []string{}
+	for _, key := range keys {
+		if len(claimants[key]) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(claimants[key]))
+		for name := range claimants[key] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		conflicts = append(conflicts, fmt.Sprintf("%s is claimed by %s", key, strings.Join(names, ", ")))
+	}
+	return conflicts
+}
+
+// whoHas returns the networks whose subnets overlap the network and the
+// nodes the host subnets overlapping it are allocated to, for the networks
+// the cluster manager manages
+func (cm *ClusterManager) whoHas(network *net.IPNet) []WhoHasOwner {
+	nccs := []*networkClusterController{cm.defaultNetClusterController}
+	if cm.secondaryNetClusterManager != nil {
+		for _, nc := range cm.secondaryNetClusterManager.nadController.GetAllNetworkControllers() {
+			if ncc, ok := nc.(*networkClusterController); ok {
+				nccs = append(nccs, ncc)
+			}
+		}
+	}
+
+	owners := []WhoHasOwner{}
+	for _, ncc := range nccs {
+		for _, subnet := range ncc.Subnets() {
+			if cidrsOverlap(subnet.CIDR, network) {
+				owners = append(owners, WhoHasOwner{Kind: WhoHasKindNetwork, Name: ncc.GetNetworkName(),
+					Network: ncc.GetNetworkName(), CIDR: subnet.CIDR.String(), Source: WhoHasSourceAllocator})
+			}
+		}
+		if ncc.nodeAllocator == nil {
+			continue
+		}
+		for _, allocated := range ncc.nodeAllocator.AllocatedSubnets(network) {
+			owners = append(owners, WhoHasOwner{Kind: WhoHasKindNode, Name: allocated.Owner, Network: ncc.GetNetworkName(),
+				CIDR: allocated.Network.String(), Source: WhoHasSourceAllocator})
+		}
+	}
+	sortWhoHasOwners(owners)
+	return owners
+}
+
+// serveWhoHas answers which networks and nodes own the IP or subnet of the
+// `ip` or `subnet` query parameter according to the allocators
+func (cm *ClusterManager) serveWhoHas(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query().Get("ip")
+	if query == "" {
+		query = req.URL.Query().Get("subnet")
+	}
+	if query == "" {
+		http.Error(w, "missing ip or subnet query parameter", http.StatusBadRequest)
+		return
+	}
+	network, err := ParseWhoHasQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cm.whoHas(network)); err != nil {
+		klog.Errorf("Failed to write the owners of %s: %v", network, err)
+	}
+}
+
+// registerWhoHasHandler exposes the who-has lookup on the metrics server if
+// metrics are enabled
+func (cm *ClusterManager) registerWhoHasHandler() {
+	if config.Metrics.BindAddress == "" {
+		return
+	}
+	metrics.RegisterHTTPHandler(WhoHasPath, http.HandlerFunc(cm.serveWhoHas))
+}
+
+// fullMaskNetwork returns the network of the single IP
+func fullMaskNetwork(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return &net.IPNet{IP: ip, Mask: util.GetIPFullMask(ip)}
+}
+
+func cidrsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func sortWhoHasOwners(owners []WhoHasOwner) {
+	sort.SliceStable(owners, func(i, j int) bool {
+		if owners[i].Kind != owners[j].Kind {
+			return owners[i].Kind < owners[j].Kind
+		}
+		if owners[i].Name != owners[j].Name {
+			return owners[i].Name < owners[j].Name
+		}
+		if owners[i].Network != owners[j].Network {
+			return owners[i].Network < owners[j].Network
+		}
+		return owners[i].CIDR < owners[j].CIDR
+	})
+}
@@ -0,0 +1,90 @@
+package clustermanager
+
+import (
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var _ = ginkgo.Describe("Who-has lookup", func() {
+	newNode := func(name, hostSubnet, address string) *kapi.Node {
+		annotations, err := util.UpdateNodeHostSubnetAnnotation(nil,
+			[]*net.IPNet{ovntest.MustParseIPNet(hostSubnet)}, ovntypes.DefaultNetworkName)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		return &kapi.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+			Status: kapi.NodeStatus{Addresses: []kapi.NodeAddress{
+				{Type: kapi.NodeInternalIP, Address: address},
+			}},
+		}
+	}
+	newPod := func(name, ip string) *kapi.Pod {
+		annotations, err := util.MarshalPodAnnotation(nil, &util.PodAnnotation{
+			IPs: []*net.IPNet{ovntest.MustParseIPNet(ip)},
+			MAC: util.IPAddrToHWAddr(ovntest.MustParseIPNet(ip).IP),
+		}, ovntypes.DefaultNetworkName)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		return &kapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Annotations: annotations}}
+	}
+
+	ginkgo.It("parses IPs and subnets", func() {
+		network, err := ParseWhoHasQuery("10.244.1.5")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(network.String()).To(gomega.Equal("10.244.1.5/32"))
+		network, err = ParseWhoHasQuery("fd00::5")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(network.String()).To(gomega.Equal("fd00::5/128"))
+		network, err = ParseWhoHasQuery("10.244.1.5/16")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(network.String()).To(gomega.Equal("10.244.0.0/16"))
+		_, err = ParseWhoHasQuery("node1")
+		gomega.Expect(err).To(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("finds the nodes and pods owning an IP and reports the conflicts", func() {
+		nodes := []*kapi.Node{
+			newNode("node1", "10.244.1.0/24", "172.18.0.2"),
+			newNode("node2", "10.244.2.0/24", "172.18.0.3"),
+		}
+		pods := []*kapi.Pod{
+			newPod("pod1", "10.244.1.5/24"),
+			newPod("pod2", "10.244.1.5/24"),
+			newPod("pod3", "10.244.1.6/24"),
+			newPod("pod4", "10.244.2.5/24"),
+		}
+
+		owners := WhoHasAnnotated(nodes, nil, pods, ovntest.MustParseIPNet("10.244.1.5/32"))
+		gomega.Expect(owners).To(gomega.Equal([]WhoHasOwner{
+			{Kind: WhoHasKindNode, Name: "node1", Network: ovntypes.DefaultNetworkName, CIDR: "10.244.1.0/24", Source: WhoHasSourceAnnotation},
+			{Kind: WhoHasKindPod, Name: "ns/pod1", Network: ovntypes.DefaultNetworkName, CIDR: "10.244.1.5/32", Source: WhoHasSourceAnnotation},
+			{Kind: WhoHasKindPod, Name: "ns/pod2", Network: ovntypes.DefaultNetworkName, CIDR: "10.244.1.5/32", Source: WhoHasSourceAnnotation},
+		}))
+		gomega.Expect(WhoHasConflicts(owners)).To(gomega.Equal([]string{
+			`10.244.1.5/32 of network "default" is claimed by pod ns/pod1, pod ns/pod2`,
+		}))
+
+		owners = WhoHasAnnotated(nodes, nil, pods, ovntest.MustParseIPNet("172.18.0.3/32"))
+		gomega.Expect(owners).To(gomega.Equal([]WhoHasOwner{
+			{Kind: WhoHasKindNode, Name: "node2", CIDR: "172.18.0.3/32", Source: WhoHasSourceAnnotation},
+		}))
+		gomega.Expect(WhoHasConflicts(owners)).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("reports a host subnet allocated to another node than the annotated one", func() {
+		owners := []WhoHasOwner{
+			{Kind: WhoHasKindNetwork, Name: "default", Network: "default", CIDR: "10.244.0.0/16", Source: WhoHasSourceAllocator},
+			{Kind: WhoHasKindNode, Name: "node1", Network: "default", CIDR: "10.244.1.0/24", Source: WhoHasSourceAllocator},
+			{Kind: WhoHasKindNode, Name: "node2", Network: "default", CIDR: "10.244.1.0/24", Source: WhoHasSourceAnnotation},
+		}
+		gomega.Expect(WhoHasConflicts(owners)).To(gomega.Equal([]string{
+			`10.244.1.0/24 of network "default" is claimed by node node1, node node2`,
+		}))
+	})
+})
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	cache "k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/id"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
@@ -40,13 +42,19 @@ type zoneClusterController struct {
 	// ID allocator for the nodes
 	nodeIDAllocator id.Allocator
 
-	// node gateway router port IP generators (connecting to the join switch)
-	nodeGWRouterLRPIPv4Generator *ipGenerator
-	nodeGWRouterLRPIPv6Generator *ipGenerator
+	// node gateway router port IP allocator (connecting to the join switch)
+	joinSubnetAllocator *joinSubnetAllocator
 
-	// Transit switch IP generator. This is required if EnableInterconnect feature is enabled.
-	transitSwitchIPv4Generator *ipGenerator
-	transitSwitchIPv6Generator *ipGenerator
+	// Transit switch IP allocator. This is required if EnableInterconnect feature is enabled.
+	transitSwitchAllocator *transitSwitchAllocator
+
+	// nodeAnnotationBatcher, if set, coalesces the updates of the node
+	// annotations with those of the networks
+	nodeAnnotationBatcher *kube.NodeAnnotationBatcher
+
+	// nodeFinalizer removes the node finalizers the zone controllers didn't
+	// remove in time
+	nodeFinalizer *nodeFinalizerController
 }
 
 func newZoneClusterController(ovnClient *util.OVNClusterManagerClientset, wf *factory.WatchFactory) (*zoneClusterController, error) {
@@ -69,50 +77,28 @@ func newZoneClusterController(ovnClient *util.OVNClusterManagerClientset, wf *fa
 	}
 	wg := &sync.WaitGroup{}
 
-	var nodeGWRouterLRPIPv4Generator, nodeGWRouterLRPIPv6Generator *ipGenerator
-
-	if config.IPv4Mode {
-		nodeGWRouterLRPIPv4Generator, err = newIPGenerator(config.Gateway.V4JoinSubnet)
-		if err != nil {
-			return nil, fmt.Errorf("error creating IP Generator for v4 join subnet %s: %w", config.Gateway.V4JoinSubnet, err)
-		}
-	}
-
-	if config.IPv6Mode {
-		nodeGWRouterLRPIPv6Generator, err = newIPGenerator(config.Gateway.V6JoinSubnet)
-		if err != nil {
-			return nil, fmt.Errorf("error creating IP Generator for v6 join subnet %s: %w", config.Gateway.V6JoinSubnet, err)
-		}
+	joinSubnetAllocator, err := newJoinSubnetAllocator()
+	if err != nil {
+		return nil, err
 	}
 
-	var transitSwitchIPv4Generator, transitSwitchIPv6Generator *ipGenerator
-
+	var transitSwitchAllocator *transitSwitchAllocator
 	if config.OVNKubernetesFeature.EnableInterconnect {
-		if config.IPv4Mode {
-			transitSwitchIPv4Generator, err = newIPGenerator(config.ClusterManager.V4TransitSwitchSubnet)
-			if err != nil {
-				return nil, fmt.Errorf("error creating IP Generator for v4 transit switch subnet %s: %w", config.ClusterManager.V4TransitSwitchSubnet, err)
-			}
-		}
-
-		if config.IPv6Mode {
-			transitSwitchIPv6Generator, err = newIPGenerator(config.ClusterManager.V6TransitSwitchSubnet)
-			if err != nil {
-				return nil, fmt.Errorf("error creating IP Generator for v6 transit switch subnet %s: %w", config.ClusterManager.V4TransitSwitchSubnet, err)
-			}
+		transitSwitchAllocator, err = newTransitSwitchAllocator()
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	zcc := &zoneClusterController{
-		kube:                         kube,
-		watchFactory:                 wf,
-		stopChan:                     make(chan struct{}),
-		wg:                           wg,
-		nodeIDAllocator:              nodeIDAllocator,
-		nodeGWRouterLRPIPv4Generator: nodeGWRouterLRPIPv4Generator,
-		nodeGWRouterLRPIPv6Generator: nodeGWRouterLRPIPv6Generator,
-		transitSwitchIPv4Generator:   transitSwitchIPv4Generator,
-		transitSwitchIPv6Generator:   transitSwitchIPv6Generator,
+		kube:                   kube,
+		watchFactory:           wf,
+		stopChan:               make(chan struct{}),
+		wg:                     wg,
+		nodeIDAllocator:        nodeIDAllocator,
+		joinSubnetAllocator:    joinSubnetAllocator,
+		transitSwitchAllocator: transitSwitchAllocator,
+		nodeFinalizer:          newNodeFinalizerController(kube, wf),
 	}
 
 	zcc.initRetryFramework()
@@ -137,6 +123,7 @@ func (zcc *zoneClusterController) initRetryFramework() {
 
 // Start starts the zone cluster controller to watch the kubernetes nodes
 func (zcc *zoneClusterController) Start(ctx context.Context) error {
+	zcc.nodeFinalizer.Run(zcc.stopChan, zcc.wg)
 	nodeHandler, err := zcc.retryNodes.WatchResource()
 
 	if err != nil {
@@ -167,18 +154,16 @@ func (zcc *zoneClusterController) handleAddUpdateNodeEvent(node *corev1.Node) er
 
 	// Allocate the IP address(es) for the node Gateway router port connecting
 	// to the Join switch
-	var v4Addr, v6Addr *net.IPNet
-	if config.IPv4Mode {
-		v4Addr, err = zcc.nodeGWRouterLRPIPv4Generator.GenerateIP(allocatedNodeID)
-		if err != nil {
-			return fmt.Errorf("failed to generate gateway router port IPv4 address for node %s : err - %w", node.Name, err)
-		}
+	joinIPs, err := zcc.joinSubnetAllocator.allocateNodeIPs(node)
+	if err != nil {
+		return fmt.Errorf("failed to allocate gateway router port addresses for node %s : err - %w", node.Name, err)
 	}
-
-	if config.IPv6Mode {
-		v6Addr, err = zcc.nodeGWRouterLRPIPv6Generator.GenerateIP(allocatedNodeID)
-		if err != nil {
-			return fmt.Errorf("failed to generate gateway router port IPv6 address for node %s : err - %w", node.Name, err)
+	var v4Addr, v6Addr *net.IPNet
+	for _, joinIP := range joinIPs {
+		if utilnet.IsIPv6(joinIP.IP) {
+			v6Addr = joinIP
+		} else {
+			v4Addr = joinIP
 		}
 	}
 
@@ -191,17 +176,15 @@ func (zcc *zoneClusterController) handleAddUpdateNodeEvent(node *corev1.Node) er
 	if config.OVNKubernetesFeature.EnableInterconnect {
 		v4Addr = nil
 		v6Addr = nil
-		if config.IPv4Mode {
-			v4Addr, err = zcc.transitSwitchIPv4Generator.GenerateIP(allocatedNodeID)
-			if err != nil {
-				return fmt.Errorf("failed to generate transit switch port IPv4 address for node %s : err - %w", node.Name, err)
-			}
+		transitSwitchIPs, err := zcc.transitSwitchAllocator.nodeIPs(node, allocatedNodeID)
+		if err != nil {
+			return fmt.Errorf("failed to allocate transit switch port addresses for node %s : err - %w", node.Name, err)
 		}
-
-		if config.IPv6Mode {
-			v6Addr, err = zcc.transitSwitchIPv6Generator.GenerateIP(allocatedNodeID)
-			if err != nil {
-				return fmt.Errorf("failed to generate transit switch port IPv6 address for node %s : err - %w", node.Name, err)
+		for _, transitSwitchIP := range transitSwitchIPs {
+			if utilnet.IsIPv6(transitSwitchIP.IP) {
+				v6Addr = transitSwitchIP
+			} else {
+				v4Addr = transitSwitchIP
 			}
 		}
 
@@ -213,17 +196,80 @@ func (zcc *zoneClusterController) handleAddUpdateNodeEvent(node *corev1.Node) er
 	}
 	// TODO (numans)  If EnableInterconnect is false, clear the NodeTransitSwitchPortAddrAnnotation if set.
 
+	if zcc.nodeAnnotationBatcher != nil {
+		return zcc.nodeAnnotationBatcher.UpdateNodeAnnotations(node.Name, func(annotations map[string]string) (map[string]string, error) {
+			for key, value := range nodeAnnotations {
+				annotations[key] = value.(string)
+			}
+			return annotations, nil
+		})
+	}
 	return zcc.kube.SetAnnotationsOnNode(node.Name, nodeAnnotations)
 }
 
 // handleAddUpdateNodeEvent handles the delete node event
 func (zcc *zoneClusterController) handleDeleteNode(node *corev1.Node) error {
 	zcc.nodeIDAllocator.ReleaseID(node.Name)
+	zcc.joinSubnetAllocator.releaseNodeIPs(node.Name)
+	if zcc.transitSwitchAllocator != nil {
+		zcc.transitSwitchAllocator.releaseNodeIPs(node.Name)
+	}
 	return nil
 }
 
 func (zcc *zoneClusterController) syncNodes(nodes []interface{}) error {
-	return zcc.syncNodeIDs(nodes)
+	if err := zcc.syncNodeIDs(nodes); err != nil {
+		return err
+	}
+	if err := zcc.syncNodeTransitSwitchIPs(nodes); err != nil {
+		return err
+	}
+	return zcc.syncNodeJoinIPs(nodes)
+}
+
+// syncNodeTransitSwitchIPs reserves the transit switch addresses the existing
+// nodes hold from the ranges of their zone before any node gets new ones
+func (zcc *zoneClusterController) syncNodeTransitSwitchIPs(nodes []interface{}) error {
+	if zcc.transitSwitchAllocator == nil {
+		return nil
+	}
+	for _, nodeObj := range nodes {
+		node, ok := nodeObj.(*corev1.Node)
+		if !ok {
+			return fmt.Errorf("spurious object in syncNodes: %v", nodeObj)
+		}
+		if err := zcc.transitSwitchAllocator.reserveNodeIPs(node); err != nil && !util.IsAnnotationNotSetError(err) {
+			klog.Infof("Node %s gets new transit switch addresses: %v", node.Name, err)
+		}
+	}
+	return nil
+}
+
+// syncNodeJoinIPs reserves the join subnet addresses of the existing nodes
+// before any node gets new ones. The nodes holding invalid addresses, like
+// duplicate ones, get new ones once handled.
+func (zcc *zoneClusterController) syncNodeJoinIPs(nodes []interface{}) error {
+	sortedNodes := make([]*corev1.Node, 0, len(nodes))
+	for _, nodeObj := range nodes {
+		node, ok := nodeObj.(*corev1.Node)
+		if !ok {
+			return fmt.Errorf("spurious object in syncNodes: %v", nodeObj)
+		}
+		sortedNodes = append(sortedNodes, node)
+	}
+	// the addresses annotated on several nodes stay with the oldest one
+	sort.SliceStable(sortedNodes, func(i, j int) bool {
+		if !sortedNodes[i].CreationTimestamp.Equal(&sortedNodes[j].CreationTimestamp) {
+			return sortedNodes[i].CreationTimestamp.Before(&sortedNodes[j].CreationTimestamp)
+		}
+		return sortedNodes[i].Name < sortedNodes[j].Name
+	})
+	for _, node := range sortedNodes {
+		if err := zcc.joinSubnetAllocator.reserveNodeIPs(node); err != nil && !util.IsAnnotationNotSetError(err) {
+			klog.Infof("Node %s gets new join subnet addresses: %v", node.Name, err)
+		}
+	}
+	return nil
 }
 
 func (zcc *zoneClusterController) syncNodeIDs(nodes []interface{}) error {
@@ -281,6 +327,7 @@ func (h *zoneClusterControllerEventHandler) AddResource(obj interface{}, fromRet
 		if !ok {
 			return fmt.Errorf("could not cast %T object to *corev1.Node", obj)
 		}
+		h.zcc.nodeFinalizer.enqueue(node)
 		if err = h.zcc.handleAddUpdateNodeEvent(node); err != nil {
 			return fmt.Errorf("node add failed for %s, will try again later: %w",
 				node.Name, err)
@@ -303,6 +350,7 @@ func (h *zoneClusterControllerEventHandler) UpdateResource(oldObj, newObj interf
 		if !ok {
 			return fmt.Errorf("could not cast %T object to *corev1.Node", newObj)
 		}
+		h.zcc.nodeFinalizer.enqueue(node)
 		if err = h.zcc.handleAddUpdateNodeEvent(node); err != nil {
 			return fmt.Errorf("node update failed for %s, will try again later: %w",
 				node.Name, err)
@@ -370,6 +418,14 @@ func (h *zoneClusterControllerEventHandler) AreResourcesEqual(obj1, obj2 interfa
 		if util.NodeTransitSwitchPortAddrAnnotationChanged(node1, node2) {
 			return false, nil
 		}
+		// the transit switch addresses depend on the zone of the node
+		if util.NodeZoneAnnotationChanged(node1, node2) {
+			return false, nil
+		}
+		// the finalizer timeout runs from the deletion of the node
+		if !node1.DeletionTimestamp.Equal(node2.DeletionTimestamp) {
+			return false, nil
+		}
 		return true, nil
 	}
 
@@ -155,6 +155,7 @@ func (pr *PodRequest) cmdAdd(kubeAuth *KubeAPIAuth, clientset *ClientSet) (*Resp
 	} else {
 		response.PodIFInfo = podInterfaceInfo
 	}
+	clientset.savePodState(pod)
 
 	return response, nil
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/localstate"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
@@ -94,6 +95,13 @@ func NewCNIServer(factory factory.NodeWatchFactory, kclient kubernetes.Interface
 	return s, nil
 }
 
+// EnableLocalState caches the state of the pods plumbed by the server in the
+// given store, and plumbs the pods from their cached state while the apiserver
+// is unreachable
+func (s *Server) EnableLocalState(store *localstate.Store) {
+	s.clientSet.localState = store
+}
+
 // Split the "CNI_ARGS" environment variable's value into a map.  CNI_ARGS
 // contains arbitrary key/value pairs separated by ';' and is for runtime or
 // plugin specific uses.  Kubernetes passes the pod namespace and name in
@@ -15,6 +15,7 @@ import (
 
 	"k8s.io/klog/v2"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
@@ -221,6 +222,15 @@ func setupInterface(netns ns.NetNS, containerID, ifName string, ifInfo *PodInter
 			}
 		}
 
+		// the host veth is attached to the userspace datapath, which doesn't
+		// complete the partial checksums of the pod traffic
+		if ifInfo.UserspaceDatapath {
+			err = util.DisableTxChecksumOffload(contIface.Name)
+			if err != nil {
+				return fmt.Errorf("could not set up container interface for the userspace datapath: %v", err)
+			}
+		}
+
 		oldHostVethName = hostVeth.Name
 
 		// to generate the unique host interface name, postfix it with the podInterface index for non-default network
@@ -369,8 +379,8 @@ func ConfigureOVS(ctx context.Context, namespace, podName, hostIfaceName string,
 			// br-int for the same pod; do not delete port in this case.
 			continue
 		}
-		if out, err := ovsExec("--with-iface", "del-port", "br-int", name); err != nil {
-			klog.Warningf("Failed to delete stale OVS port %q with iface-id %q from br-int: %v\n %q",
+		if out, err := ovsExec("--with-iface", "del-port", config.OvnKubeNode.IntegrationBridge, name); err != nil {
+			klog.Warningf("Failed to delete stale OVS port %q with iface-id %q from the integration bridge: %v\n %q",
 				name, ifaceID, err, out)
 		}
 	}
@@ -396,7 +406,7 @@ func ConfigureOVS(ctx context.Context, namespace, podName, hostIfaceName string,
 	// Add the new sandbox's OVS port, tag the port as transient so stale
 	// pod ports are scrubbed on hard reboot
 	ovsArgs := []string{
-		"--may-exist", "add-port", "br-int", hostIfaceName, "other_config:transient=true", "--", "set",
+		"--may-exist", "add-port", config.OvnKubeNode.IntegrationBridge, hostIfaceName, "other_config:transient=true", "--", "set",
 		"interface", hostIfaceName,
 		fmt.Sprintf("external_ids:attached_mac=%s", ifInfo.MAC),
 		fmt.Sprintf("external_ids:iface-id=%s", ifaceID),
@@ -527,9 +537,38 @@ func (pr *PodRequest) ConfigureInterface(getter PodInfoGetter, ifInfo *PodInterf
 		}
 	}
 
+	// the IPs of the localnet pods are on the physical network, make sure no other host already uses them
+	if pr.CNIConf.Topology == types.LocalnetTopology && config.OVNKubernetesFeature.IPConflictProbeTimeout > 0 &&
+		!ifInfo.IsDPUHostMode {
+		if err = probeIPConflicts(netns, contIface.Name, ifInfo.IPs); err != nil {
+			pr.deletePorts(hostIface.Name, pr.PodNamespace, pr.PodName)
+			return nil, fmt.Errorf("failed to configure pod %s/%s interface %s: %v", pr.PodNamespace, pr.PodName,
+				contIface.Name, err)
+		}
+	}
+
 	return []*current.Interface{hostIface, contIface}, nil
 }
 
+// probeIPConflicts fails if one of the IPs of the container interface is already in use by another host of its
+// network. Failures to probe are ignored.
+func probeIPConflicts(netns ns.NetNS, ifName string, ips []*net.IPNet) error {
+	timeout := time.Duration(config.OVNKubernetesFeature.IPConflictProbeTimeout) * time.Millisecond
+	return netns.Do(func(_ ns.NetNS) error {
+		for _, ipNet := range ips {
+			inUse, owner, err := util.ProbeAddressOverIfaceByName(ipNet.IP, ifName, timeout)
+			if err != nil {
+				klog.Warningf("Failed to probe IP %s of interface %s for conflicts: %v", ipNet.IP, ifName, err)
+				continue
+			}
+			if inUse {
+				return fmt.Errorf("IP %s is already in use by %s", ipNet.IP, owner)
+			}
+		}
+		return nil
+	})
+}
+
 func (pr *PodRequest) UnconfigureInterface(ifInfo *PodInterfaceInfo) error {
 	podDesc := fmt.Sprintf("for pod %s/%s NAD %s", pr.PodNamespace, pr.PodName, pr.nadName)
 	klog.V(5).Infof("Tear down interface (%+v) %s", *pr, podDesc)
@@ -642,7 +681,7 @@ func (pr *PodRequest) deletePodConntrack() {
 func (pr *PodRequest) deletePorts(ifaceName, podNamespace, podName string) {
 	podDesc := fmt.Sprintf("%s/%s", podNamespace, podName)
 
-	out, err := ovsExec("del-port", "br-int", ifaceName)
+	out, err := ovsExec("del-port", config.OvnKubeNode.IntegrationBridge, ifaceName)
 	if err != nil && !strings.Contains(err.Error(), "no port named") {
 		// DEL should be idempotent; don't return an error just log it
 		klog.Warningf("Failed to delete pod %q OVS port %s: %v\n  %q", podDesc, ifaceName, err, string(out))
@@ -27,6 +27,31 @@ type NetConf struct {
 	ExcludeSubnets string `json:"excludeSubnets,omitempty"`
 	// VLANID, valid in localnet topology network only
 	VLANID int `json:"vlanID,omitempty"`
+	// Firewall is a coarse allow/deny list of CIDRs applied to the traffic
+	// of all the pods attached to the network, valid for secondary networks
+	// with subnets
+	Firewall *NetworkFirewall `json:"firewall,omitempty"`
+	// HostSubnetAllocation is how the host subnets of the nodes are picked
+	// from the subnets, "sequential" (default) or "randomized", valid for
+	// layer3 network only
+	HostSubnetAllocation string `json:"hostSubnetAllocation,omitempty"`
+	// NodeIPChunkSize is the number of IPs, a power of two, of the chunks of
+	// the subnets allocated to each node to allocate the IPs of its pods from,
+	// valid for layer2 network with interconnect only
+	NodeIPChunkSize int `json:"nodeIPChunkSize,omitempty"`
+	// Internal denies any north-south connectivity to the network: no
+	// default route through it and no standalone host attached to it, not
+	// valid in localnet topology network
+	Internal bool `json:"internal,omitempty"`
+	// Passthrough allows or denies the link-local, LLDP and ARP traffic of
+	// the pods attached to the network, valid in localnet topology network
+	// only
+	Passthrough *LocalnetPassthrough `json:"passthrough,omitempty"`
+	// Role of the network for the pods attached to it, "secondary" (default)
+	// or "primary" to give the network gateway routers and the egress of the
+	// pods, like egress IPs. Valid for layer3 and layer2 topology networks
+	// with subnets only
+	Role string `json:"role,omitempty"`
 
 	// PciAddrs in case of using sriov or Auxiliry device name in case of SF
 	DeviceID string `json:"deviceID,omitempty"`
@@ -45,6 +70,53 @@ type NetConf struct {
 	LogFileMaxAge int `json:"logfile-maxage"`
 }
 
+const (
+	// NetworkFirewallActionAllow lets the matching traffic through the
+	// network firewall, still subject to the network policies
+	NetworkFirewallActionAllow = "Allow"
+	// NetworkFirewallActionDeny drops the matching traffic
+	NetworkFirewallActionDeny = "Deny"
+)
+
+// NetworkFirewall holds the ingress and egress rules of the firewall of a
+// network. The first rule matching the remote address of the traffic, in
+// order, applies; the traffic not matching any rule is allowed.
+type NetworkFirewall struct {
+	// Ingress rules match the source of the traffic to the pods
+	Ingress []NetworkFirewallRule `json:"ingress,omitempty"`
+	// Egress rules match the destination of the traffic from the pods
+	Egress []NetworkFirewallRule `json:"egress,omitempty"`
+}
+
+// NetworkFirewallRule allows or denies the traffic from or to a CIDR
+type NetworkFirewallRule struct {
+	// Action is either "Allow" or "Deny"
+	Action string `json:"action"`
+	// CIDR of the remote addresses, eg. 10.1.0.0/16
+	CIDR string `json:"cidr"`
+}
+
+const (
+	// PassthroughActionAllow always lets the matching traffic through,
+	// whatever the network firewall and the multi network policies
+	PassthroughActionAllow = "Allow"
+	// PassthroughActionDeny drops the matching traffic
+	PassthroughActionDeny = "Deny"
+)
+
+// LocalnetPassthrough holds the actions on the link-local and the control
+// protocol traffic of the pods of a localnet network, either "Allow" or
+// "Deny". The traffic of an empty action gets the default handling.
+type LocalnetPassthrough struct {
+	// LinkLocal is the action on the traffic from or to the IPv4
+	// (169.254.0.0/16) and IPv6 (fe80::/10) link-local addresses
+	LinkLocal string `json:"linkLocal,omitempty"`
+	// LLDP is the action on the LLDP frames
+	LLDP string `json:"lldp,omitempty"`
+	// ARP is the action on the ARP packets
+	ARP string `json:"arp,omitempty"`
+}
+
 // NetworkSelectionElement represents one element of the JSON format
 // Network Attachment Selection Annotation as described in section 4.1.2
 // of the CRD specification.
@@ -13,6 +13,7 @@ import (
 	kapi "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
 )
 
 // wait on a certain pod annotation related condition
@@ -41,7 +42,8 @@ func isDPUReady(podAnnotation map[string]string, nadName string) (*util.PodAnnot
 
 // getPod tries to read a Pod object from the informer cache, or if the pod
 // doesn't exist there, the apiserver. If neither a list or a kube client is
-// given, returns no pod and no error
+// given, returns no pod and no error. If the apiserver cannot be reached, the
+// pod is read from the local state, if enabled.
 func (c *ClientSet) getPod(namespace, name string) (*kapi.Pod, error) {
 	var pod *kapi.Pod
 	var err error
@@ -54,11 +56,28 @@ func (c *ClientSet) getPod(namespace, name string) (*kapi.Pod, error) {
 	if pod == nil {
 		// If the pod wasn't in our local cache, ask for it directly
 		pod, err = c.kclient.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) && c.localState != nil {
+			cached, cacheErr := c.localState.GetPod(namespace, name)
+			if cacheErr == nil {
+				klog.Warningf("Failed to get pod %s/%s from the apiserver, using its cached state: %v", namespace, name, err)
+				return cached, nil
+			}
+		}
 	}
 
 	return pod, err
 }
 
+// savePodState caches the state of the pod in the local state, if enabled
+func (c *ClientSet) savePodState(pod *kapi.Pod) {
+	if c.localState == nil {
+		return
+	}
+	if err := c.localState.SavePod(pod); err != nil {
+		klog.Warningf("Failed to cache the state of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+}
+
 // GetPodAnnotations obtains the pod UID and annotation from the cache or apiserver
 func GetPodWithAnnotations(ctx context.Context, getter PodInfoGetter,
 	namespace, name, nadName string, annotCond podAnnotWaitCond) (*kapi.Pod, map[string]string, *util.PodAnnotation, error) {
@@ -129,6 +148,7 @@ func PodAnnotation2PodInfo(podAnnotation map[string]string, podNADAnnotation *ut
 		NetName:              netName,
 		NADName:              nadName,
 		EnableUDPAggregation: config.Default.EnableUDPAggregation,
+		UserspaceDatapath:    config.IsUserspaceDatapath(),
 	}
 	return podInterfaceInfo, nil
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/localstate"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
@@ -17,8 +18,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func newPod(namespace, name string, annotations map[string]string) *v1.Pod {
@@ -240,6 +243,33 @@ var _ = Describe("CNI Utils tests", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("timed out waiting for pod after 1s"))
 		})
+
+		It("Uses the local state of the pod if the apiserver is unreachable", func() {
+			ctx, cancelFunc := context.WithTimeout(context.Background(), 1*time.Second)
+			defer cancelFunc()
+
+			clientset := newFakeClientSet(nil, &podNamespaceLister)
+			clientset.kclient.(*fake.Clientset).PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, fmt.Errorf("connection refused")
+			})
+			podNamespaceLister.On("Get", mock.AnythingOfType("string")).Return(nil, errors.NewNotFound(v1.Resource("pod"), name))
+
+			// without local state, the error is returned
+			_, _, _, err := GetPodWithAnnotations(ctx, clientset, namespace, podName, ovntypes.DefaultNetworkName, isOvnReady)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("connection refused"))
+
+			clientset.localState, err = localstate.NewStore(GinkgoT().TempDir())
+			Expect(err).ToNot(HaveOccurred())
+			pod.Annotations = map[string]string{util.OvnPodAnnotationName: defaultPodAnnotation}
+			clientset.savePodState(pod)
+
+			returnedPod, _, podNADAnnotation, err := GetPodWithAnnotations(ctx, clientset, namespace, podName,
+				ovntypes.DefaultNetworkName, isOvnReady)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(returnedPod.UID).To(Equal(pod.UID))
+			Expect(podNADAnnotation.MAC.String()).To(Equal("0a:58:c0:a8:02:03"))
+		})
 	})
 
 	Context("PodAnnotation2PodInfo", func() {
@@ -13,6 +13,9 @@ import (
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/urfave/cli/v2"
 	gcfg "gopkg.in/gcfg.v1"
@@ -67,19 +70,22 @@ var (
 		MonitorAll:            true,
 		LFlowCacheEnable:      true,
 		RawClusterSubnets:     "10.128.0.0/14/23",
+		MaxHostSubnetsPerNode: 1,
+		Topology:              types.Layer3Topology,
 		Zone:                  types.OvnDefaultZone,
 	}
 
 	// Logging holds logging-related parsed config file parameters and command-line overrides
 	Logging = LoggingConfig{
-		File:                "", // do not log to a file by default
-		CNIFile:             "",
-		LibovsdbFile:        "",
-		Level:               4,
-		LogFileMaxSize:      100, // Size in Megabytes
-		LogFileMaxBackups:   5,
-		LogFileMaxAge:       5, //days
-		ACLLoggingRateLimit: 20,
+		File:                   "", // do not log to a file by default
+		CNIFile:                "",
+		LibovsdbFile:           "",
+		Level:                  4,
+		LogFileMaxSize:         100, // Size in Megabytes
+		LogFileMaxBackups:      5,
+		LogFileMaxAge:          5, //days
+		ACLLoggingRateLimit:    20,
+		EgressIPNATLogSampling: 1,
 	}
 
 	// Monitoring holds monitoring-related parsed config file parameters and command-line overrides
@@ -112,6 +118,9 @@ var (
 		PlatformType:         "",
 		DNSServiceNamespace:  "kube-system",
 		DNSServiceName:       "kube-dns",
+
+		FullSyncTokenLeaseDuration: 60,
+		FullSyncTokenMaxWait:       600,
 	}
 
 	// Metrics holds Prometheus metrics-related parameters.
@@ -119,7 +128,21 @@ var (
 
 	// OVNKubernetesFeatureConfig holds OVN-Kubernetes feature enhancement config file parameters and command-line overrides
 	OVNKubernetesFeature = OVNKubernetesFeatureConfig{
-		EgressIPReachabiltyTotalTimeout: 1,
+		EgressIPReachabiltyTotalTimeout:     1,
+		NodeNetworkStateBackend:             NodeNetworkStateBackendAnnotation,
+		IPv6OnlyValidation:                  IPv6OnlyValidationError,
+		GARPCount:                           1,
+		GARPInterval:                        1000,
+		EgressIPCloudReconcileInterval:      300,
+		EgressIPAssignmentPolicy:            EgressIPAssignmentPolicySticky,
+		EgressIPRebalanceInterval:           60,
+		EgressIPRebalanceMaxMoves:           1,
+		StaleObjectGCInterval:               600,
+		PodIPMismatchCheckInterval:          600,
+		LoadBalancerAnnounceMode:            LoadBalancerAnnounceModeL2,
+		ObservabilityDropSamplingPercentage: 100,
+		ObservabilityCollectorPort:          4740,
+		NBRolloutOnError:                    NBRolloutOnErrorPause,
 	}
 
 	// OvnNorth holds northbound OVN database client and server authentication and location details
@@ -130,10 +153,16 @@ var (
 
 	// Gateway holds node gateway-related parsed config file parameters and command-line overrides
 	Gateway = GatewayConfig{
-		V4JoinSubnet:       "100.64.0.0/16",
-		V6JoinSubnet:       "fd98::/64",
-		V4MasqueradeSubnet: "169.254.169.0/29",
-		V6MasqueradeSubnet: "fd69::/125",
+		V4JoinSubnet:                "100.64.0.0/16",
+		V6JoinSubnet:                "fd98::/64",
+		V4JoinNodeSubnetLength:      32,
+		V6JoinNodeSubnetLength:      128,
+		V4MasqueradeSubnet:          "169.254.169.0/29",
+		V6MasqueradeSubnet:          "fd69::/125",
+		V4MasqueradeSubnetPool:      "169.254.0.0/16",
+		V6MasqueradeSubnetPool:      "fd69::/112",
+		SNATConntrackZoneAllocation: SNATConntrackZoneAllocationHost,
+		RawSNATConntrackZoneRange:   "64100-64999",
 		MasqueradeIPs: MasqueradeIPsConfig{
 			V4OVNMasqueradeIP:               net.ParseIP("169.254.169.1"),
 			V6OVNMasqueradeIP:               net.ParseIP("fd69::1"),
@@ -181,12 +210,23 @@ var (
 
 	// OvnKubeNode holds ovnkube-node parsed config file parameters and command-line overrides
 	OvnKubeNode = OvnKubeNodeConfig{
-		Mode: types.NodeModeFull,
+		Mode:              types.NodeModeFull,
+		Datapath:          types.NodeDatapathSystem,
+		IntegrationBridge: types.DefaultIntegrationBridge,
 	}
 
 	ClusterManager = ClusterManagerConfig{
 		V4TransitSwitchSubnet: "168.254.0.0/16",
 		V6TransitSwitchSubnet: "fd97::/64",
+		HostSubnetAllocation:  HostSubnetAllocationSequential,
+
+		IPFamilyConversionBatchInterval:   60,
+		SubnetFragmentationReportInterval: 300,
+		SubnetCompactionBatchInterval:     300,
+		SubnetMigrationBatchInterval:      60,
+		NodeAnnotationBatchInterval:       100,
+		WebhookTrustedUsers: "system:serviceaccount:ovn-kubernetes:ovnkube-cluster-manager," +
+			"system:serviceaccount:ovn-kubernetes:ovnkube-master",
 	}
 )
 
@@ -250,6 +290,20 @@ type DefaultConfig struct {
 	// ClusterSubnets holds parsed cluster subnet entries and may be used
 	// outside the config module.
 	ClusterSubnets []CIDRNetworkEntry
+	// MaxHostSubnetsPerNode is the maximum number of host subnets of each IP family of the
+	// default network given to a node, additional host subnets being allocated once the pod
+	// IPs of the previous ones are exhausted. 1 disables the additional host subnets.
+	MaxHostSubnetsPerNode int `gcfg:"max-host-subnets-per-node"`
+	// Topology is the topology of the default network, either "layer3" for a host subnet
+	// per node or "layer2" for a single switch spanning all the nodes
+	Topology string `gcfg:"topology"`
+	// RawHealthCheckSubnets holds the unparsed health check subnets. Should only be
+	// used inside config module.
+	RawHealthCheckSubnets string `gcfg:"health-check-subnets"`
+	// HealthCheckSubnets holds the parsed health check subnet entries each node gets a
+	// subnet of for its dedicated health check port. Empty if the health check ports
+	// are disabled.
+	HealthCheckSubnets []CIDRNetworkEntry
 	// EnableUDPAggregation is true if ovn-kubernetes should use UDP Generic Receive
 	// Offload forwarding to improve the performance of containers that transmit lots
 	// of small UDP packets by allowing them to be aggregated before passing through
@@ -279,6 +333,19 @@ type LoggingConfig struct {
 	LogFileMaxAge int `gcfg:"logfile-maxage"`
 	// Logging rate-limiting meter
 	ACLLoggingRateLimit int `gcfg:"acl-logging-rate-limit"`
+	// EgressIPNATLogFile is the path of the file ovnkube-node logs the connections SNATed to the
+	// egress IPs assigned to the node to, rotated like the log file. Disabled if empty.
+	EgressIPNATLogFile string `gcfg:"egress-ip-nat-logfile"`
+	// EgressIPNATLogSampling logs 1 out of every EgressIPNATLogSampling connections SNATed to the
+	// egress IPs
+	EgressIPNATLogSampling int `gcfg:"egress-ip-nat-log-sampling"`
+	// DropSamplingLogFile is the path of the file ovnkube-node logs the samples of the dropped packets
+	// to, rotated like the log file, when observability is enabled. Disabled if empty.
+	DropSamplingLogFile string `gcfg:"drop-sampling-logfile"`
+	// MetricsAuditLogFile is the path of the file the accesses to the metrics servers are audited to, rotated
+	// like the log file, when the metrics auth is enabled. The accesses other than the metrics scrapes, and the
+	// denied ones, are logged if empty.
+	MetricsAuditLogFile string `gcfg:"metrics-audit-logfile"`
 }
 
 // MonitoringConfig holds monitoring-related parsed config file parameters and command-line overrides
@@ -351,6 +418,24 @@ type KubernetesConfig struct {
 
 	DNSServiceNamespace string `gcfg:"dns-service-namespace"`
 	DNSServiceName      string `gcfg:"dns-service-name"`
+
+	// FullSyncControllerTokens is the number of ovnkube-controllers allowed to
+	// run their full sync at once, 0 for no limit
+	FullSyncControllerTokens int `gcfg:"full-sync-controller-tokens"`
+	// FullSyncNodeTokens is the number of ovnkube-nodes allowed to run their
+	// full sync at once, 0 for no limit
+	FullSyncNodeTokens int `gcfg:"full-sync-node-tokens"`
+	// FullSyncTokenLeaseDuration is the duration in seconds of the leases
+	// backing the full sync tokens, a token held by a component that died
+	// being free once its lease expires
+	FullSyncTokenLeaseDuration int `gcfg:"full-sync-token-lease-duration"`
+	// FullSyncTokenMaxWait is the maximum time in seconds a component waits
+	// for a full sync token before running its full sync anyway
+	FullSyncTokenMaxWait int `gcfg:"full-sync-token-max-wait"`
+	// RawPriorityNamespaces is the comma separated list of the namespaces
+	// whose objects are reconciled ahead of the others
+	RawPriorityNamespaces string `gcfg:"priority-namespaces"`
+	PriorityNamespaces    sets.Set[string]
 }
 
 // MetricsConfig holds Prometheus metrics-related parameters.
@@ -361,10 +446,19 @@ type MetricsConfig struct {
 	EnablePprof           bool   `gcfg:"enable-pprof"`
 	NodeServerPrivKey     string `gcfg:"node-server-privkey"`
 	NodeServerCert        string `gcfg:"node-server-cert"`
+	// EnableAuth holds the boolean flag to require the requests to the metrics servers to authenticate and to be
+	// allowed, by a SubjectAccessReview of their path and method, to access their endpoint
+	EnableAuth bool `gcfg:"enable-auth"`
+	// ClientCA is the file of the CAs signing the client certificates the requests to the metrics servers can
+	// authenticate with, instead of a bearer token, when EnableAuth is set
+	ClientCA string `gcfg:"client-ca"`
 	// EnableConfigDuration holds the boolean flag to enable OVN-Kubernetes master to monitor OVN-Kubernetes master
 	// configuration duration and optionally, its application to all nodes
 	EnableConfigDuration bool `gcfg:"enable-config-duration"`
 	EnableScaleMetrics   bool `gcfg:"enable-scale-metrics"`
+	// EnableEgressIPUsageMetrics holds the boolean flag to enable ovnkube-node to export the usage of the egress IPs
+	// assigned to the node, as tracked by conntrack
+	EnableEgressIPUsageMetrics bool `gcfg:"enable-egress-ip-usage-metrics"`
 }
 
 // OVNKubernetesFeatureConfig holds OVN-Kubernetes feature enhancement config file parameters and command-line overrides
@@ -379,13 +473,170 @@ type OVNKubernetesFeatureConfig struct {
 	EnableEgressQoS                 bool `gcfg:"enable-egress-qos"`
 	EnableEgressService             bool `gcfg:"enable-egress-service"`
 	EgressIPNodeHealthCheckPort     int  `gcfg:"egressip-node-healthcheck-port"`
-	EnableMultiNetwork              bool `gcfg:"enable-multi-network"`
-	EnableMultiNetworkPolicy        bool `gcfg:"enable-multi-networkpolicy"`
-	EnableStatelessNetPol           bool `gcfg:"enable-stateless-netpol"`
-	EnableInterconnect              bool `gcfg:"enable-interconnect"`
-	EnableMultiExternalGateway      bool `gcfg:"enable-multi-external-gateway"`
+	// EgressIPNextHopProbeInterval is the interval in seconds at which the
+	// egress nodes probe their gateway next hops, 0 to disable the probes
+	EgressIPNextHopProbeInterval int  `gcfg:"egressip-next-hop-probe-interval"`
+	EnableMultiNetwork           bool `gcfg:"enable-multi-network"`
+	EnableMultiNetworkPolicy     bool `gcfg:"enable-multi-networkpolicy"`
+	EnableStatelessNetPol        bool `gcfg:"enable-stateless-netpol"`
+	EnableInterconnect           bool `gcfg:"enable-interconnect"`
+	EnableMultiExternalGateway   bool `gcfg:"enable-multi-external-gateway"`
+	// EnableSharedNetpolPortGroups makes the network policies of a namespace
+	// with equivalent pod selectors share the port group of their local pods,
+	// and the network policies with equivalent namespace selector peers share
+	// the address set of the pods of the selected namespaces
+	EnableSharedNetpolPortGroups bool `gcfg:"enable-shared-netpol-port-groups"`
+	// NodeNetworkStateBackend is where the per-node network state is stored,
+	// either "annotation" or "crd"
+	NodeNetworkStateBackend string `gcfg:"node-network-state-backend"`
+	// EnableNodeFinalizer makes node deletion wait for the cleanup of the
+	// node's OVN topology
+	EnableNodeFinalizer bool `gcfg:"enable-node-finalizer"`
+	// EnableStandaloneHosts allows hosts that are not Kubernetes nodes to
+	// attach to layer2 networks through Host objects
+	EnableStandaloneHosts bool `gcfg:"enable-standalone-hosts"`
+	// IPv6OnlyValidation is how the enabled features of IPv6-only clusters
+	// are validated: "off", "error" or "disable"
+	IPv6OnlyValidation string `gcfg:"ipv6-only-validation"`
+	// GARPCount is the number of GARPs (IPv4) or unsolicited neighbor
+	// advertisements (IPv6) ovnkube-node sends for an IP that may have moved
+	// from another node: an egress IP or a LoadBalancer IP it assigns to a
+	// host interface, or the IP of a KubeVirt VM migrated to the node
+	GARPCount int `gcfg:"garp-count"`
+	// GARPInterval is the interval in milliseconds between those announcements
+	GARPInterval int `gcfg:"garp-interval"`
+	// GARPMaxTimeout is the maximum interval in seconds at which ovn-controller
+	// keeps announcing the NAT addresses of the gateway routers, like egress
+	// IPs. When 0, ovn-controller stops announcing them after its initial
+	// backoff.
+	GARPMaxTimeout int `gcfg:"garp-max-timeout"`
+	// IPConflictProbeTimeout is the time in milliseconds ovnkube-node waits
+	// for a reply to the ARP probe (IPv4) or neighbor solicitation (IPv6) it
+	// sends before assigning an egress IP to a host interface or an IP to a
+	// localnet pod, to detect the IP already in use on the physical network.
+	// 0 disables the probes.
+	IPConflictProbeTimeout int `gcfg:"ip-conflict-probe-timeout"`
+	// EgressIPCloudReconcileInterval is the interval in seconds at which the
+	// CloudPrivateIPConfigs of the egress IPs are checked against their
+	// assignments on cloud platforms. 0 disables the check.
+	EgressIPCloudReconcileInterval int `gcfg:"egressip-cloud-reconcile-interval"`
+	// EgressIPCloudProvider is the cloud provider, "gcp" or "azure", whose API
+	// ovnkube-cluster-manager calls itself to attach the egress IPs to the
+	// network interface of their node. Empty when the egress IPs don't need
+	// to be attached, or are attached by the cloud-network-config-controller
+	// on the cloud platform types.
+	EgressIPCloudProvider string `gcfg:"egressip-cloud-provider"`
+	// EgressIPAssignmentPolicy is how the egress IPs of the EgressIPs without
+	// an assignment policy move between the egress nodes once assigned, either
+	// "sticky", "balanced" or "manual"
+	EgressIPAssignmentPolicy string `gcfg:"egressip-assignment-policy"`
+	// EgressIPRebalanceInterval is the interval in seconds at which the egress
+	// IPs of the EgressIPs with the balanced assignment policy are moved
+	// between the egress nodes. 0 disables the rebalancing.
+	EgressIPRebalanceInterval int `gcfg:"egressip-rebalance-interval"`
+	// EgressIPRebalanceMaxMoves is the maximum number of egress IPs moved per
+	// rebalancing interval. 0 disables the rebalancing.
+	EgressIPRebalanceMaxMoves int `gcfg:"egressip-rebalance-max-moves"`
+	// EnableEgressIPStandby makes ovnkube-cluster-manager designate a standby
+	// node for each egress IP hosted on the OVN managed network, and
+	// ovnkube-controller pre-program the SNAT of the egress IP on it, disabled,
+	// so that the egress IP fails over to it by flipping the rows in place
+	EnableEgressIPStandby bool `gcfg:"enable-egress-ip-standby"`
+	// StaleObjectGCInterval is the interval in seconds at which the port
+	// groups and address sets whose owning Kubernetes objects no longer exist
+	// are garbage collected. 0 disables the garbage collection.
+	StaleObjectGCInterval int `gcfg:"stale-object-gc-interval"`
+	// PodIPMismatchCheckInterval is the interval in seconds at which the IPs
+	// of the local pods in their status, pod annotation and logical switch
+	// port are checked to match. 0 disables the check.
+	PodIPMismatchCheckInterval int `gcfg:"pod-ip-mismatch-check-interval"`
+	// RawLoadBalancerIPPools are the comma separated CIDRs the built-in load
+	// balancer provider allocates the IPs of the LoadBalancer services from.
+	// The provider is disabled when empty.
+	RawLoadBalancerIPPools string `gcfg:"load-balancer-ip-pools"`
+	LoadBalancerIPPools    []*net.IPNet
+	// LoadBalancerAnnounceMode is how the IPs of the LoadBalancer services
+	// are announced, either "l2" or "none"
+	LoadBalancerAnnounceMode string `gcfg:"load-balancer-announce-mode"`
+	// EnableObservability samples the packets dropped by the ACLs of ovnkube
+	// and by the default OVN pipeline to a collector on each node
+	EnableObservability bool `gcfg:"enable-observability"`
+	// ObservabilityDropSamplingPercentage is the percentage of the dropped
+	// packets that are sampled
+	ObservabilityDropSamplingPercentage int `gcfg:"observability-drop-sampling-percentage"`
+	// ObservabilityCollectorPort is the local UDP port of the IPFIX collector
+	// of ovnkube-node the samples are sent to
+	ObservabilityCollectorPort int `gcfg:"observability-collector-port"`
+	// NBRolloutBatchSize is the maximum number of northbound rows a
+	// cluster-wide change updates per transaction. 0 updates them all at once.
+	NBRolloutBatchSize int `gcfg:"nb-rollout-batch-size"`
+	// NBRolloutBatchInterval is the interval in milliseconds between two
+	// batches of a cluster-wide change
+	NBRolloutBatchInterval int `gcfg:"nb-rollout-batch-interval"`
+	// NBRolloutOnError is what a cluster-wide change does when a batch fails,
+	// either "pause" or "continue"
+	NBRolloutOnError string `gcfg:"nb-rollout-on-error"`
+	// EnableHostPort exposes the host ports of the pods in OVN, DNATing the
+	// traffic to the node IPs and host port to the pods on the gateway
+	// routers, instead of relying on the portmap CNI plugin
+	EnableHostPort bool `gcfg:"enable-host-port"`
+	// StaticRouteAuditInterval is the interval in seconds at which
+	// ovnkube-controller audits the static routes of the routers of its zone
+	// and removes the stale ones. 0 disables the removal.
+	StaticRouteAuditInterval int `gcfg:"static-route-audit-interval"`
 }
 
+const (
+	// NodeNetworkStateBackendAnnotation stores the per-node network state as
+	// annotations on the node
+	NodeNetworkStateBackendAnnotation = "annotation"
+	// NodeNetworkStateBackendCRD stores the per-node network state in a
+	// NodeNetworkState CRD named after the node, the source of truth of the
+	// host subnets of the default network. Node annotations are still kept up
+	// to date for the consumers that have not been migrated yet.
+	NodeNetworkStateBackendCRD = "crd"
+)
+
+const (
+	// LoadBalancerAnnounceModeL2 makes the node elected for a LoadBalancer
+	// service own its IPs on the gateway interface and announce them with
+	// GARPs or unsolicited neighbor advertisements
+	LoadBalancerAnnounceModeL2 = "l2"
+	// LoadBalancerAnnounceModeNone leaves the announcement of the IPs of the
+	// LoadBalancer services, for instance over BGP, to an external speaker
+	LoadBalancerAnnounceModeNone = "none"
+)
+
+const (
+	// EgressIPAssignmentPolicySticky keeps the egress IPs on their node as
+	// long as it can host them
+	EgressIPAssignmentPolicySticky = "sticky"
+	// EgressIPAssignmentPolicyBalanced also moves the egress IPs to even out
+	// the number of egress IPs of the egress nodes
+	EgressIPAssignmentPolicyBalanced = "balanced"
+	// EgressIPAssignmentPolicyManual keeps the egress IPs on their node even
+	// while it is unreachable or not ready
+	EgressIPAssignmentPolicyManual = "manual"
+)
+
+const (
+	// EgressIPCloudProviderGCP attaches the egress IPs to the nodes as alias
+	// IP ranges
+	EgressIPCloudProviderGCP = "gcp"
+	// EgressIPCloudProviderAzure attaches the egress IPs to the nodes as
+	// secondary IP configurations
+	EgressIPCloudProviderAzure = "azure"
+)
+
+const (
+	// NBRolloutOnErrorPause stops a cluster-wide change at the first failing
+	// batch, the next batches are left untouched until the change is retried
+	NBRolloutOnErrorPause = "pause"
+	// NBRolloutOnErrorContinue applies the next batches of a cluster-wide
+	// change after a failing batch, the errors are reported at the end
+	NBRolloutOnErrorContinue = "continue"
+)
+
 // GatewayMode holds the node gateway mode
 type GatewayMode string
 
@@ -418,12 +669,26 @@ type GatewayConfig struct {
 	V4JoinSubnet string `gcfg:"v4-join-subnet"`
 	// V6JoinSubnet to be used in the cluster
 	V6JoinSubnet string `gcfg:"v6-join-subnet"`
+	// V4JoinNodeSubnetLength is the prefix length of the subnet of the v4
+	// join subnet allocated to each node
+	V4JoinNodeSubnetLength int `gcfg:"v4-join-node-subnet-length"`
+	// V6JoinNodeSubnetLength is the prefix length of the subnet of the v6
+	// join subnet allocated to each node
+	V6JoinNodeSubnetLength int `gcfg:"v6-join-node-subnet-length"`
 	// V4MasqueradeSubnet to be used in the cluster
 	V4MasqueradeSubnet string `gcfg:"v4-masquerade-subnet"`
 	// V6MasqueradeSubnet to be used in the cluster
 	V6MasqueradeSubnet string `gcfg:"v6-masquerade-subnet"`
 	// MasqueradeIps to be allocated from the masquerade subnets to enable host to service traffic
 	MasqueradeIPs MasqueradeIPsConfig
+	// MasqueradeSubnetAutoSelect lets each node select other masquerade subnets, of the same
+	// size from the masquerade subnet pools, when the configured ones collide with its addresses
+	// or routes
+	MasqueradeSubnetAutoSelect bool `gcfg:"masquerade-subnet-auto-select"`
+	// V4MasqueradeSubnetPool holds the v4 masquerade subnets the nodes select from
+	V4MasqueradeSubnetPool string `gcfg:"v4-masquerade-subnet-pool"`
+	// V6MasqueradeSubnetPool holds the v6 masquerade subnets the nodes select from
+	V6MasqueradeSubnetPool string `gcfg:"v6-masquerade-subnet-pool"`
 
 	// DisablePacketMTUCheck disables adding openflow flows to check packets too large to be
 	// delivered to OVN due to pod MTU being lower than NIC MTU. Disabling this check will result in southbound packets
@@ -439,8 +704,24 @@ type GatewayConfig struct {
 	DisableForwarding bool `gcfg:"disable-forwarding"`
 	// AllowNoUplink (disabled by default) controls if the external gateway bridge without an uplink port is allowed in local gateway mode.
 	AllowNoUplink bool `gcfg:"allow-no-uplink"`
+	// SNATConntrackZoneAllocation is how the conntrack zones the gateway routers SNAT in are assigned, either
+	// "host" or "dedicated"
+	SNATConntrackZoneAllocation string `gcfg:"snat-conntrack-zone-allocation"`
+	// RawSNATConntrackZoneRange is the "min-max" range the dedicated SNAT conntrack zones are allocated from
+	RawSNATConntrackZoneRange string `gcfg:"snat-conntrack-zone-range"`
+	SNATConntrackZoneMin      int
+	SNATConntrackZoneMax      int
 }
 
+const (
+	// SNATConntrackZoneAllocationHost makes the gateway routers SNAT in the conntrack zone 0 of the host, so that
+	// the SNATed connections don't collide with the connections of the host using the node IPs
+	SNATConntrackZoneAllocationHost = "host"
+	// SNATConntrackZoneAllocationDedicated allocates a conntrack zone to each gateway router of a node, kept across
+	// restarts, so that its SNATed connections can be told apart from the connections of the host
+	SNATConntrackZoneAllocationDedicated = "dedicated"
+)
+
 // OvnAuthConfig holds client authentication and location details for
 // an OVN database (either northbound or southbound)
 type OvnAuthConfig struct {
@@ -486,6 +767,40 @@ type OvnKubeNodeConfig struct {
 	DPResourceDeviceIdsMap map[string][]string
 	MgmtPortNetdev         string `gcfg:"mgmt-port-netdev"`
 	MgmtPortDPResourceName string `gcfg:"mgmt-port-dp-resource-name"`
+	// Datapath is the OVS datapath of the node: system (the kernel datapath),
+	// or the userspace datapath with an afxdp or dpdk uplink
+	Datapath string `gcfg:"datapath"`
+	// TunnelTuning tunes the queues, the IRQ affinity and the UDP offloads of
+	// the NIC carrying the tunnel traffic according to the NUMA topology
+	TunnelTuning bool `gcfg:"tunnel-tuning"`
+	// LocalStateDir is the directory, persisted across reboots, where the pod
+	// annotations are cached for the pods to be plumbed while the apiserver is
+	// unreachable. Empty disables the cache.
+	LocalStateDir string `gcfg:"local-state-dir"`
+	// IntegrationBridge is the name of the OVS bridge of the OVN logical
+	// ports, created by ovn-controller
+	IntegrationBridge string `gcfg:"integration-bridge"`
+	// SystemID is the OVS system-id of the node, its chassis name. Empty
+	// keeps the one found on startup.
+	SystemID string `gcfg:"system-id"`
+	// RawBridgeMappings holds the unparsed physical network:OVS bridge[:backup
+	// OVS bridge] mappings of the node, in addition to the one of its gateway.
+	// Should only be used inside config module.
+	RawBridgeMappings string `gcfg:"bridge-mappings"`
+	// BridgeMappings holds the parsed OVS bridges, by physical network
+	BridgeMappings map[string]string
+	// BackupBridgeMappings holds the parsed backup OVS bridges, by physical
+	// network, mapped while the uplink of the OVS bridge is down
+	BackupBridgeMappings map[string]string
+}
+
+// HostSubnetLengthRule overrides the prefix lengths of the host subnets of
+// the default network allocated to the nodes matching its node selector. A
+// prefix length of 0 keeps the host subnet length of the cluster subnets.
+type HostSubnetLengthRule struct {
+	NodeSelector         labels.Selector
+	HostSubnetLength     int
+	IPv6HostSubnetLength int
 }
 
 // ClusterManagerConfig holds configuration for ovnkube-cluster-manager
@@ -494,8 +809,119 @@ type ClusterManagerConfig struct {
 	V4TransitSwitchSubnet string `gcfg:"v4-transit-switch-subnet"`
 	// V6TransitSwitchSubnet to be used in the cluster for interconnecting multiple zones
 	V6TransitSwitchSubnet string `gcfg:"v6-transit-switch-subnet"`
+	// RawZoneTransitSwitchSubnets holds the unparsed zone=range entries of the ranges of the
+	// transit switch subnets the transit switch addresses of the nodes of the interconnect zones
+	// are allocated from. Should only be used inside config module.
+	RawZoneTransitSwitchSubnets string `gcfg:"zone-transit-switch-subnets"`
+	// ZoneTransitSwitchSubnets holds the parsed transit switch ranges of each interconnect zone
+	ZoneTransitSwitchSubnets map[string][]*net.IPNet
+	// AllocationSnapshotPath is the path of the file, typically on a persistent volume, where
+	// the leader persists a snapshot of its allocations. Empty disables the snapshot.
+	AllocationSnapshotPath string `gcfg:"allocation-snapshot-path"`
+	// AllocationSnapshotStrict blocks the startup of the cluster manager when its allocations
+	// conflict with the persisted snapshot
+	AllocationSnapshotStrict bool `gcfg:"allocation-snapshot-strict"`
+	// MigrateRemovedClusterSubnets allows the cluster manager to start when nodes have host subnets
+	// from cluster subnets no longer configured, and to allocate them new host subnets
+	MigrateRemovedClusterSubnets bool `gcfg:"migrate-removed-cluster-subnets"`
+	// SubnetMigrationBatchSize is the maximum number of nodes of the default network migrated at
+	// a time off the removed cluster subnets. 0 migrates all the nodes at once.
+	SubnetMigrationBatchSize int `gcfg:"subnet-migration-batch-size"`
+	// SubnetMigrationBatchInterval is the minimum time, in seconds, between two batches of the
+	// migration off the removed cluster subnets
+	SubnetMigrationBatchInterval int `gcfg:"subnet-migration-batch-interval"`
+	// WarmHostSubnets is the number of host subnets of each IP family of the default network kept
+	// reserved for the next nodes. 0 disables the reservation.
+	WarmHostSubnets int `gcfg:"warm-host-subnets"`
+	// RawExcludeSubnets holds the unparsed subnets of the cluster subnets of the default network
+	// that are never allocated to the nodes. Should only be used inside config module.
+	RawExcludeSubnets string `gcfg:"exclude-subnets"`
+	// ExcludeSubnets holds the parsed subnets never allocated to the nodes
+	ExcludeSubnets []*net.IPNet
+	// RawZoneSubnets holds the unparsed zone=cluster subnet entries mapping cluster subnets of the
+	// default network to the topology zones of the nodes. Should only be used inside config module.
+	RawZoneSubnets string `gcfg:"zone-subnets"`
+	// ZoneSubnets holds the parsed cluster subnets of each topology zone
+	ZoneSubnets map[string][]*net.IPNet
+	// RawHostSubnetLengthRules holds the unparsed node selector:prefix length rules overriding
+	// the prefix length of the host subnets of the default network of the nodes matching them.
+	// Should only be used inside config module.
+	RawHostSubnetLengthRules string `gcfg:"host-subnet-length-rules"`
+	// HostSubnetLengthRules holds the parsed host subnet length rules, in order
+	HostSubnetLengthRules []HostSubnetLengthRule
+	// HostSubnetAllocation is how the host subnets of the default network are picked, either
+	// "sequential", "deterministic" or "randomized"
+	HostSubnetAllocation string `gcfg:"host-subnet-allocation"`
+	// IPv6HostSubnetSource is where the IPv6 host subnets of the default network come from,
+	// either "" for the cluster subnets or "dhcpv6-pd"
+	IPv6HostSubnetSource string `gcfg:"ipv6-host-subnet-source"`
+	// DHCPv6PDInterface is the interface the prefix delegation is requested on
+	DHCPv6PDInterface string `gcfg:"dhcpv6-pd-interface"`
+	// DHCPv6PDPrefixLength is the length of the prefixes requested, 0 to leave it to the
+	// DHCPv6 servers
+	DHCPv6PDPrefixLength int `gcfg:"dhcpv6-pd-prefix-length"`
+	// DeletedNodeSubnetGracePeriod is how long, in seconds, the host subnets of a deleted node
+	// are kept for a node of the same name to get them back. 0 releases them right away.
+	DeletedNodeSubnetGracePeriod int `gcfg:"deleted-node-subnet-grace-period"`
+	// IPFamilyConversionBatchSize is the number of nodes of the default network converted at a
+	// time from dual-stack to single-stack, or back, once the IP families of the cluster subnets
+	// changed. 0 converts all the nodes at once.
+	IPFamilyConversionBatchSize int `gcfg:"ip-family-conversion-batch-size"`
+	// IPFamilyConversionBatchInterval is the minimum time, in seconds, between two batches of
+	// the IP family conversion
+	IPFamilyConversionBatchInterval int `gcfg:"ip-family-conversion-batch-interval"`
+	// SubnetFragmentationReportInterval is how often, in seconds, the fragmentation of the
+	// cluster subnets of the default network is analyzed and reported in the metrics. 0
+	// disables the report.
+	SubnetFragmentationReportInterval int `gcfg:"subnet-fragmentation-report-interval"`
+	// SubnetCompactionBatchSize is the maximum number of nodes of the default network whose
+	// host subnets are compacted at a time, on request. 0 disables the compaction.
+	SubnetCompactionBatchSize int `gcfg:"subnet-compaction-batch-size"`
+	// SubnetCompactionBatchInterval is the minimum time, in seconds, between two batches of
+	// the host subnet compaction
+	SubnetCompactionBatchInterval int `gcfg:"subnet-compaction-batch-interval"`
+	// NodeAnnotationBatchInterval is the interval, in milliseconds, over which the updates of the
+	// annotations of a node by the controllers of the networks and zones are coalesced into a
+	// single server-side apply patch. 0 updates the node on each update.
+	NodeAnnotationBatchInterval int `gcfg:"node-annotation-batch-interval"`
+	// NetworkShards is the number of shards the secondary networks are spread over by the hash
+	// of their name. Each shard is managed by the ovnkube-cluster-manager instance winning its
+	// own leader election, so that the allocations of the networks are spread across the
+	// instances. 0 manages all the networks in the leader instance.
+	NetworkShards int `gcfg:"network-shards"`
+	// WebhookBindAddress is the address the admission webhook validating the manual edits of the
+	// host subnet and network ID annotations of the nodes is served on, with TLS, by every
+	// ovnkube-cluster-manager instance. Empty disables the webhook.
+	WebhookBindAddress string `gcfg:"webhook-bind-address"`
+	// WebhookCert is the TLS certificate of the webhook
+	WebhookCert string `gcfg:"webhook-cert"`
+	// WebhookPrivKey is the TLS private key of the webhook
+	WebhookPrivKey string `gcfg:"webhook-private-key"`
+	// WebhookTrustedUsers is a comma separated list of the users whose edits of the annotations
+	// are not validated, like the service accounts of ovnkube-cluster-manager
+	WebhookTrustedUsers string `gcfg:"webhook-trusted-users"`
 }
 
+const (
+	// HostSubnetAllocationSequential allocates the next free host subnet to
+	// the nodes in the order they join the cluster
+	HostSubnetAllocationSequential = "sequential"
+	// HostSubnetAllocationDeterministic allocates to each node the host subnet
+	// at the index given by the hash of its name, or by its host subnet index
+	// label or annotation, so that it gets the same host subnet across
+	// cluster reinstalls
+	HostSubnetAllocationDeterministic = "deterministic"
+	// HostSubnetAllocationRandomized allocates to each node the host subnet
+	// at a random index, or the next free one, so that clusters installed
+	// with the same cluster subnets are unlikely to pick overlapping host
+	// subnets
+	HostSubnetAllocationRandomized = "randomized"
+
+	// IPv6HostSubnetSourceDHCPv6PD obtains the IPv6 host subnets with DHCPv6
+	// prefix delegation, each node being its own identity association
+	IPv6HostSubnetSourceDHCPv6PD = "dhcpv6-pd"
+)
+
 // OvnDBScheme describes the OVN database connection transport method
 type OvnDBScheme string
 
@@ -697,6 +1123,12 @@ var CommonFlags = []cli.Flag{
 		Name:  "init-node",
 		Usage: "initialize node, requires the name that node is registered with in kubernetes cluster",
 	},
+	&cli.StringFlag{
+		Name: "init-single-node",
+		Usage: "initialize cluster-manager, ovnkube-controller and node in a single process for a single node " +
+			"cluster, with shared informers, no leader election and fewer OVN database monitors, requires the " +
+			"name that node is registered with in kubernetes cluster",
+	},
 	&cli.StringFlag{
 		Name:  "cleanup-node",
 		Usage: "cleanup node, requires the name that node is registered with in kubernetes cluster",
@@ -817,6 +1249,31 @@ var CommonFlags = []cli.Flag{
 			"it defaults to 24 if unspecified.",
 		Destination: &cliConfig.Default.RawClusterSubnets,
 	},
+	&cli.IntFlag{
+		Name: "max-host-subnets-per-node",
+		Usage: "The maximum number of host subnets of each IP family given to a node, additional " +
+			"host subnets being allocated once the pod IPs of the previous ones are exhausted. " +
+			"1 (default) disables the additional host subnets.",
+		Destination: &cliConfig.Default.MaxHostSubnetsPerNode,
+		Value:       Default.MaxHostSubnetsPerNode,
+	},
+	&cli.StringFlag{
+		Name: "topology",
+		Usage: "The topology of the default network: \"layer3\" (default) gives each node a host subnet of " +
+			"the cluster subnets and a logical switch, \"layer2\" puts the pods of all the nodes on a single " +
+			"logical switch holding the whole cluster subnets, so that the pods keep their IPs across nodes. " +
+			"\"layer2\" is not supported with the local gateway mode or with interconnect.",
+		Destination: &cliConfig.Default.Topology,
+		Value:       Default.Topology,
+	},
+	&cli.StringFlag{
+		Name: "health-check-subnets",
+		Usage: "A comma separated set of IP subnets and the associated hostsubnet prefix lengths, in the " +
+			"form of the cluster subnets, each node gets a subnet of for a dedicated health check port, " +
+			"keeping the node originated health check and monitoring traffic out of the management port " +
+			"(eg, \"100.66.0.0/16/28\"). Disabled if empty (default).",
+		Destination: &cliConfig.Default.RawHealthCheckSubnets,
+	},
 	&cli.BoolFlag{
 		Name:        "unprivileged-mode",
 		Usage:       "Run ovnkube-node container in unprivileged mode. Valid only with --init-node option.",
@@ -875,6 +1332,31 @@ var CommonFlags = []cli.Flag{
 		Destination: &cliConfig.Logging.ACLLoggingRateLimit,
 		Value:       20,
 	},
+	&cli.StringFlag{
+		Name: "egress-ip-nat-logfile",
+		Usage: "path of a file where ovnkube-node logs, as JSON records rotated like the log file, the connections " +
+			"SNATed to the egress IPs assigned to the node and when they started and ended. Disabled if empty.",
+		Destination: &cliConfig.Logging.EgressIPNATLogFile,
+	},
+	&cli.IntFlag{
+		Name:        "egress-ip-nat-log-sampling",
+		Usage:       "Log 1 out of every N connections SNATed to the egress IPs (default 1, every connection)",
+		Destination: &cliConfig.Logging.EgressIPNATLogSampling,
+		Value:       Logging.EgressIPNATLogSampling,
+	},
+	&cli.StringFlag{
+		Name: "drop-sampling-logfile",
+		Usage: "path of a file where ovnkube-node logs, as JSON records rotated like the log file, the samples of " +
+			"the packets dropped on the node when observability is enabled. Disabled if empty.",
+		Destination: &cliConfig.Logging.DropSamplingLogFile,
+	},
+	&cli.StringFlag{
+		Name: "metrics-audit-logfile",
+		Usage: "path of a file where the accesses to the metrics servers are logged, as JSON records rotated like the " +
+			"log file, when --metrics-enable-auth is set. Only the accesses other than the metrics scrapes, and the " +
+			"denied ones, are logged, to the log file, if empty.",
+		Destination: &cliConfig.Logging.MetricsAuditLogFile,
+	},
 	&cli.StringFlag{
 		Name:        "zone",
 		Usage:       "zone name to which ovnkube-node/ovnkube-controller belongs to",
@@ -984,6 +1466,13 @@ var OVNK8sFeatureFlags = []cli.Flag{
 		Usage:       "Configure EgressIP node reachability using gRPC on this TCP port.",
 		Destination: &cliConfig.OVNKubernetesFeature.EgressIPNodeHealthCheckPort,
 	},
+	&cli.IntFlag{
+		Name: "egressip-next-hop-probe-interval",
+		Usage: "Configure the egress nodes to probe their gateway next hops with ICMP echo requests at this " +
+			"interval in seconds, the egress IPs being moved away from a node whose next hops are unreachable " +
+			"(default: 0, disabled)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPNextHopProbeInterval,
+	},
 	&cli.BoolFlag{
 		Name:        "enable-multi-network",
 		Usage:       "Configure to use multiple NetworkAttachmentDefinition CRD feature with ovn-kubernetes.",
@@ -1020,6 +1509,196 @@ var OVNK8sFeatureFlags = []cli.Flag{
 		Destination: &cliConfig.OVNKubernetesFeature.EnableMultiExternalGateway,
 		Value:       OVNKubernetesFeature.EnableMultiExternalGateway,
 	},
+	&cli.BoolFlag{
+		Name: "enable-shared-netpol-port-groups",
+		Usage: "Configure to make the network policies of a namespace with equivalent pod selectors share the " +
+			"port group of the pods they select, instead of each policy having its own, and the network policies " +
+			"with equivalent namespace selector peers share the address set of the pods of the selected namespaces.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableSharedNetpolPortGroups,
+		Value:       OVNKubernetesFeature.EnableSharedNetpolPortGroups,
+	},
+	&cli.StringFlag{
+		Name: "node-network-state-backend",
+		Usage: "Where to store the per-node network state (host subnets, network IDs, gateway config and chassis ID): " +
+			"\"annotation\" (default) or \"crd\".",
+		Destination: &cliConfig.OVNKubernetesFeature.NodeNetworkStateBackend,
+		Value:       OVNKubernetesFeature.NodeNetworkStateBackend,
+	},
+	&cli.BoolFlag{
+		Name: "enable-node-finalizer",
+		Usage: "Configure to add a finalizer to nodes so that their deletion waits, for a limited time " +
+			"enforced by ovnkube-cluster-manager, for the cleanup of their OVN topology.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableNodeFinalizer,
+		Value:       OVNKubernetesFeature.EnableNodeFinalizer,
+	},
+	&cli.BoolFlag{
+		Name: "enable-standalone-hosts",
+		Usage: "Configure to allow hosts that are not Kubernetes nodes, running ovnkube-node in standalone-host " +
+			"mode, to attach to layer2 networks. Requires multi-network and interconnect to be enabled.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableStandaloneHosts,
+		Value:       OVNKubernetesFeature.EnableStandaloneHosts,
+	},
+	&cli.StringFlag{
+		Name: "ipv6-only-validation",
+		Usage: "How to validate that the enabled features support IPv6-only clusters: \"error\" (default) " +
+			"to refuse to start with unsupported features enabled, \"disable\" to disable them or \"off\".",
+		Destination: &cliConfig.OVNKubernetesFeature.IPv6OnlyValidation,
+		Value:       OVNKubernetesFeature.IPv6OnlyValidation,
+	},
+	&cli.IntFlag{
+		Name: "garp-count",
+		Usage: "Number of GARPs (IPv4) or unsolicited neighbor advertisements (IPv6) ovnkube-node sends when it " +
+			"assigns an egress IP or a LoadBalancer IP to a host interface, or a KubeVirt VM is migrated to the node, " +
+			"0 to send none (default: 1)",
+		Destination: &cliConfig.OVNKubernetesFeature.GARPCount,
+		Value:       OVNKubernetesFeature.GARPCount,
+	},
+	&cli.IntFlag{
+		Name:        "garp-interval",
+		Usage:       "Interval in milliseconds between the GARPs or unsolicited neighbor advertisements (default: 1000)",
+		Destination: &cliConfig.OVNKubernetesFeature.GARPInterval,
+		Value:       OVNKubernetesFeature.GARPInterval,
+	},
+	&cli.IntFlag{
+		Name: "garp-max-timeout",
+		Usage: "Maximum interval in seconds at which ovn-controller keeps sending GARPs for the NAT addresses of the " +
+			"gateway routers, like egress IPs. 0 (default) stops sending them after the initial backoff.",
+		Destination: &cliConfig.OVNKubernetesFeature.GARPMaxTimeout,
+		Value:       OVNKubernetesFeature.GARPMaxTimeout,
+	},
+	&cli.IntFlag{
+		Name: "ip-conflict-probe-timeout",
+		Usage: "Time in milliseconds ovnkube-node waits for a reply to the ARP probe (IPv4) or neighbor solicitation " +
+			"(IPv6) it sends before assigning an egress IP to a host interface or an IP to a localnet pod, failing " +
+			"the assignment when the IP is already in use. 0 (default) disables the probes.",
+		Destination: &cliConfig.OVNKubernetesFeature.IPConflictProbeTimeout,
+		Value:       OVNKubernetesFeature.IPConflictProbeTimeout,
+	},
+	&cli.IntFlag{
+		Name: "egressip-cloud-reconcile-interval",
+		Usage: "Interval in seconds at which the cloud assignments of the egress IPs are checked for drift and " +
+			"reconciled on cloud platforms, 0 to disable (default: 300)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPCloudReconcileInterval,
+		Value:       OVNKubernetesFeature.EgressIPCloudReconcileInterval,
+	},
+	&cli.StringFlag{
+		Name: "egressip-cloud-provider",
+		Usage: "Cloud provider whose API ovnkube-cluster-manager calls to attach the egress IPs to the network " +
+			"interface of their node: \"gcp\" (alias IP ranges) or \"azure\" (secondary IP configurations). Not " +
+			"supported with the platform types relying on the cloud-network-config-controller (default: none)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPCloudProvider,
+		Value:       OVNKubernetesFeature.EgressIPCloudProvider,
+	},
+	&cli.StringFlag{
+		Name: "egressip-assignment-policy",
+		Usage: "How the egress IPs of the EgressIPs without an assignment policy move between the egress nodes " +
+			"once assigned: \"sticky\", \"balanced\" or \"manual\" (default: sticky)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPAssignmentPolicy,
+		Value:       OVNKubernetesFeature.EgressIPAssignmentPolicy,
+	},
+	&cli.IntFlag{
+		Name: "egressip-rebalance-interval",
+		Usage: "Interval in seconds at which the egress IPs with the balanced assignment policy are moved " +
+			"between the egress nodes, 0 to disable (default: 60)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPRebalanceInterval,
+		Value:       OVNKubernetesFeature.EgressIPRebalanceInterval,
+	},
+	&cli.IntFlag{
+		Name:        "egressip-rebalance-max-moves",
+		Usage:       "Maximum number of egress IPs moved per rebalancing interval, 0 to disable (default: 1)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPRebalanceMaxMoves,
+		Value:       OVNKubernetesFeature.EgressIPRebalanceMaxMoves,
+	},
+	&cli.BoolFlag{
+		Name: "enable-egress-ip-standby",
+		Usage: "Designate a standby node for each egress IP hosted on the OVN managed network and pre-program " +
+			"its SNAT there, disabled, for a fast failover",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableEgressIPStandby,
+		Value:       OVNKubernetesFeature.EnableEgressIPStandby,
+	},
+	&cli.IntFlag{
+		Name: "stale-object-gc-interval",
+		Usage: "Interval in seconds at which the port groups and address sets whose owning objects no longer " +
+			"exist are garbage collected, 0 to disable (default: 600)",
+		Destination: &cliConfig.OVNKubernetesFeature.StaleObjectGCInterval,
+		Value:       OVNKubernetesFeature.StaleObjectGCInterval,
+	},
+	&cli.IntFlag{
+		Name: "pod-ip-mismatch-check-interval",
+		Usage: "Interval in seconds at which the IPs of the local pods in their status, pod annotation and " +
+			"logical switch port are checked to match, 0 to disable (default: 600)",
+		Destination: &cliConfig.OVNKubernetesFeature.PodIPMismatchCheckInterval,
+		Value:       OVNKubernetesFeature.PodIPMismatchCheckInterval,
+	},
+	&cli.StringFlag{
+		Name: "load-balancer-ip-pools",
+		Usage: "Comma separated list of CIDRs the built-in load balancer provider allocates the IPs of the " +
+			"LoadBalancer services from. The provider is disabled when empty (default).",
+		Destination: &cliConfig.OVNKubernetesFeature.RawLoadBalancerIPPools,
+		Value:       OVNKubernetesFeature.RawLoadBalancerIPPools,
+	},
+	&cli.StringFlag{
+		Name: "load-balancer-announce-mode",
+		Usage: "How the built-in load balancer provider announces the IPs of the LoadBalancer services: \"l2\" " +
+			"(default) to announce them with GARPs from a node or \"none\" to leave it to an external speaker.",
+		Destination: &cliConfig.OVNKubernetesFeature.LoadBalancerAnnounceMode,
+		Value:       OVNKubernetesFeature.LoadBalancerAnnounceMode,
+	},
+	&cli.BoolFlag{
+		Name: "enable-observability",
+		Usage: "Configure OVN to sample the packets dropped by the ACLs and by the default pipeline, and " +
+			"ovnkube-node to collect the samples. Requires an OVN version with sample collectors.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableObservability,
+		Value:       OVNKubernetesFeature.EnableObservability,
+	},
+	&cli.IntFlag{
+		Name:        "observability-drop-sampling-percentage",
+		Usage:       "Percentage of the dropped packets that are sampled, from 1 to 100 (default 100)",
+		Destination: &cliConfig.OVNKubernetesFeature.ObservabilityDropSamplingPercentage,
+		Value:       OVNKubernetesFeature.ObservabilityDropSamplingPercentage,
+	},
+	&cli.IntFlag{
+		Name:        "observability-collector-port",
+		Usage:       "Local UDP port of the IPFIX collector of ovnkube-node the samples are sent to (default 4740)",
+		Destination: &cliConfig.OVNKubernetesFeature.ObservabilityCollectorPort,
+		Value:       OVNKubernetesFeature.ObservabilityCollectorPort,
+	},
+	&cli.IntFlag{
+		Name: "nb-rollout-batch-size",
+		Usage: "Maximum number of northbound rows a cluster-wide change, like the tier or the logging of the " +
+			"ACLs, updates per transaction, 0 to update them all at once (default)",
+		Destination: &cliConfig.OVNKubernetesFeature.NBRolloutBatchSize,
+		Value:       OVNKubernetesFeature.NBRolloutBatchSize,
+	},
+	&cli.IntFlag{
+		Name:        "nb-rollout-batch-interval",
+		Usage:       "Interval in milliseconds between two batches of a cluster-wide change (default 0)",
+		Destination: &cliConfig.OVNKubernetesFeature.NBRolloutBatchInterval,
+		Value:       OVNKubernetesFeature.NBRolloutBatchInterval,
+	},
+	&cli.StringFlag{
+		Name: "nb-rollout-on-error",
+		Usage: "What a cluster-wide change does when a batch fails: \"pause\" (default) to stop until the " +
+			"change is retried or \"continue\" to apply the next batches and report the errors at the end",
+		Destination: &cliConfig.OVNKubernetesFeature.NBRolloutOnError,
+		Value:       OVNKubernetesFeature.NBRolloutOnError,
+	},
+	&cli.BoolFlag{
+		Name: "enable-host-port",
+		Usage: "Expose the host ports of the pods in OVN, on the gateway routers and the shared gateway " +
+			"bridges, instead of relying on the portmap CNI plugin. Host ports clashing with a NodePort are " +
+			"not exposed. Requires the shared gateway mode.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableHostPort,
+		Value:       OVNKubernetesFeature.EnableHostPort,
+	},
+	&cli.IntFlag{
+		Name: "static-route-audit-interval",
+		Usage: "Interval in seconds at which ovnkube-controller audits the static routes it created for the nodes, " +
+			"the pods and the external gateways against the cluster and removes the stale ones, 0 (default) to " +
+			"disable the removal",
+		Destination: &cliConfig.OVNKubernetesFeature.StaticRouteAuditInterval,
+		Value:       OVNKubernetesFeature.StaticRouteAuditInterval,
+	},
 }
 
 // K8sFlags capture Kubernetes-related options
@@ -1122,6 +1801,40 @@ var K8sFlags = []cli.Flag{
 		Destination: &cliConfig.Kubernetes.DNSServiceName,
 		Value:       Kubernetes.DNSServiceName,
 	},
+	&cli.IntFlag{
+		Name: "full-sync-controller-tokens",
+		Usage: "The number of ovnkube-controllers allowed to run their full sync at once, the others waiting " +
+			"for a token, so that they don't all load the OVN databases and the apiserver after an outage. " +
+			"0 means no limit.",
+		Destination: &cliConfig.Kubernetes.FullSyncControllerTokens,
+		Value:       Kubernetes.FullSyncControllerTokens,
+	},
+	&cli.IntFlag{
+		Name: "full-sync-node-tokens",
+		Usage: "The number of ovnkube-nodes allowed to run their full sync at once, the others waiting " +
+			"for a token. 0 means no limit.",
+		Destination: &cliConfig.Kubernetes.FullSyncNodeTokens,
+		Value:       Kubernetes.FullSyncNodeTokens,
+	},
+	&cli.IntFlag{
+		Name:        "full-sync-token-lease-duration",
+		Usage:       "The duration in seconds of the leases backing the full sync tokens",
+		Destination: &cliConfig.Kubernetes.FullSyncTokenLeaseDuration,
+		Value:       Kubernetes.FullSyncTokenLeaseDuration,
+	},
+	&cli.IntFlag{
+		Name:        "full-sync-token-max-wait",
+		Usage:       "The maximum time in seconds to wait for a full sync token before running the full sync anyway",
+		Destination: &cliConfig.Kubernetes.FullSyncTokenMaxWait,
+		Value:       Kubernetes.FullSyncTokenMaxWait,
+	},
+	&cli.StringFlag{
+		Name: "priority-namespaces",
+		Usage: "A comma separated list of cluster-critical namespaces, e.g. kube-system, whose pods, services " +
+			"and network policies are reconciled ahead of the objects of the other namespaces, so that an " +
+			"event storm in a tenant namespace doesn't delay their networking",
+		Destination: &cliConfig.Kubernetes.RawPriorityNamespaces,
+	},
 }
 
 // MetricsFlags capture metrics-related options
@@ -1157,6 +1870,18 @@ var MetricsFlags = []cli.Flag{
 		Usage:       "Certificate that the OVN node K8s metrics server uses to serve metrics over TLS.",
 		Destination: &cliConfig.Metrics.NodeServerCert,
 	},
+	&cli.BoolFlag{
+		Name: "metrics-enable-auth",
+		Usage: "If true, the requests to the metrics servers must authenticate with a bearer token, or a client certificate " +
+			"signed by --metrics-client-ca, and be allowed by the RBAC of the cluster to access the path of their endpoint " +
+			"(nonResourceURLs). The accesses are audited. Requires --node-server-cert and --node-server-privkey.",
+		Destination: &cliConfig.Metrics.EnableAuth,
+	},
+	&cli.StringFlag{
+		Name:        "metrics-client-ca",
+		Usage:       "CA bundle of the client certificates the requests to the metrics servers can authenticate with, the common name being the user and the organizations its groups.",
+		Destination: &cliConfig.Metrics.ClientCA,
+	},
 	&cli.BoolFlag{
 		Name:        "metrics-enable-config-duration",
 		Usage:       "Enables monitoring OVN-Kubernetes master and OVN configuration duration",
@@ -1167,6 +1892,11 @@ var MetricsFlags = []cli.Flag{
 		Usage:       "Enables metrics related to scaling",
 		Destination: &cliConfig.Metrics.EnableScaleMetrics,
 	},
+	&cli.BoolFlag{
+		Name:        "metrics-enable-egress-ip-usage",
+		Usage:       "Enables the per egress IP connection and byte count metrics of the node, requires the net.netfilter.nf_conntrack_acct sysctl to count bytes",
+		Destination: &cliConfig.Metrics.EnableEgressIPUsageMetrics,
+	},
 }
 
 // OvnNBFlags capture OVN northbound database options
@@ -1316,6 +2046,18 @@ var OVNGatewayFlags = []cli.Flag{
 		Destination: &cliConfig.Gateway.V6JoinSubnet,
 		Value:       Gateway.V6JoinSubnet,
 	},
+	&cli.IntFlag{
+		Name:        "gateway-v4-join-node-subnet-length",
+		Usage:       "The prefix length of the subnet of the v4 join subnet allocated to each node",
+		Destination: &cliConfig.Gateway.V4JoinNodeSubnetLength,
+		Value:       Gateway.V4JoinNodeSubnetLength,
+	},
+	&cli.IntFlag{
+		Name:        "gateway-v6-join-node-subnet-length",
+		Usage:       "The prefix length of the subnet of the v6 join subnet allocated to each node",
+		Destination: &cliConfig.Gateway.V6JoinNodeSubnetLength,
+		Value:       Gateway.V6JoinNodeSubnetLength,
+	},
 	&cli.StringFlag{
 		Name:        "gateway-v4-masquerade-subnet",
 		Usage:       "The v4 masquerade subnet used for assigning masquerade IPv4 addresses",
@@ -1328,6 +2070,25 @@ var OVNGatewayFlags = []cli.Flag{
 		Destination: &cliConfig.Gateway.V6MasqueradeSubnet,
 		Value:       Gateway.V6MasqueradeSubnet,
 	},
+	&cli.BoolFlag{
+		Name: "gateway-masquerade-subnet-auto-select",
+		Usage: "Let each node select other masquerade subnets, of the same size from the masquerade subnet " +
+			"pools, when the configured ones collide with its addresses or routes. The selected subnets are " +
+			"recorded in the k8s.ovn.org/node-masquerade-subnet node annotation.",
+		Destination: &cliConfig.Gateway.MasqueradeSubnetAutoSelect,
+	},
+	&cli.StringFlag{
+		Name:        "gateway-v4-masquerade-subnet-pool",
+		Usage:       "The v4 subnet the nodes select their v4 masquerade subnet from when the configured one collides",
+		Destination: &cliConfig.Gateway.V4MasqueradeSubnetPool,
+		Value:       Gateway.V4MasqueradeSubnetPool,
+	},
+	&cli.StringFlag{
+		Name:        "gateway-v6-masquerade-subnet-pool",
+		Usage:       "The v6 subnet the nodes select their v6 masquerade subnet from when the configured one collides",
+		Destination: &cliConfig.Gateway.V6MasqueradeSubnetPool,
+		Value:       Gateway.V6MasqueradeSubnetPool,
+	},
 	&cli.BoolFlag{
 		Name:        "disable-pkt-mtu-check",
 		Usage:       "Disable OpenFlow checks for if packet size is greater than pod MTU",
@@ -1352,6 +2113,19 @@ var OVNGatewayFlags = []cli.Flag{
 		Usage:       "Allow the external gateway bridge without an uplink port in local gateway mode",
 		Destination: &cliConfig.Gateway.AllowNoUplink,
 	},
+	&cli.StringFlag{
+		Name: "snat-conntrack-zone-allocation",
+		Usage: "How the conntrack zones the gateway routers SNAT in are assigned: \"host\" to share the conntrack " +
+			"zone 0 of the host, or \"dedicated\" to allocate a zone to each gateway router (default: host)",
+		Destination: &cliConfig.Gateway.SNATConntrackZoneAllocation,
+		Value:       Gateway.SNATConntrackZoneAllocation,
+	},
+	&cli.StringFlag{
+		Name:        "snat-conntrack-zone-range",
+		Usage:       "The min-max range the dedicated SNAT conntrack zones of the gateway routers are allocated from (default: 64100-64999)",
+		Destination: &cliConfig.Gateway.RawSNATConntrackZoneRange,
+		Value:       Gateway.RawSNATConntrackZoneRange,
+	},
 	// Deprecated CLI options
 	&cli.BoolFlag{
 		Name:        "init-gateways",
@@ -1458,6 +2232,48 @@ var OvnKubeNodeFlags = []cli.Flag{
 		Value:       OvnKubeNode.MgmtPortDPResourceName,
 		Destination: &cliConfig.OvnKubeNode.MgmtPortDPResourceName,
 	},
+	&cli.StringFlag{
+		Name: "ovnkube-node-datapath",
+		Usage: "OVS datapath of the node: system(default) for the kernel datapath, afxdp or dpdk for the " +
+			"userspace datapath with an AF_XDP or DPDK uplink",
+		Value:       OvnKubeNode.Datapath,
+		Destination: &cliConfig.OvnKubeNode.Datapath,
+	},
+	&cli.BoolFlag{
+		Name: "ovnkube-node-tunnel-tuning",
+		Usage: "Tune the NIC carrying the tunnel traffic according to the NUMA topology of the node: one RSS queue " +
+			"per CPU of the NUMA node of the NIC, its IRQs spread over these CPUs and the UDP GRO and GSO enabled",
+		Value:       OvnKubeNode.TunnelTuning,
+		Destination: &cliConfig.OvnKubeNode.TunnelTuning,
+	},
+	&cli.StringFlag{
+		Name: "ovnkube-node-local-state-dir",
+		Usage: "Directory, persisted across reboots, where the pod annotations are cached so that the pods are plumbed " +
+			"after a reboot while the apiserver is unreachable. Empty disables the cache",
+		Value:       OvnKubeNode.LocalStateDir,
+		Destination: &cliConfig.OvnKubeNode.LocalStateDir,
+	},
+	&cli.StringFlag{
+		Name:        "ovnkube-node-integration-bridge",
+		Usage:       "Name of the OVS bridge of the OVN logical ports, created by ovn-controller",
+		Value:       OvnKubeNode.IntegrationBridge,
+		Destination: &cliConfig.OvnKubeNode.IntegrationBridge,
+	},
+	&cli.StringFlag{
+		Name: "ovnkube-node-system-id",
+		Usage: "OVS system-id of the node, its chassis name, kept in the OVS external IDs. Empty keeps the one " +
+			"found on startup",
+		Value:       OvnKubeNode.SystemID,
+		Destination: &cliConfig.OvnKubeNode.SystemID,
+	},
+	&cli.StringFlag{
+		Name: "ovnkube-node-bridge-mappings",
+		Usage: "A comma separated set of physical network:OVS bridge[:backup OVS bridge] mappings kept in the OVS " +
+			"external IDs, in addition to the one of the gateway, e.g. physnet1:br-phys1,physnet2:br-phys2:br-phys3. " +
+			"A physical network is mapped to its backup bridge while the uplink of its bridge is down",
+		Value:       OvnKubeNode.RawBridgeMappings,
+		Destination: &cliConfig.OvnKubeNode.RawBridgeMappings,
+	},
 	&cli.BoolFlag{
 		Name:        "disable-ovn-iface-id-ver",
 		Usage:       "Deprecated; iface-id-ver is always enabled",
@@ -1479,6 +2295,199 @@ var ClusterManagerFlags = []cli.Flag{
 		Destination: &cliConfig.ClusterManager.V6TransitSwitchSubnet,
 		Value:       ClusterManager.V6TransitSwitchSubnet,
 	},
+	&cli.StringFlag{
+		Name: "cluster-manager-zone-transit-switch-subnets",
+		Usage: "A comma separated list of zone=range entries, e.g. \"zone-a=168.254.128.0/24,zone-b=168.254.129.0/24\", " +
+			"of ranges of the transit switch subnets the transit switch addresses of the nodes of the " +
+			"interconnect zones are allocated from, at most one range of each IP family per zone. The nodes of " +
+			"the other zones get the transit switch addresses derived from their node ID.",
+		Destination: &cliConfig.ClusterManager.RawZoneTransitSwitchSubnets,
+		Value:       ClusterManager.RawZoneTransitSwitchSubnets,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-allocation-snapshot-path",
+		Usage: "The path of the file, typically on a persistent volume, where the cluster manager leader " +
+			"persists a snapshot of its pod, egress IP and ID allocations to detect conflicting allocations " +
+			"after a control plane rebuild. Disabled if empty.",
+		Destination: &cliConfig.ClusterManager.AllocationSnapshotPath,
+		Value:       ClusterManager.AllocationSnapshotPath,
+	},
+	&cli.BoolFlag{
+		Name:        "cluster-manager-allocation-snapshot-strict",
+		Usage:       "Refuse to start the cluster manager when its allocations conflict with the persisted snapshot.",
+		Destination: &cliConfig.ClusterManager.AllocationSnapshotStrict,
+		Value:       ClusterManager.AllocationSnapshotStrict,
+	},
+	&cli.BoolFlag{
+		Name: "cluster-manager-migrate-removed-cluster-subnets",
+		Usage: "Allocate new host subnets to the nodes with a host subnet from a cluster subnet that was " +
+			"removed from the configuration, instead of refusing to start the cluster manager. " +
+			"The pods of these nodes need to be recreated.",
+		Destination: &cliConfig.ClusterManager.MigrateRemovedClusterSubnets,
+		Value:       ClusterManager.MigrateRemovedClusterSubnets,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-subnet-migration-batch-size",
+		Usage: "The maximum number of nodes migrated at a time off the removed cluster subnets, when their " +
+			"migration is enabled. The pods of these nodes need to be recreated. 0 (default) migrates all " +
+			"the nodes at once.",
+		Destination: &cliConfig.ClusterManager.SubnetMigrationBatchSize,
+		Value:       ClusterManager.SubnetMigrationBatchSize,
+	},
+	&cli.IntFlag{
+		Name:        "cluster-manager-subnet-migration-batch-interval",
+		Usage:       "The minimum time, in seconds, between two batches of the migration off the removed cluster subnets (default: 60).",
+		Destination: &cliConfig.ClusterManager.SubnetMigrationBatchInterval,
+		Value:       ClusterManager.SubnetMigrationBatchInterval,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-warm-host-subnets",
+		Usage: "Number of host subnets of each IP family of the default network kept reserved for the next " +
+			"nodes and listed in the warm-host-subnets ConfigMap. 0 (default) disables the reservation.",
+		Destination: &cliConfig.ClusterManager.WarmHostSubnets,
+		Value:       ClusterManager.WarmHostSubnets,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-exclude-subnets",
+		Usage: "A comma separated list of subnets of the cluster subnets that are never allocated to the " +
+			"nodes, e.g. ranges used by legacy infrastructure. The nodes holding host subnets overlapping " +
+			"them get new host subnets and their pods need to be recreated.",
+		Destination: &cliConfig.ClusterManager.RawExcludeSubnets,
+		Value:       ClusterManager.RawExcludeSubnets,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-zone-subnets",
+		Usage: "A comma separated list of zone=cluster subnet entries mapping cluster subnets of the default " +
+			"network to the topology.kubernetes.io/zone label of the nodes, e.g. " +
+			"\"zone-a=10.128.0.0/16,zone-b=10.129.0.0/16\". The nodes of a zone get their host subnets from " +
+			"the cluster subnets of their zone, the other nodes from the cluster subnets mapped to no zone.",
+		Destination: &cliConfig.ClusterManager.RawZoneSubnets,
+		Value:       ClusterManager.RawZoneSubnets,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-host-subnet-length-rules",
+		Usage: "A semicolon separated list of node selector:IPv4 prefix length[:IPv6 prefix length] rules " +
+			"overriding the prefix length of the host subnets of the default network of the nodes matching " +
+			"the node selector, e.g. \"node-pool=large:23;node-pool=edge:26:0\". The first matching rule " +
+			"applies, a prefix length of 0 keeps the host subnet length of the cluster subnets, and the " +
+			"k8s.ovn.org/host-subnet-prefix-length node label or annotation takes precedence.",
+		Destination: &cliConfig.ClusterManager.RawHostSubnetLengthRules,
+		Value:       ClusterManager.RawHostSubnetLengthRules,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-host-subnet-allocation",
+		Usage: "How the host subnets of the default network are allocated to the nodes: \"sequential\" " +
+			"(default) hands out the next free host subnet, \"deterministic\" picks the host subnet from " +
+			"the hash of the node name, or from its k8s.ovn.org/host-subnet-index label or annotation, so " +
+			"that a node gets the same host subnet across cluster reinstalls, \"randomized\" picks the host " +
+			"subnet at random, so that clusters with the same cluster subnets are unlikely to overlap.",
+		Destination: &cliConfig.ClusterManager.HostSubnetAllocation,
+		Value:       ClusterManager.HostSubnetAllocation,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-ipv6-host-subnet-source",
+		Usage: "Where the IPv6 host subnets of the default network come from: \"\" (default) carves them " +
+			"from the cluster subnets, \"dhcpv6-pd\" requests their delegation from the DHCPv6 servers. " +
+			"The delegated prefixes must belong to the IPv6 cluster subnets.",
+		Destination: &cliConfig.ClusterManager.IPv6HostSubnetSource,
+		Value:       ClusterManager.IPv6HostSubnetSource,
+	},
+	&cli.StringFlag{
+		Name:        "cluster-manager-dhcpv6-pd-interface",
+		Usage:       "The interface the DHCPv6 prefix delegation is requested on.",
+		Destination: &cliConfig.ClusterManager.DHCPv6PDInterface,
+		Value:       ClusterManager.DHCPv6PDInterface,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-dhcpv6-pd-prefix-length",
+		Usage: "The length of the prefixes requested with DHCPv6 prefix delegation. 0 (default) leaves " +
+			"it to the DHCPv6 servers.",
+		Destination: &cliConfig.ClusterManager.DHCPv6PDPrefixLength,
+		Value:       ClusterManager.DHCPv6PDPrefixLength,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-deleted-node-subnet-grace-period",
+		Usage: "How long, in seconds, the host subnets of a deleted node are kept for a node of the same " +
+			"name, like a node re-provisioned by the cloud provider, to get them back. 0 (default) " +
+			"releases them right away.",
+		Destination: &cliConfig.ClusterManager.DeletedNodeSubnetGracePeriod,
+		Value:       ClusterManager.DeletedNodeSubnetGracePeriod,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-ip-family-conversion-batch-size",
+		Usage: "The number of nodes converted at a time from dual-stack to single-stack, or back, once " +
+			"the IP families of the cluster subnets changed, with the progress reported in the " +
+			"IPFamilyConversion \"default\". 0 (default) converts all the nodes at once.",
+		Destination: &cliConfig.ClusterManager.IPFamilyConversionBatchSize,
+		Value:       ClusterManager.IPFamilyConversionBatchSize,
+	},
+	&cli.IntFlag{
+		Name:        "cluster-manager-ip-family-conversion-batch-interval",
+		Usage:       "The minimum time, in seconds, between two batches of the IP family conversion (default: 60).",
+		Destination: &cliConfig.ClusterManager.IPFamilyConversionBatchInterval,
+		Value:       ClusterManager.IPFamilyConversionBatchInterval,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-subnet-fragmentation-report-interval",
+		Usage: "How often, in seconds, the fragmentation of the cluster subnets of the default network " +
+			"is analyzed and reported in the metrics (default: 300). 0 disables the report.",
+		Destination: &cliConfig.ClusterManager.SubnetFragmentationReportInterval,
+		Value:       ClusterManager.SubnetFragmentationReportInterval,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-subnet-compaction-batch-size",
+		Usage: "The maximum number of cordoned nodes, annotated with k8s.ovn.org/host-subnet-compaction=true, " +
+			"whose host subnets are moved at a time to the lowest free host subnets to compact the cluster " +
+			"subnets. The pods of these nodes need to be recreated. 0 (default) disables the compaction.",
+		Destination: &cliConfig.ClusterManager.SubnetCompactionBatchSize,
+		Value:       ClusterManager.SubnetCompactionBatchSize,
+	},
+	&cli.IntFlag{
+		Name:        "cluster-manager-subnet-compaction-batch-interval",
+		Usage:       "The minimum time, in seconds, between two batches of the host subnet compaction (default: 300).",
+		Destination: &cliConfig.ClusterManager.SubnetCompactionBatchInterval,
+		Value:       ClusterManager.SubnetCompactionBatchInterval,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-node-annotation-batch-interval",
+		Usage: "The interval, in milliseconds, over which the updates of the annotations of a node, like its host " +
+			"subnets and network IDs, are coalesced into a single server-side apply patch. 0 updates the node " +
+			"on each update (default: 100).",
+		Destination: &cliConfig.ClusterManager.NodeAnnotationBatchInterval,
+		Value:       ClusterManager.NodeAnnotationBatchInterval,
+	},
+	&cli.IntFlag{
+		Name: "cluster-manager-network-shards",
+		Usage: "The number of shards the secondary networks are spread over by the hash of their name, each " +
+			"managed by the ovnkube-cluster-manager instance winning its own leader election. 0 manages all " +
+			"the networks in the leader instance (default: 0).",
+		Destination: &cliConfig.ClusterManager.NetworkShards,
+		Value:       ClusterManager.NetworkShards,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-webhook-bind-address",
+		Usage: "The address the admission webhook rejecting the manual edits of the host subnet and network ID " +
+			"annotations of the nodes that would create duplicates or out of pool subnets is served on, with " +
+			"TLS. Empty disables the webhook.",
+		Destination: &cliConfig.ClusterManager.WebhookBindAddress,
+	},
+	&cli.StringFlag{
+		Name:        "cluster-manager-webhook-cert",
+		Usage:       "The TLS certificate of the admission webhook.",
+		Destination: &cliConfig.ClusterManager.WebhookCert,
+	},
+	&cli.StringFlag{
+		Name:        "cluster-manager-webhook-private-key",
+		Usage:       "The TLS private key of the admission webhook.",
+		Destination: &cliConfig.ClusterManager.WebhookPrivKey,
+	},
+	&cli.StringFlag{
+		Name: "cluster-manager-webhook-trusted-users",
+		Usage: "A comma separated list of the users whose edits of the annotations are not validated by the " +
+			"admission webhook, like the service accounts of ovnkube-cluster-manager.",
+		Destination: &cliConfig.ClusterManager.WebhookTrustedUsers,
+		Value:       ClusterManager.WebhookTrustedUsers,
+	},
 }
 
 // Flags are general command-line flags. Apps should add these flags to their
@@ -1666,6 +2675,17 @@ func buildKubernetesConfig(exec kexec.Interface, cli, file *config, saPath strin
 		return fmt.Errorf("kubernetes service-cidrs is required")
 	}
 
+	if Kubernetes.FullSyncControllerTokens < 0 || Kubernetes.FullSyncNodeTokens < 0 {
+		return fmt.Errorf("invalid full sync tokens %d/%d: must not be negative",
+			Kubernetes.FullSyncControllerTokens, Kubernetes.FullSyncNodeTokens)
+	}
+	if Kubernetes.FullSyncTokenLeaseDuration <= 0 {
+		return fmt.Errorf("invalid full sync token lease duration %d: must be positive", Kubernetes.FullSyncTokenLeaseDuration)
+	}
+	if Kubernetes.FullSyncTokenMaxWait < 0 {
+		return fmt.Errorf("invalid full sync token max wait %d: must not be negative", Kubernetes.FullSyncTokenMaxWait)
+	}
+
 	return nil
 }
 
@@ -1695,6 +2715,17 @@ func completeKubernetesConfig(allSubnets *configSubnets) error {
 		}
 	}
 
+	Kubernetes.PriorityNamespaces = sets.New[string]()
+	if Kubernetes.RawPriorityNamespaces != "" {
+		for _, namespace := range strings.Split(Kubernetes.RawPriorityNamespaces, ",") {
+			namespace = strings.TrimSpace(namespace)
+			if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+				return fmt.Errorf("invalid priority namespace %q: %s", namespace, strings.Join(errs, ", "))
+			}
+			Kubernetes.PriorityNamespaces.Insert(namespace)
+		}
+	}
+
 	return nil
 }
 
@@ -1718,6 +2749,14 @@ func buildMetricsConfig(cli, file *config) error {
 		return err
 	}
 
+	if Metrics.EnableAuth && (Metrics.NodeServerCert == "" || Metrics.NodeServerPrivKey == "") {
+		return fmt.Errorf("metrics-enable-auth requires node-server-cert and node-server-privkey so that the " +
+			"credentials are not sent in clear text")
+	}
+	if Metrics.ClientCA != "" && !Metrics.EnableAuth {
+		return fmt.Errorf("metrics-client-ca requires metrics-enable-auth")
+	}
+
 	return nil
 }
 
@@ -1770,9 +2809,52 @@ func buildGatewayConfig(ctx *cli.Context, cli, file *config) error {
 		return fmt.Errorf("gateway VLAN ID option: %d is supported only in shared gateway mode", Gateway.VLANID)
 	}
 
+	if OVNKubernetesFeature.EnableHostPort && Gateway.Mode != GatewayModeShared {
+		return fmt.Errorf("host ports are supported only in shared gateway mode")
+	}
+
+	switch Gateway.SNATConntrackZoneAllocation {
+	case "", SNATConntrackZoneAllocationHost:
+	case SNATConntrackZoneAllocationDedicated:
+		var err error
+		Gateway.SNATConntrackZoneMin, Gateway.SNATConntrackZoneMax, err = parseConntrackZoneRange(Gateway.RawSNATConntrackZoneRange)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid SNAT conntrack zone allocation %q, must be %q or %q", Gateway.SNATConntrackZoneAllocation,
+			SNATConntrackZoneAllocationHost, SNATConntrackZoneAllocationDedicated)
+	}
+
 	return nil
 }
 
+// parseConntrackZoneRange parses a "min-max" range of conntrack zones, which
+// must not hold zone 0 of the host nor the zones of the gateway bridge flows
+func parseConntrackZoneRange(zoneRange string) (int, int, error) {
+	bounds := strings.Split(zoneRange, "-")
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid conntrack zone range %q, must be min-max", zoneRange)
+	}
+	min, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid conntrack zone range %q: %v", zoneRange, err)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid conntrack zone range %q: %v", zoneRange, err)
+	}
+	if min < 1 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("invalid conntrack zone range %q, must be within 1-65535", zoneRange)
+	}
+	// the gateway bridge flows use the conntrack zone and the next 3 zones
+	if min <= Default.ConntrackZone+3 && max >= Default.ConntrackZone {
+		return 0, 0, fmt.Errorf("conntrack zone range %q overlaps the conntrack zones %d-%d of the gateway bridge",
+			zoneRange, Default.ConntrackZone, Default.ConntrackZone+3)
+	}
+	return min, max, nil
+}
+
 func completeGatewayConfig(allSubnets *configSubnets, masqueradeIPs *MasqueradeIPsConfig) error {
 	// Validate v4 and v6 join subnets
 	v4IP, v4JoinCIDR, err := net.ParseCIDR(Gateway.V4JoinSubnet)
@@ -1784,6 +2866,18 @@ func completeGatewayConfig(allSubnets *configSubnets, masqueradeIPs *MasqueradeI
 	if err != nil || !utilnet.IsIPv6(v6IP) {
 		return fmt.Errorf("invalid gateway v6 join subnet specified, subnet: %s: error: %v", Gateway.V6JoinSubnet, err)
 	}
+	for _, join := range []struct {
+		cidr      *net.IPNet
+		subnetLen int
+	}{
+		{v4JoinCIDR, Gateway.V4JoinNodeSubnetLength},
+		{v6JoinCIDR, Gateway.V6JoinNodeSubnetLength},
+	} {
+		if prefixLen, addrLen := join.cidr.Mask.Size(); join.subnetLen < prefixLen || join.subnetLen > addrLen {
+			return fmt.Errorf("invalid join node subnet length %d of join subnet %s: must be between %d and %d",
+				join.subnetLen, join.cidr, prefixLen, addrLen)
+		}
+	}
 	allSubnets.append(configSubnetJoin, v4JoinCIDR)
 	allSubnets.append(configSubnetJoin, v6JoinCIDR)
 
@@ -1804,9 +2898,66 @@ func completeGatewayConfig(allSubnets *configSubnets, masqueradeIPs *MasqueradeI
 		return fmt.Errorf("unable to allocate V6MasqueradeIPs: %s", err)
 	}
 
-	allSubnets.append(configSubnetMasquerade, v4MasqueradeCIDR)
-	allSubnets.append(configSubnetMasquerade, v6MasqueradeCIDR)
+	if !Gateway.MasqueradeSubnetAutoSelect {
+		allSubnets.append(configSubnetMasquerade, v4MasqueradeCIDR)
+		allSubnets.append(configSubnetMasquerade, v6MasqueradeCIDR)
+	} else {
+		// the subnets the nodes may select must be checked instead
+		for _, pool := range []struct {
+			subnet     string
+			masquerade *net.IPNet
+		}{
+			{Gateway.V4MasqueradeSubnetPool, v4MasqueradeCIDR},
+			{Gateway.V6MasqueradeSubnetPool, v6MasqueradeCIDR},
+		} {
+			_, poolCIDR, err := net.ParseCIDR(pool.subnet)
+			if err != nil {
+				return fmt.Errorf("invalid gateway masquerade subnet pool %s: %v", pool.subnet, err)
+			}
+			poolLength, _ := poolCIDR.Mask.Size()
+			masqueradeLength, _ := pool.masquerade.Mask.Size()
+			if !poolCIDR.Contains(pool.masquerade.IP) || poolLength > masqueradeLength {
+				return fmt.Errorf("gateway masquerade subnet pool %s does not hold masquerade subnet %s",
+					poolCIDR, pool.masquerade)
+			}
+			allSubnets.append(configSubnetMasquerade, poolCIDR)
+		}
+	}
+
+	return nil
+}
+
+// MasqueradeIPsForSubnets returns the masquerade IPs allocated from the given
+// v4 and v6 masquerade subnets
+func MasqueradeIPsForSubnets(v4MasqueradeSubnet, v6MasqueradeSubnet string) (*MasqueradeIPsConfig, error) {
+	masqueradeIPs := &MasqueradeIPsConfig{}
+	v4MasqueradeIP, v4MasqueradeCIDR, err := net.ParseCIDR(v4MasqueradeSubnet)
+	if err != nil || utilnet.IsIPv6(v4MasqueradeCIDR.IP) {
+		return nil, fmt.Errorf("invalid v4 masquerade subnet %s: %v", v4MasqueradeSubnet, err)
+	}
+	if err = allocateV4MasqueradeIPs(v4MasqueradeIP, masqueradeIPs); err != nil {
+		return nil, fmt.Errorf("unable to allocate V4MasqueradeIPs: %s", err)
+	}
+	v6MasqueradeIP, v6MasqueradeCIDR, err := net.ParseCIDR(v6MasqueradeSubnet)
+	if err != nil || !utilnet.IsIPv6(v6MasqueradeCIDR.IP) {
+		return nil, fmt.Errorf("invalid v6 masquerade subnet %s: %v", v6MasqueradeSubnet, err)
+	}
+	if err = allocateV6MasqueradeIPs(v6MasqueradeIP, masqueradeIPs); err != nil {
+		return nil, fmt.Errorf("unable to allocate V6MasqueradeIPs: %s", err)
+	}
+	return masqueradeIPs, nil
+}
 
+// SetMasqueradeSubnets makes the given masquerade subnets, selected by the
+// node, the ones of the process along with their masquerade IPs
+func SetMasqueradeSubnets(v4MasqueradeSubnet, v6MasqueradeSubnet string) error {
+	masqueradeIPs, err := MasqueradeIPsForSubnets(v4MasqueradeSubnet, v6MasqueradeSubnet)
+	if err != nil {
+		return err
+	}
+	Gateway.V4MasqueradeSubnet = v4MasqueradeSubnet
+	Gateway.V6MasqueradeSubnet = v6MasqueradeSubnet
+	Gateway.MasqueradeIPs = *masqueradeIPs
 	return nil
 }
 
@@ -1819,6 +2970,87 @@ func buildOVNKubernetesFeatureConfig(ctx *cli.Context, cli, file *config) error
 	if err := overrideFields(&OVNKubernetesFeature, &cli.OVNKubernetesFeature, &savedOVNKubernetesFeature); err != nil {
 		return err
 	}
+	switch OVNKubernetesFeature.NodeNetworkStateBackend {
+	case NodeNetworkStateBackendAnnotation, NodeNetworkStateBackendCRD:
+	default:
+		return fmt.Errorf("invalid node network state backend %q, must be %q or %q",
+			OVNKubernetesFeature.NodeNetworkStateBackend, NodeNetworkStateBackendAnnotation, NodeNetworkStateBackendCRD)
+	}
+	switch OVNKubernetesFeature.IPv6OnlyValidation {
+	case "", IPv6OnlyValidationOff, IPv6OnlyValidationError, IPv6OnlyValidationDisable:
+	default:
+		return fmt.Errorf("invalid IPv6-only validation %q, must be %q, %q or %q", OVNKubernetesFeature.IPv6OnlyValidation,
+			IPv6OnlyValidationOff, IPv6OnlyValidationError, IPv6OnlyValidationDisable)
+	}
+	if OVNKubernetesFeature.GARPCount < 0 || OVNKubernetesFeature.GARPInterval < 0 || OVNKubernetesFeature.GARPMaxTimeout < 0 {
+		return fmt.Errorf("invalid GARP config: count %d, interval %d and max timeout %d must not be negative",
+			OVNKubernetesFeature.GARPCount, OVNKubernetesFeature.GARPInterval, OVNKubernetesFeature.GARPMaxTimeout)
+	}
+	if OVNKubernetesFeature.IPConflictProbeTimeout < 0 {
+		return fmt.Errorf("invalid IP conflict probe timeout %d, must not be negative",
+			OVNKubernetesFeature.IPConflictProbeTimeout)
+	}
+	if OVNKubernetesFeature.EgressIPCloudReconcileInterval < 0 {
+		return fmt.Errorf("invalid egress IP cloud reconcile interval %d, must not be negative",
+			OVNKubernetesFeature.EgressIPCloudReconcileInterval)
+	}
+	switch OVNKubernetesFeature.EgressIPCloudProvider {
+	case "", EgressIPCloudProviderGCP, EgressIPCloudProviderAzure:
+	default:
+		return fmt.Errorf("invalid egress IP cloud provider %q, must be %q or %q",
+			OVNKubernetesFeature.EgressIPCloudProvider, EgressIPCloudProviderGCP, EgressIPCloudProviderAzure)
+	}
+	switch OVNKubernetesFeature.EgressIPAssignmentPolicy {
+	case "", EgressIPAssignmentPolicySticky, EgressIPAssignmentPolicyBalanced, EgressIPAssignmentPolicyManual:
+	default:
+		return fmt.Errorf("invalid egress IP assignment policy %q, must be %q, %q or %q",
+			OVNKubernetesFeature.EgressIPAssignmentPolicy, EgressIPAssignmentPolicySticky,
+			EgressIPAssignmentPolicyBalanced, EgressIPAssignmentPolicyManual)
+	}
+	if OVNKubernetesFeature.EgressIPRebalanceInterval < 0 || OVNKubernetesFeature.EgressIPRebalanceMaxMoves < 0 {
+		return fmt.Errorf("invalid egress IP rebalancing config: interval %d and max moves %d must not be negative",
+			OVNKubernetesFeature.EgressIPRebalanceInterval, OVNKubernetesFeature.EgressIPRebalanceMaxMoves)
+	}
+	if OVNKubernetesFeature.StaleObjectGCInterval < 0 {
+		return fmt.Errorf("invalid stale object GC interval %d, must not be negative",
+			OVNKubernetesFeature.StaleObjectGCInterval)
+	}
+	if OVNKubernetesFeature.PodIPMismatchCheckInterval < 0 {
+		return fmt.Errorf("invalid pod IP mismatch check interval %d, must not be negative",
+			OVNKubernetesFeature.PodIPMismatchCheckInterval)
+	}
+	switch OVNKubernetesFeature.LoadBalancerAnnounceMode {
+	case "", LoadBalancerAnnounceModeL2, LoadBalancerAnnounceModeNone:
+	default:
+		return fmt.Errorf("invalid load balancer announce mode %q, must be %q or %q",
+			OVNKubernetesFeature.LoadBalancerAnnounceMode, LoadBalancerAnnounceModeL2, LoadBalancerAnnounceModeNone)
+	}
+	if OVNKubernetesFeature.EnableStandaloneHosts && !(OVNKubernetesFeature.EnableMultiNetwork && OVNKubernetesFeature.EnableInterconnect) {
+		return fmt.Errorf("standalone hosts require multi-network and interconnect to be enabled")
+	}
+	if OVNKubernetesFeature.StaticRouteAuditInterval < 0 {
+		return fmt.Errorf("invalid static route audit interval %d, must not be negative",
+			OVNKubernetesFeature.StaticRouteAuditInterval)
+	}
+	if OVNKubernetesFeature.NBRolloutBatchSize < 0 || OVNKubernetesFeature.NBRolloutBatchInterval < 0 {
+		return fmt.Errorf("invalid northbound rollout config: batch size %d and batch interval %d must not be negative",
+			OVNKubernetesFeature.NBRolloutBatchSize, OVNKubernetesFeature.NBRolloutBatchInterval)
+	}
+	switch OVNKubernetesFeature.NBRolloutOnError {
+	case "", NBRolloutOnErrorPause, NBRolloutOnErrorContinue:
+	default:
+		return fmt.Errorf("invalid northbound rollout on error %q, must be %q or %q",
+			OVNKubernetesFeature.NBRolloutOnError, NBRolloutOnErrorPause, NBRolloutOnErrorContinue)
+	}
+	if OVNKubernetesFeature.EnableObservability {
+		if OVNKubernetesFeature.ObservabilityDropSamplingPercentage < 1 || OVNKubernetesFeature.ObservabilityDropSamplingPercentage > 100 {
+			return fmt.Errorf("invalid observability drop sampling percentage %d, must be between 1 and 100",
+				OVNKubernetesFeature.ObservabilityDropSamplingPercentage)
+		}
+		if OVNKubernetesFeature.ObservabilityCollectorPort < 1 || OVNKubernetesFeature.ObservabilityCollectorPort > 65535 {
+			return fmt.Errorf("invalid observability collector port %d", OVNKubernetesFeature.ObservabilityCollectorPort)
+		}
+	}
 	return nil
 }
 
@@ -1952,6 +3184,24 @@ func completeHybridOverlayConfig(allSubnets *configSubnets) error {
 	return nil
 }
 
+// completeLoadBalancerConfig parses the IP pools of the built-in load balancer
+// provider, which must not overlap the other subnets
+func completeLoadBalancerConfig(allSubnets *configSubnets) error {
+	OVNKubernetesFeature.LoadBalancerIPPools = nil
+	if OVNKubernetesFeature.RawLoadBalancerIPPools == "" {
+		return nil
+	}
+	for _, cidrString := range strings.Split(OVNKubernetesFeature.RawLoadBalancerIPPools, ",") {
+		_, pool, err := net.ParseCIDR(strings.TrimSpace(cidrString))
+		if err != nil {
+			return fmt.Errorf("load balancer IP pool %q invalid: %v", cidrString, err)
+		}
+		OVNKubernetesFeature.LoadBalancerIPPools = append(OVNKubernetesFeature.LoadBalancerIPPools, pool)
+		allSubnets.append(configSubnetLoadBalancer, pool)
+	}
+	return nil
+}
+
 func buildClusterManagerConfig(ctx *cli.Context, cli, file *config) error {
 	// Copy config file values over default values
 	if err := overrideFields(&ClusterManager, &file.ClusterManager, &savedClusterManager); err != nil {
@@ -1963,23 +3213,291 @@ func buildClusterManagerConfig(ctx *cli.Context, cli, file *config) error {
 		return err
 	}
 
+	if ClusterManager.WarmHostSubnets < 0 {
+		return fmt.Errorf("invalid number of warm host subnets %d, must not be negative", ClusterManager.WarmHostSubnets)
+	}
+	if ClusterManager.DeletedNodeSubnetGracePeriod < 0 {
+		return fmt.Errorf("invalid deleted node subnet grace period %d, must not be negative",
+			ClusterManager.DeletedNodeSubnetGracePeriod)
+	}
+	if ClusterManager.IPFamilyConversionBatchSize < 0 || ClusterManager.IPFamilyConversionBatchInterval < 0 {
+		return fmt.Errorf("invalid IP family conversion batch size %d or interval %d, must not be negative",
+			ClusterManager.IPFamilyConversionBatchSize, ClusterManager.IPFamilyConversionBatchInterval)
+	}
+	if ClusterManager.SubnetFragmentationReportInterval < 0 {
+		return fmt.Errorf("invalid subnet fragmentation report interval %d, must not be negative",
+			ClusterManager.SubnetFragmentationReportInterval)
+	}
+	if ClusterManager.SubnetCompactionBatchSize < 0 || ClusterManager.SubnetCompactionBatchInterval < 0 {
+		return fmt.Errorf("invalid subnet compaction batch size %d or interval %d, must not be negative",
+			ClusterManager.SubnetCompactionBatchSize, ClusterManager.SubnetCompactionBatchInterval)
+	}
+	if ClusterManager.SubnetMigrationBatchSize < 0 || ClusterManager.SubnetMigrationBatchInterval < 0 {
+		return fmt.Errorf("invalid subnet migration batch size %d or interval %d, must not be negative",
+			ClusterManager.SubnetMigrationBatchSize, ClusterManager.SubnetMigrationBatchInterval)
+	}
+	if ClusterManager.NodeAnnotationBatchInterval < 0 {
+		return fmt.Errorf("invalid node annotation batch interval %d, must not be negative",
+			ClusterManager.NodeAnnotationBatchInterval)
+	}
+	if ClusterManager.NetworkShards < 0 {
+		return fmt.Errorf("invalid network shards %d, must not be negative", ClusterManager.NetworkShards)
+	}
+	if ClusterManager.WebhookBindAddress != "" && (ClusterManager.WebhookCert == "" || ClusterManager.WebhookPrivKey == "") {
+		return fmt.Errorf("the webhook certificate and private key are required with the webhook bind address")
+	}
+	switch ClusterManager.HostSubnetAllocation {
+	case HostSubnetAllocationSequential:
+	case HostSubnetAllocationDeterministic:
+		// the reserved host subnets would be handed over instead of the
+		// deterministic ones
+		if ClusterManager.WarmHostSubnets > 0 {
+			return fmt.Errorf("warm host subnets are not supported with the %q host subnet allocation",
+				HostSubnetAllocationDeterministic)
+		}
+		// the compacted nodes would lose their deterministic host subnets
+		if ClusterManager.SubnetCompactionBatchSize > 0 {
+			return fmt.Errorf("the subnet compaction is not supported with the %q host subnet allocation",
+				HostSubnetAllocationDeterministic)
+		}
+		// the index of a node spans the cluster subnets of all the zones
+		if ClusterManager.RawZoneSubnets != "" {
+			return fmt.Errorf("zone subnets are not supported with the %q host subnet allocation",
+				HostSubnetAllocationDeterministic)
+		}
+	case HostSubnetAllocationRandomized:
+		// the reserved host subnets would be picked in order
+		if ClusterManager.WarmHostSubnets > 0 {
+			return fmt.Errorf("warm host subnets are not supported with the %q host subnet allocation",
+				HostSubnetAllocationRandomized)
+		}
+		// the compacted nodes would get the lowest free host subnets
+		if ClusterManager.SubnetCompactionBatchSize > 0 {
+			return fmt.Errorf("the subnet compaction is not supported with the %q host subnet allocation",
+				HostSubnetAllocationRandomized)
+		}
+		// the random index of a node spans the cluster subnets of all the
+		// zones
+		if ClusterManager.RawZoneSubnets != "" {
+			return fmt.Errorf("zone subnets are not supported with the %q host subnet allocation",
+				HostSubnetAllocationRandomized)
+		}
+	default:
+		return fmt.Errorf("invalid host subnet allocation %q, must be %q, %q or %q", ClusterManager.HostSubnetAllocation,
+			HostSubnetAllocationSequential, HostSubnetAllocationDeterministic, HostSubnetAllocationRandomized)
+	}
+	// the reserved host subnets would be handed over to the nodes of any zone
+	if ClusterManager.RawZoneSubnets != "" && ClusterManager.WarmHostSubnets > 0 {
+		return fmt.Errorf("warm host subnets are not supported with zone subnets")
+	}
+	switch ClusterManager.IPv6HostSubnetSource {
+	case "":
+	case IPv6HostSubnetSourceDHCPv6PD:
+		if ClusterManager.DHCPv6PDInterface == "" {
+			return fmt.Errorf("the DHCPv6 prefix delegation interface is required with the %q IPv6 host subnet source",
+				IPv6HostSubnetSourceDHCPv6PD)
+		}
+		if ClusterManager.DHCPv6PDPrefixLength < 0 || ClusterManager.DHCPv6PDPrefixLength > 128 {
+			return fmt.Errorf("invalid DHCPv6 prefix delegation prefix length %d", ClusterManager.DHCPv6PDPrefixLength)
+		}
+		// the host subnets are picked by the DHCPv6 servers
+		if ClusterManager.WarmHostSubnets > 0 {
+			return fmt.Errorf("warm host subnets are not supported with the %q IPv6 host subnet source",
+				IPv6HostSubnetSourceDHCPv6PD)
+		}
+		if ClusterManager.HostSubnetAllocation != HostSubnetAllocationSequential {
+			return fmt.Errorf("the %q host subnet allocation is not supported with the %q IPv6 host subnet source",
+				ClusterManager.HostSubnetAllocation, IPv6HostSubnetSourceDHCPv6PD)
+		}
+		if ClusterManager.RawZoneSubnets != "" {
+			return fmt.Errorf("zone subnets are not supported with the %q IPv6 host subnet source",
+				IPv6HostSubnetSourceDHCPv6PD)
+		}
+	default:
+		return fmt.Errorf("invalid IPv6 host subnet source %q, must be empty or %q", ClusterManager.IPv6HostSubnetSource,
+			IPv6HostSubnetSourceDHCPv6PD)
+	}
+
 	return nil
 }
 
 // completeClusterManagerConfig completes the ClusterManager config by parsing raw values
 // into their final form.
-func completeClusterManagerConfig() error {
+func completeClusterManagerConfig(allSubnets *configSubnets) error {
 	// Validate v4 and v6 transit switch subnets
-	v4IP, _, err := net.ParseCIDR(ClusterManager.V4TransitSwitchSubnet)
+	v4IP, v4TransitSwitchCIDR, err := net.ParseCIDR(ClusterManager.V4TransitSwitchSubnet)
 	if err != nil || utilnet.IsIPv6(v4IP) {
 		return fmt.Errorf("invalid transit switch v4 subnet specified, subnet: %s: error: %v", ClusterManager.V4TransitSwitchSubnet, err)
 	}
 
-	v6IP, _, err := net.ParseCIDR(ClusterManager.V6TransitSwitchSubnet)
+	v6IP, v6TransitSwitchCIDR, err := net.ParseCIDR(ClusterManager.V6TransitSwitchSubnet)
 	if err != nil || !utilnet.IsIPv6(v6IP) {
 		return fmt.Errorf("invalid transit switch v4 join subnet specified, subnet: %s: error: %v", ClusterManager.V6TransitSwitchSubnet, err)
 	}
+	// the transit switch subnets are only in use with interconnect
+	if OVNKubernetesFeature.EnableInterconnect {
+		allSubnets.append(configSubnetTransitSwitch, v4TransitSwitchCIDR)
+		allSubnets.append(configSubnetTransitSwitch, v6TransitSwitchCIDR)
+	}
+
+	if err := completeZoneTransitSwitchSubnets(v4TransitSwitchCIDR, v6TransitSwitchCIDR); err != nil {
+		return err
+	}
+
+	if err := completeZoneSubnets(); err != nil {
+		return err
+	}
+
+	if err := completeHostSubnetLengthRules(); err != nil {
+		return err
+	}
+
+	ClusterManager.ExcludeSubnets = nil
+	if ClusterManager.RawExcludeSubnets == "" {
+		return nil
+	}
+	for _, cidrString := range strings.Split(ClusterManager.RawExcludeSubnets, ",") {
+		_, subnet, err := net.ParseCIDR(strings.TrimSpace(cidrString))
+		if err != nil {
+			return fmt.Errorf("excluded subnet %q invalid: %v", cidrString, err)
+		}
+		inClusterSubnets := false
+		for _, clusterSubnet := range Default.ClusterSubnets {
+			clusterSubnetLength, _ := clusterSubnet.CIDR.Mask.Size()
+			subnetLength, _ := subnet.Mask.Size()
+			if clusterSubnet.CIDR.Contains(subnet.IP) && subnetLength >= clusterSubnetLength {
+				inClusterSubnets = true
+				break
+			}
+		}
+		if !inClusterSubnets {
+			return fmt.Errorf("excluded subnet %s is not part of the cluster subnets", subnet)
+		}
+		ClusterManager.ExcludeSubnets = append(ClusterManager.ExcludeSubnets, subnet)
+	}
+
+	return nil
+}
+
+// completeZoneSubnets parses the zone=cluster subnet entries mapping cluster
+// subnets of the default network to topology zones. Each cluster subnet can
+// only be mapped to one zone.
+// completeZoneTransitSwitchSubnets parses the zone=range entries of the ranges
+// of the transit switch subnets of the interconnect zones. The ranges must be
+// within the transit switch subnets, so that the transit switch addresses of
+// the nodes of all the zones are on the same link, and must not overlap each
+// other.
+func completeZoneTransitSwitchSubnets(transitSwitchSubnets ...*net.IPNet) error {
+	ClusterManager.ZoneTransitSwitchSubnets = nil
+	if ClusterManager.RawZoneTransitSwitchSubnets == "" {
+		return nil
+	}
+	ClusterManager.ZoneTransitSwitchSubnets = map[string][]*net.IPNet{}
+	var ranges []*net.IPNet
+	for _, entry := range strings.Split(ClusterManager.RawZoneTransitSwitchSubnets, ",") {
+		zone, cidrString, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || zone == "" {
+			return fmt.Errorf("zone transit switch subnet %q invalid: must be zone=range", entry)
+		}
+		_, subnet, err := net.ParseCIDR(cidrString)
+		if err != nil {
+			return fmt.Errorf("zone transit switch subnet %q invalid: %v", entry, err)
+		}
+		withinTransitSwitchSubnet := false
+		for _, transitSwitchSubnet := range transitSwitchSubnets {
+			transitSwitchLength, _ := transitSwitchSubnet.Mask.Size()
+			subnetLength, _ := subnet.Mask.Size()
+			if transitSwitchSubnet.Contains(subnet.IP) && subnetLength >= transitSwitchLength {
+				withinTransitSwitchSubnet = true
+				break
+			}
+		}
+		if !withinTransitSwitchSubnet {
+			return fmt.Errorf("zone transit switch subnet %s of zone %s is not within the transit switch subnets", subnet, zone)
+		}
+		for _, other := range ranges {
+			if other.Contains(subnet.IP) || subnet.Contains(other.IP) {
+				return fmt.Errorf("zone transit switch subnet %s of zone %s overlaps %s", subnet, zone, other)
+			}
+		}
+		for _, other := range ClusterManager.ZoneTransitSwitchSubnets[zone] {
+			if utilnet.IsIPv6CIDR(other) == utilnet.IsIPv6CIDR(subnet) {
+				return fmt.Errorf("zone %s has several transit switch subnets of the same IP family", zone)
+			}
+		}
+		ranges = append(ranges, subnet)
+		ClusterManager.ZoneTransitSwitchSubnets[zone] = append(ClusterManager.ZoneTransitSwitchSubnets[zone], subnet)
+	}
+	return nil
+}
+
+func completeZoneSubnets() error {
+	ClusterManager.ZoneSubnets = nil
+	if ClusterManager.RawZoneSubnets == "" {
+		return nil
+	}
+	ClusterManager.ZoneSubnets = map[string][]*net.IPNet{}
+	zoneOfSubnet := map[string]string{}
+	for _, entry := range strings.Split(ClusterManager.RawZoneSubnets, ",") {
+		zone, cidrString, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || zone == "" {
+			return fmt.Errorf("zone subnet %q invalid: must be zone=cluster subnet", entry)
+		}
+		_, subnet, err := net.ParseCIDR(cidrString)
+		if err != nil {
+			return fmt.Errorf("zone subnet %q invalid: %v", entry, err)
+		}
+		isClusterSubnet := false
+		for _, clusterSubnet := range Default.ClusterSubnets {
+			if clusterSubnet.CIDR.String() == subnet.String() {
+				isClusterSubnet = true
+				break
+			}
+		}
+		if !isClusterSubnet {
+			return fmt.Errorf("zone subnet %s is not one of the cluster subnets", subnet)
+		}
+		if otherZone, ok := zoneOfSubnet[subnet.String()]; ok {
+			return fmt.Errorf("cluster subnet %s is mapped to zones %s and %s", subnet, otherZone, zone)
+		}
+		zoneOfSubnet[subnet.String()] = zone
+		ClusterManager.ZoneSubnets[zone] = append(ClusterManager.ZoneSubnets[zone], subnet)
+	}
+	return nil
+}
 
+// completeHostSubnetLengthRules parses the node selector:IPv4 prefix
+// length[:IPv6 prefix length] rules overriding the prefix length of the host
+// subnets of the nodes matching them.
+func completeHostSubnetLengthRules() error {
+	ClusterManager.HostSubnetLengthRules = nil
+	if ClusterManager.RawHostSubnetLengthRules == "" {
+		return nil
+	}
+	for _, entry := range strings.Split(ClusterManager.RawHostSubnetLengthRules, ";") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return fmt.Errorf("host subnet length rule %q invalid: must be node selector:IPv4 prefix length[:IPv6 prefix length]", entry)
+		}
+		selector, err := labels.Parse(parts[0])
+		if err != nil {
+			return fmt.Errorf("host subnet length rule %q invalid: %v", entry, err)
+		}
+		rule := HostSubnetLengthRule{NodeSelector: selector}
+		lengths := []*int{&rule.HostSubnetLength, &rule.IPv6HostSubnetLength}
+		for i, value := range parts[1:] {
+			maxLength := 32
+			if i == 1 {
+				maxLength = 128
+			}
+			length, err := strconv.Atoi(value)
+			if err != nil || length < 0 || length > maxLength {
+				return fmt.Errorf("host subnet length rule %q invalid: prefix length %q out of range", entry, value)
+			}
+			*lengths[i] = length
+		}
+		ClusterManager.HostSubnetLengthRules = append(ClusterManager.HostSubnetLengthRules, rule)
+	}
 	return nil
 }
 
@@ -2000,6 +3518,11 @@ func buildDefaultConfig(cli, file *config) error {
 		return fmt.Errorf("cluster subnet is required")
 	}
 
+	if Default.MaxHostSubnetsPerNode < 1 {
+		return fmt.Errorf("invalid maximum number of host subnets per node %d, must be at least 1",
+			Default.MaxHostSubnetsPerNode)
+	}
+
 	if Default.Zone == "" {
 		Default.Zone = types.OvnDefaultZone
 	}
@@ -2018,6 +3541,57 @@ func completeDefaultConfig(allSubnets *configSubnets) error {
 		allSubnets.append(configSubnetCluster, subnet.CIDR)
 	}
 
+	Default.HealthCheckSubnets = nil
+	if Default.RawHealthCheckSubnets != "" {
+		Default.HealthCheckSubnets, err = ParseClusterSubnetEntries(Default.RawHealthCheckSubnets)
+		if err != nil {
+			return fmt.Errorf("health check subnet invalid: %v", err)
+		}
+		for _, subnet := range Default.HealthCheckSubnets {
+			allSubnets.append(configSubnetHealthCheck, subnet.CIDR)
+		}
+	}
+
+	return nil
+}
+
+// validateDefaultNetworkTopology checks that the features configured along the
+// layer2 topology of the default network, whose nodes have no host subnet, no
+// management port and no node switch, can work without them.
+func validateDefaultNetworkTopology() error {
+	switch Default.Topology {
+	case types.Layer3Topology:
+		return nil
+	case types.Layer2Topology:
+	default:
+		return fmt.Errorf("invalid default network topology %q, must be %q or %q", Default.Topology,
+			types.Layer3Topology, types.Layer2Topology)
+	}
+	if OVNKubernetesFeature.EnableInterconnect {
+		return fmt.Errorf("the %s default network topology is not supported with interconnect", Default.Topology)
+	}
+	if Gateway.Mode == GatewayModeLocal {
+		return fmt.Errorf("the %s default network topology is not supported with the %q gateway mode",
+			Default.Topology, GatewayModeLocal)
+	}
+	if Gateway.DisableSNATMultipleGWs {
+		return fmt.Errorf("the %s default network topology is not supported with disable-snat-multiple-gws",
+			Default.Topology)
+	}
+	if HybridOverlay.Enabled {
+		return fmt.Errorf("the %s default network topology is not supported with hybrid overlay", Default.Topology)
+	}
+	if len(Default.HealthCheckSubnets) > 0 {
+		return fmt.Errorf("the %s default network topology is not supported with health check subnets",
+			Default.Topology)
+	}
+	if Default.MaxHostSubnetsPerNode > 1 {
+		return fmt.Errorf("the %s default network topology is not supported with additional host subnets",
+			Default.Topology)
+	}
+	if OVNKubernetesFeature.EnableHostPort {
+		return fmt.Errorf("the %s default network topology is not supported with host ports", Default.Topology)
+	}
 	return nil
 }
 
@@ -2129,6 +3703,9 @@ func initConfigWithPath(ctx *cli.Context, exec kexec.Interface, saPath string, d
 	if err = overrideFields(&Logging, &cliConfig.Logging, &savedLogging); err != nil {
 		return "", err
 	}
+	if Logging.EgressIPNATLogSampling < 1 {
+		return "", fmt.Errorf("invalid egress IP NAT log sampling %d, must be at least 1", Logging.EgressIPNATLogSampling)
+	}
 
 	var level klog.Level
 	if err := level.Set(strconv.Itoa(Logging.Level)); err != nil {
@@ -2254,7 +3831,13 @@ func completeConfig() error {
 		return err
 	}
 
-	if err := completeClusterManagerConfig(); err != nil {
+	if err := completeClusterManagerConfig(allSubnets); err != nil {
+		return err
+	}
+	if err := validateDefaultNetworkTopology(); err != nil {
+		return err
+	}
+	if err := completeLoadBalancerConfig(allSubnets); err != nil {
 		return err
 	}
 
@@ -2267,6 +3850,23 @@ func completeConfig() error {
 	if err != nil {
 		return err
 	}
+	for _, pool := range OVNKubernetesFeature.LoadBalancerIPPools {
+		if utilnet.IsIPv6CIDR(pool) && !IPv6Mode || !utilnet.IsIPv6CIDR(pool) && !IPv4Mode {
+			return fmt.Errorf("illegal network configuration: load balancer IP pool %q is not of an IP family of the cluster",
+				pool.String())
+		}
+	}
+	for _, subnet := range Default.HealthCheckSubnets {
+		if utilnet.IsIPv6CIDR(subnet.CIDR) && !IPv6Mode || !utilnet.IsIPv6CIDR(subnet.CIDR) && !IPv4Mode {
+			return fmt.Errorf("illegal network configuration: health check subnet %q is not of an IP family of the cluster",
+				subnet.CIDR.String())
+		}
+	}
+	configuredSubnets = allSubnets
+
+	if err := completeIPv6OnlyConfig(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -2507,7 +4107,7 @@ func UpdateOVNNodeAuth(masterIP []string, southboundDBPort, northboundDBPort str
 // ovnKubeNodeModeSupported validates the provided mode is supported by ovnkube node
 func ovnKubeNodeModeSupported(mode string) error {
 	found := false
-	supportedModes := []string{types.NodeModeFull, types.NodeModeDPU, types.NodeModeDPUHost}
+	supportedModes := []string{types.NodeModeFull, types.NodeModeDPU, types.NodeModeDPUHost, types.NodeModeStandaloneHost}
 	for _, m := range supportedModes {
 		if mode == m {
 			found = true
@@ -2537,6 +4137,13 @@ func buildOvnKubeNodeConfig(ctx *cli.Context, cli, file *config) error {
 		return err
 	}
 
+	if OvnKubeNode.IntegrationBridge == "" {
+		OvnKubeNode.IntegrationBridge = types.DefaultIntegrationBridge
+	}
+	if err := completeBridgeMappings(); err != nil {
+		return err
+	}
+
 	// ovnkube-node-mode dpu/dpu-host does not support hybrid overlay
 	if OvnKubeNode.Mode != types.NodeModeFull && HybridOverlay.Enabled {
 		return fmt.Errorf("hybrid overlay is not supported with ovnkube-node mode %s", OvnKubeNode.Mode)
@@ -2560,5 +4167,87 @@ func buildOvnKubeNodeConfig(ctx *cli.Context, cli, file *config) error {
 	if OvnKubeNode.Mode == types.NodeModeDPUHost && OvnKubeNode.MgmtPortNetdev == "" && OvnKubeNode.MgmtPortDPResourceName == "" {
 		return fmt.Errorf("ovnkube-node-mgmt-port-netdev or ovnkube-node-mgmt-port-dp-resource-name must be provided")
 	}
+	// a standalone host has no management port, it only attaches to the
+	// network of its Host
+	if OvnKubeNode.Mode == types.NodeModeStandaloneHost && (OvnKubeNode.MgmtPortNetdev != "" || OvnKubeNode.MgmtPortDPResourceName != "") {
+		return fmt.Errorf("ovnkube-node-mgmt-port-netdev or ovnkube-node-mgmt-port-dp-resource-name must not be provided")
+	}
+	return validateOvnKubeNodeDatapath()
+}
+
+// completeBridgeMappings parses the physical network:OVS bridge[:backup OVS
+// bridge] mappings of the node. Each physical network can only be mapped to one
+// bridge and one backup bridge, neither of which can be the integration bridge.
+func completeBridgeMappings() error {
+	OvnKubeNode.BridgeMappings = nil
+	OvnKubeNode.BackupBridgeMappings = nil
+	if OvnKubeNode.RawBridgeMappings == "" {
+		return nil
+	}
+	OvnKubeNode.BridgeMappings = map[string]string{}
+	OvnKubeNode.BackupBridgeMappings = map[string]string{}
+	for _, entry := range strings.Split(OvnKubeNode.RawBridgeMappings, ",") {
+		fields := strings.Split(strings.TrimSpace(entry), ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return fmt.Errorf("bridge mapping %q invalid: must be physical network:OVS bridge[:backup OVS bridge]", entry)
+		}
+		for _, field := range fields {
+			if field == "" {
+				return fmt.Errorf("bridge mapping %q invalid: must be physical network:OVS bridge[:backup OVS bridge]", entry)
+			}
+		}
+		physnet, bridges := fields[0], fields[1:]
+		for _, bridge := range bridges {
+			if bridge == OvnKubeNode.IntegrationBridge {
+				return fmt.Errorf("bridge mapping %q invalid: %s is the integration bridge", entry, bridge)
+			}
+		}
+		if otherBridge, ok := OvnKubeNode.BridgeMappings[physnet]; ok {
+			return fmt.Errorf("physical network %s is mapped to bridges %s and %s", physnet, otherBridge, bridges[0])
+		}
+		OvnKubeNode.BridgeMappings[physnet] = bridges[0]
+		if len(bridges) == 2 {
+			if bridges[1] == bridges[0] {
+				return fmt.Errorf("bridge mapping %q invalid: the backup bridge is the bridge", entry)
+			}
+			OvnKubeNode.BackupBridgeMappings[physnet] = bridges[1]
+		}
+	}
 	return nil
 }
+
+// validateOvnKubeNodeDatapath validates ovnkube-node-datapath and rejects the
+// features that the userspace datapath doesn't provide
+func validateOvnKubeNodeDatapath() error {
+	if OvnKubeNode.Datapath == "" {
+		OvnKubeNode.Datapath = types.NodeDatapathSystem
+	}
+	switch OvnKubeNode.Datapath {
+	case types.NodeDatapathSystem:
+		return nil
+	case types.NodeDatapathAFXDP, types.NodeDatapathDPDK:
+	default:
+		return fmt.Errorf("unexpected ovnkube-node-datapath: %s. supported datapaths: %v", OvnKubeNode.Datapath,
+			[]string{types.NodeDatapathSystem, types.NodeDatapathAFXDP, types.NodeDatapathDPDK})
+	}
+	// the hardware offload of the DPU modes and of the management port VF
+	// is only provided by the kernel datapath
+	if OvnKubeNode.Mode != types.NodeModeFull {
+		return fmt.Errorf("ovnkube-node-datapath %s is not supported with ovnkube-node mode %s",
+			OvnKubeNode.Datapath, OvnKubeNode.Mode)
+	}
+	if OvnKubeNode.MgmtPortNetdev != "" || OvnKubeNode.MgmtPortDPResourceName != "" {
+		return fmt.Errorf("ovnkube-node-mgmt-port-netdev or ovnkube-node-mgmt-port-dp-resource-name must not be "+
+			"provided with ovnkube-node-datapath %s", OvnKubeNode.Datapath)
+	}
+	// the UDP GRO forwarding is a feature of the kernel datapath
+	if Default.EnableUDPAggregation {
+		return fmt.Errorf("enable-udp-aggregation is not supported with ovnkube-node-datapath %s", OvnKubeNode.Datapath)
+	}
+	return nil
+}
+
+// IsUserspaceDatapath returns whether the node runs the OVS userspace datapath
+func IsUserspaceDatapath() bool {
+	return OvnKubeNode.Datapath == types.NodeDatapathAFXDP || OvnKubeNode.Datapath == types.NodeDatapathDPDK
+}
@@ -3,12 +3,14 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/urfave/cli/v2"
+	"k8s.io/apimachinery/pkg/util/sets"
 	kexec "k8s.io/utils/exec"
 
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
@@ -203,6 +205,7 @@ vlan-id=10
 nodeport=false
 v4-join-subnet=100.65.0.0/16
 v6-join-subnet=fd90::/64
+v4-join-node-subnet-length=30
 v4-masquerade-subnet=169.254.169.0/29
 v6-masquerade-subnet=fd69::/125
 router-subnet=10.50.0.0/16
@@ -327,6 +330,9 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(OVNKubernetesFeature.EnableInterconnect).To(gomega.BeFalse())
 			gomega.Expect(OVNKubernetesFeature.EnableMultiExternalGateway).To(gomega.BeFalse())
 			gomega.Expect(OVNKubernetesFeature.EnableAdminNetworkPolicy).To(gomega.BeFalse())
+			gomega.Expect(OVNKubernetesFeature.NodeNetworkStateBackend).To(gomega.Equal(NodeNetworkStateBackendAnnotation))
+			gomega.Expect(OVNKubernetesFeature.EnableNodeFinalizer).To(gomega.BeFalse())
+			gomega.Expect(OVNKubernetesFeature.EnableStandaloneHosts).To(gomega.BeFalse())
 
 			for _, a := range []OvnAuthConfig{OvnNorth, OvnSouth} {
 				gomega.Expect(a.Scheme).To(gomega.Equal(OvnDBSchemeUnix))
@@ -638,6 +644,8 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(Gateway.NodeportEnable).To(gomega.BeFalse())
 			gomega.Expect(Gateway.V4JoinSubnet).To(gomega.Equal("100.65.0.0/16"))
 			gomega.Expect(Gateway.V6JoinSubnet).To(gomega.Equal("fd90::/64"))
+			gomega.Expect(Gateway.V4JoinNodeSubnetLength).To(gomega.Equal(30))
+			gomega.Expect(Gateway.V6JoinNodeSubnetLength).To(gomega.Equal(128))
 			gomega.Expect(Gateway.V4MasqueradeSubnet).To(gomega.Equal("169.254.169.0/29"))
 			gomega.Expect(Gateway.V6MasqueradeSubnet).To(gomega.Equal("fd69::/125"))
 			gomega.Expect(Gateway.RouterSubnet).To(gomega.Equal("10.50.0.0/16"))
@@ -698,6 +706,7 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(Kubernetes.APIServer).To(gomega.Equal("https://4.4.3.2:8080"))
 			gomega.Expect(Kubernetes.RawServiceCIDRs).To(gomega.Equal("172.15.0.0/24"))
 			gomega.Expect(Kubernetes.RawNoHostSubnetNodes).To(gomega.Equal("test=pass"))
+			gomega.Expect(sets.List(Kubernetes.PriorityNamespaces)).To(gomega.Equal([]string{"ingress", "kube-system"}))
 			gomega.Expect(Kubernetes.HealthzBindAddress).To(gomega.Equal("0.0.0.0:4321"))
 			gomega.Expect(Kubernetes.DNSServiceNamespace).To(gomega.Equal("kube-system-2"))
 			gomega.Expect(Kubernetes.DNSServiceName).To(gomega.Equal("kube-dns-2"))
@@ -748,6 +757,9 @@ var _ = Describe("Config Operations", func() {
 			gomega.Expect(OVNKubernetesFeature.EnableInterconnect).To(gomega.BeTrue())
 			gomega.Expect(OVNKubernetesFeature.EnableMultiExternalGateway).To(gomega.BeTrue())
 			gomega.Expect(OVNKubernetesFeature.EnableAdminNetworkPolicy).To(gomega.BeTrue())
+			gomega.Expect(OVNKubernetesFeature.NodeNetworkStateBackend).To(gomega.Equal(NodeNetworkStateBackendCRD))
+			gomega.Expect(OVNKubernetesFeature.EnableNodeFinalizer).To(gomega.BeTrue())
+			gomega.Expect(OVNKubernetesFeature.EnableStandaloneHosts).To(gomega.BeTrue())
 			gomega.Expect(HybridOverlay.ClusterSubnets).To(gomega.Equal([]CIDRNetworkEntry{
 				{ovntest.MustParseIPNet("11.132.0.0/14"), 23},
 			}))
@@ -814,10 +826,14 @@ var _ = Describe("Config Operations", func() {
 			"-enable-interconnect=true",
 			"-enable-multi-external-gateway=true",
 			"-enable-admin-network-policy=true",
+			"-node-network-state-backend=crd",
+			"-enable-node-finalizer=true",
+			"-enable-standalone-hosts=true",
 			"-healthz-bind-address=0.0.0.0:4321",
 			"-zone=bar",
 			"-dns-service-namespace=kube-system-2",
 			"-dns-service-name=kube-dns-2",
+			"-priority-namespaces=kube-system, ingress",
 		}
 		err = app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
@@ -882,6 +898,21 @@ service-cidr=172.18.0.0/24
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
+	It("returns an error when a priority namespace is invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.HavePrefix("invalid priority namespace \"Kube_System\""))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-priority-namespaces=kube-system,Kube_System",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
 	It("overrides config file and defaults with CLI legacy cluster-subnet option", func() {
 		err := ioutil.WriteFile(cfgFile.Name(), []byte(`[default]
 cluster-subnets=172.18.0.0/23
@@ -1070,6 +1101,21 @@ enable-pprof=true
 		err := app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
+
+	It("returns an error when the host ports are enabled for mode other than shared gateway mode", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("host ports are supported only in shared gateway mode"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-gateway-mode=local",
+			"-enable-host-port",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 	It("returns an error when the v4 join subnet specified is invalid", func() {
 		app.Action = func(ctx *cli.Context) error {
 			_, err := InitConfig(ctx, kexec.New(), nil)
@@ -1083,6 +1129,19 @@ enable-pprof=true
 		err := app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
+	It("returns an error when the join node subnet length is shorter than the join subnet", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("invalid join node subnet length 8 of join subnet 100.64.0.0/16: must be between 16 and 32"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-gateway-v4-join-node-subnet-length=8",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 	It("returns an error when the v6 join subnet specified is invalid", func() {
 		app.Action = func(ctx *cli.Context) error {
 			_, err := InitConfig(ctx, kexec.New(), nil)
@@ -1122,6 +1181,42 @@ enable-pprof=true
 		err := app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
+	It("returns an error when the masquerade subnet pool does not hold the masquerade subnet", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError("gateway masquerade subnet pool fd69::/112 does not hold masquerade subnet fd68::/125"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-gateway-masquerade-subnet-auto-select",
+			"-gateway-v6-masquerade-subnet=fd68::/125",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+	It("successfully enables the masquerade subnet auto selection", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-gateway-masquerade-subnet-auto-select",
+			"-gateway-v4-masquerade-subnet-pool=169.254.128.0/17",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(Gateway.MasqueradeSubnetAutoSelect).To(gomega.BeTrue())
+		gomega.Expect(Gateway.V4MasqueradeSubnetPool).To(gomega.Equal("169.254.128.0/17"))
+		gomega.Expect(Gateway.V6MasqueradeSubnetPool).To(gomega.Equal("fd69::/112"))
+
+		gomega.Expect(SetMasqueradeSubnets("169.254.128.8/29", "fd69::8/125")).To(gomega.Succeed())
+		gomega.Expect(Gateway.V4MasqueradeSubnet).To(gomega.Equal("169.254.128.8/29"))
+		gomega.Expect(Gateway.MasqueradeIPs.V4HostMasqueradeIP.String()).To(gomega.Equal("169.254.128.10"))
+		gomega.Expect(Gateway.MasqueradeIPs.V6HostMasqueradeIP.String()).To(gomega.Equal("fd69::a"))
+	})
 	It("successfully overrides the default masquerade subnets", func() {
 		app.Action = func(ctx *cli.Context) error {
 			_, err := InitConfig(ctx, kexec.New(), nil)
@@ -1436,115 +1531,795 @@ enable-pprof=true
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
-	It("ignores unknown fields in config file and does not return an error", func() {
-		err := ioutil.WriteFile(cfgFile.Name(), []byte(`[default]
-key=value
-mtu=1234
+	It("rejects an IPv6-only cluster with hybrid overlay enabled by default", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(
+				"features enabled that do not support IPv6-only clusters: hybrid-overlay " +
+					"(the hybrid overlay node datapath only handles IPv4 pods)")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=fd01::/48/64",
+			"-k8s-service-cidrs=fd02::/112",
+			"-enable-hybrid-overlay",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-[foobar]
-foo=bar
-`), 0o644)
+	It("does not reject the unsupported features of IPv6-only clusters when not validating them", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(HybridOverlay.Enabled).To(gomega.BeTrue())
+			gomega.Expect(GetIPv6OnlyCompatibility().Features).To(gomega.ContainElement(
+				IPv6OnlyFeatureCompatibility{Feature: "hybrid-overlay", Enabled: true, Supported: false,
+					Notes: "the hybrid overlay node datapath only handles IPv4 pods"},
+			))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=fd01::/48/64",
+			"-k8s-service-cidrs=fd02::/112",
+			"-enable-hybrid-overlay",
+			"-ipv6-only-validation=off",
+		}
+		err := app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
+	It("disables the features that do not support IPv6-only clusters when validating IPv6-only features", func() {
 		app.Action = func(ctx *cli.Context) error {
-			var cfgPath string
-			cfgPath, err = InitConfig(ctx, kexec.New(), nil)
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(HybridOverlay.Enabled).To(gomega.BeFalse())
+			gomega.Expect(OVNKubernetesFeature.EnableEgressIP).To(gomega.BeTrue())
+
+			compatibility := GetIPv6OnlyCompatibility()
+			gomega.Expect(compatibility.IPv6Only).To(gomega.BeTrue())
+			gomega.Expect(compatibility.Validation).To(gomega.Equal(IPv6OnlyValidationDisable))
+			gomega.Expect(compatibility.Features).To(gomega.ContainElements(
+				IPv6OnlyFeatureCompatibility{Feature: "services", Enabled: true, Supported: true},
+				IPv6OnlyFeatureCompatibility{Feature: "egress-ip", Enabled: true, Supported: true},
+				IPv6OnlyFeatureCompatibility{Feature: "egress-firewall", Enabled: false, Supported: true},
+				IPv6OnlyFeatureCompatibility{Feature: "hybrid-overlay", Enabled: false, Supported: false, Disabled: true,
+					Notes: "the hybrid overlay node datapath only handles IPv4 pods"},
+			))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=fd01::/48/64",
+			"-k8s-service-cidrs=fd02::/112",
+			"-enable-hybrid-overlay",
+			"-enable-egress-ip",
+			"-ipv6-only-validation=disable",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-			// unknown section foobar and its keys & values should be ignored
-			// same for key=value in default section
+	It("checks the IPv6 configuration the features of IPv6-only clusters need", func() {
+		gomega.Expect(PrepareTestConfig()).To(gomega.Succeed())
+		_, serviceCIDR, _ := net.ParseCIDR("172.30.0.0/16")
+		Kubernetes.ServiceCIDRs = []*net.IPNet{serviceCIDR}
+		gomega.Expect(checkIPv6OnlyServices()).To(gomega.MatchError("no IPv6 service CIDR"))
+		_, serviceCIDR, _ = net.ParseCIDR("fd02::/112")
+		Kubernetes.ServiceCIDRs = []*net.IPNet{serviceCIDR}
+		Gateway.MasqueradeIPs.V6HostMasqueradeIP = nil
+		gomega.Expect(checkIPv6OnlyServices()).To(gomega.MatchError("no IPv6 masquerade IPs"))
+
+		Gateway.V6JoinSubnet = "100.64.0.0/16"
+		gomega.Expect(checkIPv6OnlyEgressIP()).To(gomega.MatchError("no IPv6 join subnet"))
+
+		OVNKubernetesFeature.EnableInterconnect = true
+		ClusterManager.V6TransitSwitchSubnet = "100.88.0.0/16"
+		gomega.Expect(checkIPv6OnlyMultiNetwork()).To(gomega.MatchError("no IPv6 transit switch subnet"))
+		OVNKubernetesFeature.EnableInterconnect = false
+		gomega.Expect(checkIPv6OnlyMultiNetwork()).To(gomega.Succeed())
+	})
+
+	It("does not validate the IPv6-only features of dual-stack clusters", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(HybridOverlay.Enabled).To(gomega.BeTrue())
+			gomega.Expect(GetIPv6OnlyCompatibility().IPv6Only).To(gomega.BeFalse())
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.0.0.0/16/24,fd01::/48/64",
+			"-k8s-service-cidrs=172.30.0.0/16,fd02::/112",
+			"-enable-hybrid-overlay",
+			"-ipv6-only-validation=error",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-			gomega.Expect(cfgPath).To(gomega.Equal(cfgFile.Name()))
-			gomega.Expect(Default.MTU).To(gomega.Equal(1234))
+	It("rejects an invalid IPv6-only validation mode", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("invalid IPv6-only validation \"warn\"")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-ipv6-only-validation=warn",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("overrides the GARP config from the config file with the CLI", func() {
+		err := ioutil.WriteFile(cfgFile.Name(), []byte(`[ovnkubernetesfeature]
+garp-count=3
+garp-interval=500
+garp-max-timeout=60
+`), 0o644)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(OVNKubernetesFeature.GARPCount).To(gomega.Equal(5))
+			gomega.Expect(OVNKubernetesFeature.GARPInterval).To(gomega.Equal(500))
+			gomega.Expect(OVNKubernetesFeature.GARPMaxTimeout).To(gomega.Equal(60))
 			return nil
 		}
 		cliArgs := []string{
 			app.Name,
 			"-config-file=" + cfgFile.Name(),
+			"-garp-count=5",
 		}
 		err = app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
-	It("rejects a config with invalid syntax", func() {
-		err := ioutil.WriteFile(cfgFile.Name(), []byte(`[default]
-mtu=1234
+	It("rejects a negative GARP count", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("invalid GARP config")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-garp-count=-1",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-[foobar
-foo=bar
+	It("overrides the northbound rollout config from the config file with the CLI", func() {
+		err := ioutil.WriteFile(cfgFile.Name(), []byte(`[ovnkubernetesfeature]
+nb-rollout-batch-size=500
+nb-rollout-batch-interval=200
+nb-rollout-on-error=continue
 `), 0o644)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 		app.Action = func(ctx *cli.Context) error {
-			_, err = InitConfig(ctx, kexec.New(), nil)
-			gomega.Expect(err).To(gomega.HaveOccurred())
-
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(OVNKubernetesFeature.NBRolloutBatchSize).To(gomega.Equal(1000))
+			gomega.Expect(OVNKubernetesFeature.NBRolloutBatchInterval).To(gomega.Equal(200))
+			gomega.Expect(OVNKubernetesFeature.NBRolloutOnError).To(gomega.Equal(NBRolloutOnErrorContinue))
 			return nil
 		}
 		cliArgs := []string{
 			app.Name,
 			"-config-file=" + cfgFile.Name(),
+			"-nb-rollout-batch-size=1000",
 		}
 		err = app.Run(cliArgs)
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
-	Describe("OvnDBAuth operations", func() {
-		var certFile, keyFile, caFile string
-
-		BeforeEach(func() {
-			var err error
-			certFile, _, err = createTempFile("cert.crt")
-			gomega.Expect(err).NotTo(gomega.HaveOccurred())
-			keyFile, _, err = createTempFile("priv.key")
-			gomega.Expect(err).NotTo(gomega.HaveOccurred())
-			caFile = filepath.Join(tmpDir, "ca.crt")
-		})
+	It("rejects an invalid northbound rollout on error", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("invalid northbound rollout on error")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-nb-rollout-on-error=abort",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-		AfterEach(func() {
-			err := os.Remove(certFile)
-			gomega.Expect(err).NotTo(gomega.HaveOccurred())
-			err = os.Remove(keyFile)
+	It("parses the load balancer IP pools", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
-			os.Remove(caFile)
-		})
-
-		const (
-			nbURL             string = "ssl:1.2.3.4:6641"
-			sbURL             string = "ssl:1.2.3.4:6642"
-			nbDummyCommonName        = "cfg-nbcommonname"
-			sbDummyCommonName        = "cfg-sbcommonname"
-		)
+			gomega.Expect(OVNKubernetesFeature.LoadBalancerIPPools).To(gomega.HaveLen(2))
+			gomega.Expect(OVNKubernetesFeature.LoadBalancerIPPools[0].String()).To(gomega.Equal("192.168.10.0/24"))
+			gomega.Expect(OVNKubernetesFeature.LoadBalancerIPPools[1].String()).To(gomega.Equal("fd03::/120"))
+			gomega.Expect(OVNKubernetesFeature.LoadBalancerAnnounceMode).To(gomega.Equal(LoadBalancerAnnounceModeL2))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.0.0.0/16/24,fd01::/48/64",
+			"-k8s-service-cidrs=172.30.0.0/16,fd02::/112",
+			"-load-balancer-ip-pools=192.168.10.0/24,fd03::/120",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-		It("configures client northbound SSL correctly", func() {
-			fexec := ovntest.NewFakeExec()
-			fexec.AddFakeCmdsNoOutputNoError([]string{
-				"ovn-nbctl --db=" + nbURL + " --timeout=5 --private-key=" + keyFile + " --certificate=" + certFile + " --bootstrap-ca-cert=" + caFile + " list nb_global",
-			})
+	It("rejects a load balancer IP pool overlapping the cluster subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("load balancer IP pool \"10.128.10.0/24\" overlaps")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-load-balancer-ip-pools=10.128.10.0/24",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-			cliConfig := &OvnAuthConfig{
-				Address:        nbURL,
-				PrivKey:        keyFile,
-				Cert:           certFile,
-				CACert:         caFile,
-				CertCommonName: nbDummyCommonName,
-			}
-			a, err := buildOvnAuth(fexec, true, cliConfig, &OvnAuthConfig{}, true)
-			gomega.Expect(err).NotTo(gomega.HaveOccurred())
-			gomega.Expect(a.Scheme).To(gomega.Equal(OvnDBSchemeSSL))
-			gomega.Expect(a.PrivKey).To(gomega.Equal(keyFile))
-			gomega.Expect(a.Cert).To(gomega.Equal(certFile))
-			gomega.Expect(a.CACert).To(gomega.Equal(caFile))
-			gomega.Expect(a.Address).To(gomega.Equal(nbURL))
-			gomega.Expect(a.CertCommonName).To(gomega.Equal(nbDummyCommonName))
-			gomega.Expect(a.northbound).To(gomega.BeTrue())
+	It("rejects a load balancer IP pool of another IP family than the cluster", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("is not of an IP family of the cluster")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-load-balancer-ip-pools=fd03::/120",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 
-			gomega.Expect(a.GetURL()).To(gomega.Equal(nbURL))
-			err = a.SetDBAuth()
+	It("parses the health check subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
-			gomega.Expect(fexec.CalledMatchesExpected()).To(gomega.BeTrue(), fexec.ErrorDesc)
-		})
-
+			gomega.Expect(Default.HealthCheckSubnets).To(gomega.HaveLen(2))
+			gomega.Expect(Default.HealthCheckSubnets[0].String()).To(gomega.Equal("100.66.0.0/16/28"))
+			gomega.Expect(Default.HealthCheckSubnets[1].String()).To(gomega.Equal("fd66::/48/64"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.0.0.0/16/24,fd01::/48/64",
+			"-k8s-service-cidrs=172.30.0.0/16,fd02::/112",
+			"-health-check-subnets=100.66.0.0/16/28,fd66::/48/64",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects a health check subnet overlapping the cluster subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("health check subnet \"10.128.10.0/24\" overlaps")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-health-check-subnets=10.128.10.0/24/28",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects an invalid load balancer announce mode", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("invalid load balancer announce mode \"bgp\"")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-load-balancer-announce-mode=bgp",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("parses the subnets excluded from the cluster subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(ClusterManager.ExcludeSubnets).To(gomega.HaveLen(2))
+			gomega.Expect(ClusterManager.ExcludeSubnets[0].String()).To(gomega.Equal("10.0.4.0/22"))
+			gomega.Expect(ClusterManager.ExcludeSubnets[1].String()).To(gomega.Equal("fd01:0:0:1::/64"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.0.0.0/16/24,fd01::/48/64",
+			"-k8s-service-cidrs=172.30.0.0/16,fd02::/112",
+			"-cluster-manager-exclude-subnets=10.0.4.0/22, fd01:0:0:1::/64",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects an excluded subnet not part of the cluster subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("excluded subnet 10.0.0.0/8 is not part of the cluster subnets")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/23",
+			"-cluster-manager-exclude-subnets=10.0.0.0/8",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("parses the cluster subnets of the zones", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(ClusterManager.ZoneSubnets).To(gomega.HaveLen(2))
+			gomega.Expect(ClusterManager.ZoneSubnets["zone-a"]).To(gomega.HaveLen(2))
+			gomega.Expect(ClusterManager.ZoneSubnets["zone-a"][0].String()).To(gomega.Equal("10.128.0.0/16"))
+			gomega.Expect(ClusterManager.ZoneSubnets["zone-a"][1].String()).To(gomega.Equal("fd01::/48"))
+			gomega.Expect(ClusterManager.ZoneSubnets["zone-b"]).To(gomega.HaveLen(1))
+			gomega.Expect(ClusterManager.ZoneSubnets["zone-b"][0].String()).To(gomega.Equal("10.129.0.0/16"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/16/24,10.129.0.0/16/24,fd01::/48/64",
+			"-k8s-service-cidrs=172.30.0.0/16,fd02::/112",
+			"-cluster-manager-zone-subnets=zone-a=10.128.0.0/16, zone-b=10.129.0.0/16,zone-a=fd01::/48",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("parses the transit switch subnets of the zones", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(ClusterManager.ZoneTransitSwitchSubnets).To(gomega.HaveLen(2))
+			gomega.Expect(ClusterManager.ZoneTransitSwitchSubnets["zone-a"]).To(gomega.HaveLen(2))
+			gomega.Expect(ClusterManager.ZoneTransitSwitchSubnets["zone-a"][0].String()).To(gomega.Equal("168.254.128.0/24"))
+			gomega.Expect(ClusterManager.ZoneTransitSwitchSubnets["zone-a"][1].String()).To(gomega.Equal("fd97::1:0/112"))
+			gomega.Expect(ClusterManager.ZoneTransitSwitchSubnets["zone-b"]).To(gomega.HaveLen(1))
+			gomega.Expect(ClusterManager.ZoneTransitSwitchSubnets["zone-b"][0].String()).To(gomega.Equal("168.254.129.0/24"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-manager-zone-transit-switch-subnets=zone-a=168.254.128.0/24, zone-b=168.254.129.0/24,zone-a=fd97::1:0/112",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects overlapping transit switch subnets of the zones", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("zone transit switch subnet 168.254.128.0/25 of zone zone-b overlaps 168.254.128.0/24")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-manager-zone-transit-switch-subnets=zone-a=168.254.128.0/24,zone-b=168.254.128.0/25",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects a transit switch subnet of a zone out of the transit switch subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("zone transit switch subnet 169.254.128.0/24 of zone zone-a is not within the transit switch subnets")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-manager-zone-transit-switch-subnets=zone-a=169.254.128.0/24",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects a transit switch subnet overlapping the cluster subnets with interconnect", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("overlaps")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/23",
+			"-enable-interconnect",
+			"-cluster-manager-v4-transit-switch-subnet=10.130.0.0/16",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("parses the host subnet length rules", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			rules := ClusterManager.HostSubnetLengthRules
+			gomega.Expect(rules).To(gomega.HaveLen(2))
+			gomega.Expect(rules[0].NodeSelector.String()).To(gomega.Equal("node-pool=large"))
+			gomega.Expect(rules[0].HostSubnetLength).To(gomega.Equal(23))
+			gomega.Expect(rules[0].IPv6HostSubnetLength).To(gomega.Equal(0))
+			gomega.Expect(rules[1].NodeSelector.String()).To(gomega.Equal("env in (edge),node-pool!=large"))
+			gomega.Expect(rules[1].HostSubnetLength).To(gomega.Equal(26))
+			gomega.Expect(rules[1].IPv6HostSubnetLength).To(gomega.Equal(60))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/24",
+			"-cluster-manager-host-subnet-length-rules=node-pool=large:23; node-pool!=large,env in (edge):26:60",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects an invalid host subnet length rule", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("prefix length \"33\" out of range")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/24",
+			"-cluster-manager-host-subnet-length-rules=node-pool=large:33",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("parses the integration bridge and the bridge mappings of the node", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(OvnKubeNode.IntegrationBridge).To(gomega.Equal("br-ovn"))
+			gomega.Expect(OvnKubeNode.SystemID).To(gomega.Equal("node1-chassis"))
+			gomega.Expect(OvnKubeNode.BridgeMappings).To(gomega.Equal(map[string]string{
+				"physnet1": "br-phys1",
+				"physnet2": "br-phys2",
+			}))
+			gomega.Expect(OvnKubeNode.BackupBridgeMappings).To(gomega.Equal(map[string]string{
+				"physnet2": "br-phys3",
+			}))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-ovnkube-node-integration-bridge=br-ovn",
+			"-ovnkube-node-system-id=node1-chassis",
+			"-ovnkube-node-bridge-mappings=physnet1:br-phys1, physnet2:br-phys2:br-phys3",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects a bridge mapping to the integration bridge", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("br-int is the integration bridge")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-ovnkube-node-bridge-mappings=physnet1:br-int",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("configures the layer2 topology of the default network", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(Default.Topology).To(gomega.Equal(types.Layer2Topology))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/16",
+			"-topology=layer2",
+			"-gateway-mode=shared",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects the layer2 topology of the default network with interconnect", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("topology is not supported with interconnect")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/16",
+			"-topology=layer2",
+			"-enable-interconnect",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects the layer2 topology of the default network with host ports", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("topology is not supported with host ports")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/16",
+			"-topology=layer2",
+			"-gateway-mode=shared",
+			"-enable-host-port",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects an unknown topology of the default network", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("invalid default network topology \"localnet\"")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-topology=localnet",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects a zone subnet that is not one of the cluster subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("zone subnet 10.128.0.0/16 is not one of the cluster subnets")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/23",
+			"-cluster-manager-zone-subnets=zone-a=10.128.0.0/16",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects a cluster subnet mapped to two zones", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("cluster subnet 10.128.0.0/14 is mapped to zones zone-a and zone-b")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/23",
+			"-cluster-manager-zone-subnets=zone-a=10.128.0.0/14,zone-b=10.128.0.0/14",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects zone subnets with warm host subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("warm host subnets are not supported with zone subnets")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/23",
+			"-cluster-manager-zone-subnets=zone-a=10.128.0.0/14",
+			"-cluster-manager-warm-host-subnets=2",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects the deterministic host subnet allocation with warm host subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("warm host subnets are not supported")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-manager-host-subnet-allocation=deterministic",
+			"-cluster-manager-warm-host-subnets=2",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects the randomized host subnet allocation with the subnet compaction", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("subnet compaction is not supported")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-manager-host-subnet-allocation=randomized",
+			"-cluster-manager-subnet-compaction-batch-size=2",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects the randomized host subnet allocation with zone subnets", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(
+				"zone subnets are not supported with the \"randomized\" host subnet allocation")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.128.0.0/14/23",
+			"-cluster-manager-host-subnet-allocation=randomized",
+			"-cluster-manager-zone-subnets=zone-a=10.128.0.0/14",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects an invalid host subnet allocation", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("invalid host subnet allocation \"random\"")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-manager-host-subnet-allocation=random",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects the DHCPv6 prefix delegation without an interface", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("prefix delegation interface is required")))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-manager-ipv6-host-subnet-source=dhcpv6-pd",
+			"-cluster-manager-dhcpv6-pd-prefix-length=64",
+		}
+		err := app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("ignores unknown fields in config file and does not return an error", func() {
+		err := ioutil.WriteFile(cfgFile.Name(), []byte(`[default]
+key=value
+mtu=1234
+
+[foobar]
+foo=bar
+`), 0o644)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		app.Action = func(ctx *cli.Context) error {
+			var cfgPath string
+			cfgPath, err = InitConfig(ctx, kexec.New(), nil)
+
+			// unknown section foobar and its keys & values should be ignored
+			// same for key=value in default section
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Expect(cfgPath).To(gomega.Equal(cfgFile.Name()))
+			gomega.Expect(Default.MTU).To(gomega.Equal(1234))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-config-file=" + cfgFile.Name(),
+		}
+		err = app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	It("rejects a config with invalid syntax", func() {
+		err := ioutil.WriteFile(cfgFile.Name(), []byte(`[default]
+mtu=1234
+
+[foobar
+foo=bar
+`), 0o644)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		app.Action = func(ctx *cli.Context) error {
+			_, err = InitConfig(ctx, kexec.New(), nil)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-config-file=" + cfgFile.Name(),
+		}
+		err = app.Run(cliArgs)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	Describe("OvnDBAuth operations", func() {
+		var certFile, keyFile, caFile string
+
+		BeforeEach(func() {
+			var err error
+			certFile, _, err = createTempFile("cert.crt")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			keyFile, _, err = createTempFile("priv.key")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			caFile = filepath.Join(tmpDir, "ca.crt")
+		})
+
+		AfterEach(func() {
+			err := os.Remove(certFile)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			err = os.Remove(keyFile)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			os.Remove(caFile)
+		})
+
+		const (
+			nbURL             string = "ssl:1.2.3.4:6641"
+			sbURL             string = "ssl:1.2.3.4:6642"
+			nbDummyCommonName        = "cfg-nbcommonname"
+			sbDummyCommonName        = "cfg-sbcommonname"
+		)
+
+		It("configures client northbound SSL correctly", func() {
+			fexec := ovntest.NewFakeExec()
+			fexec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --db=" + nbURL + " --timeout=5 --private-key=" + keyFile + " --certificate=" + certFile + " --bootstrap-ca-cert=" + caFile + " list nb_global",
+			})
+
+			cliConfig := &OvnAuthConfig{
+				Address:        nbURL,
+				PrivKey:        keyFile,
+				Cert:           certFile,
+				CACert:         caFile,
+				CertCommonName: nbDummyCommonName,
+			}
+			a, err := buildOvnAuth(fexec, true, cliConfig, &OvnAuthConfig{}, true)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(a.Scheme).To(gomega.Equal(OvnDBSchemeSSL))
+			gomega.Expect(a.PrivKey).To(gomega.Equal(keyFile))
+			gomega.Expect(a.Cert).To(gomega.Equal(certFile))
+			gomega.Expect(a.CACert).To(gomega.Equal(caFile))
+			gomega.Expect(a.Address).To(gomega.Equal(nbURL))
+			gomega.Expect(a.CertCommonName).To(gomega.Equal(nbDummyCommonName))
+			gomega.Expect(a.northbound).To(gomega.BeTrue())
+
+			gomega.Expect(a.GetURL()).To(gomega.Equal(nbURL))
+			err = a.SetDBAuth()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(fexec.CalledMatchesExpected()).To(gomega.BeTrue(), fexec.ErrorDesc)
+		})
+
 		It("configures client southbound SSL correctly", func() {
 			fexec := ovntest.NewFakeExec()
 			fexec.AddFakeCmdsNoOutputNoError([]string{
@@ -1840,6 +2615,94 @@ foo=bar
 			gomega.Expect(err.Error()).To(gomega.ContainSubstring("ovnkube-node-mgmt-port-netdev or ovnkube-node-mgmt-port-dp-resource-name must be provided"))
 		})
 
+		It("Fails if management port is provided and ovnkube node mode is standalone-host", func() {
+			cliConfig := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode:           types.NodeModeStandaloneHost,
+					MgmtPortNetdev: "enp1s0f0v0",
+				},
+			}
+			err := buildOvnKubeNodeConfig(nil, &cliConfig, &config{})
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("ovnkube-node-mgmt-port-netdev or ovnkube-node-mgmt-port-dp-resource-name must not be provided"))
+		})
+
+		It("Succeeds with the standalone-host mode", func() {
+			cliConfig := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode: types.NodeModeStandaloneHost,
+				},
+			}
+			err := buildOvnKubeNodeConfig(nil, &cliConfig, &config{})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(OvnKubeNode.Mode).To(gomega.Equal(types.NodeModeStandaloneHost))
+		})
+
+		It("Succeeds with the afxdp datapath", func() {
+			cliConfig := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode:     types.NodeModeFull,
+					Datapath: types.NodeDatapathAFXDP,
+				},
+			}
+			file := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode: types.NodeModeFull,
+				},
+			}
+			err := buildOvnKubeNodeConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(OvnKubeNode.Datapath).To(gomega.Equal(types.NodeDatapathAFXDP))
+			gomega.Expect(IsUserspaceDatapath()).To(gomega.BeTrue())
+		})
+
+		It("Fails with unsupported datapath", func() {
+			cliConfig := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode:     types.NodeModeFull,
+					Datapath: "invalid",
+				},
+			}
+			file := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode: types.NodeModeFull,
+				},
+			}
+			err := buildOvnKubeNodeConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("unexpected ovnkube-node-datapath"))
+		})
+
+		It("Fails if the dpdk datapath is used with ovnkube node mode dpu", func() {
+			cliConfig := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode:     types.NodeModeDPU,
+					Datapath: types.NodeDatapathDPDK,
+				},
+			}
+			err := buildOvnKubeNodeConfig(nil, &cliConfig, &config{})
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("ovnkube-node-datapath dpdk is not supported with ovnkube-node mode dpu"))
+		})
+
+		It("Fails if UDP aggregation is enabled with the userspace datapath", func() {
+			Default.EnableUDPAggregation = true
+			cliConfig := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode:     types.NodeModeFull,
+					Datapath: types.NodeDatapathAFXDP,
+				},
+			}
+			file := config{
+				OvnKubeNode: OvnKubeNodeConfig{
+					Mode: types.NodeModeFull,
+				},
+			}
+			err := buildOvnKubeNodeConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("enable-udp-aggregation is not supported"))
+		})
+
 		It("Succeeds if management netdev provided in the full mode", func() {
 			cliConfig := config{
 				OvnKubeNode: OvnKubeNodeConfig{
@@ -1872,4 +2735,117 @@ foo=bar
 			gomega.Expect(err).ToNot(gomega.HaveOccurred())
 		})
 	})
+
+	Describe("OVN Kubernetes feature config", func() {
+		It("Fails if standalone hosts are enabled without multi-network and interconnect", func() {
+			cliConfig := config{
+				OVNKubernetesFeature: OVNKubernetesFeatureConfig{
+					NodeNetworkStateBackend: NodeNetworkStateBackendAnnotation,
+					EnableStandaloneHosts:   true,
+					EnableMultiNetwork:      true,
+				},
+			}
+			file := config{
+				OVNKubernetesFeature: OVNKubernetesFeatureConfig{
+					NodeNetworkStateBackend: NodeNetworkStateBackendAnnotation,
+				},
+			}
+			err := buildOVNKubernetesFeatureConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("standalone hosts require multi-network and interconnect"))
+
+			cliConfig.OVNKubernetesFeature.EnableInterconnect = true
+			err = buildOVNKubernetesFeatureConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(OVNKubernetesFeature.EnableStandaloneHosts).To(gomega.BeTrue())
+		})
+
+		It("Fails if observability is enabled with an invalid drop sampling percentage", func() {
+			cliConfig := config{
+				OVNKubernetesFeature: OVNKubernetesFeatureConfig{
+					NodeNetworkStateBackend:             NodeNetworkStateBackendAnnotation,
+					EnableObservability:                 true,
+					ObservabilityDropSamplingPercentage: 101,
+					ObservabilityCollectorPort:          4741,
+				},
+			}
+			file := config{
+				OVNKubernetesFeature: OVNKubernetesFeatureConfig{
+					NodeNetworkStateBackend: NodeNetworkStateBackendAnnotation,
+				},
+			}
+			err := buildOVNKubernetesFeatureConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("invalid observability drop sampling percentage 101"))
+
+			cliConfig.OVNKubernetesFeature.ObservabilityDropSamplingPercentage = 10
+			err = buildOVNKubernetesFeatureConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(OVNKubernetesFeature.ObservabilityDropSamplingPercentage).To(gomega.Equal(10))
+		})
+
+		It("Fails if the egress IP cloud provider is unknown", func() {
+			cliConfig := config{
+				OVNKubernetesFeature: OVNKubernetesFeatureConfig{
+					NodeNetworkStateBackend: NodeNetworkStateBackendAnnotation,
+					EgressIPCloudProvider:   "aws",
+				},
+			}
+			file := config{
+				OVNKubernetesFeature: OVNKubernetesFeatureConfig{
+					NodeNetworkStateBackend: NodeNetworkStateBackendAnnotation,
+				},
+			}
+			err := buildOVNKubernetesFeatureConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("invalid egress IP cloud provider \"aws\""))
+
+			cliConfig.OVNKubernetesFeature.EgressIPCloudProvider = EgressIPCloudProviderAzure
+			err = buildOVNKubernetesFeatureConfig(nil, &cliConfig, &file)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(OVNKubernetesFeature.EgressIPCloudProvider).To(gomega.Equal(EgressIPCloudProviderAzure))
+		})
+	})
+
+	Describe("Gateway config", func() {
+		It("Parses the SNAT conntrack zone range", func() {
+			min, max, err := parseConntrackZoneRange("64100-64999")
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(min).To(gomega.Equal(64100))
+			gomega.Expect(max).To(gomega.Equal(64999))
+
+			for _, zoneRange := range []string{"64100", "a-64999", "0-100", "200-100", "100-65536", "63000-64001", "64003-64010"} {
+				_, _, err = parseConntrackZoneRange(zoneRange)
+				gomega.Expect(err).To(gomega.HaveOccurred(), zoneRange)
+			}
+		})
+	})
+
+	Describe("Metrics config", func() {
+		It("Fails if the auth is enabled without TLS", func() {
+			cliConfig := config{
+				Metrics: MetricsConfig{
+					EnableAuth: true,
+					ClientCA:   "/etc/ovn/metrics-client-ca.crt",
+				},
+			}
+			file := config{}
+			err := buildMetricsConfig(&cliConfig, &file)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("metrics-enable-auth requires node-server-cert and node-server-privkey"))
+
+			cliConfig.Metrics.NodeServerCert = "/etc/ovn/metrics.crt"
+			cliConfig.Metrics.NodeServerPrivKey = "/etc/ovn/metrics.key"
+			err = buildMetricsConfig(&cliConfig, &file)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(Metrics.EnableAuth).To(gomega.BeTrue())
+			gomega.Expect(Metrics.ClientCA).To(gomega.Equal("/etc/ovn/metrics-client-ca.crt"))
+
+			cliConfig.Metrics.EnableAuth = false
+			Metrics.EnableAuth = false
+			err = buildMetricsConfig(&cliConfig, &file)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(err.Error()).To(gomega.ContainSubstring("metrics-client-ca requires metrics-enable-auth"))
+		})
+	})
 })
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// IPv6OnlyValidationOff does not validate the enabled features of
+	// IPv6-only clusters, which might not work
+	IPv6OnlyValidationOff = "off"
+	// IPv6OnlyValidationError refuses to start an IPv6-only cluster with
+	// features enabled that do not support IPv6-only operation, the default
+	IPv6OnlyValidationError = "error"
+	// IPv6OnlyValidationDisable disables the enabled features of an
+	// IPv6-only cluster that do not support IPv6-only operation, and refuses
+	// to start if one of them can't be disabled
+	IPv6OnlyValidationDisable = "disable"
+)
+
+// IPv6OnlyFeatureCompatibility describes whether a feature supports IPv6-only
+// operation
+type IPv6OnlyFeatureCompatibility struct {
+	Feature   string `json:"feature"`
+	Enabled   bool   `json:"enabled"`
+	Supported bool   `json:"supported"`
+	// Disabled is set when the feature was enabled but got disabled because it
+	// does not support IPv6-only operation
+	Disabled bool   `json:"disabled,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// IPv6OnlyCompatibility is the IPv6-only compatibility matrix of the features
+// of the cluster
+type IPv6OnlyCompatibility struct {
+	// IPv6Only is set when the cluster only has IPv6 subnets
+	IPv6Only   bool                           `json:"ipv6Only"`
+	Validation string                         `json:"validation"`
+	Features   []IPv6OnlyFeatureCompatibility `json:"features"`
+}
+
+// ipv6OnlyFeature is a feature whose IPv6-only support is validated. enabled
+// points to the config option that enables the feature, or is nil if the
+// feature can't be disabled. check returns why the feature can't run in the
+// IPv6-only cluster as configured, or nil if it can.
+type ipv6OnlyFeature struct {
+	name    string
+	enabled *bool
+	check   func() error
+}
+
+func getIPv6OnlyFeatures() []ipv6OnlyFeature {
+	return []ipv6OnlyFeature{
+		{name: "services", check: checkIPv6OnlyServices},
+		{name: "egress-firewall", enabled: &OVNKubernetesFeature.EnableEgressFirewall},
+		{name: "egress-ip", enabled: &OVNKubernetesFeature.EnableEgressIP, check: checkIPv6OnlyEgressIP},
+		{name: "egress-qos", enabled: &OVNKubernetesFeature.EnableEgressQoS},
+		{name: "egress-service", enabled: &OVNKubernetesFeature.EnableEgressService, check: checkIPv6OnlyServices},
+		{name: "multicast", enabled: &EnableMulticast},
+		{name: "admin-network-policy", enabled: &OVNKubernetesFeature.EnableAdminNetworkPolicy},
+		{name: "multi-network", enabled: &OVNKubernetesFeature.EnableMultiNetwork, check: checkIPv6OnlyMultiNetwork},
+		{name: "multi-external-gateway", enabled: &OVNKubernetesFeature.EnableMultiExternalGateway},
+		{name: "hybrid-overlay", enabled: &HybridOverlay.Enabled, check: checkIPv6OnlyHybridOverlay},
+	}
+}
+
+// checkIPv6OnlyServices checks that the services can be reached from the
+// hosts, through the IPv6 masquerade IPs, and have IPv6 cluster IPs
+func checkIPv6OnlyServices() error {
+	hasIPv6ServiceCIDR := false
+	for _, cidr := range Kubernetes.ServiceCIDRs {
+		hasIPv6ServiceCIDR = hasIPv6ServiceCIDR || utilnet.IsIPv6CIDR(cidr)
+	}
+	if !hasIPv6ServiceCIDR {
+		return fmt.Errorf("no IPv6 service CIDR")
+	}
+	if Gateway.MasqueradeIPs.V6HostMasqueradeIP == nil || Gateway.MasqueradeIPs.V6OVNMasqueradeIP == nil {
+		return fmt.Errorf("no IPv6 masquerade IPs")
+	}
+	return nil
+}
+
+// checkIPv6OnlyEgressIP checks that the traffic of the pods can be rerouted to
+// the egress nodes through the IPv6 join subnet
+func checkIPv6OnlyEgressIP() error {
+	_, joinSubnet, err := net.ParseCIDR(Gateway.V6JoinSubnet)
+	if err != nil || !utilnet.IsIPv6CIDR(joinSubnet) {
+		return fmt.Errorf("no IPv6 join subnet")
+	}
+	return nil
+}
+
+// checkIPv6OnlyMultiNetwork checks that the zones of the user defined
+// networks can be interconnected through the IPv6 transit switch subnet
+func checkIPv6OnlyMultiNetwork() error {
+	if !OVNKubernetesFeature.EnableInterconnect {
+		return nil
+	}
+	_, transitSwitchSubnet, err := net.ParseCIDR(ClusterManager.V6TransitSwitchSubnet)
+	if err != nil || !utilnet.IsIPv6CIDR(transitSwitchSubnet) {
+		return fmt.Errorf("no IPv6 transit switch subnet")
+	}
+	return nil
+}
+
+// checkIPv6OnlyHybridOverlay checks that the pods have IPv4 addresses, the
+// only ones the hybrid overlay node datapath handles
+func checkIPv6OnlyHybridOverlay() error {
+	if !IPv4Mode {
+		return fmt.Errorf("the hybrid overlay node datapath only handles IPv4 pods")
+	}
+	return nil
+}
+
+var ipv6OnlyCompatibility = struct {
+	sync.RWMutex
+	matrix IPv6OnlyCompatibility
+}{}
+
+// GetIPv6OnlyCompatibility returns the IPv6-only compatibility matrix of the
+// features of the cluster, as computed when the configuration was completed
+func GetIPv6OnlyCompatibility() IPv6OnlyCompatibility {
+	ipv6OnlyCompatibility.RLock()
+	defer ipv6OnlyCompatibility.RUnlock()
+	matrix := ipv6OnlyCompatibility.matrix
+	matrix.Features = append([]IPv6OnlyFeatureCompatibility(nil), matrix.Features...)
+	return matrix
+}
+
+// completeIPv6OnlyConfig computes the IPv6-only compatibility matrix and, in
+// IPv6-only clusters, validates the enabled features according to the
+// configured validation mode. Must be called once the IP mode is known.
+func completeIPv6OnlyConfig() error {
+	ipv6Only := IPv6Mode && !IPv4Mode
+	validation := OVNKubernetesFeature.IPv6OnlyValidation
+	if validation == "" {
+		validation = IPv6OnlyValidationError
+	}
+	matrix := IPv6OnlyCompatibility{
+		IPv6Only:   ipv6Only,
+		Validation: validation,
+	}
+	var unsupported []string
+	for _, feature := range getIPv6OnlyFeatures() {
+		compatibility := IPv6OnlyFeatureCompatibility{
+			Feature:   feature.name,
+			Enabled:   feature.enabled == nil || *feature.enabled,
+			Supported: true,
+		}
+		// the checks only apply to IPv6-only clusters
+		if ipv6Only && feature.check != nil {
+			if err := feature.check(); err != nil {
+				compatibility.Supported = false
+				compatibility.Notes = err.Error()
+			}
+		}
+		if ipv6Only && compatibility.Enabled && !compatibility.Supported {
+			switch {
+			case validation == IPv6OnlyValidationDisable && feature.enabled != nil:
+				klog.Warningf("Disabling %s as it does not support IPv6-only clusters: %s", feature.name,
+					compatibility.Notes)
+				*feature.enabled = false
+				compatibility.Enabled = false
+				compatibility.Disabled = true
+			case validation != IPv6OnlyValidationOff:
+				unsupported = append(unsupported, fmt.Sprintf("%s (%s)", feature.name, compatibility.Notes))
+			}
+		}
+		matrix.Features = append(matrix.Features, compatibility)
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("features enabled that do not support IPv6-only clusters: %s", strings.Join(unsupported, ", "))
+	}
+
+	ipv6OnlyCompatibility.Lock()
+	defer ipv6OnlyCompatibility.Unlock()
+	ipv6OnlyCompatibility.matrix = matrix
+	return nil
+}
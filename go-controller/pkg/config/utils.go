@@ -166,6 +166,15 @@ const (
 	configSubnetService    configSubnetType = "service subnet"
 	configSubnetHybrid     configSubnetType = "hybrid overlay subnet"
 	configSubnetMasquerade configSubnetType = "masquerade subnet"
+	// the transit switch subnets of both IP families are configured whatever
+	// the IP families of the cluster
+	configSubnetTransitSwitch configSubnetType = "transit switch subnet"
+	// the IP families of the load balancer IP pools are checked against
+	// those of the cluster instead of defining them
+	configSubnetLoadBalancer configSubnetType = "load balancer IP pool"
+	// the IP families of the health check subnets are checked against those
+	// of the cluster instead of defining them
+	configSubnetHealthCheck configSubnetType = "health check subnet"
 )
 
 type configSubnet struct {
@@ -191,7 +200,8 @@ func newConfigSubnets() *configSubnets {
 // append adds a single subnet to cs
 func (cs *configSubnets) append(subnetType configSubnetType, subnet *net.IPNet) {
 	cs.subnets = append(cs.subnets, configSubnet{subnetType: subnetType, subnet: subnet})
-	if subnetType != configSubnetJoin && subnetType != configSubnetMasquerade {
+	if subnetType != configSubnetJoin && subnetType != configSubnetMasquerade && subnetType != configSubnetLoadBalancer &&
+		subnetType != configSubnetHealthCheck && subnetType != configSubnetTransitSwitch {
 		if utilnet.IsIPv6CIDR(subnet) {
 			cs.v6[subnetType] = true
 		} else {
@@ -215,6 +225,49 @@ func (cs *configSubnets) checkForOverlaps() error {
 	return nil
 }
 
+// configuredSubnets are the subnets of the completed configuration
+var configuredSubnets *configSubnets
+
+// ValidateAdditionalClusterSubnets validates cluster subnets added to the
+// default network at runtime: they must be of an IP family of the cluster and
+// must overlap neither each other nor any configured subnet.
+func ValidateAdditionalClusterSubnets(clusterSubnets []CIDRNetworkEntry) error {
+	allSubnets := newConfigSubnets()
+	if configuredSubnets != nil {
+		allSubnets.subnets = append(allSubnets.subnets, configuredSubnets.subnets...)
+	}
+	for _, clusterSubnet := range clusterSubnets {
+		if utilnet.IsIPv6CIDR(clusterSubnet.CIDR) && !IPv6Mode || !utilnet.IsIPv6CIDR(clusterSubnet.CIDR) && !IPv4Mode {
+			return fmt.Errorf("illegal network configuration: cluster subnet %q is not of an IP family of the cluster",
+				clusterSubnet.CIDR.String())
+		}
+		allSubnets.append(configSubnetCluster, clusterSubnet.CIDR)
+	}
+	return allSubnets.checkForOverlaps()
+}
+
+// ValidateHybridOverlayClusterSubnets validates hybrid overlay cluster subnets
+// enabled at runtime: they must be IPv4 and must overlap neither each other nor
+// any configured subnet other than the configured hybrid overlay subnets.
+func ValidateHybridOverlayClusterSubnets(clusterSubnets []CIDRNetworkEntry) error {
+	allSubnets := newConfigSubnets()
+	if configuredSubnets != nil {
+		for _, subnet := range configuredSubnets.subnets {
+			if subnet.subnetType != configSubnetHybrid {
+				allSubnets.subnets = append(allSubnets.subnets, subnet)
+			}
+		}
+	}
+	for _, clusterSubnet := range clusterSubnets {
+		if utilnet.IsIPv6CIDR(clusterSubnet.CIDR) {
+			return fmt.Errorf("illegal network configuration: hybrid overlay subnet %q is not IPv4",
+				clusterSubnet.CIDR.String())
+		}
+		allSubnets.append(configSubnetHybrid, clusterSubnet.CIDR)
+	}
+	return allSubnets.checkForOverlaps()
+}
+
 func (cs *configSubnets) describeSubnetType(subnetType configSubnetType) string {
 	ipv4 := cs.v4[subnetType]
 	ipv6 := cs.v6[subnetType]
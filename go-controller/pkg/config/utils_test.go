@@ -348,3 +348,103 @@ func TestParseFlowCollectors_DeduplicateEquivalentEntries(t *testing.T) {
 		t.Errorf("parsed hostPorts returned unexpected results: %+v", hp)
 	}
 }
+
+func TestValidateAdditionalClusterSubnets(t *testing.T) {
+	tests := []struct {
+		name        string
+		subnets     string
+		shouldError bool
+	}{
+		{
+			name:    "non-overlapping",
+			subnets: "10.132.0.0/14/23,10.136.0.0/14/23",
+		},
+		{
+			name:        "overlapping the cluster subnet",
+			subnets:     "10.130.0.0/15/23",
+			shouldError: true,
+		},
+		{
+			name:        "overlapping the service subnet",
+			subnets:     "172.16.0.0/16/24",
+			shouldError: true,
+		},
+		{
+			name:        "overlapping the join subnet",
+			subnets:     "100.64.0.0/16/24",
+			shouldError: true,
+		},
+		{
+			name:        "overlapping each other",
+			subnets:     "10.132.0.0/14/23,10.134.0.0/15/23",
+			shouldError: true,
+		},
+		{
+			name:        "IP family not of the cluster",
+			subnets:     "fd00:10:132::/48/64",
+			shouldError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := PrepareTestConfig(); err != nil {
+				t.Fatal(err)
+			}
+			subnets, err := ParseClusterSubnetEntries(tc.subnets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ValidateAdditionalClusterSubnets(subnets)
+			if tc.shouldError && err == nil {
+				t.Errorf("expected an error for %s", tc.subnets)
+			} else if !tc.shouldError && err != nil {
+				t.Errorf("unexpected error for %s: %v", tc.subnets, err)
+			}
+		})
+	}
+}
+
+func TestValidateHybridOverlayClusterSubnets(t *testing.T) {
+	tests := []struct {
+		name        string
+		subnets     string
+		shouldError bool
+	}{
+		{
+			name:    "non-overlapping",
+			subnets: "11.1.0.0/16/24,11.2.0.0/16/24",
+		},
+		{
+			name:        "overlapping the cluster subnet",
+			subnets:     "10.130.0.0/15/23",
+			shouldError: true,
+		},
+		{
+			name:        "overlapping each other",
+			subnets:     "11.0.0.0/8/24,11.2.0.0/16/24",
+			shouldError: true,
+		},
+		{
+			name:        "IPv6",
+			subnets:     "fd00:11::/48/64",
+			shouldError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := PrepareTestConfig(); err != nil {
+				t.Fatal(err)
+			}
+			subnets, err := ParseClusterSubnetEntries(tc.subnets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ValidateHybridOverlayClusterSubnets(subnets)
+			if tc.shouldError && err == nil {
+				t.Errorf("expected an error for %s", tc.subnets)
+			} else if !tc.shouldError && err != nil {
+				t.Errorf("unexpected error for %s: %v", tc.subnets, err)
+			}
+		})
+	}
+}
@@ -33,6 +33,72 @@ type EgressIP struct {
 type EgressIPStatus struct {
 	// The list of assigned egress IPs and their corresponding node assignment.
 	Items []EgressIPStatusItem `json:"items"`
+	// Conditions of the EgressIP, like QuotaExceeded.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Interfaces lists the interface each assigned egress IP is programmed
+	// on, as reported by its egress node.
+	// +optional
+	Interfaces []EgressIPInterfaceStatus `json:"interfaces,omitempty"`
+	// LastFailoverTime is the last time an egress IP moved from an egress
+	// node to another.
+	// +optional
+	LastFailoverTime *metav1.Time `json:"lastFailoverTime,omitempty"`
+	// Transitions is the history of the assignments of the egress IPs to the
+	// egress nodes, oldest first, limited to the last 10 transitions.
+	// +optional
+	Transitions []EgressIPTransition `json:"transitions,omitempty"`
+	// Standbys lists the node each assigned egress IP fails over to, with
+	// its SNAT pre-programmed, when the standby feature is enabled.
+	// +optional
+	Standbys []EgressIPStandbyStatus `json:"standbys,omitempty"`
+}
+
+const (
+	// EgressIPConditionQuotaExceeded is true when some egress IPs of the
+	// EgressIP are not assigned because a namespace it selects has no egress
+	// IP quota left.
+	EgressIPConditionQuotaExceeded = "QuotaExceeded"
+	// EgressIPConditionDegraded is true when some egress IPs of the EgressIP
+	// are assigned but their egress node did not report them programmed on
+	// an interface yet.
+	EgressIPConditionDegraded = "Degraded"
+)
+
+// The interface an assigned egress IP is programmed on.
+type EgressIPInterfaceStatus struct {
+	// Assigned egress IP
+	EgressIP string `json:"egressIP"`
+	// Assigned node name
+	Node string `json:"node"`
+	// Interface of the node the egress IP is programmed on
+	Interface string `json:"interface"`
+}
+
+// The standby node of an assigned egress IP.
+type EgressIPStandbyStatus struct {
+	// Assigned egress IP
+	EgressIP string `json:"egressIP"`
+	// Standby node name
+	Node string `json:"node"`
+}
+
+// A change of the egress node of an egress IP.
+type EgressIPTransition struct {
+	// Egress IP
+	EgressIP string `json:"egressIP"`
+	// FromNode is the node the egress IP was assigned to, empty if it was not
+	// assigned.
+	// +optional
+	FromNode string `json:"fromNode,omitempty"`
+	// ToNode is the node the egress IP is assigned to, empty if it is no
+	// longer assigned.
+	// +optional
+	ToNode string `json:"toNode,omitempty"`
+	// Time of the transition
+	Time metav1.Time `json:"time"`
 }
 
 // The per node status, for those egress IPs who have been assigned.
@@ -48,8 +114,10 @@ type EgressIPStatusItem struct {
 // EgressIPSpec is a desired state description of EgressIP.
 type EgressIPSpec struct {
 	// EgressIPs is the list of egress IP addresses requested. Can be IPv4 and/or IPv6.
-	// This field is mandatory.
-	EgressIPs []string `json:"egressIPs"`
+	// This field is mandatory unless FromPool is set, in which case it holds
+	// the egress IPs allocated from the pool.
+	// +optional
+	EgressIPs []string `json:"egressIPs,omitempty"`
 	// NamespaceSelector applies the egress IP only to the namespace(s) whose label
 	// matches this definition. This field is mandatory.
 	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
@@ -62,8 +130,152 @@ type EgressIPSpec struct {
 	// match this pod selector.
 	// +optional
 	PodSelector metav1.LabelSelector `json:"podSelector,omitempty"`
+	// Placement constrains the nodes the egress IPs can be assigned to and
+	// spreads them across topology domains for high availability. This field
+	// is optional, and in case it is not set: the egress IPs can be assigned
+	// to any egress node.
+	// +optional
+	Placement *EgressIPPlacement `json:"placement,omitempty"`
+	// MaxConnections is the maximum number of concurrent connections of the
+	// pods SNATed to the egress IPs on a node. New connections above the limit
+	// are rejected, or dropped for egress IPs hosted by the OVN managed
+	// network. This field is optional, and in case it is not set: the number
+	// of connections is not limited.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConnections *int32 `json:"maxConnections,omitempty"`
+	// Network is the non-OVN managed host network, as a CIDR, e.g.
+	// 192.168.100.0/24 of a dedicated egress VLAN interface, whose interface
+	// the egress IPs are assigned to, advertised from and the egress traffic
+	// of the pods leaves the nodes from. The egress IPs don't need to be part
+	// of this network, but must not be part of the OVN managed network, and
+	// are only assigned to the egress nodes with an address in this network.
+	// The egress IPs of the other IP family are not assigned. This field is
+	// optional, and in case it is not set: the egress IPs are hosted by the
+	// OVN managed network or the non-OVN managed network containing them. It
+	// is not supported on cloud platforms.
+	// +optional
+	Network string `json:"network,omitempty"`
+	// AssignmentPolicy is how the egress IPs move between the egress nodes
+	// once assigned. This field is optional, and in case it is not set: the
+	// default assignment policy of the cluster is used.
+	// +kubebuilder:validation:Enum=Sticky;Balanced;Manual
+	// +optional
+	AssignmentPolicy EgressIPAssignmentPolicy `json:"assignmentPolicy,omitempty"`
+	// FromPool requests the egress IPs from an EgressIPPool: the cluster
+	// manager allocates them from the pool and writes them to EgressIPs,
+	// replacing the ones not from the pool. This field is optional.
+	// +optional
+	FromPool *EgressIPFromPool `json:"fromPool,omitempty"`
+	// DSCP is the DSCP value the egress traffic SNATed to the egress IPs is
+	// marked with by the gateway routers of the egress nodes, for the
+	// upstream networks to prioritize or police it. This field is optional,
+	// and in case it is not set: the DSCP of the traffic is left unchanged.
+	// It is only applied to egress IPs hosted by the OVN managed network.
+	// +kubebuilder:validation:Maximum=63
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DSCP *int32 `json:"dscp,omitempty"`
+	// SourcePortRange is the range of the L4 source ports the egress traffic
+	// is SNATed to along with the egress IPs by the gateway routers of the
+	// egress nodes, for clusters sharing the same egress IPs behind a
+	// firewall to use non-overlapping source ports. This field is optional,
+	// and in case it is not set: any source port can be used. It is only
+	// applied to egress IPs hosted by the OVN managed network.
+	// +optional
+	SourcePortRange *EgressIPPortRange `json:"sourcePortRange,omitempty"`
+}
+
+// EgressIPPortRange is a range of L4 ports.
+// +kubebuilder:validation:XValidation:rule="self.end >= self.start", message="end must not be lower than start"
+type EgressIPPortRange struct {
+	// Start is the first port of the range.
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:validation:Minimum=1
+	Start int32 `json:"start"`
+	// End is the last port of the range.
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:validation:Minimum=1
+	End int32 `json:"end"`
+}
+
+// EgressIPFromPool requests egress IPs from an EgressIPPool.
+type EgressIPFromPool struct {
+	// Name is the name of the EgressIPPool.
+	Name string `json:"name"`
+	// Count is the number of egress IPs requested from each IP family of the
+	// pool. This field is optional, and in case it is not set: one egress IP
+	// of each IP family of the pool is requested.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Count int32 `json:"count,omitempty"`
 }
 
+// EgressIPAssignmentPolicy is how the egress IPs of an EgressIP move between
+// the egress nodes once assigned.
+type EgressIPAssignmentPolicy string
+
+const (
+	// EgressIPAssignmentSticky keeps the egress IPs on their node as long as
+	// it can host them: they only move when their node is deleted, unlabeled,
+	// unreachable, not ready or doesn't satisfy the placement constraints
+	// anymore.
+	EgressIPAssignmentSticky EgressIPAssignmentPolicy = "Sticky"
+	// EgressIPAssignmentBalanced also moves the egress IPs from the egress
+	// nodes hosting the most egress IPs to the ones hosting the fewest, for
+	// instance once a node is added, at a rate limited by the cluster.
+	EgressIPAssignmentBalanced EgressIPAssignmentPolicy = "Balanced"
+	// EgressIPAssignmentManual pins the egress IPs to their node, even while
+	// it is unreachable or not ready: they only move when their node is
+	// deleted, unlabeled, or doesn't satisfy the placement constraints
+	// anymore, or when their assignment is removed from the status.
+	EgressIPAssignmentManual EgressIPAssignmentPolicy = "Manual"
+)
+
+// EgressIPPlacement constrains the assignment of the egress IPs of an
+// EgressIP to egress nodes. All the constraints set must be satisfied.
+type EgressIPPlacement struct {
+	// Zones restricts the assignment to the egress nodes of these
+	// interconnect zones.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+	// NodeSelector restricts the assignment to the egress nodes whose labels
+	// match this definition, e.g. a topology.kubernetes.io/region label.
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// TopologyKey is the key of the node label, e.g.
+	// topology.kubernetes.io/zone, whose values are the topology domains the
+	// egress IPs are spread across: each egress IP is assigned to a node of
+	// the domain hosting the fewest egress IPs of this EgressIP. Egress nodes
+	// without this label are not considered.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// TopologyAffinity is whether the traffic of the selected pods may leave
+	// the cluster from the egress nodes of other topology domains than the
+	// one of their node, the domains being the values of the TopologyKey
+	// node label. This field is optional, and in case it is not set: the
+	// pods use the egress IPs assigned to any egress node. With Required,
+	// the pods only use the egress IPs assigned to the egress nodes of their
+	// topology domain, and don't use an egress IP if there is none. It is
+	// ignored if TopologyKey is not set.
+	// +kubebuilder:validation:Enum=None;Required
+	// +optional
+	TopologyAffinity EgressIPTopologyAffinity `json:"topologyAffinity,omitempty"`
+}
+
+// EgressIPTopologyAffinity is whether the pods of an EgressIP may use the
+// egress IPs assigned to the egress nodes of other topology domains.
+type EgressIPTopologyAffinity string
+
+const (
+	// EgressIPTopologyAffinityNone lets the pods use the egress IPs assigned
+	// to any egress node.
+	EgressIPTopologyAffinityNone EgressIPTopologyAffinity = "None"
+	// EgressIPTopologyAffinityRequired only lets the pods use the egress IPs
+	// assigned to the egress nodes of the topology domain of their node.
+	EgressIPTopologyAffinityRequired EgressIPTopologyAffinity = "Required"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +resource:path=egressip
 // EgressIPList is the list of EgressIPList.
@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -52,6 +53,38 @@ func (in *EgressIP) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressIPFromPool) DeepCopyInto(out *EgressIPFromPool) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressIPFromPool.
+func (in *EgressIPFromPool) DeepCopy() *EgressIPFromPool {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressIPFromPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressIPInterfaceStatus) DeepCopyInto(out *EgressIPInterfaceStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressIPInterfaceStatus.
+func (in *EgressIPInterfaceStatus) DeepCopy() *EgressIPInterfaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressIPInterfaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EgressIPList) DeepCopyInto(out *EgressIPList) {
 	*out = *in
@@ -85,6 +118,48 @@ func (in *EgressIPList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressIPPlacement) DeepCopyInto(out *EgressIPPlacement) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressIPPlacement.
+func (in *EgressIPPlacement) DeepCopy() *EgressIPPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressIPPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressIPPortRange) DeepCopyInto(out *EgressIPPortRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressIPPortRange.
+func (in *EgressIPPortRange) DeepCopy() *EgressIPPortRange {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressIPPortRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EgressIPSpec) DeepCopyInto(out *EgressIPSpec) {
 	*out = *in
@@ -95,6 +170,31 @@ func (in *EgressIPSpec) DeepCopyInto(out *EgressIPSpec) {
 	}
 	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
 	in.PodSelector.DeepCopyInto(&out.PodSelector)
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(EgressIPPlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxConnections != nil {
+		in, out := &in.MaxConnections, &out.MaxConnections
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FromPool != nil {
+		in, out := &in.FromPool, &out.FromPool
+		*out = new(EgressIPFromPool)
+		**out = **in
+	}
+	if in.DSCP != nil {
+		in, out := &in.DSCP, &out.DSCP
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SourcePortRange != nil {
+		in, out := &in.SourcePortRange, &out.SourcePortRange
+		*out = new(EgressIPPortRange)
+		**out = **in
+	}
 	return
 }
 
@@ -108,6 +208,22 @@ func (in *EgressIPSpec) DeepCopy() *EgressIPSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressIPStandbyStatus) DeepCopyInto(out *EgressIPStandbyStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressIPStandbyStatus.
+func (in *EgressIPStandbyStatus) DeepCopy() *EgressIPStandbyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressIPStandbyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EgressIPStatus) DeepCopyInto(out *EgressIPStatus) {
 	*out = *in
@@ -116,6 +232,34 @@ func (in *EgressIPStatus) DeepCopyInto(out *EgressIPStatus) {
 		*out = make([]EgressIPStatusItem, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]EgressIPInterfaceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastFailoverTime != nil {
+		in, out := &in.LastFailoverTime, &out.LastFailoverTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Transitions != nil {
+		in, out := &in.Transitions, &out.Transitions
+		*out = make([]EgressIPTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Standbys != nil {
+		in, out := &in.Standbys, &out.Standbys
+		*out = make([]EgressIPStandbyStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -144,3 +288,20 @@ func (in *EgressIPStatusItem) DeepCopy() *EgressIPStatusItem {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressIPTransition) DeepCopyInto(out *EgressIPTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressIPTransition.
+func (in *EgressIPTransition) DeepCopy() *EgressIPTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressIPTransition)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,67 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EgressIPPoolLister helps list EgressIPPools.
+// All objects returned here must be treated as read-only.
+type EgressIPPoolLister interface {
+	// List lists all EgressIPPools in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.EgressIPPool, err error)
+	// Get retrieves the EgressIPPool from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.EgressIPPool, error)
+	EgressIPPoolListerExpansion
+}
+
+// egressIPPoolLister implements the EgressIPPoolLister interface.
+type egressIPPoolLister struct {
+	indexer cache.Indexer
+}
+
+// NewEgressIPPoolLister returns a new EgressIPPoolLister.
+func NewEgressIPPoolLister(indexer cache.Indexer) EgressIPPoolLister {
+	return &egressIPPoolLister{indexer: indexer}
+}
+
+// List lists all EgressIPPools in the indexer.
+func (s *egressIPPoolLister) List(selector labels.Selector) (ret []*v1.EgressIPPool, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.EgressIPPool))
+	})
+	return ret, err
+}
+
+// Get retrieves the EgressIPPool from the index for a given name.
+func (s *egressIPPoolLister) Get(name string) (*v1.EgressIPPool, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("egressippool"), name)
+	}
+	return obj.(*v1.EgressIPPool), nil
+}
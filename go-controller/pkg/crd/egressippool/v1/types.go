@@ -0,0 +1,85 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EgressIPPoolConditionExhausted is true when some EgressIPs requesting
+	// egress IPs from the pool didn't get all of them because the pool has no
+	// free IP left.
+	EgressIPPoolConditionExhausted = "Exhausted"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +resource:path=egressippool
+// +kubebuilder:resource:shortName=eippool,scope=Cluster
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="CIDRs",type=string,JSONPath=".spec.cidrs[*]"
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=".status.size"
+// +kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=".status.allocated"
+// EgressIPPool holds ranges of egress IPs which the cluster manager allocates
+// to the EgressIPs requesting egress IPs from the pool, instead of listing
+// them.
+type EgressIPPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of EgressIPPool.
+	Spec EgressIPPoolSpec `json:"spec"`
+	// Observed usage of EgressIPPool. Read-only.
+	// +optional
+	Status EgressIPPoolStatus `json:"status,omitempty"`
+}
+
+// EgressIPPoolSpec is a desired state description of EgressIPPool.
+type EgressIPPoolSpec struct {
+	// CIDRs are the ranges of the egress IPs of the pool, e.g.
+	// 172.18.0.32/28. Can be IPv4 and/or IPv6. The network and broadcast
+	// addresses of the IPv4 ranges are not allocated. This field is
+	// mandatory.
+	// +kubebuilder:validation:MinItems=1
+	CIDRs []string `json:"cidrs"`
+	// ExcludedIPs are the IPs of the ranges which are not allocated, e.g. the
+	// ones already used by hosts. This field is optional.
+	// +optional
+	ExcludedIPs []string `json:"excludedIPs,omitempty"`
+}
+
+// EgressIPPoolStatus is the usage of the pool.
+type EgressIPPoolStatus struct {
+	// Size is the number of IPs of the pool that can be allocated, capped at
+	// 2^63-1 for the large IPv6 ranges.
+	Size int64 `json:"size"`
+	// Allocated is the number of egress IPs allocated from the pool.
+	Allocated int `json:"allocated"`
+	// Allocations are the egress IPs allocated to each EgressIP.
+	// +optional
+	Allocations []EgressIPPoolAllocation `json:"allocations,omitempty"`
+	// Conditions of the EgressIPPool, like Exhausted.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// EgressIPPoolAllocation are the egress IPs allocated to an EgressIP.
+type EgressIPPoolAllocation struct {
+	// EgressIP is the name of the EgressIP.
+	EgressIP string `json:"egressIP"`
+	// IPs are the egress IPs allocated to it.
+	IPs []string `json:"ips"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=egressippool
+// EgressIPPoolList is the list of EgressIPPool.
+type EgressIPPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of EgressIPPool.
+	Items []EgressIPPool `json:"items"`
+}
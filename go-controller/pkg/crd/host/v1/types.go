@@ -0,0 +1,77 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +resource:path=host
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Network",type=string,JSONPath=".spec.network"
+// +kubebuilder:printcolumn:name="Zone",type=string,JSONPath=".spec.zone"
+// +kubebuilder:printcolumn:name="IPs",type=string,JSONPath=".status.ipAddresses"
+// Host is a CRD describing a host that is not a Kubernetes node, e.g. a bare
+// metal server or a legacy VM, attached to a layer2 network through the
+// ovnkube-node standalone host agent running on it. The Host is named after
+// the identity the agent is started with.
+type Host struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the host.
+	Spec HostSpec `json:"spec"`
+	// Addresses allocated to the host on the network.
+	// +optional
+	Status HostStatus `json:"status,omitempty"`
+}
+
+// HostSpec defines the network the host attaches to and how to reach it.
+type HostSpec struct {
+	// Network is the name of the layer2 network the host attaches to, as set
+	// in the network attachment definitions of the network.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf", message="Network is immutable"
+	Network string `json:"network"`
+	// Zone is the OVN zone whose databases the ovn-controller of the host
+	// connects to. Defaults to the "global" zone.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// ChassisID is the OVN chassis ID of the host. It is set by the standalone
+	// host agent if not provided.
+	// +optional
+	ChassisID string `json:"chassisID,omitempty"`
+	// EncapIP is the IP address used for the tunnels to the host. It is set by
+	// the standalone host agent if not provided.
+	// +optional
+	EncapIP string `json:"encapIP,omitempty"`
+}
+
+// HostStatus holds the addresses allocated to the host on the network.
+type HostStatus struct {
+	// IPAddresses are the IP addresses, in CIDR notation, allocated to the
+	// host on the network.
+	// +optional
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+	// MACAddress is the MAC address allocated to the host on the network.
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+	// TunnelID is the tunnel key of the logical port of the host on the
+	// network.
+	// +optional
+	TunnelID int `json:"tunnelID,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=host
+// HostList is the list of Host.
+type HostList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of Host.
+	Items []Host `json:"items"`
+}
@@ -0,0 +1,67 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipfamilyconversion/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// IPFamilyConversionLister helps list IPFamilyConversions.
+// All objects returned here must be treated as read-only.
+type IPFamilyConversionLister interface {
+	// List lists all IPFamilyConversions in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.IPFamilyConversion, err error)
+	// Get retrieves the IPFamilyConversion from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.IPFamilyConversion, error)
+	IPFamilyConversionListerExpansion
+}
+
+// iPFamilyConversionLister implements the IPFamilyConversionLister interface.
+type iPFamilyConversionLister struct {
+	indexer cache.Indexer
+}
+
+// NewIPFamilyConversionLister returns a new IPFamilyConversionLister.
+func NewIPFamilyConversionLister(indexer cache.Indexer) IPFamilyConversionLister {
+	return &iPFamilyConversionLister{indexer: indexer}
+}
+
+// List lists all IPFamilyConversions in the indexer.
+func (s *iPFamilyConversionLister) List(selector labels.Selector) (ret []*v1.IPFamilyConversion, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.IPFamilyConversion))
+	})
+	return ret, err
+}
+
+// Get retrieves the IPFamilyConversion from the index for a given name.
+func (s *iPFamilyConversionLister) Get(name string) (*v1.IPFamilyConversion, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("ipfamilyconversion"), name)
+	}
+	return obj.(*v1.IPFamilyConversion), nil
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// IPFamilyConversionInProgress is the phase of a conversion rolling
+	// through the nodes
+	IPFamilyConversionInProgress = "InProgress"
+	// IPFamilyConversionPaused is the phase of a conversion paused through
+	// the spec
+	IPFamilyConversionPaused = "Paused"
+	// IPFamilyConversionCompleted is the phase of a conversion done for all
+	// the nodes
+	IPFamilyConversionCompleted = "Completed"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +resource:path=ipfamilyconversion
+// +kubebuilder:resource:shortName=ifc,scope=Cluster
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="IP Families",type=string,JSONPath=".status.ipFamilies"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Converted",type=integer,JSONPath=".status.convertedNodes"
+// +kubebuilder:printcolumn:name="Total",type=integer,JSONPath=".status.totalNodes"
+// IPFamilyConversion reports the progress of the conversion of the nodes of
+// the default network from dual-stack to single-stack, or back, rolled out by
+// the cluster manager in batches of nodes after the IP families of the
+// cluster subnets changed. There is a single IPFamilyConversion named
+// "default".
+type IPFamilyConversion struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the conversion.
+	// +optional
+	Spec IPFamilyConversionSpec `json:"spec,omitempty"`
+	// Observed progress of the conversion.
+	// +optional
+	Status IPFamilyConversionStatus `json:"status,omitempty"`
+}
+
+// IPFamilyConversionSpec controls the conversion.
+type IPFamilyConversionSpec struct {
+	// Paused stops the conversion of new batches of nodes until unset.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// IPFamilyConversionStatus is the progress of the conversion.
+type IPFamilyConversionStatus struct {
+	// IPFamilies are the IP families the nodes are converted to, "IPv4"
+	// and/or "IPv6".
+	// +optional
+	IPFamilies []string `json:"ipFamilies,omitempty"`
+	// Phase is "InProgress", "Paused" or "Completed".
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// TotalNodes is the number of nodes to convert.
+	TotalNodes int `json:"totalNodes"`
+	// ConvertedNodes is the number of nodes converted so far.
+	ConvertedNodes int `json:"convertedNodes"`
+	// Batch are the nodes of the batch being converted.
+	// +optional
+	Batch []string `json:"batch,omitempty"`
+	// StartTime is when the conversion started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is when all the nodes were converted.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=ipfamilyconversion
+// IPFamilyConversionList is the list of IPFamilyConversion.
+type IPFamilyConversionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of IPFamilyConversion.
+	Items []IPFamilyConversion `json:"items"`
+}
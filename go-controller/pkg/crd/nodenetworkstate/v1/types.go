@@ -0,0 +1,97 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NodeNetworkHostSubnetsAllocated is the condition type of a network of
+	// a node telling whether its host subnets are allocated
+	NodeNetworkHostSubnetsAllocated = "HostSubnetsAllocated"
+
+	// NodeNetworkReasonAllocated is the reason of a true
+	// NodeNetworkHostSubnetsAllocated condition
+	NodeNetworkReasonAllocated = "Allocated"
+	// NodeNetworkReasonAllocationFailed is the reason of a false
+	// NodeNetworkHostSubnetsAllocated condition
+	NodeNetworkReasonAllocationFailed = "AllocationFailed"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +resource:path=nodenetworkstate
+// +kubebuilder:resource:shortName=nns,scope=Cluster
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Chassis ID",type=string,JSONPath=".spec.chassisID"
+// NodeNetworkState is a CRD holding the per-node network state that is
+// otherwise stored as annotations on the Node object. There is one
+// NodeNetworkState per node, named after the node it describes. With the
+// "crd" node network state backend it is the source of truth of the host
+// subnets of the node, written by the cluster manager and read by
+// ovnkube-controller and ovnkube-node.
+type NodeNetworkState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the network state of the node.
+	Spec NodeNetworkStateSpec `json:"spec"`
+	// Observed status of the networks of the node.
+	// +optional
+	Status NodeNetworkStateStatus `json:"status,omitempty"`
+}
+
+// NodeNetworkStateSpec holds the network state of a node.
+type NodeNetworkStateSpec struct {
+	// Networks holds the state of each network on the node, keyed by network
+	// name.
+	// +optional
+	Networks map[string]NodeNetwork `json:"networks,omitempty"`
+	// ChassisID is the OVN chassis ID of the node.
+	// +optional
+	ChassisID string `json:"chassisID,omitempty"`
+	// L3GatewayConfig is the gateway configuration of the node, in the same
+	// format as the k8s.ovn.org/l3-gateway-config annotation.
+	// +optional
+	L3GatewayConfig string `json:"l3GatewayConfig,omitempty"`
+}
+
+// NodeNetwork holds the state of a single network on a node.
+type NodeNetwork struct {
+	// Subnets is the list of host subnets allocated to the node.
+	// +kubebuilder:validation:items:Format=cidr
+	// +optional
+	Subnets []string `json:"subnets,omitempty"`
+	// NetworkID is the ID of the network.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	NetworkID *int `json:"networkID,omitempty"`
+}
+
+// NodeNetworkStateStatus holds the status of the networks of a node.
+type NodeNetworkStateStatus struct {
+	// Networks holds the status of each network on the node, keyed by
+	// network name.
+	// +optional
+	Networks map[string]NodeNetworkStatus `json:"networks,omitempty"`
+}
+
+// NodeNetworkStatus holds the status of a single network on a node.
+type NodeNetworkStatus struct {
+	// Conditions of the network on the node, like HostSubnetsAllocated.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=nodenetworkstate
+// NodeNetworkStateList is the list of NodeNetworkState.
+type NodeNetworkStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of NodeNetworkState.
+	Items []NodeNetworkState `json:"items"`
+}
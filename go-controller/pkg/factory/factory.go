@@ -54,6 +54,15 @@ import (
 	adminbasedpolicyinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/informers/externalversions"
 	adminpolicybasedrouteinformer "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/informers/externalversions/adminpolicybasedroute/v1"
 
+	hostapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
+	hostscheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/clientset/versioned/scheme"
+	hostinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/informers/externalversions"
+	hostlister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/listers/host/v1"
+	nodenetworkstateapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	nodenetworkstatescheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/clientset/versioned/scheme"
+	nodenetworkstateinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/informers/externalversions"
+	nodenetworkstatelister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/listers/nodenetworkstate/v1"
+
 	kapi "k8s.io/api/core/v1"
 	discovery "k8s.io/api/discovery/v1"
 	knet "k8s.io/api/networking/v1"
@@ -88,6 +97,8 @@ type WatchFactory struct {
 	mnpFactory           mnpinformerfactory.SharedInformerFactory
 	egressServiceFactory egressserviceinformerfactory.SharedInformerFactory
 	apbRouteFactory      adminbasedpolicyinformerfactory.SharedInformerFactory
+	hostFactory          hostinformerfactory.SharedInformerFactory
+	nodeNetStateFactory  nodenetworkstateinformerfactory.SharedInformerFactory
 	informers            map[reflect.Type]*informer
 
 	stopChan chan struct{}
@@ -162,6 +173,8 @@ var (
 	LocalPodSelectorType                  reflect.Type = reflect.TypeOf(&localPodSelector{})
 	NetworkAttachmentDefinitionType       reflect.Type = reflect.TypeOf(&nadapi.NetworkAttachmentDefinition{})
 	MultiNetworkPolicyType                reflect.Type = reflect.TypeOf(&mnpapi.MultiNetworkPolicy{})
+	HostType                              reflect.Type = reflect.TypeOf(&hostapi.Host{})
+	NodeNetworkStateType                  reflect.Type = reflect.TypeOf(&nodenetworkstateapi.NodeNetworkState{})
 
 	// Resource types used in ovnk node
 	NamespaceExGwType                         reflect.Type = reflect.TypeOf(&namespaceExGw{})
@@ -208,6 +221,8 @@ func NewOVNKubeControllerWatchFactory(ovnClientset *util.OVNKubeControllerClient
 		mnpFactory:           mnpinformerfactory.NewSharedInformerFactory(ovnClientset.MultiNetworkPolicyClient, resyncInterval),
 		egressServiceFactory: egressserviceinformerfactory.NewSharedInformerFactory(ovnClientset.EgressServiceClient, resyncInterval),
 		apbRouteFactory:      adminbasedpolicyinformerfactory.NewSharedInformerFactory(ovnClientset.AdminPolicyRouteClient, resyncInterval),
+		hostFactory:          hostinformerfactory.NewSharedInformerFactory(ovnClientset.HostClient, resyncInterval),
+		nodeNetStateFactory:  nodenetworkstateinformerfactory.NewSharedInformerFactory(ovnClientset.NodeNetworkStateClient, resyncInterval),
 		informers:            make(map[reflect.Type]*informer),
 		stopChan:             make(chan struct{}),
 	}
@@ -231,6 +246,12 @@ func NewOVNKubeControllerWatchFactory(ovnClientset *util.OVNKubeControllerClient
 	if err := adminbasedpolicyapi.AddToScheme(adminbasedpolicyscheme.Scheme); err != nil {
 		return nil, err
 	}
+	if err := hostapi.AddToScheme(hostscheme.Scheme); err != nil {
+		return nil, err
+	}
+	if err := nodenetworkstateapi.AddToScheme(nodenetworkstatescheme.Scheme); err != nil {
+		return nil, err
+	}
 
 	if err := nadapi.AddToScheme(nadscheme.Scheme); err != nil {
 		return nil, err
@@ -336,6 +357,20 @@ func NewOVNKubeControllerWatchFactory(ovnClientset *util.OVNKubeControllerClient
 		wf.apbRouteFactory.K8s().V1().AdminPolicyBasedExternalRoutes().Informer()
 	}
 
+	if config.OVNKubernetesFeature.EnableStandaloneHosts {
+		wf.informers[HostType], err = newInformer(HostType, wf.hostFactory.K8s().V1().Hosts().Informer())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.OVNKubernetesFeature.NodeNetworkStateBackend == config.NodeNetworkStateBackendCRD {
+		wf.informers[NodeNetworkStateType], err = newInformer(NodeNetworkStateType, wf.nodeNetStateFactory.K8s().V1().NodeNetworkStates().Informer())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return wf, nil
 }
 
@@ -416,6 +451,24 @@ func (wf *WatchFactory) Start() error {
 		}
 	}
 
+	if config.OVNKubernetesFeature.EnableStandaloneHosts && wf.hostFactory != nil {
+		wf.hostFactory.Start(wf.stopChan)
+		for oType, synced := range waitForCacheSyncWithTimeout(wf.hostFactory, wf.stopChan) {
+			if !synced {
+				return fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
+
+	if config.OVNKubernetesFeature.NodeNetworkStateBackend == config.NodeNetworkStateBackendCRD && wf.nodeNetStateFactory != nil {
+		wf.nodeNetStateFactory.Start(wf.stopChan)
+		for oType, synced := range waitForCacheSyncWithTimeout(wf.nodeNetStateFactory, wf.stopChan) {
+			if !synced {
+				return fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -431,6 +484,7 @@ func NewNodeWatchFactory(ovnClientset *util.OVNNodeClientset, nodeName string) (
 		egressServiceFactory: egressserviceinformerfactory.NewSharedInformerFactory(ovnClientset.EgressServiceClient, resyncInterval),
 		eipFactory:           egressipinformerfactory.NewSharedInformerFactory(ovnClientset.EgressIPClient, resyncInterval),
 		apbRouteFactory:      adminbasedpolicyinformerfactory.NewSharedInformerFactory(ovnClientset.AdminPolicyRouteClient, resyncInterval),
+		nodeNetStateFactory:  nodenetworkstateinformerfactory.NewSharedInformerFactory(ovnClientset.NodeNetworkStateClient, resyncInterval),
 		informers:            make(map[reflect.Type]*informer),
 		stopChan:             make(chan struct{}),
 	}
@@ -444,6 +498,9 @@ func NewNodeWatchFactory(ovnClientset *util.OVNNodeClientset, nodeName string) (
 	if err := adminbasedpolicyapi.AddToScheme(adminbasedpolicyscheme.Scheme); err != nil {
 		return nil, err
 	}
+	if err := nodenetworkstateapi.AddToScheme(nodenetworkstatescheme.Scheme); err != nil {
+		return nil, err
+	}
 
 	var err error
 	wf.informers[PodType], err = newQueuedInformer(PodType, wf.iFactory.Core().V1().Pods().Informer(), wf.stopChan,
@@ -537,6 +594,13 @@ func NewNodeWatchFactory(ovnClientset *util.OVNNodeClientset, nodeName string) (
 		wf.apbRouteFactory.K8s().V1().AdminPolicyBasedExternalRoutes().Informer()
 	}
 
+	if config.OVNKubernetesFeature.NodeNetworkStateBackend == config.NodeNetworkStateBackendCRD {
+		wf.informers[NodeNetworkStateType], err = newInformer(NodeNetworkStateType, wf.nodeNetStateFactory.K8s().V1().NodeNetworkStates().Informer())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return wf, nil
 }
 
@@ -549,6 +613,7 @@ func NewClusterManagerWatchFactory(ovnClientset *util.OVNClusterManagerClientset
 		eipFactory:           egressipinformerfactory.NewSharedInformerFactory(ovnClientset.EgressIPClient, resyncInterval),
 		cpipcFactory:         ocpcloudnetworkinformerfactory.NewSharedInformerFactory(ovnClientset.CloudNetworkClient, resyncInterval),
 		egressServiceFactory: egressserviceinformerfactory.NewSharedInformerFactoryWithOptions(ovnClientset.EgressServiceClient, resyncInterval),
+		hostFactory:          hostinformerfactory.NewSharedInformerFactory(ovnClientset.HostClient, resyncInterval),
 		informers:            make(map[reflect.Type]*informer),
 		stopChan:             make(chan struct{}),
 	}
@@ -560,6 +625,10 @@ func NewClusterManagerWatchFactory(ovnClientset *util.OVNClusterManagerClientset
 		return nil, err
 	}
 
+	if err := hostapi.AddToScheme(hostscheme.Scheme); err != nil {
+		return nil, err
+	}
+
 	// For Services and Endpoints, pre-populate the shared Informer with one that
 	// has a label selector excluding headless services.
 	wf.iFactory.InformerFor(&kapi.Service{}, func(c kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
@@ -603,6 +672,11 @@ func NewClusterManagerWatchFactory(ovnClientset *util.OVNClusterManagerClientset
 		if err != nil {
 			return nil, err
 		}
+		// the egress IP quotas are annotated on the namespaces
+		wf.informers[NamespaceType], err = newInformer(NamespaceType, wf.iFactory.Core().V1().Namespaces().Informer())
+		if err != nil {
+			return nil, err
+		}
 	}
 	if util.PlatformTypeIsEgressIPCloudProvider() {
 		wf.informers[CloudPrivateIPConfigType], err = newInformer(CloudPrivateIPConfigType, wf.cpipcFactory.Cloud().V1().CloudPrivateIPConfigs().Informer())
@@ -625,6 +699,13 @@ func NewClusterManagerWatchFactory(ovnClientset *util.OVNClusterManagerClientset
 		}
 	}
 
+	if config.OVNKubernetesFeature.EnableStandaloneHosts {
+		wf.informers[HostType], err = newInformer(HostType, wf.hostFactory.K8s().V1().Hosts().Informer())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return wf, nil
 }
 
@@ -691,6 +772,14 @@ func getObjectMeta(objType reflect.Type, obj interface{}) (*metav1.ObjectMeta, e
 		if multinetworkpolicy, ok := obj.(*mnpapi.MultiNetworkPolicy); ok {
 			return &multinetworkpolicy.ObjectMeta, nil
 		}
+	case HostType:
+		if host, ok := obj.(*hostapi.Host); ok {
+			return &host.ObjectMeta, nil
+		}
+	case NodeNetworkStateType:
+		if state, ok := obj.(*nodenetworkstateapi.NodeNetworkState); ok {
+			return &state.ObjectMeta, nil
+		}
 	}
 	return nil, fmt.Errorf("cannot get ObjectMeta from type %v", objType)
 }
@@ -792,6 +881,12 @@ func (wf *WatchFactory) GetResourceHandlerFunc(objType reflect.Type) (AddHandler
 			return wf.AddCloudPrivateIPConfigHandler(funcs, processExisting)
 		}, nil
 
+	case HostType:
+		return func(namespace string, sel labels.Selector,
+			funcs cache.ResourceEventHandler, processExisting func([]interface{}) error) (*Handler, error) {
+			return wf.AddHostHandler(funcs, processExisting)
+		}, nil
+
 	case EndpointSliceForStaleConntrackRemovalType, EndpointSliceForGatewayType:
 		return func(namespace string, sel labels.Selector,
 			funcs cache.ResourceEventHandler, processExisting func([]interface{}) error) (*Handler, error) {
@@ -953,6 +1048,26 @@ func (wf *WatchFactory) RemoveNetworkAttachmentDefinitionHandler(handler *Handle
 	wf.removeHandler(NetworkAttachmentDefinitionType, handler)
 }
 
+// AddHostHandler adds a handler function that will be executed on Host object changes
+func (wf *WatchFactory) AddHostHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{}) error) (*Handler, error) {
+	return wf.addHandler(HostType, "", nil, handlerFuncs, processExisting, defaultHandlerPriority)
+}
+
+// RemoveHostHandler removes a Host object event handler function
+func (wf *WatchFactory) RemoveHostHandler(handler *Handler) {
+	wf.removeHandler(HostType, handler)
+}
+
+// AddNodeNetworkStateHandler adds a handler function that will be executed on NodeNetworkState object changes
+func (wf *WatchFactory) AddNodeNetworkStateHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{}) error) (*Handler, error) {
+	return wf.addHandler(NodeNetworkStateType, "", nil, handlerFuncs, processExisting, defaultHandlerPriority)
+}
+
+// RemoveNodeNetworkStateHandler removes a NodeNetworkState object event handler function
+func (wf *WatchFactory) RemoveNodeNetworkStateHandler(handler *Handler) {
+	wf.removeHandler(NodeNetworkStateType, handler)
+}
+
 // AddEgressIPHandler adds a handler function that will be executed on EgressIP object changes
 func (wf *WatchFactory) AddEgressIPHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{}) error) (*Handler, error) {
 	return wf.addHandler(EgressIPType, "", nil, handlerFuncs, processExisting, defaultHandlerPriority)
@@ -1099,6 +1214,30 @@ func (wf *WatchFactory) GetCloudPrivateIPConfig(name string) (*ocpcloudnetworkap
 	return cloudPrivateIPConfigLister.Get(name)
 }
 
+// GetCloudPrivateIPConfigs returns all the CloudPrivateIPConfigs
+func (wf *WatchFactory) GetCloudPrivateIPConfigs() ([]*ocpcloudnetworkapi.CloudPrivateIPConfig, error) {
+	cloudPrivateIPConfigLister := wf.informers[CloudPrivateIPConfigType].lister.(ocpcloudnetworklister.CloudPrivateIPConfigLister)
+	return cloudPrivateIPConfigLister.List(labels.Everything())
+}
+
+// GetHost returns the Host of the given name
+func (wf *WatchFactory) GetHost(name string) (*hostapi.Host, error) {
+	hostLister := wf.informers[HostType].lister.(hostlister.HostLister)
+	return hostLister.Get(name)
+}
+
+// GetHosts returns all the Hosts
+func (wf *WatchFactory) GetHosts() ([]*hostapi.Host, error) {
+	hostLister := wf.informers[HostType].lister.(hostlister.HostLister)
+	return hostLister.List(labels.Everything())
+}
+
+// GetNodeNetworkState returns the NodeNetworkState of the given node
+func (wf *WatchFactory) GetNodeNetworkState(nodeName string) (*nodenetworkstateapi.NodeNetworkState, error) {
+	stateLister := wf.informers[NodeNetworkStateType].lister.(nodenetworkstatelister.NodeNetworkStateLister)
+	return stateLister.Get(nodeName)
+}
+
 func (wf *WatchFactory) GetEgressIP(name string) (*egressipapi.EgressIP, error) {
 	egressIPLister := wf.informers[EgressIPType].lister.(egressiplister.EgressIPLister)
 	return egressIPLister.Get(name)
@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
@@ -816,6 +817,42 @@ var _ = Describe("Watch Factory Operations", func() {
 		})
 	})
 
+	Context("when priority namespaces are configured", func() {
+		BeforeEach(func() {
+			config.Kubernetes.PriorityNamespaces = sets.New("kube-system")
+			wf, err = NewMasterWatchFactory(ovnClientset)
+			Expect(err).NotTo(HaveOccurred())
+			err = wf.Start()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("serves the objects of the priority namespaces from dedicated queues", func() {
+			numEventQueues := int(defaultNumEventQueues)
+			qm := wf.informers[PodType].queueMap
+			Expect(qm.queues).To(HaveLen(numEventQueues + numPriorityEventQueues))
+			for i := 0; i < 10; i++ {
+				_, entry := qm.getQueueMapEntry(PodType, newPod(fmt.Sprintf("critical%d", i), "kube-system"))
+				Expect(int(entry.queue)).To(BeNumerically(">=", numEventQueues))
+				_, entry = qm.getQueueMapEntry(PodType, newPod(fmt.Sprintf("tenant%d", i), "tenant"))
+				Expect(int(entry.queue)).To(BeNumerically("<", numEventQueues))
+			}
+			qm = wf.informers[NamespaceType].queueMap
+			_, entry := qm.getQueueMapEntry(NamespaceType, newNamespace("kube-system"))
+			Expect(int(entry.queue)).To(BeNumerically(">=", numEventQueues))
+			_, entry = qm.getQueueMapEntry(NamespaceType, newNamespace("tenant"))
+			Expect(int(entry.queue)).To(BeNumerically("<", numEventQueues))
+		})
+		It("adds the existing objects of the priority namespaces first", func() {
+			objs := []interface{}{
+				newPod("pod1", "tenant"),
+				newPod("pod2", "kube-system"),
+				newPod("pod3", "tenant"),
+				newPod("pod4", "kube-system"),
+			}
+			sorted := priorityObjectsFirst(PodType, objs)
+			Expect(sorted).To(Equal([]interface{}{objs[1], objs[3], objs[0], objs[2]}))
+		})
+	})
+
 	addFilteredHandler := func(wf *WatchFactory, objType reflect.Type, realObjType reflect.Type, namespace string, sel labels.Selector, funcs cache.ResourceEventHandlerFuncs) (*Handler, *handlerCalls) {
 		calls := handlerCalls{}
 		h, err := wf.addHandler(objType, namespace, sel, cache.ResourceEventHandlerFuncs{
@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cryptorand"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 
@@ -16,6 +17,8 @@ import (
 	egressfirewalllister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/listers/egressfirewall/v1"
 	egressqoslister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/listers/egressqos/v1"
 	egressservicelister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressservice/v1/apis/listers/egressservice/v1"
+	hostlister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/listers/host/v1"
+	nodenetworkstatelister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/listers/nodenetworkstate/v1"
 
 	cloudprivateipconfiglister "github.com/openshift/client-go/cloudnetwork/listers/cloudnetwork/v1"
 	egressiplister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/listers/egressip/v1"
@@ -79,11 +82,19 @@ type listerInterface interface{}
 
 type initialAddFn func(*Handler, []interface{})
 
+// numPriorityEventQueues is the number of queues dedicated to the events of
+// the objects of the priority namespaces
+const numPriorityEventQueues = 2
+
 type queueMap struct {
 	sync.Mutex
 	entries map[ktypes.NamespacedName]*queueMapEntry
-	queues  []chan *event
-	wg      *sync.WaitGroup
+	// queues holds the regular queues followed by the priority queues, if
+	// any, so that the events of the priority namespaces never wait behind
+	// a backlog of events of the other namespaces
+	queues            []chan *event
+	numPriorityQueues uint32
+	wg                *sync.WaitGroup
 }
 
 type queueMapEntry struct {
@@ -222,12 +233,17 @@ func (i *informer) removeHandler(handler *Handler) {
 }
 
 func newQueueMap(numEventQueues uint32, wg *sync.WaitGroup) *queueMap {
+	var numPriorityQueues uint32
+	if config.Kubernetes.PriorityNamespaces.Len() > 0 {
+		numPriorityQueues = numPriorityEventQueues
+	}
 	qm := &queueMap{
-		entries: make(map[ktypes.NamespacedName]*queueMapEntry),
-		queues:  make([]chan *event, numEventQueues),
-		wg:      wg,
+		entries:           make(map[ktypes.NamespacedName]*queueMapEntry),
+		queues:            make([]chan *event, numEventQueues+numPriorityQueues),
+		numPriorityQueues: numPriorityQueues,
+		wg:                wg,
 	}
-	for j := 0; j < int(numEventQueues); j++ {
+	for j := range qm.queues {
 		qm.queues[j] = make(chan *event, 10)
 	}
 	return qm
@@ -263,15 +279,21 @@ func (qm *queueMap) shutdown() {
 }
 
 // getNewQueueNum finds and returns the index of the queue with the lowest
-// number of items
-func (qm *queueMap) getNewQueueNum() uint32 {
+// number of items, among the priority queues if priority is set and there
+// are any, among the regular queues otherwise
+func (qm *queueMap) getNewQueueNum(priority bool) uint32 {
 	var j, startIdx, queueIdx uint32
-	numEventQueues := uint32(len(qm.queues))
-	startIdx = uint32(cryptorand.Intn(int64(numEventQueues - 1)))
+	firstQueue := uint32(0)
+	numEventQueues := uint32(len(qm.queues)) - qm.numPriorityQueues
+	if priority && qm.numPriorityQueues > 0 {
+		firstQueue = numEventQueues
+		numEventQueues = qm.numPriorityQueues
+	}
+	startIdx = firstQueue + uint32(cryptorand.Intn(int64(numEventQueues-1)))
 	queueIdx = startIdx
 	lowestNum := len(qm.queues[startIdx])
 	for j = 0; j < numEventQueues; j++ {
-		tryQueue := (startIdx + j) % numEventQueues
+		tryQueue := firstQueue + (startIdx-firstQueue+j)%numEventQueues
 		num := len(qm.queues[tryQueue])
 		if num < lowestNum {
 			lowestNum = num
@@ -289,7 +311,8 @@ func (qm *queueMap) getNewQueueNum() uint32 {
 //
 // If there is no entry for the NamespacedName a new one is created and assigned
 // a queue slot with the least number of items (to attempt to balance queue
-// length).
+// length), among the priority queues for the objects of the priority
+// namespaces.
 //
 // If an existing entry exists it will be returned and the already-assigned
 // queue slot will be used to ensure serialization.
@@ -301,6 +324,7 @@ func (qm *queueMap) getQueueMapEntry(oType reflect.Type, obj interface{}) (ktype
 	}
 
 	namespacedName := ktypes.NamespacedName{Namespace: meta.Namespace, Name: meta.Name}
+	priority := isPriorityObject(oType, meta)
 
 	qm.Lock()
 	defer qm.Unlock()
@@ -311,13 +335,13 @@ func (qm *queueMap) getQueueMapEntry(oType reflect.Type, obj interface{}) (ktype
 			// Entry is unused because add/update operations completed
 			// but we haven't seen a delete yet. Assign new queue to
 			// ensure queue balance.
-			entry.queue = qm.getNewQueueNum()
+			entry.queue = qm.getNewQueueNum(priority)
 		}
 	} else {
 		// no entry found, assign new queue
 		entry = &queueMapEntry{
 			refcount: 1,
-			queue:    qm.getNewQueueNum(),
+			queue:    qm.getNewQueueNum(priority),
 		}
 		qm.entries[namespacedName] = entry
 	}
@@ -349,6 +373,33 @@ func (qm *queueMap) releaseQueueMapEntry(key ktypes.NamespacedName, entry *queue
 	}
 }
 
+// isPriorityObject returns whether the object belongs to a priority namespace,
+// or is a priority namespace itself
+func isPriorityObject(oType reflect.Type, meta *metav1.ObjectMeta) bool {
+	if oType == NamespaceType {
+		return config.Kubernetes.PriorityNamespaces.Has(meta.Name)
+	}
+	return config.Kubernetes.PriorityNamespaces.Has(meta.Namespace)
+}
+
+// priorityObjectsFirst returns the objects with the objects of the priority
+// namespaces first, keeping their order otherwise
+func priorityObjectsFirst(oType reflect.Type, objs []interface{}) []interface{} {
+	if config.Kubernetes.PriorityNamespaces.Len() == 0 {
+		return objs
+	}
+	sorted := make([]interface{}, 0, len(objs))
+	others := make([]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		if meta, err := getObjectMeta(oType, obj); err == nil && isPriorityObject(oType, meta) {
+			sorted = append(sorted, obj)
+		} else {
+			others = append(others, obj)
+		}
+	}
+	return append(sorted, others...)
+}
+
 // enqueueEvent adds an event to the appropriate queue for the object
 func (qm *queueMap) enqueueEvent(oldObj, obj interface{}, oType reflect.Type, isDel bool, processFunc func(*event)) {
 	key, entry := qm.getQueueMapEntry(oType, obj)
@@ -521,6 +572,10 @@ func newInformerLister(oType reflect.Type, sharedInformer cache.SharedIndexInfor
 		return multinetworkpolicylister.NewMultiNetworkPolicyLister(sharedInformer.GetIndexer()), nil
 	case EgressServiceType:
 		return egressservicelister.NewEgressServiceLister(sharedInformer.GetIndexer()), nil
+	case HostType:
+		return hostlister.NewHostLister(sharedInformer.GetIndexer()), nil
+	case NodeNetworkStateType:
+		return nodenetworkstatelister.NewNodeNetworkStateLister(sharedInformer.GetIndexer()), nil
 	}
 
 	return nil, fmt.Errorf("cannot create lister from type %v", oType)
@@ -578,8 +633,9 @@ func newQueuedInformer(oType reflect.Type, sharedInformer cache.SharedIndexInfor
 		addsMap.start(stopChan)
 
 		// Distribute the existing items into the handler-specific
-		// channel array.
-		for _, obj := range items {
+		// channel array, the items of the priority namespaces first so
+		// that they don't wait behind the others.
+		for _, obj := range priorityObjectsFirst(i.oType, items) {
 			addsMap.enqueueEvent(nil, obj, i.oType, false, func(e *event) {
 				h.OnAdd(e.obj, false)
 			})
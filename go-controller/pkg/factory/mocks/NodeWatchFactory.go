@@ -18,6 +18,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+
 	v1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/informers/externalversions/adminpolicybasedroute/v1"
 )
 
@@ -42,6 +44,32 @@ func (_m *NodeWatchFactory) APBRouteInformer() v1.AdminPolicyBasedExternalRouteI
 	return r0
 }
 
+// AddEgressIPHandler provides a mock function with given fields: handlerFuncs, processExisting
+func (_m *NodeWatchFactory) AddEgressIPHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{}) error) (*factory.Handler, error) {
+	ret := _m.Called(handlerFuncs, processExisting)
+
+	var r0 *factory.Handler
+	var r1 error
+	if rf, ok := ret.Get(0).(func(cache.ResourceEventHandler, func([]interface{}) error) (*factory.Handler, error)); ok {
+		return rf(handlerFuncs, processExisting)
+	}
+	if rf, ok := ret.Get(0).(func(cache.ResourceEventHandler, func([]interface{}) error) *factory.Handler); ok {
+		r0 = rf(handlerFuncs, processExisting)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*factory.Handler)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(cache.ResourceEventHandler, func([]interface{}) error) error); ok {
+		r1 = rf(handlerFuncs, processExisting)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddEndpointSliceHandler provides a mock function with given fields: handlerFuncs, processExisting
 func (_m *NodeWatchFactory) AddEndpointSliceHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{}) error) (*factory.Handler, error) {
 	ret := _m.Called(handlerFuncs, processExisting)
@@ -344,6 +372,32 @@ func (_m *NodeWatchFactory) GetNode(name string) (*corev1.Node, error) {
 	return r0, r1
 }
 
+// GetNodeNetworkState provides a mock function with given fields: nodeName
+func (_m *NodeWatchFactory) GetNodeNetworkState(nodeName string) (*nodenetworkstatev1.NodeNetworkState, error) {
+	ret := _m.Called(nodeName)
+
+	var r0 *nodenetworkstatev1.NodeNetworkState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*nodenetworkstatev1.NodeNetworkState, error)); ok {
+		return rf(nodeName)
+	}
+	if rf, ok := ret.Get(0).(func(string) *nodenetworkstatev1.NodeNetworkState); ok {
+		r0 = rf(nodeName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*nodenetworkstatev1.NodeNetworkState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(nodeName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNodes provides a mock function with given fields:
 func (_m *NodeWatchFactory) GetNodes() ([]*corev1.Node, error) {
 	ret := _m.Called()
@@ -564,6 +618,11 @@ func (_m *NodeWatchFactory) PodCoreInformer() informerscorev1.PodInformer {
 	return r0
 }
 
+// RemoveEgressIPHandler provides a mock function with given fields: handler
+func (_m *NodeWatchFactory) RemoveEgressIPHandler(handler *factory.Handler) {
+	_m.Called(handler)
+}
+
 // RemoveEndpointSliceHandler provides a mock function with given fields: handler
 func (_m *NodeWatchFactory) RemoveEndpointSliceHandler(handler *factory.Handler) {
 	_m.Called(handler)
@@ -0,0 +1,197 @@
+package fullsync
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coordinationclient "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/klog/v2"
+)
+
+// TokenPool staggers the full syncs of the components of a kind, like the
+// ovnkube-nodes, after a control plane outage: a component holds one of the
+// tokens of the pool while running its full sync, so that at most as many
+// components as tokens load the OVN databases and the apiserver at once. Each
+// token is backed by a Lease, named after the pool and the index of the token,
+// so that the token of a component dying during its full sync is freed once
+// its lease expires.
+type TokenPool struct {
+	leases   coordinationclient.LeasesGetter
+	name     string
+	identity string
+	tokens   int
+
+	namespace     string
+	leaseDuration time.Duration
+	retryPeriod   time.Duration
+	maxWait       time.Duration
+}
+
+// NewTokenPool returns the pool of full sync tokens of the given name in the
+// namespace, held under the identity of the component. A pool of 0 tokens
+// doesn't limit the full syncs.
+func NewTokenPool(leases coordinationclient.LeasesGetter, namespace, name, identity string, tokens int,
+	leaseDuration, maxWait time.Duration) *TokenPool {
+	return &TokenPool{
+		leases:        leases,
+		name:          name,
+		identity:      identity,
+		tokens:        tokens,
+		namespace:     namespace,
+		leaseDuration: leaseDuration,
+		retryPeriod:   leaseDuration / 4,
+		maxWait:       maxWait,
+	}
+}
+
+// Acquire blocks until a token of the pool is held and returns the function
+// releasing it, to call once the full sync is done. The token is renewed
+// until released. The full sync is let run without a token once the maximum
+// wait elapsed, or if ctx is done, so that a broken pool never blocks it.
+func (p *TokenPool) Acquire(ctx context.Context) func() {
+	if p.tokens <= 0 {
+		return func() {}
+	}
+	// the components start looking for a free token at different indexes so
+	// that they don't all compete for the first one
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(p.identity))
+	first := int(hash.Sum32() % uint32(p.tokens))
+
+	start := time.Now()
+	for {
+		for i := 0; i < p.tokens; i++ {
+			leaseName := p.leaseName((first + i) % p.tokens)
+			acquired, err := p.tryAcquire(ctx, leaseName)
+			if err != nil {
+				klog.V(5).Infof("Failed to acquire full sync token %s: %v", leaseName, err)
+				continue
+			}
+			if acquired {
+				klog.Infof("Acquired full sync token %s after %v", leaseName, time.Since(start))
+				return p.hold(leaseName)
+			}
+		}
+		if time.Since(start) >= p.maxWait {
+			klog.Warningf("No full sync token of %s freed within %v, running the full sync anyway", p.name, p.maxWait)
+			return func() {}
+		}
+		klog.V(5).Infof("All %d full sync tokens of %s are held, waiting", p.tokens, p.name)
+		select {
+		case <-ctx.Done():
+			return func() {}
+		case <-time.After(wait.Jitter(p.retryPeriod, 0.5)):
+		}
+	}
+}
+
+func (p *TokenPool) leaseName(index int) string {
+	return fmt.Sprintf("%s-full-sync-%d", p.name, index)
+}
+
+// tryAcquire takes the lease if it is free, expired or already held by the
+// component
+func (p *TokenPool) tryAcquire(ctx context.Context, leaseName string) (bool, error) {
+	now := metav1.NewMicroTime(time.Now())
+	leaseDurationSeconds := int32(p.leaseDuration / time.Second)
+	spec := coordinationv1.LeaseSpec{
+		HolderIdentity:       &p.identity,
+		LeaseDurationSeconds: &leaseDurationSeconds,
+		AcquireTime:          &now,
+		RenewTime:            &now,
+	}
+	leases := p.leases.Leases(p.namespace)
+	lease, err := leases.Get(ctx, leaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: leaseName, Namespace: p.namespace},
+			Spec:       spec,
+		}
+		if _, err = leases.Create(ctx, lease, metav1.CreateOptions{}); apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+	if !p.isFree(lease, now.Time) {
+		return false, nil
+	}
+	lease.Spec = spec
+	// a conflict means another component took the lease first
+	if _, err = leases.Update(ctx, lease, metav1.UpdateOptions{}); apierrors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// isFree returns whether the lease is held by no other component
+func (p *TokenPool) isFree(lease *coordinationv1.Lease, now time.Time) bool {
+	holder := lease.Spec.HolderIdentity
+	if holder == nil || *holder == "" || *holder == p.identity {
+		return true
+	}
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+// hold renews the lease until the returned function is called, which then
+// releases it
+func (p *TokenPool) hold(leaseName string) func() {
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		wait.Until(func() {
+			if err := p.update(leaseName, p.renew); err != nil {
+				klog.Warningf("Failed to renew full sync token %s: %v", leaseName, err)
+			}
+		}, p.leaseDuration/3, stopCh)
+	}()
+	return func() {
+		close(stopCh)
+		<-doneCh
+		if err := p.update(leaseName, p.release); err != nil {
+			// the lease expires anyway
+			klog.Warningf("Failed to release full sync token %s: %v", leaseName, err)
+			return
+		}
+		klog.Infof("Released full sync token %s", leaseName)
+	}
+}
+
+func (p *TokenPool) renew(lease *coordinationv1.Lease) {
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+}
+
+func (p *TokenPool) release(lease *coordinationv1.Lease) {
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = nil
+}
+
+// update applies the change to the lease if the component still holds it
+func (p *TokenPool) update(leaseName string, change func(lease *coordinationv1.Lease)) error {
+	leases := p.leases.Leases(p.namespace)
+	lease, err := leases.Get(context.TODO(), leaseName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != p.identity {
+		return fmt.Errorf("lease is not held by %s anymore", p.identity)
+	}
+	change(lease)
+	_, err = leases.Update(context.TODO(), lease, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,80 @@
+package fullsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTokenPool(t *testing.T) {
+	g := gomega.NewWithT(t)
+	client := fake.NewSimpleClientset()
+	newPool := func(identity string) *TokenPool {
+		return NewTokenPool(client.CoordinationV1(), "ovn-kubernetes", "ovnkube-node", identity, 1,
+			time.Second, 100*time.Millisecond)
+	}
+	holderOf := func() *string {
+		lease, err := client.CoordinationV1().Leases("ovn-kubernetes").Get(context.TODO(),
+			"ovnkube-node-full-sync-0", metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return lease.Spec.HolderIdentity
+	}
+
+	release := newPool("node1").Acquire(context.TODO())
+	g.Expect(holderOf()).To(gomega.HaveValue(gomega.Equal("node1")))
+
+	// the token is held, the second node runs its full sync anyway once the
+	// maximum wait elapsed
+	start := time.Now()
+	releaseOther := newPool("node2").Acquire(context.TODO())
+	g.Expect(time.Since(start)).To(gomega.BeNumerically(">=", 100*time.Millisecond))
+	g.Expect(holderOf()).To(gomega.HaveValue(gomega.Equal("node1")))
+	releaseOther()
+	g.Expect(holderOf()).To(gomega.HaveValue(gomega.Equal("node1")))
+
+	// the released token is free
+	release()
+	g.Expect(holderOf()).To(gomega.BeNil())
+	release = newPool("node2").Acquire(context.TODO())
+	g.Expect(holderOf()).To(gomega.HaveValue(gomega.Equal("node2")))
+	release()
+}
+
+func TestTokenPoolExpiredLease(t *testing.T) {
+	g := gomega.NewWithT(t)
+	holder := "node1"
+	leaseDurationSeconds := int32(1)
+	renewTime := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+	client := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-node-full-sync-0", Namespace: "ovn-kubernetes"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			RenewTime:            &renewTime,
+		},
+	})
+
+	// the token of the node that died during its full sync is free
+	pool := NewTokenPool(client.CoordinationV1(), "ovn-kubernetes", "ovnkube-node", "node2", 1, time.Second, time.Minute)
+	release := pool.Acquire(context.TODO())
+	lease, err := client.CoordinationV1().Leases("ovn-kubernetes").Get(context.TODO(),
+		"ovnkube-node-full-sync-0", metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(lease.Spec.HolderIdentity).To(gomega.HaveValue(gomega.Equal("node2")))
+	release()
+}
+
+func TestTokenPoolUnlimited(t *testing.T) {
+	g := gomega.NewWithT(t)
+	client := fake.NewSimpleClientset()
+	pool := NewTokenPool(client.CoordinationV1(), "ovn-kubernetes", "ovnkube-node", "node1", 0, time.Second, time.Minute)
+	pool.Acquire(context.TODO())()
+	leases, err := client.CoordinationV1().Leases("ovn-kubernetes").List(context.TODO(), metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(leases.Items).To(gomega.BeEmpty())
+}
@@ -17,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/client-go/kubernetes"
 	kv1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
@@ -45,6 +46,7 @@ type Interface interface {
 	SetAnnotationsOnPod(namespace, podName string, annotations map[string]interface{}) error
 	SetAnnotationsOnService(namespace, serviceName string, annotations map[string]interface{}) error
 	SetAnnotationsOnNode(nodeName string, annotations map[string]interface{}) error
+	ApplyAnnotationsOnNode(nodeName string, annotations map[string]string, fieldManager string) (*kapi.Node, error)
 	SetAnnotationsOnNamespace(namespaceName string, annotations map[string]interface{}) error
 	SetTaintOnNode(nodeName string, taint *kapi.Taint) error
 	RemoveTaintFromNode(nodeName string, taint *kapi.Taint) error
@@ -132,6 +134,20 @@ func (k *Kube) SetAnnotationsOnNode(nodeName string, annotations map[string]inte
 	return err
 }
 
+// ApplyAnnotationsOnNode applies the annotations on the node with a
+// server-side apply patch of the field manager. The annotations previously
+// applied by the field manager and missing from annotations are removed.
+func (k *Kube) ApplyAnnotationsOnNode(nodeName string, annotations map[string]string, fieldManager string) (*kapi.Node, error) {
+	klog.Infof("Applying annotations %v on node %s", annotations, nodeName)
+	nodeApply := corev1apply.Node(nodeName).WithAnnotations(annotations)
+	node, err := k.KClient.CoreV1().Nodes().ApplyStatus(context.TODO(), nodeApply,
+		metav1.ApplyOptions{FieldManager: fieldManager, Force: true})
+	if err != nil {
+		klog.Errorf("Error in applying annotations on node %s: %v", nodeName, err)
+	}
+	return node, err
+}
+
 // SetAnnotationsOnNamespace takes the namespace name and map of key/value string pairs to set as annotations
 func (k *Kube) SetAnnotationsOnNamespace(namespaceName string, annotations map[string]interface{}) error {
 	var err error
@@ -20,6 +20,29 @@ type Interface struct {
 	mock.Mock
 }
 
+// ApplyAnnotationsOnNode provides a mock function with given fields: nodeName, annotations, fieldManager
+func (_m *Interface) ApplyAnnotationsOnNode(nodeName string, annotations map[string]string, fieldManager string) (*apicorev1.Node, error) {
+	ret := _m.Called(nodeName, annotations, fieldManager)
+
+	var r0 *apicorev1.Node
+	if rf, ok := ret.Get(0).(func(string, map[string]string, string) *apicorev1.Node); ok {
+		r0 = rf(nodeName, annotations, fieldManager)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apicorev1.Node)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, map[string]string, string) error); ok {
+		r1 = rf(nodeName, annotations, fieldManager)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateCloudPrivateIPConfig provides a mock function with given fields: cloudPrivateIPConfig
 func (_m *Interface) CreateCloudPrivateIPConfig(cloudPrivateIPConfig *v1.CloudPrivateIPConfig) (*v1.CloudPrivateIPConfig, error) {
 	ret := _m.Called(cloudPrivateIPConfig)
@@ -0,0 +1,164 @@
+package kube
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+)
+
+// NodeAnnotationBatcher coalesces the updates of the annotations of a node,
+// made by several controllers, into a single server-side apply patch per node
+// per batch interval, instead of one node status update per update. The
+// updates are applied on the annotations of the node read when the batch is
+// applied, so that the annotations shared by the controllers, like the host
+// subnets of all the networks, are updated without conflicts.
+type NodeAnnotationBatcher struct {
+	kube         Interface
+	fieldManager string
+	interval     time.Duration
+
+	lock sync.Mutex
+	// pending are the updates waiting for the next batch, by node
+	pending map[string][]*nodeAnnotationUpdate
+	// flushing are the nodes whose batch is being applied, the next batch
+	// being scheduled once done
+	flushing sets.Set[string]
+}
+
+type nodeAnnotationUpdate struct {
+	update func(annotations map[string]string) (map[string]string, error)
+	result chan error
+}
+
+// NewNodeAnnotationBatcher returns a batcher applying the updates of the node
+// annotations as fieldManager every interval
+func NewNodeAnnotationBatcher(kube Interface, fieldManager string, interval time.Duration) *NodeAnnotationBatcher {
+	return &NodeAnnotationBatcher{
+		kube:         kube,
+		fieldManager: fieldManager,
+		interval:     interval,
+		pending:      map[string][]*nodeAnnotationUpdate{},
+		flushing:     sets.New[string](),
+	}
+}
+
+// UpdateNodeAnnotations queues the update of the annotations of the node and
+// waits for its batch to be applied. update is given a copy of the latest
+// annotations of the node, including the updates queued before it, and
+// returns them updated; the annotations it deletes are removed from the node.
+func (b *NodeAnnotationBatcher) UpdateNodeAnnotations(nodeName string, update func(annotations map[string]string) (map[string]string, error)) error {
+	u := &nodeAnnotationUpdate{
+		update: update,
+		result: make(chan error, 1),
+	}
+	b.lock.Lock()
+	b.pending[nodeName] = append(b.pending[nodeName], u)
+	if len(b.pending[nodeName]) == 1 && !b.flushing.Has(nodeName) {
+		time.AfterFunc(b.interval, func() { b.flush(nodeName) })
+	}
+	b.lock.Unlock()
+	return <-u.result
+}
+
+// flush applies the pending updates of the node and schedules the next batch
+// if updates were queued in the meantime
+func (b *NodeAnnotationBatcher) flush(nodeName string) {
+	b.lock.Lock()
+	updates := b.pending[nodeName]
+	delete(b.pending, nodeName)
+	b.flushing.Insert(nodeName)
+	b.lock.Unlock()
+
+	b.applyUpdates(nodeName, updates)
+
+	b.lock.Lock()
+	b.flushing.Delete(nodeName)
+	if len(b.pending[nodeName]) > 0 {
+		time.AfterFunc(b.interval, func() { b.flush(nodeName) })
+	}
+	b.lock.Unlock()
+}
+
+func (b *NodeAnnotationBatcher) applyUpdates(nodeName string, updates []*nodeAnnotationUpdate) {
+	// the node is read from the API server rather than from an informer
+	// cache, which may not have caught up with the previous batch yet
+	node, err := b.kube.GetNode(nodeName)
+	if err != nil {
+		for _, u := range updates {
+			u.result <- err
+		}
+		return
+	}
+
+	annotations := node.Annotations
+	var batched []*nodeAnnotationUpdate
+	for _, u := range updates {
+		updated, err := u.update(copyAnnotations(annotations))
+		if err != nil {
+			u.result <- err
+			continue
+		}
+		annotations = updated
+		batched = append(batched, u)
+	}
+
+	err = b.applyAnnotations(node, annotations)
+	for _, u := range batched {
+		u.result <- err
+	}
+}
+
+// applyAnnotations applies the annotations of the node changed by the updates
+// with a server-side apply patch, along with the other annotations owned by
+// the field manager which would otherwise be removed, and removes the deleted
+// annotations with a strategic merge patch, as they may be owned by another
+// manager
+func (b *NodeAnnotationBatcher) applyAnnotations(node *kapi.Node, annotations map[string]string) error {
+	nodeApply, err := corev1apply.ExtractNodeStatus(node, b.fieldManager)
+	if err != nil {
+		return fmt.Errorf("failed to get the annotations of node %s owned by %s: %w", node.Name, b.fieldManager, err)
+	}
+	owned := sets.KeySet(nodeApply.Annotations)
+	changed := false
+	for key, value := range annotations {
+		if currentValue, ok := node.Annotations[key]; !ok || currentValue != value {
+			owned.Insert(key)
+			changed = true
+		}
+	}
+	deleted := map[string]interface{}{}
+	for key := range node.Annotations {
+		if _, ok := annotations[key]; !ok {
+			owned.Delete(key)
+			deleted[key] = nil
+		}
+	}
+
+	if changed {
+		ownedAnnotations := make(map[string]string, owned.Len())
+		for key := range owned {
+			ownedAnnotations[key] = annotations[key]
+		}
+		if _, err := b.kube.ApplyAnnotationsOnNode(node.Name, ownedAnnotations, b.fieldManager); err != nil {
+			return fmt.Errorf("failed to apply the annotations of node %s: %w", node.Name, err)
+		}
+	}
+	if len(deleted) > 0 {
+		if err := b.kube.SetAnnotationsOnNode(node.Name, deleted); err != nil {
+			return fmt.Errorf("failed to delete annotations of node %s: %w", node.Name, err)
+		}
+	}
+	return nil
+}
+
+func copyAnnotations(annotations map[string]string) map[string]string {
+	c := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		c[key] = value
+	}
+	return c
+}
@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NodeAnnotationBatcher", func() {
+	const nodeName = "node1"
+
+	var (
+		fakeClient *fake.Clientset
+		batcher    *NodeAnnotationBatcher
+	)
+
+	BeforeEach(func() {
+		node := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        nodeName,
+				Annotations: map[string]string{"a": "1", "legacy": "x"},
+			},
+		}
+		fakeClient = fake.NewSimpleClientset(node)
+		batcher = NewNodeAnnotationBatcher(&Kube{KClient: fakeClient}, "test-manager", 100*time.Millisecond)
+	})
+
+	setAnnotation := func(key, value string) func(map[string]string) (map[string]string, error) {
+		return func(annotations map[string]string) (map[string]string, error) {
+			annotations[key] = value
+			return annotations, nil
+		}
+	}
+
+	getNodePatches := func() []core.PatchAction {
+		var patches []core.PatchAction
+		for _, action := range fakeClient.Actions() {
+			if patch, ok := action.(core.PatchAction); ok {
+				patches = append(patches, patch)
+			}
+		}
+		fakeClient.ClearActions()
+		return patches
+	}
+
+	getAnnotations := func() map[string]string {
+		node, err := fakeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return node.Annotations
+	}
+
+	It("coalesces the updates of a node into a single server-side apply patch", func() {
+		wg := &sync.WaitGroup{}
+		for key, value := range map[string]string{"b": "2", "c": "3"} {
+			wg.Add(1)
+			go func(key, value string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(batcher.UpdateNodeAnnotations(nodeName, setAnnotation(key, value))).To(Succeed())
+			}(key, value)
+		}
+		wg.Wait()
+
+		patches := getNodePatches()
+		Expect(patches).To(HaveLen(1))
+		Expect(patches[0].GetPatchType()).To(Equal(types.ApplyPatchType))
+		Expect(getAnnotations()).To(Equal(map[string]string{"a": "1", "legacy": "x", "b": "2", "c": "3"}))
+
+		// unchanged annotations are not patched
+		Expect(batcher.UpdateNodeAnnotations(nodeName, setAnnotation("b", "2"))).To(Succeed())
+		Expect(getNodePatches()).To(BeEmpty())
+	})
+
+	It("applies the updates on the latest annotations and removes the deleted ones", func() {
+		Expect(batcher.UpdateNodeAnnotations(nodeName, setAnnotation("b", "2"))).To(Succeed())
+		getNodePatches()
+
+		Expect(batcher.UpdateNodeAnnotations(nodeName, func(annotations map[string]string) (map[string]string, error) {
+			Expect(annotations).To(HaveKeyWithValue("b", "2"))
+			delete(annotations, "legacy")
+			annotations["c"] = "3"
+			return annotations, nil
+		})).To(Succeed())
+
+		patches := getNodePatches()
+		Expect(patches).To(HaveLen(2))
+		Expect(patches[0].GetPatchType()).To(Equal(types.ApplyPatchType))
+		Expect(string(patches[0].GetPatch())).To(ContainSubstring(`"c":"3"`))
+		Expect(patches[1].GetPatchType()).To(Equal(types.StrategicMergePatchType))
+		Expect(string(patches[1].GetPatch())).To(ContainSubstring(`"legacy":null`))
+		Expect(getAnnotations()).To(Equal(map[string]string{"a": "1", "b": "2", "c": "3"}))
+	})
+})
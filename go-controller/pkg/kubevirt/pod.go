@@ -149,14 +149,14 @@ func ZoneContainsPodSubnet(lsManager *logicalswitchmanager.LogicalSwitchManager,
 	return lsManager.GetSubnetName(podAnnotation.IPs)
 }
 
-// nodeContainsPodSubnet will return true if the node subnet annotation
-// contains the subnets from the argument
+// nodeContainsPodSubnet will return true if the host subnets of the node
+// contain the subnets from the argument
 func nodeContainsPodSubnet(watchFactory *factory.WatchFactory, nodeName string, podAnnotation *util.PodAnnotation, nadName string) (bool, error) {
 	node, err := watchFactory.GetNode(nodeName)
 	if err != nil {
 		return false, err
 	}
-	nodeHostSubNets, err := util.ParseNodeHostSubnetAnnotation(node, nadName)
+	nodeHostSubNets, err := util.ParseNodeHostSubnets(node, nadName, watchFactory)
 	if err != nil {
 		return false, err
 	}
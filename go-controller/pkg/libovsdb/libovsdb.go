@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/cenkalti/backoff/v4"
@@ -19,7 +20,9 @@ import (
 	"github.com/go-logr/stdr"
 	"github.com/ovn-org/libovsdb/client"
 	"github.com/ovn-org/libovsdb/model"
+	"github.com/ovn-org/libovsdb/ovsdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/nbdbext"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/sbdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
@@ -126,8 +129,19 @@ func NewSBClient(stopCh <-chan struct{}) (client.Client, error) {
 	return NewSBClientWithConfig(config.OvnSouth, prometheus.DefaultRegisterer, stopCh)
 }
 
+// NewSingleNodeSBClient creates a new OVN Southbound Database client for a
+// single node cluster, that does not monitor the tables only needed with
+// several nodes or by disabled features
+func NewSingleNodeSBClient(stopCh <-chan struct{}) (client.Client, error) {
+	return newSBClient(config.OvnSouth, prometheus.DefaultRegisterer, stopCh, true)
+}
+
 // NewSBClientWithConfig creates a new OVN Southbound Database client with the provided configuration
 func NewSBClientWithConfig(cfg config.OvnAuthConfig, promRegistry prometheus.Registerer, stopCh <-chan struct{}) (client.Client, error) {
+	return newSBClient(cfg, promRegistry, stopCh, false)
+}
+
+func newSBClient(cfg config.OvnAuthConfig, promRegistry prometheus.Registerer, stopCh <-chan struct{}, singleNode bool) (client.Client, error) {
 	dbModel, err := sbdb.FullDatabaseModel()
 	if err != nil {
 		return nil, err
@@ -150,28 +164,32 @@ func NewSBClientWithConfig(cfg config.OvnAuthConfig, promRegistry prometheus.Reg
 	// Only Monitor Required SBDB tables to reduce memory overhead
 	chassisPrivate := sbdb.ChassisPrivate{}
 	igmpGroup := sbdb.IGMPGroup{}
-	_, err = c.Monitor(ctx,
-		c.NewMonitor(
-			// used by unidling controller
-			client.WithTable(&sbdb.ControllerEvent{}),
-			// used for gateway
-			client.WithTable(&sbdb.MACBinding{}),
-			// used by node sync
-			client.WithTable(&sbdb.Chassis{}),
-			// used by zone interconnect
-			client.WithTable(&sbdb.Encap{}),
-			// used by node sync, only interested in names
-			client.WithTable(&chassisPrivate, &chassisPrivate.Name),
-			// used by node sync, only interested in Chassis reference
-			client.WithTable(&igmpGroup, &igmpGroup.Chassis),
-			// used for metrics
-			client.WithTable(&sbdb.SBGlobal{}),
-			// used for metrics
-			client.WithTable(&sbdb.PortBinding{}),
-			// used for hybrid-overlay
-			client.WithTable(&sbdb.DatapathBinding{}),
-		),
-	)
+	tables := []client.MonitorOption{
+		// used for gateway
+		client.WithTable(&sbdb.MACBinding{}),
+		// used by node sync
+		client.WithTable(&sbdb.Chassis{}),
+		// used by node sync, only interested in names
+		client.WithTable(&chassisPrivate, &chassisPrivate.Name),
+		// used by node sync, only interested in Chassis reference
+		client.WithTable(&igmpGroup, &igmpGroup.Chassis),
+		// used for metrics
+		client.WithTable(&sbdb.SBGlobal{}),
+		// used for metrics
+		client.WithTable(&sbdb.PortBinding{}),
+		// used for hybrid-overlay
+		client.WithTable(&sbdb.DatapathBinding{}),
+	}
+	// a single node has no remote chassis to create
+	if !singleNode {
+		// used by zone interconnect
+		tables = append(tables, client.WithTable(&sbdb.Encap{}))
+	}
+	if !singleNode || config.Kubernetes.OVNEmptyLbEvents {
+		// used by unidling controller
+		tables = append(tables, client.WithTable(&sbdb.ControllerEvent{}))
+	}
+	_, err = c.Monitor(ctx, c.NewMonitor(tables...))
 	if err != nil {
 		c.Close()
 		return nil, err
@@ -185,9 +203,119 @@ func NewNBClient(stopCh <-chan struct{}) (client.Client, error) {
 	return NewNBClientWithConfig(config.OvnNorth, prometheus.DefaultRegisterer, stopCh)
 }
 
+// nbOptionalTables are the tables of the generated Northbound model that the
+// supported OVN versions may lack. They are left out of the model of the
+// client when the schema of the server does not have them, the features
+// using them being disabled, see libovsdbutil.ProbeSchemaFeatures.
+var nbOptionalTables = sets.New[string](
+	nbdb.ChassisTemplateVarTable,
+)
+
+// NBModels returns the models of the tables of the Northbound database for a
+// server with the provided schema: the tables of the generated model but the
+// optional ones the schema does not have, and the tables of nbdbext the
+// schema has.
+func NBModels(schema ovsdb.DatabaseSchema) (map[string]model.Model, error) {
+	fullModel, err := nbdb.FullDatabaseModel()
+	if err != nil {
+		return nil, err
+	}
+	fullDBModel, errs := model.NewDatabaseModel(nbdb.Schema(), fullModel)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid generated Northbound model: %v", errs)
+	}
+	models := make(map[string]model.Model, len(fullDBModel.Types()))
+	for table := range fullDBModel.Types() {
+		if nbOptionalTables.Has(table) && schema.Table(table) == nil {
+			continue
+		}
+		if models[table], err = fullDBModel.NewModel(table); err != nil {
+			return nil, err
+		}
+	}
+	for table, m := range nbdbext.Models() {
+		if schema.Table(table) != nil {
+			models[table] = m
+		}
+	}
+	return models, nil
+}
+
+// NBDatabaseModel returns the model of the Northbound database for a server
+// with the provided schema, made of the NBModels of the schema. It fails if
+// the schema lacks a table or a column of the generated model that is not
+// optional.
+func NBDatabaseModel(schema ovsdb.DatabaseSchema) (model.ClientDBModel, error) {
+	models, err := NBModels(schema)
+	if err != nil {
+		return model.ClientDBModel{}, err
+	}
+	dbModel, err := model.NewClientDBModel(schema.Name, models)
+	if err != nil {
+		return model.ClientDBModel{}, err
+	}
+	if _, errs := model.NewDatabaseModel(schema, dbModel); len(errs) > 0 {
+		return model.ClientDBModel{}, fmt.Errorf("OVN Northbound schema %s is not supported: %v", schema.Version, errs)
+	}
+	return dbModel, nil
+}
+
+// newModelColumnsMonitor returns a monitor of all the tables of the model,
+// restricted to the columns of their model: the cache could not store the
+// columns of the schema of the server that are newer than the model
+func newModelColumnsMonitor(c client.Client, schema ovsdb.DatabaseSchema, dbModel model.ClientDBModel) (*client.Monitor, error) {
+	databaseModel, errs := model.NewDatabaseModel(schema, dbModel)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid model for schema %s: %v", schema.Version, errs)
+	}
+	monitor := c.NewMonitor()
+	for table := range databaseModel.Types() {
+		m, err := databaseModel.NewModel(table)
+		if err != nil {
+			return nil, err
+		}
+		info, err := databaseModel.NewModelInfo(m)
+		if err != nil {
+			return nil, err
+		}
+		columns := make([]string, 0, len(info.Metadata.Fields))
+		for column := range info.Metadata.Fields {
+			// the rows of the updates are keyed by their UUID
+			if column != "_uuid" {
+				columns = append(columns, column)
+			}
+		}
+		monitor.Tables = append(monitor.Tables, client.TableMonitor{Table: table, Fields: columns})
+	}
+	return monitor, nil
+}
+
+// getSchema returns the schema of the database of the server, connecting with
+// a model without tables so that any schema is accepted
+func getSchema(cfg config.OvnAuthConfig, dbName string) (ovsdb.DatabaseSchema, error) {
+	dbModel, err := model.NewClientDBModel(dbName, map[string]model.Model{})
+	if err != nil {
+		return ovsdb.DatabaseSchema{}, err
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c, err := newClient(cfg, dbModel, stopCh)
+	if err != nil {
+		return ovsdb.DatabaseSchema{}, err
+	}
+	defer c.Close()
+	return c.Schema(), nil
+}
+
 // NewNBClientWithConfig creates a new OVN Northbound Database client with the provided configuration
 func NewNBClientWithConfig(cfg config.OvnAuthConfig, promRegistry prometheus.Registerer, stopCh <-chan struct{}) (client.Client, error) {
-	dbModel, err := nbdb.FullDatabaseModel()
+	// probe the schema of the server first, a model with a table or a
+	// column the schema does not have being rejected at connect time
+	schema, err := getSchema(cfg, nbdb.Schema().Name)
+	if err != nil {
+		return nil, err
+	}
+	dbModel, err := NBDatabaseModel(schema)
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +343,12 @@ func NewNBClientWithConfig(cfg config.OvnAuthConfig, promRegistry prometheus.Reg
 		cancel()
 	}()
 
-	_, err = c.MonitorAll(ctx)
+	monitor, err := newModelColumnsMonitor(c, schema, dbModel)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	_, err = c.Monitor(ctx, monitor)
 	if err != nil {
 		c.Close()
 		return nil, err
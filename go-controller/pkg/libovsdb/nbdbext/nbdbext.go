@@ -0,0 +1,20 @@
+// Package nbdbext holds the models of the OVN Northbound tables that are newer
+// than the schema of the generated nbdb model. They are only added to the
+// model of the client when the schema of the server has them, so that ovnkube
+// can still connect to an older OVN.
+package nbdbext
+
+import "github.com/ovn-org/libovsdb/model"
+
+// ACLSampleNewColumn is the column of the ACL table referencing the Sample of
+// the packets of new connections hitting the ACL. It is not in the generated
+// ACL model, so it is only read and set with raw operations.
+const ACLSampleNewColumn = "sample_new"
+
+// Models returns the models of the tables of the package, by table name
+func Models() map[string]model.Model {
+	return map[string]model.Model{
+		SampleTable:          &Sample{},
+		SampleCollectorTable: &SampleCollector{},
+	}
+}
@@ -168,7 +168,7 @@ func UpdateACLsLoggingOps(nbClient libovsdbclient.Client, ops []libovsdb.Operati
 		acl := acls[i]
 		opModel := operationModel{
 			Model:          acl,
-			OnModelUpdates: []interface{}{&acl.Severity, &acl.Log},
+			OnModelUpdates: []interface{}{&acl.Severity, &acl.Log, &acl.Meter},
 			ErrNotFound:    true,
 			BulkOp:         false,
 		}
@@ -22,14 +22,17 @@ const (
 	PodSelectorOwnerType   ownerType = "PodSelector"
 	NamespaceOwnerType     ownerType = "Namespace"
 	// HybridNodeRouteOwnerType is transferred from egressgw to apbRoute controller with the same dbIDs
-	HybridNodeRouteOwnerType    ownerType = "HybridNodeRoute"
-	EgressIPOwnerType           ownerType = "EgressIP"
-	EgressServiceOwnerType      ownerType = "EgressService"
-	MulticastNamespaceOwnerType ownerType = "MulticastNS"
-	MulticastClusterOwnerType   ownerType = "MulticastCluster"
-	NetpolNodeOwnerType         ownerType = "NetpolNode"
-	NetpolNamespaceOwnerType    ownerType = "NetpolNamespace"
-	VirtualMachineOwnerType     ownerType = "VirtualMachine"
+	HybridNodeRouteOwnerType     ownerType = "HybridNodeRoute"
+	EgressIPOwnerType            ownerType = "EgressIP"
+	EgressServiceOwnerType       ownerType = "EgressService"
+	MulticastNamespaceOwnerType  ownerType = "MulticastNS"
+	MulticastClusterOwnerType    ownerType = "MulticastCluster"
+	NetpolNodeOwnerType          ownerType = "NetpolNode"
+	NetpolNamespaceOwnerType     ownerType = "NetpolNamespace"
+	NetpolIPBlockOwnerType       ownerType = "NetpolIPBlock"
+	VirtualMachineOwnerType      ownerType = "VirtualMachine"
+	NetworkFirewallOwnerType     ownerType = "NetworkFirewall"
+	LocalnetPassthroughOwnerType ownerType = "LocalnetPassthrough"
 	// NetworkPolicyPortIndexOwnerType is the old version of NetworkPolicyOwnerType, kept for sync only
 	NetworkPolicyPortIndexOwnerType ownerType = "NetworkPolicyPortIndexOwnerType"
 	// owner extra IDs, make sure to define only 1 ExternalIDKey for every string value
@@ -105,6 +108,18 @@ var AddressSetNetworkPolicy = newObjectIDsType(addressSet, NetworkPolicyOwnerTyp
 	AddressSetIPFamilyKey,
 })
 
+// AddressSetNetpolIPBlock is the address set of the ipBlocks of a multi-network
+// policy gress
+var AddressSetNetpolIPBlock = newObjectIDsType(addressSet, NetpolIPBlockOwnerType, []ExternalIDKey{
+	// policy namespace:name
+	ObjectNameKey,
+	// egress or ingress
+	PolicyDirectionKey,
+	// gress rule index
+	GressIdxKey,
+	AddressSetIPFamilyKey,
+})
+
 var AddressSetNamespace = newObjectIDsType(addressSet, NamespaceOwnerType, []ExternalIDKey{
 	// namespace
 	ObjectNameKey,
@@ -233,6 +248,21 @@ var ACLEgressFirewall = newObjectIDsType(acl, EgressFirewallOwnerType, []Externa
 	RuleIndex,
 })
 
+var ACLNetworkFirewall = newObjectIDsType(acl, NetworkFirewallOwnerType, []ExternalIDKey{
+	// egress or ingress
+	PolicyDirectionKey,
+	// index of the rule in the network firewall, or "default" for the acl
+	// allowing the traffic not matching any rule
+	RuleIndex,
+})
+
+var ACLLocalnetPassthrough = newObjectIDsType(acl, LocalnetPassthroughOwnerType, []ExternalIDKey{
+	// egress or ingress
+	PolicyDirectionKey,
+	// the traffic the acl applies to: link-local, lldp or arp
+	TypeKey,
+})
+
 var VirtualMachineDHCPOptions = newObjectIDsType(dhcpOptions, VirtualMachineOwnerType, []ExternalIDKey{
 	// We can have multiple VMs with same CIDR they  may have different
 	// hostname.
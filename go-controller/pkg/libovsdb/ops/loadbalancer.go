@@ -58,6 +58,25 @@ func BuildLoadBalancer(name string, protocol nbdb.LoadBalancerProtocol, vips, op
 	}
 }
 
+// GetLoadBalancer looks up a load balancer from the cache
+func GetLoadBalancer(nbClient libovsdbclient.Client, lb *nbdb.LoadBalancer) (*nbdb.LoadBalancer, error) {
+	found := []*nbdb.LoadBalancer{}
+	opModel := operationModel{
+		Model:          lb,
+		ExistingResult: &found,
+		ErrNotFound:    true,
+		BulkOp:         false,
+	}
+
+	modelClient := newModelClient(nbClient)
+	err := modelClient.Lookup(opModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return found[0], err
+}
+
 // CreateOrUpdateLoadBalancersOps creates or updates the provided load balancers
 // returning the corresponding ops
 func CreateOrUpdateLoadBalancersOps(nbClient libovsdbclient.Client, ops []libovsdb.Operation, lbs ...*nbdb.LoadBalancer) ([]libovsdb.Operation, error) {
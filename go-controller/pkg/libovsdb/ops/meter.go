@@ -64,3 +64,31 @@ func CreateOrUpdateMeterOps(nbClient libovsdbclient.Client, ops []ovsdb.Operatio
 	m := newModelClient(nbClient)
 	return m.CreateOrUpdateOps(ops, opModel)
 }
+
+type meterPredicate func(*nbdb.Meter) bool
+
+// DeleteMetersWithPredicateOps returns the ops to delete the meters matching
+// the provided predicate
+func DeleteMetersWithPredicateOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, p meterPredicate) ([]ovsdb.Operation, error) {
+	deleted := []*nbdb.Meter{}
+	opModel := operationModel{
+		ModelPredicate: p,
+		ExistingResult: &deleted,
+		ErrNotFound:    false,
+		BulkOp:         true,
+	}
+
+	m := newModelClient(nbClient)
+	return m.DeleteOps(ops, opModel)
+}
+
+// DeleteMetersWithPredicate deletes the meters matching the provided predicate
+func DeleteMetersWithPredicate(nbClient libovsdbclient.Client, p meterPredicate) error {
+	ops, err := DeleteMetersWithPredicateOps(nbClient, nil, p)
+	if err != nil {
+		return err
+	}
+
+	_, err = TransactAndCheck(nbClient, ops)
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ovn-org/libovsdb/client"
 	"github.com/ovn-org/libovsdb/model"
 	"github.com/ovn-org/libovsdb/ovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/nbdbext"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/sbdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
@@ -70,6 +71,10 @@ func getUUID(model model.Model) string {
 		return t.UUID
 	case *nbdb.DHCPOptions:
 		return t.UUID
+	case *nbdbext.Sample:
+		return t.UUID
+	case *nbdbext.SampleCollector:
+		return t.UUID
 	default:
 		panic(fmt.Sprintf("getUUID: unknown model %T", t))
 	}
@@ -133,6 +138,10 @@ func setUUID(model model.Model, uuid string) {
 		t.UUID = uuid
 	case *nbdb.DHCPOptions:
 		t.UUID = uuid
+	case *nbdbext.Sample:
+		t.UUID = uuid
+	case *nbdbext.SampleCollector:
+		t.UUID = uuid
 	default:
 		panic(fmt.Sprintf("setUUID: unknown model %T", t))
 	}
@@ -280,6 +289,16 @@ func copyIndexes(model model.Model) model.Model {
 			UUID:        t.UUID,
 			ExternalIDs: copyExternalIDs(t.ExternalIDs, types.PrimaryIDKey),
 		}
+	case *nbdbext.Sample:
+		return &nbdbext.Sample{
+			UUID:     t.UUID,
+			Metadata: t.Metadata,
+		}
+	case *nbdbext.SampleCollector:
+		return &nbdbext.SampleCollector{
+			UUID: t.UUID,
+			ID:   t.ID,
+		}
 	default:
 		panic(fmt.Sprintf("copyIndexes: unknown model %T", t))
 	}
@@ -341,6 +360,10 @@ func getListFromModel(model model.Model) interface{} {
 		return &[]*nbdb.ChassisTemplateVar{}
 	case *nbdb.DHCPOptions:
 		return &[]nbdb.DHCPOptions{}
+	case *nbdbext.Sample:
+		return &[]*nbdbext.Sample{}
+	case *nbdbext.SampleCollector:
+		return &[]*nbdbext.SampleCollector{}
 	default:
 		panic(fmt.Sprintf("getModelList: unknown model %T", t))
 	}
@@ -62,3 +62,27 @@ func UpdateNBGlobalSetOptions(nbClient libovsdbclient.Client, nbGlobal *nbdb.NBG
 	_, err = m.CreateOrUpdate(opModel)
 	return err
 }
+
+// DeleteNBGlobalOptions removes the options from the NB Global entry
+func DeleteNBGlobalOptions(nbClient libovsdbclient.Client, keys ...string) error {
+	nbGlobal, err := GetNBGlobal(nbClient, &nbdb.NBGlobal{})
+	if err != nil {
+		return err
+	}
+
+	nbGlobal.Options = make(map[string]string, len(keys))
+	for _, key := range keys {
+		// an empty value removes the key
+		nbGlobal.Options[key] = ""
+	}
+
+	opModel := operationModel{
+		Model:            nbGlobal,
+		OnModelMutations: []interface{}{&nbGlobal.Options},
+		ErrNotFound:      true,
+		BulkOp:           false,
+	}
+
+	m := newModelClient(nbClient)
+	return m.Delete(opModel)
+}
@@ -1071,6 +1071,27 @@ func CreateOrUpdateNATs(nbClient libovsdbclient.Client, router *nbdb.LogicalRout
 	return err
 }
 
+// UpdateNATsExternalPortRangeOps updates the external port range of the
+// provided existing NATs, clearing it if empty, and returns the corresponding
+// ops
+func UpdateNATsExternalPortRangeOps(nbClient libovsdbclient.Client, ops []libovsdb.Operation, nats ...*nbdb.NAT) ([]libovsdb.Operation, error) {
+	opModels := make([]operationModel, 0, len(nats))
+	for i := range nats {
+		// can't use i in the predicate, for loop replaces it in-memory
+		nat := nats[i]
+		opModel := operationModel{
+			Model:          nat,
+			OnModelUpdates: []interface{}{&nat.ExternalPortRange},
+			ErrNotFound:    true,
+			BulkOp:         false,
+		}
+		opModels = append(opModels, opModel)
+	}
+
+	modelClient := newModelClient(nbClient)
+	return modelClient.CreateOrUpdateOps(ops, opModels...)
+}
+
 // DeleteNATsOps deletes the provided NATs, removes them from the provided
 // logical router and returns the corresponding ops
 func DeleteNATsOps(nbClient libovsdbclient.Client, ops []libovsdb.Operation, router *nbdb.LogicalRouter, nats ...*nbdb.NAT) ([]libovsdb.Operation, error) {
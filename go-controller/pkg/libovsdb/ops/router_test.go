@@ -222,6 +222,105 @@ func TestDeleteNATsFromRouter(t *testing.T) {
 
 }
 
+func TestUpdateNATsExternalPortRange(t *testing.T) {
+	fakeNAT1 := &nbdb.NAT{
+		UUID:              buildNamedUUID(),
+		ExternalIP:        "192.168.1.110",
+		LogicalIP:         "10.128.0.5",
+		Type:              nbdb.NATTypeSNAT,
+		ExternalPortRange: "1024-2047",
+		ExternalIDs:       map[string]string{"name": "fakeNAT1"},
+	}
+
+	fakeNAT2 := &nbdb.NAT{
+		UUID:        buildNamedUUID(),
+		ExternalIP:  "192.168.1.110",
+		LogicalIP:   "10.128.0.6",
+		Type:        nbdb.NATTypeSNAT,
+		ExternalIDs: map[string]string{"name": "fakeNAT2"},
+	}
+
+	initialNbdb := libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{
+			fakeNAT1,
+			fakeNAT2,
+		},
+	}
+
+	tests := []struct {
+		desc      string
+		name      string
+		portRange string
+		expected  []libovsdbtest.TestData
+	}{
+		{
+			desc:      "clear the port range of nat 1",
+			name:      "fakeNAT1",
+			portRange: "",
+			expected: []libovsdbtest.TestData{
+				&nbdb.NAT{
+					UUID:        fakeNAT1.UUID,
+					ExternalIP:  fakeNAT1.ExternalIP,
+					LogicalIP:   fakeNAT1.LogicalIP,
+					Type:        fakeNAT1.Type,
+					ExternalIDs: fakeNAT1.ExternalIDs,
+				},
+				fakeNAT2,
+			},
+		},
+		{
+			desc:      "set the port range of nat 2",
+			name:      "fakeNAT2",
+			portRange: "2048-4095",
+			expected: []libovsdbtest.TestData{
+				fakeNAT1,
+				&nbdb.NAT{
+					UUID:              fakeNAT2.UUID,
+					ExternalIP:        fakeNAT2.ExternalIP,
+					LogicalIP:         fakeNAT2.LogicalIP,
+					Type:              fakeNAT2.Type,
+					ExternalPortRange: "2048-4095",
+					ExternalIDs:       fakeNAT2.ExternalIDs,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(initialNbdb, nil)
+			if err != nil {
+				t.Fatalf("test: \"%s\" failed to set up test harness: %v", tt.desc, err)
+			}
+			t.Cleanup(cleanup.Cleanup)
+
+			nats, err := FindNATsWithPredicate(nbClient, func(item *nbdb.NAT) bool {
+				return item.ExternalIDs["name"] == tt.name
+			})
+			if err != nil || len(nats) != 1 {
+				t.Fatal(fmt.Errorf("test: \"%s\" failed to find nat %s: %v", tt.desc, tt.name, err))
+			}
+			nats[0].ExternalPortRange = tt.portRange
+			ops, err := UpdateNATsExternalPortRangeOps(nbClient, nil, nats...)
+			if err != nil {
+				t.Fatal(fmt.Errorf("UpdateNATsExternalPortRangeOps() error = %v", err))
+			}
+			if _, err = TransactAndCheck(nbClient, ops); err != nil {
+				t.Fatal(fmt.Errorf("test: \"%s\" failed to transact: %v", tt.desc, err))
+			}
+
+			matcher := libovsdbtest.HaveData(tt.expected)
+			success, err := matcher.Match(nbClient)
+			if !success {
+				t.Fatal(fmt.Errorf("test: \"%s\" didn't match expected with actual, err: %v", tt.desc, matcher.FailureMessage(nbClient)))
+			}
+			if err != nil {
+				t.Fatal(fmt.Errorf("test: \"%s\" encountered error: %v", tt.desc, err))
+			}
+		})
+	}
+}
+
 func TestDeleteRoutersWithPredicateOps(t *testing.T) {
 	fakeRouter1 := nbdb.LogicalRouter{
 		Name:        "rtr1",
@@ -0,0 +1,160 @@
+package ops
+
+import (
+	"fmt"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	libovsdb "github.com/ovn-org/libovsdb/ovsdb"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/nbdbext"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+)
+
+type sampleCollectorPredicate func(*nbdbext.SampleCollector) bool
+
+// FindSampleCollectorsWithPredicate looks up sample collectors from the cache
+// based on a given predicate
+func FindSampleCollectorsWithPredicate(nbClient libovsdbclient.Client, p sampleCollectorPredicate) ([]*nbdbext.SampleCollector, error) {
+	found := []*nbdbext.SampleCollector{}
+	opModel := operationModel{
+		ModelPredicate: p,
+		ExistingResult: &found,
+		ErrNotFound:    false,
+		BulkOp:         true,
+	}
+
+	m := newModelClient(nbClient)
+	err := m.Lookup(opModel)
+	return found, err
+}
+
+// CreateOrUpdateSampleCollectorOps creates or updates the provided sample
+// collector, looked up by id, and returns the corresponding ops
+func CreateOrUpdateSampleCollectorOps(nbClient libovsdbclient.Client, ops []libovsdb.Operation,
+	collector *nbdbext.SampleCollector) ([]libovsdb.Operation, error) {
+	opModel := operationModel{
+		Model:          collector,
+		OnModelUpdates: onModelUpdatesAllNonDefault(),
+		ErrNotFound:    false,
+		BulkOp:         false,
+	}
+
+	m := newModelClient(nbClient)
+	return m.CreateOrUpdateOps(ops, opModel)
+}
+
+// DeleteSampleCollectorsWithPredicateOps returns the ops to delete the sample
+// collectors found using the predicate
+func DeleteSampleCollectorsWithPredicateOps(nbClient libovsdbclient.Client, ops []libovsdb.Operation,
+	p sampleCollectorPredicate) ([]libovsdb.Operation, error) {
+	deleted := []*nbdbext.SampleCollector{}
+	opModel := operationModel{
+		ModelPredicate: p,
+		ExistingResult: &deleted,
+		ErrNotFound:    false,
+		BulkOp:         true,
+	}
+
+	m := newModelClient(nbClient)
+	return m.DeleteOps(ops, opModel)
+}
+
+type samplePredicate func(*nbdbext.Sample) bool
+
+// CreateOrUpdateSamplesOps creates the provided samples, looked up by
+// metadata, or updates their collectors and returns the corresponding ops
+func CreateOrUpdateSamplesOps(nbClient libovsdbclient.Client, ops []libovsdb.Operation,
+	samples ...*nbdbext.Sample) ([]libovsdb.Operation, error) {
+	opModels := make([]operationModel, 0, len(samples))
+	for i := range samples {
+		sample := samples[i]
+		opModel := operationModel{
+			Model:          sample,
+			OnModelUpdates: []interface{}{&sample.Collectors},
+			ErrNotFound:    false,
+			BulkOp:         false,
+		}
+		opModels = append(opModels, opModel)
+	}
+
+	m := newModelClient(nbClient)
+	return m.CreateOrUpdateOps(ops, opModels...)
+}
+
+// DeleteSamplesWithPredicateOps returns the ops to delete the samples found
+// using the predicate
+func DeleteSamplesWithPredicateOps(nbClient libovsdbclient.Client, ops []libovsdb.Operation,
+	p samplePredicate) ([]libovsdb.Operation, error) {
+	deleted := []*nbdbext.Sample{}
+	opModel := operationModel{
+		ModelPredicate: p,
+		ExistingResult: &deleted,
+		ErrNotFound:    false,
+		BulkOp:         true,
+	}
+
+	m := newModelClient(nbClient)
+	return m.DeleteOps(ops, opModel)
+}
+
+// SetACLSampleNewOps returns the op setting the sample of the new connections
+// of the ACL with the provided UUID, or clearing it if sampleUUID is empty.
+// The sample_new column is not in the generated ACL model, the op is raw.
+func SetACLSampleNewOps(ops []libovsdb.Operation, aclUUID, sampleUUID string) []libovsdb.Operation {
+	sample := libovsdb.OvsSet{GoSet: []interface{}{}}
+	if sampleUUID != "" {
+		sample.GoSet = append(sample.GoSet, libovsdb.UUID{GoUUID: sampleUUID})
+	}
+	return append(ops, libovsdb.Operation{
+		Op:    libovsdb.OperationUpdate,
+		Table: nbdb.ACLTable,
+		Row:   libovsdb.Row{nbdbext.ACLSampleNewColumn: sample},
+		Where: []libovsdb.Condition{
+			libovsdb.NewCondition("_uuid", libovsdb.ConditionEqual, libovsdb.UUID{GoUUID: aclUUID}),
+		},
+	})
+}
+
+// FindACLsSampleNew returns the samples of the new connections of the ACLs
+// matching the conditions, or of all the ACLs without conditions, by ACL
+// UUID. The sample_new column is not in the generated ACL model, so it is
+// read from the server instead of the cache.
+func FindACLsSampleNew(nbClient libovsdbclient.Client, conditions ...libovsdb.Condition) (map[string]string, error) {
+	ops := []libovsdb.Operation{{
+		Op:      libovsdb.OperationSelect,
+		Table:   nbdb.ACLTable,
+		Where:   conditions,
+		Columns: []string{"_uuid", nbdbext.ACLSampleNewColumn},
+	}}
+	results, err := TransactAndCheck(nbClient, ops)
+	if err != nil {
+		return nil, err
+	}
+	samples := map[string]string{}
+	for _, row := range results[0].Rows {
+		aclUUID, ok := row["_uuid"].(libovsdb.UUID)
+		if !ok {
+			return nil, fmt.Errorf("unexpected UUID %v of ACL", row["_uuid"])
+		}
+		// a set of at most one element is encoded as the element itself, and
+		// an empty one may be left out
+		switch sample := row[nbdbext.ACLSampleNewColumn].(type) {
+		case nil:
+			continue
+		case libovsdb.UUID:
+			samples[aclUUID.GoUUID] = sample.GoUUID
+		case libovsdb.OvsSet:
+			if len(sample.GoSet) == 0 {
+				continue
+			}
+			sampleUUID, ok := sample.GoSet[0].(libovsdb.UUID)
+			if !ok {
+				return nil, fmt.Errorf("unexpected sample %v of ACL %s", sample, aclUUID.GoUUID)
+			}
+			samples[aclUUID.GoUUID] = sampleUUID.GoUUID
+		default:
+			return nil, fmt.Errorf("unexpected sample %v of ACL %s", sample, aclUUID.GoUUID)
+		}
+	}
+	return samples, nil
+}
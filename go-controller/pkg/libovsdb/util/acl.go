@@ -1,16 +1,21 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
 	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util/batching"
 
 	knet "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // aclPipelineType defines when ACLs will be applied (direction and pipeline stage).
@@ -116,7 +121,7 @@ func BuildACL(dbIDs *libovsdbops.DbObjectIDs, priority int, match, action string
 		priority,
 		match,
 		action,
-		types.OvnACLLoggingMeter,
+		GetACLLoggingMeterName(logLevels),
 		logSeverity,
 		log,
 		externalIDs,
@@ -126,9 +131,9 @@ func BuildACL(dbIDs *libovsdbops.DbObjectIDs, priority int, match, action string
 	return ACL
 }
 
-func BuildANPACL(dbIDs *libovsdbops.DbObjectIDs, priority int, match, action string, aclT ACLPipelineType) *nbdb.ACL {
-	// TODO(tssurya): Logging related parameters are nil for now, will fix this in future PRs when I add support for ANP-Logging
-	anpACL := BuildACL(dbIDs, priority, match, action, nil, aclT)
+func BuildANPACL(dbIDs *libovsdbops.DbObjectIDs, priority int, match, action string, aclT ACLPipelineType,
+	logLevels *ACLLoggingLevels) *nbdb.ACL {
+	anpACL := BuildACL(dbIDs, priority, match, action, logLevels, aclT)
 	anpACL.Tier = GetACLTier(dbIDs)
 	return anpACL
 }
@@ -165,6 +170,172 @@ func GetACLMatch(portGroupName, match string, aclDir ACLDirection) string {
 type ACLLoggingLevels struct {
 	Allow string `json:"allow,omitempty"`
 	Deny  string `json:"deny,omitempty"`
+	// Pass is the severity of the ACLs passing the traffic to the next tier,
+	// only set by admin network policies
+	Pass string `json:"pass,omitempty"`
+	// RateLimit is the maximum number of ACL log messages per second, the
+	// cluster-wide rate limit is used if not set
+	RateLimit *int `json:"rateLimit,omitempty"`
+}
+
+// validACLLogSeverities are the valid ACL logging severities, an empty
+// severity disables logging
+var validACLLogSeverities = sets.New[string](nbdb.ACLSeverityAlert, nbdb.ACLSeverityWarning, nbdb.ACLSeverityNotice,
+	nbdb.ACLSeverityInfo, nbdb.ACLSeverityDebug, "")
+
+// ParseACLLoggingAnnotation parses and validates an ACL logging annotation, an
+// empty annotation disables logging.
+func ParseACLLoggingAnnotation(annotation string) (*ACLLoggingLevels, error) {
+	aclLogging := &ACLLoggingLevels{}
+	if annotation == "" {
+		return aclLogging, nil
+	}
+	if err := json.Unmarshal([]byte(annotation), aclLogging); err != nil {
+		return nil, fmt.Errorf("could not unmarshal ACL logging annotation '%s': %v", annotation, err)
+	}
+	if !validACLLogSeverities.Has(aclLogging.Allow) {
+		return nil, fmt.Errorf("%q is not a valid allow log severity", aclLogging.Allow)
+	}
+	if !validACLLogSeverities.Has(aclLogging.Deny) {
+		return nil, fmt.Errorf("%q is not a valid deny log severity", aclLogging.Deny)
+	}
+	if !validACLLogSeverities.Has(aclLogging.Pass) {
+		return nil, fmt.Errorf("%q is not a valid pass log severity", aclLogging.Pass)
+	}
+	if aclLogging.RateLimit != nil && *aclLogging.RateLimit <= 0 {
+		return nil, fmt.Errorf("%d is not a valid log rate limit, it must be greater than 0", *aclLogging.RateLimit)
+	}
+	return aclLogging, nil
+}
+
+// GetACLLoggingMeterName returns the name of the meter that rate limits the
+// logging of ACLs with the given logging levels.
+func GetACLLoggingMeterName(aclLogging *ACLLoggingLevels) string {
+	if aclLogging == nil || aclLogging.RateLimit == nil {
+		return types.OvnACLLoggingMeter
+	}
+	return fmt.Sprintf("%s-%d", types.OvnACLLoggingMeter, *aclLogging.RateLimit)
+}
+
+// CreateOrUpdateACLLoggingMeterOps returns the ops to create or update the
+// ACL logging meter with the given name and rate limit
+func CreateOrUpdateACLLoggingMeterOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, name string,
+	rateLimit int) ([]ovsdb.Operation, error) {
+	band := &nbdb.MeterBand{
+		Action: types.MeterAction,
+		Rate:   rateLimit,
+	}
+	ops, err := libovsdbops.CreateMeterBandOps(nbClient, ops, band)
+	if err != nil {
+		return nil, fmt.Errorf("can't create meter band %v: %v", band, err)
+	}
+
+	meterFairness := true
+	meter := &nbdb.Meter{
+		Name: name,
+		Fair: &meterFairness,
+		Unit: types.PacketsPerSecond,
+	}
+	ops, err = libovsdbops.CreateOrUpdateMeterOps(nbClient, ops, meter, []*nbdb.MeterBand{band},
+		&meter.Bands, &meter.Fair, &meter.Unit)
+	if err != nil {
+		return nil, fmt.Errorf("can't create meter %v: %v", meter, err)
+	}
+	return ops, nil
+}
+
+// aclLoggingMeterRefs reference counts the ACL logging meters with their own
+// rate limit: it holds, for each meter, the keys of the objects whose ACLs use
+// it. A meter is deleted once it is not referenced anymore.
+var aclLoggingMeterRefs = struct {
+	sync.Mutex
+	refs map[string]sets.Set[string]
+}{refs: map[string]sets.Set[string]{}}
+
+// AddACLLoggingMeterRef creates the meter used by ACLs with the given logging
+// levels if they have their own rate limit, and references it by the object
+// with the given key. The meter with the cluster-wide rate limit is created on
+// startup and never deleted.
+func AddACLLoggingMeterRef(nbClient libovsdbclient.Client, objKey string, aclLogging *ACLLoggingLevels) error {
+	if aclLogging == nil || aclLogging.RateLimit == nil {
+		return nil
+	}
+	name := GetACLLoggingMeterName(aclLogging)
+	aclLoggingMeterRefs.Lock()
+	defer aclLoggingMeterRefs.Unlock()
+	ops, err := CreateOrUpdateACLLoggingMeterOps(nbClient, nil, name, *aclLogging.RateLimit)
+	if err != nil {
+		return err
+	}
+	if _, err = libovsdbops.TransactAndCheck(nbClient, ops); err != nil {
+		return fmt.Errorf("can't transact ACL logging meter: %v", err)
+	}
+	if aclLoggingMeterRefs.refs[name] == nil {
+		aclLoggingMeterRefs.refs[name] = sets.New[string]()
+	}
+	aclLoggingMeterRefs.refs[name].Insert(objKey)
+	return nil
+}
+
+// ReleaseACLLoggingMeterRefs drops the references of the object with the given
+// key to the ACL logging meters other than the one used by ACLs with the given
+// logging levels, nil drops all of them. It must be called once the ACLs of
+// the object are updated or deleted, the meters that are not referenced
+// anymore are deleted.
+func ReleaseACLLoggingMeterRefs(nbClient libovsdbclient.Client, objKey string, aclLogging *ACLLoggingLevels) error {
+	keep := GetACLLoggingMeterName(aclLogging)
+	aclLoggingMeterRefs.Lock()
+	defer aclLoggingMeterRefs.Unlock()
+	unused := sets.New[string]()
+	for name, objKeys := range aclLoggingMeterRefs.refs {
+		if name == keep || !objKeys.Has(objKey) {
+			continue
+		}
+		objKeys.Delete(objKey)
+		if objKeys.Len() == 0 {
+			delete(aclLoggingMeterRefs.refs, name)
+			unused.Insert(name)
+		}
+	}
+	if unused.Len() == 0 {
+		return nil
+	}
+	return deleteUnreferencedACLLoggingMeters(nbClient, unused.Has)
+}
+
+// DeleteUnusedACLLoggingMeters deletes the ACL logging meters with their own
+// rate limit that are neither referenced by an object nor used by an ACL, such
+// as the ones left behind by objects deleted while ovnkube-controller was down.
+func DeleteUnusedACLLoggingMeters(nbClient libovsdbclient.Client) error {
+	aclLoggingMeterRefs.Lock()
+	defer aclLoggingMeterRefs.Unlock()
+	return deleteUnreferencedACLLoggingMeters(nbClient, func(name string) bool {
+		return strings.HasPrefix(name, types.OvnACLLoggingMeter+"-") && aclLoggingMeterRefs.refs[name] == nil
+	})
+}
+
+// deleteUnreferencedACLLoggingMeters deletes the meters matching isCandidate
+// that no ACL uses. ACLs may still use a meter that is not referenced by
+// an object when their object was not added yet after a restart.
+// Must be called with aclLoggingMeterRefs locked.
+func deleteUnreferencedACLLoggingMeters(nbClient libovsdbclient.Client, isCandidate func(string) bool) error {
+	acls, err := libovsdbops.FindACLsWithPredicate(nbClient, func(acl *nbdb.ACL) bool {
+		return acl.Meter != nil && isCandidate(*acl.Meter)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the ACLs using ACL logging meters: %v", err)
+	}
+	used := sets.New[string]()
+	for _, acl := range acls {
+		used.Insert(*acl.Meter)
+	}
+	err = libovsdbops.DeleteMetersWithPredicate(nbClient, func(meter *nbdb.Meter) bool {
+		return isCandidate(meter.Name) && !used.Has(meter.Name)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete unused ACL logging meters: %v", err)
+	}
+	return nil
 }
 
 func getLogSeverity(action string, aclLogging *ACLLoggingLevels) (log bool, severity string) {
@@ -174,6 +345,8 @@ func getLogSeverity(action string, aclLogging *ACLLoggingLevels) (log bool, seve
 			severity = aclLogging.Allow
 		} else if action == nbdb.ACLActionDrop || action == nbdb.ACLActionReject {
 			severity = aclLogging.Deny
+		} else if action == nbdb.ACLActionPass {
+			severity = aclLogging.Pass
 		}
 	}
 	log = severity != ""
@@ -194,16 +367,21 @@ func UpdateACLLogging(nbClient libovsdbclient.Client, ACLs []*nbdb.ACL, aclLoggi
 	if len(ACLs) == 0 {
 		return nil
 	}
+	meter := GetACLLoggingMeterName(aclLogging)
 	for i := range ACLs {
 		log, severity := getLogSeverity(ACLs[i].Action, aclLogging)
 		libovsdbops.SetACLLogging(ACLs[i], severity, log)
+		ACLs[i].Meter = &meter
 	}
-	ops, err := libovsdbops.UpdateACLsLoggingOps(nbClient, nil, ACLs...)
-	if err != nil {
-		return fmt.Errorf("unable to get ACL logging ops: %v", err)
-	}
-	if _, err := libovsdbops.TransactAndCheck(nbClient, ops); err != nil {
-		return fmt.Errorf("unable to update ACL logging: %v", err)
-	}
-	return nil
+	// the ACLs of a namespace can be thousands, their update is rolled out
+	return batching.RolloutBatches(batching.NewRollout(0), "ACL logging", ACLs, func(batchACLs []*nbdb.ACL) error {
+		ops, err := libovsdbops.UpdateACLsLoggingOps(nbClient, nil, batchACLs...)
+		if err != nil {
+			return fmt.Errorf("unable to get ACL logging ops: %v", err)
+		}
+		if _, err := libovsdbops.TransactAndCheck(nbClient, ops); err != nil {
+			return fmt.Errorf("unable to update ACL logging: %v", err)
+		}
+		return nil
+	})
 }
@@ -0,0 +1,134 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+func TestParseACLLoggingAnnotation(t *testing.T) {
+	rateLimit := 20
+	tests := []struct {
+		desc       string
+		annotation string
+		expected   *ACLLoggingLevels
+		expectErr  bool
+	}{
+		{
+			desc:       "empty annotation disables logging",
+			annotation: "",
+			expected:   &ACLLoggingLevels{},
+		},
+		{
+			desc:       "severities without rate limit",
+			annotation: `{"allow": "notice", "deny": "alert"}`,
+			expected:   &ACLLoggingLevels{Allow: nbdb.ACLSeverityNotice, Deny: nbdb.ACLSeverityAlert},
+		},
+		{
+			desc:       "severities with rate limit",
+			annotation: `{"allow": "info", "rateLimit": 20}`,
+			expected:   &ACLLoggingLevels{Allow: nbdb.ACLSeverityInfo, RateLimit: &rateLimit},
+		},
+		{
+			desc:       "malformed annotation",
+			annotation: `{"allow": `,
+			expectErr:  true,
+		},
+		{
+			desc:       "invalid severity",
+			annotation: `{"deny": "loud"}`,
+			expectErr:  true,
+		},
+		{
+			desc:       "invalid rate limit",
+			annotation: `{"deny": "alert", "rateLimit": 0}`,
+			expectErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			aclLogging, err := ParseACLLoggingAnnotation(tt.annotation)
+			if tt.expectErr {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(aclLogging).To(gomega.Equal(tt.expected))
+		})
+	}
+}
+
+func TestACLLoggingMeterRefs(t *testing.T) {
+	g := gomega.NewWithT(t)
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+
+	// no rate limit, the cluster-wide meter is used
+	aclLogging := &ACLLoggingLevels{Allow: nbdb.ACLSeverityInfo}
+	g.Expect(GetACLLoggingMeterName(aclLogging)).To(gomega.Equal(types.OvnACLLoggingMeter))
+	g.Expect(AddACLLoggingMeterRef(nbClient, "ns1", aclLogging)).To(gomega.Succeed())
+	g.Eventually(nbClient).Should(libovsdbtest.HaveEmptyData())
+
+	rateLimit := 10
+	aclLogging.RateLimit = &rateLimit
+	g.Expect(GetACLLoggingMeterName(aclLogging)).To(gomega.Equal(types.OvnACLLoggingMeter + "-10"))
+	g.Expect(AddACLLoggingMeterRef(nbClient, "ns1", aclLogging)).To(gomega.Succeed())
+	// adding it again is a no-op
+	g.Expect(AddACLLoggingMeterRef(nbClient, "ns1", aclLogging)).To(gomega.Succeed())
+	g.Expect(AddACLLoggingMeterRef(nbClient, "ns2", aclLogging)).To(gomega.Succeed())
+
+	fair := true
+	band := &nbdb.MeterBand{UUID: "band-UUID", Action: types.MeterAction, Rate: rateLimit}
+	meter := &nbdb.Meter{
+		UUID:  "meter-UUID",
+		Name:  types.OvnACLLoggingMeter + "-10",
+		Fair:  &fair,
+		Unit:  types.PacketsPerSecond,
+		Bands: []string{band.UUID},
+	}
+	g.Eventually(nbClient).Should(libovsdbtest.HaveDataIgnoringUUIDs(band, meter))
+
+	// the meter in use is kept
+	g.Expect(ReleaseACLLoggingMeterRefs(nbClient, "ns1", aclLogging)).To(gomega.Succeed())
+	g.Expect(DeleteUnusedACLLoggingMeters(nbClient)).To(gomega.Succeed())
+	g.Eventually(nbClient).Should(libovsdbtest.HaveDataIgnoringUUIDs(band, meter))
+
+	// the meter still referenced by ns2 is kept
+	g.Expect(ReleaseACLLoggingMeterRefs(nbClient, "ns1", nil)).To(gomega.Succeed())
+	g.Eventually(nbClient).Should(libovsdbtest.HaveDataIgnoringUUIDs(band, meter))
+
+	// the meter is deleted once not referenced anymore
+	g.Expect(ReleaseACLLoggingMeterRefs(nbClient, "ns2", nil)).To(gomega.Succeed())
+	// the unreferenced band is garbage collected by OVSDB, not by the test server
+	g.Eventually(nbClient).Should(libovsdbtest.HaveDataIgnoringUUIDs(band))
+}
+
+func TestDeleteUnusedACLLoggingMeters(t *testing.T) {
+	g := gomega.NewWithT(t)
+	fair := true
+	usedMeterName := types.OvnACLLoggingMeter + "-15"
+	clusterMeter := &nbdb.Meter{UUID: "cluster-meter-UUID", Name: types.OvnACLLoggingMeter, Fair: &fair,
+		Unit: types.PacketsPerSecond}
+	usedMeter := &nbdb.Meter{UUID: "used-meter-UUID", Name: usedMeterName, Fair: &fair,
+		Unit: types.PacketsPerSecond}
+	staleMeter := &nbdb.Meter{UUID: "stale-meter-UUID", Name: types.OvnACLLoggingMeter + "-25", Fair: &fair,
+		Unit: types.PacketsPerSecond}
+	acl := &nbdb.ACL{UUID: "acl-UUID", Action: nbdb.ACLActionAllow, Direction: nbdb.ACLDirectionToLport,
+		Match: "ip4", Priority: 1001, Meter: &usedMeterName}
+	pg := &nbdb.PortGroup{UUID: "pg-UUID", Name: "pg", ACLs: []string{acl.UUID}}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{clusterMeter, usedMeter, staleMeter, acl, pg},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+
+	// the meters used by ACLs, and the cluster-wide one, are kept
+	g.Expect(DeleteUnusedACLLoggingMeters(nbClient)).To(gomega.Succeed())
+	g.Eventually(nbClient).Should(libovsdbtest.HaveData(clusterMeter, usedMeter, acl, pg))
+}
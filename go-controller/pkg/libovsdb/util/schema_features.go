@@ -0,0 +1,121 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SchemaFeature is an optional feature of the OVN Northbound database, only
+// available with the versions of OVN whose schema has its tables or columns.
+// The tables of the features are left out of the model of the client when the
+// schema does not have them, see libovsdb.NBDatabaseModel; the columns of the
+// generated model can't be, so they are not optional.
+type SchemaFeature string
+
+const (
+	// SchemaFeatureTemplateVar is the Chassis_Template_Var table, required
+	// by the template load balancers of the services
+	SchemaFeatureTemplateVar SchemaFeature = "ChassisTemplateVar"
+	// SchemaFeatureSampleCollector is the sampling of the ACLs to the
+	// collectors of the Sample_Collector table
+	SchemaFeatureSampleCollector SchemaFeature = "SampleCollector"
+)
+
+const (
+	schemaFeatureReasonSupported   = "SchemaSupported"
+	schemaFeatureReasonUnsupported = "SchemaUnsupported"
+)
+
+// schemaElement is a table, or a column of a table if column is set
+type schemaElement struct {
+	table  string
+	column string
+}
+
+func (e schemaElement) String() string {
+	if e.column == "" {
+		return "table " + e.table
+	}
+	return fmt.Sprintf("column %s of table %s", e.column, e.table)
+}
+
+// schemaFeatureRequirements are the schema elements each feature requires
+var schemaFeatureRequirements = map[SchemaFeature][]schemaElement{
+	SchemaFeatureTemplateVar:     {{table: "Chassis_Template_Var"}},
+	SchemaFeatureSampleCollector: {{table: "Sample_Collector"}, {table: "ACL", column: "sample_new"}},
+}
+
+// SchemaFeatures are the optional features supported by the schema of the
+// OVN Northbound database ovnkube is connected to
+type SchemaFeatures struct {
+	schemaVersion string
+	// missing holds the schema element missing for each unsupported feature
+	missing map[SchemaFeature]schemaElement
+}
+
+// ProbeSchemaFeatures returns the optional features supported by the schema
+// of the connected OVN Northbound database, so that the features depending on
+// them can be disabled at startup instead of having their transactions fail
+func ProbeSchemaFeatures(nbClient libovsdbclient.Client) *SchemaFeatures {
+	return probeSchemaFeatures(nbClient.Schema())
+}
+
+func probeSchemaFeatures(schema ovsdb.DatabaseSchema) *SchemaFeatures {
+	features := &SchemaFeatures{
+		schemaVersion: schema.Version,
+		missing:       map[SchemaFeature]schemaElement{},
+	}
+	for feature, elements := range schemaFeatureRequirements {
+		for _, element := range elements {
+			table := schema.Table(element.table)
+			if table == nil || element.column != "" && table.Column(element.column) == nil {
+				features.missing[feature] = element
+				break
+			}
+		}
+	}
+	return features
+}
+
+// Supported returns whether the feature is supported
+func (f *SchemaFeatures) Supported(feature SchemaFeature) bool {
+	_, missing := f.missing[feature]
+	return !missing
+}
+
+// Features returns all the optional features, sorted
+func (f *SchemaFeatures) Features() []SchemaFeature {
+	features := make([]SchemaFeature, 0, len(schemaFeatureRequirements))
+	for feature := range schemaFeatureRequirements {
+		features = append(features, feature)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i] < features[j] })
+	return features
+}
+
+// Conditions returns, sorted by feature, a condition of type <feature>Supported
+// for each feature stating whether it is supported and, if not, the schema
+// element it misses
+func (f *SchemaFeatures) Conditions() []metav1.Condition {
+	conditions := make([]metav1.Condition, 0, len(schemaFeatureRequirements))
+	for _, feature := range f.Features() {
+		condition := metav1.Condition{
+			Type:    string(feature) + "Supported",
+			Status:  metav1.ConditionTrue,
+			Reason:  schemaFeatureReasonSupported,
+			Message: fmt.Sprintf("OVN Northbound schema %s supports %s", f.schemaVersion, feature),
+		}
+		if element, missing := f.missing[feature]; missing {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = schemaFeatureReasonUnsupported
+			condition.Message = fmt.Sprintf("OVN Northbound schema %s has no %s required by %s",
+				f.schemaVersion, element, feature)
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
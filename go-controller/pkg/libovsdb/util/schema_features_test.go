@@ -0,0 +1,78 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+)
+
+func TestProbeSchemaFeatures(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	// the schema of the generated model has the template vars but no sample
+	// collectors
+	features := probeSchemaFeatures(nbdb.Schema())
+	g.Expect(features.Supported(SchemaFeatureTemplateVar)).To(gomega.BeTrue())
+	g.Expect(features.Supported(SchemaFeatureSampleCollector)).To(gomega.BeFalse())
+
+	// an older schema
+	schema := ovsdb.DatabaseSchema{
+		Name:    "OVN_Northbound",
+		Version: "6.1.0",
+		Tables: map[string]ovsdb.TableSchema{
+			"ACL": {Columns: map[string]*ovsdb.ColumnSchema{"priority": {Type: ovsdb.TypeInteger}}},
+		},
+	}
+	features = probeSchemaFeatures(schema)
+	g.Expect(features.Supported(SchemaFeatureTemplateVar)).To(gomega.BeFalse())
+	conditions := features.Conditions()
+	g.Expect(conditions).To(gomega.HaveLen(2))
+	g.Expect(conditions[0]).To(gomega.Equal(metav1.Condition{
+		Type:    "ChassisTemplateVarSupported",
+		Status:  metav1.ConditionFalse,
+		Reason:  schemaFeatureReasonUnsupported,
+		Message: "OVN Northbound schema 6.1.0 has no table Chassis_Template_Var required by ChassisTemplateVar",
+	}))
+	g.Expect(conditions[1].Type).To(gomega.Equal("SampleCollectorSupported"))
+	g.Expect(conditions[1].Status).To(gomega.Equal(metav1.ConditionFalse))
+}
+
+func TestProbeSchemaFeaturesOlderServer(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	// a server without the optional tables
+	schema := nbdb.Schema()
+	schema.Version = "6.3.0"
+	delete(schema.Tables, nbdb.ChassisTemplateVarTable)
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{NBSchema: &schema}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+
+	features := ProbeSchemaFeatures(nbClient)
+	g.Expect(features.Supported(SchemaFeatureTemplateVar)).To(gomega.BeFalse())
+	g.Expect(features.Supported(SchemaFeatureSampleCollector)).To(gomega.BeFalse())
+	conditions := features.Conditions()
+	g.Expect(conditions[0].Type).To(gomega.Equal("ChassisTemplateVarSupported"))
+	g.Expect(conditions[0].Status).To(gomega.Equal(metav1.ConditionFalse))
+
+	// the other tables are usable
+	_, err = libovsdbops.FindACLsWithPredicate(nbClient, func(*nbdb.ACL) bool { return true })
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+func TestNBDatabaseModelRequiredColumn(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	// a schema without a column of the generated model is not supported
+	schema := nbdb.Schema()
+	delete(schema.Tables[nbdb.ACLTable].Columns, "tier")
+	_, err := libovsdb.NBDatabaseModel(schema)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("OVN Northbound schema 7.0.4 is not supported")))
+}
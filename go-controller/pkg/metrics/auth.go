@@ -0,0 +1,263 @@
+package metrics
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// authCacheSize is the number of token reviews and access reviews cached
+	authCacheSize = 1024
+	// authCacheTTL is how long the token reviews and access reviews are
+	// cached, so that the scrapes don't hit the API server every time
+	authCacheTTL = time.Minute
+	// authReviewTimeout is the timeout of the token and access reviews
+	authReviewTimeout = 10 * time.Second
+)
+
+// audit decisions
+const (
+	auditDecisionAllow           = "allow"
+	auditDecisionUnauthenticated = "unauthenticated"
+	auditDecisionForbidden       = "forbidden"
+	auditDecisionError           = "error"
+)
+
+// endpointUser is the identity of a request to the metrics servers
+type endpointUser struct {
+	name   string
+	groups []string
+	// method is how the request authenticated, "token" or "certificate"
+	method string
+}
+
+// auditRecord is the record of an access to the metrics servers written to the
+// audit log
+type auditRecord struct {
+	Time           time.Time `json:"time"`
+	Server         string    `json:"server"`
+	Source         string    `json:"source"`
+	User           string    `json:"user,omitempty"`
+	Groups         []string  `json:"groups,omitempty"`
+	Authentication string    `json:"authentication,omitempty"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Decision       string    `json:"decision"`
+	Reason         string    `json:"reason,omitempty"`
+	Status         int       `json:"status"`
+}
+
+// EndpointAuth protects the endpoints of the metrics servers: a request must
+// authenticate with a bearer token, reviewed by the API server, or a client
+// certificate signed by the client CA, and be allowed by a SubjectAccessReview
+// of its path and method, so that each endpoint can be granted with the
+// nonResourceURLs of a ClusterRole. Every access is audited.
+type EndpointAuth struct {
+	client    kubernetes.Interface
+	clientCAs *x509.CertPool
+	// auditLog is where all the accesses are written to as JSON records, if
+	// set, otherwise the accesses to the debug endpoints and the denied ones
+	// are logged
+	auditLog   io.Writer
+	auditMutex sync.Mutex
+	tokens     *cache.LRUExpireCache
+	decisions  *cache.LRUExpireCache
+}
+
+// NewEndpointAuth returns the EndpointAuth reviewing the tokens and the
+// accesses with the API server, trusting the client certificates signed by the
+// CAs of the clientCAFile, if set, and writing its audit records to the
+// auditLog, if set
+func NewEndpointAuth(client kubernetes.Interface, clientCAFile string, auditLog io.Writer) (*EndpointAuth, error) {
+	auth := &EndpointAuth{
+		client:    client,
+		auditLog:  auditLog,
+		tokens:    cache.NewLRUExpireCache(authCacheSize),
+		decisions: cache.NewLRUExpireCache(authCacheSize),
+	}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the metrics client CA %s: %v", clientCAFile, err)
+		}
+		auth.clientCAs = x509.NewCertPool()
+		if !auth.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the metrics client CA %s", clientCAFile)
+		}
+	}
+	return auth, nil
+}
+
+// statusRecorder records the status code of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush lets the pprof and trace handlers stream their response
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// wrap returns the handler serving the requests of the server allowed to
+// access their endpoint
+func (a *EndpointAuth) wrap(server string, handler http.Handler) http.Handler {
+	if a == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		record := &auditRecord{
+			Time:   time.Now(),
+			Server: server,
+			Source: req.RemoteAddr,
+			Method: req.Method,
+			Path:   req.URL.Path,
+		}
+		defer a.audit(record)
+
+		user, err := a.authenticate(req)
+		if err != nil {
+			record.Decision, record.Reason, record.Status = auditDecisionError, err.Error(), http.StatusInternalServerError
+			writePlainText(record.Status, "authentication failed", w)
+			return
+		}
+		if user == nil {
+			record.Decision, record.Status = auditDecisionUnauthenticated, http.StatusUnauthorized
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ovn-kubernetes"`)
+			writePlainText(record.Status, "unauthorized", w)
+			return
+		}
+		record.User, record.Groups, record.Authentication = user.name, user.groups, user.method
+
+		allowed, reason, err := a.authorize(user, req)
+		if err != nil {
+			record.Decision, record.Reason, record.Status = auditDecisionError, err.Error(), http.StatusInternalServerError
+			writePlainText(record.Status, "authorization failed", w)
+			return
+		}
+		if !allowed {
+			record.Decision, record.Reason, record.Status = auditDecisionForbidden, reason, http.StatusForbidden
+			writePlainText(record.Status, fmt.Sprintf("user %q is not allowed to %s %s", user.name,
+				strings.ToLower(req.Method), req.URL.Path), w)
+			return
+		}
+		record.Decision = auditDecisionAllow
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, req)
+		record.Status = recorder.status
+	})
+}
+
+// authenticate returns the user of the request, nil if it is anonymous or its
+// token isn't valid
+func (a *EndpointAuth) authenticate(req *http.Request) (*endpointUser, error) {
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		cert := req.TLS.VerifiedChains[0][0]
+		return &endpointUser{name: cert.Subject.CommonName, groups: cert.Subject.Organization, method: "certificate"}, nil
+	}
+
+	header := req.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, nil
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if token == "" {
+		return nil, nil
+	}
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	if cached, ok := a.tokens.Get(key); ok {
+		return cached.(*endpointUser), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authReviewTimeout)
+	defer cancel()
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review the token: %v", err)
+	}
+	var user *endpointUser
+	if review.Status.Authenticated {
+		user = &endpointUser{name: review.Status.User.Username, groups: review.Status.User.Groups, method: "token"}
+	}
+	a.tokens.Add(key, user, authCacheTTL)
+	return user, nil
+}
+
+// authorize returns whether the user is allowed to access the endpoint of the
+// request with its method, and why not
+func (a *EndpointAuth) authorize(user *endpointUser, req *http.Request) (bool, string, error) {
+	verb := strings.ToLower(req.Method)
+	key := strings.Join([]string{user.name, strings.Join(user.groups, ","), verb, req.URL.Path}, "|")
+	if cached, ok := a.decisions.Get(key); ok {
+		status := cached.(authorizationv1.SubjectAccessReviewStatus)
+		return status.Allowed, status.Reason, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authReviewTimeout)
+	defer cancel()
+	review, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.name,
+			Groups: user.groups,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: req.URL.Path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to review the access: %v", err)
+	}
+	a.decisions.Add(key, review.Status, authCacheTTL)
+	return review.Status.Allowed, review.Status.Reason, nil
+}
+
+// audit writes the record to the audit log, or logs it if it isn't an allowed
+// metrics scrape without an audit log
+func (a *EndpointAuth) audit(record *auditRecord) {
+	if a.auditLog == nil {
+		if record.Decision == auditDecisionAllow && record.Path == "/metrics" {
+			return
+		}
+		klog.Infof("Metrics server %s access: user %q groups %v from %s %s %s: %s %s (%d)", record.Server, record.User,
+			record.Groups, record.Source, record.Method, record.Path, record.Decision, record.Reason, record.Status)
+		return
+	}
+	out, err := json.Marshal(record)
+	if err != nil {
+		klog.Errorf("Failed to marshal the metrics server audit record: %v", err)
+		return
+	}
+	a.auditMutex.Lock()
+	defer a.auditMutex.Unlock()
+	if _, err := a.auditLog.Write(append(out, '\n')); err != nil {
+		klog.Errorf("Failed to write the metrics server audit record: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestEndpointAuth(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	tokenReviews := 0
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tokenReviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "prometheus-token":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{
+				Username: "system:serviceaccount:monitoring:prometheus"}}
+		case "admin-token":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{
+				Username: "admin", Groups: []string{"system:masters"}}}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.NonResourceAttributes
+		switch {
+		case len(review.Spec.Groups) > 0 && review.Spec.Groups[0] == "system:masters":
+			review.Status.Allowed = true
+		case attributes.Path == "/metrics" && attributes.Verb == "get":
+			review.Status.Allowed = true
+		default:
+			review.Status.Reason = "no RBAC policy matched"
+		}
+		return true, review, nil
+	})
+
+	auditLog := &bytes.Buffer{}
+	auth, err := NewEndpointAuth(client, "", auditLog)
+	if err != nil {
+		t.Fatalf("failed to create the auth: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, req *http.Request) { writePlainText(http.StatusOK, "metrics", w) })
+	mux.HandleFunc("/debug/flags/v", stringFlagPutHandler(func(string) (string, error) { return "set", nil }))
+	handler := auth.wrap("127.0.0.1:9410", mux)
+
+	tests := []struct {
+		name     string
+		token    string
+		method   string
+		path     string
+		status   int
+		decision string
+		user     string
+	}{
+		{
+			name:     "anonymous request",
+			method:   http.MethodGet,
+			path:     "/metrics",
+			status:   http.StatusUnauthorized,
+			decision: auditDecisionUnauthenticated,
+		},
+		{
+			name:     "invalid token",
+			token:    "stolen-token",
+			method:   http.MethodGet,
+			path:     "/metrics",
+			status:   http.StatusUnauthorized,
+			decision: auditDecisionUnauthenticated,
+		},
+		{
+			name:     "scrape allowed",
+			token:    "prometheus-token",
+			method:   http.MethodGet,
+			path:     "/metrics",
+			status:   http.StatusOK,
+			decision: auditDecisionAllow,
+			user:     "system:serviceaccount:monitoring:prometheus",
+		},
+		{
+			name:     "debug endpoint forbidden",
+			token:    "prometheus-token",
+			method:   http.MethodPut,
+			path:     "/debug/flags/v",
+			status:   http.StatusForbidden,
+			decision: auditDecisionForbidden,
+			user:     "system:serviceaccount:monitoring:prometheus",
+		},
+		{
+			name:     "debug endpoint allowed",
+			token:    "admin-token",
+			method:   http.MethodPut,
+			path:     "/debug/flags/v",
+			status:   http.StatusOK,
+			decision: auditDecisionAllow,
+			user:     "admin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog.Reset()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("5"))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, want %d", rec.Code, tt.status)
+			}
+			var record auditRecord
+			if err := json.Unmarshal(auditLog.Bytes(), &record); err != nil {
+				t.Fatalf("failed to decode the audit record %q: %v", auditLog.String(), err)
+			}
+			if record.Decision != tt.decision || record.User != tt.user || record.Path != tt.path ||
+				record.Method != tt.method || record.Status != tt.status {
+				t.Errorf("got audit record %+v, want decision %s, user %q and status %d", record, tt.decision, tt.user,
+					tt.status)
+			}
+		})
+	}
+
+	// the token reviews are cached
+	reviews := tokenReviews
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer prometheus-token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if tokenReviews != reviews {
+		t.Errorf("got %d token reviews, want the cached review to be used", tokenReviews-reviews)
+	}
+}
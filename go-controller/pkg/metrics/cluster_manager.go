@@ -3,6 +3,7 @@ package metrics
 import (
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -54,6 +55,87 @@ var metricV6AllocatedHostSubnetCount = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "The total number of v6 host subnets currently allocated",
 })
 
+var metricNetworkHostSubnetCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "network_host_subnets",
+	Help:      "The total number of host subnets possible of a layer3 secondary network, by IP family",
+}, []string{"network", "ip_family"})
+
+var metricNetworkAllocatedHostSubnetCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "network_allocated_host_subnets",
+	Help:      "The total number of host subnets currently allocated of a layer3 secondary network, by IP family",
+}, []string{"network", "ip_family"})
+
+var metricHostSubnetFragmentationRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "host_subnet_fragmentation_ratio",
+	Help: "The share of the free host subnets of a cluster subnet of the default network that are not part of " +
+		"its largest free block, from 0 when they are contiguous to close to 1 when they are scattered",
+}, []string{"cidr"})
+
+var metricHostSubnetLargestFreeBlock = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "host_subnet_largest_free_block",
+	Help:      "The number of host subnets of the largest aligned free block of a cluster subnet of the default network",
+}, []string{"cidr"})
+
+var metricHostSubnetsCompactedCount = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "host_subnets_compacted_total",
+	Help:      "The total number of host subnets of the default network moved by the subnet compaction",
+})
+
+var metricNodeSubnetAllocationLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "node_subnet_allocation_latency_seconds",
+	Help: "The duration from the first add or update event of a node without host subnets to the commit of " +
+		"its host subnets annotation, by network",
+	Buckets: prometheus.ExponentialBuckets(.01, 2, 15)},
+	[]string{"network"},
+)
+
+var metricNodeAnnotationUpdateConflicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "node_annotation_update_conflicts_total",
+	Help:      "The total number of node host subnets annotation updates rejected with a conflict, by network"},
+	[]string{"network"},
+)
+
+var metricNodeAnnotationUpdateRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "node_annotation_update_retries_total",
+	Help: "The total number of node host subnets annotation updates that failed and are retried with " +
+		"the node event, by network"},
+	[]string{"network"},
+)
+
+var metricHostSubnetDuplicates = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "host_subnet_duplicates_total",
+	Help: "The total number of host subnets found on startup annotated on a node while already annotated on " +
+		"an older node, and replaced, by network"},
+	[]string{"network"},
+)
+
+var metricNodeCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "node_capacity",
+	Help: "The number of new nodes that can still be allocated a host subnet of each IP family of a network " +
+		"from a pool of cluster subnets, by network and pool"},
+	[]string{"network", "pool"},
+)
+
 /** EgressIP metrics recorded from cluster-manager begins**/
 var metricEgressIPCount = prometheus.NewGauge(prometheus.GaugeOpts{
 	Namespace: MetricOvnkubeNamespace,
@@ -76,8 +158,37 @@ var metricEgressIPRebalanceCount = prometheus.NewCounter(prometheus.CounterOpts{
 	Help:      "The total number of times assigned egress IP(s) needed to be moved to a different node"},
 )
 
+var metricEgressIPCloudAssignmentFailureCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "egress_ips_cloud_assignment_failures_total",
+	Help:      "The total number of times the cloud provider failed to assign an egress IP to a node, by reason"},
+	[]string{"reason"},
+)
+
+var metricEgressIPCloudDriftCount = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "egress_ips_cloud_drift_total",
+	Help:      "The total number of egress IP cloud assignments found out of sync and reconciled"},
+)
+
 /** EgressIP metrics recorded from cluster-manager ends**/
 
+var metricAllocationSnapshotConflicts = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "allocation_snapshot_conflicts",
+	Help:      "The number of allocations found on startup to conflict with the persisted allocation snapshot",
+})
+
+var metricAllocationSnapshotTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemClusterManager,
+	Name:      "allocation_snapshot_timestamp_seconds",
+	Help:      "The time of the last allocation snapshot successfully persisted, in seconds since the epoch",
+})
+
 // RegisterClusterManagerBase registers ovnkube cluster manager base metrics with the Prometheus registry.
 // This function should only be called once.
 func RegisterClusterManagerBase() {
@@ -112,16 +223,48 @@ func RegisterClusterManagerFunctional() {
 	prometheus.MustRegister(metricV6HostSubnetCount)
 	prometheus.MustRegister(metricV4AllocatedHostSubnetCount)
 	prometheus.MustRegister(metricV6AllocatedHostSubnetCount)
+	if config.OVNKubernetesFeature.EnableMultiNetwork {
+		prometheus.MustRegister(metricNetworkHostSubnetCount)
+		prometheus.MustRegister(metricNetworkAllocatedHostSubnetCount)
+	}
+	if config.ClusterManager.SubnetFragmentationReportInterval > 0 {
+		prometheus.MustRegister(metricHostSubnetFragmentationRatio)
+		prometheus.MustRegister(metricHostSubnetLargestFreeBlock)
+	}
+	if config.ClusterManager.SubnetCompactionBatchSize > 0 {
+		prometheus.MustRegister(metricHostSubnetsCompactedCount)
+	}
+	prometheus.MustRegister(metricNodeSubnetAllocationLatency)
+	prometheus.MustRegister(metricNodeAnnotationUpdateConflicts)
+	prometheus.MustRegister(metricNodeAnnotationUpdateRetries)
+	prometheus.MustRegister(metricHostSubnetDuplicates)
+	prometheus.MustRegister(metricNodeCapacity)
 	if config.OVNKubernetesFeature.EnableEgressIP {
 		prometheus.MustRegister(metricEgressIPNodeUnreacheableCount)
 		prometheus.MustRegister(metricEgressIPRebalanceCount)
 		prometheus.MustRegister(metricEgressIPCount)
+		prometheus.MustRegister(metricEgressIPCloudAssignmentFailureCount)
+		prometheus.MustRegister(metricEgressIPCloudDriftCount)
+	}
+	if config.ClusterManager.AllocationSnapshotPath != "" {
+		prometheus.MustRegister(metricAllocationSnapshotConflicts)
+		prometheus.MustRegister(metricAllocationSnapshotTimestamp)
 	}
 	if err := prometheus.Register(MetricResourceRetryFailuresCount); err != nil {
 		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
 			panic(err)
 		}
 	}
+	if err := prometheus.Register(MetricResourceRetryParkedObjects); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			panic(err)
+		}
+	}
+	if err := prometheus.Register(MetricNetworkReconcileErrors); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			panic(err)
+		}
+	}
 }
 
 // RecordSubnetUsage records the number of subnets allocated for nodes
@@ -137,6 +280,75 @@ func RecordSubnetCount(v4SubnetCount, v6SubnetCount float64) {
 	metricV6HostSubnetCount.Set(v6SubnetCount)
 }
 
+// RecordNetworkSubnetUsage records the number of subnets allocated for nodes
+// of a secondary network
+func RecordNetworkSubnetUsage(network string, v4SubnetsAllocated, v6SubnetsAllocated float64) {
+	metricNetworkAllocatedHostSubnetCount.WithLabelValues(network, "ipv4").Set(v4SubnetsAllocated)
+	metricNetworkAllocatedHostSubnetCount.WithLabelValues(network, "ipv6").Set(v6SubnetsAllocated)
+}
+
+// RecordNetworkSubnetCount records the number of available subnets of a
+// secondary network
+func RecordNetworkSubnetCount(network string, v4SubnetCount, v6SubnetCount float64) {
+	metricNetworkHostSubnetCount.WithLabelValues(network, "ipv4").Set(v4SubnetCount)
+	metricNetworkHostSubnetCount.WithLabelValues(network, "ipv6").Set(v6SubnetCount)
+}
+
+// DeleteNetworkSubnetMetrics deletes the subnet and node annotation metrics of
+// a deleted secondary network
+func DeleteNetworkSubnetMetrics(network string) {
+	labels := prometheus.Labels{"network": network}
+	metricNetworkHostSubnetCount.DeletePartialMatch(labels)
+	metricNetworkAllocatedHostSubnetCount.DeletePartialMatch(labels)
+	metricNodeSubnetAllocationLatency.DeletePartialMatch(labels)
+	metricNodeAnnotationUpdateConflicts.DeletePartialMatch(labels)
+	metricNodeAnnotationUpdateRetries.DeletePartialMatch(labels)
+	metricHostSubnetDuplicates.DeletePartialMatch(labels)
+	metricNodeCapacity.DeletePartialMatch(labels)
+}
+
+// RecordNodeCapacity records the number of new nodes that can still join a
+// network from a pool of cluster subnets
+func RecordNodeCapacity(network, pool string, nodes float64) {
+	metricNodeCapacity.WithLabelValues(network, pool).Set(nodes)
+}
+
+// RecordNodeSubnetAllocationLatency records the duration from the first event
+// of a node without host subnets to the commit of its host subnets annotation
+func RecordNodeSubnetAllocationLatency(network string, duration time.Duration) {
+	metricNodeSubnetAllocationLatency.WithLabelValues(network).Observe(duration.Seconds())
+}
+
+// RecordNodeAnnotationUpdateConflict records a node host subnets annotation
+// update rejected with a conflict
+func RecordNodeAnnotationUpdateConflict(network string) {
+	metricNodeAnnotationUpdateConflicts.WithLabelValues(network).Inc()
+}
+
+// RecordNodeAnnotationUpdateRetry records a failed node host subnets
+// annotation update, retried with the node event
+func RecordNodeAnnotationUpdateRetry(network string) {
+	metricNodeAnnotationUpdateRetries.WithLabelValues(network).Inc()
+}
+
+// RecordHostSubnetDuplicate records a duplicate host subnet found on startup
+func RecordHostSubnetDuplicate(network string) {
+	metricHostSubnetDuplicates.WithLabelValues(network).Inc()
+}
+
+// RecordHostSubnetFragmentation records the fragmentation of a cluster subnet
+// of the default network
+func RecordHostSubnetFragmentation(cidr string, ratio float64, largestFreeBlock uint64) {
+	metricHostSubnetFragmentationRatio.WithLabelValues(cidr).Set(ratio)
+	metricHostSubnetLargestFreeBlock.WithLabelValues(cidr).Set(float64(largestFreeBlock))
+}
+
+// RecordHostSubnetsCompacted records the number of host subnets moved by the
+// subnet compaction
+func RecordHostSubnetsCompacted(count int) {
+	metricHostSubnetsCompactedCount.Add(float64(count))
+}
+
 // RecordEgressIPReachableNode records how many times EgressIP detected an unuseable node.
 func RecordEgressIPUnreachableNode() {
 	metricEgressIPNodeUnreacheableCount.Inc()
@@ -147,8 +359,32 @@ func RecordEgressIPRebalance(count int) {
 	metricEgressIPRebalanceCount.Add(float64(count))
 }
 
+// RecordEgressIPCloudAssignmentFailure records a failure of the cloud provider
+// to assign an egress IP to a node, e.g. because of a quota.
+func RecordEgressIPCloudAssignmentFailure(reason string) {
+	metricEgressIPCloudAssignmentFailureCount.WithLabelValues(reason).Inc()
+}
+
+// RecordEgressIPCloudDrift records how many egress IP cloud assignments were
+// found out of sync and reconciled.
+func RecordEgressIPCloudDrift(count int) {
+	metricEgressIPCloudDriftCount.Add(float64(count))
+}
+
 // RecordEgressIPCount records the total number of Egress IPs.
 // This total may include multiple Egress IPs per EgressIP CR.
 func RecordEgressIPCount(count float64) {
 	metricEgressIPCount.Set(count)
 }
+
+// RecordAllocationSnapshotConflicts records the number of allocations that
+// conflict with the persisted allocation snapshot
+func RecordAllocationSnapshotConflicts(count int) {
+	metricAllocationSnapshotConflicts.Set(float64(count))
+}
+
+// RecordAllocationSnapshotTimestamp records the time of the last persisted
+// allocation snapshot
+func RecordAllocationSnapshotTimestamp(timestamp time.Time) {
+	metricAllocationSnapshotTimestamp.Set(float64(timestamp.Unix()))
+}
@@ -3,6 +3,7 @@ package metrics
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -73,14 +74,31 @@ type stopwatchStatistics struct {
 }
 
 // MetricResourceRetryFailuresCount is the number of times retrying to reconcile a Kubernetes
-// resource reached the maximum retry limit and will not be retried. This metric doesn't
-// need Subsystem string since it is applicable for both master and node.
+// resource reached the maximum retry limit and will not be retried until its next event. This
+// metric doesn't need Subsystem string since it is applicable for both master and node.
 var MetricResourceRetryFailuresCount = prometheus.NewCounter(prometheus.CounterOpts{
 	Namespace: MetricOvnkubeNamespace,
 	Name:      "resource_retry_failures_total",
 	Help:      "The total number of times processing a Kubernetes resource reached the maximum retry limit and was no longer processed",
 })
 
+// MetricResourceRetryParkedObjects is the number of Kubernetes resources that reached the
+// maximum retry limit and are no longer retried until their next event, by resource type.
+var MetricResourceRetryParkedObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Name:      "resource_retry_parked_objects",
+	Help:      "The number of Kubernetes resources that reached the maximum retry limit and are parked until their next event",
+}, []string{"resource"})
+
+// MetricNetworkReconcileErrors is the number of failed attempts to reconcile
+// the Kubernetes resources of each network, by network and resource type, so
+// that a network failing to reconcile can be told from the others.
+var MetricNetworkReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Name:      "network_reconcile_errors_total",
+	Help:      "The total number of failed attempts to reconcile the Kubernetes resources of each network",
+}, []string{"network", "resource"})
+
 // OVN/OVS components, namely ovn-northd, ovn-controller, and ovs-vswitchd provide various
 // metrics through the 'coverage/show' command. The following data structure holds all the
 // metrics we are interested in that output for a given component. We generalize capturing
@@ -398,7 +416,8 @@ func checkPodRunsOnGivenNode(clientset kubernetes.Interface, labels []string, k8
 
 // using the cyrpto/tls module's GetCertificate() callback function helps in picking up
 // the latest certificate (due to cert rotation on cert expiry)
-func getTLSServer(addr, certFile, privKeyFile string, handler http.Handler) *http.Server {
+// The client certificates signed by the client CAs of the auth, if any, are verified.
+func getTLSServer(addr, certFile, privKeyFile string, handler http.Handler, auth *EndpointAuth) *http.Server {
 	tlsConfig := &tls.Config{
 		GetCertificate: func(info *tls.ClientHelloInfo) (*tls.Certificate, error) {
 			cert, err := tls.LoadX509KeyPair(certFile, privKeyFile)
@@ -408,6 +427,10 @@ func getTLSServer(addr, certFile, privKeyFile string, handler http.Handler) *htt
 			return &cert, nil
 		},
 	}
+	if auth != nil && auth.clientCAs != nil {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		tlsConfig.ClientCAs = auth.clientCAs
+	}
 	server := &http.Server{
 		Addr:      addr,
 		Handler:   handler,
@@ -461,12 +484,58 @@ func writePlainText(statusCode int, text string, w http.ResponseWriter) {
 	fmt.Fprintln(w, text)
 }
 
+// httpHandlers holds additional handlers, keyed by path, that are served by the
+// metrics server. Handlers may be registered after the server has started.
+var httpHandlers = struct {
+	sync.RWMutex
+	handlers map[string]http.Handler
+}{handlers: map[string]http.Handler{}}
+
+// RegisterHTTPHandler registers a handler to be served by the metrics server
+// on the given path. Registering a handler for an already registered path
+// replaces the previous handler.
+func RegisterHTTPHandler(path string, handler http.Handler) {
+	httpHandlers.Lock()
+	defer httpHandlers.Unlock()
+	httpHandlers.handlers[path] = handler
+}
+
+// serveRegisteredHTTPHandler dispatches the request to the handler registered
+// for the request path, if any.
+func serveRegisteredHTTPHandler(w http.ResponseWriter, req *http.Request) {
+	httpHandlers.RLock()
+	handler, ok := httpHandlers.handlers[req.URL.Path]
+	httpHandlers.RUnlock()
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	handler.ServeHTTP(w, req)
+}
+
+// ipv6OnlyCompatibilityPath is the path the IPv6-only compatibility matrix of
+// the features of the cluster is served on
+const ipv6OnlyCompatibilityPath = "/ipv6-only-compatibility"
+
+// RegisterIPv6OnlyCompatibilityHandler serves the IPv6-only compatibility
+// matrix of the features of the cluster as JSON on the metrics server
+func RegisterIPv6OnlyCompatibilityHandler() {
+	RegisterHTTPHandler(ipv6OnlyCompatibilityPath, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(config.GetIPv6OnlyCompatibility()); err != nil {
+			klog.Errorf("Failed to write the IPv6-only compatibility matrix: %v", err)
+		}
+	}))
+}
+
 // StartMetricsServer runs the prometheus listener so that OVN K8s metrics can be collected
-// It puts the endpoint behind TLS if certFile and keyFile are defined.
-func StartMetricsServer(bindAddress string, enablePprof bool, certFile string, keyFile string,
+// It puts the endpoint behind TLS if certFile and keyFile are defined, and its endpoints
+// behind the auth if not nil.
+func StartMetricsServer(bindAddress string, enablePprof bool, certFile string, keyFile string, auth *EndpointAuth,
 	stopChan <-chan struct{}, wg *sync.WaitGroup) {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/", serveRegisteredHTTPHandler)
 
 	if enablePprof {
 		mux.HandleFunc("/debug/pprof/", pprof.Index)
@@ -478,6 +547,7 @@ func StartMetricsServer(bindAddress string, enablePprof bool, certFile string, k
 		// Allow changes to log level at runtime
 		mux.HandleFunc("/debug/flags/v", stringFlagPutHandler(klogSetter))
 	}
+	handler := auth.wrap(bindAddress, mux)
 	wg.Add(1)
 
 	go func() {
@@ -487,12 +557,12 @@ func StartMetricsServer(bindAddress string, enablePprof bool, certFile string, k
 			klog.Infof("Starting metrics server to serve at address %q", bindAddress)
 			var err error
 			if certFile != "" && keyFile != "" {
-				server = getTLSServer(bindAddress, certFile, keyFile, mux)
+				server = getTLSServer(bindAddress, certFile, keyFile, handler, auth)
 				err = server.ListenAndServeTLS("", "")
 			} else {
 				server = &http.Server{
 					Addr:    bindAddress,
-					Handler: mux,
+					Handler: handler,
 				}
 				err = server.ListenAndServe()
 			}
@@ -515,12 +585,13 @@ func StartMetricsServer(bindAddress string, enablePprof bool, certFile string, k
 var ovnRegistry = prometheus.NewRegistry()
 
 // StartOVNMetricsServer runs the prometheus listener so that OVN metrics can be collected
-func StartOVNMetricsServer(bindAddress, certFile, keyFile string,
+// Its endpoint is put behind the auth if not nil.
+func StartOVNMetricsServer(bindAddress, certFile, keyFile string, auth *EndpointAuth,
 	stopChan <-chan struct{}, wg *sync.WaitGroup) {
-	handler := promhttp.InstrumentMetricHandler(ovnRegistry,
-		promhttp.HandlerFor(ovnRegistry, promhttp.HandlerOpts{}))
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", handler)
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(ovnRegistry,
+		promhttp.HandlerFor(ovnRegistry, promhttp.HandlerOpts{})))
+	handler := auth.wrap(bindAddress, mux)
 
 	var server *http.Server
 	wg.Add(1)
@@ -531,12 +602,12 @@ func StartOVNMetricsServer(bindAddress, certFile, keyFile string,
 			klog.Infof("Starting OVN related metrics server to serve at address %q", bindAddress)
 			var err error
 			if certFile != "" && keyFile != "" {
-				server = getTLSServer(bindAddress, certFile, keyFile, mux)
+				server = getTLSServer(bindAddress, certFile, keyFile, handler, auth)
 				err = server.ListenAndServeTLS("", "")
 			} else {
 				server = &http.Server{
 					Addr:    bindAddress,
-					Handler: mux,
+					Handler: handler,
 				}
 				err = server.ListenAndServe()
 			}
@@ -3,6 +3,13 @@ package metrics
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	kapimtypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/sbdb"
 )
 
 func Test_parseStopwatchShowOutput(t *testing.T) {
@@ -133,3 +140,138 @@ Statistics for 'ovn-northd-loop'
 		})
 	}
 }
+
+func TestRecordNetworkSubnetUsage(t *testing.T) {
+	getGauge := func(gaugeVec *prometheus.GaugeVec, network, ipFamily string) float64 {
+		metric := &dto.Metric{}
+		if err := gaugeVec.WithLabelValues(network, ipFamily).Write(metric); err != nil {
+			t.Fatal(err)
+		}
+		return metric.GetGauge().GetValue()
+	}
+
+	RecordNetworkSubnetCount("blue", 256, 0)
+	RecordNetworkSubnetUsage("blue", 3, 0)
+	RecordNetworkSubnetCount("red", 16, 65536)
+	RecordNetworkSubnetUsage("red", 16, 2)
+	if count := getGauge(metricNetworkHostSubnetCount, "red", "ipv6"); count != 65536 {
+		t.Fatalf("expected 65536 IPv6 host subnets for network red, got %v", count)
+	}
+	if used := getGauge(metricNetworkAllocatedHostSubnetCount, "blue", "ipv4"); used != 3 {
+		t.Fatalf("expected 3 allocated IPv4 host subnets for network blue, got %v", used)
+	}
+
+	// the metrics of a deleted network are removed, not those of the others
+	DeleteNetworkSubnetMetrics("red")
+	if series := testCollect(metricNetworkHostSubnetCount); series != 2 {
+		t.Fatalf("expected the 2 series of network blue to remain, got %d series", series)
+	}
+	if series := testCollect(metricNetworkAllocatedHostSubnetCount); series != 2 {
+		t.Fatalf("expected the 2 series of network blue to remain, got %d series", series)
+	}
+}
+
+func TestRecordNodeAnnotationMetrics(t *testing.T) {
+	RecordNodeSubnetAllocationLatency("blue", 300*time.Millisecond)
+	RecordNodeSubnetAllocationLatency("blue", 2*time.Second)
+	RecordNodeAnnotationUpdateConflict("blue")
+	RecordNodeAnnotationUpdateConflict("blue")
+	RecordNodeAnnotationUpdateRetry("blue")
+	RecordNodeAnnotationUpdateConflict("red")
+
+	metric := &dto.Metric{}
+	if err := metricNodeSubnetAllocationLatency.WithLabelValues("blue").(prometheus.Histogram).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	if count := metric.GetHistogram().GetSampleCount(); count != 2 {
+		t.Fatalf("expected 2 allocation latencies for network blue, got %d", count)
+	}
+	if sum := metric.GetHistogram().GetSampleSum(); sum != 2.3 {
+		t.Fatalf("expected the allocation latencies of network blue to sum up to 2.3s, got %v", sum)
+	}
+	metric = &dto.Metric{}
+	if err := metricNodeAnnotationUpdateConflicts.WithLabelValues("blue").Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	if conflicts := metric.GetCounter().GetValue(); conflicts != 2 {
+		t.Fatalf("expected 2 annotation update conflicts for network blue, got %v", conflicts)
+	}
+
+	// the metrics of a deleted network are removed, not those of the others
+	DeleteNetworkSubnetMetrics("blue")
+	if series := testCollect(metricNodeSubnetAllocationLatency); series != 0 {
+		t.Fatalf("expected no allocation latency series to remain, got %d series", series)
+	}
+	if series := testCollect(metricNodeAnnotationUpdateConflicts); series != 1 {
+		t.Fatalf("expected the conflicts series of network red to remain, got %d series", series)
+	}
+	if series := testCollect(metricNodeAnnotationUpdateRetries); series != 0 {
+		t.Fatalf("expected no retries series to remain, got %d series", series)
+	}
+}
+
+// testCollect returns the number of series of the collector
+func testCollect(collector prometheus.Collector) int {
+	ch := make(chan prometheus.Metric, 16)
+	collector.Collect(ch)
+	close(ch)
+	return len(ch)
+}
+
+func TestPodRecorderNetworkReady(t *testing.T) {
+	pr := &PodRecorder{records: map[kapimtypes.UID]*record{}}
+	start := time.Now()
+	at := func(seconds float64) time.Time {
+		return start.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	portBinding := func(uid kapimtypes.UID, chassis *string, up bool) *sbdb.PortBinding {
+		return &sbdb.PortBinding{
+			ExternalIDs: map[string]string{"pod": "true"},
+			Options:     map[string]string{"iface-id-ver": string(uid)},
+			Chassis:     chassis,
+			Up:          &up,
+		}
+	}
+	chassis := "chassis-uuid"
+	// pod1 is created and its network set up, pod2 was already running
+	for _, uid := range []kapimtypes.UID{"pod1", "pod2"} {
+		i := item{op: addPod, uid: uid, timestamp: at(0.5), node: "node1"}
+		if uid == "pod1" {
+			i.created = start
+		}
+		pr.processItem(i)
+		pr.processItem(item{op: addPodAnnotation, uid: uid, timestamp: at(1)})
+		pr.processItem(item{op: addLogicalSwitchPort, uid: uid, timestamp: at(2)})
+		pr.processItem(item{op: addPortBinding, old: portBinding(uid, nil, false), timestamp: at(3)})
+		pr.processItem(item{op: updatePortBinding, old: portBinding(uid, nil, false),
+			new: portBinding(uid, &chassis, false), timestamp: at(4)})
+		pr.processItem(item{op: updatePortBinding, old: portBinding(uid, &chassis, false),
+			new: portBinding(uid, &chassis, true), timestamp: at(6)})
+	}
+	if len(pr.records) != 0 {
+		t.Fatalf("expected the records of the pods with their port binding up to be removed, got %v", pr.records)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		observer prometheus.Observer
+		latency  float64
+	}{
+		{"end-to-end", metricPodNetworkReadyLatency, 6},
+		{"node node1", metricPodNetworkReadyNodeLatency.WithLabelValues("node1"), 6},
+		{"stage annotation", metricPodNetworkReadyStageLatency.WithLabelValues(networkReadyStageAnnotation), 1},
+		{"stage binding", metricPodNetworkReadyStageLatency.WithLabelValues(networkReadyStageBinding), 3},
+		{"stage flows", metricPodNetworkReadyStageLatency.WithLabelValues(networkReadyStageFlows), 2},
+	} {
+		metric := &dto.Metric{}
+		if err := tc.observer.(prometheus.Histogram).Write(metric); err != nil {
+			t.Fatal(err)
+		}
+		if count := metric.GetHistogram().GetSampleCount(); count != 1 {
+			t.Fatalf("expected a single %s latency of the created pod, got %d", tc.name, count)
+		}
+		if sum := metric.GetHistogram().GetSampleSum(); sum != tc.latency {
+			t.Fatalf("expected a %s latency of %vs, got %vs", tc.name, tc.latency, sum)
+		}
+	}
+}
@@ -34,6 +34,64 @@ var metricOvnNodePortEnabled = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "Specifies if the node port is enabled on this node(1) or not(0).",
 })
 
+var metricEgressIPActiveConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "egress_ip_active_connections",
+	Help:      "The number of connections tracked by conntrack on this node that are SNATed to an egress IP.",
+},
+	[]string{"egressip", "ip"},
+)
+
+var metricEgressIPBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "egress_ip_bytes_total",
+	Help: "The total number of bytes of the connections SNATed to an egress IP on this node, as accounted by conntrack. " +
+		"The direction is egress for bytes sent by the pods and ingress for bytes they received.",
+},
+	[]string{"egressip", "ip", "direction"},
+)
+
+var metricEgressIPDestinationConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "egress_ip_max_destination_connections",
+	Help: "The highest number of connections tracked by conntrack on this node that are SNATed to an egress IP towards " +
+		"a single destination protocol, IP and port. Each of them holds a source port of the egress IP.",
+},
+	[]string{"egressip", "ip"},
+)
+
+var metricEgressIPPackets = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "egress_ip_packets_total",
+	Help: "The total number of packets of the connections SNATed to an egress IP on this node, as accounted by conntrack. " +
+		"The direction is egress for packets sent by the pods and ingress for packets they received.",
+},
+	[]string{"egressip", "ip", "direction"},
+)
+
+var metricEgressIPRejectedConnections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "egress_ip_rejected_connections_total",
+	Help:      "The total number of packets of new connections rejected on this node because an EgressIP reached its maximum number of connections.",
+},
+	[]string{"egressip"},
+)
+
+var metricTunnelTuning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "tunnel_tuning",
+	Help: "The settings of the NIC carrying the tunnel traffic of this node before and after their tuning: " +
+		"the number of queues, of IRQs, of IRQs on the CPUs of the NUMA node of the NIC and of UDP offloads enabled.",
+},
+	[]string{"interface", "setting", "state"},
+)
+
 var registerNodeMetricsOnce sync.Once
 
 func RegisterNodeMetrics() {
@@ -61,10 +119,85 @@ func RegisterNodeMetrics() {
 			func() float64 { return 1 },
 		))
 		registerWorkqueueMetrics(MetricOvnkubeNamespace, MetricOvnkubeSubsystemNode)
+		if config.OVNKubernetesFeature.EnableEgressIP {
+			prometheus.MustRegister(metricEgressIPRejectedConnections)
+		}
+		if config.OVNKubernetesFeature.EnableEgressIP && config.Metrics.EnableEgressIPUsageMetrics {
+			prometheus.MustRegister(metricEgressIPActiveConnections)
+			prometheus.MustRegister(metricEgressIPBytes)
+			prometheus.MustRegister(metricEgressIPDestinationConnections)
+			prometheus.MustRegister(metricEgressIPPackets)
+		}
+		if config.OvnKubeNode.TunnelTuning {
+			prometheus.MustRegister(metricTunnelTuning)
+		}
 		if err := prometheus.Register(MetricResourceRetryFailuresCount); err != nil {
 			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
 				panic(err)
 			}
 		}
+		if err := prometheus.Register(MetricResourceRetryParkedObjects); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				panic(err)
+			}
+		}
+		if err := prometheus.Register(MetricNetworkReconcileErrors); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				panic(err)
+			}
+		}
 	})
 }
+
+// RecordEgressIPActiveConnections records the number of connections currently
+// SNATed to the egress IP ip of the EgressIP name on this node.
+func RecordEgressIPActiveConnections(name, ip string, count int) {
+	metricEgressIPActiveConnections.WithLabelValues(name, ip).Set(float64(count))
+}
+
+// RecordEgressIPBytes records bytes exchanged over the connections SNATed to
+// the egress IP ip of the EgressIP name on this node in the given direction.
+func RecordEgressIPBytes(name, ip, direction string, bytes uint64) {
+	metricEgressIPBytes.WithLabelValues(name, ip, direction).Add(float64(bytes))
+}
+
+// RecordEgressIPDestinationConnections records the highest number of
+// connections currently SNATed to the egress IP ip of the EgressIP name on this
+// node towards a single destination.
+func RecordEgressIPDestinationConnections(name, ip string, count int) {
+	metricEgressIPDestinationConnections.WithLabelValues(name, ip).Set(float64(count))
+}
+
+// RecordEgressIPPackets records packets exchanged over the connections SNATed
+// to the egress IP ip of the EgressIP name on this node in the given direction.
+func RecordEgressIPPackets(name, ip, direction string, packets uint64) {
+	metricEgressIPPackets.WithLabelValues(name, ip, direction).Add(float64(packets))
+}
+
+// DeleteEgressIPUsageMetrics deletes the usage metrics of the egress IP ip of
+// the EgressIP name once it is not assigned to this node anymore.
+func DeleteEgressIPUsageMetrics(name, ip string) {
+	labels := prometheus.Labels{"egressip": name, "ip": ip}
+	metricEgressIPActiveConnections.DeletePartialMatch(labels)
+	metricEgressIPBytes.DeletePartialMatch(labels)
+	metricEgressIPDestinationConnections.DeletePartialMatch(labels)
+	metricEgressIPPackets.DeletePartialMatch(labels)
+}
+
+// RecordEgressIPRejectedConnections records packets of new connections rejected
+// because the EgressIP name reached its maximum number of connections.
+func RecordEgressIPRejectedConnections(name string, count uint64) {
+	metricEgressIPRejectedConnections.WithLabelValues(name).Add(float64(count))
+}
+
+// DeleteEgressIPRejectedConnections deletes the rejected connections metric of
+// the EgressIP name once its connections are not limited on this node anymore.
+func DeleteEgressIPRejectedConnections(name string) {
+	metricEgressIPRejectedConnections.DeleteLabelValues(name)
+}
+
+// RecordTunnelTuning records the value of a setting of the NIC carrying the
+// tunnel traffic, before or after its tuning.
+func RecordTunnelTuning(nic, setting, state string, value int) {
+	metricTunnelTuning.WithLabelValues(nic, setting, state).Set(float64(value))
+}
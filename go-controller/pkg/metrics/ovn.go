@@ -7,6 +7,7 @@ import (
 
 	"k8s.io/klog/v2"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -338,7 +339,7 @@ func getPortCount(portType string) float64 {
 	switch portType {
 	case "patch":
 		for _, portName := range portNames {
-			if strings.Contains(portName, "br-int") {
+			if strings.Contains(portName, config.OvnKubeNode.IntegrationBridge) {
 				portCount++
 			}
 		}
@@ -433,9 +434,9 @@ func RegisterOvnControllerMetrics(stopChan <-chan struct{}) {
 			Name:      "integration_bridge_openflow_total",
 			Help:      "The total number of OpenFlow flows in the integration bridge.",
 		}, func() float64 {
-			stdout, stderr, err := util.RunOVSOfctl("-t", "5", "dump-aggregate", "br-int")
+			stdout, stderr, err := util.RunOVSOfctl("-t", "5", "dump-aggregate", config.OvnKubeNode.IntegrationBridge)
 			if err != nil {
-				klog.Errorf("Failed to get flow count for br-int, stderr(%s): (%v)",
+				klog.Errorf("Failed to get flow count for the integration bridge, stderr(%s): (%v)",
 					stderr, err)
 				return 0
 			}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -190,6 +191,55 @@ var metricNetpolEventLatency = prometheus.NewHistogramVec(prometheus.HistogramOp
 		"event",
 	})
 
+// metricNetworkPolicyCompileLatency is the time it takes to translate a
+// network policy to OVN ACLs and port groups, per network.
+var metricNetworkPolicyCompileLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "network_policy_compile_latency_seconds",
+	Help:      "The latency of compiling a network policy into OVN ACLs and port groups per network",
+	Buckets:   prometheus.ExponentialBuckets(.004, 2, 15)},
+	[]string{
+		"network",
+	})
+
+// metricNetworkPoliciesCompiled is the number of network policies successfully
+// compiled into OVN ACLs and port groups, per network.
+var metricNetworkPoliciesCompiled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "network_policies_compiled_total",
+	Help:      "The total number of network policies compiled into OVN ACLs and port groups per network"},
+	[]string{
+		"network",
+	})
+
+// metricStaleObjectsDeleted is the number of port groups and address sets
+// garbage collected because their owning objects no longer exist, per network
+// and table.
+var metricStaleObjectsDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "stale_objects_deleted_total",
+	Help:      "The total number of port groups and address sets deleted because their owning objects no longer exist"},
+	[]string{
+		"network",
+		"table",
+	})
+
+// metricPodIPMismatches is the number of local pods of the default network
+// whose IPs don't match between their status, pod annotation and logical
+// switch port, per kind of mismatch.
+var metricPodIPMismatches = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "pod_ip_mismatches",
+	Help: "The number of local pods whose IPs in their pod annotation don't match those reported by the kubelet " +
+		"(kubelet) or those of their logical switch port (logical_switch_port)"},
+	[]string{
+		"kind",
+	})
+
 var metricNetpolLocalPodEventLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 	Namespace: MetricOvnkubeNamespace,
 	Subsystem: MetricOvnkubeSubsystemController,
@@ -261,6 +311,17 @@ var metricEgressRoutingViaHost = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "Specifies whether egress gateway mode is via host networking stack(1) or not(0)",
 })
 
+var metricOVNSchemaFeatureSupported = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "ovn_schema_feature_supported",
+	Help:      "Specifies whether the optional OVN Northbound schema feature is supported(1) or not(0)",
+},
+	[]string{
+		"feature",
+	},
+)
+
 var metricEgressFirewallCount = prometheus.NewGauge(prometheus.GaugeOpts{
 	Namespace: MetricOvnkubeNamespace,
 	Subsystem: MetricOvnkubeSubsystemController,
@@ -301,6 +362,53 @@ var metricPortBindingUpLatency = prometheus.NewHistogram(prometheus.HistogramOpt
 	Buckets:   prometheus.ExponentialBuckets(.01, 2, 15),
 })
 
+// networkReadyBuckets are the buckets of the pod network readiness SLI
+var networkReadyBuckets = merge(
+	prometheus.LinearBuckets(0.25, 0.25, 2), // 0.25s, 0.50s
+	prometheus.LinearBuckets(1, 1, 59),      // 1s, 2s, 3s, ... 59s
+	prometheus.LinearBuckets(60, 5, 12),     // 60s, 65s, 70s, ... 115s
+	prometheus.LinearBuckets(120, 30, 11))   // 2min, 2.5min, 3min, ..., 7min
+
+// metricPodNetworkReadyLatency is the end-to-end time between a pod creation
+// and its OpenFlow flows installed on its node, i.e. its port binding up
+var metricPodNetworkReadyLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "pod_network_ready_duration_seconds",
+	Help:      "The duration between a pod creation and its port binding up, once its flows are installed on its node",
+	Buckets:   networkReadyBuckets,
+})
+
+var metricPodNetworkReadyNodeLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "pod_network_ready_node_duration_seconds",
+	Help:      "The duration between a pod creation and its port binding up, once its flows are installed on its node, by node",
+	Buckets:   networkReadyBuckets,
+}, []string{
+	"node",
+})
+
+// metricPodNetworkReadyStageLatency breaks down the end-to-end time between a
+// pod creation and its port binding up in stages
+var metricPodNetworkReadyStageLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemController,
+	Name:      "pod_network_ready_stage_duration_seconds",
+	Help: "The duration of each stage between a pod creation and its port binding up: pod creation to pod annotation " +
+		"set (annotation), pod annotation set to port binding bound to the chassis of its node (binding), and port " +
+		"binding bound to port binding up (flows)",
+	Buckets: networkReadyBuckets,
+}, []string{
+	"stage",
+})
+
+const (
+	networkReadyStageAnnotation = "annotation"
+	networkReadyStageBinding    = "binding"
+	networkReadyStageFlows      = "flows"
+)
+
 var metricNetworkProgramming prometheus.ObserverVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 	Namespace: MetricOvnkubeNamespace,
 	Subsystem: MetricOvnkubeSubsystemController,
@@ -374,6 +482,7 @@ func RegisterOVNKubeControllerPerformance(nbClient libovsdbclient.Client) {
 	// This is set to not create circular import between metrics and util package
 	util.MetricOvnCliLatency = metricOvnCliLatency
 	registerWorkqueueMetrics(MetricOvnkubeNamespace, MetricOvnkubeSubsystemController)
+	prometheus.MustRegister(newNetworkPolicyACLCollector(nbClient))
 	prometheus.MustRegister(prometheus.NewGaugeFunc(
 		prometheus.GaugeOpts{
 			Namespace: MetricOvnNamespace,
@@ -402,14 +511,29 @@ func RegisterOVNKubeControllerFunctional() {
 		prometheus.MustRegister(metricPodSelectorAddrSetNamespaceEventLatency)
 		prometheus.MustRegister(metricPodEventLatency)
 	}
+	prometheus.MustRegister(metricNetworkPolicyCompileLatency)
+	prometheus.MustRegister(metricNetworkPoliciesCompiled)
+	prometheus.MustRegister(metricStaleObjectsDeleted)
+	prometheus.MustRegister(metricPodIPMismatches)
 	prometheus.MustRegister(metricEgressFirewallRuleCount)
 	prometheus.MustRegister(metricEgressFirewallCount)
 	prometheus.MustRegister(metricEgressRoutingViaHost)
+	prometheus.MustRegister(metricOVNSchemaFeatureSupported)
 	if err := prometheus.Register(MetricResourceRetryFailuresCount); err != nil {
 		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
 			panic(err)
 		}
 	}
+	if err := prometheus.Register(MetricResourceRetryParkedObjects); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			panic(err)
+		}
+	}
+	if err := prometheus.Register(MetricNetworkReconcileErrors); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			panic(err)
+		}
+	}
 }
 
 // RunTimestamp adds a goroutine that registers and updates timestamp metrics.
@@ -495,6 +619,25 @@ func RecordNetpolEvent(eventName string, duration time.Duration) {
 	metricNetpolEventLatency.WithLabelValues(eventName).Observe(duration.Seconds())
 }
 
+// RecordNetworkPolicyCompiled records a network policy successfully compiled
+// for the given network and how long it took.
+func RecordNetworkPolicyCompiled(network string, duration time.Duration) {
+	metricNetworkPolicyCompileLatency.WithLabelValues(network).Observe(duration.Seconds())
+	metricNetworkPoliciesCompiled.WithLabelValues(network).Inc()
+}
+
+// RecordStaleObjectsDeleted records the number of stale objects of the table
+// garbage collected for the given network.
+func RecordStaleObjectsDeleted(network, table string, count int) {
+	metricStaleObjectsDeleted.WithLabelValues(network, table).Add(float64(count))
+}
+
+// RecordPodIPMismatches records the number of local pods with the given kind
+// of IP mismatch.
+func RecordPodIPMismatches(kind string, count int) {
+	metricPodIPMismatches.WithLabelValues(kind).Set(float64(count))
+}
+
 func RecordNetpolLocalPodEvent(eventName string, duration time.Duration) {
 	metricNetpolLocalPodEventLatency.WithLabelValues(eventName).Observe(duration.Seconds())
 }
@@ -538,6 +681,16 @@ func RecordEgressRoutingViaHost() {
 	}
 }
 
+// RecordOVNSchemaFeature records whether the optional OVN Northbound schema
+// feature is supported
+func RecordOVNSchemaFeature(feature string, supported bool) {
+	value := 0.0
+	if supported {
+		value = 1
+	}
+	metricOVNSchemaFeatureSupported.WithLabelValues(feature).Set(value)
+}
+
 // MonitorIPSec will register a metric to determine if IPSec is enabled/disabled. It will also add a handler
 // to NB libovsdb cache to update the IPSec metric.
 // This function should only be called once.
@@ -568,6 +721,76 @@ func ipsecMetricHandler(table string, model model.Model) {
 	}
 }
 
+// networkPolicyACLCollector counts the network policy ACLs in the NB cache per
+// network, direction and action when metrics are scraped.
+type networkPolicyACLCollector struct {
+	nbClient libovsdbclient.Client
+	desc     *prometheus.Desc
+}
+
+func newNetworkPolicyACLCollector(nbClient libovsdbclient.Client) *networkPolicyACLCollector {
+	return &networkPolicyACLCollector{
+		nbClient: nbClient,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(MetricOvnkubeNamespace, MetricOvnkubeSubsystemController, "network_policy_acls"),
+			"The number of network policy ACLs per network, direction and action (allow, deny)",
+			[]string{"network", "direction", "action"},
+			nil,
+		),
+	}
+}
+
+func (c *networkPolicyACLCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *networkPolicyACLCollector) Collect(ch chan<- prometheus.Metric) {
+	for key, count := range countNetworkPolicyACLs(c.nbClient) {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count),
+			key.network, key.direction, key.action)
+	}
+}
+
+type networkPolicyACLKey struct {
+	network   string
+	direction string
+	action    string
+}
+
+// countNetworkPolicyACLs counts the ACLs created for network policies, including
+// the per-namespace default deny ACLs, grouped by network, direction and action.
+// The network is derived from the name of the controller owning the ACL.
+func countNetworkPolicyACLs(nbClient libovsdbclient.Client) map[networkPolicyACLKey]int {
+	counts := map[networkPolicyACLKey]int{}
+	acls, err := libovsdbops.FindACLsWithPredicate(nbClient, func(acl *nbdb.ACL) bool {
+		ownerType := acl.ExternalIDs[libovsdbops.OwnerTypeKey.String()]
+		return ownerType == string(libovsdbops.NetworkPolicyOwnerType) ||
+			ownerType == string(libovsdbops.NetpolNamespaceOwnerType)
+	})
+	if err != nil {
+		klog.Errorf("Failed to find network policy ACLs: %v", err)
+		return counts
+	}
+	for _, acl := range acls {
+		var action string
+		switch acl.Action {
+		case nbdb.ACLActionAllow, nbdb.ACLActionAllowRelated, nbdb.ACLActionAllowStateless:
+			action = "allow"
+		case nbdb.ACLActionDrop, nbdb.ACLActionReject:
+			action = "deny"
+		default:
+			continue
+		}
+		key := networkPolicyACLKey{
+			network:   strings.TrimSuffix(acl.ExternalIDs[libovsdbops.OwnerControllerKey.String()], "-network-controller"),
+			direction: acl.ExternalIDs[libovsdbops.PolicyDirectionKey.String()],
+			action:    action,
+		}
+		counts[key]++
+	}
+	return counts
+}
+
 // IncrementEgressFirewallCount increments the number of Egress firewalls
 func IncrementEgressFirewallCount() {
 	metricEgressFirewallCount.Inc()
@@ -598,6 +821,7 @@ const (
 	addPod
 	cleanPod
 	addLogicalSwitchPort
+	addPodAnnotation
 	queueCheckPeriod = time.Millisecond * 50
 	// prevent OOM by limiting queue size
 	queueLimit       = 10000
@@ -607,6 +831,12 @@ const (
 type record struct {
 	timestamp time.Time
 	timestampType
+	// created is the creation time of the pod, zero if the end-to-end network
+	// readiness of the pod isn't measured
+	created time.Time
+	// annotated is the time the pod annotation was set, zero if not yet
+	annotated time.Time
+	node      string
 }
 
 type item struct {
@@ -615,6 +845,8 @@ type item struct {
 	old       model.Model
 	new       model.Model
 	uid       kapimtypes.UID
+	created   time.Time
+	node      string
 }
 
 type PodRecorder struct {
@@ -635,6 +867,9 @@ func (pr *PodRecorder) Run(sbClient libovsdbclient.Client, stop <-chan struct{})
 		prometheus.MustRegister(metricLSPPortBindingLatency)
 		prometheus.MustRegister(metricPortBindingUpLatency)
 		prometheus.MustRegister(metricPortBindingChassisLatency)
+		prometheus.MustRegister(metricPodNetworkReadyLatency)
+		prometheus.MustRegister(metricPodNetworkReadyNodeLatency)
+		prometheus.MustRegister(metricPodNetworkReadyStageLatency)
 	})
 
 	pr.queue = workqueue.New()
@@ -677,9 +912,15 @@ func (pr *PodRecorder) Run(sbClient libovsdbclient.Client, stop <-chan struct{})
 	}()
 }
 
-func (pr *PodRecorder) AddPod(podUID kapimtypes.UID) {
+func (pr *PodRecorder) AddPod(pod *kapi.Pod) {
 	if pr.queue != nil && !pr.queueFull() {
-		pr.queue.Add(item{op: addPod, uid: podUID, timestamp: time.Now()})
+		i := item{op: addPod, uid: pod.UID, timestamp: time.Now(), node: pod.Spec.NodeName}
+		// the network of the pods already running when ovnkube starts isn't
+		// being set up, their end-to-end network readiness isn't measured
+		if pod.Status.Phase == kapi.PodPending {
+			i.created = pod.CreationTimestamp.Time
+		}
+		pr.queue.Add(i)
 	}
 }
 
@@ -699,6 +940,27 @@ func (pr *PodRecorder) AddLSP(podUID kapimtypes.UID, netInfo util.NetInfo) {
 	}
 }
 
+// AddPodAnnotation records that the pod annotation of the default network of
+// the pod is set, either by ovnkube-controller or by ovnkube-cluster-manager
+func (pr *PodRecorder) AddPodAnnotation(podUID kapimtypes.UID) {
+	if pr.queue != nil && !pr.queueFull() {
+		pr.queue.Add(item{op: addPodAnnotation, uid: podUID, timestamp: time.Now()})
+	}
+}
+
+func (pr *PodRecorder) addPodAnnotation(podUID kapimtypes.UID, t time.Time) {
+	var r *record
+	if r = pr.getRecord(podUID); r == nil {
+		klog.V(5).Infof("Add pod annotation event expected pod with UID %q in cache", podUID)
+		return
+	}
+	if r.created.IsZero() || !r.annotated.IsZero() {
+		return
+	}
+	r.annotated = t
+	metricPodNetworkReadyStageLatency.WithLabelValues(networkReadyStageAnnotation).Observe(t.Sub(r.created).Seconds())
+}
+
 func (pr *PodRecorder) addLSP(podUID kapimtypes.UID, t time.Time) {
 	var r *record
 	if r = pr.getRecord(podUID); r == nil {
@@ -749,6 +1011,9 @@ func (pr *PodRecorder) updatePortBinding(old, new model.Model, t time.Time) {
 
 	if oldRow.Chassis == nil && newRow.Chassis != nil && r.timestampType == portBinding {
 		metricPortBindingChassisLatency.Observe(t.Sub(r.timestamp).Seconds())
+		if !r.annotated.IsZero() {
+			metricPodNetworkReadyStageLatency.WithLabelValues(networkReadyStageBinding).Observe(t.Sub(r.annotated).Seconds())
+		}
 		r.timestamp = t
 		r.timestampType = portBindingChassis
 
@@ -756,6 +1021,13 @@ func (pr *PodRecorder) updatePortBinding(old, new model.Model, t time.Time) {
 
 	if oldRow.Up != nil && !*oldRow.Up && newRow.Up != nil && *newRow.Up && r.timestampType == portBindingChassis {
 		metricPortBindingUpLatency.Observe(t.Sub(r.timestamp).Seconds())
+		if !r.created.IsZero() {
+			metricPodNetworkReadyStageLatency.WithLabelValues(networkReadyStageFlows).Observe(t.Sub(r.timestamp).Seconds())
+			metricPodNetworkReadyLatency.Observe(t.Sub(r.created).Seconds())
+			if r.node != "" {
+				metricPodNetworkReadyNodeLatency.WithLabelValues(r.node).Observe(t.Sub(r.created).Seconds())
+			}
+		}
 		delete(pr.records, podUID)
 	}
 }
@@ -786,11 +1058,13 @@ func (pr *PodRecorder) processItem(i item) {
 	case updatePortBinding:
 		pr.updatePortBinding(i.old, i.new, i.timestamp)
 	case addPod:
-		pr.records[i.uid] = &record{timestamp: i.timestamp, timestampType: firstSeen}
+		pr.records[i.uid] = &record{timestamp: i.timestamp, timestampType: firstSeen, created: i.created, node: i.node}
 	case cleanPod:
 		delete(pr.records, i.uid)
 	case addLogicalSwitchPort:
 		pr.addLSP(i.uid, i.timestamp)
+	case addPodAnnotation:
+		pr.addPodAnnotation(i.uid, i.timestamp)
 	}
 }
 
@@ -234,3 +234,51 @@ var _ = ginkgo.Describe("Config Duration Operations", func() {
 		})
 	})
 })
+
+var _ = ginkgo.Describe("Network policy ACL metrics", func() {
+	var (
+		nbClient client.Client
+		cleanup  *libovsdbtest.Context
+	)
+
+	policyACL := func(uuid, controller, ownerType, direction, action string) *nbdb.ACL {
+		return &nbdb.ACL{
+			UUID:   uuid,
+			Action: action,
+			ExternalIDs: map[string]string{
+				libovsdbops.OwnerControllerKey.String(): controller,
+				libovsdbops.OwnerTypeKey.String():       ownerType,
+				libovsdbops.PolicyDirectionKey.String(): direction,
+			},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		netpol := string(libovsdbops.NetworkPolicyOwnerType)
+		netpolNamespace := string(libovsdbops.NetpolNamespaceOwnerType)
+		_, nbClient, cleanup = setupOvn(libovsdbtest.TestSetup{
+			NBData: []libovsdbtest.TestData{
+				policyACL("acl1", "default-network-controller", netpol, "Ingress", nbdb.ACLActionAllowRelated),
+				policyACL("acl2", "default-network-controller", netpol, "Ingress", nbdb.ACLActionAllowRelated),
+				policyACL("acl3", "default-network-controller", netpolNamespace, "Ingress", nbdb.ACLActionDrop),
+				policyACL("acl4", "blue-network-controller", netpol, "Egress", nbdb.ACLActionAllow),
+				policyACL("acl5", "blue-network-controller", netpolNamespace, "Egress", nbdb.ACLActionDrop),
+				policyACL("acl6", "blue-network-controller", netpolNamespace, "Egress", nbdb.ACLActionDrop),
+				policyACL("acl7", "default-network-controller", "EgressFirewall", "", nbdb.ACLActionDrop),
+			},
+		})
+	})
+
+	ginkgo.AfterEach(func() {
+		cleanup.Cleanup()
+	})
+
+	ginkgo.It("counts network policy ACLs per network, direction and action", func() {
+		gomega.Expect(countNetworkPolicyACLs(nbClient)).To(gomega.Equal(map[networkPolicyACLKey]int{
+			{network: "default", direction: "Ingress", action: "allow"}: 2,
+			{network: "default", direction: "Ingress", action: "deny"}:  1,
+			{network: "blue", direction: "Egress", action: "allow"}:     1,
+			{network: "blue", direction: "Egress", action: "deny"}:      2,
+		}))
+	})
+})
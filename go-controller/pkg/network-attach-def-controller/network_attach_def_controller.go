@@ -11,7 +11,6 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -21,6 +20,7 @@ import (
 	nadclientset "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned"
 	nadinformers "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/informers/externalversions"
 	nadlisters "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/listers/k8s.cni.cncf.io/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/syncmap"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
@@ -36,10 +36,13 @@ const (
 	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
 	// maxRetries = 15
 
-	avoidResync     = 0
-	numberOfWorkers = 2
-	qps             = 15
-	maxRetries      = 10
+	avoidResync = 0
+	qps         = 15
+	maxRetries  = 10
+
+	// netAttachDefResource is the resource label of the reconcile error
+	// metrics of the net-attach-defs
+	netAttachDefResource = "NetworkAttachmentDefinition"
 )
 
 var ErrNetworkControllerTopologyNotManaged = errors.New("no cluster network controller to manage topology")
@@ -73,6 +76,16 @@ type networkNADInfo struct {
 	isStarted bool
 }
 
+// networkQueue is the work queue of the net-attach-defs of a network, with
+// its own worker. It lives as long as the network, see retireQueue.
+type networkQueue struct {
+	workqueue.RateLimitingInterface
+	network string
+	// pending are the net-attach-defs queued and neither reconciled nor
+	// dropped yet, including the ones waiting to be retried
+	pending map[string]bool
+}
+
 type NetAttachDefinitionController struct {
 	name               string
 	recorder           record.EventRecorder
@@ -80,11 +93,20 @@ type NetAttachDefinitionController struct {
 	nadFactory         nadinformers.SharedInformerFactory
 	netAttachDefLister nadlisters.NetworkAttachmentDefinitionLister
 	netAttachDefSynced cache.InformerSynced
-	queue              workqueue.RateLimitingInterface
-	loopPeriod         time.Duration
 	stopChan           chan struct{}
 	wg                 sync.WaitGroup
 
+	// queues holds the work queue of each network, so that the
+	// net-attach-defs of a network failing to reconcile, or slow to, like a
+	// network controller failing to start, don't hold back the other
+	// networks. The net-attach-defs of no known network, like invalid ones,
+	// are queued under the empty network name.
+	queuesLock sync.Mutex
+	queues     map[string]*networkQueue
+	// started is set once the existing net-attach-defs are synced, the queues
+	// are processed from then on
+	started bool
+
 	// key is nadName, value is BasicNetInfo
 	perNADNetInfo *syncmap.SyncMap[util.BasicNetInfo]
 	// controller for all networks, key is netName of net-attach-def, value is networkNADInfo
@@ -100,9 +122,6 @@ func NewNetAttachDefinitionController(name string, ncm NetworkControllerManager,
 		avoidResync,
 	)
 	netAttachDefInformer := nadFactory.K8sCniCncfIo().V1().NetworkAttachmentDefinitions()
-	rateLimiter := workqueue.NewMaxOfRateLimiter(
-		workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
-		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), qps*5)})
 
 	nadController := &NetAttachDefinitionController{
 		name:               name,
@@ -111,9 +130,8 @@ func NewNetAttachDefinitionController(name string, ncm NetworkControllerManager,
 		nadFactory:         nadFactory,
 		netAttachDefLister: netAttachDefInformer.Lister(),
 		netAttachDefSynced: netAttachDefInformer.Informer().HasSynced,
-		queue:              workqueue.NewNamedRateLimitingQueue(rateLimiter, "net-attach-def"),
-		loopPeriod:         time.Second,
 		stopChan:           make(chan struct{}),
+		queues:             map[string]*networkQueue{},
 		perNADNetInfo:      syncmap.NewSyncMap[util.BasicNetInfo](),
 		perNetworkNADInfo:  syncmap.NewSyncMap[*networkNADInfo](),
 	}
@@ -151,12 +169,11 @@ func (nadController *NetAttachDefinitionController) start() error {
 	}
 
 	klog.Infof("Starting workers for %s NAD controller", nadController.name)
-	for i := 0; i < numberOfWorkers; i++ {
-		nadController.wg.Add(1)
-		go func() {
-			defer nadController.wg.Done()
-			wait.Until(nadController.worker, nadController.loopPeriod, nadController.stopChan)
-		}()
+	nadController.queuesLock.Lock()
+	defer nadController.queuesLock.Unlock()
+	nadController.started = true
+	for _, queue := range nadController.queues {
+		nadController.startWorker(queue)
 	}
 
 	return nil
@@ -166,13 +183,17 @@ func (nadController *NetAttachDefinitionController) Stop() {
 	klog.Infof("Shutting down %s NAD controller", nadController.name)
 
 	close(nadController.stopChan)
-	nadController.queue.ShutDown()
+	nadController.queuesLock.Lock()
+	for _, queue := range nadController.queues {
+		queue.ShutDown()
+	}
+	nadController.queuesLock.Unlock()
 
 	// wait for the workers to terminate
 	nadController.wg.Wait()
 
 	// stop each network controller
-	for _, oc := range nadController.getAllNetworkControllers() {
+	for _, oc := range nadController.GetAllNetworkControllers() {
 		oc.Stop()
 	}
 }
@@ -194,31 +215,60 @@ func (nadController *NetAttachDefinitionController) SyncNetworkControllers() (er
 	// The controller can only be started after all known NADs are added so as to avoid to the extent possible
 	// the errors and retries that would result if the controller attempted to process pods attached with NADs
 	// we wouldn't otherwise know about yet
+	// A network failing to be created doesn't fail the others: its
+	// net-attach-defs are retried by the worker of the network.
 	for _, nad := range existingNADs {
 		err = nadController.AddNetAttachDef(nadController.ncm, nad, false)
 		// Ignore the error if there is no network controller to manager a topology
 		if err != nil && !errors.Is(err, ErrNetworkControllerTopologyNotManaged) {
-			return err
+			klog.Errorf("%s: Failed to sync net-attach-def %s/%s, retrying: %v", nadController.name, nad.Namespace, nad.Name, err)
+			nadController.queueNetworkAttachDefinition(nad)
 		}
 	}
 
-	return nadController.ncm.CleanupDeletedNetworks(nadController.getAllNetworkControllers())
+	return nadController.ncm.CleanupDeletedNetworks(nadController.GetAllNetworkControllers())
 }
 
-func (nadController *NetAttachDefinitionController) worker() {
-	for nadController.processNextWorkItem() {
+// startWorker runs the worker of the queue until the queue is shut down, on
+// stop, or retired. queuesLock must be held.
+func (nadController *NetAttachDefinitionController) startWorker(queue *networkQueue) {
+	nadController.wg.Add(1)
+	go func() {
+		defer nadController.wg.Done()
+		for nadController.processNextWorkItem(queue) && !nadController.retireQueue(queue) {
+		}
+	}()
+}
+
+// retireQueue removes the queue of a deleted network once it has nothing left
+// to reconcile, neither queued nor waiting to be retried, and returns true if
+// it did. It is only called by the worker of the queue in between two
+// net-attach-defs, so that none is in progress and a net-attach-def of the
+// network queued afterwards gets a new queue and worker without the two
+// workers ever running together.
+func (nadController *NetAttachDefinitionController) retireQueue(queue *networkQueue) bool {
+	nadController.queuesLock.Lock()
+	defer nadController.queuesLock.Unlock()
+	if len(queue.pending) > 0 || queue.Len() > 0 || nadController.queues[queue.network] != queue {
+		return false
+	}
+	if _, ok := nadController.perNetworkNADInfo.Load(queue.network); ok {
+		return false
 	}
+	delete(nadController.queues, queue.network)
+	queue.ShutDown()
+	return true
 }
 
-func (nadController *NetAttachDefinitionController) processNextWorkItem() bool {
-	key, quit := nadController.queue.Get()
+func (nadController *NetAttachDefinitionController) processNextWorkItem(queue *networkQueue) bool {
+	key, quit := queue.Get()
 	if quit {
 		return false
 	}
-	defer nadController.queue.Done(key)
+	defer queue.Done(key)
 
 	err := nadController.sync(key.(string))
-	nadController.handleErr(err, key)
+	nadController.handleErr(queue, err, key)
 	return true
 }
 
@@ -245,7 +295,7 @@ func (nadController *NetAttachDefinitionController) sync(key string) error {
 	}
 }
 
-func (nadController *NetAttachDefinitionController) handleErr(err error, key interface{}) {
+func (nadController *NetAttachDefinitionController) handleErr(queue *networkQueue, err error, key interface{}) {
 	ns, name, keyErr := cache.SplitMetaNamespaceKey(key.(string))
 	if keyErr != nil {
 		klog.ErrorS(err, "Failed to split meta namespace cache key", "key", key)
@@ -257,28 +307,77 @@ func (nadController *NetAttachDefinitionController) handleErr(err error, key int
 	}
 
 	if err == nil {
-		nadController.queue.Forget(key)
+		nadController.forget(queue, key.(string))
 		return
 	}
 
-	if nadController.queue.NumRequeues(key) < maxRetries {
-		nadController.queue.AddRateLimited(key)
+	metrics.MetricNetworkReconcileErrors.WithLabelValues(queue.network, netAttachDefResource).Inc()
+	if queue.NumRequeues(key) < maxRetries {
+		// the net-attach-def may have been forgotten while in progress
+		nadController.queuesLock.Lock()
+		queue.pending[key.(string)] = true
+		queue.AddRateLimited(key)
+		nadController.queuesLock.Unlock()
 		klog.V(2).InfoS("Error syncing net-attach-def, retrying", "net-attach-def", klog.KRef(ns, name), "err", err)
 		return
 	}
 
 	klog.Warningf("%s: Dropping net-attach-def %q out of the queue: %v", nadController.name, key, err)
-	nadController.queue.Forget(key)
+	nadController.forget(queue, key.(string))
 	utilruntime.HandleError(err)
 }
 
+// forget stops tracking the net-attach-def in the queue
+func (nadController *NetAttachDefinitionController) forget(queue *networkQueue, key string) {
+	queue.Forget(key)
+	nadController.queuesLock.Lock()
+	defer nadController.queuesLock.Unlock()
+	delete(queue.pending, key)
+}
+
 func (nadController *NetAttachDefinitionController) queueNetworkAttachDefinition(obj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("%s: couldn't get key for net-attach-def %+v: %v", nadController.name, obj, err))
 		return
 	}
-	nadController.queue.Add(key)
+	network := nadController.getNetworkOfNAD(key, obj)
+
+	nadController.queuesLock.Lock()
+	defer nadController.queuesLock.Unlock()
+	queue := nadController.queues[network]
+	if queue == nil {
+		rateLimiter := workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), qps*5)})
+		queue = &networkQueue{
+			RateLimitingInterface: workqueue.NewRateLimitingQueue(rateLimiter),
+			network:               network,
+			pending:               map[string]bool{},
+		}
+		nadController.queues[network] = queue
+		if nadController.started {
+			nadController.startWorker(queue)
+		}
+	}
+	queue.pending[key] = true
+	queue.Add(key)
+}
+
+// getNetworkOfNAD returns the network of the net-attach-def, from its netconf
+// or, if invalid, from the netconf it had when last synced. The sync of the
+// net-attach-defs being level driven, a net-attach-def moving to another
+// network may be synced from the queue of either network.
+func (nadController *NetAttachDefinitionController) getNetworkOfNAD(key string, obj interface{}) string {
+	if nad, ok := obj.(*nettypes.NetworkAttachmentDefinition); ok {
+		if nInfo, err := util.ParseNADInfo(nad); err == nil {
+			return nInfo.GetNetworkName()
+		}
+	}
+	if nInfo, ok := nadController.perNADNetInfo.Load(key); ok {
+		return nInfo.GetNetworkName()
+	}
+	return ""
 }
 
 func (nadController *NetAttachDefinitionController) onNetworkAttachDefinitionAdd(obj interface{}) {
@@ -327,10 +426,10 @@ func (nadController *NetAttachDefinitionController) onNetworkAttachDefinitionDel
 	nadController.queueNetworkAttachDefinition(obj)
 }
 
-// getAllNetworkControllers returns a snapshot of all managed NAD associated network controllers.
+// GetAllNetworkControllers returns a snapshot of all managed NAD associated network controllers.
 // Caller needs to note that there are no guarantees the return results reflect the real time
 // condition. There maybe more controllers being added, and returned controllers may be deleted
-func (nadController *NetAttachDefinitionController) getAllNetworkControllers() []NetworkController {
+func (nadController *NetAttachDefinitionController) GetAllNetworkControllers() []NetworkController {
 	allNetworkNames := nadController.perNetworkNADInfo.GetKeys()
 	allNetworkControllers := make([]NetworkController, 0, len(allNetworkNames))
 	for _, netName := range allNetworkNames {
@@ -0,0 +1,186 @@
+package networkAttachDefController
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	nettypes "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	nadfake "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/fake"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+type fakeNetworkController struct {
+	util.NetInfo
+	ncm *fakeNetworkControllerManager
+}
+
+func (nc *fakeNetworkController) Start(ctx context.Context) error {
+	return nc.ncm.start(nc.GetNetworkName())
+}
+
+func (nc *fakeNetworkController) Stop() {
+	nc.ncm.stop(nc.GetNetworkName())
+}
+
+func (nc *fakeNetworkController) Cleanup(netName string) error { return nil }
+
+type fakeNetworkControllerManager struct {
+	sync.Mutex
+	// blocked networks don't start until unblocked
+	blocked map[string]chan struct{}
+	// failing networks fail to be created
+	failing map[string]bool
+	started map[string]bool
+}
+
+func (ncm *fakeNetworkControllerManager) NewNetworkController(netInfo util.NetInfo) (NetworkController, error) {
+	ncm.Lock()
+	defer ncm.Unlock()
+	if ncm.failing[netInfo.GetNetworkName()] {
+		return nil, fmt.Errorf("invalid subnets of network %s", netInfo.GetNetworkName())
+	}
+	return &fakeNetworkController{NetInfo: netInfo, ncm: ncm}, nil
+}
+
+func (ncm *fakeNetworkControllerManager) CleanupDeletedNetworks(allControllers []NetworkController) error {
+	return nil
+}
+
+func (ncm *fakeNetworkControllerManager) start(networkName string) error {
+	ncm.Lock()
+	blocked := ncm.blocked[networkName]
+	ncm.Unlock()
+	if blocked != nil {
+		<-blocked
+	}
+	ncm.Lock()
+	defer ncm.Unlock()
+	ncm.started[networkName] = true
+	return nil
+}
+
+func (ncm *fakeNetworkControllerManager) stop(networkName string) {
+	ncm.Lock()
+	defer ncm.Unlock()
+	delete(ncm.started, networkName)
+}
+
+func (ncm *fakeNetworkControllerManager) isStarted(networkName string) bool {
+	ncm.Lock()
+	defer ncm.Unlock()
+	return ncm.started[networkName]
+}
+
+func newNAD(name, networkName string) *nettypes.NetworkAttachmentDefinition {
+	return &nettypes.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec: nettypes.NetworkAttachmentDefinitionSpec{
+			Config: fmt.Sprintf(`{"cniVersion": "0.4.0", "name": %q, "type": "ovn-k8s-cni-overlay", `+
+				`"topology": "layer2", "netAttachDefName": "ns/%s"}`, networkName, name),
+		},
+	}
+}
+
+func TestNetworksReconciledInIsolation(t *testing.T) {
+	g := gomega.NewWithT(t)
+	unblock := make(chan struct{})
+	ncm := &fakeNetworkControllerManager{
+		blocked: map[string]chan struct{}{"slow": unblock},
+		failing: map[string]bool{"broken": true},
+		started: map[string]bool{},
+	}
+	// the fake clientset doesn't list the objects it is created with under
+	// the plural of the net-attach-defs, create them instead
+	client := nadfake.NewSimpleClientset()
+	_, err := client.K8sCniCncfIoV1().NetworkAttachmentDefinitions("ns").Create(context.TODO(),
+		newNAD("broken", "broken"), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	nadController, err := NewNetAttachDefinitionController("test", ncm, client, record.NewFakeRecorder(10))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the network failing to be created doesn't fail the start
+	g.Expect(nadController.Start()).To(gomega.Succeed())
+	defer nadController.Stop()
+	g.Expect(ncm.isStarted("broken")).To(gomega.BeFalse())
+
+	// the broken network is retried once fixed
+	ncm.Lock()
+	ncm.failing["broken"] = false
+	ncm.Unlock()
+	g.Eventually(func() bool { return ncm.isStarted("broken") }, 5*time.Second).Should(gomega.BeTrue())
+
+	// the network blocked on start doesn't hold back the networks created
+	// afterwards
+	for _, name := range []string{"slow", "fast"} {
+		_, err = client.K8sCniCncfIoV1().NetworkAttachmentDefinitions("ns").Create(context.TODO(),
+			newNAD(name, name), metav1.CreateOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+	g.Eventually(func() bool { return ncm.isStarted("fast") }, 5*time.Second).Should(gomega.BeTrue())
+	g.Expect(ncm.isStarted("slow")).To(gomega.BeFalse())
+
+	close(unblock)
+	g.Eventually(func() bool { return ncm.isStarted("slow") }, 5*time.Second).Should(gomega.BeTrue())
+}
+
+func TestNetworkQueueLifecycle(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ncm := &fakeNetworkControllerManager{
+		blocked: map[string]chan struct{}{},
+		failing: map[string]bool{},
+		started: map[string]bool{},
+	}
+	client := nadfake.NewSimpleClientset()
+	nadController, err := NewNetAttachDefinitionController("test", ncm, client, record.NewFakeRecorder(10))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(nadController.Start()).To(gomega.Succeed())
+	defer nadController.Stop()
+	getQueue := func() *networkQueue {
+		nadController.queuesLock.Lock()
+		defer nadController.queuesLock.Unlock()
+		return nadController.queues["blue"]
+	}
+
+	// the queue of the network outlives the reconciliation of its
+	// net-attach-defs
+	_, err = client.K8sCniCncfIoV1().NetworkAttachmentDefinitions("ns").Create(context.TODO(),
+		newNAD("blue", "blue"), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Eventually(func() bool { return ncm.isStarted("blue") }, 5*time.Second).Should(gomega.BeTrue())
+	queue := getQueue()
+	g.Expect(queue).NotTo(gomega.BeNil())
+	g.Consistently(getQueue).Should(gomega.BeIdenticalTo(queue))
+
+	// the queue is removed with the network, and a new one is used when the
+	// network is created again
+	err = client.K8sCniCncfIoV1().NetworkAttachmentDefinitions("ns").Delete(context.TODO(), "blue", metav1.DeleteOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Eventually(getQueue, 5*time.Second).Should(gomega.BeNil())
+	g.Expect(ncm.isStarted("blue")).To(gomega.BeFalse())
+	g.Expect(queue.ShuttingDown()).To(gomega.BeTrue())
+	_, err = client.K8sCniCncfIoV1().NetworkAttachmentDefinitions("ns").Create(context.TODO(),
+		newNAD("blue", "blue"), metav1.CreateOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Eventually(func() bool { return ncm.isStarted("blue") }, 5*time.Second).Should(gomega.BeTrue())
+	g.Expect(getQueue()).NotTo(gomega.BeIdenticalTo(queue))
+
+	// a deleted network keeps its queue while a net-attach-def is waiting to
+	// be retried
+	queue = getQueue()
+	g.Expect(nadController.retireQueue(queue)).To(gomega.BeFalse())
+	nadController.perNetworkNADInfo.Delete("blue")
+	nadController.queuesLock.Lock()
+	queue.pending["ns/other"] = true
+	nadController.queuesLock.Unlock()
+	g.Expect(nadController.retireQueue(queue)).To(gomega.BeFalse())
+	nadController.forget(queue, "ns/other")
+	g.Expect(nadController.retireQueue(queue)).To(gomega.BeTrue())
+	g.Expect(getQueue()).To(gomega.BeNil())
+}
@@ -18,9 +18,14 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
 	nad "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/network-attach-def-controller"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/ctzone"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/observability"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/routeaudit"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/topology"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
@@ -45,6 +50,8 @@ type NetworkControllerManager struct {
 	multicastSupport bool
 	// Supports OVN Template Load Balancers?
 	svcTemplateSupport bool
+	// Supports sampling to OVN Sample Collectors?
+	sampleCollectorSupport bool
 
 	stopChan chan struct{}
 	wg       *sync.WaitGroup
@@ -221,14 +228,44 @@ func (cm *NetworkControllerManager) configureSCTPSupport() error {
 	return nil
 }
 
-func (cm *NetworkControllerManager) configureSvcTemplateSupport() {
-	if _, _, err := util.RunOVNNbctl("--columns=_uuid", "list", "Chassis_Template_Var"); err != nil {
+// configureSchemaFeatures probes the optional features of the OVN Northbound
+// schema and disables the ovnkube features depending on the unsupported ones
+func (cm *NetworkControllerManager) configureSchemaFeatures() {
+	features := libovsdbutil.ProbeSchemaFeatures(cm.nbClient)
+	for _, condition := range features.Conditions() {
+		if condition.Status == metav1.ConditionTrue {
+			klog.Infof("%s: %s", condition.Type, condition.Message)
+		} else {
+			klog.Warningf("%s: %s", condition.Type, condition.Message)
+		}
+	}
+	for _, feature := range features.Features() {
+		metrics.RecordOVNSchemaFeature(string(feature), features.Supported(feature))
+	}
+
+	cm.svcTemplateSupport = features.Supported(libovsdbutil.SchemaFeatureTemplateVar)
+	if !cm.svcTemplateSupport {
 		klog.Warningf("Version of OVN in use does not support Chassis_Template_Var. " +
 			"Disabling Templates Support")
-		cm.svcTemplateSupport = false
-	} else {
-		cm.svcTemplateSupport = true
 	}
+	cm.sampleCollectorSupport = features.Supported(libovsdbutil.SchemaFeatureSampleCollector)
+	if config.OVNKubernetesFeature.EnableObservability && !cm.sampleCollectorSupport {
+		klog.Warningf("Version of OVN in use does not support sample collectors. Disabling Observability")
+		config.OVNKubernetesFeature.EnableObservability = false
+	}
+}
+
+// configureDropSampling starts the sampling of the dropped packets when
+// observability is enabled, and removes it otherwise
+func (cm *NetworkControllerManager) configureDropSampling() error {
+	if config.OVNKubernetesFeature.EnableObservability {
+		return observability.NewDropSamplingController(cm.nbClient,
+			config.OVNKubernetesFeature.ObservabilityDropSamplingPercentage).Start(cm.stopChan, cm.wg)
+	}
+	if cm.sampleCollectorSupport {
+		return observability.CleanupDropSampling(cm.nbClient)
+	}
+	return nil
 }
 
 func (cm *NetworkControllerManager) configureMetrics(stopChan <-chan struct{}) {
@@ -236,28 +273,31 @@ func (cm *NetworkControllerManager) configureMetrics(stopChan <-chan struct{}) {
 	metrics.RegisterOVNKubeControllerFunctional()
 	metrics.RunTimestamp(stopChan, cm.sbClient, cm.nbClient)
 	metrics.MonitorIPSec(cm.nbClient)
+	if config.Metrics.BindAddress != "" {
+		metrics.RegisterHTTPHandler(topology.Path, topology.NewHandler(cm.nbClient))
+		metrics.RegisterHTTPHandler(ctzone.Path, ctzone.NewHandler(cm.nbClient))
+	}
 }
 
-func (cm *NetworkControllerManager) createACLLoggingMeter() error {
-	band := &nbdb.MeterBand{
-		Action: ovntypes.MeterAction,
-		Rate:   config.Logging.ACLLoggingRateLimit,
+// runStaticRouteAudit serves the diff of the static routes of the zone on the
+// metrics server and, if enabled, periodically removes the stale ones. It runs
+// once the default network controller synced its routes.
+func (cm *NetworkControllerManager) runStaticRouteAudit() {
+	auditor := routeaudit.NewAuditor(cm.nbClient, cm.watchFactory.NodeCoreInformer().Lister(),
+		cm.watchFactory.PodCoreInformer().Lister(), cm.watchFactory)
+	if config.Metrics.BindAddress != "" {
+		metrics.RegisterHTTPHandler(routeaudit.Path, auditor)
 	}
-	ops, err := libovsdbops.CreateMeterBandOps(cm.nbClient, nil, band)
-	if err != nil {
-		return fmt.Errorf("can't create meter band %v: %v", band, err)
+	if config.OVNKubernetesFeature.StaticRouteAuditInterval > 0 {
+		auditor.Run(cm.stopChan, cm.wg, time.Duration(config.OVNKubernetesFeature.StaticRouteAuditInterval)*time.Second)
 	}
+}
 
-	meterFairness := true
-	meter := &nbdb.Meter{
-		Name: ovntypes.OvnACLLoggingMeter,
-		Fair: &meterFairness,
-		Unit: ovntypes.PacketsPerSecond,
-	}
-	ops, err = libovsdbops.CreateOrUpdateMeterOps(cm.nbClient, ops, meter, []*nbdb.MeterBand{band},
-		&meter.Bands, &meter.Fair, &meter.Unit)
+func (cm *NetworkControllerManager) createACLLoggingMeter() error {
+	ops, err := libovsdbutil.CreateOrUpdateACLLoggingMeterOps(cm.nbClient, nil, ovntypes.OvnACLLoggingMeter,
+		config.Logging.ACLLoggingRateLimit)
 	if err != nil {
-		return fmt.Errorf("can't create meter %v: %v", meter, err)
+		return err
 	}
 
 	_, err = libovsdbops.TransactAndCheck(cm.nbClient, ops)
@@ -361,7 +401,7 @@ func (cm *NetworkControllerManager) Start(ctx context.Context) error {
 		return err
 	}
 
-	cm.configureSvcTemplateSupport()
+	cm.configureSchemaFeatures()
 
 	err = cm.createACLLoggingMeter()
 	if err != nil {
@@ -376,6 +416,10 @@ func (cm *NetworkControllerManager) Start(ctx context.Context) error {
 	}
 	cm.podRecorder.Run(cm.sbClient, cm.stopChan)
 
+	if err = cm.configureDropSampling(); err != nil {
+		return fmt.Errorf("failed to configure the drop sampling: %w", err)
+	}
+
 	err = cm.initDefaultNetworkController()
 	if err != nil {
 		return fmt.Errorf("failed to init default network controller: %v", err)
@@ -384,6 +428,7 @@ func (cm *NetworkControllerManager) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to start default network controller: %v", err)
 	}
+	cm.runStaticRouteAudit()
 
 	// nadController is nil if multi-network is disabled
 	if cm.nadController != nil {
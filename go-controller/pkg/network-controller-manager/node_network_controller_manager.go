@@ -12,6 +12,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	nad "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/network-attach-def-controller"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/localstate"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
@@ -21,6 +22,10 @@ import (
 	kexec "k8s.io/utils/exec"
 )
 
+// disconnectedSyncRetryInterval is the interval the informers are waited for
+// at, when ovnkube-node starts while the apiserver is unreachable
+const disconnectedSyncRetryInterval = 10 * time.Second
+
 // nodeNetworkControllerManager structure is the object manages all controllers for all networks for ovnkube-node
 type nodeNetworkControllerManager struct {
 	name          string
@@ -31,6 +36,9 @@ type nodeNetworkControllerManager struct {
 	recorder      record.EventRecorder
 
 	defaultNodeNetworkController nad.BaseNetworkController
+	// cniServer is the CNI server started from the local state while the
+	// apiserver was unreachable on startup, nil otherwise
+	cniServer *cni.Server
 
 	// net-attach-def controller handle net-attach-def and create/delete secondary controllers
 	// nil in dpu-host mode
@@ -42,7 +50,9 @@ func (ncm *nodeNetworkControllerManager) NewNetworkController(nInfo util.NetInfo
 	topoType := nInfo.TopologyType()
 	switch topoType {
 	case ovntypes.Layer3Topology, ovntypes.Layer2Topology, ovntypes.LocalnetTopology:
-		return node.NewSecondaryNodeNetworkController(ncm.newCommonNetworkControllerInfo(), nInfo), nil
+		// the gateway routers of the primary networks share the gateway bridge of the default network
+		defaultNetController, _ := ncm.defaultNodeNetworkController.(*node.DefaultNodeNetworkController)
+		return node.NewSecondaryNodeNetworkController(ncm.newCommonNetworkControllerInfo(), nInfo, defaultNetController), nil
 	}
 	return nil, fmt.Errorf("topology type %s not supported", topoType)
 }
@@ -69,9 +79,10 @@ func NewNodeNetworkControllerManager(ovnClient *util.OVNClientset, wf factory.No
 		recorder:      eventRecorder,
 	}
 
-	// need to configure OVS interfaces for Pods on secondary networks in the DPU mode
+	// need to configure OVS interfaces for Pods on secondary networks in the DPU mode, and the
+	// flows of the gateway routers of the primary networks on the shared gateway bridge in the full mode
 	var err error
-	if config.OVNKubernetesFeature.EnableMultiNetwork && config.OvnKubeNode.Mode == ovntypes.NodeModeDPU {
+	if config.OVNKubernetesFeature.EnableMultiNetwork && config.OvnKubeNode.Mode != ovntypes.NodeModeDPUHost {
 		ncm.nadController, err = nad.NewNetAttachDefinitionController("node-network-controller-manager", ncm, ovnClient.NetworkAttchDefClient, eventRecorder)
 	}
 	if err != nil {
@@ -86,6 +97,9 @@ func (ncm *nodeNetworkControllerManager) initDefaultNodeNetworkController() erro
 	if err != nil {
 		return err
 	}
+	if ncm.cniServer != nil {
+		defaultNodeNetworkController.AdoptCNIServer(ncm.cniServer)
+	}
 	// Make sure we only set defaultNodeNetworkController in case of no error,
 	// otherwise we would initialize the interface with a nil implementation
 	// which is not the same as nil interface.
@@ -106,7 +120,12 @@ func (ncm *nodeNetworkControllerManager) Start(ctx context.Context) (err error)
 
 	err = ncm.watchFactory.Start()
 	if err != nil {
-		return err
+		if config.OvnKubeNode.LocalStateDir == "" || config.OvnKubeNode.Mode == ovntypes.NodeModeDPU {
+			return err
+		}
+		if err = ncm.startDisconnected(ctx, err); err != nil {
+			return err
+		}
 	}
 
 	// make sure we clean up after ourselves on failure
@@ -141,6 +160,39 @@ func (ncm *nodeNetworkControllerManager) Start(ctx context.Context) (err error)
 	return err
 }
 
+// startDisconnected starts the CNI server from the local state when the
+// informers cannot sync with the apiserver on startup, for the pods of the
+// node to be plumbed again from their cached state, typically after a reboot
+// of an edge node with its link to the control plane down. It then waits for
+// the apiserver to be reachable again, for the node to be reconciled by the
+// regular startup.
+func (ncm *nodeNetworkControllerManager) startDisconnected(ctx context.Context, syncErr error) error {
+	klog.Warningf("Failed to sync with the apiserver, plumbing the pods from the local state in %s until it is reachable: %v",
+		config.OvnKubeNode.LocalStateDir, syncErr)
+	store, err := localstate.NewStore(config.OvnKubeNode.LocalStateDir)
+	if err != nil {
+		return err
+	}
+	ncm.cniServer, err = cni.NewCNIServer(ncm.watchFactory, ncm.ovnNodeClient.KubeClient)
+	if err != nil {
+		return err
+	}
+	ncm.cniServer.EnableLocalState(store)
+	if err = ncm.cniServer.Start(cni.ServerRunDir); err != nil {
+		return err
+	}
+
+	// the informers keep trying to list and watch in the background
+	return wait.PollUntilContextCancel(ctx, disconnectedSyncRetryInterval, false, func(ctx context.Context) (bool, error) {
+		if err := ncm.watchFactory.Start(); err != nil {
+			klog.Infof("Waiting for the apiserver to be reachable: %v", err)
+			return false, nil
+		}
+		klog.Infof("Synced with the apiserver, reconciling node %s", ncm.name)
+		return true, nil
+	})
+}
+
 // Stop gracefully stops all managed controllers
 func (ncm *nodeNetworkControllerManager) Stop() {
 	// stop stale ovs ports cleanup
@@ -250,7 +250,7 @@ func (bnnc *BaseNodeNetworkController) addRepPort(pod *kapi.Pod, dpuCD *util.DPU
 		_ = bnnc.delRepPort(pod, dpuCD, vfRepName, nadName)
 		return err
 	}
-	klog.Infof("Port %s added to bridge br-int", vfRepName)
+	klog.Infof("Port %s added to the integration bridge", vfRepName)
 
 	link, err := util.GetNetLinkOps().LinkByName(vfRepName)
 	if err != nil {
@@ -311,11 +311,11 @@ func (bnnc *BaseNodeNetworkController) delRepPort(pod *kapi.Pod, dpuCD *util.DPU
 
 	// remove from br-int
 	return wait.PollUntilContextTimeout(context.Background(), 500*time.Millisecond, 60*time.Second, true, func(ctx context.Context) (bool, error) {
-		_, _, err := util.RunOVSVsctl("--if-exists", "del-port", "br-int", vfRepName)
+		_, _, err := util.RunOVSVsctl("--if-exists", "del-port", config.OvnKubeNode.IntegrationBridge, vfRepName)
 		if err != nil {
 			return false, nil
 		}
-		klog.Infof("Port %s deleted from bridge br-int", vfRepName)
+		klog.Infof("Port %s deleted from the integration bridge", vfRepName)
 		return true, nil
 	})
 }
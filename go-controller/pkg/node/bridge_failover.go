@@ -0,0 +1,115 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// bridgeFailoverInterval is how often the uplinks of the bridges of the
+// physical networks with a backup bridge are checked
+const bridgeFailoverInterval = 2 * time.Second
+
+const (
+	// BridgeMappingFailoverReason is the reason of the events posted when a
+	// physical network is mapped to its backup bridge, as the uplink of its
+	// bridge is down
+	BridgeMappingFailoverReason = "BridgeMappingFailover"
+	// BridgeMappingFailbackReason is the reason of the events posted when a
+	// physical network is mapped back to its bridge, as the uplink of its
+	// bridge is up again
+	BridgeMappingFailbackReason = "BridgeMappingFailback"
+)
+
+// failover maps each physical network with a backup bridge to its bridge while
+// the uplink of its bridge is up, else to its backup bridge while the uplink of
+// its backup bridge is up. When both uplinks are down the mapping is left
+// as is. The switched mappings are posted as events on the node and applied
+// right away.
+func (e *ovsExternalIDs) failover(recorder record.EventRecorder, nodeRef *kapi.ObjectReference) error {
+	physnets := make([]string, 0, len(e.backupBridges))
+	for physnet := range e.backupBridges {
+		physnets = append(physnets, physnet)
+	}
+	sort.Strings(physnets)
+
+	switched := false
+	for _, physnet := range physnets {
+		primary, backup := e.primaryBridges[physnet], e.backupBridges[physnet]
+		bridge, err := e.activeBridge(primary, backup)
+		if err != nil {
+			return fmt.Errorf("failed to check the uplinks of physical network %s: %w", physnet, err)
+		}
+		if bridge == "" || !e.switchBridgeMapping(physnet, bridge) {
+			continue
+		}
+		switched = true
+		eventType, reason := kapi.EventTypeWarning, BridgeMappingFailoverReason
+		message := fmt.Sprintf("Physical network %s is mapped to its backup bridge %s, the uplink of bridge %s is down",
+			physnet, backup, primary)
+		if bridge == primary {
+			eventType, reason = kapi.EventTypeNormal, BridgeMappingFailbackReason
+			message = fmt.Sprintf("Physical network %s is mapped back to bridge %s, its uplink is up", physnet, primary)
+		}
+		klog.Warning(message)
+		if recorder != nil {
+			recorder.Event(nodeRef, eventType, reason, message)
+		}
+	}
+	if !switched {
+		return nil
+	}
+	return e.reconcile()
+}
+
+// activeBridge returns the bridge the physical network should be mapped to:
+// the primary bridge if its uplink is up, else the backup bridge if its uplink
+// is up, else none
+func (e *ovsExternalIDs) activeBridge(primary, backup string) (string, error) {
+	for _, bridge := range []string{primary, backup} {
+		up, err := e.isUplinkUp(bridge)
+		if err != nil {
+			return "", err
+		}
+		if up {
+			return bridge, nil
+		}
+	}
+	return "", nil
+}
+
+// switchBridgeMapping maps the physical network to the bridge, returns whether
+// it was mapped to another bridge
+func (e *ovsExternalIDs) switchBridgeMapping(physnet, bridge string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.bridgeMappings[physnet] == bridge {
+		return false
+	}
+	e.bridgeMappings[physnet] = bridge
+	return true
+}
+
+// isBridgeUplinkUp returns whether the link of the uplink of the OVS bridge is
+// up. A bridge without uplink, or missing, is down.
+func isBridgeUplinkUp(bridge string) (bool, error) {
+	nic, err := util.GetNicName(bridge)
+	if err != nil {
+		klog.V(5).Infof("Failed to get the uplink of bridge %s, considered down: %v", bridge, err)
+		return false, nil
+	}
+	if nic == "" {
+		return false, nil
+	}
+	stdout, stderr, err := util.RunOVSVsctl("--if-exists", "get", "Interface", nic, "link_state")
+	if err != nil {
+		return false, fmt.Errorf("failed to get the link state of %s, stderr: %q, error: %w", nic, stderr, err)
+	}
+	return stdout == "up", nil
+}
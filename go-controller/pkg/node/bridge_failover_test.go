@@ -0,0 +1,95 @@
+package node
+
+import (
+	kapi "k8s.io/api/core/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bridge mapping failover", func() {
+	var (
+		fexec       *ovntest.FakeExec
+		recorder    *record.FakeRecorder
+		externalIDs *ovsExternalIDs
+		uplinks     map[string]bool
+	)
+	nodeRef := &kapi.ObjectReference{Kind: "Node", Name: "node1", UID: ktypes.UID("node1")}
+
+	BeforeEach(func() {
+		Expect(config.PrepareTestConfig()).To(Succeed())
+		fexec = ovntest.NewFakeExec()
+		Expect(util.SetExec(fexec)).To(Succeed())
+		recorder = record.NewFakeRecorder(10)
+		uplinks = map[string]bool{"br-phys1": true, "br-phys2": true}
+		externalIDs = &ovsExternalIDs{
+			integrationBridge: "br-int",
+			systemID:          "chassis1",
+			bridgeMappings:    map[string]string{"physnet": "breth0", "physnet1": "br-phys1"},
+			primaryBridges:    map[string]string{"physnet1": "br-phys1"},
+			backupBridges:     map[string]string{"physnet1": "br-phys2"},
+			isUplinkUp: func(bridge string) (bool, error) {
+				return uplinks[bridge], nil
+			},
+		}
+	})
+
+	addReconcileCmds := func(currentMappings, setMappings string) {
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge",
+			Output: "br-int",
+		})
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:system-id",
+			Output: "chassis1",
+		})
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge-mappings",
+			Output: currentMappings,
+		})
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-vsctl --timeout=15 set Open_vSwitch . external_ids:ovn-bridge-mappings=" + setMappings,
+		})
+	}
+
+	It("keeps the bridge while its uplink is up", func() {
+		Expect(externalIDs.failover(recorder, nodeRef)).To(Succeed())
+		Expect(externalIDs.bridgeMappings["physnet1"]).To(Equal("br-phys1"))
+		Expect(recorder.Events).To(BeEmpty())
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("fails over to the backup bridge and back with the uplink of the bridge", func() {
+		uplinks["br-phys1"] = false
+		addReconcileCmds("physnet:breth0,physnet1:br-phys1", "physnet:breth0,physnet1:br-phys2")
+		Expect(externalIDs.failover(recorder, nodeRef)).To(Succeed())
+		Expect(externalIDs.bridgeMappings["physnet1"]).To(Equal("br-phys2"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning " + BridgeMappingFailoverReason)))
+
+		// the backup bridge is kept while the uplink of the bridge is down
+		Expect(externalIDs.failover(recorder, nodeRef)).To(Succeed())
+		Expect(recorder.Events).To(BeEmpty())
+
+		uplinks["br-phys1"] = true
+		addReconcileCmds("physnet:breth0,physnet1:br-phys2", "physnet:breth0,physnet1:br-phys1")
+		Expect(externalIDs.failover(recorder, nodeRef)).To(Succeed())
+		Expect(externalIDs.bridgeMappings["physnet1"]).To(Equal("br-phys1"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Normal " + BridgeMappingFailbackReason)))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("keeps the mapping when both uplinks are down", func() {
+		uplinks["br-phys1"] = false
+		uplinks["br-phys2"] = false
+		Expect(externalIDs.failover(recorder, nodeRef)).To(Succeed())
+		Expect(externalIDs.bridgeMappings["physnet1"]).To(Equal("br-phys1"))
+		Expect(recorder.Events).To(BeEmpty())
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+})
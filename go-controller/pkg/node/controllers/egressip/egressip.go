@@ -3,13 +3,17 @@ package egressip
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"net"
 	"net/netip"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	ovnconfig "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/iprulemanager"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/iptables"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/linkmanager"
@@ -32,6 +36,7 @@ import (
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	utiliptables "k8s.io/kubernetes/pkg/util/iptables"
@@ -46,12 +51,18 @@ const (
 	chainName           = "OVN-KUBE-EGRESS-IP-MULTI-NIC"
 	iptChainName        = utiliptables.Chain(chainName)
 	maxRetries          = 15
+	// connLimitChainName is the chain of the rules jumping from the pod IPs to the connection limit chain of their EgressIP
+	connLimitChainName    = "OVN-KUBE-EGRESS-IP-CONNLIMIT"
+	iptConnLimitChainName = utiliptables.Chain(connLimitChainName)
+	// eIPConnLimitChainPrefix prefixes the connection limit chain of an EgressIP, suffixed by a hash of its name
+	eIPConnLimitChainPrefix = "OVN-KUBE-EIP-LIMIT-"
 )
 
 var (
 	_, defaultV4AnyCIDR, _ = net.ParseCIDR("0.0.0.0/0")
 	_, defaultV6AnyCIDR, _ = net.ParseCIDR("0:0:0:0:0:0:0:0")
 	iptJumpRule            = []iptables.RuleArg{{Args: []string{"-j", chainName}}}
+	iptConnLimitJumpRule   = []iptables.RuleArg{{Args: []string{"-j", connLimitChainName}}}
 )
 
 // eIPConfig represents exactly one EgressIP IP. It contains non-pod related EIP configuration information only.
@@ -61,6 +72,8 @@ type eIPConfig struct {
 	// EgressIP IP
 	ip        *netlink.Addr
 	routeLink *routemanager.RoutesPerLink
+	// maxConnections is the maximum number of concurrent connections of the EgressIP, 0 if not limited
+	maxConnections int32
 }
 
 func newEIPConfig() *eIPConfig {
@@ -126,13 +139,23 @@ type Controller struct {
 	ruleManager     *iprulemanager.Controller
 	iptablesManager *iptables.Controller
 
+	// rejectedConnections holds the packet count of the connection limit chain of each EgressIP at the last
+	// collection. It is only accessed by the rejected connections collector.
+	rejectedConnections map[string]uint64
+
+	// probeAddress checks whether an egress IP is already in use on the network of the link before it is assigned
+	// to it and returns the hardware address using it. It is nil when the IP conflict probes are disabled.
+	probeAddress func(ip net.IP, linkName string) (bool, string, error)
+	recorder     record.EventRecorder
+
 	nodeName string
 	v4       bool
 	v6       bool
 }
 
 func NewController(eIPInformer egressipinformer.EgressIPInformer, nodeInformer cache.SharedIndexInformer, namespaceInformer coreinformers.NamespaceInformer,
-	podInformer coreinformers.PodInformer, routeManager *routemanager.Controller, v4, v6 bool, nodeName string) (*Controller, error) {
+	podInformer coreinformers.PodInformer, routeManager *routemanager.Controller, v4, v6 bool, nodeName string,
+	recorder record.EventRecorder) (*Controller, error) {
 
 	c := &Controller{
 		eIPLister:   eIPInformer.Lister(),
@@ -161,10 +184,18 @@ func NewController(eIPInformer egressipinformer.EgressIPInformer, nodeInformer c
 		linkManager:           linkmanager.NewController(nodeName, v4, v6),
 		ruleManager:           iprulemanager.NewController(v4, v6),
 		iptablesManager:       iptables.NewController(),
+		rejectedConnections:   map[string]uint64{},
+		recorder:              recorder,
 		nodeName:              nodeName,
 		v4:                    v4,
 		v6:                    v6,
 	}
+	if ovnconfig.OVNKubernetesFeature.IPConflictProbeTimeout > 0 {
+		timeout := time.Duration(ovnconfig.OVNKubernetesFeature.IPConflictProbeTimeout) * time.Millisecond
+		c.probeAddress = func(ip net.IP, linkName string) (bool, string, error) {
+			return util.ProbeAddressOverIfaceByName(ip, linkName, timeout)
+		}
+	}
 	return c, nil
 }
 
@@ -236,6 +267,9 @@ func (c *Controller) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, threads int
 		if err = c.iptablesManager.EnsureRules(utiliptables.TableNAT, utiliptables.ChainPostrouting, utiliptables.ProtocolIPv4, iptJumpRule); err != nil {
 			return fmt.Errorf("failed to create rule in chain %s to jump to chain %s: %v", utiliptables.ChainPostrouting, iptChainName, err)
 		}
+		if err = c.ownConnLimitChain(utiliptables.ProtocolIPv4); err != nil {
+			return err
+		}
 	}
 	if c.v6 {
 		if err := c.iptablesManager.OwnChain(utiliptables.TableNAT, iptChainName, utiliptables.ProtocolIPv6); err != nil {
@@ -244,6 +278,9 @@ func (c *Controller) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, threads int
 		if err = c.iptablesManager.EnsureRules(utiliptables.TableNAT, utiliptables.ChainPostrouting, utiliptables.ProtocolIPv6, iptJumpRule); err != nil {
 			return fmt.Errorf("unable to ensure iptables rules for jump rule: %v", err)
 		}
+		if err = c.ownConnLimitChain(utiliptables.ProtocolIPv6); err != nil {
+			return err
+		}
 	}
 	if err := c.RepairNode(); err != nil {
 		// TODO(mk): return error here instead of logging and retry or put in a retry func
@@ -288,9 +325,82 @@ func (c *Controller) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, threads int
 		c.ruleManager.Run(stopCh, 5*time.Minute)
 		wg.Done()
 	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(c.recordRejectedConnections, 30*time.Second, stopCh)
+	}()
 	return nil
 }
 
+// ownConnLimitChain takes ownership of the chain jumping to the connection limit chains of the EgressIPs. Its stale
+// rules and the connection limit chains are removed, the ones of the EgressIPs are added back when they are synced.
+func (c *Controller) ownConnLimitChain(proto utiliptables.Protocol) error {
+	if err := c.iptablesManager.OwnChain(utiliptables.TableFilter, iptConnLimitChainName, proto); err != nil {
+		return fmt.Errorf("unable to own chain %s: %v", iptConnLimitChainName, err)
+	}
+	if err := c.iptablesManager.EnsureRules(utiliptables.TableFilter, iptConnLimitChainName, proto, nil); err != nil {
+		return fmt.Errorf("failed to remove stale rules from chain %s: %v", iptConnLimitChainName, err)
+	}
+	if err := c.iptablesManager.EnsureRules(utiliptables.TableFilter, utiliptables.ChainForward, proto, iptConnLimitJumpRule); err != nil {
+		return fmt.Errorf("failed to create rule in chain %s to jump to chain %s: %v", utiliptables.ChainForward, iptConnLimitChainName, err)
+	}
+	// nothing jumps to the connection limit chains of the EgressIPs anymore, they are created again when the
+	// EgressIPs are synced
+	chains, err := c.iptablesManager.GetChains(utiliptables.TableFilter, proto)
+	if err != nil {
+		return fmt.Errorf("failed to list the connection limit chains: %v", err)
+	}
+	for _, chain := range chains {
+		if !strings.HasPrefix(string(chain), eIPConnLimitChainPrefix) {
+			continue
+		}
+		if err := c.iptablesManager.DeleteChain(utiliptables.TableFilter, chain, proto); err != nil {
+			return fmt.Errorf("failed to delete stale connection limit chain %s: %v", chain, err)
+		}
+	}
+	return nil
+}
+
+// recordRejectedConnections records the packets rejected by the connection limit chain of each EgressIP since the
+// last collection.
+func (c *Controller) recordRejectedConnections() {
+	rejectedConnections := make(map[string]uint64, len(c.rejectedConnections))
+	for _, name := range c.cache.GetKeys() {
+		var limited, v6 bool
+		_ = c.cache.DoWithLock(name, func(name string) error {
+			existing, found := c.cache.Load(name)
+			if found && existing.eIPConfig != nil && existing.eIPConfig.ip != nil && existing.eIPConfig.maxConnections > 0 {
+				limited = true
+				v6 = existing.eIPConfig.ip.IP.To4() == nil
+			}
+			return nil
+		})
+		if !limited {
+			continue
+		}
+		proto := utiliptables.ProtocolIPv4
+		if v6 {
+			proto = utiliptables.ProtocolIPv6
+		}
+		count, err := c.iptablesManager.GetChainPacketCount(utiliptables.TableFilter, getConnLimitChain(name), proto)
+		if err != nil {
+			klog.Errorf("Failed to get the rejected connections of EgressIP %s: %v", name, err)
+			continue
+		}
+		previous := c.rejectedConnections[name]
+		if count < previous {
+			// the counters were reset when the connection limit changed
+			previous = 0
+		}
+		if count > previous {
+			metrics.RecordEgressIPRejectedConnections(name, count-previous)
+		}
+		rejectedConnections[name] = count
+	}
+	c.rejectedConnections = rejectedConnections
+}
+
 func (c *Controller) onEIPAdd(obj interface{}) {
 	_, ok := obj.(*eipv1.EgressIP)
 	if !ok {
@@ -519,7 +629,7 @@ func (c *Controller) processEIP(eip *eipv1.EgressIP) (*eIPConfig, *podIPConfigLi
 			continue
 		}
 		isV6 := eIPNet.IP.To4() == nil
-		found, link, err := findLinkOnSameNetworkAsIP(eIPNet.IP, c.v4, c.v6)
+		found, link, err := findLinkToHostEIP(eip, eIPNet.IP, c.v4, c.v6)
 		if err != nil {
 			return eIPConfig, podIPConfigs, selectedNamespaces, selectedPods, selectedNamespacesPods,
 				fmt.Errorf("failed to find a network to host EgressIP %s IP %s: %v", eip.Name, status.EgressIP, err)
@@ -529,15 +639,22 @@ func (c *Controller) processEIP(eip *eipv1.EgressIP) (*eIPConfig, *podIPConfigLi
 		}
 		// go through all selected pods and build a config per pod IP. We know there are at least one pod and these the
 		// pod(s) have IP(s).
-		eIPConfig, podIPConfigs = generateEIPConfigForPods(selectedPodIPs, link, eIPNet, isV6)
+		var maxConnections int32
+		if eip.Spec.MaxConnections != nil {
+			maxConnections = *eip.Spec.MaxConnections
+		}
+		eIPConfig, podIPConfigs = generateEIPConfigForPods(eip.Name, selectedPodIPs, link, eIPNet, isV6, maxConnections)
 		// ignore other EIP IPs. Multiple EIP IPs cannot be assigned to the same node
 		break
 	}
 	return eIPConfig, podIPConfigs, selectedNamespaces, selectedPods, selectedNamespacesPods, nil
 }
 
-func generateEIPConfigForPods(pods map[ktypes.NamespacedName][]net.IP, link netlink.Link, eIPNet *net.IPNet, v6 bool) (*eIPConfig, *podIPConfigList) {
+func generateEIPConfigForPods(name string, pods map[ktypes.NamespacedName][]net.IP, link netlink.Link, eIPNet *net.IPNet, v6 bool,
+	maxConnections int32) (*eIPConfig, *podIPConfigList) {
 	eipConfig := newEIPConfig()
+	eipConfig.name = name
+	eipConfig.maxConnections = maxConnections
 	newPodIPConfigs := newPodIPConfigList()
 	eipConfig.routeLink = getDefaultRouteForLink(link, v6)
 	eipConfig.ip = getNetlinkAddressWithLabel(eIPNet, link.Attrs().Index, link.Attrs().Name)
@@ -546,6 +663,9 @@ func generateEIPConfigForPods(pods map[ktypes.NamespacedName][]net.IP, link netl
 			ipConfig := newPodIPConfig()
 			ipConfig.ipTableRule = generateIPTablesSNATRuleArg(ip, link.Attrs().Name, eIPNet.IP.String())
 			ipConfig.ipRule = generateIPRule(ip, link.Attrs().Index)
+			if maxConnections > 0 {
+				ipConfig.connLimitRule = generateIPTablesConnLimitJumpRuleArg(ip, link.Attrs().Name, getConnLimitChain(name))
+			}
 			if ip.To4() == nil {
 				ipConfig.v6 = true
 			}
@@ -555,6 +675,33 @@ func generateEIPConfigForPods(pods map[ktypes.NamespacedName][]net.IP, link netl
 	return eipConfig, newPodIPConfigs
 }
 
+// probeEIPConflict fails when another host of the network of the link the egress IP is about to be assigned to
+// already uses it, so that the assignment is retried instead of creating a silent conflict
+func (c *Controller) probeEIPConflict(eIPConfig *eIPConfig) error {
+	if c.probeAddress == nil {
+		return nil
+	}
+	linkName := eIPConfig.routeLink.Link.Attrs().Name
+	inUse, owner, err := c.probeAddress(eIPConfig.ip.IP, linkName)
+	if err != nil {
+		// a failure to probe must not prevent the assignment
+		klog.Warningf("Failed to probe EgressIP %s IP %s on link %s for conflicts: %v", eIPConfig.name,
+			eIPConfig.ip.IP, linkName, err)
+		return nil
+	}
+	if !inUse {
+		return nil
+	}
+	eIPRef := corev1.ObjectReference{
+		Kind: "EgressIP",
+		Name: eIPConfig.name,
+	}
+	c.recorder.Eventf(&eIPRef, corev1.EventTypeWarning, "EgressIPConflict", "egress IP %s is already in use by %s on "+
+		"the network of interface %s of node %s, not assigning it", eIPConfig.ip.IP, owner, linkName, c.nodeName)
+	return fmt.Errorf("egress IP %s of EgressIP %s is already in use by %s on the network of link %s", eIPConfig.ip.IP,
+		eIPConfig.name, owner, linkName)
+}
+
 func (c *Controller) deleteRefObjects(name string) {
 	c.referencedObjectsLock.Lock()
 	delete(c.referencedObjects, name)
@@ -631,8 +778,38 @@ func (c *Controller) updateEIP(existing *state, update *config) error {
 		c.routeManager.Del(*existing.eIPConfig.routeLink)
 	}
 
+	// remove the connection limit if it was removed, its chain with it, or replace its rule if it was changed. The
+	// rules jumping to a removed chain were removed with the pod configuration above.
+	if existing.eIPConfig != nil && existing.eIPConfig.ip != nil && existing.eIPConfig.maxConnections > 0 {
+		if update == nil || update.eIPConfig == nil || update.eIPConfig.ip == nil ||
+			update.eIPConfig.maxConnections == 0 || isIPv6Addr(update.eIPConfig.ip) != isIPv6Addr(existing.eIPConfig.ip) {
+			if err := c.deleteConnLimit(existing.eIPConfig); err != nil {
+				return err
+			}
+			existing.eIPConfig.maxConnections = 0
+		} else if update.eIPConfig.maxConnections != existing.eIPConfig.maxConnections {
+			if err := c.deleteConnLimitRule(existing.eIPConfig); err != nil {
+				return err
+			}
+			existing.eIPConfig.maxConnections = 0
+		}
+	}
+
 	// apply new changes
 	if update != nil && update.eIPConfig != nil && update.eIPConfig.ip != nil && update.eIPConfig.routeLink != nil {
+		if existing.eIPConfig.ip == nil || !existing.eIPConfig.ip.Equal(*update.eIPConfig.ip) {
+			if err := c.probeEIPConflict(update.eIPConfig); err != nil {
+				return err
+			}
+		}
+		// the connection limit chain needs to exist before the pod rules jumping to it are added
+		if update.eIPConfig.maxConnections > 0 {
+			if err := c.ensureConnLimit(update.eIPConfig); err != nil {
+				return err
+			}
+		}
+		existing.eIPConfig.name = update.eIPConfig.name
+		existing.eIPConfig.maxConnections = update.eIPConfig.maxConnections
 		for updatedTargetNS, updatedTargetPod := range update.namespacesWithPods {
 			existingNs, found := existing.namespacesWithPodIPConfigs[updatedTargetNS]
 			if !found {
@@ -667,10 +844,64 @@ func (c *Controller) updateEIP(existing *state, update *config) error {
 	return nil
 }
 
+// ensureConnLimit ensures the connection limit chain of an EgressIP rejects new connections above its limit
+func (c *Controller) ensureConnLimit(eIPConfig *eIPConfig) error {
+	v6 := isIPv6Addr(eIPConfig.ip)
+	proto := utiliptables.ProtocolIPv4
+	if v6 {
+		proto = utiliptables.ProtocolIPv6
+	}
+	chain := getConnLimitChain(eIPConfig.name)
+	if err := c.iptablesManager.EnsureRules(utiliptables.TableFilter, chain, proto,
+		[]iptables.RuleArg{generateIPTablesConnLimitRuleArg(eIPConfig.maxConnections, v6)}); err != nil {
+		return fmt.Errorf("failed to ensure connection limit of EgressIP %s in chain %s: %v", eIPConfig.name, chain, err)
+	}
+	return nil
+}
+
+// deleteConnLimitRule deletes the rule of the connection limit chain of an EgressIP whose limit changed
+func (c *Controller) deleteConnLimitRule(eIPConfig *eIPConfig) error {
+	v6 := isIPv6Addr(eIPConfig.ip)
+	proto := utiliptables.ProtocolIPv4
+	if v6 {
+		proto = utiliptables.ProtocolIPv6
+	}
+	chain := getConnLimitChain(eIPConfig.name)
+	if err := c.iptablesManager.DeleteRule(utiliptables.TableFilter, chain, proto,
+		generateIPTablesConnLimitRuleArg(eIPConfig.maxConnections, v6)); err != nil {
+		return fmt.Errorf("failed to delete connection limit of EgressIP %s in chain %s: %v", eIPConfig.name, chain, err)
+	}
+	return nil
+}
+
+// deleteConnLimit flushes and deletes the connection limit chain of an EgressIP whose limit was removed
+func (c *Controller) deleteConnLimit(eIPConfig *eIPConfig) error {
+	proto := utiliptables.ProtocolIPv4
+	if isIPv6Addr(eIPConfig.ip) {
+		proto = utiliptables.ProtocolIPv6
+	}
+	chain := getConnLimitChain(eIPConfig.name)
+	if err := c.iptablesManager.DeleteChain(utiliptables.TableFilter, chain, proto); err != nil {
+		return fmt.Errorf("failed to delete connection limit chain %s of EgressIP %s: %v", chain, eIPConfig.name, err)
+	}
+	metrics.DeleteEgressIPRejectedConnections(eIPConfig.name)
+	return nil
+}
+
 func (c *Controller) deleteIPConfig(podIPConfigToDelete *podIPConfig) error {
 	if err := c.ruleManager.Delete(podIPConfigToDelete.ipRule); err != nil {
 		return err
 	}
+	if len(podIPConfigToDelete.connLimitRule.Args) > 0 {
+		proto := utiliptables.ProtocolIPv4
+		if podIPConfigToDelete.v6 {
+			proto = utiliptables.ProtocolIPv6
+		}
+		if err := c.iptablesManager.DeleteRule(utiliptables.TableFilter, iptConnLimitChainName, proto,
+			podIPConfigToDelete.connLimitRule); err != nil {
+			return err
+		}
+	}
 	if podIPConfigToDelete.v6 {
 		if err := c.iptablesManager.DeleteRule(utiliptables.TableNAT, iptChainName, utiliptables.ProtocolIPv6,
 			podIPConfigToDelete.ipTableRule); err != nil {
@@ -697,6 +928,16 @@ func (c *Controller) applyPodConfig(existingConfig *podIPConfigList, updatedPoli
 			existingConfig.InsertOverwriteFailed(*newConfig)
 			return err
 		}
+		if len(newConfig.connLimitRule.Args) > 0 {
+			proto := utiliptables.ProtocolIPv4
+			if newConfig.v6 {
+				proto = utiliptables.ProtocolIPv6
+			}
+			if err := c.iptablesManager.EnsureRules(utiliptables.TableFilter, iptConnLimitChainName, proto, []iptables.RuleArg{newConfig.connLimitRule}); err != nil {
+				existingConfig.InsertOverwriteFailed(*newConfig)
+				return fmt.Errorf("failed to ensure rules (%+v) in chain %s: %v", newConfig.connLimitRule, iptConnLimitChainName, err)
+			}
+		}
 		// v4
 		if newConfig.v6 {
 			if err := c.iptablesManager.EnsureRules(utiliptables.TableNAT, iptChainName, utiliptables.ProtocolIPv6, []iptables.RuleArg{newConfig.ipTableRule}); err != nil {
@@ -840,7 +1081,7 @@ func (c *Controller) RepairNode() error {
 				continue
 			}
 			isV6 := eIPNet.IP.To4() == nil
-			found, link, err := findLinkOnSameNetworkAsIP(eIPNet.IP, c.v4, c.v6)
+			found, link, err := findLinkToHostEIP(egressIP, eIPNet.IP, c.v4, c.v6)
 			if err != nil {
 				return fmt.Errorf("failed to find a network to host EgressIP %s IP %s: %v", egressIP.Name,
 					eIPNet.IP.String(), err)
@@ -1006,6 +1247,43 @@ func getRouteTableID(ifIndex int) int {
 	return ifIndex + routingTableIDStart
 }
 
+// findLinkToHostEIP returns the link with an address in the network selected
+// by the EgressIP if any, else the link on the same network as the IP
+func findLinkToHostEIP(eip *eipv1.EgressIP, ip net.IP, v4, v6 bool) (bool, netlink.Link, error) {
+	if eip.Spec.Network == "" {
+		return findLinkOnSameNetworkAsIP(ip, v4, v6)
+	}
+	network, err := netip.ParsePrefix(eip.Spec.Network)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to parse network %s: %v", eip.Spec.Network, err)
+	}
+	if network.Addr().Is4() != (ip.To4() != nil) {
+		return false, nil, nil
+	}
+	return findLinkOnNetwork(network.Masked(), v4, v6)
+}
+
+// findLinkOnNetwork returns the link with an address in the network
+func findLinkOnNetwork(network netip.Prefix, v4, v6 bool) (bool, netlink.Link, error) {
+	links, err := util.GetNetLinkOps().LinkList()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to list links: %v", err)
+	}
+	for _, link := range links {
+		linkPrefixes, err := linkmanager.GetExternallyAvailablePrefixesExcludeAssigned(link, v4, v6)
+		if err != nil {
+			klog.Errorf("Failed to get address from link %s: %v", link.Attrs().Name, err)
+			continue
+		}
+		for _, prefix := range linkPrefixes {
+			if prefix.Masked() == network {
+				return true, link, nil
+			}
+		}
+	}
+	return false, nil, nil
+}
+
 func findLinkOnSameNetworkAsIP(ip net.IP, v4, v6 bool) (bool, netlink.Link, error) {
 	found, link, err := findLinkOnSameNetworkAsIPUsingLPM(ip, v4, v6)
 	if err != nil {
@@ -1133,3 +1411,38 @@ func generateIPTablesSNATRuleArg(srcIP net.IP, infName, snatIP string) iptables.
 	}
 	return iptables.RuleArg{Args: []string{"-s", srcIPFullMask, "-o", infName, "-j", "SNAT", "--to-source", snatIP}}
 }
+
+// generateIPTablesConnLimitJumpRuleArg generates the rule sending the new connections of a pod IP to the connection
+// limit chain of its EgressIP
+func generateIPTablesConnLimitJumpRuleArg(srcIP net.IP, infName string, connLimitChain utiliptables.Chain) iptables.RuleArg {
+	var srcIPFullMask string
+	if srcIP.To4() != nil { // v4
+		srcIPFullMask = fmt.Sprintf("%s/32", srcIP.String())
+	} else { // v6
+		srcIPFullMask = fmt.Sprintf("%s/128", srcIP.String())
+	}
+	return iptables.RuleArg{Args: []string{"-s", srcIPFullMask, "-o", infName, "-m", "conntrack", "--ctstate", "NEW", "-j", string(connLimitChain)}}
+}
+
+// generateIPTablesConnLimitRuleArg generates the rule of the connection limit chain of an EgressIP. A zero mask groups
+// the connections of all the pod IPs jumping to the chain together.
+func generateIPTablesConnLimitRuleArg(maxConnections int32, v6 bool) iptables.RuleArg {
+	rejectWith := "icmp-port-unreachable"
+	if v6 {
+		rejectWith = "icmp6-port-unreachable"
+	}
+	return iptables.RuleArg{Args: []string{"-m", "connlimit", "--connlimit-above", strconv.Itoa(int(maxConnections)),
+		"--connlimit-mask", "0", "--connlimit-saddr", "-j", "REJECT", "--reject-with", rejectWith}}
+}
+
+// getConnLimitChain returns the connection limit chain of an EgressIP. The name of the EgressIP is hashed to fit the
+// maximum length of chain names.
+func getConnLimitChain(name string) utiliptables.Chain {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	return utiliptables.Chain(fmt.Sprintf("%s%08x", eIPConnLimitChainPrefix, hash.Sum32()))
+}
+
+func isIPv6Addr(addr *netlink.Addr) bool {
+	return addr != nil && addr.IP.To4() == nil
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/util/iptables"
 	utiliptables "k8s.io/kubernetes/pkg/util/iptables"
 	kexec "k8s.io/utils/exec"
@@ -202,7 +203,7 @@ func initController(namespaces []corev1.Namespace, pods []corev1.Pod, egressIPs
 		return nil, err
 	}
 	c, err := NewController(watchFactory.EgressIPInformer(), watchFactory.NodeInformer(), watchFactory.NamespaceInformer(),
-		watchFactory.PodCoreInformer(), rm, v4, v6, node1Name)
+		watchFactory.PodCoreInformer(), rm, v4, v6, node1Name, record.NewFakeRecorder(10))
 	if err != nil {
 		return nil, err
 	}
@@ -1072,6 +1073,62 @@ func getLinkIndex(linkName string) int {
 	return hash(linkName)%200 + 5
 }
 
+var _ = ginkgo.Describe("EgressIP connection limit", func() {
+	ginkgo.It("generates the rules limiting the connections of the pods of an EgressIP", func() {
+		link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "dummy1", Index: 5}}
+		_, eIPNet, err := net.ParseCIDR("192.168.1.10/32")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		pods := map[types.NamespacedName][]net.IP{
+			{Namespace: "ns1", Name: "pod1"}: {net.ParseIP("10.244.0.5")},
+		}
+
+		eIPConfig, podIPConfigs := generateEIPConfigForPods("egressip1", pods, link, eIPNet, false, 0)
+		gomega.Expect(eIPConfig.name).To(gomega.Equal("egressip1"))
+		gomega.Expect(eIPConfig.maxConnections).To(gomega.BeZero())
+		gomega.Expect(podIPConfigs.elems).To(gomega.HaveLen(1))
+		gomega.Expect(podIPConfigs.elems[0].connLimitRule.Args).To(gomega.BeEmpty())
+
+		chain := getConnLimitChain("egressip1")
+		gomega.Expect(len(chain)).To(gomega.BeNumerically("<=", 28))
+		gomega.Expect(chain).NotTo(gomega.Equal(getConnLimitChain("egressip2")))
+		eIPConfig, podIPConfigs = generateEIPConfigForPods("egressip1", pods, link, eIPNet, false, 100)
+		gomega.Expect(eIPConfig.maxConnections).To(gomega.BeEquivalentTo(100))
+		gomega.Expect(podIPConfigs.elems).To(gomega.HaveLen(1))
+		gomega.Expect(podIPConfigs.elems[0].connLimitRule.Args).To(gomega.Equal([]string{"-s", "10.244.0.5/32", "-o", "dummy1",
+			"-m", "conntrack", "--ctstate", "NEW", "-j", string(chain)}))
+		gomega.Expect(generateIPTablesConnLimitRuleArg(100, false).Args).To(gomega.Equal([]string{"-m", "connlimit",
+			"--connlimit-above", "100", "--connlimit-mask", "0", "--connlimit-saddr", "-j", "REJECT", "--reject-with", "icmp-port-unreachable"}))
+	})
+})
+
+var _ = ginkgo.Describe("EgressIP conflict probe", func() {
+	ginkgo.It("fails the assignment of an egress IP already in use on the network of its link", func() {
+		link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "dummy1", Index: 5}}
+		_, eIPNet, err := net.ParseCIDR("192.168.1.10/32")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		eIPConfig, _ := generateEIPConfigForPods("egressip1", nil, link, eIPNet, false, 0)
+		recorder := record.NewFakeRecorder(10)
+		c := &Controller{nodeName: node1Name, recorder: recorder}
+		// nothing is probed when the probes are disabled
+		gomega.Expect(c.probeEIPConflict(eIPConfig)).To(gomega.Succeed())
+
+		var probed []string
+		owner := ""
+		c.probeAddress = func(ip net.IP, linkName string) (bool, string, error) {
+			probed = append(probed, ip.String()+"@"+linkName)
+			return owner != "", owner, nil
+		}
+		gomega.Expect(c.probeEIPConflict(eIPConfig)).To(gomega.Succeed())
+		gomega.Expect(probed).To(gomega.Equal([]string{"192.168.1.10@dummy1"}))
+		gomega.Expect(recorder.Events).To(gomega.BeEmpty())
+
+		owner = "0a:58:0a:f4:00:06"
+		gomega.Expect(c.probeEIPConflict(eIPConfig)).To(gomega.MatchError(gomega.ContainSubstring("already in use by " + owner)))
+		gomega.Expect(recorder.Events).To(gomega.Receive(gomega.HavePrefix("Warning EgressIPConflict egress IP 192.168.1.10 " +
+			"is already in use by " + owner)))
+	})
+})
+
 func hash(s string) int {
 	h := fnv.New32a()
 	h.Write([]byte(s))
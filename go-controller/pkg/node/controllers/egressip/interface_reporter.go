@@ -0,0 +1,131 @@
+package egressip
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	egressipinformer "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/informers/externalversions/egressip/v1"
+	egressiplisters "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/listers/egressip/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/vishvananda/netlink"
+)
+
+// InterfaceReporter periodically annotates the node with the interface each
+// egress IP assigned to the node is programmed on, so that
+// ovnkube-cluster-manager reports it in the status of the EgressIPs and
+// reports the egress IPs not programmed yet as degraded. The egress IPs of
+// the OVN managed network are programmed on the gateway router, whose
+// traffic leaves the node from the gateway bridge. The others are reported
+// once their address is found on a link of the node.
+type InterfaceReporter struct {
+	nodeName      string
+	gatewayBridge string
+	kube          kube.Interface
+	nodeLister    corelisters.NodeLister
+	eIPLister     egressiplisters.EgressIPLister
+	// linkAddresses returns the name of the link of each address of the node
+	linkAddresses func() (map[string]string, error)
+}
+
+func NewInterfaceReporter(eIPInformer egressipinformer.EgressIPInformer, nodeInformer cache.SharedIndexInformer,
+	kube kube.Interface, nodeName, gatewayBridge string) *InterfaceReporter {
+	return &InterfaceReporter{
+		nodeName:      nodeName,
+		gatewayBridge: gatewayBridge,
+		kube:          kube,
+		nodeLister:    corelisters.NewNodeLister(nodeInformer.GetIndexer()),
+		eIPLister:     eIPInformer.Lister(),
+		linkAddresses: listLinkAddresses,
+	}
+}
+
+// Run reports the interfaces every interval until stopCh is closed
+func (r *InterfaceReporter) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, interval time.Duration) {
+	klog.Infof("Starting Egress IP interface reporter")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if err := r.report(); err != nil {
+				klog.Errorf("Failed to report the Egress IP interfaces: %v", err)
+			}
+		}, interval, stopCh)
+	}()
+}
+
+func (r *InterfaceReporter) report() error {
+	node, err := r.nodeLister.Get(r.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %v", r.nodeName, err)
+	}
+	assigned, err := getAssignedEgressIPs(r.eIPLister, r.nodeName)
+	if err != nil {
+		return err
+	}
+	interfaces := map[string]string{}
+	var addresses map[string]string
+	for egressIP := range assigned {
+		isOVNManagedNetwork, err := util.IsOVNManagedNetwork(node, net.ParseIP(egressIP))
+		if err != nil {
+			klog.Warningf("Unable to determine if egress IP %s is OVN managed: %v", egressIP, err)
+			continue
+		}
+		if isOVNManagedNetwork {
+			if r.gatewayBridge != "" {
+				interfaces[egressIP] = r.gatewayBridge
+			}
+			continue
+		}
+		if addresses == nil {
+			if addresses, err = r.linkAddresses(); err != nil {
+				return err
+			}
+		}
+		if link, ok := addresses[egressIP]; ok {
+			interfaces[egressIP] = link
+		}
+	}
+	annotated, err := util.ParseNodeEgressIPInterfaces(node)
+	if err != nil {
+		klog.Warningf("Overwriting the egress IP interfaces of node %s: %v", r.nodeName, err)
+	}
+	if reflect.DeepEqual(interfaces, annotated) {
+		return nil
+	}
+	klog.V(5).Infof("Egress IPs of node %s are programmed on interfaces %v", r.nodeName, interfaces)
+	nodeAnnotator := kube.NewNodeAnnotator(r.kube, r.nodeName)
+	if err := util.SetNodeEgressIPInterfaces(nodeAnnotator, interfaces); err != nil {
+		return err
+	}
+	return nodeAnnotator.Run()
+}
+
+// listLinkAddresses returns the name of the link of each address of the node
+func listLinkAddresses() (map[string]string, error) {
+	links, err := util.GetNetLinkOps().LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %v", err)
+	}
+	addresses := map[string]string{}
+	for _, link := range links {
+		addrs, err := util.GetNetLinkOps().AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			klog.Errorf("Failed to get addresses of link %s: %v", link.Attrs().Name, err)
+			continue
+		}
+		for _, addr := range addrs {
+			addresses[addr.IP.String()] = link.Attrs().Name
+		}
+	}
+	return addresses, nil
+}
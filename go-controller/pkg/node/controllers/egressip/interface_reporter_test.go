@@ -0,0 +1,78 @@
+package egressip
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	eipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	egressiplisters "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/listers/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var _ = ginkgo.Describe("EgressIP interface reporter", func() {
+	const (
+		nodeName      = "node1"
+		ovnEgressIP   = "192.168.126.101"
+		otherEgressIP = "10.10.10.5"
+	)
+
+	ginkgo.It("reports the interfaces the egress IPs assigned to the node are programmed on", func() {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: nodeName,
+				Annotations: map[string]string{
+					"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\"}", "192.168.126.12/24"),
+				},
+			},
+		}
+		eIPIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		for name, status := range map[string][]eipv1.EgressIPStatusItem{
+			"eip1": {{Node: nodeName, EgressIP: ovnEgressIP}, {Node: "node2", EgressIP: "192.168.126.102"}},
+			"eip2": {{Node: nodeName, EgressIP: otherEgressIP}},
+		} {
+			gomega.Expect(eIPIndexer.Add(&eipv1.EgressIP{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status:     eipv1.EgressIPStatus{Items: status},
+			})).To(gomega.Succeed())
+		}
+		client := fake.NewSimpleClientset(node)
+		nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		addresses := map[string]string{}
+		r := &InterfaceReporter{
+			nodeName:      nodeName,
+			gatewayBridge: "breth0",
+			kube:          &kube.Kube{KClient: client},
+			nodeLister:    corelisters.NewNodeLister(nodeIndexer),
+			eIPLister:     egressiplisters.NewEgressIPLister(eIPIndexer),
+			linkAddresses: func() (map[string]string, error) { return addresses, nil },
+		}
+		report := func() map[string]string {
+			// the lister is synced with the node as an informer would
+			node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(nodeIndexer.Update(node)).To(gomega.Succeed())
+			gomega.Expect(r.report()).To(gomega.Succeed())
+			node, err = client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			interfaces, err := util.ParseNodeEgressIPInterfaces(node)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			return interfaces
+		}
+
+		// the egress IP of the OVN managed network leaves from the gateway
+		// bridge, the other one is not programmed yet
+		gomega.Expect(report()).To(gomega.Equal(map[string]string{ovnEgressIP: "breth0"}))
+
+		addresses[otherEgressIP] = "eth1"
+		gomega.Expect(report()).To(gomega.Equal(map[string]string{ovnEgressIP: "breth0", otherEgressIP: "eth1"}))
+	})
+})
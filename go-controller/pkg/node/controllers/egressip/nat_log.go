@@ -0,0 +1,187 @@
+package egressip
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	egressipinformer "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/informers/externalversions/egressip/v1"
+	egressiplisters "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/listers/egressip/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	natLogEventStart = "start"
+	natLogEventEnd   = "end"
+)
+
+var natLogProtocols = map[uint8]string{
+	1:   "icmp",
+	6:   "tcp",
+	17:  "udp",
+	58:  "icmpv6",
+	132: "sctp",
+}
+
+// natLogRecord is a record of the NAT translation log, written as a JSON line
+type natLogRecord struct {
+	Time     string `json:"time"`
+	Event    string `json:"event"`
+	EgressIP string `json:"egressIP"`
+	Protocol string `json:"protocol"`
+	SrcIP    string `json:"srcIP"`
+	SrcPort  uint16 `json:"srcPort"`
+	DstIP    string `json:"dstIP"`
+	DstPort  uint16 `json:"dstPort"`
+	SNATIP   string `json:"snatIP"`
+	SNATPort uint16 `json:"snatPort"`
+}
+
+// NATLogger periodically logs the connections SNATed to the egress IPs
+// assigned to the node, as tracked by conntrack, when they are first seen and
+// when they are gone, so that the egress traffic can be traced back to its
+// source. Like the UsageCollector, it covers both the OVN managed and the
+// non-OVN managed networks. Connections shorter than the interval might be
+// missed.
+type NATLogger struct {
+	nodeName  string
+	v4        bool
+	v6        bool
+	eIPLister egressiplisters.EgressIPLister
+	writer    io.WriteCloser
+	// sampling logs 1 out of every sampling connections
+	sampling uint64
+	now      func() time.Time
+	// flows holds the logged flows that were still tracked at the previous
+	// collection so that their end is logged once they are gone
+	flows map[usageFlowKey]*natLogRecord
+}
+
+func NewNATLogger(eIPInformer egressipinformer.EgressIPInformer, v4, v6 bool, nodeName string, writer io.WriteCloser,
+	sampling int) *NATLogger {
+	if sampling < 1 {
+		sampling = 1
+	}
+	return &NATLogger{
+		nodeName:  nodeName,
+		v4:        v4,
+		v6:        v6,
+		eIPLister: eIPInformer.Lister(),
+		writer:    writer,
+		sampling:  uint64(sampling),
+		now:       time.Now,
+		flows:     map[usageFlowKey]*natLogRecord{},
+	}
+}
+
+// Run logs the SNATed connections every interval until stopCh is closed
+func (l *NATLogger) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, interval time.Duration) {
+	klog.Infof("Starting Egress IP NAT logger")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if err := l.collect(); err != nil {
+				klog.Errorf("Failed to log the Egress IP NAT translations: %v", err)
+			}
+		}, interval, stopCh)
+		if err := l.writer.Close(); err != nil {
+			klog.Warningf("Failed to close the Egress IP NAT log: %v", err)
+		}
+	}()
+}
+
+func (l *NATLogger) collect() error {
+	assigned, err := getAssignedEgressIPs(l.eIPLister, l.nodeName)
+	if err != nil {
+		return err
+	}
+	var flows []*netlink.ConntrackFlow
+	if len(assigned) > 0 {
+		flows, err = listConntrackFlows(l.v4, l.v6)
+		if err != nil {
+			return err
+		}
+	}
+	for _, record := range l.update(assigned, flows) {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := l.writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update returns the records of the sampled flows SNATed to the assigned
+// egress IPs that started or ended since the previous update
+func (l *NATLogger) update(assigned map[string]string, flows []*netlink.ConntrackFlow) []*natLogRecord {
+	now := l.now().UTC()
+	var records []*natLogRecord
+	seen := make(map[usageFlowKey]*natLogRecord, len(l.flows))
+	for _, flow := range flows {
+		key, ok := getSNATFlowKey(flow, assigned)
+		if !ok || !l.sampled(key) {
+			continue
+		}
+		if record, ok := l.flows[key]; ok {
+			seen[key] = record
+			continue
+		}
+		// the start of the flow is only known when conntrack timestamps are
+		// enabled
+		start := now
+		if flow.TimeStart != 0 {
+			start = time.Unix(0, int64(flow.TimeStart)).UTC()
+		}
+		protocol, ok := natLogProtocols[flow.Forward.Protocol]
+		if !ok {
+			protocol = strconv.Itoa(int(flow.Forward.Protocol))
+		}
+		record := &natLogRecord{
+			Time:     start.Format(time.RFC3339Nano),
+			Event:    natLogEventStart,
+			EgressIP: assigned[key.egressIP],
+			Protocol: protocol,
+			SrcIP:    key.srcIP,
+			SrcPort:  key.srcPort,
+			DstIP:    key.dstIP,
+			DstPort:  key.dstPort,
+			SNATIP:   key.egressIP,
+			SNATPort: flow.Reverse.DstPort,
+		}
+		records = append(records, record)
+		seen[key] = record
+	}
+	for key, record := range l.flows {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		end := *record
+		end.Time = now.Format(time.RFC3339Nano)
+		end.Event = natLogEventEnd
+		records = append(records, &end)
+	}
+	l.flows = seen
+	return records
+}
+
+// sampled returns whether the flow is logged. The decision only depends on
+// the flow so that both its start and end are logged.
+func (l *NATLogger) sampled(key usageFlowKey) bool {
+	if l.sampling == 1 {
+		return true
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key.srcIP + "/" + key.dstIP + "/" + key.egressIP))
+	_, _ = hash.Write([]byte{key.protocol, byte(key.srcPort >> 8), byte(key.srcPort), byte(key.dstPort >> 8), byte(key.dstPort)})
+	return hash.Sum64()%l.sampling == 0
+}
@@ -0,0 +1,80 @@
+package egressip
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+var _ = ginkgo.Describe("EgressIP NAT logger", func() {
+	const (
+		egressIP1 = "172.18.0.33"
+		podIP     = "10.244.0.5"
+		dstIP     = "1.1.1.1"
+	)
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	newNATLogger := func(sampling uint64) *NATLogger {
+		return &NATLogger{
+			nodeName: "node1",
+			v4:       true,
+			sampling: sampling,
+			now:      func() time.Time { return now },
+			flows:    map[usageFlowKey]*natLogRecord{},
+		}
+	}
+
+	ginkgo.It("logs the start and end of the connections SNATed to the assigned egress IPs", func() {
+		l := newNATLogger(1)
+		assigned := map[string]string{egressIP1: "eip1"}
+
+		flow := newSNATConntrackFlow(podIP, dstIP, egressIP1, 40000, 100, 200)
+		flow.Reverse.DstPort = 50000
+		started := newSNATConntrackFlow(podIP, dstIP, egressIP1, 40001, 100, 200)
+		started.TimeStart = uint64(now.Add(-time.Second).UnixNano())
+		records := l.update(assigned, []*netlink.ConntrackFlow{
+			flow,
+			started,
+			newSNATConntrackFlow(egressIP1, dstIP, egressIP1, 40000, 100, 200),
+			newSNATConntrackFlow(podIP, dstIP, "172.18.0.55", 40002, 100, 200),
+		})
+		gomega.Expect(records).To(gomega.ConsistOf(
+			&natLogRecord{Time: "2023-06-01T10:00:00Z", Event: natLogEventStart, EgressIP: "eip1", Protocol: "tcp",
+				SrcIP: podIP, SrcPort: 40000, DstIP: dstIP, DstPort: 443, SNATIP: egressIP1, SNATPort: 50000},
+			&natLogRecord{Time: "2023-06-01T09:59:59Z", Event: natLogEventStart, EgressIP: "eip1", Protocol: "tcp",
+				SrcIP: podIP, SrcPort: 40001, DstIP: dstIP, DstPort: 443, SNATIP: egressIP1, SNATPort: 40001},
+		))
+
+		// the connections still tracked are not logged again
+		now = now.Add(5 * time.Second)
+		records = l.update(assigned, []*netlink.ConntrackFlow{flow})
+		gomega.Expect(records).To(gomega.ConsistOf(
+			&natLogRecord{Time: "2023-06-01T10:00:05Z", Event: natLogEventEnd, EgressIP: "eip1", Protocol: "tcp",
+				SrcIP: podIP, SrcPort: 40001, DstIP: dstIP, DstPort: 443, SNATIP: egressIP1, SNATPort: 40001},
+		))
+		gomega.Expect(l.update(assigned, []*netlink.ConntrackFlow{flow})).To(gomega.BeEmpty())
+
+		// the connections end when the egress IP moves away from the node
+		records = l.update(map[string]string{}, []*netlink.ConntrackFlow{flow})
+		gomega.Expect(records).To(gomega.HaveLen(1))
+		gomega.Expect(records[0].Event).To(gomega.Equal(natLogEventEnd))
+		gomega.Expect(l.flows).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("samples the connections", func() {
+		l := newNATLogger(4)
+		assigned := map[string]string{egressIP1: "eip1"}
+		var flows []*netlink.ConntrackFlow
+		for port := uint16(40000); port < 40400; port++ {
+			flows = append(flows, newSNATConntrackFlow(podIP, dstIP, egressIP1, port, 0, 0))
+		}
+		records := l.update(assigned, flows)
+		gomega.Expect(len(records)).To(gomega.BeNumerically(">", 50))
+		gomega.Expect(len(records)).To(gomega.BeNumerically("<", 150))
+
+		// the end of the sampled connections only is logged
+		gomega.Expect(l.update(assigned, nil)).To(gomega.HaveLen(len(records)))
+	})
+})
@@ -0,0 +1,186 @@
+package egressip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// nextHopProbeTimeout is how long the reply to a probe is waited for
+	nextHopProbeTimeout = time.Second
+	// nextHopProbeFailureThreshold is the number of consecutive failed probes
+	// of a next hop after which it is reported unreachable. A single
+	// successful probe reports it reachable again.
+	nextHopProbeFailureThreshold = 3
+)
+
+// NextHopProber periodically probes the gateway next hops of the node with
+// ICMP echo requests and annotates the node with the ones found unreachable,
+// so that ovnkube-cluster-manager moves the egress IPs of the node to another
+// egress node even if the node itself is healthy.
+type NextHopProber struct {
+	nodeName   string
+	kube       kube.Interface
+	nodeLister corelisters.NodeLister
+	probe      func(net.IP) error
+	// failures holds the number of consecutive failed probes of each next hop
+	failures map[string]int
+	// seq is the sequence number of the next probe
+	seq uint16
+}
+
+func NewNextHopProber(nodeInformer cache.SharedIndexInformer, kube kube.Interface, nodeName string) *NextHopProber {
+	p := &NextHopProber{
+		nodeName:   nodeName,
+		kube:       kube,
+		nodeLister: corelisters.NewNodeLister(nodeInformer.GetIndexer()),
+		failures:   map[string]int{},
+	}
+	p.probe = p.ping
+	return p
+}
+
+// Run probes the next hops every interval until stopCh is closed
+func (p *NextHopProber) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, interval time.Duration) {
+	klog.Infof("Starting Egress IP next hop prober")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if err := p.probeNextHops(); err != nil {
+				klog.Errorf("Failed to probe the Egress IP next hops: %v", err)
+			}
+		}, interval, stopCh)
+	}()
+}
+
+func (p *NextHopProber) probeNextHops() error {
+	node, err := p.nodeLister.Get(p.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %v", p.nodeName, err)
+	}
+	var nextHops []net.IP
+	if gwConfig, err := util.ParseNodeL3GatewayAnnotation(node); err != nil {
+		if !util.IsAnnotationNotSetError(err) {
+			return err
+		}
+	} else {
+		nextHops = gwConfig.NextHops
+	}
+	unreachable := p.update(nextHops)
+	annotated, err := util.ParseNodeEgressNextHopsUnreachable(node)
+	if err != nil {
+		klog.Warningf("Overwriting the unreachable next hops of node %s: %v", p.nodeName, err)
+	}
+	if reflect.DeepEqual(unreachable, annotated) {
+		return nil
+	}
+	if len(unreachable) > 0 {
+		klog.Warningf("Egress IP next hops %v of node %s are unreachable", unreachable, p.nodeName)
+	} else {
+		klog.Infof("Egress IP next hops of node %s are reachable again", p.nodeName)
+	}
+	nodeAnnotator := kube.NewNodeAnnotator(p.kube, p.nodeName)
+	if err := util.SetNodeEgressNextHopsUnreachable(nodeAnnotator, unreachable); err != nil {
+		return err
+	}
+	return nodeAnnotator.Run()
+}
+
+// update probes the next hops and returns, sorted, the ones that failed the
+// last nextHopProbeFailureThreshold probes
+func (p *NextHopProber) update(nextHops []net.IP) []string {
+	var unreachable []string
+	failures := make(map[string]int, len(nextHops))
+	for _, nextHop := range nextHops {
+		key := nextHop.String()
+		if err := p.probe(nextHop); err != nil {
+			failures[key] = p.failures[key] + 1
+			klog.V(5).Infof("Egress IP next hop %s probe %d failed: %v", key, failures[key], err)
+			if failures[key] >= nextHopProbeFailureThreshold {
+				unreachable = append(unreachable, key)
+			}
+		}
+	}
+	p.failures = failures
+	sort.Strings(unreachable)
+	return unreachable
+}
+
+// ping sends an ICMP echo request to the next hop and waits for its reply
+func (p *NextHopProber) ping(nextHop net.IP) error {
+	network, address := "ip4:icmp", "0.0.0.0"
+	requestType, replyType := byte(8), byte(0)
+	if nextHop.To4() == nil {
+		network, address = "ip6:ipv6-icmp", "::"
+		requestType, replyType = 128, 129
+	}
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return fmt.Errorf("failed to open ICMP socket: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(nextHopProbeTimeout)); err != nil {
+		return err
+	}
+	p.seq++
+	id := uint16(os.Getpid())
+	if _, err := conn.WriteTo(buildEchoRequest(requestType, id, p.seq), &net.IPAddr{IP: nextHop}); err != nil {
+		return fmt.Errorf("failed to send echo request: %v", err)
+	}
+	reply := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(reply)
+		if err != nil {
+			return fmt.Errorf("no echo reply: %v", err)
+		}
+		if peerIP, ok := peer.(*net.IPAddr); !ok || !peerIP.IP.Equal(nextHop) || n < 8 || reply[0] != replyType {
+			continue
+		}
+		if binary.BigEndian.Uint16(reply[4:6]) == id && binary.BigEndian.Uint16(reply[6:8]) == p.seq {
+			return nil
+		}
+	}
+}
+
+// buildEchoRequest builds an ICMP or ICMPv6 echo request without payload.
+// The ICMPv6 checksum is left to the kernel.
+func buildEchoRequest(requestType byte, id, seq uint16) []byte {
+	msg := make([]byte, 8)
+	msg[0] = requestType
+	binary.BigEndian.PutUint16(msg[4:6], id)
+	binary.BigEndian.PutUint16(msg[6:8], seq)
+	if requestType == 8 {
+		binary.BigEndian.PutUint16(msg[2:4], icmpChecksum(msg))
+	}
+	return msg
+}
+
+// icmpChecksum returns the internet checksum of the message, RFC 1071
+func icmpChecksum(msg []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(msg); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(msg[i : i+2]))
+	}
+	if len(msg)%2 == 1 {
+		sum += uint32(msg[len(msg)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
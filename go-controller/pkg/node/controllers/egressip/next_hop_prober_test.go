@@ -0,0 +1,86 @@
+package egressip
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var _ = ginkgo.Describe("EgressIP next hop prober", func() {
+	const (
+		nodeName = "node1"
+		nextHop1 = "172.18.0.1"
+		nextHop2 = "172.18.0.2"
+	)
+
+	ginkgo.It("reports the next hops failing consecutive probes unreachable", func() {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: nodeName,
+				Annotations: map[string]string{
+					"k8s.ovn.org/l3-gateway-config": fmt.Sprintf(`{"default":{"mode":"shared",`+
+						`"mac-address":"7e:57:f8:f0:3c:49","ip-address":"172.18.0.10/16","next-hops":["%s","%s"]}}`,
+						nextHop1, nextHop2),
+					"k8s.ovn.org/node-chassis-id": "1d6b9b2e-7bd4-4c46-a4fb-3a7b2b9a0d26",
+				},
+			},
+		}
+		client := fake.NewSimpleClientset(node)
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		failing := map[string]bool{}
+		p := &NextHopProber{
+			nodeName:   nodeName,
+			kube:       &kube.Kube{KClient: client},
+			nodeLister: corelisters.NewNodeLister(indexer),
+			failures:   map[string]int{},
+			probe: func(nextHop net.IP) error {
+				if failing[nextHop.String()] {
+					return fmt.Errorf("timeout")
+				}
+				return nil
+			},
+		}
+		probe := func() []string {
+			// the lister is synced with the node as an informer would
+			node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(indexer.Update(node)).To(gomega.Succeed())
+			gomega.Expect(p.probeNextHops()).To(gomega.Succeed())
+			node, err = client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			unreachable, err := util.ParseNodeEgressNextHopsUnreachable(node)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			return unreachable
+		}
+
+		gomega.Expect(probe()).To(gomega.BeEmpty())
+
+		failing[nextHop2] = true
+		for i := 1; i < nextHopProbeFailureThreshold; i++ {
+			gomega.Expect(probe()).To(gomega.BeEmpty())
+		}
+		gomega.Expect(probe()).To(gomega.Equal([]string{nextHop2}))
+
+		// a single successful probe reports the next hop reachable again
+		failing[nextHop2] = false
+		gomega.Expect(probe()).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("builds valid ICMP echo requests", func() {
+		msg := buildEchoRequest(8, 0x1234, 1)
+		gomega.Expect(msg).To(gomega.HaveLen(8))
+		// the checksum of a message including its checksum is 0
+		gomega.Expect(icmpChecksum(msg)).To(gomega.BeZero())
+	})
+})
@@ -26,6 +26,8 @@ type podIPConfig struct {
 	v6          bool
 	ipTableRule iptables.RuleArg
 	ipRule      netlink.Rule
+	// connLimitRule jumps to the connection limit chain of the EgressIP, it has no args if connections are not limited
+	connLimitRule iptables.RuleArg
 }
 
 func newPodIPConfig() *podIPConfig {
@@ -42,6 +44,9 @@ func (pIC *podIPConfig) equal(pIC2 *podIPConfig) bool {
 	if pIC.ipRule.String() != pIC2.ipRule.String() {
 		return false
 	}
+	if !equal(pIC.connLimitRule.Args, pIC2.connLimitRule.Args) {
+		return false
+	}
 	return true
 }
 
@@ -0,0 +1,257 @@
+package egressip
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	egressipinformer "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/informers/externalversions/egressip/v1"
+	egressiplisters "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/listers/egressip/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	usageDirectionEgress  = "egress"
+	usageDirectionIngress = "ingress"
+)
+
+// usageFlowKey identifies a conntrack flow across conntrack table dumps
+type usageFlowKey struct {
+	protocol  uint8
+	srcIP     string
+	srcPort   uint16
+	dstIP     string
+	dstPort   uint16
+	egressIP  string
+	timeStart uint64
+}
+
+// usageFlowCounters are the byte and packet counters of a conntrack flow
+type usageFlowCounters struct {
+	egressBytes    uint64
+	ingressBytes   uint64
+	egressPackets  uint64
+	ingressPackets uint64
+}
+
+// usageDestinationKey identifies the destination of SNATed connections. The
+// connections of an egress IP towards the same destination each hold one of
+// its source ports.
+type usageDestinationKey struct {
+	protocol uint8
+	dstIP    string
+	dstPort  uint16
+}
+
+// egressIPUsage is the usage of an egress IP since the previous collection
+type egressIPUsage struct {
+	name        string
+	connections int
+	// destinationConnections is the highest number of connections towards
+	// a single destination
+	destinationConnections int
+	egressBytes            uint64
+	ingressBytes           uint64
+	egressPackets          uint64
+	ingressPackets         uint64
+}
+
+// UsageCollector periodically exports the number of connections, the bytes and
+// the packets SNATed to each egress IP assigned to the node, as tracked by
+// conntrack, along with the highest number of connections of an egress IP
+// towards a single destination, which nears the exhaustion of its source
+// ports for that destination. It covers both the OVN managed networks, where
+// the SNAT is performed by the gateway router in the kernel datapath, and the
+// non-OVN managed networks, where it is performed by iptables.
+type UsageCollector struct {
+	nodeName  string
+	v4        bool
+	v6        bool
+	eIPLister egressiplisters.EgressIPLister
+	// flows holds the byte and packet counters of the SNATed flows of the
+	// previous collection so that only the bytes and packets exchanged since
+	// then are recorded
+	flows map[usageFlowKey]usageFlowCounters
+	// assigned holds the egress IPs assigned to the node at the previous
+	// collection, mapped to the name of their EgressIP
+	assigned map[string]string
+}
+
+func NewUsageCollector(eIPInformer egressipinformer.EgressIPInformer, v4, v6 bool, nodeName string) *UsageCollector {
+	return &UsageCollector{
+		nodeName:  nodeName,
+		v4:        v4,
+		v6:        v6,
+		eIPLister: eIPInformer.Lister(),
+		flows:     map[usageFlowKey]usageFlowCounters{},
+		assigned:  map[string]string{},
+	}
+}
+
+// Run collects the egress IP usage every interval until stopCh is closed
+func (u *UsageCollector) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, interval time.Duration) {
+	klog.Infof("Starting Egress IP usage collector")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if err := u.collect(); err != nil {
+				klog.Errorf("Failed to collect the Egress IP usage: %v", err)
+			}
+		}, interval, stopCh)
+	}()
+}
+
+func (u *UsageCollector) collect() error {
+	assigned, err := getAssignedEgressIPs(u.eIPLister, u.nodeName)
+	if err != nil {
+		return err
+	}
+	var flows []*netlink.ConntrackFlow
+	if len(assigned) > 0 {
+		flows, err = listConntrackFlows(u.v4, u.v6)
+		if err != nil {
+			return err
+		}
+	}
+	for ip, usage := range u.update(assigned, flows) {
+		metrics.RecordEgressIPActiveConnections(usage.name, ip, usage.connections)
+		metrics.RecordEgressIPDestinationConnections(usage.name, ip, usage.destinationConnections)
+		metrics.RecordEgressIPBytes(usage.name, ip, usageDirectionEgress, usage.egressBytes)
+		metrics.RecordEgressIPBytes(usage.name, ip, usageDirectionIngress, usage.ingressBytes)
+		metrics.RecordEgressIPPackets(usage.name, ip, usageDirectionEgress, usage.egressPackets)
+		metrics.RecordEgressIPPackets(usage.name, ip, usageDirectionIngress, usage.ingressPackets)
+	}
+	return nil
+}
+
+// getAssignedEgressIPs returns the egress IPs assigned to the node mapped to
+// the name of their EgressIP
+func getAssignedEgressIPs(eIPLister egressiplisters.EgressIPLister, nodeName string) (map[string]string, error) {
+	eIPs, err := eIPLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list EgressIPs: %v", err)
+	}
+	assigned := map[string]string{}
+	for _, eIP := range eIPs {
+		for _, status := range eIP.Status.Items {
+			if status.Node != nodeName {
+				continue
+			}
+			ip := net.ParseIP(status.EgressIP)
+			if ip == nil {
+				continue
+			}
+			assigned[ip.String()] = eIP.Name
+		}
+	}
+	return assigned, nil
+}
+
+func listConntrackFlows(v4, v6 bool) ([]*netlink.ConntrackFlow, error) {
+	var flows []*netlink.ConntrackFlow
+	families := []netlink.InetFamily{}
+	if v4 {
+		families = append(families, netlink.FAMILY_V4)
+	}
+	if v6 {
+		families = append(families, netlink.FAMILY_V6)
+	}
+	for _, family := range families {
+		familyFlows, err := util.GetNetLinkOps().ConntrackTableList(netlink.ConntrackTable, family)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the conntrack table for family %d: %v", family, err)
+		}
+		flows = append(flows, familyFlows...)
+	}
+	return flows, nil
+}
+
+// getSNATFlowKey returns the key of the flow, and whether it is SNATed to one
+// of the assigned egress IPs
+func getSNATFlowKey(flow *netlink.ConntrackFlow, assigned map[string]string) (usageFlowKey, bool) {
+	// the reply of a connection SNATed to an egress IP is destined to it.
+	// Connections originated by the egress IP itself, like those tracked in
+	// the host zones after the SNAT, are not translated and skipped so that
+	// SNATed connections are not accounted twice.
+	egressIP := flow.Reverse.DstIP.String()
+	if _, ok := assigned[egressIP]; !ok || flow.Forward.SrcIP.Equal(flow.Reverse.DstIP) {
+		return usageFlowKey{}, false
+	}
+	return usageFlowKey{
+		protocol:  flow.Forward.Protocol,
+		srcIP:     flow.Forward.SrcIP.String(),
+		srcPort:   flow.Forward.SrcPort,
+		dstIP:     flow.Forward.DstIP.String(),
+		dstPort:   flow.Forward.DstPort,
+		egressIP:  egressIP,
+		timeStart: flow.TimeStart,
+	}, true
+}
+
+// update accounts the flows SNATed to the assigned egress IPs and returns the
+// usage of each egress IP since the previous update. The metrics of the egress
+// IPs that are not assigned to the node anymore are deleted.
+func (u *UsageCollector) update(assigned map[string]string, flows []*netlink.ConntrackFlow) map[string]*egressIPUsage {
+	usages := make(map[string]*egressIPUsage, len(assigned))
+	for ip, name := range assigned {
+		usages[ip] = &egressIPUsage{name: name}
+	}
+	seen := make(map[usageFlowKey]usageFlowCounters)
+	destinations := make(map[string]map[usageDestinationKey]int, len(assigned))
+	for _, flow := range flows {
+		key, ok := getSNATFlowKey(flow, assigned)
+		if !ok {
+			continue
+		}
+		usage := usages[key.egressIP]
+		counters := usageFlowCounters{
+			egressBytes:    flow.Forward.Bytes,
+			ingressBytes:   flow.Reverse.Bytes,
+			egressPackets:  flow.Forward.Packets,
+			ingressPackets: flow.Reverse.Packets,
+		}
+		previous := u.flows[key]
+		usage.connections++
+		usage.egressBytes += counterIncrease(counters.egressBytes, previous.egressBytes)
+		usage.ingressBytes += counterIncrease(counters.ingressBytes, previous.ingressBytes)
+		usage.egressPackets += counterIncrease(counters.egressPackets, previous.egressPackets)
+		usage.ingressPackets += counterIncrease(counters.ingressPackets, previous.ingressPackets)
+		seen[key] = counters
+
+		if destinations[key.egressIP] == nil {
+			destinations[key.egressIP] = map[usageDestinationKey]int{}
+		}
+		destination := usageDestinationKey{protocol: key.protocol, dstIP: key.dstIP, dstPort: key.dstPort}
+		destinations[key.egressIP][destination]++
+		if count := destinations[key.egressIP][destination]; count > usage.destinationConnections {
+			usage.destinationConnections = count
+		}
+	}
+	u.flows = seen
+
+	for ip, name := range u.assigned {
+		if assigned[ip] != name {
+			metrics.DeleteEgressIPUsageMetrics(name, ip)
+		}
+	}
+	u.assigned = assigned
+	return usages
+}
+
+// counterIncrease returns the increase of a conntrack counter since its
+// previous value, 0 if it was reset
+func counterIncrease(current, previous uint64) uint64 {
+	if current > previous {
+		return current - previous
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package egressip
+
+import (
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+func newSNATConntrackFlow(podIP, dstIP, egressIP string, srcPort uint16, egressBytes, ingressBytes uint64) *netlink.ConntrackFlow {
+	flow := &netlink.ConntrackFlow{FamilyType: netlink.FAMILY_V4}
+	flow.Forward.Protocol = 6
+	flow.Forward.SrcIP = net.ParseIP(podIP)
+	flow.Forward.SrcPort = srcPort
+	flow.Forward.DstIP = net.ParseIP(dstIP)
+	flow.Forward.DstPort = 443
+	flow.Forward.Bytes = egressBytes
+	flow.Forward.Packets = egressBytes / 10
+	flow.Reverse.Protocol = 6
+	flow.Reverse.SrcIP = net.ParseIP(dstIP)
+	flow.Reverse.SrcPort = 443
+	flow.Reverse.DstIP = net.ParseIP(egressIP)
+	flow.Reverse.DstPort = srcPort
+	flow.Reverse.Bytes = ingressBytes
+	flow.Reverse.Packets = ingressBytes / 10
+	return flow
+}
+
+var _ = ginkgo.Describe("EgressIP usage collector", func() {
+	const (
+		egressIP1 = "172.18.0.33"
+		egressIP2 = "172.18.0.44"
+		podIP     = "10.244.0.5"
+		dstIP     = "1.1.1.1"
+	)
+
+	ginkgo.It("accounts the connections, bytes and packets SNATed to the assigned egress IPs", func() {
+		u := &UsageCollector{
+			nodeName: "node1",
+			v4:       true,
+			flows:    map[usageFlowKey]usageFlowCounters{},
+			assigned: map[string]string{},
+		}
+		assigned := map[string]string{egressIP1: "eip1", egressIP2: "eip2"}
+
+		// the host zone flow after the SNAT and flows of other IPs are ignored
+		hostFlow := newSNATConntrackFlow(egressIP1, dstIP, egressIP1, 40000, 1000, 1000)
+		otherFlow := newSNATConntrackFlow(podIP, dstIP, "172.18.0.55", 40001, 1000, 1000)
+		usages := u.update(assigned, []*netlink.ConntrackFlow{
+			newSNATConntrackFlow(podIP, dstIP, egressIP1, 40000, 100, 200),
+			newSNATConntrackFlow(podIP, dstIP, egressIP1, 40002, 10, 20),
+			newSNATConntrackFlow(podIP, "8.8.8.8", egressIP1, 40004, 0, 0),
+			hostFlow,
+			otherFlow,
+		})
+		gomega.Expect(usages).To(gomega.HaveLen(2))
+		gomega.Expect(*usages[egressIP1]).To(gomega.Equal(egressIPUsage{name: "eip1", connections: 3, destinationConnections: 2,
+			egressBytes: 110, ingressBytes: 220, egressPackets: 11, ingressPackets: 22}))
+		gomega.Expect(*usages[egressIP2]).To(gomega.Equal(egressIPUsage{name: "eip2"}))
+
+		// only the bytes and packets exchanged since the previous update are accounted
+		usages = u.update(assigned, []*netlink.ConntrackFlow{
+			newSNATConntrackFlow(podIP, dstIP, egressIP1, 40000, 150, 300),
+			newSNATConntrackFlow(podIP, dstIP, egressIP2, 40003, 20, 20),
+		})
+		gomega.Expect(*usages[egressIP1]).To(gomega.Equal(egressIPUsage{name: "eip1", connections: 1, destinationConnections: 1,
+			egressBytes: 50, ingressBytes: 100, egressPackets: 5, ingressPackets: 10}))
+		gomega.Expect(*usages[egressIP2]).To(gomega.Equal(egressIPUsage{name: "eip2", connections: 1, destinationConnections: 1,
+			egressBytes: 20, ingressBytes: 20, egressPackets: 2, ingressPackets: 2}))
+
+		// egress IPs that moved away from the node are not accounted anymore
+		usages = u.update(map[string]string{egressIP2: "eip2"}, []*netlink.ConntrackFlow{
+			newSNATConntrackFlow(podIP, dstIP, egressIP1, 40000, 200, 400),
+		})
+		gomega.Expect(usages).To(gomega.HaveLen(1))
+		gomega.Expect(*usages[egressIP2]).To(gomega.Equal(egressIPUsage{name: "eip2"}))
+		gomega.Expect(u.flows).To(gomega.BeEmpty())
+	})
+})
@@ -0,0 +1,167 @@
+package kubevirt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	ovnkubevirt "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kubevirt"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// Announcer announces the IPs of the KubeVirt virtual machines live migrated
+// to the node once their migration completes, with the configured number of
+// GARPs (IPv4) or unsolicited neighbor advertisements (IPv6). They are
+// injected in the integration bridge on behalf of the virtual machine, so
+// that the switches of the network learn that its IPs moved without waiting
+// for the virtual machine to announce them itself.
+type Announcer struct {
+	nodeName    string
+	podInformer cache.SharedIndexInformer
+	// announce sends one announcement of the IPs of the pod
+	announce func(pod *corev1.Pod) error
+
+	lock sync.Mutex
+	// cancels holds the channel stopping the announcements of each pod, by key
+	cancels map[string]chan struct{}
+}
+
+func NewAnnouncer(podInformer cache.SharedIndexInformer, nodeName string) *Announcer {
+	return &Announcer{
+		nodeName:    nodeName,
+		podInformer: podInformer,
+		announce:    announcePod,
+		cancels:     map[string]chan struct{}{},
+	}
+}
+
+// Run announces the IPs of the virtual machines whose live migration to the
+// node completes until stopCh is closed
+func (a *Announcer) Run(stopCh <-chan struct{}, wg *sync.WaitGroup) error {
+	klog.Infof("Starting KubeVirt virtual machine IPs announcer")
+	_, err := a.podInformer.AddEventHandler(factory.WithUpdateHandlingForObjReplace(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			oldPod, newPod := old.(*corev1.Pod), new.(*corev1.Pod)
+			if a.migrationCompleted(oldPod, newPod) {
+				a.start(newPod, stopCh, wg)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				klog.Errorf("Failed to get the key of deleted pod %#v: %v", obj, err)
+				return
+			}
+			a.stop(key)
+		},
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to add the pod event handler of the KubeVirt virtual machine IPs announcer: %v", err)
+	}
+	return nil
+}
+
+// migrationCompleted returns whether the pod is the one of a virtual machine
+// whose live migration to the node just completed
+func (a *Announcer) migrationCompleted(oldPod, newPod *corev1.Pod) bool {
+	if newPod.Spec.NodeName != a.nodeName || util.PodWantsHostNetwork(newPod) ||
+		!ovnkubevirt.IsPodLiveMigratable(newPod) {
+		return false
+	}
+	// the annotation is set by KubeVirt once the migration target pod is
+	// ready to receive the traffic of the virtual machine
+	return oldPod.Annotations[kubevirtv1.MigrationTargetReadyTimestamp] == "" &&
+		newPod.Annotations[kubevirtv1.MigrationTargetReadyTimestamp] != ""
+}
+
+// start sends the announcements of the IPs of the pod in the background,
+// stopping the previous ones of the pod if any
+func (a *Announcer) start(pod *corev1.Pod, stopCh <-chan struct{}, wg *sync.WaitGroup) {
+	key := pod.Namespace + "/" + pod.Name
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if cancel, ok := a.cancels[key]; ok {
+		close(cancel)
+	}
+	cancel := make(chan struct{})
+	a.cancels[key] = cancel
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.run(key, pod, cancel, stopCh)
+	}()
+}
+
+// stop stops the announcements of the pod
+func (a *Announcer) stop(key string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if cancel, ok := a.cancels[key]; ok {
+		close(cancel)
+		delete(a.cancels, key)
+	}
+}
+
+func (a *Announcer) run(key string, pod *corev1.Pod, cancel, stopCh <-chan struct{}) {
+	defer func() {
+		a.lock.Lock()
+		defer a.lock.Unlock()
+		if a.cancels[key] == cancel {
+			delete(a.cancels, key)
+		}
+	}()
+	interval := time.Duration(config.OVNKubernetesFeature.GARPInterval) * time.Millisecond
+	for i := 0; i < config.OVNKubernetesFeature.GARPCount; i++ {
+		if i > 0 {
+			select {
+			case <-cancel:
+				return
+			case <-stopCh:
+				return
+			case <-time.After(interval):
+			}
+		}
+		if err := a.announce(pod); err != nil {
+			klog.Errorf("Failed to announce the IPs of the migrated virtual machine of pod %s: %v", key, err)
+		}
+	}
+}
+
+// announcePod injects the announcements of the IPs of the pod on the default
+// network in the integration bridge, as if sent by the pod through its port,
+// so that they go through the OVN pipeline of the pod like its own traffic
+func announcePod(pod *corev1.Pod) error {
+	podAnnotation, err := util.UnmarshalPodAnnotation(pod.Annotations, types.DefaultNetworkName)
+	if err != nil {
+		return err
+	}
+	ifaceID := util.GetLogicalPortName(pod.Namespace, pod.Name)
+	stdout, stderr, err := util.RunOVSVsctl("--no-heading", "--data=bare", "--columns=ofport", "find", "Interface",
+		"external-ids:iface-id="+ifaceID)
+	if err != nil {
+		return fmt.Errorf("failed to get the OVS port of %s, stderr: %q, error: %v", ifaceID, stderr, err)
+	}
+	ofport := strings.TrimSpace(strings.Split(stdout, "\n")[0])
+	if ofport == "" || ofport == "-1" {
+		return fmt.Errorf("no OVS port found for %s", ifaceID)
+	}
+	for _, ip := range podAnnotation.IPs {
+		frame := util.BuildAnnouncementFrame(ip.IP, podAnnotation.MAC)
+		_, stderr, err := util.RunOVSOfctl("-O", "OpenFlow13", "packet-out", config.OvnKubeNode.IntegrationBridge,
+			fmt.Sprintf("in_port=%s,packet=%s,actions=table", ofport, hex.EncodeToString(frame)))
+		if err != nil {
+			return fmt.Errorf("failed to inject the announcement of IP %s, stderr: %q, error: %v", ip.IP, stderr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package kubevirt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func newVMPod(nodeName string, annotations map[string]string) *corev1.Pod {
+	podAnnotations := map[string]string{
+		kubevirtv1.AllowPodBridgeNetworkLiveMigrationAnnotation: "",
+		util.OvnPodAnnotationName: `{"default":{"ip_addresses":["10.244.0.5/24","fd00:10:244::5/64"],` +
+			`"mac_address":"0a:58:0a:f4:00:05"}}`,
+	}
+	for k, v := range annotations {
+		podAnnotations[k] = v
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "virt-launcher-vm1", Namespace: "ns1", Annotations: podAnnotations},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func TestMigrationCompleted(t *testing.T) {
+	a := NewAnnouncer(nil, "node1")
+	ready := map[string]string{kubevirtv1.MigrationTargetReadyTimestamp: "2023-06-01T10:00:00Z"}
+	tests := []struct {
+		name     string
+		oldPod   *corev1.Pod
+		newPod   *corev1.Pod
+		expected bool
+	}{
+		{
+			name:     "the migration to the node completes",
+			oldPod:   newVMPod("node1", nil),
+			newPod:   newVMPod("node1", ready),
+			expected: true,
+		},
+		{
+			name:   "the migration already completed",
+			oldPod: newVMPod("node1", ready),
+			newPod: newVMPod("node1", ready),
+		},
+		{
+			name:   "the migration to another node completes",
+			oldPod: newVMPod("node2", nil),
+			newPod: newVMPod("node2", ready),
+		},
+		{
+			name:   "the pod is not live migratable",
+			oldPod: &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node1"}},
+			newPod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: ready}, Spec: corev1.PodSpec{NodeName: "node1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.migrationCompleted(tt.oldPod, tt.newPod); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAnnouncePod(t *testing.T) {
+	if err := config.PrepareTestConfig(); err != nil {
+		t.Fatalf("failed to prepare test config: %v", err)
+	}
+	fexec := ovntest.NewFakeExec()
+	if err := util.SetExec(fexec); err != nil {
+		t.Fatalf("failed to set the fake exec: %v", err)
+	}
+	mac := ovntest.MustParseMAC("0a:58:0a:f4:00:05")
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovs-vsctl --timeout=15 --no-heading --data=bare --columns=ofport find Interface external-ids:iface-id=ns1_virt-launcher-vm1",
+		Output: "5",
+	})
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovs-ofctl -O OpenFlow13 packet-out br-int in_port=5,packet=" +
+			hex.EncodeToString(util.BuildAnnouncementFrame(ovntest.MustParseIP("10.244.0.5"), mac)) + ",actions=table",
+		"ovs-ofctl -O OpenFlow13 packet-out br-int in_port=5,packet=" +
+			hex.EncodeToString(util.BuildAnnouncementFrame(ovntest.MustParseIP("fd00:10:244::5"), mac)) + ",actions=table",
+	})
+	if err := announcePod(newVMPod("node1", nil)); err != nil {
+		t.Fatalf("failed to announce the IPs of the pod: %v", err)
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Fatal(fexec.ErrorDesc())
+	}
+}
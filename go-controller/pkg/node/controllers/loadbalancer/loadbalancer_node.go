@@ -0,0 +1,285 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/linkmanager"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	maxRetries = 10
+	// linkSyncPeriod is the period at which the IPs are reconciled on the link
+	linkSyncPeriod = 30 * time.Second
+)
+
+// Controller announces on the L2 network the IPs of the LoadBalancer services
+// for which the node was elected by the built-in load balancer provider. The
+// IPs are bound to the gateway interface, so that the node answers ARP and
+// neighbor solicitations for them, and announced with GARPs or unsolicited
+// neighbor advertisements when they are bound. Their traffic is handled by the
+// OVN load balancers, like the traffic of any other ingress IP.
+type Controller struct {
+	stopCh <-chan struct{}
+	sync.Mutex
+	thisNode    string // name of the node we're running on
+	linkName    string // name of the gateway interface the IPs are bound to
+	linkManager *linkmanager.Controller
+
+	serviceLister  corelisters.ServiceLister
+	servicesSynced cache.InformerSynced
+	servicesQueue  workqueue.RateLimitingInterface
+
+	services map[string][]net.IP // svc key -> IPs bound to the link
+}
+
+func NewController(stopCh <-chan struct{}, thisNode, linkName string, serviceInformer cache.SharedIndexInformer) (*Controller, error) {
+	klog.Info("Setting up event handlers for the LoadBalancer services IPs")
+
+	c := &Controller{
+		stopCh:      stopCh,
+		thisNode:    thisNode,
+		linkName:    linkName,
+		linkManager: linkmanager.NewLoadBalancerController(thisNode, config.IPv4Mode, config.IPv6Mode),
+		services:    map[string][]net.IP{},
+	}
+
+	c.serviceLister = corelisters.NewServiceLister(serviceInformer.GetIndexer())
+	c.servicesSynced = serviceInformer.HasSynced
+	c.servicesQueue = workqueue.NewNamedRateLimitingQueue(
+		workqueue.NewItemFastSlowRateLimiter(1*time.Second, 5*time.Second, 5),
+		"loadbalancerservices",
+	)
+	_, err := serviceInformer.AddEventHandler(factory.WithUpdateHandlingForObjReplace(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onServiceAdd,
+		UpdateFunc: c.onServiceUpdate,
+		DeleteFunc: c.onServiceDelete,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Controller) onServiceAdd(obj interface{}) {
+	c.queueService(obj)
+}
+
+func (c *Controller) onServiceUpdate(oldObj, newObj interface{}) {
+	oldService := oldObj.(*corev1.Service)
+	newService := newObj.(*corev1.Service)
+
+	// don't process resync or objects that are marked for deletion
+	if oldService.ResourceVersion == newService.ResourceVersion ||
+		!newService.GetDeletionTimestamp().IsZero() {
+		return
+	}
+	c.queueService(newObj)
+}
+
+func (c *Controller) onServiceDelete(obj interface{}) {
+	c.queueService(obj)
+}
+
+func (c *Controller) queueService(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %+v: %v", obj, err))
+		return
+	}
+	c.servicesQueue.Add(key)
+}
+
+func (c *Controller) Run(wg *sync.WaitGroup, threadiness int) error {
+	defer utilruntime.HandleCrash()
+
+	klog.Infof("Starting the LoadBalancer services IPs controller")
+
+	if !util.WaitForNamedCacheSyncWithTimeout("loadbalancer_services", c.stopCh, c.servicesSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+
+	// the link manager removes the IPs it finds on the links which are not
+	// wanted anymore, like those bound before a restart
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.linkManager.Run(c.stopCh, linkSyncPeriod)
+	}()
+
+	for i := 0; i < threadiness; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(func() {
+				c.runServiceWorker(wg)
+			}, time.Second, c.stopCh)
+		}()
+	}
+
+	// add shutdown goroutine waiting for c.stopCh
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// wait until we're told to stop
+		<-c.stopCh
+
+		klog.Infof("Shutting down the LoadBalancer services IPs controller")
+		c.servicesQueue.ShutDown()
+	}()
+
+	return nil
+}
+
+func (c *Controller) runServiceWorker(wg *sync.WaitGroup) {
+	for c.processNextServiceWorkItem(wg) {
+	}
+}
+
+func (c *Controller) processNextServiceWorkItem(wg *sync.WaitGroup) bool {
+	wg.Add(1)
+	defer wg.Done()
+
+	key, quit := c.servicesQueue.Get()
+	if quit {
+		return false
+	}
+
+	defer c.servicesQueue.Done(key)
+
+	err := c.syncService(key.(string))
+	if err == nil {
+		c.servicesQueue.Forget(key)
+		return true
+	}
+
+	utilruntime.HandleError(fmt.Errorf("%v failed with : %v", key, err))
+
+	if c.servicesQueue.NumRequeues(key) < maxRetries {
+		c.servicesQueue.AddRateLimited(key)
+		return true
+	}
+
+	c.servicesQueue.Forget(key)
+	return true
+}
+
+func (c *Controller) syncService(key string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	svc, err := c.serviceLister.Services(namespace).Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	var wanted []net.IP
+	if svc != nil {
+		wanted = c.wantedIPs(svc)
+	}
+	current := c.services[key]
+
+	link, err := util.GetNetLinkOps().LinkByName(c.linkName)
+	if err != nil {
+		return fmt.Errorf("failed to get link %s: %v", c.linkName, err)
+	}
+
+	for _, ip := range current {
+		if containsIP(wanted, ip) {
+			continue
+		}
+		if err := c.linkManager.DelAddress(addressFor(link, ip)); err != nil {
+			return fmt.Errorf("failed to remove IP %s of service %s from link %s: %v", ip, key, c.linkName, err)
+		}
+		klog.Infof("Removed IP %s of LoadBalancer service %s from link %s", ip, key, c.linkName)
+	}
+	for _, ip := range wanted {
+		if containsIP(current, ip) {
+			continue
+		}
+		// the link manager announces the IP once bound
+		if err := c.linkManager.AddAddress(addressFor(link, ip)); err != nil {
+			return fmt.Errorf("failed to add IP %s of service %s to link %s: %v", ip, key, c.linkName, err)
+		}
+		klog.Infof("Added IP %s of LoadBalancer service %s to link %s", ip, key, c.linkName)
+	}
+
+	if len(wanted) > 0 {
+		c.services[key] = wanted
+	} else {
+		delete(c.services, key)
+	}
+	return nil
+}
+
+// wantedIPs returns the ingress IPs of the service to bind to the link: the
+// ones allocated from the load balancer IP pools, if this node was elected to
+// announce them
+func (c *Controller) wantedIPs(svc *corev1.Service) []net.IP {
+	if !util.ServiceTypeHasLoadBalancer(svc) || svc.Annotations[types.LoadBalancerNodeAnnotation] != c.thisNode {
+		return nil
+	}
+	var ips []net.IP
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		ip := utilnet.ParseIPSloppy(ingress.IP)
+		if ip == nil || !inPools(ip) {
+			continue
+		}
+		if utilnet.IsIPv6(ip) && !config.IPv6Mode || !utilnet.IsIPv6(ip) && !config.IPv4Mode {
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+func inPools(ip net.IP) bool {
+	for _, pool := range config.OVNKubernetesFeature.LoadBalancerIPPools {
+		if pool.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// addressFor returns the host address of the IP on the link
+func addressFor(link netlink.Link, ip net.IP) netlink.Addr {
+	mask := net.CIDRMask(32, 32)
+	if utilnet.IsIPv6(ip) {
+		mask = net.CIDRMask(128, 128)
+	}
+	return netlink.Addr{IPNet: &net.IPNet{IP: ip, Mask: mask}, LinkIndex: link.Attrs().Index}
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, candidate := range ips {
+		if candidate.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
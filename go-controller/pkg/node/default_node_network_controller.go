@@ -14,9 +14,11 @@ import (
 	kapi "k8s.io/api/core/v1"
 	discovery "k8s.io/api/discovery/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	ktypes "k8s.io/apimachinery/pkg/types"
 	apierrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
@@ -30,10 +32,16 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/controllers/egressip"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/controllers/egressservice"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/controllers/kubevirt"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/controllers/loadbalancer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/controllers/upgrade"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/iprulemanager"
 	nodeipt "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/iptables"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/localstate"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/observability"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/ovspinning"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/routemanager"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/tunneltuning"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/apbroute"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/healthcheck"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/retry"
@@ -42,6 +50,7 @@ import (
 
 	"github.com/containernetworking/plugins/pkg/ip"
 	"github.com/vishvananda/netlink"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 )
 
 type CommonNodeNetworkControllerInfo struct {
@@ -99,6 +108,8 @@ type DefaultNodeNetworkController struct {
 	// Node healthcheck server for cloud load balancers
 	healthzServer *proxierHealthUpdater
 	routeManager  *routemanager.Controller
+	// ruleManager, if set, manages the ip rules of the health check port
+	ruleManager *iprulemanager.Controller
 
 	// retry framework for namespaces, used for the removal of stale conntrack entries for external gateways
 	retryNamespaces *retry.RetryFramework
@@ -106,6 +117,13 @@ type DefaultNodeNetworkController struct {
 	retryEndpointSlices *retry.RetryFramework
 
 	apbExternalRouteNodeController *apbroute.ExternalGatewayNodeController
+
+	// cniServer, if set, is the CNI server already started while the
+	// apiserver was unreachable, used instead of starting a new one
+	cniServer *cni.Server
+	// localState, if set, caches the state of the pods of the node for them
+	// to be plumbed while the apiserver is unreachable
+	localState *localstate.Store
 }
 
 func newDefaultNodeNetworkController(cnnci *CommonNodeNetworkControllerInfo, stopChan chan struct{},
@@ -122,6 +140,12 @@ func newDefaultNodeNetworkController(cnnci *CommonNodeNetworkControllerInfo, sto
 	}
 }
 
+// hasHealthCheckPort returns whether the node has a dedicated health check
+// port, only supported in full mode
+func (nc *DefaultNodeNetworkController) hasHealthCheckPort() bool {
+	return len(config.Default.HealthCheckSubnets) > 0 && config.OvnKubeNode.Mode == types.NodeModeFull
+}
+
 // NewDefaultNodeNetworkController creates a new network controller for node management of the default network
 func NewDefaultNodeNetworkController(cnnci *CommonNodeNetworkControllerInfo) (*DefaultNodeNetworkController, error) {
 	var err error
@@ -147,6 +171,13 @@ func NewDefaultNodeNetworkController(cnnci *CommonNodeNetworkControllerInfo) (*D
 		return nil, err
 	}
 
+	if config.OvnKubeNode.LocalStateDir != "" && config.OvnKubeNode.Mode != types.NodeModeDPU {
+		nc.localState, err = localstate.NewStore(config.OvnKubeNode.LocalStateDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	nc.initRetryFrameworkForNode()
 
 	return nc, nil
@@ -160,11 +191,11 @@ func (nc *DefaultNodeNetworkController) initRetryFrameworkForNode() {
 func clearOVSFlowTargets() error {
 	_, _, err := util.RunOVSVsctl(
 		"--",
-		"clear", "bridge", "br-int", "netflow",
+		"clear", "bridge", config.OvnKubeNode.IntegrationBridge, "netflow",
 		"--",
-		"clear", "bridge", "br-int", "sflow",
+		"clear", "bridge", config.OvnKubeNode.IntegrationBridge, "sflow",
 		"--",
-		"clear", "bridge", "br-int", "ipfix",
+		"clear", "bridge", config.OvnKubeNode.IntegrationBridge, "ipfix",
 	)
 	if err != nil {
 		return err
@@ -215,7 +246,7 @@ func setOVSFlowTargets(node *kapi.Node) error {
 			fmt.Sprintf("targets=[%s]", collectors),
 			"active_timeout=60",
 			"--",
-			"set", "bridge", "br-int", "netflow=@netflow",
+			"set", "bridge", config.OvnKubeNode.IntegrationBridge, "netflow=@netflow",
 		)
 		if err != nil {
 			return fmt.Errorf("error setting NetFlow: %v\n  %q", err, stderr)
@@ -235,7 +266,7 @@ func setOVSFlowTargets(node *kapi.Node) error {
 			"agent="+types.SFlowAgent,
 			fmt.Sprintf("targets=[%s]", collectors),
 			"--",
-			"set", "bridge", "br-int", "sflow=@sflow",
+			"set", "bridge", config.OvnKubeNode.IntegrationBridge, "sflow=@sflow",
 		)
 		if err != nil {
 			return fmt.Errorf("error setting SFlow: %v\n  %q", err, stderr)
@@ -261,7 +292,7 @@ func setOVSFlowTargets(node *kapi.Node) error {
 		if config.IPFIX.Sampling != 0 {
 			args = append(args, fmt.Sprintf("sampling=%d", config.IPFIX.Sampling))
 		}
-		args = append(args, "--", "set", "bridge", "br-int", "ipfix=@ipfix")
+		args = append(args, "--", "set", "bridge", config.OvnKubeNode.IntegrationBridge, "ipfix=@ipfix")
 		_, stderr, err := util.RunOVSVsctl(args...)
 		if err != nil {
 			return fmt.Errorf("error setting IPFIX: %v\n  %q", err, stderr)
@@ -286,6 +317,10 @@ func setupOVNNode(node *kapi.Node) error {
 		}
 	}
 
+	if err := warnIntegrationBridgeRename(); err != nil {
+		return err
+	}
+
 	setExternalIdsCmd := []string{
 		"set",
 		"Open_vSwitch",
@@ -308,6 +343,13 @@ func setupOVNNode(node *kapi.Node) error {
 		fmt.Sprintf("external_ids:ovn-monitor-all=%t", config.Default.MonitorAll),
 		fmt.Sprintf("external_ids:ovn-ofctrl-wait-before-clear=%d", config.Default.OfctrlWaitBeforeClear),
 		fmt.Sprintf("external_ids:ovn-enable-lflow-cache=%t", config.Default.LFlowCacheEnable),
+		fmt.Sprintf("external_ids:ovn-bridge=%s", config.OvnKubeNode.IntegrationBridge),
+	}
+
+	if config.OvnKubeNode.SystemID != "" {
+		setExternalIdsCmd = append(setExternalIdsCmd,
+			fmt.Sprintf("external_ids:system-id=%s", config.OvnKubeNode.SystemID),
+		)
 	}
 
 	if config.Default.LFlowCacheLimit > 0 {
@@ -322,6 +364,13 @@ func setupOVNNode(node *kapi.Node) error {
 		)
 	}
 
+	// ovn-controller creates br-int with the userspace datapath of the node
+	if config.IsUserspaceDatapath() {
+		setExternalIdsCmd = append(setExternalIdsCmd,
+			fmt.Sprintf("external_ids:ovn-bridge-datapath-type=%s", userspaceDatapathType),
+		)
+	}
+
 	_, stderr, err := util.RunOVSVsctl(setExternalIdsCmd...)
 	if err != nil {
 		return fmt.Errorf("error setting OVS external IDs: %v\n  %q", err, stderr)
@@ -381,13 +430,13 @@ func isOVNControllerReady() (bool, error) {
 	}
 
 	// check whether br-int exists on node
-	_, _, err = util.RunOVSVsctl("--", "br-exists", "br-int")
+	_, _, err = util.RunOVSVsctl("--", "br-exists", config.OvnKubeNode.IntegrationBridge)
 	if err != nil {
 		return false, nil
 	}
 
 	// check by dumping br-int flow entries
-	stdout, _, err := util.RunOVSOfctl("dump-aggregate", "br-int")
+	stdout, _, err := util.RunOVSOfctl("dump-aggregate", config.OvnKubeNode.IntegrationBridge)
 	if err != nil {
 		klog.V(5).Infof("Error dumping aggregate flows: %v", err)
 		return false, nil
@@ -671,6 +720,12 @@ func portExists(namespace, name string) bool {
 
 /** HACK END **/
 
+// getNodeHostSubnets returns the host subnets of the node for the default
+// network, read from the node network state with the "crd" backend
+func (nc *DefaultNodeNetworkController) getNodeHostSubnets(node *kapi.Node) ([]*net.IPNet, error) {
+	return util.ParseNodeHostSubnets(node, types.DefaultNetworkName, nc.watchFactory)
+}
+
 // Start learns the subnets assigned to it by the master controller
 // and calls the SetupNode script which establishes the logical switch
 func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
@@ -692,11 +747,24 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 		defer nc.wg.Done()
 		nc.routeManager.Run(nc.stopChan, 4*time.Minute)
 	}()
+	if nc.hasHealthCheckPort() {
+		nc.ruleManager = iprulemanager.NewController(config.IPv4Mode, config.IPv6Mode)
+		nc.wg.Add(1)
+		go func() {
+			defer nc.wg.Done()
+			nc.ruleManager.Run(nc.stopChan, 4*time.Minute)
+		}()
+	}
 
 	if node, err = nc.Kube.GetNode(nc.name); err != nil {
 		return fmt.Errorf("error retrieving node %s: %v", nc.name, err)
 	}
 
+	// the masquerade subnets must be settled before anything uses them
+	if err := selectNodeMasqueradeSubnets(node); err != nil {
+		return fmt.Errorf("failed to select the masquerade subnets of node %s: %w", nc.name, err)
+	}
+
 	nodeAddrStr, err := util.GetNodePrimaryIP(node)
 	if err != nil {
 		return err
@@ -738,32 +806,53 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 			}
 		}
 
+		if err := checkUserspaceDatapathSupport(); err != nil {
+			return err
+		}
+
 		err = setupOVNNode(node)
 		if err != nil {
 			return err
 		}
 	}
 
+	// the nodes of the layer2 topology of the default network have no host
+	// subnet and no management port, the pods being reached through the
+	// gateway router
+	layer2 := config.Default.Topology == types.Layer2Topology
+
 	// First wait for the node logical switch to be created by the Master, timeout is 300s.
 	err = wait.PollUntilContextTimeout(context.Background(), 500*time.Millisecond, 300*time.Second, true, func(ctx context.Context) (bool, error) {
 		if node, err = nc.Kube.GetNode(nc.name); err != nil {
 			klog.Infof("Waiting to retrieve node %s: %v", nc.name, err)
 			return false, nil
 		}
-		subnets, err = util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		if layer2 {
+			return true, nil
+		}
+		subnets, err = nc.getNodeHostSubnets(node)
 		if err != nil {
-			klog.Infof("Waiting for node %s to start, no annotation found on node for subnet: %v", nc.name, err)
+			klog.Infof("Waiting for node %s to start, no host subnet allocated to the node: %v", nc.name, err)
 			return false, nil
 		}
+		if nc.hasHealthCheckPort() {
+			if _, err := util.ParseNodeHealthCheckSubnets(node); err != nil {
+				klog.Infof("Waiting for node %s to start, no health check subnet allocated to the node: %v", nc.name, err)
+				return false, nil
+			}
+		}
 		return true, nil
 	})
 	if err != nil {
 		return fmt.Errorf("timed out waiting for node's: %q logical switch: %v", nc.name, err)
 	}
 	klog.Infof("Node %s ready for ovn initialization with subnet %s", nc.name, util.JoinIPNets(subnets, ","))
+	// the management port and the gateway are set up on the first host subnet
+	// of each IP family, the additional host subnets only hold pod IPs
+	subnets = util.PrimaryHostSubnets(subnets)
 
 	// Create CNI Server
-	if config.OvnKubeNode.Mode != types.NodeModeDPU {
+	if config.OvnKubeNode.Mode != types.NodeModeDPU && nc.cniServer == nil {
 		kclient, ok := nc.Kube.(*kube.Kube)
 		if !ok {
 			return fmt.Errorf("cannot get kubeclient for starting CNI server")
@@ -772,9 +861,23 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		if nc.localState != nil {
+			cniServer.EnableLocalState(nc.localState)
+		}
 	}
 
 	nodeAnnotator := kube.NewNodeAnnotator(nc.Kube, node.Name)
+	if err := setNodeMasqueradeSubnetsAnnotation(nodeAnnotator); err != nil {
+		return fmt.Errorf("failed to set the masquerade subnets annotation of node %s: %w", nc.name, err)
+	}
+	// record the generations of the host subnets and network ids the node is
+	// set up from, along with the annotations derived from them
+	generations, err := util.ParseHandshakeGenerations(node)
+	if err != nil {
+		klog.Warningf("Failed to parse the handshake generations of node %s: %v", nc.name, err)
+	} else if err := util.SetHandshakeObservedGenerations(nodeAnnotator, generations); err != nil {
+		return fmt.Errorf("failed to set the handshake observed generations of node %s: %w", nc.name, err)
+	}
 	waiter := newStartupWaiter()
 
 	// Use the device from environment when the DP resource name is specified.
@@ -798,9 +901,22 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 	}
 
 	// Setup management ports
-	mgmtPorts, mgmtPortConfig, err := createNodeManagementPorts(node, nodeAnnotator, waiter, subnets, nc.routeManager)
-	if err != nil {
-		return err
+	var mgmtPorts []managementPortEntry
+	var mgmtPortConfig *managementPortConfig
+	if !layer2 {
+		mgmtPorts, mgmtPortConfig, err = createNodeManagementPorts(node, nodeAnnotator, waiter, subnets, nc.routeManager)
+		if err != nil {
+			return err
+		}
+	}
+	if nc.hasHealthCheckPort() {
+		if err := createNodeHealthCheckPort(node, subnets, nc.routeManager, nc.ruleManager); err != nil {
+			return err
+		}
+	} else if config.OvnKubeNode.Mode == types.NodeModeFull {
+		if err := deleteNodeHealthCheckPort(); err != nil {
+			return err
+		}
 	}
 
 	// Initialize gateway
@@ -816,10 +932,45 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 		}
 	}
 
+	// the OVS external IDs, once set up along with the gateway, are repaired
+	// when they drift
+	if config.OvnKubeNode.Mode != types.NodeModeDPUHost {
+		externalIDs, err := newOVSExternalIDs()
+		if err != nil {
+			return fmt.Errorf("failed to get the OVS external IDs of node %s: %w", nc.name, err)
+		}
+		if err := externalIDs.reconcile(); err != nil {
+			return err
+		}
+		go wait.Until(func() {
+			if err := externalIDs.reconcile(); err != nil {
+				klog.Errorf("Failed to reconcile the OVS external IDs: %v", err)
+			}
+		}, ovsExternalIDsReconcileInterval, nc.stopChan)
+		// the physical networks with a backup bridge fail over to it while
+		// the uplink of their bridge is down
+		if len(config.OvnKubeNode.BackupBridgeMappings) > 0 {
+			nodeRef := &kapi.ObjectReference{
+				Kind: "Node",
+				Name: nc.name,
+				UID:  ktypes.UID(nc.name),
+			}
+			go wait.Until(func() {
+				if err := externalIDs.failover(nc.recorder, nodeRef); err != nil {
+					klog.Errorf("Failed to fail over the bridge mappings: %v", err)
+				}
+			}, bridgeFailoverInterval, nc.stopChan)
+		}
+	}
+
 	if err := util.SetNodeZone(nodeAnnotator, sbZone); err != nil {
 		return fmt.Errorf("failed to set node zone annotation for node %s: %w", nc.name, err)
 	}
 
+	if err := util.SetNodeAppliedClusterSubnets(nodeAnnotator); err != nil {
+		return fmt.Errorf("failed to set the applied cluster subnets annotation for node %s: %w", nc.name, err)
+	}
+
 	if err := nodeAnnotator.Run(); err != nil {
 		return fmt.Errorf("failed to set node %s annotations: %w", nc.name, err)
 	}
@@ -868,7 +1019,7 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 				}
 				for _, node := range nodes.Items {
 					if nc.name != node.Name && util.GetNodeZone(&node) != config.Default.Zone {
-						nodeSubnets, err := util.ParseNodeHostSubnetAnnotation(&node, types.DefaultNetworkName)
+						nodeSubnets, err := nc.getNodeHostSubnets(&node)
 						if err != nil {
 							err1 = fmt.Errorf("unable to fetch node-subnet annotation for node %s: err, %v", node.Name, err)
 							return false, nil
@@ -985,7 +1136,7 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 
 			// Determine if we need to run upgrade checks
 			if initialTopoVersion != types.OvnCurrentTopologyVersion {
-				if needLegacySvcRoute {
+				if needLegacySvcRoute && mgmtPortConfig != nil {
 					klog.Info("System may be upgrading, falling back to legacy K8S Service via management port")
 					// add back legacy route for service via management port
 					link, err := util.LinkSetUp(types.K8sMgmtIntfName)
@@ -1056,9 +1207,9 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 		if err != nil {
 			klog.Errorf("Deletion of bridge br-ext failed: %v (%v)", err, stderr)
 		}
-		_, stderr, err = util.RunOVSVsctl("--if-exists", "del-port", "br-int", "int")
+		_, stderr, err = util.RunOVSVsctl("--if-exists", "del-port", config.OvnKubeNode.IntegrationBridge, "int")
 		if err != nil {
-			klog.Errorf("Deletion of port int on  br-int failed: %v (%v)", err, stderr)
+			klog.Errorf("Deletion of port int on the integration bridge failed: %v (%v)", err, stderr)
 		}
 	}
 
@@ -1102,9 +1253,15 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 			return err
 		}
 	} else {
-		// start the cni server
-		if err := cniServer.Start(cni.ServerRunDir); err != nil {
-			return err
+		// start the cni server, unless it was started while the apiserver
+		// was unreachable
+		if cniServer != nil {
+			if err := cniServer.Start(cni.ServerRunDir); err != nil {
+				return err
+			}
+		}
+		if nc.localState != nil {
+			go wait.Until(nc.pruneLocalState, localStatePruneInterval, nc.stopChan)
 		}
 
 		// Write CNI config file if it doesn't already exist
@@ -1124,6 +1281,18 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 			return err
 		}
 	}
+	if len(config.OVNKubernetesFeature.LoadBalancerIPPools) > 0 &&
+		config.OVNKubernetesFeature.LoadBalancerAnnounceMode != config.LoadBalancerAnnounceModeNone &&
+		config.OvnKubeNode.Mode == types.NodeModeFull {
+		c, err := loadbalancer.NewController(nc.stopChan, nc.name, nc.gateway.GetGatewayBridgeIface(),
+			nc.watchFactory.(*factory.WatchFactory).ServiceInformer())
+		if err != nil {
+			return fmt.Errorf("failed to create the LoadBalancer services IPs controller: %v", err)
+		}
+		if err = c.Run(nc.wg, 1); err != nil {
+			return fmt.Errorf("failed to run the LoadBalancer services IPs controller: %v", err)
+		}
+	}
 	if config.OVNKubernetesFeature.EnableMultiExternalGateway {
 		if err = nc.apbExternalRouteNodeController.Run(nc.wg, 1); err != nil {
 			return err
@@ -1133,7 +1302,7 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 	if config.OVNKubernetesFeature.EnableEgressIP && !util.PlatformTypeIsEgressIPCloudProvider() {
 		c, err := egressip.NewController(nc.watchFactory.EgressIPInformer(), nc.watchFactory.NodeInformer(),
 			nc.watchFactory.NamespaceInformer(), nc.watchFactory.PodCoreInformer(), nc.routeManager, config.IPv4Mode,
-			config.IPv6Mode, nc.name)
+			config.IPv6Mode, nc.name, nc.recorder)
 		if err != nil {
 			return fmt.Errorf("failed to create egress IP controller: %v", err)
 		}
@@ -1144,6 +1313,63 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 	} else {
 		klog.Infof("Egress IP for non-OVN managed networks is disabled")
 	}
+	if config.OVNKubernetesFeature.EnableEgressIP && config.OVNKubernetesFeature.EgressIPNextHopProbeInterval > 0 {
+		// probe the gateway next hops so that the egress IPs are moved away from the node when they are unreachable
+		egressip.NewNextHopProber(nc.watchFactory.NodeInformer(), nc.Kube, nc.name).Run(nc.stopChan, nc.wg,
+			time.Duration(config.OVNKubernetesFeature.EgressIPNextHopProbeInterval)*time.Second)
+	} else if err = nc.clearEgressNextHopsUnreachable(); err != nil {
+		klog.Warningf("Failed to clear the unreachable egress next hops of node %s: %v", nc.name, err)
+	}
+	if config.OVNKubernetesFeature.EnableEgressIP {
+		// every 5 seconds report the interfaces the egress IPs assigned to the node are programmed on
+		gatewayBridge := ""
+		if nc.gateway != nil {
+			gatewayBridge = nc.gateway.GetGatewayBridgeIface()
+		}
+		egressip.NewInterfaceReporter(nc.watchFactory.EgressIPInformer(), nc.watchFactory.NodeInformer(), nc.Kube,
+			nc.name, gatewayBridge).Run(nc.stopChan, nc.wg, 5*time.Second)
+	}
+	if config.OVNKubernetesFeature.EnableEgressIP && config.Metrics.EnableEgressIPUsageMetrics {
+		// every 30 seconds export the connections and bytes of the egress IPs assigned to the node
+		egressip.NewUsageCollector(nc.watchFactory.EgressIPInformer(), config.IPv4Mode, config.IPv6Mode,
+			nc.name).Run(nc.stopChan, nc.wg, 30*time.Second)
+	}
+	if config.OVNKubernetesFeature.EnableEgressIP && config.Logging.EgressIPNATLogFile != "" {
+		// every 5 seconds log the connections SNATed to the egress IPs assigned to the node
+		natLog := &lumberjack.Logger{
+			Filename:   config.Logging.EgressIPNATLogFile,
+			MaxSize:    config.Logging.LogFileMaxSize, // megabytes
+			MaxBackups: config.Logging.LogFileMaxBackups,
+			MaxAge:     config.Logging.LogFileMaxAge, // days
+			Compress:   true,
+		}
+		egressip.NewNATLogger(nc.watchFactory.EgressIPInformer(), config.IPv4Mode, config.IPv6Mode, nc.name, natLog,
+			config.Logging.EgressIPNATLogSampling).Run(nc.stopChan, nc.wg, 5*time.Second)
+	}
+	if config.OVNKubernetesFeature.EnableObservability && config.Logging.DropSamplingLogFile != "" {
+		dropLog := &lumberjack.Logger{
+			Filename:   config.Logging.DropSamplingLogFile,
+			MaxSize:    config.Logging.LogFileMaxSize, // megabytes
+			MaxBackups: config.Logging.LogFileMaxBackups,
+			MaxAge:     config.Logging.LogFileMaxAge, // days
+			Compress:   true,
+		}
+		err = observability.NewDropCollector(corev1listers.NewPodLister(nc.watchFactory.LocalPodInformer().GetIndexer()),
+			nc.name, config.OVNKubernetesFeature.ObservabilityCollectorPort, dropLog).Run(nc.stopChan, nc.wg)
+		if err != nil {
+			return fmt.Errorf("failed to start the drop sample collector: %w", err)
+		}
+	} else if err = observability.CleanupCollectorSet(); err != nil {
+		klog.Warningf("Failed to clean up the drop sample collector set: %v", err)
+	}
+
+	if config.OvnKubeNode.Mode != types.NodeModeDPUHost && config.OVNKubernetesFeature.GARPCount > 0 {
+		// announce the IPs of the virtual machines live migrated to the node
+		err = kubevirt.NewAnnouncer(nc.watchFactory.LocalPodInformer(), nc.name).Run(nc.stopChan, nc.wg)
+		if err != nil {
+			return err
+		}
+	}
 
 	nc.wg.Add(1)
 	go func() {
@@ -1151,10 +1377,30 @@ func (nc *DefaultNodeNetworkController) Start(ctx context.Context) error {
 		ovspinning.Run(nc.stopChan)
 	}()
 
+	tunneltuning.Run()
+
 	klog.Infof("Default node network controller initialized and ready.")
 	return nil
 }
 
+// clearEgressNextHopsUnreachable removes the unreachable next hops reported
+// while the next hops were probed, so that the egress IPs are not kept away
+// from the node once the probes are disabled
+func (nc *DefaultNodeNetworkController) clearEgressNextHopsUnreachable() error {
+	node, err := nc.watchFactory.GetNode(nc.name)
+	if err != nil {
+		return err
+	}
+	if nextHops, err := util.ParseNodeEgressNextHopsUnreachable(node); err == nil && len(nextHops) == 0 {
+		return nil
+	}
+	nodeAnnotator := kube.NewNodeAnnotator(nc.Kube, nc.name)
+	if err := util.SetNodeEgressNextHopsUnreachable(nodeAnnotator, nil); err != nil {
+		return err
+	}
+	return nodeAnnotator.Run()
+}
+
 // Stop gracefully stops the controller
 // deleteLogicalEntities will never be true for default network
 func (nc *DefaultNodeNetworkController) Stop() {
@@ -1431,12 +1677,18 @@ func upgradeServiceRoute(routeManager *routemanager.Controller, bridgeName strin
 // - br-ex, where we don't really care about the next hop GW in use as traffic is always routed to OVN
 // - OVN, only when there is no default GW as it wouldn't matter since there is no external traffic
 func DummyNextHopIPs() []net.IP {
+	return MasqueradeDummyNextHopIPs(&config.Gateway.MasqueradeIPs)
+}
+
+// MasqueradeDummyNextHopIPs returns the dummy next hop IPs of the given
+// masquerade IPs, those of a node that selected its masquerade subnets
+func MasqueradeDummyNextHopIPs(masqueradeIPs *config.MasqueradeIPsConfig) []net.IP {
 	var nextHops []net.IP
 	if config.IPv4Mode {
-		nextHops = append(nextHops, config.Gateway.MasqueradeIPs.V4DummyNextHopMasqueradeIP)
+		nextHops = append(nextHops, masqueradeIPs.V4DummyNextHopMasqueradeIP)
 	}
 	if config.IPv6Mode {
-		nextHops = append(nextHops, config.Gateway.MasqueradeIPs.V6DummyNextHopMasqueradeIP)
+		nextHops = append(nextHops, masqueradeIPs.V6DummyNextHopMasqueradeIP)
 	}
 	return nextHops
 }
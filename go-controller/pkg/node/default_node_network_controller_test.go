@@ -244,6 +244,9 @@ var _ = Describe("Node", func() {
 				}
 
 				fexec := ovntest.NewFakeExec()
+				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge",
+				})
 				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 					Cmd: fmt.Sprintf("ovs-vsctl --timeout=15 set Open_vSwitch . "+
 						"external_ids:ovn-encap-type=geneve "+
@@ -255,7 +258,8 @@ var _ = Describe("Node", func() {
 						"external_ids:ovn-is-interconn=false "+
 						"external_ids:ovn-monitor-all=true "+
 						"external_ids:ovn-ofctrl-wait-before-clear=0 "+
-						"external_ids:ovn-enable-lflow-cache=true",
+						"external_ids:ovn-enable-lflow-cache=true "+
+						"external_ids:ovn-bridge=br-int",
 						nodeIP, interval, ofintval, ofintval, nodeName),
 				})
 				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
@@ -349,6 +353,9 @@ var _ = Describe("Node", func() {
 				}
 
 				fexec := ovntest.NewFakeExec()
+				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge",
+				})
 				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 					Cmd: fmt.Sprintf("ovs-vsctl --timeout=15 set Open_vSwitch . "+
 						"external_ids:ovn-encap-type=geneve "+
@@ -361,6 +368,7 @@ var _ = Describe("Node", func() {
 						"external_ids:ovn-monitor-all=true "+
 						"external_ids:ovn-ofctrl-wait-before-clear=0 "+
 						"external_ids:ovn-enable-lflow-cache=false "+
+						"external_ids:ovn-bridge=br-int "+
 						"external_ids:ovn-limit-lflow-cache=1000 "+
 						"external_ids:ovn-memlimit-lflow-cache-kb=100000",
 						nodeIP, interval, ofintval, ofintval, nodeName),
@@ -417,6 +425,9 @@ var _ = Describe("Node", func() {
 				}
 
 				fexec := ovntest.NewFakeExec()
+				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge",
+				})
 				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 					Cmd: fmt.Sprintf("ovs-vsctl --timeout=15 set Open_vSwitch . "+
 						"external_ids:ovn-encap-type=geneve "+
@@ -428,7 +439,8 @@ var _ = Describe("Node", func() {
 						"external_ids:ovn-is-interconn=false "+
 						"external_ids:ovn-monitor-all=true "+
 						"external_ids:ovn-ofctrl-wait-before-clear=0 "+
-						"external_ids:ovn-enable-lflow-cache=true",
+						"external_ids:ovn-enable-lflow-cache=true "+
+						"external_ids:ovn-bridge=br-int",
 						nodeIP, interval, ofintval, ofintval, nodeName),
 				})
 
@@ -491,6 +503,9 @@ var _ = Describe("Node", func() {
 				}
 
 				fexec := ovntest.NewFakeExec()
+				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge",
+				})
 				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 					Cmd: fmt.Sprintf("ovs-vsctl --timeout=15 set Open_vSwitch . "+
 						"external_ids:ovn-encap-type=geneve "+
@@ -502,7 +517,8 @@ var _ = Describe("Node", func() {
 						"external_ids:ovn-is-interconn=false "+
 						"external_ids:ovn-monitor-all=true "+
 						"external_ids:ovn-ofctrl-wait-before-clear=0 "+
-						"external_ids:ovn-enable-lflow-cache=true",
+						"external_ids:ovn-enable-lflow-cache=true "+
+						"external_ids:ovn-bridge=br-int",
 						nodeIP, interval, ofintval, ofintval, nodeName),
 				})
 
@@ -565,6 +581,9 @@ var _ = Describe("Node", func() {
 				}
 
 				fexec := ovntest.NewFakeExec()
+				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge",
+				})
 				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 					Cmd: fmt.Sprintf("ovs-vsctl --timeout=15 set Open_vSwitch . "+
 						"external_ids:ovn-encap-type=geneve "+
@@ -576,7 +595,8 @@ var _ = Describe("Node", func() {
 						"external_ids:ovn-is-interconn=false "+
 						"external_ids:ovn-monitor-all=true "+
 						"external_ids:ovn-ofctrl-wait-before-clear=0 "+
-						"external_ids:ovn-enable-lflow-cache=true",
+						"external_ids:ovn-enable-lflow-cache=true "+
+						"external_ids:ovn-bridge=br-int",
 						nodeIP, interval, ofintval, ofintval, nodeName),
 				})
 
@@ -39,10 +39,15 @@ type gateway struct {
 	nodePortWatcherIptables informer.ServiceEventHandler
 	// nodePortWatcher is used in Local+Shared GW modes to handle nodePort flows in shared OVS bridge
 	nodePortWatcher informer.ServiceAndEndpointsEventHandler
-	openflowManager *openflowManager
-	nodeIPManager   *addressManager
-	initFunc        func() error
-	readyFunc       func() (bool, error)
+	// hostPortWatcher is used in Shared GW mode to steer the traffic to the host ports of the pods to OVN
+	hostPortWatcher *hostPortWatcher
+	// egressIPConnLimitWatcher is used in Local+Shared GW modes to limit the connections of the egress IPs on the
+	// OVN managed network
+	egressIPConnLimitWatcher *egressIPConnLimitWatcher
+	openflowManager          *openflowManager
+	nodeIPManager            *addressManager
+	initFunc                 func() error
+	readyFunc                func() (bool, error)
 
 	watchFactory *factory.WatchFactory // used for retry
 	stopChan     <-chan struct{}
@@ -221,6 +226,18 @@ func (g *gateway) Init(wf factory.NodeWatchFactory, stopChan <-chan struct{}, wg
 	if _, err = endpointSlicesRetryFramework.WatchResource(); err != nil {
 		return fmt.Errorf("gateway init failed to start watching endpointslices: %v", err)
 	}
+
+	if g.hostPortWatcher != nil {
+		if err = g.hostPortWatcher.watchPods(); err != nil {
+			return fmt.Errorf("gateway init failed to start watching pods for host ports: %v", err)
+		}
+	}
+
+	if g.egressIPConnLimitWatcher != nil {
+		if err = g.egressIPConnLimitWatcher.watchEgressIPs(); err != nil {
+			return fmt.Errorf("gateway init failed to start watching egress IPs for connection limits: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -417,6 +434,11 @@ func bridgeForInterface(intfName, nodeName, physicalNetworkName string, gwIPs []
 		res.uplinkName = uplinkName
 		gwIntf = bridgeName
 	} else if _, _, err := util.RunOVSVsctl("br-exists", intfName); err != nil {
+		// A DPDK uplink is not a kernel interface anymore
+		if config.OvnKubeNode.Datapath == types.NodeDatapathDPDK {
+			return nil, fmt.Errorf("gateway interface %s must be an OVS bridge with a DPDK uplink with "+
+				"ovnkube-node-datapath %s", intfName, config.OvnKubeNode.Datapath)
+		}
 		// This is not a OVS bridge. We need to create a OVS bridge
 		// and add cluster.GatewayIntf as a port of that bridge.
 		bridgeName, err := util.NicToBridge(intfName)
@@ -441,6 +463,9 @@ func bridgeForInterface(intfName, nodeName, physicalNetworkName string, gwIPs []
 		}
 		res.bridgeName = intfName
 	}
+	if err := setupUserspaceDatapathBridge(res.bridgeName, res.uplinkName); err != nil {
+		return nil, err
+	}
 	var err error
 	// Now, we get IP addresses for the bridge
 	if len(gwIPs) > 0 {
@@ -463,7 +488,7 @@ func bridgeForInterface(intfName, nodeName, physicalNetworkName string, gwIPs []
 
 	// the name of the patch port created by ovn-controller is of the form
 	// patch-<logical_port_name_of_localnet_port>-to-br-int
-	res.patchPort = "patch-" + res.bridgeName + "_" + nodeName + "-to-br-int"
+	res.patchPort = "patch-" + res.bridgeName + "_" + nodeName + "-to-" + config.OvnKubeNode.IntegrationBridge
 
 	// for DPU we use the host MAC address for the Gateway configuration
 	if config.OvnKubeNode.Mode == types.NodeModeDPU {
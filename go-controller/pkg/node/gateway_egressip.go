@@ -0,0 +1,327 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// egressIPConnLimitOutTable is the table the traffic of a limited egress IP leaving the node continues in once
+	// tracked in the conntrack zone of the egress IP
+	egressIPConnLimitOutTable = 10
+	// egressIPConnLimitInTable is the table the return traffic of a limited egress IP continues in once tracked in
+	// the conntrack zone of the egress IP
+	egressIPConnLimitInTable = 11
+	// egressIPConnLimitZoneOffset is the offset from the default conntrack zone of the first conntrack zone of the
+	// limited egress IPs, well above the zones ovn-controller allocates first
+	egressIPConnLimitZoneOffset = 1000
+	// maxConntrackZone is the highest conntrack zone
+	maxConntrackZone = 65535
+)
+
+// egressIPConnLimitWatcher limits the concurrent connections of the EgressIPs assigned to the node on the OVN
+// managed network. Their traffic is SNATed by the gateway router and leaves the node through the shared gateway
+// bridge without going through the host, so the limit is enforced in the bridge: the connections of each limited
+// egress IP are tracked in a conntrack zone of their own, limited in the datapath, which drops the packets of the new
+// connections above the limit.
+type egressIPConnLimitWatcher struct {
+	nodeName     string
+	ofportPhys   string
+	ofportPatch  string
+	ofm          *openflowManager
+	watchFactory factory.NodeWatchFactory
+
+	lock sync.Mutex
+	// limits holds the connection limit of each limited EgressIP, by name
+	limits map[string]*egressIPConnLimit
+}
+
+// egressIPConnLimit is the connection limit of an egress IP
+type egressIPConnLimit struct {
+	ip             net.IP
+	zone           int
+	maxConnections int32
+}
+
+func newEgressIPConnLimitWatcher(nodeName string, gwBridge *bridgeConfiguration, ofm *openflowManager,
+	watchFactory factory.NodeWatchFactory) (*egressIPConnLimitWatcher, error) {
+	ofportPatch, stderr, err := util.GetOVSOfPort("--if-exists", "get",
+		"interface", gwBridge.patchPort, "ofport")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ofport of %s, stderr: %q, error: %v",
+			gwBridge.patchPort, stderr, err)
+	}
+	ofportPhys, stderr, err := util.GetOVSOfPort("--if-exists", "get",
+		"interface", gwBridge.uplinkName, "ofport")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ofport of %s, stderr: %q, error: %v",
+			gwBridge.uplinkName, stderr, err)
+	}
+	return &egressIPConnLimitWatcher{
+		nodeName:     nodeName,
+		ofportPhys:   ofportPhys,
+		ofportPatch:  ofportPatch,
+		ofm:          ofm,
+		watchFactory: watchFactory,
+		limits:       map[string]*egressIPConnLimit{},
+	}, nil
+}
+
+// watchEgressIPs handles the EgressIPs, the connection limit of their egress
+// IP being set on each change. The limits of the conntrack zones of the
+// EgressIPs not limited on the node anymore are removed once the existing
+// EgressIPs are handled.
+func (w *egressIPConnLimitWatcher) watchEgressIPs() error {
+	w.ofm.updateFlowCacheEntry("EgressIPConnLimit", w.commonFlows())
+	_, err := w.watchFactory.AddEgressIPHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.syncEgressIP(obj.(*egressipv1.EgressIP))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			w.syncEgressIP(new.(*egressipv1.EgressIP))
+		},
+		DeleteFunc: func(obj interface{}) {
+			eip, ok := obj.(*egressipv1.EgressIP)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					klog.Errorf("Couldn't get object from tombstone %#v", obj)
+					return
+				}
+				eip, ok = tombstone.Obj.(*egressipv1.EgressIP)
+				if !ok {
+					klog.Errorf("Tombstone contained object that is not an EgressIP %#v", obj)
+					return
+				}
+			}
+			w.deleteEgressIP(eip.Name)
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	w.ofm.requestFlowSync()
+	return w.deleteStaleConnLimits()
+}
+
+// syncEgressIP sets the connection limit of the egress IP of the EgressIP
+// assigned to the node on the OVN managed network, if any
+func (w *egressIPConnLimitWatcher) syncEgressIP(eip *egressipv1.EgressIP) {
+	ip, err := w.getOVNManagedEgressIP(eip)
+	if err != nil {
+		klog.Errorf("Failed to get the egress IP of EgressIP %s on node %s: %v", eip.Name, w.nodeName, err)
+		return
+	}
+	if ip == nil || eip.Spec.MaxConnections == nil || *eip.Spec.MaxConnections <= 0 {
+		w.deleteEgressIP(eip.Name)
+		return
+	}
+	maxConnections := *eip.Spec.MaxConnections
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	limit, ok := w.limits[eip.Name]
+	if !ok {
+		zone, err := w.allocateZone()
+		if err != nil {
+			klog.Errorf("Failed to limit the connections of EgressIP %s: %v", eip.Name, err)
+			return
+		}
+		limit = &egressIPConnLimit{zone: zone}
+		w.limits[eip.Name] = limit
+	}
+	if limit.maxConnections != maxConnections {
+		if err := setConntrackZoneLimit(limit.zone, maxConnections); err != nil {
+			klog.Errorf("Failed to limit the connections of EgressIP %s: %v", eip.Name, err)
+			return
+		}
+		limit.maxConnections = maxConnections
+	}
+	if !limit.ip.Equal(ip) {
+		limit.ip = ip
+		w.ofm.updateFlowCacheEntry(egressIPConnLimitFlowKey(eip.Name), w.egressIPFlows(limit))
+		w.ofm.requestFlowSync()
+	}
+}
+
+// deleteEgressIP removes the connection limit of the EgressIP: its flows, and
+// the limit and connections of its conntrack zone
+func (w *egressIPConnLimitWatcher) deleteEgressIP(name string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	limit, ok := w.limits[name]
+	if !ok {
+		return
+	}
+	w.ofm.deleteFlowsByKey(egressIPConnLimitFlowKey(name))
+	w.ofm.requestFlowSync()
+	if err := deleteConntrackZoneLimit(limit.zone); err != nil {
+		// the limit is removed as stale on restart otherwise
+		klog.Errorf("Failed to remove the connection limit of EgressIP %s: %v", name, err)
+	}
+	delete(w.limits, name)
+}
+
+// deleteStaleConnLimits removes the limits of the conntrack zones of the
+// EgressIPs that are not limited on the node anymore
+func (w *egressIPConnLimitWatcher) deleteStaleConnLimits() error {
+	stdout, stderr, err := util.RunOVSAppctl("dpctl/ct-get-limits")
+	if err != nil {
+		return fmt.Errorf("failed to get the conntrack zone limits, stderr: %q, error: %v", stderr, err)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	zones := sets.New[int]()
+	for _, limit := range w.limits {
+		zones.Insert(limit.zone)
+	}
+	minZone := config.Default.ConntrackZone + egressIPConnLimitZoneOffset
+	for _, zone := range parseConntrackZoneLimits(stdout) {
+		if zone < minZone || zone > maxConntrackZone || zones.Has(zone) {
+			continue
+		}
+		if err := deleteConntrackZoneLimit(zone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOVNManagedEgressIP returns the egress IP of the EgressIP assigned to the
+// node if it is on the OVN managed network
+func (w *egressIPConnLimitWatcher) getOVNManagedEgressIP(eip *egressipv1.EgressIP) (net.IP, error) {
+	for _, status := range eip.Status.Items {
+		if status.Node != w.nodeName {
+			continue
+		}
+		ip := utilnet.ParseIPSloppy(status.EgressIP)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid egress IP %q", status.EgressIP)
+		}
+		node, err := w.watchFactory.GetNode(w.nodeName)
+		if err != nil {
+			return nil, err
+		}
+		isOVNManaged, err := util.IsOVNManagedNetwork(node, ip)
+		if err != nil {
+			return nil, err
+		}
+		if isOVNManaged {
+			return ip, nil
+		}
+	}
+	return nil, nil
+}
+
+// allocateZone returns the lowest conntrack zone not used by another EgressIP
+func (w *egressIPConnLimitWatcher) allocateZone() (int, error) {
+	zones := sets.New[int]()
+	for _, limit := range w.limits {
+		zones.Insert(limit.zone)
+	}
+	for zone := config.Default.ConntrackZone + egressIPConnLimitZoneOffset; zone <= maxConntrackZone; zone++ {
+		if !zones.Has(zone) {
+			return zone, nil
+		}
+	}
+	return 0, fmt.Errorf("no conntrack zone left for the connection limits of the egress IPs")
+}
+
+// commonFlows returns the flows the traffic of the limited egress IPs
+// continues in, in the tables of the traffic leaving and coming back to the
+// node once tracked in the conntrack zones of the egress IPs: they go on like
+// the traffic of the other egress IPs
+func (w *egressIPConnLimitWatcher) commonFlows() []string {
+	var flows []string
+	for _, ipPrefix := range []string{"ip", "ipv6"} {
+		if (ipPrefix == "ipv6" && !config.IPv6Mode) || (ipPrefix == "ip" && !config.IPv4Mode) {
+			continue
+		}
+		flows = append(flows,
+			fmt.Sprintf("cookie=%s, priority=100, table=%d, %s, "+
+				"actions=ct(commit, zone=%d, exec(set_field:%s->ct_mark)), output:%s",
+				defaultOpenFlowCookie, egressIPConnLimitOutTable, ipPrefix, config.Default.ConntrackZone, ctMarkOVN,
+				w.ofportPhys),
+			fmt.Sprintf("cookie=%s, priority=100, table=%d, %s, actions=ct(zone=%d, nat, table=1)",
+				defaultOpenFlowCookie, egressIPConnLimitInTable, ipPrefix, config.Default.ConntrackZone))
+	}
+	return flows
+}
+
+// egressIPFlows returns the flows tracking the traffic of the egress IP, in
+// both directions, in its conntrack zone. The new connections are committed to
+// the zone, failing above its limit.
+func (w *egressIPConnLimitWatcher) egressIPFlows(limit *egressIPConnLimit) []string {
+	ipPrefix := "ip"
+	if utilnet.IsIPv6(limit.ip) {
+		ipPrefix = "ipv6"
+	}
+	return []string{
+		// table 0, traffic of the egress IP leaving the node, one priority
+		// above the traffic of the pods leaving the node
+		fmt.Sprintf("cookie=%s, priority=101, in_port=%s, %s, %s_src=%s, actions=ct(commit, zone=%d, table=%d)",
+			defaultOpenFlowCookie, w.ofportPatch, ipPrefix, ipPrefix, limit.ip, limit.zone, egressIPConnLimitOutTable),
+		// table 0, return traffic of the egress IP, one priority above the
+		// traffic coming from external
+		fmt.Sprintf("cookie=%s, priority=51, in_port=%s, %s, %s_dst=%s, actions=ct(zone=%d, table=%d)",
+			defaultOpenFlowCookie, w.ofportPhys, ipPrefix, ipPrefix, limit.ip, limit.zone, egressIPConnLimitInTable),
+	}
+}
+
+func egressIPConnLimitFlowKey(name string) string {
+	return "EgressIPConnLimit_" + name
+}
+
+// setConntrackZoneLimit limits the number of connections of the conntrack zone
+func setConntrackZoneLimit(zone int, maxConnections int32) error {
+	_, stderr, err := util.RunOVSAppctl("dpctl/ct-set-limits", fmt.Sprintf("zone=%d,limit=%d", zone, maxConnections))
+	if err != nil {
+		return fmt.Errorf("failed to set the limit of conntrack zone %d to %d, stderr: %q, error: %v",
+			zone, maxConnections, stderr, err)
+	}
+	return nil
+}
+
+// deleteConntrackZoneLimit removes the limit of the conntrack zone and flushes
+// its connections
+func deleteConntrackZoneLimit(zone int) error {
+	_, stderr, err := util.RunOVSAppctl("dpctl/ct-del-limits", fmt.Sprintf("zone=%d", zone))
+	if err != nil {
+		return fmt.Errorf("failed to remove the limit of conntrack zone %d, stderr: %q, error: %v", zone, stderr, err)
+	}
+	_, stderr, err = util.RunOVSAppctl("dpctl/flush-conntrack", fmt.Sprintf("zone=%d", zone))
+	if err != nil {
+		return fmt.Errorf("failed to flush conntrack zone %d, stderr: %q, error: %v", zone, stderr, err)
+	}
+	return nil
+}
+
+// parseConntrackZoneLimits returns the zones listed by 'ovs-appctl
+// dpctl/ct-get-limits', whose lines are like 'zone=65000,limit=10,count=3'
+func parseConntrackZoneLimits(out string) []int {
+	var zones []int
+	for _, line := range strings.Split(out, "\n") {
+		for _, field := range strings.Split(strings.TrimSpace(line), ",") {
+			if !strings.HasPrefix(field, "zone=") {
+				continue
+			}
+			if zone, err := strconv.Atoi(strings.TrimPrefix(field, "zone=")); err == nil {
+				zones = append(zones, zone)
+			}
+		}
+	}
+	return zones
+}
@@ -0,0 +1,119 @@
+package node
+
+import (
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	utilpointer "k8s.io/utils/pointer"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gateway egress IP connection limits", func() {
+	var (
+		wf    *factory.WatchFactory
+		w     *egressIPConnLimitWatcher
+		fexec *ovntest.FakeExec
+	)
+
+	newEgressIP := func(egressIP string, maxConnections int32) *egressipv1.EgressIP {
+		return &egressipv1.EgressIP{
+			ObjectMeta: metav1.ObjectMeta{Name: "eip1"},
+			Spec: egressipv1.EgressIPSpec{
+				EgressIPs:      []string{egressIP},
+				MaxConnections: utilpointer.Int32(maxConnections),
+			},
+			Status: egressipv1.EgressIPStatus{
+				Items: []egressipv1.EgressIPStatusItem{{Node: "node1", EgressIP: egressIP}},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		Expect(config.PrepareTestConfig()).To(Succeed())
+		config.IPv4Mode = true
+		config.IPv6Mode = false
+		fexec = ovntest.NewFakeExec()
+		Expect(util.SetExec(fexec)).To(Succeed())
+		fakeClient := &util.OVNNodeClientset{
+			KubeClient: fake.NewSimpleClientset(&kapi.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "node1",
+					Annotations: map[string]string{"k8s.ovn.org/node-primary-ifaddr": `{"ipv4":"172.18.0.2/24"}`},
+				},
+			}),
+		}
+		var err error
+		wf, err = factory.NewNodeWatchFactory(fakeClient, "node1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wf.Start()).To(Succeed())
+		w = &egressIPConnLimitWatcher{
+			nodeName:     "node1",
+			ofportPhys:   "eth0",
+			ofportPatch:  "patch-breth0_ov",
+			ofm:          &openflowManager{flowCache: map[string][]string{}, flowChan: make(chan struct{}, 1)},
+			watchFactory: wf,
+			limits:       map[string]*egressIPConnLimit{},
+		}
+	})
+
+	AfterEach(func() {
+		wf.Shutdown()
+	})
+
+	It("limits the connections of the egress IPs on the OVN managed network in a conntrack zone", func() {
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-appctl --timeout=15 dpctl/ct-set-limits zone=65000,limit=10",
+		})
+		w.syncEgressIP(newEgressIP("172.18.0.100", 10))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+		Expect(w.ofm.flowCache["EgressIPConnLimit_eip1"]).To(ConsistOf(
+			MatchRegexp(`^cookie=0x[0-9a-f]+, priority=101, in_port=patch-breth0_ov, ip, ip_src=172.18.0.100, actions=ct\(commit, zone=65000, table=10\)$`),
+			MatchRegexp(`^cookie=0x[0-9a-f]+, priority=51, in_port=eth0, ip, ip_dst=172.18.0.100, actions=ct\(zone=65000, table=11\)$`),
+		))
+
+		By("updating the limit")
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-appctl --timeout=15 dpctl/ct-set-limits zone=65000,limit=20",
+		})
+		w.syncEgressIP(newEgressIP("172.18.0.100", 20))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+
+		By("removing the limit")
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-appctl --timeout=15 dpctl/ct-del-limits zone=65000",
+			"ovs-appctl --timeout=15 dpctl/flush-conntrack zone=65000",
+		})
+		w.syncEgressIP(newEgressIP("172.18.0.100", 0))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+		Expect(w.ofm.flowCache).NotTo(HaveKey("EgressIPConnLimit_eip1"))
+		Expect(w.limits).To(BeEmpty())
+	})
+
+	It("does not limit the connections of the egress IPs on a secondary host network", func() {
+		w.syncEgressIP(newEgressIP("10.10.0.100", 10))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+		Expect(w.ofm.flowCache).To(BeEmpty())
+	})
+
+	It("removes the stale conntrack zone limits", func() {
+		w.limits["eip1"] = &egressIPConnLimit{zone: 65000}
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovs-appctl --timeout=15 dpctl/ct-get-limits",
+			Output: "default limit=0\nzone=64001,limit=5,count=0\nzone=65000,limit=10,count=3\nzone=65001,limit=10,count=0",
+		})
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-appctl --timeout=15 dpctl/ct-del-limits zone=65001",
+			"ovs-appctl --timeout=15 dpctl/flush-conntrack zone=65001",
+		})
+		Expect(w.deleteStaleConnLimits()).To(Succeed())
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+})
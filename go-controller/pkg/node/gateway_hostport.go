@@ -0,0 +1,188 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	kapi "k8s.io/api/core/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// hostPortWatcher steers the traffic to the host ports of the pods of the node
+// from the shared gateway bridge to OVN, whose gateway router DNATs it to the
+// pods. The flows are one priority below the ones of the NodePorts so that a
+// NodePort wins over a host port clashing with it.
+type hostPortWatcher struct {
+	nodeName     string
+	ofportPhys   string
+	ofportPatch  string
+	ofm          *openflowManager
+	watchFactory factory.NodeWatchFactory
+
+	lock sync.Mutex
+	// podFlowKeys holds the keys of the flows of the host ports, by pod
+	podFlowKeys map[ktypes.NamespacedName][]string
+}
+
+func newHostPortWatcher(nodeName string, gwBridge *bridgeConfiguration, ofm *openflowManager,
+	watchFactory factory.NodeWatchFactory) (*hostPortWatcher, error) {
+	ofportPatch, stderr, err := util.GetOVSOfPort("--if-exists", "get",
+		"interface", gwBridge.patchPort, "ofport")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ofport of %s, stderr: %q, error: %v",
+			gwBridge.patchPort, stderr, err)
+	}
+	ofportPhys, stderr, err := util.GetOVSOfPort("--if-exists", "get",
+		"interface", gwBridge.uplinkName, "ofport")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ofport of %s, stderr: %q, error: %v",
+			gwBridge.uplinkName, stderr, err)
+	}
+	return &hostPortWatcher{
+		nodeName:     nodeName,
+		ofportPhys:   ofportPhys,
+		ofportPatch:  ofportPatch,
+		ofm:          ofm,
+		watchFactory: watchFactory,
+		podFlowKeys:  map[ktypes.NamespacedName][]string{},
+	}, nil
+}
+
+// watchPods handles the pods of the node, the flows of their host ports being
+// rebuilt on each change
+func (hpw *hostPortWatcher) watchPods() error {
+	_, err := hpw.watchFactory.AddPodHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			hpw.syncPod(obj.(*kapi.Pod))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			hpw.syncPod(new.(*kapi.Pod))
+		},
+		DeleteFunc: func(obj interface{}) {
+			pod, ok := obj.(*kapi.Pod)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					klog.Errorf("Couldn't get object from tombstone %#v", obj)
+					return
+				}
+				pod, ok = tombstone.Obj.(*kapi.Pod)
+				if !ok {
+					klog.Errorf("Tombstone contained object that is not a pod %#v", obj)
+					return
+				}
+			}
+			hpw.deletePod(ktypes.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+		},
+	}, nil)
+	return err
+}
+
+// syncPod sets the flows of the host ports of the pod if of the node, the
+// ones clashing with a NodePort left out
+func (hpw *hostPortWatcher) syncPod(pod *kapi.Pod) {
+	podName := ktypes.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+	hostPorts := util.PodHostPorts(pod)
+	if pod.Spec.NodeName != hpw.nodeName || len(hostPorts) == 0 || util.PodCompleted(pod) {
+		hpw.deletePod(podName)
+		return
+	}
+	services, err := hpw.watchFactory.GetServices()
+	if err != nil {
+		klog.Errorf("Failed to get the services to check the host ports of pod %s: %v", podName, err)
+	}
+
+	flows := map[string][]string{}
+	for _, hostPort := range hostPorts {
+		if service := util.GetNodePortService(services, hostPort.Protocol, hostPort.HostPort); service != nil {
+			klog.Warningf("Host port %d/%s of pod %s is not exposed, clashing with the NodePort of service %s/%s",
+				hostPort.HostPort, hostPort.Protocol, podName, service.Namespace, service.Name)
+			continue
+		}
+		for key, hostPortFlows := range hpw.hostPortFlows(podName, hostPort) {
+			flows[key] = hostPortFlows
+		}
+	}
+
+	hpw.lock.Lock()
+	defer hpw.lock.Unlock()
+	keys := make([]string, 0, len(flows))
+	for key, hostPortFlows := range flows {
+		hpw.ofm.updateFlowCacheEntry(key, hostPortFlows)
+		keys = append(keys, key)
+	}
+	for _, key := range hpw.podFlowKeys[podName] {
+		if _, ok := flows[key]; !ok {
+			hpw.ofm.deleteFlowsByKey(key)
+		}
+	}
+	hpw.podFlowKeys[podName] = keys
+	hpw.ofm.requestFlowSync()
+}
+
+// deletePod deletes the flows of the host ports of the pod
+func (hpw *hostPortWatcher) deletePod(podName ktypes.NamespacedName) {
+	hpw.lock.Lock()
+	defer hpw.lock.Unlock()
+	keys, ok := hpw.podFlowKeys[podName]
+	if !ok {
+		return
+	}
+	for _, key := range keys {
+		hpw.ofm.deleteFlowsByKey(key)
+	}
+	delete(hpw.podFlowKeys, podName)
+	hpw.ofm.requestFlowSync()
+}
+
+// hostPortFlows returns the flows of the host port, by flow cache key, of each
+// IP family of the cluster: the traffic to the host port is sent to OVN and
+// its return traffic out of the physical interface. A host port bound to a
+// host IP only matches the traffic to that IP.
+func (hpw *hostPortWatcher) hostPortFlows(podName ktypes.NamespacedName, hostPort kapi.ContainerPort) map[string][]string {
+	hostIP := utilnet.ParseIPSloppy(hostPort.HostIP)
+	protocol := strings.ToLower(string(hostPort.Protocol))
+	flows := map[string][]string{}
+	for _, isIPv6 := range []bool{false, true} {
+		if (isIPv6 && !config.IPv6Mode) || (!isIPv6 && !config.IPv4Mode) {
+			continue
+		}
+		if hostIP != nil && utilnet.IsIPv6(hostIP) != isIPv6 {
+			continue
+		}
+		flowProtocol, ipPrefix := protocol, "nw"
+		if isIPv6 {
+			flowProtocol, ipPrefix = protocol+"6", "ipv6"
+		}
+		dstMatch, srcMatch := "", ""
+		if hostIP != nil && !hostIP.IsUnspecified() {
+			dstMatch = fmt.Sprintf(" %s_dst=%s,", ipPrefix, hostIP)
+			srcMatch = fmt.Sprintf(" %s_src=%s,", ipPrefix, hostIP)
+		}
+		cookie, err := svcToCookie(podName.Namespace, podName.Name, flowProtocol, hostPort.HostPort)
+		if err != nil {
+			klog.Warningf("Unable to generate cookie for host port of pod: %s, %s, %d, error: %v",
+				podName, flowProtocol, hostPort.HostPort, err)
+			cookie = "0"
+		}
+		key := strings.Join([]string{"HostPort", podName.Namespace, podName.Name, flowProtocol,
+			fmt.Sprintf("%d", hostPort.HostPort)}, "_")
+		flows[key] = []string{
+			// table=0, matches on the traffic to the host port and sends it to the OVN pipeline
+			fmt.Sprintf("cookie=%s, priority=108, in_port=%s, %s,%s tp_dst=%d, actions=output:%s",
+				cookie, hpw.ofportPhys, flowProtocol, dstMatch, hostPort.HostPort, hpw.ofportPatch),
+			// table=0, matches on the return traffic from the host port and sends it out of the physical interface
+			fmt.Sprintf("cookie=%s, priority=108, in_port=%s, %s,%s tp_src=%d, actions=output:%s",
+				cookie, hpw.ofportPatch, flowProtocol, srcMatch, hostPort.HostPort, hpw.ofportPhys),
+		}
+	}
+	return flows
+}
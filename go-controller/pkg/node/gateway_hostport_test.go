@@ -0,0 +1,102 @@
+package node
+
+import (
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shared gateway host ports", func() {
+	var (
+		wf  *factory.WatchFactory
+		hpw *hostPortWatcher
+	)
+
+	newPod := func(nodeName string, ports ...kapi.ContainerPort) *kapi.Pod {
+		return &kapi.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"},
+			Spec: kapi.PodSpec{
+				NodeName:   nodeName,
+				Containers: []kapi.Container{{Ports: ports}},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		Expect(config.PrepareTestConfig()).To(Succeed())
+		config.IPv4Mode = true
+		config.IPv6Mode = false
+		fakeClient := &util.OVNNodeClientset{
+			KubeClient: fake.NewSimpleClientset(&kapi.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "ns2"},
+				Spec: kapi.ServiceSpec{
+					Type:  kapi.ServiceTypeNodePort,
+					Ports: []kapi.ServicePort{{Protocol: kapi.ProtocolTCP, Port: 90, NodePort: 30090}},
+				},
+			}),
+		}
+		var err error
+		wf, err = factory.NewNodeWatchFactory(fakeClient, "node1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wf.Start()).To(Succeed())
+		hpw = &hostPortWatcher{
+			nodeName:     "node1",
+			ofportPhys:   "eth0",
+			ofportPatch:  "patch-breth0_ov",
+			ofm:          &openflowManager{flowCache: map[string][]string{}},
+			watchFactory: wf,
+			podFlowKeys:  map[ktypes.NamespacedName][]string{},
+		}
+	})
+
+	AfterEach(func() {
+		wf.Shutdown()
+	})
+
+	It("steers the host ports of the pods of the node to OVN", func() {
+		hpw.syncPod(newPod("node1",
+			kapi.ContainerPort{ContainerPort: 80, HostPort: 8080},
+			kapi.ContainerPort{ContainerPort: 53, HostPort: 5353, Protocol: kapi.ProtocolUDP, HostIP: "172.18.0.2"},
+			kapi.ContainerPort{ContainerPort: 90, HostPort: 30090},
+		))
+		// the host port clashing with the NodePort is left out
+		Expect(hpw.ofm.flowCache).To(HaveLen(2))
+		Expect(hpw.ofm.flowCache).To(HaveKey("HostPort_ns1_pod1_tcp_8080"))
+		Expect(hpw.ofm.flowCache["HostPort_ns1_pod1_tcp_8080"]).To(ConsistOf(
+			MatchRegexp(`^cookie=0x[0-9a-f]+, priority=108, in_port=eth0, tcp, tp_dst=8080, actions=output:patch-breth0_ov$`),
+			MatchRegexp(`^cookie=0x[0-9a-f]+, priority=108, in_port=patch-breth0_ov, tcp, tp_src=8080, actions=output:eth0$`),
+		))
+		Expect(hpw.ofm.flowCache["HostPort_ns1_pod1_udp_5353"]).To(ConsistOf(
+			MatchRegexp(`^cookie=0x[0-9a-f]+, priority=108, in_port=eth0, udp, nw_dst=172.18.0.2, tp_dst=5353, actions=output:patch-breth0_ov$`),
+			MatchRegexp(`^cookie=0x[0-9a-f]+, priority=108, in_port=patch-breth0_ov, udp, nw_src=172.18.0.2, tp_src=5353, actions=output:eth0$`),
+		))
+
+		// the flows of the host ports removed from the pod are deleted
+		hpw.syncPod(newPod("node1", kapi.ContainerPort{ContainerPort: 80, HostPort: 8080}))
+		Expect(hpw.ofm.flowCache).To(HaveLen(1))
+		Expect(hpw.ofm.flowCache).To(HaveKey("HostPort_ns1_pod1_tcp_8080"))
+
+		// and all of them along with the pod
+		hpw.deletePod(ktypes.NamespacedName{Namespace: "ns1", Name: "pod1"})
+		Expect(hpw.ofm.flowCache).To(BeEmpty())
+		Expect(hpw.podFlowKeys).To(BeEmpty())
+	})
+
+	It("ignores the pods of the other nodes and the host networked pods", func() {
+		hpw.syncPod(newPod("node2", kapi.ContainerPort{ContainerPort: 80, HostPort: 8080}))
+		Expect(hpw.ofm.flowCache).To(BeEmpty())
+
+		pod := newPod("node1", kapi.ContainerPort{ContainerPort: 8080, HostPort: 8080})
+		pod.Spec.HostNetwork = true
+		hpw.syncPod(pod)
+		Expect(hpw.ofm.flowCache).To(BeEmpty())
+	})
+})
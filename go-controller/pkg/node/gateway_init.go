@@ -298,15 +298,25 @@ func getInterfaceByIP(ip net.IP) (string, error) {
 	return "", fmt.Errorf("failed to find network interface with IP: %s", ip)
 }
 
-// configureSvcRouteViaInterface routes svc traffic through the provided interface
+// configureSvcRouteViaInterface routes svc traffic through the provided interface.
+// On the layer2 topology of the default network, whose nodes have no management
+// port, the traffic to the cluster subnets is routed through it as well.
 func configureSvcRouteViaInterface(routeManager *routemanager.Controller, iface string, gwIPs []net.IP) error {
 	link, err := util.LinkSetUp(iface)
 	if err != nil {
 		return fmt.Errorf("unable to get link for %s, error: %v", iface, err)
 	}
 
+	subnets := config.Kubernetes.ServiceCIDRs
+	if config.Default.Topology == types.Layer2Topology {
+		subnets = append([]*net.IPNet{}, subnets...)
+		for _, clusterSubnet := range config.Default.ClusterSubnets {
+			subnets = append(subnets, clusterSubnet.CIDR)
+		}
+	}
+
 	var routes []routemanager.Route
-	for _, subnet := range config.Kubernetes.ServiceCIDRs {
+	for _, subnet := range subnets {
 		gwIP, err := util.MatchIPFamily(utilnet.IsIPv6CIDR(subnet), gwIPs)
 		if err != nil {
 			return fmt.Errorf("unable to find gateway IP for subnet: %v, found IPs: %v", subnet, gwIPs)
@@ -1629,6 +1629,55 @@ var _ = Describe("Gateway unit tests", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("Configures the cluster subnet routes on interface on the layer2 topology", func() {
+			_, svcCIDR, err := net.ParseCIDR("10.96.0.0/16")
+			Expect(err).ToNot(HaveOccurred())
+			_, clusterSubnet, err := net.ParseCIDR("10.128.0.0/16")
+			Expect(err).ToNot(HaveOccurred())
+			config.Kubernetes.ServiceCIDRs = []*net.IPNet{svcCIDR}
+			config.Default.ClusterSubnets = []config.CIDRNetworkEntry{{CIDR: clusterSubnet, HostSubnetLength: 24}}
+			config.Default.Topology = types.Layer2Topology
+			gwIPs := []net.IP{net.ParseIP("10.0.0.11")}
+			lnk := &linkMock.Link{}
+			lnkAttr := &netlink.LinkAttrs{
+				Name:  "ens1f0",
+				Index: 5,
+			}
+			var addedMutex sync.Mutex
+			var added []string
+			lnk.On("Attrs").Return(lnkAttr)
+			netlinkMock.On("LinkByName", mock.Anything).Return(lnk, nil)
+			netlinkMock.On("LinkSetUp", mock.Anything).Return(nil)
+			netlinkMock.On("RouteListFiltered", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+			netlinkMock.On("RouteAdd", mock.Anything).Run(func(args mock.Arguments) {
+				route := args.Get(0).(*netlink.Route)
+				Expect(route.Gw.Equal(gwIPs[0])).To(BeTrue())
+				addedMutex.Lock()
+				defer addedMutex.Unlock()
+				added = append(added, route.Dst.String())
+			}).Return(nil)
+			wg := &sync.WaitGroup{}
+			rm := routemanager.NewController()
+			rm.SetNetLinkOpMockInst(netlinkMock)
+			stopCh := make(chan struct{})
+			wg.Add(1)
+			go func() {
+				rm.Run(stopCh, 10*time.Second)
+				wg.Done()
+			}()
+			defer func() {
+				close(stopCh)
+				wg.Wait()
+			}()
+			err = configureSvcRouteViaInterface(rm, "ens1f0", gwIPs)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(func() []string {
+				addedMutex.Lock()
+				defer addedMutex.Unlock()
+				return append([]string{}, added...)
+			}).Should(ConsistOf("10.96.0.0/16", "10.128.0.0/16"))
+		})
+
 		It("Fails if link set up fails", func() {
 			netlinkMock.On("LinkByName", mock.Anything).Return(nil, fmt.Errorf("failed to find interface"))
 			gwIPs := []net.IP{net.ParseIP("10.0.0.11")}
@@ -1675,6 +1724,44 @@ var _ = Describe("Gateway unit tests", func() {
 		})
 	})
 
+	Context("flowsForDefaultBridge", func() {
+
+		It("Sends the host traffic to the cluster subnets to OVN on the layer2 topology", func() {
+			_, svcCIDR, err := net.ParseCIDR("10.96.0.0/16")
+			Expect(err).ToNot(HaveOccurred())
+			_, clusterSubnet, err := net.ParseCIDR("10.128.0.0/16")
+			Expect(err).ToNot(HaveOccurred())
+			config.IPv4Mode = true
+			config.Kubernetes.ServiceCIDRs = []*net.IPNet{svcCIDR}
+			config.Default.ClusterSubnets = []config.CIDRNetworkEntry{{CIDR: clusterSubnet, HostSubnetLength: 24}}
+			bridge := &bridgeConfiguration{
+				bridgeName:  "breth0",
+				ips:         []*net.IPNet{ovntest.MustParseIPNet("192.168.1.10/24")},
+				macAddress:  ovntest.MustParseMAC("0a:58:c0:a8:01:0a"),
+				ofPortPatch: "1",
+				ofPortPhys:  "2",
+				ofPortHost:  "LOCAL",
+			}
+			hostToPod := fmt.Sprintf("cookie=%s, priority=500, in_port=LOCAL, ip, ip_dst=10.128.0.0/16,"+
+				"actions=ct(commit,zone=%d,nat(src=%s),table=2)",
+				defaultOpenFlowCookie, HostMasqCTZone, config.Gateway.MasqueradeIPs.V4HostMasqueradeIP)
+			podToHost := fmt.Sprintf("cookie=%s, priority=500, in_port=1, ip, ip_src=10.128.0.0/16, ip_dst=%s,"+
+				"actions=ct(zone=%d,nat,table=3)",
+				defaultOpenFlowCookie, config.Gateway.MasqueradeIPs.V4HostMasqueradeIP, HostMasqCTZone)
+
+			flows, err := flowsForDefaultBridge(bridge, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(flows).NotTo(ContainElement(hostToPod))
+			Expect(flows).NotTo(ContainElement(podToHost))
+
+			config.Default.Topology = types.Layer2Topology
+			flows, err = flowsForDefaultBridge(bridge, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(flows).To(ContainElement(hostToPod))
+			Expect(flows).To(ContainElement(podToHost))
+		})
+	})
+
 	Context("getGatewayNextHops", func() {
 
 		It("Finds correct gateway interface and nexthops without configuration", func() {
@@ -128,6 +128,14 @@ func newLocalGateway(nodeName string, hostSubnets []*net.IPNet, gwNextHops []net
 			gw.openflowManager.requestFlowSync()
 		}
 
+		if config.OVNKubernetesFeature.EnableEgressIP {
+			klog.Info("Creating Local Gateway Egress IP Connection Limit Watcher")
+			gw.egressIPConnLimitWatcher, err = newEgressIPConnLimitWatcher(nodeName, gwBridge, gw.openflowManager, watchFactory)
+			if err != nil {
+				return err
+			}
+		}
+
 		if err := addHostMACBindings(gwBridge.bridgeName); err != nil {
 			return fmt.Errorf("failed to add MAC bindings for service routing")
 		}
@@ -25,6 +25,7 @@ import (
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/cache"
+	utilnet "k8s.io/utils/net"
 )
 
 const (
@@ -201,11 +202,15 @@ type ctFilterDesc struct {
 func addConntrackMocks(nlMock *mocks.NetLinkOps, filterDescs []ctFilterDesc) {
 	ctMocks := make([]ovntest.TestifyMockHelper, 0, len(filterDescs))
 	for _, ctf := range filterDescs {
+		family := netlink.FAMILY_V4
+		if utilnet.IsIPv6String(ctf.ip) {
+			family = netlink.FAMILY_V6
+		}
 		ctMocks = append(ctMocks, ovntest.TestifyMockHelper{
 			OnCallMethodName: "ConntrackDeleteFilter",
 			OnCallMethodArgs: []interface{}{
 				netlink.ConntrackTableType(netlink.ConntrackTable),
-				netlink.InetFamily(netlink.FAMILY_V4),
+				netlink.InetFamily(family),
 				makeConntrackFilter(ctf.ip, ctf.port, kapi.ProtocolTCP),
 			},
 			RetArgList: []interface{}{uint(1), nil},
@@ -1907,6 +1912,144 @@ var _ = Describe("Node Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("manages iptables rules and openflows for both IP families when the ipFamilyPolicy of a LoadBalancer changes, SGW", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Gateway.Mode = config.GatewayModeShared
+				clusterIPv4 := "10.129.0.2"
+				clusterIPv6 := "fd00:10:96::10"
+				externalIPv4 := "1.1.1.1"
+				externalIPv6 := "fd00:1::1"
+				ingressIPv4 := "5.5.5.5"
+				ingressIPv6 := "fd00:5::5"
+				singleStackPolicy := v1.IPFamilyPolicySingleStack
+				dualStackPolicy := v1.IPFamilyPolicyPreferDualStack
+				service := *newService("service1", "namespace1", clusterIPv4,
+					[]v1.ServicePort{
+						{
+							NodePort: int32(31111),
+							Protocol: v1.ProtocolTCP,
+							Port:     int32(8080),
+						},
+					},
+					v1.ServiceTypeLoadBalancer,
+					[]string{externalIPv4, externalIPv6},
+					v1.ServiceStatus{
+						LoadBalancer: v1.LoadBalancerStatus{
+							Ingress: []v1.LoadBalancerIngress{{IP: ingressIPv4}, {IP: ingressIPv6}},
+						},
+					},
+					false, false,
+				)
+				service.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol}
+				service.Spec.IPFamilyPolicy = &singleStackPolicy
+				dualStackService := *service.DeepCopy()
+				dualStackService.Spec.ClusterIPs = []string{clusterIPv4, clusterIPv6}
+				dualStackService.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+				dualStackService.Spec.IPFamilyPolicy = &dualStackPolicy
+				endpointSlice := *newEndpointSlice(
+					"service1",
+					"namespace1",
+					[]discovery.Endpoint{},
+					[]discovery.EndpointPort{})
+
+				fakeOvnNode.start(ctx,
+					&v1.ServiceList{
+						Items: []v1.Service{
+							service,
+						},
+					},
+					&endpointSlice,
+				)
+				Expect(config.IPv4Mode).To(BeTrue())
+				Expect(config.IPv6Mode).To(BeTrue())
+
+				fNPW.watchFactory = fakeOvnNode.watcher
+				Expect(startNodePortWatcher(fNPW, fakeOvnNode.fakeClient, &fakeMgmtPortConfig)).To(Succeed())
+				err := fNPW.AddService(&service)
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedIPv6Tables := func(nodePortRules, externalIPRules []string) map[string]util.FakeTable {
+					return map[string]util.FakeTable{
+						"nat": {
+							"PREROUTING": []string{
+								"-j OVN-KUBE-ETP",
+								"-j OVN-KUBE-EXTERNALIP",
+								"-j OVN-KUBE-NODEPORT",
+							},
+							"OUTPUT": []string{
+								"-j OVN-KUBE-EXTERNALIP",
+								"-j OVN-KUBE-NODEPORT",
+								"-j OVN-KUBE-ITP",
+							},
+							"POSTROUTING": []string{
+								"-j OVN-KUBE-EGRESS-SVC",
+							},
+							"OVN-KUBE-NODEPORT":      nodePortRules,
+							"OVN-KUBE-EXTERNALIP":    externalIPRules,
+							"OVN-KUBE-SNAT-MGMTPORT": []string{},
+							"OVN-KUBE-ETP":           []string{},
+							"OVN-KUBE-ITP":           []string{},
+							"OVN-KUBE-EGRESS-SVC":    []string{},
+						},
+						"filter": {},
+						"mangle": {
+							"OUTPUT": []string{
+								"-j OVN-KUBE-ITP",
+							},
+							"OVN-KUBE-ITP": []string{},
+						},
+					}
+				}
+				expectSingleStackIPv4 := func() {
+					f6 := iptV6.(*util.FakeIPTables)
+					Expect(f6.MatchState(expectedIPv6Tables([]string{}, []string{}))).To(Succeed())
+					Expect(fNPW.ofm.flowCache["NodePort_namespace1_service1_tcp_31111"]).NotTo(BeNil())
+					Expect(fNPW.ofm.flowCache["External_namespace1_service1_1.1.1.1_8080"]).NotTo(BeNil())
+					Expect(fNPW.ofm.flowCache["Ingress_namespace1_service1_5.5.5.5_8080"]).NotTo(BeNil())
+					Expect(fNPW.ofm.flowCache["NodePort_namespace1_service1_tcp6_31111"]).To(BeNil())
+					Expect(fNPW.ofm.flowCache["External_namespace1_service1_fd00:1::1_8080"]).To(BeNil())
+					Expect(fNPW.ofm.flowCache["Ingress_namespace1_service1_fd00:5::5_8080"]).To(BeNil())
+				}
+				expectSingleStackIPv4()
+
+				// PreferDualStack adds an IPv6 cluster IP: the IPv6 node port,
+				// external IP and LoadBalancer IP are handled too
+				err = fNPW.UpdateService(&service, &dualStackService)
+				Expect(err).NotTo(HaveOccurred())
+				f6 := iptV6.(*util.FakeIPTables)
+				Expect(f6.MatchState(expectedIPv6Tables(
+					[]string{
+						fmt.Sprintf("-p TCP -m addrtype --dst-type LOCAL --dport 31111 -j DNAT --to-destination [%s]:8080", clusterIPv6),
+					},
+					[]string{
+						fmt.Sprintf("-p TCP -d %s --dport 8080 -j DNAT --to-destination [%s]:8080", ingressIPv6, clusterIPv6),
+						fmt.Sprintf("-p TCP -d %s --dport 8080 -j DNAT --to-destination [%s]:8080", externalIPv6, clusterIPv6),
+					},
+				))).To(Succeed())
+				Expect(fNPW.ofm.flowCache["NodePort_namespace1_service1_tcp_31111"]).NotTo(BeNil())
+				Expect(fNPW.ofm.flowCache["NodePort_namespace1_service1_tcp6_31111"]).To(ContainElement(
+					ContainSubstring("priority=110, in_port=eth0, tcp6, tp_dst=31111, actions=output:patch-breth0_ov")))
+				Expect(fNPW.ofm.flowCache["External_namespace1_service1_fd00:1::1_8080"]).NotTo(BeNil())
+				Expect(fNPW.ofm.flowCache["Ingress_namespace1_service1_fd00:5::5_8080"]).NotTo(BeNil())
+
+				// back to SingleStack, the IPv6 cluster IP is removed along
+				// with its conntrack entries
+				addConntrackMocks(netlinkMock, []ctFilterDesc{{clusterIPv6, 8080}})
+				err = fNPW.UpdateService(&dualStackService, &service)
+				Expect(err).NotTo(HaveOccurred())
+				expectSingleStackIPv4()
+				netlinkMock.AssertExpectations(GinkgoT())
+
+				return nil
+			}
+			err := app.Run([]string{
+				app.Name,
+				"--cluster-subnets=10.128.0.0/14,fd00:10:128::/48",
+				"--k8s-service-cidrs=172.30.0.0/16,fd00:10:96::/112",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("manages iptables rules and openflows for NodePort backed by ovn-k pods where ETP=local, LGW", func() {
 			app.Action = func(ctx *cli.Context) error {
 				config.Gateway.Mode = config.GatewayModeLocal
@@ -43,6 +43,10 @@ const (
 	ctMarkOVN = "0x1"
 	// ctMarkHost is the conntrack mark value for host traffic
 	ctMarkHost = "0x2"
+	// ctMarkUDNBase is the base of the conntrack mark values of the traffic of
+	// the gateway routers of the primary user defined networks, offset by their
+	// network ID
+	ctMarkUDNBase = 2
 	// ovnkubeITPMark is the fwmark used for host->ITP=local svc traffic. Note that the fwmark is not a part
 	// of the packet, but just stored by kernel in its memory to track/filter packet. Hence fwmark is lost as
 	// soon as packet exits the host.
@@ -141,6 +145,9 @@ func (npw *nodePortWatcher) updateServiceFlowCache(service *kapi.Service, add, h
 	var errors []error
 
 	isServiceTypeETPLocal := util.ServiceExternalTrafficPolicyLocal(service)
+	// the traffic of a service is only steered for the IP families of its cluster
+	// IPs: OVN has no load balancer for the other family of a single-stack service
+	svcHasIPv4, svcHasIPv6 := util.GetClusterIPFamilies(service)
 
 	actions := fmt.Sprintf("output:%s", npw.ofportPatch)
 
@@ -149,10 +156,10 @@ func (npw *nodePortWatcher) updateServiceFlowCache(service *kapi.Service, add, h
 		protocol := strings.ToLower(string(svcPort.Protocol))
 		if svcPort.NodePort > 0 {
 			flowProtocols := []string{}
-			if config.IPv4Mode {
+			if config.IPv4Mode && svcHasIPv4 {
 				flowProtocols = append(flowProtocols, protocol)
 			}
-			if config.IPv6Mode {
+			if config.IPv6Mode && svcHasIPv6 {
 				flowProtocols = append(flowProtocols, protocol+"6")
 			}
 			for _, flowProtocol := range flowProtocols {
@@ -220,6 +227,11 @@ func (npw *nodePortWatcher) updateServiceFlowCache(service *kapi.Service, add, h
 		// NodePort/Ingress access in the OVS bridge will only ever come from outside of the host
 		for _, ing := range service.Status.LoadBalancer.Ingress {
 			if len(ing.IP) > 0 {
+				if !serviceHasIPFamilyOf(ing.IP, svcHasIPv4, svcHasIPv6) {
+					klog.V(5).Infof("Skipping flows for LoadBalancer ingress IP %s of service %s/%s without a cluster IP of its IP family",
+						ing.IP, service.Namespace, service.Name)
+					continue
+				}
 				if err = npw.createLbAndExternalSvcFlows(service, &svcPort, add, hasLocalHostNetworkEp, protocol, actions, utilnet.ParseIPSloppy(ing.IP).String(), "Ingress"); err != nil {
 					errors = append(errors, err)
 				}
@@ -227,6 +239,11 @@ func (npw *nodePortWatcher) updateServiceFlowCache(service *kapi.Service, add, h
 		}
 		// flows for externalIPs
 		for _, externalIP := range service.Spec.ExternalIPs {
+			if !serviceHasIPFamilyOf(externalIP, svcHasIPv4, svcHasIPv6) {
+				klog.V(5).Infof("Skipping flows for external IP %s of service %s/%s without a cluster IP of its IP family",
+					externalIP, service.Namespace, service.Name)
+				continue
+			}
 			if err = npw.createLbAndExternalSvcFlows(service, &svcPort, add, hasLocalHostNetworkEp, protocol, actions, utilnet.ParseIPSloppy(externalIP).String(), "External"); err != nil {
 				errors = append(errors, err)
 			}
@@ -236,6 +253,20 @@ func (npw *nodePortWatcher) updateServiceFlowCache(service *kapi.Service, add, h
 
 }
 
+// serviceHasIPFamilyOf returns whether the service has a cluster IP of the IP
+// family of ip. Unparsable IPs are let through so that the error is reported
+// when their flows are generated.
+func serviceHasIPFamilyOf(ip string, svcHasIPv4, svcHasIPv6 bool) bool {
+	parsedIP := utilnet.ParseIPSloppy(ip)
+	if parsedIP == nil {
+		return true
+	}
+	if utilnet.IsIPv6(parsedIP) {
+		return svcHasIPv6
+	}
+	return svcHasIPv4
+}
+
 // createLbAndExternalSvcFlows handles managing breth0 gateway flows for ingress traffic towards kubernetes services
 // (externalIP and LoadBalancer types). By default incoming traffic into the node is steered directly into OVN (case3 below).
 //
@@ -555,6 +586,7 @@ func serviceUpdateNotNeeded(old, new *kapi.Service) bool {
 		reflect.DeepEqual(new.Spec.ExternalIPs, old.Spec.ExternalIPs) &&
 		reflect.DeepEqual(new.Spec.ClusterIP, old.Spec.ClusterIP) &&
 		reflect.DeepEqual(new.Spec.ClusterIPs, old.Spec.ClusterIPs) &&
+		reflect.DeepEqual(new.Spec.IPFamilies, old.Spec.IPFamilies) &&
 		reflect.DeepEqual(new.Spec.Type, old.Spec.Type) &&
 		reflect.DeepEqual(new.Status.LoadBalancer.Ingress, old.Status.LoadBalancer.Ingress) &&
 		reflect.DeepEqual(new.Spec.ExternalTrafficPolicy, old.Spec.ExternalTrafficPolicy) &&
@@ -609,7 +641,7 @@ func (npw *nodePortWatcher) UpdateService(old, new *kapi.Service) error {
 
 	if serviceUpdateNotNeeded(old, new) {
 		klog.V(5).Infof("Skipping service update for: %s as change does not apply to any of .Spec.Ports, "+
-			".Spec.ExternalIP, .Spec.ClusterIP, .Spec.ClusterIPs, .Spec.Type, .Status.LoadBalancer.Ingress, "+
+			".Spec.ExternalIP, .Spec.ClusterIP, .Spec.ClusterIPs, .Spec.IPFamilies, .Spec.Type, .Status.LoadBalancer.Ingress, "+
 			".Spec.ExternalTrafficPolicy, .Spec.InternalTrafficPolicy", new.Name)
 		return nil
 	}
@@ -636,6 +668,9 @@ func (npw *nodePortWatcher) UpdateService(old, new *kapi.Service) error {
 			errors = append(errors, err)
 		}
 	}
+	if err = deleteConntrackForRemovedServiceVIPs(old, new); err != nil {
+		errors = append(errors, err)
+	}
 	if err = apierrors.NewAggregate(errors); err != nil {
 		return fmt.Errorf("UpdateService failed for nodePortWatcher: %v", err)
 	}
@@ -657,6 +692,22 @@ func deleteConntrackForServiceVIP(svcVIPs []string, svcPorts []kapi.ServicePort,
 	return nil
 }
 
+// deleteConntrackForRemovedServiceVIPs deletes the conntrack entries of the
+// cluster IPs, external IPs and LoadBalancer IPs of the old service that the
+// new service does not have anymore, like the cluster IP of the IP family
+// removed when the ipFamilyPolicy of the service changes to SingleStack
+func deleteConntrackForRemovedServiceVIPs(old, new *kapi.Service) error {
+	newVIPs := sets.New[string](util.GetClusterIPs(new)...)
+	newVIPs.Insert(util.GetExternalAndLBIPs(new)...)
+	var removedVIPs []string
+	for _, vip := range append(util.GetClusterIPs(old), util.GetExternalAndLBIPs(old)...) {
+		if !newVIPs.Has(vip) {
+			removedVIPs = append(removedVIPs, vip)
+		}
+	}
+	return deleteConntrackForServiceVIP(removedVIPs, old.Spec.Ports, old.Namespace, old.Name)
+}
+
 // deleteConntrackForService deletes the conntrack entries corresponding to the service VIPs of the provided service
 func (npw *nodePortWatcher) deleteConntrackForService(service *kapi.Service) error {
 	// remove conntrack entries for LB VIPs and External IPs
@@ -982,7 +1033,7 @@ func (npwipt *nodePortWatcherIptables) UpdateService(old, new *kapi.Service) err
 	if serviceUpdateNotNeeded(old, new) {
 		klog.V(5).Infof("Skipping service update for: %s as change does not apply to "+
 			"any of .Spec.Ports, .Spec.ExternalIP, .Spec.ClusterIP, .Spec.ClusterIPs,"+
-			" .Spec.Type, .Status.LoadBalancer.Ingress", new.Name)
+			" .Spec.IPFamilies, .Spec.Type, .Status.LoadBalancer.Ingress", new.Name)
 		return nil
 	}
 
@@ -997,6 +1048,9 @@ func (npwipt *nodePortWatcherIptables) UpdateService(old, new *kapi.Service) err
 			errors = append(errors, err)
 		}
 	}
+	if err = deleteConntrackForRemovedServiceVIPs(old, new); err != nil {
+		errors = append(errors, err)
+	}
 	if err = apierrors.NewAggregate(errors); err != nil {
 		return fmt.Errorf("UpdateService failed for nodePortWatcherIptables: %v", err)
 	}
@@ -1058,6 +1112,7 @@ func newGatewayOpenFlowManager(gwBridge, exGWBridge *bridgeConfiguration, subnet
 		exGWFlowCache:         make(map[string][]string),
 		exGWFlowMutex:         sync.Mutex{},
 		flowChan:              make(chan struct{}, 1),
+		udnGateways:           make(map[string]*udnGateway),
 	}
 
 	if err := ofm.updateBridgeFlowCache(subnets, extraIPs); err != nil {
@@ -1259,6 +1314,35 @@ func flowsForDefaultBridge(bridge *bridgeConfiguration, extraIPs []net.IP) ([]st
 				"actions=drop", defaultOpenFlowCookie, ofPortPatch, protoPrefix, protoPrefix, svcCIDR))
 	}
 
+	// table 0, packets coming from Host -> Pod on the layer2 topology of the
+	// default network: the nodes have no management port, the host reaches
+	// the pods through the gateway router like the services
+	if config.Default.Topology == types.Layer2Topology {
+		for _, clusterSubnet := range config.Default.ClusterSubnets {
+			if utilnet.IsIPv4CIDR(clusterSubnet.CIDR) {
+				protoPrefix = "ip"
+				masqIP = config.Gateway.MasqueradeIPs.V4HostMasqueradeIP.String()
+			} else {
+				protoPrefix = "ipv6"
+				masqIP = config.Gateway.MasqueradeIPs.V6HostMasqueradeIP.String()
+			}
+
+			// table 0, Host -> OVN towards the pods, SNAT to special IP
+			dftFlows = append(dftFlows,
+				fmt.Sprintf("cookie=%s, priority=500, in_port=%s, %s, %s_dst=%s,"+
+					"actions=ct(commit,zone=%d,nat(src=%s),table=2)",
+					defaultOpenFlowCookie, ofPortHost, protoPrefix, protoPrefix, clusterSubnet.CIDR,
+					HostMasqCTZone, masqIP))
+
+			// table 0, Reply traffic of the pods to host, coming from OVN, unSNAT
+			dftFlows = append(dftFlows,
+				fmt.Sprintf("cookie=%s, priority=500, in_port=%s, %s, %s_src=%s, %s_dst=%s,"+
+					"actions=ct(zone=%d,nat,table=3)",
+					defaultOpenFlowCookie, ofPortPatch, protoPrefix, protoPrefix, clusterSubnet.CIDR,
+					protoPrefix, masqIP, HostMasqCTZone))
+		}
+	}
+
 	actions := fmt.Sprintf("output:%s", ofPortPatch)
 
 	if ofPortPhys != "" {
@@ -1758,8 +1842,9 @@ func newSharedGateway(nodeName string, subnets []*net.IPNet, gwNextHops []net.IP
 		}
 
 		if config.Gateway.NodeportEnable {
-			if config.OvnKubeNode.Mode == types.NodeModeFull {
-				// (TODO): Internal Traffic Policy is not supported in DPU mode
+			// (TODO): Internal Traffic Policy is not supported in DPU mode, nor
+			// on the layer2 topology, whose nodes have no management port
+			if config.OvnKubeNode.Mode == types.NodeModeFull && len(subnets) > 0 {
 				if err := initSvcViaMgmPortRoutingRules(subnets); err != nil {
 					return err
 				}
@@ -1774,6 +1859,22 @@ func newSharedGateway(nodeName string, subnets []*net.IPNet, gwNextHops []net.IP
 			gw.openflowManager.requestFlowSync()
 		}
 
+		if config.OVNKubernetesFeature.EnableHostPort {
+			klog.Info("Creating Shared Gateway Host Port Watcher")
+			gw.hostPortWatcher, err = newHostPortWatcher(nodeName, gwBridge, gw.openflowManager, watchFactory)
+			if err != nil {
+				return err
+			}
+		}
+
+		if config.OVNKubernetesFeature.EnableEgressIP {
+			klog.Info("Creating Shared Gateway Egress IP Connection Limit Watcher")
+			gw.egressIPConnLimitWatcher, err = newEgressIPConnLimitWatcher(nodeName, gwBridge, gw.openflowManager, watchFactory)
+			if err != nil {
+				return err
+			}
+		}
+
 		if err := addHostMACBindings(gwBridge.bridgeName); err != nil {
 			return fmt.Errorf("failed to add MAC bindings for service routing")
 		}
@@ -0,0 +1,143 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// udnGateway is the gateway router of a primary user defined network on the
+// node. It has the IPs of the node like the gateway router of the default
+// network, but its own localnet port on the shared gateway bridge and the MAC
+// address of its network ID. The connections leaving it are committed with the
+// conntrack mark of the network and its MAC address rewritten to the one of
+// the bridge, so that the replies, sent to the MAC address of the bridge, are
+// steered back to it.
+type udnGateway struct {
+	// patchPort is the patch port created by ovn-controller for the localnet
+	// port of the gateway router
+	patchPort   string
+	ofPortPatch string
+	macAddress  net.HardwareAddr
+	ctMark      string
+}
+
+// newUDNGateway returns the gateway of the network with the given ID on the
+// bridge, ofPortPatch being the ofport of its patch port
+func newUDNGateway(bridge *bridgeConfiguration, netInfo util.NetInfo, networkID int, ofPortPatch string) *udnGateway {
+	return &udnGateway{
+		patchPort:   udnGatewayPatchPort(bridge, netInfo),
+		ofPortPatch: ofPortPatch,
+		macAddress:  util.UDNGatewayRouterHWAddr(networkID),
+		ctMark:      fmt.Sprintf("0x%x", ctMarkUDNBase+networkID),
+	}
+}
+
+// udnGatewayPatchPort returns the name of the patch port created by
+// ovn-controller for the localnet port of the gateway router of the network,
+// of the form patch-<logical_port_name_of_localnet_port>-to-br-int
+func udnGatewayPatchPort(bridge *bridgeConfiguration, netInfo util.NetInfo) string {
+	return "patch-" + netInfo.GetNetworkScopedName(bridge.interfaceID) + "-to-" + config.OvnKubeNode.IntegrationBridge
+}
+
+// addUDNGateway adds the flows of the gateway of the network to the bridge
+func (c *openflowManager) addUDNGateway(netName string, gw *udnGateway) {
+	c.defaultBridge.Lock()
+	defer c.defaultBridge.Unlock()
+	c.udnGateways[netName] = gw
+	c.updateFlowCacheEntry("UDN", flowsForUDNGateways(c.defaultBridge, c.udnGateways))
+	c.requestFlowSync()
+}
+
+// deleteUDNGateway removes the flows of the gateway of the network from the
+// bridge
+func (c *openflowManager) deleteUDNGateway(netName string) {
+	c.defaultBridge.Lock()
+	defer c.defaultBridge.Unlock()
+	if _, ok := c.udnGateways[netName]; !ok {
+		return
+	}
+	delete(c.udnGateways, netName)
+	c.updateFlowCacheEntry("UDN", flowsForUDNGateways(c.defaultBridge, c.udnGateways))
+	c.requestFlowSync()
+}
+
+// flowsForUDNGateways returns the flows of the gateways of the primary user
+// defined networks on the bridge, by network name
+func flowsForUDNGateways(bridge *bridgeConfiguration, udnGateways map[string]*udnGateway) []string {
+	ofPortPhys := bridge.ofPortPhys
+	if ofPortPhys == "" || len(udnGateways) == 0 {
+		return nil
+	}
+	bridgeMacAddress := bridge.macAddress.String()
+	netNames := make([]string, 0, len(udnGateways))
+	for netName := range udnGateways {
+		netNames = append(netNames, netName)
+	}
+	sort.Strings(netNames)
+
+	var ipPrefixes []string
+	if config.IPv4Mode {
+		ipPrefixes = append(ipPrefixes, "ip")
+	}
+	if config.IPv6Mode {
+		ipPrefixes = append(ipPrefixes, "ipv6")
+	}
+	var flows []string
+	// the neighbor replies to the bridge are also sent to the gateway routers,
+	// which resolve their next hops with the MAC address of the bridge
+	replyActions := []string{"output:" + bridge.ofPortPatch, "output:" + bridge.ofPortHost}
+	for _, netName := range netNames {
+		gw := udnGateways[netName]
+		replyActions = append(replyActions, fmt.Sprintf("mod_dl_dst=%s,output:%s", gw.macAddress, gw.ofPortPatch))
+		for _, ipPrefix := range ipPrefixes {
+			// table 0, packets coming from the gateway router headed externally. Commit connections with
+			// the ct_mark of the network so that reverse direction goes back to the gateway router.
+			flows = append(flows,
+				fmt.Sprintf("cookie=%s, priority=100, in_port=%s, %s, "+
+					"actions=ct(commit, zone=%d, exec(set_field:%s->ct_mark)), mod_dl_src=%s, output:%s",
+					defaultOpenFlowCookie, gw.ofPortPatch, ipPrefix, config.Default.ConntrackZone, gw.ctMark,
+					bridgeMacAddress, ofPortPhys))
+			// table 1, established and related connections in zone 64000 with the ct_mark of the network
+			// go to the gateway router
+			for _, ctState := range []string{"+trk+est", "+trk+rel"} {
+				flows = append(flows,
+					fmt.Sprintf("cookie=%s, priority=100, table=1, %s, ct_state=%s, ct_mark=%s, "+
+						"actions=mod_dl_dst=%s, output:%s",
+						defaultOpenFlowCookie, ipPrefix, ctState, gw.ctMark, gw.macAddress, gw.ofPortPatch))
+			}
+		}
+		// table 0, the gateway router resolves its next hops with the MAC address of the bridge
+		if config.IPv4Mode {
+			flows = append(flows,
+				fmt.Sprintf("cookie=%s, priority=110, in_port=%s, arp, arp_op=1, "+
+					"actions=mod_dl_src=%s, set_field:%s->arp_sha, output:%s",
+					defaultOpenFlowCookie, gw.ofPortPatch, bridgeMacAddress, bridgeMacAddress, ofPortPhys))
+		}
+		if config.IPv6Mode {
+			flows = append(flows,
+				fmt.Sprintf("cookie=%s, priority=110, in_port=%s, icmp6, icmp_type=135, icmp_code=0, "+
+					"actions=mod_dl_src=%s, set_field:%s->nd_sll, output:%s",
+					defaultOpenFlowCookie, gw.ofPortPatch, bridgeMacAddress, bridgeMacAddress, ofPortPhys))
+		}
+		// table 0, drop the rest of the traffic of the gateway router, its neighbor replies would advertise
+		// the IPs of the node with the MAC address of the network
+		flows = append(flows,
+			fmt.Sprintf("cookie=%s, priority=10, in_port=%s, actions=drop", defaultOpenFlowCookie, gw.ofPortPatch))
+	}
+	if config.IPv4Mode {
+		flows = append(flows,
+			fmt.Sprintf("cookie=%s, priority=11, table=0, in_port=%s, dl_dst=%s, arp, arp_op=2, actions=%s",
+				defaultOpenFlowCookie, ofPortPhys, bridgeMacAddress, strings.Join(replyActions, ",")))
+	}
+	if config.IPv6Mode {
+		flows = append(flows,
+			fmt.Sprintf("cookie=%s, priority=11, table=0, in_port=%s, dl_dst=%s, icmp6, icmp_type=136, actions=%s",
+				defaultOpenFlowCookie, ofPortPhys, bridgeMacAddress, strings.Join(replyActions, ",")))
+	}
+	return flows
+}
@@ -0,0 +1,79 @@
+package node
+
+import (
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shared gateway of the primary user defined networks", func() {
+	var (
+		bridge *bridgeConfiguration
+		ofm    *openflowManager
+	)
+
+	BeforeEach(func() {
+		Expect(config.PrepareTestConfig()).To(Succeed())
+		config.IPv4Mode = true
+		config.IPv6Mode = false
+		bridge = &bridgeConfiguration{
+			bridgeName:  "breth0",
+			interfaceID: "breth0_node1",
+			macAddress:  ovntest.MustParseMAC("7e:57:f8:f0:3c:49"),
+			ofPortPatch: "2",
+			ofPortPhys:  "1",
+			ofPortHost:  ovsLocalPort,
+		}
+		ofm = &openflowManager{
+			defaultBridge: bridge,
+			flowCache:     map[string][]string{},
+			flowChan:      make(chan struct{}, 1),
+			udnGateways:   map[string]*udnGateway{},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(config.PrepareTestConfig()).To(Succeed())
+	})
+
+	It("steers the traffic of the gateway router of the network back to it", func() {
+		netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "blue"},
+			Topology: types.Layer3Topology,
+			Subnets:  "10.10.0.0/16/24",
+			Role:     types.NetworkRolePrimary,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		gw := newUDNGateway(bridge, netInfo, 3, "5")
+		Expect(gw.patchPort).To(Equal("patch-blue_breth0_node1-to-br-int"))
+		Expect(gw.macAddress.String()).To(Equal("0a:5a:00:00:00:03"))
+		Expect(gw.ctMark).To(Equal("0x5"))
+
+		ofm.addUDNGateway("blue", gw)
+		Expect(ofm.flowCache["UDN"]).To(ConsistOf(
+			"cookie=0xdeff105, priority=100, in_port=5, ip, actions=ct(commit, zone=64000, "+
+				"exec(set_field:0x5->ct_mark)), mod_dl_src=7e:57:f8:f0:3c:49, output:1",
+			"cookie=0xdeff105, priority=100, table=1, ip, ct_state=+trk+est, ct_mark=0x5, "+
+				"actions=mod_dl_dst=0a:5a:00:00:00:03, output:5",
+			"cookie=0xdeff105, priority=100, table=1, ip, ct_state=+trk+rel, ct_mark=0x5, "+
+				"actions=mod_dl_dst=0a:5a:00:00:00:03, output:5",
+			"cookie=0xdeff105, priority=110, in_port=5, arp, arp_op=1, actions=mod_dl_src=7e:57:f8:f0:3c:49, "+
+				"set_field:7e:57:f8:f0:3c:49->arp_sha, output:1",
+			"cookie=0xdeff105, priority=10, in_port=5, actions=drop",
+			"cookie=0xdeff105, priority=11, table=0, in_port=1, dl_dst=7e:57:f8:f0:3c:49, arp, arp_op=2, "+
+				"actions=output:2,output:LOCAL,mod_dl_dst=0a:5a:00:00:00:03,output:5",
+		))
+		Eventually(ofm.flowChan).Should(Receive())
+
+		// the flows are removed with the network
+		ofm.deleteUDNGateway("blue")
+		Expect(ofm.flowCache["UDN"]).To(BeEmpty())
+	})
+})
@@ -0,0 +1,142 @@
+//go:build linux
+// +build linux
+
+package node
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/iprulemanager"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node/routemanager"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// healthCheckRouteTable is the routing table of the routes to the cluster
+	// subnets through the health check port
+	healthCheckRouteTable = 250
+	// healthCheckRulePriority is the priority of the ip rules steering the
+	// traffic sourced from the health check port addresses to its routing
+	// table, ahead of those of egress services and egress IPs
+	healthCheckRulePriority = 4000
+)
+
+// createNodeHealthCheckPort creates the dedicated health check port of the
+// node, an OVS internal port on a per node subnet separate from the host
+// subnets. The traffic the node originates from the health check port
+// addresses, like that of the health checks and the monitoring agents bound to
+// them, reaches the pods through this port instead of the management port.
+func createNodeHealthCheckPort(node *kapi.Node, hostSubnets []*net.IPNet, routeManager *routemanager.Controller,
+	ruleManager *iprulemanager.Controller) error {
+	subnets, err := util.ParseNodeHealthCheckSubnets(node)
+	if err != nil {
+		return fmt.Errorf("failed to get the health check subnets of node %s: %w", node.Name, err)
+	}
+
+	// the MAC addresses are derived from the first addresses, as done by
+	// ovnkube-controller for the logical ports
+	hcIfAddr := util.GetNodeManagementIfAddr(subnets[0])
+	macAddress := util.IPAddrToHWAddr(hcIfAddr.IP)
+	var routerMAC net.HardwareAddr
+	for _, hostSubnet := range hostSubnets {
+		routerMAC = util.IPAddrToHWAddr(util.GetNodeGatewayIfAddr(hostSubnet).IP)
+		if !utilnet.IsIPv6CIDR(hostSubnet) {
+			break
+		}
+	}
+
+	stdout, stderr, err := util.RunOVSVsctl(
+		"--", "--may-exist", "add-port", config.OvnKubeNode.IntegrationBridge, types.K8sHealthCheckIntfName,
+		"--", "set", "interface", types.K8sHealthCheckIntfName,
+		"type=internal", "mtu_request="+fmt.Sprintf("%d", config.Default.MTU),
+		fmt.Sprintf("mac=%s", strings.ReplaceAll(macAddress.String(), ":", "\\:")),
+		"external-ids:iface-id="+types.HealthCheckPortPrefix+node.Name)
+	if err != nil {
+		return fmt.Errorf("failed to add the health check port to the integration bridge, stdout: %q, stderr: %q, error: %w",
+			stdout, stderr, err)
+	}
+
+	link, err := util.LinkSetUp(types.K8sHealthCheckIntfName)
+	if err != nil {
+		return err
+	}
+
+	for _, subnet := range subnets {
+		isIPv6 := utilnet.IsIPv6CIDR(subnet)
+		ifAddr := util.GetNodeManagementIfAddr(subnet)
+		gwIP := util.GetNodeGatewayIfAddr(subnet).IP
+		if exists, err := util.LinkAddrExist(link, ifAddr); err != nil {
+			return err
+		} else if !exists {
+			if err := util.LinkAddrAdd(link, ifAddr, 0); err != nil {
+				return err
+			}
+		}
+
+		var routes []routemanager.Route
+		for _, clusterSubnet := range config.Default.ClusterSubnets {
+			if utilnet.IsIPv6CIDR(clusterSubnet.CIDR) != isIPv6 {
+				continue
+			}
+			subnetCopy := *clusterSubnet.CIDR
+			routes = append(routes, routemanager.Route{
+				GwIP:   gwIP,
+				Subnet: &subnetCopy,
+				MTU:    config.Default.RoutableMTU,
+				Table:  healthCheckRouteTable,
+			})
+		}
+		routeManager.Add(routemanager.RoutesPerLink{Link: link, Routes: routes})
+
+		// the router IP is resolved like on the management port, see
+		// setupManagementPortIPFamilyConfig
+		if exists, err := util.LinkNeighExists(link, gwIP, routerMAC); err != nil {
+			return err
+		} else if !exists {
+			if err := util.LinkNeighAdd(link, gwIP, routerMAC); err != nil {
+				return err
+			}
+		}
+
+		rule := *netlink.NewRule()
+		rule.Table = healthCheckRouteTable
+		rule.Priority = healthCheckRulePriority
+		rule.Family = netlink.FAMILY_V4
+		if isIPv6 {
+			rule.Family = netlink.FAMILY_V6
+		}
+		rule.Src = &net.IPNet{IP: ifAddr.IP, Mask: util.GetIPFullMask(ifAddr.IP)}
+		if err := ruleManager.Add(rule); err != nil {
+			return fmt.Errorf("failed to add the ip rule of the health check port address %s: %w", ifAddr.IP, err)
+		}
+	}
+
+	klog.Infof("Created the health check port %s with the subnets %s", types.K8sHealthCheckIntfName,
+		util.JoinIPNets(subnets, ","))
+	return nil
+}
+
+// deleteNodeHealthCheckPort deletes the health check port, if any, left over
+// from when the health check subnets were enabled
+func deleteNodeHealthCheckPort() error {
+	if _, err := util.GetNetLinkOps().LinkByName(types.K8sHealthCheckIntfName); err != nil {
+		if util.GetNetLinkOps().IsLinkNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+	_, stderr, err := util.RunOVSVsctl("--if-exists", "del-port", config.OvnKubeNode.IntegrationBridge, types.K8sHealthCheckIntfName)
+	if err != nil {
+		return fmt.Errorf("failed to delete the health check port, stderr: %q, error: %w", stderr, err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +38,7 @@ type Controller struct {
 	chains     []Chain
 	iptV4      iptables.Interface
 	iptV6      iptables.Interface
+	exec       kexec.Interface
 }
 
 // NewController creates a controller to manage chains and rules
@@ -47,6 +49,7 @@ func NewController() *Controller {
 		mu:         &sync.Mutex{},
 		iptV4:      iptables.New(kexec.New(), iptables.ProtocolIPv4),
 		iptV6:      iptables.New(kexec.New(), iptables.ProtocolIPv6),
+		exec:       kexec.New(),
 	}
 }
 
@@ -107,6 +110,78 @@ func (c *Controller) FlushChain(table iptables.Table, chain iptables.Chain, prot
 	return c.reconcile()
 }
 
+// DeleteChain stops managing a chain, then flushes and deletes it. The rules jumping to the chain must be removed
+// beforehand.
+func (c *Controller) DeleteChain(table iptables.Table, chain iptables.Chain, proto iptables.Protocol) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := Chain{
+		Table: table,
+		Chain: chain,
+		Proto: proto,
+	}
+	delete(c.chainRules, ch)
+	chains := make([]Chain, 0, len(c.chains))
+	for _, existingChain := range c.chains {
+		if existingChain != ch {
+			chains = append(chains, existingChain)
+		}
+	}
+	c.chains = chains
+	ipt := c.iptV4
+	if proto == iptables.ProtocolIPv6 {
+		ipt = c.iptV6
+	}
+	return execIPTablesWithRetry(func() error {
+		if _, err := ipt.ChainExists(table, chain); err != nil {
+			if isResourceError(err) {
+				return err
+			}
+			// iptables fails listing a chain that doesn't exist
+			return nil
+		}
+		if err := ipt.FlushChain(table, chain); err != nil {
+			return fmt.Errorf("failed to flush chain %s in table %s: %w", chain, table, err)
+		}
+		if err := ipt.DeleteChain(table, chain); err != nil {
+			return fmt.Errorf("failed to delete chain %s in table %s: %w", chain, table, err)
+		}
+		return nil
+	})
+}
+
+// GetChains returns the chains of a table
+func (c *Controller) GetChains(table iptables.Table, proto iptables.Protocol) ([]iptables.Chain, error) {
+	ipt := c.iptV4
+	if proto == iptables.ProtocolIPv6 {
+		ipt = c.iptV6
+	}
+	buf := bytes.NewBuffer(nil)
+	err := execIPTablesWithRetry(func() error {
+		if err := ipt.SaveInto(table, buf); err != nil {
+			return fmt.Errorf("failed to retrieve iptables table %s: %v", table, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseChains(buf.String()), nil
+}
+
+// parseChains returns the chains declared in the output of 'iptables-save -t <table>'
+func parseChains(out string) []iptables.Chain {
+	chains := make([]iptables.Chain, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, ":") {
+			continue
+		}
+		// cleave off the policy and counters of ':${chain_name} ${policy} [packets:bytes]'
+		chains = append(chains, iptables.Chain(strings.Fields(line[1:])[0]))
+	}
+	return chains
+}
+
 func (c *Controller) DeleteRule(table iptables.Table, chain iptables.Chain, proto iptables.Protocol, ruleArg RuleArg) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -189,6 +264,38 @@ func (c *Controller) GetIPv6ChainRuleArgs(table iptables.Table, chain iptables.C
 	return getChainRuleArgs(c.iptV6, table, chain)
 }
 
+// GetChainPacketCount returns the total number of packets that matched the rules of a chain
+func (c *Controller) GetChainPacketCount(table iptables.Table, chain iptables.Chain, proto iptables.Protocol) (uint64, error) {
+	cmd := "iptables"
+	if proto == iptables.ProtocolIPv6 {
+		cmd = "ip6tables"
+	}
+	out, err := c.exec.Command(cmd, "-w", "-t", string(table), "-L", string(chain), "-v", "-x", "-n").CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list chain %s in table %s: %v (%s)", chain, table, err, string(out))
+	}
+	return parseChainPacketCount(string(out))
+}
+
+// parseChainPacketCount sums the packet counters of the rules listed by
+// 'iptables -L <chain> -v -x -n'
+func parseChainPacketCount(out string) (uint64, error) {
+	var count uint64
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		// skip the chain and the column headers
+		if len(fields) == 0 || fields[0] == "Chain" || fields[0] == "pkts" {
+			continue
+		}
+		packets, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse the packet counter of rule %q: %v", line, err)
+		}
+		count += packets
+	}
+	return count, nil
+}
+
 func getChainRuleArgs(ipt iptables.Interface, table iptables.Table, chain iptables.Chain) ([]RuleArg, error) {
 	buf := bytes.NewBuffer(nil)
 	err := execIPTablesWithRetry(func() error {
@@ -91,6 +91,39 @@ var _ = ginkgo.Describe("IPTables Manager", func() {
 		})
 	})
 
+	ginkgo.Context("Delete chain", func() {
+		ginkgo.It("flushes and deletes the chain and stops managing it", func() {
+			ruleArg := RuleArg{[]string{"-s", "10.10.10.50", "-j", "REJECT"}}
+			gomega.Expect(testNS.Do(func(netNS ns.NetNS) error {
+				if err := c.OwnChain(utiliptables.TableFilter, testChainName, utiliptables.ProtocolIPv4); err != nil {
+					return err
+				}
+				return c.EnsureRules(utiliptables.TableFilter, testChainName, utiliptables.ProtocolIPv4, []RuleArg{ruleArg})
+			})).Should(gomega.Succeed())
+			gomega.Expect(testNS.Do(func(netNS ns.NetNS) error {
+				return c.DeleteChain(utiliptables.TableFilter, testChainName, utiliptables.ProtocolIPv4)
+			})).Should(gomega.Succeed())
+			// the chain is not recreated by the reconciliation
+			time.Sleep(100 * time.Millisecond)
+			gomega.Expect(testNS.Do(func(netNS ns.NetNS) error {
+				chains, err := c.GetChains(utiliptables.TableFilter, utiliptables.ProtocolIPv4)
+				if err != nil {
+					return err
+				}
+				for _, chain := range chains {
+					if chain == testChainName {
+						return fmt.Errorf("expect not to find chain %s", testChainName)
+					}
+				}
+				return nil
+			})).Should(gomega.Succeed())
+			// deleting a chain that doesn't exist succeeds
+			gomega.Expect(testNS.Do(func(netNS ns.NetNS) error {
+				return c.DeleteChain(utiliptables.TableFilter, testChainName, utiliptables.ProtocolIPv4)
+			})).Should(gomega.Succeed())
+		})
+	})
+
 	ginkgo.Context("Ensure rules", func() {
 		ruleArg := RuleArg{[]string{"-s", "10.10.10.50", "-j", "MARK", "--set-mark", "1000"}}
 		testRuleArgs := []RuleArg{ruleArg,
@@ -171,3 +204,35 @@ func commandExists(cmd string) bool {
 	_, err := exec.LookPath(cmd)
 	return err == nil
 }
+
+var _ = ginkgo.Describe("IPTables chain packet count", func() {
+	ginkgo.It("sums the packet counters of the rules of the chain", func() {
+		out := `Chain OVN-KUBE-EIP-LIMIT-1a2b3c4d (1 references)
+    pkts      bytes target     prot opt in     out     source               destination
+      12      720 REJECT     all  --  *      *       0.0.0.0/0            0.0.0.0/0            #conn src/0 > 10 reject-with icmp-port-unreachable
+       3      180 REJECT     all  --  *      *       0.0.0.0/0            0.0.0.0/0            #conn src/0 > 20 reject-with icmp-port-unreachable
+`
+		count, err := parseChainPacketCount(out)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(count).To(gomega.BeEquivalentTo(15))
+
+		count, err = parseChainPacketCount("Chain EMPTY (0 references)\n    pkts      bytes target     prot opt in     out     source               destination\n")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(count).To(gomega.BeZero())
+	})
+})
+
+var _ = ginkgo.Describe("IPTables chains", func() {
+	ginkgo.It("parses the chains of a table", func() {
+		out := `# Generated by iptables-save v1.8.7 on Fri Oct 16 16:06:39 2026
+*filter
+:INPUT ACCEPT [0:0]
+:FORWARD DROP [12:720]
+:OVN-KUBE-EIP-LIMIT-1a2b3c4d - [0:0]
+-A FORWARD -j OVN-KUBE-EGRESS-IP-CONNLIMIT
+COMMIT
+`
+		gomega.Expect(parseChains(out)).To(gomega.Equal([]utiliptables.Chain{"INPUT", "FORWARD", "OVN-KUBE-EIP-LIMIT-1a2b3c4d"}))
+		gomega.Expect(parseChains("")).To(gomega.BeEmpty())
+	})
+})
@@ -2,11 +2,13 @@ package linkmanager
 
 import (
 	"fmt"
+	"net"
 	"net/netip"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	"k8s.io/klog/v2"
@@ -29,16 +31,34 @@ type Controller struct {
 	ipv4Enabled bool
 	ipv6Enabled bool
 	store       map[string][]netlink.Addr
+	// addressLabel returns the label of the addresses the controller owns on a link
+	addressLabel func(linkName string) string
+	// announcements holds the channel stopping the announcements of each address added to a link, by link and IP
+	announcements map[string]chan struct{}
 }
 
 // NewController creates a controller to manage linux network interfaces
 func NewController(name string, v4, v6 bool) *Controller {
+	return newController(name, v4, v6, GetAssignedAddressLabel)
+}
+
+// NewLoadBalancerController creates a controller to manage the IPs of the
+// LoadBalancer services on linux network interfaces. Its addresses are
+// labelled apart from the assigned ones so that each controller only removes
+// its own stale addresses.
+func NewLoadBalancerController(name string, v4, v6 bool) *Controller {
+	return newController(name, v4, v6, util.GetLoadBalancerAddressLabel)
+}
+
+func newController(name string, v4, v6 bool, addressLabel func(linkName string) string) *Controller {
 	return &Controller{
-		mu:          &sync.Mutex{},
-		name:        name,
-		ipv4Enabled: v4,
-		ipv6Enabled: v6,
-		store:       make(map[string][]netlink.Addr, 0),
+		mu:            &sync.Mutex{},
+		name:          name,
+		ipv4Enabled:   v4,
+		ipv6Enabled:   v6,
+		store:         make(map[string][]netlink.Addr, 0),
+		addressLabel:  addressLabel,
+		announcements: map[string]chan struct{}{},
 	}
 }
 
@@ -50,6 +70,11 @@ func (c *Controller) Run(stopCh <-chan struct{}, syncPeriod time.Duration) {
 	for {
 		select {
 		case <-stopCh:
+			c.mu.Lock()
+			for key := range c.announcements {
+				c.stopAnnouncement(key)
+			}
+			c.mu.Unlock()
 			return
 		case <-ticker.C:
 			c.mu.Lock()
@@ -78,7 +103,7 @@ func (c *Controller) AddAddress(address netlink.Addr) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	// overwrite label to the name of this component in-order to aid address ownership. Label must start with link name.
-	address.Label = GetAssignedAddressLabel(link.Attrs().Name)
+	address.Label = c.addressLabel(link.Attrs().Name)
 	c.addAddressToStore(link.Attrs().Name, address)
 	c.reconcile()
 	return nil
@@ -130,7 +155,8 @@ func (c *Controller) reconcile() {
 		// cleanup any stale addresses on the link
 		for _, foundAddress := range foundAddresses {
 			// we label any address we create, so if we aren't managing a link, we must remove any stale addresses
-			if foundAddress.Label == GetAssignedAddressLabel(linkName) && !containsAddress(wantedAddresses, foundAddress) {
+			if foundAddress.Label == c.addressLabel(linkName) && !containsAddress(wantedAddresses, foundAddress) {
+				c.stopAnnouncement(announcementKey(linkName, foundAddress.IP))
 				if err := util.GetNetLinkOps().AddrDel(link, &foundAddress); err != nil && !util.GetNetLinkOps().IsLinkNotFoundError(err) {
 					klog.Errorf("Link Network Manager: failed to delete address %q from link %q",
 						foundAddress.String(), linkName)
@@ -152,19 +178,67 @@ func (c *Controller) reconcile() {
 			if err = util.GetNetLinkOps().AddrAdd(link, &addressWanted); err != nil {
 				klog.Errorf("Link manager: failed to add address %q to link %q: %v", addressWanted.String(), linkName, err)
 			}
-			// For IPv4, use arping to try to update other hosts ARP caches, in case this IP was
-			// previously active on another node
-			if addressWanted.IP.To4() != nil {
-				if err = arping.GratuitousArpOverIfaceByName(addressWanted.IP, linkName); err != nil {
-					klog.Errorf("Failed to send a GARP for IP %s over interface %s: %v", addressWanted.IP.String(),
-						linkName, err)
-				}
-			}
+			// Try to update other hosts neighbor caches, in case this IP was previously active on another node
+			c.startAnnouncement(addressWanted.IP, linkName)
 			klog.Infof("Link manager completed adding address %s to link %s", addressWanted, linkName)
 		}
 	}
 }
 
+// startAnnouncement announces the IP over the link in the background, until
+// the announcements are done or the IP is removed from the link
+func (c *Controller) startAnnouncement(ip net.IP, linkName string) {
+	key := announcementKey(linkName, ip)
+	c.stopAnnouncement(key)
+	stopCh := make(chan struct{})
+	c.announcements[key] = stopCh
+	go func() {
+		announceAddress(ip, linkName, stopCh)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.announcements[key] == stopCh {
+			delete(c.announcements, key)
+		}
+	}()
+}
+
+// stopAnnouncement stops the announcements of the IP of the link, if any
+func (c *Controller) stopAnnouncement(key string) {
+	if stopCh, ok := c.announcements[key]; ok {
+		close(stopCh)
+		delete(c.announcements, key)
+	}
+}
+
+func announcementKey(linkName string, ip net.IP) string {
+	return linkName + "/" + ip.String()
+}
+
+// announceAddress sends the configured number of GARPs (IPv4) or unsolicited neighbor advertisements (IPv6)
+// for the IP over the link, at the configured interval, until stopCh is closed
+func announceAddress(ip net.IP, linkName string, stopCh <-chan struct{}) {
+	interval := time.Duration(config.OVNKubernetesFeature.GARPInterval) * time.Millisecond
+	for i := 0; i < config.OVNKubernetesFeature.GARPCount; i++ {
+		if i > 0 {
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(interval):
+			}
+		}
+		if ip.To4() != nil {
+			if err := arping.GratuitousArpOverIfaceByName(ip, linkName); err != nil {
+				klog.Errorf("Failed to send a GARP for IP %s over interface %s: %v", ip.String(), linkName, err)
+			}
+		} else {
+			if err := util.UnsolicitedNeighborAdvertisementOverIfaceByName(ip, linkName); err != nil {
+				klog.Errorf("Failed to send an unsolicited neighbor advertisement for IP %s over interface %s: %v",
+					ip.String(), linkName, err)
+			}
+		}
+	}
+}
+
 func (c *Controller) addAddressToStore(linkName string, newAddress netlink.Addr) {
 	addressesSaved, found := c.store[linkName]
 	if !found {
@@ -189,7 +263,9 @@ func (c *Controller) delAddressFromStore(linkName string, address netlink.Addr)
 	temp := addressesSaved[:0]
 	for _, addressSaved := range addressesSaved {
 		if !addressSaved.Equal(address) {
-			temp = append(temp, address)
+			temp = append(temp, addressSaved)
+		} else {
+			c.stopAnnouncement(announcementKey(linkName, addressSaved.IP))
 		}
 	}
 	c.store[linkName] = temp
@@ -200,6 +276,11 @@ func GetAssignedAddressLabel(linkName string) string {
 	return fmt.Sprintf("%sovn", linkName)
 }
 
+// isManagedAddressLabel returns whether the label is the one of the addresses assigned to the link by a link manager
+func isManagedAddressLabel(label, linkName string) bool {
+	return label == GetAssignedAddressLabel(linkName) || label == util.GetLoadBalancerAddressLabel(linkName)
+}
+
 // GetExternallyAvailableAddressesExcludeAssigned gets all addresses assigned on an interface with the following characteristics:
 // Must be up
 // Address must have scope universe
@@ -214,7 +295,7 @@ func GetExternallyAvailableAddressesExcludeAssigned(link netlink.Link, v4, v6 bo
 	}
 	tmp := addresses[:0]
 	for _, address := range addresses {
-		if address.Label == GetAssignedAddressLabel(link.Attrs().Name) {
+		if isManagedAddressLabel(address.Label, link.Attrs().Name) {
 			continue
 		}
 		tmp = append(tmp, address)
@@ -272,7 +353,7 @@ func GetExternallyAvailablePrefixesExcludeAssigned(link netlink.Link, v4, v6 boo
 				return nil, fmt.Errorf("failed to understand if address (%s) is managed or not by link with index %d: %v",
 					address.String(), address.LinkIndex, err)
 			}
-			if address.Label == GetAssignedAddressLabel(link.Attrs().Name) {
+			if isManagedAddressLabel(address.Label, link.Attrs().Name) {
 				continue
 			}
 		}
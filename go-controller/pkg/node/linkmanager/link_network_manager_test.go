@@ -0,0 +1,48 @@
+package linkmanager
+
+import (
+	"net"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+var _ = ginkgo.Describe("Link network manager announcements", func() {
+	var c *Controller
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		// the announcements are not sent over the missing link, and the next one is far enough not to be sent
+		config.OVNKubernetesFeature.GARPCount = 2
+		config.OVNKubernetesFeature.GARPInterval = int(time.Hour / time.Millisecond)
+		c = NewController("test", true, true)
+	})
+
+	ginkgo.It("stops the announcements of an address when it is removed", func() {
+		address := netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP("192.168.126.101"), Mask: net.CIDRMask(24, 32)}}
+		c.mu.Lock()
+		c.addAddressToStore("missing0", address)
+		c.startAnnouncement(address.IP, "missing0")
+		stopCh := c.announcements["missing0/192.168.126.101"]
+		gomega.Expect(stopCh).NotTo(gomega.BeNil())
+		c.delAddressFromStore("missing0", address)
+		gomega.Expect(c.announcements).To(gomega.BeEmpty())
+		c.mu.Unlock()
+		gomega.Expect(stopCh).To(gomega.BeClosed())
+	})
+
+	ginkgo.It("restarts the announcements of an address added again", func() {
+		ip := net.ParseIP("192.168.126.101")
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.startAnnouncement(ip, "missing0")
+		stopCh := c.announcements["missing0/192.168.126.101"]
+		c.startAnnouncement(ip, "missing0")
+		gomega.Expect(stopCh).To(gomega.BeClosed())
+		gomega.Expect(c.announcements).To(gomega.HaveLen(1))
+	})
+})
@@ -0,0 +1,46 @@
+package node
+
+import (
+	"time"
+
+	kapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni"
+)
+
+// localStatePruneInterval is the interval the cached state of the pods no
+// longer on the node is dropped at
+const localStatePruneInterval = 5 * time.Minute
+
+// AdoptCNIServer makes the controller use the given CNI server, already
+// started to plumb the pods from the local state while the apiserver was
+// unreachable, instead of starting its own. Must be called before Start.
+func (nc *DefaultNodeNetworkController) AdoptCNIServer(server *cni.Server) {
+	nc.cniServer = server
+}
+
+// pruneLocalState drops the cached state of the pods no longer on the node,
+// or recreated since they were plumbed. The informer cache of the pods is only
+// trusted once synced with the apiserver, so nothing is pruned while it is
+// unreachable.
+func (nc *DefaultNodeNetworkController) pruneLocalState() {
+	if !nc.watchFactory.LocalPodInformer().HasSynced() {
+		return
+	}
+	pruned, err := nc.localState.Prune(func(cached *kapi.Pod) bool {
+		pod, err := nc.watchFactory.GetPod(cached.Namespace, cached.Name)
+		if err != nil {
+			// keep the state on errors other than the pod being gone
+			return !kerrors.IsNotFound(err)
+		}
+		return pod.UID == cached.UID
+	})
+	if err != nil {
+		klog.Warningf("Failed to prune the local state of the pods: %v", err)
+	}
+	if pruned > 0 {
+		klog.Infof("Dropped the local state of %d pods no longer on node %s", pruned, nc.name)
+	}
+}
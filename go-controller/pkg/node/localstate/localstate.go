@@ -0,0 +1,174 @@
+// Package localstate caches on the node the state ovnkube-node needs to plumb
+// the pods of the node while the apiserver is unreachable, like after a reboot
+// of an edge node with an intermittent link to the control plane.
+package localstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+const podsDir = "pods"
+
+// pod is the cached state of a pod: its identity and its annotations, which
+// hold everything needed to plumb its interfaces
+type pod struct {
+	Namespace   string            `json:"namespace"`
+	Name        string            `json:"name"`
+	UID         string            `json:"uid"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Store caches the state of the pods of the node in a directory, one file per
+// pod, that must survive the reboots of the node
+type Store struct {
+	dir  string
+	lock sync.Mutex
+}
+
+// NewStore returns a store caching the state in the given directory, created
+// if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, podsDir), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the local state directory %s: %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) podPath(namespace, name string) string {
+	// namespaces and pod names are DNS labels and subdomains, without '_'
+	return filepath.Join(s.dir, podsDir, namespace+"_"+name+".json")
+}
+
+// SavePod caches the annotations of the pod, replacing the previous state of
+// the pod if any
+func (s *Store) SavePod(p *corev1.Pod) error {
+	data, err := json.Marshal(&pod{
+		Namespace:   p.Namespace,
+		Name:        p.Name,
+		UID:         string(p.UID),
+		Annotations: p.Annotations,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	path := s.podPath(p.Namespace, p.Name)
+	// write to a temporary file first and rename it so that a crash or a
+	// reboot never leaves a partial state behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file of pod %s/%s: %w", p.Namespace, p.Name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the state of pod %s/%s: %w", p.Namespace, p.Name, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync the state of pod %s/%s: %w", p.Namespace, p.Name, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close the state of pod %s/%s: %w", p.Namespace, p.Name, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// GetPod returns the pod as cached, with only its namespace, name, UID and
+// annotations set. A NotFound error is returned if the pod is not cached.
+func (s *Store) GetPod(namespace, name string) (*corev1.Pod, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, err := os.ReadFile(s.podPath(namespace, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, apierrors.NewNotFound(corev1.Resource("pods"), namespace+"/"+name)
+		}
+		return nil, err
+	}
+	var p pod
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse the cached state of pod %s/%s: %w", namespace, name, err)
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   p.Namespace,
+			Name:        p.Name,
+			UID:         ktypes.UID(p.UID),
+			Annotations: p.Annotations,
+		},
+	}, nil
+}
+
+// DeletePod drops the cached state of the pod, if any
+func (s *Store) DeletePod(namespace, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := os.Remove(s.podPath(namespace, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ListPods returns all the cached pods, as returned by GetPod
+func (s *Store) ListPods() ([]*corev1.Pod, error) {
+	s.lock.Lock()
+	entries, err := os.ReadDir(filepath.Join(s.dir, podsDir))
+	s.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		namespace, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".json"), "_")
+		if !ok {
+			continue
+		}
+		p, err := s.GetPod(namespace, name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		pods = append(pods, p)
+	}
+	return pods, nil
+}
+
+// Prune drops the cached state of the pods for which current returns false,
+// typically the pods no longer on the node, or recreated with a different UID,
+// once the apiserver is reachable again. It returns the number of pods
+// dropped.
+func (s *Store) Prune(current func(cached *corev1.Pod) bool) (int, error) {
+	pods, err := s.ListPods()
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, p := range pods {
+		if current(p) {
+			continue
+		}
+		if err := s.DeletePod(p.Namespace, p.Name); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
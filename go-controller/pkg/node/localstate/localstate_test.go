@@ -0,0 +1,84 @@
+package localstate
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+func newPod(namespace, name, uid string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			UID:         ktypes.UID(uid),
+			Annotations: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{}}`},
+			Labels:      map[string]string{"app": name},
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetPod("ns1", "pod1"); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected a NotFound error for a pod not cached, got %v", err)
+	}
+
+	for _, pod := range []*corev1.Pod{newPod("ns1", "pod1", "uid1"), newPod("ns1", "pod2", "uid2"), newPod("ns2", "pod1", "uid3")} {
+		if err := store.SavePod(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the state survives a restart
+	store, err = NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := store.GetPod("ns1", "pod1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := newPod("ns1", "pod1", "uid1")
+	expected.Labels = nil
+	if !reflect.DeepEqual(pod, expected) {
+		t.Fatalf("expected the cached pod %+v, got %+v", expected, pod)
+	}
+
+	// the pods gone or recreated are pruned
+	pruned, err := store.Prune(func(cached *corev1.Pod) bool {
+		return cached.Namespace == "ns1" && cached.Name == "pod1" && cached.UID == "uid1"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 2 {
+		t.Fatalf("expected 2 pods pruned, got %d", pruned)
+	}
+	pods, err := store.ListPods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].Name != "pod1" || pods[0].Namespace != "ns1" {
+		t.Fatalf("expected only ns1/pod1 to be left, got %+v", pods)
+	}
+
+	if err := store.DeletePod("ns1", "pod1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeletePod("ns1", "pod1"); err != nil {
+		t.Fatalf("expected deleting a pod not cached to succeed, got %v", err)
+	}
+	if _, err := store.GetPod("ns1", "pod1"); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected a NotFound error for a deleted pod, got %v", err)
+	}
+}
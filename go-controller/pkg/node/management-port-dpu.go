@@ -77,7 +77,7 @@ func (mp *managementPortRepresentor) Create(_ *routemanager.Controller, nodeAnno
 	}
 
 	ovsArgs := []string{
-		"--", "--may-exist", "add-port", "br-int", k8sMgmtIntfName,
+		"--", "--may-exist", "add-port", config.OvnKubeNode.IntegrationBridge, k8sMgmtIntfName,
 		"--", "set", "interface", k8sMgmtIntfName,
 		"external-ids:iface-id=" + types.K8sPrefix + mp.nodeName,
 	}
@@ -87,7 +87,7 @@ func (mp *managementPortRepresentor) Create(_ *routemanager.Controller, nodeAnno
 	// Plug management port representor to OVS.
 	stdout, stderr, err := util.RunOVSVsctl(ovsArgs...)
 	if err != nil {
-		klog.Errorf("Failed to add port %q to br-int, stdout: %q, stderr: %q, error: %v",
+		klog.Errorf("Failed to add port %q to the integration bridge, stdout: %q, stderr: %q, error: %v",
 			k8sMgmtIntfName, stdout, stderr, err)
 		return nil, err
 	}
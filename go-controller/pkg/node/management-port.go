@@ -86,13 +86,13 @@ func (mp *managementPort) Create(routeManager *routemanager.Controller, nodeAnno
 	// Create a OVS internal interface.
 	legacyMgmtIntfName := util.GetLegacyK8sMgmtIntfName(mp.nodeName)
 	stdout, stderr, err := util.RunOVSVsctl(
-		"--", "--if-exists", "del-port", "br-int", legacyMgmtIntfName,
-		"--", "--may-exist", "add-port", "br-int", types.K8sMgmtIntfName,
+		"--", "--if-exists", "del-port", config.OvnKubeNode.IntegrationBridge, legacyMgmtIntfName,
+		"--", "--may-exist", "add-port", config.OvnKubeNode.IntegrationBridge, types.K8sMgmtIntfName,
 		"--", "set", "interface", types.K8sMgmtIntfName,
 		"type=internal", "mtu_request="+fmt.Sprintf("%d", config.Default.MTU),
 		"external-ids:iface-id="+types.K8sPrefix+mp.nodeName)
 	if err != nil {
-		klog.Errorf("Failed to add port to br-int, stdout: %q, stderr: %q, error: %v", stdout, stderr, err)
+		klog.Errorf("Failed to add port to the integration bridge, stdout: %q, stderr: %q, error: %v", stdout, stderr, err)
 		return nil, err
 	}
 	macAddress, err := util.GetOVSPortMACAddress(types.K8sMgmtIntfName)
@@ -109,6 +109,13 @@ func (mp *managementPort) Create(routeManager *routemanager.Controller, nodeAnno
 		return nil, err
 	}
 
+	// the management port is a tap of the userspace datapath
+	if config.IsUserspaceDatapath() {
+		if err := util.DisableTxChecksumOffload(types.K8sMgmtIntfName); err != nil {
+			return nil, err
+		}
+	}
+
 	cfg, err := createPlatformManagementPort(routeManager, types.K8sMgmtIntfName, mp.hostSubnets)
 	if err != nil {
 		return nil, err
@@ -150,7 +157,7 @@ func managementPortReady() (bool, error) {
 	// OpenFlow table 65 performs logical-to-physical translation. It matches the packet’s logical
 	// egress  port. Its actions output the packet to the port attached to the OVN integration bridge
 	// that represents that logical  port.
-	stdout, _, err := util.RunOVSOfctl("--no-stats", "--no-names", "dump-flows", "br-int",
+	stdout, _, err := util.RunOVSOfctl("--no-stats", "--no-names", "dump-flows", config.OvnKubeNode.IntegrationBridge,
 		"table=65,out_port="+ofport)
 	if err != nil {
 		return false, nil
@@ -368,7 +368,7 @@ func syncMgmtPortInterface(hostSubnets []*net.IPNet, mgmtPortName string, isExpe
 		}
 
 		klog.Infof("Found OVS internal port. Removing it")
-		_, stderr, err := util.RunOVSVsctl("del-port", "br-int", mgmtPortName)
+		_, stderr, err := util.RunOVSVsctl("del-port", config.OvnKubeNode.IntegrationBridge, mgmtPortName)
 		if err != nil {
 			return fmt.Errorf("failed to remove OVS internal port: %s", stderr)
 		}
@@ -394,7 +394,7 @@ func unconfigureMgmtRepresentorPort(mgmtPortName string) error {
 		klog.Warningf("No saved management port representor name for %s, renaming to %s", mgmtPortName, savedName)
 	}
 
-	_, stderr, err = util.RunOVSVsctl("--if-exists", "del-port", "br-int", mgmtPortName)
+	_, stderr, err = util.RunOVSVsctl("--if-exists", "del-port", config.OvnKubeNode.IntegrationBridge, mgmtPortName)
 	if err != nil {
 		return fmt.Errorf("failed to remove OVS port: %s", stderr)
 	}
@@ -0,0 +1,218 @@
+//go:build linux
+// +build linux
+
+package node
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// maxMasqueradeSubnetCandidates bounds the subnets of a masquerade subnet pool
+// checked for collisions, the IPv6 pools being possibly huge
+const maxMasqueradeSubnetCandidates = 1 << 16
+
+// selectNodeMasqueradeSubnets makes the masquerade subnets of the node the
+// ones of the process: the configured ones, unless they collide with the
+// addresses and routes of the node or with the cluster, service and join
+// subnets, in which case other subnets of the same size are selected from the
+// masquerade subnet pools. The subnets the node selected on a previous run are
+// kept while they don't collide so that the masquerade IPs stay stable.
+func selectNodeMasqueradeSubnets(node *kapi.Node) error {
+	if !config.Gateway.MasqueradeSubnetAutoSelect {
+		return nil
+	}
+	previous, err := util.ParseNodeMasqueradeSubnets(node)
+	if err != nil && !util.IsAnnotationNotSetError(err) {
+		klog.Warningf("Ignoring the masquerade subnets node %s selected: %v", node.Name, err)
+	}
+	reserved, err := getMasqueradeSubnetReservedCIDRs()
+	if err != nil {
+		return err
+	}
+
+	v4MasqueradeSubnet := config.Gateway.V4MasqueradeSubnet
+	v6MasqueradeSubnet := config.Gateway.V6MasqueradeSubnet
+	for _, family := range []struct {
+		isIPv6     bool
+		enabled    bool
+		masquerade *string
+		pool       string
+	}{
+		{false, config.IPv4Mode, &v4MasqueradeSubnet, config.Gateway.V4MasqueradeSubnetPool},
+		{true, config.IPv6Mode, &v6MasqueradeSubnet, config.Gateway.V6MasqueradeSubnetPool},
+	} {
+		if !family.enabled {
+			continue
+		}
+		_, configured, err := net.ParseCIDR(*family.masquerade)
+		if err != nil {
+			return fmt.Errorf("invalid masquerade subnet %s: %w", *family.masquerade, err)
+		}
+		_, pool, err := net.ParseCIDR(family.pool)
+		if err != nil {
+			return fmt.Errorf("invalid masquerade subnet pool %s: %w", family.pool, err)
+		}
+		previousSubnet, _ := util.MatchFirstIPNetFamily(family.isIPv6, previous)
+		// the masquerade subnets configured on the host by a previous run are
+		// not collisions
+		own := []*net.IPNet{configured}
+		if previousSubnet != nil {
+			own = append(own, previousSubnet)
+		}
+		subnet, err := selectMasqueradeSubnet(configured, previousSubnet, pool, ignoreOwnCIDRs(reserved, own))
+		if err != nil {
+			return err
+		}
+		if subnet.String() != configured.String() {
+			klog.Infof("Masquerade subnet %s collides on node %s, using %s", configured, node.Name, subnet)
+		}
+		*family.masquerade = subnet.String()
+	}
+	return config.SetMasqueradeSubnets(v4MasqueradeSubnet, v6MasqueradeSubnet)
+}
+
+// setNodeMasqueradeSubnetsAnnotation records the masquerade subnets of the
+// node so that the gateway router and the load balancers of the node use them
+func setNodeMasqueradeSubnetsAnnotation(nodeAnnotator kube.Annotator) error {
+	if !config.Gateway.MasqueradeSubnetAutoSelect {
+		util.DeleteNodeMasqueradeSubnets(nodeAnnotator)
+		return nil
+	}
+	var v4MasqueradeSubnet, v6MasqueradeSubnet string
+	if config.IPv4Mode {
+		v4MasqueradeSubnet = config.Gateway.V4MasqueradeSubnet
+	}
+	if config.IPv6Mode {
+		v6MasqueradeSubnet = config.Gateway.V6MasqueradeSubnet
+	}
+	return util.SetNodeMasqueradeSubnets(nodeAnnotator, v4MasqueradeSubnet, v6MasqueradeSubnet)
+}
+
+// selectMasqueradeSubnet returns the first of the previously selected, the
+// configured and the subnets of the pool of the size of the configured one
+// that doesn't overlap the reserved CIDRs
+func selectMasqueradeSubnet(configured, previous, pool *net.IPNet, reserved []*net.IPNet) (*net.IPNet, error) {
+	size, bits := configured.Mask.Size()
+	candidates := []*net.IPNet{}
+	if previous != nil && pool.Contains(previous.IP) {
+		if previousSize, _ := previous.Mask.Size(); previousSize == size {
+			candidates = append(candidates, previous)
+		}
+	}
+	candidates = append(candidates, configured)
+	for _, candidate := range candidates {
+		if !overlapsAny(candidate, reserved) {
+			return candidate, nil
+		}
+	}
+
+	if bits-size >= 31 {
+		return nil, fmt.Errorf("masquerade subnet %s is too large to select another one from pool %s", configured, pool)
+	}
+	base := utilnet.BigForIP(pool.IP)
+	step := 1 << uint(bits-size)
+	for i := 0; i < maxMasqueradeSubnetCandidates; i++ {
+		ip := utilnet.AddIPOffset(base, i*step)
+		if !pool.Contains(ip) {
+			break
+		}
+		candidate := &net.IPNet{IP: ip, Mask: configured.Mask}
+		if !overlapsAny(candidate, reserved) {
+			return candidate, nil
+		}
+	}
+	return nil, fmt.Errorf("no masquerade subnet of the size of %s left in pool %s", configured, pool)
+}
+
+// getMasqueradeSubnetReservedCIDRs returns the CIDRs the masquerade subnets
+// must not overlap: the networks of the addresses and the destinations of the
+// routes of the node, and the cluster, service and join subnets
+func getMasqueradeSubnetReservedCIDRs() ([]*net.IPNet, error) {
+	var reserved []*net.IPNet
+	addrs, err := util.GetNetLinkOps().AddrList(nil, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the addresses of the node: %w", err)
+	}
+	for _, addr := range addrs {
+		if addr.IPNet == nil || addr.IP.IsLoopback() {
+			continue
+		}
+		reserved = append(reserved, &net.IPNet{IP: addr.IP.Mask(addr.Mask), Mask: addr.Mask})
+	}
+	routes, err := util.GetNetLinkOps().RouteList(nil, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the routes of the node: %w", err)
+	}
+	for _, route := range routes {
+		if route.Dst == nil {
+			continue
+		}
+		reserved = append(reserved, route.Dst)
+	}
+	reserved = ignoreLinkLocalCIDRs(reserved)
+
+	for _, clusterSubnet := range config.Default.ClusterSubnets {
+		reserved = append(reserved, clusterSubnet.CIDR)
+	}
+	reserved = append(reserved, config.Kubernetes.ServiceCIDRs...)
+	for _, joinSubnet := range []string{config.Gateway.V4JoinSubnet, config.Gateway.V6JoinSubnet} {
+		if _, cidr, err := net.ParseCIDR(joinSubnet); err == nil {
+			reserved = append(reserved, cidr)
+		}
+	}
+	return reserved, nil
+}
+
+// ignoreLinkLocalCIDRs drops the whole link-local subnets, routed on some
+// hosts and holding the masquerade subnets
+func ignoreLinkLocalCIDRs(cidrs []*net.IPNet) []*net.IPNet {
+	kept := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		size, _ := cidr.Mask.Size()
+		if cidr.IP.IsLinkLocalUnicast() && (utilnet.IsIPv6CIDR(cidr) && size <= 64 || !utilnet.IsIPv6CIDR(cidr) && size <= 16) {
+			continue
+		}
+		kept = append(kept, cidr)
+	}
+	return kept
+}
+
+// ignoreOwnCIDRs drops the CIDRs within the given masquerade subnets
+func ignoreOwnCIDRs(cidrs, own []*net.IPNet) []*net.IPNet {
+	kept := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		isOwn := false
+		for _, subnet := range own {
+			size, _ := cidr.Mask.Size()
+			subnetSize, _ := subnet.Mask.Size()
+			if subnet.Contains(cidr.IP) && size >= subnetSize {
+				isOwn = true
+				break
+			}
+		}
+		if !isOwn {
+			kept = append(kept, cidr)
+		}
+	}
+	return kept
+}
+
+// overlapsAny returns whether the subnet overlaps any of the CIDRs
+func overlapsAny(subnet *net.IPNet, cidrs []*net.IPNet) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(subnet.IP) || subnet.Contains(cidr.IP) {
+			return true
+		}
+	}
+	return false
+}
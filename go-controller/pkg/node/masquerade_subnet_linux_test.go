@@ -0,0 +1,81 @@
+package node
+
+import (
+	"net"
+
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Masquerade subnet selection", func() {
+	var (
+		configured *net.IPNet
+		pool       *net.IPNet
+	)
+
+	BeforeEach(func() {
+		configured = ovntest.MustParseIPNet("169.254.169.0/29")
+		pool = ovntest.MustParseIPNet("169.254.0.0/16")
+	})
+
+	It("keeps the configured masquerade subnet when it doesn't collide", func() {
+		subnet, err := selectMasqueradeSubnet(configured, nil, pool, []*net.IPNet{
+			ovntest.MustParseIPNet("10.0.0.0/24"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subnet.String()).To(Equal("169.254.169.0/29"))
+	})
+
+	It("selects the first subnet of the pool that doesn't collide", func() {
+		subnet, err := selectMasqueradeSubnet(configured, nil, pool, []*net.IPNet{
+			ovntest.MustParseIPNet("169.254.169.0/24"),
+			ovntest.MustParseIPNet("169.254.0.0/28"),
+			ovntest.MustParseIPNet("169.254.0.17/32"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subnet.String()).To(Equal("169.254.0.24/29"))
+	})
+
+	It("keeps the previously selected masquerade subnet while it doesn't collide", func() {
+		previous := ovntest.MustParseIPNet("169.254.0.40/29")
+		subnet, err := selectMasqueradeSubnet(configured, previous, pool, []*net.IPNet{
+			ovntest.MustParseIPNet("169.254.169.0/24"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subnet.String()).To(Equal("169.254.0.40/29"))
+
+		// a previous subnet of another size is not kept
+		previous = ovntest.MustParseIPNet("169.254.0.32/28")
+		subnet, err = selectMasqueradeSubnet(configured, previous, pool, []*net.IPNet{
+			ovntest.MustParseIPNet("169.254.169.0/24"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subnet.String()).To(Equal("169.254.0.0/29"))
+	})
+
+	It("fails once the pool is exhausted", func() {
+		_, err := selectMasqueradeSubnet(configured, nil, ovntest.MustParseIPNet("169.254.169.0/28"), []*net.IPNet{
+			ovntest.MustParseIPNet("169.254.169.0/29"),
+			ovntest.MustParseIPNet("169.254.169.8/30"),
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("ignores the link-local subnets and the masquerade addresses of the node", func() {
+		cidrs := []*net.IPNet{
+			ovntest.MustParseIPNet("169.254.0.0/16"),
+			ovntest.MustParseIPNet("fe80::/64"),
+			ovntest.MustParseIPNet("169.254.169.0/29"),
+			ovntest.MustParseIPNet("169.254.169.2/32"),
+			ovntest.MustParseIPNet("169.254.169.0/24"),
+			ovntest.MustParseIPNet("10.0.0.0/24"),
+		}
+		kept := ignoreOwnCIDRs(ignoreLinkLocalCIDRs(cidrs), []*net.IPNet{configured})
+		Expect(kept).To(ConsistOf(
+			ovntest.MustParseIPNet("169.254.169.0/24"),
+			ovntest.MustParseIPNet("10.0.0.0/24"),
+		))
+	})
+})
@@ -414,11 +414,11 @@ func (c *addressManager) isValidNodeIP(addr net.IP) bool {
 	}
 
 	if utilnet.IsIPv4(addr) {
-		if c.mgmtPortConfig.ipv4 != nil && c.mgmtPortConfig.ipv4.ifAddr.IP.Equal(addr) {
+		if c.mgmtPortConfig != nil && c.mgmtPortConfig.ipv4 != nil && c.mgmtPortConfig.ipv4.ifAddr.IP.Equal(addr) {
 			return false
 		}
 	} else if utilnet.IsIPv6(addr) {
-		if c.mgmtPortConfig.ipv6 != nil && c.mgmtPortConfig.ipv6.ifAddr.IP.Equal(addr) {
+		if c.mgmtPortConfig != nil && c.mgmtPortConfig.ipv6 != nil && c.mgmtPortConfig.ipv6.ifAddr.IP.Equal(addr) {
 			return false
 		}
 	}
@@ -0,0 +1,300 @@
+package observability
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/observability"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// aclNameCacheTTL is how long the ACL of a sample metadata is cached,
+	// including when it could not be found
+	aclNameCacheTTL = 5 * time.Minute
+	// podIPsRefreshInterval is how often the IPs of the local pods are
+	// refreshed when samples are received
+	podIPsRefreshInterval = 5 * time.Second
+)
+
+var dropProtocols = map[uint64]string{
+	1:   "icmp",
+	6:   "tcp",
+	17:  "udp",
+	58:  "icmpv6",
+	132: "sctp",
+}
+
+// dropRecord is a record of the drop log, written as a JSON line
+type dropRecord struct {
+	Time string `json:"time"`
+	// Source is what dropped the packet, a drop ACL or the default pipeline
+	Source observability.DropSource `json:"source"`
+	// ACL is the name of the drop ACL, like NP:namespace:Ingress for the
+	// default deny of the network policies of a namespace
+	ACL string `json:"acl,omitempty"`
+	// LogicalFlow is the first 32 bits of the UUID of the logical flow of the
+	// default pipeline that dropped the packet
+	LogicalFlow string `json:"logicalFlow,omitempty"`
+	Protocol    string `json:"protocol,omitempty"`
+	SrcIP       string `json:"srcIP,omitempty"`
+	SrcPort     uint16 `json:"srcPort,omitempty"`
+	SrcPod      string `json:"srcPod,omitempty"`
+	DstIP       string `json:"dstIP,omitempty"`
+	DstPort     uint16 `json:"dstPort,omitempty"`
+	DstPod      string `json:"dstPod,omitempty"`
+}
+
+type cachedACLName struct {
+	name   string
+	expiry time.Time
+}
+
+// DropCollector is the IPFIX collector of the samples of the packets dropped
+// on the node. It logs them with what dropped them, resolving the drop ACL
+// from the sample metadata through the OVN Northbound database when
+// reachable, and the local pods they were sent from or to.
+type DropCollector struct {
+	nodeName  string
+	port      int
+	podLister listers.PodLister
+	writer    io.WriteCloser
+	decoder   *ipfixDecoder
+	now       func() time.Time
+	// resolveACLName returns the name of the ACL sampled with the metadata
+	resolveACLName func(metadata uint32) (string, error)
+	aclNames       map[uint32]cachedACLName
+	podIPs         map[string]string
+	podIPsExpiry   time.Time
+}
+
+func NewDropCollector(podLister listers.PodLister, nodeName string, port int, writer io.WriteCloser) *DropCollector {
+	return &DropCollector{
+		nodeName:       nodeName,
+		port:           port,
+		podLister:      podLister,
+		writer:         writer,
+		decoder:        newIPFIXDecoder(),
+		now:            time.Now,
+		resolveACLName: resolveACLName,
+		aclNames:       map[uint32]cachedACLName{},
+	}
+}
+
+// Run configures br-int to send the samples to the collector, then logs them
+// until stopCh is closed
+func (c *DropCollector) Run(stopCh <-chan struct{}, wg *sync.WaitGroup) error {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: c.port})
+	if err != nil {
+		return fmt.Errorf("failed to listen for the drop samples: %w", err)
+	}
+	if err := configureCollectorSet(c.port); err != nil {
+		conn.Close()
+		return err
+	}
+	klog.Infof("Starting the drop sample collector on port %d", c.port)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.receive(conn)
+		if err := c.writer.Close(); err != nil {
+			klog.Warningf("Failed to close the drop log: %v", err)
+		}
+	}()
+	go func() {
+		<-stopCh
+		conn.Close()
+	}()
+	return nil
+}
+
+func (c *DropCollector) receive(conn *net.UDPConn) {
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			klog.Warningf("Failed to receive drop samples: %v", err)
+			continue
+		}
+		records, err := c.decoder.decode(buf[:n])
+		if err != nil {
+			klog.V(5).Infof("Failed to decode drop samples: %v", err)
+			continue
+		}
+		for _, record := range records {
+			drop := c.toDropRecord(record)
+			if drop == nil {
+				continue
+			}
+			line, err := json.Marshal(drop)
+			if err != nil {
+				klog.Errorf("Failed to marshal drop record: %v", err)
+				continue
+			}
+			if _, err := c.writer.Write(append(line, '\n')); err != nil {
+				klog.Errorf("Failed to log drop record: %v", err)
+			}
+		}
+	}
+}
+
+// toDropRecord returns the drop record of an IPFIX record, nil if it is not
+// a sample of ovnkube
+func (c *DropCollector) toDropRecord(record *ipfixRecord) *dropRecord {
+	source, ok := observability.GetDropSource(record.obsDomainID)
+	if !ok {
+		return nil
+	}
+	drop := &dropRecord{
+		Time:   c.now().UTC().Format(time.RFC3339Nano),
+		Source: source,
+	}
+	if point, ok := record.uint(ieObservationPointID); ok {
+		switch source {
+		case observability.DropSourceACL:
+			drop.ACL = c.getACLName(uint32(point))
+		case observability.DropSourceDefault:
+			drop.LogicalFlow = fmt.Sprintf("%08x", point)
+		}
+	}
+	if protocol, ok := record.uint(ieProtocolIdentifier); ok {
+		drop.Protocol = dropProtocols[protocol]
+		if drop.Protocol == "" {
+			drop.Protocol = fmt.Sprintf("%d", protocol)
+		}
+	}
+	for _, id := range []uint16{ieSourceIPv4Address, ieSourceIPv6Address} {
+		if ip := record.fields[id]; len(ip) == net.IPv4len || len(ip) == net.IPv6len {
+			drop.SrcIP = net.IP(ip).String()
+		}
+	}
+	for _, id := range []uint16{ieDestinationIPv4Address, ieDestinationIPv6Address} {
+		if ip := record.fields[id]; len(ip) == net.IPv4len || len(ip) == net.IPv6len {
+			drop.DstIP = net.IP(ip).String()
+		}
+	}
+	if port, ok := record.uint(ieSourceTransportPort); ok {
+		drop.SrcPort = uint16(port)
+	}
+	if port, ok := record.uint(ieDestinationTransportPort); ok {
+		drop.DstPort = uint16(port)
+	}
+	podIPs := c.getPodIPs()
+	drop.SrcPod = podIPs[drop.SrcIP]
+	drop.DstPod = podIPs[drop.DstIP]
+	return drop
+}
+
+func (c *DropCollector) getACLName(metadata uint32) string {
+	now := c.now()
+	if cached, ok := c.aclNames[metadata]; ok && now.Before(cached.expiry) {
+		return cached.name
+	}
+	name, err := c.resolveACLName(metadata)
+	if err != nil {
+		klog.V(5).Infof("Failed to resolve the ACL of drop sample %d: %v", metadata, err)
+	}
+	c.aclNames[metadata] = cachedACLName{name: name, expiry: now.Add(aclNameCacheTTL)}
+	return name
+}
+
+// getPodIPs returns the namespace/name of the local pods by IP
+func (c *DropCollector) getPodIPs() map[string]string {
+	now := c.now()
+	if c.podIPs != nil && now.Before(c.podIPsExpiry) {
+		return c.podIPs
+	}
+	pods, err := c.podLister.List(labels.Everything())
+	if err != nil {
+		klog.Warningf("Failed to list the local pods: %v", err)
+		return c.podIPs
+	}
+	podIPs := map[string]string{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != c.nodeName || pod.Spec.HostNetwork || util.PodCompleted(pod) {
+			continue
+		}
+		for _, ip := range pod.Status.PodIPs {
+			podIPs[ip.IP] = pod.Namespace + "/" + pod.Name
+		}
+	}
+	c.podIPs = podIPs
+	c.podIPsExpiry = now.Add(podIPsRefreshInterval)
+	return podIPs
+}
+
+// resolveACLName looks up the ACL sampled with the metadata in the OVN
+// Northbound database
+func resolveACLName(metadata uint32) (string, error) {
+	sample, stderr, err := util.RunOVNNbctl("--no-leader-only", "--bare", "--columns=_uuid", "find", "Sample",
+		fmt.Sprintf("metadata=%d", metadata))
+	if err != nil {
+		return "", fmt.Errorf("failed to find the sample: %v, stderr: %q", err, stderr)
+	}
+	sample = firstLine(sample)
+	if sample == "" {
+		return "", nil
+	}
+	names, stderr, err := util.RunOVNNbctl("--no-leader-only", "--bare", "--columns=name", "find", "ACL",
+		"sample_new="+sample)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the ACL: %v, stderr: %q", err, stderr)
+	}
+	return firstLine(names), nil
+}
+
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// configureCollectorSet makes br-int send the samples of the collector set
+// of ovnkube to the local collector port
+func configureCollectorSet(port int) error {
+	if err := CleanupCollectorSet(); err != nil {
+		return err
+	}
+	_, stderr, err := util.RunOVSVsctl(
+		"--", "--id=@br", "get", "Bridge", config.OvnKubeNode.IntegrationBridge,
+		"--", "--id=@ipfix", "create", "IPFIX", fmt.Sprintf("targets=\"127.0.0.1:%d\"", port),
+		"--", "create", "Flow_Sample_Collector_Set", fmt.Sprintf("id=%d", observability.CollectorSetID),
+		"bridge=@br", "ipfix=@ipfix")
+	if err != nil {
+		return fmt.Errorf("failed to create the drop sample collector set: %v, stderr: %q", err, stderr)
+	}
+	return nil
+}
+
+// CleanupCollectorSet removes the collector set of ovnkube from br-int, if
+// any, when observability is disabled
+func CleanupCollectorSet() error {
+	uuids, stderr, err := util.RunOVSVsctl("--no-heading", "--data=bare", "--columns=_uuid", "find",
+		"Flow_Sample_Collector_Set", fmt.Sprintf("id=%d", observability.CollectorSetID))
+	if err != nil {
+		return fmt.Errorf("failed to find the drop sample collector set: %v, stderr: %q", err, stderr)
+	}
+	for _, uuid := range strings.Fields(uuids) {
+		if _, stderr, err := util.RunOVSVsctl("--if-exists", "destroy", "Flow_Sample_Collector_Set", uuid); err != nil {
+			return fmt.Errorf("failed to delete the drop sample collector set: %v, stderr: %q", err, stderr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package observability
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/observability"
+)
+
+// ipfixTestMessage builds an IPFIX message of the given observation domain
+// holding the given sets
+func ipfixTestMessage(obsDomainID uint32, sets ...[]byte) []byte {
+	msg := make([]byte, ipfixMessageHeaderLen)
+	binary.BigEndian.PutUint16(msg[0:2], ipfixVersion)
+	binary.BigEndian.PutUint32(msg[12:16], obsDomainID)
+	for _, set := range sets {
+		msg = append(msg, set...)
+	}
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(msg)))
+	return msg
+}
+
+func ipfixTestSet(setID uint16, body ...[]byte) []byte {
+	set := make([]byte, ipfixSetHeaderLen)
+	binary.BigEndian.PutUint16(set[0:2], setID)
+	for _, b := range body {
+		set = append(set, b...)
+	}
+	binary.BigEndian.PutUint16(set[2:4], uint16(len(set)))
+	return set
+}
+
+func be16(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+func be32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func ipfixTestRecord(point uint32, protocol byte, src, dst string, srcPort, dstPort uint16) []byte {
+	var record []byte
+	record = append(record, be32(point)...)
+	record = append(record, protocol)
+	record = append(record, net.ParseIP(src).To4()...)
+	record = append(record, net.ParseIP(dst).To4()...)
+	record = append(record, be16(srcPort)...)
+	record = append(record, be16(dstPort)...)
+	// enterprise field
+	record = append(record, be32(7)...)
+	return record
+}
+
+func TestDropCollector(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	template := []byte{}
+	template = append(template, be16(256)...)
+	template = append(template, be16(7)...)
+	for _, field := range [][2]uint16{
+		{ieObservationPointID, 4},
+		{ieProtocolIdentifier, 1},
+		{ieSourceIPv4Address, 4},
+		{ieDestinationIPv4Address, 4},
+		{ieSourceTransportPort, 2},
+		{ieDestinationTransportPort, 2},
+	} {
+		template = append(template, be16(field[0])...)
+		template = append(template, be16(field[1])...)
+	}
+	template = append(template, be16(ipfixEnterpriseBit|1)...)
+	template = append(template, be16(4)...)
+	template = append(template, be32(6876)...)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, pod := range []*kapi.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "client"},
+			Spec:       kapi.PodSpec{NodeName: "node1"},
+			Status:     kapi.PodStatus{PodIPs: []kapi.PodIP{{IP: "10.128.0.5"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "server"},
+			Spec:       kapi.PodSpec{NodeName: "node2"},
+			Status:     kapi.PodStatus{PodIPs: []kapi.PodIP{{IP: "10.128.1.6"}}},
+		},
+	} {
+		g.Expect(indexer.Add(pod)).To(gomega.Succeed())
+	}
+	c := NewDropCollector(listers.NewPodLister(indexer), "node1", 4740, nil)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	resolved := 0
+	c.resolveACLName = func(metadata uint32) (string, error) {
+		resolved++
+		if metadata == 1234 {
+			return "NP:ns1:Egress", nil
+		}
+		return "", nil
+	}
+
+	// the data set received before its template is skipped
+	aclDomain := uint32(observability.ACLSampleCollectorID<<24 | 3)
+	data := ipfixTestSet(256, ipfixTestRecord(1234, 6, "10.128.0.5", "10.128.1.6", 40000, 8080))
+	records, err := c.decoder.decode(ipfixTestMessage(aclDomain, data))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(records).To(gomega.BeEmpty())
+
+	// two records padded
+	data = ipfixTestSet(256,
+		ipfixTestRecord(1234, 6, "10.128.0.5", "10.128.1.6", 40000, 8080),
+		ipfixTestRecord(1234, 17, "10.128.0.5", "10.128.1.7", 40001, 53),
+		[]byte{0, 0, 0})
+	records, err = c.decoder.decode(ipfixTestMessage(aclDomain, ipfixTestSet(ipfixTemplateSetID, template), data))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(records).To(gomega.HaveLen(2))
+	g.Expect(c.toDropRecord(records[0])).To(gomega.Equal(&dropRecord{
+		Time:     "2024-05-01T10:00:00Z",
+		Source:   observability.DropSourceACL,
+		ACL:      "NP:ns1:Egress",
+		Protocol: "tcp",
+		SrcIP:    "10.128.0.5",
+		SrcPort:  40000,
+		SrcPod:   "ns1/client",
+		DstIP:    "10.128.1.6",
+		DstPort:  8080,
+	}))
+	g.Expect(c.toDropRecord(records[1]).Protocol).To(gomega.Equal("udp"))
+	// the ACL name is cached
+	g.Expect(resolved).To(gomega.Equal(1))
+
+	// the templates are per observation domain
+	defaultDomain := uint32(observability.DefaultDropDomainID<<24 | 3)
+	data = ipfixTestSet(256, ipfixTestRecord(0xabcd1234, 1, "10.128.1.6", "10.128.0.5", 0, 0))
+	records, err = c.decoder.decode(ipfixTestMessage(defaultDomain, ipfixTestSet(ipfixTemplateSetID, template), data))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(records).To(gomega.HaveLen(1))
+	g.Expect(c.toDropRecord(records[0])).To(gomega.Equal(&dropRecord{
+		Time:        "2024-05-01T10:00:00Z",
+		Source:      observability.DropSourceDefault,
+		LogicalFlow: "abcd1234",
+		Protocol:    "icmp",
+		SrcIP:       "10.128.1.6",
+		DstIP:       "10.128.0.5",
+		DstPod:      "ns1/client",
+	}))
+
+	// the samples of other collector sets are ignored
+	records, err = c.decoder.decode(ipfixTestMessage(5<<24, ipfixTestSet(ipfixTemplateSetID, template), data))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(c.toDropRecord(records[0])).To(gomega.BeNil())
+
+	// truncated messages are rejected
+	_, err = c.decoder.decode(ipfixTestMessage(aclDomain, data)[:30])
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,200 @@
+package observability
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	ipfixVersion           = 10
+	ipfixMessageHeaderLen  = 16
+	ipfixSetHeaderLen      = 4
+	ipfixTemplateSetID     = 2
+	ipfixOptionsTemplateID = 3
+	ipfixMinDataSetID      = 256
+	ipfixVariableLength    = 65535
+	ipfixEnterpriseBit     = 0x8000
+)
+
+// IANA information elements of the samples exported by OVS
+const (
+	ieProtocolIdentifier       = 4
+	ieSourceTransportPort      = 7
+	ieSourceIPv4Address        = 8
+	ieDestinationTransportPort = 11
+	ieDestinationIPv4Address   = 12
+	ieSourceIPv6Address        = 27
+	ieDestinationIPv6Address   = 28
+	ieObservationPointID       = 138
+)
+
+// ipfixField is a field of an IPFIX template
+type ipfixField struct {
+	id         uint16
+	length     uint16
+	enterprise bool
+}
+
+type ipfixTemplateKey struct {
+	obsDomainID uint32
+	templateID  uint16
+}
+
+// ipfixRecord is a data record, holding the values of its IANA fields
+type ipfixRecord struct {
+	obsDomainID uint32
+	fields      map[uint16][]byte
+}
+
+// ipfixDecoder decodes the data records of IPFIX messages with the templates
+// previously received from the same observation domain
+type ipfixDecoder struct {
+	templates map[ipfixTemplateKey][]ipfixField
+}
+
+func newIPFIXDecoder() *ipfixDecoder {
+	return &ipfixDecoder{templates: map[ipfixTemplateKey][]ipfixField{}}
+}
+
+// decode returns the data records of the message. The data sets whose
+// template is unknown yet are skipped.
+func (d *ipfixDecoder) decode(msg []byte) ([]*ipfixRecord, error) {
+	if len(msg) < ipfixMessageHeaderLen {
+		return nil, fmt.Errorf("IPFIX message too short: %d bytes", len(msg))
+	}
+	if version := binary.BigEndian.Uint16(msg[0:2]); version != ipfixVersion {
+		return nil, fmt.Errorf("unsupported IPFIX version %d", version)
+	}
+	length := int(binary.BigEndian.Uint16(msg[2:4]))
+	if length < ipfixMessageHeaderLen || length > len(msg) {
+		return nil, fmt.Errorf("invalid IPFIX message length %d", length)
+	}
+	obsDomainID := binary.BigEndian.Uint32(msg[12:16])
+
+	var records []*ipfixRecord
+	sets := msg[ipfixMessageHeaderLen:length]
+	for len(sets) > 0 {
+		if len(sets) < ipfixSetHeaderLen {
+			return nil, fmt.Errorf("IPFIX set header truncated")
+		}
+		setID := binary.BigEndian.Uint16(sets[0:2])
+		setLen := int(binary.BigEndian.Uint16(sets[2:4]))
+		if setLen < ipfixSetHeaderLen || setLen > len(sets) {
+			return nil, fmt.Errorf("invalid IPFIX set length %d", setLen)
+		}
+		body := sets[ipfixSetHeaderLen:setLen]
+		sets = sets[setLen:]
+		switch {
+		case setID == ipfixTemplateSetID:
+			if err := d.decodeTemplates(obsDomainID, body); err != nil {
+				return nil, err
+			}
+		case setID == ipfixOptionsTemplateID:
+			// OVS doesn't export options of interest
+		case setID >= ipfixMinDataSetID:
+			template, ok := d.templates[ipfixTemplateKey{obsDomainID: obsDomainID, templateID: setID}]
+			if !ok {
+				continue
+			}
+			setRecords, err := decodeDataRecords(obsDomainID, template, body)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, setRecords...)
+		}
+	}
+	return records, nil
+}
+
+func (d *ipfixDecoder) decodeTemplates(obsDomainID uint32, body []byte) error {
+	// the set may be padded
+	for len(body) >= 4 {
+		templateID := binary.BigEndian.Uint16(body[0:2])
+		fieldCount := int(binary.BigEndian.Uint16(body[2:4]))
+		body = body[4:]
+		fields := make([]ipfixField, 0, fieldCount)
+		for i := 0; i < fieldCount; i++ {
+			if len(body) < 4 {
+				return fmt.Errorf("IPFIX template %d truncated", templateID)
+			}
+			id := binary.BigEndian.Uint16(body[0:2])
+			field := ipfixField{
+				id:         id &^ ipfixEnterpriseBit,
+				length:     binary.BigEndian.Uint16(body[2:4]),
+				enterprise: id&ipfixEnterpriseBit != 0,
+			}
+			body = body[4:]
+			if field.enterprise {
+				if len(body) < 4 {
+					return fmt.Errorf("IPFIX template %d truncated", templateID)
+				}
+				body = body[4:]
+			}
+			fields = append(fields, field)
+		}
+		key := ipfixTemplateKey{obsDomainID: obsDomainID, templateID: templateID}
+		if fieldCount == 0 {
+			// template withdrawal
+			delete(d.templates, key)
+			continue
+		}
+		d.templates[key] = fields
+	}
+	return nil
+}
+
+func decodeDataRecords(obsDomainID uint32, template []ipfixField, body []byte) ([]*ipfixRecord, error) {
+	// the set may be padded with fewer bytes than a record
+	minLength := 0
+	for _, field := range template {
+		if field.length == ipfixVariableLength {
+			minLength++
+		} else {
+			minLength += int(field.length)
+		}
+	}
+	var records []*ipfixRecord
+	for len(body) > 0 && len(body) >= minLength {
+		record := &ipfixRecord{obsDomainID: obsDomainID, fields: make(map[uint16][]byte, len(template))}
+		for _, field := range template {
+			length := int(field.length)
+			if field.length == ipfixVariableLength {
+				if len(body) < 1 {
+					return nil, fmt.Errorf("IPFIX data record truncated")
+				}
+				length = int(body[0])
+				body = body[1:]
+				if length == 255 {
+					if len(body) < 2 {
+						return nil, fmt.Errorf("IPFIX data record truncated")
+					}
+					length = int(binary.BigEndian.Uint16(body[0:2]))
+					body = body[2:]
+				}
+			}
+			if len(body) < length {
+				return nil, fmt.Errorf("IPFIX data record truncated")
+			}
+			if !field.enterprise {
+				record.fields[field.id] = body[:length]
+			}
+			body = body[length:]
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// uint returns the value of an unsigned integer field, encoded with reduced
+// size or not
+func (r *ipfixRecord) uint(id uint16) (uint64, bool) {
+	value, ok := r.fields[id]
+	if !ok || len(value) == 0 || len(value) > 8 {
+		return 0, false
+	}
+	var n uint64
+	for _, b := range value {
+		n = n<<8 | uint64(b)
+	}
+	return n, true
+}
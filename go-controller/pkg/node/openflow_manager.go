@@ -21,6 +21,9 @@ type openflowManager struct {
 	exGWFlowMutex sync.Mutex
 	// channel to indicate we need to update flows immediately
 	flowChan chan struct{}
+	// udnGateways are the gateways of the primary user defined networks on
+	// the default bridge, by network name, protected by the lock of the bridge
+	udnGateways map[string]*udnGateway
 }
 
 func (c *openflowManager) updateFlowCacheEntry(key string, flows []string) {
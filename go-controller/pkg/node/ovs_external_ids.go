@@ -0,0 +1,186 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// ovsExternalIDsReconcileInterval is how often the OVS external IDs of the
+// node are checked for drift
+const ovsExternalIDsReconcileInterval = 30 * time.Second
+
+// ovsExternalIDs are the OVS external IDs of the node that ovn-controller
+// depends on, as declared by ovnkube-node: the name of the integration bridge,
+// the system-id and the bridge mappings. They are repaired when they drift,
+// e.g. when changed by hand or by another agent of the node.
+type ovsExternalIDs struct {
+	integrationBridge string
+	systemID          string
+	// lock protects bridgeMappings, switched by the failover of the physical
+	// networks with a backup bridge
+	lock sync.Mutex
+	// bridgeMappings holds the OVS bridges, by physical network
+	bridgeMappings map[string]string
+	// primaryBridges and backupBridges hold the configured OVS bridges and
+	// backup OVS bridges of the physical networks with a backup bridge
+	primaryBridges map[string]string
+	backupBridges  map[string]string
+	// isUplinkUp returns whether the uplink of the OVS bridge is up
+	isUplinkUp func(bridge string) (bool, error)
+}
+
+// newOVSExternalIDs declares the OVS external IDs of the node once it is set
+// up: the configured integration bridge, the configured system-id or else the
+// one found, and the bridge mappings found, like the one of the gateway,
+// overridden by the configured ones.
+func newOVSExternalIDs() (*ovsExternalIDs, error) {
+	e := &ovsExternalIDs{
+		integrationBridge: config.OvnKubeNode.IntegrationBridge,
+		systemID:          config.OvnKubeNode.SystemID,
+		bridgeMappings:    map[string]string{},
+		primaryBridges:    map[string]string{},
+		backupBridges:     map[string]string{},
+		isUplinkUp:        isBridgeUplinkUp,
+	}
+	if e.systemID == "" {
+		systemID, err := util.GetNodeChassisID()
+		if err != nil {
+			return nil, err
+		}
+		e.systemID = systemID
+	}
+	mappings, err := getOVSExternalID("ovn-bridge-mappings")
+	if err != nil {
+		return nil, err
+	}
+	for physnet, bridge := range parseBridgeMappings(mappings) {
+		// the mapping of the local node access bridge is being removed
+		if physnet != types.LocalNetworkName {
+			e.bridgeMappings[physnet] = bridge
+		}
+	}
+	for physnet, bridge := range config.OvnKubeNode.BridgeMappings {
+		e.bridgeMappings[physnet] = bridge
+	}
+	for physnet, backupBridge := range config.OvnKubeNode.BackupBridgeMappings {
+		e.primaryBridges[physnet] = config.OvnKubeNode.BridgeMappings[physnet]
+		e.backupBridges[physnet] = backupBridge
+	}
+	return e, nil
+}
+
+// reconcile repairs the OVS external IDs of the node that drifted from the
+// declared ones. The bridge mappings of other physical networks are kept.
+func (e *ovsExternalIDs) reconcile() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	var setArgs, drifted []string
+	for _, id := range []struct{ key, value string }{
+		{"ovn-bridge", e.integrationBridge},
+		{"system-id", e.systemID},
+	} {
+		current, err := getOVSExternalID(id.key)
+		if err != nil {
+			return err
+		}
+		if current != id.value {
+			setArgs = append(setArgs, fmt.Sprintf("external_ids:%s=%s", id.key, id.value))
+			drifted = append(drifted, fmt.Sprintf("%s %q", id.key, current))
+		}
+	}
+
+	current, err := getOVSExternalID("ovn-bridge-mappings")
+	if err != nil {
+		return err
+	}
+	currentMappings := parseBridgeMappings(current)
+	mappings := make([]string, 0, len(currentMappings)+len(e.bridgeMappings))
+	mappingsDrifted := false
+	// the mappings are kept in order, the missing ones appended
+	for _, mapping := range strings.Split(current, ",") {
+		physnet, _, _ := strings.Cut(mapping, ":")
+		if bridge, ok := e.bridgeMappings[physnet]; ok {
+			if currentMappings[physnet] != bridge {
+				mappingsDrifted = true
+			}
+			mapping = physnet + ":" + bridge
+		}
+		if mapping != "" {
+			mappings = append(mappings, mapping)
+		}
+	}
+	physnets := make([]string, 0, len(e.bridgeMappings))
+	for physnet := range e.bridgeMappings {
+		physnets = append(physnets, physnet)
+	}
+	sort.Strings(physnets)
+	for _, physnet := range physnets {
+		if _, ok := currentMappings[physnet]; !ok {
+			mappingsDrifted = true
+			mappings = append(mappings, physnet+":"+e.bridgeMappings[physnet])
+		}
+	}
+	if mappingsDrifted {
+		setArgs = append(setArgs, "external_ids:ovn-bridge-mappings="+strings.Join(mappings, ","))
+		drifted = append(drifted, fmt.Sprintf("ovn-bridge-mappings %q", current))
+	}
+
+	if len(setArgs) == 0 {
+		return nil
+	}
+	klog.Warningf("Repairing the drifted OVS external IDs: %s", strings.Join(drifted, ", "))
+	_, stderr, err := util.RunOVSVsctl(append([]string{"set", "Open_vSwitch", "."}, setArgs...)...)
+	if err != nil {
+		return fmt.Errorf("failed to repair the OVS external IDs, stderr: %q, error: %w", stderr, err)
+	}
+	return nil
+}
+
+// warnIntegrationBridgeRename warns when the integration bridge is renamed:
+// ovn-controller creates the new bridge but the ports of the pods are left on
+// the previous one, the node must be drained and the previous bridge deleted
+func warnIntegrationBridgeRename() error {
+	previous, err := getOVSExternalID("ovn-bridge")
+	if err != nil {
+		return err
+	}
+	if previous == "" {
+		previous = types.DefaultIntegrationBridge
+	}
+	if previous != config.OvnKubeNode.IntegrationBridge {
+		klog.Warningf("The integration bridge is renamed from %s to %s, the pods attached to %s have no "+
+			"connectivity until they are recreated", previous, config.OvnKubeNode.IntegrationBridge, previous)
+	}
+	return nil
+}
+
+// getOVSExternalID returns the value of the external ID of the Open_vSwitch
+// table, empty if not set
+func getOVSExternalID(key string) (string, error) {
+	stdout, stderr, err := util.RunOVSVsctl("--if-exists", "get", "Open_vSwitch", ".", "external_ids:"+key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the OVS external ID %s, stderr: %q, error: %w", key, stderr, err)
+	}
+	return stdout, nil
+}
+
+// parseBridgeMappings parses ovn-bridge-mappings, in the form of
+// physnet1:br1,physnet2:br2
+func parseBridgeMappings(mappings string) map[string]string {
+	bridges := map[string]string{}
+	for _, mapping := range strings.Split(mappings, ",") {
+		if physnet, bridge, found := strings.Cut(mapping, ":"); found {
+			bridges[physnet] = bridge
+		}
+	}
+	return bridges
+}
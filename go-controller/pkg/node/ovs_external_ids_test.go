@@ -0,0 +1,67 @@
+package node
+
+import (
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVS external IDs", func() {
+	var fexec *ovntest.FakeExec
+
+	BeforeEach(func() {
+		Expect(config.PrepareTestConfig()).To(Succeed())
+		fexec = ovntest.NewFakeExec()
+		Expect(util.SetExec(fexec)).To(Succeed())
+	})
+
+	addGetCmd := func(key, output string) {
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:" + key,
+			Output: output,
+		})
+	}
+
+	It("declares the external IDs found on startup and the configured ones", func() {
+		config.OvnKubeNode.IntegrationBridge = "br-ovn"
+		config.OvnKubeNode.BridgeMappings = map[string]string{"physnet2": "br-phys2"}
+		addGetCmd("system-id", "chassis1")
+		addGetCmd("ovn-bridge-mappings", "physnet:breth0,locnet:br-local,physnet2:br-old")
+
+		externalIDs, err := newOVSExternalIDs()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalIDs.integrationBridge).To(Equal("br-ovn"))
+		Expect(externalIDs.systemID).To(Equal("chassis1"))
+		Expect(externalIDs.bridgeMappings).To(Equal(map[string]string{
+			"physnet":  "breth0",
+			"physnet2": "br-phys2",
+		}))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("repairs the drifted external IDs, keeping the other bridge mappings", func() {
+		externalIDs := &ovsExternalIDs{
+			integrationBridge: "br-int",
+			systemID:          "chassis1",
+			bridgeMappings:    map[string]string{"physnet": "breth0", "physnet2": "br-phys2"},
+		}
+		addGetCmd("ovn-bridge", "br-int")
+		addGetCmd("system-id", "chassis2")
+		addGetCmd("ovn-bridge-mappings", "physnet3:br-phys3,physnet:br-other")
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-vsctl --timeout=15 set Open_vSwitch . external_ids:system-id=chassis1 " +
+				"external_ids:ovn-bridge-mappings=physnet3:br-phys3,physnet:breth0,physnet2:br-phys2",
+		})
+		Expect(externalIDs.reconcile()).To(Succeed())
+
+		// nothing to repair
+		addGetCmd("ovn-bridge", "br-int")
+		addGetCmd("system-id", "chassis1")
+		addGetCmd("ovn-bridge-mappings", "physnet3:br-phys3,physnet:breth0,physnet2:br-phys2")
+		Expect(externalIDs.reconcile()).To(Succeed())
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+})
@@ -3,24 +3,40 @@ package node
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 )
 
+// udnGatewayPollInterval is the interval the network ID and the patch port of
+// the gateway router of a primary network are waited for at
+const udnGatewayPollInterval = 2 * time.Second
+
 // SecondaryNodeNetworkController structure is the object which holds the controls for starting
 // and reacting upon the watched resources (e.g. pods, endpoints) for secondary network
 type SecondaryNodeNetworkController struct {
 	BaseNodeNetworkController
 	// pod events factory handler
 	podHandler *factory.Handler
+	// defaultNetController is the controller of the default network, whose
+	// shared gateway bridge the gateway routers of a primary network are
+	// connected to
+	defaultNetController *DefaultNodeNetworkController
+	// udnGatewayFlows is the openflow manager the flows of the gateway router
+	// of the primary network are added to, nil if there are none
+	udnGatewayFlows *openflowManager
 }
 
 // NewSecondaryNodeNetworkController creates a new OVN controller for creating logical network
 // infrastructure and policy for default l3 network
-func NewSecondaryNodeNetworkController(cnnci *CommonNodeNetworkControllerInfo, netInfo util.NetInfo) *SecondaryNodeNetworkController {
+func NewSecondaryNodeNetworkController(cnnci *CommonNodeNetworkControllerInfo, netInfo util.NetInfo,
+	defaultNetController *DefaultNodeNetworkController) *SecondaryNodeNetworkController {
 	return &SecondaryNodeNetworkController{
 		BaseNodeNetworkController: BaseNodeNetworkController{
 			CommonNodeNetworkControllerInfo: *cnnci,
@@ -28,20 +44,65 @@ func NewSecondaryNodeNetworkController(cnnci *CommonNodeNetworkControllerInfo, n
 			stopChan:                        make(chan struct{}),
 			wg:                              &sync.WaitGroup{},
 		},
+		defaultNetController: defaultNetController,
 	}
 }
 
 // Start starts the default controller; handles all events and creates all needed logical entities
 func (nc *SecondaryNodeNetworkController) Start(ctx context.Context) error {
 	klog.Infof("Start secondary node network controller of network %s", nc.GetNetworkName())
-	handler, err := nc.watchPodsDPU()
-	if err != nil {
-		return err
+	if config.OvnKubeNode.Mode == types.NodeModeDPU {
+		handler, err := nc.watchPodsDPU()
+		if err != nil {
+			return err
+		}
+		nc.podHandler = handler
+	}
+	if nc.IsPrimaryNetwork() && config.OvnKubeNode.Mode == types.NodeModeFull && nc.defaultNetController != nil {
+		if gw, ok := nc.defaultNetController.gateway.(*gateway); ok && gw.openflowManager != nil {
+			nc.udnGatewayFlows = gw.openflowManager
+			nc.wg.Add(1)
+			go func() {
+				defer nc.wg.Done()
+				nc.addUDNGateway()
+			}()
+		}
 	}
-	nc.podHandler = handler
 	return nil
 }
 
+// addUDNGateway adds the flows of the gateway router of the primary network to
+// the shared gateway bridge, once the network has an ID and ovn-controller has
+// created the patch port of the localnet port of the gateway router
+func (nc *SecondaryNodeNetworkController) addUDNGateway() {
+	bridge := nc.udnGatewayFlows.defaultBridge
+	patchPort := udnGatewayPatchPort(bridge, nc.NetInfo)
+	err := wait.PollUntilContextCancel(wait.ContextForChannel(nc.stopChan), udnGatewayPollInterval, true,
+		func(context.Context) (bool, error) {
+			node, err := nc.watchFactory.GetNode(nc.name)
+			if err != nil {
+				klog.V(5).Infof("Waiting for node %s: %v", nc.name, err)
+				return false, nil
+			}
+			networkID, err := util.ParseNetworkIDAnnotation(node, nc.GetNetworkName())
+			if err != nil {
+				klog.V(5).Infof("Waiting for the ID of network %s: %v", nc.GetNetworkName(), err)
+				return false, nil
+			}
+			ofPortPatch, _, err := util.GetOVSOfPort("--if-exists", "get", "interface", patchPort, "ofport")
+			if err != nil || ofPortPatch == "" {
+				klog.V(5).Infof("Waiting for patch port %s of network %s", patchPort, nc.GetNetworkName())
+				return false, nil
+			}
+			nc.udnGatewayFlows.addUDNGateway(nc.GetNetworkName(), newUDNGateway(bridge, nc.NetInfo, networkID, ofPortPatch))
+			klog.Infof("Added the flows of the gateway router of network %s on port %s", nc.GetNetworkName(), patchPort)
+			return true, nil
+		})
+	if err != nil {
+		klog.V(5).Infof("Stopped waiting for the gateway router of network %s: %v", nc.GetNetworkName(), err)
+	}
+}
+
 // Stop gracefully stops the controller
 func (nc *SecondaryNodeNetworkController) Stop() {
 	klog.Infof("Stop secondary node network controller of network %s", nc.GetNetworkName())
@@ -51,6 +112,9 @@ func (nc *SecondaryNodeNetworkController) Stop() {
 	if nc.podHandler != nil {
 		nc.watchFactory.RemovePodHandler(nc.podHandler)
 	}
+	if nc.udnGatewayFlows != nil {
+		nc.udnGatewayFlows.deleteUDNGateway(nc.GetNetworkName())
+	}
 }
 
 // Cleanup cleans up node entities for the given secondary network
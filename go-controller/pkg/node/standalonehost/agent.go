@@ -0,0 +1,201 @@
+package standalonehost
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	hostv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
+	hostclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/clientset/versioned"
+	hostinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/informers/externalversions"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// InterfaceName is the name of the OVS internal interface attaching the
+	// host to its network
+	InterfaceName = "ovn-k8s-host0"
+
+	// resyncInterval is the interval the Host is reconciled at, which also
+	// retries failed reconciliations
+	resyncInterval = 30 * time.Second
+)
+
+// Agent runs on a host that is not a Kubernetes node and attaches it to a
+// layer2 network as described by the Host of the same name: it reports the
+// chassis ID and encap IP of the host in the Host spec and configures the
+// addresses allocated by cluster manager in the Host status on an OVS
+// internal interface bound to the logical switch port of the host.
+type Agent struct {
+	name   string
+	client hostclientset.Interface
+
+	// last status configured on the interface
+	configured *hostv1.HostStatus
+}
+
+// NewAgent builds the standalone host agent of the Host with the given name
+func NewAgent(name string, client hostclientset.Interface) *Agent {
+	return &Agent{
+		name:   name,
+		client: client,
+	}
+}
+
+// Run watches the Host of the agent and reconciles it until the context is
+// cancelled
+func (a *Agent) Run(ctx context.Context) error {
+	klog.Infof("Starting standalone host agent for host %s", a.name)
+
+	factory := hostinformerfactory.NewSharedInformerFactoryWithOptions(a.client, resyncInterval,
+		hostinformerfactory.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", a.name).String()
+		}))
+	informer := factory.K8s().V1().Hosts().Informer()
+
+	// a single informer goroutine delivers the events, so reconciliations
+	// never run concurrently
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			a.reconcile(ctx, obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			a.reconcile(ctx, newObj)
+		},
+		DeleteFunc: func(_ interface{}) {
+			klog.Warningf("Host %s was deleted, its addresses are no longer valid", a.name)
+			a.configured = nil
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add event handler for host %s: %w", a.name, err)
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for the informer of host %s to sync", a.name)
+	}
+
+	<-ctx.Done()
+	klog.Infof("Stopped standalone host agent for host %s", a.name)
+	return nil
+}
+
+func (a *Agent) reconcile(ctx context.Context, obj interface{}) {
+	host, ok := obj.(*hostv1.Host)
+	if !ok {
+		klog.Errorf("Could not cast %T object to *hostv1.Host", obj)
+		return
+	}
+	if err := a.ensureSpec(ctx, host); err != nil {
+		klog.Errorf("Failed to update spec of host %s: %v", a.name, err)
+		return
+	}
+	if len(host.Status.IPAddresses) == 0 {
+		klog.Infof("Waiting for addresses to be allocated to host %s", a.name)
+		return
+	}
+	if a.configured != nil && reflect.DeepEqual(*a.configured, host.Status) {
+		return
+	}
+	if err := a.configureInterface(host); err != nil {
+		klog.Errorf("Failed to configure interface %s of host %s: %v", InterfaceName, a.name, err)
+		return
+	}
+	status := host.Status
+	a.configured = &status
+	klog.Infof("Configured interface %s of host %s with addresses %v", InterfaceName, a.name, host.Status.IPAddresses)
+}
+
+// ensureSpec sets the chassis ID and encap IP of the host in its spec if they
+// were not provided
+func (a *Agent) ensureSpec(ctx context.Context, host *hostv1.Host) error {
+	if host.Spec.ChassisID != "" && host.Spec.EncapIP != "" {
+		return nil
+	}
+
+	var err error
+	host = host.DeepCopy()
+	if host.Spec.ChassisID == "" {
+		if host.Spec.ChassisID, err = util.GetNodeChassisID(); err != nil {
+			return err
+		}
+	}
+	if host.Spec.EncapIP == "" {
+		if host.Spec.EncapIP, err = getEncapIP(); err != nil {
+			return err
+		}
+	}
+
+	_, err = a.client.K8sV1().Hosts().Update(ctx, host, metav1.UpdateOptions{})
+	return err
+}
+
+// getEncapIP returns the encap IP of the host, either configured or set by
+// the admin in the external IDs of the local OVS
+func getEncapIP() (string, error) {
+	if config.Default.EncapIP != "" {
+		return config.Default.EncapIP, nil
+	}
+	encapIP, stderr, err := util.RunOVSVsctl("--if-exists", "get",
+		"Open_vSwitch", ".", "external_ids:ovn-encap-ip")
+	if err != nil {
+		return "", fmt.Errorf("failed to get ovn-encap-ip, stderr: %q, error: %v", stderr, err)
+	}
+	encapIP = strings.Trim(encapIP, "\"")
+	if encapIP == "" {
+		return "", fmt.Errorf("no ovn-encap-ip configured in the local host")
+	}
+	return encapIP, nil
+}
+
+// configureInterface creates the OVS internal interface of the host bound to
+// its logical switch port and sets the allocated addresses on it
+func (a *Agent) configureInterface(host *hostv1.Host) error {
+	ips, err := util.ParseIPNets(host.Status.IPAddresses)
+	if err != nil {
+		return fmt.Errorf("failed to parse IPs: %w", err)
+	}
+	mac, err := net.ParseMAC(host.Status.MACAddress)
+	if err != nil {
+		return fmt.Errorf("failed to parse MAC address: %w", err)
+	}
+
+	portName := util.GetSecondaryNetworkHostLogicalPortName(host.Name, host.Spec.Network)
+	stdout, stderr, err := util.RunOVSVsctl(
+		"--", "--may-exist", "add-port", config.OvnKubeNode.IntegrationBridge, InterfaceName,
+		"--", "set", "interface", InterfaceName,
+		"type=internal", "mtu_request="+fmt.Sprintf("%d", config.Default.MTU),
+		"external-ids:iface-id="+portName,
+		fmt.Sprintf("mac=\"%s\"", mac.String()))
+	if err != nil {
+		return fmt.Errorf("failed to add port %s to the integration bridge, stdout: %q, stderr: %q, error: %v",
+			InterfaceName, stdout, stderr, err)
+	}
+
+	link, err := util.LinkSetUp(InterfaceName)
+	if err != nil {
+		return err
+	}
+	if err = util.GetNetLinkOps().LinkSetHardwareAddr(link, mac); err != nil {
+		return fmt.Errorf("failed to set MAC address %s on %s: %w", mac, InterfaceName, err)
+	}
+	if err = util.LinkAddrFlush(link); err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if err = util.LinkAddrAdd(link, ip, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
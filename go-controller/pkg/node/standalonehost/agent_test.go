@@ -0,0 +1,81 @@
+package standalonehost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	hostv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
+	hostfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/clientset/versioned/fake"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestEnsureSpec(t *testing.T) {
+	tests := []struct {
+		desc     string
+		spec     hostv1.HostSpec
+		encapIP  string
+		cmds     []ovntest.ExpectedCmd
+		expected hostv1.HostSpec
+	}{
+		{
+			desc:     "provided spec is left untouched",
+			spec:     hostv1.HostSpec{Network: "blue", ChassisID: "provided", EncapIP: "10.0.0.1"},
+			expected: hostv1.HostSpec{Network: "blue", ChassisID: "provided", EncapIP: "10.0.0.1"},
+		},
+		{
+			desc: "chassis ID and encap IP are read from OVS",
+			spec: hostv1.HostSpec{Network: "blue"},
+			cmds: []ovntest.ExpectedCmd{
+				{
+					Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:system-id",
+					Output: "chassis-1",
+				},
+				{
+					Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-encap-ip",
+					Output: "\"10.0.0.2\"",
+				},
+			},
+			expected: hostv1.HostSpec{Network: "blue", ChassisID: "chassis-1", EncapIP: "10.0.0.2"},
+		},
+		{
+			desc:     "configured encap IP takes precedence",
+			spec:     hostv1.HostSpec{Network: "blue", ChassisID: "provided"},
+			encapIP:  "10.0.0.3",
+			expected: hostv1.HostSpec{Network: "blue", ChassisID: "provided", EncapIP: "10.0.0.3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+			config.Default.EncapIP = tt.encapIP
+
+			fexec := ovntest.NewFakeExec()
+			for i := range tt.cmds {
+				fexec.AddFakeCmd(&tt.cmds[i])
+			}
+			g.Expect(util.SetExec(fexec)).To(gomega.Succeed())
+			defer util.ResetRunner()
+
+			host := &hostv1.Host{
+				ObjectMeta: metav1.ObjectMeta{Name: "host1"},
+				Spec:       tt.spec,
+			}
+			client := hostfake.NewSimpleClientset(host)
+			a := NewAgent(host.Name, client)
+
+			g.Expect(a.ensureSpec(context.TODO(), host)).To(gomega.Succeed())
+			g.Expect(fexec.CalledMatchesExpected()).To(gomega.BeTrue(), fexec.ErrorDesc)
+
+			updated, err := client.K8sV1().Hosts().Get(context.TODO(), host.Name, metav1.GetOptions{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(updated.Spec).To(gomega.Equal(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,321 @@
+//go:build linux
+// +build linux
+
+package tunneltuning
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/safchain/ethtool"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// These variables are meant to be used in unit tests
+var sysClassNetPath = "/sys/class/net"
+var sysNodePath = "/sys/devices/system/node"
+var sysCPUPath = "/sys/devices/system/cpu"
+var procIRQPath = "/proc/irq"
+var newEthtoolFn = func() (ethtoolInterface, error) { return ethtool.NewEthtool() }
+
+// ethtoolInterface is the subset of ethtool used to tune the interfaces
+type ethtoolInterface interface {
+	GetChannels(intf string) (ethtool.Channels, error)
+	SetChannels(intf string, channels ethtool.Channels) (ethtool.Channels, error)
+	Features(intf string) (map[string]bool, error)
+	Change(intf string, config map[string]bool) error
+	Close()
+}
+
+// udpOffloadFeatures are the GRO and GSO features of the UDP, and of the UDP
+// tunnels, enabled on the tunnel interfaces when supported
+var udpOffloadFeatures = []string{
+	"rx-udp-gro-forwarding",
+	"tx-udp-segmentation",
+	"tx-udp_tnl-segmentation",
+	"tx-udp_tnl-csum-segmentation",
+}
+
+// tuningState is what the tuning changes on the NIC carrying the tunnel
+// traffic, reported before and after the tuning
+type tuningState struct {
+	// queues is the number of combined RSS queues of the NIC
+	queues int
+	// irqs is the number of IRQs of the NIC and localIRQs the number of them
+	// whose affinity is limited to the CPUs of the NUMA node of the NIC
+	irqs      int
+	localIRQs int
+	// udpOffloads is the number of enabled UDP GRO and GSO features
+	udpOffloads int
+}
+
+// Run tunes the NIC carrying the Geneve traffic of the node for throughput,
+// according to the NUMA topology of the node: one RSS queue per CPU of the
+// NUMA node of the NIC, the IRQs of the NIC spread over these CPUs and the
+// UDP GRO and GSO features enabled on the NIC and on the Geneve interface.
+// The settings before and after the tuning are reported in the metrics.
+// This feature is enabled by the ovnkube-node-tunnel-tuning option.
+func Run() {
+	if !config.OvnKubeNode.TunnelTuning {
+		return
+	}
+	if config.OvnKubeNode.Mode == types.NodeModeDPUHost || config.IsUserspaceDatapath() {
+		klog.Infof("Tunnel tuning is not supported in ovnkube-node mode %s with ovnkube-node-datapath %s",
+			config.OvnKubeNode.Mode, config.OvnKubeNode.Datapath)
+		return
+	}
+	nic, err := getTunnelNIC()
+	if err != nil {
+		klog.Warningf("Can't tune the tunnel interface: %v", err)
+		return
+	}
+	tunnelIntf := fmt.Sprintf("genev_sys_%d", config.Default.EncapPort)
+	klog.Infof("Tuning the tunnel interface %s and its NIC %s", tunnelIntf, nic)
+	if err := tune(nic, tunnelIntf); err != nil {
+		klog.Warningf("Failed to tune the tunnel interface %s and its NIC %s: %v", tunnelIntf, nic, err)
+	}
+}
+
+// getTunnelNIC returns the NIC holding the encap IP of the node, or the uplink
+// of the OVS bridge holding it
+func getTunnelNIC() (string, error) {
+	encapIP := net.ParseIP(config.Default.EncapIP)
+	if encapIP == nil {
+		return "", fmt.Errorf("invalid encap IP %q", config.Default.EncapIP)
+	}
+	intf, _, err := util.GetIFNameAndMTUForAddress(encapIP)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := util.RunOVSVsctl("br-exists", intf); err != nil {
+		return intf, nil
+	}
+	return util.GetNicName(intf)
+}
+
+func tune(nic, tunnelIntf string) error {
+	e, err := newEthtoolFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize ethtool: %w", err)
+	}
+	defer e.Close()
+
+	cpus, err := getLocalCPUs(nic)
+	if err != nil {
+		return err
+	}
+	recordTuningState(nic, "before", getTuningState(e, nic, cpus))
+
+	var errs []error
+	if err := tuneQueues(e, nic, len(cpus)); err != nil {
+		errs = append(errs, err)
+	}
+	if err := tuneIRQAffinity(nic, cpus); err != nil {
+		errs = append(errs, err)
+	}
+	for _, intf := range []string{nic, tunnelIntf} {
+		if err := enableUDPOffloads(e, intf); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	after := getTuningState(e, nic, cpus)
+	recordTuningState(nic, "after", after)
+	klog.Infof("Tuned NIC %s: %d queues, %d of %d IRQs on the CPUs %v of its NUMA node, %d UDP offloads",
+		nic, after.queues, after.localIRQs, after.irqs, cpus, after.udpOffloads)
+	return utilerrors.NewAggregate(errs)
+}
+
+func recordTuningState(nic, state string, s tuningState) {
+	metrics.RecordTunnelTuning(nic, "queues", state, s.queues)
+	metrics.RecordTunnelTuning(nic, "irqs", state, s.irqs)
+	metrics.RecordTunnelTuning(nic, "local_irqs", state, s.localIRQs)
+	metrics.RecordTunnelTuning(nic, "udp_offloads", state, s.udpOffloads)
+}
+
+// getLocalCPUs returns the online CPUs of the NUMA node of the NIC, or all
+// the online CPUs if the NIC has no NUMA affinity
+func getLocalCPUs(nic string) ([]int, error) {
+	value, err := os.ReadFile(filepath.Join(sysClassNetPath, nic, "device", "numa_node"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the NUMA node of NIC %s: %w", nic, err)
+	}
+	numaNode, err := strconv.Atoi(strings.TrimSpace(string(value)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NUMA node %q of NIC %s", value, nic)
+	}
+	cpuListPath := filepath.Join(sysCPUPath, "online")
+	if numaNode >= 0 {
+		cpuListPath = filepath.Join(sysNodePath, fmt.Sprintf("node%d", numaNode), "cpulist")
+	}
+	value, err = os.ReadFile(cpuListPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the CPUs of NUMA node %d: %w", numaNode, err)
+	}
+	cpus, err := parseCPUList(string(value))
+	if err != nil {
+		return nil, err
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("no CPU found for NUMA node %d of NIC %s", numaNode, nic)
+	}
+	return cpus, nil
+}
+
+// parseCPUList parses a list of CPUs in the linux format, e.g. 0-5,8,10
+func parseCPUList(cpuList string) ([]int, error) {
+	var cpus []int
+	cpuList = strings.TrimSpace(cpuList)
+	if cpuList == "" {
+		return nil, nil
+	}
+	for _, item := range strings.Split(cpuList, ",") {
+		first, last, isRange := strings.Cut(item, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list %q", cpuList)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return nil, fmt.Errorf("invalid CPU list %q", cpuList)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// getNICIRQs returns the MSI IRQs of the NIC, sorted
+func getNICIRQs(nic string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(sysClassNetPath, nic, "device", "msi_irqs"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the IRQs of NIC %s: %w", nic, err)
+	}
+	irqs := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		irq, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		irqs = append(irqs, irq)
+	}
+	sort.Ints(irqs)
+	return irqs, nil
+}
+
+func getTuningState(e ethtoolInterface, nic string, cpus []int) tuningState {
+	var s tuningState
+	if channels, err := e.GetChannels(nic); err == nil {
+		s.queues = int(channels.CombinedCount)
+	}
+	localCPUs := make(map[int]bool, len(cpus))
+	for _, cpu := range cpus {
+		localCPUs[cpu] = true
+	}
+	irqs, _ := getNICIRQs(nic)
+	for _, irq := range irqs {
+		value, err := os.ReadFile(filepath.Join(procIRQPath, strconv.Itoa(irq), "smp_affinity_list"))
+		if err != nil {
+			continue
+		}
+		s.irqs++
+		affinity, err := parseCPUList(string(value))
+		if err != nil || len(affinity) == 0 {
+			continue
+		}
+		local := true
+		for _, cpu := range affinity {
+			local = local && localCPUs[cpu]
+		}
+		if local {
+			s.localIRQs++
+		}
+	}
+	if features, err := e.Features(nic); err == nil {
+		for _, feature := range udpOffloadFeatures {
+			if features[feature] {
+				s.udpOffloads++
+			}
+		}
+	}
+	return s
+}
+
+// tuneQueues sets as many combined RSS queues on the NIC as it has local
+// CPUs, within the maximum supported by the NIC
+func tuneQueues(e ethtoolInterface, nic string, numCPUs int) error {
+	channels, err := e.GetChannels(nic)
+	if err != nil {
+		return fmt.Errorf("failed to get the queues of NIC %s: %w", nic, err)
+	}
+	// only NICs with combined queues are tuned
+	if channels.MaxCombined == 0 {
+		return nil
+	}
+	queues := uint32(numCPUs)
+	if queues > channels.MaxCombined {
+		queues = channels.MaxCombined
+	}
+	if channels.CombinedCount == queues {
+		return nil
+	}
+	channels.CombinedCount = queues
+	if _, err := e.SetChannels(nic, channels); err != nil {
+		return fmt.Errorf("failed to set %d queues on NIC %s: %w", queues, nic, err)
+	}
+	return nil
+}
+
+// tuneIRQAffinity spreads the IRQs of the NIC over its local CPUs, one CPU
+// per IRQ in turn
+func tuneIRQAffinity(nic string, cpus []int) error {
+	irqs, err := getNICIRQs(nic)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i, irq := range irqs {
+		cpu := strconv.Itoa(cpus[i%len(cpus)])
+		path := filepath.Join(procIRQPath, strconv.Itoa(irq), "smp_affinity_list")
+		if err := os.WriteFile(path, []byte(cpu), 0o644); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set the affinity of IRQ %d of NIC %s to CPU %s: %w", irq, nic, cpu, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// enableUDPOffloads enables the UDP GRO and GSO features supported by the
+// interface
+func enableUDPOffloads(e ethtoolInterface, intf string) error {
+	features, err := e.Features(intf)
+	if err != nil {
+		return fmt.Errorf("failed to get the features of interface %s: %w", intf, err)
+	}
+	change := map[string]bool{}
+	for _, feature := range udpOffloadFeatures {
+		if enabled, supported := features[feature]; supported && !enabled {
+			change[feature] = true
+		}
+	}
+	if len(change) == 0 {
+		return nil
+	}
+	if err := e.Change(intf, change); err != nil {
+		return fmt.Errorf("failed to enable the UDP offloads of interface %s: %w", intf, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+//go:build linux
+// +build linux
+
+package tunneltuning
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/safchain/ethtool"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEthtool struct {
+	channels map[string]ethtool.Channels
+	features map[string]map[string]bool
+}
+
+func (f *fakeEthtool) GetChannels(intf string) (ethtool.Channels, error) {
+	return f.channels[intf], nil
+}
+
+func (f *fakeEthtool) SetChannels(intf string, channels ethtool.Channels) (ethtool.Channels, error) {
+	f.channels[intf] = channels
+	return channels, nil
+}
+
+func (f *fakeEthtool) Features(intf string) (map[string]bool, error) {
+	return f.features[intf], nil
+}
+
+func (f *fakeEthtool) Change(intf string, config map[string]bool) error {
+	for feature, enabled := range config {
+		f.features[intf][feature] = enabled
+	}
+	return nil
+}
+
+func (f *fakeEthtool) Close() {}
+
+// mockSysfs creates the sysfs and procfs entries of a NIC of the given NUMA
+// node with the given IRQs, and of the given CPUs of the NUMA nodes
+func mockSysfs(t *testing.T, nic, numaNode string, irqs []string, nodeCPUs map[string]string) {
+	root := t.TempDir()
+	sysClassNetPath = filepath.Join(root, "class", "net")
+	sysNodePath = filepath.Join(root, "node")
+	sysCPUPath = filepath.Join(root, "cpu")
+	procIRQPath = filepath.Join(root, "irq")
+
+	writeFile := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(sysClassNetPath, nic, "device", "numa_node"), numaNode+"\n")
+	for _, irq := range irqs {
+		writeFile(filepath.Join(sysClassNetPath, nic, "device", "msi_irqs", irq), "msix\n")
+		writeFile(filepath.Join(procIRQPath, irq, "smp_affinity_list"), "0-7\n")
+	}
+	for node, cpus := range nodeCPUs {
+		writeFile(filepath.Join(sysNodePath, "node"+node, "cpulist"), cpus+"\n")
+	}
+	writeFile(filepath.Join(sysCPUPath, "online"), "0-7\n")
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := parseCPUList("0-2,5,8-9\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 5, 8, 9}, cpus)
+
+	cpus, err = parseCPUList("")
+	assert.NoError(t, err)
+	assert.Empty(t, cpus)
+
+	_, err = parseCPUList("3-1")
+	assert.Error(t, err)
+}
+
+func TestTune(t *testing.T) {
+	mockSysfs(t, "eth0", "1", []string{"40", "41", "42", "43", "44"}, map[string]string{"0": "0-3", "1": "4-7"})
+	fake := &fakeEthtool{
+		channels: map[string]ethtool.Channels{
+			"eth0": {MaxCombined: 8, CombinedCount: 2},
+		},
+		features: map[string]map[string]bool{
+			"eth0": {
+				"rx-udp-gro-forwarding":   false,
+				"tx-udp_tnl-segmentation": true,
+				"rx-gro":                  true,
+			},
+			"genev_sys_6081": {
+				"rx-udp-gro-forwarding": false,
+			},
+		},
+	}
+	newEthtoolFn = func() (ethtoolInterface, error) { return fake, nil }
+
+	before := getTuningState(fake, "eth0", []int{4, 5, 6, 7})
+	assert.Equal(t, tuningState{queues: 2, irqs: 5, localIRQs: 0, udpOffloads: 1}, before)
+
+	if err := tune("eth0", "genev_sys_6081"); err != nil {
+		t.Fatal(err)
+	}
+
+	// one queue per CPU of the NUMA node of the NIC
+	assert.Equal(t, uint32(4), fake.channels["eth0"].CombinedCount)
+	// the IRQs spread over these CPUs
+	for irq, cpu := range map[string]string{"40": "4", "41": "5", "42": "6", "43": "7", "44": "4"} {
+		affinity, err := os.ReadFile(filepath.Join(procIRQPath, irq, "smp_affinity_list"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, cpu, strings.TrimSpace(string(affinity)), "affinity of IRQ %s", irq)
+	}
+	// and the supported UDP offloads enabled
+	assert.True(t, fake.features["eth0"]["rx-udp-gro-forwarding"])
+	assert.True(t, fake.features["genev_sys_6081"]["rx-udp-gro-forwarding"])
+	assert.NotContains(t, fake.features["eth0"], "tx-udp-segmentation")
+
+	after := getTuningState(fake, "eth0", []int{4, 5, 6, 7})
+	assert.Equal(t, tuningState{queues: 4, irqs: 5, localIRQs: 5, udpOffloads: 2}, after)
+}
+
+func TestTuneWithoutNUMAAffinity(t *testing.T) {
+	mockSysfs(t, "eth0", "-1", []string{"40"}, nil)
+	fake := &fakeEthtool{
+		channels: map[string]ethtool.Channels{
+			"eth0": {MaxCombined: 6, CombinedCount: 1},
+		},
+		features: map[string]map[string]bool{"eth0": {}, "genev_sys_6081": {}},
+	}
+	newEthtoolFn = func() (ethtoolInterface, error) { return fake, nil }
+
+	cpus, err := getLocalCPUs("eth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, cpus)
+
+	if err := tune("eth0", "genev_sys_6081"); err != nil {
+		t.Fatal(err)
+	}
+	// the queues are capped to the maximum of the NIC
+	assert.Equal(t, uint32(6), fake.channels["eth0"].CombinedCount)
+}
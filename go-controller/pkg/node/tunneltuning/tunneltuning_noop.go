@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package tunneltuning
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+func Run() {
+	if config.OvnKubeNode.TunnelTuning {
+		klog.Infof("Tunnel tuning is supported on linux platform only")
+	}
+}
@@ -0,0 +1,93 @@
+//go:build linux
+// +build linux
+
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// userspaceDatapathType is the OVS datapath type of the userspace datapath
+const userspaceDatapathType = "netdev"
+
+// parseOVSSet parses the value of an OVS set column, eg. [netdev, system]
+func parseOVSSet(value string) []string {
+	value = strings.Trim(value, "[]")
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ", ")
+}
+
+// checkUserspaceDatapathSupport fails the startup of the node when OVS doesn't
+// provide the userspace datapath or the uplink port type of the node.
+func checkUserspaceDatapathSupport() error {
+	if !config.IsUserspaceDatapath() {
+		return nil
+	}
+	stdout, stderr, err := util.RunOVSVsctl("get", "Open_vSwitch", ".", "datapath_types")
+	if err != nil {
+		return fmt.Errorf("failed to get the OVS datapath types, stderr: %q, error: %v", stderr, err)
+	}
+	if !util.SliceHasStringItem(parseOVSSet(stdout), userspaceDatapathType) {
+		return fmt.Errorf("OVS does not provide the userspace datapath required by ovnkube-node-datapath %s, "+
+			"datapath types: %s", config.OvnKubeNode.Datapath, stdout)
+	}
+
+	switch config.OvnKubeNode.Datapath {
+	case types.NodeDatapathAFXDP:
+		stdout, stderr, err = util.RunOVSVsctl("get", "Open_vSwitch", ".", "iface_types")
+		if err != nil {
+			return fmt.Errorf("failed to get the OVS interface types, stderr: %q, error: %v", stderr, err)
+		}
+		if !util.SliceHasStringItem(parseOVSSet(stdout), types.NodeDatapathAFXDP) {
+			return fmt.Errorf("OVS is not built with AF_XDP support required by ovnkube-node-datapath %s",
+				config.OvnKubeNode.Datapath)
+		}
+	case types.NodeDatapathDPDK:
+		stdout, stderr, err = util.RunOVSVsctl("get", "Open_vSwitch", ".", "dpdk_initialized")
+		if err != nil {
+			return fmt.Errorf("failed to get the OVS DPDK status, stderr: %q, error: %v", stderr, err)
+		}
+		if stdout != "true" {
+			return fmt.Errorf("OVS DPDK is not initialized, it is required by ovnkube-node-datapath %s: "+
+				"set other_config:dpdk-init=true", config.OvnKubeNode.Datapath)
+		}
+	}
+	return nil
+}
+
+// setupUserspaceDatapathBridge checks that the gateway bridge and its uplink
+// use the userspace datapath of the node, and disables the transmit checksum
+// offloads of the internal port of the bridge which carries the host traffic.
+func setupUserspaceDatapathBridge(bridgeName, uplinkName string) error {
+	if !config.IsUserspaceDatapath() {
+		return nil
+	}
+	datapathType, stderr, err := util.RunOVSVsctl("get", "bridge", bridgeName, "datapath_type")
+	if err != nil {
+		return fmt.Errorf("failed to get the datapath type of bridge %s, stderr: %q, error: %v", bridgeName, stderr, err)
+	}
+	// changing the datapath of an existing bridge re-creates its internal
+	// port, flushing the host addresses, so it is left to the administrator
+	if datapathType != userspaceDatapathType {
+		return fmt.Errorf("gateway bridge %s must have datapath_type=%s with ovnkube-node-datapath %s, got %q",
+			bridgeName, userspaceDatapathType, config.OvnKubeNode.Datapath, datapathType)
+	}
+	if uplinkName != "" {
+		ifaceType, stderr, err := util.RunOVSVsctl("get", "interface", uplinkName, "type")
+		if err != nil {
+			return fmt.Errorf("failed to get the type of interface %s, stderr: %q, error: %v", uplinkName, stderr, err)
+		}
+		if ifaceType != config.OvnKubeNode.Datapath {
+			return fmt.Errorf("uplink %s of gateway bridge %s must be of type %s, got %q",
+				uplinkName, bridgeName, config.OvnKubeNode.Datapath, ifaceType)
+		}
+	}
+	return util.DisableTxChecksumOffload(bridgeName)
+}
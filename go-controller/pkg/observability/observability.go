@@ -0,0 +1,66 @@
+// Package observability holds what ovnkube-controller, which configures the
+// sampling of the dropped packets in OVN, and ovnkube-node, which collects the
+// samples, need to agree on.
+package observability
+
+import (
+	"hash/fnv"
+)
+
+const (
+	// CollectorSetID is the id of the OVS Flow_Sample_Collector_Set of br-int
+	// the samples of the dropped packets are sent to
+	CollectorSetID = 42
+	// ACLSampleCollectorID is the id of the Sample_Collector of the drop ACLs.
+	// OVN sets it as the 8 most significant bits of the observation domain id
+	// of their samples, whose observation point id is the sample metadata.
+	ACLSampleCollectorID = 1
+	// DefaultDropDomainID is the debug_drop_domain_id of the drops of the
+	// default OVN pipeline. OVN sets it as the 8 most significant bits of the
+	// observation domain id of their samples, whose observation point id is
+	// the first 32 bits of the UUID of the dropping logical flow.
+	DefaultDropDomainID = 2
+)
+
+// DropSource is what dropped a sampled packet
+type DropSource string
+
+const (
+	// DropSourceACL is a drop ACL of ovnkube
+	DropSourceACL DropSource = "acl"
+	// DropSourceDefault is the default OVN pipeline
+	DropSourceDefault DropSource = "default"
+)
+
+// GetDropSource returns what dropped the packets sampled with the given
+// observation domain id, if sampled by ovnkube
+func GetDropSource(obsDomainID uint32) (DropSource, bool) {
+	switch obsDomainID >> 24 {
+	case ACLSampleCollectorID:
+		return DropSourceACL, true
+	case DefaultDropDomainID:
+		return DropSourceDefault, true
+	default:
+		return "", false
+	}
+}
+
+// ACLSampleMetadata returns the metadata of the sample of the ACL with the
+// given primary id. It is derived from the id so that it is stable across
+// restarts; the ACLs whose ids collide share their sample.
+func ACLSampleMetadata(aclPrimaryID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(aclPrimaryID))
+	metadata := int(h.Sum32() & 0x7fffffff)
+	if metadata == 0 {
+		// the metadata must be at least 1
+		metadata = 1
+	}
+	return metadata
+}
+
+// SamplingProbability converts a sampling percentage to the probability of
+// a Sample_Collector, out of 65535
+func SamplingProbability(percentage int) int {
+	return percentage * 65535 / 100
+}
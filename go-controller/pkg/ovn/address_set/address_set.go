@@ -312,6 +312,17 @@ func GetHashNamesForAS(dbIDs *libovsdbops.DbObjectIDs) (string, string) {
 		buildAddressSet(dbIDs, ipv6InternalID).Name
 }
 
+// GetDbAddrSets returns the nbdb.AddressSet objects with the given dbIDs for
+// ipv4 and ipv6 with the given addresses, that may be IPs or CIDRs, to manage
+// address sets that are not limited to IPs without an AddressSet.
+func GetDbAddrSets(dbIDs *libovsdbops.DbObjectIDs, v4Addresses, v6Addresses []string) (*nbdb.AddressSet, *nbdb.AddressSet) {
+	v4set := buildAddressSet(dbIDs, ipv4InternalID)
+	v4set.Addresses = v4Addresses
+	v6set := buildAddressSet(dbIDs, ipv6InternalID)
+	v6set.Addresses = v6Addresses
+	return v4set, v6set
+}
+
 // GetTestDbAddrSets returns nbdb.AddressSet objects both for ipv4 and ipv6, regardless of current config.
 // May only be used for testing.
 func GetTestDbAddrSets(dbIDs *libovsdbops.DbObjectIDs, ips []net.IP) (*nbdb.AddressSet, *nbdb.AddressSet) {
@@ -11,8 +11,10 @@ import (
 
 	mnpapi "github.com/k8snetworkplumbingwg/multi-networkpolicy/pkg/apis/k8s.cni.cncf.io/v1beta1"
 	egressfirewall "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
+	hostapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/retry"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
 
@@ -31,7 +33,8 @@ func hasResourceAnUpdateFunc(objType reflect.Type) bool {
 		factory.EgressNodeType,
 		factory.EgressFwNodeType,
 		factory.NamespaceType,
-		factory.MultiNetworkPolicyType:
+		factory.MultiNetworkPolicyType,
+		factory.HostType:
 		return true
 	}
 	return false
@@ -66,11 +69,7 @@ func (h *baseNetworkControllerEventHandler) areResourcesEqual(objType reflect.Ty
 		}
 
 		// when shouldUpdateNode is false, the hostsubnet is not assigned by ovn-kubernetes
-		shouldUpdate, err := shouldUpdateNode(node2, node1)
-		if err != nil {
-			klog.Errorf(err.Error())
-		}
-		return !shouldUpdate, nil
+		return !shouldUpdateNode(node2, node1), nil
 
 	case factory.PodType,
 		factory.EgressIPPodType:
@@ -124,6 +123,17 @@ func (h *baseNetworkControllerEventHandler) areResourcesEqual(objType reflect.Ty
 			return false, fmt.Errorf("could not cast obj2 of type %T to *multinetworkpolicyapi.MultiNetworkPolicy", obj2)
 		}
 		return reflect.DeepEqual(mnp1, mnp2), nil
+
+	case factory.HostType:
+		host1, ok := obj1.(*hostapi.Host)
+		if !ok {
+			return false, fmt.Errorf("could not cast obj1 of type %T to *hostapi.Host", obj1)
+		}
+		host2, ok := obj2.(*hostapi.Host)
+		if !ok {
+			return false, fmt.Errorf("could not cast obj2 of type %T to *hostapi.Host", obj2)
+		}
+		return reflect.DeepEqual(host1.Spec, host2.Spec) && reflect.DeepEqual(host1.Status, host2.Status), nil
 	}
 
 	return false, fmt.Errorf("no object comparison for type %s", objType)
@@ -168,6 +178,9 @@ func (h *baseNetworkControllerEventHandler) getResourceFromInformerCache(objType
 	case factory.MultiNetworkPolicyType:
 		obj, err = watchFactory.GetMultiNetworkPolicy(namespace, name)
 
+	case factory.HostType:
+		obj, err = watchFactory.GetHost(name)
+
 	default:
 		err = fmt.Errorf("object type %s not supported, cannot retrieve it from informers cache",
 			objType)
@@ -200,6 +213,24 @@ func needsUpdateDuringRetry(objType reflect.Type) bool {
 	return false
 }
 
+// maxFailedAttempts returns the error budget of the objects of the given
+// resource type, zero for the default budget of the retry framework.
+func maxFailedAttempts(objType reflect.Type) uint8 {
+	switch objType {
+	case factory.NodeType,
+		factory.LocalPodSelectorType:
+		// a node is set up once ovnkube-node published its chassis and gateway
+		// config, and the local pods of a policy once their logical switch
+		// port exists, which can take a while on a node that is starting
+		return 3 * retry.MaxFailedAttempts
+	case factory.EgressFirewallType:
+		// an egress firewall rejected by OVN, like one with an invalid rule,
+		// is only fixed by an update, which resumes its retries
+		return 5
+	}
+	return 0
+}
+
 // IsObjectInTerminalState returns true if the object is in a terminal state.
 func (h *baseNetworkControllerEventHandler) isObjectInTerminalState(objType reflect.Type, obj interface{}) bool {
 	switch objType {
@@ -137,6 +137,12 @@ type BaseNetworkController struct {
 
 	podSelectorAddressSets *syncmap.SyncMap[*PodSelectorAddressSet]
 
+	// map of the port groups shared by the network policies of a namespace
+	// with equivalent pod selectors, when EnableSharedNetpolPortGroups is set
+	// key is namespace + pod selector, see getNetpolPortGroupKey
+	// allowed locking order is networkPolicy.Lock -> netpolPortGroups key Lock -> sharedNetpolPortGroups key Lock
+	netpolPortGroups *syncmap.SyncMap[*netpolPortGroup]
+
 	// stopChan per controller
 	stopChan chan struct{}
 	// waitGroup per-Controller
@@ -164,6 +170,12 @@ type BaseSecondaryNetworkController struct {
 	BaseNetworkController
 	// multi-network policy events factory handler
 	policyHandler *factory.Handler
+	// retry framework for the EgressIPs of a primary network
+	retryEgressIPs *ovnretry.RetryFramework
+	// EgressIP events factory handler
+	egressIPHandler *factory.Handler
+	// serializes the reconciliation of the EgressIPs of a primary network
+	udnEgressIPLock sync.Mutex
 }
 
 // NewCommonNetworkControllerInfo creates CommonNetworkControllerInfo shared by controllers
@@ -260,7 +272,7 @@ func (bnc *BaseNetworkController) syncNodeClusterRouterPort(node *kapi.Node, hos
 	}
 
 	if len(hostSubnets) == 0 {
-		hostSubnets, err = util.ParseNodeHostSubnetAnnotation(node, bnc.GetNetworkName())
+		hostSubnets, err = bnc.getNodeHostSubnets(node)
 		if err != nil {
 			return err
 		}
@@ -284,6 +296,12 @@ func (bnc *BaseNetworkController) syncNodeClusterRouterPort(node *kapi.Node, hos
 		gwIfAddr := util.GetNodeGatewayIfAddr(hostSubnet)
 		lrpNetworks = append(lrpNetworks, gwIfAddr.String())
 	}
+	// the health check port of the node is routed through the node switch
+	if !bnc.IsSecondary() {
+		for _, gwIfAddr := range getNodeHealthCheckGatewayIfAddrs(node) {
+			lrpNetworks = append(lrpNetworks, gwIfAddr.String())
+		}
+	}
 	logicalRouterPort := nbdb.LogicalRouterPort{
 		Name:     lrpName,
 		MAC:      nodeLRPMAC.String(),
@@ -331,7 +349,8 @@ func (bnc *BaseNetworkController) createNodeLogicalSwitch(nodeName string, hostS
 
 	var v4Gateway, v6Gateway net.IP
 	logicalSwitch.OtherConfig = map[string]string{}
-	for _, hostSubnet := range hostSubnets {
+	// the additional host subnets are only known to ovnkube-controller IPAM
+	for _, hostSubnet := range util.PrimaryHostSubnets(hostSubnets) {
 		gwIfAddr := util.GetNodeGatewayIfAddr(hostSubnet)
 		mgmtIfAddr := util.GetNodeManagementIfAddr(hostSubnet)
 
@@ -382,6 +401,10 @@ func (bnc *BaseNetworkController) createNodeLogicalSwitch(nodeName string, hostS
 		return fmt.Errorf("failed to add logical switch %+v: %v", logicalSwitch, err)
 	}
 
+	if err := bnc.ensureNetworkFirewall(switchName); err != nil {
+		return err
+	}
+
 	// Connect the switch to the router.
 	logicalSwitchPort := nbdb.LogicalSwitchPort{
 		Name:      types.SwitchToRouterPrefix + switchName,
@@ -2,17 +2,30 @@ package ovn
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
 	mnpapi "github.com/k8snetworkplumbingwg/multi-networkpolicy/pkg/apis/k8s.cni.cncf.io/v1beta1"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	addressset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/address_set"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
+	kapi "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
 	"k8s.io/klog/v2"
 )
 
 const PolicyForAnnotation = "k8s.v1.cni.cncf.io/policy-for"
 
+// multiPolicyIPBlockAddrSetThreshold is the number of ipBlocks without
+// exceptions of a multi-network policy gress from which they are matched with
+// an address set instead of with an ACL per ipBlock and protocol. The
+// multi-network policies of IPAM-less networks, that can only have ipBlock
+// peers, would otherwise create as many ACLs as allowed subnets.
+const multiPolicyIPBlockAddrSetThreshold = 4
+
 func (bsnc *BaseSecondaryNetworkController) syncMultiNetworkPolicies(multiPolicies []interface{}) error {
 	expectedPolicies := make(map[string]map[string]bool)
 	for _, npInterface := range multiPolicies {
@@ -34,7 +47,60 @@ func (bsnc *BaseSecondaryNetworkController) syncMultiNetworkPolicies(multiPolici
 		}
 	}
 
-	return bsnc.syncNetworkPoliciesCommon(expectedPolicies)
+	if err := bsnc.syncNetworkPoliciesCommon(expectedPolicies); err != nil {
+		return err
+	}
+	// the ACLs of the stale policies are deleted, the address sets of their
+	// ipBlocks are not referenced anymore
+	predicateIDs := libovsdbops.NewDbObjectIDs(libovsdbops.AddressSetNetpolIPBlock, bsnc.controllerName, nil)
+	if err := deleteAddrSetsWithoutACLRef(predicateIDs, bsnc.nbClient); err != nil {
+		return fmt.Errorf("failed to delete stale multi-network policy ipBlock address sets: %w", err)
+	}
+	return nil
+}
+
+// createMultiPolicyIPBlockAddrSetsOps returns the operations to create the
+// address sets of the ipBlocks of the multi-network policy gresses with at
+// least multiPolicyIPBlockAddrSetThreshold ipBlocks without exceptions, and
+// makes these gresses match them with a single ACL per protocol.
+func (bnc *BaseNetworkController) createMultiPolicyIPBlockAddrSetsOps(np *networkPolicy,
+	ops []ovsdb.Operation) ([]ovsdb.Operation, error) {
+	var err error
+	for _, gp := range append(append([]*gressPolicy{}, np.ingressPolicies...), np.egressPolicies...) {
+		v4CIDRs, v6CIDRs := gp.getIPBlockAddrSetCIDRs()
+		if len(v4CIDRs)+len(v6CIDRs) < multiPolicyIPBlockAddrSetThreshold {
+			continue
+		}
+		gp.ipBlockAddrSetDbIDs = gp.getIPBlockAddrSetDbIDs()
+		v4AddrSet, v6AddrSet := addressset.GetDbAddrSets(gp.ipBlockAddrSetDbIDs, v4CIDRs, v6CIDRs)
+		var addrSets []*nbdb.AddressSet
+		if len(v4CIDRs) > 0 {
+			addrSets = append(addrSets, v4AddrSet)
+		}
+		if len(v6CIDRs) > 0 {
+			addrSets = append(addrSets, v6AddrSet)
+		}
+		ops, err = libovsdbops.CreateOrUpdateAddressSetsOps(bnc.nbClient, ops, addrSets...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ipBlock address sets ops for multi-network policy %s: %v",
+				np.getKey(), err)
+		}
+	}
+	return ops, nil
+}
+
+// deleteMultiPolicyIPBlockAddrSets deletes the address sets of the ipBlocks of
+// the multi-network policy, its ACLs must already be deleted.
+func (bnc *BaseNetworkController) deleteMultiPolicyIPBlockAddrSets(np *networkPolicy) error {
+	predicateIDs := libovsdbops.NewDbObjectIDs(libovsdbops.AddressSetNetpolIPBlock, bnc.controllerName,
+		map[libovsdbops.ExternalIDKey]string{
+			libovsdbops.ObjectNameKey: getACLPolicyKey(np.namespace, np.name),
+		})
+	p := libovsdbops.GetPredicate[*nbdb.AddressSet](predicateIDs, nil)
+	if err := libovsdbops.DeleteAddressSetsWithPredicate(bnc.nbClient, p); err != nil {
+		return fmt.Errorf("failed to delete ipBlock address sets of multi-network policy %s: %v", np.getKey(), err)
+	}
+	return nil
 }
 
 func (bsnc *BaseSecondaryNetworkController) shouldApplyMultiPolicy(mpolicy *mnpapi.MultiNetworkPolicy) bool {
@@ -60,9 +126,48 @@ func (bsnc *BaseSecondaryNetworkController) shouldApplyMultiPolicy(mpolicy *mnpa
 	return false
 }
 
+// convertMultiNetPolicyPort converts a multi-network policy port to a network
+// policy port. As for network policies, the protocol defaults to TCP.
+func convertMultiNetPolicyPort(mport mnpapi.MultiNetworkPolicyPort) knet.NetworkPolicyPort {
+	protocol := kapi.ProtocolTCP
+	if mport.Protocol != nil {
+		protocol = *mport.Protocol
+	}
+	return knet.NetworkPolicyPort{
+		Protocol: &protocol,
+		Port:     mport.Port,
+	}
+}
+
+// convertMultiNetPolicyIPBlock converts a multi-network policy ipBlock to a
+// network policy ipBlock, validating it the same way the API server validates
+// network policy ipBlocks.
+func convertMultiNetPolicyIPBlock(mipb *mnpapi.IPBlock) (*knet.IPBlock, error) {
+	if mipb == nil {
+		return nil, nil
+	}
+	_, cidr, err := net.ParseCIDR(mipb.CIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipBlock cidr %q: %w", mipb.CIDR, err)
+	}
+	cidrLen, _ := cidr.Mask.Size()
+	for _, exceptCIDR := range mipb.Except {
+		_, except, err := net.ParseCIDR(exceptCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ipBlock except %q: %w", exceptCIDR, err)
+		}
+		exceptLen, _ := except.Mask.Size()
+		if !cidr.Contains(except.IP) || exceptLen <= cidrLen {
+			return nil, fmt.Errorf("ipBlock except %q must be a strict subset of cidr %q", exceptCIDR, mipb.CIDR)
+		}
+	}
+	return &knet.IPBlock{CIDR: mipb.CIDR, Except: mipb.Except}, nil
+}
+
 func convertMultiNetPolicyToNetPolicy(mpolicy *mnpapi.MultiNetworkPolicy, allowPeerSelectors bool) (*knet.NetworkPolicy, error) {
 	var policy knet.NetworkPolicy
 	var ipb *knet.IPBlock
+	var err error
 
 	policy.Name = mpolicy.Name
 	policy.Namespace = mpolicy.Namespace
@@ -73,19 +178,16 @@ func convertMultiNetPolicyToNetPolicy(mpolicy *mnpapi.MultiNetworkPolicy, allowP
 		var ingress knet.NetworkPolicyIngressRule
 		ingress.Ports = make([]knet.NetworkPolicyPort, len(mingress.Ports))
 		for j, mport := range mingress.Ports {
-			ingress.Ports[j] = knet.NetworkPolicyPort{
-				Protocol: mport.Protocol,
-				Port:     mport.Port,
-			}
+			ingress.Ports[j] = convertMultiNetPolicyPort(mport)
 		}
 		ingress.From = make([]knet.NetworkPolicyPeer, len(mingress.From))
 		for j, mfrom := range mingress.From {
 			if !allowPeerSelectors && isPeerSelector(mfrom) {
 				return nil, fmt.Errorf("invalid ingress peer %v in multi-network policy %s; IPAM-less networks can only have `ipBlock` peers", mfrom, mpolicy.Name)
 			}
-			ipb = nil
-			if mfrom.IPBlock != nil {
-				ipb = &knet.IPBlock{CIDR: mfrom.IPBlock.CIDR, Except: mfrom.IPBlock.Except}
+			ipb, err = convertMultiNetPolicyIPBlock(mfrom.IPBlock)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ingress peer in multi-network policy %s: %w", mpolicy.Name, err)
 			}
 			ingress.From[j] = knet.NetworkPolicyPeer{
 				PodSelector:       mfrom.PodSelector,
@@ -100,19 +202,16 @@ func convertMultiNetPolicyToNetPolicy(mpolicy *mnpapi.MultiNetworkPolicy, allowP
 		var egress knet.NetworkPolicyEgressRule
 		egress.Ports = make([]knet.NetworkPolicyPort, len(megress.Ports))
 		for j, mport := range megress.Ports {
-			egress.Ports[j] = knet.NetworkPolicyPort{
-				Protocol: mport.Protocol,
-				Port:     mport.Port,
-			}
+			egress.Ports[j] = convertMultiNetPolicyPort(mport)
 		}
 		egress.To = make([]knet.NetworkPolicyPeer, len(megress.To))
 		for j, mto := range megress.To {
 			if !allowPeerSelectors && isPeerSelector(mto) {
 				return nil, fmt.Errorf("invalid egress peer %v in multi-network policy %s; IPAM-less networks can only have `ipBlock` peers", mto, mpolicy.Name)
 			}
-			ipb = nil
-			if mto.IPBlock != nil {
-				ipb = &knet.IPBlock{CIDR: mto.IPBlock.CIDR, Except: mto.IPBlock.Except}
+			ipb, err = convertMultiNetPolicyIPBlock(mto.IPBlock)
+			if err != nil {
+				return nil, fmt.Errorf("invalid egress peer in multi-network policy %s: %w", mpolicy.Name, err)
 			}
 			egress.To[j] = knet.NetworkPolicyPeer{
 				PodSelector:       mto.PodSelector,
@@ -126,6 +225,14 @@ func convertMultiNetPolicyToNetPolicy(mpolicy *mnpapi.MultiNetworkPolicy, allowP
 	for i, mpolicytype := range mpolicy.Spec.PolicyTypes {
 		policy.Spec.PolicyTypes[i] = knet.PolicyType(mpolicytype)
 	}
+	if len(policy.Spec.PolicyTypes) == 0 {
+		// default the policy types the same way the API server does for
+		// network policies
+		policy.Spec.PolicyTypes = []knet.PolicyType{knet.PolicyTypeIngress}
+		if len(policy.Spec.Egress) > 0 {
+			policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, knet.PolicyTypeEgress)
+		}
+	}
 	return &policy, nil
 }
 
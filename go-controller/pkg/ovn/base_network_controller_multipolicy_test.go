@@ -4,8 +4,10 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	v1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/k8snetworkplumbingwg/multi-networkpolicy/pkg/apis/k8s.cni.cncf.io/v1beta1"
 )
@@ -27,7 +29,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 							},
 						},
 						Egress:      []netv1.NetworkPolicyEgressRule{},
-						PolicyTypes: []netv1.PolicyType{},
+						PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress},
 					},
 				}))
 	})
@@ -46,7 +48,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 								Ports: []netv1.NetworkPolicyPort{},
 							},
 						},
-						PolicyTypes: []netv1.PolicyType{},
+						PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress, netv1.PolicyTypeEgress},
 					},
 				}))
 	})
@@ -65,7 +67,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 							},
 						},
 						Egress:      []netv1.NetworkPolicyEgressRule{},
-						PolicyTypes: []netv1.PolicyType{},
+						PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress},
 					},
 				}))
 	})
@@ -84,7 +86,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 								Ports: []netv1.NetworkPolicyPort{},
 							},
 						},
-						PolicyTypes: []netv1.PolicyType{},
+						PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress, netv1.PolicyTypeEgress},
 					},
 				}))
 	})
@@ -101,7 +103,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 						},
 					},
 					Egress:      []netv1.NetworkPolicyEgressRule{},
-					PolicyTypes: []netv1.PolicyType{},
+					PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress},
 				},
 			},
 		))
@@ -119,7 +121,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 							Ports: []netv1.NetworkPolicyPort{},
 						},
 					},
-					PolicyTypes: []netv1.PolicyType{},
+					PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress, netv1.PolicyTypeEgress},
 				},
 			},
 		))
@@ -138,7 +140,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 							},
 						},
 						Egress:      []netv1.NetworkPolicyEgressRule{},
-						PolicyTypes: []netv1.PolicyType{},
+						PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress},
 					},
 				},
 			))
@@ -157,7 +159,7 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 								Ports: []netv1.NetworkPolicyPort{},
 							},
 						},
-						PolicyTypes: []netv1.PolicyType{},
+						PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress, netv1.PolicyTypeEgress},
 					},
 				},
 			))
@@ -186,6 +188,49 @@ var _ = Describe("convertMultiNetPolicyToNetPolicy", func() {
 		_, err := convertMultiNetPolicyToNetPolicy(multiNetPolicyWithEgressNamespaceSelector(policyName), allowPeerSelectors)
 		Expect(err).To(HaveOccurred())
 	})
+
+	It("keeps explicit policy types", func() {
+		allowPeerSelectors := true
+		mpolicy := multiNetPolicyWithEgressIPBlock()
+		mpolicy.Spec.PolicyTypes = []v1beta1.MultiPolicyType{v1beta1.PolicyTypeEgress}
+		policy, err := convertMultiNetPolicyToNetPolicy(mpolicy, allowPeerSelectors)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Spec.PolicyTypes).To(Equal([]netv1.PolicyType{netv1.PolicyTypeEgress}))
+	})
+
+	It("defaults the port protocol to TCP", func() {
+		allowPeerSelectors := true
+		port := intstr.FromInt(8080)
+		sctp := v1.ProtocolSCTP
+		mpolicy := multiNetPolicyWithIngressIPBlock()
+		mpolicy.Spec.Ingress[0].Ports = []v1beta1.MultiNetworkPolicyPort{
+			{Port: &port},
+			{Protocol: &sctp, Port: &port},
+		}
+		policy, err := convertMultiNetPolicyToNetPolicy(mpolicy, allowPeerSelectors)
+		Expect(err).NotTo(HaveOccurred())
+		tcp := v1.ProtocolTCP
+		Expect(policy.Spec.Ingress[0].Ports).To(Equal([]netv1.NetworkPolicyPort{
+			{Protocol: &tcp, Port: &port},
+			{Protocol: &sctp, Port: &port},
+		}))
+	})
+
+	It("*fails* to translate a policy with an invalid `ipBlock` cidr", func() {
+		allowPeerSelectors := true
+		mpolicy := multiNetPolicyWithIngressIPBlock()
+		mpolicy.Spec.Ingress[0].From[0].IPBlock.CIDR = "10.10.0.0"
+		_, err := convertMultiNetPolicyToNetPolicy(mpolicy, allowPeerSelectors)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("*fails* to translate a policy with an `ipBlock` except outside of the cidr", func() {
+		allowPeerSelectors := true
+		mpolicy := multiNetPolicyWithEgressIPBlock()
+		mpolicy.Spec.Egress[0].To[0].IPBlock.Except = []string{"10.20.0.0/24"}
+		_, err := convertMultiNetPolicyToNetPolicy(mpolicy, allowPeerSelectors)
+		Expect(err).To(HaveOccurred())
+	})
 })
 
 func sameLabelsEverywhere() *metav1.LabelSelector {
@@ -51,6 +51,10 @@ type namespaceInfo struct {
 	// key is <namespace>_<pod name>
 	routingExternalPodGWs map[string]gatewayInfo
 
+	// providerNetworkEgress is the provider network egress parsed from
+	// annotation k8s.ovn.org/provider-network-egress
+	providerNetworkEgress *util.ProviderNetworkEgress
+
 	multicastEnabled bool
 
 	// If not empty, then it has to be set to a logging a severity level, e.g. "notice", "alert", etc
@@ -100,6 +104,10 @@ func (bnc *BaseNetworkController) WatchNamespaces() error {
 //	ii) annotation == ""
 //	iii) annotation == "{}"
 //
+// *) "rateLimit" sets the maximum number of log messages per second for the namespace ACLs. If it is not present or
+//
+//	invalid, the cluster-wide rate limit is used.
+//
 // *) If one of "allow" or "deny" can be parsed and has a valid value, but the other key is not present in the
 //
 //	annotation, then assume that this key should be disabled by setting its nsInfo value to "".
@@ -114,6 +122,7 @@ func (bnc *BaseNetworkController) aclLoggingUpdateNsInfo(annotation string, nsIn
 			// Disable Allow and Deny logging to ensure idempotency.
 			nsInfo.aclLogging.Allow = ""
 			nsInfo.aclLogging.Deny = ""
+			nsInfo.aclLogging.RateLimit = nil
 			return fmt.Errorf("could not unmarshal namespace ACL annotation '%s', disabling logging, err: %q",
 				annotation, err)
 		}
@@ -141,6 +150,17 @@ func (bnc *BaseNetworkController) aclLoggingUpdateNsInfo(annotation string, nsIn
 		nsInfo.aclLogging.Allow = ""
 	}
 
+	// Set the rate limit, falling back to the cluster-wide rate limit.
+	nsInfo.aclLogging.RateLimit = nil
+	if aclLevels.RateLimit != nil {
+		if *aclLevels.RateLimit <= 0 {
+			errors = append(errors, fmt.Errorf("using the default rate limit due to an invalid rate limit annotation. "+
+				"%d is not a valid log rate limit, it must be greater than 0", *aclLevels.RateLimit))
+		} else {
+			nsInfo.aclLogging.RateLimit = aclLevels.RateLimit
+		}
+	}
+
 	return apierrors.NewAggregate(errors)
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"reflect"
 	"strings"
 	"time"
 
@@ -19,6 +20,8 @@ import (
 	kapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	ref "k8s.io/client-go/tools/reference"
 	"k8s.io/klog/v2"
 
 	libovsdbclient "github.com/ovn-org/libovsdb/client"
@@ -90,8 +93,8 @@ func (bnc *BaseNetworkController) deleteStaleLogicalSwitchPorts(expectedLogicalP
 
 	// get all switches that Pod logical port would be reside on.
 	topoType := bnc.TopologyType()
-	if !bnc.IsSecondary() || topoType == ovntypes.Layer3Topology {
-		// for default network and layer3 topology type networks, get all local zone node switches
+	if topoType == ovntypes.Layer3Topology {
+		// for layer3 topology type networks, get all local zone node switches
 		nodes, err := bnc.GetLocalZoneNodes()
 		if err != nil {
 			return fmt.Errorf("failed to get nodes: %v", err)
@@ -394,19 +397,19 @@ func (bnc *BaseNetworkController) podExpectedInLogicalCache(pod *kapi.Pod) bool
 }
 
 func (bnc *BaseNetworkController) getExpectedSwitchName(pod *kapi.Pod) (string, error) {
-	switchName := pod.Spec.NodeName
-	if bnc.IsSecondary() {
-		topoType := bnc.TopologyType()
-		switch topoType {
-		case ovntypes.Layer3Topology:
-			switchName = bnc.GetNetworkScopedName(pod.Spec.NodeName)
-		case ovntypes.Layer2Topology:
-			switchName = bnc.GetNetworkScopedName(ovntypes.OVNLayer2Switch)
-		case ovntypes.LocalnetTopology:
-			switchName = bnc.GetNetworkScopedName(ovntypes.OVNLocalnetSwitch)
-		default:
-			return "", fmt.Errorf("topology type %s not supported", topoType)
-		}
+	// the network scoped names of the default network are the plain names, so
+	// that the switches of its nodes are named after them
+	var switchName string
+	topoType := bnc.TopologyType()
+	switch topoType {
+	case ovntypes.Layer3Topology:
+		switchName = bnc.GetNetworkScopedName(pod.Spec.NodeName)
+	case ovntypes.Layer2Topology:
+		switchName = bnc.GetNetworkScopedName(ovntypes.OVNLayer2Switch)
+	case ovntypes.LocalnetTopology:
+		switchName = bnc.GetNetworkScopedName(ovntypes.OVNLocalnetSwitch)
+	default:
+		return "", fmt.Errorf("topology type %s not supported", topoType)
 	}
 	return switchName, nil
 }
@@ -429,6 +432,11 @@ func (bnc *BaseNetworkController) ensurePodAnnotation(pod *kapi.Pod, nadName str
 		_, zoneContainsPodSubnet := kubevirt.ZoneContainsPodSubnet(bnc.lsManager, podAnnotation)
 		return podAnnotation, zoneContainsPodSubnet, nil
 	}
+	// don't override a malformed, likely hand-edited, annotation
+	if err := util.ValidatePodAnnotation(pod.Annotations); err != nil {
+		bnc.recordInvalidPodAnnotationEvent(pod, err)
+		return nil, false, err
+	}
 	podAnnotation, err := util.UnmarshalPodAnnotation(pod.Annotations, nadName)
 	if err != nil {
 		return nil, true, nil
@@ -436,6 +444,19 @@ func (bnc *BaseNetworkController) ensurePodAnnotation(pod *kapi.Pod, nadName str
 	return podAnnotation, true, nil
 }
 
+// recordInvalidPodAnnotationEvent posts an event rejecting the malformed
+// annotation of the pod
+func (bnc *BaseNetworkController) recordInvalidPodAnnotationEvent(pod *kapi.Pod, err error) {
+	podRef, refErr := ref.GetReference(scheme.Scheme, pod)
+	if refErr != nil {
+		klog.Errorf("Couldn't get a reference to pod %s/%s to post an event: '%v'",
+			pod.Namespace, pod.Name, refErr)
+		return
+	}
+	bnc.recorder.Eventf(podRef, kapi.EventTypeWarning, "InvalidPodNetworksAnnotation",
+		"Rejected the %s annotation: %v", util.OvnPodAnnotationName, err)
+}
+
 func (bnc *BaseNetworkController) addLogicalPortToNetwork(pod *kapi.Pod, nadName string,
 	network *nadapi.NetworkSelectionElement) (ops []ovsdb.Operation,
 	lsp *nbdb.LogicalSwitchPort, podAnnotation *util.PodAnnotation, newlyCreatedPort bool, err error) {
@@ -560,7 +581,7 @@ func (bnc *BaseNetworkController) addLogicalPortToNetwork(pod *kapi.Pod, nadName
 	}
 
 	// CNI depends on the flows from port security, delay setting it until end
-	lsp.PortSecurity = addresses
+	lsp.PortSecurity = bnc.getLocalnetPassthroughPortSecurity(addresses, podAnnotation.IPs)
 
 	// On layer2 topology with interconnect, we need to add specific port config
 	if bnc.isLayer2Interconnect() {
@@ -609,6 +630,9 @@ func (bnc *BaseNetworkController) assignPodAddresses(switchName string) (net.Har
 	}
 	podCIDRs, err = bnc.lsManager.AllocateNextIPs(switchName)
 	if err != nil {
+		if errors.Is(err, ipallocator.ErrFull) {
+			bnc.reportExhaustedHostSubnets(switchName)
+		}
 		return nil, nil, err
 	}
 	if len(podCIDRs) > 0 {
@@ -617,6 +641,44 @@ func (bnc *BaseNetworkController) assignPodAddresses(switchName string) (net.Har
 	return podMAC, podCIDRs, nil
 }
 
+// reportExhaustedHostSubnets lists the host subnets of the node without free
+// IPs in the node exhausted subnets annotation for cluster manager to allocate
+// an additional host subnet to the node, if allowed. Only for the default
+// network, whose switches are named after the nodes.
+func (bnc *BaseNetworkController) reportExhaustedHostSubnets(nodeName string) {
+	if bnc.IsSecondary() || config.Default.MaxHostSubnetsPerNode <= 1 {
+		return
+	}
+	exhausted, err := bnc.lsManager.GetExhaustedSubnets(nodeName)
+	if err != nil || len(exhausted) == 0 {
+		return
+	}
+	node, err := bnc.watchFactory.GetNode(nodeName)
+	if err != nil {
+		klog.Warningf("Failed to get node %s to report its exhausted host subnets: %v", nodeName, err)
+		return
+	}
+	reported, _ := util.ParseNodeExhaustedSubnetAnnotation(node, bnc.GetNetworkName())
+	if reflect.DeepEqual(util.StringSlice(reported), util.StringSlice(exhausted)) {
+		return
+	}
+	annotations := map[string]string{}
+	for k, v := range node.Annotations {
+		annotations[k] = v
+	}
+	if annotations, err = util.UpdateNodeExhaustedSubnetAnnotation(annotations, exhausted, bnc.GetNetworkName()); err != nil {
+		klog.Warningf("Failed to report the exhausted host subnets %v of node %s: %v", util.StringSlice(exhausted), nodeName, err)
+		return
+	}
+	klog.Infof("Host subnets %v of node %s are exhausted, requesting an additional host subnet",
+		util.StringSlice(exhausted), nodeName)
+	if err := bnc.UpdateNodeAnnotationWithRetry(nodeName, map[string]string{
+		util.OvnNodeExhaustedSubnetsAnnotation: annotations[util.OvnNodeExhaustedSubnetsAnnotation],
+	}); err != nil {
+		klog.Warningf("Failed to report the exhausted host subnets of node %s: %v", nodeName, err)
+	}
+}
+
 // Given a logical switch port and the switch on which it is scheduled, get all
 // addresses currently assigned to it including subnet masks.
 func (bnc *BaseNetworkController) getPortAddresses(switchName string, existingLSP *nbdb.LogicalSwitchPort) (net.HardwareAddr, []*net.IPNet, error) {
@@ -755,7 +817,10 @@ func (bnc *BaseNetworkController) allocatePodAnnotation(pod *kapi.Pod, existingL
 	var podMac net.HardwareAddr
 	var podIfAddrs []*net.IPNet
 
-	switchName := pod.Spec.NodeName
+	switchName, err := bnc.getExpectedSwitchName(pod)
+	if err != nil {
+		return nil, false, err
+	}
 
 	podAnnotation, zoneContainsPodSubnet, err := bnc.ensurePodAnnotation(pod, nadName)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	kapi "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	kerrorsutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
@@ -160,6 +161,11 @@ type networkPolicy struct {
 	egressPolicies  []*gressPolicy
 	isIngress       bool
 	isEgress        bool
+	// podSelector selects the local pods of the policy
+	podSelector labels.Selector
+	// aclLogging overrides the namespace ACL logging levels for the policy
+	// ACLs, nil if the policy doesn't set its own
+	aclLogging *libovsdbutil.ACLLoggingLevels
 
 	// network policy owns only 1 local pod handler
 	localPodHandler *factory.Handler
@@ -179,6 +185,9 @@ type networkPolicy struct {
 	localPods sync.Map
 
 	portGroupName string
+	// portGroupKey is the key of the port group in bnc.netpolPortGroups when
+	// the port group is shared with other policies, empty otherwise
+	portGroupKey string
 	// this is a signal for related event handlers that they are/should be stopped.
 	// it will be set to true before any networkPolicy infrastructure is deleted,
 	// therefore every handler can either do its work and be sure all required resources are there,
@@ -191,6 +200,12 @@ type networkPolicy struct {
 
 func NewNetworkPolicy(policy *knet.NetworkPolicy) *networkPolicy {
 	policyTypeIngress, policyTypeEgress := getPolicyType(policy)
+	// NetworkPolicy is validated by the apiserver, the local pod handler will
+	// report an invalid selector
+	podSelector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		podSelector = labels.Nothing()
+	}
 	np := &networkPolicy{
 		name:            policy.Name,
 		namespace:       policy.Namespace,
@@ -198,12 +213,37 @@ func NewNetworkPolicy(policy *knet.NetworkPolicy) *networkPolicy {
 		egressPolicies:  make([]*gressPolicy, 0),
 		isIngress:       policyTypeIngress,
 		isEgress:        policyTypeEgress,
+		podSelector:     podSelector,
 		nsHandlerList:   make([]*factory.Handler, 0),
 		localPods:       sync.Map{},
 	}
+	if annotation, ok := policy.Annotations[util.AclLoggingAnnotation]; ok {
+		np.aclLogging, err = libovsdbutil.ParseACLLoggingAnnotation(annotation)
+		if err != nil {
+			klog.Warningf("Network policy %s/%s: ignoring malformed ACL logging annotation, "+
+				"using the namespace ACL logging, err: %v", policy.Namespace, policy.Name, err)
+		}
+	}
 	return np
 }
 
+// getACLLogging returns the ACL logging levels of the policy ACLs: the
+// namespace levels, overridden by the ones set on the policy. Policy ACLs
+// only allow traffic, deny logging is set by the namespace default deny ACLs.
+func (np *networkPolicy) getACLLogging(nsACLLogging *libovsdbutil.ACLLoggingLevels) *libovsdbutil.ACLLoggingLevels {
+	if np.aclLogging == nil {
+		return nsACLLogging
+	}
+	aclLogging := *nsACLLogging
+	if np.aclLogging.Allow != "" {
+		aclLogging.Allow = np.aclLogging.Allow
+	}
+	if np.aclLogging.RateLimit != nil {
+		aclLogging.RateLimit = np.aclLogging.RateLimit
+	}
+	return &aclLogging
+}
+
 // syncNetworkPoliciesCommon syncs logical entities associated with existing network policies.
 // It serves both networkpolicies (for default network) and multi-networkpolicies (for secondary networks)
 func (bnc *BaseNetworkController) syncNetworkPoliciesCommon(expectedPolicies map[string]map[string]bool) error {
@@ -221,6 +261,7 @@ func (bnc *BaseNetworkController) syncNetworkPoliciesCommon(expectedPolicies map
 		return fmt.Errorf("cannot find NetworkPolicy ACLs: %v", err)
 	}
 	stalePGs := sets.Set[string]{}
+	staleACLs := map[string]*nbdb.ACL{}
 	for _, netpolACL := range netpolACLs {
 		// policy-owned acl
 		namespace, policyName, err := parseACLPolicyKey(netpolACL.ExternalIDs[libovsdbops.ObjectNameKey.String()])
@@ -231,6 +272,42 @@ func (bnc *BaseNetworkController) syncNetworkPoliciesCommon(expectedPolicies map
 			// policy doesn't exist on k8s, cleanup
 			portGroupName, _ := bnc.getNetworkPolicyPGName(namespace, policyName)
 			stalePGs.Insert(portGroupName)
+			staleACLs[netpolACL.UUID] = netpolACL
+		}
+	}
+	// the acls of the stale policies may be applied to port groups shared with
+	// existing policies, delete them from these port groups
+	var ops []ovsdb.Operation
+	if len(staleACLs) > 0 {
+		sharedPGs, err := libovsdbops.FindPortGroupsWithPredicate(bnc.nbClient, func(pg *nbdb.PortGroup) bool {
+			if stalePGs.Has(pg.Name) {
+				return false
+			}
+			for _, uuid := range pg.ACLs {
+				if staleACLs[uuid] != nil {
+					return true
+				}
+			}
+			return false
+		})
+		if err != nil {
+			return fmt.Errorf("cannot find the port groups of stale NetworkPolicy ACLs: %v", err)
+		}
+		for _, pg := range sharedPGs {
+			var pgStaleACLs []*nbdb.ACL
+			for _, uuid := range pg.ACLs {
+				if staleACLs[uuid] != nil {
+					pgStaleACLs = append(pgStaleACLs, staleACLs[uuid])
+				}
+			}
+			if len(pgStaleACLs) == len(pg.ACLs) {
+				stalePGs.Insert(pg.Name)
+				continue
+			}
+			ops, err = libovsdbops.DeleteACLsFromPortGroupOps(bnc.nbClient, ops, pg.Name, pgStaleACLs...)
+			if err != nil {
+				return fmt.Errorf("failed to get delete stale ACLs from port group %s ops: %v", pg.Name, err)
+			}
 		}
 	}
 	// default deny port groups
@@ -249,18 +326,31 @@ func (bnc *BaseNetworkController) syncNetworkPoliciesCommon(expectedPolicies map
 			stalePGs.Insert(bnc.defaultDenyPortGroupName(namespace, egressDefaultDenySuffix))
 		}
 	}
-	if len(stalePGs) > 0 {
-		sets.List[string](stalePGs)
-		err = libovsdbops.DeletePortGroups(bnc.nbClient, sets.List[string](stalePGs)...)
+	if len(stalePGs) > 0 || len(ops) > 0 {
+		ops, err = libovsdbops.DeletePortGroupsOps(bnc.nbClient, ops, sets.List[string](stalePGs)...)
+		if err == nil {
+			_, err = libovsdbops.TransactAndCheck(bnc.nbClient, ops)
+		}
 		if err != nil {
 			return fmt.Errorf("error removing stale port groups %v: %v", stalePGs, err)
 		}
 		klog.Infof("Network policy sync cleaned up %d stale port groups", len(stalePGs))
 	}
 
+	// the ACL logging meters of the deleted objects are not referenced anymore
+	if err = libovsdbutil.DeleteUnusedACLLoggingMeters(bnc.nbClient); err != nil {
+		return fmt.Errorf("failed to delete unused ACL logging meters: %v", err)
+	}
+
 	return nil
 }
 
+// getACLLoggingMeterRefKey returns the key with which the object of the given
+// owner type references the ACL logging meter used by its ACLs
+func (bnc *BaseNetworkController) getACLLoggingMeterRefKey(ownerType, name string) string {
+	return bnc.controllerName + ":" + ownerType + ":" + name
+}
+
 func getAllowFromNodeACLDbIDs(nodeName, mgmtPortIP, controller string) *libovsdbops.DbObjectIDs {
 	return libovsdbops.NewDbObjectIDs(libovsdbops.ACLNetpolNode, controller,
 		map[libovsdbops.ExternalIDKey]string{
@@ -375,6 +465,10 @@ func (bnc *BaseNetworkController) createDefaultDenyPGAndACLs(namespace, policy s
 	ingressDenyACL, ingressAllowACL := bnc.buildDenyACLs(namespace, ingressPGName, aclLogging, libovsdbutil.ACLIngress)
 	egressPGName := bnc.defaultDenyPortGroupName(namespace, egressDefaultDenySuffix)
 	egressDenyACL, egressAllowACL := bnc.buildDenyACLs(namespace, egressPGName, aclLogging, libovsdbutil.ACLEgress)
+	meterRefKey := bnc.getACLLoggingMeterRefKey(string(libovsdbops.NetpolNamespaceOwnerType), namespace)
+	if err := libovsdbutil.AddACLLoggingMeterRef(bnc.nbClient, meterRefKey, aclLogging); err != nil {
+		return err
+	}
 	ops, err := libovsdbops.CreateOrUpdateACLsOps(bnc.nbClient, nil, ingressDenyACL, ingressAllowACL, egressDenyACL, egressAllowACL)
 	if err != nil {
 		return err
@@ -416,8 +510,8 @@ func (bnc *BaseNetworkController) deleteDefaultDenyPGAndACLs(namespace string) e
 	if err != nil {
 		return fmt.Errorf("failed to transact deleteDefaultDenyPGAndACLs: %v", err)
 	}
-
-	return nil
+	meterRefKey := bnc.getACLLoggingMeterRefKey(string(libovsdbops.NetpolNamespaceOwnerType), namespace)
+	return libovsdbutil.ReleaseACLLoggingMeterRefs(bnc.nbClient, meterRefKey, nil)
 }
 
 // must be called with namespace lock
@@ -433,7 +527,15 @@ func (bnc *BaseNetworkController) updateACLLoggingForPolicy(np *networkPolicy, a
 		libovsdbops.ObjectNameKey: getACLPolicyKey(np.namespace, np.name),
 	})
 	p := libovsdbops.GetPredicate[*nbdb.ACL](predicateIDs, nil)
-	return libovsdbutil.UpdateACLLoggingWithPredicate(bnc.nbClient, p, aclLogging)
+	policyACLLogging := np.getACLLogging(aclLogging)
+	meterRefKey := bnc.getACLLoggingMeterRefKey(string(libovsdbops.NetworkPolicyOwnerType), np.getKey())
+	if err := libovsdbutil.AddACLLoggingMeterRef(bnc.nbClient, meterRefKey, policyACLLogging); err != nil {
+		return err
+	}
+	if err := libovsdbutil.UpdateACLLoggingWithPredicate(bnc.nbClient, p, policyACLLogging); err != nil {
+		return err
+	}
+	return libovsdbutil.ReleaseACLLoggingMeterRefs(bnc.nbClient, meterRefKey, policyACLLogging)
 }
 
 func (bnc *BaseNetworkController) updateACLLoggingForDefaultACLs(ns string, nsInfo *namespaceInfo) error {
@@ -453,10 +555,14 @@ func (bnc *BaseNetworkController) updateACLLoggingForDefaultACLs(ns string, nsIn
 		if err != nil {
 			return fmt.Errorf("failed to find netpol default deny acls for namespace %s: %v", ns, err)
 		}
+		meterRefKey := bnc.getACLLoggingMeterRefKey(string(libovsdbops.NetpolNamespaceOwnerType), ns)
+		if err := libovsdbutil.AddACLLoggingMeterRef(bnc.nbClient, meterRefKey, &nsInfo.aclLogging); err != nil {
+			return fmt.Errorf("unable to create ACL logging meter for namespace %s: %w", ns, err)
+		}
 		if err := libovsdbutil.UpdateACLLogging(bnc.nbClient, defaultDenyACLs, &nsInfo.aclLogging); err != nil {
 			return fmt.Errorf("unable to update ACL logging for namespace %s: %w", ns, err)
 		}
-		return nil
+		return libovsdbutil.ReleaseACLLoggingMeterRefs(bnc.nbClient, meterRefKey, &nsInfo.aclLogging)
 	})
 }
 
@@ -762,6 +868,98 @@ func (bnc *BaseNetworkController) handleLocalPodSelectorAddFunc(np *networkPolic
 	return nil
 }
 
+// planNewLocalPodPolicyPorts adds the operations to add the newly created
+// logical switch port of a local pod to the port groups of the network policies
+// selecting the pod, and to the default deny port groups of its namespace, to
+// the transaction that creates the port. That way the pod is never reachable
+// before the policies are applied to it. Policies whose resources are not
+// created yet are skipped, their local pod handlers will add the pod.
+// The policies and the default deny port groups of the namespace stay locked
+// until the transaction is committed or aborted.
+func (bnc *BaseNetworkController) planNewLocalPodPolicyPorts(planner *txnPlanner, pod *kapi.Pod,
+	lsp *nbdb.LogicalSwitchPort) error {
+	podLabels := labels.Set(pod.Labels)
+	nps := []*networkPolicy{}
+	unlockPolicies := func() {
+		for _, np := range nps {
+			np.RUnlock()
+		}
+	}
+	for _, npKey := range bnc.networkPolicies.GetKeys() {
+		if !strings.HasPrefix(npKey, pod.Namespace+"/") {
+			continue
+		}
+		np, ok := bnc.networkPolicies.Load(npKey)
+		if !ok || !np.podSelector.Matches(podLabels) {
+			continue
+		}
+		np.RLock()
+		if np.deleted || np.portGroupName == "" {
+			np.RUnlock()
+			continue
+		}
+		nps = append(nps, np)
+	}
+	if len(nps) == 0 {
+		return nil
+	}
+
+	pgKey := pod.Namespace
+	bnc.sharedNetpolPortGroups.LockKey(pgKey)
+	sharedPGs, ok := bnc.sharedNetpolPortGroups.Load(pgKey)
+	if !ok {
+		bnc.sharedNetpolPortGroups.UnlockKey(pgKey)
+		unlockPolicies()
+		return fmt.Errorf("port groups for ns %s don't exist", pod.Namespace)
+	}
+	unlock := func() {
+		bnc.sharedNetpolPortGroups.UnlockKey(pgKey)
+		unlockPolicies()
+	}
+
+	// the port UUID is a named UUID until the transaction is committed
+	portNamesToUUIDs := map[string]string{lsp.Name: lsp.UUID}
+	ingressDenyPGName := bnc.defaultDenyPortGroupName(pod.Namespace, ingressDefaultDenySuffix)
+	egressDenyPGName := bnc.defaultDenyPortGroupName(pod.Namespace, egressDefaultDenySuffix)
+	planned := []*networkPolicy{}
+	// counters were updated, update back to initial values on abort
+	rollback := func() {
+		for _, np := range planned {
+			sharedPGs.deletePortsForPolicy(np, portNamesToUUIDs)
+		}
+		unlock()
+	}
+	var ops []ovsdb.Operation
+	var err error
+	for _, np := range nps {
+		if ops, err = libovsdbops.AddPortsToPortGroupOps(bnc.nbClient, ops, np.portGroupName, lsp.UUID); err != nil {
+			break
+		}
+		ingressDenyPorts, egressDenyPorts := sharedPGs.addPortsForPolicy(np, portNamesToUUIDs)
+		planned = append(planned, np)
+		if ops, err = libovsdbops.AddPortsToPortGroupOps(bnc.nbClient, ops, ingressDenyPGName, ingressDenyPorts...); err != nil {
+			break
+		}
+		if ops, err = libovsdbops.AddPortsToPortGroupOps(bnc.nbClient, ops, egressDenyPGName, egressDenyPorts...); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		rollback()
+		return fmt.Errorf("unable to get ops to add port %s to network policy port groups: %v", lsp.Name, err)
+	}
+
+	planner.addParticipant(ops,
+		func() {
+			for _, np := range planned {
+				np.localPods.Store(lsp.Name, lsp.UUID)
+			}
+			unlock()
+		},
+		rollback)
+	return nil
+}
+
 // handleLocalPodSelectorDelFunc handles delete event for local pod, should be retriable
 func (bnc *BaseNetworkController) handleLocalPodSelectorDelFunc(np *networkPolicy, objs ...interface{}) error {
 	if !bnc.IsSecondary() && config.Metrics.EnableScaleMetrics {
@@ -985,20 +1183,31 @@ func (bnc *BaseNetworkController) createNetworkPolicy(policy *knet.NetworkPolicy
 
 		// 4. Build policy ACLs and port group. All the local pods that this policy
 		// selects will be eventually added to this port group.
-		portGroupName, readableGroupName := bnc.getNetworkPolicyPGName(policy.Namespace, policy.Name)
-		np.portGroupName = portGroupName
+		// The port group may be shared with the other policies of the namespace
+		// with an equivalent pod selector.
+		np.portGroupName, _ = bnc.getNetworkPolicyPGName(policy.Namespace, policy.Name)
+		if config.OVNKubernetesFeature.EnableSharedNetpolPortGroups {
+			np.portGroupKey = getNetpolPortGroupKey(policy.Namespace, &policy.Spec.PodSelector)
+			np.portGroupName, _ = bnc.getSharedNetworkPolicyPGName(np.portGroupKey)
+		}
 		ops := []ovsdb.Operation{}
 
-		acls := bnc.buildNetworkPolicyACLs(np, aclLogging)
-		ops, err = libovsdbops.CreateOrUpdateACLsOps(bnc.nbClient, ops, acls...)
-		if err != nil {
-			return fmt.Errorf("failed to create ACL ops: %v", err)
+		if bnc.IsSecondary() {
+			ops, err = bnc.createMultiPolicyIPBlockAddrSetsOps(np, ops)
+			if err != nil {
+				return err
+			}
 		}
 
-		pg := bnc.buildPortGroup(np.portGroupName, readableGroupName, nil, acls)
-		ops, err = libovsdbops.CreateOrUpdatePortGroupsOps(bnc.nbClient, ops, pg)
+		policyACLLogging := np.getACLLogging(aclLogging)
+		meterRefKey := bnc.getACLLoggingMeterRefKey(string(libovsdbops.NetworkPolicyOwnerType), np.getKey())
+		if err = libovsdbutil.AddACLLoggingMeterRef(bnc.nbClient, meterRefKey, policyACLLogging); err != nil {
+			return err
+		}
+		acls := bnc.buildNetworkPolicyACLs(np, policyACLLogging)
+		ops, err = libovsdbops.CreateOrUpdateACLsOps(bnc.nbClient, ops, acls...)
 		if err != nil {
-			return fmt.Errorf("failed to create ops to add port to a port group: %v", err)
+			return fmt.Errorf("failed to create ACL ops: %v", err)
 		}
 
 		var recordOps []ovsdb.Operation
@@ -1009,9 +1218,8 @@ func (bnc *BaseNetworkController) createNetworkPolicy(policy *knet.NetworkPolicy
 		}
 		ops = append(ops, recordOps...)
 
-		_, err = libovsdbops.TransactAndCheck(bnc.nbClient, ops)
-		if err != nil {
-			return fmt.Errorf("failed to run ovsdb txn to add ports to port group: %v", err)
+		if err = bnc.createNetworkPolicyPortGroup(np, &policy.Spec.PodSelector, acls, ops); err != nil {
+			return err
 		}
 		txOkCallBack()
 
@@ -1067,7 +1275,7 @@ func (bnc *BaseNetworkController) setupGressPolicy(np *networkPolicy, gp *gressP
 	podSel, _ := metav1.LabelSelectorAsSelector(podSelector)
 	nsSel, _ := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
 
-	if podSel.Empty() && (peer.NamespaceSelector == nil || !nsSel.Empty()) {
+	if podSel.Empty() && (peer.NamespaceSelector == nil || !nsSel.Empty()) && !bnc.useSharedNamespaceSelectorPeer(peer) {
 		// namespace-based filtering
 		if peer.NamespaceSelector == nil {
 			// nil namespace selector means same namespace
@@ -1139,6 +1347,7 @@ func (bnc *BaseNetworkController) addNetworkPolicy(policy *knet.NetworkPolicy) e
 	var np *networkPolicy
 	var err error
 
+	compileStart := time.Now()
 	np, err = bnc.createNetworkPolicy(policy, &aclLogging)
 	defer func() {
 		if err != nil {
@@ -1162,6 +1371,7 @@ func (bnc *BaseNetworkController) addNetworkPolicy(policy *knet.NetworkPolicy) e
 	if err != nil {
 		return fmt.Errorf("failed to create Network Policy %s: %v", npKey, err)
 	}
+	metrics.RecordNetworkPolicyCompiled(bnc.GetNetworkName(), time.Since(compileStart))
 	klog.Infof("Create network policy %s resources completed, update namespace loglevel", npKey)
 
 	// 3. lock namespace
@@ -1179,7 +1389,8 @@ func (bnc *BaseNetworkController) addNetworkPolicy(policy *knet.NetworkPolicy) e
 	// 4. check if namespace information related to network policy has changed,
 	// network policy only reacts to namespace update ACL log level.
 	// Run handleNetPolNamespaceUpdate sequence, but only for 1 newly added policy.
-	if nsInfo.aclLogging.Deny != aclLogging.Deny {
+	rateLimitChanged := libovsdbutil.GetACLLoggingMeterName(&nsInfo.aclLogging) != libovsdbutil.GetACLLoggingMeterName(&aclLogging)
+	if nsInfo.aclLogging.Deny != aclLogging.Deny || rateLimitChanged {
 		if err = bnc.updateACLLoggingForDefaultACLs(policy.Namespace, nsInfo); err != nil {
 			return fmt.Errorf("network policy %s failed to be created: update default deny ACLs failed: %v", npKey, err)
 		} else {
@@ -1187,7 +1398,7 @@ func (bnc *BaseNetworkController) addNetworkPolicy(policy *knet.NetworkPolicy) e
 				npKey, nsInfo.aclLogging.Deny, nsInfo.aclLogging.Allow)
 		}
 	}
-	if nsInfo.aclLogging.Allow != aclLogging.Allow {
+	if nsInfo.aclLogging.Allow != aclLogging.Allow || rateLimitChanged {
 		if err = bnc.updateACLLoggingForPolicy(np, &nsInfo.aclLogging); err != nil {
 			return fmt.Errorf("network policy %s failed to be created: update policy ACLs failed: %v", npKey, err)
 		} else {
@@ -1269,10 +1480,15 @@ func (bnc *BaseNetworkController) cleanupNetworkPolicy(np *networkPolicy) error
 	bnc.shutdownHandlers(np)
 	var err error
 
-	// Delete the port group, idempotent
-	ops, err := libovsdbops.DeletePortGroupsOps(bnc.nbClient, nil, np.portGroupName)
+	// Delete the port group, or the policy ACLs from the port group shared
+	// with other policies, idempotent
+	if np.portGroupKey != "" {
+		bnc.netpolPortGroups.LockKey(np.portGroupKey)
+		defer bnc.netpolPortGroups.UnlockKey(np.portGroupKey)
+	}
+	ops, pgDeleted, err := bnc.deleteNetworkPolicyPortGroupOps(np, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get delete network policy port group %s ops: %v", np.portGroupName, err)
+		return err
 	}
 	recordOps, txOkCallBack, _, err := bnc.AddConfigDurationRecord("networkpolicy", np.namespace, np.name)
 	if err != nil {
@@ -1286,8 +1502,14 @@ func (bnc *BaseNetworkController) cleanupNetworkPolicy(np *networkPolicy) error
 	}
 	// transaction was successful, exec callback
 	txOkCallBack()
+	pgDeleted()
 	// cleanup local pods, since they were deleted from port groups
 	np.localPods = sync.Map{}
+	// the policy ACLs were deleted with the port groups
+	meterRefKey := bnc.getACLLoggingMeterRefKey(string(libovsdbops.NetworkPolicyOwnerType), npKey)
+	if err = libovsdbutil.ReleaseACLLoggingMeterRefs(bnc.nbClient, meterRefKey, nil); err != nil {
+		return fmt.Errorf("unable to release ACL logging meters: %v", err)
+	}
 
 	err = bnc.delPolicyFromDefaultPortGroups(np)
 	if err != nil {
@@ -1305,6 +1527,12 @@ func (bnc *BaseNetworkController) cleanupNetworkPolicy(np *networkPolicy) error
 	}
 	np.peerAddressSets = nil
 
+	if bnc.IsSecondary() {
+		if err = bnc.deleteMultiPolicyIPBlockAddrSets(np); err != nil {
+			return err
+		}
+	}
+
 	// finally, delete netpol from existing networkPolicies
 	// this is the signal that cleanup was successful
 	bnc.networkPolicies.Delete(npKey)
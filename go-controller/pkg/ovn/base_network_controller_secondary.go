@@ -10,6 +10,7 @@ import (
 	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	libovsdbclient "github.com/ovn-org/libovsdb/client"
 	"github.com/ovn-org/libovsdb/ovsdb"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
@@ -51,7 +52,10 @@ func (bsnc *BaseSecondaryNetworkController) AddSecondaryNetworkResourceCommon(ob
 		if !ok {
 			return fmt.Errorf("could not cast %T object to *knet.Pod", obj)
 		}
-		return bsnc.ensurePodForSecondaryNetwork(pod, true)
+		if err := bsnc.ensurePodForSecondaryNetwork(pod, true); err != nil {
+			return err
+		}
+		return bsnc.ensurePodPrimaryNetworkEgress(pod)
 
 	case factory.NamespaceType:
 		ns, ok := obj.(*kapi.Namespace)
@@ -80,6 +84,13 @@ func (bsnc *BaseSecondaryNetworkController) AddSecondaryNetworkResourceCommon(ob
 			return err
 		}
 
+	case factory.EgressIPType:
+		eIP, ok := obj.(*egressipv1.EgressIP)
+		if !ok {
+			return fmt.Errorf("could not cast %T object to *egressipv1.EgressIP", obj)
+		}
+		return bsnc.ensureUDNEgressIP(eIP.Name)
+
 	default:
 		return fmt.Errorf("object type %s not supported", objType)
 	}
@@ -97,7 +108,10 @@ func (bsnc *BaseSecondaryNetworkController) UpdateSecondaryNetworkResourceCommon
 		oldPod := oldObj.(*kapi.Pod)
 		newPod := newObj.(*kapi.Pod)
 
-		return bsnc.ensurePodForSecondaryNetwork(newPod, inRetryCache || util.PodScheduled(oldPod) != util.PodScheduled(newPod))
+		if err := bsnc.ensurePodForSecondaryNetwork(newPod, inRetryCache || util.PodScheduled(oldPod) != util.PodScheduled(newPod)); err != nil {
+			return err
+		}
+		return bsnc.ensurePodPrimaryNetworkEgress(newPod)
 
 	case factory.NamespaceType:
 		oldNs, newNs := oldObj.(*kapi.Namespace), newObj.(*kapi.Namespace)
@@ -140,6 +154,13 @@ func (bsnc *BaseSecondaryNetworkController) UpdateSecondaryNetworkResourceCommon
 			}
 		}
 
+	case factory.EgressIPType:
+		eIP, ok := newObj.(*egressipv1.EgressIP)
+		if !ok {
+			return fmt.Errorf("could not cast %T object to *egressipv1.EgressIP", newObj)
+		}
+		return bsnc.ensureUDNEgressIP(eIP.Name)
+
 	default:
 		return fmt.Errorf("object type %s not supported", objType)
 	}
@@ -159,7 +180,10 @@ func (bsnc *BaseSecondaryNetworkController) DeleteSecondaryNetworkResourceCommon
 		if cachedObj != nil {
 			portInfoMap = cachedObj.(map[string]*lpInfo)
 		}
-		return bsnc.removePodForSecondaryNetwork(pod, portInfoMap)
+		if err := bsnc.removePodForSecondaryNetwork(pod, portInfoMap); err != nil {
+			return err
+		}
+		return bsnc.deletePodPrimaryNetworkEgress(pod)
 
 	case factory.NamespaceType:
 		ns := obj.(*kapi.Namespace)
@@ -181,6 +205,13 @@ func (bsnc *BaseSecondaryNetworkController) DeleteSecondaryNetworkResourceCommon
 			return err
 		}
 
+	case factory.EgressIPType:
+		eIP, ok := obj.(*egressipv1.EgressIP)
+		if !ok {
+			return fmt.Errorf("could not cast %T object to *egressipv1.EgressIP", obj)
+		}
+		return bsnc.ensureUDNEgressIP(eIP.Name)
+
 	default:
 		return fmt.Errorf("object type %s not supported", objType)
 	}
@@ -545,6 +576,19 @@ func (bsnc *BaseSecondaryNetworkController) WatchMultiNetworkPolicy() error {
 	return err
 }
 
+// WatchEgressIPs starts the watching of the EgressIPs of a primary network and
+// calls back the appropriate handler logic
+func (bsnc *BaseSecondaryNetworkController) WatchEgressIPs() error {
+	if bsnc.retryEgressIPs == nil || bsnc.egressIPHandler != nil {
+		return nil
+	}
+	handler, err := bsnc.retryEgressIPs.WatchResource()
+	if err == nil {
+		bsnc.egressIPHandler = handler
+	}
+	return err
+}
+
 // cleanupPolicyLogicalEntities cleans up all the port groups and addressset belongs to the given network
 func cleanupPolicyLogicalEntities(nbClient libovsdbclient.Client, ops []ovsdb.Operation, netName string) ([]ovsdb.Operation, error) {
 	var err error
@@ -172,6 +172,8 @@ func (oc *BaseSecondaryLayer2NetworkController) newRetryFramework(
 		HasUpdateFunc:          hasResourceAnUpdateFunc(objectType),
 		NeedsUpdateDuringRetry: needsUpdateDuringRetry(objectType),
 		ObjType:                objectType,
+		MaxFailedAttempts:      maxFailedAttempts(objectType),
+		NetworkName:            oc.GetNetworkName(),
 		EventHandler:           eventHandler,
 	}
 	return retry.NewRetryFramework(
@@ -212,6 +214,15 @@ func (oc *BaseSecondaryLayer2NetworkController) cleanup(topotype, netName string
 		return fmt.Errorf("failed to get ops for deleting switches of network %s: %v", netName, err)
 	}
 
+	// delete the routers of a primary layer 2 network
+	ops, err = libovsdbops.DeleteLogicalRoutersWithPredicateOps(oc.nbClient, ops,
+		func(item *nbdb.LogicalRouter) bool {
+			return item.ExternalIDs[types.NetworkExternalID] == netName
+		})
+	if err != nil {
+		return fmt.Errorf("failed to get ops for deleting routers of network %s: %v", netName, err)
+	}
+
 	ops, err = cleanupPolicyLogicalEntities(oc.nbClient, ops, netName)
 	if err != nil {
 		return err
@@ -219,7 +230,7 @@ func (oc *BaseSecondaryLayer2NetworkController) cleanup(topotype, netName string
 
 	_, err = libovsdbops.TransactAndCheck(oc.nbClient, ops)
 	if err != nil {
-		return fmt.Errorf("failed to deleting switches of network %s: %v", netName, err)
+		return fmt.Errorf("failed to deleting routers/switches of network %s: %v", netName, err)
 	}
 
 	return nil
@@ -282,6 +293,14 @@ func (oc *BaseSecondaryLayer2NetworkController) initializeLogicalSwitch(switchNa
 		return nil, fmt.Errorf("failed to create logical switch %+v: %v", logicalSwitch, err)
 	}
 
+	if err = oc.ensureNetworkFirewall(switchName); err != nil {
+		return nil, err
+	}
+
+	if err = oc.ensureLocalnetPassthrough(switchName); err != nil {
+		return nil, err
+	}
+
 	if err = oc.lsManager.AddOrUpdateSwitch(switchName, hostSubnets, excludeSubnets...); err != nil {
 		return nil, err
 	}
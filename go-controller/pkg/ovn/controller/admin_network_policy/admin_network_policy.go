@@ -131,6 +131,11 @@ func (c *Controller) ensureAdminNetworkPolicy(anp *anpapi.AdminNetworkPolicy) er
 	}
 	atLeastOneRuleUpdated := false
 	desiredACLs := c.getACLsOfRules(desiredANPState, currentANPState, portGroupName, &atLeastOneRuleUpdated, false)
+	// the ACL logging meter must exist before the ACLs use it
+	meterRefKey := c.getACLLoggingMeterRefKey(desiredANPState.name, false)
+	if err = libovsdbutil.AddACLLoggingMeterRef(c.nbClient, meterRefKey, desiredANPState.aclLoggingParams); err != nil {
+		return fmt.Errorf("failed to create ACL logging meter for anp %s: %v", desiredANPState.name, err)
+	}
 
 	if !loaded {
 		// this is a fresh ANP create
@@ -145,7 +150,7 @@ func (c *Controller) ensureAdminNetworkPolicy(anp *anpapi.AdminNetworkPolicy) er
 		c.anpPriorityMap[desiredANPState.anpPriority] = anp.Name
 		// since transact was successful we can finally populate the cache
 		c.anpCache[anp.Name] = desiredANPState
-		return nil
+		return c.releaseStaleACLLoggingMeterRefs(desiredANPState, false)
 	}
 	var ops []ovsdb.Operation
 	// ANP state existed in the cache, which means its either an ANP update or pod/namespace add/update/delete
@@ -262,6 +267,17 @@ func (c *Controller) ensureAdminNetworkPolicy(anp *anpapi.AdminNetworkPolicy) er
 	// since transact was successful we can finally replace the currentANPState in the cache with the latest desired one
 	// TODO(tssurya); check if c.Lock is enough to protect the c.anpCache or we can be more efficient here
 	c.anpCache[anp.Name] = desiredANPState
+	return c.releaseStaleACLLoggingMeterRefs(desiredANPState, false)
+}
+
+// releaseStaleACLLoggingMeterRefs releases the ACL logging meters that the ACLs
+// of the admin network policy don't use anymore
+func (c *Controller) releaseStaleACLLoggingMeterRefs(anpState *adminNetworkPolicyState, isBanp bool) error {
+	err := libovsdbutil.ReleaseACLLoggingMeterRefs(c.nbClient, c.getACLLoggingMeterRefKey(anpState.name, isBanp),
+		anpState.aclLoggingParams)
+	if err != nil {
+		return fmt.Errorf("failed to release stale ACL logging meters of %s: %v", anpState.name, err)
+	}
 	return nil
 }
 
@@ -276,7 +292,7 @@ func (c *Controller) getACLsOfRules(desiredANPState, currentANPState *adminNetwo
 		len(currentANPState.ingressRules) == len(desiredANPState.ingressRules) &&
 		len(currentANPState.egressRules) == len(desiredANPState.egressRules))
 	for i, ingressRule := range desiredANPState.ingressRules {
-		acl := c.convertANPRuleToACL(ingressRule, pgName, desiredANPState.name, isBanp)
+		acl := c.convertANPRuleToACL(ingressRule, pgName, desiredANPState.name, desiredANPState.aclLoggingParams, isBanp)
 		acls = append(acls, acl...)
 		if isAtLeastOneRuleUpdatedCheckRequired &&
 			!*atLeastOneRuleUpdated &&
@@ -286,7 +302,7 @@ func (c *Controller) getACLsOfRules(desiredANPState, currentANPState *adminNetwo
 		}
 	}
 	for i, egressRule := range desiredANPState.egressRules {
-		acl := c.convertANPRuleToACL(egressRule, pgName, desiredANPState.name, isBanp)
+		acl := c.convertANPRuleToACL(egressRule, pgName, desiredANPState.name, desiredANPState.aclLoggingParams, isBanp)
 		acls = append(acls, acl...)
 		if isAtLeastOneRuleUpdatedCheckRequired &&
 			!*atLeastOneRuleUpdated &&
@@ -295,13 +311,20 @@ func (c *Controller) getACLsOfRules(desiredANPState, currentANPState *adminNetwo
 			*atLeastOneRuleUpdated = true
 		}
 	}
+	// the logging of all the ACLs changes with the ACL logging annotation
+	if isAtLeastOneRuleUpdatedCheckRequired && !*atLeastOneRuleUpdated &&
+		!reflect.DeepEqual(desiredANPState.aclLoggingParams, currentANPState.aclLoggingParams) {
+		klog.V(3).Infof("ANP %s's ACL logging was updated", desiredANPState.name)
+		*atLeastOneRuleUpdated = true
+	}
 
 	return acls
 }
 
 // convertANPRuleToACL takes the given gressRule and converts it into an ACL(0 ports rule) or
 // multiple ACLs(ports are set) and returns those ACLs for a given gressRule
-func (c *Controller) convertANPRuleToACL(rule *gressRule, pgName, anpName string, isBanp bool) []*nbdb.ACL {
+func (c *Controller) convertANPRuleToACL(rule *gressRule, pgName, anpName string,
+	aclLogging *libovsdbutil.ACLLoggingLevels, isBanp bool) []*nbdb.ACL {
 	// create address-set
 	// TODO (tssurya): Revisit this logic to see if its better to do one address-set per peer
 	// and join them with OR if that is more perf efficient. Had briefly discussed this OVN team
@@ -325,6 +348,7 @@ func (c *Controller) convertANPRuleToACL(rule *gressRule, pgName, anpName string
 			match,
 			rule.action,
 			libovsdbutil.ACLDirectionToACLPipeline(libovsdbutil.ACLDirection(rule.gressPrefix)),
+			aclLogging,
 		)
 		acls = append(acls, acl)
 		return acls
@@ -345,6 +369,7 @@ func (c *Controller) convertANPRuleToACL(rule *gressRule, pgName, anpName string
 			match,
 			rule.action,
 			libovsdbutil.ACLDirectionToACLPipeline(libovsdbutil.ACLDirection(rule.gressPrefix)),
+			aclLogging,
 		)
 		acls = append(acls, acl)
 	}
@@ -504,6 +529,9 @@ func (c *Controller) clearAdminNetworkPolicy(anpName string) error {
 	if err != nil {
 		return fmt.Errorf("unable to delete PG %s for ANP %s: %w", readableGroupName, anp.name, err)
 	}
+	if err = libovsdbutil.ReleaseACLLoggingMeterRefs(c.nbClient, c.getACLLoggingMeterRefKey(anp.name, false), nil); err != nil {
+		return fmt.Errorf("failed to release ACL logging meters of ANP %s: %w", anp.name, err)
+	}
 	// remove address-sets that were created for the peers of each rule fpr the whole ANP
 	// do this after ACLs are gone so that there is no lingering references
 	err = c.clearASForPeers(anp.name, libovsdbops.AddressSetAdminNetworkPolicy)
@@ -0,0 +1,80 @@
+package adminnetworkpolicy
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	anpapi "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+func TestANPACLLogging(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+	config.IPv4Mode = true
+
+	newANP := func(annotations map[string]string) *anpapi.AdminNetworkPolicy {
+		peers := []anpapi.AdminNetworkPolicyPeer{{Namespaces: &anpapi.NamespacedPeer{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"house": "slytherin"}},
+		}}}
+		return &anpapi.AdminNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "harry-potter", Annotations: annotations},
+			Spec: anpapi.AdminNetworkPolicySpec{
+				Priority: 5,
+				Subject: anpapi.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"house": "gryffindor"},
+				}},
+				Ingress: []anpapi.AdminNetworkPolicyIngressRule{
+					{Name: "deny", Action: anpapi.AdminNetworkPolicyRuleActionDeny, From: peers},
+					{Name: "pass", Action: anpapi.AdminNetworkPolicyRuleActionPass, From: peers},
+				},
+				Egress: []anpapi.AdminNetworkPolicyEgressRule{
+					{Name: "allow", Action: anpapi.AdminNetworkPolicyRuleActionAllow, To: peers},
+				},
+			},
+		}
+	}
+	c := &Controller{controllerName: "default-network-controller"}
+
+	currentState, err := newAdminNetworkPolicyState(newANP(nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(currentState.aclLoggingParams).To(gomega.BeNil())
+
+	desiredState, err := newAdminNetworkPolicyState(newANP(map[string]string{
+		util.AclLoggingAnnotation: `{"allow": "info", "deny": "alert", "pass": "warning", "rateLimit": 30}`,
+	}))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	atLeastOneRuleUpdated := false
+	acls := c.getACLsOfRules(desiredState, currentState, "pg", &atLeastOneRuleUpdated, false)
+	// only the ACL logging changed, the ACLs must be updated
+	g.Expect(atLeastOneRuleUpdated).To(gomega.BeTrue())
+	g.Expect(acls).To(gomega.HaveLen(3))
+	expectedSeverities := map[string]string{
+		nbdb.ACLActionDrop:         nbdb.ACLSeverityAlert,
+		nbdb.ACLActionPass:         nbdb.ACLSeverityWarning,
+		nbdb.ACLActionAllowRelated: nbdb.ACLSeverityInfo,
+	}
+	for _, acl := range acls {
+		g.Expect(acl.Log).To(gomega.BeTrue())
+		g.Expect(acl.Severity).To(gomega.HaveValue(gomega.Equal(expectedSeverities[acl.Action])), acl.Action)
+		g.Expect(acl.Meter).To(gomega.HaveValue(gomega.Equal(types.OvnACLLoggingMeter + "-30")))
+	}
+
+	// a malformed annotation disables the logging
+	desiredState, err = newAdminNetworkPolicyState(newANP(map[string]string{
+		util.AclLoggingAnnotation: `{"allow": "loud"}`,
+	}))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(desiredState.aclLoggingParams).To(gomega.BeNil())
+	atLeastOneRuleUpdated = false
+	acls = c.getACLsOfRules(desiredState, currentState, "pg", &atLeastOneRuleUpdated, false)
+	g.Expect(atLeastOneRuleUpdated).To(gomega.BeFalse())
+	for _, acl := range acls {
+		g.Expect(acl.Log).To(gomega.BeFalse())
+		g.Expect(acl.Meter).To(gomega.HaveValue(gomega.Equal(types.OvnACLLoggingMeter)))
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ovn-org/libovsdb/ovsdb"
 	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	libovsdbutil "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/util"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
@@ -101,6 +102,9 @@ func (c *Controller) clearBaselineAdminNetworkPolicy(banpName string) error {
 	if err != nil {
 		return fmt.Errorf("unable to delete PG %s for BANP %s: %w", readableGroupName, banp.name, err)
 	}
+	if err = libovsdbutil.ReleaseACLLoggingMeterRefs(c.nbClient, c.getACLLoggingMeterRefKey(banp.name, true), nil); err != nil {
+		return fmt.Errorf("failed to release ACL logging meters of BANP %s: %w", banp.name, err)
+	}
 	// remove address-sets that were created for the peers of each rule fpr the whole ANP
 	// do this after ACLs are gone so that there is no lingering references
 	err = c.clearASForPeers(banp.name, libovsdbops.AddressSetBaselineAdminNetworkPolicy)
@@ -138,6 +142,11 @@ func (c *Controller) ensureBaselineAdminNetworkPolicy(banp *anpapi.BaselineAdmin
 	}
 	atLeastOneRuleUpdated := false
 	desiredACLs := c.getACLsOfRules(desiredBANPState, currentBANPState, portGroupName, &atLeastOneRuleUpdated, true)
+	// the ACL logging meter must exist before the ACLs use it
+	meterRefKey := c.getACLLoggingMeterRefKey(desiredBANPState.name, true)
+	if err = libovsdbutil.AddACLLoggingMeterRef(c.nbClient, meterRefKey, desiredBANPState.aclLoggingParams); err != nil {
+		return fmt.Errorf("failed to create ACL logging meter for banp %s: %v", desiredBANPState.name, err)
+	}
 
 	// Comparing names for figuring out if cache is populated or not is safe
 	// because the singleton BANP will always be called "default" in any cluster
@@ -152,7 +161,7 @@ func (c *Controller) ensureBaselineAdminNetworkPolicy(banp *anpapi.BaselineAdmin
 		}
 		// since transact was successful we can finally populate the cache
 		c.banpCache = desiredBANPState
-		return nil
+		return c.releaseStaleACLLoggingMeterRefs(desiredBANPState, true)
 	}
 	var ops []ovsdb.Operation
 	// BANP state existed in the cache, which means its either a BANP update or pod/namespace add/update/delete
@@ -255,5 +264,5 @@ func (c *Controller) ensureBaselineAdminNetworkPolicy(banp *anpapi.BaselineAdmin
 	}
 	// since transact was successful we can finally replace the currentBANPState in the cache with the latest desired one
 	c.banpCache = desiredBANPState
-	return nil
+	return c.releaseStaleACLLoggingMeterRefs(desiredBANPState, true)
 }
@@ -88,6 +88,9 @@ type adminNetworkPolicyState struct {
 	ingressRules []*gressRule
 	// egressRules stores the objects needed to track .Spec.Egress changes
 	egressRules []*gressRule
+	// aclLoggingParams stores the ACL logging levels set with the
+	// k8s.ovn.org/acl-logging annotation, nil if logging is disabled
+	aclLoggingParams *libovsdbutil.ACLLoggingLevels
 }
 
 // newAdminNetworkPolicyState takes the provided ANP API object and creates a new corresponding
@@ -100,6 +103,7 @@ func newAdminNetworkPolicyState(raw *anpapi.AdminNetworkPolicy) (*adminNetworkPo
 		ingressRules: make([]*gressRule, 0),
 		egressRules:  make([]*gressRule, 0),
 	}
+	anp.aclLoggingParams = getACLLoggingLevels(raw.Annotations, "ANP "+raw.Name)
 	var err error
 	anp.subject, err = newAdminNetworkPolicySubject(raw.Spec.Subject)
 	if err != nil {
@@ -300,6 +304,7 @@ func newBaselineAdminNetworkPolicyState(raw *anpapi.BaselineAdminNetworkPolicy)
 		ingressRules: make([]*gressRule, 0),
 		egressRules:  make([]*gressRule, 0),
 	}
+	banp.aclLoggingParams = getACLLoggingLevels(raw.Annotations, "BANP "+raw.Name)
 	var err error
 	banp.subject, err = newAdminNetworkPolicySubject(raw.Spec.Subject)
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
 	anpapi "sigs.k8s.io/network-policy-api/apis/v1alpha1"
 )
 
@@ -178,3 +179,28 @@ func constructMatchFromProtocolPorts(protocol string, ports *gressPolicyPorts) s
 	}
 	return l4Match
 }
+
+// getACLLoggingLevels returns the ACL logging levels set with the
+// k8s.ovn.org/acl-logging annotation of an admin network policy, a malformed
+// annotation disables the logging of its ACLs.
+func getACLLoggingLevels(annotations map[string]string, policyDesc string) *libovsdbutil.ACLLoggingLevels {
+	annotation, ok := annotations[util.AclLoggingAnnotation]
+	if !ok {
+		return nil
+	}
+	aclLogging, err := libovsdbutil.ParseACLLoggingAnnotation(annotation)
+	if err != nil {
+		klog.Warningf("%s: ignoring malformed ACL logging annotation, ACL logging is disabled: %v", policyDesc, err)
+		return nil
+	}
+	return aclLogging
+}
+
+// getACLLoggingMeterRefKey returns the key with which the admin network
+// policy references the ACL logging meter used by its ACLs
+func (c *Controller) getACLLoggingMeterRefKey(name string, isBanp bool) string {
+	if isBanp {
+		return c.controllerName + ":" + string(libovsdbops.BaselineAdminNetworkPolicyOwnerType) + ":" + name
+	}
+	return c.controllerName + ":" + string(libovsdbops.AdminNetworkPolicyOwnerType) + ":" + name
+}
@@ -0,0 +1,204 @@
+package ctzone
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// The conntrack zones of the logical switches and routers are allocated by
+// ovn-controller on each chassis, except for the zone the gateway routers
+// SNAT in, given by their snat-ct-zone option, and the zones of the OpenFlow
+// flows of the gateway bridge. This package allocates the SNAT zones of the
+// gateway routers and exports all the zones ovnkube assigns, to match the
+// conntrack entries of a node with the NAT they come from.
+
+// Path is the path of the metrics server where the conntrack zones are served
+const Path = "/conntrack-zones"
+
+// snatCTZoneOption is the option of a gateway router giving its SNAT zone
+const snatCTZoneOption = "snat-ct-zone"
+
+// Allocator allocates the dedicated SNAT conntrack zones of the gateway
+// routers. The gateway routers of a node, one per network, are bound to the
+// same chassis and must have different zones. The zones are persisted in the
+// snat-ct-zone option of the gateway routers and the zone a gateway router
+// already has is kept when free, so that they are stable across restarts.
+type Allocator struct {
+	sync.Mutex
+	min, max int
+	// zones are the zones of the gateway routers of each node
+	zones map[string]map[string]int
+}
+
+// NewAllocator returns an allocator of the conntrack zones from min to max
+func NewAllocator(min, max int) *Allocator {
+	return &Allocator{
+		min:   min,
+		max:   max,
+		zones: map[string]map[string]int{},
+	}
+}
+
+// Allocate returns the zone of the gateway router of the node: the zone
+// already allocated to it, else the requested zone if within the range and
+// free on the node, else the lowest free zone
+func (a *Allocator) Allocate(node, router string, requested int) (int, error) {
+	a.Lock()
+	defer a.Unlock()
+	routers := a.zones[node]
+	if routers == nil {
+		routers = map[string]int{}
+		a.zones[node] = routers
+	}
+	if zone, ok := routers[router]; ok {
+		return zone, nil
+	}
+	used := make(map[int]bool, len(routers))
+	for _, zone := range routers {
+		used[zone] = true
+	}
+	if requested >= a.min && requested <= a.max && !used[requested] {
+		routers[router] = requested
+		return requested, nil
+	}
+	for zone := a.min; zone <= a.max; zone++ {
+		if !used[zone] {
+			routers[router] = zone
+			return zone, nil
+		}
+	}
+	return 0, fmt.Errorf("no conntrack zone left in range %d-%d for gateway router %s of node %s", a.min, a.max, router, node)
+}
+
+// Release frees the zone of the gateway router of the node
+func (a *Allocator) Release(node, router string) {
+	a.Lock()
+	defer a.Unlock()
+	delete(a.zones[node], router)
+	if len(a.zones[node]) == 0 {
+		delete(a.zones, node)
+	}
+}
+
+// GetSNATZone returns the SNAT zone of the gateway router, -1 if it has none
+func GetSNATZone(lr *nbdb.LogicalRouter) int {
+	zone, err := strconv.Atoi(lr.Options[snatCTZoneOption])
+	if err != nil {
+		return -1
+	}
+	return zone
+}
+
+// SetSNATZone sets the SNAT zone of the gateway router
+func SetSNATZone(lr *nbdb.LogicalRouter, zone int) {
+	if lr.Options == nil {
+		lr.Options = map[string]string{}
+	}
+	lr.Options[snatCTZoneOption] = strconv.Itoa(zone)
+}
+
+// GatewayRouterZone is the SNAT conntrack zone of a gateway router
+type GatewayRouterZone struct {
+	Network  string `json:"network"`
+	Node     string `json:"node"`
+	Router   string `json:"router"`
+	SNATZone int    `json:"snatZone"`
+}
+
+// Zones are the conntrack zones assigned by ovnkube in the Northbound
+// database of a zone, and by the gateway bridge flows of every node
+type Zones struct {
+	Zone string `json:"zone"`
+	// SNATAllocation is how the SNAT zones of the gateway routers are
+	// assigned, see config.Gateway.SNATConntrackZoneAllocation
+	SNATAllocation string `json:"snatAllocation"`
+	// GatewayBridge are the zones of the gateway bridge flows by use
+	GatewayBridge  map[string]int      `json:"gatewayBridge"`
+	GatewayRouters []GatewayRouterZone `json:"gatewayRouters"`
+}
+
+// getGatewayRouterNode returns the node of the gateway router of the network,
+// an empty string if the router isn't a gateway router
+func getGatewayRouterNode(network, router string) string {
+	prefix := types.GWRouterPrefix
+	if network != types.DefaultNetworkName {
+		prefix = util.GetSecondaryNetworkPrefix(network) + prefix
+	}
+	if !strings.HasPrefix(router, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(router, prefix)
+}
+
+// ListZones returns the conntrack zones assigned by ovnkube, limited to the
+// gateway routers of the node if not empty
+func ListZones(nbClient libovsdbclient.Client, node string) (*Zones, error) {
+	routers, err := libovsdbops.FindLogicalRoutersWithPredicate(nbClient, func(lr *nbdb.LogicalRouter) bool {
+		_, ok := lr.Options[snatCTZoneOption]
+		return ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the gateway routers: %w", err)
+	}
+	zones := &Zones{
+		Zone:           config.Default.Zone,
+		SNATAllocation: config.Gateway.SNATConntrackZoneAllocation,
+		GatewayBridge: map[string]int{
+			"gateway":         config.Default.ConntrackZone,
+			"host-masquerade": config.Default.ConntrackZone + 1,
+			"ovn-masquerade":  config.Default.ConntrackZone + 2,
+			"host-nodeport":   config.Default.ConntrackZone + 3,
+		},
+		GatewayRouters: []GatewayRouterZone{},
+	}
+	for _, lr := range routers {
+		network := lr.ExternalIDs[types.NetworkExternalID]
+		if network == "" {
+			network = types.DefaultNetworkName
+		}
+		routerNode := getGatewayRouterNode(network, lr.Name)
+		if routerNode == "" || (node != "" && routerNode != node) {
+			continue
+		}
+		zones.GatewayRouters = append(zones.GatewayRouters, GatewayRouterZone{
+			Network:  network,
+			Node:     routerNode,
+			Router:   lr.Name,
+			SNATZone: GetSNATZone(lr),
+		})
+	}
+	sort.Slice(zones.GatewayRouters, func(i, j int) bool {
+		return zones.GatewayRouters[i].Router < zones.GatewayRouters[j].Router
+	})
+	return zones, nil
+}
+
+// NewHandler returns a handler serving the conntrack zones in JSON, limited to
+// the gateway routers of the node given by the "node" query parameter if set
+func NewHandler(nbClient libovsdbclient.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		zones, err := ListZones(nbClient, req.URL.Query().Get("node"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to list the conntrack zones: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(zones); err != nil {
+			klog.Errorf("Failed to write the conntrack zones: %v", err)
+		}
+	})
+}
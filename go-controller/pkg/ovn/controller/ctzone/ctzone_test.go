@@ -0,0 +1,88 @@
+package ctzone
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestAllocator(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	allocator := NewAllocator(100, 101)
+	// the requested zone is allocated when free and within the range
+	g.Expect(allocator.Allocate("node1", "GR_node1", 101)).To(gomega.Equal(101))
+	// the zone already allocated is kept
+	g.Expect(allocator.Allocate("node1", "GR_node1", 100)).To(gomega.Equal(101))
+	// a zone in use on the node is not allocated twice
+	g.Expect(allocator.Allocate("node1", "blue_GR_node1", 101)).To(gomega.Equal(100))
+	_, err := allocator.Allocate("node1", "red_GR_node1", -1)
+	g.Expect(err).To(gomega.HaveOccurred())
+	// the zones are per node
+	g.Expect(allocator.Allocate("node2", "GR_node2", 101)).To(gomega.Equal(101))
+	// a zone out of the range is not allocated
+	g.Expect(allocator.Allocate("node2", "blue_GR_node2", 0)).To(gomega.Equal(100))
+
+	allocator.Release("node1", "GR_node1")
+	g.Expect(allocator.Allocate("node1", "red_GR_node1", -1)).To(gomega.Equal(101))
+}
+
+func TestListZones(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{
+			&nbdb.LogicalRouter{
+				UUID:    "gr-node1-uuid",
+				Name:    types.GWRouterPrefix + "node1",
+				Options: map[string]string{snatCTZoneOption: "64100"},
+			},
+			&nbdb.LogicalRouter{
+				UUID:    "gr-node2-uuid",
+				Name:    types.GWRouterPrefix + "node2",
+				Options: map[string]string{snatCTZoneOption: "64101"},
+			},
+			&nbdb.LogicalRouter{
+				UUID:        "blue-gr-node1-uuid",
+				Name:        util.GetSecondaryNetworkPrefix("blue") + types.GWRouterPrefix + "node1",
+				Options:     map[string]string{snatCTZoneOption: "0"},
+				ExternalIDs: map[string]string{types.NetworkExternalID: "blue"},
+			},
+			&nbdb.LogicalRouter{
+				UUID: "cluster-router-uuid",
+				Name: types.OVNClusterRouter,
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to set up the test harness: %v", err)
+	}
+	t.Cleanup(cleanup.Cleanup)
+
+	zones, err := ListZones(nbClient, "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(zones.GatewayRouters).To(gomega.Equal([]GatewayRouterZone{
+		{Network: types.DefaultNetworkName, Node: "node1", Router: "GR_node1", SNATZone: 64100},
+		{Network: types.DefaultNetworkName, Node: "node2", Router: "GR_node2", SNATZone: 64101},
+		{Network: "blue", Node: "node1", Router: "blue_GR_node1", SNATZone: 0},
+	}))
+
+	handler := NewHandler(nbClient)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path+"?node=node2", nil))
+	g.Expect(rec.Code).To(gomega.Equal(http.StatusOK))
+	zones = &Zones{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), zones)).To(gomega.Succeed())
+	g.Expect(zones.GatewayRouters).To(gomega.Equal([]GatewayRouterZone{
+		{Network: types.DefaultNetworkName, Node: "node2", Router: "GR_node2", SNATZone: 64101},
+	}))
+	g.Expect(zones.GatewayBridge).To(gomega.HaveLen(4))
+}
@@ -78,6 +78,10 @@ type Controller struct {
 	nodesSynced cache.InformerSynced
 	nodesQueue  workqueue.RateLimitingInterface
 
+	// nodeNetworkStates gets the node network state holding the host subnets
+	// of the nodes with the "crd" backend
+	nodeNetworkStates util.NodeNetworkStateGetter
+
 	// An address set factory that creates address sets
 	addressSetFactory addressset.AddressSetFactory
 
@@ -121,6 +125,7 @@ func NewController(
 	serviceInformer coreinformers.ServiceInformer,
 	endpointSliceInformer discoveryinformers.EndpointSliceInformer,
 	nodeInformer coreinformers.NodeInformer,
+	nodeNetworkStates util.NodeNetworkStateGetter,
 	zone string) (*Controller, error) {
 	klog.Info("Setting up event handlers for Egress Services")
 
@@ -136,6 +141,7 @@ func NewController(
 		services:                                 map[string]*svcState{},
 		nodes:                                    map[string]*nodeState{},
 		nodesZoneState:                           map[string]bool{},
+		nodeNetworkStates:                        nodeNetworkStates,
 		zone:                                     zone,
 	}
 
@@ -0,0 +1,275 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	libovsdbcache "github.com/ovn-org/libovsdb/cache"
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/model"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/nbdbext"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/observability"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+const (
+	// maxRetries is the number of times an ACL is retried before it is
+	// dropped out of the queue
+	maxRetries = 10
+
+	sampleCollectorName = "ovnkube-drops"
+
+	nbGlobalDebugDropCollectorSet = "debug_drop_collector_set"
+	nbGlobalDebugDropDomainID     = "debug_drop_domain_id"
+)
+
+// DropSamplingController samples the packets dropped by the drop and reject
+// ACLs of ovnkube, with the sample metadata derived from the ACL, and by the
+// default OVN pipeline to the collector set of ovnkube-node. The ACLs are
+// watched in the NB cache rather than sampled when created, so that the ACLs
+// of all the features are covered.
+type DropSamplingController struct {
+	nbClient libovsdbclient.Client
+	// probability is the probability of the sample collector, out of 65535
+	probability   int
+	collectorUUID string
+	// queue holds the UUIDs of the ACLs to sync
+	queue workqueue.RateLimitingInterface
+}
+
+// NewDropSamplingController creates a controller sampling the given
+// percentage of the dropped packets
+func NewDropSamplingController(nbClient libovsdbclient.Client, percentage int) *DropSamplingController {
+	return &DropSamplingController{
+		nbClient:    nbClient,
+		probability: observability.SamplingProbability(percentage),
+		queue: workqueue.NewNamedRateLimitingQueue(
+			workqueue.NewItemFastSlowRateLimiter(1*time.Second, 5*time.Second, 5),
+			"dropSampling",
+		),
+	}
+}
+
+// Start sets up the sample collector and the sampling of the default drops,
+// then samples the drop ACLs until stopCh is closed
+func (c *DropSamplingController) Start(stopCh <-chan struct{}, wg *sync.WaitGroup) error {
+	klog.Infof("Starting the drop sampling controller")
+	collector := &nbdbext.SampleCollector{
+		ID:          observability.ACLSampleCollectorID,
+		Name:        sampleCollectorName,
+		Probability: c.probability,
+		SetID:       observability.CollectorSetID,
+	}
+	ops, err := libovsdbops.CreateOrUpdateSampleCollectorOps(c.nbClient, nil, collector)
+	if err != nil {
+		return fmt.Errorf("failed to create the sample collector: %w", err)
+	}
+	if _, err = libovsdbops.TransactAndCheckAndSetUUIDs(c.nbClient, collector, ops); err != nil {
+		return fmt.Errorf("failed to create the sample collector: %w", err)
+	}
+	c.collectorUUID = collector.UUID
+
+	err = libovsdbops.UpdateNBGlobalSetOptions(c.nbClient, &nbdb.NBGlobal{Options: map[string]string{
+		nbGlobalDebugDropCollectorSet: strconv.Itoa(observability.CollectorSetID),
+		nbGlobalDebugDropDomainID:     strconv.Itoa(observability.DefaultDropDomainID),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to enable the sampling of the default drops: %w", err)
+	}
+
+	c.nbClient.Cache().AddEventHandler(&libovsdbcache.EventHandlerFuncs{
+		AddFunc: func(table string, m model.Model) {
+			if table == nbdb.ACLTable {
+				c.queue.Add(m.(*nbdb.ACL).UUID)
+			}
+		},
+		UpdateFunc: func(table string, _, m model.Model) {
+			if table == nbdb.ACLTable {
+				c.queue.Add(m.(*nbdb.ACL).UUID)
+			}
+		},
+	})
+	// the ACLs added before the handler
+	acls, err := libovsdbops.FindACLsWithPredicate(c.nbClient, func(*nbdb.ACL) bool { return true })
+	if err != nil {
+		return fmt.Errorf("failed to list the ACLs: %w", err)
+	}
+	for _, acl := range acls {
+		c.queue.Add(acl.UUID)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(c.worker, time.Second, stopCh)
+	}()
+	go func() {
+		<-stopCh
+		c.queue.ShutDown()
+	}()
+	return nil
+}
+
+func (c *DropSamplingController) worker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *DropSamplingController) processNextWorkItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	err := c.syncACL(key.(string))
+	if err == nil {
+		c.queue.Forget(key)
+		return true
+	}
+	if c.queue.NumRequeues(key) < maxRetries {
+		klog.V(2).Infof("Failed to sync the sample of ACL %s, retrying: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	klog.Warningf("Dropping ACL %s out of the drop sampling queue: %v", key, err)
+	c.queue.Forget(key)
+	utilruntime.HandleError(err)
+	return true
+}
+
+// syncACL samples the ACL if it is a drop or reject ACL of ovnkube, and
+// removes the sample of ovnkube from the ACL otherwise
+func (c *DropSamplingController) syncACL(uuid string) error {
+	acl := &nbdb.ACL{UUID: uuid}
+	ctx, cancel := context.WithTimeout(context.Background(), types.OVSDBTimeout)
+	defer cancel()
+	if err := c.nbClient.Get(ctx, acl); err != nil {
+		if errors.Is(err, libovsdbclient.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	aclSamples, err := libovsdbops.FindACLsSampleNew(c.nbClient,
+		ovsdb.NewCondition("_uuid", ovsdb.ConditionEqual, ovsdb.UUID{GoUUID: uuid}))
+	if err != nil {
+		return err
+	}
+	sampleUUID := aclSamples[uuid]
+	sampled := isSampledACL(acl)
+	if sampled == (sampleUUID != "") {
+		return nil
+	}
+	var ops []ovsdb.Operation
+	if sampled {
+		sample := &nbdbext.Sample{
+			Collectors: []string{c.collectorUUID},
+			Metadata:   observability.ACLSampleMetadata(acl.ExternalIDs[types.PrimaryIDKey]),
+		}
+		ops, err = libovsdbops.CreateOrUpdateSamplesOps(c.nbClient, nil, sample)
+		if err != nil {
+			return err
+		}
+		ops = libovsdbops.SetACLSampleNewOps(ops, acl.UUID, sample.UUID)
+	} else {
+		// keep the samples of other owners
+		sample := &nbdbext.Sample{UUID: sampleUUID}
+		if err := c.nbClient.Get(ctx, sample); err != nil {
+			if errors.Is(err, libovsdbclient.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		if !hasCollector(sample, c.collectorUUID) {
+			return nil
+		}
+		ops = libovsdbops.SetACLSampleNewOps(ops, acl.UUID, "")
+	}
+	_, err = libovsdbops.TransactAndCheck(c.nbClient, ops)
+	return err
+}
+
+// isSampledACL returns whether the ACL is a drop or reject ACL of ovnkube
+func isSampledACL(acl *nbdb.ACL) bool {
+	if acl.ExternalIDs[types.PrimaryIDKey] == "" {
+		return false
+	}
+	return acl.Action == nbdb.ACLActionDrop || acl.Action == nbdb.ACLActionReject
+}
+
+func hasCollector(sample *nbdbext.Sample, collectorUUID string) bool {
+	for _, collector := range sample.Collectors {
+		if collector == collectorUUID {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanupDropSampling removes the sampling of the dropped packets, if it was
+// set up, when drop sampling is disabled
+func CleanupDropSampling(nbClient libovsdbclient.Client) error {
+	collectors, err := libovsdbops.FindSampleCollectorsWithPredicate(nbClient, func(collector *nbdbext.SampleCollector) bool {
+		return collector.ID == observability.ACLSampleCollectorID && collector.Name == sampleCollectorName
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the sample collector: %w", err)
+	}
+	if len(collectors) == 0 {
+		return nil
+	}
+	klog.Infof("Cleaning up the drop sampling")
+	collectorUUID := collectors[0].UUID
+	samples := []*nbdbext.Sample{}
+	ctx, cancel := context.WithTimeout(context.Background(), types.OVSDBTimeout)
+	defer cancel()
+	err = nbClient.WhereCache(func(sample *nbdbext.Sample) bool { return hasCollector(sample, collectorUUID) }).List(ctx, &samples)
+	if err != nil {
+		return fmt.Errorf("failed to find the samples: %w", err)
+	}
+	sampleUUIDs := make(map[string]bool, len(samples))
+	for _, sample := range samples {
+		sampleUUIDs[sample.UUID] = true
+	}
+	aclSamples, err := libovsdbops.FindACLsSampleNew(nbClient)
+	if err != nil {
+		return fmt.Errorf("failed to find the sampled ACLs: %w", err)
+	}
+	var ops []ovsdb.Operation
+	for aclUUID, sampleUUID := range aclSamples {
+		if sampleUUIDs[sampleUUID] {
+			ops = libovsdbops.SetACLSampleNewOps(ops, aclUUID, "")
+		}
+	}
+	ops, err = libovsdbops.DeleteSamplesWithPredicateOps(nbClient, ops, func(sample *nbdbext.Sample) bool {
+		return sampleUUIDs[sample.UUID]
+	})
+	if err != nil {
+		return err
+	}
+	ops, err = libovsdbops.DeleteSampleCollectorsWithPredicateOps(nbClient, ops, func(collector *nbdbext.SampleCollector) bool {
+		return collector.UUID == collectorUUID
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = libovsdbops.TransactAndCheck(nbClient, ops); err != nil {
+		return fmt.Errorf("failed to remove the drop sampling: %w", err)
+	}
+	return libovsdbops.UpdateNBGlobalSetOptions(nbClient, &nbdb.NBGlobal{Options: map[string]string{
+		nbGlobalDebugDropCollectorSet: "",
+		nbGlobalDebugDropDomainID:     "",
+	}})
+}
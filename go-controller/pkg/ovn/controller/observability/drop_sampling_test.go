@@ -0,0 +1,197 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/ovn-org/libovsdb/model"
+	"github.com/ovn-org/libovsdb/ovsdb"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/nbdbext"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/observability"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+// sampleSchemaTables are the tables of the OVN Northbound schema with sample
+// collectors that the generated model doesn't have
+const sampleSchemaTables = `{
+  "Sample": {
+    "columns": {
+      "collectors": {"type": {"key": {"type": "uuid", "refTable": "Sample_Collector", "refType": "strong"}, "min": 0, "max": "unlimited"}},
+      "metadata": {"type": {"key": {"type": "integer", "minInteger": 1, "maxInteger": 4294967295}}}
+    },
+    "indexes": [["metadata"]]
+  },
+  "Sample_Collector": {
+    "columns": {
+      "external_ids": {"type": {"key": {"type": "string"}, "value": {"type": "string"}, "min": 0, "max": "unlimited"}},
+      "id": {"type": {"key": {"type": "integer", "minInteger": 1, "maxInteger": 255}}},
+      "name": {"type": "string"},
+      "probability": {"type": {"key": {"type": "integer", "minInteger": 0, "maxInteger": 65535}}},
+      "set_id": {"type": {"key": {"type": "integer", "minInteger": 1, "maxInteger": 4294967295}}}
+    },
+    "indexes": [["id"]]
+  }
+}`
+
+const sampleSchemaACLColumn = `{"type": {"key": {"type": "uuid", "refTable": "Sample", "refType": "strong"}, "min": 0, "max": 1}}`
+
+// sampleACL is the ACL the NB server with sample collectors stores
+type sampleACL struct {
+	UUID        string            `ovsdb:"_uuid"`
+	Action      nbdb.ACLAction    `ovsdb:"action"`
+	Direction   nbdb.ACLDirection `ovsdb:"direction"`
+	ExternalIDs map[string]string `ovsdb:"external_ids"`
+	Label       int               `ovsdb:"label"`
+	Log         bool              `ovsdb:"log"`
+	Match       string            `ovsdb:"match"`
+	Meter       *string           `ovsdb:"meter"`
+	Name        *string           `ovsdb:"name"`
+	Options     map[string]string `ovsdb:"options"`
+	Priority    int               `ovsdb:"priority"`
+	SampleNew   *string           `ovsdb:"sample_new"`
+	Severity    *nbdb.ACLSeverity `ovsdb:"severity"`
+	Tier        int               `ovsdb:"tier"`
+}
+
+// sampleSchema returns the schema of the generated model with the sample
+// collectors
+func sampleSchema(t *testing.T) *ovsdb.DatabaseSchema {
+	schema := nbdb.Schema()
+	tables := map[string]ovsdb.TableSchema{}
+	if err := json.Unmarshal([]byte(sampleSchemaTables), &tables); err != nil {
+		t.Fatalf("failed to parse the sample tables: %v", err)
+	}
+	for name, table := range tables {
+		schema.Tables[name] = table
+	}
+	column := &ovsdb.ColumnSchema{}
+	if err := json.Unmarshal([]byte(sampleSchemaACLColumn), column); err != nil {
+		t.Fatalf("failed to parse the ACL sample column: %v", err)
+	}
+	schema.Tables[nbdb.ACLTable].Columns[nbdbext.ACLSampleNewColumn] = column
+	return &schema
+}
+
+func TestDropSamplingController(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dropACL := &sampleACL{
+		UUID:        "drop-acl-uuid",
+		Action:      nbdb.ACLActionDrop,
+		Direction:   nbdb.ACLDirectionToLport,
+		Match:       "outport == @a1234",
+		Priority:    1000,
+		ExternalIDs: map[string]string{types.PrimaryIDKey: "default-network-controller:NetpolNamespace:ns1:Ingress"},
+	}
+	allowACL := &sampleACL{
+		UUID:        "allow-acl-uuid",
+		Action:      nbdb.ACLActionAllowRelated,
+		Direction:   nbdb.ACLDirectionToLport,
+		Match:       "outport == @a1234 && ip4.src == 10.0.0.1",
+		Priority:    1001,
+		ExternalIDs: map[string]string{types.PrimaryIDKey: "default-network-controller:NetworkPolicy:ns1:policy1:Ingress:0"},
+	}
+	foreignACL := &sampleACL{
+		UUID:      "foreign-acl-uuid",
+		Action:    nbdb.ACLActionDrop,
+		Direction: nbdb.ACLDirectionToLport,
+		Match:     "ip4.src == 10.0.0.2",
+		Priority:  1000,
+	}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBSchema:       sampleSchema(t),
+		NBServerModels: map[string]model.Model{nbdb.ACLTable: &sampleACL{}},
+		NBData: []libovsdbtest.TestData{
+			&nbdb.NBGlobal{UUID: "nb-global-uuid"},
+			dropACL,
+			allowACL,
+			foreignACL,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to set up the test harness: %v", err)
+	}
+	t.Cleanup(cleanup.Cleanup)
+
+	// the test harness replaces the UUIDs, look the ACLs up by match
+	getACL := func(match string) *nbdb.ACL {
+		acls, err := libovsdbops.FindACLsWithPredicate(nbClient, func(acl *nbdb.ACL) bool { return acl.Match == match })
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(acls).To(gomega.HaveLen(1))
+		return acls[0]
+	}
+	getSampleUUID := func(match string) string {
+		acl := getACL(match)
+		aclSamples, err := libovsdbops.FindACLsSampleNew(nbClient,
+			ovsdb.NewCondition("_uuid", ovsdb.ConditionEqual, ovsdb.UUID{GoUUID: acl.UUID}))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return aclSamples[acl.UUID]
+	}
+	getSampleMetadata := func(match string) int {
+		sampleUUID := getSampleUUID(match)
+		if sampleUUID == "" {
+			return 0
+		}
+		sample := &nbdbext.Sample{UUID: sampleUUID}
+		g.Expect(nbClient.Get(context.TODO(), sample)).To(gomega.Succeed())
+		return sample.Metadata
+	}
+
+	stopCh := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	c := NewDropSamplingController(nbClient, 50)
+	g.Expect(c.Start(stopCh, wg)).To(gomega.Succeed())
+
+	collectors, err := libovsdbops.FindSampleCollectorsWithPredicate(nbClient, func(*nbdbext.SampleCollector) bool { return true })
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(collectors).To(gomega.HaveLen(1))
+	g.Expect(collectors[0].SetID).To(gomega.Equal(observability.CollectorSetID))
+	g.Expect(collectors[0].Probability).To(gomega.Equal(32767))
+	nbGlobal, err := libovsdbops.GetNBGlobal(nbClient, &nbdb.NBGlobal{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(nbGlobal.Options).To(gomega.Equal(map[string]string{
+		nbGlobalDebugDropCollectorSet: "42",
+		nbGlobalDebugDropDomainID:     "2",
+	}))
+
+	// only the drop ACLs of ovnkube are sampled
+	expectedMetadata := observability.ACLSampleMetadata(dropACL.ExternalIDs[types.PrimaryIDKey])
+	g.Eventually(func() int { return getSampleMetadata(dropACL.Match) }).Should(gomega.Equal(expectedMetadata))
+	g.Consistently(func() string { return getSampleUUID(allowACL.Match) }).Should(gomega.BeEmpty())
+	g.Expect(getSampleUUID(foreignACL.Match)).To(gomega.BeEmpty())
+
+	// the ACLs created or updated later are synced
+	newACL := &nbdb.ACL{
+		Action:      nbdb.ACLActionReject,
+		Direction:   nbdb.ACLDirectionFromLport,
+		Match:       "inport == @a1234",
+		Priority:    1000,
+		ExternalIDs: map[string]string{types.PrimaryIDKey: "default-network-controller:NetpolNamespace:ns1:Egress"},
+	}
+	g.Expect(libovsdbops.CreateOrUpdateACLs(nbClient, newACL)).To(gomega.Succeed())
+	g.Eventually(func() int { return getSampleMetadata(newACL.Match) }).Should(
+		gomega.Equal(observability.ACLSampleMetadata(newACL.ExternalIDs[types.PrimaryIDKey])))
+	updatedACL := getACL(dropACL.Match)
+	updatedACL.Action = nbdb.ACLActionAllow
+	g.Expect(libovsdbops.CreateOrUpdateACLs(nbClient, updatedACL)).To(gomega.Succeed())
+	g.Eventually(func() string { return getSampleUUID(dropACL.Match) }).Should(gomega.BeEmpty())
+
+	// the sampling is removed when disabled
+	close(stopCh)
+	wg.Wait()
+	g.Expect(CleanupDropSampling(nbClient)).To(gomega.Succeed())
+	g.Expect(getSampleUUID(newACL.Match)).To(gomega.BeEmpty())
+	collectors, err = libovsdbops.FindSampleCollectorsWithPredicate(nbClient, func(*nbdbext.SampleCollector) bool { return true })
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(collectors).To(gomega.BeEmpty())
+	nbGlobal, err = libovsdbops.GetNBGlobal(nbClient, &nbdb.NBGlobal{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(nbGlobal.Options).To(gomega.BeEmpty())
+}
@@ -0,0 +1,564 @@
+package routeaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// The static routes of the logical routers are created by several controllers,
+// mostly without external IDs telling who owns them, and outlive the nodes and
+// pods they were created for when an event is missed. The auditor derives the
+// routes ovnkube owns from the nodes and the pods, compares them with the
+// routes of the Northbound database of the zone, reports the difference and
+// removes the stale routes. The routes of other kinds, like the ones of the
+// egress IPs or the hybrid overlay, are not audited.
+
+// Path is the path of the metrics server where the route diff is served
+const Path = "/static-routes"
+
+// Kind is the kind of an audited static route
+type Kind string
+
+const (
+	// KindNodeSubnet routes the traffic of the host subnet of a node of the
+	// zone to its gateway router, or to its management port in local gateway
+	// mode
+	KindNodeSubnet Kind = "node-subnet"
+	// KindRemoteNode routes the host subnet and the gateway router IPs of a
+	// node of another zone to its transit switch port
+	KindRemoteNode Kind = "remote-node"
+	// KindPodEgress routes the traffic of a pod of the layer2 default network
+	// to the gateway router of its node
+	KindPodEgress Kind = "pod-egress"
+	// KindExternalGateway routes the traffic of a pod to an external gateway
+	// on the gateway router of its node
+	KindExternalGateway Kind = "external-gateway"
+)
+
+const ecmpSymmetricReplyOption = "ecmp_symmetric_reply"
+
+// Route is an audited static route
+type Route struct {
+	Kind Kind `json:"kind"`
+	// Node is the node the route was created for
+	Node       string `json:"node,omitempty"`
+	Policy     string `json:"policy,omitempty"`
+	IPPrefix   string `json:"ipPrefix"`
+	Nexthop    string `json:"nexthop"`
+	OutputPort string `json:"outputPort,omitempty"`
+	// uuid is the UUID of the route in the Northbound database, empty for
+	// the missing routes
+	uuid string
+}
+
+// key identifies the route regardless of its output port, which the
+// expected routes don't tell
+func (r Route) key() string {
+	return strings.Join([]string{string(r.Kind), r.Node, r.Policy, r.IPPrefix, r.Nexthop}, "|")
+}
+
+func (r Route) String() string {
+	s := fmt.Sprintf("%s %s via %s", r.Kind, r.IPPrefix, r.Nexthop)
+	if r.Policy != "" {
+		s = fmt.Sprintf("%s %s %s via %s", r.Kind, r.Policy, r.IPPrefix, r.Nexthop)
+	}
+	if r.Node != "" {
+		s += " node " + r.Node
+	}
+	return s
+}
+
+// RouterDiff is the difference between the audited static routes of a router
+// and the expected ones
+type RouterDiff struct {
+	Network string `json:"network"`
+	Router  string `json:"router"`
+	// InSync is the number of audited routes matching an expected route
+	InSync int `json:"inSync"`
+	// Missing are the expected routes the router doesn't have
+	Missing []Route `json:"missing,omitempty"`
+	// Stale are the audited routes of the router not expected anymore
+	Stale []Route `json:"stale,omitempty"`
+}
+
+// Auditor audits the static routes of the cluster and gateway routers of the
+// zone
+type Auditor struct {
+	nbClient   libovsdbclient.Client
+	nodeLister corelisters.NodeLister
+	podLister  corelisters.PodLister
+	// nodeNetworkStates gets the node network state holding the host
+	// subnets of the nodes with the "crd" backend
+	nodeNetworkStates util.NodeNetworkStateGetter
+	// stale are the UUIDs of the routes found stale by the last
+	// reconciliation. A route is only removed when found stale twice in a
+	// row, so that the routes created while the listers catch up with the
+	// nodes and pods they were created for are left alone.
+	stale sets.Set[string]
+}
+
+func NewAuditor(nbClient libovsdbclient.Client, nodeLister corelisters.NodeLister, podLister corelisters.PodLister,
+	nodeNetworkStates util.NodeNetworkStateGetter) *Auditor {
+	return &Auditor{
+		nbClient:          nbClient,
+		nodeLister:        nodeLister,
+		podLister:         podLister,
+		nodeNetworkStates: nodeNetworkStates,
+		stale:             sets.New[string](),
+	}
+}
+
+// Run removes the stale static routes every interval until stopCh is closed
+func (a *Auditor) Run(stopCh <-chan struct{}, wg *sync.WaitGroup, interval time.Duration) {
+	klog.Infof("Starting the static route audit")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if err := a.reconcile(); err != nil {
+				klog.Errorf("Failed to audit the static routes: %v", err)
+			}
+		}, interval, stopCh)
+	}()
+}
+
+// reconcile removes the routes found stale by the previous reconciliation and
+// still stale
+func (a *Auditor) reconcile() error {
+	diffs, err := a.Diff("", "")
+	if err != nil {
+		return err
+	}
+	stale := sets.New[string]()
+	var errs []error
+	for _, diff := range diffs {
+		if len(diff.Missing) > 0 {
+			klog.V(4).Infof("Router %s is missing the static routes %v", diff.Router, diff.Missing)
+		}
+		var toDelete []*nbdb.LogicalRouterStaticRoute
+		var removed []string
+		for _, route := range diff.Stale {
+			if !a.stale.Has(route.uuid) {
+				stale.Insert(route.uuid)
+				continue
+			}
+			toDelete = append(toDelete, &nbdb.LogicalRouterStaticRoute{UUID: route.uuid})
+			removed = append(removed, route.String())
+		}
+		if len(toDelete) == 0 {
+			continue
+		}
+		klog.Infof("Removing the stale static routes %v from router %s", removed, diff.Router)
+		if err := libovsdbops.DeleteLogicalRouterStaticRoutes(a.nbClient, diff.Router, toDelete...); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove the stale static routes of router %s: %w", diff.Router, err))
+			for _, lrsr := range toDelete {
+				stale.Insert(lrsr.UUID)
+			}
+		}
+	}
+	a.stale = stale
+	return kerrors.NewAggregate(errs)
+}
+
+// Diff returns the difference between the audited static routes of the
+// routers of the zone and the expected ones, limited to the network and the
+// router if not empty
+func (a *Auditor) Diff(network, router string) ([]RouterDiff, error) {
+	nodes, err := a.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the nodes: %w", err)
+	}
+	routers, err := libovsdbops.FindLogicalRoutersWithPredicate(a.nbClient, func(*nbdb.LogicalRouter) bool { return true })
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the logical routers: %w", err)
+	}
+	routes, err := libovsdbops.FindLogicalRouterStaticRoutesWithPredicate(a.nbClient,
+		func(*nbdb.LogicalRouterStaticRoute) bool { return true })
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the static routes: %w", err)
+	}
+	routesByUUID := make(map[string]*nbdb.LogicalRouterStaticRoute, len(routes))
+	for _, lrsr := range routes {
+		routesByUUID[lrsr.UUID] = lrsr
+	}
+	routerNames := sets.New[string]()
+	for _, lr := range routers {
+		routerNames.Insert(lr.Name)
+	}
+	var podIPs map[string]*kapi.Pod
+	getPodIPs := func() (map[string]*kapi.Pod, error) {
+		if podIPs == nil {
+			if podIPs, err = a.getPodIPs(); err != nil {
+				return nil, err
+			}
+		}
+		return podIPs, nil
+	}
+
+	diffs := []RouterDiff{}
+	for _, lr := range routers {
+		lrNetwork := lr.ExternalIDs[types.NetworkExternalID]
+		if lrNetwork == "" {
+			lrNetwork = types.DefaultNetworkName
+		}
+		if (network != "" && lrNetwork != network) || (router != "" && lr.Name != router) {
+			continue
+		}
+		lrRoutes := make([]*nbdb.LogicalRouterStaticRoute, 0, len(lr.StaticRoutes))
+		for _, uuid := range lr.StaticRoutes {
+			if lrsr := routesByUUID[uuid]; lrsr != nil {
+				lrRoutes = append(lrRoutes, lrsr)
+			}
+		}
+		var diff *RouterDiff
+		switch {
+		case lr.Name == networkScopedName(lrNetwork, types.OVNClusterRouter):
+			topology := lr.ExternalIDs[types.TopologyExternalID]
+			if lrNetwork == types.DefaultNetworkName {
+				topology = config.Default.Topology
+			}
+			var expected []Route
+			if lrNetwork == types.DefaultNetworkName && topology == types.Layer2Topology {
+				ips, err := getPodIPs()
+				if err != nil {
+					return nil, err
+				}
+				expected = expectedPodEgressRoutes(nodes, ips)
+			} else if topology == types.Layer3Topology {
+				expected = expectedNodeRoutes(lrNetwork, nodes, routerNames, a.nodeNetworkStates)
+			}
+			diff = auditRouter(lrNetwork, lr.Name, lrRoutes, classifyClusterRouterRoute, expected, nil)
+		case lrNetwork == types.DefaultNetworkName && strings.HasPrefix(lr.Name, types.GWRouterPrefix):
+			ips, err := getPodIPs()
+			if err != nil {
+				return nil, err
+			}
+			node := strings.TrimPrefix(lr.Name, types.GWRouterPrefix)
+			classify := func(lrsr *nbdb.LogicalRouterStaticRoute) *Route {
+				return classifyGatewayRouterRoute(node, lrsr)
+			}
+			isExpected := func(route Route) bool {
+				pod := ips[route.IPPrefix]
+				return pod != nil && pod.Spec.NodeName == node
+			}
+			diff = auditRouter(lrNetwork, lr.Name, lrRoutes, classify, nil, isExpected)
+		default:
+			continue
+		}
+		if diff.InSync > 0 || len(diff.Missing) > 0 || len(diff.Stale) > 0 {
+			diffs = append(diffs, *diff)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Router < diffs[j].Router })
+	return diffs, nil
+}
+
+// auditRouter compares the audited routes of the router, the ones classify
+// returns a route for, with the expected routes and, for the routes whose
+// expected counterpart can't be computed, isExpected
+func auditRouter(network, router string, lrRoutes []*nbdb.LogicalRouterStaticRoute,
+	classify func(*nbdb.LogicalRouterStaticRoute) *Route, expected []Route, isExpected func(Route) bool) *RouterDiff {
+	diff := &RouterDiff{Network: network, Router: router}
+	expectedKeys := make(map[string]Route, len(expected))
+	for _, route := range expected {
+		expectedKeys[route.key()] = route
+	}
+	found := sets.New[string]()
+	for _, lrsr := range lrRoutes {
+		route := classify(lrsr)
+		if route == nil {
+			continue
+		}
+		key := route.key()
+		_, ok := expectedKeys[key]
+		if !ok && isExpected != nil {
+			ok = isExpected(*route)
+		}
+		// a duplicate of an expected route is stale as well
+		if ok && !found.Has(key) {
+			found.Insert(key)
+			diff.InSync++
+			continue
+		}
+		diff.Stale = append(diff.Stale, *route)
+	}
+	for key, route := range expectedKeys {
+		if !found.Has(key) {
+			diff.Missing = append(diff.Missing, route)
+		}
+	}
+	sortRoutes(diff.Missing)
+	sortRoutes(diff.Stale)
+	return diff
+}
+
+func sortRoutes(routes []Route) {
+	sort.Slice(routes, func(i, j int) bool { return routes[i].key() < routes[j].key() })
+}
+
+func networkScopedName(network, name string) string {
+	if network == types.DefaultNetworkName {
+		return name
+	}
+	return util.GetSecondaryNetworkPrefix(network) + name
+}
+
+func newRoute(kind Kind, node string, lrsr *nbdb.LogicalRouterStaticRoute) *Route {
+	route := &Route{
+		Kind:     kind,
+		Node:     node,
+		IPPrefix: lrsr.IPPrefix,
+		Nexthop:  lrsr.Nexthop,
+		uuid:     lrsr.UUID,
+	}
+	if lrsr.Policy != nil {
+		route.Policy = string(*lrsr.Policy)
+	}
+	if lrsr.OutputPort != nil {
+		route.OutputPort = *lrsr.OutputPort
+	}
+	return route
+}
+
+func isSrcIPRoute(lrsr *nbdb.LogicalRouterStaticRoute) bool {
+	return lrsr.Policy != nil && *lrsr.Policy == nbdb.LogicalRouterStaticRoutePolicySrcIP
+}
+
+func isClusterSubnet(prefix string) bool {
+	for _, clusterSubnet := range config.Default.ClusterSubnets {
+		if clusterSubnet.CIDR.String() == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyClusterRouterRoute returns the audited route of a static route of a
+// cluster router, nil if it is not audited
+func classifyClusterRouterRoute(lrsr *nbdb.LogicalRouterStaticRoute) *Route {
+	if node := lrsr.ExternalIDs["ic-node"]; node != "" {
+		return newRoute(KindRemoteNode, node, lrsr)
+	}
+	// the routes of the egress IPs and of the hybrid overlay have a name
+	if !isSrcIPRoute(lrsr) || len(lrsr.ExternalIDs) > 0 {
+		return nil
+	}
+	nexthop := net.ParseIP(lrsr.Nexthop)
+	if nexthop == nil {
+		return nil
+	}
+	if ip := net.ParseIP(lrsr.IPPrefix); ip != nil {
+		if config.ContainsJoinIP(nexthop) {
+			return newRoute(KindPodEgress, "", lrsr)
+		}
+		return nil
+	}
+	_, prefix, err := net.ParseCIDR(lrsr.IPPrefix)
+	// the routes of the whole cluster subnets are the ones of the KubeVirt
+	// live migrations
+	if err != nil || isClusterSubnet(prefix.String()) {
+		return nil
+	}
+	// the next hop is the gateway router or the management port of the node
+	if config.ContainsJoinIP(nexthop) || containsClusterIP(nexthop) {
+		return newRoute(KindNodeSubnet, "", lrsr)
+	}
+	return nil
+}
+
+func containsClusterIP(ip net.IP) bool {
+	for _, clusterSubnet := range config.Default.ClusterSubnets {
+		if clusterSubnet.CIDR.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyGatewayRouterRoute returns the audited route of a static route of
+// the gateway router of the node, nil if it is not audited
+func classifyGatewayRouterRoute(node string, lrsr *nbdb.LogicalRouterStaticRoute) *Route {
+	if !isSrcIPRoute(lrsr) || lrsr.Options[ecmpSymmetricReplyOption] != "true" {
+		return nil
+	}
+	route := newRoute(KindExternalGateway, node, lrsr)
+	// the pod IP is matched without its mask
+	if ip, _, err := net.ParseCIDR(lrsr.IPPrefix); err == nil {
+		route.IPPrefix = ip.String()
+	}
+	return route
+}
+
+// expectedNodeRoutes returns the routes of the host subnets of the nodes on the
+// cluster router of a layer3 network
+func expectedNodeRoutes(network string, nodes []*kapi.Node, routerNames sets.Set[string],
+	nodeNetworkStates util.NodeNetworkStateGetter) []Route {
+	var routes []Route
+	for _, node := range nodes {
+		hostSubnets, err := util.ParseNodeHostSubnets(node, network, nodeNetworkStates)
+		if err != nil {
+			continue
+		}
+		if util.GetNodeZone(node) != config.Default.Zone {
+			if config.OVNKubernetesFeature.EnableInterconnect {
+				routes = append(routes, expectedRemoteNodeRoutes(network, node, hostSubnets)...)
+			}
+			continue
+		}
+		if network != types.DefaultNetworkName {
+			continue
+		}
+		for _, hostSubnet := range hostSubnets {
+			route := Route{
+				Kind:     KindNodeSubnet,
+				Policy:   string(nbdb.LogicalRouterStaticRoutePolicySrcIP),
+				IPPrefix: hostSubnet.String(),
+			}
+			isIPv6 := utilnet.IsIPv6CIDR(hostSubnet)
+			if config.Gateway.Mode == config.GatewayModeLocal {
+				primarySubnet, err := util.MatchFirstIPNetFamily(isIPv6, hostSubnets)
+				if err != nil {
+					continue
+				}
+				route.Nexthop = util.GetNodeManagementIfAddr(primarySubnet).IP.String()
+			} else {
+				// the routes to the gateway router come with the gateway router
+				if !routerNames.Has(types.GWRouterPrefix + node.Name) {
+					continue
+				}
+				gwLRPIPs, err := util.ParseNodeGatewayRouterLRPAddrs(node)
+				if err != nil {
+					continue
+				}
+				gwLRPIP, err := util.MatchFirstIPNetFamily(isIPv6, gwLRPIPs)
+				if err != nil {
+					continue
+				}
+				route.Nexthop = gwLRPIP.IP.String()
+			}
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// expectedRemoteNodeRoutes returns the routes of the host subnets and, for
+// the default network, the gateway router IPs of a node of another zone to its
+// transit switch port
+func expectedRemoteNodeRoutes(network string, node *kapi.Node, hostSubnets []*net.IPNet) []Route {
+	transitSwitchPortIPs, err := util.ParseNodeTransitSwitchPortAddrs(node)
+	if err != nil {
+		return nil
+	}
+	var routes []Route
+	addRoutes := func(prefixes []*net.IPNet, fullMask bool) {
+		for _, prefix := range prefixes {
+			for _, nexthop := range transitSwitchPortIPs {
+				if utilnet.IsIPv6CIDR(prefix) != utilnet.IsIPv6CIDR(nexthop) {
+					continue
+				}
+				ipPrefix := prefix.String()
+				if fullMask {
+					ipPrefix = prefix.IP.String() + util.GetIPFullMaskString(prefix.IP.String())
+				}
+				routes = append(routes, Route{
+					Kind:     KindRemoteNode,
+					Node:     node.Name,
+					IPPrefix: ipPrefix,
+					Nexthop:  nexthop.IP.String(),
+				})
+			}
+		}
+	}
+	addRoutes(hostSubnets, false)
+	if network == types.DefaultNetworkName {
+		if gwLRPIPs, err := util.ParseNodeGatewayRouterLRPAddrs(node); err == nil {
+			addRoutes(gwLRPIPs, true)
+		}
+	}
+	return routes
+}
+
+// expectedPodEgressRoutes returns the routes of the pods of the layer2 default
+// network to the gateway router of their node
+func expectedPodEgressRoutes(nodes []*kapi.Node, podIPs map[string]*kapi.Pod) []Route {
+	gwLRPIPs := map[string][]*net.IPNet{}
+	for _, node := range nodes {
+		if ips, err := util.ParseNodeGatewayRouterLRPAddrs(node); err == nil {
+			gwLRPIPs[node.Name] = ips
+		}
+	}
+	var routes []Route
+	for podIP, pod := range podIPs {
+		ip := net.ParseIP(podIP)
+		gwLRPIP, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6(ip), gwLRPIPs[pod.Spec.NodeName])
+		if err != nil {
+			continue
+		}
+		routes = append(routes, Route{
+			Kind:     KindPodEgress,
+			Policy:   string(nbdb.LogicalRouterStaticRoutePolicySrcIP),
+			IPPrefix: podIP,
+			Nexthop:  gwLRPIP.IP.String(),
+		})
+	}
+	return routes
+}
+
+// getPodIPs returns the running pods of the default network by IP
+func (a *Auditor) getPodIPs() (map[string]*kapi.Pod, error) {
+	pods, err := a.podLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods: %w", err)
+	}
+	podIPs := map[string]*kapi.Pod{}
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork || pod.Spec.NodeName == "" || util.PodCompleted(pod) {
+			continue
+		}
+		annotation, err := util.UnmarshalPodAnnotation(pod.Annotations, types.DefaultNetworkName)
+		if err != nil {
+			continue
+		}
+		for _, ip := range annotation.IPs {
+			podIPs[ip.IP.String()] = pod
+		}
+	}
+	return podIPs, nil
+}
+
+// ServeHTTP serves the route diff in JSON, limited to the network and the
+// router given by the "network" and "router" query parameters if set
+func (a *Auditor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	diffs, err := a.Diff(req.URL.Query().Get("network"), req.URL.Query().Get("router"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to audit the static routes: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diffs); err != nil {
+		klog.Errorf("Failed to write the static route diff: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package routeaudit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+func newNode(name, zone, subnet, gwLRPIP, transitSwitchIP string) *kapi.Node {
+	return &kapi.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				"k8s.ovn.org/zone-name":                       zone,
+				"k8s.ovn.org/node-subnets":                    `{"default":"` + subnet + `"}`,
+				"k8s.ovn.org/node-gateway-router-lrp-ifaddr":  `{"ipv4":"` + gwLRPIP + `"}`,
+				"k8s.ovn.org/node-transit-switch-port-ifaddr": `{"ipv4":"` + transitSwitchIP + `"}`,
+			},
+		},
+	}
+}
+
+// diffWithoutUUIDs returns the diff of the auditor without the UUIDs of the
+// routes, which the test harness generates
+func diffWithoutUUIDs(auditor *Auditor) ([]RouterDiff, error) {
+	diffs, err := auditor.Diff("", "")
+	for _, diff := range diffs {
+		for i := range diff.Stale {
+			diff.Stale[i].uuid = ""
+		}
+	}
+	return diffs, err
+}
+
+func TestAuditor(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+	config.OVNKubernetesFeature.EnableInterconnect = true
+	config.Default.Zone = "zone1"
+
+	srcIP := &nbdb.LogicalRouterStaticRoutePolicySrcIP
+	port := "rtoe-GR_node1"
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{
+			&nbdb.LogicalRouter{
+				UUID: "cluster-router-uuid",
+				Name: types.OVNClusterRouter,
+				StaticRoutes: []string{"node1-subnet-uuid", "stale-subnet-uuid", "node2-subnet-uuid", "node3-subnet-uuid",
+					"egressip-uuid"},
+			},
+			&nbdb.LogicalRouter{
+				UUID:         "gr-node1-uuid",
+				Name:         types.GWRouterPrefix + "node1",
+				StaticRoutes: []string{"pod1-exgw-uuid", "stale-exgw-uuid"},
+			},
+			&nbdb.LogicalRouterStaticRoute{UUID: "node1-subnet-uuid", Policy: srcIP, IPPrefix: "10.244.0.0/24",
+				Nexthop: "100.64.0.2"},
+			&nbdb.LogicalRouterStaticRoute{UUID: "stale-subnet-uuid", Policy: srcIP, IPPrefix: "10.244.5.0/24",
+				Nexthop: "100.64.0.9"},
+			&nbdb.LogicalRouterStaticRoute{UUID: "node2-subnet-uuid", IPPrefix: "10.244.1.0/24", Nexthop: "100.88.0.3",
+				ExternalIDs: map[string]string{"ic-node": "node2"}},
+			&nbdb.LogicalRouterStaticRoute{UUID: "node3-subnet-uuid", IPPrefix: "10.244.2.0/24", Nexthop: "100.88.0.4",
+				ExternalIDs: map[string]string{"ic-node": "node3"}},
+			&nbdb.LogicalRouterStaticRoute{UUID: "egressip-uuid", Policy: srcIP, IPPrefix: "10.244.0.7",
+				Nexthop: "100.64.0.3", ExternalIDs: map[string]string{"name": "egressip1"}},
+			&nbdb.LogicalRouterStaticRoute{UUID: "pod1-exgw-uuid", Policy: srcIP, IPPrefix: "10.244.0.5/32",
+				Nexthop: "172.18.0.5", OutputPort: &port, Options: map[string]string{ecmpSymmetricReplyOption: "true"}},
+			&nbdb.LogicalRouterStaticRoute{UUID: "stale-exgw-uuid", Policy: srcIP, IPPrefix: "10.244.0.6/32",
+				Nexthop: "172.18.0.5", OutputPort: &port, Options: map[string]string{ecmpSymmetricReplyOption: "true"}},
+		},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	g.Expect(nodeIndexer.Add(newNode("node1", "zone1", "10.244.0.0/24", "100.64.0.2/16", "100.88.0.2/16"))).To(gomega.Succeed())
+	g.Expect(nodeIndexer.Add(newNode("node2", "zone2", "10.244.1.0/24", "100.64.0.3/16", "100.88.0.3/16"))).To(gomega.Succeed())
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	g.Expect(podIndexer.Add(&kapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "ns1",
+			Annotations: map[string]string{
+				"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["10.244.0.5/24"],"mac_address":"0a:58:0a:f4:00:05"}}`,
+			},
+		},
+		Spec: kapi.PodSpec{NodeName: "node1"},
+	})).To(gomega.Succeed())
+	auditor := NewAuditor(nbClient, corelisters.NewNodeLister(nodeIndexer), corelisters.NewPodLister(podIndexer), nil)
+
+	expected := []RouterDiff{
+		{
+			Network: types.DefaultNetworkName,
+			Router:  types.GWRouterPrefix + "node1",
+			InSync:  1,
+			Stale: []Route{{Kind: KindExternalGateway, Node: "node1", Policy: "src-ip", IPPrefix: "10.244.0.6",
+				Nexthop: "172.18.0.5", OutputPort: port}},
+		},
+		{
+			Network: types.DefaultNetworkName,
+			Router:  types.OVNClusterRouter,
+			InSync:  2,
+			// the route to the gateway router of the remote node is missing
+			Missing: []Route{{Kind: KindRemoteNode, Node: "node2", IPPrefix: "100.64.0.3/32", Nexthop: "100.88.0.3"}},
+			Stale: []Route{
+				{Kind: KindNodeSubnet, Policy: "src-ip", IPPrefix: "10.244.5.0/24", Nexthop: "100.64.0.9"},
+				{Kind: KindRemoteNode, Node: "node3", IPPrefix: "10.244.2.0/24", Nexthop: "100.88.0.4"},
+			},
+		},
+	}
+	g.Expect(diffWithoutUUIDs(auditor)).To(gomega.Equal(expected))
+
+	rec := httptest.NewRecorder()
+	auditor.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path+"?router="+types.OVNClusterRouter, nil))
+	g.Expect(rec.Code).To(gomega.Equal(http.StatusOK))
+	var served []RouterDiff
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &served)).To(gomega.Succeed())
+	g.Expect(served).To(gomega.HaveLen(1))
+	g.Expect(served[0].Router).To(gomega.Equal(types.OVNClusterRouter))
+	g.Expect(served[0].Stale).To(gomega.HaveLen(2))
+
+	// the stale routes are only removed when still stale at the next reconciliation
+	g.Expect(auditor.reconcile()).To(gomega.Succeed())
+	g.Expect(diffWithoutUUIDs(auditor)).To(gomega.Equal(expected))
+	g.Expect(auditor.reconcile()).To(gomega.Succeed())
+	diffs, err := auditor.Diff("", "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(diffs).To(gomega.HaveLen(2))
+	for _, diff := range diffs {
+		g.Expect(diff.Stale).To(gomega.BeEmpty())
+	}
+	g.Expect(diffs[1].InSync).To(gomega.Equal(2))
+	g.Expect(diffs[1].Missing).To(gomega.HaveLen(1))
+}
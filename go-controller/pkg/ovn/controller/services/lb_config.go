@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
-	conf "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/unidling"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
@@ -378,11 +377,11 @@ func buildTemplateLBs(service *v1.Service, configs []lbConfig, nodes []nodeInfo,
 						switchV6TargetNeedsTemplate = true
 					}
 
-					routerV4targetips, changed := config.makeNodeRouterTargetIPs(&node, config.eps.V4IPs, conf.Gateway.MasqueradeIPs.V4HostMasqueradeIP.String())
+					routerV4targetips, changed := config.makeNodeRouterTargetIPs(&node, config.eps.V4IPs, node.hostMasqueradeIP(false))
 					if !routerV4TargetNeedsTemplate && changed {
 						routerV4TargetNeedsTemplate = true
 					}
-					routerV6targetips, changed := config.makeNodeRouterTargetIPs(&node, config.eps.V6IPs, conf.Gateway.MasqueradeIPs.V6HostMasqueradeIP.String())
+					routerV6targetips, changed := config.makeNodeRouterTargetIPs(&node, config.eps.V6IPs, node.hostMasqueradeIP(true))
 					if !routerV6TargetNeedsTemplate && changed {
 						routerV6TargetNeedsTemplate = true
 					}
@@ -567,8 +566,8 @@ func buildPerNodeLBs(service *v1.Service, configs []lbConfig, nodes []nodeInfo)
 				switchV4targetips, _ := config.makeNodeSwitchTargetIPs(&node, config.eps.V4IPs)
 				switchV6targetips, _ := config.makeNodeSwitchTargetIPs(&node, config.eps.V6IPs)
 
-				routerV4targetips, _ := config.makeNodeRouterTargetIPs(&node, config.eps.V4IPs, conf.Gateway.MasqueradeIPs.V4HostMasqueradeIP.String())
-				routerV6targetips, _ := config.makeNodeRouterTargetIPs(&node, config.eps.V6IPs, conf.Gateway.MasqueradeIPs.V6HostMasqueradeIP.String())
+				routerV4targetips, _ := config.makeNodeRouterTargetIPs(&node, config.eps.V4IPs, node.hostMasqueradeIP(false))
+				routerV6targetips, _ := config.makeNodeRouterTargetIPs(&node, config.eps.V6IPs, node.hostMasqueradeIP(true))
 
 				routerV4targets := joinHostsPort(routerV4targetips, config.eps.Port)
 				routerV6targets := joinHostsPort(routerV6targetips, config.eps.Port)
@@ -597,10 +596,10 @@ func buildPerNodeLBs(service *v1.Service, configs []lbConfig, nodes []nodeInfo)
 
 					if config.externalTrafficLocal && config.hasNodePort {
 						// add special masqueradeIP as a vip if its nodePort svc with ETP=local
-						mvip := conf.Gateway.MasqueradeIPs.V4HostETPLocalMasqueradeIP.String()
+						mvip := node.hostETPLocalMasqueradeIP(false)
 						targetsETP := joinHostsPort(switchV4targetips, config.eps.Port)
 						if isv6 {
-							mvip = conf.Gateway.MasqueradeIPs.V6HostMasqueradeIP.String()
+							mvip = node.hostETPLocalMasqueradeIP(true)
 							targetsETP = joinHostsPort(switchV6targetips, config.eps.Port)
 						}
 						switchRules = append(switchRules, LBRule{
@@ -1006,13 +1006,64 @@ func Test_buildPerNodeLBs(t *testing.T) {
 	//defaultRouters := []string{"gr-node-a", "gr-node-b"}
 	//defaultSwitches := []string{"switch-node-a", "switch-node-b"}
 
+	masqueradeNodes := []nodeInfo{defaultNodes[0], defaultNodes[1]}
+	masqueradeIPs, err := globalconfig.MasqueradeIPsForSubnets("169.254.0.8/29", "fd69::8/125")
+	assert.NoError(t, err)
+	masqueradeNodes[0].masqueradeIPs = masqueradeIPs
+
 	tc := []struct {
 		name           string
 		service        *v1.Service
 		configs        []lbConfig
+		nodes          []nodeInfo
 		expectedShared []LB
 		expectedLocal  []LB
 	}{
+		{
+			name:    "host-network pod, node with selected masquerade subnets",
+			service: defaultService,
+			configs: []lbConfig{
+				{
+					vips:     []string{"1.2.3.4"},
+					protocol: v1.ProtocolTCP,
+					inport:   80,
+					eps: util.LbEndpoints{
+						V4IPs: []string{"10.0.0.1"},
+						Port:  8080,
+					},
+				},
+			},
+			nodes: masqueradeNodes,
+			expectedShared: []LB{
+				{
+					Name:        "Service_testns/foo_TCP_node_router_node-a",
+					ExternalIDs: defaultExternalIDs,
+					Routers:     []string{"gr-node-a"},
+					Protocol:    "TCP",
+					Rules: []LBRule{
+						{
+							Source:  Addr{IP: "1.2.3.4", Port: 80},
+							Targets: []Addr{{IP: "169.254.0.10", Port: 8080}},
+						},
+					},
+					Opts: defaultOpts,
+				},
+				{
+					Name:        "Service_testns/foo_TCP_node_switch_node-a_merged",
+					ExternalIDs: defaultExternalIDs,
+					Routers:     []string{"gr-node-b"},
+					Switches:    []string{"switch-node-a", "switch-node-b"},
+					Protocol:    "TCP",
+					Rules: []LBRule{
+						{
+							Source:  Addr{IP: "1.2.3.4", Port: 80},
+							Targets: []Addr{{IP: "10.0.0.1", Port: 8080}},
+						},
+					},
+					Opts: defaultOpts,
+				},
+			},
+		},
 		{
 			name:    "host-network pod",
 			service: defaultService,
@@ -1951,16 +2002,20 @@ func Test_buildPerNodeLBs(t *testing.T) {
 
 	for i, tt := range tc {
 		t.Run(fmt.Sprintf("%d_%s", i, tt.name), func(t *testing.T) {
+			nodes := defaultNodes
+			if tt.nodes != nil {
+				nodes = tt.nodes
+			}
 
 			if tt.expectedShared != nil {
 				globalconfig.Gateway.Mode = globalconfig.GatewayModeShared
-				actual := buildPerNodeLBs(tt.service, tt.configs, defaultNodes)
+				actual := buildPerNodeLBs(tt.service, tt.configs, nodes)
 				assert.Equal(t, tt.expectedShared, actual, "shared gateway mode not as expected")
 			}
 
 			if tt.expectedLocal != nil {
 				globalconfig.Gateway.Mode = globalconfig.GatewayModeLocal
-				actual := buildPerNodeLBs(tt.service, tt.configs, defaultNodes)
+				actual := buildPerNodeLBs(tt.service, tt.configs, nodes)
 				assert.Equal(t, tt.expectedLocal, actual, "local gateway mode not as expected")
 			}
 
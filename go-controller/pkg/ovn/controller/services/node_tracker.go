@@ -33,6 +33,10 @@ type nodeTracker struct {
 
 	// zone in which this nodeTracker is tracking
 	zone string
+
+	// nodeNetworkStates gets the node network state holding the host subnets
+	// of the nodes with the "crd" backend
+	nodeNetworkStates util.NodeNetworkStateGetter
 }
 
 type nodeInfo struct {
@@ -51,6 +55,9 @@ type nodeInfo struct {
 	// The chassisID of the node (ovs.external-ids:system-id)
 	chassisID string
 
+	// The masquerade IPs of the node, nil for the configured ones
+	masqueradeIPs *globalconfig.MasqueradeIPsConfig
+
 	// The node's zone
 	zone string
 	/** HACK BEGIN **/
@@ -67,6 +74,32 @@ func (ni *nodeInfo) hostAddressesStr() []string {
 	return out
 }
 
+// hostMasqueradeIP returns the masquerade IP of the host of the node, from the
+// masquerade subnets the node selected if any
+func (ni *nodeInfo) hostMasqueradeIP(isIPv6 bool) string {
+	masqueradeIPs := ni.masqueradeIPs
+	if masqueradeIPs == nil {
+		masqueradeIPs = &globalconfig.Gateway.MasqueradeIPs
+	}
+	if isIPv6 {
+		return masqueradeIPs.V6HostMasqueradeIP.String()
+	}
+	return masqueradeIPs.V4HostMasqueradeIP.String()
+}
+
+// hostETPLocalMasqueradeIP returns the masquerade IP of the host of the node
+// for the ExternalTrafficPolicy=local NodePort services
+func (ni *nodeInfo) hostETPLocalMasqueradeIP(isIPv6 bool) string {
+	masqueradeIPs := ni.masqueradeIPs
+	if masqueradeIPs == nil {
+		masqueradeIPs = &globalconfig.Gateway.MasqueradeIPs
+	}
+	if isIPv6 {
+		return masqueradeIPs.V6HostMasqueradeIP.String()
+	}
+	return masqueradeIPs.V4HostETPLocalMasqueradeIP.String()
+}
+
 func (ni *nodeInfo) l3gatewayAddressesStr() []string {
 	out := make([]string, 0, len(ni.l3gatewayAddresses))
 	for _, ip := range ni.l3gatewayAddresses {
@@ -96,11 +129,12 @@ func (ni *nodeInfo) nodeSubnets() []net.IPNet {
 	return out
 }
 
-func newNodeTracker(zone string, resyncFn func(nodes []nodeInfo)) *nodeTracker {
+func newNodeTracker(zone string, resyncFn func(nodes []nodeInfo), nodeNetworkStates util.NodeNetworkStateGetter) *nodeTracker {
 	return &nodeTracker{
-		nodes:    map[string]nodeInfo{},
-		zone:     zone,
-		resyncFn: resyncFn,
+		nodes:             map[string]nodeInfo{},
+		zone:              zone,
+		resyncFn:          resyncFn,
+		nodeNetworkStates: nodeNetworkStates,
 	}
 }
 
@@ -133,13 +167,15 @@ func (nt *nodeTracker) Start(nodeInformer coreinformers.NodeInformer) (cache.Res
 			// - the name of the node (very rare) has changed
 			// - the `host-addresses` annotation changed
 			// - node changes its zone
+			// - node selects other masquerade subnets
 			// . No need to trigger update for any other field change.
 			if util.NodeSubnetAnnotationChanged(oldObj, newObj) ||
 				util.NodeL3GatewayAnnotationChanged(oldObj, newObj) ||
 				oldObj.Name != newObj.Name ||
 				util.NodeHostAddressesAnnotationChanged(oldObj, newObj) ||
 				util.NodeZoneAnnotationChanged(oldObj, newObj) ||
-				util.NodeMigratedZoneAnnotationChanged(oldObj, newObj) {
+				util.NodeMigratedZoneAnnotationChanged(oldObj, newObj) ||
+				util.NodeMasqueradeSubnetsAnnotationChanged(oldObj, newObj) {
 				nt.updateNode(newObj)
 			}
 		},
@@ -166,7 +202,7 @@ func (nt *nodeTracker) Start(nodeInformer coreinformers.NodeInformer) (cache.Res
 // updateNodeInfo updates the node info cache, and syncs all services
 // if it changed.
 func (nt *nodeTracker) updateNodeInfo(nodeName, switchName, routerName, chassisID string, l3gatewayAddresses,
-	hostAddresses []net.IP, podSubnets []*net.IPNet, zone string, migrated bool) {
+	hostAddresses []net.IP, podSubnets []*net.IPNet, masqueradeIPs *globalconfig.MasqueradeIPsConfig, zone string, migrated bool) {
 	ni := nodeInfo{
 		name:               nodeName,
 		l3gatewayAddresses: l3gatewayAddresses,
@@ -175,6 +211,7 @@ func (nt *nodeTracker) updateNodeInfo(nodeName, switchName, routerName, chassisI
 		gatewayRouterName:  routerName,
 		switchName:         switchName,
 		chassisID:          chassisID,
+		masqueradeIPs:      masqueradeIPs,
 		zone:               zone,
 		migrated:           migrated,
 	}
@@ -222,7 +259,7 @@ func (nt *nodeTracker) removeNode(nodeName string) {
 // The gateway router will exist sometime after the L3Gateway annotation is set.
 func (nt *nodeTracker) updateNode(node *v1.Node) {
 	klog.V(2).Infof("Processing possible switch / router updates for node %s", node.Name)
-	hsn, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+	hsn, err := util.ParseNodeHostSubnets(node, types.DefaultNetworkName, nt.nodeNetworkStates)
 	if err != nil || hsn == nil {
 		// usually normal; means the node's gateway hasn't been initialized yet
 		klog.Infof("Node %s has invalid / no HostSubnet annotations (probably waiting on initialization): %v", node.Name, err)
@@ -261,6 +298,13 @@ func (nt *nodeTracker) updateNode(node *v1.Node) {
 		hostAddressesIPs = append(hostAddressesIPs, ip)
 	}
 
+	var masqueradeIPs *globalconfig.MasqueradeIPsConfig
+	if _, err := util.ParseNodeMasqueradeSubnets(node); err == nil {
+		if masqueradeIPs, err = util.GetNodeMasqueradeIPs(node); err != nil {
+			klog.Warningf("Failed to get the masquerade IPs of node %s, using the configured ones: %v", node.Name, err)
+		}
+	}
+
 	nt.updateNodeInfo(
 		node.Name,
 		switchName,
@@ -269,6 +313,7 @@ func (nt *nodeTracker) updateNode(node *v1.Node) {
 		l3gatewayAddresses,
 		hostAddressesIPs,
 		hsn,
+		masqueradeIPs,
 		util.GetNodeZone(node),
 		util.HasNodeMigratedZone(node),
 	)
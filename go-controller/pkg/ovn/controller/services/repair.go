@@ -128,10 +128,12 @@ func (r *repair) runBeforeSync(useTemplates bool) {
 	klog.V(2).Infof("Deleted %d stale service LBs", len(staleLBs))
 
 	// Delete those stale template vars
-	if err := libovsdbops.DeleteAllChassisTemplateVarVariables(r.nbClient, staleTemplateNames.UnsortedList()); err != nil {
-		klog.Errorf("Failed to delete stale Chassis Template Vars: %v", err)
+	if useTemplates {
+		if err := libovsdbops.DeleteAllChassisTemplateVarVariables(r.nbClient, staleTemplateNames.UnsortedList()); err != nil {
+			klog.Errorf("Failed to delete stale Chassis Template Vars: %v", err)
+		}
+		klog.V(2).Infof("Deleted %d stale Chassis Template Vars", len(staleTemplateNames))
 	}
-	klog.V(2).Infof("Deleted %d stale Chassis Template Vars", len(staleTemplateNames))
 
 	// Remove existing reject rules. They are not used anymore
 	// given the introduction of idling loadbalancers
@@ -43,6 +43,10 @@ const (
 	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
 	maxRetries = 15
 
+	// priorityWorkers is the number of workers of the services of the
+	// priority namespaces
+	priorityWorkers = 2
+
 	controllerName     = "ovn-lb-controller"
 	nodeControllerName = "node-tracker-controller"
 )
@@ -55,6 +59,7 @@ func NewController(client clientset.Interface,
 	serviceInformer coreinformers.ServiceInformer,
 	endpointSliceInformer discoveryinformers.EndpointSliceInformer,
 	nodeInformer coreinformers.NodeInformer,
+	nodeNetworkStates util.NodeNetworkStateGetter,
 	recorder record.EventRecorder,
 ) (*Controller, error) {
 	klog.V(4).Info("Creating event broadcaster")
@@ -62,6 +67,7 @@ func NewController(client clientset.Interface,
 		client:                client,
 		nbClient:              nbClient,
 		queue:                 workqueue.NewNamedRateLimitingQueue(newRatelimiter(100), controllerName),
+		priorityQueue:         workqueue.NewNamedRateLimitingQueue(newRatelimiter(100), controllerName+"-priority"),
 		workerLoopPeriod:      time.Second,
 		alreadyApplied:        map[string][]LB{},
 		nodeIPv4Templates:     NewNodeIPsTemplates(v1.IPv4Protocol),
@@ -82,7 +88,7 @@ func NewController(client clientset.Interface,
 	// load balancers need to be applied to nodes, so
 	// we need to watch Node objects for changes.
 	// Need to re-sync all services when a node gains its switch or GWR
-	c.nodeTracker = newNodeTracker(zone, c.RequestFullSync)
+	c.nodeTracker = newNodeTracker(zone, c.RequestFullSync, nodeNetworkStates)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +121,10 @@ type Controller struct {
 	// service that's inserted multiple times to be processed more than
 	// necessary.
 	queue workqueue.RateLimitingInterface
+	// priorityQueue holds the services of the priority namespaces, served
+	// by dedicated workers so that they don't wait behind the services of
+	// the other namespaces
+	priorityQueue workqueue.RateLimitingInterface
 
 	// workerLoopPeriod is the time between worker runs. The workers process the queue of service and pod changes.
 	workerLoopPeriod time.Duration
@@ -156,6 +166,7 @@ type Controller struct {
 func (c *Controller) Run(workers int, stopCh <-chan struct{}, runRepair, useLBGroups, useTemplates bool) error {
 	defer utilruntime.HandleCrash()
 	defer c.queue.ShutDown()
+	defer c.priorityQueue.ShutDown()
 
 	c.useLBGroups = useLBGroups
 	c.useTemplates = useTemplates
@@ -213,7 +224,12 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}, runRepair, useLBGr
 	// Start the workers after the repair loop to avoid races
 	klog.Info("Starting workers")
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.worker, c.workerLoopPeriod, stopCh)
+		go wait.Until(func() { c.worker(c.queue) }, c.workerLoopPeriod, stopCh)
+	}
+	if globalconfig.Kubernetes.PriorityNamespaces.Len() > 0 {
+		for i := 0; i < priorityWorkers; i++ {
+			go wait.Until(func() { c.worker(c.priorityQueue) }, c.workerLoopPeriod, stopCh)
+		}
 	}
 
 	<-stopCh
@@ -224,46 +240,46 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}, runRepair, useLBGr
 // marks them done. You may run as many of these in parallel as you wish; the
 // workqueue guarantees that they will not end up processing the same service
 // at the same time.
-func (c *Controller) worker() {
-	for c.processNextWorkItem() {
+func (c *Controller) worker(queue workqueue.RateLimitingInterface) {
+	for c.processNextWorkItem(queue) {
 	}
 }
 
-func (c *Controller) processNextWorkItem() bool {
-	eKey, quit := c.queue.Get()
+func (c *Controller) processNextWorkItem(queue workqueue.RateLimitingInterface) bool {
+	eKey, quit := queue.Get()
 	if quit {
 		return false
 	}
-	defer c.queue.Done(eKey)
+	defer queue.Done(eKey)
 
 	err := c.syncService(eKey.(string))
-	c.handleErr(err, eKey)
+	c.handleErr(queue, err, eKey)
 
 	return true
 }
 
-func (c *Controller) handleErr(err error, key interface{}) {
+func (c *Controller) handleErr(queue workqueue.RateLimitingInterface, err error, key interface{}) {
 	ns, name, keyErr := cache.SplitMetaNamespaceKey(key.(string))
 	if keyErr != nil {
 		klog.ErrorS(err, "Failed to split meta namespace cache key", "key", key)
 	}
 	if err == nil {
 		metrics.GetConfigDurationRecorder().End("service", ns, name)
-		c.queue.Forget(key)
+		queue.Forget(key)
 		return
 	}
 
 	metrics.MetricRequeueServiceCount.Inc()
 
-	if c.queue.NumRequeues(key) < maxRetries {
+	if queue.NumRequeues(key) < maxRetries {
 		klog.V(2).InfoS("Error syncing service, retrying", "service", klog.KRef(ns, name), "err", err)
-		c.queue.AddRateLimited(key)
+		queue.AddRateLimited(key)
 		return
 	}
 
 	klog.Warningf("Dropping service %q out of the queue: %v", key, err)
 	metrics.GetConfigDurationRecorder().End("service", ns, name)
-	c.queue.Forget(key)
+	queue.Forget(key)
 	utilruntime.HandleError(err)
 }
 
@@ -524,8 +540,24 @@ func (c *Controller) RequestFullSync(nodeInfos []nodeInfo) {
 	}
 }
 
+// NodeHostSubnetsChanged updates the node whose host subnets changed in its
+// node network state, as a change of its node subnet annotation does
+func (c *Controller) NodeHostSubnetsChanged(node *v1.Node) {
+	c.nodeTracker.updateNode(node)
+}
+
 // handlers
 
+// queueFor returns the queue of the service with the key: the priority queue
+// for the services of the priority namespaces, the regular queue otherwise
+func (c *Controller) queueFor(key string) workqueue.RateLimitingInterface {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err == nil && globalconfig.Kubernetes.PriorityNamespaces.Has(namespace) {
+		return c.priorityQueue
+	}
+	return c.queue
+}
+
 // onServiceAdd queues the Service for processing.
 func (c *Controller) onServiceAdd(obj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
@@ -536,7 +568,7 @@ func (c *Controller) onServiceAdd(obj interface{}) {
 	klog.V(5).Infof("Adding service %s", key)
 	service := obj.(*v1.Service)
 	metrics.GetConfigDurationRecorder().Start("service", service.Namespace, service.Name)
-	c.queue.Add(key)
+	c.queueFor(key).Add(key)
 }
 
 // onServiceUpdate updates the Service Selector in the cache and queues the Service for processing.
@@ -553,7 +585,7 @@ func (c *Controller) onServiceUpdate(oldObj, newObj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(newObj)
 	if err == nil {
 		metrics.GetConfigDurationRecorder().Start("service", newService.Namespace, newService.Name)
-		c.queue.Add(key)
+		c.queueFor(key).Add(key)
 	}
 }
 
@@ -567,7 +599,7 @@ func (c *Controller) onServiceDelete(obj interface{}) {
 	klog.V(4).Infof("Deleting service %s", key)
 	service := obj.(*v1.Service)
 	metrics.GetConfigDurationRecorder().Start("service", service.Namespace, service.Name)
-	c.queue.Add(key)
+	c.queueFor(key).Add(key)
 }
 
 // onEndpointSliceAdd queues a sync for the relevant Service for a sync
@@ -632,7 +664,7 @@ func (c *Controller) queueServiceForEndpointSlice(endpointSlice *discovery.Endpo
 		return
 	}
 
-	c.queue.Add(key)
+	c.queueFor(key).Add(key)
 }
 
 // serviceControllerKey returns a controller key for a Service but derived from
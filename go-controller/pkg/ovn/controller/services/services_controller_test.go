@@ -21,6 +21,7 @@ import (
 	discovery "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
@@ -74,6 +75,7 @@ func newControllerWithDBSetup(dbSetup libovsdbtest.TestSetup) (*serviceControlle
 		informerFactory.Core().V1().Services(),
 		informerFactory.Discovery().V1().EndpointSlices(),
 		informerFactory.Core().V1().Nodes(),
+		nil,
 		recorder,
 	)
 	gomega.Expect(err).ToNot(gomega.HaveOccurred())
@@ -655,6 +657,39 @@ func Test_ETPCluster_NodePort_Service_WithMultipleIPAddresses(t *testing.T) {
 
 }
 
+func TestPriorityNamespaceServices(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	globalconfig.Kubernetes.PriorityNamespaces = sets.New("kube-system")
+	defer func() {
+		globalconfig.Kubernetes.PriorityNamespaces = nil
+	}()
+
+	controller, err := newController()
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	defer controller.close()
+
+	tenantSvc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc-foo", Namespace: "tenant"}}
+	criticalSvc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"}}
+	controller.onServiceAdd(tenantSvc)
+	controller.onServiceAdd(criticalSvc)
+	controller.queueServiceForEndpointSlice(&discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-dns-abcde",
+			Namespace: "kube-system",
+			Labels:    map[string]string{discovery.LabelServiceName: "kube-dns"},
+		},
+	})
+
+	g.Expect(controller.queue.Len()).To(gomega.Equal(1))
+	g.Expect(controller.priorityQueue.Len()).To(gomega.Equal(1))
+	key, _ := controller.priorityQueue.Get()
+	g.Expect(key).To(gomega.Equal("kube-system/kube-dns"))
+	controller.priorityQueue.Done(key)
+	key, _ = controller.queue.Get()
+	g.Expect(key).To(gomega.Equal("tenant/svc-foo"))
+	controller.queue.Done(key)
+}
+
 func nodeLogicalSwitch(nodeName string, lbGroups []string, namespacedServiceNames ...string) *nbdb.LogicalSwitch {
 	ls := &nbdb.LogicalSwitch{
 		UUID:              nodeSwitchName(nodeName),
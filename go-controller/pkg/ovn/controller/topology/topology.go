@@ -0,0 +1,317 @@
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+const (
+	// Path is the path of the metrics server where the topology is served
+	Path = "/topology"
+
+	formatJSON = "json"
+	formatDOT  = "dot"
+)
+
+// NodeKind is the kind of a logical entity of the topology
+type NodeKind string
+
+const (
+	KindSwitch       NodeKind = "switch"
+	KindRouter       NodeKind = "router"
+	KindSwitchPort   NodeKind = "switch-port"
+	KindRouterPort   NodeKind = "router-port"
+	KindLoadBalancer NodeKind = "load-balancer"
+)
+
+// EdgeKind is the kind of a relationship between logical entities
+type EdgeKind string
+
+const (
+	// EdgePort links a switch or a router to its port
+	EdgePort EdgeKind = "port"
+	// EdgePeer links a switch port of type router to its router port, or two
+	// peer router ports
+	EdgePeer EdgeKind = "peer"
+	// EdgeLoadBalancer links a switch or a router to a load balancer applied
+	// to it, directly or through a load balancer group
+	EdgeLoadBalancer EdgeKind = "load-balancer"
+)
+
+// Node is a logical entity of the topology
+type Node struct {
+	// ID identifies the node in the graph, it is the kind and the name of the
+	// entity so that it is stable across exports
+	ID         string            `json:"id"`
+	Kind       NodeKind          `json:"kind"`
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Edge is a relationship between two logical entities of the topology
+type Edge struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+// Graph is the logical topology built by ovnkube for a network in the
+// Northbound database of a zone. With interconnect, the entities of the other
+// zones only show as remote ports.
+type Graph struct {
+	Network string `json:"network"`
+	Zone    string `json:"zone"`
+	Nodes   []Node `json:"nodes"`
+	Edges   []Edge `json:"edges"`
+
+	nodes map[string]bool
+	edges map[Edge]bool
+}
+
+func nodeID(kind NodeKind, name string) string {
+	return string(kind) + ":" + name
+}
+
+func (g *Graph) addNode(kind NodeKind, name string, attributes map[string]string) string {
+	id := nodeID(kind, name)
+	if !g.nodes[id] {
+		g.nodes[id] = true
+		g.Nodes = append(g.Nodes, Node{ID: id, Kind: kind, Name: name, Attributes: attributes})
+	}
+	return id
+}
+
+func (g *Graph) addEdge(from, to string, kind EdgeKind) {
+	edge := Edge{From: from, To: to, Kind: kind}
+	if !g.edges[edge] {
+		g.edges[edge] = true
+		g.Edges = append(g.Edges, edge)
+	}
+}
+
+// isNetworkEntity returns whether the external IDs of a switch or a router
+// belong to the network
+func isNetworkEntity(network string, externalIDs map[string]string) bool {
+	if network == types.DefaultNetworkName {
+		_, ok := externalIDs[types.NetworkExternalID]
+		return !ok
+	}
+	return externalIDs[types.NetworkExternalID] == network
+}
+
+// BuildGraph exports the switches and routers of the network, their ports and
+// the load balancers applied to them from the Northbound database
+func BuildGraph(nbClient libovsdbclient.Client, network string) (*Graph, error) {
+	g := &Graph{
+		Network: network,
+		Zone:    config.Default.Zone,
+		Nodes:   []Node{},
+		Edges:   []Edge{},
+		nodes:   map[string]bool{},
+		edges:   map[Edge]bool{},
+	}
+	switches, err := libovsdbops.FindLogicalSwitchesWithPredicate(nbClient, func(ls *nbdb.LogicalSwitch) bool {
+		return isNetworkEntity(network, ls.ExternalIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the logical switches: %w", err)
+	}
+	routers, err := libovsdbops.FindLogicalRoutersWithPredicate(nbClient, func(lr *nbdb.LogicalRouter) bool {
+		return isNetworkEntity(network, lr.ExternalIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the logical routers: %w", err)
+	}
+	sort.Slice(switches, func(i, j int) bool { return switches[i].Name < switches[j].Name })
+	sort.Slice(routers, func(i, j int) bool { return routers[i].Name < routers[j].Name })
+
+	ctx, cancel := context.WithTimeout(context.Background(), types.OVSDBTimeout)
+	defer cancel()
+	// the peers are linked once all the ports are known, by name
+	routerPortPeers := map[string]string{}
+	for _, ls := range switches {
+		id := g.addNode(KindSwitch, ls.Name, nil)
+		for _, uuid := range ls.Ports {
+			lsp := &nbdb.LogicalSwitchPort{UUID: uuid}
+			if err := nbClient.Get(ctx, lsp); err != nil {
+				klog.V(5).Infof("Skipping port %s of switch %s: %v", uuid, ls.Name, err)
+				continue
+			}
+			attributes := map[string]string{}
+			if lsp.Type != "" {
+				attributes["type"] = lsp.Type
+			}
+			if len(lsp.Addresses) > 0 {
+				attributes["addresses"] = strings.Join(lsp.Addresses, ",")
+			}
+			portID := g.addNode(KindSwitchPort, lsp.Name, attributes)
+			g.addEdge(id, portID, EdgePort)
+			if lsp.Type == "router" && lsp.Options["router-port"] != "" {
+				routerPortPeers[portID] = lsp.Options["router-port"]
+			}
+		}
+		if err := g.addLoadBalancers(ctx, nbClient, id, ls.LoadBalancer, ls.LoadBalancerGroup); err != nil {
+			return nil, err
+		}
+	}
+	for _, lr := range routers {
+		id := g.addNode(KindRouter, lr.Name, nil)
+		for _, uuid := range lr.Ports {
+			lrp := &nbdb.LogicalRouterPort{UUID: uuid}
+			if err := nbClient.Get(ctx, lrp); err != nil {
+				klog.V(5).Infof("Skipping port %s of router %s: %v", uuid, lr.Name, err)
+				continue
+			}
+			attributes := map[string]string{"mac": lrp.MAC}
+			if len(lrp.Networks) > 0 {
+				attributes["networks"] = strings.Join(lrp.Networks, ",")
+			}
+			portID := g.addNode(KindRouterPort, lrp.Name, attributes)
+			g.addEdge(id, portID, EdgePort)
+			if lrp.Peer != nil && lrp.Name < *lrp.Peer {
+				routerPortPeers[portID] = *lrp.Peer
+			}
+		}
+		if err := g.addLoadBalancers(ctx, nbClient, id, lr.LoadBalancer, lr.LoadBalancerGroup); err != nil {
+			return nil, err
+		}
+	}
+	for from, peer := range routerPortPeers {
+		if to := nodeID(KindRouterPort, peer); g.nodes[to] {
+			g.addEdge(from, to, EdgePeer)
+		}
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g, nil
+}
+
+// addLoadBalancers adds the load balancers applied to a switch or a router,
+// directly or through load balancer groups
+func (g *Graph) addLoadBalancers(ctx context.Context, nbClient libovsdbclient.Client, id string, lbs, groups []string) error {
+	lbs = append([]string{}, lbs...)
+	for _, uuid := range groups {
+		group := &nbdb.LoadBalancerGroup{UUID: uuid}
+		if err := nbClient.Get(ctx, group); err != nil {
+			if errors.Is(err, libovsdbclient.ErrNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to get the load balancer group %s: %w", uuid, err)
+		}
+		lbs = append(lbs, group.LoadBalancer...)
+	}
+	for _, uuid := range lbs {
+		lb := &nbdb.LoadBalancer{UUID: uuid}
+		if err := nbClient.Get(ctx, lb); err != nil {
+			if errors.Is(err, libovsdbclient.ErrNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to get the load balancer %s: %w", uuid, err)
+		}
+		attributes := map[string]string{}
+		if lb.Protocol != nil {
+			attributes["protocol"] = *lb.Protocol
+		}
+		vips := make([]string, 0, len(lb.Vips))
+		for vip := range lb.Vips {
+			vips = append(vips, vip)
+		}
+		sort.Strings(vips)
+		if len(vips) > 0 {
+			attributes["vips"] = strings.Join(vips, ",")
+		}
+		g.addEdge(id, g.addNode(KindLoadBalancer, lb.Name, attributes), EdgeLoadBalancer)
+	}
+	return nil
+}
+
+// dotShapes are the shapes of the nodes of each kind in DOT
+var dotShapes = map[NodeKind]string{
+	KindSwitch:       "box",
+	KindRouter:       "octagon",
+	KindSwitchPort:   "ellipse",
+	KindRouterPort:   "ellipse",
+	KindLoadBalancer: "diamond",
+}
+
+// WriteDOT writes the graph in the DOT language of Graphviz
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "graph %q {\n", g.Network+"@"+g.Zone)
+	for _, node := range g.Nodes {
+		label := node.Name
+		if node.Attributes["type"] != "" {
+			label += "\\n(" + node.Attributes["type"] + ")"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q shape=%s];\n", node.ID, label, dotShapes[node.Kind])
+	}
+	for _, edge := range g.Edges {
+		style := "solid"
+		switch edge.Kind {
+		case EdgePeer:
+			style = "bold"
+		case EdgeLoadBalancer:
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %q -- %q [style=%s];\n", edge.From, edge.To, style)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// NewHandler returns a handler serving the topology of the network given by
+// the "network" query parameter, the default network if not set, in the
+// format given by the "format" query parameter, "json" (default) or "dot"
+func NewHandler(nbClient libovsdbclient.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		format := query.Get("format")
+		if format != "" && format != formatJSON && format != formatDOT {
+			http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+			return
+		}
+		network := query.Get("network")
+		if network == "" {
+			network = types.DefaultNetworkName
+		}
+		g, err := BuildGraph(nbClient, network)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to export the topology of network %s: %v", network, err),
+				http.StatusInternalServerError)
+			return
+		}
+		if len(g.Nodes) == 0 {
+			http.Error(w, fmt.Sprintf("no topology found for network %q", network), http.StatusNotFound)
+			return
+		}
+		if format == formatDOT {
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			err = g.WriteDOT(w)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(w).Encode(g)
+		}
+		if err != nil {
+			klog.Errorf("Failed to write the topology of network %s: %v", network, err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package topology
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+func TestBuildGraph(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	protocol := nbdb.LoadBalancerProtocolTCP
+	peer := "rtos-node1"
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{
+			&nbdb.LogicalSwitchPort{
+				UUID:      "pod-lsp-uuid",
+				Name:      "ns1_pod1",
+				Addresses: []string{"0a:58:0a:80:00:05 10.128.0.5"},
+			},
+			&nbdb.LogicalSwitchPort{
+				UUID:    "stor-lsp-uuid",
+				Name:    "stor-node1",
+				Type:    "router",
+				Options: map[string]string{"router-port": peer},
+			},
+			&nbdb.LogicalRouterPort{
+				UUID:     "rtos-lrp-uuid",
+				Name:     peer,
+				MAC:      "0a:58:0a:80:00:01",
+				Networks: []string{"10.128.0.1/24"},
+			},
+			&nbdb.LoadBalancer{
+				UUID:     "lb-uuid",
+				Name:     "Service_ns1/svc1_TCP_cluster",
+				Protocol: &protocol,
+				Vips:     map[string]string{"172.30.0.10:80": "10.128.0.5:8080"},
+			},
+			&nbdb.LoadBalancerGroup{
+				UUID:         "lbg-uuid",
+				Name:         types.ClusterLBGroupName,
+				LoadBalancer: []string{"lb-uuid"},
+			},
+			&nbdb.LogicalSwitch{
+				UUID:              "node1-ls-uuid",
+				Name:              "node1",
+				Ports:             []string{"pod-lsp-uuid", "stor-lsp-uuid"},
+				LoadBalancerGroup: []string{"lbg-uuid"},
+			},
+			&nbdb.LogicalRouter{
+				UUID:  "cluster-router-uuid",
+				Name:  types.OVNClusterRouter,
+				Ports: []string{"rtos-lrp-uuid"},
+			},
+			&nbdb.LogicalSwitch{
+				UUID:        "blue-ls-uuid",
+				Name:        "blue_node1",
+				ExternalIDs: map[string]string{types.NetworkExternalID: "blue"},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to set up the test harness: %v", err)
+	}
+	t.Cleanup(cleanup.Cleanup)
+
+	graph, err := BuildGraph(nbClient, types.DefaultNetworkName)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(graph.Nodes).To(gomega.ConsistOf(
+		Node{ID: "switch:node1", Kind: KindSwitch, Name: "node1"},
+		Node{ID: "switch-port:ns1_pod1", Kind: KindSwitchPort, Name: "ns1_pod1",
+			Attributes: map[string]string{"addresses": "0a:58:0a:80:00:05 10.128.0.5"}},
+		Node{ID: "switch-port:stor-node1", Kind: KindSwitchPort, Name: "stor-node1",
+			Attributes: map[string]string{"type": "router"}},
+		Node{ID: "load-balancer:Service_ns1/svc1_TCP_cluster", Kind: KindLoadBalancer, Name: "Service_ns1/svc1_TCP_cluster",
+			Attributes: map[string]string{"protocol": "tcp", "vips": "172.30.0.10:80"}},
+		Node{ID: "router:" + types.OVNClusterRouter, Kind: KindRouter, Name: types.OVNClusterRouter},
+		Node{ID: "router-port:rtos-node1", Kind: KindRouterPort, Name: "rtos-node1",
+			Attributes: map[string]string{"mac": "0a:58:0a:80:00:01", "networks": "10.128.0.1/24"}},
+	))
+	g.Expect(graph.Edges).To(gomega.ConsistOf(
+		Edge{From: "switch:node1", To: "switch-port:ns1_pod1", Kind: EdgePort},
+		Edge{From: "switch:node1", To: "switch-port:stor-node1", Kind: EdgePort},
+		Edge{From: "switch:node1", To: "load-balancer:Service_ns1/svc1_TCP_cluster", Kind: EdgeLoadBalancer},
+		Edge{From: "router:" + types.OVNClusterRouter, To: "router-port:rtos-node1", Kind: EdgePort},
+		Edge{From: "switch-port:stor-node1", To: "router-port:rtos-node1", Kind: EdgePeer},
+	))
+
+	var dot bytes.Buffer
+	g.Expect(graph.WriteDOT(&dot)).To(gomega.Succeed())
+	g.Expect(dot.String()).To(gomega.ContainSubstring(`"switch-port:stor-node1" -- "router-port:rtos-node1" [style=bold];`))
+
+	// the entities of the other networks are not included
+	graph, err = BuildGraph(nbClient, "blue")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(graph.Nodes).To(gomega.ConsistOf(Node{ID: "switch:blue_node1", Kind: KindSwitch, Name: "blue_node1"}))
+
+	handler := NewHandler(nbClient)
+	for query, code := range map[string]int{
+		"":                         http.StatusOK,
+		"?network=blue&format=dot": http.StatusOK,
+		"?network=red":             http.StatusNotFound,
+		"?format=svg":              http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path+query, nil))
+		g.Expect(rec.Code).To(gomega.Equal(code), query)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	addressset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/address_set"
 	anpcontroller "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/admin_network_policy"
 	apbroutecontroller "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/apbroute"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/ctzone"
 	egresssvc "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/egressservice"
 	svccontroller "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/services"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/unidling"
@@ -87,6 +88,10 @@ type DefaultNetworkController struct {
 	// Cluster-wide router default Control Plane Protection (COPP) UUID
 	defaultCOPPUUID string
 
+	// Allocator of the dedicated SNAT conntrack zones of the gateway routers,
+	// nil unless the dedicated allocation strategy is configured
+	snatCTZoneAllocator *ctzone.Allocator
+
 	// Controller used for programming OVN for egress IP
 	eIPC egressIPZoneController
 
@@ -124,6 +129,9 @@ type DefaultNetworkController struct {
 	hybridOverlayFailed         sync.Map
 	syncZoneICFailed            sync.Map
 	syncMigratablePodsFailed    sync.Map
+	// generation of the host subnets of each node last processed, so that
+	// older host subnets of a node are not acted upon
+	nodeSubnetsGenerations sync.Map
 
 	// variable to determine if all pods present on the node during startup have been processed
 	// updated atomically
@@ -159,6 +167,7 @@ func newDefaultNetworkControllerCommon(cnci *CommonNetworkControllerInfo,
 		cnci.watchFactory.ServiceCoreInformer(),
 		cnci.watchFactory.EndpointSliceCoreInformer(),
 		cnci.watchFactory.NodeCoreInformer(),
+		cnci.watchFactory,
 		cnci.recorder,
 	)
 	if err != nil {
@@ -200,6 +209,7 @@ func newDefaultNetworkControllerCommon(cnci *CommonNetworkControllerInfo,
 			networkPolicies:             syncmap.NewSyncMap[*networkPolicy](),
 			sharedNetpolPortGroups:      syncmap.NewSyncMap[*defaultDenyPortGroups](),
 			podSelectorAddressSets:      syncmap.NewSyncMap[*PodSelectorAddressSet](),
+			netpolPortGroups:            syncmap.NewSyncMap[*netpolPortGroup](),
 			stopChan:                    defaultStopChan,
 			wg:                          defaultWg,
 			localZoneNodes:              &sync.Map{},
@@ -224,6 +234,9 @@ func newDefaultNetworkControllerCommon(cnci *CommonNetworkControllerInfo,
 		zoneChassisHandler:           zoneChassisHandler,
 		apbExternalRouteController:   apbExternalRouteController,
 	}
+	if config.Gateway.SNATConntrackZoneAllocation == config.SNATConntrackZoneAllocationDedicated {
+		oc.snatCTZoneAllocator = ctzone.NewAllocator(config.Gateway.SNATConntrackZoneMin, config.Gateway.SNATConntrackZoneMax)
+	}
 
 	// Allocate IPs for logical router port "GwRouterToJoinSwitchPrefix + OVNClusterRouter". This should always
 	// allocate the first IPs in the join switch subnets.
@@ -271,6 +284,8 @@ func (oc *DefaultNetworkController) newRetryFramework(
 		HasUpdateFunc:          hasResourceAnUpdateFunc(objectType),
 		NeedsUpdateDuringRetry: needsUpdateDuringRetry(objectType),
 		ObjType:                objectType,
+		MaxFailedAttempts:      maxFailedAttempts(objectType),
+		NetworkName:            oc.GetNetworkName(),
 		EventHandler:           eventHandler,
 	}
 	r := retry.NewRetryFramework(
@@ -295,7 +310,7 @@ func (oc *DefaultNetworkController) syncAddressSetsAndAcls() error {
 	if err != nil {
 		return fmt.Errorf("failed to get existing nodes: %w", err)
 	}
-	aclSyncer := aclsyncer.NewACLSyncer(oc.nbClient, oc.controllerName)
+	aclSyncer := aclsyncer.NewACLSyncer(oc.nbClient, oc.controllerName, oc.watchFactory)
 	err = aclSyncer.SyncACLs(existingNodes)
 	if err != nil {
 		return fmt.Errorf("failed to sync acls on controller init: %v", err)
@@ -404,6 +419,9 @@ func (oc *DefaultNetworkController) Init(ctx context.Context) error {
 		klog.Errorf("Failed to setup master (%v)", err)
 		return err
 	}
+	if err := oc.syncGARPMaxTimeout(); err != nil {
+		return err
+	}
 	// Sync external gateway routes. External gateway are set via Admin Policy Based External Route CRs.
 	// So execute an individual sync method at startup to cleanup any difference
 	klog.V(4).Info("Cleaning External Gateway ECMP routes")
@@ -433,6 +451,10 @@ func (oc *DefaultNetworkController) Run(ctx context.Context) error {
 		return err
 	}
 
+	if err := oc.WatchNodeNetworkStates(); err != nil {
+		return err
+	}
+
 	startSvc := time.Now()
 	// Services should be started after nodes to prevent LB churn
 	err := oc.StartServiceController(oc.wg, true)
@@ -557,6 +579,9 @@ func (oc *DefaultNetworkController) Run(ctx context.Context) error {
 		}()
 	}
 
+	oc.runStaleObjectGC(oc.wg)
+	oc.runPodIPMismatchCheck(oc.wg)
+
 	// Master is fully running and resource handlers have synced, update Topology version in OVN and the ConfigMap
 	if err := oc.reportTopologyVersion(ctx); err != nil {
 		klog.Errorf("Failed to report topology version: %v", err)
@@ -625,7 +650,7 @@ func (h *defaultNetworkControllerEventHandler) RecordAddEvent(obj interface{}) {
 	case factory.PodType:
 		pod := obj.(*kapi.Pod)
 		klog.V(5).Infof("Recording add event on pod %s/%s", pod.Namespace, pod.Name)
-		h.oc.podRecorder.AddPod(pod.UID)
+		h.oc.podRecorder.AddPod(pod)
 		metrics.GetConfigDurationRecorder().Start("pod", pod.Namespace, pod.Name)
 	case factory.PolicyType:
 		np := obj.(*knet.NetworkPolicy)
@@ -730,6 +755,9 @@ func (h *defaultNetworkControllerEventHandler) AddResource(obj interface{}, from
 			return fmt.Errorf("could not cast %T object to *kapi.Node", obj)
 		}
 		if h.oc.isLocalZoneNode(node) {
+			if isNodeDeletionPending(node) {
+				return h.oc.cleanupPendingNodeDeletion(node)
+			}
 			var nodeParams *nodeSyncs
 			if fromRetryLoop {
 				_, nodeSync := h.oc.addNodeFailed.Load(node.Name)
@@ -748,11 +776,14 @@ func (h *defaultNetworkControllerEventHandler) AddResource(obj interface{}, from
 					zoneICSync,
 					syncMigratablePods}
 			} else {
-				nodeHostSubnets, _ := util.ParseNodeHostSubnetAnnotation(node, ovntypes.DefaultNetworkName)
+				nodeHostSubnets, _ := h.oc.getNodeHostSubnets(node)
 				syncMigratablePods := nodeHostSubnets != nil
 				nodeParams = &nodeSyncs{true, true, true, true, config.HybridOverlay.Enabled, config.OVNKubernetesFeature.EnableInterconnect, syncMigratablePods}
 			}
 
+			if err = h.oc.ensureNodeFinalizer(node); err != nil {
+				return err
+			}
 			if err = h.oc.addUpdateLocalNodeEvent(node, nodeParams); err != nil {
 				klog.Infof("Node add failed for %s, will try again later: %v",
 					node.Name, err)
@@ -877,16 +908,36 @@ func (h *defaultNetworkControllerEventHandler) UpdateResource(oldObj, newObj int
 		// |--------------------+-------------------+-------------------------------------------------+
 		newNodeIsLocalZoneNode := h.oc.isLocalZoneNode(newNode)
 		zoneClusterChanged := h.oc.nodeZoneClusterChanged(oldNode, newNode, newNodeIsLocalZoneNode)
-		nodeSubnetChanged := nodeSubnetChanged(oldNode, newNode)
+		// host subnets older than the ones already processed are not acted upon
+		nodeSubnetChanged := nodeSubnetChanged(oldNode, newNode, h.oc.GetNetworkName()) && !h.oc.nodeSubnetsGenerationStale(newNode)
 		if newNodeIsLocalZoneNode {
+			if isNodeDeletionPending(newNode) {
+				return h.oc.cleanupPendingNodeDeletion(newNode)
+			}
+			if err := h.oc.ensureNodeFinalizer(newNode); err != nil {
+				return err
+			}
 			var nodeSyncsParam *nodeSyncs
-			if h.oc.isLocalZoneNode(oldNode) {
+			if noHostSubnetChanged(oldNode, newNode) && !util.NoHostSubnet(newNode) {
+				klog.Infof("Node %s is now managed by OVN", newNode.Name)
+				// The node has no topology yet. Trigger a full node sync.
+				nodeSyncsParam = &nodeSyncs{true, true, true, true, config.HybridOverlay.Enabled, config.OVNKubernetesFeature.EnableInterconnect, true}
+			} else if h.oc.isLocalZoneNode(oldNode) {
 				// determine what actually changed in this update
 				_, nodeSync := h.oc.addNodeFailed.Load(newNode.Name)
+				// the node switch is updated with the changed host subnets,
+				// like an additional host subnet
+				if nodeSubnetChanged {
+					_, err := h.oc.getNodeHostSubnets(newNode)
+					nodeSync = nodeSync || err == nil
+				}
 				_, failed := h.oc.nodeClusterRouterPortFailed.Load(newNode.Name)
-				clusterRtrSync := failed || nodeChassisChanged(oldNode, newNode) || nodeSubnetChanged
+				healthCheckSubnetsChanged := util.NodeHealthCheckSubnetsAnnotationChanged(oldNode, newNode)
+				clusterRtrSync := failed || nodeChassisChanged(oldNode, newNode) || nodeSubnetChanged ||
+					healthCheckSubnetsChanged
 				_, failed = h.oc.mgmtPortFailed.Load(newNode.Name)
-				mgmtSync := failed || macAddressChanged(oldNode, newNode) || nodeSubnetChanged
+				mgmtSync := failed || macAddressChanged(oldNode, newNode) || nodeSubnetChanged ||
+					healthCheckSubnetsChanged
 				_, failed = h.oc.gatewaysFailed.Load(newNode.Name)
 				gwSync := (failed || gatewayChanged(oldNode, newNode) ||
 					nodeSubnetChanged || hostAddressesChanged(oldNode, newNode) ||
@@ -911,18 +962,31 @@ func (h *defaultNetworkControllerEventHandler) UpdateResource(oldObj, newObj int
 				nodeSyncsParam = &nodeSyncs{true, true, true, true, true, config.OVNKubernetesFeature.EnableInterconnect, true}
 			}
 
-			return h.oc.addUpdateLocalNodeEvent(newNode, nodeSyncsParam)
+			if err := h.oc.addUpdateLocalNodeEvent(newNode, nodeSyncsParam); err != nil {
+				return err
+			}
+			h.oc.observeNodeSubnetsGeneration(newNode)
+			return nil
 		} else {
+			if noHostSubnetChanged(oldNode, newNode) && util.NoHostSubnet(newNode) {
+				return h.oc.cleanupRemoteNoHostSubnetNode(newNode)
+			}
 			_, syncZoneIC := h.oc.syncZoneICFailed.Load(newNode.Name)
 
 			// Check if the node moved from local zone to remote zone and if so syncZoneIC should be set to true.
-			// Also check if node subnet changed, so static routes are properly set
-			syncZoneIC = syncZoneIC || h.oc.isLocalZoneNode(oldNode) || nodeSubnetChanged || zoneClusterChanged
+			// Also check if node subnet changed, so static routes are properly set, or if the node is now
+			// managed by OVN
+			syncZoneIC = syncZoneIC || h.oc.isLocalZoneNode(oldNode) || nodeSubnetChanged || zoneClusterChanged ||
+				noHostSubnetChanged(oldNode, newNode)
 			if syncZoneIC {
 				klog.Infof("Node %s in remote zone %s needs interconnect zone sync up. Zone cluster changed: %v",
 					newNode.Name, util.GetNodeZone(newNode), zoneClusterChanged)
 			}
-			return h.oc.addUpdateRemoteNodeEvent(newNode, syncZoneIC)
+			if err := h.oc.addUpdateRemoteNodeEvent(newNode, syncZoneIC); err != nil {
+				return err
+			}
+			h.oc.observeNodeSubnetsGeneration(newNode)
+			return nil
 		}
 
 	case factory.EgressIPType:
@@ -1086,7 +1150,7 @@ func (h *defaultNetworkControllerEventHandler) SyncFunc(objs []interface{}) erro
 			syncFunc = nil
 
 		case factory.NamespaceType:
-			syncFunc = h.oc.syncNamespaces
+			syncFunc = h.oc.syncDefaultNetworkNamespaces
 
 		default:
 			return fmt.Errorf("no sync function for object type %s", h.objType)
@@ -0,0 +1,228 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// On the layer2 topology of the default network the pods of all the nodes are
+// attached to a single switch holding the whole cluster subnets, connected to
+// the ovn_cluster_router with the gateway IPs of the cluster subnets. The nodes
+// have no host subnet, node switch or management port: only their gateway
+// router is set up, and the traffic of each pod leaving the cluster is routed
+// to the gateway router of its node by a src-ip static route of the pod IP.
+// The hosts reach the pods through their gateway router as well.
+
+// setupLayer2Switch creates the switch of the layer2 topology and connects it
+// to the ovn_cluster_router.
+func (oc *DefaultNetworkController) setupLayer2Switch() error {
+	var clusterSubnets []*net.IPNet
+	for _, clusterSubnet := range config.Default.ClusterSubnets {
+		clusterSubnets = append(clusterSubnets, clusterSubnet.CIDR)
+	}
+
+	// the switch is set up like a node switch whose host subnets would be the
+	// cluster subnets
+	if err := oc.createNodeLogicalSwitch(types.OVNLayer2Switch, clusterSubnets, oc.clusterLoadBalancerGroupUUID,
+		oc.switchLoadBalancerGroupUUID); err != nil {
+		return fmt.Errorf("failed to create the %s switch: %w", types.OVNLayer2Switch, err)
+	}
+
+	// logical router port MAC is based on IPv4 subnet if there is one, else IPv6
+	var lrpMAC net.HardwareAddr
+	for _, clusterSubnet := range clusterSubnets {
+		lrpMAC = util.IPAddrToHWAddr(util.GetNodeGatewayIfAddr(clusterSubnet).IP)
+		if !utilnet.IsIPv6CIDR(clusterSubnet) {
+			break
+		}
+	}
+	lrpNetworks := []string{}
+	for _, clusterSubnet := range clusterSubnets {
+		lrpNetworks = append(lrpNetworks, util.GetNodeGatewayIfAddr(clusterSubnet).String())
+	}
+	logicalRouterPort := nbdb.LogicalRouterPort{
+		Name:     types.RouterToSwitchPrefix + types.OVNLayer2Switch,
+		MAC:      lrpMAC.String(),
+		Networks: lrpNetworks,
+	}
+	logicalRouter := nbdb.LogicalRouter{Name: types.OVNClusterRouter}
+	err := libovsdbops.CreateOrUpdateLogicalRouterPort(oc.nbClient, &logicalRouter, &logicalRouterPort, nil,
+		&logicalRouterPort.MAC, &logicalRouterPort.Networks)
+	if err != nil {
+		return fmt.Errorf("failed to add logical router port %+v on router %s: %w", logicalRouterPort,
+			types.OVNClusterRouter, err)
+	}
+	return nil
+}
+
+// addUpdateLayer2LocalNodeEvent sets up a local zone node of the layer2
+// topology, whose only logical network is its gateway router.
+func (oc *DefaultNetworkController) addUpdateLayer2LocalNodeEvent(node *kapi.Node, nSyncs *nodeSyncs) error {
+	var errs []error
+
+	klog.Infof("Adding or Updating Node %q of the layer2 topology", node.Name)
+	if nSyncs.syncNode {
+		if err := oc.addLayer2Node(node); err != nil {
+			oc.addNodeFailed.Store(node.Name, true)
+			oc.gatewaysFailed.Store(node.Name, true)
+			return fmt.Errorf("nodeAdd: error adding node %q: %w", node.Name, err)
+		}
+		oc.addNodeFailed.Delete(node.Name)
+	}
+
+	if nSyncs.syncGw {
+		if err := oc.syncNodeGateway(node, nil); err != nil {
+			errs = append(errs, err)
+			oc.gatewaysFailed.Store(node.Name, true)
+		} else if err := oc.ensureLayer2HostSNATs(node); err != nil {
+			errs = append(errs, err)
+			oc.gatewaysFailed.Store(node.Name, true)
+		} else {
+			oc.gatewaysFailed.Delete(node.Name)
+		}
+	}
+
+	// the egress routes of the pods need the gateway router of the node
+	if _, gwFailed := oc.gatewaysFailed.Load(node.Name); !gwFailed {
+		if nSyncs.syncNode || nSyncs.syncGw {
+			errs = append(errs, oc.addAllPodsOnNode(node.Name)...)
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// addLayer2Node is the layer2 topology counterpart of addNode.
+func (oc *DefaultNetworkController) addLayer2Node(node *kapi.Node) error {
+	if err := oc.deleteStaleNodeChassis(node); err != nil {
+		return err
+	}
+
+	// the traffic of the host reaches the pods through the gateway router
+	lrpIPs, err := util.ParseNodeGatewayRouterLRPAddrs(node)
+	if err != nil {
+		return fmt.Errorf("failed to get join switch port IP address for node %s: %v", node.Name, err)
+	}
+	hostNetworkPolicyIPs := make([]net.IP, 0, len(lrpIPs))
+	for _, lrpIP := range lrpIPs {
+		hostNetworkPolicyIPs = append(hostNetworkPolicyIPs, lrpIP.IP)
+	}
+	return oc.addHostNetworkPolicyIPs(hostNetworkPolicyIPs)
+}
+
+// ensureLayer2HostSNATs SNATs the host masquerade IPs to the join IPs of the
+// gateway router of the node. The host has no management port: its traffic to
+// the cluster subnets enters the gateway router from the shared gateway bridge
+// with the host masquerade IP as source, the same on every node, and the
+// replies of the pods must be routed back to this gateway router by the
+// ovn_cluster_router, which has a route to each of its join IPs.
+func (oc *DefaultNetworkController) ensureLayer2HostSNATs(node *kapi.Node) error {
+	l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(node)
+	if err != nil {
+		return err
+	}
+	if l3GatewayConfig.Mode == config.GatewayModeDisabled {
+		return nil
+	}
+	gwLRPIPs, err := util.ParseNodeGatewayRouterLRPAddrs(node)
+	if err != nil {
+		return fmt.Errorf("failed to get join switch port IP address for node %s: %w", node.Name, err)
+	}
+	_, _, masqueradeIPs, err := oc.getNodeMasqueradeConfig(node.Name)
+	if err != nil {
+		return err
+	}
+	nats := make([]*nbdb.NAT, 0, len(gwLRPIPs))
+	for _, gwLRPIP := range gwLRPIPs {
+		hostMasqueradeIP := masqueradeIPs.V4HostMasqueradeIP
+		if utilnet.IsIPv6(gwLRPIP.IP) {
+			hostMasqueradeIP = masqueradeIPs.V6HostMasqueradeIP
+		}
+		logicalIP := &net.IPNet{IP: hostMasqueradeIP, Mask: util.GetIPFullMask(hostMasqueradeIP)}
+		nats = append(nats, libovsdbops.BuildSNAT(&gwLRPIP.IP, logicalIP, "", nil))
+	}
+	logicalRouter := nbdb.LogicalRouter{Name: types.GWRouterPrefix + node.Name}
+	if err := libovsdbops.CreateOrUpdateNATs(oc.nbClient, &logicalRouter, nats...); err != nil {
+		return fmt.Errorf("failed to create the host SNATs on router %s: %w", logicalRouter.Name, err)
+	}
+	return nil
+}
+
+// ensureLayer2PodEgressRoutes routes the traffic of the pod IPs leaving the
+// cluster to the gateway router of the node of the pod, replacing the routes
+// to the gateway router of the node the pod was migrated from, if any.
+func (oc *DefaultNetworkController) ensureLayer2PodEgressRoutes(pod *kapi.Pod, podIPs []*net.IPNet) error {
+	node, err := oc.watchFactory.GetNode(pod.Spec.NodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", pod.Spec.NodeName, err)
+	}
+	gwLRPIPs, err := util.ParseNodeGatewayRouterLRPAddrs(node)
+	if err != nil {
+		return fmt.Errorf("failed to get join switch port IP address for node %s: %w", node.Name, err)
+	}
+	for _, podIP := range podIPs {
+		gwLRPIP, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6CIDR(podIP), gwLRPIPs)
+		if err != nil {
+			return err
+		}
+		lrsr := nbdb.LogicalRouterStaticRoute{
+			Policy:   &nbdb.LogicalRouterStaticRoutePolicySrcIP,
+			IPPrefix: podIP.IP.String(),
+			Nexthop:  gwLRPIP.IP.String(),
+		}
+		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return item.IPPrefix == lrsr.IPPrefix && libovsdbops.PolicyEqualPredicate(lrsr.Policy, item.Policy)
+		}
+		if err := libovsdbops.CreateOrReplaceLogicalRouterStaticRouteWithPredicate(oc.nbClient, types.OVNClusterRouter,
+			&lrsr, p, &lrsr.Nexthop); err != nil {
+			return fmt.Errorf("error creating static route %+v in %s: %w", lrsr, types.OVNClusterRouter, err)
+		}
+	}
+	return nil
+}
+
+// isLayer2PodEgressRoute returns true for the src-ip routes of the pod IPs to
+// the gateway routers
+func isLayer2PodEgressRoute(item *nbdb.LogicalRouterStaticRoute) bool {
+	return item.Policy != nil && *item.Policy == nbdb.LogicalRouterStaticRoutePolicySrcIP &&
+		net.ParseIP(item.IPPrefix) != nil && config.ContainsJoinIP(net.ParseIP(item.Nexthop))
+}
+
+// deleteLayer2PodEgressRoutes removes the egress routes of the pod IPs
+func (oc *DefaultNetworkController) deleteLayer2PodEgressRoutes(podIPs []*net.IPNet) error {
+	ips := sets.New[string]()
+	for _, podIP := range podIPs {
+		ips.Insert(podIP.IP.String())
+	}
+	p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+		return isLayer2PodEgressRoute(item) && ips.Has(item.IPPrefix)
+	}
+	if err := libovsdbops.DeleteLogicalRouterStaticRoutesWithPredicate(oc.nbClient, types.OVNClusterRouter, p); err != nil {
+		return fmt.Errorf("failed to delete the egress routes of %v: %w", sets.List(ips), err)
+	}
+	return nil
+}
+
+// deleteStaleLayer2PodEgressRoutes removes the egress routes of the IPs of the
+// pods deleted while ovnkube-controller was down
+func (oc *DefaultNetworkController) deleteStaleLayer2PodEgressRoutes(podIPs sets.Set[string]) error {
+	p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+		return isLayer2PodEgressRoute(item) && !podIPs.Has(item.IPPrefix)
+	}
+	if err := libovsdbops.DeleteLogicalRouterStaticRoutesWithPredicate(oc.nbClient, types.OVNClusterRouter, p); err != nil {
+		return fmt.Errorf("failed to delete the stale pod egress routes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package ovn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	lsm "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/logical_switch_manager"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/onsi/gomega"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSetupLayer2Switch(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+	t.Cleanup(func() { _ = config.PrepareTestConfig() })
+	var err error
+	config.Default.Topology = types.Layer2Topology
+	config.Default.ClusterSubnets, err = config.ParseClusterSubnetEntries("10.128.0.0/16,fd00:10:128::/48")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	clusterRouter := &nbdb.LogicalRouter{UUID: "cluster-router-UUID", Name: types.OVNClusterRouter}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{clusterRouter},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+	oc := &DefaultNetworkController{
+		BaseNetworkController: BaseNetworkController{
+			CommonNetworkControllerInfo: CommonNetworkControllerInfo{nbClient: nbClient},
+			NetInfo:                     &util.DefaultNetInfo{},
+			lsManager:                   lsm.NewLogicalSwitchManager(),
+		},
+	}
+
+	g.Expect(oc.setupLayer2Switch()).To(gomega.Succeed())
+	sw, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: types.OVNLayer2Switch})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(sw.OtherConfig).To(gomega.HaveKeyWithValue("subnet", "10.128.0.0/16"))
+	g.Expect(sw.OtherConfig).To(gomega.HaveKeyWithValue("ipv6_prefix", "fd00:10:128::"))
+	lrp, err := libovsdbops.GetLogicalRouterPort(nbClient, &nbdb.LogicalRouterPort{Name: types.RouterToSwitchPrefix + types.OVNLayer2Switch})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(lrp.Networks).To(gomega.ConsistOf("10.128.0.1/16", "fd00:10:128::1/48"))
+	g.Expect(lrp.MAC).To(gomega.Equal(util.IPAddrToHWAddr(net.ParseIP("10.128.0.1")).String()))
+	router, err := libovsdbops.GetLogicalRouter(nbClient, &nbdb.LogicalRouter{Name: types.OVNClusterRouter})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(router.Ports).To(gomega.ConsistOf(lrp.UUID))
+
+	// the gateway and management IPs of the cluster subnets are not given
+	// to the pods
+	g.Expect(oc.lsManager.AllocateIPs(types.OVNLayer2Switch, []*net.IPNet{ovntest.MustParseIPNet("10.128.0.1/16")})).NotTo(gomega.Succeed())
+	g.Expect(oc.lsManager.AllocateIPs(types.OVNLayer2Switch, []*net.IPNet{ovntest.MustParseIPNet("10.128.3.4/16")})).To(gomega.Succeed())
+}
+
+func TestDeleteLayer2PodEgressRoutes(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+
+	newRoute := func(uuid, prefix, nexthop string) *nbdb.LogicalRouterStaticRoute {
+		return &nbdb.LogicalRouterStaticRoute{
+			UUID:     uuid,
+			Policy:   &nbdb.LogicalRouterStaticRoutePolicySrcIP,
+			IPPrefix: prefix,
+			Nexthop:  nexthop,
+		}
+	}
+	routes := []*nbdb.LogicalRouterStaticRoute{
+		newRoute("pod1-UUID", "10.128.0.5", "100.64.0.2"),
+		newRoute("pod2-UUID", "10.128.0.6", "100.64.0.3"),
+		newRoute("pod3-UUID", "10.128.0.7", "100.64.0.3"),
+		// not an egress route of a pod
+		newRoute("subnet-UUID", "10.128.0.0/16", "100.64.0.3"),
+	}
+	clusterRouter := &nbdb.LogicalRouter{UUID: "cluster-router-UUID", Name: types.OVNClusterRouter}
+	data := []libovsdbtest.TestData{clusterRouter}
+	for _, route := range routes {
+		clusterRouter.StaticRoutes = append(clusterRouter.StaticRoutes, route.UUID)
+		data = append(data, route)
+	}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{NBData: data}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+	oc := &DefaultNetworkController{
+		BaseNetworkController: BaseNetworkController{
+			CommonNetworkControllerInfo: CommonNetworkControllerInfo{nbClient: nbClient},
+		},
+	}
+	getRoutePrefixes := func() []string {
+		router, err := libovsdbops.GetLogicalRouter(nbClient, &nbdb.LogicalRouter{Name: types.OVNClusterRouter})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		uuids := sets.New(router.StaticRoutes...)
+		found, err := libovsdbops.FindLogicalRouterStaticRoutesWithPredicate(nbClient,
+			func(item *nbdb.LogicalRouterStaticRoute) bool { return uuids.Has(item.UUID) })
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		prefixes := []string{}
+		for _, route := range found {
+			prefixes = append(prefixes, route.IPPrefix)
+		}
+		return prefixes
+	}
+
+	g.Expect(oc.deleteLayer2PodEgressRoutes([]*net.IPNet{ovntest.MustParseIPNet("10.128.0.5/16")})).To(gomega.Succeed())
+	g.Expect(getRoutePrefixes()).To(gomega.ConsistOf("10.128.0.6", "10.128.0.7", "10.128.0.0/16"))
+
+	g.Expect(oc.deleteStaleLayer2PodEgressRoutes(sets.New("10.128.0.7"))).To(gomega.Succeed())
+	g.Expect(getRoutePrefixes()).To(gomega.ConsistOf("10.128.0.7", "10.128.0.0/16"))
+}
+
+func TestEnsureLayer2HostSNATs(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+	t.Cleanup(func() { _ = config.PrepareTestConfig() })
+	config.Default.Topology = types.Layer2Topology
+	config.IPv4Mode = true
+	config.IPv6Mode = true
+
+	node := &kapi.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Annotations: map[string]string{
+				"k8s.ovn.org/l3-gateway-config": `{"default":{"mode":"shared","mac-address":"7e:57:f8:f0:3c:49",` +
+					`"ip-addresses":["192.168.126.12/24","fc00:f853:ccd:e793::3/64"],` +
+					`"next-hops":["192.168.126.1","fc00:f853:ccd:e793::1"]}}`,
+				"k8s.ovn.org/node-chassis-id":                "cb9ec8fa-b409-4ef3-9f42-d9283c47aac6",
+				"k8s.ovn.org/node-gateway-router-lrp-ifaddr": `{"ipv4":"100.64.0.2/16","ipv6":"fd98::2/64"}`,
+			},
+		},
+	}
+	gwRouter := &nbdb.LogicalRouter{UUID: "gw-router-UUID", Name: types.GWRouterPrefix + node.Name}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{gwRouter},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+	wf, err := factory.NewMasterWatchFactory(&util.OVNMasterClientset{KubeClient: fake.NewSimpleClientset(node)})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(wf.Start()).To(gomega.Succeed())
+	t.Cleanup(wf.Shutdown)
+	oc := &DefaultNetworkController{
+		BaseNetworkController: BaseNetworkController{
+			CommonNetworkControllerInfo: CommonNetworkControllerInfo{nbClient: nbClient, watchFactory: wf},
+			NetInfo:                     &util.DefaultNetInfo{},
+		},
+	}
+
+	// the replies of the pods to the host masquerade IPs go back to the join
+	// IPs of the gateway router, twice to check the SNATs are not duplicated
+	for i := 0; i < 2; i++ {
+		g.Expect(oc.ensureLayer2HostSNATs(node)).To(gomega.Succeed())
+		nats, err := libovsdbops.GetRouterNATs(nbClient, &nbdb.LogicalRouter{Name: gwRouter.Name})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		found := map[string]string{}
+		for _, nat := range nats {
+			g.Expect(nat.Type).To(gomega.Equal(nbdb.NATTypeSNAT))
+			found[nat.LogicalIP] = nat.ExternalIP
+		}
+		g.Expect(found).To(gomega.Equal(map[string]string{
+			config.Gateway.MasqueradeIPs.V4HostMasqueradeIP.String(): "100.64.0.2",
+			config.Gateway.MasqueradeIPs.V6HostMasqueradeIP.String(): "fd98::2",
+		}))
+	}
+}
@@ -38,6 +38,9 @@ type egressFirewall struct {
 	name        string
 	namespace   string
 	egressRules []*egressFirewallRule
+	// aclLogging overrides the namespace ACL logging levels for the egress
+	// firewall ACLs, nil if the egress firewall doesn't set its own
+	aclLogging *libovsdbutil.ACLLoggingLevels
 }
 
 type egressFirewallRule struct {
@@ -67,9 +70,42 @@ func cloneEgressFirewall(originalEgressfirewall *egressfirewallapi.EgressFirewal
 		namespace:   originalEgressfirewall.Namespace,
 		egressRules: make([]*egressFirewallRule, 0),
 	}
+	if annotation, ok := originalEgressfirewall.Annotations[util.AclLoggingAnnotation]; ok {
+		var err error
+		ef.aclLogging, err = libovsdbutil.ParseACLLoggingAnnotation(annotation)
+		if err != nil {
+			klog.Warningf("EgressFirewall %s/%s: ignoring malformed ACL logging annotation, "+
+				"using the namespace ACL logging, err: %v", ef.namespace, ef.name, err)
+		}
+	}
 	return ef
 }
 
+// getACLLogging returns the ACL logging levels of the egress firewall ACLs:
+// the namespace levels, overridden by the ones set on the egress firewall.
+func (ef *egressFirewall) getACLLogging(nsACLLogging *libovsdbutil.ACLLoggingLevels) *libovsdbutil.ACLLoggingLevels {
+	aclLogging := *nsACLLogging
+	if ef.aclLogging == nil {
+		return &aclLogging
+	}
+	if ef.aclLogging.Allow != "" {
+		aclLogging.Allow = ef.aclLogging.Allow
+	}
+	if ef.aclLogging.Deny != "" {
+		aclLogging.Deny = ef.aclLogging.Deny
+	}
+	if ef.aclLogging.RateLimit != nil {
+		aclLogging.RateLimit = ef.aclLogging.RateLimit
+	}
+	return &aclLogging
+}
+
+// getEgressFirewallACLLoggingMeterRefKey returns the key with which the egress
+// firewall of the namespace references the ACL logging meter of its ACLs
+func (oc *DefaultNetworkController) getEgressFirewallACLLoggingMeterRefKey(namespace string) string {
+	return oc.getACLLoggingMeterRefKey(string(libovsdbops.EgressFirewallOwnerType), namespace)
+}
+
 // newEgressFirewallRule creates a new egressFirewallRule. For the logging level, it will pick either of
 // aclLoggingAllow or aclLoggingDeny depending if this is an allow or deny rule.
 func (oc *DefaultNetworkController) newEgressFirewallRule(rawEgressFirewallRule egressfirewallapi.EgressFirewallRule, id int) (*egressFirewallRule, error) {
@@ -241,10 +277,14 @@ func (oc *DefaultNetworkController) addEgressFirewall(egressFirewall *egressfire
 		return fmt.Errorf("cannot ensure addressSet for namespace %s: %v", egressFirewall.Namespace, err)
 	}
 	ipv4HashedAS, ipv6HashedAS := as.GetASHashNames()
-	aclLoggingLevels := oc.GetNamespaceACLLogging(ef.namespace)
+	aclLoggingLevels := ef.getACLLogging(oc.GetNamespaceACLLogging(ef.namespace))
 	// store egress firewall before calling addEgressFirewallRules, since it doesn't have a cleanup, and oc.egressFirewalls
 	// object will be used on retry to cleanup
 	oc.egressFirewalls.Store(egressFirewall.Namespace, ef)
+	meterRefKey := oc.getEgressFirewallACLLoggingMeterRefKey(ef.namespace)
+	if err := libovsdbutil.AddACLLoggingMeterRef(oc.nbClient, meterRefKey, aclLoggingLevels); err != nil {
+		return err
+	}
 	if err := oc.addEgressFirewallRules(ef, ipv4HashedAS, ipv6HashedAS, aclLoggingLevels); err != nil {
 		return err
 	}
@@ -278,6 +318,10 @@ func (oc *DefaultNetworkController) deleteEgressFirewall(egressFirewallObj *egre
 	if err := oc.deleteEgressFirewallRules(egressFirewallObj.Namespace); err != nil {
 		return err
 	}
+	meterRefKey := oc.getEgressFirewallACLLoggingMeterRefKey(egressFirewallObj.Namespace)
+	if err := libovsdbutil.ReleaseACLLoggingMeterRefs(oc.nbClient, meterRefKey, nil); err != nil {
+		return err
+	}
 	if deleteDNS {
 		if err := oc.egressFirewallDNS.Delete(egressFirewallObj.Namespace); err != nil {
 			return err
@@ -677,9 +721,17 @@ func (oc *DefaultNetworkController) updateACLLoggingForEgressFirewall(egressFire
 			libovsdbops.ObjectNameKey: ef.namespace,
 		})
 	p := libovsdbops.GetPredicate[*nbdb.ACL](predicateIDs, nil)
-	if err := libovsdbutil.UpdateACLLoggingWithPredicate(oc.nbClient, p, &nsInfo.aclLogging); err != nil {
+	aclLogging := ef.getACLLogging(&nsInfo.aclLogging)
+	meterRefKey := oc.getEgressFirewallACLLoggingMeterRefKey(ef.namespace)
+	if err := libovsdbutil.AddACLLoggingMeterRef(oc.nbClient, meterRefKey, aclLogging); err != nil {
+		return false, fmt.Errorf("unable to create ACL logging meter in ns %s, err: %v", ef.namespace, err)
+	}
+	if err := libovsdbutil.UpdateACLLoggingWithPredicate(oc.nbClient, p, aclLogging); err != nil {
 		return false, fmt.Errorf("unable to update ACL logging in ns %s, err: %v", ef.namespace, err)
 	}
+	if err := libovsdbutil.ReleaseACLLoggingMeterRefs(oc.nbClient, meterRefKey, aclLogging); err != nil {
+		return false, fmt.Errorf("unable to release stale ACL logging meters in ns %s, err: %v", ef.namespace, err)
+	}
 	return true, nil
 }
 
@@ -755,7 +807,7 @@ func (oc *DefaultNetworkController) updateEgressFirewallForNode(oldNode, newNode
 			return false
 		}
 		ipv4HashedAS, ipv6HashedAS := as.GetASHashNames()
-		aclLoggingLevels := oc.GetNamespaceACLLogging(ef.namespace)
+		aclLoggingLevels := ef.getACLLogging(oc.GetNamespaceACLLogging(ef.namespace))
 		if err := oc.addEgressFirewallRules(ef, ipv4HashedAS, ipv6HashedAS,
 			aclLoggingLevels, modifiedRuleIDs...); err != nil {
 			efErr = fmt.Errorf("failed to add egress firewall for namespace: %s, error: %w", namespace, err)
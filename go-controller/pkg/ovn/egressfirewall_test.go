@@ -911,6 +911,61 @@ var _ = ginkgo.Describe("OVN EgressFirewall Operations", func() {
 				err := app.Run([]string{app.Name})
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
 			})
+			ginkgo.It(fmt.Sprintf("overrides the namespace ACL logging with the egressfirewall's annotation, gateway mode %s", gwMode), func() {
+				config.Gateway.Mode = gwMode
+				app.Action = func(ctx *cli.Context) error {
+					namespace1 := *newNamespace("namespace1")
+					namespace1.Annotations[util.AclLoggingAnnotation] = `{ "deny": "alert", "allow": "alert" }`
+					egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+						{
+							Type: "Allow",
+							To: egressfirewallapi.EgressFirewallDestination{
+								CIDRSelector: "1.2.3.4/23",
+							},
+						},
+					})
+					egressFirewall.Annotations = map[string]string{
+						util.AclLoggingAnnotation: `{ "allow": "info", "rateLimit": 15 }`,
+					}
+
+					startOvn(dbSetup, []v1.Namespace{namespace1}, []egressfirewallapi.EgressFirewall{*egressFirewall})
+
+					asHash, _ := getNsAddrSetHashNames(namespace1.Name)
+					dbIDs := fakeOVN.controller.getEgressFirewallACLDbIDs(egressFirewall.Namespace, 0)
+					ipv4ACL := libovsdbops.BuildACL(
+						libovsdbutil.GetACLName(dbIDs),
+						nbdb.ACLDirectionToLport,
+						t.EgressFirewallStartPriority,
+						"(ip4.dst == 1.2.3.4/23) && ip4.src == $"+asHash,
+						nbdb.ACLActionAllow,
+						t.OvnACLLoggingMeter+"-15",
+						nbdb.ACLSeverityInfo,
+						true,
+						dbIDs.GetExternalIDs(),
+						nil,
+						t.DefaultACLTier,
+					)
+					ipv4ACL.UUID = "ipv4ACL-UUID"
+					fair := true
+					band := &nbdb.MeterBand{UUID: "band-UUID", Action: t.MeterAction, Rate: 15}
+					meter := &nbdb.Meter{
+						UUID:  "meter-UUID",
+						Name:  t.OvnACLLoggingMeter + "-15",
+						Fair:  &fair,
+						Unit:  t.PacketsPerSecond,
+						Bands: []string{band.UUID},
+					}
+
+					clusterPortGroup.ACLs = []string{ipv4ACL.UUID}
+					expectedDatabaseState := append(initialData, ipv4ACL, band, meter)
+					gomega.Eventually(fakeOVN.nbClient).Should(libovsdbtest.HaveData(expectedDatabaseState))
+
+					return nil
+				}
+
+				err := app.Run([]string{app.Name})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			})
 			for _, ipMode := range []string{"IPv4", "IPv6"} {
 				ginkgo.It(fmt.Sprintf("configures egress firewall correctly with node selector, gateway mode: %s, IP mode: %s", gwMode, ipMode), func() {
 					nodeIP := "10.10.10.1"
@@ -1180,6 +1235,52 @@ var _ = ginkgo.Describe("OVN EgressFirewall Operations", func() {
 				err := app.Run([]string{app.Name})
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
 			})
+			ginkgo.It(fmt.Sprintf("parks an object with an invalid rule after the egress firewall error budget, gateway mode %s", gwMode), func() {
+				config.Gateway.Mode = gwMode
+				app.Action = func(ctx *cli.Context) error {
+					namespace1 := *newNamespace("namespace1")
+					egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+						{
+							Type: "Deny",
+							To: egressfirewallapi.EgressFirewallDestination{
+								// wrong CIDR format, creation will fail
+								CIDRSelector: "1.2.3.4",
+							},
+						},
+					})
+					startOvn(dbSetup, []v1.Namespace{namespace1}, nil)
+					budget := fakeOVN.controller.retryEgressFirewalls.ResourceHandler.MaxFailedAttempts
+					gomega.Expect(budget).To(gomega.BeNumerically("<", retry.MaxFailedAttempts))
+
+					_, err := fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(egressFirewall.Namespace).
+						Create(context.TODO(), egressFirewall, metav1.CreateOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					efKey, err := retry.GetResourceKey(egressFirewall)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					retry.CheckRetryObjectEventually(efKey, true, fakeOVN.controller.retryEgressFirewalls)
+
+					// once the budget of egress firewalls is exhausted, well before
+					// the default budget, the object is parked
+					retry.SetFailedAttemptsCounterForTestingOnly(efKey, budget-1, fakeOVN.controller.retryEgressFirewalls)
+					retry.SetRetryObjWithNoBackoff(efKey, fakeOVN.controller.retryEgressFirewalls)
+					fakeOVN.controller.retryEgressFirewalls.RequestRetryObjs()
+					retry.CheckRetryObjectMultipleFieldsEventually(
+						efKey,
+						fakeOVN.controller.retryEgressFirewalls,
+						nil,                                // skip oldObj
+						nil,                                // skip newObj
+						nil,                                // skip config
+						gomega.BeNumerically("==", budget), // failedAttempts should reach the budget
+					)
+					retry.SetRetryObjWithNoBackoff(efKey, fakeOVN.controller.retryEgressFirewalls)
+					fakeOVN.controller.retryEgressFirewalls.RequestRetryObjs()
+					retry.CheckRetryObjectParkedEventually(efKey, true, fakeOVN.controller.retryEgressFirewalls)
+
+					return nil
+				}
+				err := app.Run([]string{app.Name})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			})
 			ginkgo.It(fmt.Sprintf("correctly cleans up object that failed to be created, gateway mode %s", gwMode), func() {
 				config.Gateway.Mode = gwMode
 				app.Action = func(ctx *cli.Context) error {
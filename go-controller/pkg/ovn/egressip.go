@@ -70,7 +70,7 @@ func getEgressIPAddrSetDbIDs(name egressIpAddrSetName, controller string) *libov
 //
 // NOTE: `Spec.EgressIPs“ updates for EIP object are not processed here, that is the job of cluster manager
 //
-//	We only care about `Spec.NamespaceSelector`, `Spec.PodSelector` and `Status` field
+//	We only care about `Spec.NamespaceSelector`, `Spec.PodSelector`, `Spec.DSCP`, `Spec.SourcePortRange` and `Status` field
 func (oc *DefaultNetworkController) reconcileEgressIP(old, new *egressipv1.EgressIP) (err error) {
 	// CASE 1: EIP object deletion, we need to teardown database configuration for all the statuses
 	if old != nil && new == nil {
@@ -266,8 +266,24 @@ func (oc *DefaultNetworkController) reconcileEgressIP(old, new *egressipv1.Egres
 				}
 			}
 		}
+		// CASE 3.5: The topology affinity of the placement changed: the pods
+		// may be served by other egress nodes, redo the setup of all of them.
+		if isEgressIPTopologyAffinityChanged(oldEIP, newEIP) && len(newEIP.Status.Items) > 0 {
+			if err := oc.deleteEgressIPAssignments(newEIP.Name, newEIP.Status.Items); err != nil {
+				return err
+			}
+			if err := oc.addEgressIPAssignments(newEIP.Name, newEIP.Status.Items, newEIP.Spec.NamespaceSelector, newEIP.Spec.PodSelector); err != nil {
+				return err
+			}
+		}
 	}
-	return nil
+	if err := oc.reconcileEgressIPDSCP(old, new); err != nil {
+		return err
+	}
+	if err := oc.reconcileEgressIPSourcePortRange(new); err != nil {
+		return err
+	}
+	return oc.reconcileEgressIPStandbys(old, new)
 }
 
 // reconcileEgressIPNamespace reconciles the database configuration setup in nbdb
@@ -314,6 +330,12 @@ func (oc *DefaultNetworkController) reconcileEgressIPNamespace(old, new *v1.Name
 				return err
 			}
 		}
+		if config.OVNKubernetesFeature.EnableEgressIPStandby && len(egressIP.Status.Standbys) > 0 &&
+			namespaceSelector.Matches(oldLabels) != namespaceSelector.Matches(newLabels) {
+			if err := oc.reconcileEgressIPStandbys(nil, egressIP); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -425,7 +447,7 @@ func (oc *DefaultNetworkController) reconcileEgressIPPod(old, new *v1.Pod) (err
 			}
 		}
 	}
-	return nil
+	return oc.reconcileEgressIPStandbysOfPod(egressIPs, namespaceLabels, oldPodLabels, newPodLabels)
 }
 
 // main reconcile functions end here and local zone controller functions begin
@@ -483,6 +505,9 @@ func (oc *DefaultNetworkController) addPodEgressIPAssignments(name string, statu
 		klog.Infof("Pod %s is already in completed state, skipping egress ip assignment", podKey)
 		return nil
 	}
+	// The pods of an EgressIP with a topology affinity are only served by the
+	// egress nodes of their topology domain.
+	statusAssignments = oc.filterEgressIPStatusesByPodTopology(name, statusAssignments, pod)
 	// If statusAssignments is empty just return, not doing this will delete the
 	// external GW set up, even though there might be no egress IP set up to
 	// perform.
@@ -823,6 +848,12 @@ func (oc *DefaultNetworkController) syncEgressIPs(namespaces []interface{}) erro
 	if err = oc.syncStaleSNATRules(egressIPCache); err != nil {
 		return fmt.Errorf("syncEgressIPs unable to remove stale nats: %v", err)
 	}
+	if err = oc.syncStaleEgressIPDSCPRules(egressIPCache); err != nil {
+		return fmt.Errorf("syncEgressIPs unable to remove stale QoS rules: %v", err)
+	}
+	if err = oc.syncStaleEgressIPStandbys(egressIPCache); err != nil {
+		return fmt.Errorf("syncEgressIPs unable to remove stale standby rows: %v", err)
+	}
 	if err = oc.syncPodAssignmentCache(egressIPCache); err != nil {
 		return fmt.Errorf("syncEgressIPs unable to sync internal pod assignment cache: %v", err)
 	}
@@ -1491,7 +1522,7 @@ func (e *egressIPZoneController) addPodEgressIPAssignment(egressIPName string, s
 	var ops []ovsdb.Operation
 	if loadedEgressNode && isLocalZoneEgressNode {
 		if isOVNManagedNetwork {
-			ops, err = createNATRuleOps(e.nbClient, nil, podIPs, status, egressIPName)
+			ops, err = createNATRuleOps(e.nbClient, nil, podIPs, status, egressIPName, e.getSourcePortRange(egressIPName))
 			if err != nil {
 				return fmt.Errorf("unable to create NAT rule ops for status: %v, err: %v", status, err)
 			}
@@ -1861,8 +1892,14 @@ func (e *egressIPZoneController) createStaticRouteOps(ops []ovsdb.Operation, pod
 			},
 			Policy: &nbdb.LogicalRouterStaticRoutePolicySrcIP,
 		}
+		// the static route pre-programmed while the egress node was the
+		// standby node of the egress IP is flipped in place
 		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
-			return item.IPPrefix == lrsr.IPPrefix && item.Nexthop == lrsr.Nexthop && item.ExternalIDs["name"] == lrsr.ExternalIDs["name"] && item.Policy == lrsr.Policy
+			if item.IPPrefix != lrsr.IPPrefix || item.Nexthop != lrsr.Nexthop {
+				return false
+			}
+			return (item.ExternalIDs["name"] == lrsr.ExternalIDs["name"] && item.Policy == lrsr.Policy) ||
+				(isEgressIPStandbyRow(item.ExternalIDs, egressIPName, status.EgressIP) && libovsdbops.PolicyEqualPredicate(item.Policy, lrsr.Policy))
 		}
 
 		ops, err = libovsdbops.CreateOrUpdateLogicalRouterStaticRoutesWithPredicateOps(e.nbClient, ops, types.OVNClusterRouter, &lrsr, p)
@@ -2170,7 +2207,7 @@ func DeleteLegacyDefaultNoRerouteNodePolicies(nbClient libovsdbclient.Client, no
 	return libovsdbops.DeleteLogicalRouterPoliciesWithPredicate(nbClient, types.OVNClusterRouter, p)
 }
 
-func buildSNATFromEgressIPStatus(podIP net.IP, status egressipv1.EgressIPStatusItem, egressIPName string) (*nbdb.NAT, error) {
+func buildSNATFromEgressIPStatus(podIP net.IP, status egressipv1.EgressIPStatusItem, egressIPName, portRange string) (*nbdb.NAT, error) {
 	logicalIP := &net.IPNet{
 		IP:   podIP,
 		Mask: util.GetIPFullMask(podIP),
@@ -2179,16 +2216,17 @@ func buildSNATFromEgressIPStatus(podIP net.IP, status egressipv1.EgressIPStatusI
 	logicalPort := types.K8sPrefix + status.Node
 	externalIds := map[string]string{"name": egressIPName}
 	nat := libovsdbops.BuildSNAT(&externalIP, logicalIP, logicalPort, externalIds)
+	nat.ExternalPortRange = portRange
 	return nat, nil
 }
 
-func createNATRuleOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, podIPs []*net.IPNet, status egressipv1.EgressIPStatusItem, egressIPName string) ([]ovsdb.Operation, error) {
+func createNATRuleOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, podIPs []*net.IPNet, status egressipv1.EgressIPStatusItem, egressIPName, portRange string) ([]ovsdb.Operation, error) {
 	nats := make([]*nbdb.NAT, 0, len(podIPs))
 	var nat *nbdb.NAT
 	var err error
 	for _, podIP := range podIPs {
 		if (utilnet.IsIPv6String(status.EgressIP) && utilnet.IsIPv6(podIP.IP)) || (!utilnet.IsIPv6String(status.EgressIP) && !utilnet.IsIPv6(podIP.IP)) {
-			nat, err = buildSNATFromEgressIPStatus(podIP.IP, status, egressIPName)
+			nat, err = buildSNATFromEgressIPStatus(podIP.IP, status, egressIPName, portRange)
 			if err != nil {
 				return nil, err
 			}
@@ -2198,6 +2236,11 @@ func createNATRuleOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, pod
 	router := &nbdb.LogicalRouter{
 		Name: util.GetGatewayRouterFromNode(status.Node),
 	}
+	if config.OVNKubernetesFeature.EnableEgressIPStandby {
+		if err = flipEgressIPStandbySNATs(nbClient, router, egressIPName, status.EgressIP, nats); err != nil {
+			return nil, fmt.Errorf("unable to get the standby snat rules of router %s: %v", router.Name, err)
+		}
+	}
 	ops, err = libovsdbops.CreateOrUpdateNATsOps(nbClient, ops, router, nats...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create snat rules, for router: %s, error: %v", router.Name, err)
@@ -2211,7 +2254,7 @@ func deleteNATRuleOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, pod
 	var err error
 	for _, podIP := range podIPs {
 		if (utilnet.IsIPv6String(status.EgressIP) && utilnet.IsIPv6(podIP.IP)) || (!utilnet.IsIPv6String(status.EgressIP) && !utilnet.IsIPv6(podIP.IP)) {
-			nat, err = buildSNATFromEgressIPStatus(podIP.IP, status, egressIPName)
+			nat, err = buildSNATFromEgressIPStatus(podIP.IP, status, egressIPName, "")
 			if err != nil {
 				return nil, err
 			}
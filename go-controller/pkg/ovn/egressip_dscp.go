@@ -0,0 +1,184 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// The egress traffic SNATed to the egress IPs of an EgressIP with a DSCP
+// value is marked by QoS rules of the external switches of the egress nodes,
+// matching the packets leaving their gateway routers from the egress IPs.
+// Logical routers don't have QoS rules in OVN.
+
+const (
+	// egressIPDSCPPriority is the priority of the QoS rules marking the egress
+	// traffic of the EgressIPs. The external switches have no other QoS rule.
+	egressIPDSCPPriority = 100
+	// egressIPDSCPExternalID is the external ID of the QoS rules of an
+	// EgressIP holding its name
+	egressIPDSCPExternalID = "EgressIP"
+)
+
+// buildEgressIPDSCPQoS returns the QoS rule marking the traffic SNATed to the
+// egress IP on the node with the DSCP value
+func buildEgressIPDSCPQoS(egressIPName, egressIP, node string, dscp int) *nbdb.QoS {
+	ipFamily := "ip4"
+	if utilnet.IsIPv6String(egressIP) {
+		ipFamily = "ip6"
+	}
+	return &nbdb.QoS{
+		Direction: nbdb.QoSDirectionFromLport,
+		// the egress IP comes first for the match of an egress IP not to be
+		// part of the match of another
+		Match: fmt.Sprintf("%s.src == %s && inport == \"%s%s%s\"", ipFamily, egressIP,
+			types.EXTSwitchToGWRouterPrefix, types.GWRouterPrefix, node),
+		Priority: egressIPDSCPPriority,
+		Action:   map[string]int{nbdb.QoSActionDSCP: dscp},
+		ExternalIDs: map[string]string{
+			egressIPDSCPExternalID: egressIPName,
+			"node":                 node,
+		},
+	}
+}
+
+// getEgressIPDSCPQoSes returns the QoS rules marking the traffic of the egress
+// IPs of the EgressIP assigned to the nodes of the zone, by node
+func (oc *DefaultNetworkController) getEgressIPDSCPQoSes(eIP *egressipv1.EgressIP) (map[string][]*nbdb.QoS, error) {
+	qoses := map[string][]*nbdb.QoS{}
+	if eIP == nil || eIP.Spec.DSCP == nil {
+		return qoses, nil
+	}
+	for _, status := range eIP.Status.Items {
+		if isLocalZoneEgressNode, loaded := oc.eIPC.nodeZoneState.Load(status.Node); !loaded || !isLocalZoneEgressNode {
+			continue
+		}
+		node, err := oc.watchFactory.GetNode(status.Node)
+		if err != nil {
+			// the egress IP is moved away from the deleted node
+			klog.V(5).Infof("Unable to get the egress node %s of EgressIP %s: %v", status.Node, eIP.Name, err)
+			continue
+		}
+		isOVNManagedNetwork, err := util.IsOVNManagedNetwork(node, net.ParseIP(status.EgressIP))
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine if egress IP %s of EgressIP %s is OVN managed: %v",
+				status.EgressIP, eIP.Name, err)
+		}
+		if !isOVNManagedNetwork {
+			continue
+		}
+		qoses[status.Node] = append(qoses[status.Node],
+			buildEgressIPDSCPQoS(eIP.Name, status.EgressIP, status.Node, int(*eIP.Spec.DSCP)))
+	}
+	return qoses, nil
+}
+
+// reconcileEgressIPDSCP marks the traffic SNATed to the egress IPs of the
+// EgressIP on the egress nodes of the zone with its DSCP value, and removes
+// the QoS rules of the egress IPs no longer assigned to them.
+func (oc *DefaultNetworkController) reconcileEgressIPDSCP(old, new *egressipv1.EgressIP) error {
+	name := ""
+	if old != nil {
+		name = old.Name
+	}
+	if new != nil {
+		name = new.Name
+	}
+	qoses, err := oc.getEgressIPDSCPQoSes(new)
+	if err != nil {
+		return err
+	}
+	existing, err := libovsdbops.FindQoSesWithPredicate(oc.nbClient, func(item *nbdb.QoS) bool {
+		return item.ExternalIDs[egressIPDSCPExternalID] == name
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the QoS rules of EgressIP %s: %v", name, err)
+	}
+	if len(existing) == 0 && len(qoses) == 0 {
+		return nil
+	}
+
+	desired := sets.New[string]()
+	var ops []ovsdb.Operation
+	for node, nodeQoSes := range qoses {
+		for _, qos := range nodeQoSes {
+			desired.Insert(qos.Match)
+		}
+		ops, err = libovsdbops.CreateOrUpdateQoSesOps(oc.nbClient, ops, nodeQoSes...)
+		if err != nil {
+			return fmt.Errorf("unable to create the QoS rules of EgressIP %s on node %s: %v", name, node, err)
+		}
+		ops, err = libovsdbops.AddQoSesToLogicalSwitchOps(oc.nbClient, ops, types.ExternalSwitchPrefix+node, nodeQoSes...)
+		if err != nil {
+			return fmt.Errorf("unable to add the QoS rules of EgressIP %s to the external switch of node %s: %v", name, node, err)
+		}
+	}
+	stale := []*nbdb.QoS{}
+	for _, qos := range existing {
+		if !desired.Has(qos.Match) {
+			stale = append(stale, qos)
+		}
+	}
+	if ops, err = deleteEgressIPDSCPQoSesOps(oc.nbClient, ops, stale); err != nil {
+		return fmt.Errorf("unable to delete the stale QoS rules of EgressIP %s: %v", name, err)
+	}
+	_, err = libovsdbops.TransactAndCheck(oc.nbClient, ops)
+	return err
+}
+
+// deleteEgressIPDSCPQoSesOps returns the ops to remove the QoS rules from the
+// switches referencing them and delete them
+func deleteEgressIPDSCPQoSesOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, qoses []*nbdb.QoS) ([]ovsdb.Operation, error) {
+	if len(qoses) == 0 {
+		return ops, nil
+	}
+	uuids := sets.New[string]()
+	for _, qos := range qoses {
+		uuids.Insert(qos.UUID)
+	}
+	switches, err := libovsdbops.FindLogicalSwitchesWithPredicate(nbClient, func(item *nbdb.LogicalSwitch) bool {
+		return uuids.HasAny(item.QOSRules...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, sw := range switches {
+		if ops, err = libovsdbops.RemoveQoSesFromLogicalSwitchOps(nbClient, ops, sw.Name, qoses...); err != nil {
+			return nil, err
+		}
+	}
+	return libovsdbops.DeleteQoSesOps(nbClient, ops, qoses...)
+}
+
+// syncStaleEgressIPDSCPRules deletes the QoS rules of the EgressIPs deleted
+// while ovnkube-controller was down
+func (oc *DefaultNetworkController) syncStaleEgressIPDSCPRules(egressIPCache map[string]egressIPCacheEntry) error {
+	stale, err := libovsdbops.FindQoSesWithPredicate(oc.nbClient, func(item *nbdb.QoS) bool {
+		name, ok := item.ExternalIDs[egressIPDSCPExternalID]
+		if !ok {
+			return false
+		}
+		_, exists := egressIPCache[name]
+		return !exists
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the stale EgressIP QoS rules: %v", err)
+	}
+	ops, err := deleteEgressIPDSCPQoSesOps(oc.nbClient, nil, stale)
+	if err != nil {
+		return fmt.Errorf("unable to delete the stale EgressIP QoS rules: %v", err)
+	}
+	_, err = libovsdbops.TransactAndCheck(oc.nbClient, ops)
+	return err
+}
@@ -0,0 +1,73 @@
+package ovn
+
+import (
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+)
+
+// The egress traffic of the pods of an EgressIP with a source port range is
+// SNATed to the egress IPs and to a source port of the range by the gateway
+// routers of the egress nodes, set as the external port range of the SNATs of
+// the pods.
+
+// getEgressIPSourcePortRange returns the external port range of the SNATs of
+// the EgressIP, empty if it has no source port range
+func getEgressIPSourcePortRange(eIP *egressipv1.EgressIP) string {
+	if eIP == nil || eIP.Spec.SourcePortRange == nil {
+		return ""
+	}
+	portRange := eIP.Spec.SourcePortRange
+	if portRange.Start == portRange.End {
+		return fmt.Sprintf("%d", portRange.Start)
+	}
+	return fmt.Sprintf("%d-%d", portRange.Start, portRange.End)
+}
+
+// getSourcePortRange returns the external port range of the SNATs of the
+// EgressIP with the name, empty if it has no source port range or is gone
+func (e *egressIPZoneController) getSourcePortRange(egressIPName string) string {
+	eIP, err := e.watchFactory.GetEgressIP(egressIPName)
+	if err != nil {
+		klog.V(5).Infof("Unable to get EgressIP %s for its source port range: %v", egressIPName, err)
+		return ""
+	}
+	return getEgressIPSourcePortRange(eIP)
+}
+
+// reconcileEgressIPSourcePortRange updates the external port range of the
+// SNATs of the pods of the EgressIP which don't have its source port range,
+// the SNATs being only created with the source port range of the EgressIP
+// at the time
+func (oc *DefaultNetworkController) reconcileEgressIPSourcePortRange(eIP *egressipv1.EgressIP) error {
+	if eIP == nil {
+		// the SNATs are deleted along with the pod assignments
+		return nil
+	}
+	// the pod assignments create the SNATs
+	oc.eIPC.podAssignmentMutex.Lock()
+	defer oc.eIPC.podAssignmentMutex.Unlock()
+	portRange := getEgressIPSourcePortRange(eIP)
+	nats, err := libovsdbops.FindNATsWithPredicate(oc.nbClient, func(item *nbdb.NAT) bool {
+		return item.Type == nbdb.NATTypeSNAT && item.ExternalIDs["name"] == eIP.Name && item.ExternalPortRange != portRange
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the SNATs of EgressIP %s: %v", eIP.Name, err)
+	}
+	if len(nats) == 0 {
+		return nil
+	}
+	for _, nat := range nats {
+		nat.ExternalPortRange = portRange
+	}
+	ops, err := libovsdbops.UpdateNATsExternalPortRangeOps(oc.nbClient, nil, nats...)
+	if err != nil {
+		return fmt.Errorf("unable to update the source port range of the SNATs of EgressIP %s: %v", eIP.Name, err)
+	}
+	_, err = libovsdbops.TransactAndCheck(oc.nbClient, ops)
+	return err
+}
@@ -0,0 +1,378 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// The standby node of an egress IP hosted on the OVN managed network, set in
+// the status of its EgressIP by ovnkube-cluster-manager, gets the SNATs of the
+// pods of the EgressIP pre-programmed on its gateway router, disabled: they
+// SNAT to the IP of the gateway router, like the SNAT of the cluster subnets,
+// instead of the egress IP, so that the gateway router neither advertises nor
+// answers for the egress IP. With interconnect, the static routes of the pods
+// of the other zones to the gateway router are pre-programmed too. When the
+// egress IP fails over to the standby node, the pre-programmed rows are
+// flipped in place to the egress IP instead of being created.
+
+const (
+	// egressIPStandbyExternalID is the external ID of the rows pre-programmed
+	// for a standby node holding the name of their EgressIP
+	egressIPStandbyExternalID = "EgressIPStandby"
+	// egressIPStandbyIPExternalID is the external ID of the rows
+	// pre-programmed for a standby node holding their egress IP
+	egressIPStandbyIPExternalID = "egress-ip"
+)
+
+// isEgressIPStandbyRow returns whether the external IDs are the ones of a row
+// pre-programmed for the standby node of the egress IP of the EgressIP
+func isEgressIPStandbyRow(externalIDs map[string]string, egressIPName, egressIP string) bool {
+	return externalIDs[egressIPStandbyExternalID] == egressIPName && externalIDs[egressIPStandbyIPExternalID] == egressIP
+}
+
+// getEgressIPStandbyPods returns the pods selected by the EgressIP which have
+// IPs
+func (oc *DefaultNetworkController) getEgressIPStandbyPods(eIP *egressipv1.EgressIP) ([]*kapi.Pod, error) {
+	namespaces, err := oc.watchFactory.GetNamespacesBySelector(eIP.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(&eIP.Spec.PodSelector)
+	if err != nil {
+		return nil, err
+	}
+	var pods []*kapi.Pod
+	for _, namespace := range namespaces {
+		namespacePods, err := oc.watchFactory.GetPods(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range namespacePods {
+			if !podSelector.Matches(labels.Set(pod.Labels)) || util.PodCompleted(pod) || util.PodWantsHostNetwork(pod) ||
+				pod.Spec.NodeName == "" {
+				continue
+			}
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// getEgressIPStandbyRows returns the SNATs, by gateway router, and the static
+// routes to pre-program for the standby nodes of the zone of the egress IPs
+// of the EgressIP
+func (oc *DefaultNetworkController) getEgressIPStandbyRows(eIP *egressipv1.EgressIP) (map[string][]*nbdb.NAT, []*nbdb.LogicalRouterStaticRoute, error) {
+	nats := map[string][]*nbdb.NAT{}
+	var routes []*nbdb.LogicalRouterStaticRoute
+	if eIP == nil || !config.OVNKubernetesFeature.EnableEgressIPStandby {
+		return nats, routes, nil
+	}
+	activeNodes := map[string]string{}
+	for _, status := range eIP.Status.Items {
+		activeNodes[status.EgressIP] = status.Node
+	}
+	var pods []*kapi.Pod
+	for _, standby := range eIP.Status.Standbys {
+		activeNode, assigned := activeNodes[standby.EgressIP]
+		if !assigned || activeNode == standby.Node {
+			continue
+		}
+		if isLocalZoneStandbyNode, loaded := oc.eIPC.nodeZoneState.Load(standby.Node); !loaded || !isLocalZoneStandbyNode {
+			continue
+		}
+		node, err := oc.watchFactory.GetNode(standby.Node)
+		if err != nil {
+			klog.V(5).Infof("Unable to get the standby node %s of EgressIP %s: %v", standby.Node, eIP.Name, err)
+			continue
+		}
+		egressIP := net.ParseIP(standby.EgressIP)
+		isOVNManagedNetwork, err := util.IsOVNManagedNetwork(node, egressIP)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to determine if egress IP %s of EgressIP %s is OVN managed: %v",
+				standby.EgressIP, eIP.Name, err)
+		}
+		if !isOVNManagedNetwork {
+			continue
+		}
+		isEgressIPv6 := utilnet.IsIPv6(egressIP)
+		gatewayConfig, err := util.ParseNodeL3GatewayAnnotation(node)
+		if err != nil {
+			klog.V(5).Infof("Unable to get the gateway router IP of the standby node %s of EgressIP %s: %v",
+				standby.Node, eIP.Name, err)
+			continue
+		}
+		gatewayIP, err := util.MatchFirstIPNetFamily(isEgressIPv6, gatewayConfig.IPAddresses)
+		if err != nil {
+			continue
+		}
+		// the static routes of the pods of the other zones would compete
+		// with the ones to the egress node if it is in the zone too
+		isLocalZoneActiveNode, _ := oc.eIPC.nodeZoneState.Load(activeNode)
+		var joinIP net.IP
+		if config.OVNKubernetesFeature.EnableInterconnect && !isLocalZoneActiveNode {
+			if joinIP, err = oc.eIPC.getGatewayRouterJoinIP(standby.Node, isEgressIPv6); err != nil {
+				klog.V(5).Infof("Unable to get the gateway router join IP of the standby node %s of EgressIP %s: %v",
+					standby.Node, eIP.Name, err)
+			}
+		}
+		if pods == nil {
+			if pods, err = oc.getEgressIPStandbyPods(eIP); err != nil {
+				return nil, nil, fmt.Errorf("unable to get the pods of EgressIP %s: %v", eIP.Name, err)
+			}
+		}
+		externalIDs := map[string]string{
+			egressIPStandbyExternalID:   eIP.Name,
+			egressIPStandbyIPExternalID: standby.EgressIP,
+		}
+		router := util.GetGatewayRouterFromNode(standby.Node)
+		for _, pod := range pods {
+			// the traffic of the pods of the standby node reaches the gateway
+			// router without being rerouted, and may have a SNAT of its own,
+			// and the standby node can't serve the pods of other topology
+			// domains with a topology affinity
+			if pod.Spec.NodeName == standby.Node ||
+				!oc.isEgressNodeInPodTopology(eIP.Spec.Placement, standby.Node, pod.Spec.NodeName) {
+				continue
+			}
+			podIPs, err := util.GetPodCIDRsWithFullMask(pod, oc.NetInfo)
+			if err != nil {
+				continue
+			}
+			isLocalZonePod, _ := oc.eIPC.nodeZoneState.Load(pod.Spec.NodeName)
+			for _, podIP := range util.MatchAllIPNetFamily(isEgressIPv6, podIPs) {
+				nats[router] = append(nats[router], libovsdbops.BuildSNAT(&gatewayIP.IP, podIP, types.K8sPrefix+standby.Node,
+					externalIDs))
+				if joinIP != nil && !isLocalZonePod {
+					routes = append(routes, &nbdb.LogicalRouterStaticRoute{
+						IPPrefix:    podIP.IP.String(),
+						Nexthop:     joinIP.String(),
+						Policy:      &nbdb.LogicalRouterStaticRoutePolicySrcIP,
+						ExternalIDs: externalIDs,
+					})
+				}
+			}
+		}
+	}
+	return nats, routes, nil
+}
+
+// reconcileEgressIPStandbys pre-programs the SNATs and static routes of the
+// standby nodes of the zone of the egress IPs of the EgressIP, and removes
+// the ones no longer needed
+func (oc *DefaultNetworkController) reconcileEgressIPStandbys(old, new *egressipv1.EgressIP) error {
+	// the pod assignments flip the standby SNATs
+	oc.eIPC.podAssignmentMutex.Lock()
+	defer oc.eIPC.podAssignmentMutex.Unlock()
+	name := ""
+	if old != nil {
+		name = old.Name
+	}
+	if new != nil {
+		name = new.Name
+	}
+	nats, routes, err := oc.getEgressIPStandbyRows(new)
+	if err != nil {
+		return err
+	}
+	existingNATs, err := libovsdbops.FindNATsWithPredicate(oc.nbClient, func(item *nbdb.NAT) bool {
+		return item.ExternalIDs[egressIPStandbyExternalID] == name
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the standby SNATs of EgressIP %s: %v", name, err)
+	}
+	existingRoutes, err := libovsdbops.FindLogicalRouterStaticRoutesWithPredicate(oc.nbClient, func(item *nbdb.LogicalRouterStaticRoute) bool {
+		return item.ExternalIDs[egressIPStandbyExternalID] == name
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the standby static routes of EgressIP %s: %v", name, err)
+	}
+	if len(nats) == 0 && len(routes) == 0 && len(existingNATs) == 0 && len(existingRoutes) == 0 {
+		return nil
+	}
+
+	var ops []ovsdb.Operation
+	desired := sets.New[string]()
+	for router, routerNATs := range nats {
+		for _, nat := range routerNATs {
+			desired.Insert(router + "/" + nat.LogicalIP + "/" + nat.ExternalIDs[egressIPStandbyIPExternalID])
+		}
+		ops, err = libovsdbops.CreateOrUpdateNATsOps(oc.nbClient, ops, &nbdb.LogicalRouter{Name: router}, routerNATs...)
+		if err != nil {
+			return fmt.Errorf("unable to create the standby SNATs of EgressIP %s on router %s: %v", name, router, err)
+		}
+	}
+	var staleNATs []*nbdb.NAT
+	for _, nat := range existingNATs {
+		if nat.LogicalPort == nil {
+			staleNATs = append(staleNATs, nat)
+			continue
+		}
+		router := util.GetGatewayRouterFromNode(strings.TrimPrefix(*nat.LogicalPort, types.K8sPrefix))
+		if !desired.Has(router + "/" + nat.LogicalIP + "/" + nat.ExternalIDs[egressIPStandbyIPExternalID]) {
+			staleNATs = append(staleNATs, nat)
+		}
+	}
+	if ops, err = deleteEgressIPStandbyNATsOps(oc.nbClient, ops, staleNATs); err != nil {
+		return fmt.Errorf("unable to delete the stale standby SNATs of EgressIP %s: %v", name, err)
+	}
+
+	desired = sets.New[string]()
+	for _, route := range routes {
+		desired.Insert(route.IPPrefix + "/" + route.Nexthop + "/" + route.ExternalIDs[egressIPStandbyIPExternalID])
+		lrsr := route
+		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return item.IPPrefix == lrsr.IPPrefix && item.Nexthop == lrsr.Nexthop &&
+				libovsdbops.PolicyEqualPredicate(item.Policy, lrsr.Policy) &&
+				isEgressIPStandbyRow(item.ExternalIDs, name, lrsr.ExternalIDs[egressIPStandbyIPExternalID])
+		}
+		ops, err = libovsdbops.CreateOrUpdateLogicalRouterStaticRoutesWithPredicateOps(oc.nbClient, ops, types.OVNClusterRouter, lrsr, p)
+		if err != nil {
+			return fmt.Errorf("unable to create the standby static routes of EgressIP %s: %v", name, err)
+		}
+	}
+	var staleRoutes []*nbdb.LogicalRouterStaticRoute
+	for _, route := range existingRoutes {
+		if !desired.Has(route.IPPrefix + "/" + route.Nexthop + "/" + route.ExternalIDs[egressIPStandbyIPExternalID]) {
+			staleRoutes = append(staleRoutes, route)
+		}
+	}
+	if ops, err = deleteEgressIPStandbyRoutesOps(oc.nbClient, ops, staleRoutes); err != nil {
+		return fmt.Errorf("unable to delete the stale standby static routes of EgressIP %s: %v", name, err)
+	}
+	_, err = libovsdbops.TransactAndCheck(oc.nbClient, ops)
+	return err
+}
+
+// reconcileEgressIPStandbysOfPod reconciles the standby rows of the EgressIPs
+// with standby nodes selecting the pod, before or after its update
+func (oc *DefaultNetworkController) reconcileEgressIPStandbysOfPod(egressIPs []*egressipv1.EgressIP, namespaceLabels,
+	oldPodLabels, newPodLabels labels.Set) error {
+	if !config.OVNKubernetesFeature.EnableEgressIPStandby {
+		return nil
+	}
+	for _, egressIP := range egressIPs {
+		if len(egressIP.Status.Standbys) == 0 {
+			continue
+		}
+		namespaceSelector, _ := metav1.LabelSelectorAsSelector(&egressIP.Spec.NamespaceSelector)
+		if !namespaceSelector.Matches(namespaceLabels) {
+			continue
+		}
+		podSelector, _ := metav1.LabelSelectorAsSelector(&egressIP.Spec.PodSelector)
+		if !podSelector.Matches(oldPodLabels) && !podSelector.Matches(newPodLabels) {
+			continue
+		}
+		if err := oc.reconcileEgressIPStandbys(nil, egressIP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flipEgressIPStandbySNATs makes the SNATs of the egress IP to create on the
+// gateway router of its egress node update the SNATs pre-programmed there
+// while it was the standby node of the egress IP, if any
+func flipEgressIPStandbySNATs(nbClient libovsdbclient.Client, router *nbdb.LogicalRouter, egressIPName, egressIP string, nats []*nbdb.NAT) error {
+	routerNATs, err := libovsdbops.GetRouterNATs(nbClient, router)
+	if err != nil {
+		if err == libovsdbclient.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	standbys := map[string]string{}
+	for _, nat := range routerNATs {
+		if isEgressIPStandbyRow(nat.ExternalIDs, egressIPName, egressIP) {
+			standbys[nat.LogicalIP] = nat.UUID
+		}
+	}
+	for _, nat := range nats {
+		if uuid, ok := standbys[nat.LogicalIP]; ok {
+			nat.UUID = uuid
+		}
+	}
+	return nil
+}
+
+// deleteEgressIPStandbyNATsOps returns the ops to remove the SNATs from the
+// routers referencing them and delete them
+func deleteEgressIPStandbyNATsOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, nats []*nbdb.NAT) ([]ovsdb.Operation, error) {
+	if len(nats) == 0 {
+		return ops, nil
+	}
+	uuids := sets.New[string]()
+	for _, nat := range nats {
+		uuids.Insert(nat.UUID)
+	}
+	return libovsdbops.DeleteNATsWithPredicateOps(nbClient, ops, func(item *nbdb.NAT) bool {
+		return uuids.Has(item.UUID)
+	})
+}
+
+// deleteEgressIPStandbyRoutesOps returns the ops to delete the static routes
+// from the cluster router
+func deleteEgressIPStandbyRoutesOps(nbClient libovsdbclient.Client, ops []ovsdb.Operation, routes []*nbdb.LogicalRouterStaticRoute) ([]ovsdb.Operation, error) {
+	if len(routes) == 0 {
+		return ops, nil
+	}
+	uuids := sets.New[string]()
+	for _, route := range routes {
+		uuids.Insert(route.UUID)
+	}
+	return libovsdbops.DeleteLogicalRouterStaticRoutesWithPredicateOps(nbClient, ops, types.OVNClusterRouter,
+		func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return uuids.Has(item.UUID)
+		})
+}
+
+// syncStaleEgressIPStandbys deletes the standby rows of the EgressIPs deleted
+// while ovnkube-controller was down
+func (oc *DefaultNetworkController) syncStaleEgressIPStandbys(egressIPCache map[string]egressIPCacheEntry) error {
+	isStale := func(externalIDs map[string]string) bool {
+		name, ok := externalIDs[egressIPStandbyExternalID]
+		if !ok {
+			return false
+		}
+		_, exists := egressIPCache[name]
+		return !exists
+	}
+	nats, err := libovsdbops.FindNATsWithPredicate(oc.nbClient, func(item *nbdb.NAT) bool {
+		return isStale(item.ExternalIDs)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the stale EgressIP standby SNATs: %v", err)
+	}
+	routes, err := libovsdbops.FindLogicalRouterStaticRoutesWithPredicate(oc.nbClient, func(item *nbdb.LogicalRouterStaticRoute) bool {
+		return isStale(item.ExternalIDs)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to find the stale EgressIP standby static routes: %v", err)
+	}
+	ops, err := deleteEgressIPStandbyNATsOps(oc.nbClient, nil, nats)
+	if err != nil {
+		return fmt.Errorf("unable to delete the stale EgressIP standby SNATs: %v", err)
+	}
+	ops, err = deleteEgressIPStandbyRoutesOps(oc.nbClient, ops, routes)
+	if err != nil {
+		return fmt.Errorf("unable to delete the stale EgressIP standby static routes: %v", err)
+	}
+	_, err = libovsdbops.TransactAndCheck(oc.nbClient, ops)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"time"
 
@@ -10048,6 +10049,599 @@ var _ = ginkgo.Describe("OVN master EgressIP Operations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 	})
+
+	ginkgo.Context("EgressIP DSCP", func() {
+
+		ginkgo.It("should mark the traffic SNATed to the egress IPs on the external switch of the egress node", func() {
+			app.Action = func(ctx *cli.Context) error {
+				egressIP := "192.168.126.101"
+				node1IPv4Net := "192.168.126.0/24"
+				node1IPv4 := "192.168.126.202/24"
+
+				egressNamespace := newNamespace(namespace)
+				annotations := map[string]string{
+					"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", node1IPv4, ""),
+					"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4Node1Subnet),
+					"k8s.ovn.org/zone-name":           "global",
+					"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", node1IPv4),
+				}
+				labels := map[string]string{
+					"k8s.ovn.org/egress-assignable": "",
+				}
+				node1 := getNodeObj(node1Name, annotations, labels)
+				dscp := int32(10)
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP},
+						DSCP:      &dscp,
+						NamespaceSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"name": egressNamespace.Name,
+							},
+						},
+					},
+				}
+
+				fakeOvn.startWithDBSetup(
+					libovsdbtest.TestSetup{
+						NBData: []libovsdbtest.TestData{
+							&nbdb.LogicalRouterPort{
+								UUID:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Name:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name,
+								Networks: []string{nodeLogicalRouterIfAddrV4},
+							},
+							&nbdb.LogicalRouter{
+								Name: ovntypes.OVNClusterRouter,
+								UUID: ovntypes.OVNClusterRouter + "-UUID",
+							},
+							&nbdb.LogicalRouter{
+								Name:  ovntypes.GWRouterPrefix + node1.Name,
+								UUID:  ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Ports: []string{ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID"},
+							},
+							&nbdb.LogicalSwitch{
+								UUID: types.ExternalSwitchPrefix + node1.Name + "-UUID",
+								Name: types.ExternalSwitchPrefix + node1.Name,
+							},
+						},
+					},
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{node1},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{*egressNamespace},
+					})
+
+				err := fakeOvn.controller.WatchEgressIPNamespaces()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIPPods()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				fakeOvn.patchEgressIPObj(node1Name, egressIPName, egressIP, node1IPv4Net)
+				gomega.Eventually(getEgressIPStatusLen(egressIPName)).Should(gomega.Equal(1))
+
+				getDSCPs := func() map[string]int {
+					qoses, err := libovsdbops.FindQoSesWithPredicate(fakeOvn.nbClient, func(item *nbdb.QoS) bool {
+						return item.ExternalIDs[egressIPDSCPExternalID] == egressIPName
+					})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					extSwitch, err := libovsdbops.GetLogicalSwitch(fakeOvn.nbClient, &nbdb.LogicalSwitch{Name: types.ExternalSwitchPrefix + node1Name})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(extSwitch.QOSRules).To(gomega.HaveLen(len(qoses)))
+					dscps := map[string]int{}
+					for _, qos := range qoses {
+						gomega.Expect(qos.Direction).To(gomega.Equal(nbdb.QoSDirectionFromLport))
+						dscps[qos.Match] = qos.Action[nbdb.QoSActionDSCP]
+					}
+					return dscps
+				}
+				match := fmt.Sprintf("ip4.src == %s && inport == \"%s%s%s\"", egressIP,
+					types.EXTSwitchToGWRouterPrefix, types.GWRouterPrefix, node1Name)
+				gomega.Eventually(getDSCPs).Should(gomega.Equal(map[string]int{match: 10}))
+
+				// the QoS rule follows the DSCP of the EgressIP
+				eIPUpdate, err := fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				dscp = 46
+				eIPUpdate.Spec.DSCP = &dscp
+				_, err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Update(context.TODO(), eIPUpdate, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getDSCPs).Should(gomega.Equal(map[string]int{match: 46}))
+
+				err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Delete(context.TODO(), egressIPName, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getDSCPs).Should(gomega.BeEmpty())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIP source port range", func() {
+
+		ginkgo.It("should SNAT the traffic of the pods to the source port range of the EgressIP", func() {
+			app.Action = func(ctx *cli.Context) error {
+				egressIP := "192.168.126.101"
+				node1IPv4 := "192.168.126.202/24"
+
+				egressPod := *newPodWithLabels(namespace, podName, node1Name, podV4IP, egressPodLabel)
+				egressNamespace := newNamespace(namespace)
+				annotations := map[string]string{
+					"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", node1IPv4, ""),
+					"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4Node1Subnet),
+					"k8s.ovn.org/zone-name":           "global",
+					"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", node1IPv4),
+				}
+				labels := map[string]string{
+					"k8s.ovn.org/egress-assignable": "",
+				}
+				node1 := getNodeObj(node1Name, annotations, labels)
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs:       []string{egressIP},
+						SourcePortRange: &egressipv1.EgressIPPortRange{Start: 1024, End: 2047},
+						PodSelector: metav1.LabelSelector{
+							MatchLabels: egressPodLabel,
+						},
+						NamespaceSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"name": egressNamespace.Name,
+							},
+						},
+					},
+					Status: egressipv1.EgressIPStatus{
+						Items: []egressipv1.EgressIPStatusItem{
+							{
+								Node:     node1Name,
+								EgressIP: egressIP,
+							},
+						},
+					},
+				}
+
+				fakeOvn.startWithDBSetup(
+					libovsdbtest.TestSetup{
+						NBData: []libovsdbtest.TestData{
+							&nbdb.LogicalRouterPort{
+								UUID:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Name:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name,
+								Networks: []string{nodeLogicalRouterIfAddrV4},
+							},
+							&nbdb.LogicalRouter{
+								Name: ovntypes.OVNClusterRouter,
+								UUID: ovntypes.OVNClusterRouter + "-UUID",
+							},
+							&nbdb.LogicalRouter{
+								Name:  ovntypes.GWRouterPrefix + node1.Name,
+								UUID:  ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Ports: []string{ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID"},
+							},
+						},
+					},
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{node1},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{*egressNamespace},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{egressPod},
+					})
+
+				i, n, _ := net.ParseCIDR(podV4IP + "/23")
+				n.IP = i
+				fakeOvn.controller.logicalPortCache.add(&egressPod, "", types.DefaultNetworkName, "", nil, []*net.IPNet{n})
+
+				err := fakeOvn.controller.WatchEgressIPNamespaces()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIPPods()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				// getPortRanges returns the external port ranges of the SNATs
+				// of the pod, by external IP
+				getPortRanges := func() map[string]string {
+					router := &nbdb.LogicalRouter{Name: ovntypes.GWRouterPrefix + node1Name}
+					nats, err := libovsdbops.GetRouterNATs(fakeOvn.nbClient, router)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					portRanges := map[string]string{}
+					for _, nat := range nats {
+						if nat.LogicalIP == podV4IP && nat.ExternalIDs["name"] == egressIPName {
+							portRanges[nat.ExternalIP] = nat.ExternalPortRange
+						}
+					}
+					return portRanges
+				}
+				gomega.Eventually(getPortRanges).Should(gomega.Equal(map[string]string{egressIP: "1024-2047"}))
+
+				// the SNAT follows the source port range of the EgressIP
+				updateSourcePortRange := func(portRange *egressipv1.EgressIPPortRange) {
+					eIPUpdate, err := fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					eIPUpdate.Spec.SourcePortRange = portRange
+					_, err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Update(context.TODO(), eIPUpdate, metav1.UpdateOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				}
+				updateSourcePortRange(&egressipv1.EgressIPPortRange{Start: 3000, End: 3000})
+				gomega.Eventually(getPortRanges).Should(gomega.Equal(map[string]string{egressIP: "3000"}))
+				updateSourcePortRange(nil)
+				gomega.Eventually(getPortRanges).Should(gomega.Equal(map[string]string{egressIP: ""}))
+
+				err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Delete(context.TODO(), egressIPName, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getPortRanges).Should(gomega.BeEmpty())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIP standby", func() {
+
+		ginkgo.It("should pre-program the SNATs on the standby node and flip them on failover", func() {
+			config.OVNKubernetesFeature.EnableEgressIPStandby = true
+			app.Action = func(ctx *cli.Context) error {
+				egressIP := "192.168.126.101"
+				node1IPv4 := "192.168.126.202/24"
+				node2IPv4 := "192.168.126.51/24"
+
+				egressPod := *newPodWithLabels(namespace, podName, node1Name, podV4IP, egressPodLabel)
+				egressNamespace := newNamespace(namespace)
+				annotations := map[string]string{
+					"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", node1IPv4, ""),
+					"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4Node1Subnet),
+					"k8s.ovn.org/zone-name":           "global",
+					"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", node1IPv4),
+					"k8s.ovn.org/l3-gateway-config":   `{"default":{"mode":"local","mac-address":"7e:57:f8:f0:3c:49", "ip-address":"192.168.126.12/24", "next-hop":"192.168.126.1"}}`,
+					"k8s.ovn.org/node-chassis-id":     "79fdcfc4-6fe6-4cd3-8242-c0f85a4668ec",
+				}
+				labels := map[string]string{
+					"k8s.ovn.org/egress-assignable": "",
+				}
+				node1 := getNodeObj(node1Name, annotations, labels)
+				annotations = map[string]string{
+					"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", node2IPv4, ""),
+					"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", v4Node2Subnet),
+					"k8s.ovn.org/zone-name":           "global",
+					"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", node2IPv4),
+					"k8s.ovn.org/l3-gateway-config":   `{"default":{"mode":"local","mac-address":"7e:57:f8:f0:3c:50", "ip-address":"192.168.126.13/24", "next-hop":"192.168.126.1"}}`,
+					"k8s.ovn.org/node-chassis-id":     "79fdcfc4-6fe6-4cd3-8242-c0f85a4668ed",
+				}
+				node2 := getNodeObj(node2Name, annotations, labels)
+
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP},
+						PodSelector: metav1.LabelSelector{
+							MatchLabels: egressPodLabel,
+						},
+						NamespaceSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"name": egressNamespace.Name,
+							},
+						},
+					},
+					Status: egressipv1.EgressIPStatus{
+						Items: []egressipv1.EgressIPStatusItem{
+							{
+								Node:     node1Name,
+								EgressIP: egressIP,
+							},
+						},
+						Standbys: []egressipv1.EgressIPStandbyStatus{
+							{
+								EgressIP: egressIP,
+								Node:     node2Name,
+							},
+						},
+					},
+				}
+
+				fakeOvn.startWithDBSetup(
+					libovsdbtest.TestSetup{
+						NBData: []libovsdbtest.TestData{
+							&nbdb.LogicalRouterPort{
+								UUID:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Name:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name,
+								Networks: []string{nodeLogicalRouterIfAddrV4},
+							},
+							&nbdb.LogicalRouterPort{
+								UUID:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node2.Name + "-UUID",
+								Name:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node2.Name,
+								Networks: []string{node2LogicalRouterIfAddrV4},
+							},
+							&nbdb.LogicalRouter{
+								Name: ovntypes.OVNClusterRouter,
+								UUID: ovntypes.OVNClusterRouter + "-UUID",
+							},
+							&nbdb.LogicalRouter{
+								Name:  ovntypes.GWRouterPrefix + node1.Name,
+								UUID:  ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Ports: []string{ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID"},
+							},
+							&nbdb.LogicalRouter{
+								Name:  ovntypes.GWRouterPrefix + node2.Name,
+								UUID:  ovntypes.GWRouterPrefix + node2.Name + "-UUID",
+								Ports: []string{ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node2.Name + "-UUID"},
+							},
+						},
+					},
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{node1, node2},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{*egressNamespace},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{egressPod},
+					})
+
+				i, n, _ := net.ParseCIDR(podV4IP + "/23")
+				n.IP = i
+				fakeOvn.controller.logicalPortCache.add(&egressPod, "", types.DefaultNetworkName, "", nil, []*net.IPNet{n})
+
+				err := fakeOvn.controller.WatchEgressIPNamespaces()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIPPods()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				// getSNATs returns the external IP and whether it is a
+				// standby SNAT of the SNATs of the pod, by UUID and router
+				getSNATs := func() map[string]string {
+					snats := map[string]string{}
+					for _, node := range []string{node1Name, node2Name} {
+						router := &nbdb.LogicalRouter{Name: ovntypes.GWRouterPrefix + node}
+						nats, err := libovsdbops.GetRouterNATs(fakeOvn.nbClient, router)
+						gomega.Expect(err).NotTo(gomega.HaveOccurred())
+						for _, nat := range nats {
+							if nat.LogicalIP != podV4IP {
+								continue
+							}
+							gomega.Expect(*nat.LogicalPort).To(gomega.Equal("k8s-" + node))
+							snats[router.Name+"/"+nat.UUID] = fmt.Sprintf("%s standby=%t", nat.ExternalIP,
+								isEgressIPStandbyRow(nat.ExternalIDs, egressIPName, egressIP))
+						}
+					}
+					return snats
+				}
+				var standbyUUID string
+				gomega.Eventually(func() map[string]string {
+					snats := getSNATs()
+					for key, snat := range snats {
+						if snat == "192.168.126.13 standby=true" {
+							standbyUUID = strings.TrimPrefix(key, ovntypes.GWRouterPrefix+node2Name+"/")
+						}
+					}
+					return snats
+				}).Should(gomega.HaveLen(2))
+				gomega.Expect(standbyUUID).NotTo(gomega.BeEmpty())
+				gomega.Expect(getSNATs()).To(gomega.HaveKeyWithValue(gomega.HavePrefix(ovntypes.GWRouterPrefix+node1Name+"/"),
+					egressIP+" standby=false"))
+
+				// the egress IP fails over to its standby node, which flips the
+				// pre-programmed SNAT, and the former egress node, hosting the
+				// pod, stands by without a SNAT
+				eIPUpdate, err := fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				eIPUpdate.Status = egressipv1.EgressIPStatus{
+					Items: []egressipv1.EgressIPStatusItem{
+						{
+							Node:     node2Name,
+							EgressIP: egressIP,
+						},
+					},
+					Standbys: []egressipv1.EgressIPStandbyStatus{
+						{
+							EgressIP: egressIP,
+							Node:     node1Name,
+						},
+					},
+				}
+				_, err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Update(context.TODO(), eIPUpdate, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getSNATs).Should(gomega.Equal(map[string]string{
+					ovntypes.GWRouterPrefix + node2Name + "/" + standbyUUID: egressIP + " standby=false",
+				}))
+
+				err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Delete(context.TODO(), egressIPName, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getSNATs).Should(gomega.BeEmpty())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("EgressIP topology affinity", func() {
+
+		ginkgo.It("should only reroute the pods to the egress nodes of their topology domain", func() {
+			app.Action = func(ctx *cli.Context) error {
+				egressIP1 := "192.168.126.101"
+				egressIP2 := "192.168.126.102"
+				node1IPv4 := "192.168.126.202/24"
+				node2IPv4 := "192.168.126.51/24"
+
+				egressPod := *newPodWithLabels(namespace, podName, node1Name, podV4IP, egressPodLabel)
+				egressNamespace := newNamespace(namespace)
+				newTopologyNode := func(name, nodeIPv4, subnet, domain string) v1.Node {
+					annotations := map[string]string{
+						"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s\", \"ipv6\": \"%s\"}", nodeIPv4, ""),
+						"k8s.ovn.org/node-subnets":        fmt.Sprintf("{\"default\":\"%s\"}", subnet),
+						"k8s.ovn.org/zone-name":           "global",
+						"k8s.ovn.org/host-addresses":      fmt.Sprintf("[\"%s\"]", nodeIPv4),
+					}
+					labels := map[string]string{
+						"k8s.ovn.org/egress-assignable": "",
+						"topology.kubernetes.io/zone":   domain,
+					}
+					return getNodeObj(name, annotations, labels)
+				}
+				node1 := newTopologyNode(node1Name, node1IPv4, v4Node1Subnet, "a")
+				node2 := newTopologyNode(node2Name, node2IPv4, v4Node2Subnet, "b")
+
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP1, egressIP2},
+						PodSelector: metav1.LabelSelector{
+							MatchLabels: egressPodLabel,
+						},
+						NamespaceSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"name": egressNamespace.Name,
+							},
+						},
+						Placement: &egressipv1.EgressIPPlacement{
+							TopologyKey:      "topology.kubernetes.io/zone",
+							TopologyAffinity: egressipv1.EgressIPTopologyAffinityRequired,
+						},
+					},
+					Status: egressipv1.EgressIPStatus{
+						Items: []egressipv1.EgressIPStatusItem{
+							{
+								Node:     node1Name,
+								EgressIP: egressIP1,
+							},
+							{
+								Node:     node2Name,
+								EgressIP: egressIP2,
+							},
+						},
+					},
+				}
+
+				fakeOvn.startWithDBSetup(
+					libovsdbtest.TestSetup{
+						NBData: []libovsdbtest.TestData{
+							&nbdb.LogicalRouterPort{
+								UUID:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Name:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name,
+								Networks: []string{nodeLogicalRouterIfAddrV4},
+							},
+							&nbdb.LogicalRouterPort{
+								UUID:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node2.Name + "-UUID",
+								Name:     ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node2.Name,
+								Networks: []string{node2LogicalRouterIfAddrV4},
+							},
+							&nbdb.LogicalRouter{
+								Name: ovntypes.OVNClusterRouter,
+								UUID: ovntypes.OVNClusterRouter + "-UUID",
+							},
+							&nbdb.LogicalRouter{
+								Name:  ovntypes.GWRouterPrefix + node1.Name,
+								UUID:  ovntypes.GWRouterPrefix + node1.Name + "-UUID",
+								Ports: []string{ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node1.Name + "-UUID"},
+							},
+							&nbdb.LogicalRouter{
+								Name:  ovntypes.GWRouterPrefix + node2.Name,
+								UUID:  ovntypes.GWRouterPrefix + node2.Name + "-UUID",
+								Ports: []string{ovntypes.GWRouterToJoinSwitchPrefix + ovntypes.GWRouterPrefix + node2.Name + "-UUID"},
+							},
+						},
+					},
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{node1, node2},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{*egressNamespace},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{egressPod},
+					})
+
+				i, n, _ := net.ParseCIDR(podV4IP + "/23")
+				n.IP = i
+				fakeOvn.controller.logicalPortCache.add(&egressPod, "", types.DefaultNetworkName, "", nil, []*net.IPNet{n})
+
+				err := fakeOvn.controller.WatchEgressIPNamespaces()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIPPods()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressNodes()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchEgressIP()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				// getNextHops returns the next hops the traffic of the pod is
+				// rerouted to and the external IPs it is SNATed to
+				getNextHops := func() []string {
+					policies, err := libovsdbops.FindLogicalRouterPoliciesWithPredicate(fakeOvn.nbClient, func(item *nbdb.LogicalRouterPolicy) bool {
+						return item.Priority == types.EgressIPReroutePriority && item.Match == fmt.Sprintf("ip4.src == %s", podV4IP)
+					})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					var nextHops []string
+					for _, policy := range policies {
+						nextHops = append(nextHops, policy.Nexthops...)
+					}
+					nats, err := libovsdbops.FindNATsWithPredicate(fakeOvn.nbClient, func(item *nbdb.NAT) bool {
+						return item.LogicalIP == podV4IP && item.ExternalIDs["name"] == egressIPName
+					})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					for _, nat := range nats {
+						nextHops = append(nextHops, nat.ExternalIP)
+					}
+					sort.Strings(nextHops)
+					return nextHops
+				}
+				gomega.Eventually(getNextHops).Should(gomega.Equal([]string{nodeLogicalRouterIPv4[0], egressIP1}))
+
+				// without the topology affinity, the pod is served by both
+				// egress nodes
+				eIPUpdate, err := fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				eIPUpdate.Spec.Placement.TopologyAffinity = egressipv1.EgressIPTopologyAffinityNone
+				_, err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Update(context.TODO(), eIPUpdate, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getNextHops).Should(gomega.Equal([]string{nodeLogicalRouterIPv4[0], node2LogicalRouterIPv4[0],
+					egressIP1, egressIP2}))
+
+				// and only by the egress node of its topology domain again once
+				// the affinity is required
+				eIPUpdate, err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Get(context.TODO(), egressIPName, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				eIPUpdate.Spec.Placement.TopologyAffinity = egressipv1.EgressIPTopologyAffinityRequired
+				_, err = fakeOvn.fakeClient.EgressIPClient.K8sV1().EgressIPs().Update(context.TODO(), eIPUpdate, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getNextHops).Should(gomega.Equal([]string{nodeLogicalRouterIPv4[0], egressIP1}))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+	})
 })
 
 // TEST UTILITY FUNCTIONS;
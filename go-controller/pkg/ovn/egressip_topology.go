@@ -0,0 +1,72 @@
+package ovn
+
+import (
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+)
+
+// hasEgressIPTopologyAffinity returns whether the pods of the EgressIP only
+// use the egress IPs assigned to the egress nodes of their topology domain
+func hasEgressIPTopologyAffinity(placement *egressipv1.EgressIPPlacement) bool {
+	return placement != nil && placement.TopologyKey != "" &&
+		placement.TopologyAffinity == egressipv1.EgressIPTopologyAffinityRequired
+}
+
+// isEgressNodeInPodTopology returns whether the egress node can serve the pods
+// of the node according to the topology affinity of the placement of their
+// EgressIP: both nodes must be in the same topology domain
+func (oc *DefaultNetworkController) isEgressNodeInPodTopology(placement *egressipv1.EgressIPPlacement, egressNodeName, podNodeName string) bool {
+	if !hasEgressIPTopologyAffinity(placement) || egressNodeName == podNodeName {
+		return true
+	}
+	egressNode, err := oc.watchFactory.GetNode(egressNodeName)
+	if err != nil {
+		klog.V(5).Infof("Unable to get the egress node %s: %v", egressNodeName, err)
+		return false
+	}
+	podNode, err := oc.watchFactory.GetNode(podNodeName)
+	if err != nil {
+		klog.V(5).Infof("Unable to get the node %s: %v", podNodeName, err)
+		return false
+	}
+	egressDomain, ok := egressNode.Labels[placement.TopologyKey]
+	if !ok {
+		return false
+	}
+	podDomain, ok := podNode.Labels[placement.TopologyKey]
+	return ok && egressDomain == podDomain
+}
+
+// filterEgressIPStatusesByPodTopology returns the statuses of the EgressIP
+// whose egress node can serve the pod according to the topology affinity of
+// its placement
+func (oc *DefaultNetworkController) filterEgressIPStatusesByPodTopology(name string, statuses []egressipv1.EgressIPStatusItem,
+	pod *kapi.Pod) []egressipv1.EgressIPStatusItem {
+	eIP, err := oc.watchFactory.GetEgressIP(name)
+	if err != nil || !hasEgressIPTopologyAffinity(eIP.Spec.Placement) {
+		return statuses
+	}
+	filtered := make([]egressipv1.EgressIPStatusItem, 0, len(statuses))
+	for _, status := range statuses {
+		if oc.isEgressNodeInPodTopology(eIP.Spec.Placement, status.Node, pod.Spec.NodeName) {
+			filtered = append(filtered, status)
+		}
+	}
+	if len(filtered) < len(statuses) {
+		klog.V(5).Infof("EgressIP %s serves pod %s/%s with the egress IPs of its topology domain: %v", name,
+			pod.Namespace, pod.Name, filtered)
+	}
+	return filtered
+}
+
+// isEgressIPTopologyAffinityChanged returns whether the pods of the EgressIP
+// are served by other egress nodes after its update
+func isEgressIPTopologyAffinityChanged(old, new *egressipv1.EgressIP) bool {
+	oldAffinity, newAffinity := hasEgressIPTopologyAffinity(old.Spec.Placement), hasEgressIPTopologyAffinity(new.Spec.Placement)
+	if oldAffinity != newAffinity {
+		return true
+	}
+	return newAffinity && old.Spec.Placement.TopologyKey != new.Spec.Placement.TopologyKey
+}
@@ -0,0 +1,393 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/libovsdb/ovsdb"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// The egress IPs of the pods of a primary user defined network are handled
+// like the ones of the default network, on the logical entities of the network:
+// the traffic of the selected pods leaving the cluster is rerouted by a policy
+// of the cluster router of the network to the gateway routers of the network on
+// the egress nodes, which SNAT it to the egress IPs. Only the egress IPs hosted
+// by the OVN managed network are supported.
+//
+// With interconnect, the traffic of the pods of the layer3 topology is rerouted
+// to the egress nodes of other zones through the transit switch, the zone of the
+// egress node rerouting it again to its gateway router. On the layer2 topology
+// the cluster router is local to each zone, so the pods only use the egress IPs
+// assigned to the egress nodes of their zone.
+//
+// The state of an EgressIP is level driven: its logical entities, tagged with
+// its name and the network, are recomputed on any change of the EgressIP or of
+// the pods of the namespaces it selects.
+
+// udnEgressIPNextHop is the next hop of the traffic of the pods to an egress
+// node, with whether the egress node is in the local zone
+type udnEgressIPNextHop struct {
+	ip    string
+	local bool
+}
+
+// getUDNEgressIPExternalIDs returns the external IDs of the SNATs and reroute
+// policies of the EgressIP
+func (bsnc *BaseSecondaryNetworkController) getUDNEgressIPExternalIDs(eIPName string) map[string]string {
+	externalIDs := bsnc.getUDNExternalIDs()
+	externalIDs[types.UDNEgressIPExternalID] = eIPName
+	return externalIDs
+}
+
+// ensureUDNNoReroutePolicies keeps the traffic of the pods within the network
+// and to the join subnet from being rerouted to the egress nodes
+func (bsnc *BaseSecondaryNetworkController) ensureUDNNoReroutePolicies() error {
+	clusterRouter := bsnc.GetNetworkScopedName(types.OVNClusterRouter)
+	for _, subnet := range bsnc.Subnets() {
+		isIPv6 := utilnet.IsIPv6CIDR(subnet.CIDR)
+		joinSubnet := config.Gateway.V4JoinSubnet
+		if isIPv6 {
+			joinSubnet = config.Gateway.V6JoinSubnet
+		}
+		for _, dst := range []string{subnet.CIDR.String(), joinSubnet} {
+			lrp := nbdb.LogicalRouterPolicy{
+				Priority:    types.DefaultNoRereoutePriority,
+				Action:      nbdb.LogicalRouterPolicyActionAllow,
+				Match:       fmt.Sprintf("%s.src == %s && %s.dst == %s", ipFamilyName(isIPv6), subnet.CIDR, ipFamilyName(isIPv6), dst),
+				ExternalIDs: bsnc.getUDNExternalIDs(),
+			}
+			p := func(item *nbdb.LogicalRouterPolicy) bool {
+				return item.Match == lrp.Match && item.Priority == lrp.Priority &&
+					item.ExternalIDs[types.NetworkExternalID] == bsnc.GetNetworkName()
+			}
+			err := libovsdbops.CreateOrUpdateLogicalRouterPolicyWithPredicate(bsnc.nbClient, clusterRouter, &lrp, p)
+			if err != nil {
+				return fmt.Errorf("error creating logical router policy %+v on router %s: %v", lrp, clusterRouter, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getUDNEgressIPPods returns the IPs on the network of the pods selected by the
+// EgressIP, by pod
+func (bsnc *BaseSecondaryNetworkController) getUDNEgressIPPods(eIP *egressipv1.EgressIP) (map[*kapi.Pod][]*net.IPNet, error) {
+	namespaces, err := bsnc.watchFactory.GetNamespacesBySelector(eIP.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the namespaces of EgressIP %s: %v", eIP.Name, err)
+	}
+	podsIPs := map[*kapi.Pod][]*net.IPNet{}
+	for _, namespace := range namespaces {
+		pods, err := bsnc.watchFactory.GetPodsBySelector(namespace.Name, eIP.Spec.PodSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the pods of EgressIP %s in namespace %s: %v", eIP.Name,
+				namespace.Name, err)
+		}
+		for _, pod := range pods {
+			if !util.PodScheduled(pod) || util.PodWantsHostNetwork(pod) || util.PodCompleted(pod) {
+				continue
+			}
+			podIPs, err := bsnc.getPodPrimaryNetworkIPs(pod)
+			if err != nil {
+				return nil, err
+			}
+			if len(podIPs) > 0 {
+				podsIPs[pod] = podIPs
+			}
+		}
+	}
+	return podsIPs, nil
+}
+
+// getUDNEgressIPNextHop returns the next hop of the traffic of the pods to the
+// egress node, an empty one if the egress node can't be reached
+func (bsnc *BaseSecondaryNetworkController) getUDNEgressIPNextHop(node *kapi.Node, isIPv6 bool) (udnEgressIPNextHop, error) {
+	if bsnc.isLocalZoneNode(node) {
+		gwLRPIfAddrs, err := bsnc.getUDNGatewayRouterJoinIfAddrs(node)
+		if err != nil {
+			return udnEgressIPNextHop{}, err
+		}
+		gwLRPIfAddr, err := util.MatchFirstIPNetFamily(isIPv6, gwLRPIfAddrs)
+		if err != nil {
+			return udnEgressIPNextHop{}, err
+		}
+		return udnEgressIPNextHop{ip: gwLRPIfAddr.IP.String(), local: true}, nil
+	}
+	if !config.OVNKubernetesFeature.EnableInterconnect || bsnc.TopologyType() != types.Layer3Topology {
+		return udnEgressIPNextHop{}, nil
+	}
+	transitIPs, err := util.ParseNodeTransitSwitchPortAddrs(node)
+	if err != nil {
+		return udnEgressIPNextHop{}, fmt.Errorf("unable to fetch transit switch IP for node %s: %w", node.Name, err)
+	}
+	transitIP, err := util.MatchFirstIPNetFamily(isIPv6, transitIPs)
+	if err != nil {
+		return udnEgressIPNextHop{}, err
+	}
+	return udnEgressIPNextHop{ip: transitIP.IP.String()}, nil
+}
+
+// ensureUDNEgressIP reconciles the logical entities of the EgressIP on the
+// network with its current state, removing them if it no longer exists
+func (bsnc *BaseSecondaryNetworkController) ensureUDNEgressIP(eIPName string) error {
+	bsnc.udnEgressIPLock.Lock()
+	defer bsnc.udnEgressIPLock.Unlock()
+
+	eIP, err := bsnc.watchFactory.GetEgressIP(eIPName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get EgressIP %s: %v", eIPName, err)
+	}
+
+	clusterRouter := bsnc.GetNetworkScopedName(types.OVNClusterRouter)
+	// desired SNATs by gateway router, and reroute policies by match
+	nats := map[string]map[string]*nbdb.NAT{}
+	policies := map[string]*nbdb.LogicalRouterPolicy{}
+	if eIP != nil {
+		podsIPs, err := bsnc.getUDNEgressIPPods(eIP)
+		if err != nil {
+			return err
+		}
+		for _, status := range eIP.Status.Items {
+			egressIP := net.ParseIP(status.EgressIP)
+			if egressIP == nil || status.Network != "" {
+				continue
+			}
+			node, err := bsnc.watchFactory.GetNode(status.Node)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get node %s: %v", status.Node, err)
+			}
+			isOVNManagedNetwork, err := util.IsOVNManagedNetwork(node, egressIP)
+			if err != nil {
+				return fmt.Errorf("failed to determine if egress IP %s of EgressIP %s is OVN managed: %v",
+					status.EgressIP, eIPName, err)
+			}
+			if !isOVNManagedNetwork {
+				klog.V(5).Infof("Egress IP %s of EgressIP %s is not OVN managed, skipping it on network %s",
+					status.EgressIP, eIPName, bsnc.GetNetworkName())
+				continue
+			}
+			isIPv6 := utilnet.IsIPv6(egressIP)
+			nextHop, err := bsnc.getUDNEgressIPNextHop(node, isIPv6)
+			if err != nil {
+				return err
+			}
+			if nextHop.ip == "" {
+				continue
+			}
+			gatewayRouter := bsnc.getUDNGatewayRouterName(node.Name)
+			for pod, podIPs := range podsIPs {
+				isLocalPod := bsnc.isPodScheduledinLocalZone(pod)
+				// remote pods are rerouted by their own zone, and only reach
+				// the egress nodes of the local zone through the transit
+				// switch of the layer3 topology
+				if !isLocalPod && (!nextHop.local || bsnc.TopologyType() != types.Layer3Topology) {
+					continue
+				}
+				for _, podIP := range util.MatchAllIPNetFamily(isIPv6, podIPs) {
+					if nextHop.local {
+						if nats[gatewayRouter] == nil {
+							nats[gatewayRouter] = map[string]*nbdb.NAT{}
+						}
+						logicalIP := &net.IPNet{IP: podIP.IP, Mask: util.GetIPFullMask(podIP.IP)}
+						nats[gatewayRouter][podIP.IP.String()] = libovsdbops.BuildSNAT(&egressIP, logicalIP, "",
+							bsnc.getUDNEgressIPExternalIDs(eIPName))
+					}
+					match := fmt.Sprintf("%s.src == %s", ipFamilyName(isIPv6), podIP.IP)
+					lrp, ok := policies[match]
+					if !ok {
+						lrp = &nbdb.LogicalRouterPolicy{
+							Match:       match,
+							Priority:    types.EgressIPReroutePriority,
+							Action:      nbdb.LogicalRouterPolicyActionReroute,
+							ExternalIDs: bsnc.getUDNEgressIPExternalIDs(eIPName),
+						}
+						policies[match] = lrp
+					}
+					// the zone of a remote egress node reroutes the traffic
+					// of the local pods again to its gateway router
+					if !util.SliceHasStringItem(lrp.Nexthops, nextHop.ip) {
+						lrp.Nexthops = append(lrp.Nexthops, nextHop.ip)
+					}
+				}
+			}
+		}
+	}
+
+	isEgressIPEntity := func(externalIDs map[string]string) bool {
+		return externalIDs[types.UDNEgressIPExternalID] == eIPName &&
+			externalIDs[types.NetworkExternalID] == bsnc.GetNetworkName()
+	}
+	var ops []ovsdb.Operation
+	for match, lrp := range policies {
+		p := func(item *nbdb.LogicalRouterPolicy) bool {
+			return item.Match == match && item.Priority == lrp.Priority && isEgressIPEntity(item.ExternalIDs)
+		}
+		ops, err = libovsdbops.CreateOrUpdateLogicalRouterPolicyWithPredicateOps(bsnc.nbClient, ops, clusterRouter,
+			lrp, p, &lrp.Nexthops, &lrp.Action, &lrp.ExternalIDs)
+		if err != nil {
+			return fmt.Errorf("error creating logical router policy %+v on router %s: %v", lrp, clusterRouter, err)
+		}
+	}
+	p := func(item *nbdb.LogicalRouterPolicy) bool {
+		_, desired := policies[item.Match]
+		return !desired && isEgressIPEntity(item.ExternalIDs)
+	}
+	ops, err = libovsdbops.DeleteLogicalRouterPolicyWithPredicateOps(bsnc.nbClient, ops, clusterRouter, p)
+	if err != nil {
+		return fmt.Errorf("failed to delete the stale policies of EgressIP %s on router %s: %v", eIPName,
+			clusterRouter, err)
+	}
+
+	gatewayRouters, err := libovsdbops.FindLogicalRoutersWithPredicate(bsnc.nbClient, bsnc.isUDNGatewayRouter)
+	if err != nil {
+		return fmt.Errorf("failed to find the gateway routers of network %s: %v", bsnc.GetNetworkName(), err)
+	}
+	for _, gatewayRouter := range gatewayRouters {
+		router := &nbdb.LogicalRouter{Name: gatewayRouter.Name}
+		routerNATs, err := libovsdbops.GetRouterNATs(bsnc.nbClient, router)
+		if err != nil {
+			return err
+		}
+		var staleNATs []*nbdb.NAT
+		for _, nat := range routerNATs {
+			if !isEgressIPEntity(nat.ExternalIDs) {
+				continue
+			}
+			if desired, ok := nats[gatewayRouter.Name][nat.LogicalIP]; !ok || desired.ExternalIP != nat.ExternalIP {
+				staleNATs = append(staleNATs, nat)
+			}
+		}
+		if len(staleNATs) > 0 {
+			ops, err = libovsdbops.DeleteNATsOps(bsnc.nbClient, ops, router, staleNATs...)
+			if err != nil {
+				return fmt.Errorf("failed to delete the stale SNATs of EgressIP %s on router %s: %v", eIPName,
+					router.Name, err)
+			}
+		}
+		if len(nats[gatewayRouter.Name]) == 0 {
+			continue
+		}
+		routerDesiredNATs := make([]*nbdb.NAT, 0, len(nats[gatewayRouter.Name]))
+		for _, nat := range nats[gatewayRouter.Name] {
+			routerDesiredNATs = append(routerDesiredNATs, nat)
+		}
+		ops, err = libovsdbops.CreateOrUpdateNATsOps(bsnc.nbClient, ops, router, routerDesiredNATs...)
+		if err != nil {
+			return fmt.Errorf("unable to create the SNATs of EgressIP %s on router %s: %v", eIPName, router.Name, err)
+		}
+	}
+
+	if _, err := libovsdbops.TransactAndCheck(bsnc.nbClient, ops); err != nil {
+		return fmt.Errorf("failed to configure EgressIP %s on network %s: %v", eIPName, bsnc.GetNetworkName(), err)
+	}
+	return nil
+}
+
+// ensureUDNEgressIPsForPod reconciles the EgressIPs selecting the namespace of
+// the pod, the pod having been added, updated or deleted
+func (bsnc *BaseSecondaryNetworkController) ensureUDNEgressIPsForPod(pod *kapi.Pod) error {
+	if !config.OVNKubernetesFeature.EnableEgressIP {
+		return nil
+	}
+	namespace, err := bsnc.watchFactory.GetNamespace(pod.Namespace)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s: %v", pod.Namespace, err)
+	}
+	eIPs, err := bsnc.watchFactory.GetEgressIPs()
+	if err != nil {
+		return fmt.Errorf("failed to get the EgressIPs: %v", err)
+	}
+	var errs []error
+	for _, eIP := range eIPs {
+		selector, err := metav1.LabelSelectorAsSelector(&eIP.Spec.NamespaceSelector)
+		if err != nil {
+			klog.Errorf("Invalid namespace selector of EgressIP %s: %v", eIP.Name, err)
+			continue
+		}
+		if !selector.Matches(labels.Set(namespace.Labels)) {
+			continue
+		}
+		if err := bsnc.ensureUDNEgressIP(eIP.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// ensureUDNEgressIPs reconciles all the EgressIPs, when the gateway of a node
+// has been set up
+func (bsnc *BaseSecondaryNetworkController) ensureUDNEgressIPs() error {
+	if !bsnc.IsPrimaryNetwork() || !config.OVNKubernetesFeature.EnableEgressIP {
+		return nil
+	}
+	eIPs, err := bsnc.watchFactory.GetEgressIPs()
+	if err != nil {
+		return fmt.Errorf("failed to get the EgressIPs: %v", err)
+	}
+	var errs []error
+	for _, eIP := range eIPs {
+		if err := bsnc.ensureUDNEgressIP(eIP.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// syncUDNEgressIPs removes the logical entities of the EgressIPs deleted while
+// ovnkube-controller was down
+func (bsnc *BaseSecondaryNetworkController) syncUDNEgressIPs(eIPs []interface{}) error {
+	eIPNames := sets.New[string]()
+	for _, obj := range eIPs {
+		eIP, ok := obj.(*egressipv1.EgressIP)
+		if !ok {
+			return fmt.Errorf("spurious object in syncUDNEgressIPs: %v", obj)
+		}
+		eIPNames.Insert(eIP.Name)
+	}
+	isStaleEntity := func(externalIDs map[string]string) bool {
+		eIPName, ok := externalIDs[types.UDNEgressIPExternalID]
+		return ok && !eIPNames.Has(eIPName) && externalIDs[types.NetworkExternalID] == bsnc.GetNetworkName()
+	}
+
+	clusterRouter := bsnc.GetNetworkScopedName(types.OVNClusterRouter)
+	p := func(item *nbdb.LogicalRouterPolicy) bool {
+		return isStaleEntity(item.ExternalIDs)
+	}
+	ops, err := libovsdbops.DeleteLogicalRouterPolicyWithPredicateOps(bsnc.nbClient, nil, clusterRouter, p)
+	if err != nil {
+		return fmt.Errorf("failed to delete the stale EgressIP policies on router %s: %v", clusterRouter, err)
+	}
+	natPredicate := func(item *nbdb.NAT) bool {
+		return isStaleEntity(item.ExternalIDs)
+	}
+	ops, err = libovsdbops.DeleteNATsWithPredicateOps(bsnc.nbClient, ops, natPredicate)
+	if err != nil {
+		return fmt.Errorf("failed to delete the stale EgressIP SNATs of network %s: %v", bsnc.GetNetworkName(), err)
+	}
+	if _, err := libovsdbops.TransactAndCheck(bsnc.nbClient, ops); err != nil {
+		return fmt.Errorf("failed to delete the stale EgressIPs of network %s: %v", bsnc.GetNetworkName(), err)
+	}
+	return nil
+}
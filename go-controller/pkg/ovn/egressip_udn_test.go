@@ -0,0 +1,182 @@
+package ovn
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	egressipfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned/fake"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/onsi/gomega"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPrimaryNetworkEgressIP(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+	t.Cleanup(func() { _ = config.PrepareTestConfig() })
+	config.OVNKubernetesFeature.EnableEgressIP = true
+	config.OVNKubernetesFeature.EnableMultiNetwork = true
+	config.IPv4Mode = true
+
+	netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "blue"},
+		Topology: types.Layer3Topology,
+		Subnets:  "10.10.0.0/16/24",
+		Role:     types.NetworkRolePrimary,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	netInfo.AddNAD("ns/blue")
+
+	node := &kapi.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Annotations: map[string]string{
+				"k8s.ovn.org/l3-gateway-config": `{"default":{"mode":"shared","mac-address":"7e:57:f8:f0:3c:49",` +
+					`"ip-addresses":["192.168.126.12/24"],"next-hops":["192.168.126.1"],` +
+					`"interface-id":"breth0_node1"}}`,
+				"k8s.ovn.org/node-chassis-id":                "cb9ec8fa-b409-4ef3-9f42-d9283c47aac6",
+				"k8s.ovn.org/node-gateway-router-lrp-ifaddr": `{"ipv4":"100.64.0.2/16"}`,
+				"k8s.ovn.org/node-primary-ifaddr":            `{"ipv4":"192.168.126.12/24"}`,
+				"k8s.ovn.org/node-subnets":                   `{"blue":"10.10.1.0/24"}`,
+				"k8s.ovn.org/network-ids":                    `{"blue":"3"}`,
+			},
+		},
+	}
+	namespace := &kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{"name": "ns"}}}
+	pod := &kapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "ns",
+			Labels:    map[string]string{"app": "eip"},
+			Annotations: map[string]string{
+				"k8s.v1.cni.cncf.io/networks": `[{"name":"blue","namespace":"ns"}]`,
+				"k8s.ovn.org/pod-networks": `{"ns/blue":{"ip_addresses":["10.10.1.5/24"],` +
+					`"mac_address":"0a:58:0a:0a:01:05"}}`,
+			},
+		},
+		Spec:   kapi.PodSpec{NodeName: node.Name},
+		Status: kapi.PodStatus{Phase: kapi.PodRunning},
+	}
+	eIP := &egressipv1.EgressIP{
+		ObjectMeta: metav1.ObjectMeta{Name: "eip"},
+		Spec: egressipv1.EgressIPSpec{
+			EgressIPs:         []string{"192.168.126.100"},
+			NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"name": "ns"}},
+			PodSelector:       metav1.LabelSelector{MatchLabels: map[string]string{"app": "eip"}},
+		},
+		Status: egressipv1.EgressIPStatus{
+			Items: []egressipv1.EgressIPStatusItem{{Node: node.Name, EgressIP: "192.168.126.100"}},
+		},
+	}
+
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+	wf, err := factory.NewOVNKubeControllerWatchFactory(&util.OVNKubeControllerClientset{
+		KubeClient:     fake.NewSimpleClientset(node, namespace, pod),
+		EgressIPClient: egressipfake.NewSimpleClientset(eIP),
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(wf.Start()).To(gomega.Succeed())
+	t.Cleanup(wf.Shutdown)
+	localZoneNodes := &sync.Map{}
+	localZoneNodes.Store(node.Name, true)
+	oc := &BaseSecondaryNetworkController{
+		BaseNetworkController: BaseNetworkController{
+			CommonNetworkControllerInfo: CommonNetworkControllerInfo{
+				nbClient:     nbClient,
+				watchFactory: wf,
+				zone:         types.OvnDefaultZone,
+			},
+			NetInfo:        netInfo,
+			localZoneNodes: localZoneNodes,
+		},
+	}
+
+	_, err = oc.createOvnClusterRouter()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(oc.ensureUDNJoinSwitch()).To(gomega.Succeed())
+	g.Expect(oc.ensureUDNNoReroutePolicies()).To(gomega.Succeed())
+	g.Expect(oc.ensureUDNGateway(node, []*net.IPNet{ovntest.MustParseIPNet("10.10.1.0/24")})).To(gomega.Succeed())
+
+	gatewayRouter := &nbdb.LogicalRouter{Name: "blue_" + types.GWRouterPrefix + node.Name}
+	clusterRouter := "blue_" + types.OVNClusterRouter
+
+	// the external port of the gateway router has the MAC address of the
+	// network, not the one of the shared gateway bridge
+	externalPort, err := libovsdbops.GetLogicalRouterPort(nbClient, &nbdb.LogicalRouterPort{
+		Name: "blue_" + types.GWRouterToExtSwitchPrefix + types.GWRouterPrefix + node.Name,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(externalPort.MAC).To(gomega.Equal("0a:5a:00:00:00:03"))
+	g.Expect(externalPort.Networks).To(gomega.Equal([]string{"192.168.126.12/24"}))
+	getNATs := func() map[string]string {
+		nats, err := libovsdbops.GetRouterNATs(nbClient, gatewayRouter)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		found := map[string]string{}
+		for _, nat := range nats {
+			g.Expect(nat.Type).To(gomega.Equal(nbdb.NATTypeSNAT))
+			found[nat.LogicalIP] = nat.ExternalIP
+		}
+		return found
+	}
+	getReroutes := func() map[string][]string {
+		policies, err := libovsdbops.FindLogicalRouterPoliciesWithPredicate(nbClient,
+			func(item *nbdb.LogicalRouterPolicy) bool {
+				return item.Priority == types.EgressIPReroutePriority
+			})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		found := map[string][]string{}
+		for _, policy := range policies {
+			g.Expect(policy.ExternalIDs).To(gomega.HaveKeyWithValue(types.NetworkExternalID, "blue"))
+			found[policy.Match] = policy.Nexthops
+		}
+		return found
+	}
+
+	// the traffic of the network is SNATed to the node IP by default
+	g.Expect(getNATs()).To(gomega.Equal(map[string]string{"10.10.0.0/16": "192.168.126.12"}))
+	router, err := libovsdbops.GetLogicalRouter(nbClient, &nbdb.LogicalRouter{Name: clusterRouter})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(router.Policies).To(gomega.HaveLen(2))
+
+	// the traffic of the selected pod is rerouted to the gateway router of the
+	// egress node, which SNATs it to the egress IP, twice to check nothing is
+	// duplicated
+	for i := 0; i < 2; i++ {
+		g.Expect(oc.ensureUDNEgressIP(eIP.Name)).To(gomega.Succeed())
+		g.Expect(getNATs()).To(gomega.Equal(map[string]string{
+			"10.10.0.0/16": "192.168.126.12",
+			"10.10.1.5":    "192.168.126.100",
+		}))
+		g.Expect(getReroutes()).To(gomega.Equal(map[string][]string{"ip4.src == 10.10.1.5": {"100.64.0.2"}}))
+	}
+
+	// the egress IP entities of the EgressIPs deleted while down are removed
+	g.Expect(oc.syncUDNEgressIPs(nil)).To(gomega.Succeed())
+	g.Expect(getNATs()).To(gomega.Equal(map[string]string{"10.10.0.0/16": "192.168.126.12"}))
+	g.Expect(getReroutes()).To(gomega.BeEmpty())
+
+	// removing the gateway of the node removes its routes on the cluster router
+	g.Expect(oc.ensureUDNEgressIP(eIP.Name)).To(gomega.Succeed())
+	g.Expect(oc.deleteUDNGateway(node.Name)).To(gomega.Succeed())
+	_, err = libovsdbops.GetLogicalRouter(nbClient, gatewayRouter)
+	g.Expect(err).To(gomega.HaveOccurred())
+	router, err = libovsdbops.GetLogicalRouter(nbClient, &nbdb.LogicalRouter{Name: clusterRouter})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(router.StaticRoutes).To(gomega.BeEmpty())
+}
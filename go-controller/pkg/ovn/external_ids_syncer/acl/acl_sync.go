@@ -46,15 +46,19 @@ const (
 type aclSyncer struct {
 	nbClient       libovsdbclient.Client
 	controllerName string
+	// nodeNetworkStates gets the node network state holding the host subnets
+	// of the nodes with the "crd" backend
+	nodeNetworkStates util.NodeNetworkStateGetter
 	// txnBatchSize is used to control how many acls will be updated with 1 db transaction.
 	txnBatchSize int
 }
 
 // controllerName is the name of the new controller that should own all acls without controller
-func NewACLSyncer(nbClient libovsdbclient.Client, controllerName string) *aclSyncer {
+func NewACLSyncer(nbClient libovsdbclient.Client, controllerName string, nodeNetworkStates util.NodeNetworkStateGetter) *aclSyncer {
 	return &aclSyncer{
-		nbClient:       nbClient,
-		controllerName: controllerName,
+		nbClient:          nbClient,
+		controllerName:    controllerName,
+		nodeNetworkStates: nodeNetworkStates,
 		// create time (which is the upper bound of how much time an update can take) for 20K ACLs
 		// (gress ACL were used for testing as the ones that have the biggest number of ExternalIDs)
 		// is ~4 sec, which is safe enough to not exceed 10 sec transaction timeout.
@@ -130,9 +134,10 @@ func (syncer *aclSyncer) SyncACLs(existingNodes *v1.NodeList) error {
 		}
 
 		// update acls with new ExternalIDs
-		err = batching.Batch[*nbdb.ACL](syncer.txnBatchSize, uniquePrimaryIDACLs, func(batchACLs []*nbdb.ACL) error {
-			return libovsdbops.CreateOrUpdateACLs(syncer.nbClient, batchACLs...)
-		})
+		err = batching.RolloutBatches[*nbdb.ACL](batching.NewRollout(syncer.txnBatchSize), "ACL external IDs",
+			uniquePrimaryIDACLs, func(batchACLs []*nbdb.ACL) error {
+				return libovsdbops.CreateOrUpdateACLs(syncer.nbClient, batchACLs...)
+			})
 		if err != nil {
 			return fmt.Errorf("cannot update stale ACLs: %v", err)
 		}
@@ -165,9 +170,10 @@ func (syncer *aclSyncer) SyncACLs(existingNodes *v1.NodeList) error {
 			acl.Tier = types.DefaultACLTier // move tier to 2
 		}
 		// batch ACLs together in order of their priority: lowest first and then highest
-		err = batching.Batch[*nbdb.ACL](syncer.txnBatchSize, aclsInTier0, func(batchACLs []*nbdb.ACL) error {
-			return libovsdbops.CreateOrUpdateACLs(syncer.nbClient, batchACLs...)
-		})
+		err = batching.RolloutBatches[*nbdb.ACL](batching.NewRollout(syncer.txnBatchSize), "ACL tier",
+			aclsInTier0, func(batchACLs []*nbdb.ACL) error {
+				return libovsdbops.CreateOrUpdateACLs(syncer.nbClient, batchACLs...)
+			})
 		if err != nil {
 			return fmt.Errorf("cannot update ACLs to tier2: %v", err)
 		}
@@ -271,7 +277,7 @@ func (syncer *aclSyncer) updateStaleNetpolNodeACLs(legacyACLs []*nbdb.ACL, exist
 	}
 	matchToNode := map[string]aclInfo{}
 	for _, node := range existingNodes {
-		hostSubnets, err := util.ParseNodeHostSubnetAnnotation(&node, types.DefaultNetworkName)
+		hostSubnets, err := util.ParseNodeHostSubnets(&node, types.DefaultNetworkName, syncer.nodeNetworkStates)
 		if err != nil {
 			klog.Warningf("Couldn't parse hostSubnet annotation for node %s: %v", node.Name, err)
 			continue
@@ -7,6 +7,7 @@ import (
 
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
 	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
@@ -60,7 +61,7 @@ func testSyncerWithData(data []aclSync, controllerName string, initialDbState, f
 		expectedDbState = append(expectedDbState, acl)
 	}
 	// run sync
-	syncer := NewACLSyncer(libovsdbOvnNBClient, controllerName)
+	syncer := NewACLSyncer(libovsdbOvnNBClient, controllerName, nil)
 	err = syncer.SyncACLs(&v1.NodeList{Items: existingNodes})
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	// check results
@@ -299,6 +300,45 @@ var _ = ginkgo.Describe("OVN ACL Syncer", func() {
 			}}}
 		testSyncerWithData(testData, controllerName, []libovsdbtest.TestData{}, nil, existingNodes)
 	})
+	ginkgo.It("rolls out the updates of the acls in batches", func() {
+		gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		defer func() {
+			gomega.Expect(config.PrepareTestConfig()).To(gomega.Succeed())
+		}()
+		config.OVNKubernetesFeature.NBRolloutBatchSize = 1
+		config.OVNKubernetesFeature.NBRolloutBatchInterval = 1
+
+		nodeName := "node1"
+		testData := []aclSync{}
+		existingNodes := []v1.Node{}
+		for _, mgmtIP := range []string{"10.244.0.2", "10.244.1.2", "10.244.2.2"} {
+			testData = append(testData, aclSync{
+				before: libovsdbops.BuildACL(
+					"",
+					nbdb.ACLDirectionToLport,
+					types.DefaultAllowPriority,
+					"ip4.src=="+mgmtIP,
+					nbdb.ACLActionAllowRelated,
+					types.OvnACLLoggingMeter,
+					"",
+					false,
+					nil,
+					nil,
+					types.PlaceHolderACLTier,
+				),
+				after: syncerToBuildData.getAllowFromNodeACLDbIDs(nodeName, mgmtIP),
+			})
+		}
+		hostSubnets := map[string][]string{types.DefaultNetworkName: {"10.244.0.0/24", "10.244.1.0/24", "10.244.2.0/24"}}
+		bytes, err := json.Marshal(hostSubnets)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		existingNodes = append(existingNodes, v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        nodeName,
+				Annotations: map[string]string{"k8s.ovn.org/node-subnets": string(bytes)},
+			}})
+		testSyncerWithData(testData, controllerName, []libovsdbtest.TestData{}, nil, existingNodes)
+	})
 	ginkgo.It("updates gress policy acls", func() {
 		policyNamespace := "policyNamespace"
 		policyName := "policyName"
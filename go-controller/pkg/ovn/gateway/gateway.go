@@ -3,6 +3,7 @@ package gateway
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 
 	libovsdbclient "github.com/ovn-org/libovsdb/client"
@@ -10,7 +11,6 @@ import (
 	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
 	libovsdbutil "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/util"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
-	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
@@ -62,10 +62,10 @@ func GetGatewayPhysicalIPs(nbClient libovsdbclient.Client, gatewayRouter string)
 	return nil, fmt.Errorf("no physical IPs found for gateway %s", gatewayRouter)
 }
 
-// CreateDummyGWMacBindings creates mac bindings (ipv4 and ipv6) for a fake next hops
-// used by host->service traffic
-func CreateDummyGWMacBindings(sbClient libovsdbclient.Client, nodeName string) error {
-	for _, nextHop := range node.DummyNextHopIPs() {
+// CreateDummyGWMacBindings creates mac bindings (ipv4 and ipv6) for the fake next hops
+// of the node used by host->service traffic
+func CreateDummyGWMacBindings(sbClient libovsdbclient.Client, nodeName string, dummyNextHops []net.IP) error {
+	for _, nextHop := range dummyNextHops {
 		dummyNextHopMAC := util.IPAddrToHWAddr(nextHop)
 		nodeGWRouter := util.GetGatewayRouterFromNode(nodeName)
 		logicalPort := ovntypes.GWRouterToExtSwitchPrefix + nodeGWRouter
@@ -62,6 +62,9 @@ func (oc *DefaultNetworkController) gatewayCleanup(nodeName string) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete gateway router %s: %v", gatewayRouter, err)
 	}
+	if oc.snatCTZoneAllocator != nil {
+		oc.snatCTZoneAllocator.Release(nodeName, gatewayRouter)
+	}
 
 	// Remove external switch
 	externalSwitch := types.ExternalSwitchPrefix + nodeName
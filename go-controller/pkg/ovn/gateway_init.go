@@ -21,6 +21,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/controller/ctzone"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/gateway"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
@@ -109,6 +110,60 @@ func (oc *DefaultNetworkController) cleanupStalePodSNATs(nodeName string, nodeIP
 	return nil
 }
 
+// getNodeMasqueradeConfig returns the masquerade subnets and IPs of the node,
+// the configured ones unless the node selected others
+func (oc *DefaultNetworkController) getNodeMasqueradeConfig(nodeName string) (string, string, *config.MasqueradeIPsConfig, error) {
+	kNode, err := oc.watchFactory.GetNode(nodeName)
+	if err != nil {
+		klog.V(5).Infof("Using the configured masquerade subnets for node %s: %v", nodeName, err)
+		return config.Gateway.V4MasqueradeSubnet, config.Gateway.V6MasqueradeSubnet, &config.Gateway.MasqueradeIPs, nil
+	}
+	v4MasqueradeSubnet, v6MasqueradeSubnet, err := util.GetNodeMasqueradeSubnets(kNode)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to get the masquerade subnets of node %s: %w", nodeName, err)
+	}
+	masqueradeIPs, err := util.GetNodeMasqueradeIPs(kNode)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to get the masquerade IPs of node %s: %w", nodeName, err)
+	}
+	return v4MasqueradeSubnet, v6MasqueradeSubnet, masqueradeIPs, nil
+}
+
+// deleteStaleMasqueradeRoutes deletes the return service routes to the host of
+// the masquerade subnets the node doesn't use anymore, from the masquerade
+// subnet pools
+func (oc *DefaultNetworkController) deleteStaleMasqueradeRoutes(gatewayRouter, externalRouterPort, v4MasqueradeSubnet,
+	v6MasqueradeSubnet string) error {
+	var pools []*net.IPNet
+	for _, pool := range []string{config.Gateway.V4MasqueradeSubnetPool, config.Gateway.V6MasqueradeSubnetPool} {
+		if _, cidr, err := net.ParseCIDR(pool); err == nil {
+			pools = append(pools, cidr)
+		}
+	}
+	p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+		if item.OutputPort == nil || *item.OutputPort != externalRouterPort ||
+			item.IPPrefix == v4MasqueradeSubnet || item.IPPrefix == v6MasqueradeSubnet {
+			return false
+		}
+		_, prefix, err := net.ParseCIDR(item.IPPrefix)
+		if err != nil || !prefix.Contains(net.ParseIP(item.Nexthop)) {
+			return false
+		}
+		prefixLength, _ := prefix.Mask.Size()
+		for _, pool := range pools {
+			poolLength, _ := pool.Mask.Size()
+			if pool.Contains(prefix.IP) && prefixLength >= poolLength {
+				return true
+			}
+		}
+		return false
+	}
+	if err := libovsdbops.DeleteLogicalRouterStaticRoutesWithPredicate(oc.nbClient, gatewayRouter, p); err != nil {
+		return fmt.Errorf("error deleting the stale masquerade routes of GR %s: %v", gatewayRouter, err)
+	}
+	return nil
+}
+
 // gatewayInit creates a gateway router for the local chassis.
 // enableGatewayMTU enables options:gateway_mtu for gateway routers.
 func (oc *DefaultNetworkController) gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*net.IPNet,
@@ -158,6 +213,19 @@ func (oc *DefaultNetworkController) gatewayInit(nodeName string, clusterIPSubnet
 		return fmt.Errorf("failed in retrieving %s, error: %v", gatewayRouter, err)
 	}
 
+	if oc.snatCTZoneAllocator != nil {
+		// keep the zone the gateway router already has if it is still free
+		requested := -1
+		if oldLogicalRouter != nil {
+			requested = ctzone.GetSNATZone(oldLogicalRouter)
+		}
+		zone, err := oc.snatCTZoneAllocator.Allocate(nodeName, gatewayRouter, requested)
+		if err != nil {
+			return fmt.Errorf("failed to allocate the SNAT conntrack zone of %s: %v", gatewayRouter, err)
+		}
+		ctzone.SetSNATZone(&logicalRouter, zone)
+	}
+
 	if oldLogicalRouter != nil && oldLogicalRouter.ExternalIDs != nil {
 		if physicalIPs, ok := oldLogicalRouter.ExternalIDs["physical_ips"]; ok {
 			oldExternalIPs := strings.Split(physicalIPs, ",")
@@ -286,15 +354,26 @@ func (oc *DefaultNetworkController) gatewayInit(nodeName string, clusterIPSubnet
 
 	nextHops := l3GatewayConfig.NextHops
 
-	if err := gateway.CreateDummyGWMacBindings(oc.sbClient, nodeName); err != nil {
+	// the node may have selected other masquerade subnets than the configured ones
+	v4MasqueradeSubnet, v6MasqueradeSubnet, masqueradeIPs, err := oc.getNodeMasqueradeConfig(nodeName)
+	if err != nil {
+		return err
+	}
+	dummyNextHops := node.MasqueradeDummyNextHopIPs(masqueradeIPs)
+
+	if err := gateway.CreateDummyGWMacBindings(oc.sbClient, nodeName, dummyNextHops); err != nil {
+		return err
+	}
+
+	if err := oc.deleteStaleMasqueradeRoutes(gatewayRouter, externalRouterPort, v4MasqueradeSubnet, v6MasqueradeSubnet); err != nil {
 		return err
 	}
 
-	for _, nextHop := range node.DummyNextHopIPs() {
+	for _, nextHop := range dummyNextHops {
 		// Add return service route for OVN back to host
-		prefix := config.Gateway.V4MasqueradeSubnet
+		prefix := v4MasqueradeSubnet
 		if utilnet.IsIPv6(nextHop) {
-			prefix = config.Gateway.V6MasqueradeSubnet
+			prefix = v6MasqueradeSubnet
 		}
 		lrsr := nbdb.LogicalRouterStaticRoute{
 			IPPrefix:   prefix,
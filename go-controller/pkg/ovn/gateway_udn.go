@@ -0,0 +1,626 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// A primary user defined network gets, like the default network, a gateway
+// router on each local zone node, connected to the cluster router of the
+// network through the join switch of the network and to the physical network
+// through an external switch. The networks being isolated from each other, the
+// join IPs of the gateway routers and of the cluster router are the ones of the
+// default network, and the external IPs are the ones of the node. The external
+// port of the gateway router has the MAC address of the network ID though, for
+// the shared gateway bridge of the node to steer the traffic of its localnet
+// port back to it, see the udnGateway of pkg/node.
+//
+// The traffic of the pods leaving the cluster is routed to the gateway router
+// of their node, by a src-ip static route of the host subnet of the node on the
+// layer3 topology, or of the pod IP on the layer2 topology, and SNATed to the
+// node IP. On the layer2 topology the cluster router of the network is
+// connected to the switch of the network with the gateway IPs of its subnets,
+// and is local to each zone.
+
+// getUDNGatewayRouterName returns the name of the gateway router of the network
+// on the node
+func (bsnc *BaseSecondaryNetworkController) getUDNGatewayRouterName(nodeName string) string {
+	return bsnc.GetNetworkScopedName(types.GWRouterPrefix + nodeName)
+}
+
+// isUDNGatewayRouter returns true for the gateway routers of the network
+func (bsnc *BaseSecondaryNetworkController) isUDNGatewayRouter(item *nbdb.LogicalRouter) bool {
+	return item.ExternalIDs[types.NetworkExternalID] == bsnc.GetNetworkName() &&
+		strings.HasPrefix(item.Name, bsnc.GetNetworkScopedName(types.GWRouterPrefix))
+}
+
+// getUDNExternalIDs returns the external IDs of the logical entities of the
+// gateways of the network
+func (bsnc *BaseSecondaryNetworkController) getUDNExternalIDs() map[string]string {
+	return map[string]string{
+		types.NetworkExternalID:  bsnc.GetNetworkName(),
+		types.TopologyExternalID: bsnc.TopologyType(),
+	}
+}
+
+// matchNetworkIPFamilies returns the given addresses of the IP families of the
+// network
+func (bsnc *BaseSecondaryNetworkController) matchNetworkIPFamilies(ipNets []*net.IPNet) []*net.IPNet {
+	ipv4Mode, ipv6Mode := bsnc.IPMode()
+	var matched []*net.IPNet
+	for _, ipNet := range ipNets {
+		if utilnet.IsIPv6CIDR(ipNet) && ipv6Mode || !utilnet.IsIPv6CIDR(ipNet) && ipv4Mode {
+			matched = append(matched, ipNet)
+		}
+	}
+	return matched
+}
+
+// getUDNClusterRouterJoinIfAddrs returns the join IPs of the cluster router of
+// the network, the first IPs of the join subnets
+func (bsnc *BaseSecondaryNetworkController) getUDNClusterRouterJoinIfAddrs() ([]*net.IPNet, error) {
+	ipv4Mode, ipv6Mode := bsnc.IPMode()
+	var joinSubnetsConfig []string
+	if ipv4Mode {
+		joinSubnetsConfig = append(joinSubnetsConfig, config.Gateway.V4JoinSubnet)
+	}
+	if ipv6Mode {
+		joinSubnetsConfig = append(joinSubnetsConfig, config.Gateway.V6JoinSubnet)
+	}
+	var ifAddrs []*net.IPNet
+	for _, joinSubnetString := range joinSubnetsConfig {
+		_, joinSubnet, err := net.ParseCIDR(joinSubnetString)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing join subnet string %s: %v", joinSubnetString, err)
+		}
+		ifAddrs = append(ifAddrs, &net.IPNet{
+			IP:   utilnet.AddIPOffset(utilnet.BigForIP(joinSubnet.IP), 1),
+			Mask: joinSubnet.Mask,
+		})
+	}
+	return ifAddrs, nil
+}
+
+// getUDNGatewayRouterJoinIfAddrs returns the join IPs of the gateway router of
+// the network on the node
+func (bsnc *BaseSecondaryNetworkController) getUDNGatewayRouterJoinIfAddrs(node *kapi.Node) ([]*net.IPNet, error) {
+	gwLRPIfAddrs, err := util.ParseNodeGatewayRouterLRPAddrs(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join switch port IP address for node %s: %w", node.Name, err)
+	}
+	gwLRPIfAddrs = bsnc.matchNetworkIPFamilies(gwLRPIfAddrs)
+	if len(gwLRPIfAddrs) == 0 {
+		return nil, fmt.Errorf("node %s has no join switch port IP address of the IP families of network %s",
+			node.Name, bsnc.GetNetworkName())
+	}
+	return gwLRPIfAddrs, nil
+}
+
+// ensureUDNJoinSwitch creates the join switch of the network and connects it to
+// the cluster router of the network
+func (bsnc *BaseSecondaryNetworkController) ensureUDNJoinSwitch() error {
+	joinSwitch := nbdb.LogicalSwitch{
+		Name:        bsnc.GetNetworkScopedName(types.OVNJoinSwitch),
+		ExternalIDs: bsnc.getUDNExternalIDs(),
+	}
+	if err := libovsdbops.CreateOrUpdateLogicalSwitch(bsnc.nbClient, &joinSwitch, &joinSwitch.ExternalIDs); err != nil {
+		return fmt.Errorf("failed to create logical switch %+v: %v", joinSwitch, err)
+	}
+
+	drLRPIfAddrs, err := bsnc.getUDNClusterRouterJoinIfAddrs()
+	if err != nil {
+		return err
+	}
+	drRouterPort := bsnc.GetNetworkScopedName(types.GWRouterToJoinSwitchPrefix + types.OVNClusterRouter)
+	logicalRouterPort := nbdb.LogicalRouterPort{
+		Name:     drRouterPort,
+		MAC:      util.IPAddrToHWAddr(drLRPIfAddrs[0].IP).String(),
+		Networks: ipNetsToStrings(drLRPIfAddrs),
+	}
+	logicalRouter := nbdb.LogicalRouter{Name: bsnc.GetNetworkScopedName(types.OVNClusterRouter)}
+	err = libovsdbops.CreateOrUpdateLogicalRouterPort(bsnc.nbClient, &logicalRouter, &logicalRouterPort, nil,
+		&logicalRouterPort.MAC, &logicalRouterPort.Networks)
+	if err != nil {
+		return fmt.Errorf("failed to add logical router port %+v on router %s: %v", logicalRouterPort,
+			logicalRouter.Name, err)
+	}
+
+	logicalSwitchPort := nbdb.LogicalSwitchPort{
+		Name: bsnc.GetNetworkScopedName(types.JoinSwitchToGWRouterPrefix + types.OVNClusterRouter),
+		Type: "router",
+		Options: map[string]string{
+			"router-port": drRouterPort,
+		},
+		Addresses: []string{"router"},
+	}
+	if err := libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitch(bsnc.nbClient, &joinSwitch, &logicalSwitchPort); err != nil {
+		return fmt.Errorf("failed to create logical switch port %+v on switch %s: %v", logicalSwitchPort,
+			joinSwitch.Name, err)
+	}
+	return nil
+}
+
+// ensureUDNLayer2ClusterRouterPort connects the cluster router of the layer2
+// network to its switch with the gateway IPs of its subnets. The port of the
+// switch has the same reserved tunnel key in every zone, each zone routing the
+// traffic of its pods.
+func (bsnc *BaseSecondaryNetworkController) ensureUDNLayer2ClusterRouterPort() error {
+	var lrpIfAddrs []*net.IPNet
+	for _, subnet := range bsnc.Subnets() {
+		lrpIfAddrs = append(lrpIfAddrs, util.GetNodeGatewayIfAddr(subnet.CIDR))
+	}
+	// logical router port MAC is based on IPv4 subnet if there is one, else IPv6
+	var lrpMAC net.HardwareAddr
+	for _, lrpIfAddr := range lrpIfAddrs {
+		lrpMAC = util.IPAddrToHWAddr(lrpIfAddr.IP)
+		if !utilnet.IsIPv6CIDR(lrpIfAddr) {
+			break
+		}
+	}
+
+	switchName := bsnc.GetNetworkScopedName(types.OVNLayer2Switch)
+	routerPort := bsnc.GetNetworkScopedName(types.RouterToSwitchPrefix + types.OVNLayer2Switch)
+	logicalRouterPort := nbdb.LogicalRouterPort{
+		Name:     routerPort,
+		MAC:      lrpMAC.String(),
+		Networks: ipNetsToStrings(lrpIfAddrs),
+	}
+	logicalRouter := nbdb.LogicalRouter{Name: bsnc.GetNetworkScopedName(types.OVNClusterRouter)}
+	err := libovsdbops.CreateOrUpdateLogicalRouterPort(bsnc.nbClient, &logicalRouter, &logicalRouterPort, nil,
+		&logicalRouterPort.MAC, &logicalRouterPort.Networks)
+	if err != nil {
+		return fmt.Errorf("failed to add logical router port %+v on router %s: %v", logicalRouterPort,
+			logicalRouter.Name, err)
+	}
+
+	logicalSwitchPort := nbdb.LogicalSwitchPort{
+		Name: bsnc.GetNetworkScopedName(types.SwitchToRouterPrefix + types.OVNLayer2Switch),
+		Type: "router",
+		Options: map[string]string{
+			"router-port": routerPort,
+		},
+		Addresses: []string{"router"},
+	}
+	if config.OVNKubernetesFeature.EnableInterconnect {
+		logicalSwitchPort.Options["requested-tnl-key"] = strconv.Itoa(types.Layer2RouterPortTunnelKey)
+	}
+	sw := nbdb.LogicalSwitch{Name: switchName}
+	if err := libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitch(bsnc.nbClient, &sw, &logicalSwitchPort); err != nil {
+		return fmt.Errorf("failed to create logical switch port %+v on switch %s: %v", logicalSwitchPort,
+			switchName, err)
+	}
+	return nil
+}
+
+// ensureUDNGateway creates or updates the gateway router of the network on the
+// local zone node, with the host subnets of the node on the layer3 topology.
+func (bsnc *BaseSecondaryNetworkController) ensureUDNGateway(node *kapi.Node, hostSubnets []*net.IPNet) error {
+	l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(node)
+	if err != nil {
+		return err
+	}
+	if l3GatewayConfig.Mode == config.GatewayModeDisabled {
+		return bsnc.deleteUDNGateway(node.Name)
+	}
+	gwLRPIfAddrs, err := bsnc.getUDNGatewayRouterJoinIfAddrs(node)
+	if err != nil {
+		return err
+	}
+	drLRPIfAddrs, err := bsnc.getUDNClusterRouterJoinIfAddrs()
+	if err != nil {
+		return err
+	}
+	externalIPs := bsnc.matchNetworkIPFamilies(l3GatewayConfig.IPAddresses)
+	if len(externalIPs) == 0 {
+		return fmt.Errorf("node %s has no gateway IP address of the IP families of network %s", node.Name,
+			bsnc.GetNetworkName())
+	}
+	networkID, err := util.ParseNetworkIDAnnotation(node, bsnc.GetNetworkName())
+	if err != nil {
+		return fmt.Errorf("failed to get the ID of network %s on node %s: %w", bsnc.GetNetworkName(), node.Name, err)
+	}
+	defaultCOPPUUID, err := EnsureDefaultCOPP(bsnc.nbClient)
+	if err != nil {
+		return fmt.Errorf("unable to create router control plane protection: %w", err)
+	}
+
+	gatewayRouter := bsnc.getUDNGatewayRouterName(node.Name)
+	physicalIPs := make([]string, 0, len(externalIPs))
+	for _, ip := range externalIPs {
+		physicalIPs = append(physicalIPs, ip.IP.String())
+	}
+	logicalRouter := nbdb.LogicalRouter{
+		Name: gatewayRouter,
+		Options: map[string]string{
+			"always_learn_from_arp_request": "false",
+			"dynamic_neigh_routers":         "true",
+			"chassis":                       l3GatewayConfig.ChassisID,
+		},
+		ExternalIDs: bsnc.getUDNExternalIDs(),
+		Copp:        &defaultCOPPUUID,
+	}
+	logicalRouter.ExternalIDs["physical_ip"] = physicalIPs[0]
+	logicalRouter.ExternalIDs["physical_ips"] = strings.Join(physicalIPs, ",")
+	err = libovsdbops.CreateOrUpdateLogicalRouter(bsnc.nbClient, &logicalRouter, &logicalRouter.Options,
+		&logicalRouter.ExternalIDs, &logicalRouter.Copp)
+	if err != nil {
+		return fmt.Errorf("failed to create logical router %+v: %v", logicalRouter, err)
+	}
+
+	// connect the gateway router to the join switch
+	gwRouterPort := bsnc.GetNetworkScopedName(types.GWRouterToJoinSwitchPrefix + types.GWRouterPrefix + node.Name)
+	logicalSwitchPort := nbdb.LogicalSwitchPort{
+		Name:      bsnc.GetNetworkScopedName(types.JoinSwitchToGWRouterPrefix + types.GWRouterPrefix + node.Name),
+		Type:      "router",
+		Addresses: []string{"router"},
+		Options: map[string]string{
+			"router-port": gwRouterPort,
+		},
+	}
+	joinSwitch := nbdb.LogicalSwitch{Name: bsnc.GetNetworkScopedName(types.OVNJoinSwitch)}
+	if err := libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitch(bsnc.nbClient, &joinSwitch, &logicalSwitchPort); err != nil {
+		return fmt.Errorf("failed to create port %v on logical switch %q: %v", logicalSwitchPort.Name,
+			joinSwitch.Name, err)
+	}
+	logicalRouterPort := nbdb.LogicalRouterPort{
+		Name:     gwRouterPort,
+		MAC:      util.IPAddrToHWAddr(gwLRPIfAddrs[0].IP).String(),
+		Networks: ipNetsToStrings(gwLRPIfAddrs),
+	}
+	err = libovsdbops.CreateOrUpdateLogicalRouterPort(bsnc.nbClient, &logicalRouter, &logicalRouterPort, nil,
+		&logicalRouterPort.MAC, &logicalRouterPort.Networks)
+	if err != nil {
+		return fmt.Errorf("failed to create port %+v on router %s: %v", logicalRouterPort, gatewayRouter, err)
+	}
+
+	// route the subnets of the network to the cluster router
+	for _, subnet := range bsnc.Subnets() {
+		drLRPIfAddr, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6CIDR(subnet.CIDR), drLRPIfAddrs)
+		if err != nil {
+			return fmt.Errorf("failed to add a static route in GR %s with distributed router as the nexthop: %v",
+				gatewayRouter, err)
+		}
+		lrsr := nbdb.LogicalRouterStaticRoute{
+			IPPrefix: subnet.CIDR.String(),
+			Nexthop:  drLRPIfAddr.IP.String(),
+		}
+		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return item.IPPrefix == lrsr.IPPrefix && libovsdbops.PolicyEqualPredicate(item.Policy, lrsr.Policy)
+		}
+		err = libovsdbops.CreateOrReplaceLogicalRouterStaticRouteWithPredicate(bsnc.nbClient, gatewayRouter, &lrsr, p,
+			&lrsr.Nexthop)
+		if err != nil {
+			return fmt.Errorf("failed to add a static route %+v in GR %s with distributed router as the nexthop: %v",
+				lrsr, gatewayRouter, err)
+		}
+	}
+
+	externalMAC := util.UDNGatewayRouterHWAddr(networkID)
+	if err := bsnc.addUDNExternalSwitch(node.Name, gatewayRouter, l3GatewayConfig, externalMAC, externalIPs); err != nil {
+		return err
+	}
+
+	// add the default routes to the next hops of the node
+	externalRouterPort := bsnc.GetNetworkScopedName(types.GWRouterToExtSwitchPrefix + types.GWRouterPrefix + node.Name)
+	for _, nextHop := range l3GatewayConfig.NextHops {
+		if len(util.MatchAllIPNetFamily(utilnet.IsIPv6(nextHop), externalIPs)) == 0 {
+			continue
+		}
+		allIPs := "0.0.0.0/0"
+		if utilnet.IsIPv6(nextHop) {
+			allIPs = "::/0"
+		}
+		lrsr := nbdb.LogicalRouterStaticRoute{
+			IPPrefix:   allIPs,
+			Nexthop:    nextHop.String(),
+			OutputPort: &externalRouterPort,
+		}
+		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return item.OutputPort != nil && *item.OutputPort == *lrsr.OutputPort && item.IPPrefix == lrsr.IPPrefix &&
+				libovsdbops.PolicyEqualPredicate(lrsr.Policy, item.Policy)
+		}
+		err := libovsdbops.CreateOrReplaceLogicalRouterStaticRouteWithPredicate(bsnc.nbClient, gatewayRouter, &lrsr,
+			p, &lrsr.Nexthop)
+		if err != nil {
+			return fmt.Errorf("error creating static route %+v in GR %s: %v", lrsr, gatewayRouter, err)
+		}
+	}
+
+	// route the traffic of the host subnets leaving the cluster to the gateway
+	// router of the node
+	clusterRouter := bsnc.GetNetworkScopedName(types.OVNClusterRouter)
+	for _, hostSubnet := range hostSubnets {
+		gwLRPIfAddr, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6CIDR(hostSubnet), gwLRPIfAddrs)
+		if err != nil {
+			return fmt.Errorf("failed to add source IP address based routes in distributed router %s: %v",
+				clusterRouter, err)
+		}
+		lrsr := nbdb.LogicalRouterStaticRoute{
+			Policy:   &nbdb.LogicalRouterStaticRoutePolicySrcIP,
+			IPPrefix: hostSubnet.String(),
+			Nexthop:  gwLRPIfAddr.IP.String(),
+		}
+		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return item.IPPrefix == lrsr.IPPrefix && libovsdbops.PolicyEqualPredicate(lrsr.Policy, item.Policy)
+		}
+		err = libovsdbops.CreateOrReplaceLogicalRouterStaticRouteWithPredicate(bsnc.nbClient, clusterRouter, &lrsr, p,
+			&lrsr.Nexthop)
+		if err != nil {
+			return fmt.Errorf("error creating static route %+v in %s: %v", lrsr, clusterRouter, err)
+		}
+	}
+
+	// SNAT the subnets of the network to the node IPs
+	nats := make([]*nbdb.NAT, 0, len(bsnc.Subnets()))
+	for _, subnet := range bsnc.Subnets() {
+		externalIP, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6CIDR(subnet.CIDR), externalIPs)
+		if err != nil {
+			return fmt.Errorf("failed to create default SNAT rules for gateway router %s: %v", gatewayRouter, err)
+		}
+		nats = append(nats, libovsdbops.BuildSNAT(&externalIP.IP, subnet.CIDR, "", bsnc.getUDNExternalIDs()))
+	}
+	if err := libovsdbops.CreateOrUpdateNATs(bsnc.nbClient, &logicalRouter, nats...); err != nil {
+		return fmt.Errorf("failed to update SNAT rule for pod on router %s error: %v", gatewayRouter, err)
+	}
+	return nil
+}
+
+// addUDNExternalSwitch creates the external switch of the gateway router of the
+// network on the node, connected to the physical network
+func (bsnc *BaseSecondaryNetworkController) addUDNExternalSwitch(nodeName, gatewayRouter string,
+	l3GatewayConfig *util.L3GatewayConfig, externalMAC net.HardwareAddr, externalIPs []*net.IPNet) error {
+	externalRouterPort := bsnc.GetNetworkScopedName(types.GWRouterToExtSwitchPrefix + types.GWRouterPrefix + nodeName)
+	externalLogicalRouterPort := nbdb.LogicalRouterPort{
+		Name: externalRouterPort,
+		MAC:  externalMAC.String(),
+		ExternalIDs: map[string]string{
+			"gateway-physical-ip": "yes",
+		},
+		Networks: ipNetsToStrings(externalIPs),
+	}
+	logicalRouter := nbdb.LogicalRouter{Name: gatewayRouter}
+	err := libovsdbops.CreateOrUpdateLogicalRouterPort(bsnc.nbClient, &logicalRouter, &externalLogicalRouterPort, nil,
+		&externalLogicalRouterPort.MAC, &externalLogicalRouterPort.Networks, &externalLogicalRouterPort.ExternalIDs)
+	if err != nil {
+		return fmt.Errorf("failed to add logical router port %+v to router %s: %v", externalLogicalRouterPort,
+			gatewayRouter, err)
+	}
+
+	externalSwitch := nbdb.LogicalSwitch{
+		Name:        bsnc.GetNetworkScopedName(externalSwitchName("", nodeName)),
+		ExternalIDs: bsnc.getUDNExternalIDs(),
+	}
+	if err := libovsdbops.CreateOrUpdateLogicalSwitch(bsnc.nbClient, &externalSwitch, &externalSwitch.ExternalIDs); err != nil {
+		return fmt.Errorf("failed to create logical switch %+v: %v", externalSwitch, err)
+	}
+	externalLogicalSwitchPort := nbdb.LogicalSwitchPort{
+		Name:      bsnc.GetNetworkScopedName(l3GatewayConfig.InterfaceID),
+		Addresses: []string{"unknown"},
+		Type:      "localnet",
+		Options: map[string]string{
+			"network_name": types.PhysicalNetworkName,
+		},
+	}
+	if l3GatewayConfig.VLANID != nil && *l3GatewayConfig.VLANID != 0 {
+		intVlanID := int(*l3GatewayConfig.VLANID)
+		externalLogicalSwitchPort.TagRequest = &intVlanID
+	}
+	externalLogicalSwitchPortToRouter := nbdb.LogicalSwitchPort{
+		Name: bsnc.GetNetworkScopedName(types.EXTSwitchToGWRouterPrefix + types.GWRouterPrefix + nodeName),
+		Type: "router",
+		Options: map[string]string{
+			"router-port": externalRouterPort,
+		},
+		Addresses: []string{externalMAC.String()},
+	}
+	err = libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitch(bsnc.nbClient, &externalSwitch,
+		&externalLogicalSwitchPort, &externalLogicalSwitchPortToRouter)
+	if err != nil {
+		return fmt.Errorf("failed to create logical switch ports %+v, %+v on switch %s: %v", externalLogicalSwitchPort,
+			externalLogicalSwitchPortToRouter, externalSwitch.Name, err)
+	}
+	return nil
+}
+
+// deleteUDNGateway removes the gateway router of the network on the node, its
+// external switch, its port on the join switch and the routes of the cluster
+// router to it
+func (bsnc *BaseSecondaryNetworkController) deleteUDNGateway(nodeName string) error {
+	gwRouterPort := &nbdb.LogicalRouterPort{
+		Name: bsnc.GetNetworkScopedName(types.GWRouterToJoinSwitchPrefix + types.GWRouterPrefix + nodeName),
+	}
+	gwRouterPort, err := libovsdbops.GetLogicalRouterPort(bsnc.nbClient, gwRouterPort)
+	if err != nil && err != libovsdbclient.ErrNotFound {
+		return fmt.Errorf("failed to get the join port of the gateway router of node %s on network %s: %v",
+			nodeName, bsnc.GetNetworkName(), err)
+	}
+	if gwRouterPort != nil {
+		gwLRPIPs := sets.New[string]()
+		for _, network := range gwRouterPort.Networks {
+			ip, _, err := net.ParseCIDR(network)
+			if err == nil {
+				gwLRPIPs.Insert(ip.String())
+			}
+		}
+		clusterRouter := bsnc.GetNetworkScopedName(types.OVNClusterRouter)
+		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return gwLRPIPs.Has(item.Nexthop)
+		}
+		if err := libovsdbops.DeleteLogicalRouterStaticRoutesWithPredicate(bsnc.nbClient, clusterRouter, p); err != nil {
+			return fmt.Errorf("failed to delete the routes to the gateway router of node %s on network %s: %v",
+				nodeName, bsnc.GetNetworkName(), err)
+		}
+	}
+
+	joinSwitch := nbdb.LogicalSwitch{Name: bsnc.GetNetworkScopedName(types.OVNJoinSwitch)}
+	joinSwitchPort := nbdb.LogicalSwitchPort{
+		Name: bsnc.GetNetworkScopedName(types.JoinSwitchToGWRouterPrefix + types.GWRouterPrefix + nodeName),
+	}
+	err = libovsdbops.DeleteLogicalSwitchPorts(bsnc.nbClient, &joinSwitch, &joinSwitchPort)
+	if err != nil && err != libovsdbclient.ErrNotFound {
+		return fmt.Errorf("failed to delete the join port of the gateway router of node %s on network %s: %v",
+			nodeName, bsnc.GetNetworkName(), err)
+	}
+
+	externalSwitch := bsnc.GetNetworkScopedName(externalSwitchName("", nodeName))
+	if err := libovsdbops.DeleteLogicalSwitch(bsnc.nbClient, externalSwitch); err != nil && err != libovsdbclient.ErrNotFound {
+		return fmt.Errorf("failed to delete external switch %s: %v", externalSwitch, err)
+	}
+
+	gatewayRouter := nbdb.LogicalRouter{Name: bsnc.getUDNGatewayRouterName(nodeName)}
+	if err := libovsdbops.DeleteLogicalRouter(bsnc.nbClient, &gatewayRouter); err != nil && err != libovsdbclient.ErrNotFound {
+		return fmt.Errorf("failed to delete gateway router %s: %v", gatewayRouter.Name, err)
+	}
+	return nil
+}
+
+// deleteStaleUDNGateways removes the gateway routers of the network of the
+// nodes which are no longer local zone nodes
+func (bsnc *BaseSecondaryNetworkController) deleteStaleUDNGateways(localNodes sets.Set[string]) error {
+	gatewayRouters, err := libovsdbops.FindLogicalRoutersWithPredicate(bsnc.nbClient, bsnc.isUDNGatewayRouter)
+	if err != nil {
+		return fmt.Errorf("failed to find the gateway routers of network %s: %v", bsnc.GetNetworkName(), err)
+	}
+	for _, gatewayRouter := range gatewayRouters {
+		nodeName := strings.TrimPrefix(gatewayRouter.Name, bsnc.GetNetworkScopedName(types.GWRouterPrefix))
+		if localNodes.Has(nodeName) {
+			continue
+		}
+		klog.Infof("Deleting the stale gateway router %s of network %s", gatewayRouter.Name, bsnc.GetNetworkName())
+		if err := bsnc.deleteUDNGateway(nodeName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureUDNLayer2PodEgressRoutes routes the traffic of the local pod IPs
+// leaving the cluster to the gateway router of the node of the pod
+func (bsnc *BaseSecondaryNetworkController) ensureUDNLayer2PodEgressRoutes(pod *kapi.Pod, podIPs []*net.IPNet) error {
+	node, err := bsnc.watchFactory.GetNode(pod.Spec.NodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", pod.Spec.NodeName, err)
+	}
+	gwLRPIfAddrs, err := bsnc.getUDNGatewayRouterJoinIfAddrs(node)
+	if err != nil {
+		return err
+	}
+	clusterRouter := bsnc.GetNetworkScopedName(types.OVNClusterRouter)
+	for _, podIP := range podIPs {
+		gwLRPIfAddr, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6CIDR(podIP), gwLRPIfAddrs)
+		if err != nil {
+			return err
+		}
+		lrsr := nbdb.LogicalRouterStaticRoute{
+			Policy:   &nbdb.LogicalRouterStaticRoutePolicySrcIP,
+			IPPrefix: podIP.IP.String(),
+			Nexthop:  gwLRPIfAddr.IP.String(),
+		}
+		p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+			return item.IPPrefix == lrsr.IPPrefix && libovsdbops.PolicyEqualPredicate(lrsr.Policy, item.Policy)
+		}
+		if err := libovsdbops.CreateOrReplaceLogicalRouterStaticRouteWithPredicate(bsnc.nbClient, clusterRouter,
+			&lrsr, p, &lrsr.Nexthop); err != nil {
+			return fmt.Errorf("error creating static route %+v in %s: %w", lrsr, clusterRouter, err)
+		}
+	}
+	return nil
+}
+
+// deleteUDNLayer2PodEgressRoutes removes the egress routes of the pod IPs
+func (bsnc *BaseSecondaryNetworkController) deleteUDNLayer2PodEgressRoutes(podIPs []*net.IPNet) error {
+	ips := sets.New[string]()
+	for _, podIP := range podIPs {
+		ips.Insert(podIP.IP.String())
+	}
+	p := func(item *nbdb.LogicalRouterStaticRoute) bool {
+		return item.Policy != nil && *item.Policy == nbdb.LogicalRouterStaticRoutePolicySrcIP && ips.Has(item.IPPrefix)
+	}
+	clusterRouter := bsnc.GetNetworkScopedName(types.OVNClusterRouter)
+	if err := libovsdbops.DeleteLogicalRouterStaticRoutesWithPredicate(bsnc.nbClient, clusterRouter, p); err != nil {
+		return fmt.Errorf("failed to delete the egress routes of %v: %w", sets.List(ips), err)
+	}
+	return nil
+}
+
+// getPodPrimaryNetworkIPs returns the IPs of the pod on the network, nil if the
+// pod is not attached to the network or not annotated yet
+func (bsnc *BaseSecondaryNetworkController) getPodPrimaryNetworkIPs(pod *kapi.Pod) ([]*net.IPNet, error) {
+	on, networkMap, err := util.GetPodNADToNetworkMapping(pod, bsnc.NetInfo)
+	if err != nil || !on {
+		// configuration errors are reported when adding the pod
+		return nil, nil
+	}
+	var podIPs []*net.IPNet
+	for nadName := range networkMap {
+		podAnnotation, err := util.UnmarshalPodAnnotation(pod.Annotations, nadName)
+		if err != nil {
+			if util.IsAnnotationNotSetError(err) {
+				continue
+			}
+			return nil, err
+		}
+		podIPs = append(podIPs, podAnnotation.IPs...)
+	}
+	return podIPs, nil
+}
+
+// ensurePodPrimaryNetworkEgress sets up the egress of the pod on the primary
+// network: its egress routes on the layer2 topology and its egress IPs
+func (bsnc *BaseSecondaryNetworkController) ensurePodPrimaryNetworkEgress(pod *kapi.Pod) error {
+	if !bsnc.IsPrimaryNetwork() || !util.PodScheduled(pod) || util.PodWantsHostNetwork(pod) {
+		return nil
+	}
+	if bsnc.TopologyType() == types.Layer2Topology && bsnc.isPodScheduledinLocalZone(pod) {
+		podIPs, err := bsnc.getPodPrimaryNetworkIPs(pod)
+		if err != nil {
+			return err
+		}
+		if err := bsnc.ensureUDNLayer2PodEgressRoutes(pod, podIPs); err != nil {
+			return err
+		}
+	}
+	return bsnc.ensureUDNEgressIPsForPod(pod)
+}
+
+// deletePodPrimaryNetworkEgress tears down the egress of the pod on the
+// primary network
+func (bsnc *BaseSecondaryNetworkController) deletePodPrimaryNetworkEgress(pod *kapi.Pod) error {
+	if !bsnc.IsPrimaryNetwork() || !util.PodScheduled(pod) || util.PodWantsHostNetwork(pod) {
+		return nil
+	}
+	if bsnc.TopologyType() == types.Layer2Topology {
+		podIPs, err := bsnc.getPodPrimaryNetworkIPs(pod)
+		if err != nil {
+			return err
+		}
+		if err := bsnc.deleteUDNLayer2PodEgressRoutes(podIPs); err != nil {
+			return err
+		}
+	}
+	return bsnc.ensureUDNEgressIPsForPod(pod)
+}
+
+// ipNetsToStrings returns the CIDR notations of the given addresses
+func ipNetsToStrings(ipNets []*net.IPNet) []string {
+	strs := make([]string, 0, len(ipNets))
+	for _, ipNet := range ipNets {
+		strs = append(strs, ipNet.String())
+	}
+	return strs
+}
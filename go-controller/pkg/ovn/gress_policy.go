@@ -26,6 +26,8 @@ const (
 	emptyIdx = -1
 	// emptyProtocol is used to create ACL for gressPolicy that doesn't have port policies hence no protocols
 	emptyProtocol = "None"
+	// ipBlockAddrSetIdx is used to create ACL for the ipBlocks of gressPolicy matched with an address set
+	ipBlockAddrSetIdx = -2
 )
 
 type gressPolicy struct {
@@ -52,6 +54,9 @@ type gressPolicy struct {
 	portPolicies []*portPolicy
 
 	ipBlocks []*knet.IPBlock
+	// ipBlockAddrSetDbIDs is set when the ipBlocks without exceptions are
+	// matched with an address set instead of with an ACL each
+	ipBlockAddrSetDbIDs *libovsdbops.DbObjectIDs
 
 	// set to true for stateless network policies (stateless acls), otherwise set to false
 	isNetPolStateless bool
@@ -239,6 +244,12 @@ func (gp *gressPolicy) getMatchFromIPBlock(lportMatch, l4Match string) []string
 	var matchStrings []string
 	var matchStr, ipVersion string
 	for _, ipBlock := range gp.ipBlocks {
+		if gp.ipBlockAddrSetDbIDs != nil && len(ipBlock.Except) == 0 {
+			// matched with the ipBlocks address set, keep the index of the
+			// other ipBlocks
+			matchStrings = append(matchStrings, "")
+			continue
+		}
 		if utilnet.IsIPv6CIDRString(ipBlock.CIDR) {
 			ipVersion = "ip6"
 		} else {
@@ -260,6 +271,49 @@ func (gp *gressPolicy) getMatchFromIPBlock(lportMatch, l4Match string) []string
 	return matchStrings
 }
 
+// getIPBlockAddrSetCIDRs returns the ipv4 and ipv6 CIDRs of the ipBlocks
+// without exceptions
+func (gp *gressPolicy) getIPBlockAddrSetCIDRs() ([]string, []string) {
+	var v4CIDRs, v6CIDRs []string
+	for _, ipBlock := range gp.ipBlocks {
+		if len(ipBlock.Except) > 0 {
+			continue
+		}
+		if utilnet.IsIPv6CIDRString(ipBlock.CIDR) {
+			v6CIDRs = append(v6CIDRs, ipBlock.CIDR)
+		} else {
+			v4CIDRs = append(v4CIDRs, ipBlock.CIDR)
+		}
+	}
+	return v4CIDRs, v6CIDRs
+}
+
+// getMatchFromIPBlockAddrSet returns the match of the ipBlocks address set,
+// the ipBlocks address set must be used.
+func (gp *gressPolicy) getMatchFromIPBlockAddrSet(lportMatch, l4Match string) string {
+	direction := "dst"
+	if gp.policyType == knet.PolicyTypeIngress {
+		direction = "src"
+	}
+	v4CIDRs, v6CIDRs := gp.getIPBlockAddrSetCIDRs()
+	v4HashName, v6HashName := addressset.GetHashNamesForAS(gp.ipBlockAddrSetDbIDs)
+	var l3Matches []string
+	if len(v4CIDRs) > 0 {
+		l3Matches = append(l3Matches, fmt.Sprintf("ip4.%s == $%s", direction, v4HashName))
+	}
+	if len(v6CIDRs) > 0 {
+		l3Matches = append(l3Matches, fmt.Sprintf("ip6.%s == $%s", direction, v6HashName))
+	}
+	l3Match := l3Matches[0]
+	if len(l3Matches) > 1 {
+		l3Match = fmt.Sprintf("(%s)", strings.Join(l3Matches, " || "))
+	}
+	if l4Match == noneMatch {
+		return fmt.Sprintf("%s && %s", l3Match, lportMatch)
+	}
+	return fmt.Sprintf("%s && %s && %s", l3Match, l4Match, lportMatch)
+}
+
 // addNamespaceAddressSet adds a namespace address set to the gress policy.
 // If the address set is not found in the db, return error.
 // If the address set is already added for this policy, return false, otherwise returns true.
@@ -352,11 +406,20 @@ func (gp *gressPolicy) buildLocalPodACLs(portGroupName string, aclLogging *libov
 			// Add ACL allow rule for IPBlock CIDR
 			ipBlockMatches := gp.getMatchFromIPBlock(lportMatch, l4Match)
 			for ipBlockIdx, ipBlockMatch := range ipBlockMatches {
+				if ipBlockMatch == "" {
+					continue
+				}
 				aclIDs := gp.getNetpolACLDbIDs(ipBlockIdx, protocol)
 				acl := libovsdbutil.BuildACL(aclIDs, types.DefaultAllowPriority, ipBlockMatch, action,
 					aclLogging, gp.aclPipeline)
 				createdACLs = append(createdACLs, acl)
 			}
+			if gp.ipBlockAddrSetDbIDs != nil {
+				aclIDs := gp.getNetpolACLDbIDs(ipBlockAddrSetIdx, protocol)
+				acl := libovsdbutil.BuildACL(aclIDs, types.DefaultAllowPriority,
+					gp.getMatchFromIPBlockAddrSet(lportMatch, l4Match), action, aclLogging, gp.aclPipeline)
+				createdACLs = append(createdACLs, acl)
+			}
 		}
 		// if there are pod/namespace selector, then allow packets from/to that address_set or
 		// if the NetworkPolicyPeer is empty, then allow from all sources or to all destinations.
@@ -418,9 +481,21 @@ func (gp *gressPolicy) getNetpolACLDbIDs(ipBlockIdx int, protocol string) *libov
 			// - for every IPBlock +1 ACL
 			// Therefore unique id for a given gressPolicy is protocol name + IPBlock idx
 			// (protocol will be "None" if no port policy is defined, and empty policy and all
-			// selector-based peers ACLs will have idx=-1)
+			// selector-based peers ACLs will have idx=-1, and the ipBlocks address set ACL idx=-2)
 			libovsdbops.IpBlockIndexKey: strconv.Itoa(ipBlockIdx),
 			// protocol key
 			libovsdbops.PortPolicyProtocolKey: protocol,
 		})
 }
+
+func (gp *gressPolicy) getIPBlockAddrSetDbIDs() *libovsdbops.DbObjectIDs {
+	return libovsdbops.NewDbObjectIDs(libovsdbops.AddressSetNetpolIPBlock, gp.controllerName,
+		map[libovsdbops.ExternalIDKey]string{
+			// policy namespace+name
+			libovsdbops.ObjectNameKey: getACLPolicyKey(gp.policyNamespace, gp.policyName),
+			// egress or ingress
+			libovsdbops.PolicyDirectionKey: string(gp.policyType),
+			// gress rule index
+			libovsdbops.GressIdxKey: strconv.Itoa(gp.idx),
+		})
+}
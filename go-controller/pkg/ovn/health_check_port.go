@@ -0,0 +1,78 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// getNodeHealthCheckGatewayIfAddrs returns the addresses of the node cluster
+// router port in the health check subnets of the node, if any
+func getNodeHealthCheckGatewayIfAddrs(node *kapi.Node) []*net.IPNet {
+	subnets, err := util.ParseNodeHealthCheckSubnets(node)
+	if err != nil {
+		if !util.IsAnnotationNotSetError(err) {
+			klog.Warningf("Failed to get the health check subnets of node %s: %v", node.Name, err)
+		}
+		return nil
+	}
+	gwIfAddrs := make([]*net.IPNet, 0, len(subnets))
+	for _, subnet := range subnets {
+		gwIfAddrs = append(gwIfAddrs, util.GetNodeGatewayIfAddr(subnet))
+	}
+	return gwIfAddrs
+}
+
+// syncNodeHealthCheckPort creates the dedicated health check port of the node
+// on its node switch from the health check subnets of the node, or deletes it
+// if the node has none. Like the management port, the health check port is
+// allowed to reach the pods of the node regardless of the network policies.
+func (oc *DefaultNetworkController) syncNodeHealthCheckPort(node *kapi.Node) error {
+	sw := nbdb.LogicalSwitch{Name: node.Name}
+	logicalSwitchPort := nbdb.LogicalSwitchPort{
+		Name: types.HealthCheckPortPrefix + node.Name,
+	}
+
+	subnets, err := util.ParseNodeHealthCheckSubnets(node)
+	if err != nil {
+		if !util.IsAnnotationNotSetError(err) {
+			return fmt.Errorf("failed to get the health check subnets of node %s: %w", node.Name, err)
+		}
+		if err := libovsdbops.DeleteLogicalSwitchPorts(oc.nbClient, &sw, &logicalSwitchPort); err != nil {
+			return fmt.Errorf("failed to delete the health check port of node %s: %w", node.Name, err)
+		}
+		return nil
+	}
+
+	var addresses string
+	for _, subnet := range subnets {
+		hcIfAddr := util.GetNodeManagementIfAddr(subnet)
+		if addresses == "" {
+			// the MAC address of the port is derived from its first address
+			addresses = util.IPAddrToHWAddr(hcIfAddr.IP).String()
+		}
+		addresses += " " + hcIfAddr.IP.String()
+
+		if err := oc.addAllowACLFromNode(node.Name, hcIfAddr.IP); err != nil {
+			return err
+		}
+	}
+
+	logicalSwitchPort.Addresses = []string{addresses}
+	if err := libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitch(oc.nbClient, &sw, &logicalSwitchPort); err != nil {
+		return fmt.Errorf("failed to create the health check port of node %s: %w", node.Name, err)
+	}
+
+	if err := libovsdbops.AddPortsToPortGroup(oc.nbClient, types.ClusterPortGroupNameBase, logicalSwitchPort.UUID); err != nil {
+		return fmt.Errorf("failed to add the health check port of node %s to the cluster port group: %w", node.Name, err)
+	}
+
+	return nil
+}
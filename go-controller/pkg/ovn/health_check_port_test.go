@@ -0,0 +1,60 @@
+package ovn
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+var _ = ginkgo.Describe("OVN node health check port", func() {
+	var fakeOvn *FakeOVN
+
+	ginkgo.BeforeEach(func() {
+		// Restore global default values before each testcase
+		err := config.PrepareTestConfig()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		fakeOvn = NewFakeOVN(false)
+	})
+
+	ginkgo.AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	getHealthCheckPort := func() (*nbdb.LogicalSwitchPort, error) {
+		return libovsdbops.GetLogicalSwitchPort(fakeOvn.nbClient,
+			&nbdb.LogicalSwitchPort{Name: ovntypes.HealthCheckPortPrefix + "node1"})
+	}
+
+	ginkgo.It("creates and deletes the health check port with the health check subnets of the node", func() {
+		node := newNode("node1", "192.168.126.202")
+		node.Annotations["k8s.ovn.org/node-health-check-subnets"] = `["100.66.0.16/28","fd66:0:0:1::/64"]`
+		fakeOvn.startWithDBSetup(libovsdbtest.TestSetup{NBData: []libovsdbtest.TestData{
+			&nbdb.LogicalSwitch{UUID: "node1-uuid", Name: "node1"},
+			newClusterPortGroup(),
+		}}, &v1.NodeList{Items: []v1.Node{*node}})
+
+		gomega.Expect(fakeOvn.controller.syncNodeHealthCheckPort(node)).To(gomega.Succeed())
+		lsp, err := getHealthCheckPort()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(lsp.Addresses).To(gomega.Equal([]string{"0a:58:64:42:00:12 100.66.0.18 fd66:0:0:1::2"}))
+		pg, err := libovsdbops.GetPortGroup(fakeOvn.nbClient, &nbdb.PortGroup{Name: ovntypes.ClusterPortGroupNameBase})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(pg.Ports).To(gomega.ContainElement(lsp.UUID))
+
+		gomega.Expect(getNodeHealthCheckGatewayIfAddrs(node)).To(gomega.HaveLen(2))
+
+		delete(node.Annotations, "k8s.ovn.org/node-health-check-subnets")
+		gomega.Expect(fakeOvn.controller.syncNodeHealthCheckPort(node)).To(gomega.Succeed())
+		_, err = getHealthCheckPort()
+		gomega.Expect(err).To(gomega.HaveOccurred())
+		gomega.Expect(getNodeHealthCheckGatewayIfAddrs(node)).To(gomega.BeEmpty())
+	})
+})
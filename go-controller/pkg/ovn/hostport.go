@@ -0,0 +1,275 @@
+package ovn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+	ref "k8s.io/client-go/tools/reference"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// hostPortLoadBalancerKind is the kind of the load balancers of the host ports
+// of the pods, owned by their pod
+const hostPortLoadBalancerKind = "Pod"
+
+// hostPortProtocols are the protocols of the host ports, one load balancer
+// each
+var hostPortProtocols = []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP}
+
+// hostPortLoadBalancerName returns the name of the load balancer of the host
+// ports of the given protocol of the pod
+func hostPortLoadBalancerName(namespace, name string, protocol kapi.Protocol) string {
+	return fmt.Sprintf("HostPort_%s/%s_%s", namespace, name, protocol)
+}
+
+// syncPodHostPorts exposes the host ports of the local zone pod on its node.
+// A load balancer per protocol DNATs the node IPs and host port to the pod IP
+// and container port, on the gateway router of the node for the traffic
+// entering from the shared gateway bridge, and on the node switch for the
+// traffic of the pods of the node. The host ports clashing with the NodePort
+// of a service are not exposed, the NodePort taking precedence.
+func (oc *DefaultNetworkController) syncPodHostPorts(pod *kapi.Pod) error {
+	if !config.OVNKubernetesFeature.EnableHostPort || oc.TopologyType() != types.Layer3Topology {
+		return nil
+	}
+	hostPorts := util.PodHostPorts(pod)
+	if len(hostPorts) == 0 || util.PodCompleted(pod) {
+		return oc.deletePodHostPorts(pod.Namespace, pod.Name, pod.Spec.NodeName)
+	}
+	node, err := oc.watchFactory.GetNode(pod.Spec.NodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", pod.Spec.NodeName, err)
+	}
+	gwConfig, err := util.ParseNodeL3GatewayAnnotation(node)
+	if err != nil {
+		return fmt.Errorf("failed to get the gateway config of node %s: %w", node.Name, err)
+	}
+	podIPs, err := util.GetPodCIDRsWithFullMask(pod, oc.NetInfo)
+	if err != nil {
+		return fmt.Errorf("failed to get the IPs of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	services, err := oc.watchFactory.GetServices()
+	if err != nil {
+		return fmt.Errorf("failed to get the services: %w", err)
+	}
+
+	vips, conflicts := buildHostPortVIPs(pod, hostPorts, gwConfig.IPAddresses, podIPs, services)
+	for _, conflict := range conflicts {
+		oc.recordHostPortConflictEvent(pod, conflict.hostPort, conflict.service)
+	}
+	return oc.ensurePodHostPorts(pod.Namespace, pod.Name, node.Name, vips)
+}
+
+// hostPortConflict is a host port clashing with the NodePort of a service
+type hostPortConflict struct {
+	hostPort kapi.ContainerPort
+	service  *kapi.Service
+}
+
+// buildHostPortVIPs returns the VIPs of the host ports of the pod on the node
+// IPs, the backend being the pod IP and container port, by protocol, and the
+// host ports left out as clashing with a NodePort
+func buildHostPortVIPs(pod *kapi.Pod, hostPorts []kapi.ContainerPort, nodeIPs, podIPs []*net.IPNet,
+	services []*kapi.Service) (map[kapi.Protocol]map[string]string, []hostPortConflict) {
+	vips := map[kapi.Protocol]map[string]string{}
+	conflicts := []hostPortConflict{}
+	for _, hostPort := range hostPorts {
+		if service := util.GetNodePortService(services, hostPort.Protocol, hostPort.HostPort); service != nil {
+			conflicts = append(conflicts, hostPortConflict{hostPort: hostPort, service: service})
+			continue
+		}
+		for _, hostIP := range hostPortIPs(hostPort, nodeIPs) {
+			podIP, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6(hostIP), podIPs)
+			if err != nil {
+				klog.Warningf("Host port %s of pod %s/%s is not exposed: %v",
+					util.JoinHostPortInt32(hostIP.String(), hostPort.HostPort), pod.Namespace, pod.Name, err)
+				continue
+			}
+			if vips[hostPort.Protocol] == nil {
+				vips[hostPort.Protocol] = map[string]string{}
+			}
+			vips[hostPort.Protocol][util.JoinHostPortInt32(hostIP.String(), hostPort.HostPort)] =
+				util.JoinHostPortInt32(podIP.IP.String(), hostPort.ContainerPort)
+		}
+	}
+	return vips, conflicts
+}
+
+// ensurePodHostPorts makes the load balancers of the host ports of the pod
+// match the VIPs, by protocol, on the gateway router and the switch of the
+// node. The load balancers of the protocols without VIPs are deleted.
+func (oc *DefaultNetworkController) ensurePodHostPorts(namespace, name, nodeName string, vips map[kapi.Protocol]map[string]string) error {
+	externalIDs := map[string]string{
+		types.LoadBalancerKindExternalID:  hostPortLoadBalancerKind,
+		types.LoadBalancerOwnerExternalID: namespace + "/" + name,
+	}
+	lbs := []*nbdb.LoadBalancer{}
+	staleLBNames := []string{}
+	for _, protocol := range hostPortProtocols {
+		lbName := hostPortLoadBalancerName(namespace, name, protocol)
+		if len(vips[protocol]) == 0 {
+			staleLBNames = append(staleLBNames, lbName)
+			continue
+		}
+		lbs = append(lbs, libovsdbops.BuildLoadBalancer(lbName, strings.ToLower(string(protocol)), vips[protocol],
+			map[string]string{}, externalIDs))
+	}
+
+	ops, err := oc.deleteHostPortLoadBalancersOps(nil, nodeName, staleLBNames)
+	if err != nil {
+		return fmt.Errorf("failed to create ops to delete the stale host port load balancers of pod %s/%s: %w",
+			namespace, name, err)
+	}
+	if len(lbs) > 0 {
+		ops, err = libovsdbops.CreateOrUpdateLoadBalancersOps(oc.nbClient, ops, lbs...)
+		if err != nil {
+			return fmt.Errorf("failed to create ops to update the host port load balancers of pod %s/%s: %w",
+				namespace, name, err)
+		}
+		gwRouter := &nbdb.LogicalRouter{Name: types.GWRouterPrefix + nodeName}
+		ops, err = libovsdbops.AddLoadBalancersToLogicalRouterOps(oc.nbClient, ops, gwRouter, lbs...)
+		if err != nil {
+			return fmt.Errorf("failed to create ops to add the host port load balancers of pod %s/%s to router %s: %w",
+				namespace, name, gwRouter.Name, err)
+		}
+		nodeSwitch := &nbdb.LogicalSwitch{Name: oc.GetNetworkScopedName(nodeName)}
+		ops, err = libovsdbops.AddLoadBalancersToLogicalSwitchOps(oc.nbClient, ops, nodeSwitch, lbs...)
+		if err != nil {
+			return fmt.Errorf("failed to create ops to add the host port load balancers of pod %s/%s to switch %s: %w",
+				namespace, name, nodeSwitch.Name, err)
+		}
+	}
+	if _, err = libovsdbops.TransactAndCheckAndSetUUIDs(oc.nbClient, lbs, ops); err != nil {
+		return fmt.Errorf("failed to update the host port load balancers of pod %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// deletePodHostPorts deletes the load balancers of the host ports of the pod
+func (oc *DefaultNetworkController) deletePodHostPorts(namespace, name, nodeName string) error {
+	lbNames := make([]string, 0, len(hostPortProtocols))
+	for _, protocol := range hostPortProtocols {
+		lbNames = append(lbNames, hostPortLoadBalancerName(namespace, name, protocol))
+	}
+	ops, err := oc.deleteHostPortLoadBalancersOps(nil, nodeName, lbNames)
+	if err != nil {
+		return fmt.Errorf("failed to create ops to delete the host port load balancers of pod %s/%s: %w", namespace, name, err)
+	}
+	if _, err = libovsdbops.TransactAndCheck(oc.nbClient, ops); err != nil {
+		return fmt.Errorf("failed to delete the host port load balancers of pod %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// deleteHostPortLoadBalancersOps returns the ops deleting the existing load
+// balancers of the given names, removed from the gateway router and the switch
+// of the node first
+func (oc *DefaultNetworkController) deleteHostPortLoadBalancersOps(ops []ovsdb.Operation, nodeName string,
+	lbNames []string) ([]ovsdb.Operation, error) {
+	lbs := []*nbdb.LoadBalancer{}
+	for _, lbName := range lbNames {
+		lb, err := libovsdbops.GetLoadBalancer(oc.nbClient, &nbdb.LoadBalancer{Name: lbName})
+		if errors.Is(err, libovsdbclient.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get load balancer %s: %w", lbName, err)
+		}
+		lbs = append(lbs, lb)
+	}
+	if len(lbs) == 0 {
+		return ops, nil
+	}
+	ops, err := libovsdbops.RemoveLoadBalancersFromLogicalRouterOps(oc.nbClient, ops,
+		&nbdb.LogicalRouter{Name: types.GWRouterPrefix + nodeName}, lbs...)
+	if err != nil {
+		return nil, err
+	}
+	ops, err = libovsdbops.RemoveLoadBalancersFromLogicalSwitchOps(oc.nbClient, ops,
+		&nbdb.LogicalSwitch{Name: oc.GetNetworkScopedName(nodeName)}, lbs...)
+	if err != nil {
+		return nil, err
+	}
+	return libovsdbops.DeleteLoadBalancersOps(oc.nbClient, ops, lbs...)
+}
+
+// deleteStaleHostPortLoadBalancers deletes, on startup, the load balancers of
+// the host ports of the pods deleted in the meantime, or of all the pods if
+// the host ports are not exposed anymore
+func (oc *DefaultNetworkController) deleteStaleHostPortLoadBalancers(pods []interface{}) error {
+	expectedOwners := sets.New[string]()
+	if config.OVNKubernetesFeature.EnableHostPort {
+		for _, podInterface := range pods {
+			pod, ok := podInterface.(*kapi.Pod)
+			if !ok || len(util.PodHostPorts(pod)) == 0 {
+				continue
+			}
+			expectedOwners.Insert(pod.Namespace + "/" + pod.Name)
+		}
+	}
+	lbs, err := libovsdbops.ListLoadBalancers(oc.nbClient)
+	if err != nil {
+		return fmt.Errorf("failed to list the load balancers: %w", err)
+	}
+	staleLBs := []*nbdb.LoadBalancer{}
+	for _, lb := range lbs {
+		if lb.ExternalIDs[types.LoadBalancerKindExternalID] == hostPortLoadBalancerKind &&
+			!expectedOwners.Has(lb.ExternalIDs[types.LoadBalancerOwnerExternalID]) {
+			staleLBs = append(staleLBs, lb)
+		}
+	}
+	if len(staleLBs) == 0 {
+		return nil
+	}
+	if err := libovsdbops.DeleteLoadBalancers(oc.nbClient, staleLBs); err != nil {
+		return fmt.Errorf("failed to delete the stale host port load balancers: %w", err)
+	}
+	klog.Infof("Deleted %d stale host port load balancers", len(staleLBs))
+	return nil
+}
+
+// hostPortIPs returns the IPs the host port is exposed on: its host IP, if
+// any, else the node IPs, of the IP family of the host IP if unspecified
+func hostPortIPs(hostPort kapi.ContainerPort, nodeIPs []*net.IPNet) []net.IP {
+	hostIP := utilnet.ParseIPSloppy(hostPort.HostIP)
+	if hostIP != nil && !hostIP.IsUnspecified() {
+		return []net.IP{hostIP}
+	}
+	ips := []net.IP{}
+	for _, nodeIP := range nodeIPs {
+		if hostIP != nil && utilnet.IsIPv6(hostIP) != utilnet.IsIPv6(nodeIP.IP) {
+			continue
+		}
+		ips = append(ips, nodeIP.IP)
+	}
+	return ips
+}
+
+// recordHostPortConflictEvent posts an event warning that the host port of the
+// pod is not exposed, clashing with the NodePort of the service
+func (oc *DefaultNetworkController) recordHostPortConflictEvent(pod *kapi.Pod, hostPort kapi.ContainerPort, service *kapi.Service) {
+	klog.Warningf("Host port %d/%s of pod %s/%s is not exposed, clashing with the NodePort of service %s/%s",
+		hostPort.HostPort, hostPort.Protocol, pod.Namespace, pod.Name, service.Namespace, service.Name)
+	podRef, err := ref.GetReference(scheme.Scheme, pod)
+	if err != nil {
+		klog.Errorf("Couldn't get a reference to pod %s/%s to post an event: '%v'", pod.Namespace, pod.Name, err)
+		return
+	}
+	oc.recorder.Eventf(podRef, kapi.EventTypeWarning, "HostPortConflict",
+		"Host port %d/%s is not exposed, clashing with the NodePort of service %s/%s",
+		hostPort.HostPort, hostPort.Protocol, service.Namespace, service.Name)
+}
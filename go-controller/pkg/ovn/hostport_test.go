@@ -0,0 +1,127 @@
+package ovn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/onsi/gomega"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+func TestBuildHostPortVIPs(t *testing.T) {
+	g := gomega.NewWithT(t)
+	pod := &kapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"},
+		Spec: kapi.PodSpec{Containers: []kapi.Container{{
+			Ports: []kapi.ContainerPort{
+				{ContainerPort: 80, HostPort: 8080},
+				{ContainerPort: 53, HostPort: 5353, Protocol: kapi.ProtocolUDP, HostIP: "0.0.0.0"},
+				{ContainerPort: 443, HostPort: 8443, HostIP: "172.18.0.2"},
+				{ContainerPort: 90, HostPort: 30090},
+				{ContainerPort: 91},
+			},
+		}}},
+	}
+	nodeIPs := []*net.IPNet{ovntest.MustParseIPNet("172.18.0.2/16"), ovntest.MustParseIPNet("fc00:f853:ccd:e793::2/64")}
+	podIPs := []*net.IPNet{ovntest.MustParseIPNet("10.128.0.5/32"), ovntest.MustParseIPNet("fd00:10:244::5/128")}
+	services := []*kapi.Service{{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "ns2"},
+		Spec: kapi.ServiceSpec{
+			Type:  kapi.ServiceTypeNodePort,
+			Ports: []kapi.ServicePort{{Protocol: kapi.ProtocolTCP, Port: 90, NodePort: 30090}},
+		},
+	}}
+
+	vips, conflicts := buildHostPortVIPs(pod, util.PodHostPorts(pod), nodeIPs, podIPs, services)
+	g.Expect(vips).To(gomega.Equal(map[kapi.Protocol]map[string]string{
+		kapi.ProtocolTCP: {
+			"172.18.0.2:8080":              "10.128.0.5:80",
+			"[fc00:f853:ccd:e793::2]:8080": "[fd00:10:244::5]:80",
+			"172.18.0.2:8443":              "10.128.0.5:443",
+		},
+		kapi.ProtocolUDP: {
+			"172.18.0.2:5353": "10.128.0.5:53",
+		},
+	}))
+	// the host port clashing with the NodePort is left out
+	g.Expect(conflicts).To(gomega.HaveLen(1))
+	g.Expect(conflicts[0].hostPort.HostPort).To(gomega.BeEquivalentTo(30090))
+	g.Expect(conflicts[0].service.Name).To(gomega.Equal("svc1"))
+}
+
+func TestEnsurePodHostPorts(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gwRouter := &nbdb.LogicalRouter{UUID: "gw-router-UUID", Name: types.GWRouterPrefix + "node1"}
+	nodeSwitch := &nbdb.LogicalSwitch{UUID: "node-switch-UUID", Name: "node1"}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{gwRouter, nodeSwitch},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+	oc := &DefaultNetworkController{
+		BaseNetworkController: BaseNetworkController{
+			CommonNetworkControllerInfo: CommonNetworkControllerInfo{nbClient: nbClient},
+			NetInfo:                     &util.DefaultNetInfo{},
+		},
+	}
+
+	getLBs := func() map[string]*nbdb.LoadBalancer {
+		lbs, err := libovsdbops.ListLoadBalancers(nbClient)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		byName := map[string]*nbdb.LoadBalancer{}
+		for _, lb := range lbs {
+			byName[lb.Name] = lb
+		}
+		return byName
+	}
+	getAttachedLBs := func() ([]string, []string) {
+		router, err := libovsdbops.GetLogicalRouter(nbClient, &nbdb.LogicalRouter{Name: gwRouter.Name})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		sw, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: nodeSwitch.Name})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return router.LoadBalancer, sw.LoadBalancer
+	}
+
+	g.Expect(oc.ensurePodHostPorts("ns1", "pod1", "node1", map[kapi.Protocol]map[string]string{
+		kapi.ProtocolTCP: {"172.18.0.2:8080": "10.128.0.5:80"},
+		kapi.ProtocolUDP: {"172.18.0.2:5353": "10.128.0.5:53"},
+	})).To(gomega.Succeed())
+	lbs := getLBs()
+	g.Expect(lbs).To(gomega.HaveLen(2))
+	tcpLB := lbs["HostPort_ns1/pod1_TCP"]
+	g.Expect(tcpLB).NotTo(gomega.BeNil())
+	g.Expect(tcpLB.Vips).To(gomega.Equal(map[string]string{"172.18.0.2:8080": "10.128.0.5:80"}))
+	g.Expect(*tcpLB.Protocol).To(gomega.Equal(nbdb.LoadBalancerProtocolTCP))
+	g.Expect(tcpLB.ExternalIDs).To(gomega.HaveKeyWithValue(types.LoadBalancerOwnerExternalID, "ns1/pod1"))
+	udpLB := lbs["HostPort_ns1/pod1_UDP"]
+	g.Expect(udpLB).NotTo(gomega.BeNil())
+	routerLBs, switchLBs := getAttachedLBs()
+	g.Expect(routerLBs).To(gomega.ConsistOf(tcpLB.UUID, udpLB.UUID))
+	g.Expect(switchLBs).To(gomega.ConsistOf(tcpLB.UUID, udpLB.UUID))
+
+	// the load balancer of the protocol without host ports anymore is deleted
+	g.Expect(oc.ensurePodHostPorts("ns1", "pod1", "node1", map[kapi.Protocol]map[string]string{
+		kapi.ProtocolTCP: {"172.18.0.2:8080": "10.128.0.6:80"},
+	})).To(gomega.Succeed())
+	lbs = getLBs()
+	g.Expect(lbs).To(gomega.HaveLen(1))
+	g.Expect(lbs["HostPort_ns1/pod1_TCP"].Vips).To(gomega.Equal(map[string]string{"172.18.0.2:8080": "10.128.0.6:80"}))
+	routerLBs, switchLBs = getAttachedLBs()
+	g.Expect(routerLBs).To(gomega.ConsistOf(tcpLB.UUID))
+	g.Expect(switchLBs).To(gomega.ConsistOf(tcpLB.UUID))
+
+	// and all of them along with the pod
+	g.Expect(oc.deletePodHostPorts("ns1", "pod1", "node1")).To(gomega.Succeed())
+	g.Expect(getLBs()).To(gomega.BeEmpty())
+	routerLBs, switchLBs = getAttachedLBs()
+	g.Expect(routerLBs).To(gomega.BeEmpty())
+	g.Expect(switchLBs).To(gomega.BeEmpty())
+}
@@ -0,0 +1,148 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	libovsdbutil "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	localnetPassthroughLinkLocal = "link-local"
+	localnetPassthroughLLDP      = "lldp"
+	localnetPassthroughARP       = "arp"
+
+	// lldpMatch matches the LLDP frames by their ethertype
+	lldpMatch = "eth.type == 0x88cc"
+)
+
+func getLocalnetPassthroughACLDbIDs(aclDir libovsdbutil.ACLDirection, trafficType, controller string) *libovsdbops.DbObjectIDs {
+	return libovsdbops.NewDbObjectIDs(libovsdbops.ACLLocalnetPassthrough, controller,
+		map[libovsdbops.ExternalIDKey]string{
+			libovsdbops.PolicyDirectionKey: string(aclDir),
+			libovsdbops.TypeKey:            trafficType,
+		})
+}
+
+// getLinkLocalMatch matches the traffic from or to the link-local addresses of
+// the IP families of the network, of both without subnets. IPv6 neighbor
+// discovery is never denied.
+func (bnc *BaseNetworkController) getLinkLocalMatch(deny bool) string {
+	ipv4Mode, ipv6Mode := bnc.IPMode()
+	if !ipv4Mode && !ipv6Mode {
+		ipv4Mode, ipv6Mode = true, true
+	}
+	matches := []string{}
+	if ipv4Mode {
+		matches = append(matches, fmt.Sprintf("ip4.src == %s || ip4.dst == %s", util.IPv4LinkLocalCIDR, util.IPv4LinkLocalCIDR))
+	}
+	if ipv6Mode {
+		match := fmt.Sprintf("ip6.src == %s || ip6.dst == %s", util.IPv6LinkLocalCIDR, util.IPv6LinkLocalCIDR)
+		if deny {
+			match = fmt.Sprintf("!nd && (%s)", match)
+		}
+		matches = append(matches, match)
+	}
+	if len(matches) == 1 {
+		return matches[0]
+	}
+	return "(" + strings.Join(matches, ") || (") + ")"
+}
+
+// getLocalnetPassthroughACLs builds the ACLs of the passthrough actions of the
+// localnet network, one per direction and traffic type with an action. They
+// are in the tier of the network firewall, above its rules: the allowed
+// traffic bypasses the network firewall and the multi network policies.
+func (bnc *BaseNetworkController) getLocalnetPassthroughACLs() []*nbdb.ACL {
+	passthrough := bnc.Passthrough()
+	if passthrough == nil {
+		return nil
+	}
+	acls := []*nbdb.ACL{}
+	for _, aclDir := range []libovsdbutil.ACLDirection{libovsdbutil.ACLIngress, libovsdbutil.ACLEgress} {
+		aclPipeline := libovsdbutil.ACLDirectionToACLPipeline(aclDir)
+		for _, traffic := range []struct {
+			trafficType string
+			action      util.PassthroughAction
+		}{
+			{localnetPassthroughLinkLocal, passthrough.LinkLocal},
+			{localnetPassthroughLLDP, passthrough.LLDP},
+			{localnetPassthroughARP, passthrough.ARP},
+		} {
+			if traffic.action == util.PassthroughDefault {
+				continue
+			}
+			deny := traffic.action == util.PassthroughDeny
+			var match, action string
+			switch traffic.trafficType {
+			case localnetPassthroughLinkLocal:
+				// link-local connections are tracked like the others
+				match = bnc.getLinkLocalMatch(deny)
+				action = nbdb.ACLActionAllowRelated
+			case localnetPassthroughLLDP:
+				match = lldpMatch
+				action = nbdb.ACLActionAllow
+			case localnetPassthroughARP:
+				match = "arp"
+				action = nbdb.ACLActionAllow
+			}
+			if deny {
+				action = nbdb.ACLActionDrop
+			}
+			dbIDs := getLocalnetPassthroughACLDbIDs(aclDir, traffic.trafficType, bnc.controllerName)
+			acl := libovsdbutil.BuildACL(dbIDs, types.LocalnetPassthroughPriority, match, action, nil, aclPipeline)
+			acl.Tier = types.NetworkFirewallACLTier
+			acls = append(acls, acl)
+		}
+	}
+	return acls
+}
+
+// ensureLocalnetPassthrough applies the passthrough actions of the localnet
+// network to its logical switch, removing from it the ACLs of previous ones.
+func (bnc *BaseNetworkController) ensureLocalnetPassthrough(switchName string) error {
+	if bnc.TopologyType() != types.LocalnetTopology {
+		return nil
+	}
+	predicateIDs := libovsdbops.NewDbObjectIDs(libovsdbops.ACLLocalnetPassthrough, bnc.controllerName, nil)
+	if err := bnc.ensureSwitchACLs(switchName, predicateIDs, bnc.getLocalnetPassthroughACLs()); err != nil {
+		return fmt.Errorf("failed to apply the passthrough actions of network %s to switch %s: %v",
+			bnc.GetNetworkName(), switchName, err)
+	}
+	return nil
+}
+
+// getLocalnetPassthroughPortSecurity returns the port security of a pod port
+// of the given addresses. When the link-local traffic is allowed, the
+// link-local ranges of the IP families of the pod IPs are added so that the
+// pod can use link-local addresses; the pods without IPs of a family are not
+// restricted on it anyway.
+func (bnc *BaseNetworkController) getLocalnetPassthroughPortSecurity(addresses []string, podIPs []*net.IPNet) []string {
+	passthrough := bnc.Passthrough()
+	if passthrough == nil || passthrough.LinkLocal != util.PassthroughAllow || len(addresses) != 1 {
+		return addresses
+	}
+	portSecurity := addresses[0]
+	var hasIPv4, hasIPv6 bool
+	for _, podIP := range podIPs {
+		if utilnet.IsIPv6CIDR(podIP) {
+			hasIPv6 = true
+		} else {
+			hasIPv4 = true
+		}
+	}
+	if hasIPv4 {
+		portSecurity += " " + util.IPv4LinkLocalCIDR.String()
+	}
+	if hasIPv6 {
+		portSecurity += " " + util.IPv6LinkLocalCIDR.String()
+	}
+	return []string{portSecurity}
+}
@@ -0,0 +1,110 @@
+package ovn
+
+import (
+	"net"
+	"testing"
+
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/onsi/gomega"
+)
+
+func TestEnsureLocalnetPassthrough(t *testing.T) {
+	g := gomega.NewWithT(t)
+	newNetInfo := func(passthrough *ovncnitypes.LocalnetPassthrough) util.NetInfo {
+		netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:     cnitypes.NetConf{Name: "blue"},
+			Topology:    types.LocalnetTopology,
+			Subnets:     "fd00:10:1::/64",
+			Passthrough: passthrough,
+		})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return netInfo
+	}
+	sw := &nbdb.LogicalSwitch{UUID: "switch-UUID", Name: "blue_ovn_localnet_switch"}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{sw},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+
+	bnc := &BaseNetworkController{
+		CommonNetworkControllerInfo: CommonNetworkControllerInfo{nbClient: nbClient},
+		controllerName:              "blue-network-controller",
+		NetInfo: newNetInfo(&ovncnitypes.LocalnetPassthrough{
+			LinkLocal: ovncnitypes.PassthroughActionDeny,
+			LLDP:      ovncnitypes.PassthroughActionAllow,
+		}),
+	}
+	switchACLs := func() map[string]*nbdb.ACL {
+		s, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: sw.Name})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		acls := map[string]*nbdb.ACL{}
+		for _, uuid := range s.ACLs {
+			found, err := libovsdbops.FindACLs(nbClient, []*nbdb.ACL{{UUID: uuid}})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(found).To(gomega.HaveLen(1))
+			acls[found[0].ExternalIDs[libovsdbops.PolicyDirectionKey.String()]+":"+
+				found[0].ExternalIDs[libovsdbops.TypeKey.String()]] = found[0]
+		}
+		return acls
+	}
+
+	g.Expect(bnc.ensureLocalnetPassthrough(sw.Name)).To(gomega.Succeed())
+	acls := switchACLs()
+	g.Expect(acls).To(gomega.HaveLen(4))
+	for _, aclDir := range []string{"Ingress", "Egress"} {
+		linkLocalACL := acls[aclDir+":"+localnetPassthroughLinkLocal]
+		g.Expect(linkLocalACL).NotTo(gomega.BeNil())
+		g.Expect(linkLocalACL.Match).To(gomega.Equal("!nd && (ip6.src == fe80::/10 || ip6.dst == fe80::/10)"))
+		g.Expect(linkLocalACL.Action).To(gomega.Equal(nbdb.ACLActionDrop))
+		g.Expect(linkLocalACL.Priority).To(gomega.Equal(types.LocalnetPassthroughPriority))
+		g.Expect(linkLocalACL.Tier).To(gomega.Equal(types.NetworkFirewallACLTier))
+		lldpACL := acls[aclDir+":"+localnetPassthroughLLDP]
+		g.Expect(lldpACL).NotTo(gomega.BeNil())
+		g.Expect(lldpACL.Match).To(gomega.Equal(lldpMatch))
+		g.Expect(lldpACL.Action).To(gomega.Equal(nbdb.ACLActionAllow))
+	}
+
+	// the ACLs of the previous actions are removed
+	bnc.NetInfo = newNetInfo(&ovncnitypes.LocalnetPassthrough{ARP: ovncnitypes.PassthroughActionDeny})
+	g.Expect(bnc.ensureLocalnetPassthrough(sw.Name)).To(gomega.Succeed())
+	acls = switchACLs()
+	g.Expect(acls).To(gomega.HaveLen(2))
+	g.Expect(acls["Ingress:"+localnetPassthroughARP].Match).To(gomega.Equal("arp"))
+	g.Expect(acls["Ingress:"+localnetPassthroughARP].Action).To(gomega.Equal(nbdb.ACLActionDrop))
+
+	// and all of them once the network has no passthrough actions
+	bnc.NetInfo = newNetInfo(nil)
+	g.Expect(bnc.ensureLocalnetPassthrough(sw.Name)).To(gomega.Succeed())
+	g.Expect(switchACLs()).To(gomega.BeEmpty())
+}
+
+func TestGetLocalnetPassthroughPortSecurity(t *testing.T) {
+	g := gomega.NewWithT(t)
+	netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:     cnitypes.NetConf{Name: "blue"},
+		Topology:    types.LocalnetTopology,
+		Subnets:     "10.1.0.0/16",
+		Passthrough: &ovncnitypes.LocalnetPassthrough{LinkLocal: ovncnitypes.PassthroughActionAllow},
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	bnc := &BaseNetworkController{NetInfo: netInfo}
+
+	addresses := []string{"0a:58:0a:01:00:05 10.1.0.5"}
+	g.Expect(bnc.getLocalnetPassthroughPortSecurity(addresses, []*net.IPNet{ovntest.MustParseIPNet("10.1.0.5/16")})).
+		To(gomega.Equal([]string{"0a:58:0a:01:00:05 10.1.0.5 169.254.0.0/16"}))
+	// the addresses of the port are left untouched
+	g.Expect(addresses).To(gomega.Equal([]string{"0a:58:0a:01:00:05 10.1.0.5"}))
+	// the pods without IPs are not restricted on the IPs
+	g.Expect(bnc.getLocalnetPassthroughPortSecurity([]string{"0a:58:0a:01:00:05"}, nil)).
+		To(gomega.Equal([]string{"0a:58:0a:01:00:05"}))
+}
@@ -17,10 +17,11 @@ type LogicalSwitchManager struct {
 	reserveIPs bool
 }
 
-// Initializes a new logical switch manager for L3 networks
+// Initializes a new logical switch manager for L3 networks, allocating a
+// single IP of each IP family across the host subnets of a switch
 func NewLogicalSwitchManager() *LogicalSwitchManager {
 	return &LogicalSwitchManager{
-		allocator:  subnet.NewAllocator(),
+		allocator:  subnet.NewIPFamilyAllocator(),
 		reserveIPs: true,
 	}
 }
@@ -87,12 +88,18 @@ func (manager *LogicalSwitchManager) AllocateIPs(switchName string, ipnets []*ne
 	return manager.allocator.AllocateIPs(switchName, ipnets)
 }
 
-// AllocateNextIPs allocates IP addresses from each of the host subnets
-// for a given switch
+// AllocateNextIPs allocates IP addresses from the host subnets for a given
+// switch, one of each IP family for L3 networks
 func (manager *LogicalSwitchManager) AllocateNextIPs(switchName string) ([]*net.IPNet, error) {
 	return manager.allocator.AllocateNextIPs(switchName)
 }
 
+// GetExhaustedSubnets returns the host subnets of a given switch without free
+// IPs
+func (manager *LogicalSwitchManager) GetExhaustedSubnets(switchName string) ([]*net.IPNet, error) {
+	return manager.allocator.GetExhaustedSubnets(switchName)
+}
+
 func (manager *LogicalSwitchManager) AllocateHybridOverlay(switchName string, hybridOverlayAnnotation []string) ([]*net.IPNet, error) {
 	var err error
 	var allocatedAddresses []*net.IPNet
@@ -112,7 +119,7 @@ func (manager *LogicalSwitchManager) AllocateHybridOverlay(switchName string, hy
 
 	// if we are not provided with any addresses, try to allocate the well known address
 	hostSubnets := manager.GetSwitchSubnets(switchName)
-	for _, hostSubnet := range hostSubnets {
+	for _, hostSubnet := range util.PrimaryHostSubnets(hostSubnets) {
 		allocatedAddresses = append(allocatedAddresses, util.GetNodeHybridOverlayIfAddr(hostSubnet))
 	}
 	err = manager.AllocateIPs(switchName, allocatedAddresses)
@@ -3,10 +3,12 @@ package ovn
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
@@ -35,6 +37,10 @@ const (
 	IdledServiceAnnotationSuffix   = "idled-at"
 	OvnNodeAnnotationRetryInterval = 100 * time.Millisecond
 	OvnNodeAnnotationRetryTimeout  = 1 * time.Second
+
+	// garpMaxTimeoutOption is the NB_Global option holding the maximum
+	// interval in seconds at which ovn-controller keeps sending GARPs
+	garpMaxTimeoutOption = "garp_max_timeout_sec"
 )
 
 // cleanup obsolete *gressDefaultDeny port groups
@@ -111,6 +117,38 @@ func (oc *DefaultNetworkController) upgradeOVNTopology(existingNodes *kapi.NodeL
 	return err
 }
 
+// syncGARPMaxTimeout configures the maximum interval at which ovn-controller
+// keeps sending GARPs for the NAT addresses of the gateway routers, like the
+// egress IPs, so that the switches that age out their entries keep learning
+// where those IPs live
+func (oc *DefaultNetworkController) syncGARPMaxTimeout() error {
+	nbGlobal, err := libovsdbops.GetNBGlobal(oc.nbClient, &nbdb.NBGlobal{})
+	if err != nil {
+		return fmt.Errorf("failed to get NB_Global: %v", err)
+	}
+	current, isSet := nbGlobal.Options[garpMaxTimeoutOption]
+	if config.OVNKubernetesFeature.GARPMaxTimeout == 0 {
+		if !isSet {
+			return nil
+		}
+		if err = libovsdbops.DeleteNBGlobalOptions(oc.nbClient, garpMaxTimeoutOption); err != nil {
+			return fmt.Errorf("failed to delete NB_Global option %s: %v", garpMaxTimeoutOption, err)
+		}
+		return nil
+	}
+	timeout := strconv.Itoa(config.OVNKubernetesFeature.GARPMaxTimeout)
+	if current == timeout {
+		return nil
+	}
+	err = libovsdbops.UpdateNBGlobalSetOptions(oc.nbClient, &nbdb.NBGlobal{
+		Options: map[string]string{garpMaxTimeoutOption: timeout},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set NB_Global option %s to %q: %v", garpMaxTimeoutOption, timeout, err)
+	}
+	return nil
+}
+
 // SetupMaster creates the central router and load-balancers for the network
 func (oc *DefaultNetworkController) SetupMaster(existingNodeNames []string) error {
 	// Create default Control Plane Protection (COPP) entry for routers
@@ -227,6 +265,12 @@ func (oc *DefaultNetworkController) SetupMaster(existingNodeNames []string) erro
 		return fmt.Errorf("failed to create logical switch port %+v and switch %s: %v", logicalSwitchPort, types.OVNJoinSwitch, err)
 	}
 
+	// The pods of the layer2 topology are attached to a single switch
+	// connected to the distributed router.
+	if oc.TopologyType() == types.Layer2Topology {
+		return oc.setupLayer2Switch()
+	}
+
 	return nil
 }
 
@@ -237,7 +281,7 @@ func (oc *DefaultNetworkController) syncNodeManagementPort(node *kapi.Node, host
 	}
 
 	if hostSubnets == nil {
-		hostSubnets, err = util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+		hostSubnets, err = oc.getNodeHostSubnets(node)
 		if err != nil {
 			return err
 		}
@@ -245,7 +289,8 @@ func (oc *DefaultNetworkController) syncNodeManagementPort(node *kapi.Node, host
 
 	var v4Subnet *net.IPNet
 	addresses := macAddress.String()
-	for _, hostSubnet := range hostSubnets {
+	// the management port only has addresses in the primary host subnets
+	for _, hostSubnet := range util.PrimaryHostSubnets(hostSubnets) {
 		mgmtIfAddr := util.GetNodeManagementIfAddr(hostSubnet)
 		addresses += " " + mgmtIfAddr.IP.String()
 
@@ -256,7 +301,14 @@ func (oc *DefaultNetworkController) syncNodeManagementPort(node *kapi.Node, host
 		if !utilnet.IsIPv6CIDR(hostSubnet) {
 			v4Subnet = hostSubnet
 		}
+	}
+	for _, hostSubnet := range hostSubnets {
 		if config.Gateway.Mode == config.GatewayModeLocal {
+			primarySubnet, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6CIDR(hostSubnet), hostSubnets)
+			if err != nil {
+				return err
+			}
+			mgmtIfAddr := util.GetNodeManagementIfAddr(primarySubnet)
 			lrsr := nbdb.LogicalRouterStaticRoute{
 				Policy:   &nbdb.LogicalRouterStaticRoutePolicySrcIP,
 				IPPrefix: hostSubnet.String(),
@@ -265,7 +317,7 @@ func (oc *DefaultNetworkController) syncNodeManagementPort(node *kapi.Node, host
 			p := func(item *nbdb.LogicalRouterStaticRoute) bool {
 				return item.IPPrefix == lrsr.IPPrefix && libovsdbops.PolicyEqualPredicate(lrsr.Policy, item.Policy)
 			}
-			err := libovsdbops.CreateOrReplaceLogicalRouterStaticRouteWithPredicate(oc.nbClient, types.OVNClusterRouter,
+			err = libovsdbops.CreateOrReplaceLogicalRouterStaticRouteWithPredicate(oc.nbClient, types.OVNClusterRouter,
 				&lrsr, p, &lrsr.Nexthop)
 			if err != nil {
 				return fmt.Errorf("error creating static route %+v on router %s: %v", lrsr, types.OVNClusterRouter, err)
@@ -320,7 +372,7 @@ func (oc *DefaultNetworkController) syncGatewayLogicalNetwork(node *kapi.Node, l
 		return fmt.Errorf("failed to init shared interface gateway: %v", err)
 	}
 
-	for _, subnet := range hostSubnets {
+	for _, subnet := range util.PrimaryHostSubnets(hostSubnets) {
 		hostIfAddr := util.GetNodeManagementIfAddr(subnet)
 		l3GatewayConfigIP, err := util.MatchFirstIPNetFamily(utilnet.IsIPv6(hostIfAddr.IP), l3GatewayConfig.IPAddresses)
 		if err != nil {
@@ -341,7 +393,7 @@ func (oc *DefaultNetworkController) syncGatewayLogicalNetwork(node *kapi.Node, l
 func (oc *DefaultNetworkController) ensureNodeLogicalNetwork(node *kapi.Node, hostSubnets []*net.IPNet) error {
 	var hostNetworkPolicyIPs []net.IP
 
-	for _, hostSubnet := range hostSubnets {
+	for _, hostSubnet := range util.PrimaryHostSubnets(hostSubnets) {
 		mgmtIfAddr := util.GetNodeManagementIfAddr(hostSubnet)
 		hostNetworkPolicyIPs = append(hostNetworkPolicyIPs, mgmtIfAddr.IP)
 	}
@@ -359,31 +411,33 @@ func (oc *DefaultNetworkController) ensureNodeLogicalNetwork(node *kapi.Node, ho
 	}
 
 	// add the host network IPs for this node to host network namespace's address set
-	if err = func() error {
-		hostNetworkNamespace := config.Kubernetes.HostNetworkNamespace
-		if hostNetworkNamespace != "" {
-			nsInfo, nsUnlock, err := oc.ensureNamespaceLocked(hostNetworkNamespace, true, nil)
-			if err != nil {
-				return fmt.Errorf("failed to ensure namespace locked: %v", err)
-			}
-			defer nsUnlock()
-			if err = nsInfo.addressSet.AddIPs(hostNetworkPolicyIPs); err != nil {
-				return err
-			}
-		}
-		return nil
-	}(); err != nil {
+	if err = oc.addHostNetworkPolicyIPs(hostNetworkPolicyIPs); err != nil {
 		return err
 	}
 
 	return oc.createNodeLogicalSwitch(node.Name, hostSubnets, oc.clusterLoadBalancerGroupUUID, oc.switchLoadBalancerGroupUUID)
 }
 
+// addHostNetworkPolicyIPs adds the IPs the traffic of the hosts comes from to
+// the address set of the host network namespace.
+func (oc *DefaultNetworkController) addHostNetworkPolicyIPs(hostNetworkPolicyIPs []net.IP) error {
+	hostNetworkNamespace := config.Kubernetes.HostNetworkNamespace
+	if hostNetworkNamespace == "" {
+		return nil
+	}
+	nsInfo, nsUnlock, err := oc.ensureNamespaceLocked(hostNetworkNamespace, true, nil)
+	if err != nil {
+		return fmt.Errorf("failed to ensure namespace locked: %v", err)
+	}
+	defer nsUnlock()
+	return nsInfo.addressSet.AddIPs(hostNetworkPolicyIPs)
+}
+
 func (oc *DefaultNetworkController) addNode(node *kapi.Node) ([]*net.IPNet, error) {
 	// Node subnet for the default network is allocated by cluster manager.
 	// Make sure that the node is allocated with the subnet before proceeding
 	// to create OVN Northbound resources.
-	hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+	hostSubnets, err := oc.getNodeHostSubnets(node)
 	if err != nil {
 		return nil, err
 	}
@@ -450,12 +504,14 @@ func (oc *DefaultNetworkController) deleteStaleNodeChassis(node *kapi.Node) erro
 		p := func(item *sbdb.Chassis) bool {
 			return item.Name == staleChassis
 		}
-		if err = libovsdbops.DeleteChassisTemplateVar(oc.nbClient, &nbdb.ChassisTemplateVar{Chassis: staleChassis}); err != nil {
-			// Send an event and Log on failure
-			oc.recorder.Eventf(node, kapi.EventTypeWarning, "ErrorMismatchChassis",
-				"Node %s is now with a new chassis ID. Its stale chassis template vars are still in the NBDB",
-				node.Name)
-			return fmt.Errorf("node %s is now with a new chassis ID. Its stale chassis template vars are still in the NBDB", node.Name)
+		if oc.svcTemplateSupport {
+			if err = libovsdbops.DeleteChassisTemplateVar(oc.nbClient, &nbdb.ChassisTemplateVar{Chassis: staleChassis}); err != nil {
+				// Send an event and Log on failure
+				oc.recorder.Eventf(node, kapi.EventTypeWarning, "ErrorMismatchChassis",
+					"Node %s is now with a new chassis ID. Its stale chassis template vars are still in the NBDB",
+					node.Name)
+				return fmt.Errorf("node %s is now with a new chassis ID. Its stale chassis template vars are still in the NBDB", node.Name)
+			}
 		}
 		if err = libovsdbops.DeleteChassisWithPredicate(oc.sbClient, p); err != nil {
 			// Send an event and Log on failure
@@ -489,6 +545,9 @@ func (oc *DefaultNetworkController) cleanupNodeResources(nodeName string) error
 	if err := libovsdbops.DeleteChassisWithPredicate(oc.sbClient, p); err != nil {
 		return fmt.Errorf("failed to remove the chassis associated with node %s in the OVN SB Chassis table: %v", nodeName, err)
 	}
+	if !oc.svcTemplateSupport {
+		return nil
+	}
 	if err := libovsdbops.DeleteChassisTemplateVar(oc.nbClient, chassisTemplateVars...); err != nil {
 		return fmt.Errorf("failed deleting chassis template variables for %s: %v", nodeName, err)
 	}
@@ -539,8 +598,11 @@ func (oc *DefaultNetworkController) syncNodes(kNodes []interface{}) error {
 		}
 
 		// Add the node to the foundNodes only if it belongs to the local zone.
+		// The topology of a node labeled as not managed by OVN is stale.
 		if oc.isLocalZoneNode(node) {
-			foundNodes.Insert(node.Name)
+			if !util.NoHostSubnet(node) {
+				foundNodes.Insert(node.Name)
+			}
 			oc.localZoneNodes.Store(node.Name, true)
 			localZoneNodeNames = append(localZoneNodeNames, node.Name)
 		} else {
@@ -559,7 +621,8 @@ func (oc *DefaultNetworkController) syncNodes(kNodes []interface{}) error {
 
 	staleNodes := sets.NewString()
 	for _, nodeSwitch := range nodeSwitches {
-		if nodeSwitch.Name != types.TransitSwitch && !foundNodes.Has(nodeSwitch.Name) {
+		if nodeSwitch.Name != types.TransitSwitch && nodeSwitch.Name != types.OVNLayer2Switch &&
+			!foundNodes.Has(nodeSwitch.Name) {
 			staleNodes.Insert(nodeSwitch.Name)
 		}
 	}
@@ -722,6 +785,9 @@ func (oc *DefaultNetworkController) addUpdateLocalNodeEvent(node *kapi.Node, nSy
 	_, _ = oc.localZoneNodes.LoadOrStore(node.Name, true)
 
 	if noHostSubnet := util.NoHostSubnet(node); noHostSubnet {
+		if err := oc.cleanupNoHostSubnetNode(node); err != nil {
+			return fmt.Errorf("nodeAdd: error cleaning up node %s no longer managed by OVN: %w", node.Name, err)
+		}
 		err := oc.lsManager.AddNoHostSubnetSwitch(node.Name)
 		if err != nil {
 			return fmt.Errorf("nodeAdd: error adding noHost subnet for switch %s: %w", node.Name, err)
@@ -736,6 +802,21 @@ func (oc *DefaultNetworkController) addUpdateLocalNodeEvent(node *kapi.Node, nSy
 		return nil
 	}
 
+	// report the cluster subnets applied for the node, ovnkube-cluster-manager
+	// only handing out host subnets from those applied for every node
+	appliedAnnotator := kube.NewNodeAnnotator(oc.kube, node.Name)
+	if err := util.SetControllerAppliedClusterSubnets(appliedAnnotator, node); err != nil {
+		return fmt.Errorf("nodeAdd: error setting the applied cluster subnets of node %s: %w", node.Name, err)
+	}
+	// a node that is already gone is cleaned up by its delete event
+	if err := appliedAnnotator.Run(); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("nodeAdd: error setting the applied cluster subnets of node %s: %w", node.Name, err)
+	}
+
+	if oc.TopologyType() == types.Layer2Topology {
+		return oc.addUpdateLayer2LocalNodeEvent(node, nSyncs)
+	}
+
 	klog.Infof("Adding or Updating Node %q", node.Name)
 	if nSyncs.syncNode {
 		if hostSubnets, err = oc.addNode(node); err != nil {
@@ -773,6 +854,9 @@ func (oc *DefaultNetworkController) addUpdateLocalNodeEvent(node *kapi.Node, nSy
 
 	if nSyncs.syncMgmtPort {
 		err := oc.syncNodeManagementPort(node, hostSubnets)
+		if err == nil {
+			err = oc.syncNodeHealthCheckPort(node)
+		}
 		if err != nil {
 			errs = append(errs, err)
 			oc.mgmtPortFailed.Store(node.Name, true)
@@ -933,10 +1017,144 @@ func (oc *DefaultNetworkController) deleteNodeEvent(node *kapi.Node) error {
 	oc.gatewaysFailed.Delete(node.Name)
 	oc.nodeClusterRouterPortFailed.Delete(node.Name)
 	oc.localZoneNodes.Delete(node.Name)
+	oc.nodeSubnetsGenerations.Delete(node.Name)
+
+	return nil
+}
+
+// cleanupNoHostSubnetNode removes the topology of a local zone node labeled as
+// not managed by OVN after it was, like if the node was deleted. The pods of
+// the node lose their connectivity and need to be recreated.
+func (oc *DefaultNetworkController) cleanupNoHostSubnetNode(node *kapi.Node) error {
+	if len(oc.lsManager.GetSwitchSubnets(node.Name)) == 0 {
+		return nil
+	}
+	klog.Warningf("Node %s is no longer managed by OVN, removing its topology: its pods need to be recreated", node.Name)
+
+	if config.HybridOverlay.Enabled {
+		if err := oc.removeHybridLRPolicySharedGW(node.Name); err != nil {
+			return err
+		}
+	}
+	if err := oc.cleanupNodeResources(node.Name); err != nil {
+		return err
+	}
+	if config.OVNKubernetesFeature.EnableInterconnect {
+		if err := oc.zoneICHandler.DeleteNode(node); err != nil {
+			return err
+		}
+		oc.syncZoneICFailed.Delete(node.Name)
+	}
 
+	oc.lsManager.DeleteSwitch(node.Name)
+	oc.addNodeFailed.Delete(node.Name)
+	oc.mgmtPortFailed.Delete(node.Name)
+	oc.gatewaysFailed.Delete(node.Name)
+	oc.nodeClusterRouterPortFailed.Delete(node.Name)
+	oc.nodeSubnetsGenerations.Delete(node.Name)
+	return nil
+}
+
+// cleanupRemoteNoHostSubnetNode removes the interconnect resources of a remote
+// zone node labeled as not managed by OVN after it was
+func (oc *DefaultNetworkController) cleanupRemoteNoHostSubnetNode(node *kapi.Node) error {
+	klog.Infof("Remote zone node %s is no longer managed by OVN, removing its interconnect resources", node.Name)
+	if !config.OVNKubernetesFeature.EnableInterconnect {
+		return nil
+	}
+	if err := oc.zoneICHandler.DeleteNode(node); err != nil {
+		return err
+	}
+	if err := oc.zoneChassisHandler.DeleteRemoteZoneNode(node); err != nil {
+		return err
+	}
+	oc.syncZoneICFailed.Delete(node.Name)
+	oc.nodeSubnetsGenerations.Delete(node.Name)
 	return nil
 }
 
+// observeNodeSubnetsGeneration records the generation of the host subnets of
+// the node once processed
+func (oc *DefaultNetworkController) observeNodeSubnetsGeneration(node *kapi.Node) {
+	if generation := util.GetHandshakeGeneration(node, util.HandshakeNodeSubnets); generation > 0 {
+		oc.nodeSubnetsGenerations.Store(node.Name, generation)
+	}
+}
+
+// nodeSubnetsGenerationStale returns true if the host subnets of the node are
+// older than the ones already processed, e.g. annotated by a previous
+// ovnkube-cluster-manager leader still running during an upgrade
+func (oc *DefaultNetworkController) nodeSubnetsGenerationStale(node *kapi.Node) bool {
+	observed, ok := oc.nodeSubnetsGenerations.Load(node.Name)
+	if !ok || !util.HandshakeGenerationStale(node, util.HandshakeNodeSubnets, observed.(int64)) {
+		return false
+	}
+	klog.Infof("Ignoring the host subnets of node %s of generation %d, older than the processed generation %d",
+		node.Name, util.GetHandshakeGeneration(node, util.HandshakeNodeSubnets), observed)
+	return true
+}
+
+func hasNodeFinalizer(node *kapi.Node) bool {
+	for _, finalizer := range node.Finalizers {
+		if finalizer == ovntypes.OvnK8sNodeFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// isNodeDeletionPending returns true if the node is being deleted and its
+// deletion is waiting for the cleanup of its topology
+func isNodeDeletionPending(node *kapi.Node) bool {
+	return node.DeletionTimestamp != nil && hasNodeFinalizer(node)
+}
+
+// ensureNodeFinalizer adds the node finalizer to a local zone node if the
+// feature is enabled, or removes it if the feature was disabled. The finalizer
+// is not added to nodes that are already being deleted.
+func (oc *DefaultNetworkController) ensureNodeFinalizer(node *kapi.Node) error {
+	hasFinalizer := hasNodeFinalizer(node)
+	if hasFinalizer == config.OVNKubernetesFeature.EnableNodeFinalizer || node.DeletionTimestamp != nil {
+		return nil
+	}
+	if hasFinalizer {
+		return oc.removeNodeFinalizer(node)
+	}
+	newNode := node.DeepCopy()
+	newNode.Finalizers = append(newNode.Finalizers, ovntypes.OvnK8sNodeFinalizer)
+	if err := oc.kube.PatchNode(node, newNode); err != nil {
+		return fmt.Errorf("failed to add finalizer to node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+func (oc *DefaultNetworkController) removeNodeFinalizer(node *kapi.Node) error {
+	newNode := node.DeepCopy()
+	newNode.Finalizers = make([]string, 0, len(node.Finalizers))
+	for _, finalizer := range node.Finalizers {
+		if finalizer != ovntypes.OvnK8sNodeFinalizer {
+			newNode.Finalizers = append(newNode.Finalizers, finalizer)
+		}
+	}
+	if err := oc.kube.PatchNode(node, newNode); err != nil {
+		return fmt.Errorf("failed to remove finalizer from node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// cleanupPendingNodeDeletion cleans up the topology of a local zone node whose
+// deletion is waiting on the node finalizer and then removes the finalizer. The
+// cleanup is retried until it succeeds or the cluster manager removes the
+// finalizer once timed out, the cleanup being then retried on the node delete
+// event.
+func (oc *DefaultNetworkController) cleanupPendingNodeDeletion(node *kapi.Node) error {
+	klog.Infof("Node %s is being deleted, cleaning up its topology before removing its finalizer", node.Name)
+	if err := oc.deleteNodeEvent(node); err != nil {
+		return fmt.Errorf("failed to clean up topology of deleted node %s: %w", node.Name, err)
+	}
+	return oc.removeNodeFinalizer(node)
+}
+
 // getOVNClusterRouterPortToJoinSwitchIPs returns the IP addresses for the
 // logical router port "GwRouterToJoinSwitchPrefix + OVNClusterRouter" from the
 // config.Gateway.V4JoinSubnet and  config.Gateway.V6JoinSubnet. This will
@@ -1526,6 +1526,51 @@ var _ = ginkgo.Describe("Default network controller operations", func() {
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
+	ginkgo.It("holds node deletion with a finalizer until the node topology is cleaned up", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := config.InitConfig(ctx, nil, nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			startFakeController(oc, wg)
+
+			getNodeFinalizers := func() []string {
+				node, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), testNode.Name, metav1.GetOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				return node.Finalizers
+			}
+			gomega.Eventually(getNodeFinalizers).Should(gomega.ConsistOf(types.OvnK8sNodeFinalizer))
+			gomega.Eventually(func() error {
+				_, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: node1.Name})
+				return err
+			}).Should(gomega.Succeed())
+
+			ginkgo.By("requesting the deletion of the node")
+			// the fake client doesn't honor finalizers, set the deletion timestamp
+			// as the API server would
+			node, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), testNode.Name, metav1.GetOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			now := metav1.Now()
+			node.DeletionTimestamp = &now
+			_, err = fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Eventually(func() bool {
+				_, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: node1.Name})
+				return errors.Is(err, libovsdbclient.ErrNotFound)
+			}, 10).Should(gomega.BeTrue())
+			gomega.Eventually(getNodeFinalizers).Should(gomega.BeEmpty())
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+			"-cluster-subnets=" + clusterCIDR,
+			"--init-gateways",
+			"--nodeport",
+			"--enable-node-finalizer",
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
 	ginkgo.It("use node retry for a node without a host subnet", func() {
 		app.Action = func(ctx *cli.Context) error {
 			_, err := config.InitConfig(ctx, nil, nil)
@@ -1562,6 +1607,55 @@ var _ = ginkgo.Describe("Default network controller operations", func() {
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
 
+	ginkgo.It("cleans up the topology of a node labeled as not managed by OVN, and adds it back once managed", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := config.InitConfig(ctx, nil, nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			config.Kubernetes.NoHostSubnetNodes = &metav1.LabelSelector{
+				MatchLabels: nodeNoHostSubnetAnnotation(),
+			}
+			startFakeController(oc, wg)
+
+			nodeSwitchExists := func() bool {
+				_, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: node1.Name})
+				return err == nil
+			}
+			gomega.Eventually(nodeSwitchExists, 10).Should(gomega.BeTrue())
+
+			ginkgo.By("labeling the node as not managed by OVN")
+			node, err := fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), testNode.Name, metav1.GetOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			node.Labels = nodeNoHostSubnetAnnotation()
+			_, err = fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Eventually(nodeSwitchExists, 10).Should(gomega.BeFalse())
+			gomega.Eventually(func() bool {
+				_, err = libovsdbops.GetLogicalRouter(nbClient, &nbdb.LogicalRouter{Name: types.GWRouterPrefix + node1.Name})
+				return errors.Is(err, libovsdbclient.ErrNotFound)
+			}, 10).Should(gomega.BeTrue())
+			gomega.Eventually(func() bool { return oc.lsManager.IsNonHostSubnetSwitch(node1.Name) }, 10).Should(gomega.BeTrue())
+
+			ginkgo.By("removing the label")
+			node, err = fakeClient.KubeClient.CoreV1().Nodes().Get(context.TODO(), testNode.Name, metav1.GetOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			node.Labels = nil
+			_, err = fakeClient.KubeClient.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Eventually(nodeSwitchExists, 10).Should(gomega.BeTrue())
+			gomega.Eventually(func() []*net.IPNet { return oc.lsManager.GetSwitchSubnets(node1.Name) }, 10).Should(
+				gomega.Equal(ovntest.MustParseIPNets(node1.NodeSubnet)))
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+			"-cluster-subnets=" + clusterCIDR,
+			"--init-gateways",
+			"--nodeport",
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
 	ginkgo.It("reconciles node host subnets after dual-stack to single-stack downgrade", func() {
 		app.Action = func(ctx *cli.Context) error {
 			_, err := config.InitConfig(ctx, nil, nil)
@@ -2043,3 +2137,67 @@ func TestController_deleteStaleNodeChassis(t *testing.T) {
 		})
 	}
 }
+
+func TestController_syncGARPMaxTimeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		garpMaxTimeout  int
+		initialOptions  map[string]string
+		expectedOptions map[string]string
+	}{
+		{
+			name:            "sets the GARP max timeout",
+			garpMaxTimeout:  60,
+			initialOptions:  map[string]string{"mac_prefix": "0a:58:0a"},
+			expectedOptions: map[string]string{"mac_prefix": "0a:58:0a", garpMaxTimeoutOption: "60"},
+		},
+		{
+			name:            "updates the GARP max timeout",
+			garpMaxTimeout:  30,
+			initialOptions:  map[string]string{garpMaxTimeoutOption: "60"},
+			expectedOptions: map[string]string{garpMaxTimeoutOption: "30"},
+		},
+		{
+			name:            "removes the GARP max timeout when unset",
+			initialOptions:  map[string]string{"mac_prefix": "0a:58:0a", garpMaxTimeoutOption: "60"},
+			expectedOptions: map[string]string{"mac_prefix": "0a:58:0a"},
+		},
+		{
+			name:            "removes an empty GARP max timeout when unset",
+			initialOptions:  map[string]string{"mac_prefix": "0a:58:0a", garpMaxTimeoutOption: ""},
+			expectedOptions: map[string]string{"mac_prefix": "0a:58:0a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.PrepareTestConfig(); err != nil {
+				t.Fatalf("%s: failed to prepare test config: %v", tt.name, err)
+			}
+			config.OVNKubernetesFeature.GARPMaxTimeout = tt.garpMaxTimeout
+
+			nbClient, libovsdbCleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+				NBData: []libovsdbtest.TestData{
+					&nbdb.NBGlobal{UUID: "nb-global-UUID", Options: tt.initialOptions},
+				},
+			}, nil)
+			if err != nil {
+				t.Fatalf("%s: failed to create the NB test harness: %v", tt.name, err)
+			}
+			t.Cleanup(libovsdbCleanup.Cleanup)
+
+			controller := getFakeController(DefaultNetworkControllerName)
+			controller.nbClient = nbClient
+			if err = controller.syncGARPMaxTimeout(); err != nil {
+				t.Fatalf("%s: failed to sync the GARP max timeout: %v", tt.name, err)
+			}
+
+			nbGlobal, err := libovsdbops.GetNBGlobal(nbClient, &nbdb.NBGlobal{})
+			if err != nil {
+				t.Fatalf("%s: failed to get NB_Global: %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(nbGlobal.Options, tt.expectedOptions) {
+				t.Fatalf("%s: expected options %v, got %v", tt.name, tt.expectedOptions, nbGlobal.Options)
+			}
+		})
+	}
+}
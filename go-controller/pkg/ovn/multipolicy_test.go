@@ -17,7 +17,9 @@ import (
 
 	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	addressset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/address_set"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
@@ -485,6 +487,77 @@ var _ = ginkgo.Describe("OVN MultiNetworkPolicy Operations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 
+		ginkgo.It("matches the ipBlocks of a multinetworkPolicy gress with an address set", func() {
+			app.Action = func(ctx *cli.Context) error {
+				var err error
+
+				topology := ovntypes.Layer2Topology
+				subnets := "10.1.0.0/24"
+				setSecondaryNetworkTestData(topology, subnets)
+
+				namespace1 := *newNamespace(namespaceName1)
+				var peers []knet.NetworkPolicyPeer
+				cidrs := []string{"10.2.0.0/24", "10.3.0.0/24", "10.4.0.0/24", "10.5.0.0/24"}
+				for _, cidr := range cidrs {
+					peers = append(peers, knet.NetworkPolicyPeer{IPBlock: &knet.IPBlock{CIDR: cidr}})
+				}
+				peers = append(peers, knet.NetworkPolicyPeer{
+					IPBlock: &knet.IPBlock{CIDR: "10.6.0.0/16", Except: []string{"10.6.1.0/24"}},
+				})
+				policy := newNetworkPolicy(netPolicyName1, namespace1.Name, metav1.LabelSelector{},
+					[]knet.NetworkPolicyIngressRule{{From: peers}}, nil)
+				policy.Annotations = map[string]string{PolicyForAnnotation: nadNamespacedName}
+				mpolicy := convertNetPolicyToMultiNetPolicy(policy)
+
+				node := *newNode(nodeName, "192.168.126.202/24")
+				startOvn(initialDB, false, []v1.Node{node}, []v1.Namespace{namespace1}, nil, nil,
+					[]nettypes.NetworkAttachmentDefinition{*nad}, nil, nil)
+				_, err = fakeOvn.fakeClient.MultiNetworkPolicyClient.K8sCniCncfIoV1beta1().MultiNetworkPolicies(mpolicy.Namespace).
+					Create(context.TODO(), mpolicy, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				ocInfo := fakeOvn.secondaryControllers[secondaryNetworkName]
+				gp := newGressPolicy(knet.PolicyTypeIngress, 0, namespace1.Name, policy.Name,
+					ocInfo.bnc.controllerName, false, netInfo)
+				v4HashName, _ := addressset.GetHashNamesForAS(gp.getIPBlockAddrSetDbIDs())
+				getAddrSetAddresses := func() []string {
+					as, err := libovsdbops.GetAddressSet(fakeOvn.nbClient, &nbdb.AddressSet{Name: v4HashName})
+					if err != nil {
+						return nil
+					}
+					return as.Addresses
+				}
+				gomega.Eventually(getAddrSetAddresses).Should(gomega.ConsistOf(cidrs))
+
+				// the ipBlocks address set and the ipBlock with exceptions have an ACL each
+				predicateIDs := libovsdbops.NewDbObjectIDs(libovsdbops.ACLNetworkPolicy, ocInfo.bnc.controllerName,
+					map[libovsdbops.ExternalIDKey]string{
+						libovsdbops.ObjectNameKey: getACLPolicyKey(namespace1.Name, policy.Name),
+					})
+				acls, err := libovsdbops.FindACLsWithPredicate(fakeOvn.nbClient,
+					libovsdbops.GetPredicate[*nbdb.ACL](predicateIDs, nil))
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(acls).To(gomega.HaveLen(2))
+				matches := map[string]string{}
+				for _, acl := range acls {
+					matches[acl.ExternalIDs[libovsdbops.IpBlockIndexKey.String()]] = acl.Match
+				}
+				gomega.Expect(matches).To(gomega.HaveKeyWithValue("-2", gomega.HavePrefix("ip4.src == $"+v4HashName+" && ")))
+				gomega.Expect(matches).To(gomega.HaveKeyWithValue("4",
+					gomega.HavePrefix("ip4.src == 10.6.0.0/16 && ip4.src != {10.6.1.0/24} && ")))
+
+				ginkgo.By("Deleting the multi network policy deletes the address set")
+				err = fakeOvn.fakeClient.MultiNetworkPolicyClient.K8sCniCncfIoV1beta1().MultiNetworkPolicies(mpolicy.Namespace).
+					Delete(context.TODO(), mpolicy.Name, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getAddrSetAddresses).Should(gomega.BeNil())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
 		ginkgo.It("correctly creates and deletes network policy and multi network policy with the same policy", func() {
 			app.Action = func(ctx *cli.Context) error {
 				var err error
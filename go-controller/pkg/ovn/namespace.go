@@ -137,12 +137,43 @@ func (oc *DefaultNetworkController) configureNamespace(nsInfo *namespaceInfo, ns
 		}
 	}
 
+	if annotation, ok := ns.Annotations[util.ProviderNetworkEgressAnnotation]; ok {
+		pne, err := util.ParseProviderNetworkEgressAnnotation(annotation)
+		if err != nil {
+			errors = append(errors, err)
+		} else {
+			nsInfo.providerNetworkEgress = pne
+			if err = oc.syncProviderNetworkEgress(ns.Name, nsInfo); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
 	if err := oc.configureNamespaceCommon(nsInfo, ns); err != nil {
 		errors = append(errors, err)
 	}
 	return kerrors.NewAggregate(errors)
 }
 
+// syncDefaultNetworkNamespaces syncs the namespaces and removes the provider
+// network egresses of the namespaces that no longer have one
+func (oc *DefaultNetworkController) syncDefaultNetworkNamespaces(namespaces []interface{}) error {
+	if err := oc.syncNamespaces(namespaces); err != nil {
+		return err
+	}
+	nsWithProviderNetworkEgress := sets.New[string]()
+	for _, nsInterface := range namespaces {
+		ns, ok := nsInterface.(*kapi.Namespace)
+		if !ok {
+			return fmt.Errorf("spurious object in syncDefaultNetworkNamespaces: %v", nsInterface)
+		}
+		if _, ok := ns.Annotations[util.ProviderNetworkEgressAnnotation]; ok {
+			nsWithProviderNetworkEgress.Insert(ns.Name)
+		}
+	}
+	return oc.syncProviderNetworkEgresses(nsWithProviderNetworkEgress)
+}
+
 func (oc *DefaultNetworkController) updateNamespace(old, newer *kapi.Namespace) error {
 	var errors []error
 	klog.Infof("[%s] updating namespace", old.Name)
@@ -252,6 +283,23 @@ func (oc *DefaultNetworkController) updateNamespace(old, newer *kapi.Namespace)
 		}
 	}
 
+	pneAnnotation := newer.Annotations[util.ProviderNetworkEgressAnnotation]
+	if pneAnnotation != old.Annotations[util.ProviderNetworkEgressAnnotation] {
+		var pne *util.ProviderNetworkEgress
+		var err error
+		if pneAnnotation != "" {
+			pne, err = util.ParseProviderNetworkEgressAnnotation(pneAnnotation)
+		}
+		if err != nil {
+			errors = append(errors, err)
+		} else {
+			nsInfo.providerNetworkEgress = pne
+			if err = oc.syncProviderNetworkEgress(old.Name, nsInfo); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
 	if err := oc.multicastUpdateNamespace(newer, nsInfo); err != nil {
 		errors = append(errors, err)
 	}
@@ -273,6 +321,11 @@ func (oc *DefaultNetworkController) deleteNamespace(ns *kapi.Namespace) error {
 	if err := oc.multicastDeleteNamespace(ns, nsInfo); err != nil {
 		return fmt.Errorf("failed to delete multicast namespace error %v", err)
 	}
+	if nsInfo.providerNetworkEgress != nil {
+		if err := oc.ensureProviderNetworkEgress(ns.Name, nil); err != nil {
+			return fmt.Errorf("failed to delete provider network egress for namespace: %s, error: %v", ns.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -299,12 +352,12 @@ func (oc *DefaultNetworkController) getAllHostNamespaceAddresses() []net.IP {
 	} else {
 		ips = make([]net.IP, 0, len(existingNodes))
 		for _, node := range existingNodes {
-			hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node, types.DefaultNetworkName)
+			hostSubnets, err := oc.getNodeHostSubnets(node)
 			if err != nil {
 				klog.Warningf("Error parsing host subnet annotation for node %s (%v)",
 					node.Name, err)
 			}
-			for _, hostSubnet := range hostSubnets {
+			for _, hostSubnet := range util.PrimaryHostSubnets(hostSubnets) {
 				mgmtIfAddr := util.GetNodeManagementIfAddr(hostSubnet)
 				ips = append(ips, mgmtIfAddr.IP)
 			}
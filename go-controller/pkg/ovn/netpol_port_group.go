@@ -0,0 +1,198 @@
+package ovn
+
+import (
+	"errors"
+	"fmt"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	libovsdbutil "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+
+	knet "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// netpolPortGroup is the port group of the local pods shared by the network
+// policies of a namespace with equivalent pod selectors, when
+// EnableSharedNetpolPortGroups is set. Templated policies, like the ones
+// allowing the traffic from the monitoring or ingress namespaces to all the
+// pods of every namespace, then use a single port group per namespace.
+// Every policy applies its ACLs to the port group, and its local pod handler
+// adds and deletes the pods it selects, which are the same for all of them.
+// The port group is deleted with the last policy using it.
+// netpolPortGroup should always be accessed with bnc.netpolPortGroups key lock.
+type netpolPortGroup struct {
+	name string
+	// backRefs are the keys of the network policies using the port group,
+	// see networkPolicy.getKeyWithKind
+	backRefs map[string]bool
+}
+
+// useSharedNamespaceSelectorPeer returns whether the pods of the namespaces
+// selected by a network policy peer with a non-empty namespace selector and no
+// pod selector are matched with the pod selector address set shared by all the
+// policies with an equivalent namespace selector, when
+// EnableSharedNetpolPortGroups is set. Otherwise every policy watches the
+// selected namespaces itself and matches all their address sets. The shared
+// address set only has the pod IPs, so the peers selecting the host network
+// namespace keep matching its address set.
+func (bnc *BaseNetworkController) useSharedNamespaceSelectorPeer(peer knet.NetworkPolicyPeer) bool {
+	if !config.OVNKubernetesFeature.EnableSharedNetpolPortGroups || peer.NamespaceSelector == nil ||
+		(peer.PodSelector != nil && len(peer.PodSelector.MatchLabels)+len(peer.PodSelector.MatchExpressions) > 0) {
+		return false
+	}
+	if config.Kubernetes.HostNetworkNamespace == "" {
+		return true
+	}
+	hostNetworkNamespace, err := bnc.watchFactory.GetNamespace(config.Kubernetes.HostNetworkNamespace)
+	if err != nil {
+		return true
+	}
+	nsSel, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+	return err == nil && !nsSel.Matches(labels.Set(hostNetworkNamespace.Labels))
+}
+
+func getNetpolPortGroupKey(namespace string, podSelector *metav1.LabelSelector) string {
+	return namespace + "_" + shortLabelSelectorString(podSelector)
+}
+
+// getSharedNetworkPolicyPGName returns the name of the port group shared by
+// the network policies with the given key, and its readable name
+func (bnc *BaseNetworkController) getSharedNetworkPolicyPGName(pgKey string) (pgName, readablePGName string) {
+	return libovsdbutil.HashedPortGroup(bnc.GetNetworkScopedName(pgKey)), pgKey
+}
+
+// createNetworkPolicyPortGroup transacts ops with the operations to create the
+// port group of the local pods of the network policy with its ACLs, or to add
+// its ACLs to the port group shared with the other policies of the namespace
+// with an equivalent pod selector when np.portGroupKey is set. The port group
+// of the policy left by ovnkube-controller running with the other
+// EnableSharedNetpolPortGroups value is cleaned up.
+func (bnc *BaseNetworkController) createNetworkPolicyPortGroup(np *networkPolicy, podSelector *metav1.LabelSelector,
+	acls []*nbdb.ACL, ops []ovsdb.Operation) error {
+	var err error
+	if np.portGroupKey == "" {
+		sharedPGName, _ := bnc.getSharedNetworkPolicyPGName(getNetpolPortGroupKey(np.namespace, podSelector))
+		ops, err = bnc.deletePolicyACLsFromPortGroupOps(ops, sharedPGName, acls)
+		if err != nil {
+			return err
+		}
+		_, readableGroupName := bnc.getNetworkPolicyPGName(np.namespace, np.name)
+		pg := bnc.buildPortGroup(np.portGroupName, readableGroupName, nil, acls)
+		ops, err = libovsdbops.CreateOrUpdatePortGroupsOps(bnc.nbClient, ops, pg)
+		if err != nil {
+			return fmt.Errorf("failed to create ops to add port to a port group: %v", err)
+		}
+		_, err = libovsdbops.TransactAndCheck(bnc.nbClient, ops)
+		if err != nil {
+			return fmt.Errorf("failed to run ovsdb txn to add ports to port group: %v", err)
+		}
+		return nil
+	}
+
+	return bnc.netpolPortGroups.DoWithLock(np.portGroupKey, func(pgKey string) error {
+		sharedPG, found := bnc.netpolPortGroups.Load(pgKey)
+		if found {
+			ops, err = libovsdbops.AddACLsToPortGroupOps(bnc.nbClient, ops, sharedPG.name, acls...)
+			if err != nil {
+				return fmt.Errorf("failed to create ops to add ACLs to port group %s: %v", sharedPG.name, err)
+			}
+		} else {
+			// the port group is reset like the port group of a policy, the
+			// other policies using it add their ACLs and pods back
+			sharedPG = &netpolPortGroup{
+				name:     np.portGroupName,
+				backRefs: map[string]bool{},
+			}
+			_, readableGroupName := bnc.getSharedNetworkPolicyPGName(pgKey)
+			pg := bnc.buildPortGroup(sharedPG.name, readableGroupName, nil, acls)
+			ops, err = libovsdbops.CreateOrUpdatePortGroupsOps(bnc.nbClient, ops, pg)
+			if err != nil {
+				return fmt.Errorf("failed to create ops to add port to a port group: %v", err)
+			}
+		}
+		// only this policy used its own port group
+		pgName, _ := bnc.getNetworkPolicyPGName(np.namespace, np.name)
+		ops, err = libovsdbops.DeletePortGroupsOps(bnc.nbClient, ops, pgName)
+		if err != nil {
+			return fmt.Errorf("failed to get delete network policy port group %s ops: %v", pgName, err)
+		}
+		_, err = libovsdbops.TransactAndCheck(bnc.nbClient, ops)
+		if err != nil {
+			return fmt.Errorf("failed to run ovsdb txn to add ports to port group: %v", err)
+		}
+		bnc.netpolPortGroups.LoadOrStore(pgKey, sharedPG)
+		sharedPG.backRefs[np.getKeyWithKind()] = true
+		return nil
+	})
+}
+
+// deleteNetworkPolicyPortGroupOps returns the operations to delete the port
+// group of the local pods of the network policy, or its ACLs from the port
+// group shared with other policies, and the function to call once they are
+// transacted. The key of the shared port group must be locked.
+func (bnc *BaseNetworkController) deleteNetworkPolicyPortGroupOps(np *networkPolicy,
+	ops []ovsdb.Operation) ([]ovsdb.Operation, func(), error) {
+	var err error
+	if np.portGroupKey == "" {
+		ops, err = libovsdbops.DeletePortGroupsOps(bnc.nbClient, ops, np.portGroupName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get delete network policy port group %s ops: %v", np.portGroupName, err)
+		}
+		return ops, func() {}, nil
+	}
+	sharedPG, found := bnc.netpolPortGroups.Load(np.portGroupKey)
+	if !found {
+		return ops, func() {}, nil
+	}
+	backRef := np.getKeyWithKind()
+	if len(sharedPG.backRefs) == 0 || (len(sharedPG.backRefs) == 1 && sharedPG.backRefs[backRef]) {
+		ops, err = libovsdbops.DeletePortGroupsOps(bnc.nbClient, ops, sharedPG.name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get delete network policy port group %s ops: %v", sharedPG.name, err)
+		}
+		return ops, func() { bnc.netpolPortGroups.Delete(np.portGroupKey) }, nil
+	}
+	predicateIDs := libovsdbops.NewDbObjectIDs(libovsdbops.ACLNetworkPolicy, bnc.controllerName, map[libovsdbops.ExternalIDKey]string{
+		libovsdbops.ObjectNameKey: getACLPolicyKey(np.namespace, np.name),
+	})
+	p := libovsdbops.GetPredicate[*nbdb.ACL](predicateIDs, nil)
+	acls, err := libovsdbops.FindACLsWithPredicate(bnc.nbClient, p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find the ACLs of network policy %s: %v", np.getKey(), err)
+	}
+	ops, err = libovsdbops.DeleteACLsFromPortGroupOps(bnc.nbClient, ops, sharedPG.name, acls...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get delete ACLs from port group %s ops: %v", sharedPG.name, err)
+	}
+	return ops, func() { delete(sharedPG.backRefs, backRef) }, nil
+}
+
+// deletePolicyACLsFromPortGroupOps returns the operations to delete the ACLs
+// from the port group, or the port group if it only has these ACLs
+func (bnc *BaseNetworkController) deletePolicyACLsFromPortGroupOps(ops []ovsdb.Operation, pgName string,
+	acls []*nbdb.ACL) ([]ovsdb.Operation, error) {
+	pg, err := libovsdbops.GetPortGroup(bnc.nbClient, &nbdb.PortGroup{Name: pgName})
+	if err != nil {
+		if errors.Is(err, libovsdbclient.ErrNotFound) {
+			return ops, nil
+		}
+		return nil, fmt.Errorf("failed to get port group %s: %v", pgName, err)
+	}
+	pgACLs := sets.New[string](pg.ACLs...)
+	var pgPolicyACLs []*nbdb.ACL
+	for _, acl := range acls {
+		if pgACLs.Has(acl.UUID) {
+			pgPolicyACLs = append(pgPolicyACLs, acl)
+		}
+	}
+	if len(pgPolicyACLs) == pgACLs.Len() {
+		return libovsdbops.DeletePortGroupsOps(bnc.nbClient, ops, pgName)
+	}
+	return libovsdbops.DeleteACLsFromPortGroupOps(bnc.nbClient, ops, pgName, pgPolicyACLs...)
+}
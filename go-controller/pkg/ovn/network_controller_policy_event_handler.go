@@ -39,6 +39,8 @@ func (bnc *BaseNetworkController) newNetpolRetryFramework(
 		HasUpdateFunc:          hasPolicyResourceAnUpdateFunc(objectType),
 		NeedsUpdateDuringRetry: needsPolicyResourceUpdateDuringRetry(objectType),
 		ObjType:                objectType,
+		MaxFailedAttempts:      maxFailedAttempts(objectType),
+		NetworkName:            bnc.GetNetworkName(),
 		EventHandler:           eventHandler,
 	}
 	return retry.NewRetryFramework(
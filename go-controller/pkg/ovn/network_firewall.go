@@ -0,0 +1,150 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	libovsdbutil "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilnet "k8s.io/utils/net"
+)
+
+// networkFirewallDefaultAllowIndex is the rule index of the ACLs allowing the
+// traffic not matching any rule of the network firewall
+const networkFirewallDefaultAllowIndex = "default"
+
+func getNetworkFirewallACLDbIDs(aclDir libovsdbutil.ACLDirection, ruleIndex, controller string) *libovsdbops.DbObjectIDs {
+	return libovsdbops.NewDbObjectIDs(libovsdbops.ACLNetworkFirewall, controller,
+		map[libovsdbops.ExternalIDKey]string{
+			libovsdbops.PolicyDirectionKey: string(aclDir),
+			libovsdbops.RuleIndex:          ruleIndex,
+		})
+}
+
+// getNetworkFirewallMatch matches the traffic of the pods of the network from
+// (egress) or to (ingress) the given CIDR. Neighbor discovery is never
+// filtered.
+func (bnc *BaseNetworkController) getNetworkFirewallMatch(cidr *net.IPNet, aclDir libovsdbutil.ACLDirection) string {
+	isIPv6 := utilnet.IsIPv6CIDR(cidr)
+	ipVersion := "ip4"
+	if isIPv6 {
+		ipVersion = "ip6"
+	}
+	subnets := []string{}
+	for _, subnet := range bnc.Subnets() {
+		if utilnet.IsIPv6CIDR(subnet.CIDR) == isIPv6 {
+			subnets = append(subnets, subnet.CIDR.String())
+		}
+	}
+	networkSubnets := "{" + strings.Join(subnets, ", ") + "}"
+
+	var match string
+	if aclDir == libovsdbutil.ACLIngress {
+		match = fmt.Sprintf("%s.src == %s && %s.dst == %s", ipVersion, cidr, ipVersion, networkSubnets)
+	} else {
+		match = fmt.Sprintf("%s.src == %s && %s.dst == %s", ipVersion, networkSubnets, ipVersion, cidr)
+	}
+	if isIPv6 {
+		match = "!nd && " + match
+	}
+	return match
+}
+
+// getNetworkFirewallACLs builds the ACLs of the firewall of the network:
+//   - one ACL per rule, in the order of the rules, passing the allowed traffic
+//     to the multi network policies and dropping the denied one.
+//   - one ACL per direction allowing the traffic not matching any rule, which
+//     also allows the replies of the allowed connections.
+func (bnc *BaseNetworkController) getNetworkFirewallACLs() []*nbdb.ACL {
+	firewall := bnc.Firewall()
+	if firewall == nil {
+		return nil
+	}
+	acls := make([]*nbdb.ACL, 0, len(firewall.Ingress)+len(firewall.Egress)+2)
+	for _, aclDir := range []libovsdbutil.ACLDirection{libovsdbutil.ACLIngress, libovsdbutil.ACLEgress} {
+		rules := firewall.Ingress
+		if aclDir == libovsdbutil.ACLEgress {
+			rules = firewall.Egress
+		}
+		aclPipeline := libovsdbutil.ACLDirectionToACLPipeline(aclDir)
+		for i, rule := range rules {
+			action := nbdb.ACLActionDrop
+			if rule.Allow {
+				action = nbdb.ACLActionPass
+			}
+			dbIDs := getNetworkFirewallACLDbIDs(aclDir, strconv.Itoa(i), bnc.controllerName)
+			acl := libovsdbutil.BuildACL(dbIDs, types.NetworkFirewallStartPriority-i,
+				bnc.getNetworkFirewallMatch(rule.CIDR, aclDir), action, nil, aclPipeline)
+			acl.Tier = types.NetworkFirewallACLTier
+			acls = append(acls, acl)
+		}
+		dbIDs := getNetworkFirewallACLDbIDs(aclDir, networkFirewallDefaultAllowIndex, bnc.controllerName)
+		acls = append(acls, libovsdbutil.BuildACL(dbIDs, types.NetworkFirewallDefaultAllowPriority, "ip",
+			nbdb.ACLActionAllowRelated, nil, aclPipeline))
+	}
+	return acls
+}
+
+// ensureNetworkFirewall applies the firewall of the secondary network to the
+// given logical switch of the network, removing from it the ACLs of a previous
+// firewall of the network.
+func (bnc *BaseNetworkController) ensureNetworkFirewall(switchName string) error {
+	if !bnc.IsSecondary() {
+		return nil
+	}
+	predicateIDs := libovsdbops.NewDbObjectIDs(libovsdbops.ACLNetworkFirewall, bnc.controllerName, nil)
+	if err := bnc.ensureSwitchACLs(switchName, predicateIDs, bnc.getNetworkFirewallACLs()); err != nil {
+		return fmt.Errorf("failed to apply the network firewall of network %s to switch %s: %v",
+			bnc.GetNetworkName(), switchName, err)
+	}
+	return nil
+}
+
+// ensureSwitchACLs adds the given ACLs to the logical switch, removing from it
+// the existing ACLs matching the predicate IDs that are not part of them
+func (bnc *BaseNetworkController) ensureSwitchACLs(switchName string, predicateIDs *libovsdbops.DbObjectIDs, acls []*nbdb.ACL) error {
+	existingACLs, err := libovsdbops.FindACLsWithPredicate(bnc.nbClient, libovsdbops.GetPredicate[*nbdb.ACL](predicateIDs, nil))
+	if err != nil {
+		return fmt.Errorf("unable to find the existing ACLs: %v", err)
+	}
+	if len(acls) == 0 && len(existingACLs) == 0 {
+		return nil
+	}
+
+	aclIDs := sets.New[string]()
+	for _, acl := range acls {
+		aclIDs.Insert(acl.ExternalIDs[libovsdbops.PrimaryIDKey.String()])
+	}
+	staleACLs := []*nbdb.ACL{}
+	for _, acl := range existingACLs {
+		if !aclIDs.Has(acl.ExternalIDs[libovsdbops.PrimaryIDKey.String()]) {
+			staleACLs = append(staleACLs, acl)
+		}
+	}
+
+	ops, err := libovsdbops.CreateOrUpdateACLsOps(bnc.nbClient, nil, acls...)
+	if err != nil {
+		return err
+	}
+	if len(acls) > 0 {
+		ops, err = libovsdbops.AddACLsToLogicalSwitchOps(bnc.nbClient, ops, switchName, acls...)
+		if err != nil {
+			return err
+		}
+	}
+	if len(staleACLs) > 0 {
+		ops, err = libovsdbops.RemoveACLsFromLogicalSwitchesWithPredicateOps(bnc.nbClient, ops,
+			func(item *nbdb.LogicalSwitch) bool { return item.Name == switchName }, staleACLs...)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = libovsdbops.TransactAndCheck(bnc.nbClient, ops)
+	return err
+}
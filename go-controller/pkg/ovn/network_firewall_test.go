@@ -0,0 +1,98 @@
+package ovn
+
+import (
+	"testing"
+
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/onsi/gomega"
+)
+
+func TestEnsureNetworkFirewall(t *testing.T) {
+	g := gomega.NewWithT(t)
+	newNetInfo := func(firewall *ovncnitypes.NetworkFirewall) util.NetInfo {
+		netInfo, err := util.NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "blue"},
+			Topology: types.Layer2Topology,
+			Subnets:  "10.1.0.0/16,fd00:10:1::/64",
+			Firewall: firewall,
+		})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return netInfo
+	}
+	sw := &nbdb.LogicalSwitch{UUID: "switch-UUID", Name: "blue_ovn_layer2_switch"}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{sw},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+
+	bnc := &BaseNetworkController{
+		CommonNetworkControllerInfo: CommonNetworkControllerInfo{nbClient: nbClient},
+		controllerName:              "blue-network-controller",
+		NetInfo: newNetInfo(&ovncnitypes.NetworkFirewall{
+			Ingress: []ovncnitypes.NetworkFirewallRule{
+				{Action: ovncnitypes.NetworkFirewallActionAllow, CIDR: "10.2.1.0/24"},
+				{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "10.2.0.0/16"},
+			},
+			Egress: []ovncnitypes.NetworkFirewallRule{
+				{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "fd00:10:2::/48"},
+			},
+		}),
+	}
+	switchACLs := func() map[string]*nbdb.ACL {
+		s, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: sw.Name})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		acls := map[string]*nbdb.ACL{}
+		for _, uuid := range s.ACLs {
+			found, err := libovsdbops.FindACLs(nbClient, []*nbdb.ACL{{UUID: uuid}})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(found).To(gomega.HaveLen(1))
+			acls[found[0].ExternalIDs[libovsdbops.PolicyDirectionKey.String()]+":"+
+				found[0].ExternalIDs[libovsdbops.RuleIndex.String()]] = found[0]
+		}
+		return acls
+	}
+
+	g.Expect(bnc.ensureNetworkFirewall(sw.Name)).To(gomega.Succeed())
+	acls := switchACLs()
+	g.Expect(acls).To(gomega.HaveLen(5))
+	g.Expect(acls["Ingress:0"].Match).To(gomega.Equal("ip4.src == 10.2.1.0/24 && ip4.dst == {10.1.0.0/16}"))
+	g.Expect(acls["Ingress:0"].Action).To(gomega.Equal(nbdb.ACLActionPass))
+	g.Expect(acls["Ingress:0"].Priority).To(gomega.Equal(types.NetworkFirewallStartPriority))
+	g.Expect(acls["Ingress:0"].Tier).To(gomega.Equal(types.NetworkFirewallACLTier))
+	g.Expect(acls["Ingress:1"].Action).To(gomega.Equal(nbdb.ACLActionDrop))
+	g.Expect(acls["Ingress:1"].Priority).To(gomega.Equal(types.NetworkFirewallStartPriority - 1))
+	g.Expect(acls["Egress:0"].Match).To(gomega.Equal("!nd && ip6.src == {fd00:10:1::/64} && ip6.dst == fd00:10:2::/48"))
+	g.Expect(acls["Egress:0"].Direction).To(gomega.Equal(nbdb.ACLDirectionFromLport))
+	for _, aclDir := range []string{"Ingress", "Egress"} {
+		defaultACL := acls[aclDir+":"+networkFirewallDefaultAllowIndex]
+		g.Expect(defaultACL).NotTo(gomega.BeNil())
+		g.Expect(defaultACL.Action).To(gomega.Equal(nbdb.ACLActionAllowRelated))
+		g.Expect(defaultACL.Tier).To(gomega.Equal(types.DefaultACLTier))
+	}
+
+	// the rules of a previous firewall are removed
+	bnc.NetInfo = newNetInfo(&ovncnitypes.NetworkFirewall{
+		Ingress: []ovncnitypes.NetworkFirewallRule{
+			{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "10.3.0.0/16"},
+		},
+	})
+	g.Expect(bnc.ensureNetworkFirewall(sw.Name)).To(gomega.Succeed())
+	acls = switchACLs()
+	g.Expect(acls).To(gomega.HaveLen(3))
+	g.Expect(acls["Ingress:0"].Match).To(gomega.Equal("ip4.src == 10.3.0.0/16 && ip4.dst == {10.1.0.0/16}"))
+	g.Expect(acls["Ingress:0"].Action).To(gomega.Equal(nbdb.ACLActionDrop))
+
+	// and all of them once the network has no firewall
+	bnc.NetInfo = newNetInfo(nil)
+	g.Expect(bnc.ensureNetworkFirewall(sw.Name)).To(gomega.Succeed())
+	g.Expect(switchACLs()).To(gomega.BeEmpty())
+}
@@ -0,0 +1,115 @@
+package ovn
+
+import (
+	"net"
+	"reflect"
+
+	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// useNodeNetworkState returns whether the host subnets of the nodes are read
+// from their NodeNetworkState rather than from the node subnet annotation.
+// Only the default network controller, which resyncs the nodes whose state
+// changes, reads them from there.
+func (bnc *BaseNetworkController) useNodeNetworkState() bool {
+	return util.UseNodeNetworkState(bnc.GetNetworkName())
+}
+
+// getNodeHostSubnets returns the host subnets of the node for the network of
+// the controller, read from the node network state with the "crd" backend.
+// A node whose host subnets are not allocated yet returns an annotation not
+// set error either way.
+func (bnc *BaseNetworkController) getNodeHostSubnets(node *kapi.Node) ([]*net.IPNet, error) {
+	return util.ParseNodeHostSubnets(node, bnc.GetNetworkName(), bnc.watchFactory)
+}
+
+// WatchNodeNetworkStates resyncs the nodes whose host subnets changed in their
+// node network state, or whose node network state is created or deleted.
+// No-op unless the host subnets are read from there.
+func (oc *DefaultNetworkController) WatchNodeNetworkStates() error {
+	if !oc.useNodeNetworkState() {
+		return nil
+	}
+	// the existing node network states were read when the nodes were synced,
+	// their initial add events need no resync
+	existing := map[string]string{}
+	_, err := oc.watchFactory.AddNodeNetworkStateHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			state, ok := obj.(*nodenetworkstatev1.NodeNetworkState)
+			if !ok {
+				return
+			}
+			if existing[state.Name] == state.ResourceVersion {
+				return
+			}
+			oc.resyncNodeHostSubnets(state.Name)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldState, ok := old.(*nodenetworkstatev1.NodeNetworkState)
+			if !ok {
+				return
+			}
+			newState, ok := new.(*nodenetworkstatev1.NodeNetworkState)
+			if !ok {
+				return
+			}
+			if reflect.DeepEqual(oldState.Spec.Networks[types.DefaultNetworkName].Subnets,
+				newState.Spec.Networks[types.DefaultNetworkName].Subnets) {
+				return
+			}
+			oc.resyncNodeHostSubnets(newState.Name)
+		},
+		DeleteFunc: func(obj interface{}) {
+			state, ok := obj.(*nodenetworkstatev1.NodeNetworkState)
+			if !ok {
+				return
+			}
+			// the node is resynced to clean up what depends on its host
+			// subnets, unless it is deleted too
+			oc.resyncNodeHostSubnets(state.Name)
+		},
+	}, func(objs []interface{}) error {
+		for _, obj := range objs {
+			if state, ok := obj.(*nodenetworkstatev1.NodeNetworkState); ok {
+				existing[state.Name] = state.ResourceVersion
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// resyncNodeHostSubnets retries the node setup that depends on its host
+// subnets, like a change of its node subnet annotation does
+func (oc *DefaultNetworkController) resyncNodeHostSubnets(nodeName string) {
+	node, err := oc.watchFactory.GetNode(nodeName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to get node %s whose network state changed: %v", nodeName, err)
+		}
+		return
+	}
+	klog.Infof("Host subnets of node %s changed in its network state, resyncing it", nodeName)
+	oc.svcController.NodeHostSubnetsChanged(node)
+	if oc.isLocalZoneNode(node) {
+		oc.addNodeFailed.Store(nodeName, true)
+		oc.nodeClusterRouterPortFailed.Store(nodeName, true)
+		oc.mgmtPortFailed.Store(nodeName, true)
+		oc.gatewaysFailed.Store(nodeName, true)
+		oc.syncMigratablePodsFailed.Store(nodeName, true)
+	} else {
+		oc.syncZoneICFailed.Store(nodeName, true)
+	}
+	if err := oc.retryNodes.AddRetryObjWithAddNoBackoff(node); err != nil {
+		klog.Errorf("Failed to retry node %s whose network state changed: %v", nodeName, err)
+		return
+	}
+	oc.retryNodes.RequestRetryObjs()
+}
@@ -125,11 +125,24 @@ func (oc *DefaultNetworkController) ensurePod(oldPod, pod *kapi.Pod, addPort boo
 
 	if oc.isPodScheduledinLocalZone(pod) {
 		klog.V(5).Infof("Ensuring zone local for Pod %s/%s in node %s", pod.Namespace, pod.Name, pod.Spec.NodeName)
-		return oc.ensureLocalZonePod(oldPod, pod, addPort)
+		if err := oc.ensureLocalZonePod(oldPod, pod, addPort); err != nil {
+			return err
+		}
+	} else {
+		klog.V(5).Infof("Ensuring zone remote for Pod %s/%s in node %s", pod.Namespace, pod.Name, pod.Spec.NodeName)
+		if err := oc.ensureRemoteZonePod(oldPod, pod, addPort); err != nil {
+			return err
+		}
 	}
 
-	klog.V(5).Infof("Ensuring zone remote for Pod %s/%s in node %s", pod.Namespace, pod.Name, pod.Spec.NodeName)
-	return oc.ensureRemoteZonePod(oldPod, pod, addPort)
+	// the pod IPs are SNATed on the egress node of the provider network egress
+	// of the namespace, if any
+	if addPort || oldPod == nil || oldPod.Annotations[util.OvnPodAnnotationName] != pod.Annotations[util.OvnPodAnnotationName] {
+		if err := oc.syncPodProviderNetworkEgress(pod); err != nil {
+			return fmt.Errorf("failed to sync the provider network egress of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+	return nil
 }
 
 // ensureLocalZonePod tries to set up a local zone pod. It returns nil on success and error on failure; failure
@@ -169,7 +182,16 @@ func (oc *DefaultNetworkController) ensureLocalZonePod(oldPod, pod *kapi.Pod, ad
 		}
 	}
 
-	if kubevirt.IsPodLiveMigratable(pod) {
+	if addPort || oldPod == nil || oldPod.Annotations[util.OvnPodAnnotationName] != pod.Annotations[util.OvnPodAnnotationName] ||
+		!reflect.DeepEqual(util.PodHostPorts(oldPod), util.PodHostPorts(pod)) || util.PodCompleted(oldPod) != util.PodCompleted(pod) {
+		if err := oc.syncPodHostPorts(pod); err != nil {
+			return fmt.Errorf("failed to sync the host ports of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	// the pods keep their IPs on the switch of the layer2 topology, whose
+	// egress routes follow them
+	if kubevirt.IsPodLiveMigratable(pod) && oc.TopologyType() == ovntypes.Layer3Topology {
 		return kubevirt.EnsureLocalZonePodAddressesToNodeRoute(oc.watchFactory, oc.nbClient, oc.lsManager, pod, ovntypes.DefaultNetworkName)
 	}
 
@@ -228,6 +250,9 @@ func (oc *DefaultNetworkController) removePod(pod *kapi.Pod, portInfo *lpInfo) e
 			return err
 		}
 	}
+	if err := oc.syncPodProviderNetworkEgress(pod); err != nil {
+		return fmt.Errorf("failed to sync the provider network egress of pod %s: %w", getPodNamespacedName(pod), err)
+	}
 
 	return kubevirt.CleanUpLiveMigratablePod(oc.nbClient, oc.watchFactory, pod)
 }
@@ -255,6 +280,9 @@ func (oc *DefaultNetworkController) removeLocalZonePod(pod *kapi.Pod, portInfo *
 		return fmt.Errorf("deleteLogicalPort failed for pod %s: %w",
 			getPodNamespacedName(pod), err)
 	}
+	if err := oc.deletePodHostPorts(pod.Namespace, pod.Name, pod.Spec.NodeName); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -340,8 +368,10 @@ func (oc *DefaultNetworkController) syncNodeGateway(node *kapi.Node, hostSubnets
 		return err
 	}
 
-	if hostSubnets == nil {
-		hostSubnets, err = util.ParseNodeHostSubnetAnnotation(node, ovntypes.DefaultNetworkName)
+	// the nodes of the layer2 topology have no host subnet
+	layer2 := oc.TopologyType() == ovntypes.Layer2Topology
+	if hostSubnets == nil && !layer2 {
+		hostSubnets, err = oc.getNodeHostSubnets(node)
 		if err != nil {
 			return err
 		}
@@ -351,7 +381,7 @@ func (oc *DefaultNetworkController) syncNodeGateway(node *kapi.Node, hostSubnets
 		if err := oc.gatewayCleanup(node.Name); err != nil {
 			return fmt.Errorf("error cleaning up gateway for node %s: %v", node.Name, err)
 		}
-	} else if hostSubnets != nil {
+	} else if hostSubnets != nil || layer2 {
 		var hostAddrs sets.Set[string]
 		if config.Gateway.Mode == config.GatewayModeShared {
 			hostAddrs, err = util.ParseNodeHostAddressesDropNetMask(node)
@@ -387,9 +417,15 @@ func macAddressChanged(oldNode, node *kapi.Node) bool {
 	return !bytes.Equal(oldMacAddress, macAddress)
 }
 
-func nodeSubnetChanged(oldNode, node *kapi.Node) bool {
-	oldSubnets, _ := util.ParseNodeHostSubnetAnnotation(oldNode, ovntypes.DefaultNetworkName)
-	newSubnets, _ := util.ParseNodeHostSubnetAnnotation(node, ovntypes.DefaultNetworkName)
+// nodeSubnetChanged returns true if the node subnet annotation of the node
+// changed for the given network. Changes of host subnets stored in the node
+// network state are not seen here but resync the node on their own.
+func nodeSubnetChanged(oldNode, node *kapi.Node, netName string) bool {
+	if util.UseNodeNetworkState(netName) {
+		return false
+	}
+	oldSubnets, _ := util.ParseNodeHostSubnetAnnotation(oldNode, netName)
+	newSubnets, _ := util.ParseNodeHostSubnetAnnotation(node, netName)
 	return !reflect.DeepEqual(oldSubnets, newSubnets)
 }
 
@@ -405,21 +441,16 @@ func nodeGatewayMTUSupportChanged(oldNode, node *kapi.Node) bool {
 }
 
 // shouldUpdateNode() determines if the ovn-kubernetes plugin should update the state of the node.
-// ovn-kube should not perform an update if it does not assign a hostsubnet, or if you want to change
-// whether or not ovn-kubernetes assigns a hostsubnet
-func shouldUpdateNode(node, oldNode *kapi.Node) (bool, error) {
-	newNoHostSubnet := util.NoHostSubnet(node)
-	oldNoHostSubnet := util.NoHostSubnet(oldNode)
-
-	if oldNoHostSubnet && newNoHostSubnet {
-		return false, nil
-	} else if oldNoHostSubnet && !newNoHostSubnet {
-		return false, fmt.Errorf("error updating node %s, cannot remove assigned hostsubnet, please delete node and recreate.", node.Name)
-	} else if !oldNoHostSubnet && newNoHostSubnet {
-		return false, fmt.Errorf("error updating node %s, cannot assign a hostsubnet to already created node, please delete node and recreate.", node.Name)
-	}
+// ovn-kube should not perform an update if the node is not assigned a hostsubnet, unless the node
+// starts being managed by ovn-kubernetes.
+func shouldUpdateNode(node, oldNode *kapi.Node) bool {
+	return !util.NoHostSubnet(node) || !util.NoHostSubnet(oldNode)
+}
 
-	return true, nil
+// noHostSubnetChanged returns true if the node got labeled as not managed by
+// ovn-kubernetes, or if that label was removed
+func noHostSubnetChanged(oldNode, node *kapi.Node) bool {
+	return util.NoHostSubnet(oldNode) != util.NoHostSubnet(node)
 }
 
 func (oc *DefaultNetworkController) StartServiceController(wg *sync.WaitGroup, runRepair bool) error {
@@ -457,7 +488,7 @@ func (oc *DefaultNetworkController) InitEgressServiceZoneController() (*egresssv
 		initClusterEgressPolicies, ensureNodeNoReroutePolicies, deleteLegacyDefaultNoRerouteNodePolicies,
 		oc.stopChan, oc.watchFactory.EgressServiceInformer(), oc.watchFactory.ServiceCoreInformer(),
 		oc.watchFactory.EndpointSliceCoreInformer(),
-		oc.watchFactory.NodeCoreInformer(), oc.zone)
+		oc.watchFactory.NodeCoreInformer(), oc.watchFactory, oc.zone)
 }
 
 func (oc *DefaultNetworkController) newANPController() error {
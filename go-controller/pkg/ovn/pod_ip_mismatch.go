@@ -0,0 +1,213 @@
+package ovn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	libovsdbutil "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	// podIPMismatchKubelet is a mismatch between the pod IPs reported by the
+	// kubelet in the pod status and those of the pod annotation
+	podIPMismatchKubelet = "kubelet"
+	// podIPMismatchLSP is a mismatch between the IPs of the logical switch
+	// port of the pod and those of the pod annotation
+	podIPMismatchLSP = "logical_switch_port"
+)
+
+// podIPMismatchChecker compares the IPs of the local pods of the default
+// network in their pod annotation, the source of truth, with the pod IPs
+// reported by the kubelet and with the addresses of their logical switch port,
+// which can diverge after a restore of the databases or a race, silently
+// breaking the network policies and services selecting the pod. A logical
+// switch port mismatch is fixed by adding the pod again. A kubelet mismatch
+// can't be fixed without recreating the pod and is only reported with an
+// event. A mismatch is only acted upon when found on two consecutive runs, so
+// that the pod handlers have had time to converge.
+type podIPMismatchChecker struct {
+	oc *DefaultNetworkController
+	// candidates are the mismatches, by kind and pod UID, found on the
+	// previous run
+	candidates sets.Set[string]
+	// reported are the mismatches, by kind and pod UID, already reported with
+	// an event
+	reported sets.Set[string]
+}
+
+func newPodIPMismatchChecker(oc *DefaultNetworkController) *podIPMismatchChecker {
+	return &podIPMismatchChecker{
+		oc:         oc,
+		candidates: sets.New[string](),
+		reported:   sets.New[string](),
+	}
+}
+
+// runPodIPMismatchCheck checks the IPs of the local pods at the configured
+// interval until stopChan is closed
+func (oc *DefaultNetworkController) runPodIPMismatchCheck(wg *sync.WaitGroup) {
+	if config.OVNKubernetesFeature.PodIPMismatchCheckInterval == 0 {
+		return
+	}
+	checker := newPodIPMismatchChecker(oc)
+	interval := time.Duration(config.OVNKubernetesFeature.PodIPMismatchCheckInterval) * time.Second
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if err := checker.run(); err != nil {
+				klog.Errorf("Failed to check the IPs of the pods: %v", err)
+			}
+		}, interval, oc.stopChan)
+	}()
+}
+
+// sameIPs returns whether the IPs are the same regardless of their order
+func sameIPs(a, b []net.IP) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ips := sets.New[string]()
+	for _, ip := range a {
+		ips.Insert(ip.String())
+	}
+	for _, ip := range b {
+		if !ips.Has(ip.String()) {
+			return false
+		}
+	}
+	return true
+}
+
+// getPodIPMismatches returns the kinds of IP mismatches of the pod, with the
+// mismatching IPs
+func (c *podIPMismatchChecker) getPodIPMismatches(pod *kapi.Pod, annotationIPs []net.IP) (map[string][]net.IP, error) {
+	mismatches := map[string][]net.IP{}
+	// the kubelet only reports the pod IPs once the sandbox is ready
+	if len(pod.Status.PodIPs) > 0 {
+		statusIPs := make([]net.IP, 0, len(pod.Status.PodIPs))
+		for _, podIP := range pod.Status.PodIPs {
+			if ip := net.ParseIP(podIP.IP); ip != nil {
+				statusIPs = append(statusIPs, ip)
+			}
+		}
+		if !sameIPs(statusIPs, annotationIPs) {
+			mismatches[podIPMismatchKubelet] = statusIPs
+		}
+	}
+
+	lsp := &nbdb.LogicalSwitchPort{Name: util.GetLogicalPortName(pod.Namespace, pod.Name)}
+	lsp, err := libovsdbops.GetLogicalSwitchPort(c.oc.nbClient, lsp)
+	if err != nil {
+		// a pod without logical switch port is handled by the pod retries
+		if errors.Is(err, libovsdbclient.ErrNotFound) {
+			return mismatches, nil
+		}
+		return nil, err
+	}
+	_, lspIPs, err := libovsdbutil.ExtractPortAddresses(lsp)
+	if err != nil {
+		return nil, err
+	}
+	if !sameIPs(lspIPs, annotationIPs) {
+		mismatches[podIPMismatchLSP] = lspIPs
+	}
+	return mismatches, nil
+}
+
+// run acts upon the mismatches found on this run and the previous one and
+// remembers the others for the next run
+func (c *podIPMismatchChecker) run() error {
+	pods, err := c.oc.watchFactory.GetAllPods()
+	if err != nil {
+		return fmt.Errorf("failed to list the pods: %w", err)
+	}
+	found := sets.New[string]()
+	confirmed := map[string]int{podIPMismatchKubelet: 0, podIPMismatchLSP: 0}
+	var lspMismatchPods []*kapi.Pod
+	for _, pod := range pods {
+		if !util.PodScheduled(pod) || util.PodWantsHostNetwork(pod) || util.PodCompleted(pod) ||
+			util.PodTerminating(pod) || !c.oc.isPodScheduledinLocalZone(pod) {
+			continue
+		}
+		podAnnotation, err := util.UnmarshalPodAnnotation(pod.Annotations, types.DefaultNetworkName)
+		if err != nil {
+			// the pod is not allocated yet
+			continue
+		}
+		annotationIPs := make([]net.IP, 0, len(podAnnotation.IPs))
+		for _, podIP := range podAnnotation.IPs {
+			annotationIPs = append(annotationIPs, podIP.IP)
+		}
+		mismatches, err := c.getPodIPMismatches(pod, annotationIPs)
+		if err != nil {
+			return fmt.Errorf("failed to check the IPs of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		for kind, ips := range mismatches {
+			key := kind + "/" + string(pod.UID)
+			found.Insert(key)
+			if !c.candidates.Has(key) {
+				continue
+			}
+			confirmed[kind]++
+			if !c.reported.Has(key) {
+				klog.Warningf("IPs %v of pod %s/%s in its pod annotation don't match the IPs %v of its %s",
+					annotationIPs, pod.Namespace, pod.Name, ips, kind)
+				c.reportPodIPMismatch(pod, kind, annotationIPs, ips)
+				c.reported.Insert(key)
+			}
+			if kind == podIPMismatchLSP {
+				lspMismatchPods = append(lspMismatchPods, pod)
+			}
+		}
+	}
+	c.candidates = found
+	c.reported = c.reported.Intersection(found)
+	for kind, count := range confirmed {
+		metrics.RecordPodIPMismatches(kind, count)
+	}
+
+	// the pods are added again for their logical switch port to be updated
+	// from their pod annotation
+	for _, pod := range lspMismatchPods {
+		if err := c.oc.retryPods.AddRetryObjWithAddNoBackoff(pod); err != nil {
+			klog.Errorf("Failed to retry pod %s/%s with an IP mismatch: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	if len(lspMismatchPods) > 0 {
+		klog.Infof("Retrying %d pods whose logical switch port IPs don't match their pod annotation", len(lspMismatchPods))
+		c.oc.retryPods.RequestRetryObjs()
+	}
+	return nil
+}
+
+func (c *podIPMismatchChecker) reportPodIPMismatch(pod *kapi.Pod, kind string, annotationIPs, ips []net.IP) {
+	podRef := &kapi.ObjectReference{
+		Kind:      "Pod",
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		UID:       pod.UID,
+	}
+	message := fmt.Sprintf("IPs %v reported by the kubelet don't match the IPs %v allocated by OVN-Kubernetes, "+
+		"the pod needs to be recreated", ips, annotationIPs)
+	if kind == podIPMismatchLSP {
+		message = fmt.Sprintf("IPs %v of the logical switch port don't match the IPs %v allocated by OVN-Kubernetes, "+
+			"the logical switch port is updated", ips, annotationIPs)
+	}
+	c.oc.recorder.Event(podRef, kapi.EventTypeWarning, "PodIPMismatch", message)
+}
@@ -0,0 +1,99 @@
+package ovn
+
+import (
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/retry"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+var _ = ginkgo.Describe("OVN pod IP mismatch check", func() {
+	var fakeOvn *FakeOVN
+
+	ginkgo.BeforeEach(func() {
+		// Restore global default values before each testcase
+		err := config.PrepareTestConfig()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		fakeOvn = NewFakeOVN(false)
+	})
+
+	ginkgo.AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	newAnnotatedPod := func(name, annotationIP, statusIP string) *v1.Pod {
+		pod := newPod("namespace1", name, "node1", statusIP)
+		_, ipNet, err := net.ParseCIDR(annotationIP + "/24")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		ipNet.IP = net.ParseIP(annotationIP)
+		pod.Annotations, err = util.MarshalPodAnnotation(pod.Annotations, &util.PodAnnotation{
+			IPs: []*net.IPNet{ipNet},
+			MAC: util.IPAddrToHWAddr(ipNet.IP),
+		}, ovntypes.DefaultNetworkName)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		return pod
+	}
+
+	newLSP := func(pod *v1.Pod, ip string) *nbdb.LogicalSwitchPort {
+		return &nbdb.LogicalSwitchPort{
+			UUID:      pod.Name + "-uuid",
+			Name:      util.GetLogicalPortName(pod.Namespace, pod.Name),
+			Addresses: []string{util.IPAddrToHWAddr(net.ParseIP(ip)).String() + " " + ip},
+		}
+	}
+
+	ginkgo.It("reports and fixes the mismatches found on two runs", func() {
+		matchingPod := newAnnotatedPod("matching", "10.128.1.3", "10.128.1.3")
+		kubeletMismatchPod := newAnnotatedPod("kubelet-mismatch", "10.128.1.4", "10.128.1.40")
+		lspMismatchPod := newAnnotatedPod("lsp-mismatch", "10.128.1.5", "10.128.1.5")
+		matchingLSP := newLSP(matchingPod, "10.128.1.3")
+		kubeletMismatchLSP := newLSP(kubeletMismatchPod, "10.128.1.4")
+		lspMismatchLSP := newLSP(lspMismatchPod, "10.128.1.50")
+		initialData := []libovsdbtest.TestData{
+			matchingLSP, kubeletMismatchLSP, lspMismatchLSP,
+			&nbdb.LogicalSwitch{
+				UUID:  "node1-uuid",
+				Name:  "node1",
+				Ports: []string{matchingLSP.UUID, kubeletMismatchLSP.UUID, lspMismatchLSP.UUID},
+			},
+		}
+		fakeOvn.startWithDBSetup(libovsdbtest.TestSetup{NBData: initialData},
+			&v1.PodList{Items: []v1.Pod{*matchingPod, *kubeletMismatchPod, *lspMismatchPod}})
+		fakeOvn.controller.localZoneNodes.Store("node1", true)
+
+		checker := newPodIPMismatchChecker(fakeOvn.controller)
+		lspMismatchKey, err := retry.GetResourceKey(lspMismatchPod)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		// the mismatches are only candidates on the first run
+		gomega.Expect(checker.run()).To(gomega.Succeed())
+		gomega.Consistently(fakeOvn.fakeRecorder.Events).ShouldNot(gomega.Receive())
+		gomega.Expect(retry.CheckRetryObj(lspMismatchKey, fakeOvn.controller.retryPods)).To(gomega.BeFalse())
+
+		// and reported once on the next runs, the pod with the logical
+		// switch port mismatch being added again
+		gomega.Expect(checker.run()).To(gomega.Succeed())
+		events := []string{<-fakeOvn.fakeRecorder.Events, <-fakeOvn.fakeRecorder.Events}
+		gomega.Expect(events).To(gomega.ConsistOf(
+			gomega.And(gomega.ContainSubstring("PodIPMismatch"), gomega.ContainSubstring("10.128.1.40")),
+			gomega.And(gomega.ContainSubstring("PodIPMismatch"), gomega.ContainSubstring("10.128.1.50")),
+		))
+		gomega.Expect(retry.CheckRetryObj(lspMismatchKey, fakeOvn.controller.retryPods)).To(gomega.BeTrue())
+		matchingKey, err := retry.GetResourceKey(matchingPod)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(retry.CheckRetryObj(matchingKey, fakeOvn.controller.retryPods)).To(gomega.BeFalse())
+
+		gomega.Expect(checker.run()).To(gomega.Succeed())
+		gomega.Consistently(fakeOvn.fakeRecorder.Events).ShouldNot(gomega.Receive())
+	})
+})
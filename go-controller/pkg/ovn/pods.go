@@ -11,13 +11,13 @@ import (
 	hotypes "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kubevirt"
-	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	kapi "k8s.io/api/core/v1"
 	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
@@ -27,6 +27,8 @@ func (oc *DefaultNetworkController) syncPods(pods []interface{}) error {
 	//
 	// TBD: Before this succeeds, add Pod handler should not continue to allocate IPs for the new Pods.
 	expectedLogicalPorts := make(map[string]bool)
+	// the IPs of the pods keeping their egress routes on the layer2 topology
+	expectedPodIPs := sets.New[string]()
 	vms := make(map[ktypes.NamespacedName]bool)
 	var err error
 	for _, podInterface := range pods {
@@ -58,6 +60,9 @@ func (oc *DefaultNetworkController) syncPods(pods []interface{}) error {
 		if expectedLogicalPortName != "" {
 			expectedLogicalPorts[expectedLogicalPortName] = true
 		}
+		for _, podIP := range annotations.IPs {
+			expectedPodIPs.Insert(podIP.IP.String())
+		}
 
 		// delete the outdated hybrid overlay subnet route if it exists
 		newRoutes := []util.PodRoute{}
@@ -103,6 +108,14 @@ func (oc *DefaultNetworkController) syncPods(pods []interface{}) error {
 	if err := kubevirt.SyncVirtualMachines(oc.nbClient, vms); err != nil {
 		return fmt.Errorf("failed syncing running virtual machines: %v", err)
 	}
+	if oc.TopologyType() == ovntypes.Layer2Topology {
+		if err := oc.deleteStaleLayer2PodEgressRoutes(expectedPodIPs); err != nil {
+			return err
+		}
+	}
+	if err := oc.deleteStaleHostPortLoadBalancers(pods); err != nil {
+		return err
+	}
 	return oc.deleteStaleLogicalSwitchPorts(expectedLogicalPorts)
 }
 
@@ -136,6 +149,11 @@ func (oc *DefaultNetworkController) deleteLogicalPort(pod *kapi.Pod, portInfo *l
 	if err := oc.deleteGWRoutesForPod(podNsName, pInfo.ips); err != nil {
 		return fmt.Errorf("cannot delete GW Routes for pod %s: %w", podDesc, err)
 	}
+	if oc.TopologyType() == ovntypes.Layer2Topology {
+		if err := oc.deleteLayer2PodEgressRoutes(pInfo.ips); err != nil {
+			return fmt.Errorf("cannot delete egress routes for pod %s: %w", podDesc, err)
+		}
+	}
 
 	// Releasing IPs needs to happen last so that we can deterministically know that if delete failed that
 	// the IP of the pod needs to be released. Otherwise we could have a completed pod failed to be removed
@@ -148,8 +166,11 @@ func (oc *DefaultNetworkController) deleteLogicalPort(pod *kapi.Pod, portInfo *l
 }
 
 func (oc *DefaultNetworkController) addLogicalPort(pod *kapi.Pod) (err error) {
+	switchName, err := oc.getExpectedSwitchName(pod)
+	if err != nil {
+		return err
+	}
 	// If a node does node have an assigned hostsubnet don't wait for the logical switch to appear
-	switchName := pod.Spec.NodeName
 	if oc.lsManager.IsNonHostSubnetSwitch(switchName) {
 		return nil
 	}
@@ -186,6 +207,7 @@ func (oc *DefaultNetworkController) addLogicalPort(pod *kapi.Pod) (err error) {
 	if err != nil {
 		return err
 	}
+	oc.podRecorder.AddPodAnnotation(pod.UID)
 
 	// Ensure the namespace/nsInfo exists
 	routingExternalGWs, routingPodGWs, addOps, err := oc.addPodToNamespace(pod.Namespace, podAnnotation.IPs)
@@ -228,14 +250,26 @@ func (oc *DefaultNetworkController) addLogicalPort(pod *kapi.Pod) (err error) {
 		}
 	}
 
+	planner := newTxnPlanner(ops)
+	defer planner.abort()
+	if newlyCreatedPort {
+		// add the new port to the network policy port groups in the same
+		// transaction, so that the pod is not reachable before the policies
+		// selecting it are applied
+		if err = oc.planNewLocalPodPolicyPorts(planner, pod, lsp); err != nil {
+			return err
+		}
+	}
+
 	recordOps, txOkCallBack, _, err := oc.AddConfigDurationRecord("pod", pod.Namespace, pod.Name)
 	if err != nil {
 		klog.Errorf("Config duration recorder: %v", err)
 	}
-	ops = append(ops, recordOps...)
+	planner.addOps(recordOps...)
 
 	transactStart := time.Now()
-	_, err = libovsdbops.TransactAndCheckAndSetUUIDs(oc.nbClient, lsp, ops)
+	ops = planner.ops
+	_, err = planner.transact(oc.nbClient, lsp)
 	libovsdbExecuteTime = time.Since(transactStart)
 	if err != nil {
 		return fmt.Errorf("error transacting operations %+v: %v", ops, err)
@@ -243,6 +277,12 @@ func (oc *DefaultNetworkController) addLogicalPort(pod *kapi.Pod) (err error) {
 	txOkCallBack()
 	oc.podRecorder.AddLSP(pod.UID, oc.NetInfo)
 
+	if oc.TopologyType() == ovntypes.Layer2Topology {
+		if err = oc.ensureLayer2PodEgressRoutes(pod, podAnnotation.IPs); err != nil {
+			return err
+		}
+	}
+
 	// check if this pod is serving as an external GW
 	err = oc.addPodExternalGW(pod)
 	if err != nil {
@@ -288,7 +328,11 @@ func (oc *DefaultNetworkController) allocateSyncPodsIPs(pod *kapi.Pod) (string,
 	if err != nil {
 		return "", nil, nil
 	}
-	expectedLogicalPortName, err := oc.allocatePodIPsOnSwitch(pod, annotations, ovntypes.DefaultNetworkName, pod.Spec.NodeName)
+	switchName, err := oc.getExpectedSwitchName(pod)
+	if err != nil {
+		return "", nil, err
+	}
+	expectedLogicalPortName, err := oc.allocatePodIPsOnSwitch(pod, annotations, ovntypes.DefaultNetworkName, switchName)
 	if err != nil {
 		return "", nil, err
 	}
@@ -297,6 +297,7 @@ func (p testPod) getAnnotationsJson() string {
 		Gateways []string   `json:"gateway_ips,omitempty"`
 		Routes   []podRoute `json:"routes,omitempty"`
 		TunnelID int        `json:"tunnel_id,omitempty"`
+		Version  int        `json:"version,omitempty"`
 	}
 
 	var address string
@@ -332,6 +333,7 @@ func (p testPod) getAnnotationsJson() string {
 			Gateway:  nodeGWIP,
 			Gateways: nodeGWIPs,
 			Routes:   routes,
+			Version:  util.PodAnnotationVersion,
 		},
 	}
 
@@ -347,6 +349,7 @@ func (p testPod) getAnnotationsJson() string {
 				IP:       ip,
 				IPs:      []string{ip},
 				TunnelID: portInfo.tunnelID,
+				Version:  util.PodAnnotationVersion,
 			}
 			podAnnotations[nad] = podAnnotation
 		}
@@ -585,6 +588,60 @@ var _ = ginkgo.Describe("OVN Pod Operations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 
+		ginkgo.It("rejects a pod with a malformed annotation", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespaceT := *newNamespace("namespace1")
+				t := newTPod(
+					"node1",
+					"10.128.1.0/24",
+					"10.128.1.2",
+					"10.128.1.1",
+					"myPod",
+					"10.128.1.3",
+					"0a:58:0a:80:01:03",
+					namespaceT.Name,
+				)
+
+				fakeOvn.startWithDBSetup(initialDB,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespaceT,
+						},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{
+							*newNode(node1Name, "192.168.126.202/24"),
+						},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{},
+					},
+				)
+
+				t.populateLogicalSwitchCache(fakeOvn)
+				err := fakeOvn.controller.WatchNamespaces()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				err = fakeOvn.controller.WatchPods()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				// hand-edited with a malformed pod IP
+				malformed := `{"default":{"ip_addresses":["10.128.1./24"],"mac_address":"0a:58:0a:80:01:03"}}`
+				pod := newPod(t.namespace, t.podName, t.nodeName, t.podIP)
+				pod.Annotations = map[string]string{util.OvnPodAnnotationName: malformed}
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(t.namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Eventually(fakeOvn.fakeRecorder.Events).Should(gomega.Receive(gomega.ContainSubstring("InvalidPodNetworksAnnotation")))
+				gomega.Consistently(func() string {
+					return getPodAnnotations(fakeOvn.fakeClient.KubeClient, t.namespace, t.podName)
+				}).Should(gomega.Equal(malformed))
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
 		ginkgo.It("allows allocation after pods are completed", func() {
 			app.Action = func(ctx *cli.Context) error {
 				namespaceT := *newNamespace("namespace1")
@@ -1229,7 +1286,7 @@ var _ = ginkgo.Describe("OVN Pod Operations", func() {
 					gomega.BeNumerically("==", retry.MaxFailedAttempts), // failedAttempts should reach the max
 				)
 
-				// restore nbdb, trigger a retry and verify that the retry entry gets deleted
+				// restore nbdb, trigger a retry and verify that the retry entry gets parked
 				// because it reached retry.MaxFailedAttempts and the corresponding pod has NOT been added to OVN
 				connCtx, cancel := context.WithTimeout(context.Background(), ovntypes.OVSDBTimeout)
 				defer cancel()
@@ -1242,13 +1299,32 @@ var _ = ginkgo.Describe("OVN Pod Operations", func() {
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
 				gomega.Expect(pod).NotTo(gomega.BeNil())
 
-				// check that the retry cache no longer has the entry
-				retry.CheckRetryObjectEventually(key, false, fakeOvn.controller.retryPods)
+				// check that the retry entry is parked and no longer retried
+				retry.CheckRetryObjectParkedEventually(key, true, fakeOvn.controller.retryPods)
+				fakeOvn.controller.retryPods.RequestRetryObjs()
+				retry.CheckRetryObjectMultipleFieldsEventually(
+					key,
+					fakeOvn.controller.retryPods,
+					nil, // skip oldObj
+					nil, // skip newObj
+					nil, // skip config
+					gomega.BeNumerically("==", retry.MaxFailedAttempts), // failedAttempts should not change
+				)
 
 				// check that pod doesn't appear in OVN
 				gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(
 					getExpectedDataPodsAndSwitches([]testPod{}, []string{"node1"})...))
 
+				// update the pod and verify that the new event resumes the retries of the parked
+				// entry, the pod is added to OVN and the retry entry gets deleted
+				pod.Labels = map[string]string{"retry": "resume"}
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(podTest.namespace).Update(
+					context.TODO(), pod, metav1.UpdateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				retry.CheckRetryObjectEventually(key, false, fakeOvn.controller.retryPods)
+				gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(
+					getExpectedDataPodsAndSwitches([]testPod{podTest}, []string{"node1"})...))
+
 				return nil
 			}
 
@@ -1342,7 +1418,7 @@ var _ = ginkgo.Describe("OVN Pod Operations", func() {
 					gomega.BeNumerically("==", retry.MaxFailedAttempts), // failedAttempts should be the max
 				)
 
-				// restore nbdb and verify that the retry entry gets deleted because it reached
+				// restore nbdb and verify that the retry entry gets parked because it reached
 				// retry.MaxFailedAttempts and the corresponding pod has NOT been deleted from OVN
 				connCtx, cancel := context.WithTimeout(context.Background(), ovntypes.OVSDBTimeout)
 				defer cancel()
@@ -1356,8 +1432,8 @@ var _ = ginkgo.Describe("OVN Pod Operations", func() {
 				gomega.Expect(err).To(gomega.HaveOccurred())
 				gomega.Expect(pod).To(gomega.BeNil())
 
-				// check that the retry cache no longer has the entry
-				retry.CheckRetryObjectEventually(key, false, fakeOvn.controller.retryPods)
+				// check that the retry entry is parked
+				retry.CheckRetryObjectParkedEventually(key, true, fakeOvn.controller.retryPods)
 
 				// check that the pod is still in OVN
 				gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(expectedData...))
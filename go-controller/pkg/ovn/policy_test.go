@@ -1191,6 +1191,145 @@ var _ = ginkgo.Describe("OVN NetworkPolicy Operations", func() {
 			gomega.Expect(app.Run([]string{app.Name})).To(gomega.Succeed())
 		})
 
+		ginkgo.It("shares the port group of the network policies with equivalent pod selectors", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.OVNKubernetesFeature.EnableSharedNetpolPortGroups = true
+				namespace1 := *newNamespace(namespaceName1)
+				nPodTest := getTestPod(namespace1.Name, nodeName)
+				networkPolicy := getPortNetworkPolicy(netPolicyName1, namespace1.Name, labelName, labelVal, portNum)
+				startOvn(initialDB, []v1.Namespace{namespace1}, []knet.NetworkPolicy{*networkPolicy},
+					[]testPod{nPodTest}, map[string]string{labelName: labelVal})
+
+				sharedPGName, _ := fakeOvn.controller.getSharedNetworkPolicyPGName(
+					getNetpolPortGroupKey(namespace1.Name, &networkPolicy.Spec.PodSelector))
+				getSharedPG := func() *nbdb.PortGroup {
+					pg, err := libovsdbops.GetPortGroup(fakeOvn.nbClient, &nbdb.PortGroup{Name: sharedPGName})
+					if err != nil {
+						return nil
+					}
+					return pg
+				}
+				getSharedPGACLs := func() int {
+					pg := getSharedPG()
+					if pg == nil {
+						return -1
+					}
+					return len(pg.ACLs)
+				}
+				policyPGExists := func(name string) bool {
+					pgName, _ := fakeOvn.controller.getNetworkPolicyPGName(namespace1.Name, name)
+					_, err := libovsdbops.GetPortGroup(fakeOvn.nbClient, &nbdb.PortGroup{Name: pgName})
+					return err == nil
+				}
+
+				ginkgo.By("Check the policy applies its ACLs to the shared port group")
+				gomega.Eventually(getSharedPGACLs).Should(gomega.Equal(2))
+				gomega.Expect(getSharedPG().Ports).To(gomega.HaveLen(1))
+				gomega.Expect(policyPGExists(netPolicyName1)).To(gomega.BeFalse())
+
+				ginkgo.By("Creating another policy with the same pod selector")
+				networkPolicy2 := getPortNetworkPolicy(netPolicyName2, namespace1.Name, labelName, labelVal, portNum+1)
+				_, err := fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).
+					Create(context.TODO(), networkPolicy2, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getSharedPGACLs).Should(gomega.Equal(4))
+				gomega.Expect(getSharedPG().Ports).To(gomega.HaveLen(1))
+				gomega.Expect(policyPGExists(netPolicyName2)).To(gomega.BeFalse())
+
+				ginkgo.By("Deleting the first policy keeps the port group of the second one")
+				err = fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).
+					Delete(context.TODO(), networkPolicy.Name, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getSharedPGACLs).Should(gomega.Equal(2))
+				gomega.Expect(getSharedPG().Ports).To(gomega.HaveLen(1))
+
+				ginkgo.By("Deleting the second policy deletes the port group")
+				err = fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(networkPolicy2.Namespace).
+					Delete(context.TODO(), networkPolicy2.Name, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getSharedPG).Should(gomega.BeNil())
+
+				return nil
+			}
+
+			gomega.Expect(app.Run([]string{app.Name})).To(gomega.Succeed())
+		})
+
+		ginkgo.It("shares the address set of the namespace selector peers of the network policies", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.OVNKubernetesFeature.EnableSharedNetpolPortGroups = true
+				namespace1 := *newNamespace(namespaceName1)
+				namespace2 := *newNamespaceWithLabels(namespaceName2, map[string]string{"team": "monitoring"})
+				peerNamespaceSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "monitoring"}}
+				newPeerPolicy := func(name string) *knet.NetworkPolicy {
+					return newNetworkPolicy(name, namespace1.Name, metav1.LabelSelector{},
+						[]knet.NetworkPolicyIngressRule{{
+							From: []knet.NetworkPolicyPeer{{NamespaceSelector: peerNamespaceSelector}},
+						}}, nil)
+				}
+				networkPolicy := newPeerPolicy(netPolicyName1)
+				startOvn(initialDB, []v1.Namespace{namespace1, namespace2}, []knet.NetworkPolicy{*networkPolicy},
+					nil, nil)
+
+				peerASKey := getPodSelectorKey(&metav1.LabelSelector{}, peerNamespaceSelector, "")
+				getPeerASBackRefs := func() int {
+					var backRefs int
+					_ = fakeOvn.controller.podSelectorAddressSets.DoWithLock(peerASKey, func(key string) error {
+						if psAddrSet, found := fakeOvn.controller.podSelectorAddressSets.Load(key); found {
+							backRefs = len(psAddrSet.backRefs)
+						} else {
+							backRefs = -1
+						}
+						return nil
+					})
+					return backRefs
+				}
+				peerASv4, _ := addressset.GetHashNamesForAS(getPodSelectorAddrSetDbIDs(peerASKey, DefaultNetworkControllerName))
+				nsASv4, _ := getNsAddrSetHashNames(namespace2.Name)
+				getIngressACLMatch := func(policyName string) string {
+					acls, err := libovsdbops.FindACLsWithPredicate(fakeOvn.nbClient, func(acl *nbdb.ACL) bool {
+						return acl.ExternalIDs[libovsdbops.ObjectNameKey.String()] == getACLPolicyKey(namespace1.Name, policyName) &&
+							acl.ExternalIDs[libovsdbops.PolicyDirectionKey.String()] == string(knet.PolicyTypeIngress)
+					})
+					if err != nil || len(acls) != 1 {
+						return ""
+					}
+					return acls[0].Match
+				}
+
+				ginkgo.By("Check the policy matches the shared address set of the peer")
+				gomega.Eventually(getPeerASBackRefs).Should(gomega.Equal(1))
+				gomega.Eventually(func() string { return getIngressACLMatch(netPolicyName1) }).Should(
+					gomega.ContainSubstring(peerASv4))
+				gomega.Expect(getIngressACLMatch(netPolicyName1)).NotTo(gomega.ContainSubstring(nsASv4))
+
+				ginkgo.By("Creating another policy with the same peer namespace selector")
+				networkPolicy2 := newPeerPolicy(netPolicyName2)
+				_, err := fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(networkPolicy2.Namespace).
+					Create(context.TODO(), networkPolicy2, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getPeerASBackRefs).Should(gomega.Equal(2))
+				gomega.Eventually(func() string { return getIngressACLMatch(netPolicyName2) }).Should(
+					gomega.ContainSubstring(peerASv4))
+
+				ginkgo.By("Deleting the first policy keeps the address set of the second one")
+				err = fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).
+					Delete(context.TODO(), networkPolicy.Name, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getPeerASBackRefs).Should(gomega.Equal(1))
+
+				ginkgo.By("Deleting the second policy deletes the address set")
+				err = fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(networkPolicy2.Namespace).
+					Delete(context.TODO(), networkPolicy2.Name, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Eventually(getPeerASBackRefs).Should(gomega.Equal(-1))
+
+				return nil
+			}
+
+			gomega.Expect(app.Run([]string{app.Name})).To(gomega.Succeed())
+		})
+
 		ginkgo.It("correctly retries creating a network policy allowing a port to a local pod", func() {
 			app.Action = func(ctx *cli.Context) error {
 				namespace1 := *newNamespace(namespaceName1)
@@ -2011,6 +2150,69 @@ var _ = ginkgo.Describe("OVN NetworkPolicy Operations", func() {
 			gomega.Expect(app.Run([]string{app.Name})).To(gomega.Succeed())
 		})
 
+		ginkgo.It("policies override the namespace allow logging level and rate limit with their own annotation", func() {
+			app.Action = func(ctx *cli.Context) error {
+				startOvn(initialDB, []v1.Namespace{originalNamespace}, nil, nil, nil)
+
+				newPolicy := getMatchLabelsNetworkPolicy(netPolicyName1, namespaceName1, namespaceName2, "", true, false)
+				newPolicy.Annotations = map[string]string{
+					util.AclLoggingAnnotation: fmt.Sprintf(`{ "deny": "%s", "allow": "%s", "rateLimit": 10 }`,
+						nbdb.ACLSeverityDebug, nbdb.ACLSeverityInfo),
+				}
+				ginkgo.By("Creating new network policy")
+				_, err := fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(namespaceName1).
+					Create(context.TODO(), newPolicy, metav1.CreateOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred(), "should have managed to create a new network policy")
+
+				policyData := getPolicyDataWithLogSev(newPolicy, nil, []string{}, nil, nbdb.ACLSeverityInfo)
+				meterName := types.OvnACLLoggingMeter + "-10"
+				for _, data := range policyData {
+					if acl, ok := data.(*nbdb.ACL); ok {
+						acl.Meter = &meterName
+					}
+				}
+				fair := true
+				band := &nbdb.MeterBand{UUID: "band-UUID", Action: types.MeterAction, Rate: 10}
+				meter := &nbdb.Meter{
+					UUID:  "meter-UUID",
+					Name:  meterName,
+					Fair:  &fair,
+					Unit:  types.PacketsPerSecond,
+					Bands: []string{band.UUID},
+				}
+				expectedData := initialDB.NBData
+				expectedData = append(expectedData, policyData...)
+				// the policy deny severity is ignored, default deny ACLs are shared by the namespace
+				expectedData = append(expectedData, getDefaultDenyDataWithLogSev(newPolicy, nil, nbdb.ACLSeverityAlert)...)
+				expectedData = append(expectedData, band, meter)
+				gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(expectedData...))
+
+				ginkgo.By("updating the namespace's ACL logging severity keeps the policy override")
+				gomega.Expect(
+					updateNamespaceACLLogSeverity(&originalNamespace, nbdb.ACLSeverityWarning, nbdb.ACLSeverityWarning)).To(gomega.Succeed(),
+					"should have managed to update the ACL logging severity within the namespace")
+				expectedData = initialDB.NBData
+				expectedData = append(expectedData, policyData...)
+				expectedData = append(expectedData, getDefaultDenyDataWithLogSev(newPolicy, nil, nbdb.ACLSeverityWarning)...)
+				expectedData = append(expectedData, band, meter)
+				gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(expectedData...))
+
+				ginkgo.By("deleting the policy deletes its ACL logging meter")
+				err = fakeOvn.fakeClient.KubeClient.NetworkingV1().NetworkPolicies(namespaceName1).
+					Delete(context.TODO(), newPolicy.Name, metav1.DeleteOptions{})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				// test server does not garbage collect ACLs and meter bands, so we just expect
+				// the default deny ACLs and the band to stay
+				defaultDenyData := getDefaultDenyDataWithLogSev(newPolicy, nil, nbdb.ACLSeverityWarning)
+				expectedData = initialDB.NBData
+				expectedData = append(expectedData, defaultDenyData[:len(defaultDenyData)-2]...)
+				expectedData = append(expectedData, band)
+				gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(expectedData...))
+				return nil
+			}
+			gomega.Expect(app.Run([]string{app.Name})).To(gomega.Succeed())
+		})
+
 		ginkgo.It("creates stateless OVN ACLs based off of the annotation", func() {
 			app.Action = func(ctx *cli.Context) error {
 				namespace1 := *newNamespace(namespaceName1)
@@ -0,0 +1,390 @@
+package ovn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	libovsdb "github.com/ovn-org/libovsdb/ovsdb"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilnet "k8s.io/utils/net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	libovsdbutil "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// providerNetworkEgressState is what the provider network egress of a
+// namespace is built from
+type providerNetworkEgressState struct {
+	*util.ProviderNetworkEgress
+	// localEgressNode is true if the egress node is in the zone of the
+	// controller, which then owns its gateway router
+	localEgressNode bool
+	// nextHop is the next hop of the traffic of the namespace on the
+	// ovn_cluster_router: the join IP of the gateway router of the egress node
+	// or, if the egress node is in another zone, its transit switch IP
+	nextHop string
+	// addressSetHashName is the name of the address set of the namespace of
+	// the IP family of the SNAT IP
+	addressSetHashName string
+	// podIPs are the IPs of the pods of the namespace of the IP family of the
+	// SNAT IP, only needed on the egress node
+	podIPs []net.IP
+}
+
+func (s *providerNetworkEgressState) match() string {
+	return fmt.Sprintf("%s.src == $%s", ipFamilyName(utilnet.IsIPv6(s.SNATIP.IP)), s.addressSetHashName)
+}
+
+// syncProviderNetworkEgress builds the provider network egress of the
+// namespace, or removes it if the namespace has none.
+// must be called with nsInfo lock
+func (oc *DefaultNetworkController) syncProviderNetworkEgress(namespace string, nsInfo *namespaceInfo) error {
+	pne := nsInfo.providerNetworkEgress
+	if pne == nil {
+		return oc.ensureProviderNetworkEgress(namespace, nil)
+	}
+	if config.Gateway.DisableSNATMultipleGWs {
+		return fmt.Errorf("provider network egress of namespace %s is not supported with disable-snat-multiple-gws", namespace)
+	}
+	node, err := oc.watchFactory.GetNode(pne.Node)
+	if err != nil {
+		return fmt.Errorf("failed to get egress node %s of namespace %s: %w", pne.Node, namespace, err)
+	}
+	isIPv6 := utilnet.IsIPv6(pne.SNATIP.IP)
+	state := &providerNetworkEgressState{
+		ProviderNetworkEgress: pne,
+		localEgressNode:       oc.isLocalZoneNode(node),
+	}
+	v4HashName, v6HashName := nsInfo.addressSet.GetASHashNames()
+	state.addressSetHashName = v4HashName
+	if isIPv6 {
+		state.addressSetHashName = v6HashName
+	}
+
+	var nextHops []*net.IPNet
+	if state.localEgressNode {
+		nextHops, err = libovsdbutil.GetLRPAddrs(oc.nbClient, types.GWRouterToJoinSwitchPrefix+types.GWRouterPrefix+pne.Node)
+	} else {
+		nextHops, err = util.ParseNodeTransitSwitchPortAddrs(node)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the next hop of egress node %s of namespace %s: %w", pne.Node, namespace, err)
+	}
+	nextHop, err := util.MatchFirstIPNetFamily(isIPv6, nextHops)
+	if err != nil {
+		return fmt.Errorf("failed to get the next hop of egress node %s of namespace %s: %w", pne.Node, namespace, err)
+	}
+	state.nextHop = nextHop.IP.String()
+
+	if state.localEgressNode {
+		pods, err := oc.watchFactory.GetPods(namespace)
+		if err != nil {
+			return fmt.Errorf("failed to get the pods of namespace %s: %w", namespace, err)
+		}
+		for _, pod := range pods {
+			if !util.PodScheduled(pod) || util.PodWantsHostNetwork(pod) || util.PodCompleted(pod) {
+				continue
+			}
+			podIPs, err := util.GetPodCIDRsWithFullMask(pod, oc.NetInfo)
+			if err != nil {
+				// the pod is not wired yet, it is synced again once it is
+				continue
+			}
+			for _, podIP := range podIPs {
+				if utilnet.IsIPv6(podIP.IP) == isIPv6 {
+					state.podIPs = append(state.podIPs, podIP.IP)
+				}
+			}
+		}
+	}
+	return oc.ensureProviderNetworkEgress(namespace, state)
+}
+
+// syncPodProviderNetworkEgress syncs the provider network egress of the
+// namespace of the pod, if any, once the pod is added, updated or removed
+func (oc *DefaultNetworkController) syncPodProviderNetworkEgress(pod *kapi.Pod) error {
+	nsInfo, nsUnlock := oc.getNamespaceLocked(pod.Namespace, false)
+	if nsInfo == nil {
+		return nil
+	}
+	defer nsUnlock()
+	if nsInfo.providerNetworkEgress == nil {
+		return nil
+	}
+	return oc.syncProviderNetworkEgress(pod.Namespace, nsInfo)
+}
+
+// ensureProviderNetworkEgress makes the NB database match the given state of
+// the provider network egress of the namespace, removing it if state is nil:
+//   - on the ovn_cluster_router, a policy rerouting the traffic of the pods of
+//     the namespace to the egress node
+//   - on the gateway router of the egress node, a port on the localnet switch
+//     of the provider network, a policy rerouting the traffic of the pods to
+//     the gateway of the provider network and a SNAT per pod to the SNAT IP
+func (oc *DefaultNetworkController) ensureProviderNetworkEgress(namespace string, state *providerNetworkEgressState) error {
+	owned := func(externalIDs map[string]string) bool {
+		return externalIDs[types.ProviderNetworkEgressExternalID] == namespace
+	}
+	externalIDs := map[string]string{types.ProviderNetworkEgressExternalID: namespace}
+	lrpName := types.GWRouterToProviderNetworkPrefix + namespace
+	lspName := types.ProviderNetworkToGWRouterPrefix + namespace
+	gwRouterName, switchName := "", ""
+	if state != nil && state.localEgressNode {
+		gwRouterName = types.GWRouterPrefix + state.Node
+		switchName = util.GetSecondaryNetworkPrefix(state.Network) + types.OVNLocalnetSwitch
+	}
+
+	// remove the provider network egress from the routers and the switch it
+	// is no longer on, like the ones of a previous egress node
+	policies, err := libovsdbops.FindLogicalRouterPoliciesWithPredicate(oc.nbClient,
+		func(item *nbdb.LogicalRouterPolicy) bool { return owned(item.ExternalIDs) })
+	if err != nil {
+		return fmt.Errorf("failed to find the provider network egress policies of namespace %s: %w", namespace, err)
+	}
+	nats, err := libovsdbops.FindNATsWithPredicate(oc.nbClient,
+		func(item *nbdb.NAT) bool { return owned(item.ExternalIDs) })
+	if err != nil {
+		return fmt.Errorf("failed to find the provider network egress NATs of namespace %s: %w", namespace, err)
+	}
+	lrp, err := libovsdbops.GetLogicalRouterPort(oc.nbClient, &nbdb.LogicalRouterPort{Name: lrpName})
+	if err != nil && !errors.Is(err, libovsdbclient.ErrNotFound) {
+		return fmt.Errorf("failed to get logical router port %s: %w", lrpName, err)
+	}
+	policyUUIDs := sets.New[string]()
+	for _, policy := range policies {
+		policyUUIDs.Insert(policy.UUID)
+	}
+	natsByUUID := map[string]*nbdb.NAT{}
+	for _, nat := range nats {
+		natsByUUID[nat.UUID] = nat
+	}
+	routers, err := libovsdbops.FindLogicalRoutersWithPredicate(oc.nbClient, func(item *nbdb.LogicalRouter) bool {
+		if policyUUIDs.HasAny(item.Policies...) {
+			return true
+		}
+		for _, uuid := range item.Nat {
+			if natsByUUID[uuid] != nil {
+				return true
+			}
+		}
+		return lrp != nil && sets.New(item.Ports...).Has(lrp.UUID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the routers of the provider network egress of namespace %s: %w", namespace, err)
+	}
+	var ops []libovsdb.Operation
+	var staleLRPRouters []*nbdb.LogicalRouter
+	for _, router := range routers {
+		routerPolicyUUIDs := sets.New(router.Policies...)
+		if state != nil && (router.Name == types.OVNClusterRouter || router.Name == gwRouterName) {
+			continue
+		}
+		ops, err = libovsdbops.DeleteLogicalRouterPolicyWithPredicateOps(oc.nbClient, ops, router.Name,
+			func(item *nbdb.LogicalRouterPolicy) bool {
+				return owned(item.ExternalIDs) && routerPolicyUUIDs.Has(item.UUID)
+			})
+		if err != nil {
+			return err
+		}
+		var routerNATs []*nbdb.NAT
+		for _, uuid := range router.Nat {
+			if natsByUUID[uuid] != nil {
+				routerNATs = append(routerNATs, natsByUUID[uuid])
+			}
+		}
+		if len(routerNATs) > 0 {
+			ops, err = libovsdbops.DeleteNATsOps(oc.nbClient, ops, router, routerNATs...)
+			if err != nil {
+				return err
+			}
+		}
+		if lrp != nil && sets.New(router.Ports...).Has(lrp.UUID) {
+			staleLRPRouters = append(staleLRPRouters, router)
+		}
+	}
+	lsp, err := libovsdbops.GetLogicalSwitchPort(oc.nbClient, &nbdb.LogicalSwitchPort{Name: lspName})
+	if err != nil && !errors.Is(err, libovsdbclient.ErrNotFound) {
+		return fmt.Errorf("failed to get logical switch port %s: %w", lspName, err)
+	}
+	if lsp != nil {
+		switches, err := libovsdbops.FindLogicalSwitchesWithPredicate(oc.nbClient, func(item *nbdb.LogicalSwitch) bool {
+			return item.Name != switchName && sets.New(item.Ports...).Has(lsp.UUID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find the switches of logical switch port %s: %w", lspName, err)
+		}
+		for _, sw := range switches {
+			ops, err = libovsdbops.DeleteLogicalSwitchPortsOps(oc.nbClient, ops, sw, lsp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if _, err = libovsdbops.TransactAndCheck(oc.nbClient, ops); err != nil {
+		return fmt.Errorf("failed to remove the stale provider network egress of namespace %s: %w", namespace, err)
+	}
+	for _, router := range staleLRPRouters {
+		if err := libovsdbops.DeleteLogicalRouterPorts(oc.nbClient, router, lrp); err != nil {
+			return fmt.Errorf("failed to delete logical router port %s from router %s: %w", lrpName, router.Name, err)
+		}
+	}
+	if state == nil {
+		return nil
+	}
+
+	// the traffic of the pods is sent to the egress node, after the policies
+	// of higher priorities keeping the traffic within the cluster on the
+	// ovn_cluster_router
+	clusterRouterPolicy := &nbdb.LogicalRouterPolicy{
+		Priority:    types.ProviderNetworkEgressReroutePriority,
+		Match:       state.match(),
+		Action:      nbdb.LogicalRouterPolicyActionReroute,
+		Nexthops:    []string{state.nextHop},
+		ExternalIDs: externalIDs,
+	}
+	ops, err = oc.createOrUpdateProviderNetworkEgressPolicyOps(nil, types.OVNClusterRouter, clusterRouterPolicy, owned)
+	if err != nil {
+		return err
+	}
+	if !state.localEgressNode {
+		if _, err = libovsdbops.TransactAndCheck(oc.nbClient, ops); err != nil {
+			return fmt.Errorf("failed to create the provider network egress of namespace %s: %w", namespace, err)
+		}
+		return nil
+	}
+
+	// the gateway router of the egress node is connected to the provider
+	// network with the SNAT IP
+	gwRouter, err := libovsdbops.GetLogicalRouter(oc.nbClient, &nbdb.LogicalRouter{Name: gwRouterName})
+	if err != nil {
+		return fmt.Errorf("failed to get gateway router %s: %w", gwRouterName, err)
+	}
+	lrp = &nbdb.LogicalRouterPort{
+		Name:        lrpName,
+		MAC:         util.IPAddrToHWAddr(state.SNATIP.IP).String(),
+		Networks:    []string{state.SNATIP.String()},
+		ExternalIDs: externalIDs,
+	}
+	if err := libovsdbops.CreateOrUpdateLogicalRouterPort(oc.nbClient, gwRouter, lrp, nil,
+		&lrp.MAC, &lrp.Networks, &lrp.ExternalIDs); err != nil {
+		return fmt.Errorf("failed to create logical router port %s on router %s: %w", lrpName, gwRouterName, err)
+	}
+	lsp = &nbdb.LogicalSwitchPort{
+		Name:        lspName,
+		Type:        "router",
+		Addresses:   []string{"router"},
+		Options:     map[string]string{"router-port": lrpName},
+		ExternalIDs: externalIDs,
+	}
+	ops, err = libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitchOps(oc.nbClient, ops,
+		&nbdb.LogicalSwitch{Name: switchName}, lsp)
+	if err != nil {
+		return fmt.Errorf("failed to create logical switch port %s on the switch of network %s: %w",
+			lspName, state.Network, err)
+	}
+	// only the traffic coming from the join switch is rerouted, which also
+	// keeps the match different from the one of the ovn_cluster_router
+	gwRouterPolicy := &nbdb.LogicalRouterPolicy{
+		Priority: types.ProviderNetworkEgressReroutePriority,
+		Match: fmt.Sprintf("inport == %q && %s", types.GWRouterToJoinSwitchPrefix+gwRouterName,
+			state.match()),
+		Action:      nbdb.LogicalRouterPolicyActionReroute,
+		Nexthops:    []string{state.Gateway.String()},
+		ExternalIDs: externalIDs,
+	}
+	ops, err = oc.createOrUpdateProviderNetworkEgressPolicyOps(ops, gwRouterName, gwRouterPolicy, owned)
+	if err != nil {
+		return err
+	}
+
+	// a SNAT per pod, more specific than the SNAT of the cluster subnets to
+	// the node IP
+	podNATs := make([]*nbdb.NAT, 0, len(state.podIPs))
+	podIPs := sets.New[string]()
+	for _, podIP := range state.podIPs {
+		podNATs = append(podNATs, libovsdbops.BuildSNAT(&state.SNATIP.IP, &net.IPNet{IP: podIP, Mask: util.GetIPFullMask(podIP)}, "", externalIDs))
+		podIPs.Insert(podIP.String())
+	}
+	var staleNATs []*nbdb.NAT
+	for _, uuid := range gwRouter.Nat {
+		if nat := natsByUUID[uuid]; nat != nil && !podIPs.Has(nat.LogicalIP) {
+			staleNATs = append(staleNATs, nat)
+		}
+	}
+	if len(staleNATs) > 0 {
+		ops, err = libovsdbops.DeleteNATsOps(oc.nbClient, ops, gwRouter, staleNATs...)
+		if err != nil {
+			return err
+		}
+	}
+	if len(podNATs) > 0 {
+		ops, err = libovsdbops.CreateOrUpdateNATsOps(oc.nbClient, ops, gwRouter, podNATs...)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err = libovsdbops.TransactAndCheck(oc.nbClient, ops); err != nil {
+		return fmt.Errorf("failed to create the provider network egress of namespace %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// createOrUpdateProviderNetworkEgressPolicyOps creates or updates the policy
+// of the provider network egress of a namespace on the given router
+func (oc *DefaultNetworkController) createOrUpdateProviderNetworkEgressPolicyOps(ops []libovsdb.Operation, routerName string,
+	policy *nbdb.LogicalRouterPolicy, owned func(map[string]string) bool) ([]libovsdb.Operation, error) {
+	router, err := libovsdbops.GetLogicalRouter(oc.nbClient, &nbdb.LogicalRouter{Name: routerName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get router %s: %w", routerName, err)
+	}
+	routerPolicyUUIDs := sets.New(router.Policies...)
+	ops, err = libovsdbops.CreateOrUpdateLogicalRouterPolicyWithPredicateOps(oc.nbClient, ops, routerName, policy,
+		func(item *nbdb.LogicalRouterPolicy) bool {
+			return owned(item.ExternalIDs) && routerPolicyUUIDs.Has(item.UUID)
+		},
+		&policy.Priority, &policy.Match, &policy.Nexthops, &policy.Action, &policy.ExternalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the provider network egress policy on router %s: %w", routerName, err)
+	}
+	return ops, nil
+}
+
+// syncProviderNetworkEgresses removes the provider network egresses of the
+// namespaces that no longer have one, like the ones deleted while ovnkube was
+// down
+func (oc *DefaultNetworkController) syncProviderNetworkEgresses(namespaces sets.Set[string]) error {
+	stale := sets.New[string]()
+	policies, err := libovsdbops.FindLogicalRouterPoliciesWithPredicate(oc.nbClient, func(item *nbdb.LogicalRouterPolicy) bool {
+		namespace, ok := item.ExternalIDs[types.ProviderNetworkEgressExternalID]
+		return ok && !namespaces.Has(namespace)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the provider network egress policies: %w", err)
+	}
+	for _, policy := range policies {
+		stale.Insert(policy.ExternalIDs[types.ProviderNetworkEgressExternalID])
+	}
+	nats, err := libovsdbops.FindNATsWithPredicate(oc.nbClient, func(item *nbdb.NAT) bool {
+		namespace, ok := item.ExternalIDs[types.ProviderNetworkEgressExternalID]
+		return ok && !namespaces.Has(namespace)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the provider network egress NATs: %w", err)
+	}
+	for _, nat := range nats {
+		stale.Insert(nat.ExternalIDs[types.ProviderNetworkEgressExternalID])
+	}
+	for _, namespace := range sets.List(stale) {
+		if err := oc.ensureProviderNetworkEgress(namespace, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
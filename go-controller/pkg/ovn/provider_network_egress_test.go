@@ -0,0 +1,132 @@
+package ovn
+
+import (
+	"net"
+	"testing"
+
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestEnsureProviderNetworkEgress(t *testing.T) {
+	g := gomega.NewWithT(t)
+	clusterRouter := &nbdb.LogicalRouter{UUID: "cluster-router-UUID", Name: types.OVNClusterRouter}
+	gwRouter1 := &nbdb.LogicalRouter{UUID: "gw-router1-UUID", Name: types.GWRouterPrefix + "node1"}
+	gwRouter2 := &nbdb.LogicalRouter{UUID: "gw-router2-UUID", Name: types.GWRouterPrefix + "node2"}
+	sw := &nbdb.LogicalSwitch{UUID: "switch-UUID", Name: "tenant.blue_" + types.OVNLocalnetSwitch}
+	nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+		NBData: []libovsdbtest.TestData{clusterRouter, gwRouter1, gwRouter2, sw},
+	}, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	t.Cleanup(cleanup.Cleanup)
+	oc := &DefaultNetworkController{
+		BaseNetworkController: BaseNetworkController{
+			CommonNetworkControllerInfo: CommonNetworkControllerInfo{nbClient: nbClient},
+		},
+	}
+
+	getRouter := func(router *nbdb.LogicalRouter) *nbdb.LogicalRouter {
+		found, err := libovsdbops.GetLogicalRouter(nbClient, &nbdb.LogicalRouter{Name: router.Name})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return found
+	}
+	getPolicies := func(router *nbdb.LogicalRouter) []*nbdb.LogicalRouterPolicy {
+		uuids := sets.New(getRouter(router).Policies...)
+		policies, err := libovsdbops.FindLogicalRouterPoliciesWithPredicate(nbClient,
+			func(item *nbdb.LogicalRouterPolicy) bool { return uuids.Has(item.UUID) })
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		return policies
+	}
+	getNATIPs := func(router *nbdb.LogicalRouter) []string {
+		nats, err := libovsdbops.GetRouterNATs(nbClient, getRouter(router))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		ips := []string{}
+		for _, nat := range nats {
+			g.Expect(nat.Type).To(gomega.Equal(nbdb.NATTypeSNAT))
+			g.Expect(nat.ExternalIP).To(gomega.Equal("192.168.10.5"))
+			ips = append(ips, nat.LogicalIP)
+		}
+		return ips
+	}
+	newState := func(node string, podIPs ...string) *providerNetworkEgressState {
+		state := &providerNetworkEgressState{
+			ProviderNetworkEgress: &util.ProviderNetworkEgress{
+				Network: "tenant-blue",
+				Node:    node,
+				SNATIP:  ovntest.MustParseIPNet("192.168.10.5/24"),
+				Gateway: net.ParseIP("192.168.10.1"),
+			},
+			localEgressNode:    true,
+			nextHop:            "100.64.0.2",
+			addressSetHashName: "a123",
+		}
+		for _, podIP := range podIPs {
+			state.podIPs = append(state.podIPs, net.ParseIP(podIP))
+		}
+		return state
+	}
+
+	g.Expect(oc.ensureProviderNetworkEgress("ns1", newState("node1", "10.128.0.5", "10.128.1.6"))).To(gomega.Succeed())
+	policies := getPolicies(clusterRouter)
+	g.Expect(policies).To(gomega.HaveLen(1))
+	g.Expect(policies[0].Priority).To(gomega.Equal(types.ProviderNetworkEgressReroutePriority))
+	g.Expect(policies[0].Match).To(gomega.Equal("ip4.src == $a123"))
+	g.Expect(policies[0].Nexthops).To(gomega.ConsistOf("100.64.0.2"))
+	policies = getPolicies(gwRouter1)
+	g.Expect(policies).To(gomega.HaveLen(1))
+	g.Expect(policies[0].Match).To(gomega.Equal(`inport == "rtoj-GR_node1" && ip4.src == $a123`))
+	g.Expect(policies[0].Nexthops).To(gomega.ConsistOf("192.168.10.1"))
+	g.Expect(getNATIPs(gwRouter1)).To(gomega.ConsistOf("10.128.0.5", "10.128.1.6"))
+	lrp, err := libovsdbops.GetLogicalRouterPort(nbClient, &nbdb.LogicalRouterPort{Name: types.GWRouterToProviderNetworkPrefix + "ns1"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(lrp.Networks).To(gomega.ConsistOf("192.168.10.5/24"))
+	g.Expect(getRouter(gwRouter1).Ports).To(gomega.ConsistOf(lrp.UUID))
+	lsp, err := libovsdbops.GetLogicalSwitchPort(nbClient, &nbdb.LogicalSwitchPort{Name: types.ProviderNetworkToGWRouterPrefix + "ns1"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(lsp.Options).To(gomega.HaveKeyWithValue("router-port", lrp.Name))
+
+	// the SNATs of the pods gone are removed
+	g.Expect(oc.ensureProviderNetworkEgress("ns1", newState("node1", "10.128.0.5"))).To(gomega.Succeed())
+	g.Expect(getNATIPs(gwRouter1)).To(gomega.ConsistOf("10.128.0.5"))
+
+	// the gateway router of the previous egress node is cleaned up
+	g.Expect(oc.ensureProviderNetworkEgress("ns1", newState("node2", "10.128.0.5"))).To(gomega.Succeed())
+	g.Expect(getPolicies(clusterRouter)).To(gomega.HaveLen(1))
+	g.Expect(getPolicies(gwRouter1)).To(gomega.BeEmpty())
+	g.Expect(getNATIPs(gwRouter1)).To(gomega.BeEmpty())
+	g.Expect(getRouter(gwRouter1).Ports).To(gomega.BeEmpty())
+	g.Expect(getPolicies(gwRouter2)).To(gomega.HaveLen(1))
+	g.Expect(getNATIPs(gwRouter2)).To(gomega.ConsistOf("10.128.0.5"))
+	g.Expect(getRouter(gwRouter2).Ports).To(gomega.HaveLen(1))
+
+	// an egress node of another zone only needs the policy of the
+	// ovn_cluster_router
+	remoteState := newState("node3")
+	remoteState.localEgressNode = false
+	remoteState.nextHop = "100.88.0.4"
+	g.Expect(oc.ensureProviderNetworkEgress("ns1", remoteState)).To(gomega.Succeed())
+	policies = getPolicies(clusterRouter)
+	g.Expect(policies).To(gomega.HaveLen(1))
+	g.Expect(policies[0].Nexthops).To(gomega.ConsistOf("100.88.0.4"))
+	g.Expect(getPolicies(gwRouter2)).To(gomega.BeEmpty())
+	g.Expect(getNATIPs(gwRouter2)).To(gomega.BeEmpty())
+	g.Expect(getRouter(gwRouter2).Ports).To(gomega.BeEmpty())
+	s, err := libovsdbops.GetLogicalSwitch(nbClient, &nbdb.LogicalSwitch{Name: sw.Name})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(s.Ports).To(gomega.BeEmpty())
+
+	// and everything is removed along with the provider network egress
+	g.Expect(oc.ensureProviderNetworkEgress("ns1", newState("node1", "10.128.0.5"))).To(gomega.Succeed())
+	g.Expect(oc.syncProviderNetworkEgresses(sets.New[string]())).To(gomega.Succeed())
+	g.Expect(getPolicies(clusterRouter)).To(gomega.BeEmpty())
+	g.Expect(getPolicies(gwRouter1)).To(gomega.BeEmpty())
+	g.Expect(getNATIPs(gwRouter1)).To(gomega.BeEmpty())
+	g.Expect(getRouter(gwRouter1).Ports).To(gomega.BeEmpty())
+}
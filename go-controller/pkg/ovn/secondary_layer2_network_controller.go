@@ -9,6 +9,7 @@ import (
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/allocator/pod"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	hostapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	addressset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/address_set"
 	lsm "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/logical_switch_manager"
@@ -20,6 +21,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
@@ -27,6 +29,16 @@ import (
 // for a secondary layer2 network
 type SecondaryLayer2NetworkController struct {
 	BaseSecondaryLayer2NetworkController
+
+	// retry framework and handler for the standalone hosts of the network
+	retryHosts  *retry.RetryFramework
+	hostHandler *factory.Handler
+
+	// chassis handler for the remote standalone hosts of the network
+	zoneChassisHandler *zoneinterconnect.ZoneChassisHandler
+
+	// local zone nodes whose gateway failed to be set up on a primary network
+	gatewaysFailed sync.Map
 }
 
 // NewSecondaryLayer2NetworkController create a new OVN controller for the given secondary layer2 nad
@@ -38,7 +50,7 @@ func NewSecondaryLayer2NetworkController(cnci *CommonNetworkControllerInfo, netI
 	addressSetFactory := addressset.NewOvnAddressSetFactory(cnci.nbClient, ipv4Mode, ipv6Mode)
 
 	oc := &SecondaryLayer2NetworkController{
-		BaseSecondaryLayer2NetworkController{
+		BaseSecondaryLayer2NetworkController: BaseSecondaryLayer2NetworkController{
 			BaseSecondaryNetworkController: BaseSecondaryNetworkController{
 				BaseNetworkController: BaseNetworkController{
 					CommonNetworkControllerInfo: *cnci,
@@ -52,6 +64,7 @@ func NewSecondaryLayer2NetworkController(cnci *CommonNetworkControllerInfo, netI
 					networkPolicies:             syncmap.NewSyncMap[*networkPolicy](),
 					sharedNetpolPortGroups:      syncmap.NewSyncMap[*defaultDenyPortGroups](),
 					podSelectorAddressSets:      syncmap.NewSyncMap[*PodSelectorAddressSet](),
+					netpolPortGroups:            syncmap.NewSyncMap[*netpolPortGroup](),
 					stopChan:                    stopChan,
 					wg:                          &sync.WaitGroup{},
 					localZoneNodes:              &sync.Map{},
@@ -65,6 +78,10 @@ func NewSecondaryLayer2NetworkController(cnci *CommonNetworkControllerInfo, netI
 		oc.zoneICHandler = zoneinterconnect.NewZoneInterconnectHandler(oc.NetInfo, oc.nbClient, oc.sbClient, oc.watchFactory)
 	}
 
+	if oc.hasStandaloneHosts() {
+		oc.zoneChassisHandler = zoneinterconnect.NewZoneChassisHandler(oc.sbClient)
+	}
+
 	if oc.allocatesPodAnnotation() {
 		podAnnotationAllocator := pod.NewPodAnnotationAllocator(
 			netInfo,
@@ -103,7 +120,27 @@ func (oc *SecondaryLayer2NetworkController) run(ctx context.Context) error {
 		return err
 	}
 
-	return oc.BaseSecondaryLayer2NetworkController.run()
+	if err = oc.BaseSecondaryLayer2NetworkController.run(); err != nil {
+		return err
+	}
+
+	// WatchEgressIPs depends on WatchPods and WatchNodes
+	if err = oc.WatchEgressIPs(); err != nil {
+		return err
+	}
+
+	if oc.hasStandaloneHosts() {
+		return oc.WatchHosts()
+	}
+
+	return nil
+}
+
+// hasStandaloneHosts returns whether standalone hosts can attach to the
+// network, which requires the network to have IPAM and not to be internal
+func (oc *SecondaryLayer2NetworkController) hasStandaloneHosts() bool {
+	return config.OVNKubernetesFeature.EnableStandaloneHosts && util.DoesNetworkRequireIPAM(oc.NetInfo) &&
+		!oc.IsInternal()
 }
 
 // Cleanup cleans up logical entities for the given network, called from net-attach-def routine
@@ -116,8 +153,28 @@ func (oc *SecondaryLayer2NetworkController) Cleanup(netName string) error {
 func (oc *SecondaryLayer2NetworkController) Init() error {
 	switchName := oc.GetNetworkScopedName(types.OVNLayer2Switch)
 
-	_, err := oc.initializeLogicalSwitch(switchName, oc.Subnets(), oc.ExcludeSubnets())
-	return err
+	if _, err := oc.initializeLogicalSwitch(switchName, oc.Subnets(), oc.ExcludeSubnets()); err != nil {
+		return err
+	}
+	if !oc.IsPrimaryNetwork() {
+		return nil
+	}
+
+	// the pods of a primary network egress through a cluster router local to
+	// the zone, connected to the gateway routers of the network
+	if _, err := oc.createOvnClusterRouter(); err != nil {
+		return err
+	}
+	if err := oc.ensureUDNJoinSwitch(); err != nil {
+		return err
+	}
+	if err := oc.ensureUDNLayer2ClusterRouterPort(); err != nil {
+		return err
+	}
+	if config.OVNKubernetesFeature.EnableEgressIP {
+		return oc.ensureUDNNoReroutePolicies()
+	}
+	return nil
 }
 
 func (oc *SecondaryLayer2NetworkController) Stop() {
@@ -127,11 +184,24 @@ func (oc *SecondaryLayer2NetworkController) Stop() {
 	if oc.nodeHandler != nil {
 		oc.watchFactory.RemoveNodeHandler(oc.nodeHandler)
 	}
+	if oc.hostHandler != nil {
+		oc.watchFactory.RemoveHostHandler(oc.hostHandler)
+	}
+	if oc.egressIPHandler != nil {
+		oc.watchFactory.RemoveEgressIPHandler(oc.egressIPHandler)
+	}
 }
 
 func (oc *SecondaryLayer2NetworkController) initRetryFramework() {
 	oc.BaseSecondaryLayer2NetworkController.initRetryFramework()
 	oc.retryNodes = oc.newRetryFramework(factory.NodeType)
+	if oc.hasStandaloneHosts() {
+		oc.retryHosts = oc.newRetryFramework(factory.HostType)
+	}
+	// egress IPs are only supported on primary networks
+	if oc.IsPrimaryNetwork() && config.OVNKubernetesFeature.EnableEgressIP {
+		oc.retryEgressIPs = oc.newRetryFramework(factory.EgressIPType)
+	}
 }
 
 // newRetryFramework builds and returns a retry framework for the input resource type;
@@ -147,6 +217,8 @@ func (oc *SecondaryLayer2NetworkController) newRetryFramework(objectType reflect
 		HasUpdateFunc:          hasResourceAnUpdateFunc(objectType),
 		NeedsUpdateDuringRetry: needsUpdateDuringRetry(objectType),
 		ObjType:                objectType,
+		MaxFailedAttempts:      maxFailedAttempts(objectType),
+		NetworkName:            oc.GetNetworkName(),
 		EventHandler:           eventHandler,
 	}
 	return retry.NewRetryFramework(
@@ -157,16 +229,28 @@ func (oc *SecondaryLayer2NetworkController) newRetryFramework(objectType reflect
 	)
 }
 
-func (oc *SecondaryLayer2NetworkController) addUpdateNodeEvent(node *corev1.Node) error {
+// addUpdateNodeEvent handles the addition or update of the node, syncGw
+// telling whether its gateway needs to be set up on a primary network
+func (oc *SecondaryLayer2NetworkController) addUpdateNodeEvent(node *corev1.Node, syncGw bool) error {
 	if oc.isLocalZoneNode(node) {
-		return oc.addUpdateLocalNodeEvent(node)
+		return oc.addUpdateLocalNodeEvent(node, syncGw)
 	}
 	return oc.addUpdateRemoteNodeEvent(node)
 }
 
-func (oc *SecondaryLayer2NetworkController) addUpdateLocalNodeEvent(node *corev1.Node) error {
+func (oc *SecondaryLayer2NetworkController) addUpdateLocalNodeEvent(node *corev1.Node, syncGw bool) error {
 	_, present := oc.localZoneNodes.LoadOrStore(node.Name, true)
 
+	if syncGw && oc.IsPrimaryNetwork() {
+		// the gateway is set up before the pods, their egress routes pointing
+		// to it
+		if err := oc.syncNodeGateway(node); err != nil {
+			oc.gatewaysFailed.Store(node.Name, true)
+			return err
+		}
+		oc.gatewaysFailed.Delete(node.Name)
+	}
+
 	if !present {
 		// process all pods so they are reconfigured as local
 		errs := oc.addAllPodsOnNode(node.Name)
@@ -179,6 +263,16 @@ func (oc *SecondaryLayer2NetworkController) addUpdateLocalNodeEvent(node *corev1
 	return nil
 }
 
+// syncNodeGateway sets up the gateway router of the node on the primary network
+// and the egress IPs using it
+func (oc *SecondaryLayer2NetworkController) syncNodeGateway(node *corev1.Node) error {
+	if err := oc.ensureUDNGateway(node, nil); err != nil {
+		return fmt.Errorf("failed to set up the gateway of node %s for network %s: %w", node.Name,
+			oc.GetNetworkName(), err)
+	}
+	return oc.ensureUDNEgressIPs()
+}
+
 func (oc *SecondaryLayer2NetworkController) addUpdateRemoteNodeEvent(node *corev1.Node) error {
 	_, present := oc.localZoneNodes.Load(node.Name)
 
@@ -200,10 +294,36 @@ func (oc *SecondaryLayer2NetworkController) addUpdateRemoteNodeEvent(node *corev
 }
 
 func (oc *SecondaryLayer2NetworkController) deleteNodeEvent(node *corev1.Node) error {
+	if oc.IsPrimaryNetwork() {
+		if err := oc.deleteUDNGateway(node.Name); err != nil {
+			return fmt.Errorf("failed to delete the gateway of node %s for network %s: %w", node.Name,
+				oc.GetNetworkName(), err)
+		}
+		oc.gatewaysFailed.Delete(node.Name)
+	}
 	oc.localZoneNodes.Delete(node.Name)
 	return nil
 }
 
+// syncNodes removes the gateways of the primary network of the nodes which are
+// no longer local zone nodes
+func (oc *SecondaryLayer2NetworkController) syncNodes(nodes []interface{}) error {
+	if !oc.IsPrimaryNetwork() {
+		return nil
+	}
+	foundNodes := sets.New[string]()
+	for _, tmp := range nodes {
+		node, ok := tmp.(*corev1.Node)
+		if !ok {
+			return fmt.Errorf("spurious object in syncNodes: %v", tmp)
+		}
+		if oc.isLocalZoneNode(node) {
+			foundNodes.Insert(node.Name)
+		}
+	}
+	return oc.deleteStaleUDNGateways(foundNodes)
+}
+
 type secondaryLayer2NetworkControllerEventHandler struct {
 	baseHandler  baseNetworkControllerEventHandler
 	watchFactory *factory.WatchFactory
@@ -219,7 +339,17 @@ func (h *secondaryLayer2NetworkControllerEventHandler) AddResource(obj interface
 		if !ok {
 			return fmt.Errorf("could not cast %T object to Node", obj)
 		}
-		return h.oc.addUpdateNodeEvent(node)
+		syncGw := true
+		if fromRetryLoop {
+			_, syncGw = h.oc.gatewaysFailed.Load(node.Name)
+		}
+		return h.oc.addUpdateNodeEvent(node, syncGw)
+	case factory.HostType:
+		host, ok := obj.(*hostapi.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T object to Host", obj)
+		}
+		return h.oc.addUpdateHostEvent(nil, host)
 	default:
 		return h.oc.AddSecondaryNetworkResourceCommon(h.objType, obj)
 	}
@@ -228,11 +358,28 @@ func (h *secondaryLayer2NetworkControllerEventHandler) AddResource(obj interface
 func (h *secondaryLayer2NetworkControllerEventHandler) UpdateResource(oldObj interface{}, newObj interface{}, inRetryCache bool) error {
 	switch h.objType {
 	case factory.NodeType:
+		oldNode, ok := oldObj.(*corev1.Node)
+		if !ok {
+			return fmt.Errorf("could not cast %T old object to Node", oldObj)
+		}
 		node, ok := newObj.(*corev1.Node)
 		if !ok {
 			return fmt.Errorf("could not cast %T object to Node", newObj)
 		}
-		return h.oc.addUpdateNodeEvent(node)
+		_, gwFailed := h.oc.gatewaysFailed.Load(node.Name)
+		syncGw := gwFailed || !h.oc.isLocalZoneNode(oldNode) || gatewayChanged(oldNode, node) ||
+			nodeChassisChanged(oldNode, node)
+		return h.oc.addUpdateNodeEvent(node, syncGw)
+	case factory.HostType:
+		oldHost, ok := oldObj.(*hostapi.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T old object to Host", oldObj)
+		}
+		newHost, ok := newObj.(*hostapi.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T new object to Host", newObj)
+		}
+		return h.oc.addUpdateHostEvent(oldHost, newHost)
 	default:
 		return h.oc.UpdateSecondaryNetworkResourceCommon(h.objType, oldObj, newObj, inRetryCache)
 	}
@@ -246,6 +393,12 @@ func (h *secondaryLayer2NetworkControllerEventHandler) DeleteResource(obj interf
 			return fmt.Errorf("could not cast %T object to Node", obj)
 		}
 		return h.oc.deleteNodeEvent(node)
+	case factory.HostType:
+		host, ok := obj.(*hostapi.Host)
+		if !ok {
+			return fmt.Errorf("could not cast %T object to Host", obj)
+		}
+		return h.oc.deleteHostEvent(host)
 	default:
 		return h.oc.DeleteSecondaryNetworkResourceCommon(h.objType, obj, cachedObj)
 	}
@@ -260,7 +413,11 @@ func (h *secondaryLayer2NetworkControllerEventHandler) SyncFunc(objs []interface
 	} else {
 		switch h.objType {
 		case factory.NodeType:
-			// no need to sync anything
+			syncFunc = h.oc.syncNodes
+		case factory.HostType:
+			syncFunc = h.oc.syncHosts
+		case factory.EgressIPType:
+			syncFunc = h.oc.syncUDNEgressIPs
 		default:
 			return fmt.Errorf("no sync function for object type %s", h.objType)
 		}
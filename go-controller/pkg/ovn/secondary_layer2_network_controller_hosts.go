@@ -0,0 +1,179 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	hostapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+// hostExternalID is the external ID of the logical switch port of a
+// standalone host, set to the name of the host
+const hostExternalID = "host"
+
+// WatchHosts starts the watching of the standalone hosts attached to the
+// network
+func (oc *SecondaryLayer2NetworkController) WatchHosts() error {
+	if oc.hostHandler != nil {
+		return nil
+	}
+
+	handler, err := oc.retryHosts.WatchResource()
+	if err != nil {
+		return err
+	}
+	oc.hostHandler = handler
+	return nil
+}
+
+// hostZone returns the zone of a standalone host
+func hostZone(host *hostapi.Host) string {
+	if host.Spec.Zone == "" {
+		return types.OvnDefaultZone
+	}
+	return host.Spec.Zone
+}
+
+func (oc *SecondaryLayer2NetworkController) isLocalZoneHost(host *hostapi.Host) bool {
+	return hostZone(host) == oc.zone
+}
+
+// syncHosts removes the logical switch ports of the hosts that no longer
+// exist on this network and the remote chassis of the hosts that no longer
+// exist at all
+func (oc *SecondaryLayer2NetworkController) syncHosts(objs []interface{}) error {
+	allHosts := sets.New[string]()
+	networkHosts := sets.New[string]()
+	for _, obj := range objs {
+		host, ok := obj.(*hostapi.Host)
+		if !ok {
+			return fmt.Errorf("spurious object in syncHosts: %v", obj)
+		}
+		allHosts.Insert(host.Name)
+		if host.Spec.Network == oc.GetNetworkName() {
+			networkHosts.Insert(host.Name)
+		}
+	}
+
+	sw := &nbdb.LogicalSwitch{Name: oc.GetNetworkScopedName(types.OVNLayer2Switch)}
+	p := func(lsp *nbdb.LogicalSwitchPort) bool {
+		hostName, ok := lsp.ExternalIDs[hostExternalID]
+		return ok && lsp.ExternalIDs[types.NetworkExternalID] == oc.GetNetworkName() && !networkHosts.Has(hostName)
+	}
+	ops, err := libovsdbops.DeleteLogicalSwitchPortsWithPredicateOps(oc.nbClient, nil, sw, p)
+	if err != nil {
+		return fmt.Errorf("failed to find stale host ports of network %s: %w", oc.GetNetworkName(), err)
+	}
+	if _, err = libovsdbops.TransactAndCheck(oc.nbClient, ops); err != nil {
+		return fmt.Errorf("failed to delete stale host ports of network %s: %w", oc.GetNetworkName(), err)
+	}
+
+	return oc.zoneChassisHandler.SyncHosts(allHosts)
+}
+
+// addUpdateHostEvent attaches a standalone host of this network, once it has
+// been allocated addresses, to the network switch. A host of a remote zone
+// gets a remote port bound to a remote chassis pointing at the host.
+func (oc *SecondaryLayer2NetworkController) addUpdateHostEvent(old, host *hostapi.Host) error {
+	if host.Spec.Network != oc.GetNetworkName() {
+		return nil
+	}
+
+	if old != nil && (hostZone(old) != hostZone(host) || old.Spec.ChassisID != host.Spec.ChassisID) {
+		// the host moved, clean up its previous port and chassis
+		if err := oc.deleteHostEvent(old); err != nil {
+			return err
+		}
+	}
+
+	if len(host.Status.IPAddresses) == 0 {
+		// wait for cluster manager to allocate the addresses
+		return nil
+	}
+
+	isLocal := oc.isLocalZoneHost(host)
+	if !isLocal && (host.Spec.ChassisID == "" || host.Spec.EncapIP == "") {
+		// wait for the agent of the host to report how to reach it
+		return nil
+	}
+
+	ips, err := util.ParseIPNets(host.Status.IPAddresses)
+	if err != nil {
+		return fmt.Errorf("failed to parse IPs of host %s: %w", host.Name, err)
+	}
+	mac, err := net.ParseMAC(host.Status.MACAddress)
+	if err != nil {
+		return fmt.Errorf("failed to parse MAC address of host %s: %w", host.Name, err)
+	}
+
+	addresses := mac.String()
+	for _, ip := range ips {
+		addresses = addresses + " " + ip.IP.String()
+	}
+
+	lsp := &nbdb.LogicalSwitchPort{
+		Name:         util.GetSecondaryNetworkHostLogicalPortName(host.Name, oc.GetNetworkName()),
+		Addresses:    []string{addresses},
+		PortSecurity: []string{addresses},
+		Options:      map[string]string{},
+		ExternalIDs: map[string]string{
+			hostExternalID:           host.Name,
+			types.NetworkExternalID:  oc.GetNetworkName(),
+			types.TopologyExternalID: oc.TopologyType(),
+		},
+	}
+	if isLocal && host.Spec.ChassisID != "" {
+		lsp.Options["requested-chassis"] = host.Spec.ChassisID
+	}
+	err = oc.zoneICHandler.AddTransitPortConfig(!isLocal, &util.PodAnnotation{TunnelID: host.Status.TunnelID}, lsp)
+	if err != nil {
+		return err
+	}
+
+	if !isLocal {
+		err = oc.zoneChassisHandler.AddRemoteZoneHost(host.Name, host.Spec.ChassisID, host.Spec.EncapIP)
+		if err != nil {
+			return err
+		}
+	}
+
+	sw := &nbdb.LogicalSwitch{Name: oc.GetNetworkScopedName(types.OVNLayer2Switch)}
+	if err = libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitch(oc.nbClient, sw, lsp); err != nil {
+		return fmt.Errorf("failed to create logical switch port %s for host %s: %w", lsp.Name, host.Name, err)
+	}
+
+	if !isLocal {
+		err = oc.zoneICHandler.BindTransitRemoteHostPort(host.Name, host.Spec.ChassisID, lsp.Name)
+		if err != nil {
+			return fmt.Errorf("failed to bind remote transit port of host %s: %w", host.Name, err)
+		}
+	}
+
+	klog.V(5).Infof("Attached host %s with addresses %s to network %s", host.Name, addresses, oc.GetNetworkName())
+	return nil
+}
+
+// deleteHostEvent detaches a standalone host of this network from the
+// network switch
+func (oc *SecondaryLayer2NetworkController) deleteHostEvent(host *hostapi.Host) error {
+	if host.Spec.Network != oc.GetNetworkName() {
+		return nil
+	}
+
+	sw := &nbdb.LogicalSwitch{Name: oc.GetNetworkScopedName(types.OVNLayer2Switch)}
+	lsp := &nbdb.LogicalSwitchPort{Name: util.GetSecondaryNetworkHostLogicalPortName(host.Name, oc.GetNetworkName())}
+	err := libovsdbops.DeleteLogicalSwitchPorts(oc.nbClient, sw, lsp)
+	if err != nil && err != libovsdbclient.ErrNotFound {
+		return fmt.Errorf("failed to delete logical switch port %s of host %s: %w", lsp.Name, host.Name, err)
+	}
+
+	return oc.zoneChassisHandler.DeleteRemoteZoneHost(host.Name)
+}
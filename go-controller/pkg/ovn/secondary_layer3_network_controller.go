@@ -103,9 +103,10 @@ func (h *secondaryLayer3NetworkControllerEventHandler) AddResource(obj interface
 				_, nodeSync := h.oc.addNodeFailed.Load(node.Name)
 				_, clusterRtrSync := h.oc.nodeClusterRouterPortFailed.Load(node.Name)
 				_, syncZoneIC := h.oc.syncZoneICFailed.Load(node.Name)
-				nodeParams = &nodeSyncs{syncNode: nodeSync, syncClusterRouterPort: clusterRtrSync, syncZoneIC: syncZoneIC}
+				_, syncGw := h.oc.gatewaysFailed.Load(node.Name)
+				nodeParams = &nodeSyncs{syncNode: nodeSync, syncClusterRouterPort: clusterRtrSync, syncZoneIC: syncZoneIC, syncGw: syncGw}
 			} else {
-				nodeParams = &nodeSyncs{syncNode: true, syncClusterRouterPort: true, syncZoneIC: config.OVNKubernetesFeature.EnableInterconnect}
+				nodeParams = &nodeSyncs{syncNode: true, syncClusterRouterPort: true, syncZoneIC: config.OVNKubernetesFeature.EnableInterconnect, syncGw: true}
 			}
 			if err := h.oc.addUpdateLocalNodeEvent(node, nodeParams); err != nil {
 				klog.Errorf("Node add failed for %s, will try again later: %v",
@@ -140,31 +141,43 @@ func (h *secondaryLayer3NetworkControllerEventHandler) UpdateResource(oldObj, ne
 		}
 		newNodeIsLocalZoneNode := h.oc.isLocalZoneNode(newNode)
 		zoneClusterChanged := h.oc.nodeZoneClusterChanged(oldNode, newNode, newNodeIsLocalZoneNode)
-		nodeSubnetChanged := nodeSubnetChanged(oldNode, newNode)
+		nodeSubnetChanged := nodeSubnetChanged(oldNode, newNode, h.oc.GetNetworkName())
 		if newNodeIsLocalZoneNode {
 			var nodeSyncsParam *nodeSyncs
-			if h.oc.isLocalZoneNode(oldNode) {
+			if noHostSubnetChanged(oldNode, newNode) && !util.NoHostSubnet(newNode) {
+				klog.Infof("Node %s is now managed by OVN for network %s", newNode.Name, h.oc.GetNetworkName())
+				// The node has no topology yet. Trigger a full node sync.
+				nodeSyncsParam = &nodeSyncs{syncNode: true, syncClusterRouterPort: true, syncZoneIC: config.OVNKubernetesFeature.EnableInterconnect, syncGw: true}
+			} else if h.oc.isLocalZoneNode(oldNode) {
 				// determine what actually changed in this update
 				_, nodeSync := h.oc.addNodeFailed.Load(newNode.Name)
 				_, failed := h.oc.nodeClusterRouterPortFailed.Load(newNode.Name)
 				clusterRtrSync := failed || nodeChassisChanged(oldNode, newNode) || nodeSubnetChanged
 				_, syncZoneIC := h.oc.syncZoneICFailed.Load(newNode.Name)
 				syncZoneIC = syncZoneIC || zoneClusterChanged
-				nodeSyncsParam = &nodeSyncs{syncNode: nodeSync, syncClusterRouterPort: clusterRtrSync, syncZoneIC: syncZoneIC}
+				_, gwFailed := h.oc.gatewaysFailed.Load(newNode.Name)
+				gwSync := gwFailed || gatewayChanged(oldNode, newNode) || nodeChassisChanged(oldNode, newNode) ||
+					nodeSubnetChanged
+				nodeSyncsParam = &nodeSyncs{syncNode: nodeSync, syncClusterRouterPort: clusterRtrSync, syncZoneIC: syncZoneIC, syncGw: gwSync}
 			} else {
 				klog.Infof("Node %s moved from the remote zone %s to local zone.",
 					newNode.Name, util.GetNodeZone(oldNode), util.GetNodeZone(newNode))
 				// The node is now a local zone node. Trigger a full node sync.
-				nodeSyncsParam = &nodeSyncs{syncNode: true, syncClusterRouterPort: true, syncZoneIC: config.OVNKubernetesFeature.EnableInterconnect}
+				nodeSyncsParam = &nodeSyncs{syncNode: true, syncClusterRouterPort: true, syncZoneIC: config.OVNKubernetesFeature.EnableInterconnect, syncGw: true}
 			}
 
 			return h.oc.addUpdateLocalNodeEvent(newNode, nodeSyncsParam)
 		} else {
+			if noHostSubnetChanged(oldNode, newNode) && util.NoHostSubnet(newNode) {
+				return h.oc.cleanupRemoteNoHostSubnetNode(newNode)
+			}
 			_, syncZoneIC := h.oc.syncZoneICFailed.Load(newNode.Name)
 
 			// Check if the node moved from local zone to remote zone and if so syncZoneIC should be set to true.
-			// Also check if node subnet changed, so static routes are properly set
-			syncZoneIC = syncZoneIC || h.oc.isLocalZoneNode(oldNode) || nodeSubnetChanged || zoneClusterChanged
+			// Also check if node subnet changed, so static routes are properly set, or if the node is now
+			// managed by OVN
+			syncZoneIC = syncZoneIC || h.oc.isLocalZoneNode(oldNode) || nodeSubnetChanged || zoneClusterChanged ||
+				noHostSubnetChanged(oldNode, newNode)
 			if syncZoneIC {
 				klog.Infof("Node %s in remote zone %s needs interconnect zone sync up. Zone cluster changed: %v",
 					newNode.Name, util.GetNodeZone(newNode), zoneClusterChanged)
@@ -213,6 +226,9 @@ func (h *secondaryLayer3NetworkControllerEventHandler) SyncFunc(objs []interface
 		case factory.MultiNetworkPolicyType:
 			syncFunc = h.oc.syncMultiNetworkPolicies
 
+		case factory.EgressIPType:
+			syncFunc = h.oc.syncUDNEgressIPs
+
 		default:
 			return fmt.Errorf("no sync function for object type %s", h.objType)
 		}
@@ -238,6 +254,7 @@ type SecondaryLayer3NetworkController struct {
 	addNodeFailed               sync.Map
 	nodeClusterRouterPortFailed sync.Map
 	syncZoneICFailed            sync.Map
+	gatewaysFailed              sync.Map
 }
 
 // NewSecondaryLayer3NetworkController create a new OVN controller for the given secondary layer3 NAD
@@ -266,6 +283,7 @@ func NewSecondaryLayer3NetworkController(cnci *CommonNetworkControllerInfo, netI
 				networkPolicies:             syncmap.NewSyncMap[*networkPolicy](),
 				sharedNetpolPortGroups:      syncmap.NewSyncMap[*defaultDenyPortGroups](),
 				podSelectorAddressSets:      syncmap.NewSyncMap[*PodSelectorAddressSet](),
+				netpolPortGroups:            syncmap.NewSyncMap[*netpolPortGroup](),
 				stopChan:                    stopChan,
 				wg:                          &sync.WaitGroup{},
 				localZoneNodes:              &sync.Map{},
@@ -276,6 +294,7 @@ func NewSecondaryLayer3NetworkController(cnci *CommonNetworkControllerInfo, netI
 		addNodeFailed:               sync.Map{},
 		nodeClusterRouterPortFailed: sync.Map{},
 		syncZoneICFailed:            sync.Map{},
+		gatewaysFailed:              sync.Map{},
 	}
 
 	if oc.allocatesPodAnnotation() {
@@ -304,6 +323,11 @@ func (oc *SecondaryLayer3NetworkController) initRetryFramework() {
 		oc.retryNamespaces = oc.newRetryFramework(factory.NamespaceType)
 		oc.retryNetworkPolicies = oc.newRetryFramework(factory.MultiNetworkPolicyType)
 	}
+
+	// egress IPs are only supported on primary networks
+	if oc.IsPrimaryNetwork() && config.OVNKubernetesFeature.EnableEgressIP {
+		oc.retryEgressIPs = oc.newRetryFramework(factory.EgressIPType)
+	}
 }
 
 // newRetryFramework builds and returns a retry framework for the input resource type;
@@ -320,6 +344,8 @@ func (oc *SecondaryLayer3NetworkController) newRetryFramework(
 		HasUpdateFunc:          hasResourceAnUpdateFunc(objectType),
 		NeedsUpdateDuringRetry: needsUpdateDuringRetry(objectType),
 		ObjType:                objectType,
+		MaxFailedAttempts:      maxFailedAttempts(objectType),
+		NetworkName:            oc.GetNetworkName(),
 		EventHandler:           eventHandler,
 	}
 	return retry.NewRetryFramework(
@@ -359,6 +385,9 @@ func (oc *SecondaryLayer3NetworkController) Stop() {
 	if oc.namespaceHandler != nil {
 		oc.watchFactory.RemoveNamespaceHandler(oc.namespaceHandler)
 	}
+	if oc.egressIPHandler != nil {
+		oc.watchFactory.RemoveEgressIPHandler(oc.egressIPHandler)
+	}
 }
 
 // Cleanup cleans up logical entities for the given network, called from net-attach-def routine
@@ -430,6 +459,11 @@ func (oc *SecondaryLayer3NetworkController) Run() error {
 		return err
 	}
 
+	// WatchEgressIPs depends on WatchPods and WatchNodes
+	if err := oc.WatchEgressIPs(); err != nil {
+		return err
+	}
+
 	klog.Infof("Completing all the Watchers for network %s took %v", oc.GetNetworkName(), time.Since(start))
 
 	// controller is fully running and resource handlers have synced, update Topology version in OVN
@@ -454,8 +488,19 @@ func (oc *SecondaryLayer3NetworkController) WatchNodes() error {
 }
 
 func (oc *SecondaryLayer3NetworkController) Init(ctx context.Context) error {
-	_, err := oc.createOvnClusterRouter()
-	return err
+	if _, err := oc.createOvnClusterRouter(); err != nil {
+		return err
+	}
+	if !oc.IsPrimaryNetwork() {
+		return nil
+	}
+	if err := oc.ensureUDNJoinSwitch(); err != nil {
+		return err
+	}
+	if config.OVNKubernetesFeature.EnableEgressIP {
+		return oc.ensureUDNNoReroutePolicies()
+	}
+	return nil
 }
 
 func (oc *SecondaryLayer3NetworkController) addUpdateLocalNodeEvent(node *kapi.Node, nSyncs *nodeSyncs) error {
@@ -466,6 +511,10 @@ func (oc *SecondaryLayer3NetworkController) addUpdateLocalNodeEvent(node *kapi.N
 	_, _ = oc.localZoneNodes.LoadOrStore(node.Name, true)
 
 	if noHostSubnet := util.NoHostSubnet(node); noHostSubnet {
+		if err := oc.cleanupNoHostSubnetNode(node); err != nil {
+			return fmt.Errorf("nodeAdd: error cleaning up node %s no longer managed by OVN for network %s: %w",
+				node.Name, oc.GetNetworkName(), err)
+		}
 		err := oc.lsManager.AddNoHostSubnetSwitch(oc.GetNetworkScopedName(node.Name))
 		if err != nil {
 			return fmt.Errorf("nodeAdd: error adding noHost subnet for switch %s: %w", oc.GetNetworkScopedName(node.Name), err)
@@ -495,6 +544,16 @@ func (oc *SecondaryLayer3NetworkController) addUpdateLocalNodeEvent(node *kapi.N
 		}
 	}
 
+	if nSyncs.syncGw && oc.IsPrimaryNetwork() {
+		err := oc.syncNodeGateway(node, hostSubnets)
+		if err != nil {
+			errs = append(errs, err)
+			oc.gatewaysFailed.Store(node.Name, true)
+		} else {
+			oc.gatewaysFailed.Delete(node.Name)
+		}
+	}
+
 	// ensure pods that already exist on this node have their logical ports created
 	if nSyncs.syncNode { // do this only if it is a new node add
 		errors := oc.addAllPodsOnNode(node.Name)
@@ -517,6 +576,24 @@ func (oc *SecondaryLayer3NetworkController) addUpdateLocalNodeEvent(node *kapi.N
 	return err
 }
 
+// syncNodeGateway sets up the gateway router of the node on the primary network
+// and the egress IPs using it
+func (oc *SecondaryLayer3NetworkController) syncNodeGateway(node *kapi.Node, hostSubnets []*net.IPNet) error {
+	if hostSubnets == nil {
+		var err error
+		hostSubnets, err = util.ParseNodeHostSubnetAnnotation(node, oc.GetNetworkName())
+		if err != nil {
+			return fmt.Errorf("failed to get the subnets of node %s for network %s: %w", node.Name,
+				oc.GetNetworkName(), err)
+		}
+	}
+	if err := oc.ensureUDNGateway(node, hostSubnets); err != nil {
+		return fmt.Errorf("failed to set up the gateway of node %s for network %s: %w", node.Name,
+			oc.GetNetworkName(), err)
+	}
+	return oc.ensureUDNEgressIPs()
+}
+
 func (oc *SecondaryLayer3NetworkController) addUpdateRemoteNodeEvent(node *kapi.Node, syncZoneIc bool) error {
 	_, present := oc.localZoneNodes.Load(node.Name)
 
@@ -576,7 +653,55 @@ func (oc *SecondaryLayer3NetworkController) deleteNodeEvent(node *kapi.Node) err
 	return nil
 }
 
+// cleanupNoHostSubnetNode removes the topology of a local zone node labeled as
+// not managed by OVN after it was, like if the node was deleted. The pods of
+// the node lose their connectivity on the network and need to be recreated.
+func (oc *SecondaryLayer3NetworkController) cleanupNoHostSubnetNode(node *kapi.Node) error {
+	switchName := oc.GetNetworkScopedName(node.Name)
+	if len(oc.lsManager.GetSwitchSubnets(switchName)) == 0 {
+		return nil
+	}
+	klog.Warningf("Node %s is no longer managed by OVN, removing its topology for network %s: its pods need to be recreated",
+		node.Name, oc.GetNetworkName())
+
+	if err := oc.deleteNode(node.Name); err != nil {
+		return err
+	}
+	if config.OVNKubernetesFeature.EnableInterconnect {
+		if err := oc.zoneICHandler.DeleteNode(node); err != nil {
+			return err
+		}
+		oc.syncZoneICFailed.Delete(node.Name)
+	}
+
+	oc.lsManager.DeleteSwitch(switchName)
+	oc.addNodeFailed.Delete(node.Name)
+	oc.nodeClusterRouterPortFailed.Delete(node.Name)
+	return nil
+}
+
+// cleanupRemoteNoHostSubnetNode removes the interconnect resources of a remote
+// zone node labeled as not managed by OVN after it was
+func (oc *SecondaryLayer3NetworkController) cleanupRemoteNoHostSubnetNode(node *kapi.Node) error {
+	klog.Infof("Remote zone node %s is no longer managed by OVN, removing its interconnect resources for network %s",
+		node.Name, oc.GetNetworkName())
+	if !config.OVNKubernetesFeature.EnableInterconnect {
+		return nil
+	}
+	if err := oc.zoneICHandler.DeleteNode(node); err != nil {
+		return err
+	}
+	oc.syncZoneICFailed.Delete(node.Name)
+	return nil
+}
+
 func (oc *SecondaryLayer3NetworkController) deleteNode(nodeName string) error {
+	if oc.IsPrimaryNetwork() {
+		if err := oc.deleteUDNGateway(nodeName); err != nil {
+			return fmt.Errorf("error deleting node %s gateway: %v", nodeName, err)
+		}
+		oc.gatewaysFailed.Delete(nodeName)
+	}
 	if err := oc.deleteNodeLogicalNetwork(nodeName); err != nil {
 		return fmt.Errorf("error deleting node %s logical network: %v", nodeName, err)
 	}
@@ -622,6 +747,12 @@ func (oc *SecondaryLayer3NetworkController) syncNodes(nodes []interface{}) error
 		}
 	}
 
+	if oc.IsPrimaryNetwork() {
+		if err := oc.deleteStaleUDNGateways(foundNodes); err != nil {
+			return fmt.Errorf("failed to delete the stale gateways of network %s: %w", oc.GetNetworkName(), err)
+		}
+	}
+
 	if config.OVNKubernetesFeature.EnableInterconnect {
 		if err := oc.zoneICHandler.SyncNodes(nodes); err != nil {
 			return fmt.Errorf("zoneICHandler failed to sync nodes: error: %w", err)
@@ -0,0 +1,202 @@
+package ovn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+// staleObjectGC garbage collects the port groups and address sets of a
+// network controller whose owning Kubernetes objects no longer exist, like the
+// ones left behind when ovnkube-controller crashes in the middle of a delete.
+// The owner of an address set is given by its external IDs, the owner of a
+// port group by the external IDs of its ACLs. Objects whose owner can't be
+// told, like port groups without ACLs or shared address sets, are kept. An
+// object is only deleted when found stale on two consecutive runs, so that
+// the handlers of the owner deletion have had time to delete it.
+type staleObjectGC struct {
+	bnc *BaseNetworkController
+	// candidates are the UUIDs of the objects found stale on the previous run
+	candidates sets.Set[string]
+}
+
+func newStaleObjectGC(bnc *BaseNetworkController) *staleObjectGC {
+	return &staleObjectGC{
+		bnc:        bnc,
+		candidates: sets.New[string](),
+	}
+}
+
+// runStaleObjectGC garbage collects the stale port groups and address sets at
+// the configured interval until stopChan is closed
+func (bnc *BaseNetworkController) runStaleObjectGC(wg *sync.WaitGroup) {
+	if config.OVNKubernetesFeature.StaleObjectGCInterval == 0 {
+		return
+	}
+	gc := newStaleObjectGC(bnc)
+	interval := time.Duration(config.OVNKubernetesFeature.StaleObjectGCInterval) * time.Second
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			if err := gc.run(); err != nil {
+				klog.Errorf("Failed to garbage collect the stale objects of network %s: %v", bnc.GetNetworkName(), err)
+			}
+		}, interval, bnc.stopChan)
+	}()
+}
+
+// ownerExists returns whether the owner of an object of the controller,
+// given by its owner type and name, exists, and whether it could be told
+func (gc *staleObjectGC) ownerExists(ownerType, name string) (bool, bool) {
+	var err error
+	switch ownerType {
+	case string(libovsdbops.NamespaceOwnerType), string(libovsdbops.NetpolNamespaceOwnerType),
+		string(libovsdbops.MulticastNamespaceOwnerType):
+		_, err = gc.bnc.watchFactory.GetNamespace(name)
+	case string(libovsdbops.NetworkPolicyOwnerType):
+		namespace, policy, errParse := parseACLPolicyKey(name)
+		if errParse != nil {
+			return false, false
+		}
+		_, err = gc.bnc.watchFactory.GetNetworkPolicy(namespace, policy)
+	case string(libovsdbops.HybridNodeRouteOwnerType):
+		_, err = gc.bnc.watchFactory.GetNode(name)
+	default:
+		return false, false
+	}
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, true
+		}
+		klog.Warningf("Failed to get the %s owner %s of OVN objects: %v", ownerType, name, err)
+		return false, false
+	}
+	return true, true
+}
+
+// isStale returns whether the external IDs belong to an object of the
+// controller whose owner is known not to exist anymore
+func (gc *staleObjectGC) isStale(externalIDs map[string]string) bool {
+	if externalIDs[libovsdbops.OwnerControllerKey.String()] != gc.bnc.controllerName {
+		return false
+	}
+	exists, known := gc.ownerExists(externalIDs[libovsdbops.OwnerTypeKey.String()],
+		externalIDs[libovsdbops.ObjectNameKey.String()])
+	return known && !exists
+}
+
+// isStalePortGroup returns whether the port group has ACLs, all of them owned
+// by objects that don't exist anymore
+func (gc *staleObjectGC) isStalePortGroup(pg *nbdb.PortGroup) (bool, error) {
+	if len(pg.ACLs) == 0 {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), types.OVSDBTimeout)
+	defer cancel()
+	for _, uuid := range pg.ACLs {
+		acl := &nbdb.ACL{UUID: uuid}
+		if err := gc.bnc.nbClient.Get(ctx, acl); err != nil {
+			if errors.Is(err, libovsdbclient.ErrNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		if !gc.isStale(acl.ExternalIDs) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// run deletes the objects found stale on this run and the previous one and
+// remembers the others found stale for the next run
+func (gc *staleObjectGC) run() error {
+	stale := sets.New[string]()
+	var stalePGs []*nbdb.PortGroup
+	var staleAddrSets []*nbdb.AddressSet
+
+	pgs, err := libovsdbops.FindPortGroupsWithPredicate(gc.bnc.nbClient, func(pg *nbdb.PortGroup) bool {
+		if gc.bnc.IsSecondary() {
+			return pg.ExternalIDs[types.NetworkExternalID] == gc.bnc.GetNetworkName()
+		}
+		return pg.ExternalIDs[types.NetworkExternalID] == ""
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the port groups: %w", err)
+	}
+	for _, pg := range pgs {
+		isStale, err := gc.isStalePortGroup(pg)
+		if err != nil {
+			return fmt.Errorf("failed to check port group %s: %w", pg.Name, err)
+		}
+		if !isStale {
+			continue
+		}
+		stale.Insert(pg.UUID)
+		if gc.candidates.Has(pg.UUID) {
+			stalePGs = append(stalePGs, pg)
+		}
+	}
+
+	addrSets, err := libovsdbops.FindAddressSetsWithPredicate(gc.bnc.nbClient, func(as *nbdb.AddressSet) bool {
+		return gc.isStale(as.ExternalIDs)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find the address sets: %w", err)
+	}
+	for _, as := range addrSets {
+		stale.Insert(as.UUID)
+		if gc.candidates.Has(as.UUID) {
+			staleAddrSets = append(staleAddrSets, as)
+		}
+	}
+	gc.candidates = stale
+
+	if len(stalePGs) == 0 && len(staleAddrSets) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(stalePGs))
+	for _, pg := range stalePGs {
+		names = append(names, pg.Name)
+	}
+	var ops []ovsdb.Operation
+	// the ACLs are garbage collected with the port groups
+	ops, err = libovsdbops.DeletePortGroupsOps(gc.bnc.nbClient, ops, names...)
+	if err != nil {
+		return err
+	}
+	ops, err = libovsdbops.DeleteAddressSetsOps(gc.bnc.nbClient, ops, staleAddrSets...)
+	if err != nil {
+		return err
+	}
+	if _, err = libovsdbops.TransactAndCheck(gc.bnc.nbClient, ops); err != nil {
+		return fmt.Errorf("failed to delete the stale port groups %v and address sets: %w", names, err)
+	}
+	for _, pg := range stalePGs {
+		gc.candidates.Delete(pg.UUID)
+	}
+	for _, as := range staleAddrSets {
+		gc.candidates.Delete(as.UUID)
+	}
+	klog.Infof("Garbage collected %d stale port groups and %d stale address sets of network %s",
+		len(stalePGs), len(staleAddrSets), gc.bnc.GetNetworkName())
+	metrics.RecordStaleObjectsDeleted(gc.bnc.GetNetworkName(), nbdb.PortGroupTable, len(stalePGs))
+	metrics.RecordStaleObjectsDeleted(gc.bnc.GetNetworkName(), nbdb.AddressSetTable, len(staleAddrSets))
+	return nil
+}
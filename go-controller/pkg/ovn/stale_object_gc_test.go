@@ -0,0 +1,86 @@
+package ovn
+
+import (
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	addressset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/address_set"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+)
+
+var _ = ginkgo.Describe("OVN stale object garbage collection", func() {
+	var fakeOvn *FakeOVN
+
+	ginkgo.BeforeEach(func() {
+		// Restore global default values before each testcase
+		err := config.PrepareTestConfig()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		fakeOvn = NewFakeOVN(false)
+	})
+
+	ginkgo.AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	policyACL := func(uuid, controller, policyKey string) *nbdb.ACL {
+		return &nbdb.ACL{
+			UUID:      uuid,
+			Action:    nbdb.ACLActionAllow,
+			Direction: nbdb.ACLDirectionToLport,
+			Match:     "ip4",
+			ExternalIDs: map[string]string{
+				libovsdbops.OwnerControllerKey.String(): controller,
+				libovsdbops.OwnerTypeKey.String():       string(libovsdbops.NetworkPolicyOwnerType),
+				libovsdbops.ObjectNameKey.String():      policyKey,
+			},
+		}
+	}
+
+	ginkgo.It("deletes the objects of deleted owners found stale on two runs", func() {
+		existingV4, existingV6 := addressset.GetTestDbAddrSets(
+			getNamespaceAddrSetDbIDs("existing", DefaultNetworkControllerName), []net.IP{net.ParseIP("10.128.0.5")})
+		staleV4, staleV6 := addressset.GetTestDbAddrSets(
+			getNamespaceAddrSetDbIDs("deleted", DefaultNetworkControllerName), []net.IP{net.ParseIP("10.128.0.6")})
+		otherV4, _ := addressset.GetTestDbAddrSets(
+			getNamespaceAddrSetDbIDs("deleted", "other-controller"), nil)
+		stalePolicyACL := policyACL("stale-acl-uuid", DefaultNetworkControllerName, "existing:deleted-policy")
+		otherPolicyACL := policyACL("other-acl-uuid", "other-controller", "existing:deleted-policy")
+		unknownACL := &nbdb.ACL{
+			UUID:      "unknown-acl-uuid",
+			Action:    nbdb.ACLActionAllow,
+			Direction: nbdb.ACLDirectionToLport,
+			Match:     "ip4",
+		}
+		initialData := []libovsdbtest.TestData{
+			existingV4, existingV6, staleV4, staleV6, otherV4,
+			stalePolicyACL, otherPolicyACL, unknownACL,
+			&nbdb.PortGroup{UUID: "stale-pg-uuid", Name: "stale_pg", ACLs: []string{stalePolicyACL.UUID}},
+			&nbdb.PortGroup{UUID: "other-pg-uuid", Name: "other_pg", ACLs: []string{otherPolicyACL.UUID}},
+			&nbdb.PortGroup{UUID: "unknown-pg-uuid", Name: "unknown_pg", ACLs: []string{unknownACL.UUID}},
+			&nbdb.PortGroup{UUID: "empty-pg-uuid", Name: "empty_pg"},
+		}
+		fakeOvn.startWithDBSetup(libovsdbtest.TestSetup{NBData: initialData}, newNamespace("existing"))
+
+		gc := newStaleObjectGC(&fakeOvn.controller.BaseNetworkController)
+		// the stale objects are only candidates on the first run
+		gomega.Expect(gc.run()).To(gomega.Succeed())
+		gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(initialData))
+
+		gomega.Expect(gc.run()).To(gomega.Succeed())
+		// test server does not garbage collect ACLs, the stale one stays
+		expectedData := []libovsdbtest.TestData{
+			existingV4, existingV6, otherV4,
+			stalePolicyACL, otherPolicyACL, unknownACL,
+			&nbdb.PortGroup{UUID: "other-pg-uuid", Name: "other_pg", ACLs: []string{otherPolicyACL.UUID}},
+			&nbdb.PortGroup{UUID: "unknown-pg-uuid", Name: "unknown_pg", ACLs: []string{unknownACL.UUID}},
+			&nbdb.PortGroup{UUID: "empty-pg-uuid", Name: "empty_pg"},
+		}
+		gomega.Eventually(fakeOvn.nbClient).Should(libovsdbtest.HaveData(expectedData))
+	})
+})
@@ -0,0 +1,76 @@
+package ovn
+
+import (
+	libovsdbclient "github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/ovsdb"
+
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+)
+
+// txnPlanner groups the NB operations that different reconcilers prepare for
+// the same object into a single transaction, e.g. the creation of a pod's
+// logical switch port and its network policy port group memberships, so that
+// there is no window where only part of the changes is applied.
+//
+// Reconcilers that keep in-memory state tied to their operations join the
+// transaction as participants, with a commit callback to run once the
+// transaction succeeds and an abort callback to undo their state otherwise.
+// Participants may hold locks until either callback is called.
+type txnPlanner struct {
+	ops      []ovsdb.Operation
+	onCommit []func()
+	onAbort  []func()
+}
+
+func newTxnPlanner(ops []ovsdb.Operation) *txnPlanner {
+	return &txnPlanner{ops: ops}
+}
+
+// addOps adds operations without any related state to the transaction
+func (p *txnPlanner) addOps(ops ...ovsdb.Operation) {
+	p.ops = append(p.ops, ops...)
+}
+
+// addParticipant adds the operations of a participant to the transaction.
+// Exactly one of commit or abort will be called.
+func (p *txnPlanner) addParticipant(ops []ovsdb.Operation, commit, abort func()) {
+	p.ops = append(p.ops, ops...)
+	if commit != nil {
+		p.onCommit = append(p.onCommit, commit)
+	}
+	if abort != nil {
+		p.onAbort = append(p.onAbort, abort)
+	}
+}
+
+// transact runs all the planned operations in a single transaction, sets the
+// UUIDs of the given models that were created by it and then calls the commit
+// callbacks of the participants on success, or their abort callbacks on error.
+func (p *txnPlanner) transact(nbClient libovsdbclient.Client, models interface{}) ([]ovsdb.OperationResult, error) {
+	results, err := libovsdbops.TransactAndCheckAndSetUUIDs(nbClient, models, p.ops)
+	if err != nil {
+		p.abort()
+		return nil, err
+	}
+	for _, commit := range p.onCommit {
+		commit()
+	}
+	p.reset()
+	return results, nil
+}
+
+// abort calls the abort callbacks of the participants without running the
+// transaction. It is a no-op if the planner was already transacted or
+// aborted, so it can be deferred right after creating the planner.
+func (p *txnPlanner) abort() {
+	for i := len(p.onAbort) - 1; i >= 0; i-- {
+		p.onAbort[i]()
+	}
+	p.reset()
+}
+
+func (p *txnPlanner) reset() {
+	p.ops = nil
+	p.onCommit = nil
+	p.onAbort = nil
+}
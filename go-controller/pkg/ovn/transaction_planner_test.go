@@ -0,0 +1,85 @@
+package ovn
+
+import (
+	"testing"
+
+	libovsdbops "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/libovsdb/ops"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/nbdb"
+	libovsdbtest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing/libovsdb"
+
+	"github.com/onsi/gomega"
+)
+
+func TestTxnPlanner(t *testing.T) {
+	tests := []struct {
+		desc string
+		// failTxn adds an operation that makes the transaction fail
+		failTxn bool
+	}{
+		{
+			desc: "transacts a new port and its port group membership together and commits the participants",
+		},
+		{
+			desc:    "aborts the participants when the transaction fails",
+			failTxn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			existingPort := &nbdb.LogicalSwitchPort{UUID: "existing-port-UUID", Name: "existing-port"}
+			sw := &nbdb.LogicalSwitch{UUID: "switch-UUID", Name: "node1", Ports: []string{existingPort.UUID}}
+			pg := &nbdb.PortGroup{UUID: "pg-UUID", Name: "pg"}
+			nbClient, cleanup, err := libovsdbtest.NewNBTestHarness(libovsdbtest.TestSetup{
+				NBData: []libovsdbtest.TestData{existingPort, sw, pg},
+			}, nil)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			t.Cleanup(cleanup.Cleanup)
+
+			lsp := &nbdb.LogicalSwitchPort{Name: "port"}
+			ops, err := libovsdbops.CreateOrUpdateLogicalSwitchPortsOnSwitchOps(nbClient, nil, &nbdb.LogicalSwitch{Name: sw.Name}, lsp)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			planner := newTxnPlanner(ops)
+
+			pgOps, err := libovsdbops.AddPortsToPortGroupOps(nbClient, nil, pg.Name, lsp.UUID)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			var committedUUID string
+			var commits, aborts int
+			planner.addParticipant(pgOps, func() {
+				commits++
+				committedUUID = lsp.UUID
+			}, func() {
+				aborts++
+			})
+
+			if tt.failTxn {
+				// a port with the same name as an existing one violates the
+				// table index
+				failOps, err := nbClient.Create(&nbdb.LogicalSwitchPort{Name: existingPort.Name})
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+				planner.addOps(failOps...)
+			}
+
+			_, err = planner.transact(nbClient, lsp)
+			// aborting a transacted planner is a no-op
+			planner.abort()
+
+			if tt.failTxn {
+				g.Expect(err).To(gomega.HaveOccurred())
+				g.Expect(commits).To(gomega.Equal(0))
+				g.Expect(aborts).To(gomega.Equal(1))
+				g.Eventually(nbClient).Should(libovsdbtest.HaveData(existingPort, sw, pg))
+				return
+			}
+
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(commits).To(gomega.Equal(1))
+			g.Expect(aborts).To(gomega.Equal(0))
+			g.Expect(committedUUID).To(gomega.Equal(lsp.UUID))
+			expectedPort := &nbdb.LogicalSwitchPort{UUID: lsp.UUID, Name: lsp.Name}
+			expectedSwitch := &nbdb.LogicalSwitch{UUID: sw.UUID, Name: sw.Name, Ports: []string{existingPort.UUID, lsp.UUID}}
+			expectedPG := &nbdb.PortGroup{UUID: pg.UUID, Name: pg.Name, Ports: []string{lsp.UUID}}
+			g.Eventually(nbClient).Should(libovsdbtest.HaveData(existingPort, expectedPort, expectedSwitch, expectedPG))
+		})
+	}
+}
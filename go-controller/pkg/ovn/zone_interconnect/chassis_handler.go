@@ -18,6 +18,10 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
 
+// standaloneHostChassisKey flags, in the chassis other_config, the remote
+// chassis created for standalone hosts rather than for nodes
+const standaloneHostChassisKey = "standalone-host"
+
 // ZoneChassisHandler creates chassis records for the remote zone nodes
 // in the OVN Southbound DB. It also creates the encap records.
 type ZoneChassisHandler struct {
@@ -107,6 +111,10 @@ func (zic *ZoneChassisHandler) SyncNodes(kNodes []interface{}) error {
 	}
 
 	for _, ch := range chassis {
+		if isStandaloneHostChassis(ch) {
+			// remote standalone host chassis are synced with the hosts
+			continue
+		}
 		if ch.OtherConfig != nil && strings.ToLower(ch.OtherConfig["is-remote"]) == "true" {
 			if !foundNodes.Has(ch.Hostname) {
 				// Its a stale remote chassis, delete it.
@@ -160,3 +168,62 @@ func (zch *ZoneChassisHandler) createOrUpdateNodeChassis(node *corev1.Node, isRe
 
 	return libovsdbops.CreateOrUpdateChassis(zch.sbClient, &chassis, &encap)
 }
+
+// AddRemoteZoneHost creates the remote chassis of a standalone host of a
+// remote zone in the SB DB. The chassis is flagged so that it is not taken
+// for a stale node chassis.
+func (zch *ZoneChassisHandler) AddRemoteZoneHost(hostName, chassisID, encapIP string) error {
+	chassis := sbdb.Chassis{
+		Name:     chassisID,
+		Hostname: hostName,
+		OtherConfig: map[string]string{
+			"is-remote":              "true",
+			standaloneHostChassisKey: "true",
+		},
+	}
+
+	encap := sbdb.Encap{
+		ChassisName: chassisID,
+		IP:          encapIP,
+		Type:        "geneve",
+		Options:     map[string]string{"csum": "true"},
+	}
+
+	// set the geneve port if using something else than default
+	if config.Default.EncapPort != config.DefaultEncapPort {
+		encap.Options["dst_port"] = strconv.FormatUint(uint64(config.Default.EncapPort), 10)
+	}
+
+	if err := libovsdbops.CreateOrUpdateChassis(zch.sbClient, &chassis, &encap); err != nil {
+		return fmt.Errorf("failed to create or update remote chassis for host %s, error: %w", hostName, err)
+	}
+	return nil
+}
+
+// DeleteRemoteZoneHost deletes the remote chassis (if it exists) of a
+// standalone host.
+func (zch *ZoneChassisHandler) DeleteRemoteZoneHost(hostName string) error {
+	p := func(chassis *sbdb.Chassis) bool {
+		return chassis.Hostname == hostName && isStandaloneHostChassis(chassis)
+	}
+	if err := libovsdbops.DeleteChassisWithPredicate(zch.sbClient, p); err != nil {
+		return fmt.Errorf("failed to remove the remote chassis of host %s in the OVN SB Chassis table: %w", hostName, err)
+	}
+	return nil
+}
+
+// SyncHosts cleans up the remote chassis records in the OVN Southbound db
+// for the stale standalone hosts
+func (zch *ZoneChassisHandler) SyncHosts(hostNames sets.Set[string]) error {
+	p := func(chassis *sbdb.Chassis) bool {
+		return isStandaloneHostChassis(chassis) && !hostNames.Has(chassis.Hostname)
+	}
+	if err := libovsdbops.DeleteChassisWithPredicate(zch.sbClient, p); err != nil {
+		return fmt.Errorf("failed to delete stale remote chassis of hosts: %w", err)
+	}
+	return nil
+}
+
+func isStandaloneHostChassis(chassis *sbdb.Chassis) bool {
+	return chassis.OtherConfig != nil && strings.ToLower(chassis.OtherConfig[standaloneHostChassisKey]) == "true"
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/urfave/cli/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -406,4 +407,67 @@ var _ = ginkgo.Describe("Zone Interconnect Chassis Operations", func() {
 		})
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	})
+
+	ginkgo.It("Add, delete and sync remote zone hosts", func() {
+		app.Action = func(ctx *cli.Context) error {
+			dbSetup := libovsdbtest.TestSetup{
+				SBData: initialSBDB,
+			}
+
+			_, err := config.InitConfig(ctx, nil, nil)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			config.Kubernetes.HostNetworkNamespace = ""
+
+			var libovsdbOvnSBClient libovsdbclient.Client
+			_, libovsdbOvnSBClient, libovsdbCleanup, err = libovsdbtest.NewNBSBTestHarness(dbSetup)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			host1Chassis := sbdb.Chassis{Name: "0c7f2e6a-2c4e-4a7a-9a59-9c0b5a2f1d01"}
+			host2Chassis := sbdb.Chassis{Name: "0c7f2e6a-2c4e-4a7a-9a59-9c0b5a2f1d02"}
+
+			zoneChassisHandler := NewZoneChassisHandler(libovsdbOvnSBClient)
+			err = zoneChassisHandler.AddRemoteZoneHost("host1", host1Chassis.Name, "10.0.0.20")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			err = zoneChassisHandler.AddRemoteZoneHost("host2", host2Chassis.Name, "10.0.0.21")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			hostCh, err := libovsdbops.GetChassis(libovsdbOvnSBClient, &host1Chassis)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(hostCh.Hostname).To(gomega.Equal("host1"))
+			gomega.Expect(hostCh.OtherConfig).Should(gomega.HaveKeyWithValue("is-remote", "true"))
+			gomega.Expect(hostCh.OtherConfig).Should(gomega.HaveKeyWithValue(standaloneHostChassisKey, "true"))
+
+			// syncing nodes leaves the host chassis alone
+			err = zoneChassisHandler.SyncNodes([]interface{}{&testNode1, &testNode2})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			_, err = libovsdbops.GetChassis(libovsdbOvnSBClient, &host1Chassis)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			// syncing hosts removes the chassis of the stale hosts only
+			err = zoneChassisHandler.SyncHosts(sets.New[string]("host1"))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			_, err = libovsdbops.GetChassis(libovsdbOvnSBClient, &host1Chassis)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			_, err = libovsdbops.GetChassis(libovsdbOvnSBClient, &host2Chassis)
+			gomega.Expect(err).To(gomega.MatchError(libovsdbclient.ErrNotFound))
+			_, err = libovsdbops.GetChassis(libovsdbOvnSBClient, &node1Chassis)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			err = zoneChassisHandler.DeleteRemoteZoneHost("host1")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			_, err = libovsdbops.GetChassis(libovsdbOvnSBClient, &host1Chassis)
+			gomega.Expect(err).To(gomega.MatchError(libovsdbclient.ErrNotFound))
+
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+			"-cluster-subnets=" + clusterCIDR,
+			"-init-cluster-manager",
+			"-zone-join-switch-subnets=" + joinSubnetCIDR,
+			"-enable-interconnect",
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
 })
@@ -112,6 +112,8 @@ const (
  * AddTransitSwitchConfig will add to the switch the specific transit config
  * AddTransitPortConfig will add to the local or remote port the specific transit config
  * BindTransitRemotePort will bind the remote port to the remote chassis
+ * BindTransitRemoteHostPort will bind the remote port of a standalone host to
+ * the remote chassis of the host, created by ZoneChassisHandler as well
  *
  *
  * Note that the Chassis entry for each remote zone node is created by ZoneChassisHandler
@@ -376,6 +378,12 @@ func (zic *ZoneInterconnectHandler) BindTransitRemotePort(nodeName, portName str
 	return zic.setRemotePortBindingChassis(nodeName, portName, chassisId)
 }
 
+// BindTransitRemoteHostPort binds the remote port of a standalone host to
+// the remote chassis of the host
+func (zic *ZoneInterconnectHandler) BindTransitRemoteHostPort(hostName, chassisID, portName string) error {
+	return zic.setRemotePortBindingChassis(hostName, portName, chassisID)
+}
+
 func (zic *ZoneInterconnectHandler) addTransitSwitchConfig(sw *nbdb.LogicalSwitch, networkID int) {
 	if sw.OtherConfig == nil {
 		sw.OtherConfig = map[string]string{}
@@ -646,7 +654,7 @@ func (zic *ZoneInterconnectHandler) addRemoteNodeStaticRoutes(node *corev1.Node,
 		return nil
 	}
 
-	nodeSubnets, err := util.ParseNodeHostSubnetAnnotation(node, zic.GetNetworkName())
+	nodeSubnets, err := util.ParseNodeHostSubnets(node, zic.GetNetworkName(), zic.watchFactory)
 	if err != nil {
 		return fmt.Errorf("failed to parse node %s subnets annotation %w", node.Name, err)
 	}
@@ -695,7 +703,7 @@ func (zic *ZoneInterconnectHandler) deleteLocalNodeStaticRoutes(node *corev1.Nod
 		return nil
 	}
 
-	nodeSubnets, err := util.ParseNodeHostSubnetAnnotation(node, zic.GetNetworkName())
+	nodeSubnets, err := util.ParseNodeHostSubnets(node, zic.GetNetworkName(), zic.watchFactory)
 	if err != nil {
 		return fmt.Errorf("failed to parse node %s subnets annotation %w", node.Name, err)
 	}
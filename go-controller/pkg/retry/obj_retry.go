@@ -13,6 +13,7 @@ import (
 
 	"k8s.io/klog/v2"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/syncmap"
@@ -23,6 +24,7 @@ const RetryObjInterval = 30 * time.Second
 const MaxFailedAttempts = 15 // same value used for the services level-driven controller
 const initialBackoff = 1
 const noBackoff = 0
+const maxBackoff = 60
 
 // retryObjEntry is a generic object caching with retry mechanism
 // that resources can use to eventually complete their intended operations.
@@ -38,6 +40,9 @@ type retryObjEntry struct {
 	backoffSec time.Duration
 	// number of times this object has been unsuccessfully added/updated/deleted
 	failedAttempts uint8
+	// parked is true once the object exhausted its error budget: it is no longer
+	// retried until a new event is received for it
+	parked bool
 }
 
 type EventHandler interface {
@@ -94,6 +99,14 @@ type ResourceHandler struct {
 	HasUpdateFunc          bool
 	NeedsUpdateDuringRetry bool
 	ObjType                reflect.Type
+	// MaxFailedAttempts is the error budget of each object of this resource type: the number
+	// of failed attempts after which the object is parked, set by the controllers after how
+	// long the objects of the type may legitimately wait on others. MaxFailedAttempts is used
+	// when zero.
+	MaxFailedAttempts uint8
+	// NetworkName is the network the objects are reconciled for, labeling the
+	// reconcile error metrics. Empty stands for the default network.
+	NetworkName string
 	EventHandler
 }
 
@@ -142,7 +155,7 @@ func (r *RetryFramework) initRetryObjWithAddBackoff(obj interface{}, lockedKey s
 	entry, _ := r.retryEntries.LoadOrStore(lockedKey, &retryObjEntry{backoffSec: backoff})
 	entry.timeStamp = time.Now()
 	entry.newObj = obj
-	entry.failedAttempts = 0
+	r.resetFailedAttempts(entry)
 	entry.backoffSec = backoff
 	return entry
 }
@@ -160,7 +173,7 @@ func (r *RetryFramework) initRetryObjWithUpdate(oldObj, newObj interface{}, lock
 	entry.timeStamp = time.Now()
 	entry.newObj = newObj
 	entry.config = oldObj
-	entry.failedAttempts = 0
+	r.resetFailedAttempts(entry)
 	return entry
 }
 
@@ -177,7 +190,7 @@ func (r *RetryFramework) InitRetryObjWithDelete(obj interface{}, lockedKey strin
 	if entry.config == nil {
 		entry.config = config
 	}
-	entry.failedAttempts = 0
+	r.resetFailedAttempts(entry)
 	if noRetryAdd {
 		// will not be retried for addition
 		entry.newObj = nil
@@ -203,9 +216,54 @@ func (r *RetryFramework) getRetryObj(lockedKey string) (value *retryObjEntry, fo
 }
 
 func (r *RetryFramework) DeleteRetryObj(lockedKey string) {
+	if entry, found := r.getRetryObj(lockedKey); found {
+		r.unparkRetryObj(entry)
+	}
 	r.retryEntries.Delete(lockedKey)
 }
 
+// maxFailedAttempts returns the error budget of the objects of the resource type
+func (r *RetryFramework) maxFailedAttempts() uint8 {
+	if r.ResourceHandler.MaxFailedAttempts > 0 {
+		return r.ResourceHandler.MaxFailedAttempts
+	}
+	return MaxFailedAttempts
+}
+
+// resetFailedAttempts gives a new error budget to an object upon a new event, and
+// resumes its retries if it was parked
+func (r *RetryFramework) resetFailedAttempts(entry *retryObjEntry) {
+	entry.failedAttempts = 0
+	r.unparkRetryObj(entry)
+}
+
+// parkRetryObj stops retrying an object that exhausted its error budget, so that it
+// doesn't take turns from the other objects, and reports it with an event and metrics.
+// The entry is kept until a new event for the object gives it a new error budget.
+func (r *RetryFramework) parkRetryObj(entry *retryObjEntry, lockedKey string) {
+	klog.Warningf("Parking retry entry for %s %s: exceeded number of failed attempts (%d),"+
+		" it won't be retried until its next event", r.ResourceHandler.ObjType, lockedKey, entry.failedAttempts)
+	entry.parked = true
+	metrics.MetricResourceRetryFailuresCount.Inc()
+	metrics.MetricResourceRetryParkedObjects.WithLabelValues(r.ResourceHandler.ObjType.String()).Inc()
+	obj := entry.newObj
+	if obj == nil {
+		obj = entry.oldObj
+	}
+	if obj != nil {
+		r.ResourceHandler.RecordErrorEvent(obj, "RetryFailed",
+			fmt.Errorf("failed to reconcile and retried %d times, parked until the next event for object: %v",
+				entry.failedAttempts, obj))
+	}
+}
+
+func (r *RetryFramework) unparkRetryObj(entry *retryObjEntry) {
+	if entry.parked {
+		entry.parked = false
+		metrics.MetricResourceRetryParkedObjects.WithLabelValues(r.ResourceHandler.ObjType.String()).Dec()
+	}
+}
+
 // setRetryObjWithNoBackoff sets an object's backoff to be retried
 // immediately during the next retry iteration
 // Used only for testing right now
@@ -223,6 +281,11 @@ func (r *RetryFramework) removeDeleteFromRetryObj(entry *retryObjEntry) {
 // for the given key
 func (r *RetryFramework) increaseFailedAttemptsCounter(entry *retryObjEntry) {
 	entry.failedAttempts++
+	networkName := r.ResourceHandler.NetworkName
+	if networkName == "" {
+		networkName = ovntypes.DefaultNetworkName
+	}
+	metrics.MetricNetworkReconcileErrors.WithLabelValues(networkName, r.ResourceHandler.ObjType.String()).Inc()
 }
 
 // RequestRetryFramework allows a caller to immediately request to iterate through all objects that
@@ -255,15 +318,12 @@ func (r *RetryFramework) resourceRetry(objKey string, now time.Time) {
 			return
 		}
 
-		if entry.failedAttempts >= MaxFailedAttempts {
-			klog.Warningf("Dropping retry entry for %s %s: exceeded number of failed attempts",
-				r.ResourceHandler.ObjType, objKey)
-			r.DeleteRetryObj(key)
-			metrics.MetricResourceRetryFailuresCount.Inc()
-			if entry.newObj != nil {
-				r.ResourceHandler.RecordErrorEvent(entry.newObj, "RetryFailed",
-					fmt.Errorf("failed to reconcile and retried %d times for object: %v", MaxFailedAttempts, entry.newObj))
-			}
+		if entry.parked {
+			klog.V(5).Infof("%v resource %s is parked: skip", r.ResourceHandler.ObjType, objKey)
+			return
+		}
+		if entry.failedAttempts >= r.maxFailedAttempts() {
+			r.parkRetryObj(entry, key)
 			return
 		}
 		forceRetry := false
@@ -281,8 +341,8 @@ func (r *RetryFramework) resourceRetry(objKey string, now time.Time) {
 
 		// update backoff for future attempts in case of failure
 		entry.backoffSec = entry.backoffSec * 2
-		if entry.backoffSec > 60 {
-			entry.backoffSec = 60
+		if entry.backoffSec > maxBackoff {
+			entry.backoffSec = maxBackoff
 		}
 
 		// storing original obj for metrics
@@ -317,7 +377,7 @@ func (r *RetryFramework) resourceRetry(objKey string, now time.Time) {
 			if err := r.ResourceHandler.UpdateResource(entry.config, entry.newObj, true); err != nil {
 				entry.timeStamp = time.Now()
 				entry.failedAttempts++
-				if entry.failedAttempts >= MaxFailedAttempts {
+				if entry.failedAttempts >= r.maxFailedAttempts() {
 					klog.Errorf("Retry update failed final attempt for %s %s: error: %v", r.ResourceHandler.ObjType, objKey, err)
 				} else {
 					klog.Infof("%v retry update failed for %s, will try again later: %v", r.ResourceHandler.ObjType, objKey, err)
@@ -340,7 +400,7 @@ func (r *RetryFramework) resourceRetry(objKey string, now time.Time) {
 				if err := r.ResourceHandler.DeleteResource(entry.oldObj, entry.config); err != nil {
 					entry.timeStamp = time.Now()
 					entry.failedAttempts++
-					if entry.failedAttempts >= MaxFailedAttempts {
+					if entry.failedAttempts >= r.maxFailedAttempts() {
 						klog.Errorf("Retry delete failed final attempt for %s %s: error: %v", r.ResourceHandler.ObjType, objKey, err)
 					} else {
 						klog.Infof("Retry delete failed for %s %s, will try again later: %v",
@@ -364,7 +424,7 @@ func (r *RetryFramework) resourceRetry(objKey string, now time.Time) {
 				if err := r.ResourceHandler.AddResource(entry.newObj, true); err != nil {
 					entry.timeStamp = time.Now()
 					entry.failedAttempts++
-					if entry.failedAttempts >= MaxFailedAttempts {
+					if entry.failedAttempts >= r.maxFailedAttempts() {
 						klog.Errorf("Retry add failed final attempt for %s %s: error: %v", r.ResourceHandler.ObjType, objKey, err)
 					} else {
 						klog.Infof("Retry add failed for %s %s, will try again later: %v", r.ResourceHandler.ObjType, objKey, err)
@@ -401,18 +461,45 @@ func (r *RetryFramework) iterateRetryResources() {
 	// Process the above list of objects that need retry by holding the lock for each one of them.
 	klog.V(5).Infof("Going to retry %v resource setup for %d objects: %s", r.ResourceHandler.ObjType, len(entriesKeys), entriesKeys)
 
-	for _, entryKey := range entriesKeys {
-		wg.Add(1)
-		go func(entryKey string) {
-			defer wg.Done()
-			r.resourceRetry(entryKey, now)
-		}(entryKey)
+	// The objects of the priority namespaces are retried first, so that a
+	// large backlog of failed objects in the other namespaces doesn't
+	// delay them
+	priorityKeys, otherKeys := r.splitPriorityKeys(entriesKeys)
+	for _, keys := range [][]string{priorityKeys, otherKeys} {
+		for _, entryKey := range keys {
+			wg.Add(1)
+			go func(entryKey string) {
+				defer wg.Done()
+				r.resourceRetry(entryKey, now)
+			}(entryKey)
+		}
+		klog.V(5).Infof("Waiting for all the %s retry setup to complete in iterateRetryResources", r.ResourceHandler.ObjType)
+		wg.Wait()
 	}
-	klog.V(5).Infof("Waiting for all the %s retry setup to complete in iterateRetryResources", r.ResourceHandler.ObjType)
-	wg.Wait()
 	klog.V(5).Infof("Function iterateRetryResources for %s ended (in %v)", r.ResourceHandler.ObjType, time.Since(now))
 }
 
+// splitPriorityKeys splits the keys into the keys of the objects of the
+// priority namespaces, or of the priority namespaces themselves, and the others
+func (r *RetryFramework) splitPriorityKeys(keys []string) ([]string, []string) {
+	if config.Kubernetes.PriorityNamespaces.Len() == 0 {
+		return nil, keys
+	}
+	var priorityKeys, otherKeys []string
+	for _, key := range keys {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err == nil && r.ResourceHandler.ObjType == factory.NamespaceType {
+			namespace = name
+		}
+		if err == nil && config.Kubernetes.PriorityNamespaces.Has(namespace) {
+			priorityKeys = append(priorityKeys, key)
+		} else {
+			otherKeys = append(otherKeys, key)
+		}
+	}
+	return priorityKeys, otherKeys
+}
+
 // periodicallyRetryResources tracks RetryFramework and checks if any object needs to be retried for add or delete every
 // RetryObjInterval seconds or when requested through retryChan.
 func (r *RetryFramework) periodicallyRetryResources() {
@@ -119,6 +119,15 @@ func CheckRetryObjectEventuallyWrapped(g gomega.Gomega, key string, shouldExist
 	}, inspectTimeout).Should(expectedValue)
 }
 
+// CheckRetryObjectParkedEventually verifies that eventually the retry entry exists and is
+// parked, or not, after exhausting its error budget
+func CheckRetryObjectParkedEventually(key string, shouldBeParked bool, r *RetryFramework) {
+	gomega.Eventually(func() bool {
+		entry, found := GetRetryObj(key, r)
+		return found && entry.parked
+	}, inspectTimeout).Should(gomega.Equal(shouldBeParked))
+}
+
 // CheckRetryObjectMultipleFieldsEventually verifies that eventually the oldObj, newObj, config and
 // failedAttemptsfields fields all satisfy the input conditions expectedParams, given in
 // the same order. In order not to check any of these four fields, the corresponding input
@@ -40,6 +40,15 @@ type TestSetup struct {
 	// addition of invalid data (like duplicate indexes).
 	IgnoreConstraints bool
 
+	// NBSchema, when set, is the schema of the NB server instead of the
+	// schema of the generated model, like the schema of another OVN version
+	NBSchema *ovsdb.DatabaseSchema
+	// NBServerModels, when set, are the models the NB server stores the
+	// provided tables of NBSchema with instead of the models of the clients,
+	// like models with the columns newer than the generated model. The
+	// NBData of these tables must use them.
+	NBServerModels map[string]model.Model
+
 	NBData []TestData
 	SBData []TestData
 }
@@ -101,7 +110,13 @@ func NewNBTestHarness(setup TestSetup, testCtx *Context) (libovsdbclient.Client,
 		testCtx = newContext()
 	}
 
-	client, server, err := newOVSDBTestHarness(setup.NBData, setup.IgnoreConstraints, newNBServer, newNBClient, testCtx)
+	newServer := newNBServer
+	if setup.NBSchema != nil {
+		newServer = func(cfg config.OvnAuthConfig, data []TestData, ignoreConstraints bool) (*TestOvsdbServer, error) {
+			return newNBServerWithSchema(cfg, *setup.NBSchema, setup.NBServerModels, data, ignoreConstraints)
+		}
+	}
+	client, server, err := newOVSDBTestHarness(setup.NBData, setup.IgnoreConstraints, newServer, newNBClient, testCtx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -200,6 +215,24 @@ func newNBServer(cfg config.OvnAuthConfig, data []TestData, ignoreConstraints bo
 	return newOVSDBServer(cfg, dbModel, schema, data, ignoreConstraints)
 }
 
+// newNBServerWithSchema runs a NB server with the provided schema, storing the
+// tables the model of the clients has for it, with the provided models if any
+func newNBServerWithSchema(cfg config.OvnAuthConfig, schema ovsdb.DatabaseSchema, serverModels map[string]model.Model,
+	data []TestData, ignoreConstraints bool) (*TestOvsdbServer, error) {
+	models, err := libovsdb.NBModels(schema)
+	if err != nil {
+		return nil, err
+	}
+	for table, m := range serverModels {
+		models[table] = m
+	}
+	dbModel, err := model.NewClientDBModel(schema.Name, models)
+	if err != nil {
+		return nil, err
+	}
+	return newOVSDBServer(cfg, dbModel, schema, data, ignoreConstraints)
+}
+
 func testDataToOperations(dbMod model.DatabaseModel, data []TestData) ([]ovsdb.Operation, error) {
 	m := mapper.NewMapper(dbMod.Schema)
 	newData := copystructure.Must(copystructure.Copy(data)).([]TestData)
@@ -7,9 +7,16 @@ const (
 	DefaultNetworkName  = "default"
 	K8sPrefix           = "k8s-"
 	HybridOverlayPrefix = "int-"
+	// HealthCheckPortPrefix is the prefix of the logical switch port of the
+	// dedicated health check port of a node, which can't be mistaken for a
+	// management port
+	HealthCheckPortPrefix = "hc-k8s-"
 
 	// K8sMgmtIntfName name to be used as an OVS internal port on the node
 	K8sMgmtIntfName = "ovn-k8s-mp0"
+	// K8sHealthCheckIntfName is the name of the OVS internal port of the
+	// dedicated health check port on the node
+	K8sHealthCheckIntfName = "ovn-k8s-hc0"
 
 	// PhysicalNetworkName is the name that maps to an OVS bridge that provides
 	// access to physical/external network
@@ -24,6 +31,10 @@ const (
 	LocalBridgeName            = "br-local"
 	LocalnetGatewayNextHopPort = "ovn-k8s-gw0"
 
+	// DefaultIntegrationBridge is the default name of the OVS bridge of the
+	// OVN logical ports, created by ovn-controller
+	DefaultIntegrationBridge = "br-int"
+
 	// types.OVNClusterRouter is the name of the distributed router
 	OVNClusterRouter = "ovn_cluster_router"
 	OVNJoinSwitch    = "join"
@@ -43,6 +54,11 @@ const (
 	EXTSwitchToGWRouterPrefix    = "etor-"
 	GWRouterToExtSwitchPrefix    = "rtoe-"
 	EgressGWSwitchPrefix         = "exgw-"
+	// the port of a gateway router on the localnet switch of the provider
+	// network egress of a namespace, and its peer port, suffixed by the
+	// namespace name
+	GWRouterToProviderNetworkPrefix = "rtopn-"
+	ProviderNetworkToGWRouterPrefix = "pntor-"
 
 	NodeLocalSwitch = "node_local_switch"
 
@@ -69,6 +85,15 @@ const (
 	DefaultAllowPriority = 1001
 	// Default deny acl rule priority
 	DefaultDenyPriority = 1000
+	// Priority of the first rule of a network firewall, the next rules have
+	// decreasing priorities
+	NetworkFirewallStartPriority = 32000
+	// Priority of the acl allowing the traffic not matching a network
+	// firewall rule, which makes the network firewall stateful
+	NetworkFirewallDefaultAllowPriority = 0
+	// Priority of the acls allowing or denying the link-local and control
+	// protocol traffic of a localnet network, above the network firewall rules
+	LocalnetPassthroughPriority = 32500
 
 	// ACL Tiers
 	// Tier 0 is currently un-used and is a placeholder tier for future use cases (can be renamed when we have a use for it).
@@ -81,6 +106,10 @@ const (
 	DefaultANPACLTier = 1
 	// Default Tier for all ACLs belonging to Baseline Admin Network Policy
 	DefaultBANPACLTier = 3
+	// Tier for the rules of a network firewall, which are evaluated before the
+	// multi network policies. Admin network policies don't apply to secondary
+	// networks.
+	NetworkFirewallACLTier = 1
 
 	// priority of logical router policies on the OVNClusterRouter
 	EgressFirewallStartPriority           = 10000
@@ -93,6 +122,7 @@ const (
 	DefaultNoRereoutePriority             = 102
 	EgressSVCReroutePriority              = 101
 	EgressIPReroutePriority               = 100
+	ProviderNetworkEgressReroutePriority  = 99
 	EgressLiveMigrationReroutePiority     = 10
 
 	V6NodeLocalNATSubnet           = "fd99::/64"
@@ -128,6 +158,8 @@ const (
 	// Deprecated: we used to set topology version as an annotation on the node. We don't do this anymore.
 	OvnK8sTopoAnno         = OvnK8sPrefix + "/" + "topology-version"
 	OvnK8sSmallMTUTaintKey = OvnK8sPrefix + "/" + "mtu-too-small"
+	// OvnK8sNodeFinalizer holds the deletion of a node until its OVN topology is cleaned up
+	OvnK8sNodeFinalizer = OvnK8sPrefix + "/" + "node-topology-cleanup"
 
 	// name of the configmap used to synchronize status (e.g. watch for topology changes)
 	OvnK8sStatusCMName         = "control-plane-status"
@@ -140,6 +172,17 @@ const (
 	NodeModeFull    = "full"
 	NodeModeDPU     = "dpu"
 	NodeModeDPUHost = "dpu-host"
+	// NodeModeStandaloneHost attaches a host that is not a Kubernetes node to
+	// the network of its Host object
+	NodeModeStandaloneHost = "standalone-host"
+
+	// OVNKube-Node datapath types
+	// NodeDatapathSystem is the OVS kernel datapath
+	NodeDatapathSystem = "system"
+	// NodeDatapathAFXDP is the OVS userspace datapath with an AF_XDP uplink
+	NodeDatapathAFXDP = "afxdp"
+	// NodeDatapathDPDK is the OVS userspace datapath with a DPDK uplink
+	NodeDatapathDPDK = "dpdk"
 
 	// Geneve header length for IPv4 (https://github.com/openshift/cluster-network-operator/pull/720#issuecomment-664020823)
 	GeneveHeaderLengthIPv4 = 58
@@ -164,6 +207,12 @@ const (
 	TopologyExternalID = OvnK8sPrefix + "/" + "topology"
 	// key for topology version external-id
 	TopologyVersionExternalID = "k8s-ovn-topo-version"
+	// key for the namespace external-id of the logical entities of the
+	// provider network egress of a namespace
+	ProviderNetworkEgressExternalID = OvnK8sPrefix + "/" + "provider-network-egress"
+	// key for the EgressIP name external-id of the logical entities of the
+	// egress IPs of the primary user defined networks
+	UDNEgressIPExternalID = OvnK8sPrefix + "/" + "udn-egress-ip"
 	// key for load_balancer kind external-id
 	LoadBalancerKindExternalID = OvnK8sPrefix + "/" + "kind"
 	// key for load_balancer service external-id
@@ -174,6 +223,10 @@ const (
 	Layer2Topology   = "layer2"
 	LocalnetTopology = "localnet"
 
+	// roles of the user defined networks defined in CNI netconf
+	NetworkRolePrimary   = "primary"
+	NetworkRoleSecondary = "secondary"
+
 	// db index keys
 	// PrimaryIDKey is used as a primary client index
 	PrimaryIDKey = OvnK8sPrefix + "/id"
@@ -185,9 +238,20 @@ const (
 	EgressServiceNoHost     = ""    // set on services with no allocated node
 	EgressServiceNoSNATHost = "ALL" // set on services with sourceIPBy=Network
 
+	// LoadBalancerClass is the class of the LoadBalancer services handled by
+	// the built-in load balancer provider, besides those without a class
+	LoadBalancerClass = OvnK8sPrefix + "/load-balancer"
+	// LoadBalancerNodeAnnotation is set by the built-in load balancer provider
+	// on the LoadBalancer services to the node announcing their IPs
+	LoadBalancerNodeAnnotation = OvnK8sPrefix + "/load-balancer-node"
+
 	// MaxLogicalPortTunnelKey is maximum tunnel key that can be requested for a
 	// Logical Switch or Router Port
 	MaxLogicalPortTunnelKey = 32767
+	// Layer2RouterPortTunnelKey is the tunnel key of the port of the layer2
+	// switch of a primary network connected to its cluster router, the same
+	// in every zone and never allocated to a pod
+	Layer2RouterPortTunnelKey = 1
 
 	// InformerSyncTimeout is used to wait from the initial informer cache sync.
 	// It allows ~4 list() retries with the default reflector exponential backoff config
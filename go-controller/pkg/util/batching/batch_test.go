@@ -7,6 +7,7 @@ import (
 
 	"strings"
 	"testing"
+	"time"
 )
 
 type batchTestData struct {
@@ -79,3 +80,64 @@ func TestBatch(t *testing.T) {
 		g.Expect(result).To(gomega.Equal(tCase.data))
 	}
 }
+
+func TestRolloutBatches(t *testing.T) {
+	tt := []struct {
+		name         string
+		batchSize    int
+		pauseOnError bool
+		data         []int
+		failBatch    int
+		batches      [][]int
+		expectErr    string
+	}{
+		{
+			name:    "single batch",
+			data:    []int{1, 2, 3},
+			batches: [][]int{{1, 2, 3}},
+		},
+		{
+			name:      "batches in order",
+			batchSize: 2,
+			data:      []int{1, 2, 3, 4, 5},
+			batches:   [][]int{{1, 2}, {3, 4}, {5}},
+		},
+		{
+			name:         "pause on error",
+			batchSize:    2,
+			pauseOnError: true,
+			data:         []int{1, 2, 3, 4, 5},
+			failBatch:    2,
+			batches:      [][]int{{1, 2}, {3, 4}},
+			expectErr:    "paused at batch 2 of 3, 2 of 5 items applied",
+		},
+		{
+			name:      "continue on error",
+			batchSize: 2,
+			data:      []int{1, 2, 3, 4, 5},
+			failBatch: 2,
+			batches:   [][]int{{1, 2}, {3, 4}, {5}},
+			expectErr: "1 of 3 batches failed",
+		},
+	}
+
+	for _, tCase := range tt {
+		g := gomega.NewGomegaWithT(t)
+		ginkgo.By(tCase.name)
+		var batches [][]int
+		rollout := Rollout{BatchSize: tCase.batchSize, Interval: time.Millisecond, PauseOnError: tCase.pauseOnError}
+		err := RolloutBatches[int](rollout, "test", tCase.data, func(l []int) error {
+			batches = append(batches, l)
+			if len(batches) == tCase.failBatch {
+				return fmt.Errorf("batch failed")
+			}
+			return nil
+		})
+		if tCase.expectErr != "" {
+			g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(tCase.expectErr)), tCase.name)
+		} else {
+			g.Expect(err).NotTo(gomega.HaveOccurred(), tCase.name)
+		}
+		g.Expect(batches).To(gomega.Equal(tCase.batches), tCase.name)
+	}
+}
@@ -0,0 +1,86 @@
+package batching
+
+import (
+	"fmt"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+// Rollout is how a cluster-wide change touching many northbound rows, like the
+// tier or the logging of the ACLs, is applied: in batches at a bounded rate,
+// instead of one giant transaction.
+type Rollout struct {
+	// BatchSize is the maximum number of items of a batch, 0 for a single
+	// batch
+	BatchSize int
+	// Interval is the time waited between two batches
+	Interval time.Duration
+	// PauseOnError stops the rollout at the first failing batch, the next
+	// batches are left untouched until the change is retried. Otherwise the
+	// next batches are applied and the errors returned at the end.
+	PauseOnError bool
+}
+
+// NewRollout returns the rollout of the cluster-wide changes as configured, in
+// batches of defaultBatchSize items when the batch size is not configured
+func NewRollout(defaultBatchSize int) Rollout {
+	rollout := Rollout{
+		BatchSize:    config.OVNKubernetesFeature.NBRolloutBatchSize,
+		Interval:     time.Duration(config.OVNKubernetesFeature.NBRolloutBatchInterval) * time.Millisecond,
+		PauseOnError: config.OVNKubernetesFeature.NBRolloutOnError != config.NBRolloutOnErrorContinue,
+	}
+	if rollout.BatchSize == 0 {
+		rollout.BatchSize = defaultBatchSize
+	}
+	return rollout
+}
+
+// RolloutBatches applies eachFn to the batches of data in order, waiting the
+// interval of the rollout between two batches. The change is named in the
+// logs and the errors.
+func RolloutBatches[T any](rollout Rollout, name string, data []T, eachFn func([]T) error) error {
+	if len(data) == 0 {
+		return nil
+	}
+	batchSize := rollout.BatchSize
+	if batchSize <= 0 || batchSize > len(data) {
+		batchSize = len(data)
+	}
+	batches := (len(data) + batchSize - 1) / batchSize
+	if batches == 1 {
+		return eachFn(data)
+	}
+
+	start := time.Now()
+	var errs []error
+	for batch := 0; batch < batches; batch++ {
+		if batch > 0 && rollout.Interval > 0 {
+			time.Sleep(rollout.Interval)
+		}
+		first := batch * batchSize
+		last := first + batchSize
+		if last > len(data) {
+			last = len(data)
+		}
+		if err := eachFn(data[first:last]); err != nil {
+			if rollout.PauseOnError {
+				return fmt.Errorf("rollout of %s paused at batch %d of %d, %d of %d items applied: %w",
+					name, batch+1, batches, first, len(data), err)
+			}
+			klog.Warningf("Rollout of %s: batch %d of %d failed, continuing: %v", name, batch+1, batches, err)
+			errs = append(errs, err)
+			continue
+		}
+		klog.V(5).Infof("Rollout of %s: batch %d of %d applied", name, batch+1, batches)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("rollout of %s: %d of %d batches failed: %w", name, len(errs), batches,
+			kerrors.NewAggregate(errs))
+	}
+	klog.Infof("Rolled out %s to %d items in %d batches in %v", name, len(data), batches, time.Since(start))
+	return nil
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+)
+
+const (
+	// AdditionalClusterSubnetsConfigMapName is the name of the ConfigMap, in
+	// the OVN-Kubernetes config namespace, listing the cluster subnets added to
+	// the default network at runtime
+	AdditionalClusterSubnetsConfigMapName = "additional-cluster-subnets"
+	// AdditionalClusterSubnetsKey is the key of the ConfigMap holding the
+	// cluster subnets, in the format of the cluster-subnets option
+	AdditionalClusterSubnetsKey = "cluster-subnets"
+)
+
+// ParseAdditionalClusterSubnets returns the valid cluster subnets of the
+// additional cluster subnets ConfigMap
+func ParseAdditionalClusterSubnets(cm *corev1.ConfigMap) ([]config.CIDRNetworkEntry, error) {
+	value := cm.Data[AdditionalClusterSubnetsKey]
+	if value == "" {
+		return nil, nil
+	}
+	clusterSubnets, err := config.ParseClusterSubnetEntries(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional cluster subnets %q: %w", value, err)
+	}
+	if err := config.ValidateAdditionalClusterSubnets(clusterSubnets); err != nil {
+		return nil, fmt.Errorf("invalid additional cluster subnets %q: %w", value, err)
+	}
+	return clusterSubnets, nil
+}
+
+// LoadAdditionalClusterSubnets appends the cluster subnets of the additional
+// cluster subnets ConfigMap to the configured cluster subnets of the default
+// network, for ovnkube-controller and ovnkube-node to apply them. Must be
+// called on startup, before the configured cluster subnets are read. An
+// invalid ConfigMap is ignored.
+func LoadAdditionalClusterSubnets(kubeClient kubernetes.Interface) error {
+	cm, err := kubeClient.CoreV1().ConfigMaps(config.Kubernetes.OVNConfigNamespace).Get(context.TODO(),
+		AdditionalClusterSubnetsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get the additional cluster subnets: %w", err)
+	}
+	clusterSubnets, err := ParseAdditionalClusterSubnets(cm)
+	if err != nil {
+		klog.Errorf("Ignoring ConfigMap %s: %v", AdditionalClusterSubnetsConfigMapName, err)
+		return nil
+	}
+	for _, clusterSubnet := range clusterSubnets {
+		found := false
+		for _, configured := range config.Default.ClusterSubnets {
+			if configured.CIDR.String() == clusterSubnet.CIDR.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			config.Default.ClusterSubnets = append(config.Default.ClusterSubnets, clusterSubnet)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package dhcpv6
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	clientPort = 546
+	serverPort = 547
+	// exchangeTimeout is how long a reply is waited for before the message
+	// is retransmitted
+	exchangeTimeout = 2 * time.Second
+	exchangeRetries = 3
+)
+
+// allDHCPRelayAgentsAndServers is the multicast address the messages are sent to
+var allDHCPRelayAgentsAndServers = net.ParseIP("ff02::1:2")
+
+var errNoReply = errors.New("no reply from the DHCPv6 servers")
+
+// Lease is a prefix delegated to an identity association of the client
+type Lease struct {
+	IAID     uint32
+	Prefix   *net.IPNet
+	ServerID []byte
+	// Obtained is when the lease was obtained or last renewed
+	Obtained time.Time
+	T1       time.Duration
+	T2       time.Duration
+	Valid    time.Duration
+}
+
+// RenewAt returns when the lease must be renewed
+func (l *Lease) RenewAt() time.Time {
+	t1 := l.T1
+	if t1 == 0 {
+		// left to the client by the server
+		t1 = l.Valid / 2
+	}
+	return l.Obtained.Add(t1)
+}
+
+// Expiry returns when the delegated prefix stops being valid
+func (l *Lease) Expiry() time.Time {
+	return l.Obtained.Add(l.Valid)
+}
+
+// Client obtains delegated prefixes from the DHCPv6 servers of a link. Each
+// prefix is delegated to its own identity association, identified by its
+// IAID, so that a single client can hold many prefixes.
+type Client struct {
+	// exchanges are serialized as they share the client port
+	sync.Mutex
+	duid   []byte
+	listen *net.UDPAddr
+	server *net.UDPAddr
+	now    func() time.Time
+}
+
+// NewClient returns a client sending its messages on the given interface,
+// identified by a link-layer DUID built from the address of the interface
+func NewClient(ifName string) (*Client, error) {
+	iface, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interface %s: %w", ifName, err)
+	}
+	if len(iface.HardwareAddr) == 0 {
+		return nil, fmt.Errorf("interface %s has no link-layer address to build the DUID from", ifName)
+	}
+	// DUID-LL with the ethernet hardware type
+	duid := []byte{0, 3, 0, 1}
+	duid = append(duid, iface.HardwareAddr...)
+	return newClient(duid,
+		&net.UDPAddr{IP: net.IPv6unspecified, Port: clientPort, Zone: ifName},
+		&net.UDPAddr{IP: allDHCPRelayAgentsAndServers, Port: serverPort, Zone: ifName}), nil
+}
+
+func newClient(duid []byte, listen, server *net.UDPAddr) *Client {
+	return &Client{
+		duid:   duid,
+		listen: listen,
+		server: server,
+		now:    time.Now,
+	}
+}
+
+// Acquire obtains a prefix for the identity association, of the given length
+// if not 0 and the server honors the hint
+func (c *Client) Acquire(iaid uint32, prefixLen int) (*Lease, error) {
+	ia := &iaPD{iaid: iaid}
+	if prefixLen > 0 {
+		ia.prefix = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(prefixLen, 128)}
+	}
+	solicit := c.newMessage(msgSolicit, option{optIAPD, ia.marshal()}, option{optRapidCommit, nil})
+	resp, err := c.exchange(solicit, func(m *message) bool {
+		return m.msgType == msgAdvertise || m.msgType == msgReply && m.option(optRapidCommit) != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.msgType == msgReply {
+		return c.leaseFromReply(resp, iaid)
+	}
+
+	// request the prefix advertised by the server
+	advertised, err := c.leaseFromReply(resp, iaid)
+	if err != nil {
+		return nil, err
+	}
+	ia = &iaPD{iaid: iaid, prefix: advertised.Prefix}
+	request := c.newMessage(msgRequest, option{optServerID, advertised.ServerID}, option{optIAPD, ia.marshal()})
+	resp, err = c.exchange(request, isReply)
+	if err != nil {
+		return nil, err
+	}
+	return c.leaseFromReply(resp, iaid)
+}
+
+// Renew extends the lease with the server that delegated the prefix, or any
+// server if it is unknown or does not reply. The renewed lease might hold
+// another prefix.
+func (c *Client) Renew(lease *Lease) (*Lease, error) {
+	ia := &iaPD{iaid: lease.IAID, prefix: lease.Prefix}
+	if len(lease.ServerID) > 0 {
+		renew := c.newMessage(msgRenew, option{optServerID, lease.ServerID}, option{optIAPD, ia.marshal()})
+		resp, err := c.exchange(renew, isReply)
+		if err == nil {
+			return c.leaseFromReply(resp, lease.IAID)
+		}
+		if !errors.Is(err, errNoReply) {
+			return nil, err
+		}
+		klog.Warningf("No reply to the renewal of the delegated prefix %s, rebinding it", lease.Prefix)
+	}
+	rebind := c.newMessage(msgRebind, option{optIAPD, ia.marshal()})
+	resp, err := c.exchange(rebind, isReply)
+	if err != nil {
+		return nil, err
+	}
+	return c.leaseFromReply(resp, lease.IAID)
+}
+
+// Release gives the delegated prefix back to the server
+func (c *Client) Release(lease *Lease) error {
+	if len(lease.ServerID) == 0 {
+		return fmt.Errorf("unknown server for the delegated prefix %s", lease.Prefix)
+	}
+	ia := &iaPD{iaid: lease.IAID, prefix: lease.Prefix}
+	release := c.newMessage(msgRelease, option{optServerID, lease.ServerID}, option{optIAPD, ia.marshal()})
+	_, err := c.exchange(release, isReply)
+	return err
+}
+
+func isReply(m *message) bool {
+	return m.msgType == msgReply
+}
+
+func (c *Client) newMessage(msgType uint8, options ...option) *message {
+	m := &message{msgType: msgType}
+	_, _ = rand.Read(m.transactionID[:])
+	m.options = append([]option{{optClientID, c.duid}}, options...)
+	return m
+}
+
+// exchange sends the message, retransmitting it, until a reply of the same
+// transaction is accepted
+func (c *Client) exchange(req *message, accept func(*message) bool) (*message, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	conn, err := net.ListenUDP("udp6", c.listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", c.listen, err)
+	}
+	defer conn.Close()
+
+	start := c.now()
+	buf := make([]byte, 1500)
+	for attempt := 0; attempt < exchangeRetries; attempt++ {
+		// the elapsed time since the start of the exchange, in hundredths of
+		// a second
+		elapsed := c.now().Sub(start) / (10 * time.Millisecond)
+		if elapsed > 0xffff {
+			elapsed = 0xffff
+		}
+		msg := *req
+		msg.options = append(append([]option{}, req.options...),
+			option{optElapsedTime, binary.BigEndian.AppendUint16(nil, uint16(elapsed))})
+		if _, err := conn.WriteToUDP(msg.marshal(), c.server); err != nil {
+			return nil, fmt.Errorf("failed to send DHCPv6 message to %s: %w", c.server, err)
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(exchangeTimeout)); err != nil {
+			return nil, err
+		}
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			resp, err := parseMessage(buf[:n])
+			if err != nil {
+				klog.V(5).Infof("Ignoring invalid DHCPv6 message: %v", err)
+				continue
+			}
+			if resp.transactionID != req.transactionID || !accept(resp) {
+				continue
+			}
+			return resp, nil
+		}
+	}
+	return nil, errNoReply
+}
+
+// leaseFromReply returns the lease of the identity association in the reply
+// or advertisement of a server
+func (c *Client) leaseFromReply(m *message, iaid uint32) (*Lease, error) {
+	if status := m.option(optStatusCode); status != nil {
+		if code, msg := parseStatusCode(status); code != statusSuccess {
+			return nil, fmt.Errorf("DHCPv6 server returned status %d: %s", code, msg)
+		}
+	}
+	serverID := m.option(optServerID)
+	if len(serverID) == 0 {
+		return nil, fmt.Errorf("DHCPv6 server did not identify itself")
+	}
+	for _, opt := range m.options {
+		if opt.code != optIAPD {
+			continue
+		}
+		ia, err := parseIAPD(opt.data)
+		if err != nil {
+			return nil, err
+		}
+		if ia.iaid != iaid {
+			continue
+		}
+		if ia.status != statusSuccess {
+			return nil, fmt.Errorf("DHCPv6 server returned status %d for IA_PD %d: %s", ia.status, iaid, ia.statusMessage)
+		}
+		if ia.prefix == nil {
+			return nil, fmt.Errorf("DHCPv6 server delegated no prefix to IA_PD %d", iaid)
+		}
+		return &Lease{
+			IAID:     iaid,
+			Prefix:   ia.prefix,
+			ServerID: append([]byte{}, serverID...),
+			Obtained: c.now(),
+			T1:       ia.t1,
+			T2:       ia.t2,
+			Valid:    ia.valid,
+		}, nil
+	}
+	return nil, fmt.Errorf("DHCPv6 server returned no IA_PD %d", iaid)
+}
@@ -0,0 +1,197 @@
+package dhcpv6
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer delegates the prefixes of its pool, in order, to the identity
+// associations soliciting them
+type fakeServer struct {
+	t         *testing.T
+	conn      *net.UDPConn
+	serverID  []byte
+	pool      []string
+	delegated map[uint32]*net.IPNet
+	// rapidCommit replies to the solicits asking for it
+	rapidCommit bool
+	// received holds the types of the messages received
+	received []uint8
+	lock     sync.Mutex
+}
+
+func newFakeServer(t *testing.T, rapidCommit bool, pool ...string) *fakeServer {
+	conn, err := net.ListenUDP("udp6", &net.UDPAddr{IP: net.IPv6loopback})
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	s := &fakeServer{
+		t:           t,
+		conn:        conn,
+		serverID:    []byte{0, 3, 0, 1, 2, 0, 0, 0, 0, 1},
+		pool:        pool,
+		delegated:   map[uint32]*net.IPNet{},
+		rapidCommit: rapidCommit,
+	}
+	go s.serve()
+	t.Cleanup(func() { conn.Close() })
+	return s
+}
+
+func (s *fakeServer) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req, err := parseMessage(buf[:n])
+		if err != nil {
+			s.t.Errorf("server received an invalid message: %v", err)
+			return
+		}
+		s.lock.Lock()
+		s.received = append(s.received, req.msgType)
+		s.lock.Unlock()
+		ia, err := parseIAPD(req.option(optIAPD))
+		if err != nil {
+			s.t.Errorf("server received an invalid IA_PD: %v", err)
+			return
+		}
+		resp := &message{msgType: msgReply, transactionID: req.transactionID}
+		resp.options = []option{{optClientID, req.option(optClientID)}, {optServerID, s.serverID}}
+		switch req.msgType {
+		case msgSolicit:
+			if !s.rapidCommit || req.option(optRapidCommit) == nil {
+				resp.msgType = msgAdvertise
+			} else {
+				resp.options = append(resp.options, option{optRapidCommit, nil})
+			}
+			fallthrough
+		case msgRequest, msgRenew, msgRebind:
+			prefix, ok := s.delegated[ia.iaid]
+			if !ok {
+				if len(s.pool) == 0 {
+					resp.options = append(resp.options, option{optStatusCode, []byte{0, 6}})
+					break
+				}
+				_, prefix, _ = net.ParseCIDR(s.pool[0])
+				s.pool = s.pool[1:]
+				s.delegated[ia.iaid] = prefix
+			}
+			reply := &iaPD{iaid: ia.iaid, t1: time.Hour, t2: 2 * time.Hour, prefix: prefix,
+				preferred: 3 * time.Hour, valid: 4 * time.Hour}
+			resp.options = append(resp.options, option{optIAPD, reply.marshal()})
+		case msgRelease:
+			delete(s.delegated, ia.iaid)
+		}
+		if _, err := s.conn.WriteToUDP(resp.marshal(), addr); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) messages() []uint8 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]uint8{}, s.received...)
+}
+
+func newTestClient(s *fakeServer) *Client {
+	return newClient([]byte{0, 3, 0, 1, 2, 0, 0, 0, 0, 2}, &net.UDPAddr{IP: net.IPv6loopback},
+		s.conn.LocalAddr().(*net.UDPAddr))
+}
+
+func TestClientAcquireRenewRelease(t *testing.T) {
+	s := newFakeServer(t, false, "fd00:10:1::/64", "fd00:10:2::/64")
+	c := newTestClient(s)
+
+	lease, err := c.Acquire(1, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Prefix.String() != "fd00:10:1::/64" {
+		t.Fatalf("expected the delegated prefix fd00:10:1::/64, got %s", lease.Prefix)
+	}
+	if lease.T1 != time.Hour || lease.Valid != 4*time.Hour || lease.RenewAt() != lease.Obtained.Add(time.Hour) {
+		t.Fatalf("unexpected lease times: %+v", lease)
+	}
+	// each identity association gets its own prefix
+	other, err := c.Acquire(2, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Prefix.String() != "fd00:10:2::/64" {
+		t.Fatalf("expected the delegated prefix fd00:10:2::/64, got %s", other.Prefix)
+	}
+	if _, err := c.Acquire(3, 64); err == nil {
+		t.Fatal("expected an error once the pool is exhausted")
+	}
+
+	renewed, err := c.Renew(lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.Prefix.String() != lease.Prefix.String() {
+		t.Fatalf("expected the renewed prefix %s, got %s", lease.Prefix, renewed.Prefix)
+	}
+	// a lease without a known server is rebound
+	if _, err := c.Renew(&Lease{IAID: 2, Prefix: other.Prefix}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Release(lease); err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint8{msgSolicit, msgRequest, msgSolicit, msgRequest, msgSolicit, msgRenew, msgRebind, msgRelease}
+	received := s.messages()
+	if len(received) != len(expected) {
+		t.Fatalf("expected the server to receive the messages %v, got %v", expected, received)
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Fatalf("expected the server to receive the messages %v, got %v", expected, received)
+		}
+	}
+}
+
+func TestClientAcquireRapidCommit(t *testing.T) {
+	s := newFakeServer(t, true, "fd00:10:1::/56")
+	c := newTestClient(s)
+
+	lease, err := c.Acquire(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Prefix.String() != "fd00:10:1::/56" {
+		t.Fatalf("expected the delegated prefix fd00:10:1::/56, got %s", lease.Prefix)
+	}
+	if received := s.messages(); len(received) != 1 {
+		t.Fatalf("expected a single exchange, got the messages %v", received)
+	}
+}
+
+func TestParseIAPD(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("2001:db8:1::/48")
+	ia := &iaPD{iaid: 7, t1: time.Minute, t2: 2 * time.Minute, prefix: prefix, preferred: time.Hour, valid: 2 * time.Hour}
+	parsed, err := parseIAPD(ia.marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.iaid != 7 || parsed.t1 != time.Minute || parsed.t2 != 2*time.Minute || parsed.prefix.String() != prefix.String() ||
+		parsed.preferred != time.Hour || parsed.valid != 2*time.Hour {
+		t.Fatalf("unexpected IA_PD %+v", parsed)
+	}
+
+	// withdrawn prefixes are ignored
+	ia.valid = 0
+	if parsed, err = parseIAPD(ia.marshal()); err != nil || parsed.prefix != nil {
+		t.Fatalf("expected no prefix, got %v, %v", parsed.prefix, err)
+	}
+
+	if _, err := parseIAPD([]byte{0, 0, 0, 1}); err == nil {
+		t.Fatal("expected an error for a truncated IA_PD")
+	}
+}
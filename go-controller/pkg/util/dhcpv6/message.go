@@ -0,0 +1,189 @@
+// Package dhcpv6 implements the client side of DHCPv6 prefix delegation
+// (RFC 8415), only as much as needed to obtain, renew and release delegated
+// prefixes.
+package dhcpv6
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// message types
+const (
+	msgSolicit   uint8 = 1
+	msgAdvertise uint8 = 2
+	msgRequest   uint8 = 3
+	msgRenew     uint8 = 5
+	msgRebind    uint8 = 6
+	msgReply     uint8 = 7
+	msgRelease   uint8 = 8
+)
+
+// option codes
+const (
+	optClientID    uint16 = 1
+	optServerID    uint16 = 2
+	optElapsedTime uint16 = 8
+	optStatusCode  uint16 = 13
+	optRapidCommit uint16 = 14
+	optIAPD        uint16 = 25
+	optIAPrefix    uint16 = 26
+)
+
+// statusSuccess is the status code of a successful exchange
+const statusSuccess uint16 = 0
+
+// option is a DHCPv6 option
+type option struct {
+	code uint16
+	data []byte
+}
+
+// message is a DHCPv6 client/server message
+type message struct {
+	msgType       uint8
+	transactionID [3]byte
+	options       []option
+}
+
+func (m *message) marshal() []byte {
+	b := []byte{m.msgType, m.transactionID[0], m.transactionID[1], m.transactionID[2]}
+	for _, opt := range m.options {
+		b = appendOption(b, opt.code, opt.data)
+	}
+	return b
+}
+
+func (m *message) option(code uint16) []byte {
+	for _, opt := range m.options {
+		if opt.code == code {
+			return opt.data
+		}
+	}
+	return nil
+}
+
+func appendOption(b []byte, code uint16, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, code)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+func parseOptions(b []byte) ([]option, error) {
+	var options []option
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("truncated option header")
+		}
+		code := binary.BigEndian.Uint16(b[0:2])
+		length := int(binary.BigEndian.Uint16(b[2:4]))
+		if len(b) < 4+length {
+			return nil, fmt.Errorf("truncated option %d", code)
+		}
+		options = append(options, option{code: code, data: b[4 : 4+length]})
+		b = b[4+length:]
+	}
+	return options, nil
+}
+
+func parseMessage(b []byte) (*message, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("message too short")
+	}
+	m := &message{msgType: b[0]}
+	copy(m.transactionID[:], b[1:4])
+	var err error
+	m.options, err = parseOptions(b[4:])
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// iaPD is an identity association for prefix delegation holding at most one
+// prefix
+type iaPD struct {
+	iaid   uint32
+	t1     time.Duration
+	t2     time.Duration
+	prefix *net.IPNet
+	// preferred and valid are the lifetimes of the prefix
+	preferred time.Duration
+	valid     time.Duration
+	// status is the status code of the IA_PD, or of its prefix
+	status        uint16
+	statusMessage string
+}
+
+func (ia *iaPD) marshal() []byte {
+	b := binary.BigEndian.AppendUint32(nil, ia.iaid)
+	b = binary.BigEndian.AppendUint32(b, uint32(ia.t1/time.Second))
+	b = binary.BigEndian.AppendUint32(b, uint32(ia.t2/time.Second))
+	if ia.prefix != nil {
+		prefixLen, _ := ia.prefix.Mask.Size()
+		p := binary.BigEndian.AppendUint32(nil, uint32(ia.preferred/time.Second))
+		p = binary.BigEndian.AppendUint32(p, uint32(ia.valid/time.Second))
+		p = append(p, byte(prefixLen))
+		p = append(p, ia.prefix.IP.To16()...)
+		b = appendOption(b, optIAPrefix, p)
+	}
+	return b
+}
+
+func parseIAPD(b []byte) (*iaPD, error) {
+	if len(b) < 12 {
+		return nil, fmt.Errorf("IA_PD option too short")
+	}
+	ia := &iaPD{
+		iaid: binary.BigEndian.Uint32(b[0:4]),
+		t1:   time.Duration(binary.BigEndian.Uint32(b[4:8])) * time.Second,
+		t2:   time.Duration(binary.BigEndian.Uint32(b[8:12])) * time.Second,
+	}
+	options, err := parseOptions(b[12:])
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range options {
+		switch opt.code {
+		case optStatusCode:
+			ia.status, ia.statusMessage = parseStatusCode(opt.data)
+		case optIAPrefix:
+			if len(opt.data) < 25 {
+				return nil, fmt.Errorf("IA prefix option too short")
+			}
+			valid := time.Duration(binary.BigEndian.Uint32(opt.data[4:8])) * time.Second
+			// prefixes with a zero valid lifetime are being withdrawn
+			if valid == 0 || ia.prefix != nil {
+				continue
+			}
+			prefixLen := int(opt.data[8])
+			if prefixLen > 128 {
+				return nil, fmt.Errorf("invalid prefix length %d", prefixLen)
+			}
+			ip := net.IP(append([]byte{}, opt.data[9:25]...))
+			mask := net.CIDRMask(prefixLen, 128)
+			ia.prefix = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+			ia.preferred = time.Duration(binary.BigEndian.Uint32(opt.data[0:4])) * time.Second
+			ia.valid = valid
+			prefixOptions, err := parseOptions(opt.data[25:])
+			if err != nil {
+				return nil, err
+			}
+			for _, prefixOpt := range prefixOptions {
+				if prefixOpt.code == optStatusCode {
+					ia.status, ia.statusMessage = parseStatusCode(prefixOpt.data)
+				}
+			}
+		}
+	}
+	return ia, nil
+}
+
+func parseStatusCode(b []byte) (uint16, string) {
+	if len(b) < 2 {
+		return statusSuccess, ""
+	}
+	return binary.BigEndian.Uint16(b[0:2]), string(b[2:])
+}
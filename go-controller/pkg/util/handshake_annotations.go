@@ -0,0 +1,128 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+
+	kapi "k8s.io/api/core/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+)
+
+// The node annotations set by a component for another one to act upon form a
+// handshake. The producer of a handshake stamps it with a generation, bumped
+// each time the annotation changes, in the same update as the annotation, and
+// its consumer records the generation it acted upon as observed generation,
+// e.g.
+//
+//	annotations:
+//	  k8s.ovn.org/handshake-generations: |
+//	    {"node-subnets": 3, "network-ids": 2}
+//	  k8s.ovn.org/handshake-observed-generations: |
+//	    {"node-subnets": 3, "network-ids": 2}
+//
+// A consumer can then tell an annotation it already acted upon, or older than
+// it, from a newer one, and whether a node caught up with the latest update of
+// a handshake can be told from its annotations. The annotations of a handshake written by a
+// component not stamping them, e.g. during an upgrade, are left with the
+// generation they had, so a consumer still compares their content.
+const (
+	// ovnHandshakeGenerations holds the generation of each handshake of the
+	// node produced by ovnkube-cluster-manager
+	ovnHandshakeGenerations = "k8s.ovn.org/handshake-generations"
+	// ovnHandshakeObservedGenerations holds the generation of each handshake
+	// of the node that ovnkube-node set up the node from
+	ovnHandshakeObservedGenerations = "k8s.ovn.org/handshake-observed-generations"
+
+	// HandshakeNodeSubnets is the handshake of the "k8s.ovn.org/node-subnets"
+	// annotation
+	HandshakeNodeSubnets = "node-subnets"
+	// HandshakeNetworkIDs is the handshake of the "k8s.ovn.org/network-ids"
+	// annotation
+	HandshakeNetworkIDs = "network-ids"
+)
+
+// handshakeAnnotations maps each handshake to the annotation it stamps
+var handshakeAnnotations = map[string]string{
+	HandshakeNodeSubnets: ovnNodeSubnets,
+	HandshakeNetworkIDs:  ovnNetworkIDs,
+}
+
+func parseHandshakeGenerations(annotations map[string]string, annotationName string) (map[string]int64, error) {
+	generations := map[string]int64{}
+	annotation, ok := annotations[annotationName]
+	if !ok {
+		return generations, nil
+	}
+	if err := json.Unmarshal([]byte(annotation), &generations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s annotation %q: %v", annotationName, annotation, err)
+	}
+	return generations, nil
+}
+
+// UpdateHandshakeGenerations bumps, in the 'annotations' map, the generation
+// of each handshake whose annotation differs from 'oldAnnotations'
+func UpdateHandshakeGenerations(oldAnnotations, annotations map[string]string) (map[string]string, error) {
+	generations, err := parseHandshakeGenerations(annotations, ovnHandshakeGenerations)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	for handshake, annotationName := range handshakeAnnotations {
+		if oldAnnotations[annotationName] != annotations[annotationName] {
+			generations[handshake]++
+			changed = true
+		}
+	}
+	if !changed {
+		return annotations, nil
+	}
+	bytes, err := json.Marshal(generations)
+	if err != nil {
+		return nil, err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ovnHandshakeGenerations] = string(bytes)
+	return annotations, nil
+}
+
+// ParseHandshakeGenerations returns the generation of each handshake of the
+// node, empty if none is stamped
+func ParseHandshakeGenerations(node *kapi.Node) (map[string]int64, error) {
+	return parseHandshakeGenerations(node.Annotations, ovnHandshakeGenerations)
+}
+
+// GetHandshakeGeneration returns the generation of the handshake of the node,
+// 0 if not stamped
+func GetHandshakeGeneration(node *kapi.Node, handshake string) int64 {
+	generations, err := ParseHandshakeGenerations(node)
+	if err != nil {
+		return 0
+	}
+	return generations[handshake]
+}
+
+// SetHandshakeObservedGenerations records the generations of the handshakes
+// of the node that the node was set up from
+func SetHandshakeObservedGenerations(nodeAnnotator kube.Annotator, generations map[string]int64) error {
+	if len(generations) == 0 {
+		return nil
+	}
+	return nodeAnnotator.Set(ovnHandshakeObservedGenerations, generations)
+}
+
+// ParseHandshakeObservedGenerations returns the generations of the handshakes
+// of the node that the node was set up from, empty if none is recorded
+func ParseHandshakeObservedGenerations(node *kapi.Node) (map[string]int64, error) {
+	return parseHandshakeGenerations(node.Annotations, ovnHandshakeObservedGenerations)
+}
+
+// HandshakeGenerationStale returns true if the handshake of the node is
+// stamped with an older generation than the 'observed' one, i.e. the node is
+// older than the one its annotation was already acted upon from
+func HandshakeGenerationStale(node *kapi.Node, handshake string, observed int64) bool {
+	generation := GetHandshakeGeneration(node, handshake)
+	return generation > 0 && generation < observed
+}
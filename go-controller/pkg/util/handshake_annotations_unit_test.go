@@ -0,0 +1,79 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateHandshakeGenerations(t *testing.T) {
+	tests := []struct {
+		desc           string
+		oldAnnotations map[string]string
+		annotations    map[string]string
+		expGenerations map[string]int64
+	}{
+		{
+			desc:           "no generation is stamped if no handshake changed",
+			oldAnnotations: map[string]string{ovnNodeSubnets: `{"default":"10.128.0.0/24"}`},
+			annotations:    map[string]string{ovnNodeSubnets: `{"default":"10.128.0.0/24"}`},
+			expGenerations: map[string]int64{},
+		},
+		{
+			desc:           "the generation of a new handshake starts at 1",
+			oldAnnotations: map[string]string{},
+			annotations:    map[string]string{ovnNodeSubnets: `{"default":"10.128.0.0/24"}`},
+			expGenerations: map[string]int64{HandshakeNodeSubnets: 1},
+		},
+		{
+			desc: "only the generation of the changed handshakes is bumped",
+			oldAnnotations: map[string]string{
+				ovnNodeSubnets:          `{"default":"10.128.0.0/24"}`,
+				ovnNetworkIDs:           `{"default":"0"}`,
+				ovnHandshakeGenerations: `{"node-subnets":3,"network-ids":2}`,
+			},
+			annotations: map[string]string{
+				ovnNodeSubnets:          `{"default":"10.128.0.0/24"}`,
+				ovnNetworkIDs:           `{"default":"0","blue":"1"}`,
+				ovnHandshakeGenerations: `{"node-subnets":3,"network-ids":2}`,
+			},
+			expGenerations: map[string]int64{HandshakeNodeSubnets: 3, HandshakeNetworkIDs: 3},
+		},
+		{
+			desc: "the generation of a removed handshake is bumped",
+			oldAnnotations: map[string]string{
+				ovnNodeSubnets:          `{"default":"10.128.0.0/24"}`,
+				ovnHandshakeGenerations: `{"node-subnets":3}`,
+			},
+			annotations: map[string]string{
+				ovnHandshakeGenerations: `{"node-subnets":3}`,
+			},
+			expGenerations: map[string]int64{HandshakeNodeSubnets: 4},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			annotations, err := UpdateHandshakeGenerations(tc.oldAnnotations, tc.annotations)
+			assert.NoError(t, err)
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: annotations}}
+			generations, err := ParseHandshakeGenerations(node)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expGenerations, generations)
+		})
+	}
+}
+
+func TestHandshakeGenerationStale(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        "node1",
+		Annotations: map[string]string{ovnHandshakeGenerations: `{"node-subnets":3}`},
+	}}
+	assert.False(t, HandshakeGenerationStale(node, HandshakeNodeSubnets, 0))
+	assert.False(t, HandshakeGenerationStale(node, HandshakeNodeSubnets, 3))
+	assert.True(t, HandshakeGenerationStale(node, HandshakeNodeSubnets, 4))
+	// the handshakes not stamped, e.g. by an older ovnkube-cluster-manager,
+	// are never stale
+	assert.False(t, HandshakeGenerationStale(node, HandshakeNetworkIDs, 4))
+}
@@ -34,8 +34,12 @@ import (
 	adminpolicybasedrouteclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/clientset/versioned"
 	egressfirewallclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned"
 	egressipclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned"
+	egressippoolclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressippool/v1/apis/clientset/versioned"
 	egressqosclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/clientset/versioned"
 	egressserviceclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressservice/v1/apis/clientset/versioned"
+	hostclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/host/v1/apis/clientset/versioned"
+	ipfamilyconversionclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipfamilyconversion/v1/apis/clientset/versioned"
+	nodenetworkstateclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1/apis/clientset/versioned"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	anpclientset "sigs.k8s.io/network-policy-api/pkg/client/clientset/versioned"
 )
@@ -52,6 +56,10 @@ type OVNClientset struct {
 	MultiNetworkPolicyClient multinetworkpolicyclientset.Interface
 	EgressServiceClient      egressserviceclientset.Interface
 	AdminPolicyRouteClient   adminpolicybasedrouteclientset.Interface
+	NodeNetworkStateClient   nodenetworkstateclientset.Interface
+	HostClient               hostclientset.Interface
+	IPFamilyConversionClient ipfamilyconversionclientset.Interface
+	EgressIPPoolClient       egressippoolclientset.Interface
 }
 
 // OVNMasterClientset
@@ -65,6 +73,8 @@ type OVNMasterClientset struct {
 	MultiNetworkPolicyClient multinetworkpolicyclientset.Interface
 	EgressServiceClient      egressserviceclientset.Interface
 	AdminPolicyRouteClient   adminpolicybasedrouteclientset.Interface
+	NodeNetworkStateClient   nodenetworkstateclientset.Interface
+	HostClient               hostclientset.Interface
 }
 
 // OVNNetworkControllerManagerClientset
@@ -77,6 +87,8 @@ type OVNKubeControllerClientset struct {
 	MultiNetworkPolicyClient multinetworkpolicyclientset.Interface
 	EgressServiceClient      egressserviceclientset.Interface
 	AdminPolicyRouteClient   adminpolicybasedrouteclientset.Interface
+	NodeNetworkStateClient   nodenetworkstateclientset.Interface
+	HostClient               hostclientset.Interface
 }
 
 type OVNNodeClientset struct {
@@ -84,14 +96,20 @@ type OVNNodeClientset struct {
 	EgressServiceClient    egressserviceclientset.Interface
 	EgressIPClient         egressipclientset.Interface
 	AdminPolicyRouteClient adminpolicybasedrouteclientset.Interface
+	NodeNetworkStateClient nodenetworkstateclientset.Interface
+	HostClient             hostclientset.Interface
 }
 
 type OVNClusterManagerClientset struct {
-	KubeClient            kubernetes.Interface
-	EgressIPClient        egressipclientset.Interface
-	CloudNetworkClient    ocpcloudnetworkclientset.Interface
-	NetworkAttchDefClient networkattchmentdefclientset.Interface
-	EgressServiceClient   egressserviceclientset.Interface
+	KubeClient               kubernetes.Interface
+	EgressIPClient           egressipclientset.Interface
+	CloudNetworkClient       ocpcloudnetworkclientset.Interface
+	NetworkAttchDefClient    networkattchmentdefclientset.Interface
+	EgressServiceClient      egressserviceclientset.Interface
+	NodeNetworkStateClient   nodenetworkstateclientset.Interface
+	HostClient               hostclientset.Interface
+	IPFamilyConversionClient ipfamilyconversionclientset.Interface
+	EgressIPPoolClient       egressippoolclientset.Interface
 }
 
 func (cs *OVNClientset) GetMasterClientset() *OVNMasterClientset {
@@ -105,6 +123,8 @@ func (cs *OVNClientset) GetMasterClientset() *OVNMasterClientset {
 		MultiNetworkPolicyClient: cs.MultiNetworkPolicyClient,
 		EgressServiceClient:      cs.EgressServiceClient,
 		AdminPolicyRouteClient:   cs.AdminPolicyRouteClient,
+		NodeNetworkStateClient:   cs.NodeNetworkStateClient,
+		HostClient:               cs.HostClient,
 	}
 }
 
@@ -118,6 +138,8 @@ func (cs *OVNMasterClientset) GetOVNKubeControllerClientset() *OVNKubeController
 		MultiNetworkPolicyClient: cs.MultiNetworkPolicyClient,
 		EgressServiceClient:      cs.EgressServiceClient,
 		AdminPolicyRouteClient:   cs.AdminPolicyRouteClient,
+		NodeNetworkStateClient:   cs.NodeNetworkStateClient,
+		HostClient:               cs.HostClient,
 	}
 }
 
@@ -131,16 +153,22 @@ func (cs *OVNClientset) GetOVNKubeControllerClientset() *OVNKubeControllerClient
 		MultiNetworkPolicyClient: cs.MultiNetworkPolicyClient,
 		EgressServiceClient:      cs.EgressServiceClient,
 		AdminPolicyRouteClient:   cs.AdminPolicyRouteClient,
+		NodeNetworkStateClient:   cs.NodeNetworkStateClient,
+		HostClient:               cs.HostClient,
 	}
 }
 
 func (cs *OVNClientset) GetClusterManagerClientset() *OVNClusterManagerClientset {
 	return &OVNClusterManagerClientset{
-		KubeClient:            cs.KubeClient,
-		EgressIPClient:        cs.EgressIPClient,
-		CloudNetworkClient:    cs.CloudNetworkClient,
-		NetworkAttchDefClient: cs.NetworkAttchDefClient,
-		EgressServiceClient:   cs.EgressServiceClient,
+		KubeClient:               cs.KubeClient,
+		EgressIPClient:           cs.EgressIPClient,
+		CloudNetworkClient:       cs.CloudNetworkClient,
+		NetworkAttchDefClient:    cs.NetworkAttchDefClient,
+		EgressServiceClient:      cs.EgressServiceClient,
+		NodeNetworkStateClient:   cs.NodeNetworkStateClient,
+		HostClient:               cs.HostClient,
+		IPFamilyConversionClient: cs.IPFamilyConversionClient,
+		EgressIPPoolClient:       cs.EgressIPPoolClient,
 	}
 }
 
@@ -150,14 +178,17 @@ func (cs *OVNClientset) GetNodeClientset() *OVNNodeClientset {
 		EgressServiceClient:    cs.EgressServiceClient,
 		EgressIPClient:         cs.EgressIPClient,
 		AdminPolicyRouteClient: cs.AdminPolicyRouteClient,
+		NodeNetworkStateClient: cs.NodeNetworkStateClient,
+		HostClient:             cs.HostClient,
 	}
 }
 
 func (cs *OVNMasterClientset) GetNodeClientset() *OVNNodeClientset {
 	return &OVNNodeClientset{
-		KubeClient:          cs.KubeClient,
-		EgressServiceClient: cs.EgressServiceClient,
-		EgressIPClient:      cs.EgressIPClient,
+		KubeClient:             cs.KubeClient,
+		EgressServiceClient:    cs.EgressServiceClient,
+		EgressIPClient:         cs.EgressIPClient,
+		NodeNetworkStateClient: cs.NodeNetworkStateClient,
 	}
 }
 
@@ -284,6 +315,26 @@ func NewOVNClientset(conf *config.KubernetesConfig) (*OVNClientset, error) {
 		return nil, err
 	}
 
+	nodeNetworkStateClientset, err := nodenetworkstateclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	hostClientset, err := hostclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ipFamilyConversionClientset, err := ipfamilyconversionclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	egressIPPoolClientset, err := egressippoolclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+
 	return &OVNClientset{
 		KubeClient:               kclientset,
 		ANPClient:                anpClientset,
@@ -295,6 +346,10 @@ func NewOVNClientset(conf *config.KubernetesConfig) (*OVNClientset, error) {
 		MultiNetworkPolicyClient: multiNetworkPolicyClientset,
 		EgressServiceClient:      egressserviceClientset,
 		AdminPolicyRouteClient:   adminPolicyBasedRouteClientset,
+		NodeNetworkStateClient:   nodeNetworkStateClientset,
+		HostClient:               hostClientset,
+		IPFamilyConversionClient: ipFamilyConversionClientset,
+		EgressIPPoolClient:       egressIPPoolClientset,
 	}, nil
 }
 
@@ -320,6 +375,18 @@ func GetClusterIPs(service *kapi.Service) []string {
 	return []string{}
 }
 
+// GetClusterIPFamilies returns whether the service has IPv4 and IPv6 cluster IPs
+func GetClusterIPFamilies(service *kapi.Service) (hasIPv4, hasIPv6 bool) {
+	for _, clusterIP := range GetClusterIPs(service) {
+		if utilnet.IsIPv6String(clusterIP) {
+			hasIPv6 = true
+		} else {
+			hasIPv4 = true
+		}
+	}
+	return hasIPv4, hasIPv6
+}
+
 // GetExternalAndLBIPs returns an array with the ExternalIPs and LoadBalancer IPs present in the service
 func GetExternalAndLBIPs(service *kapi.Service) []string {
 	svcVIPs := []string{}
@@ -422,6 +489,44 @@ func PodCompleted(pod *kapi.Pod) bool {
 	return pod.Status.Phase == kapi.PodSucceeded || pod.Status.Phase == kapi.PodFailed
 }
 
+// PodHostPorts returns the container ports of the pod exposed on a host port,
+// their protocol defaulted to TCP. Host networked pods have none, their ports
+// being the ones of the host.
+func PodHostPorts(pod *kapi.Pod) []kapi.ContainerPort {
+	if PodWantsHostNetwork(pod) {
+		return nil
+	}
+	var hostPorts []kapi.ContainerPort
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort <= 0 {
+				continue
+			}
+			if port.Protocol == "" {
+				port.Protocol = kapi.ProtocolTCP
+			}
+			hostPorts = append(hostPorts, port)
+		}
+	}
+	return hostPorts
+}
+
+// GetNodePortService returns the service exposing a NodePort of the given
+// protocol and port among the services, if any
+func GetNodePortService(services []*kapi.Service, protocol kapi.Protocol, port int32) *kapi.Service {
+	for _, service := range services {
+		if !ServiceTypeHasNodePort(service) {
+			continue
+		}
+		for _, svcPort := range service.Spec.Ports {
+			if svcPort.NodePort == port && svcPort.Protocol == protocol {
+				return service
+			}
+		}
+	}
+	return nil
+}
+
 // PodRunning checks if the pod is in running state or not
 func PodRunning(pod *kapi.Pod) bool {
 	return pod.Status.Phase == kapi.PodRunning
@@ -153,6 +153,62 @@ func TestIsClusterIPSet(t *testing.T) {
 	}
 }
 
+func TestGetClusterIPFamilies(t *testing.T) {
+	tests := []struct {
+		desc    string
+		inp     v1.Service
+		expIPv4 bool
+		expIPv6 bool
+	}{
+		{
+			desc: "headless service has no IP family",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					ClusterIP: v1.ClusterIPNone,
+				},
+			},
+		},
+		{
+			desc: "single-stack IPv4 service with only ClusterIP set",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					ClusterIP: "10.96.0.10",
+				},
+			},
+			expIPv4: true,
+		},
+		{
+			desc: "single-stack IPv6 service",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					ClusterIP:  "fd00:10:96::10",
+					ClusterIPs: []string{"fd00:10:96::10"},
+				},
+			},
+			expIPv6: true,
+		},
+		{
+			desc: "dual-stack service",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					ClusterIP:  "fd00:10:96::10",
+					ClusterIPs: []string{"fd00:10:96::10", "10.96.0.10"},
+				},
+			},
+			expIPv4: true,
+			expIPv6: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			hasIPv4, hasIPv6 := GetClusterIPFamilies(&tc.inp)
+			assert.Equal(t, tc.expIPv4, hasIPv4)
+			assert.Equal(t, tc.expIPv6, hasIPv6)
+		})
+	}
+}
+
 func TestValidateProtocol(t *testing.T) {
 	tests := []struct {
 		desc   string
@@ -3,6 +3,7 @@ package util
 import (
 	"errors"
 	"fmt"
+	"math/bits"
 	"net"
 	"strings"
 	"sync"
@@ -35,6 +36,12 @@ type BasicNetInfo interface {
 	Subnets() []config.CIDRNetworkEntry
 	ExcludeSubnets() []*net.IPNet
 	Vlan() uint
+	Firewall() *NetworkFirewall
+	HostSubnetAllocation() string
+	NodeIPChunkSize() int
+	IsInternal() bool
+	Passthrough() *LocalnetPassthrough
+	IsPrimaryNetwork() bool
 
 	// utility methods
 	CompareNetInfo(BasicNetInfo) bool
@@ -96,10 +103,11 @@ func (nInfo *DefaultNetInfo) CompareNetInfo(netBasicInfo BasicNetInfo) bool {
 	return ok
 }
 
-// TopologyType returns the defaultNetConfInfo's topology type which is empty
+// TopologyType returns the defaultNetConfInfo's topology type, layer3 unless
+// configured otherwise
 func (nInfo *DefaultNetInfo) TopologyType() string {
 	// TODO(trozet): optimize other checks using this function after changing default network type from "" -> L3
-	return types.Layer3Topology
+	return config.Default.Topology
 }
 
 // MTU returns the defaultNetConfInfo's MTU value
@@ -127,6 +135,38 @@ func (nInfo *DefaultNetInfo) Vlan() uint {
 	return config.Gateway.VLANID
 }
 
+// Firewall returns the defaultNetConfInfo's Firewall value which is nil
+func (nInfo *DefaultNetInfo) Firewall() *NetworkFirewall {
+	return nil
+}
+
+// HostSubnetAllocation returns the defaultNetConfInfo's HostSubnetAllocation value
+func (nInfo *DefaultNetInfo) HostSubnetAllocation() string {
+	return config.ClusterManager.HostSubnetAllocation
+}
+
+// NodeIPChunkSize returns the defaultNetConfInfo's NodeIPChunkSize value which
+// is 0 as the default network has no per node IP chunks
+func (nInfo *DefaultNetInfo) NodeIPChunkSize() int {
+	return 0
+}
+
+// IsInternal returns false as the default network has north-south connectivity
+func (nInfo *DefaultNetInfo) IsInternal() bool {
+	return false
+}
+
+// Passthrough returns the defaultNetConfInfo's Passthrough value which is nil
+func (nInfo *DefaultNetInfo) Passthrough() *LocalnetPassthrough {
+	return nil
+}
+
+// IsPrimaryNetwork returns true as the default network provides the gateway
+// routers and the egress of the pods
+func (nInfo *DefaultNetInfo) IsPrimaryNetwork() bool {
+	return true
+}
+
 // SecondaryNetInfo holds the network name information for secondary network if non-nil
 type secondaryNetInfo struct {
 	netName  string
@@ -137,6 +177,12 @@ type secondaryNetInfo struct {
 	ipv4mode, ipv6mode bool
 	subnets            []config.CIDRNetworkEntry
 	excludeSubnets     []*net.IPNet
+	firewall           *NetworkFirewall
+	hostSubnetAlloc    string
+	nodeIPChunkSize    int
+	internal           bool
+	passthrough        *LocalnetPassthrough
+	primary            bool
 
 	// all net-attach-def NAD names for this network, used to determine if a pod needs
 	// to be plumbed for this network
@@ -218,6 +264,43 @@ func (nInfo *secondaryNetInfo) ExcludeSubnets() []*net.IPNet {
 	return nInfo.excludeSubnets
 }
 
+// Firewall returns the Firewall value
+func (nInfo *secondaryNetInfo) Firewall() *NetworkFirewall {
+	return nInfo.firewall
+}
+
+// HostSubnetAllocation returns the HostSubnetAllocation value, sequential for
+// the networks without host subnets
+func (nInfo *secondaryNetInfo) HostSubnetAllocation() string {
+	if nInfo.hostSubnetAlloc == "" {
+		return config.HostSubnetAllocationSequential
+	}
+	return nInfo.hostSubnetAlloc
+}
+
+// NodeIPChunkSize returns the NodeIPChunkSize value, 0 if the pod IPs are not
+// allocated from per node IP chunks
+func (nInfo *secondaryNetInfo) NodeIPChunkSize() int {
+	return nInfo.nodeIPChunkSize
+}
+
+// IsInternal returns true if the network has no north-south connectivity
+func (nInfo *secondaryNetInfo) IsInternal() bool {
+	return nInfo.internal
+}
+
+// Passthrough returns the Passthrough value, nil if the link-local and control
+// protocol traffic gets the default handling
+func (nInfo *secondaryNetInfo) Passthrough() *LocalnetPassthrough {
+	return nInfo.passthrough
+}
+
+// IsPrimaryNetwork returns true if the network has the primary role, with
+// gateway routers providing the egress of its pods
+func (nInfo *secondaryNetInfo) IsPrimaryNetwork() bool {
+	return nInfo.primary
+}
+
 // CompareNetInfo compares for equality this network information with the other
 func (nInfo *secondaryNetInfo) CompareNetInfo(other BasicNetInfo) bool {
 	if nInfo.netName != other.GetNetworkName() {
@@ -232,6 +315,21 @@ func (nInfo *secondaryNetInfo) CompareNetInfo(other BasicNetInfo) bool {
 	if nInfo.vlan != other.Vlan() {
 		return false
 	}
+	if nInfo.HostSubnetAllocation() != other.HostSubnetAllocation() {
+		return false
+	}
+	if nInfo.nodeIPChunkSize != other.NodeIPChunkSize() {
+		return false
+	}
+	if nInfo.internal != other.IsInternal() {
+		return false
+	}
+	if !cmp.Equal(nInfo.passthrough, other.Passthrough()) {
+		return false
+	}
+	if nInfo.primary != other.IsPrimaryNetwork() {
+		return false
+	}
 
 	lessCIDRNetworkEntry := func(a, b config.CIDRNetworkEntry) bool { return a.String() < b.String() }
 	if !cmp.Equal(nInfo.subnets, other.Subnets(), cmpopts.SortSlices(lessCIDRNetworkEntry)) {
@@ -239,7 +337,12 @@ func (nInfo *secondaryNetInfo) CompareNetInfo(other BasicNetInfo) bool {
 	}
 
 	lessIPNet := func(a, b net.IPNet) bool { return a.String() < b.String() }
-	return cmp.Equal(nInfo.excludeSubnets, other.ExcludeSubnets(), cmpopts.SortSlices(lessIPNet))
+	if !cmp.Equal(nInfo.excludeSubnets, other.ExcludeSubnets(), cmpopts.SortSlices(lessIPNet)) {
+		return false
+	}
+
+	// the order of the firewall rules matters
+	return cmp.Equal(nInfo.firewall, other.Firewall())
 }
 
 func newLayer3NetConfInfo(netconf *ovncnitypes.NetConf) (NetInfo, error) {
@@ -247,12 +350,35 @@ func newLayer3NetConfInfo(netconf *ovncnitypes.NetConf) (NetInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	if netconf.Passthrough != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: passthrough is only valid in %s topology", netconf.Topology,
+			netconf.Name, types.LocalnetTopology)
+	}
+	firewall, err := parseNetworkFirewall(netconf.Firewall, subnets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
+	}
+	switch netconf.HostSubnetAllocation {
+	case "", config.HostSubnetAllocationSequential, config.HostSubnetAllocationRandomized:
+	default:
+		return nil, fmt.Errorf("invalid %s netconf %s: invalid host subnet allocation %q, must be %q or %q",
+			netconf.Topology, netconf.Name, netconf.HostSubnetAllocation, config.HostSubnetAllocationSequential,
+			config.HostSubnetAllocationRandomized)
+	}
+	primary, err := parseNetworkRole(netconf, subnets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
+	}
 
 	ni := &secondaryNetInfo{
-		netName:  netconf.Name,
-		topology: types.Layer3Topology,
-		subnets:  subnets,
-		mtu:      netconf.MTU,
+		netName:         netconf.Name,
+		topology:        types.Layer3Topology,
+		subnets:         subnets,
+		mtu:             netconf.MTU,
+		firewall:        firewall,
+		hostSubnetAlloc: netconf.HostSubnetAllocation,
+		internal:        netconf.Internal,
+		primary:         primary,
 	}
 	ni.ipv4mode, ni.ipv6mode = getIPMode(subnets)
 	return ni, nil
@@ -263,13 +389,41 @@ func newLayer2NetConfInfo(netconf *ovncnitypes.NetConf) (NetInfo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
 	}
+	firewall, err := parseNetworkFirewall(netconf.Firewall, subnets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
+	}
+	if netconf.NodeIPChunkSize != 0 {
+		if err := setNodeIPChunkSize(subnets, netconf.NodeIPChunkSize); err != nil {
+			return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
+		}
+	}
+	if netconf.Passthrough != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: passthrough is only valid in %s topology", netconf.Topology,
+			netconf.Name, types.LocalnetTopology)
+	}
+	primary, err := parseNetworkRole(netconf, subnets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
+	}
+	if primary {
+		// the gateway IPs of the subnets are the ones of the cluster router
+		for _, subnet := range subnets {
+			gwIP := GetNodeGatewayIfAddr(subnet.CIDR).IP
+			excludes = append(excludes, &net.IPNet{IP: gwIP, Mask: GetIPFullMask(gwIP)})
+		}
+	}
 
 	ni := &secondaryNetInfo{
-		netName:        netconf.Name,
-		topology:       types.Layer2Topology,
-		subnets:        subnets,
-		excludeSubnets: excludes,
-		mtu:            netconf.MTU,
+		netName:         netconf.Name,
+		topology:        types.Layer2Topology,
+		subnets:         subnets,
+		excludeSubnets:  excludes,
+		mtu:             netconf.MTU,
+		firewall:        firewall,
+		nodeIPChunkSize: netconf.NodeIPChunkSize,
+		internal:        netconf.Internal,
+		primary:         primary,
 	}
 	ni.ipv4mode, ni.ipv6mode = getIPMode(subnets)
 	return ni, nil
@@ -280,6 +434,23 @@ func newLocalnetNetConfInfo(netconf *ovncnitypes.NetConf) (NetInfo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
 	}
+	firewall, err := parseNetworkFirewall(netconf.Firewall, subnets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
+	}
+	if netconf.Internal {
+		// the physical network is connected outside the cluster
+		return nil, fmt.Errorf("invalid %s netconf %s: a %s network can't be internal", netconf.Topology,
+			netconf.Name, types.LocalnetTopology)
+	}
+	passthrough, err := parseLocalnetPassthrough(netconf.Passthrough, subnets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s netconf %s: %v", netconf.Topology, netconf.Name, err)
+	}
+	if netconf.Role == types.NetworkRolePrimary {
+		return nil, fmt.Errorf("invalid %s netconf %s: a %s network can't be primary", netconf.Topology,
+			netconf.Name, types.LocalnetTopology)
+	}
 
 	ni := &secondaryNetInfo{
 		netName:        netconf.Name,
@@ -287,12 +458,56 @@ func newLocalnetNetConfInfo(netconf *ovncnitypes.NetConf) (NetInfo, error) {
 		subnets:        subnets,
 		excludeSubnets: excludes,
 		mtu:            netconf.MTU,
+		firewall:       firewall,
 		vlan:           uint(netconf.VLANID),
+		passthrough:    passthrough,
 	}
 	ni.ipv4mode, ni.ipv6mode = getIPMode(subnets)
 	return ni, nil
 }
 
+// parseNetworkRole validates the role of a layer3 or layer2 network and returns
+// whether it is primary. A primary network must have subnets for the gateway
+// routers to route and must not be internal.
+func parseNetworkRole(netconf *ovncnitypes.NetConf, subnets []config.CIDRNetworkEntry) (bool, error) {
+	switch netconf.Role {
+	case "", types.NetworkRoleSecondary:
+		return false, nil
+	case types.NetworkRolePrimary:
+	default:
+		return false, fmt.Errorf("invalid role %q, must be %q or %q", netconf.Role, types.NetworkRolePrimary,
+			types.NetworkRoleSecondary)
+	}
+	if len(subnets) == 0 {
+		return false, fmt.Errorf("a %s network requires subnets", types.NetworkRolePrimary)
+	}
+	if netconf.Internal {
+		return false, fmt.Errorf("a %s network can't be internal", types.NetworkRolePrimary)
+	}
+	return true, nil
+}
+
+// setNodeIPChunkSize sets the host subnet length of the layer2 subnets to the
+// one of the node IP chunks of the given size, which must be a power of two of
+// at least 4 IPs smaller than each of the subnets.
+func setNodeIPChunkSize(subnets []config.CIDRNetworkEntry, size int) error {
+	if size < 4 || size&(size-1) != 0 {
+		return fmt.Errorf("invalid node IP chunk size %d, must be a power of two greater than or equal to 4", size)
+	}
+	if len(subnets) == 0 {
+		return fmt.Errorf("node IP chunks require subnets")
+	}
+	chunkBits := bits.TrailingZeros(uint(size))
+	for i := range subnets {
+		prefixLen, addrLen := subnets[i].CIDR.Mask.Size()
+		if addrLen-prefixLen <= chunkBits {
+			return fmt.Errorf("node IP chunk size %d is not smaller than subnet %s", size, subnets[i].CIDR)
+		}
+		subnets[i].HostSubnetLength = addrLen - chunkBits
+	}
+	return nil
+}
+
 func parseSubnets(subnetsString, excludeSubnetsString, topology string) ([]config.CIDRNetworkEntry, []*net.IPNet, error) {
 	var parseSubnets func(clusterSubnetCmd string) ([]config.CIDRNetworkEntry, error)
 	switch topology {
@@ -344,6 +559,152 @@ func parseSubnets(subnetsString, excludeSubnetsString, topology string) ([]confi
 	return subnets, excludeIPNets, nil
 }
 
+// MaxNetworkFirewallRules is the maximum number of rules of a network firewall
+// in each direction
+const MaxNetworkFirewallRules = 100
+
+// NetworkFirewall holds the parsed rules of the firewall of a network
+type NetworkFirewall struct {
+	Ingress []NetworkFirewallRule
+	Egress  []NetworkFirewallRule
+}
+
+// NetworkFirewallRule is a parsed network firewall rule
+type NetworkFirewallRule struct {
+	Allow bool
+	CIDR  *net.IPNet
+}
+
+// parseNetworkFirewall validates the firewall of a network: the CIDR of each
+// rule must be of an IP family of the network subnets. A firewall without any
+// rule is returned as nil.
+func parseNetworkFirewall(firewall *ovncnitypes.NetworkFirewall, subnets []config.CIDRNetworkEntry) (*NetworkFirewall, error) {
+	if firewall == nil || len(firewall.Ingress)+len(firewall.Egress) == 0 {
+		return nil, nil
+	}
+	if len(subnets) == 0 {
+		return nil, fmt.Errorf("firewall requires the network to have subnets")
+	}
+	ipv4Mode, ipv6Mode := getIPMode(subnets)
+	parseRules := func(direction string, rules []ovncnitypes.NetworkFirewallRule) ([]NetworkFirewallRule, error) {
+		if len(rules) > MaxNetworkFirewallRules {
+			return nil, fmt.Errorf("firewall has %d %s rules, more than the maximum of %d",
+				len(rules), direction, MaxNetworkFirewallRules)
+		}
+		parsed := make([]NetworkFirewallRule, 0, len(rules))
+		for i, rule := range rules {
+			var allow bool
+			switch rule.Action {
+			case ovncnitypes.NetworkFirewallActionAllow:
+				allow = true
+			case ovncnitypes.NetworkFirewallActionDeny:
+			default:
+				return nil, fmt.Errorf("firewall %s rule %d has an invalid action %q", direction, i, rule.Action)
+			}
+			_, cidr, err := net.ParseCIDR(strings.TrimSpace(rule.CIDR))
+			if err != nil {
+				return nil, fmt.Errorf("firewall %s rule %d has an invalid CIDR: %v", direction, i, err)
+			}
+			if isIPv6 := knet.IsIPv6CIDR(cidr); (isIPv6 && !ipv6Mode) || (!isIPv6 && !ipv4Mode) {
+				return nil, fmt.Errorf("firewall %s rule %d CIDR %s is not of an IP family of the network subnets",
+					direction, i, cidr)
+			}
+			parsed = append(parsed, NetworkFirewallRule{Allow: allow, CIDR: cidr})
+		}
+		return parsed, nil
+	}
+	ingress, err := parseRules("ingress", firewall.Ingress)
+	if err != nil {
+		return nil, err
+	}
+	egress, err := parseRules("egress", firewall.Egress)
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkFirewall{Ingress: ingress, Egress: egress}, nil
+}
+
+// PassthroughAction is the action on some link-local or control protocol
+// traffic of the pods of a localnet network
+type PassthroughAction int
+
+const (
+	// PassthroughDefault leaves the traffic to the port security, the network
+	// firewall and the multi network policies
+	PassthroughDefault PassthroughAction = iota
+	// PassthroughAllow always lets the traffic through
+	PassthroughAllow
+	// PassthroughDeny drops the traffic
+	PassthroughDeny
+)
+
+// LocalnetPassthrough holds the parsed passthrough actions of a localnet
+// network
+type LocalnetPassthrough struct {
+	LinkLocal PassthroughAction
+	LLDP      PassthroughAction
+	ARP       PassthroughAction
+}
+
+var (
+	// IPv4LinkLocalCIDR is the IPv4 link-local range
+	IPv4LinkLocalCIDR = &net.IPNet{IP: net.IPv4(169, 254, 0, 0).To4(), Mask: net.CIDRMask(16, 32)}
+	// IPv6LinkLocalCIDR is the IPv6 link-local range
+	IPv6LinkLocalCIDR = &net.IPNet{IP: net.ParseIP("fe80::"), Mask: net.CIDRMask(10, 128)}
+)
+
+// parseLocalnetPassthrough validates the passthrough actions of a localnet
+// network, rejecting those breaking the connectivity of the pods over the
+// subnets of the network:
+//   - denying the link-local traffic when a subnet is link-local.
+//   - denying ARP when the network has IPv4 subnets.
+func parseLocalnetPassthrough(passthrough *ovncnitypes.LocalnetPassthrough, subnets []config.CIDRNetworkEntry) (*LocalnetPassthrough, error) {
+	if passthrough == nil {
+		return nil, nil
+	}
+	parseAction := func(name, action string) (PassthroughAction, error) {
+		switch action {
+		case "":
+			return PassthroughDefault, nil
+		case ovncnitypes.PassthroughActionAllow:
+			return PassthroughAllow, nil
+		case ovncnitypes.PassthroughActionDeny:
+			return PassthroughDeny, nil
+		default:
+			return PassthroughDefault, fmt.Errorf("passthrough %s has an invalid action %q, must be %q or %q",
+				name, action, ovncnitypes.PassthroughActionAllow, ovncnitypes.PassthroughActionDeny)
+		}
+	}
+	parsed := &LocalnetPassthrough{}
+	var err error
+	if parsed.LinkLocal, err = parseAction("linkLocal", passthrough.LinkLocal); err != nil {
+		return nil, err
+	}
+	if parsed.LLDP, err = parseAction("lldp", passthrough.LLDP); err != nil {
+		return nil, err
+	}
+	if parsed.ARP, err = parseAction("arp", passthrough.ARP); err != nil {
+		return nil, err
+	}
+	ipv4Mode, _ := getIPMode(subnets)
+	if parsed.ARP == PassthroughDeny && ipv4Mode {
+		return nil, fmt.Errorf("denying ARP would break the IPv4 connectivity of the pods over the network subnets")
+	}
+	if parsed.LinkLocal == PassthroughDeny {
+		for _, subnet := range subnets {
+			if IPv4LinkLocalCIDR.Contains(subnet.CIDR.IP) || subnet.CIDR.Contains(IPv4LinkLocalCIDR.IP) ||
+				IPv6LinkLocalCIDR.Contains(subnet.CIDR.IP) || subnet.CIDR.Contains(IPv6LinkLocalCIDR.IP) {
+				return nil, fmt.Errorf("denying the link-local traffic would drop the traffic of link-local subnet %s",
+					subnet.CIDR)
+			}
+		}
+	}
+	if *parsed == (LocalnetPassthrough{}) {
+		return nil, nil
+	}
+	return parsed, nil
+}
+
 func getIPMode(subnets []config.CIDRNetworkEntry) (bool, bool) {
 	var ipv6Mode, ipv4Mode bool
 	for _, subnet := range subnets {
@@ -9,6 +9,9 @@ import (
 
 	cnitypes "github.com/containernetworking/cni/pkg/types"
 
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 
 	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
@@ -315,3 +318,390 @@ func applyNADDefaults(nad *nadv1.NetworkAttachmentDefinition) *nadv1.NetworkAtta
 	nad.Namespace = namespace
 	return nad
 }
+
+func TestParseNetworkFirewall(t *testing.T) {
+	dualStackSubnets := []config.CIDRNetworkEntry{
+		{CIDR: ovntest.MustParseIPNet("10.1.0.0/16")},
+		{CIDR: ovntest.MustParseIPNet("fd00:10:1::/64")},
+	}
+	tooManyRules := make([]ovncnitypes.NetworkFirewallRule, MaxNetworkFirewallRules+1)
+	for i := range tooManyRules {
+		tooManyRules[i] = ovncnitypes.NetworkFirewallRule{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "10.2.0.0/16"}
+	}
+	tests := []struct {
+		desc             string
+		firewall         *ovncnitypes.NetworkFirewall
+		subnets          []config.CIDRNetworkEntry
+		expectedFirewall *NetworkFirewall
+		expectError      bool
+	}{
+		{
+			desc:    "no firewall",
+			subnets: dualStackSubnets,
+		},
+		{
+			desc:     "firewall without rules",
+			firewall: &ovncnitypes.NetworkFirewall{},
+			subnets:  dualStackSubnets,
+		},
+		{
+			desc: "dual stack rules",
+			firewall: &ovncnitypes.NetworkFirewall{
+				Ingress: []ovncnitypes.NetworkFirewallRule{
+					{Action: ovncnitypes.NetworkFirewallActionAllow, CIDR: "10.2.1.0/24"},
+					{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: " 10.2.3.4/16"},
+				},
+				Egress: []ovncnitypes.NetworkFirewallRule{
+					{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "fd00:10:2::/48"},
+				},
+			},
+			subnets: dualStackSubnets,
+			expectedFirewall: &NetworkFirewall{
+				Ingress: []NetworkFirewallRule{
+					{Allow: true, CIDR: ovntest.MustParseIPNet("10.2.1.0/24")},
+					{Allow: false, CIDR: ovntest.MustParseIPNet("10.2.0.0/16")},
+				},
+				Egress: []NetworkFirewallRule{
+					{Allow: false, CIDR: ovntest.MustParseIPNet("fd00:10:2::/48")},
+				},
+			},
+		},
+		{
+			desc: "network without subnets",
+			firewall: &ovncnitypes.NetworkFirewall{
+				Egress: []ovncnitypes.NetworkFirewallRule{{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "10.2.0.0/16"}},
+			},
+			expectError: true,
+		},
+		{
+			desc: "invalid action",
+			firewall: &ovncnitypes.NetworkFirewall{
+				Ingress: []ovncnitypes.NetworkFirewallRule{{Action: "Reject", CIDR: "10.2.0.0/16"}},
+			},
+			subnets:     dualStackSubnets,
+			expectError: true,
+		},
+		{
+			desc: "invalid CIDR",
+			firewall: &ovncnitypes.NetworkFirewall{
+				Ingress: []ovncnitypes.NetworkFirewallRule{{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "10.2.0.0"}},
+			},
+			subnets:     dualStackSubnets,
+			expectError: true,
+		},
+		{
+			desc: "CIDR of an IP family not in the network",
+			firewall: &ovncnitypes.NetworkFirewall{
+				Egress: []ovncnitypes.NetworkFirewallRule{{Action: ovncnitypes.NetworkFirewallActionDeny, CIDR: "fd00:10:2::/48"}},
+			},
+			subnets:     dualStackSubnets[:1],
+			expectError: true,
+		},
+		{
+			desc:        "too many rules",
+			firewall:    &ovncnitypes.NetworkFirewall{Ingress: tooManyRules},
+			subnets:     dualStackSubnets,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			firewall, err := parseNetworkFirewall(tc.firewall, tc.subnets)
+			if tc.expectError {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(firewall).To(gomega.Equal(tc.expectedFirewall))
+		})
+	}
+}
+
+func TestNetInfoHostSubnetAllocation(t *testing.T) {
+	tests := []struct {
+		desc               string
+		allocation         string
+		expectedAllocation string
+		expectError        bool
+	}{
+		{
+			desc:               "sequential by default",
+			expectedAllocation: config.HostSubnetAllocationSequential,
+		},
+		{
+			desc:               "randomized",
+			allocation:         config.HostSubnetAllocationRandomized,
+			expectedAllocation: config.HostSubnetAllocationRandomized,
+		},
+		{
+			desc:        "deterministic is only supported on the default network",
+			allocation:  config.HostSubnetAllocationDeterministic,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			netInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+				NetConf:              cnitypes.NetConf{Name: "l3-network"},
+				Topology:             types.Layer3Topology,
+				Subnets:              "192.168.0.0/16/24",
+				HostSubnetAllocation: tc.allocation,
+			})
+			if tc.expectError {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(netInfo.HostSubnetAllocation()).To(gomega.Equal(tc.expectedAllocation))
+		})
+	}
+}
+
+func TestNetInfoNodeIPChunkSize(t *testing.T) {
+	tests := []struct {
+		desc                      string
+		subnets                   string
+		chunkSize                 int
+		expectedHostSubnetLengths []int
+		expectError               bool
+	}{
+		{
+			desc:                      "no node IP chunks by default",
+			subnets:                   "192.168.0.0/16,fd00:192:168::/64",
+			expectedHostSubnetLengths: []int{0, 0},
+		},
+		{
+			desc:                      "node IP chunks of each IP family",
+			subnets:                   "192.168.0.0/16,fd00:192:168::/64",
+			chunkSize:                 64,
+			expectedHostSubnetLengths: []int{26, 122},
+		},
+		{
+			desc:        "chunk size not a power of two",
+			subnets:     "192.168.0.0/16",
+			chunkSize:   100,
+			expectError: true,
+		},
+		{
+			desc:        "chunk size not smaller than the subnet",
+			subnets:     "192.168.0.0/24",
+			chunkSize:   256,
+			expectError: true,
+		},
+		{
+			desc:        "node IP chunks without subnets",
+			chunkSize:   64,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			netInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+				NetConf:         cnitypes.NetConf{Name: "l2-network"},
+				Topology:        types.Layer2Topology,
+				Subnets:         tc.subnets,
+				NodeIPChunkSize: tc.chunkSize,
+			})
+			if tc.expectError {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(netInfo.NodeIPChunkSize()).To(gomega.Equal(tc.chunkSize))
+			hostSubnetLengths := []int{}
+			for _, subnet := range netInfo.Subnets() {
+				hostSubnetLengths = append(hostSubnetLengths, subnet.HostSubnetLength)
+			}
+			g.Expect(hostSubnetLengths).To(gomega.Equal(tc.expectedHostSubnetLengths))
+		})
+	}
+}
+
+func TestNetInfoInternal(t *testing.T) {
+	g := gomega.NewWithT(t)
+	for _, topology := range []string{types.Layer3Topology, types.Layer2Topology} {
+		netInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "internal-network"},
+			Topology: topology,
+			Subnets:  "192.168.0.0/16",
+			Internal: true,
+		})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(netInfo.IsInternal()).To(gomega.BeTrue())
+	}
+
+	// a localnet network is connected outside the cluster
+	_, err := NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "localnet-network"},
+		Topology: types.LocalnetTopology,
+		Internal: true,
+	})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the pods can't have a default route through an internal network
+	netInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "internal-network"},
+		Topology: types.Layer2Topology,
+		Subnets:  "192.168.0.0/16",
+		Internal: true,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	pod := &kapi.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"}}
+	podAnnotation := &PodAnnotation{IPs: []*net.IPNet{ovntest.MustParseIPNet("192.168.0.5/16")}}
+	err = AddRoutesGatewayIP(netInfo, pod, podAnnotation, &nadv1.NetworkSelectionElement{Name: "nad"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	err = AddRoutesGatewayIP(netInfo, pod, podAnnotation, &nadv1.NetworkSelectionElement{
+		Name:           "nad",
+		GatewayRequest: []net.IP{net.ParseIP("192.168.0.1")},
+	})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestNetInfoRole(t *testing.T) {
+	g := gomega.NewWithT(t)
+	for _, topology := range []string{types.Layer3Topology, types.Layer2Topology} {
+		netInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "secondary-network"},
+			Topology: topology,
+			Subnets:  "192.168.0.0/16",
+		})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(netInfo.IsPrimaryNetwork()).To(gomega.BeFalse())
+
+		netInfo, err = NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "primary-network"},
+			Topology: topology,
+			Subnets:  "192.168.0.0/16",
+			Role:     types.NetworkRolePrimary,
+		})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(netInfo.IsPrimaryNetwork()).To(gomega.BeTrue())
+
+		// a primary network needs subnets and to be connected outside the cluster
+		_, err = NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "primary-network"},
+			Topology: topology,
+			Role:     types.NetworkRolePrimary,
+		})
+		g.Expect(err).To(gomega.HaveOccurred())
+		_, err = NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "primary-network"},
+			Topology: topology,
+			Subnets:  "192.168.0.0/16",
+			Role:     types.NetworkRolePrimary,
+			Internal: true,
+		})
+		g.Expect(err).To(gomega.HaveOccurred())
+
+		_, err = NewNetInfo(&ovncnitypes.NetConf{
+			NetConf:  cnitypes.NetConf{Name: "secondary-network"},
+			Topology: topology,
+			Subnets:  "192.168.0.0/16",
+			Role:     "default",
+		})
+		g.Expect(err).To(gomega.HaveOccurred())
+	}
+
+	_, err := NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "localnet-network"},
+		Topology: types.LocalnetTopology,
+		Subnets:  "192.168.0.0/16",
+		Role:     types.NetworkRolePrimary,
+	})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the pods can get their default route through the gateway of a primary
+	// layer2 network, which is excluded from their IPs
+	netInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "primary-network"},
+		Topology: types.Layer2Topology,
+		Subnets:  "192.168.0.0/16",
+		Role:     types.NetworkRolePrimary,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(netInfo.ExcludeSubnets()).To(gomega.ContainElement(ovntest.MustParseIPNet("192.168.0.1/32")))
+	pod := &kapi.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "pod",
+		Namespace:   "ns",
+		Annotations: map[string]string{PodNetworkRoutingAnnotation: `{"defaultRoute":"ns/nad"}`},
+	}}
+	podAnnotation := &PodAnnotation{IPs: []*net.IPNet{ovntest.MustParseIPNet("192.168.0.5/16")}}
+	err = AddRoutesGatewayIP(netInfo, pod, podAnnotation, &nadv1.NetworkSelectionElement{Name: "nad", Namespace: "ns"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(podAnnotation.Gateways).To(gomega.HaveLen(1))
+	g.Expect(podAnnotation.Gateways[0].Equal(ovntest.MustParseIP("192.168.0.1"))).To(gomega.BeTrue())
+}
+
+func TestParseLocalnetPassthrough(t *testing.T) {
+	ipv4Subnets := []config.CIDRNetworkEntry{{CIDR: ovntest.MustParseIPNet("10.1.0.0/16")}}
+	ipv6Subnets := []config.CIDRNetworkEntry{{CIDR: ovntest.MustParseIPNet("fd00:10:1::/64")}}
+	tests := []struct {
+		desc                string
+		passthrough         *ovncnitypes.LocalnetPassthrough
+		subnets             []config.CIDRNetworkEntry
+		expectedPassthrough *LocalnetPassthrough
+		expectError         bool
+	}{
+		{
+			desc:    "no passthrough",
+			subnets: ipv4Subnets,
+		},
+		{
+			desc:        "passthrough without actions",
+			passthrough: &ovncnitypes.LocalnetPassthrough{},
+			subnets:     ipv4Subnets,
+		},
+		{
+			desc: "allowed link-local and LLDP",
+			passthrough: &ovncnitypes.LocalnetPassthrough{
+				LinkLocal: ovncnitypes.PassthroughActionAllow,
+				LLDP:      ovncnitypes.PassthroughActionAllow,
+			},
+			subnets:             ipv4Subnets,
+			expectedPassthrough: &LocalnetPassthrough{LinkLocal: PassthroughAllow, LLDP: PassthroughAllow},
+		},
+		{
+			desc:                "denied ARP on an IPv6 network",
+			passthrough:         &ovncnitypes.LocalnetPassthrough{ARP: ovncnitypes.PassthroughActionDeny},
+			subnets:             ipv6Subnets,
+			expectedPassthrough: &LocalnetPassthrough{ARP: PassthroughDeny},
+		},
+		{
+			desc:        "denied ARP on an IPv4 network",
+			passthrough: &ovncnitypes.LocalnetPassthrough{ARP: ovncnitypes.PassthroughActionDeny},
+			subnets:     ipv4Subnets,
+			expectError: true,
+		},
+		{
+			desc:        "denied link-local traffic on a link-local network",
+			passthrough: &ovncnitypes.LocalnetPassthrough{LinkLocal: ovncnitypes.PassthroughActionDeny},
+			subnets:     []config.CIDRNetworkEntry{{CIDR: ovntest.MustParseIPNet("169.254.10.0/24")}},
+			expectError: true,
+		},
+		{
+			desc:        "invalid action",
+			passthrough: &ovncnitypes.LocalnetPassthrough{LLDP: "Forward"},
+			subnets:     ipv4Subnets,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			passthrough, err := parseLocalnetPassthrough(tc.passthrough, tc.subnets)
+			if tc.expectError {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(passthrough).To(gomega.Equal(tc.expectedPassthrough))
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	kapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
 )
 
 const (
@@ -21,8 +25,84 @@ const (
 	ExternalGatewayPodIPsAnnotation = "k8s.ovn.org/external-gw-pod-ips"
 	// Annotation for enabling ACL logging to controller's log file
 	AclLoggingAnnotation = "k8s.ovn.org/acl-logging"
+	// Annotation routing the egress traffic of the namespace through a
+	// localnet secondary network
+	ProviderNetworkEgressAnnotation = "k8s.ovn.org/provider-network-egress"
+	// Annotation limiting the number of egress IPs assigned to the EgressIPs
+	// selecting the namespace
+	EgressIPQuotaAnnotation = "k8s.ovn.org/egressip-quota"
 )
 
+// ParseEgressIPQuotaAnnotation returns the maximum number of egress IPs
+// assigned to the EgressIPs selecting the namespace, and false if the
+// namespace has no egress IP quota.
+func ParseEgressIPQuotaAnnotation(namespace *kapi.Namespace) (int, bool, error) {
+	annotation, ok := namespace.Annotations[EgressIPQuotaAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	quota, err := strconv.Atoi(annotation)
+	if err != nil || quota < 0 {
+		return 0, false, fmt.Errorf("invalid egress IP quota annotation %q of namespace %s, expected a non-negative integer",
+			annotation, namespace.Name)
+	}
+	return quota, true, nil
+}
+
+// ProviderNetworkEgress is the egress of the pods of a namespace through a
+// provider network: the traffic of the pods leaving the cluster is routed by
+// the gateway router of the egress node to the gateway of the provider
+// network, SNATed to an IP of the provider network.
+type ProviderNetworkEgress struct {
+	// Network is the name of the localnet secondary network
+	Network string
+	// Node is the name of the egress node
+	Node string
+	// SNATIP is the IP the traffic is SNATed to, with the prefix length of
+	// the subnet of the provider network
+	SNATIP *net.IPNet
+	// Gateway is the next hop of the traffic in the provider network
+	Gateway net.IP
+}
+
+type providerNetworkEgressAnnotation struct {
+	Network string `json:"network"`
+	Node    string `json:"node"`
+	SNATIP  string `json:"snatIP"`
+	Gateway string `json:"gateway"`
+}
+
+// ParseProviderNetworkEgressAnnotation parses the provider network egress
+// annotation of a namespace, like
+// {"network":"tenant-blue","node":"node1","snatIP":"192.168.10.5/24","gateway":"192.168.10.1"}
+func ParseProviderNetworkEgressAnnotation(annotation string) (*ProviderNetworkEgress, error) {
+	var a providerNetworkEgressAnnotation
+	if err := json.Unmarshal([]byte(annotation), &a); err != nil {
+		return nil, fmt.Errorf("could not parse provider network egress annotation %q: %v", annotation, err)
+	}
+	if a.Network == "" || a.Node == "" {
+		return nil, fmt.Errorf("provider network egress annotation %q must set the network and the node", annotation)
+	}
+	snatIP, subnet, err := net.ParseCIDR(a.SNATIP)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SNAT IP in provider network egress annotation %q: %v", annotation, err)
+	}
+	gateway := net.ParseIP(a.Gateway)
+	if gateway == nil {
+		return nil, fmt.Errorf("invalid gateway in provider network egress annotation %q", annotation)
+	}
+	if utilnet.IsIPv6(snatIP) != utilnet.IsIPv6(gateway) || !subnet.Contains(gateway) || gateway.Equal(snatIP) {
+		return nil, fmt.Errorf("the gateway of provider network egress annotation %q is not another IP of the subnet of its SNAT IP",
+			annotation)
+	}
+	return &ProviderNetworkEgress{
+		Network: a.Network,
+		Node:    a.Node,
+		SNATIP:  &net.IPNet{IP: snatIP, Mask: subnet.Mask},
+		Gateway: gateway,
+	}, nil
+}
+
 func UpdateExternalGatewayPodIPsAnnotation(k kube.Interface, namespace string, exgwIPs []string) error {
 	exgwPodAnnotation := strings.Join(exgwIPs, ",")
 	err := k.SetAnnotationsOnNamespace(namespace, map[string]interface{}{ExternalGatewayPodIPsAnnotation: exgwPodAnnotation})
@@ -0,0 +1,130 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+)
+
+func TestParseProviderNetworkEgressAnnotation(t *testing.T) {
+	tests := []struct {
+		desc        string
+		annotation  string
+		expected    *ProviderNetworkEgress
+		expectedErr bool
+	}{
+		{
+			desc:       "parses an IPv4 provider network egress",
+			annotation: `{"network":"tenant-blue","node":"node1","snatIP":"192.168.10.5/24","gateway":"192.168.10.1"}`,
+			expected: &ProviderNetworkEgress{
+				Network: "tenant-blue",
+				Node:    "node1",
+				SNATIP:  ovntest.MustParseIPNet("192.168.10.5/24"),
+				Gateway: net.ParseIP("192.168.10.1"),
+			},
+		},
+		{
+			desc:       "parses an IPv6 provider network egress",
+			annotation: `{"network":"tenant-blue","node":"node1","snatIP":"fd00:10::5/64","gateway":"fd00:10::1"}`,
+			expected: &ProviderNetworkEgress{
+				Network: "tenant-blue",
+				Node:    "node1",
+				SNATIP:  ovntest.MustParseIPNet("fd00:10::5/64"),
+				Gateway: net.ParseIP("fd00:10::1"),
+			},
+		},
+		{
+			desc:        "invalid JSON",
+			annotation:  `tenant-blue`,
+			expectedErr: true,
+		},
+		{
+			desc:        "missing node",
+			annotation:  `{"network":"tenant-blue","snatIP":"192.168.10.5/24","gateway":"192.168.10.1"}`,
+			expectedErr: true,
+		},
+		{
+			desc:        "SNAT IP without prefix length",
+			annotation:  `{"network":"tenant-blue","node":"node1","snatIP":"192.168.10.5","gateway":"192.168.10.1"}`,
+			expectedErr: true,
+		},
+		{
+			desc:        "gateway out of the subnet of the SNAT IP",
+			annotation:  `{"network":"tenant-blue","node":"node1","snatIP":"192.168.10.5/24","gateway":"192.168.11.1"}`,
+			expectedErr: true,
+		},
+		{
+			desc:        "gateway of another IP family",
+			annotation:  `{"network":"tenant-blue","node":"node1","snatIP":"192.168.10.5/24","gateway":"fd00:10::1"}`,
+			expectedErr: true,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			pne, err := ParseProviderNetworkEgressAnnotation(tc.annotation)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected.Network, pne.Network)
+			assert.Equal(t, tc.expected.Node, pne.Node)
+			assert.Equal(t, tc.expected.SNATIP.String(), pne.SNATIP.String())
+			assert.True(t, tc.expected.Gateway.Equal(pne.Gateway))
+		})
+	}
+}
+
+func TestParseEgressIPQuotaAnnotation(t *testing.T) {
+	tests := []struct {
+		desc        string
+		annotations map[string]string
+		expected    int
+		expectedSet bool
+		expectedErr bool
+	}{
+		{
+			desc: "no quota",
+		},
+		{
+			desc:        "parses a quota",
+			annotations: map[string]string{EgressIPQuotaAnnotation: "2"},
+			expected:    2,
+			expectedSet: true,
+		},
+		{
+			desc:        "parses a zero quota",
+			annotations: map[string]string{EgressIPQuotaAnnotation: "0"},
+			expectedSet: true,
+		},
+		{
+			desc:        "negative quota",
+			annotations: map[string]string{EgressIPQuotaAnnotation: "-1"},
+			expectedErr: true,
+		},
+		{
+			desc:        "not a number",
+			annotations: map[string]string{EgressIPQuotaAnnotation: "two"},
+			expectedErr: true,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			namespace := &kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Annotations: tc.annotations}}
+			quota, set, err := ParseEgressIPQuotaAnnotation(namespace)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, quota)
+			assert.Equal(t, tc.expectedSet, set)
+		})
+	}
+}
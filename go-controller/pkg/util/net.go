@@ -107,6 +107,14 @@ func IPAddrToHWAddr(ip net.IP) net.HardwareAddr {
 	return net.HardwareAddr{0x0A, 0x58, hash[0], hash[1], hash[2], hash[3]}
 }
 
+// UDNGatewayRouterHWAddr returns the MAC address of the external port of the
+// gateway routers of the primary user defined network with the given network
+// ID (0A:5A:00:00:HH:LL), so that the shared gateway bridge can tell it apart
+// from the MAC address of the gateway routers of the default network.
+func UDNGatewayRouterHWAddr(networkID int) net.HardwareAddr {
+	return net.HardwareAddr{0x0A, 0x5A, 0x00, 0x00, byte(networkID >> 8), byte(networkID)}
+}
+
 // HWAddrToIPv6LLA generates the IPv6 link local address from the given hwaddr,
 // with prefix 'fe80:/64'.
 func HWAddrToIPv6LLA(hwaddr net.HardwareAddr) net.IP {
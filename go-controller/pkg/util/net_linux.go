@@ -13,7 +13,9 @@ import (
 
 	"github.com/j-keck/arping"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/safchain/ethtool"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/net/ipv6"
 	"golang.org/x/sys/unix"
 
 	kapi "k8s.io/api/core/v1"
@@ -47,6 +49,7 @@ type NetLinkOps interface {
 	NeighDel(neigh *netlink.Neigh) error
 	NeighList(linkIndex, family int) ([]netlink.Neigh, error)
 	ConntrackDeleteFilter(table netlink.ConntrackTableType, family netlink.InetFamily, filter netlink.CustomConntrackFilter) (uint, error)
+	ConntrackTableList(table netlink.ConntrackTableType, family netlink.InetFamily) ([]*netlink.ConntrackFlow, error)
 }
 
 type defaultNetLinkOps struct {
@@ -169,6 +172,10 @@ func (defaultNetLinkOps) ConntrackDeleteFilter(table netlink.ConntrackTableType,
 	return netlink.ConntrackDeleteFilter(table, family, filter)
 }
 
+func (defaultNetLinkOps) ConntrackTableList(table netlink.ConntrackTableType, family netlink.InetFamily) ([]*netlink.ConntrackFlow, error) {
+	return netlink.ConntrackTableList(table, family)
+}
+
 func getFamily(ip net.IP) int {
 	if utilnet.IsIPv6(ip) {
 		return netlink.FAMILY_V6
@@ -368,6 +375,289 @@ func SetARPTimeout() {
 	arping.SetTimeout(50 * time.Millisecond) // hard-coded for now
 }
 
+// UnsolicitedNeighborAdvertisementOverIfaceByName sends an unsolicited
+// neighbor advertisement of the IPv6 address ip to all the nodes attached to
+// the interface, so that they update their neighbor caches in case the IP was
+// previously active on another node
+func UnsolicitedNeighborAdvertisementOverIfaceByName(ip net.IP, ifaceName string) error {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return fmt.Errorf("failed to get interface %s: %v", ifaceName, err)
+	}
+	conn, err := net.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return fmt.Errorf("failed to open ICMPv6 socket: %v", err)
+	}
+	pc := ipv6.NewPacketConn(conn)
+	defer pc.Close()
+	// neighbor discovery messages with another hop limit are discarded
+	if err = pc.SetMulticastHopLimit(255); err != nil {
+		return fmt.Errorf("failed to set the hop limit of the ICMPv6 socket: %v", err)
+	}
+	if err = pc.SetMulticastInterface(iface); err != nil {
+		return fmt.Errorf("failed to set the interface of the ICMPv6 socket: %v", err)
+	}
+	msg := buildUnsolicitedNeighborAdvertisement(ip, iface.HardwareAddr)
+	if _, err = pc.WriteTo(msg, nil, &net.IPAddr{IP: net.IPv6linklocalallnodes, Zone: ifaceName}); err != nil {
+		return fmt.Errorf("failed to send neighbor advertisement: %v", err)
+	}
+	return nil
+}
+
+// buildUnsolicitedNeighborAdvertisement builds the ICMPv6 neighbor
+// advertisement of ip with the override flag and the target link-layer
+// address option set, as described in RFC 4861 section 4.4. The checksum is
+// left to the kernel.
+func buildUnsolicitedNeighborAdvertisement(ip net.IP, mac net.HardwareAddr) []byte {
+	msg := make([]byte, 32)
+	msg[0] = byte(ipv6.ICMPTypeNeighborAdvertisement)
+	msg[4] = 0x20 // override flag
+	copy(msg[8:24], ip.To16())
+	msg[24] = 2 // target link-layer address option
+	msg[25] = 1 // option length in units of 8 octets
+	copy(msg[26:32], mac)
+	return msg
+}
+
+// BuildAnnouncementFrame builds the ethernet frame of the GARP (IPv4) or of
+// the unsolicited neighbor advertisement (IPv6) announcing that ip is at mac,
+// for it to be injected in a bridge on behalf of a host that does not
+// announce it itself
+func BuildAnnouncementFrame(ip net.IP, mac net.HardwareAddr) []byte {
+	if ip.To4() != nil {
+		frame := buildARPProbe(ip, mac)
+		copy(frame[28:32], ip.To4())
+		return frame
+	}
+	msg := buildUnsolicitedNeighborAdvertisement(ip, mac)
+	frame := make([]byte, 14+40+len(msg))
+	// all nodes multicast MAC
+	copy(frame[0:6], net.HardwareAddr{0x33, 0x33, 0, 0, 0, 1})
+	copy(frame[6:12], mac)
+	frame[12], frame[13] = 0x86, 0xdd // IPv6 ethertype
+	header := frame[14:54]
+	header[0] = 0x60 // version
+	header[4], header[5] = byte(len(msg)>>8), byte(len(msg))
+	header[6] = 58  // ICMPv6
+	header[7] = 255 // hop limit of the neighbor discovery messages
+	copy(header[8:24], ip.To16())
+	copy(header[24:40], net.IPv6linklocalallnodes)
+	copy(frame[54:], msg)
+	checksum := icmpv6Checksum(header[8:24], header[24:40], frame[54:])
+	frame[56], frame[57] = byte(checksum>>8), byte(checksum)
+	return frame
+}
+
+// icmpv6Checksum returns the checksum of the ICMPv6 message, computed over
+// the IPv6 pseudo-header as described in RFC 4443 section 2.3
+func icmpv6Checksum(src, dst, msg []byte) uint16 {
+	data := make([]byte, 40, 40+len(msg))
+	copy(data[0:16], src)
+	copy(data[16:32], dst)
+	data[34], data[35] = byte(len(msg)>>8), byte(len(msg))
+	data[39] = 58
+	data = append(data, msg...)
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// ProbeAddressOverIfaceByName checks whether another host attached to the
+// interface already uses ip, sending an ARP probe (IPv4, RFC 5227) or a
+// neighbor solicitation (IPv6, RFC 4861) and waiting up to timeout for a
+// reply. When the IP is in use, it returns true and the hardware address of
+// the host using it.
+func ProbeAddressOverIfaceByName(ip net.IP, ifaceName string, timeout time.Duration) (bool, string, error) {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get interface %s: %v", ifaceName, err)
+	}
+	if ip.To4() != nil {
+		return arpProbe(ip.To4(), iface, timeout)
+	}
+	return neighborSolicitationProbe(ip, iface, timeout)
+}
+
+// arpProbe sends an ARP probe of ip over the interface and waits for an ARP
+// packet of another host claiming it
+func arpProbe(ip net.IP, iface *net.Interface, timeout time.Duration) (bool, string, error) {
+	protocol := htons(unix.ETH_P_ARP)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(protocol))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to open ARP socket: %v", err)
+	}
+	defer unix.Close(fd)
+	if err = unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: protocol, Ifindex: iface.Index}); err != nil {
+		return false, "", fmt.Errorf("failed to bind the ARP socket to interface %s: %v", iface.Name, err)
+	}
+	broadcast := &unix.SockaddrLinklayer{Protocol: protocol, Ifindex: iface.Index, Halen: 6}
+	copy(broadcast.Addr[:], ethernetBroadcast)
+	if err = unix.Sendto(fd, buildARPProbe(ip, iface.HardwareAddr), 0, broadcast); err != nil {
+		return false, "", fmt.Errorf("failed to send ARP probe: %v", err)
+	}
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 1500)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, "", nil
+		}
+		tv := unix.NsecToTimeval(remaining.Nanoseconds())
+		if err = unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+			return false, "", fmt.Errorf("failed to set the timeout of the ARP socket: %v", err)
+		}
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err == unix.EAGAIN || err == unix.EWOULDBLOCK {
+			return false, "", nil
+		}
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("failed to receive ARP packets: %v", err)
+		}
+		if mac := parseARPConflict(buf[:n], ip, iface.HardwareAddr); mac != nil {
+			return true, mac.String(), nil
+		}
+	}
+}
+
+var ethernetBroadcast = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+func htons(i uint16) uint16 {
+	return i<<8 | i>>8
+}
+
+// buildARPProbe builds the ethernet frame of the ARP probe of ip, an ARP
+// request with a zero sender IP as described in RFC 5227 section 2.1.1
+func buildARPProbe(ip net.IP, mac net.HardwareAddr) []byte {
+	frame := make([]byte, 42)
+	copy(frame[0:6], ethernetBroadcast)
+	copy(frame[6:12], mac)
+	frame[12], frame[13] = 0x08, 0x06 // ARP ethertype
+	frame[15] = 1                     // ethernet hardware type
+	frame[16], frame[17] = 0x08, 0x00 // IPv4 protocol type
+	frame[18] = 6                     // hardware address length
+	frame[19] = 4                     // protocol address length
+	frame[21] = 1                     // request
+	copy(frame[22:28], mac)
+	copy(frame[38:42], ip.To4())
+	return frame
+}
+
+// parseARPConflict returns the sender hardware address of the ARP packet of
+// the frame if it was sent by another host using ip, nil otherwise
+func parseARPConflict(frame []byte, ip net.IP, mac net.HardwareAddr) net.HardwareAddr {
+	if len(frame) < 42 || frame[12] != 0x08 || frame[13] != 0x06 {
+		return nil
+	}
+	sender := net.HardwareAddr(frame[22:28])
+	if !net.IP(frame[28:32]).Equal(ip) || bytes.Equal(sender, mac) {
+		return nil
+	}
+	return append(net.HardwareAddr{}, sender...)
+}
+
+// neighborSolicitationProbe sends a neighbor solicitation of ip over the
+// interface and waits for the neighbor advertisement of another host
+func neighborSolicitationProbe(ip net.IP, iface *net.Interface, timeout time.Duration) (bool, string, error) {
+	conn, err := net.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return false, "", fmt.Errorf("failed to open ICMPv6 socket: %v", err)
+	}
+	pc := ipv6.NewPacketConn(conn)
+	defer pc.Close()
+	// neighbor discovery messages with another hop limit are discarded
+	if err = pc.SetMulticastHopLimit(255); err != nil {
+		return false, "", fmt.Errorf("failed to set the hop limit of the ICMPv6 socket: %v", err)
+	}
+	if err = pc.SetMulticastInterface(iface); err != nil {
+		return false, "", fmt.Errorf("failed to set the interface of the ICMPv6 socket: %v", err)
+	}
+	// the local host must not answer its own solicitation
+	if err = pc.SetMulticastLoopback(false); err != nil {
+		return false, "", fmt.Errorf("failed to disable the loopback of the ICMPv6 socket: %v", err)
+	}
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeNeighborAdvertisement)
+	if err = pc.SetICMPFilter(&filter); err != nil {
+		return false, "", fmt.Errorf("failed to filter the ICMPv6 socket: %v", err)
+	}
+	msg := buildNeighborSolicitation(ip, iface.HardwareAddr)
+	if _, err = pc.WriteTo(msg, nil, &net.IPAddr{IP: solicitedNodeMulticastAddress(ip), Zone: iface.Name}); err != nil {
+		return false, "", fmt.Errorf("failed to send neighbor solicitation: %v", err)
+	}
+	if err = pc.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return false, "", fmt.Errorf("failed to set the timeout of the ICMPv6 socket: %v", err)
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, _, src, err := pc.ReadFrom(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return false, "", nil
+			}
+			return false, "", fmt.Errorf("failed to receive neighbor advertisements: %v", err)
+		}
+		if inUse, owner := parseNeighborAdvertisementConflict(buf[:n], ip, iface.HardwareAddr); inUse {
+			if owner == "" && src != nil {
+				owner = src.String()
+			}
+			return true, owner, nil
+		}
+	}
+}
+
+// solicitedNodeMulticastAddress returns the solicited-node multicast address
+// of ip, as described in RFC 4291 section 2.7.1
+func solicitedNodeMulticastAddress(ip net.IP) net.IP {
+	addr := net.ParseIP("ff02::1:ff00:0")
+	copy(addr[13:], ip.To16()[13:])
+	return addr
+}
+
+// buildNeighborSolicitation builds the ICMPv6 neighbor solicitation of ip with
+// the source link-layer address option set, as described in RFC 4861 section
+// 4.3. The checksum is left to the kernel.
+func buildNeighborSolicitation(ip net.IP, mac net.HardwareAddr) []byte {
+	msg := make([]byte, 32)
+	msg[0] = byte(ipv6.ICMPTypeNeighborSolicitation)
+	copy(msg[8:24], ip.To16())
+	msg[24] = 1 // source link-layer address option
+	msg[25] = 1 // option length in units of 8 octets
+	copy(msg[26:32], mac)
+	return msg
+}
+
+// parseNeighborAdvertisementConflict returns whether the ICMPv6 message is
+// the neighbor advertisement of ip by another host, and the target link-layer
+// address it advertises, if any
+func parseNeighborAdvertisementConflict(msg []byte, ip net.IP, mac net.HardwareAddr) (bool, string) {
+	if len(msg) < 24 || msg[0] != byte(ipv6.ICMPTypeNeighborAdvertisement) || !net.IP(msg[8:24]).Equal(ip) {
+		return false, ""
+	}
+	for opts := msg[24:]; len(opts) >= 8 && opts[1] > 0 && len(opts) >= int(opts[1])*8; opts = opts[int(opts[1])*8:] {
+		if opts[0] != 2 {
+			continue
+		}
+		target := net.HardwareAddr(opts[2:8])
+		if bytes.Equal(target, mac) {
+			return false, ""
+		}
+		return true, target.String()
+	}
+	return true, ""
+}
+
 func GetMACAddressFromARP(neighIP net.IP) (net.HardwareAddr, error) {
 	hwAddr, _, err := arping.Ping(neighIP)
 	if err != nil {
@@ -473,7 +763,8 @@ func GetNetworkInterfaceIPs(iface string) ([]*net.IPNet, error) {
 
 	var ips []*net.IPNet
 	for _, addr := range addrs {
-		if addr.IP.IsLinkLocalUnicast() || IsAddressReservedForInternalUse(addr.IP) || IsAddressAddedByKeepAlived(addr) {
+		if addr.IP.IsLinkLocalUnicast() || IsAddressReservedForInternalUse(addr.IP) || IsAddressAddedByKeepAlived(addr) ||
+			addr.Label == GetLoadBalancerAddressLabel(iface) {
 			continue
 		}
 		// Ignore addresses marked as secondary or deprecated since they may
@@ -504,6 +795,12 @@ func IsAddressReservedForInternalUse(addr net.IP) bool {
 	return subnet.Contains(addr)
 }
 
+// GetLoadBalancerAddressLabel returns the label of the IP addresses of the LoadBalancer services the built-in load
+// balancer provider binds to the interface 'linkName'. These IPs are not owned by the node.
+func GetLoadBalancerAddressLabel(linkName string) string {
+	return fmt.Sprintf("%slb", linkName)
+}
+
 // IsAddressAddedByKeepAlived returns true if the input interface address obtained
 // through netlink has a label that ends with ":vip", which is how keepalived
 // marks the IP addresses it adds (https://github.com/openshift/machine-config-operator/pull/3683)
@@ -576,3 +873,33 @@ func filterRouteByDstAndGw(link netlink.Link, subnet *net.IPNet, gw net.IP) (*ne
 		},
 		netlink.RT_FILTER_DST | netlink.RT_FILTER_OIF | netlink.RT_FILTER_GW
 }
+
+// DisableTxChecksumOffload disables the transmit checksum offloads of the
+// interface. The OVS userspace datapath doesn't complete the partial checksums
+// of the packets it receives from the tap and veth interfaces it is attached
+// to.
+func DisableTxChecksumOffload(ifname string) error {
+	e, err := ethtool.NewEthtool()
+	if err != nil {
+		return fmt.Errorf("failed to initialize ethtool: %v", err)
+	}
+	defer e.Close()
+
+	names, err := e.FeatureNames(ifname)
+	if err != nil {
+		return fmt.Errorf("failed to get the features of %s: %v", ifname, err)
+	}
+	features := map[string]bool{}
+	for name := range names {
+		if strings.HasPrefix(name, "tx-checksum") {
+			features[name] = false
+		}
+	}
+	if len(features) == 0 {
+		return nil
+	}
+	if err := e.Change(ifname, features); err != nil {
+		return fmt.Errorf("could not disable the transmit checksum offloads of %s: %v", ifname, err)
+	}
+	return nil
+}
@@ -1138,3 +1138,118 @@ func TestIsAddressReservedForInternalUse(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildUnsolicitedNeighborAdvertisement(t *testing.T) {
+	ip := ovntest.MustParseIP("fd00:10:244::5")
+	mac := ovntest.MustParseMAC("0a:58:0a:f4:00:05")
+	msg := buildUnsolicitedNeighborAdvertisement(ip, mac)
+	assert.Len(t, msg, 32)
+	// type neighbor advertisement, code 0 and checksum left to the kernel
+	assert.Equal(t, []byte{136, 0, 0, 0}, msg[0:4])
+	// only the override flag is set
+	assert.Equal(t, []byte{0x20, 0, 0, 0}, msg[4:8])
+	assert.Equal(t, []byte(ip.To16()), msg[8:24])
+	// target link-layer address option
+	assert.Equal(t, []byte{2, 1}, msg[24:26])
+	assert.Equal(t, []byte(mac), msg[26:32])
+}
+
+func TestBuildAnnouncementFrame(t *testing.T) {
+	mac := ovntest.MustParseMAC("0a:58:0a:f4:00:05")
+
+	ip := ovntest.MustParseIP("10.244.0.5")
+	frame := BuildAnnouncementFrame(ip, mac)
+	assert.Len(t, frame, 42)
+	assert.Equal(t, []byte(ethernetBroadcast), frame[0:6])
+	// ARP request whose sender and target IPs are the announced IP
+	assert.Equal(t, []byte{0x08, 0x06, 0, 1, 0x08, 0x00, 6, 4, 0, 1}, frame[12:22])
+	assert.Equal(t, []byte(mac), frame[22:28])
+	assert.Equal(t, []byte(ip.To4()), frame[28:32])
+	assert.Equal(t, []byte(ip.To4()), frame[38:42])
+
+	ip = ovntest.MustParseIP("fd00:10:244::5")
+	frame = BuildAnnouncementFrame(ip, mac)
+	assert.Len(t, frame, 86)
+	assert.Equal(t, []byte{0x33, 0x33, 0, 0, 0, 1}, frame[0:6])
+	assert.Equal(t, []byte(mac), frame[6:12])
+	assert.Equal(t, []byte{0x86, 0xdd}, frame[12:14])
+	// payload length, ICMPv6 and hop limit
+	assert.Equal(t, []byte{0, 32, 58, 255}, frame[18:22])
+	assert.Equal(t, []byte(ip.To16()), frame[22:38])
+	assert.Equal(t, []byte(net.IPv6linklocalallnodes), frame[38:54])
+	assert.Equal(t, buildUnsolicitedNeighborAdvertisement(ip, mac)[4:], frame[58:])
+	// the checksum of a message with a valid checksum is zero
+	assert.Equal(t, uint16(0), icmpv6Checksum(frame[22:38], frame[38:54], frame[54:]))
+}
+
+func TestBuildARPProbe(t *testing.T) {
+	ip := ovntest.MustParseIP("192.168.126.101")
+	mac := ovntest.MustParseMAC("0a:58:0a:f4:00:05")
+	frame := buildARPProbe(ip, mac)
+	assert.Len(t, frame, 42)
+	assert.Equal(t, []byte(ethernetBroadcast), frame[0:6])
+	assert.Equal(t, []byte(mac), frame[6:12])
+	// ARP request of an IPv4 address over ethernet
+	assert.Equal(t, []byte{0x08, 0x06, 0, 1, 0x08, 0x00, 6, 4, 0, 1}, frame[12:22])
+	assert.Equal(t, []byte(mac), frame[22:28])
+	// the sender IP of a probe is zero
+	assert.Equal(t, []byte{0, 0, 0, 0}, frame[28:32])
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0}, frame[32:38])
+	assert.Equal(t, []byte(ip.To4()), frame[38:42])
+}
+
+func TestParseARPConflict(t *testing.T) {
+	ip := ovntest.MustParseIP("192.168.126.101").To4()
+	mac := ovntest.MustParseMAC("0a:58:0a:f4:00:05")
+	other := ovntest.MustParseMAC("0a:58:0a:f4:00:06")
+	arpFrame := func(sender net.HardwareAddr, senderIP net.IP) []byte {
+		frame := buildARPProbe(ip, sender)
+		frame[21] = 2 // reply
+		copy(frame[28:32], senderIP.To4())
+		return frame
+	}
+	// the reply of another host using the IP
+	assert.Equal(t, other, parseARPConflict(arpFrame(other, ip), ip, mac))
+	// the probe of another host or the own probe
+	assert.Nil(t, parseARPConflict(buildARPProbe(ip, other), ip, mac))
+	assert.Nil(t, parseARPConflict(buildARPProbe(ip, mac), ip, mac))
+	// the reply of a host using another IP
+	assert.Nil(t, parseARPConflict(arpFrame(other, ovntest.MustParseIP("192.168.126.102")), ip, mac))
+	// truncated frame
+	assert.Nil(t, parseARPConflict(arpFrame(other, ip)[:40], ip, mac))
+}
+
+func TestBuildNeighborSolicitation(t *testing.T) {
+	ip := ovntest.MustParseIP("fd00:10:244::5")
+	mac := ovntest.MustParseMAC("0a:58:0a:f4:00:05")
+	msg := buildNeighborSolicitation(ip, mac)
+	assert.Len(t, msg, 32)
+	// type neighbor solicitation, code 0 and checksum left to the kernel
+	assert.Equal(t, []byte{135, 0, 0, 0, 0, 0, 0, 0}, msg[0:8])
+	assert.Equal(t, []byte(ip.To16()), msg[8:24])
+	// source link-layer address option
+	assert.Equal(t, []byte{1, 1}, msg[24:26])
+	assert.Equal(t, []byte(mac), msg[26:32])
+	assert.Equal(t, ovntest.MustParseIP("ff02::1:ff00:5"), solicitedNodeMulticastAddress(ip))
+}
+
+func TestParseNeighborAdvertisementConflict(t *testing.T) {
+	ip := ovntest.MustParseIP("fd00:10:244::5")
+	mac := ovntest.MustParseMAC("0a:58:0a:f4:00:05")
+	other := ovntest.MustParseMAC("0a:58:0a:f4:00:06")
+	inUse, owner := parseNeighborAdvertisementConflict(buildUnsolicitedNeighborAdvertisement(ip, other), ip, mac)
+	assert.True(t, inUse)
+	assert.Equal(t, other.String(), owner)
+	// without target link-layer address option
+	inUse, owner = parseNeighborAdvertisementConflict(buildUnsolicitedNeighborAdvertisement(ip, other)[:24], ip, mac)
+	assert.True(t, inUse)
+	assert.Equal(t, "", owner)
+	// the own advertisement, the advertisement of another IP or another message
+	inUse, _ = parseNeighborAdvertisementConflict(buildUnsolicitedNeighborAdvertisement(ip, mac), ip, mac)
+	assert.False(t, inUse)
+	inUse, _ = parseNeighborAdvertisementConflict(
+		buildUnsolicitedNeighborAdvertisement(ovntest.MustParseIP("fd00:10:244::6"), other), ip, mac)
+	assert.False(t, inUse)
+	inUse, _ = parseNeighborAdvertisementConflict(buildNeighborSolicitation(ip, other), ip, mac)
+	assert.False(t, inUse)
+}
@@ -314,6 +314,31 @@ func TestIPAddrToHWAddr(t *testing.T) {
 	}
 }
 
+func TestUDNGatewayRouterHWAddr(t *testing.T) {
+	tests := []struct {
+		desc      string
+		networkID int
+		outExp    net.HardwareAddr
+	}{
+		{
+			desc:      "network ID of one byte",
+			networkID: 5,
+			outExp:    ovntest.MustParseMAC("0a:5a:00:00:00:05"),
+		},
+		{
+			desc:      "network ID of two bytes",
+			networkID: 4095,
+			outExp:    ovntest.MustParseMAC("0a:5a:00:00:0f:ff"),
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			res := UDNGatewayRouterHWAddr(tc.networkID)
+			assert.Equal(t, tc.outExp, res)
+		})
+	}
+}
+
 func TestJoinIPs(t *testing.T) {
 	tests := []struct {
 		desc         string
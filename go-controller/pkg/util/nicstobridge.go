@@ -12,6 +12,9 @@ import (
 	"github.com/k8snetworkplumbingwg/sriovnet"
 	"github.com/vishvananda/netlink"
 	"k8s.io/klog/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 )
 
 const (
@@ -65,8 +68,9 @@ func GetNicName(brName string) (string, error) {
 					iface, brName, stderr, err)
 
 			}
-			// If system Type we know this is the OVS port is the NIC
-			if stdout == "system" {
+			// If system Type we know this is the OVS port is the NIC, as is
+			// the AF_XDP or DPDK port of the userspace datapath
+			if stdout == "system" || (config.IsUserspaceDatapath() && stdout == config.OvnKubeNode.Datapath) {
 				systemPorts = append(systemPorts, port)
 			}
 		}
@@ -228,14 +232,26 @@ func NicToBridge(iface string) (string, error) {
 	}
 
 	bridge := GetBridgeName(iface)
-	stdout, stderr, err := RunOVSVsctl(
+	args := []string{
 		"--", "--may-exist", "add-br", bridge,
 		"--", "br-set-external-id", bridge, "bridge-id", bridge,
 		"--", "br-set-external-id", bridge, "bridge-uplink", iface,
 		"--", "set", "bridge", bridge, "fail-mode=standalone",
 		fmt.Sprintf("other_config:hwaddr=%s", ifaceLink.Attrs().HardwareAddr),
+	}
+	// the bridge of the userspace datapath must be created with it, its
+	// internal port being re-created on a change of datapath. Only an AF_XDP
+	// uplink is a kernel interface.
+	if config.OvnKubeNode.Datapath == types.NodeDatapathAFXDP {
+		args = append(args, "datapath_type=netdev")
+	}
+	args = append(args,
 		"--", "--may-exist", "add-port", bridge, iface,
 		"--", "set", "port", iface, "other-config:transient=true")
+	if config.OvnKubeNode.Datapath == types.NodeDatapathAFXDP {
+		args = append(args, "--", "set", "interface", iface, "type=afxdp")
+	}
+	stdout, stderr, err := RunOVSVsctl(args...)
 	if err != nil {
 		klog.Errorf("Failed to create OVS bridge, stdout: %q, stderr: %q, error: %v", stdout, stderr, err)
 		return "", err
@@ -338,9 +354,9 @@ func BridgeToNic(bridge string) error {
 		}
 		// stdout has the peer interface, just delete it
 		peer := strings.TrimSpace(stdout)
-		_, stderr, err = RunOVSVsctl("--if-exists", "del-port", "br-int", peer)
+		_, stderr, err = RunOVSVsctl("--if-exists", "del-port", config.OvnKubeNode.IntegrationBridge, peer)
 		if err != nil {
-			klog.Warningf("Failed to delete patch port %q on br-int, "+
+			klog.Warningf("Failed to delete patch port %q on the integration bridge, "+
 				"stderr: %q, error: %v", peer, stderr, err)
 		}
 	}
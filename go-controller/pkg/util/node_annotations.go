@@ -63,6 +63,11 @@ const (
 	// ovnNodeManagementPortMacAddress is the constant string representing the annotation key
 	ovnNodeManagementPortMacAddress = "k8s.ovn.org/node-mgmt-port-mac-address"
 
+	// ovnNodeHealthCheckSubnets is the annotation holding the subnets of the
+	// dedicated health check port of the node (i.e: ["100.66.0.16/28"]). It is
+	// set by cluster manager.
+	ovnNodeHealthCheckSubnets = "k8s.ovn.org/node-health-check-subnets"
+
 	// ovnNodeChassisID is the systemID of the node needed for creating L3 gateway
 	ovnNodeChassisID = "k8s.ovn.org/node-chassis-id"
 
@@ -72,12 +77,28 @@ const (
 	// ovnNodeGRLRPAddr is the CIDR form representation of Gate Router LRP IP address to join switch (i.e: 100.64.0.5/24)
 	ovnNodeGRLRPAddr = "k8s.ovn.org/node-gateway-router-lrp-ifaddr"
 
+	// ovnNodeMasqueradeSubnet is the masquerade subnets the node selected, when
+	// the configured ones collide with its addresses or routes
+	// (i.e: {"ipv4":"169.254.0.0/29","ipv6":"fd69::8/125"}). It is set by ovnkube-node.
+	ovnNodeMasqueradeSubnet = "k8s.ovn.org/node-masquerade-subnet"
+
 	// OvnNodeEgressLabel is a user assigned node label indicating to ovn-kubernetes that the node is to be used for egress IP assignment
 	ovnNodeEgressLabel = "k8s.ovn.org/egress-assignable"
 
 	// ovnNodeHostAddresses is used to track the different host IP addresses on the node
 	ovnNodeHostAddresses = "k8s.ovn.org/host-addresses"
 
+	// ovnNodeEgressNextHopsUnreachable lists the gateway next hops of the node
+	// found unreachable by the probes of ovnkube-node
+	// (i.e: ["172.18.0.1"]). It is set by ovnkube-node.
+	ovnNodeEgressNextHopsUnreachable = "k8s.ovn.org/egress-next-hops-unreachable"
+
+	// ovnNodeEgressIPInterfaces maps the egress IPs assigned to the node to
+	// the interface they are programmed on
+	// (i.e: {"172.18.0.100":"breth0","192.168.100.10":"eth1"}). It is set by
+	// ovnkube-node.
+	ovnNodeEgressIPInterfaces = "k8s.ovn.org/egress-ip-interfaces"
+
 	// egressIPConfigAnnotationKey is used to indicate the cloud subnet and
 	// capacity for each node. It is set by
 	// openshift/cloud-network-config-controller
@@ -87,6 +108,15 @@ const (
 	// ovnkube-node gets the node's zone from the OVN Southbound database.
 	ovnNodeZoneName = "k8s.ovn.org/zone-name"
 
+	// ovnNodeAppliedClusterSubnets lists the cluster subnets of the default
+	// network ovnkube-node of the node applied. It is set by ovnkube-node.
+	ovnNodeAppliedClusterSubnets = "k8s.ovn.org/node-applied-cluster-subnets"
+
+	// ovnControllerAppliedClusterSubnets lists the cluster subnets of the
+	// default network the ovnkube-controller of the zone of the node applied.
+	// It is set by ovnkube-controller.
+	ovnControllerAppliedClusterSubnets = "k8s.ovn.org/controller-applied-cluster-subnets"
+
 	/** HACK BEGIN **/
 	// TODO(tssurya): Remove this annotation a few months from now (when one or two release jump
 	// upgrades are done). This has been added only to minimize disruption for upgrades when
@@ -404,6 +434,55 @@ func ParseNodeManagementPortMACAddress(node *kapi.Node) (net.HardwareAddr, error
 	return net.ParseMAC(macAddress)
 }
 
+// SetNodeHealthCheckSubnets sets the subnets of the dedicated health check
+// port of the node
+func SetNodeHealthCheckSubnets(nodeAnnotator kube.Annotator, subnets []*net.IPNet) error {
+	subnetStrs := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		subnetStrs = append(subnetStrs, subnet.String())
+	}
+	bytes, err := json.Marshal(subnetStrs)
+	if err != nil {
+		return err
+	}
+	return nodeAnnotator.Set(ovnNodeHealthCheckSubnets, string(bytes))
+}
+
+// DeleteNodeHealthCheckSubnets removes the subnets of the dedicated health
+// check port of the node
+func DeleteNodeHealthCheckSubnets(nodeAnnotator kube.Annotator) {
+	nodeAnnotator.Delete(ovnNodeHealthCheckSubnets)
+}
+
+// ParseNodeHealthCheckSubnets returns the subnets of the dedicated health check
+// port of the node
+func ParseNodeHealthCheckSubnets(node *kapi.Node) ([]*net.IPNet, error) {
+	annotation, ok := node.Annotations[ovnNodeHealthCheckSubnets]
+	if !ok {
+		return nil, newAnnotationNotSetError("%s annotation not found for node %q", ovnNodeHealthCheckSubnets, node.Name)
+	}
+	var subnetStrs []string
+	if err := json.Unmarshal([]byte(annotation), &subnetStrs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s annotation %q of node %q: %v", ovnNodeHealthCheckSubnets,
+			annotation, node.Name, err)
+	}
+	subnets := make([]*net.IPNet, 0, len(subnetStrs))
+	for _, subnetStr := range subnetStrs {
+		_, subnet, err := net.ParseCIDR(subnetStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse health check subnet %q of node %q: %v", subnetStr, node.Name, err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// NodeHealthCheckSubnetsAnnotationChanged returns whether the subnets of the
+// dedicated health check port of the node changed
+func NodeHealthCheckSubnetsAnnotationChanged(oldNode, newNode *kapi.Node) bool {
+	return oldNode.Annotations[ovnNodeHealthCheckSubnets] != newNode.Annotations[ovnNodeHealthCheckSubnets]
+}
+
 type primaryIfAddrAnnotation struct {
 	IPv4 string `json:"ipv4,omitempty"`
 	IPv6 string `json:"ipv6,omitempty"`
@@ -600,6 +679,76 @@ func ParseNodeTransitSwitchPortAddrs(node *kapi.Node) ([]*net.IPNet, error) {
 	return parsePrimaryIfAddrAnnotation(node, ovnTransitSwitchPortAddr)
 }
 
+// SetNodeMasqueradeSubnets sets the masquerade subnets the node selected
+func SetNodeMasqueradeSubnets(nodeAnnotator kube.Annotator, v4MasqueradeSubnet, v6MasqueradeSubnet string) error {
+	return nodeAnnotator.Set(ovnNodeMasqueradeSubnet, primaryIfAddrAnnotation{
+		IPv4: v4MasqueradeSubnet,
+		IPv6: v6MasqueradeSubnet,
+	})
+}
+
+// DeleteNodeMasqueradeSubnets removes the masquerade subnets the node selected
+func DeleteNodeMasqueradeSubnets(nodeAnnotator kube.Annotator) {
+	nodeAnnotator.Delete(ovnNodeMasqueradeSubnet)
+}
+
+// ParseNodeMasqueradeSubnets returns the masquerade subnets the node selected
+// stored in the 'ovnNodeMasqueradeSubnet' annotation
+func ParseNodeMasqueradeSubnets(node *kapi.Node) ([]*net.IPNet, error) {
+	subnets, err := parsePrimaryIfAddrAnnotation(node, ovnNodeMasqueradeSubnet)
+	if err != nil {
+		return nil, err
+	}
+	for _, subnet := range subnets {
+		if !subnet.IP.Equal(subnet.IP.Mask(subnet.Mask)) {
+			return nil, fmt.Errorf("invalid masquerade subnet %s of node %q", subnet, node.Name)
+		}
+	}
+	return subnets, nil
+}
+
+// NodeMasqueradeSubnetsAnnotationChanged returns whether the masquerade subnets
+// the node selected changed
+func NodeMasqueradeSubnetsAnnotationChanged(oldNode, newNode *kapi.Node) bool {
+	return oldNode.Annotations[ovnNodeMasqueradeSubnet] != newNode.Annotations[ovnNodeMasqueradeSubnet]
+}
+
+// GetNodeMasqueradeSubnets returns the v4 and v6 masquerade subnets of the
+// node: the ones it selected if any, else the configured ones
+func GetNodeMasqueradeSubnets(node *kapi.Node) (string, string, error) {
+	v4MasqueradeSubnet := config.Gateway.V4MasqueradeSubnet
+	v6MasqueradeSubnet := config.Gateway.V6MasqueradeSubnet
+	subnets, err := ParseNodeMasqueradeSubnets(node)
+	if err != nil {
+		if IsAnnotationNotSetError(err) {
+			return v4MasqueradeSubnet, v6MasqueradeSubnet, nil
+		}
+		return "", "", err
+	}
+	for _, subnet := range subnets {
+		if utilnet.IsIPv6CIDR(subnet) {
+			v6MasqueradeSubnet = subnet.String()
+		} else {
+			v4MasqueradeSubnet = subnet.String()
+		}
+	}
+	return v4MasqueradeSubnet, v6MasqueradeSubnet, nil
+}
+
+// GetNodeMasqueradeIPs returns the masquerade IPs allocated from the
+// masquerade subnets of the node
+func GetNodeMasqueradeIPs(node *kapi.Node) (*config.MasqueradeIPsConfig, error) {
+	v4MasqueradeSubnet, v6MasqueradeSubnet, err := GetNodeMasqueradeSubnets(node)
+	if err != nil {
+		return nil, err
+	}
+	if v4MasqueradeSubnet == config.Gateway.V4MasqueradeSubnet && v6MasqueradeSubnet == config.Gateway.V6MasqueradeSubnet {
+		masqueradeIPs := config.Gateway.MasqueradeIPs
+		return &masqueradeIPs, nil
+	}
+	return config.MasqueradeIPsForSubnets(v4MasqueradeSubnet, v6MasqueradeSubnet)
+}
+
 // ParseCloudEgressIPConfig returns the cloud's information concerning the node's primary network interface
 func ParseCloudEgressIPConfig(node *kapi.Node) (*ParsedNodeEgressIPConfiguration, error) {
 	egressIPConfigAnnotation, ok := node.Annotations[cloudEgressIPConfigAnnotationKey]
@@ -691,6 +840,62 @@ func NodeHostAddressesAnnotationChanged(oldNode, newNode *v1.Node) bool {
 	return oldNode.Annotations[ovnNodeHostAddresses] != newNode.Annotations[ovnNodeHostAddresses]
 }
 
+// SetNodeEgressNextHopsUnreachable sets the gateway next hops of the node
+// found unreachable, or removes the annotation if there are none
+func SetNodeEgressNextHopsUnreachable(nodeAnnotator kube.Annotator, nextHops []string) error {
+	if len(nextHops) == 0 {
+		nodeAnnotator.Delete(ovnNodeEgressNextHopsUnreachable)
+		return nil
+	}
+	return nodeAnnotator.Set(ovnNodeEgressNextHopsUnreachable, nextHops)
+}
+
+// ParseNodeEgressNextHopsUnreachable returns the gateway next hops of the node
+// found unreachable, empty if none is
+func ParseNodeEgressNextHopsUnreachable(node *kapi.Node) ([]string, error) {
+	annotation, ok := node.Annotations[ovnNodeEgressNextHopsUnreachable]
+	if !ok {
+		return nil, nil
+	}
+	var nextHops []string
+	if err := json.Unmarshal([]byte(annotation), &nextHops); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal egress next hops unreachable annotation %s for node %q: %v",
+			annotation, node.Name, err)
+	}
+	return nextHops, nil
+}
+
+// SetNodeEgressIPInterfaces sets the interfaces the egress IPs assigned to
+// the node are programmed on, or removes the annotation if there are none
+func SetNodeEgressIPInterfaces(nodeAnnotator kube.Annotator, interfaces map[string]string) error {
+	if len(interfaces) == 0 {
+		nodeAnnotator.Delete(ovnNodeEgressIPInterfaces)
+		return nil
+	}
+	return nodeAnnotator.Set(ovnNodeEgressIPInterfaces, interfaces)
+}
+
+// ParseNodeEgressIPInterfaces returns the interfaces the egress IPs assigned
+// to the node are programmed on, by egress IP, empty if none is
+func ParseNodeEgressIPInterfaces(node *kapi.Node) (map[string]string, error) {
+	annotation, ok := node.Annotations[ovnNodeEgressIPInterfaces]
+	if !ok {
+		return map[string]string{}, nil
+	}
+	interfaces := map[string]string{}
+	if err := json.Unmarshal([]byte(annotation), &interfaces); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal egress IP interfaces annotation %s for node %q: %v",
+			annotation, node.Name, err)
+	}
+	return interfaces, nil
+}
+
+// NodeEgressIPInterfacesAnnotationChanged returns true if the interfaces the
+// egress IPs assigned to the node are programmed on changed
+func NodeEgressIPInterfacesAnnotationChanged(oldNode, newNode *kapi.Node) bool {
+	return oldNode.Annotations[ovnNodeEgressIPInterfaces] != newNode.Annotations[ovnNodeEgressIPInterfaces]
+}
+
 // ParseNodeHostAddresses returns the parsed host addresses living on a node
 func ParseNodeHostAddresses(node *kapi.Node) (sets.Set[string], error) {
 	addrAnnotation, ok := node.Annotations[ovnNodeHostAddresses]
@@ -876,6 +1081,33 @@ func GetNonOVNNetworkContainingIP(node *v1.Node, ip net.IP) (string, error) {
 	return match.String(), nil
 }
 
+// GetNonOVNNetwork returns the non OVN managed network of the node equal to the argument network, if of the IP family of
+// the argument IP. If no network is found, an empty string is returned
+func GetNonOVNNetwork(node *v1.Node, network string, ip net.IP) (string, error) {
+	selected, err := netip.ParsePrefix(network)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse network %s: %v", network, err)
+	}
+	if selected.Addr().Is4() != (ip.To4() != nil) {
+		return "", nil
+	}
+	networks, err := ParseNodeHostAddressesExcludeOVNManagedNetworks(node)
+	if err != nil {
+		return "", fmt.Errorf("failed to get host-addresses annotation excluding OVN managed networks for node %s: %v",
+			node.Name, err)
+	}
+	cidrs, err := makeCIDRs(networks...)
+	if err != nil {
+		return "", err
+	}
+	for i, cidr := range cidrs {
+		if cidr.Masked() == selected.Masked() {
+			return networks[i], nil
+		}
+	}
+	return "", nil
+}
+
 // UpdateNodeIDAnnotation updates the ovnNodeID annotation with the node id in the annotations map
 // and returns it.
 func UpdateNodeIDAnnotation(annotations map[string]interface{}, nodeID int) map[string]interface{} {
@@ -913,6 +1145,56 @@ func SetNodeZone(nodeAnnotator kube.Annotator, zoneName string) error {
 	return nodeAnnotator.Set(ovnNodeZoneName, zoneName)
 }
 
+// appliedClusterSubnets returns the cluster subnets of the default network, in
+// the format of the applied cluster subnets node annotations
+func appliedClusterSubnets() string {
+	cidrs := make([]string, 0, len(config.Default.ClusterSubnets))
+	for _, clusterSubnet := range config.Default.ClusterSubnets {
+		cidrs = append(cidrs, clusterSubnet.CIDR.String())
+	}
+	bytes, _ := json.Marshal(cidrs)
+	return string(bytes)
+}
+
+// SetNodeAppliedClusterSubnets sets the cluster subnets of the default network
+// in the 'ovnNodeAppliedClusterSubnets' node annotation
+func SetNodeAppliedClusterSubnets(nodeAnnotator kube.Annotator) error {
+	return nodeAnnotator.Set(ovnNodeAppliedClusterSubnets, appliedClusterSubnets())
+}
+
+// SetControllerAppliedClusterSubnets sets the cluster subnets of the default
+// network in the 'ovnControllerAppliedClusterSubnets' node annotation, if they
+// changed
+func SetControllerAppliedClusterSubnets(nodeAnnotator kube.Annotator, node *kapi.Node) error {
+	value := appliedClusterSubnets()
+	if node.Annotations[ovnControllerAppliedClusterSubnets] == value {
+		return nil
+	}
+	return nodeAnnotator.Set(ovnControllerAppliedClusterSubnets, value)
+}
+
+// HasAppliedClusterSubnet returns true if both ovnkube-node of the node and the
+// ovnkube-controller of its zone applied the cluster subnet
+func HasAppliedClusterSubnet(node *kapi.Node, clusterSubnet *net.IPNet) bool {
+	for _, annotation := range []string{ovnNodeAppliedClusterSubnets, ovnControllerAppliedClusterSubnets} {
+		var cidrs []string
+		if err := json.Unmarshal([]byte(node.Annotations[annotation]), &cidrs); err != nil {
+			return false
+		}
+		found := false
+		for _, cidr := range cidrs {
+			if cidr == clusterSubnet.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 /** HACK BEGIN **/
 // TODO(tssurya): Remove this a few months from now
 // SetNodeZoneMigrated sets the node's zone in the 'ovnNodeMigratedZoneName' node annotation.
@@ -616,3 +616,100 @@ func TestParseNodeGatewayMTUSupport(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNodeMasqueradeIPs(t *testing.T) {
+	tests := []struct {
+		desc           string
+		annotation     string
+		errExpected    bool
+		expV4HostIP    string
+		expV6HostIP    string
+		expV4NextHopIP string
+		expV6NextHopIP string
+	}{
+		{
+			desc:           "the configured masquerade subnets are used when the node did not select any",
+			expV4HostIP:    "169.254.169.2",
+			expV6HostIP:    "fd69::2",
+			expV4NextHopIP: "169.254.169.4",
+			expV6NextHopIP: "fd69::4",
+		},
+		{
+			desc:           "the masquerade subnets the node selected are used",
+			annotation:     `{"ipv4":"169.254.0.8/29","ipv6":"fd69::8/125"}`,
+			expV4HostIP:    "169.254.0.10",
+			expV6HostIP:    "fd69::a",
+			expV4NextHopIP: "169.254.0.12",
+			expV6NextHopIP: "fd69::c",
+		},
+		{
+			desc:           "the configured masquerade subnet is used for the family the node did not select",
+			annotation:     `{"ipv4":"169.254.0.8/29"}`,
+			expV4HostIP:    "169.254.0.10",
+			expV6HostIP:    "fd69::2",
+			expV4NextHopIP: "169.254.0.12",
+			expV6NextHopIP: "fd69::4",
+		},
+		{
+			desc:        "error: the selected masquerade subnet is not a network address",
+			annotation:  `{"ipv4":"169.254.0.9/29"}`,
+			errExpected: true,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			assert.NoError(t, config.PrepareTestConfig())
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: map[string]string{}}}
+			if tc.annotation != "" {
+				node.Annotations["k8s.ovn.org/node-masquerade-subnet"] = tc.annotation
+			}
+			masqueradeIPs, err := GetNodeMasqueradeIPs(node)
+			if tc.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expV4HostIP, masqueradeIPs.V4HostMasqueradeIP.String())
+			assert.Equal(t, tc.expV6HostIP, masqueradeIPs.V6HostMasqueradeIP.String())
+			assert.Equal(t, tc.expV4NextHopIP, masqueradeIPs.V4DummyNextHopMasqueradeIP.String())
+			assert.Equal(t, tc.expV6NextHopIP, masqueradeIPs.V6DummyNextHopMasqueradeIP.String())
+		})
+	}
+}
+
+func TestHasAppliedClusterSubnet(t *testing.T) {
+	clusterSubnet := ovntest.MustParseIPNet("10.132.0.0/23")
+	tests := []struct {
+		desc        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			desc: "applied by ovnkube-node and ovnkube-controller",
+			annotations: map[string]string{
+				ovnNodeAppliedClusterSubnets:       `["10.128.0.0/14","10.132.0.0/23"]`,
+				ovnControllerAppliedClusterSubnets: `["10.128.0.0/14","10.132.0.0/23"]`,
+			},
+			expected: true,
+		},
+		{
+			desc: "only applied by ovnkube-node",
+			annotations: map[string]string{
+				ovnNodeAppliedClusterSubnets:       `["10.128.0.0/14","10.132.0.0/23"]`,
+				ovnControllerAppliedClusterSubnets: `["10.128.0.0/14"]`,
+			},
+		},
+		{
+			desc: "not reported by ovnkube-controller",
+			annotations: map[string]string{
+				ovnNodeAppliedClusterSubnets: `["10.128.0.0/14","10.132.0.0/23"]`,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: tc.annotations}}
+			assert.Equal(t, tc.expected, HasAppliedClusterSubnet(node, clusterSubnet))
+		})
+	}
+}
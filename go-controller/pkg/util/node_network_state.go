@@ -0,0 +1,104 @@
+package util
+
+import (
+	"fmt"
+	"net"
+
+	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+// NodeNetworkStateGetter gets the NodeNetworkState of a node, like the watch
+// factories do from their informer cache
+type NodeNetworkStateGetter interface {
+	GetNodeNetworkState(nodeName string) (*nodenetworkstatev1.NodeNetworkState, error)
+}
+
+// UseNodeNetworkState returns whether the host subnets of the given network
+// are stored in the NodeNetworkState of the nodes rather than in their
+// "k8s.ovn.org/node-subnets" annotation. With the "crd" backend, the host
+// subnets of the default network are no longer annotated, unless hybrid
+// overlay is enabled: the hybrid overlay nodes, e.g. Windows nodes, only read
+// them from the annotation.
+func UseNodeNetworkState(netName string) bool {
+	return config.OVNKubernetesFeature.NodeNetworkStateBackend == config.NodeNetworkStateBackendCRD &&
+		netName == types.DefaultNetworkName && !config.HybridOverlay.Enabled
+}
+
+// ParseNodeHostSubnets returns the host subnets of the given network of a
+// node, read from its NodeNetworkState if they are stored there and from its
+// node subnet annotation otherwise. Like ParseNodeHostSubnetAnnotation, an
+// annotation not set error is returned if they are not allocated yet.
+func ParseNodeHostSubnets(node *kapi.Node, netName string, states NodeNetworkStateGetter) ([]*net.IPNet, error) {
+	if !UseNodeNetworkState(netName) {
+		return ParseNodeHostSubnetAnnotation(node, netName)
+	}
+	state, err := states.GetNodeNetworkState(node.Name)
+	if apierrors.IsNotFound(err) {
+		return ParseNodeNetworkStateHostSubnets(node.Name, nil, netName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseNodeNetworkStateHostSubnets(node.Name, state, netName)
+}
+
+// NodeNetworkStateSpecFromAnnotations builds the NodeNetworkState spec of a
+// node out of its "k8s.ovn.org/node-subnets", "k8s.ovn.org/network-ids",
+// "k8s.ovn.org/node-chassis-id" and "k8s.ovn.org/l3-gateway-config"
+// annotations. Annotations that are not set are skipped.
+func NodeNetworkStateSpecFromAnnotations(node *kapi.Node) (*nodenetworkstatev1.NodeNetworkStateSpec, error) {
+	spec := &nodenetworkstatev1.NodeNetworkStateSpec{
+		Networks:        map[string]nodenetworkstatev1.NodeNetwork{},
+		ChassisID:       node.Annotations[ovnNodeChassisID],
+		L3GatewayConfig: node.Annotations[ovnNodeL3GatewayConfig],
+	}
+
+	subnetsMap, err := parseSubnetAnnotation(node.Annotations, ovnNodeSubnets)
+	if err != nil && !IsAnnotationNotSetError(err) {
+		return nil, fmt.Errorf("failed to parse node %s subnets annotation: %w", node.Name, err)
+	}
+	for netName, subnets := range subnetsMap {
+		nodeNetwork := spec.Networks[netName]
+		for _, subnet := range subnets {
+			nodeNetwork.Subnets = append(nodeNetwork.Subnets, subnet.String())
+		}
+		spec.Networks[netName] = nodeNetwork
+	}
+
+	networkIDsMap, err := GetNodeNetworkIDsAnnotationNetworkIDs(node)
+	if err != nil && !IsAnnotationNotSetError(err) {
+		return nil, fmt.Errorf("failed to parse node %s network IDs annotation: %w", node.Name, err)
+	}
+	for netName, id := range networkIDsMap {
+		nodeNetwork := spec.Networks[netName]
+		networkID := id
+		nodeNetwork.NetworkID = &networkID
+		spec.Networks[netName] = nodeNetwork
+	}
+
+	return spec, nil
+}
+
+// ParseNodeNetworkStateHostSubnets returns the host subnets of the given
+// network stored in the NodeNetworkState of a node, or an annotation not set
+// error, like ParseNodeHostSubnetAnnotation, if the node has no state or no
+// host subnets for the network yet.
+func ParseNodeNetworkStateHostSubnets(nodeName string, state *nodenetworkstatev1.NodeNetworkState, netName string) ([]*net.IPNet, error) {
+	if state == nil {
+		return nil, newAnnotationNotSetError("node %q has no network state", nodeName)
+	}
+	nodeNetwork, ok := state.Spec.Networks[netName]
+	if !ok || len(nodeNetwork.Subnets) == 0 {
+		return nil, newAnnotationNotSetError("node %q has no host subnets for network %s in its network state", nodeName, netName)
+	}
+	subnets, err := ParseIPNets(nodeNetwork.Subnets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse node %s host subnets for network %s: %w", nodeName, netName, err)
+	}
+	return subnets, nil
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nodenetworkstatev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodenetworkstate/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+func TestParseNodeNetworkStateHostSubnets(t *testing.T) {
+	newState := func(subnets ...string) *nodenetworkstatev1.NodeNetworkState {
+		return &nodenetworkstatev1.NodeNetworkState{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Spec: nodenetworkstatev1.NodeNetworkStateSpec{
+				Networks: map[string]nodenetworkstatev1.NodeNetwork{
+					types.DefaultNetworkName: {Subnets: subnets},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		desc           string
+		state          *nodenetworkstatev1.NodeNetworkState
+		netName        string
+		expected       []string
+		expectedNotSet bool
+		expectedErr    bool
+	}{
+		{
+			desc:     "returns the host subnets of the network",
+			state:    newState("10.128.0.0/24", "fd00:10:128::/64"),
+			netName:  types.DefaultNetworkName,
+			expected: []string{"10.128.0.0/24", "fd00:10:128::/64"},
+		},
+		{
+			desc:           "node without network state",
+			netName:        types.DefaultNetworkName,
+			expectedNotSet: true,
+		},
+		{
+			desc:           "network without host subnets",
+			state:          newState("10.128.0.0/24"),
+			netName:        "blue",
+			expectedNotSet: true,
+		},
+		{
+			desc:           "network whose host subnets are not allocated yet",
+			state:          newState(),
+			netName:        types.DefaultNetworkName,
+			expectedNotSet: true,
+		},
+		{
+			desc:        "invalid host subnet",
+			state:       newState("10.128.0.0"),
+			netName:     types.DefaultNetworkName,
+			expectedErr: true,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			subnets, err := ParseNodeNetworkStateHostSubnets("node1", tc.state, tc.netName)
+			switch {
+			case tc.expectedNotSet:
+				assert.True(t, IsAnnotationNotSetError(err), "expected an annotation not set error, got %v", err)
+			case tc.expectedErr:
+				assert.Error(t, err)
+				assert.False(t, IsAnnotationNotSetError(err))
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, StringSlice(subnets))
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 
 	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	nadutils "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/utils"
@@ -13,6 +14,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	listers "k8s.io/client-go/listers/core/v1"
 	utilnet "k8s.io/utils/net"
 )
@@ -45,17 +47,67 @@ import (
 // The "ip_address" and "gateway_ip" fields are deprecated and will eventually go away.
 // (And they are not output when "ip_addresses" or "gateway_ips" contains multiple
 // values.)
+//
+// Each network is written with the "version" of its format, PodAnnotationVersion.
+// Networks of an older version, or without a version like the ones written before
+// the annotation was versioned, are migrated to the current version when parsed;
+// networks of a newer version are parsed as far as the fields known to this version
+// go. A new field changing how a network is read bumps PodAnnotationVersion and adds
+// the migration from the previous version to podAnnotationMigrations.
 
 const (
 	// OvnPodAnnotationName is the constant string representing the POD annotation key
 	OvnPodAnnotationName = "k8s.ovn.org/pod-networks"
 	// DefNetworkAnnotation is the pod annotation for the cluster-wide default network
 	DefNetworkAnnotation = "v1.multus-cni.io/default-network"
+	// PodNetworkRoutingAnnotation is the pod annotation selecting the network
+	// attachments providing the default route of a pod and reaching its DNS
+	// servers, and the destinations routed through each attachment
+	PodNetworkRoutingAnnotation = "k8s.ovn.org/network-routing"
+)
+
+const (
+	// PodAnnotationVersion is the version of the format of the networks
+	// written in the pod annotation
+	PodAnnotationVersion = 1
 )
 
 var ErrNoPodIPFound = errors.New("no pod IPs found")
 var ErrOverridePodIPs = errors.New("requested pod IPs trying to override IPs exists in pod annotation")
 
+// podAnnotationMigrations migrate a network of the pod annotation of the version
+// of their index to the next version
+var podAnnotationMigrations = []func(a *podAnnotation){
+	// unversioned networks may only have the deprecated single-stack fields
+	func(a *podAnnotation) {
+		if len(a.IPs) == 0 && a.IP != "" {
+			a.IPs = []string{a.IP}
+		}
+		if len(a.Gateways) == 0 && a.Gateway != "" {
+			a.Gateways = []string{a.Gateway}
+		}
+	},
+}
+
+type invalidPodAnnotationError struct {
+	err error
+}
+
+func (e *invalidPodAnnotationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidPodAnnotationError) Unwrap() error {
+	return e.err
+}
+
+// IsInvalidPodAnnotationError returns true if the error indicates that the pod
+// annotation is malformed
+func IsInvalidPodAnnotationError(err error) bool {
+	var invalidPodAnnotationError *invalidPodAnnotationError
+	return errors.As(err, &invalidPodAnnotationError)
+}
+
 // PodAnnotation describes the assigned network details for a single pod network. (The
 // actual annotation may include the equivalent of multiple PodAnnotations.)
 type PodAnnotation struct {
@@ -96,6 +148,19 @@ type podAnnotation struct {
 	Gateway string `json:"gateway_ip,omitempty"`
 
 	TunnelID int `json:"tunnel_id,omitempty"`
+
+	Version int `json:"version,omitempty"`
+}
+
+// migrate migrates the network to PodAnnotationVersion
+func (a *podAnnotation) migrate() error {
+	if a.Version < 0 {
+		return fmt.Errorf("bad annotation data (invalid version %d)", a.Version)
+	}
+	for ; a.Version < PodAnnotationVersion; a.Version++ {
+		podAnnotationMigrations[a.Version](a)
+	}
+	return nil
 }
 
 // Internal struct used to marshal PodRoute to the pod annotation
@@ -116,6 +181,7 @@ func MarshalPodAnnotation(annotations map[string]string, podInfo *PodAnnotation,
 	pa := podAnnotation{
 		TunnelID: podInfo.TunnelID,
 		MAC:      podInfo.MAC.String(),
+		Version:  PodAnnotationVersion,
 	}
 
 	if len(podInfo.IPs) == 1 {
@@ -132,6 +198,9 @@ func MarshalPodAnnotation(annotations map[string]string, podInfo *PodAnnotation,
 
 	existingPa, ok := podNetworks[nadName]
 	if ok {
+		if err := existingPa.migrate(); err != nil {
+			return nil, &invalidPodAnnotationError{err: err}
+		}
 		if len(pa.IPs) != len(existingPa.IPs) {
 			return nil, ErrOverridePodIPs
 		}
@@ -170,7 +239,6 @@ func MarshalPodAnnotation(annotations map[string]string, podInfo *PodAnnotation,
 
 // UnmarshalPodAnnotation returns the Pod's network info of the given network from pod.Annotations
 func UnmarshalPodAnnotation(annotations map[string]string, nadName string) (*PodAnnotation, error) {
-	var err error
 	ovnAnnotation, ok := annotations[OvnPodAnnotationName]
 	if !ok {
 		return nil, newAnnotationNotSetError("could not find OVN pod annotation in %v", annotations)
@@ -181,13 +249,26 @@ func UnmarshalPodAnnotation(annotations map[string]string, nadName string) (*Pod
 		return nil, err
 	}
 
-	tempA, ok := podNetworks[nadName]
+	a, ok := podNetworks[nadName]
 	if !ok {
 		return nil, fmt.Errorf("no ovn pod annotation for network %s: %q",
 			nadName, ovnAnnotation)
 	}
 
-	a := &tempA
+	podAnnotation, err := parsePodAnnotation(&a)
+	if err != nil {
+		return nil, &invalidPodAnnotationError{err: err}
+	}
+	return podAnnotation, nil
+}
+
+// parsePodAnnotation parses a network of the pod annotation, migrated to the
+// current version
+func parsePodAnnotation(a *podAnnotation) (*PodAnnotation, error) {
+	var err error
+	if err = a.migrate(); err != nil {
+		return nil, err
+	}
 
 	podAnnotation := &PodAnnotation{
 		TunnelID: a.TunnelID,
@@ -197,11 +278,7 @@ func UnmarshalPodAnnotation(annotations map[string]string, nadName string) (*Pod
 		return nil, fmt.Errorf("failed to parse pod MAC %q: %v", a.MAC, err)
 	}
 
-	if len(a.IPs) == 0 {
-		if a.IP != "" {
-			a.IPs = append(a.IPs, a.IP)
-		}
-	} else if a.IP != "" && a.IP != a.IPs[0] {
+	if a.IP != "" && (len(a.IPs) == 0 || a.IP != a.IPs[0]) {
 		return nil, fmt.Errorf("bad annotation data (ip_address and ip_addresses conflict)")
 	}
 	for _, ipstr := range a.IPs {
@@ -213,11 +290,7 @@ func UnmarshalPodAnnotation(annotations map[string]string, nadName string) (*Pod
 		podAnnotation.IPs = append(podAnnotation.IPs, ipnet)
 	}
 
-	if len(a.Gateways) == 0 {
-		if a.Gateway != "" {
-			a.Gateways = append(a.Gateways, a.Gateway)
-		}
-	} else if a.Gateway != "" && a.Gateway != a.Gateways[0] {
+	if a.Gateway != "" && (len(a.Gateways) == 0 || a.Gateway != a.Gateways[0]) {
 		return nil, fmt.Errorf("bad annotation data (gateway_ip and gateway_ips conflict)")
 	}
 	for _, gwstr := range a.Gateways {
@@ -256,13 +329,62 @@ func UnmarshalPodAnnotationAllNetworks(annotations map[string]string) (map[strin
 	ovnAnnotation, ok := annotations[OvnPodAnnotationName]
 	if ok {
 		if err := json.Unmarshal([]byte(ovnAnnotation), &podNetworks); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal ovn pod annotation %q: %v",
-				ovnAnnotation, err)
+			return nil, &invalidPodAnnotationError{err: fmt.Errorf("failed to unmarshal ovn pod annotation %q: %v",
+				ovnAnnotation, err)}
 		}
 	}
 	return podNetworks, nil
 }
 
+// ValidatePodAnnotation checks that all the networks of the pod annotation, if
+// set, are well formed: the addresses parse, the gateways are of the IP
+// families of the pod IPs and the pod IPs are not repeated. It returns an
+// error for which IsInvalidPodAnnotationError is true otherwise.
+func ValidatePodAnnotation(annotations map[string]string) error {
+	podNetworks, err := UnmarshalPodAnnotationAllNetworks(annotations)
+	if err != nil {
+		return err
+	}
+	for nadName, a := range podNetworks {
+		podAnnotation, err := parsePodAnnotation(&a)
+		if err == nil {
+			err = validatePodAnnotation(podAnnotation)
+		}
+		if err != nil {
+			return &invalidPodAnnotationError{err: fmt.Errorf("invalid ovn pod annotation for network %s: %w", nadName, err)}
+		}
+	}
+	return nil
+}
+
+func validatePodAnnotation(podAnnotation *PodAnnotation) error {
+	if podAnnotation.TunnelID < 0 {
+		return fmt.Errorf("bad annotation data (invalid tunnel id %d)", podAnnotation.TunnelID)
+	}
+	ips := map[string]bool{}
+	var hasIPv4, hasIPv6 bool
+	for _, ip := range podAnnotation.IPs {
+		if ips[ip.IP.String()] {
+			return fmt.Errorf("bad annotation data (pod IP %s repeated)", ip.IP)
+		}
+		ips[ip.IP.String()] = true
+		if utilnet.IsIPv6(ip.IP) {
+			hasIPv6 = true
+		} else {
+			hasIPv4 = true
+		}
+	}
+	if len(podAnnotation.Gateways) > len(podAnnotation.IPs) {
+		return fmt.Errorf("bad annotation data (more gateways than pod IPs)")
+	}
+	for _, gw := range podAnnotation.Gateways {
+		if utilnet.IsIPv6(gw) && !hasIPv6 || !utilnet.IsIPv6(gw) && !hasIPv4 {
+			return fmt.Errorf("bad annotation data (gateway %s of a different family than the pod IPs)", gw)
+		}
+	}
+	return nil
+}
+
 // GetPodCIDRsWithFullMask returns the pod's IP addresses in a CIDR with FullMask format
 // Internally it calls GetPodIPsOfNetwork
 func GetPodCIDRsWithFullMask(pod *v1.Pod, nInfo NetInfo) ([]*net.IPNet, error) {
@@ -441,6 +563,111 @@ func joinSubnetToRoute(isIPv6 bool, gatewayIP net.IP) PodRoute {
 	}
 }
 
+// PodNetworkRouting is the routing of a pod with multiple network
+// attachments, set with the PodNetworkRoutingAnnotation. The networks are
+// referred to by the namespaced name of the network attachment definition of
+// the attachment, or by types.DefaultNetworkName for the cluster default
+// network.
+type PodNetworkRouting struct {
+	// DefaultRoute is the network providing the default route of the pod,
+	// overriding the default-route gateway requests of the attachments
+	DefaultRoute string `json:"defaultRoute,omitempty"`
+	// DNS is the network the DNS servers of the pod dnsConfig are reached
+	// through
+	DNS string `json:"dns,omitempty"`
+	// Routes are the destination CIDRs routed through each network
+	Routes map[string][]string `json:"routes,omitempty"`
+}
+
+// GetPodNetworkRouting returns the network routing of the pod, nil if it has
+// no PodNetworkRoutingAnnotation.
+func GetPodNetworkRouting(pod *v1.Pod) (*PodNetworkRouting, error) {
+	annotation, ok := pod.Annotations[PodNetworkRoutingAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	routing := &PodNetworkRouting{}
+	if err := json.Unmarshal([]byte(annotation), routing); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the %s annotation of pod %s/%s: %v",
+			PodNetworkRoutingAnnotation, pod.Namespace, pod.Name, err)
+	}
+	networks := []string{routing.DefaultRoute, routing.DNS}
+	for network, dests := range routing.Routes {
+		networks = append(networks, network)
+		for _, dest := range dests {
+			if _, _, err := net.ParseCIDR(dest); err != nil {
+				return nil, fmt.Errorf("invalid %s annotation of pod %s/%s: invalid route destination %q of network %s",
+					PodNetworkRoutingAnnotation, pod.Namespace, pod.Name, dest, network)
+			}
+		}
+	}
+	for _, network := range networks {
+		if network != "" && network != types.DefaultNetworkName && len(strings.Split(network, "/")) != 2 {
+			return nil, fmt.Errorf("invalid %s annotation of pod %s/%s: network %q is neither %s nor a "+
+				"namespaced network attachment definition name", PodNetworkRoutingAnnotation, pod.Namespace,
+				pod.Name, network, types.DefaultNetworkName)
+		}
+	}
+	return routing, nil
+}
+
+// addPodNetworkRoutingRoutes adds to the pod annotation the routes to the DNS
+// servers and to the destinations the network routing of the pod routes
+// through the network, with the gateway of their IP family, if any
+func addPodNetworkRoutingRoutes(pod *v1.Pod, podAnnotation *PodAnnotation, routing *PodNetworkRouting,
+	nadName string, gateways []net.IP) error {
+	var dests []*net.IPNet
+	if routing.DNS == nadName && pod.Spec.DNSConfig != nil {
+		for _, nameserver := range pod.Spec.DNSConfig.Nameservers {
+			ip := net.ParseIP(nameserver)
+			if ip == nil {
+				return fmt.Errorf("pod %s/%s has an invalid DNS server %q", pod.Namespace, pod.Name, nameserver)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			dests = append(dests, &net.IPNet{IP: ip, Mask: GetIPFullMask(ip)})
+		}
+	}
+	for _, dest := range routing.Routes[nadName] {
+		// validated by GetPodNetworkRouting
+		_, ipNet, _ := net.ParseCIDR(dest)
+		dests = append(dests, ipNet)
+	}
+	for _, dest := range dests {
+		gateway, err := MatchFirstIPFamily(utilnet.IsIPv6CIDR(dest), gateways)
+		if err != nil {
+			return fmt.Errorf("pod %s/%s routes %s through network %s, which has no gateway of this IP family",
+				pod.Namespace, pod.Name, dest, nadName)
+		}
+		podAnnotation.Routes = append(podAnnotation.Routes, PodRoute{Dest: dest, NextHop: gateway})
+	}
+	return nil
+}
+
+// validatePodNetworkRouting checks that the networks the network routing of
+// the pod refers to are attachments of the pod: the cluster default network,
+// the networks of its network selection elements, or the network nadName the
+// routing is applied to
+func validatePodNetworkRouting(pod *v1.Pod, routing *PodNetworkRouting,
+	networks []*nadapi.NetworkSelectionElement, nadName string) error {
+	attachments := sets.New(types.DefaultNetworkName, nadName)
+	for _, network := range networks {
+		attachments.Insert(GetNADName(network.Namespace, network.Name))
+	}
+	referred := []string{routing.DefaultRoute, routing.DNS}
+	for network := range routing.Routes {
+		referred = append(referred, network)
+	}
+	for _, network := range referred {
+		if network != "" && !attachments.Has(network) {
+			return fmt.Errorf("invalid %s annotation of pod %s/%s: network %s is not an attachment of the pod",
+				PodNetworkRoutingAnnotation, pod.Namespace, pod.Name, network)
+		}
+	}
+	return nil
+}
+
 // addRoutesGatewayIP updates the provided pod annotation for the provided pod
 // with the gateways derived from the allocated IPs
 func AddRoutesGatewayIP(
@@ -452,16 +679,61 @@ func AddRoutesGatewayIP(
 	// generate the nodeSubnets from the allocated IPs
 	nodeSubnets := IPsToNetworkIPs(podAnnotation.IPs...)
 
+	routing, err := GetPodNetworkRouting(pod)
+	if err != nil {
+		return err
+	}
+	if routing == nil {
+		routing = &PodNetworkRouting{}
+	}
+	networks, err := GetK8sPodAllNetworkSelections(pod)
+	if err != nil {
+		return fmt.Errorf("error while getting network attachment definition for [%s/%s]: %v",
+			pod.Namespace, pod.Name, err)
+	}
+	nadName := types.DefaultNetworkName
+	if netinfo.IsSecondary() {
+		nadName = GetNADName(network.Namespace, network.Name)
+	}
+	if err := validatePodNetworkRouting(pod, routing, networks, nadName); err != nil {
+		return err
+	}
+
 	if netinfo.IsSecondary() {
 		// for secondary network, see if its network-attachment's annotation has default-route key.
-		// If present, then we need to add default route for it
-		podAnnotation.Gateways = append(podAnnotation.Gateways, network.GatewayRequest...)
+		// If present, then we need to add default route for it, unless the
+		// network is internal, the traffic having no way out of it, or the
+		// network routing of the pod selects another network
+		gatewayRequested := len(network.GatewayRequest) > 0
+		if gatewayRequested && routing.DefaultRoute != "" && routing.DefaultRoute != nadName {
+			return fmt.Errorf("pod %s/%s requests a default route through network %s, but its %s annotation "+
+				"selects network %s", pod.Namespace, pod.Name, nadName, PodNetworkRoutingAnnotation, routing.DefaultRoute)
+		}
+		if netinfo.IsInternal() && (gatewayRequested || routing.DefaultRoute == nadName) {
+			return fmt.Errorf("pod %s/%s requests a default route through the internal network %s",
+				pod.Namespace, pod.Name, netinfo.GetNetworkName())
+		}
+		// the gateways of the network are the requested ones, the node
+		// gateway of the layer3 topology, or the cluster router of the primary
+		// layer2 topology
+		gateways := network.GatewayRequest
 		topoType := netinfo.TopologyType()
 		switch topoType {
 		case types.Layer2Topology, types.LocalnetTopology:
 			// no route needed for directly connected subnets
-			return nil
+			if !netinfo.IsPrimaryNetwork() || gatewayRequested {
+				break
+			}
+			for _, podIfAddr := range podAnnotation.IPs {
+				for _, subnet := range netinfo.Subnets() {
+					if subnet.CIDR.Contains(podIfAddr.IP) {
+						gateways = append(gateways, GetNodeGatewayIfAddr(subnet.CIDR).IP)
+						break
+					}
+				}
+			}
 		case types.Layer3Topology:
+			var nodeGateways []net.IP
 			for _, podIfAddr := range podAnnotation.IPs {
 				isIPv6 := utilnet.IsIPv6CIDR(podIfAddr)
 				nodeSubnet, err := MatchFirstIPNetFamily(isIPv6, nodeSubnets)
@@ -469,6 +741,7 @@ func AddRoutesGatewayIP(
 					return err
 				}
 				gatewayIPnet := GetNodeGatewayIfAddr(nodeSubnet)
+				nodeGateways = append(nodeGateways, gatewayIPnet.IP)
 				for _, clusterSubnet := range netinfo.Subnets() {
 					if isIPv6 == utilnet.IsIPv6CIDR(clusterSubnet.CIDR) {
 						podAnnotation.Routes = append(podAnnotation.Routes, PodRoute{
@@ -478,19 +751,28 @@ func AddRoutesGatewayIP(
 					}
 				}
 			}
-			return nil
+			if !gatewayRequested {
+				gateways = nodeGateways
+			}
+		default:
+			return fmt.Errorf("topology type %s not supported", topoType)
+		}
+		if routing.DefaultRoute == nadName && !gatewayRequested {
+			if len(gateways) == 0 {
+				return fmt.Errorf("pod %s/%s selects network %s for its default route, which has no gateway: "+
+					"request one with the default-route of the network selection element",
+					pod.Namespace, pod.Name, nadName)
+			}
+			podAnnotation.Gateways = append(podAnnotation.Gateways, gateways...)
+		} else {
+			podAnnotation.Gateways = append(podAnnotation.Gateways, network.GatewayRequest...)
 		}
-		return fmt.Errorf("topology type %s not supported", topoType)
+		return addPodNetworkRoutingRoutes(pod, podAnnotation, routing, nadName, gateways)
 	}
 
 	// if there are other network attachments for the pod, then check if those network-attachment's
 	// annotation has default-route key. If present, then we need to skip adding default route for
 	// OVN interface
-	networks, err := GetK8sPodAllNetworkSelections(pod)
-	if err != nil {
-		return fmt.Errorf("error while getting network attachment definition for [%s/%s]: %v",
-			pod.Namespace, pod.Name, err)
-	}
 	otherDefaultRouteV4 := false
 	otherDefaultRouteV6 := false
 	for _, network := range networks {
@@ -502,6 +784,13 @@ func AddRoutesGatewayIP(
 			}
 		}
 	}
+	// the network routing of the pod selecting the network of the default
+	// route takes precedence over the gateway requests
+	if routing.DefaultRoute != "" {
+		otherDefaultRouteV4 = routing.DefaultRoute != types.DefaultNetworkName
+		otherDefaultRouteV6 = otherDefaultRouteV4
+	}
+	var gateways []net.IP
 
 	for _, podIfAddr := range podAnnotation.IPs {
 		isIPv6 := utilnet.IsIPv6CIDR(podIfAddr)
@@ -512,9 +801,10 @@ func AddRoutesGatewayIP(
 
 		gatewayIPnet := GetNodeGatewayIfAddr(nodeSubnet)
 
-		// Ensure default pod network traffic always goes to OVN
+		// Ensure default pod network traffic always goes to OVN, the cluster
+		// subnets being directly connected on the layer2 topology
 		for _, clusterSubnet := range config.Default.ClusterSubnets {
-			if isIPv6 == utilnet.IsIPv6CIDR(clusterSubnet.CIDR) {
+			if netinfo.TopologyType() == types.Layer3Topology && isIPv6 == utilnet.IsIPv6CIDR(clusterSubnet.CIDR) {
 				podAnnotation.Routes = append(podAnnotation.Routes, PodRoute{
 					Dest:    clusterSubnet.CIDR,
 					NextHop: gatewayIPnet.IP,
@@ -539,10 +829,11 @@ func AddRoutesGatewayIP(
 		if !otherDefaultRoute {
 			podAnnotation.Gateways = append(podAnnotation.Gateways, gatewayIPnet.IP)
 		}
+		gateways = append(gateways, gatewayIPnet.IP)
 
 		// Ensure default join subnet traffic always goes to OVN
 		podAnnotation.Routes = append(podAnnotation.Routes, joinSubnetToRoute(isIPv6, gatewayIPnet.IP))
 	}
 
-	return nil
+	return addPodNetworkRoutingRoutes(pod, podAnnotation, routing, types.DefaultNetworkName, gateways)
 }
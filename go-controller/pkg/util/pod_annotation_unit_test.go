@@ -8,9 +8,11 @@ import (
 	"testing"
 
 	cnitypes "github.com/containernetworking/cni/pkg/types"
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/stretchr/testify/assert"
@@ -28,14 +30,14 @@ func TestMarshalPodAnnotation(t *testing.T) {
 		{
 			desc:           "PodAnnotation instance with no fields set",
 			inpPodAnnot:    PodAnnotation{},
-			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":""}}`},
+			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":"","version":1}}`},
 		},
 		{
 			desc: "single IP assigned to pod with MAC, Gateway, Routes NOT SPECIFIED",
 			inpPodAnnot: PodAnnotation{
 				IPs: []*net.IPNet{ovntest.MustParseIPNet("192.168.0.5/24")},
 			},
-			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"","ip_address":"192.168.0.5/24"}}`},
+			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"","ip_address":"192.168.0.5/24","version":1}}`},
 		},
 		{
 			desc: "multiple IPs assigned to pod with MAC, Gateway, Routes NOT SPECIFIED",
@@ -45,7 +47,7 @@ func TestMarshalPodAnnotation(t *testing.T) {
 					ovntest.MustParseIPNet("fd01::1234/64"),
 				},
 			},
-			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24","fd01::1234/64"],"mac_address":""}}`},
+			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24","fd01::1234/64"],"mac_address":"","version":1}}`},
 		},
 		{
 			desc: "test code path when podInfo.Gateways count is equal to ONE",
@@ -55,7 +57,7 @@ func TestMarshalPodAnnotation(t *testing.T) {
 					net.ParseIP("192.168.0.1"),
 				},
 			},
-			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"","gateway_ips":["192.168.0.1"],"ip_address":"192.168.0.5/24","gateway_ip":"192.168.0.1"}}`},
+			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"","gateway_ips":["192.168.0.1"],"ip_address":"192.168.0.5/24","gateway_ip":"192.168.0.1","version":1}}`},
 		},
 		{
 			desc:     "verify error thrown when number of gateways greater than one for a single-stack network",
@@ -90,7 +92,7 @@ func TestMarshalPodAnnotation(t *testing.T) {
 					},
 				},
 			},
-			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":"","routes":[{"dest":"192.168.1.0/24","nextHop":"192.168.1.1"}]}}`},
+			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":"","routes":[{"dest":"192.168.1.0/24","nextHop":"192.168.1.1"}],"version":1}}`},
 		},
 		{
 			desc: "next hop not set for route",
@@ -101,7 +103,7 @@ func TestMarshalPodAnnotation(t *testing.T) {
 					},
 				},
 			},
-			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":"","routes":[{"dest":"192.168.1.0/24","nextHop":""}]}}`},
+			expectedOutput: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":"","routes":[{"dest":"192.168.1.0/24","nextHop":""}],"version":1}}`},
 		},
 	}
 
@@ -200,6 +202,24 @@ func TestUnmarshalPodAnnotation(t *testing.T) {
 			desc:        "verify successful unmarshal of pod annotation when *only* the MAC address is present",
 			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"mac_address":"0a:58:fd:98:00:01"}}`},
 		},
+		{
+			desc:        "verify successful unmarshal of versioned pod annotation",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24","fd01::5/64"],"mac_address":"0a:58:fd:98:00:01","gateway_ips":["192.168.0.1","fd01::1"],"version":1}}`},
+		},
+		{
+			desc:        "verify error thrown when versioned pod annotation only has ip_address",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":"0a:58:fd:98:00:01","ip_address":"192.168.0.11/24","version":1}}`},
+			errMatch:    fmt.Errorf("bad annotation data (ip_address and ip_addresses conflict)"),
+		},
+		{
+			desc:        "verify error thrown when pod annotation version is invalid",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:fd:98:00:01","version":-1}}`},
+			errMatch:    fmt.Errorf("bad annotation data (invalid version -1)"),
+		},
+		{
+			desc:        "verify successful unmarshal of pod annotation of a newer version",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:fd:98:00:01","new_field":"value","version":2}}`},
+		},
 	}
 	for i, tc := range tests {
 		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
@@ -217,6 +237,86 @@ func TestUnmarshalPodAnnotation(t *testing.T) {
 	}
 }
 
+func TestUnmarshalPodAnnotationMigration(t *testing.T) {
+	expected := &PodAnnotation{
+		IPs:      []*net.IPNet{ovntest.MustParseIPNet("192.168.0.5/24")},
+		MAC:      ovntest.MustParseMAC("0a:58:fd:98:00:01"),
+		Gateways: []net.IP{ovntest.MustParseIP("192.168.0.1")},
+	}
+	// an unversioned annotation with the deprecated fields only
+	annotations := map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"mac_address":"0a:58:fd:98:00:01","ip_address":"192.168.0.5/24","gateway_ip":"192.168.0.1"}}`}
+	res, err := UnmarshalPodAnnotation(annotations, types.DefaultNetworkName)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, res)
+
+	// is rewritten in the current version with the same IPs
+	annotations, err = MarshalPodAnnotation(annotations, expected, types.DefaultNetworkName)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:fd:98:00:01","gateway_ips":["192.168.0.1"],"ip_address":"192.168.0.5/24","gateway_ip":"192.168.0.1","version":1}}`,
+		annotations[OvnPodAnnotationName])
+	res, err = UnmarshalPodAnnotation(annotations, types.DefaultNetworkName)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, res)
+}
+
+func TestValidatePodAnnotation(t *testing.T) {
+	tests := []struct {
+		desc        string
+		inpAnnotMap map[string]string
+		errMatch    error
+	}{
+		{
+			desc:        "no pod annotation",
+			inpAnnotMap: nil,
+		},
+		{
+			desc:        "valid pod annotation of several networks",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24","fd01::5/64"],"mac_address":"0a:58:fd:98:00:01","gateway_ips":["192.168.0.1","fd01::1"],"version":1},"ns1/l2":{"ip_addresses":["10.1.130.2/24"],"mac_address":"0a:58:0a:01:82:02","tunnel_id":3,"version":1}}`},
+		},
+		{
+			desc:        "valid unversioned pod annotation",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"mac_address":"0a:58:fd:98:00:01","ip_address":"192.168.0.5/24","gateway_ip":"192.168.0.1"}}`},
+		},
+		{
+			desc:        "malformed json",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":null,"mac_address":"}}`},
+			errMatch:    fmt.Errorf("failed to unmarshal ovn pod annotation"),
+		},
+		{
+			desc:        "malformed pod IP of a secondary network",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:fd:98:00:01","version":1},"ns1/l2":{"ip_addresses":["10.1.130./24"],"mac_address":"0a:58:0a:01:82:02","version":1}}`},
+			errMatch:    fmt.Errorf("invalid ovn pod annotation for network ns1/l2: failed to parse pod IP"),
+		},
+		{
+			desc:        "repeated pod IP",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24","192.168.0.5/24"],"mac_address":"0a:58:fd:98:00:01","version":1}}`},
+			errMatch:    fmt.Errorf("bad annotation data (pod IP 192.168.0.5 repeated)"),
+		},
+		{
+			desc:        "gateway of a different family",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:fd:98:00:01","gateway_ips":["fd01::1"],"version":1}}`},
+			errMatch:    fmt.Errorf("bad annotation data (gateway fd01::1 of a different family than the pod IPs)"),
+		},
+		{
+			desc:        "invalid tunnel id",
+			inpAnnotMap: map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:fd:98:00:01","tunnel_id":-1,"version":1}}`},
+			errMatch:    fmt.Errorf("bad annotation data (invalid tunnel id -1)"),
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			e := ValidatePodAnnotation(tc.inpAnnotMap)
+			if tc.errMatch != nil {
+				assert.Error(t, e)
+				assert.Contains(t, e.Error(), tc.errMatch.Error())
+				assert.True(t, IsInvalidPodAnnotationError(e))
+			} else {
+				assert.NoError(t, e)
+			}
+		})
+	}
+}
+
 func TestGetPodIPsOfNetwork(t *testing.T) {
 	const (
 		secondaryNetworkIPAddr = "200.200.200.200"
@@ -360,3 +460,173 @@ func newDummyNetInfo(namespace, networkName string) NetInfo {
 	netInfo.AddNAD(GetNADName(namespace, networkName))
 	return netInfo
 }
+
+func TestAddRoutesGatewayIPNetworkRouting(t *testing.T) {
+	const nadName = "ns1/blue"
+	layer3NetInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "blue"},
+		Topology: types.Layer3Topology,
+		Subnets:  "10.128.0.0/14/23",
+	})
+	assert.NoError(t, err)
+	layer2NetInfo, err := NewNetInfo(&ovncnitypes.NetConf{
+		NetConf:  cnitypes.NetConf{Name: "blue"},
+		Topology: types.Layer2Topology,
+		Subnets:  "192.168.0.0/16",
+	})
+	assert.NoError(t, err)
+	newPod := func(routing string, nameservers ...string) *v1.Pod {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod",
+			Namespace:   "ns1",
+			Annotations: map[string]string{nadapi.NetworkAttachmentAnnot: "blue"},
+		}}
+		if routing != "" {
+			pod.Annotations[PodNetworkRoutingAnnotation] = routing
+		}
+		if len(nameservers) > 0 {
+			pod.Spec.DNSConfig = &v1.PodDNSConfig{Nameservers: nameservers}
+		}
+		return pod
+	}
+	tests := []struct {
+		desc             string
+		netInfo          NetInfo
+		podIP            string
+		pod              *v1.Pod
+		gatewayRequest   []net.IP
+		expectedGateways []net.IP
+		expectedRoutes   []PodRoute
+		expectError      bool
+	}{
+		{
+			desc:             "default network without network routing",
+			netInfo:          &DefaultNetInfo{},
+			podIP:            "10.244.0.5/24",
+			pod:              newPod(""),
+			expectedGateways: []net.IP{ovntest.MustParseIP("10.244.0.1")},
+			expectedRoutes: []PodRoute{
+				{Dest: ovntest.MustParseIPNet("10.128.0.0/14"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("172.16.1.0/24"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("100.64.0.0/16"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+			},
+		},
+		{
+			desc:    "default network with the default route, DNS servers and routes through other networks",
+			netInfo: &DefaultNetInfo{},
+			podIP:   "10.244.0.5/24",
+			pod: newPod(`{"defaultRoute": "ns1/blue", "dns": "ns1/blue", "routes": {"ns1/blue": ["172.16.0.0/12"]}}`,
+				"172.16.0.10"),
+			expectedRoutes: []PodRoute{
+				{Dest: ovntest.MustParseIPNet("10.128.0.0/14"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("172.16.1.0/24"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("100.64.0.0/16"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+			},
+		},
+		{
+			desc:    "default network with DNS servers and routes through it",
+			netInfo: &DefaultNetInfo{},
+			podIP:   "10.244.0.5/24",
+			pod: newPod(`{"defaultRoute": "ns1/blue", "dns": "default", "routes": {"default": ["10.0.0.0/8"]}}`,
+				"10.0.0.10"),
+			expectedRoutes: []PodRoute{
+				{Dest: ovntest.MustParseIPNet("10.128.0.0/14"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("172.16.1.0/24"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("100.64.0.0/16"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("10.0.0.10/32"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+				{Dest: ovntest.MustParseIPNet("10.0.0.0/8"), NextHop: ovntest.MustParseIP("10.244.0.1")},
+			},
+		},
+		{
+			desc:    "layer3 network with the default route, DNS servers and routes through it",
+			netInfo: layer3NetInfo,
+			podIP:   "10.128.2.5/23",
+			pod: newPod(`{"defaultRoute": "ns1/blue", "dns": "ns1/blue", "routes": {"ns1/blue": ["172.16.0.0/12"]}}`,
+				"172.16.0.10"),
+			expectedGateways: []net.IP{ovntest.MustParseIP("10.128.2.1")},
+			expectedRoutes: []PodRoute{
+				{Dest: ovntest.MustParseIPNet("10.128.0.0/14"), NextHop: ovntest.MustParseIP("10.128.2.1")},
+				{Dest: ovntest.MustParseIPNet("172.16.0.10/32"), NextHop: ovntest.MustParseIP("10.128.2.1")},
+				{Dest: ovntest.MustParseIPNet("172.16.0.0/12"), NextHop: ovntest.MustParseIP("10.128.2.1")},
+			},
+		},
+		{
+			desc:             "layer2 network with routes through the requested gateway",
+			netInfo:          layer2NetInfo,
+			podIP:            "192.168.0.5/16",
+			pod:              newPod(`{"routes": {"ns1/blue": ["172.16.0.0/12"]}}`),
+			gatewayRequest:   []net.IP{ovntest.MustParseIP("192.168.0.254")},
+			expectedGateways: []net.IP{ovntest.MustParseIP("192.168.0.254")},
+			expectedRoutes: []PodRoute{
+				{Dest: ovntest.MustParseIPNet("172.16.0.0/12"), NextHop: ovntest.MustParseIP("192.168.0.254")},
+			},
+		},
+		{
+			desc:        "layer2 network with the default route and no gateway",
+			netInfo:     layer2NetInfo,
+			podIP:       "192.168.0.5/16",
+			pod:         newPod(`{"defaultRoute": "ns1/blue"}`),
+			expectError: true,
+		},
+		{
+			desc:           "layer2 network with a gateway request and the default route through another network",
+			netInfo:        layer2NetInfo,
+			podIP:          "192.168.0.5/16",
+			pod:            newPod(`{"defaultRoute": "default"}`),
+			gatewayRequest: []net.IP{ovntest.MustParseIP("192.168.0.254")},
+			expectError:    true,
+		},
+		{
+			desc:        "invalid route destination",
+			netInfo:     layer2NetInfo,
+			podIP:       "192.168.0.5/16",
+			pod:         newPod(`{"routes": {"ns1/blue": ["172.16.0.0"]}}`),
+			expectError: true,
+		},
+		{
+			desc:        "invalid network",
+			netInfo:     &DefaultNetInfo{},
+			podIP:       "10.244.0.5/24",
+			pod:         newPod(`{"defaultRoute": "blue"}`),
+			expectError: true,
+		},
+		{
+			desc:        "default network with the default route through a network the pod is not attached to",
+			netInfo:     &DefaultNetInfo{},
+			podIP:       "10.244.0.5/24",
+			pod:         newPod(`{"defaultRoute": "ns1/red"}`),
+			expectError: true,
+		},
+		{
+			desc:        "layer3 network with DNS servers through a network the pod is not attached to",
+			netInfo:     layer3NetInfo,
+			podIP:       "10.128.2.5/23",
+			pod:         newPod(`{"dns": "ns2/blue"}`, "172.16.0.10"),
+			expectError: true,
+		},
+		{
+			desc:           "layer2 network with routes through a network the pod is not attached to",
+			netInfo:        layer2NetInfo,
+			podIP:          "192.168.0.5/16",
+			pod:            newPod(`{"routes": {"ns1/red": ["172.16.0.0/12"]}}`),
+			gatewayRequest: []net.IP{ovntest.MustParseIP("192.168.0.254")},
+			expectError:    true,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			assert.NoError(t, config.PrepareTestConfig())
+			podAnnotation := &PodAnnotation{IPs: []*net.IPNet{ovntest.MustParseIPNet(tc.podIP)}}
+			network := &nadapi.NetworkSelectionElement{Name: "blue", Namespace: "ns1", GatewayRequest: tc.gatewayRequest}
+			assert.Equal(t, nadName, GetNADName(network.Namespace, network.Name))
+			err := AddRoutesGatewayIP(tc.netInfo, tc.pod, podAnnotation, network)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprint(tc.expectedGateways), fmt.Sprint(podAnnotation.Gateways))
+			assert.Equal(t, fmt.Sprint(tc.expectedRoutes), fmt.Sprint(podAnnotation.Routes))
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	kapi "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	utilnet "k8s.io/utils/net"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
@@ -32,10 +33,36 @@ import (
 //       {
 //         "default": ["10.130.0.0/23", "fd01:0:0:2::/64"]
 //       }
+//
+// A node whose host subnets ran out of pod IPs can be given additional host
+// subnets, listed after the first host subnet of each IP family, which is the
+// primary one holding the gateway and management port addresses:
+//
+//   annotations:
+//     k8s.ovn.org/node-subnets: |
+//       {
+//         "default": ["10.130.0.0/23", "fd01:0:0:2::/64", "10.130.8.0/23"]
+//       }
+//
+// ovnkube-controller asks for an additional host subnet by listing the
+// exhausted host subnets of the node in the "k8s.ovn.org/node-exhausted-subnets"
+// annotation, in the same format.
 
 const (
 	// ovnNodeSubnets is the constant string representing the node subnets annotation key
 	ovnNodeSubnets = "k8s.ovn.org/node-subnets"
+
+	// OvnNodeSubnetsAnnotation is the exported name of the node subnets
+	// annotation, for the tools that rewrite it as a whole
+	OvnNodeSubnetsAnnotation = ovnNodeSubnets
+
+	// ovnNodeExhaustedSubnets is the constant string representing the node
+	// exhausted subnets annotation key
+	ovnNodeExhaustedSubnets = "k8s.ovn.org/node-exhausted-subnets"
+
+	// OvnNodeExhaustedSubnetsAnnotation is the exported name of the node
+	// exhausted subnets annotation, for its writers
+	OvnNodeExhaustedSubnetsAnnotation = ovnNodeExhaustedSubnets
 )
 
 // updateSubnetAnnotation add the hostSubnets of the given network to the input node annotations;
@@ -195,3 +222,137 @@ func GetNodeSubnetAnnotationNetworkNames(node *kapi.Node) ([]string, error) {
 
 	return nodeNetworks, nil
 }
+
+// NodeHostSubnetAnnotationFormat is the format of the values of the
+// "k8s.ovn.org/node-subnets" annotation
+type NodeHostSubnetAnnotationFormat string
+
+const (
+	// NodeHostSubnetAnnotationFormatLegacy is the single-stack format where each
+	// network maps to a single host subnet, e.g. {"default":"10.130.0.0/23"}.
+	// It is only written by old releases.
+	NodeHostSubnetAnnotationFormatLegacy NodeHostSubnetAnnotationFormat = "legacy"
+	// NodeHostSubnetAnnotationFormatPerNetwork is the current format where each
+	// network maps to a list of host subnets, e.g. {"default":["10.130.0.0/23"]}
+	NodeHostSubnetAnnotationFormatPerNetwork NodeHostSubnetAnnotationFormat = "per-network"
+)
+
+// GetNodeHostSubnetAnnotationFormat returns the format of the
+// "k8s.ovn.org/node-subnets" annotation of a node
+func GetNodeHostSubnetAnnotationFormat(node *kapi.Node) (NodeHostSubnetAnnotationFormat, error) {
+	annotation, ok := node.Annotations[ovnNodeSubnets]
+	if !ok {
+		return "", newAnnotationNotSetError("node %q has no %q annotation", node.Name, ovnNodeSubnets)
+	}
+	if err := json.Unmarshal([]byte(annotation), &map[string][]string{}); err == nil {
+		return NodeHostSubnetAnnotationFormatPerNetwork, nil
+	}
+	if err := json.Unmarshal([]byte(annotation), &map[string]string{}); err == nil {
+		return NodeHostSubnetAnnotationFormatLegacy, nil
+	}
+	return "", fmt.Errorf("could not parse node %q annotation %q as either format", node.Name, annotation)
+}
+
+// ConvertNodeHostSubnetAnnotation returns the "k8s.ovn.org/node-subnets"
+// annotation of a node rewritten in the given format. Only the nodes with a
+// single host subnet per network can be converted to the legacy format.
+func ConvertNodeHostSubnetAnnotation(node *kapi.Node, format NodeHostSubnetAnnotationFormat) (string, error) {
+	subnetsMap, err := parseSubnetAnnotation(node.Annotations, ovnNodeSubnets)
+	if err != nil {
+		return "", err
+	}
+
+	var subnetsAny interface{}
+	switch format {
+	case NodeHostSubnetAnnotationFormatPerNetwork:
+		subnetsStrMap := make(map[string][]string, len(subnetsMap))
+		for netName, subnets := range subnetsMap {
+			subnetsStrMap[netName] = StringSlice(subnets)
+		}
+		subnetsAny = subnetsStrMap
+	case NodeHostSubnetAnnotationFormatLegacy:
+		subnetsStrMap := make(map[string]string, len(subnetsMap))
+		for netName, subnets := range subnetsMap {
+			if len(subnets) != 1 {
+				return "", fmt.Errorf("node %q has %d host subnets for network %s, the legacy format only supports one",
+					node.Name, len(subnets), netName)
+			}
+			subnetsStrMap[netName] = subnets[0].String()
+		}
+		subnetsAny = subnetsStrMap
+	default:
+		return "", fmt.Errorf("unknown node host subnet annotation format %q", format)
+	}
+
+	bytes, err := json.Marshal(subnetsAny)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// ParseNodeHostSubnetAnnotationAllNetworks parses the "k8s.ovn.org/node-subnets"
+// annotation on a node and returns the host subnets of all the networks
+func ParseNodeHostSubnetAnnotationAllNetworks(node *kapi.Node) (map[string][]*net.IPNet, error) {
+	return parseSubnetAnnotation(node.Annotations, ovnNodeSubnets)
+}
+
+// UpdateNodeExhaustedSubnetAnnotation updates the
+// "k8s.ovn.org/node-exhausted-subnets" annotation for network "netName" with
+// the host subnets of the node without free pod IPs, suitable for passing to
+// kube.SetAnnotationsOnNode. If exhaustedSubnets is empty, it deletes the
+// annotation for network "netName".
+func UpdateNodeExhaustedSubnetAnnotation(annotations map[string]string, exhaustedSubnets []*net.IPNet, netName string) (map[string]string, error) {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	err := updateSubnetAnnotation(annotations, ovnNodeExhaustedSubnets, netName, exhaustedSubnets)
+	if err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// ParseNodeExhaustedSubnetAnnotation parses the
+// "k8s.ovn.org/node-exhausted-subnets" annotation on a node and returns the
+// exhausted host subnets of the given network.
+func ParseNodeExhaustedSubnetAnnotation(node *kapi.Node, netName string) ([]*net.IPNet, error) {
+	subnetsMap, err := parseSubnetAnnotation(node.Annotations, ovnNodeExhaustedSubnets)
+	if err != nil {
+		return nil, err
+	}
+	subnets, ok := subnetsMap[netName]
+	if !ok {
+		return nil, newAnnotationNotSetError("node %q has no %q annotation for network %s", node.Name, ovnNodeExhaustedSubnets, netName)
+	}
+	return subnets, nil
+}
+
+// NodeExhaustedSubnetAnnotationChanged returns whether the
+// "k8s.ovn.org/node-exhausted-subnets" annotation changed
+func NodeExhaustedSubnetAnnotationChanged(oldNode, newNode *v1.Node) bool {
+	return oldNode.Annotations[ovnNodeExhaustedSubnets] != newNode.Annotations[ovnNodeExhaustedSubnets]
+}
+
+// PrimaryHostSubnets returns the first host subnet of each IP family, the one
+// holding the gateway and management port addresses of the node, without the
+// additional host subnets only holding pod IPs
+func PrimaryHostSubnets(hostSubnets []*net.IPNet) []*net.IPNet {
+	var primary []*net.IPNet
+	foundIPv4, foundIPv6 := false, false
+	for _, hostSubnet := range hostSubnets {
+		if utilnet.IsIPv6CIDR(hostSubnet) {
+			if foundIPv6 {
+				continue
+			}
+			foundIPv6 = true
+		} else {
+			if foundIPv4 {
+				continue
+			}
+			foundIPv4 = true
+		}
+		primary = append(primary, hostSubnet)
+	}
+	return primary
+}
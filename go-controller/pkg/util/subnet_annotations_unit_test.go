@@ -359,3 +359,127 @@ func TestParseNodeHostSubnetAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertNodeHostSubnetAnnotation(t *testing.T) {
+	tests := []struct {
+		desc       string
+		annotation string
+		format     NodeHostSubnetAnnotationFormat
+		expFormat  NodeHostSubnetAnnotationFormat
+		expResult  string
+		errExp     bool
+	}{
+		{
+			desc:       "legacy to per-network",
+			annotation: "{\"default\":\"10.244.0.0/24\",\"blue\":\"192.168.1.0/24\"}",
+			format:     NodeHostSubnetAnnotationFormatPerNetwork,
+			expFormat:  NodeHostSubnetAnnotationFormatLegacy,
+			expResult:  "{\"blue\":[\"192.168.1.0/24\"],\"default\":[\"10.244.0.0/24\"]}",
+		},
+		{
+			desc:       "per-network to legacy",
+			annotation: "{\"default\":[\"10.244.0.0/24\"]}",
+			format:     NodeHostSubnetAnnotationFormatLegacy,
+			expFormat:  NodeHostSubnetAnnotationFormatPerNetwork,
+			expResult:  "{\"default\":\"10.244.0.0/24\"}",
+		},
+		{
+			desc:       "per-network to per-network",
+			annotation: "{\"default\":[\"10.244.0.0/24\",\"fd02:0:0:2::/64\"]}",
+			format:     NodeHostSubnetAnnotationFormatPerNetwork,
+			expFormat:  NodeHostSubnetAnnotationFormatPerNetwork,
+			expResult:  "{\"default\":[\"10.244.0.0/24\",\"fd02:0:0:2::/64\"]}",
+		},
+		{
+			desc:       "dual-stack to legacy",
+			annotation: "{\"default\":[\"10.244.0.0/24\",\"fd02:0:0:2::/64\"]}",
+			format:     NodeHostSubnetAnnotationFormatLegacy,
+			expFormat:  NodeHostSubnetAnnotationFormatPerNetwork,
+			errExp:     true,
+		},
+		{
+			desc:       "unknown format",
+			annotation: "{\"default\":[\"10.244.0.0/24\"]}",
+			format:     "foo",
+			expFormat:  NodeHostSubnetAnnotationFormatPerNetwork,
+			errExp:     true,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			node := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "testNode",
+					Annotations: map[string]string{"k8s.ovn.org/node-subnets": tc.annotation},
+				},
+			}
+			format, err := GetNodeHostSubnetAnnotationFormat(node)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expFormat, format)
+
+			res, err := ConvertNodeHostSubnetAnnotation(node, tc.format)
+			if tc.errExp {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expResult, res)
+
+			// the converted annotation parses to the same host subnets
+			converted := &v1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"k8s.ovn.org/node-subnets": res}}}
+			expSubnets, err := ParseNodeHostSubnetAnnotationAllNetworks(node)
+			assert.NoError(t, err)
+			subnets, err := ParseNodeHostSubnetAnnotationAllNetworks(converted)
+			assert.NoError(t, err)
+			assert.Equal(t, expSubnets, subnets)
+		})
+	}
+}
+
+func TestPrimaryHostSubnets(t *testing.T) {
+	tests := []struct {
+		desc        string
+		hostSubnets []string
+		expResult   []string
+	}{
+		{
+			desc:        "single host subnet",
+			hostSubnets: []string{"10.244.0.0/24"},
+			expResult:   []string{"10.244.0.0/24"},
+		},
+		{
+			desc:        "dual-stack host subnets",
+			hostSubnets: []string{"10.244.0.0/24", "fd02:0:0:2::/64"},
+			expResult:   []string{"10.244.0.0/24", "fd02:0:0:2::/64"},
+		},
+		{
+			desc:        "additional host subnets",
+			hostSubnets: []string{"10.244.0.0/24", "fd02:0:0:2::/64", "10.244.5.0/24", "fd02:0:0:7::/64", "10.244.9.0/24"},
+			expResult:   []string{"10.244.0.0/24", "fd02:0:0:2::/64"},
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			hostSubnets, err := ParseIPNets(tc.hostSubnets)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expResult, StringSlice(PrimaryHostSubnets(hostSubnets)))
+		})
+	}
+}
+
+func TestNodeExhaustedSubnetAnnotation(t *testing.T) {
+	exhausted, err := ParseIPNets([]string{"10.244.0.0/24"})
+	assert.NoError(t, err)
+	annotations, err := UpdateNodeExhaustedSubnetAnnotation(nil, exhausted, types.DefaultNetworkName)
+	assert.NoError(t, err)
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNode", Annotations: annotations}}
+	subnets, err := ParseNodeExhaustedSubnetAnnotation(node, types.DefaultNetworkName)
+	assert.NoError(t, err)
+	assert.Equal(t, StringSlice(exhausted), StringSlice(subnets))
+	_, err = ParseNodeExhaustedSubnetAnnotation(node, "other")
+	assert.True(t, IsAnnotationNotSetError(err))
+
+	annotations, err = UpdateNodeExhaustedSubnetAnnotation(annotations, nil, types.DefaultNetworkName)
+	assert.NoError(t, err)
+	assert.Empty(t, annotations)
+}
@@ -316,6 +316,12 @@ func GetSecondaryNetworkLogicalPortName(podNamespace, podName, nadName string) s
 	return GetSecondaryNetworkPrefix(nadName) + composePortName(podNamespace, podName)
 }
 
+// GetSecondaryNetworkHostLogicalPortName returns the name of the logical port,
+// and OVS iface-id, of a standalone host attached to a secondary network
+func GetSecondaryNetworkHostLogicalPortName(hostName, netName string) string {
+	return GetSecondaryNetworkPrefix(netName) + "host_" + hostName
+}
+
 func GetLogicalPortName(podNamespace, podName string) string {
 	return composePortName(podNamespace, podName)
 }